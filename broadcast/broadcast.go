@@ -40,6 +40,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/textileio/go-threads/util/clock"
 )
 
 // ErrClosedChannel means the caller attempted to send to one or more closed broadcast channels.
@@ -56,6 +57,7 @@ type Broadcaster struct {
 	nextID    uint
 	capacity  int
 	closed    bool
+	clock     clock.Clock // lazy init
 }
 
 // NewBroadcaster returns a new Broadcaster with the given capacity (0 means un-buffered).
@@ -63,6 +65,12 @@ func NewBroadcaster(n int) *Broadcaster {
 	return &Broadcaster{capacity: n}
 }
 
+// NewBroadcasterWithClock returns a new Broadcaster like NewBroadcaster, which measures
+// send timeouts with the given clock.
+func NewBroadcasterWithClock(n int, clk clock.Clock) *Broadcaster {
+	return &Broadcaster{capacity: n, clock: clk}
+}
+
 // SendWithTimeout broadcasts a message to each listener's channel.
 // Sending on a closed channel causes a runtime panic.
 // This method blocks for a duration of up to `timeout` on each channel.
@@ -73,15 +81,26 @@ func (b *Broadcaster) SendWithTimeout(v interface{}, timeout time.Duration) erro
 	if b.closed {
 		return ErrClosedChannel
 	}
+	if b.clock == nil {
+		b.clock = clock.New()
+	}
 	var result *multierror.Error
 	for id, l := range b.listeners {
+		// a ready listener must not lose the race against an expired timeout
+		select {
+		case l <- v:
+			continue
+		default:
+		}
+		timer := b.clock.NewTimer(timeout)
 		select {
 		case l <- v:
 			// Success!
-		case <-time.After(timeout):
+		case <-timer.Chan():
 			err := fmt.Sprintf("unable to send to listener '%d'", id)
 			result = multierror.Append(result, errors.New(err))
 		}
+		timer.Stop()
 	}
 	if result != nil {
 		return result.ErrorOrNil()
@@ -110,6 +129,13 @@ func (b *Broadcaster) Discard() {
 	}
 }
 
+// SetCapacity changes the buffer size of listeners. Existing listeners keep their buffers.
+func (b *Broadcaster) SetCapacity(n int) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.capacity = n
+}
+
 // Listen returns a Listener for the broadcast channel.
 func (b *Broadcaster) Listen() *Listener {
 	b.m.Lock()
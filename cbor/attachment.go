@@ -0,0 +1,180 @@
+package cbor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipfs/go-ipld-format"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/crypto"
+)
+
+// AttachmentChunkSize is the max size of a single attachment chunk.
+var AttachmentChunkSize = 256 << 10
+
+const attachmentType = "threads/attachment"
+
+func init() {
+	cbornode.RegisterCborType(attachment{})
+}
+
+// attachment defines the root node structure of an attachment.
+// The root is never encrypted, so the DAG can be replicated by hosts without
+// the read key. Chunks hold the (optionally encrypted) binary data.
+type attachment struct {
+	Type      string
+	Size      int64
+	Encrypted bool
+	Chunks    []cid.Cid
+}
+
+// CreateAttachment splits data into chunks, optionally encrypting each one with key,
+// and adds the resulting DAG to the dag service. The returned root node may be linked
+// from a record body.
+func CreateAttachment(ctx context.Context, dag format.DAGService, r io.Reader, key crypto.EncryptionKey) (format.Node, error) {
+	var (
+		obj    = &attachment{Type: attachmentType, Encrypted: key != nil}
+		buf    = make([]byte, AttachmentChunkSize)
+		readEr error
+	)
+	for readEr == nil {
+		var n int
+		n, readEr = io.ReadFull(r, buf)
+		if readEr != nil && readEr != io.EOF && readEr != io.ErrUnexpectedEOF {
+			return nil, readEr
+		}
+		if n == 0 {
+			break
+		}
+		data := buf[:n]
+		if key != nil {
+			var err error
+			if data, err = key.Encrypt(data); err != nil {
+				return nil, err
+			}
+		}
+		chunk, err := cbornode.WrapObject(data, mh.SHA2_256, -1)
+		if err != nil {
+			return nil, err
+		}
+		// chunks are added one by one, so the data is never fully held in memory
+		if err = dag.Add(ctx, chunk); err != nil {
+			return nil, err
+		}
+		obj.Chunks = append(obj.Chunks, chunk.Cid())
+		obj.Size += int64(n)
+	}
+
+	root, err := cbornode.WrapObject(obj, mh.SHA2_256, -1)
+	if err != nil {
+		return nil, err
+	}
+	if err = dag.Add(ctx, root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// IsAttachment returns whether or not the node is an attachment root.
+func IsAttachment(node format.Node) bool {
+	_, err := attachmentFromNode(node)
+	return err == nil
+}
+
+// GetAttachment returns a reader of the attachment data. Chunks are fetched
+// from the dag service lazily while reading. The key is only required for
+// encrypted attachments.
+func GetAttachment(ctx context.Context, dag format.NodeGetter, id cid.Cid, key crypto.DecryptionKey) (*Attachment, error) {
+	node, err := dag.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := attachmentFromNode(node)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Encrypted && key == nil {
+		return nil, fmt.Errorf("decryption key is required")
+	}
+	return &Attachment{
+		ctx: ctx,
+		dag: dag,
+		key: key,
+		obj: obj,
+	}, nil
+}
+
+// AttachmentChunks returns the IDs of attachment chunks.
+func AttachmentChunks(node format.Node) ([]cid.Cid, error) {
+	obj, err := attachmentFromNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Chunks, nil
+}
+
+func attachmentFromNode(node format.Node) (*attachment, error) {
+	obj := new(attachment)
+	if err := cbornode.DecodeInto(node.RawData(), obj); err != nil {
+		return nil, err
+	}
+	if obj.Type != attachmentType {
+		return nil, fmt.Errorf("node %s is not an attachment", node.Cid())
+	}
+	return obj, nil
+}
+
+// Attachment reads binary data of an attachment.
+type Attachment struct {
+	ctx context.Context
+	dag format.NodeGetter
+	key crypto.DecryptionKey
+	obj *attachment
+
+	next int
+	buf  []byte
+}
+
+var _ io.Reader = (*Attachment)(nil)
+
+// Size returns the size of the plain attachment data.
+func (a *Attachment) Size() int64 {
+	return a.obj.Size
+}
+
+// Read implements io.Reader.
+func (a *Attachment) Read(p []byte) (int, error) {
+	for len(a.buf) == 0 {
+		if a.next >= len(a.obj.Chunks) {
+			return 0, io.EOF
+		}
+		if err := a.loadChunk(a.obj.Chunks[a.next]); err != nil {
+			return 0, err
+		}
+		a.next++
+	}
+	n := copy(p, a.buf)
+	a.buf = a.buf[n:]
+	return n, nil
+}
+
+func (a *Attachment) loadChunk(id cid.Cid) error {
+	node, err := a.dag.Get(a.ctx, id)
+	if err != nil {
+		return fmt.Errorf("getting chunk %s: %w", id, err)
+	}
+	var data []byte
+	if err = cbornode.DecodeInto(node.RawData(), &data); err != nil {
+		return err
+	}
+	if a.obj.Encrypted {
+		if data, err = a.key.Decrypt(data); err != nil {
+			return fmt.Errorf("decrypting chunk %s: %w", id, err)
+		}
+	}
+	a.buf = data
+	return nil
+}
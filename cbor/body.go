@@ -0,0 +1,132 @@
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipfs/go-ipld-format"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// BodyChunkSize is the max size of a single node of a coded event body.
+// Larger bodies are split into a DAG, so records don't hit libp2p message limits.
+var BodyChunkSize = 256 << 10
+
+const chunkedBodyType = "threads/body"
+
+func init() {
+	cbornode.RegisterCborType(chunkedBody{})
+}
+
+// chunkedBody defines the root node structure of a body split into chunks.
+// Chunks hold consecutive parts of the raw data of the coded body node, which
+// is already encrypted, so the root is never encrypted.
+type chunkedBody struct {
+	Type   string
+	Size   int64
+	Chunks []cid.Cid
+}
+
+// splitBody splits a coded body larger than BodyChunkSize into chunks.
+// The returned root is nil if the body fits into a single node.
+func splitBody(coded format.Node) (format.Node, []format.Node, error) {
+	raw := coded.RawData()
+	if BodyChunkSize <= 0 || len(raw) <= BodyChunkSize {
+		return nil, nil, nil
+	}
+	obj := &chunkedBody{Type: chunkedBodyType, Size: int64(len(raw))}
+	var chunks []format.Node
+	for i := 0; i < len(raw); i += BodyChunkSize {
+		end := i + BodyChunkSize
+		if end > len(raw) {
+			end = len(raw)
+		}
+		chunk, err := cbornode.WrapObject(raw[i:end], mh.SHA2_256, -1)
+		if err != nil {
+			return nil, nil, err
+		}
+		chunks = append(chunks, chunk)
+		obj.Chunks = append(obj.Chunks, chunk.Cid())
+	}
+	root, err := cbornode.WrapObject(obj, mh.SHA2_256, -1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, chunks, nil
+}
+
+// joinBody reassembles a coded body from its chunks, fetching them from the dag service.
+func joinBody(ctx context.Context, dag format.NodeGetter, obj *chunkedBody) (format.Node, error) {
+	if err := obj.validate(); err != nil {
+		return nil, err
+	}
+	// chunks are fetched concurrently, but GetMany doesn't preserve the order
+	nodes := make(map[cid.Cid]format.Node, len(obj.Chunks))
+	for opt := range dag.GetMany(ctx, obj.Chunks) {
+		if opt.Err != nil {
+			return nil, fmt.Errorf("getting body chunk: %w", opt.Err)
+		}
+		nodes[opt.Node.Cid()] = opt.Node
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, obj.Size))
+	for _, id := range obj.Chunks {
+		node, ok := nodes[id]
+		if !ok {
+			return nil, fmt.Errorf("missing body chunk %s", id)
+		}
+		var data []byte
+		if err := cbornode.DecodeInto(node.RawData(), &data); err != nil {
+			return nil, err
+		}
+		if int64(buf.Len()+len(data)) > obj.Size {
+			return nil, fmt.Errorf("body size mismatch: expected %d bytes, got more", obj.Size)
+		}
+		buf.Write(data)
+	}
+	if int64(buf.Len()) != obj.Size {
+		return nil, fmt.Errorf("body size mismatch: expected %d bytes, got %d", obj.Size, buf.Len())
+	}
+	return cbornode.Decode(buf.Bytes(), mh.SHA2_256, -1)
+}
+
+// BodyChunks returns the IDs of the chunks and the total size of a chunked body.
+// It fails if the node is not the root of a chunked body.
+func BodyChunks(node format.Node) ([]cid.Cid, int64, error) {
+	obj, err := chunkedBodyFromNode(node)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = obj.validate(); err != nil {
+		return nil, 0, err
+	}
+	return obj.Chunks, obj.Size, nil
+}
+
+// IsChunkedBody returns whether or not the node is the root of a chunked body.
+func IsChunkedBody(node format.Node) bool {
+	_, err := chunkedBodyFromNode(node)
+	return err == nil
+}
+
+func chunkedBodyFromNode(node format.Node) (*chunkedBody, error) {
+	obj := new(chunkedBody)
+	if err := cbornode.DecodeInto(node.RawData(), obj); err != nil {
+		return nil, err
+	}
+	if obj.Type != chunkedBodyType {
+		return nil, fmt.Errorf("node %s is not a chunked body", node.Cid())
+	}
+	return obj, nil
+}
+
+// validate checks the declared size against the number of chunks, so it can be trusted
+// before anything is allocated for the body.
+func (b *chunkedBody) validate() error {
+	if b.Size <= 0 || len(b.Chunks) == 0 || b.Size > int64(len(b.Chunks))*int64(BodyChunkSize) {
+		return fmt.Errorf("bad chunked body size %d for %d chunks", b.Size, len(b.Chunks))
+	}
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 )
 
 // EncodeBlock returns a node by encrypting the block's raw bytes with key.
-func EncodeBlock(block blocks.Block, key crypto.EncryptionKey) (format.Node, error) {
+func EncodeBlock(block blocks.Block, key crypto.Encrypter) (format.Node, error) {
 	coded, err := key.Encrypt(block.RawData())
 	if err != nil {
 		return nil, err
@@ -18,7 +18,7 @@ func EncodeBlock(block blocks.Block, key crypto.EncryptionKey) (format.Node, err
 }
 
 // DecodeBlock returns a node by decrypting the block's raw bytes with key.
-func DecodeBlock(block blocks.Block, key crypto.DecryptionKey) (format.Node, error) {
+func DecodeBlock(block blocks.Block, key crypto.Decrypter) (format.Node, error) {
 	var raw []byte
 	err := cbornode.DecodeInto(block.RawData(), &raw)
 	if err != nil {
@@ -0,0 +1,108 @@
+package cbor
+
+import (
+	"fmt"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipfs/go-ipld-format"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/crypto"
+)
+
+const (
+	// CodecZstd compresses event bodies with zstd.
+	CodecZstd = "zstd"
+	// CodecSnappy compresses event bodies with snappy, which is faster but compresses less.
+	CodecSnappy = "snappy"
+
+	// maxDecompressedBodySize bounds the decompressed size of a single body.
+	maxDecompressedBodySize = 64 << 20
+)
+
+// BodyCodecs are the codecs event bodies may be compressed with.
+var BodyCodecs = []string{CodecZstd, CodecSnappy}
+
+var (
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func init() {
+	// options are valid, so errors are not expected
+	var err error
+	if zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)); err != nil {
+		panic(err)
+	}
+	if zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedBodySize)); err != nil {
+		panic(err)
+	}
+}
+
+// IsBodyCodec returns whether event bodies can be compressed with the codec.
+func IsBodyCodec(codec string) bool {
+	for _, c := range BodyCodecs {
+		if c == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBody returns a node by compressing the body's raw bytes with codec,
+// if set, and encrypting them with key.
+func encodeBody(body format.Node, key crypto.Encrypter, codec string) (format.Node, error) {
+	if codec == "" {
+		return EncodeBlock(body, key)
+	}
+	var raw []byte
+	switch codec {
+	case CodecZstd:
+		raw = zstdEncoder.EncodeAll(body.RawData(), nil)
+	case CodecSnappy:
+		raw = snappy.Encode(nil, body.RawData())
+	default:
+		return nil, fmt.Errorf("unknown body codec %s", codec)
+	}
+	coded, err := key.Encrypt(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cbornode.WrapObject(coded, mh.SHA2_256, -1)
+}
+
+// decodeBody returns a node by decrypting the coded body with key, and
+// decompressing it with codec, if set.
+func decodeBody(coded format.Node, key crypto.Decrypter, codec string) (format.Node, error) {
+	if codec == "" {
+		return DecodeBlock(coded, key)
+	}
+	var raw []byte
+	if err := cbornode.DecodeInto(coded.RawData(), &raw); err != nil {
+		return nil, err
+	}
+	compressed, err := key.Decrypt(raw)
+	if err != nil {
+		return nil, err
+	}
+	var decoded []byte
+	switch codec {
+	case CodecZstd:
+		if decoded, err = zstdDecoder.DecodeAll(compressed, nil); err != nil {
+			return nil, err
+		}
+	case CodecSnappy:
+		if n, err := snappy.DecodedLen(compressed); err != nil {
+			return nil, err
+		} else if n > maxDecompressedBodySize {
+			return nil, fmt.Errorf("decompressed body size %d exceeds the limit", n)
+		}
+		if decoded, err = snappy.Decode(nil, compressed); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown body codec %s", codec)
+	}
+	return cbornode.Decode(decoded, mh.SHA2_256, -1)
+}
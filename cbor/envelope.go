@@ -0,0 +1,151 @@
+package cbor
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/textileio/go-threads/core/net"
+)
+
+// Record envelope versions. Record nodes are never changed between versions,
+// so record IDs and log chains stay the same for every peer. Newer versions
+// only add fields carried next to the record nodes.
+const (
+	// EnvelopeV1 carries record, event, header and body nodes.
+	EnvelopeV1 = 1
+	// EnvelopeV2 additionally carries signed record extensions.
+	EnvelopeV2 = 2
+
+	// EnvelopeVersion is the latest supported envelope version.
+	EnvelopeVersion = EnvelopeV2
+)
+
+func init() {
+	cbornode.RegisterCborType(extensions{})
+}
+
+// extensions defines the node structure of record extensions. Fields are kept
+// encoded, so the signature is checked against the exact bytes produced by the author.
+type extensions struct {
+	Fields []byte
+	Sig    []byte
+}
+
+// EncodeExtensions returns signed record extensions. Extensions are bound to
+// the record with id and signed by the log key.
+func EncodeExtensions(id cid.Cid, fields map[string][]byte, key ic.PrivKey) ([]byte, error) {
+	return EncodeSignedExtensions(context.Background(), id, fields, net.NewKeySigner(key))
+}
+
+// EncodeSignedExtensions returns record extensions like EncodeExtensions, signed by the log signer.
+func EncodeSignedExtensions(ctx context.Context, id cid.Cid, fields map[string][]byte, signer net.Signer) ([]byte, error) {
+	fb, err := cbornode.DumpObject(fields)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(ctx, extensionsPayload(id, fb))
+	if err != nil {
+		return nil, fmt.Errorf("signing record extensions: %w", err)
+	}
+	return cbornode.DumpObject(&extensions{Fields: fb, Sig: sig})
+}
+
+// DecodeExtensions returns extension fields from the encoded extensions.
+// The signature is not checked, use VerifyExtensions for that.
+func DecodeExtensions(raw []byte) (map[string][]byte, error) {
+	obj := new(extensions)
+	if err := cbornode.DecodeInto(raw, obj); err != nil {
+		return nil, err
+	}
+	fields := make(map[string][]byte)
+	if err := cbornode.DecodeInto(obj.Fields, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// VerifyExtensions returns a nil error if the extensions of the record with id are signed by key.
+func VerifyExtensions(id cid.Cid, raw []byte, key ic.PubKey) error {
+	obj := new(extensions)
+	if err := cbornode.DecodeInto(raw, obj); err != nil {
+		return err
+	}
+	ok, err := key.Verify(extensionsPayload(id, obj.Fields), obj.Sig)
+	if !ok || err != nil {
+		return fmt.Errorf("bad extensions signature")
+	}
+	return nil
+}
+
+// Annotations returns the annotations carried by the extension fields.
+func Annotations(fields map[string][]byte) map[string]string {
+	var annotations map[string]string
+	for k, v := range fields {
+		if strings.HasPrefix(k, net.AnnotationPrefix) {
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[strings.TrimPrefix(k, net.AnnotationPrefix)] = string(v)
+		}
+	}
+	return annotations
+}
+
+// EncodeClock returns the extension field value carrying a record clock.
+func EncodeClock(clock uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, clock)]
+}
+
+// Clock returns the record clock carried by the extension fields, or zero if it's missing.
+func Clock(fields map[string][]byte) uint64 {
+	v, ok := fields[net.ClockExtension]
+	if !ok {
+		return 0
+	}
+	clock, n := binary.Uvarint(v)
+	if n <= 0 {
+		return 0
+	}
+	return clock
+}
+
+// EncodeSeq returns the extension field value carrying a record sequence number.
+func EncodeSeq(seq uint64) []byte {
+	return EncodeClock(seq)
+}
+
+// Seq returns the record sequence number carried by the extension fields, or zero if it's missing.
+func Seq(fields map[string][]byte) uint64 {
+	v, ok := fields[net.SeqExtension]
+	if !ok {
+		return 0
+	}
+	seq, n := binary.Uvarint(v)
+	if n <= 0 {
+		return 0
+	}
+	return seq
+}
+
+// checkAnnotations returns an error if the annotations carried by the extension
+// fields exceed net.MaxAnnotationsSize.
+func checkAnnotations(fields map[string][]byte) error {
+	var size int
+	for k, v := range Annotations(fields) {
+		size += len(k) + len(v)
+	}
+	if size > net.MaxAnnotationsSize {
+		return fmt.Errorf("record annotations size %d exceeds the limit of %d bytes", size, net.MaxAnnotationsSize)
+	}
+	return nil
+}
+
+func extensionsPayload(id cid.Cid, fields []byte) []byte {
+	return append(id.Bytes(), fields...)
+}
@@ -27,24 +27,50 @@ type event struct {
 // eventHeader defines the node structure of an event header.
 type eventHeader struct {
 	Key []byte `refmt:",omitempty"`
+	// Codec the body is compressed with before encryption, if any.
+	Codec string `refmt:",omitempty"`
 }
 
-// CreateEvent create a new event by wrapping the body node.
-func CreateEvent(ctx context.Context, dag format.DAGService, body format.Node, rkey crypto.EncryptionKey) (net.Event, error) {
+// CreateEvent create a new event by wrapping the body node. The body is encrypted with
+// a single-use key carried by the header, which is encrypted with rkey, usually the
+// thread read key.
+func CreateEvent(ctx context.Context, dag format.DAGService, body format.Node, rkey crypto.Encrypter) (net.Event, error) {
+	return CreateCompressedEvent(ctx, dag, body, rkey, "")
+}
+
+// CreateCompressedEvent is like CreateEvent, but compresses the body with one of BodyCodecs
+// before encryption. The codec is kept in the header, so readers which don't support
+// compressed bodies fail to decode the header. An empty codec leaves the body as is.
+func CreateCompressedEvent(
+	ctx context.Context,
+	dag format.DAGService,
+	body format.Node,
+	rkey crypto.Encrypter,
+	codec string,
+) (net.Event, error) {
 	key, err := sym.NewRandom()
 	if err != nil {
 		return nil, err
 	}
-	codedBody, err := EncodeBlock(body, key)
+	codedBody, err := encodeBody(body, key, codec)
+	if err != nil {
+		return nil, err
+	}
+	bodyRoot, bodyChunks, err := splitBody(codedBody)
 	if err != nil {
 		return nil, err
 	}
+	storedBody := codedBody
+	if bodyRoot != nil {
+		storedBody = bodyRoot
+	}
 	keyb, err := key.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
 	eventHeader := &eventHeader{
-		Key: keyb,
+		Key:   keyb,
+		Codec: codec,
 	}
 	header, err := cbornode.WrapObject(eventHeader, mh.SHA2_256, -1)
 	if err != nil {
@@ -55,7 +81,7 @@ func CreateEvent(ctx context.Context, dag format.DAGService, body format.Node, r
 		return nil, err
 	}
 	obj := &event{
-		Body:   codedBody.Cid(),
+		Body:   storedBody.Cid(),
 		Header: codedHeader.Cid(),
 	}
 	node, err := cbornode.WrapObject(obj, mh.SHA2_256, -1)
@@ -64,7 +90,8 @@ func CreateEvent(ctx context.Context, dag format.DAGService, body format.Node, r
 	}
 
 	if dag != nil {
-		if err = dag.AddMany(ctx, []format.Node{node, codedHeader, codedBody}); err != nil {
+		nodes := append([]format.Node{node, codedHeader, storedBody}, bodyChunks...)
+		if err = dag.AddMany(ctx, nodes); err != nil {
 			return nil, err
 		}
 	}
@@ -76,7 +103,8 @@ func CreateEvent(ctx context.Context, dag format.DAGService, body format.Node, r
 			Node: codedHeader,
 			obj:  eventHeader,
 		},
-		body: codedBody,
+		body:  storedBody,
+		coded: codedBody,
 	}, nil
 }
 
@@ -115,9 +143,15 @@ func EventFromRecord(ctx context.Context, dag format.DAGService, rec net.Record)
 	return event, nil
 }
 
-// RemoveEvent removes an event from the dag service.
+// RemoveEvent removes an event from the dag service, including chunks of its body.
 func RemoveEvent(ctx context.Context, dag format.DAGService, e *Event) error {
-	return dag.RemoveMany(ctx, []cid.Cid{e.Cid(), e.HeaderID(), e.BodyID()})
+	ids := []cid.Cid{e.Cid(), e.HeaderID(), e.BodyID()}
+	if body, err := e.GetBody(ctx, dag, nil); err == nil {
+		if chunks, _, err := BodyChunks(body); err == nil {
+			ids = append(ids, chunks...)
+		}
+	}
+	return dag.RemoveMany(ctx, ids)
 }
 
 // Event is a IPLD node representing an event.
@@ -127,13 +161,14 @@ type Event struct {
 	obj    *event
 	header *EventHeader
 	body   format.Node
+	coded  format.Node
 }
 
 func (e *Event) HeaderID() cid.Cid {
 	return e.obj.Header
 }
 
-func (e *Event) GetHeader(ctx context.Context, dag format.DAGService, key crypto.DecryptionKey) (net.EventHeader, error) {
+func (e *Event) GetHeader(ctx context.Context, dag format.DAGService, key crypto.Decrypter) (net.EventHeader, error) {
 	if e.header == nil {
 		coded, err := dag.Get(ctx, e.obj.Header)
 		if err != nil {
@@ -166,8 +201,14 @@ func (e *Event) BodyID() cid.Cid {
 	return e.obj.Body
 }
 
-func (e *Event) GetBody(ctx context.Context, dag format.DAGService, key crypto.DecryptionKey) (format.Node, error) {
-	var k crypto.DecryptionKey
+// GetBody returns the body node. Without a key, the node stored under BodyID is
+// returned as is, i.e., the root of chunked bodies. Otherwise, chunked bodies
+// are reassembled before being decoded.
+func (e *Event) GetBody(ctx context.Context, dag format.DAGService, key crypto.Decrypter) (format.Node, error) {
+	var (
+		k     crypto.DecryptionKey
+		codec string
+	)
 	if key != nil {
 		header, err := e.GetHeader(ctx, dag, key)
 		if err != nil {
@@ -177,6 +218,7 @@ func (e *Event) GetBody(ctx context.Context, dag format.DAGService, key crypto.D
 		if err != nil {
 			return nil, err
 		}
+		codec = e.header.obj.Codec
 	}
 
 	var err error
@@ -189,9 +231,17 @@ func (e *Event) GetBody(ctx context.Context, dag format.DAGService, key crypto.D
 
 	if k == nil {
 		return e.body, nil
-	} else {
-		return DecodeBlock(e.body, k)
 	}
+	if e.coded == nil {
+		if obj, err := chunkedBodyFromNode(e.body); err == nil {
+			if e.coded, err = joinBody(ctx, dag, obj); err != nil {
+				return nil, err
+			}
+		} else {
+			e.coded = e.body
+		}
+	}
+	return decodeBody(e.coded, k, codec)
 }
 
 // EventHeader is an IPLD node representing an event header.
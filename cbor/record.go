@@ -30,30 +30,44 @@ type record struct {
 }
 
 // CreateRecordConfig wraps all the elements needed for creating a new record.
+// The record is signed by Signer, or by the log private key Key if there is none.
 type CreateRecordConfig struct {
 	Block      format.Node
 	Prev       cid.Cid
 	Key        ic.PrivKey
+	Signer     net.Signer
 	PubKey     thread.PubKey
 	ServiceKey crypto.EncryptionKey
+	Extensions map[string][]byte
+	// Clock is the logical timestamp of the record, see net.Record.Clock. It's carried by the
+	// signed extensions, so peers not supporting extensions get the record without it.
+	// Zero leaves the record without a clock.
+	Clock uint64
+	// Seq is the position of the record in its log, see net.Record.Seq. It's carried by the
+	// signed extensions like Clock, zero leaves the record without a sequence number.
+	Seq uint64
 }
 
 // CreateRecord returns a new record from the given block and log private key.
 func CreateRecord(ctx context.Context, dag format.DAGService, config CreateRecordConfig) (net.Record, error) {
-	pkb, err := config.PubKey.MarshalBinary()
-	if err != nil {
+	if err := checkAnnotations(config.Extensions); err != nil {
 		return nil, err
 	}
-	var payload []byte
-	if config.Prev.Defined() {
-		payload = append(config.Block.Cid().Bytes(), config.Prev.Bytes()...)
-	} else {
-		payload = pkb
+	signer := config.Signer
+	if signer == nil {
+		if config.Key == nil {
+			return nil, fmt.Errorf("a signer or log key is required to create records")
+		}
+		signer = net.NewKeySigner(config.Key)
 	}
-	sig, err := config.Key.Sign(payload)
+	pkb, err := config.PubKey.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
+	sig, err := signer.Sign(ctx, recordPayload(config.Block.Cid(), config.Prev, pkb))
+	if err != nil {
+		return nil, fmt.Errorf("signing record: %w", err)
+	}
 	obj := &record{
 		Block:  config.Block.Cid(),
 		Sig:    sig,
@@ -75,13 +89,43 @@ func CreateRecord(ctx context.Context, dag format.DAGService, config CreateRecor
 		}
 	}
 
+	fields := config.Extensions
+	if config.Clock != 0 || config.Seq != 0 {
+		// the given extensions may be shared by several records, so they're copied
+		fields = make(map[string][]byte, len(config.Extensions)+2)
+		for k, v := range config.Extensions {
+			fields[k] = v
+		}
+		if config.Clock != 0 {
+			fields[net.ClockExtension] = EncodeClock(config.Clock)
+		}
+		if config.Seq != 0 {
+			fields[net.SeqExtension] = EncodeSeq(config.Seq)
+		}
+	}
+	var ext []byte
+	if len(fields) > 0 {
+		if ext, err = EncodeSignedExtensions(ctx, coded.Cid(), fields, signer); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Record{
 		Node:  coded,
 		obj:   obj,
 		block: config.Block,
+		ext:   ext,
 	}, nil
 }
 
+// recordPayload returns the bytes signed by the author of a record.
+func recordPayload(block, prev cid.Cid, pkb []byte) []byte {
+	if prev.Defined() {
+		return append(block.Bytes(), prev.Bytes()...)
+	}
+	return pkb
+}
+
 // GetRecord returns a record from the given cid.
 func GetRecord(ctx context.Context, dag format.DAGService, id cid.Cid, key crypto.DecryptionKey) (net.Record, error) {
 	coded, err := dag.Get(ctx, id)
@@ -115,6 +159,16 @@ func RemoveRecord(ctx context.Context, dag format.DAGService, rec net.Record) er
 // RecordToProto returns a proto version of a record for transport.
 // Nodes are sent encrypted.
 func RecordToProto(ctx context.Context, dag format.DAGService, rec net.Record) (*pb.Log_Record, error) {
+	return recordToProto(ctx, dag, rec, true)
+}
+
+// RestrictedRecordToProto returns a proto version of a record without the body, which
+// is withheld by the thread ACL. The body is not loaded, so it may be missing in the dag.
+func RestrictedRecordToProto(ctx context.Context, dag format.DAGService, rec net.Record) (*pb.Log_Record, error) {
+	return recordToProto(ctx, dag, rec, false)
+}
+
+func recordToProto(ctx context.Context, dag format.DAGService, rec net.Record, withBody bool) (*pb.Log_Record, error) {
 	block, err := rec.GetBlock(ctx, dag)
 	if err != nil {
 		return nil, err
@@ -130,17 +184,40 @@ func RecordToProto(ctx context.Context, dag format.DAGService, rec net.Record) (
 	if err != nil {
 		return nil, err
 	}
-	body, err := event.GetBody(ctx, dag, nil)
-	if err != nil {
-		return nil, err
-	}
 
-	return &pb.Log_Record{
+	pbrec := &pb.Log_Record{
 		RecordNode: rec.RawData(),
 		EventNode:  block.RawData(),
 		HeaderNode: header.RawData(),
-		BodyNode:   body.RawData(),
-	}, nil
+		Version:    EnvelopeV1,
+		Restricted: !withBody,
+	}
+	if withBody {
+		body, err := event.GetBody(ctx, dag, nil)
+		if err != nil {
+			return nil, err
+		}
+		pbrec.BodyNode = body.RawData()
+	}
+	if r, ok := rec.(rawExtended); ok && len(r.RawExtensions()) > 0 {
+		pbrec.Version = EnvelopeV2
+		pbrec.Extensions = r.RawExtensions()
+	}
+	return pbrec, nil
+}
+
+// DowngradeRecord returns a proto version of a record that doesn't exceed the given envelope version.
+// The passed record is not modified.
+func DowngradeRecord(rec *pb.Log_Record, version int32) *pb.Log_Record {
+	if rec.Version <= version {
+		return rec
+	}
+	down := *rec
+	down.Version = version
+	if version < EnvelopeV2 {
+		down.Extensions = nil
+	}
+	return &down
 }
 
 // RecordFromProto returns a node from a serialized version that contains link data.
@@ -161,9 +238,12 @@ func RecordFromProto(rec *pb.Log_Record, key crypto.DecryptionKey) (net.Record,
 	if err != nil {
 		return nil, err
 	}
-	body, err := cbornode.Decode(rec.BodyNode, mh.SHA2_256, -1)
-	if err != nil {
-		return nil, err
+	// the body is loaded from the DAG on first use if it's not included, see headers-only pulls
+	var body format.Node
+	if len(rec.BodyNode) > 0 {
+		if body, err = cbornode.Decode(rec.BodyNode, mh.SHA2_256, -1); err != nil {
+			return nil, err
+		}
 	}
 
 	decoded, err := DecodeBlock(rnode, key)
@@ -188,9 +268,11 @@ func RecordFromProto(rec *pb.Log_Record, key crypto.DecryptionKey) (net.Record,
 		body: body,
 	}
 	return &Record{
-		Node:  rnode,
-		obj:   robj,
-		block: event,
+		Node:       rnode,
+		obj:        robj,
+		block:      event,
+		ext:        rec.Extensions,
+		restricted: rec.Restricted && body == nil,
 	}, nil
 }
 
@@ -198,8 +280,10 @@ func RecordFromProto(rec *pb.Log_Record, key crypto.DecryptionKey) (net.Record,
 type Record struct {
 	format.Node
 
-	obj   *record
-	block format.Node
+	obj        *record
+	block      format.Node
+	ext        []byte
+	restricted bool
 }
 
 func (r *Record) BlockID() cid.Cid {
@@ -235,15 +319,80 @@ func (r *Record) Verify(key ic.PubKey) error {
 	if r.block == nil {
 		return fmt.Errorf("block not loaded")
 	}
-	var payload []byte
-	if r.PrevID().Defined() {
-		payload = append(r.block.Cid().Bytes(), r.PrevID().Bytes()...)
-	} else {
-		payload = r.PubKey()
+	if err := r.verifySig(r.block.Cid(), key); err != nil {
+		return err
 	}
+	if len(r.ext) > 0 {
+		return VerifyExtensions(r.Cid(), r.ext, key)
+	}
+	return nil
+}
+
+// VerifySig checks the record and extensions signatures without loading the block.
+func (r *Record) VerifySig(key ic.PubKey) error {
+	if err := r.verifySig(r.obj.Block, key); err != nil {
+		return err
+	}
+	if len(r.ext) > 0 {
+		return VerifyExtensions(r.Cid(), r.ext, key)
+	}
+	return nil
+}
+
+func (r *Record) verifySig(block cid.Cid, key ic.PubKey) error {
+	payload := recordPayload(block, r.PrevID(), r.PubKey())
 	ok, err := key.Verify(payload, r.Sig())
 	if !ok || err != nil {
 		return fmt.Errorf("bad signature")
 	}
 	return nil
 }
+
+// Extensions returns the record extension fields, if any.
+func (r *Record) Extensions() map[string][]byte {
+	if len(r.ext) == 0 {
+		return nil
+	}
+	fields, err := DecodeExtensions(r.ext)
+	if err != nil {
+		return nil
+	}
+	return fields
+}
+
+// Annotations returns the record annotations, if any.
+func (r *Record) Annotations() map[string]string {
+	return Annotations(r.Extensions())
+}
+
+// Clock returns the record clock, or zero if it's missing.
+func (r *Record) Clock() uint64 {
+	return Clock(r.Extensions())
+}
+
+// Seq returns the position of the record in its log, or zero if it's missing.
+func (r *Record) Seq() uint64 {
+	return Seq(r.Extensions())
+}
+
+// rawExtended is implemented by records carrying encoded extensions,
+// including wrappers of the records defined here.
+type rawExtended interface {
+	RawExtensions() []byte
+}
+
+// RawExtensions returns the encoded record extensions.
+func (r *Record) RawExtensions() []byte {
+	return r.ext
+}
+
+// Restricted returns whether the body of the record was withheld by the thread ACL of
+// the peer it was received from, so it can't be loaded.
+func (r *Record) Restricted() bool {
+	return r.restricted
+}
+
+// SetRawExtensions attaches the encoded extensions to the record.
+func (r *Record) SetRawExtensions(ext []byte) {
+	r.ext = ext
+}
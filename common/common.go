@@ -3,6 +3,8 @@ package common
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -12,21 +14,29 @@ import (
 
 	ipfslite "github.com/hsanjuan/ipfs-lite"
 	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	"github.com/libp2p/go-libp2p"
 	connmgr "github.com/libp2p/go-libp2p-connmgr"
 	cconnmgr "github.com/libp2p/go-libp2p-core/connmgr"
 	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/routing"
 	"github.com/libp2p/go-libp2p-peerstore/pstoreds"
 	ma "github.com/multiformats/go-multiaddr"
 	mongods "github.com/textileio/go-ds-mongo"
 	"github.com/textileio/go-threads/core/app"
+	kcore "github.com/textileio/go-threads/core/keystore"
 	core "github.com/textileio/go-threads/core/logstore"
+	netcore "github.com/textileio/go-threads/core/net"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
 	"github.com/textileio/go-threads/logstore/lstoreds"
 	"github.com/textileio/go-threads/logstore/lstorehybrid"
 	"github.com/textileio/go-threads/logstore/lstoremem"
 	"github.com/textileio/go-threads/net"
 	"github.com/textileio/go-threads/util"
+	"github.com/textileio/go-threads/util/clock"
+	"github.com/textileio/go-threads/util/encds"
 	"google.golang.org/grpc"
 )
 
@@ -35,6 +45,10 @@ type NetBoostrapper interface {
 	app.Net
 	GetIpfsLite() *ipfslite.Peer
 	Bootstrap(addrs []peer.AddrInfo)
+	// ConnGater returns the connection gater of the host, nil if gating is disabled.
+	ConnGater() *net.ConnGater
+	// Store returns the logstore of the network.
+	Store() core.Logstore
 }
 
 func DefaultNetwork(opts ...NetOption) (NetBoostrapper, error) {
@@ -72,21 +86,41 @@ func DefaultNetwork(opts ...NetOption) (NetBoostrapper, error) {
 		return nil, fin.Cleanup(err)
 	}
 
+	hostOpts := []libp2p.Option{
+		libp2p.Peerstore(pstore),
+		libp2p.ConnectionManager(config.ConnManager),
+		libp2p.DisableRelay(),
+	}
+	var gater *net.ConnGater
+	if config.ConnGating {
+		gater = net.NewConnGater(config.ConnAllowList, config.ConnDenyList)
+		hostOpts = append(hostOpts, libp2p.ConnectionGater(gater))
+	}
+
 	h, d, err := ipfslite.SetupLibp2p(
 		ctx,
 		hostKey,
 		nil,
 		[]ma.Multiaddr{config.HostAddr},
 		litestore,
-		libp2p.Peerstore(pstore),
-		libp2p.ConnectionManager(config.ConnManager),
-		libp2p.DisableRelay(),
+		hostOpts...,
 	)
 	if err != nil {
 		return nil, fin.Cleanup(err)
 	}
 
-	lite, err := ipfslite.New(ctx, litestore, h, d, nil)
+	// Blocks are cached decrypted once bodies are read, so they can be encrypted at rest
+	blockds := ds.Batching(litestore)
+	if config.BlockEncryption {
+		key, err := blockEncryptionKey(config, litestore)
+		if err != nil {
+			return nil, fin.Cleanup(err)
+		}
+		blockds = encds.Wrap(litestore, blockstore.BlockPrefix, key,
+			encds.WithPlaintextMigration(config.BlockEncryptionMigration))
+	}
+
+	lite, err := ipfslite.New(ctx, blockds, h, d, nil)
 	if err != nil {
 		return nil, fin.Cleanup(err)
 	}
@@ -96,10 +130,72 @@ func DefaultNetwork(opts ...NetOption) (NetBoostrapper, error) {
 		return nil, fin.Cleanup(err)
 	}
 
+	var router routing.Routing
+	if config.Discovery {
+		router = d
+	}
+
 	// Build a network
 	api, err := net.NewNetwork(ctx, h, lite.BlockStore(), lite, tstore, net.Config{
-		Debug:  config.Debug,
-		PubSub: config.PubSub,
+		Debug:                  config.Debug,
+		PubSub:                 config.PubSub,
+		FetchAttachments:       config.FetchAttachments,
+		Datastore:              namespace.Wrap(litestore, ds.NewKey("/net")),
+		PersistCallQueues:      config.PersistCallQueues,
+		MaxPeerCalls:           config.MaxPeerCalls,
+		ListenAddr:             config.ListenAddr,
+		ListenTLS:              config.ListenTLS,
+		ListenToken:            config.ListenToken,
+		WebSocketAddr:          config.WebSocketAddr,
+		WebSocketTLS:           config.WebSocketTLS,
+		RateLimits:             config.RateLimits,
+		MaxRecordSize:          config.MaxRecordSize,
+		MaxRecordBodySize:      config.MaxRecordBodySize,
+		GCInterval:             config.GCInterval,
+		CommitHooks:            config.CommitHooks,
+		AcceptHooks:            config.AcceptHooks,
+		PersistHooks:           config.PersistHooks,
+		KeyRotationHook:        config.KeyRotationHook,
+		RecordClock:            config.RecordClock,
+		Keystore:               config.Keystore,
+		RecordCipher:           config.RecordCipher,
+		Signers:                config.Signers,
+		SigningTimeout:         config.SigningTimeout,
+		TrackAcks:              config.TrackAcks,
+		HeaderSync:             config.HeaderSync,
+		EdgeGossip:             config.EdgeGossip,
+		PrivateTopics:          config.PrivateTopics,
+		RequireCapabilities:    config.RequireCapabilities,
+		Compression:            config.Compression,
+		CompressionCodec:       config.CompressionCodec,
+		BodyCompression:        config.BodyCompression,
+		CheckpointVerification: config.CheckpointVerification,
+		EventLogSize:           config.EventLogSize,
+		LinkDepth:              config.LinkDepth,
+		LazyLogs:               config.LazyLogs,
+		PeerBanThreshold:       config.PeerBanThreshold,
+		PeerBanDuration:        config.PeerBanDuration,
+		KeyEscrow:              config.KeyEscrow,
+		TokenTTL:               config.TokenTTL,
+		ReadOnly:               config.ReadOnly,
+		SharedBlocks:           config.SharedBlocks,
+		Embedded:               config.Embedded,
+		Routing:                router,
+		AdminAddr:              config.AdminAddr,
+		AdminTLS:               config.AdminTLS,
+		AdminToken:             config.AdminToken,
+		ThreadLockWidth:        config.ThreadLockWidth,
+		ThreadLockTimeout:      config.ThreadLockTimeout,
+		ConnGater:              gater,
+		Relay:                  config.Relay,
+		Topology:               config.Topology,
+		Quotas:                 config.Quotas,
+		Publish:                config.Publish,
+		ConnPool:               config.ConnPool,
+		DeadLetterAttempts:     config.DeadLetterAttempts,
+		ServerInterceptors:     config.ServerInterceptors,
+		ClientInterceptors:     config.ClientInterceptors,
+		Clock:                  config.Clock,
 	}, config.GRPCServerOptions, config.GRPCDialOptions)
 	if err != nil {
 		return nil, fin.Cleanup(err)
@@ -109,6 +205,7 @@ func DefaultNetwork(opts ...NetOption) (NetBoostrapper, error) {
 	return &netBoostrapper{
 		Net:       api,
 		litepeer:  lite,
+		gater:     gater,
 		finalizer: fin,
 	}, nil
 }
@@ -116,6 +213,9 @@ func DefaultNetwork(opts ...NetOption) (NetBoostrapper, error) {
 func buildLogstore(ctx context.Context, config NetConfig, fin *util.Finalizer) (core.Logstore, error) {
 	switch config.LSType {
 	case LogstoreInMemory:
+		if config.Keystore != nil {
+			return lstoremem.NewLogstoreWithKeystore(config.Keystore), nil
+		}
 		return lstoremem.NewLogstore(), nil
 
 	case LogstoreHybrid:
@@ -124,6 +224,9 @@ func buildLogstore(ctx context.Context, config NetConfig, fin *util.Finalizer) (
 			return nil, err
 		}
 		mls := lstoremem.NewLogstore()
+		if config.Keystore != nil {
+			mls = lstoremem.NewLogstoreWithKeystore(config.Keystore)
+		}
 		return lstorehybrid.NewLogstore(pls, mls)
 
 	case LogstorePersistent:
@@ -139,7 +242,9 @@ func persistentLogstore(ctx context.Context, config NetConfig, fin *util.Finaliz
 	if err != nil {
 		return nil, err
 	}
-	return lstoreds.NewLogstore(ctx, pds, lstoreds.DefaultOpts())
+	opts := lstoreds.DefaultOpts()
+	opts.Keystore = config.Keystore
+	return lstoreds.NewLogstore(ctx, pds, opts)
 }
 
 func persistentStore(ctx context.Context, config NetConfig, name string, fin *util.Finalizer) (ds.Batching, error) {
@@ -174,7 +279,69 @@ func mongoStore(ctx context.Context, uri, db, collection string, fin *util.Final
 	return dstore, nil
 }
 
+// blockEncryptionIdentity names the keystore identity whose private key is the secret
+// the block encryption key is derived from.
+const blockEncryptionIdentity = "block-encryption"
+
+// blockEncryptionSalt is the repository key of the salt of passphrase derived block
+// encryption keys.
+var blockEncryptionSalt = ds.NewKey("blockencryption/salt")
+
+// blockEncryptionKey derives the key encrypting blocks at rest from the operator
+// passphrase if set, or else from a secret held by the keystore, which is generated
+// on first use. Nothing the key can be derived from is kept in the repository.
+func blockEncryptionKey(config NetConfig, store ds.Datastore) (*sym.Key, error) {
+	if config.BlockEncryptionPassphrase != "" {
+		salt, err := store.Get(blockEncryptionSalt)
+		if errors.Is(err, ds.ErrNotFound) {
+			salt = make([]byte, encds.SaltBytes)
+			if _, err = rand.Read(salt); err != nil {
+				return nil, err
+			}
+			err = store.Put(blockEncryptionSalt, salt)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return encds.PassphraseKey(config.BlockEncryptionPassphrase, salt)
+	}
+	if config.Keystore == nil {
+		return nil, errors.New("block encryption requires a keystore or a passphrase")
+	}
+	secret, err := config.Keystore.Identity(blockEncryptionIdentity)
+	if errors.Is(err, kcore.ErrIdentityNotFound) {
+		if secret, _, err = newIPFSHostKey(); err != nil {
+			return nil, err
+		}
+		err = config.Keystore.AddIdentity(blockEncryptionIdentity, secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := secret.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return encds.DeriveKey(raw, "threads block encryption")
+}
+
+// getIPFSHostKey returns the host key held by the keystore if set. Otherwise, or if the
+// keystore is empty, the key is read from the repository, or generated, and added to it.
 func getIPFSHostKey(config NetConfig, store ds.Datastore) (crypto.PrivKey, error) {
+	if config.Keystore == nil {
+		return getRepoHostKey(config, store)
+	}
+	key, err := config.Keystore.HostKey()
+	if err != nil || key != nil {
+		return key, err
+	}
+	if key, err = getRepoHostKey(config, store); err != nil {
+		return nil, err
+	}
+	return key, config.Keystore.SetHostKey(key)
+}
+
+func getRepoHostKey(config NetConfig, store ds.Datastore) (crypto.PrivKey, error) {
 	if len(config.MongoUri) != 0 {
 		k := ds.NewKey("key")
 		bytes, err := store.Get(k)
@@ -265,16 +432,77 @@ const (
 )
 
 type NetConfig struct {
-	HostAddr          ma.Multiaddr
-	ConnManager       cconnmgr.ConnManager
-	GRPCServerOptions []grpc.ServerOption
-	GRPCDialOptions   []grpc.DialOption
-	LSType            LogstoreType
-	BadgerRepoPath    string
-	MongoUri          string
-	MongoDB           string
-	PubSub            bool
-	Debug             bool
+	HostAddr                  ma.Multiaddr
+	ConnManager               cconnmgr.ConnManager
+	GRPCServerOptions         []grpc.ServerOption
+	GRPCDialOptions           []grpc.DialOption
+	LSType                    LogstoreType
+	BadgerRepoPath            string
+	MongoUri                  string
+	MongoDB                   string
+	PubSub                    bool
+	PersistCallQueues         bool
+	MaxPeerCalls              int
+	FetchAttachments          bool
+	ListenAddr                ma.Multiaddr
+	ListenTLS                 *tls.Config
+	ListenToken               string
+	WebSocketAddr             ma.Multiaddr
+	WebSocketTLS              *tls.Config
+	RateLimits                net.RateLimits
+	MaxRecordSize             int
+	MaxRecordBodySize         int
+	GCInterval                time.Duration
+	CommitHooks               []netcore.CommitHook
+	AcceptHooks               []netcore.AcceptHook
+	PersistHooks              []netcore.PersistHooks
+	KeyRotationHook           netcore.KeyRotationHook
+	RecordClock               netcore.RecordClock
+	Keystore                  kcore.Keystore
+	RecordCipher              netcore.RecordCipher
+	Signers                   netcore.SignerProvider
+	SigningTimeout            time.Duration
+	TrackAcks                 bool
+	HeaderSync                bool
+	EdgeGossip                bool
+	PrivateTopics             bool
+	RequireCapabilities       bool
+	Compression               bool
+	CompressionCodec          string
+	BodyCompression           bool
+	CheckpointVerification    bool
+	EventLogSize              int
+	LinkDepth                 int
+	LazyLogs                  bool
+	PeerBanThreshold          int
+	PeerBanDuration           time.Duration
+	KeyEscrow                 bool
+	TokenTTL                  time.Duration
+	ReadOnly                  bool
+	SharedBlocks              bool
+	BlockEncryption           bool
+	BlockEncryptionPassphrase string
+	BlockEncryptionMigration  bool
+	Embedded                  bool
+	Discovery                 bool
+	AdminAddr                 ma.Multiaddr
+	AdminTLS                  *tls.Config
+	AdminToken                string
+	ThreadLockWidth           int
+	ThreadLockTimeout         time.Duration
+	ConnGating                bool
+	ConnAllowList             []peer.ID
+	ConnDenyList              []peer.ID
+	Relay                     net.RelayConfig
+	Topology                  net.TopologyConfig
+	Quotas                    net.QuotaConfig
+	Publish                   net.PublishConfig
+	ConnPool                  net.ConnPoolConfig
+	DeadLetterAttempts        int
+	ServerInterceptors        net.ServerInterceptors
+	ClientInterceptors        net.ClientInterceptors
+	Clock                     clock.Clock
+	Debug                     bool
 }
 
 type NetOption func(c *NetConfig) error
@@ -321,6 +549,438 @@ func WithNetPubSub(enabled bool) NetOption {
 	}
 }
 
+func WithNetPersistCallQueues(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.PersistCallQueues = enabled
+		return nil
+	}
+}
+
+func WithNetMaxPeerCalls(max int) NetOption {
+	return func(c *NetConfig) error {
+		c.MaxPeerCalls = max
+		return nil
+	}
+}
+
+func WithNetFetchAttachments(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.FetchAttachments = enabled
+		return nil
+	}
+}
+
+func WithNetListenAddr(addr ma.Multiaddr) NetOption {
+	return func(c *NetConfig) error {
+		c.ListenAddr = addr
+		return nil
+	}
+}
+
+func WithNetListenTLS(conf *tls.Config) NetOption {
+	return func(c *NetConfig) error {
+		c.ListenTLS = conf
+		return nil
+	}
+}
+
+func WithNetListenToken(token string) NetOption {
+	return func(c *NetConfig) error {
+		c.ListenToken = token
+		return nil
+	}
+}
+
+func WithNetWebSocketAddr(addr ma.Multiaddr) NetOption {
+	return func(c *NetConfig) error {
+		c.WebSocketAddr = addr
+		return nil
+	}
+}
+
+func WithNetWebSocketTLS(conf *tls.Config) NetOption {
+	return func(c *NetConfig) error {
+		c.WebSocketTLS = conf
+		return nil
+	}
+}
+
+func WithNetRateLimits(limits net.RateLimits) NetOption {
+	return func(c *NetConfig) error {
+		c.RateLimits = limits
+		return nil
+	}
+}
+
+func WithNetMaxRecordSize(size int) NetOption {
+	return func(c *NetConfig) error {
+		c.MaxRecordSize = size
+		return nil
+	}
+}
+
+func WithNetMaxRecordBodySize(size int) NetOption {
+	return func(c *NetConfig) error {
+		c.MaxRecordBodySize = size
+		return nil
+	}
+}
+
+func WithNetGCInterval(interval time.Duration) NetOption {
+	return func(c *NetConfig) error {
+		c.GCInterval = interval
+		return nil
+	}
+}
+
+func WithNetCommitHooks(hooks ...netcore.CommitHook) NetOption {
+	return func(c *NetConfig) error {
+		c.CommitHooks = hooks
+		return nil
+	}
+}
+
+func WithNetRecordCipher(cipher netcore.RecordCipher) NetOption {
+	return func(c *NetConfig) error {
+		c.RecordCipher = cipher
+		return nil
+	}
+}
+
+func WithNetSigners(signers netcore.SignerProvider) NetOption {
+	return func(c *NetConfig) error {
+		c.Signers = signers
+		return nil
+	}
+}
+
+func WithNetSigningTimeout(timeout time.Duration) NetOption {
+	return func(c *NetConfig) error {
+		c.SigningTimeout = timeout
+		return nil
+	}
+}
+
+func WithNetAcceptHooks(hooks ...netcore.AcceptHook) NetOption {
+	return func(c *NetConfig) error {
+		c.AcceptHooks = hooks
+		return nil
+	}
+}
+
+func WithNetPersistHooks(hooks ...netcore.PersistHooks) NetOption {
+	return func(c *NetConfig) error {
+		c.PersistHooks = hooks
+		return nil
+	}
+}
+
+func WithNetKeyRotationHook(hook netcore.KeyRotationHook) NetOption {
+	return func(c *NetConfig) error {
+		c.KeyRotationHook = hook
+		return nil
+	}
+}
+
+func WithNetRecordClock(clock netcore.RecordClock) NetOption {
+	return func(c *NetConfig) error {
+		c.RecordClock = clock
+		return nil
+	}
+}
+
+func WithNetTrackAcks(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.TrackAcks = enabled
+		return nil
+	}
+}
+
+func WithNetHeaderSync(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.HeaderSync = enabled
+		return nil
+	}
+}
+
+func WithNetEdgeGossip(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.EdgeGossip = enabled
+		return nil
+	}
+}
+
+func WithNetPrivateTopics(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.PrivateTopics = enabled
+		return nil
+	}
+}
+
+func WithNetRequireCapabilities(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.RequireCapabilities = enabled
+		return nil
+	}
+}
+
+func WithNetCompression(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.Compression = enabled
+		return nil
+	}
+}
+
+func WithNetCompressionCodec(codec string) NetOption {
+	return func(c *NetConfig) error {
+		c.CompressionCodec = codec
+		return nil
+	}
+}
+
+func WithNetBodyCompression(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.BodyCompression = enabled
+		return nil
+	}
+}
+
+func WithNetLazyLogs(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.LazyLogs = enabled
+		return nil
+	}
+}
+
+func WithNetPeerBans(threshold int, duration time.Duration) NetOption {
+	return func(c *NetConfig) error {
+		c.PeerBanThreshold = threshold
+		c.PeerBanDuration = duration
+		return nil
+	}
+}
+
+func WithNetTokenTTL(ttl time.Duration) NetOption {
+	return func(c *NetConfig) error {
+		c.TokenTTL = ttl
+		return nil
+	}
+}
+
+func WithNetKeyEscrow(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.KeyEscrow = enabled
+		return nil
+	}
+}
+
+func WithNetReadOnly(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.ReadOnly = enabled
+		return nil
+	}
+}
+
+func WithNetSharedBlocks(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.SharedBlocks = enabled
+		return nil
+	}
+}
+
+// WithNetBlockEncryption encrypts blocks at rest with a key derived from a secret held
+// by the keystore, or from the passphrase set with WithNetBlockEncryptionPassphrase.
+// Blocks stored before in the clear can't be read, see WithNetBlockEncryptionMigration.
+func WithNetBlockEncryption(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.BlockEncryption = enabled
+		return nil
+	}
+}
+
+// WithNetBlockEncryptionPassphrase derives the key encrypting blocks at rest from an
+// operator passphrase, which must be the same on every start of the repository.
+func WithNetBlockEncryptionPassphrase(passphrase string) NetOption {
+	return func(c *NetConfig) error {
+		c.BlockEncryptionPassphrase = passphrase
+		return nil
+	}
+}
+
+// WithNetBlockEncryptionMigration reads blocks stored in the clear before block encryption
+// was enabled as is, and encrypts them once rewritten. It should only be enabled while
+// migrating an existing repository, since blocks planted in the clear are read as well.
+func WithNetBlockEncryptionMigration(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.BlockEncryptionMigration = enabled
+		return nil
+	}
+}
+
+// WithNetKeystore keeps the host key, log private keys and thread keys in the keystore
+// instead of the repository, and lets the host act as identities held by it.
+// Keys stored in the repository before are still read from it.
+func WithNetKeystore(ks kcore.Keystore) NetOption {
+	return func(c *NetConfig) error {
+		c.Keystore = ks
+		return nil
+	}
+}
+
+func WithNetLinkDepth(depth int) NetOption {
+	return func(c *NetConfig) error {
+		c.LinkDepth = depth
+		return nil
+	}
+}
+
+func WithNetEventLogSize(size int) NetOption {
+	return func(c *NetConfig) error {
+		c.EventLogSize = size
+		return nil
+	}
+}
+
+func WithNetCheckpointVerification(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.CheckpointVerification = enabled
+		return nil
+	}
+}
+
+func WithNetEmbedded(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.Embedded = enabled
+		return nil
+	}
+}
+
+func WithNetDiscovery(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.Discovery = enabled
+		return nil
+	}
+}
+
+func WithNetAdminAddr(addr ma.Multiaddr) NetOption {
+	return func(c *NetConfig) error {
+		c.AdminAddr = addr
+		return nil
+	}
+}
+
+func WithNetAdminTLS(conf *tls.Config) NetOption {
+	return func(c *NetConfig) error {
+		c.AdminTLS = conf
+		return nil
+	}
+}
+
+func WithNetAdminToken(token string) NetOption {
+	return func(c *NetConfig) error {
+		c.AdminToken = token
+		return nil
+	}
+}
+
+func WithNetThreadLockWidth(width int) NetOption {
+	return func(c *NetConfig) error {
+		c.ThreadLockWidth = width
+		return nil
+	}
+}
+
+func WithNetThreadLockTimeout(timeout time.Duration) NetOption {
+	return func(c *NetConfig) error {
+		c.ThreadLockTimeout = timeout
+		return nil
+	}
+}
+
+func WithNetConnGating(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.ConnGating = enabled
+		return nil
+	}
+}
+
+func WithNetConnAllowList(peers ...peer.ID) NetOption {
+	return func(c *NetConfig) error {
+		c.ConnAllowList = peers
+		return nil
+	}
+}
+
+func WithNetConnDenyList(peers ...peer.ID) NetOption {
+	return func(c *NetConfig) error {
+		c.ConnDenyList = peers
+		return nil
+	}
+}
+
+func WithNetRelay(conf net.RelayConfig) NetOption {
+	return func(c *NetConfig) error {
+		c.Relay = conf
+		return nil
+	}
+}
+
+func WithNetQuotas(conf net.QuotaConfig) NetOption {
+	return func(c *NetConfig) error {
+		c.Quotas = conf
+		return nil
+	}
+}
+
+func WithNetTopology(conf net.TopologyConfig) NetOption {
+	return func(c *NetConfig) error {
+		c.Topology = conf
+		return nil
+	}
+}
+
+func WithNetPublish(conf net.PublishConfig) NetOption {
+	return func(c *NetConfig) error {
+		c.Publish = conf
+		return nil
+	}
+}
+
+func WithNetConnPool(conf net.ConnPoolConfig) NetOption {
+	return func(c *NetConfig) error {
+		c.ConnPool = conf
+		return nil
+	}
+}
+
+func WithNetDeadLetterAttempts(attempts int) NetOption {
+	return func(c *NetConfig) error {
+		c.DeadLetterAttempts = attempts
+		return nil
+	}
+}
+
+func WithNetServerInterceptors(interceptors net.ServerInterceptors) NetOption {
+	return func(c *NetConfig) error {
+		c.ServerInterceptors = interceptors
+		return nil
+	}
+}
+
+func WithNetClientInterceptors(interceptors net.ClientInterceptors) NetOption {
+	return func(c *NetConfig) error {
+		c.ClientInterceptors = interceptors
+		return nil
+	}
+}
+
+func WithNetClock(clk clock.Clock) NetOption {
+	return func(c *NetConfig) error {
+		c.Clock = clk
+		return nil
+	}
+}
+
 func WithNetLogstore(lt LogstoreType) NetOption {
 	return func(c *NetConfig) error {
 		c.LSType = lt
@@ -346,6 +1006,7 @@ func WithNetMongoPersistence(uri, db string) NetOption {
 type netBoostrapper struct {
 	app.Net
 	litepeer  *ipfslite.Peer
+	gater     *net.ConnGater
 	finalizer *util.Finalizer
 }
 
@@ -359,6 +1020,14 @@ func (tsb *netBoostrapper) GetIpfsLite() *ipfslite.Peer {
 	return tsb.litepeer
 }
 
+func (tsb *netBoostrapper) ConnGater() *net.ConnGater {
+	return tsb.gater
+}
+
+func (tsb *netBoostrapper) Store() core.Logstore {
+	return tsb.Net.(interface{ Store() core.Logstore }).Store()
+}
+
 func (tsb *netBoostrapper) Close() error {
 	return tsb.finalizer.Cleanup(nil)
 }
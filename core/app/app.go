@@ -10,6 +10,7 @@ import (
 	"github.com/textileio/go-threads/broadcast"
 	"github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
 	"github.com/textileio/go-threads/util"
 )
 
@@ -100,12 +101,28 @@ type Net interface {
 	net.Net
 
 	// ConnectApp returns an app<->thread connector.
-	ConnectApp(App, thread.ID) (*Connector, error)
+	ConnectApp(App, thread.ID, ...ConnectOption) (*Connector, error)
 
 	// Validate thread ID and token against the net host.
 	// If token is present and was issued the net host (is valid), the embedded public key is returned.
 	// If token is not present, both the returned public key and error will be nil.
 	Validate(id thread.ID, token thread.Token, readOnly bool) (thread.PubKey, error)
+
+	// CreateRecordsAcross creates chains of new records in multiple threads in one transaction.
+	// Either all records become part of the host's logs and are pushed to peers, or none do.
+	// Records are returned in the order of the writes.
+	CreateRecordsAcross(ctx context.Context, writes []ThreadWrite) ([][]net.ThreadRecord, error)
+
+	// RecordDecrypter returns the scheme opening record headers of the thread, see net.RecordCipher.
+	// It's nil if the host can't read records of the thread.
+	RecordDecrypter(ctx context.Context, id thread.ID) (crypto.Decrypter, error)
+}
+
+// ThreadWrite is a batch of record bodies written to a thread by Net.CreateRecordsAcross.
+type ThreadWrite struct {
+	ID     thread.ID
+	Bodies []format.Node
+	Opts   []net.ThreadOption
 }
 
 // Connector connects an app to a thread.
@@ -116,22 +133,44 @@ type Connector struct {
 	token     net.Token
 	threadID  thread.ID
 	threadKey thread.Key
+	resolver  ConflictResolver
+}
+
+// ConnectOptions defines options for connecting an app to a thread.
+type ConnectOptions struct {
+	Resolver ConflictResolver
+}
+
+// ConnectOption specifies a connect option.
+type ConnectOption func(*ConnectOptions)
+
+// WithConflictResolver sets the strategy reconciling concurrent updates of the thread.
+// Defaults to LastWriterWins.
+func WithConflictResolver(r ConflictResolver) ConnectOption {
+	return func(args *ConnectOptions) {
+		args.Resolver = r
+	}
 }
 
 // Connection receives new thread records, which are pumped to the app.
 type Connection func(context.Context, thread.ID) (<-chan net.ThreadRecord, error)
 
 // NewConnector creates bidirectional connection between an app and a thread.
-func NewConnector(app App, net Net, tinfo thread.Info) (*Connector, error) {
+func NewConnector(app App, net Net, tinfo thread.Info, opts ...ConnectOption) (*Connector, error) {
 	if !tinfo.Key.CanRead() {
 		return nil, fmt.Errorf("read key not found for thread %s", tinfo.ID)
 	}
+	args := &ConnectOptions{Resolver: LastWriterWins}
+	for _, opt := range opts {
+		opt(args)
+	}
 	return &Connector{
 		Net:       net,
 		app:       app,
 		token:     util.GenerateRandomBytes(32),
 		threadID:  tinfo.ID,
 		threadKey: tinfo.Key,
+		resolver:  args.Resolver,
 	}, nil
 }
 
@@ -150,6 +189,35 @@ func (c *Connector) CreateNetRecord(ctx context.Context, body format.Node, token
 	return c.Net.CreateRecord(ctx, c.threadID, body, net.WithThreadToken(token), net.WithAPIToken(c.token))
 }
 
+// ConnectorWrite is a batch of record bodies written to the thread of a connector by CreateNetRecordsAcross.
+type ConnectorWrite struct {
+	Connector *Connector
+	Bodies    []format.Node
+	Token     thread.Token
+}
+
+// CreateNetRecordsAcross calls net.CreateRecordsAcross while supplying thread IDs and API tokens
+// of the connectors, e.g., to write an index thread along with a data thread. The connectors
+// have to share the net.
+func CreateNetRecordsAcross(ctx context.Context, writes ...ConnectorWrite) ([][]net.ThreadRecord, error) {
+	if len(writes) == 0 {
+		return nil, nil
+	}
+	n := writes[0].Connector.Net
+	tws := make([]ThreadWrite, len(writes))
+	for i, w := range writes {
+		if w.Connector.Net != n {
+			return nil, fmt.Errorf("connector of thread %s uses another net", w.Connector.threadID)
+		}
+		tws[i] = ThreadWrite{
+			ID:     w.Connector.threadID,
+			Bodies: w.Bodies,
+			Opts:   []net.ThreadOption{net.WithThreadToken(w.Token), net.WithAPIToken(w.Connector.token)},
+		}
+	}
+	return n.CreateRecordsAcross(ctx, tws)
+}
+
 // Validate thread token against the net host.
 func (c *Connector) Validate(token thread.Token, readOnly bool) error {
 	_, err := c.Net.Validate(c.threadID, token, readOnly)
@@ -161,6 +229,11 @@ func (c *Connector) ValidateNetRecordBody(ctx context.Context, body format.Node,
 	return c.app.ValidateNetRecordBody(ctx, body, identity)
 }
 
+// ResolveConflict reconciles concurrent updates of a value with the thread's conflict resolver.
+func (c *Connector) ResolveConflict(current, incoming Update) (Update, error) {
+	return c.resolver.Resolve(current, incoming)
+}
+
 // HandleNetRecord calls the connection app's HandleNetRecord while supplying thread key.
 func (c *Connector) HandleNetRecord(ctx context.Context, rec net.ThreadRecord) error {
 	return c.app.HandleNetRecord(ctx, rec, c.threadKey)
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Update is a change of a value carried by thread records, e.g., a db instance.
+type Update struct {
+	// Key identifies the value within the thread.
+	Key string
+	// Field is the top-level field of the value which changed, empty if the whole value changed.
+	Field string
+	// Value after the change, nil if the value was deleted.
+	Value []byte
+	// Time (wall-clock, in nanoseconds) the change was made.
+	Time int64
+}
+
+// ConflictResolver reconciles concurrent updates of a value, so every replica of a thread
+// ends up with the same view regardless of the order records arrive in. Custom CRDTs can
+// be plugged in by implementing the interface. Replicas resolving with different resolvers
+// diverge, so every replica of a thread must use the resolver of the same name.
+type ConflictResolver interface {
+	// Name identifies the resolver across replicas.
+	Name() string
+	// Resolve returns the update resulting from applying incoming on top of current.
+	// current has a nil value if the value doesn't exist locally.
+	Resolve(current, incoming Update) (Update, error)
+}
+
+type resolverFunc struct {
+	name string
+	f    func(current, incoming Update) (Update, error)
+}
+
+// NewResolver returns a conflict resolver calling f, identified by name.
+func NewResolver(name string, f func(current, incoming Update) (Update, error)) ConflictResolver {
+	return &resolverFunc{name: name, f: f}
+}
+
+func (r *resolverFunc) Name() string {
+	return r.name
+}
+
+func (r *resolverFunc) Resolve(current, incoming Update) (Update, error) {
+	return r.f(current, incoming)
+}
+
+// LastWriterWins keeps the update with the latest timestamp. Ties are broken by comparing
+// the values, so the winner doesn't depend on the order updates are received in.
+var LastWriterWins = NewResolver("last-writer-wins", lastWriterWins)
+
+func lastWriterWins(current, incoming Update) (Update, error) {
+	if incoming.Time > current.Time ||
+		incoming.Time == current.Time && bytes.Compare(incoming.Value, current.Value) > 0 {
+		return incoming, nil
+	}
+	return current, nil
+}
+
+// FieldMergeFunc returns the merged value of a top-level field changed by both updates.
+type FieldMergeFunc func(key, field string, current, incoming json.RawMessage) (json.RawMessage, error)
+
+// FieldMerge merges concurrent values of the same top-level field with the callback, which
+// must return the same value regardless of the order of its arguments. Fields set by one of
+// the updates only are kept, and deletions are resolved with LastWriterWins. The resolver
+// is identified by name, see ConflictResolver.
+func FieldMerge(name string, merge FieldMergeFunc) ConflictResolver {
+	return NewResolver("field-merge/"+name, func(current, incoming Update) (Update, error) {
+		if current.Value == nil || incoming.Value == nil || bytes.Equal(current.Value, incoming.Value) {
+			return lastWriterWins(current, incoming)
+		}
+		merged, err := merge(incoming.Key, incoming.Field, current.Value, incoming.Value)
+		if err != nil {
+			return Update{}, err
+		}
+		res := Update{Key: incoming.Key, Field: incoming.Field, Value: merged, Time: incoming.Time}
+		if current.Time > res.Time {
+			res.Time = current.Time
+		}
+		return res, nil
+	})
+}
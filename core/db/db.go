@@ -88,3 +88,29 @@ type EventCodec interface {
 	// EventsFromBytes deserializes a format.Node bytes payload into Events.
 	EventsFromBytes(data []byte) ([]Event, error)
 }
+
+// ResolveFunc reconciles the stored value of a top-level field of an instance with a
+// concurrent update of it, returning the resulting value and its time. Values of missing
+// fields are nil.
+type ResolveFunc func(
+	key ds.Key,
+	field string,
+	current []byte,
+	currentTime int64,
+	incoming []byte,
+	incomingTime int64,
+) ([]byte, int64, error)
+
+// ResolvingEventCodec is an EventCodec which can reconcile events received from other
+// peers with concurrent updates of the stored instances.
+type ResolvingEventCodec interface {
+	EventCodec
+	// ReduceResolved applies events like Reduce, passing the changed fields of instances through resolve.
+	ReduceResolved(
+		events []Event,
+		store ds.TxnDatastore,
+		baseKey ds.Key,
+		indexFunc IndexFunc,
+		resolve ResolveFunc,
+	) ([]ReduceAction, error)
+}
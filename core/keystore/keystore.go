@@ -0,0 +1,63 @@
+package keystore
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+// ErrIdentityNotFound indicates a requested identity is not held by the keystore.
+var ErrIdentityNotFound = errors.New("identity not found")
+
+// Keystore holds the secret keys of a node: the host key, the private keys of named
+// identities the node acts as, and the private keys of its logs along with thread keys.
+// Getters return nil keys if they're missing.
+type Keystore interface {
+	// HostKey returns the private key of the host.
+	HostKey() (crypto.PrivKey, error)
+
+	// SetHostKey sets the private key of the host.
+	SetHostKey(crypto.PrivKey) error
+
+	// AddIdentity adds the private key of an identity under a name.
+	AddIdentity(name string, key crypto.PrivKey) error
+
+	// Identity returns the private key of a named identity, or ErrIdentityNotFound.
+	Identity(name string) (crypto.PrivKey, error)
+
+	// Identities returns the names of all identities.
+	Identities() ([]string, error)
+
+	// LogKey returns the private key of a log.
+	LogKey(thread.ID, peer.ID) (crypto.PrivKey, error)
+
+	// AddLogKey adds the private key of a log.
+	AddLogKey(thread.ID, peer.ID, crypto.PrivKey) error
+
+	// ReadKey returns the read key of a thread.
+	ReadKey(thread.ID) (*sym.Key, error)
+
+	// AddReadKey adds the read key of a thread.
+	AddReadKey(thread.ID, *sym.Key) error
+
+	// ServiceKey returns the service key of a thread.
+	ServiceKey(thread.ID) (*sym.Key, error)
+
+	// AddServiceKey adds the service key of a thread.
+	AddServiceKey(thread.ID, *sym.Key) error
+
+	// Logs returns the logs of a thread with private keys.
+	Logs(thread.ID) (peer.IDSlice, error)
+
+	// Threads returns the threads with any keys.
+	Threads() (thread.IDSlice, error)
+
+	// ClearThread deletes all keys of a thread.
+	ClearThread(thread.ID) error
+
+	// ClearLog deletes the private key of a log.
+	ClearLog(thread.ID, peer.ID) error
+}
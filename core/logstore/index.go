@@ -0,0 +1,47 @@
+package logstore
+
+import (
+	"sort"
+
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/whyrusleeping/base32"
+)
+
+// ThreadIndexLess reports whether thread a precedes thread b in the thread index of
+// logstores. Threads are indexed by the unpadded base32 encoding of their IDs, which
+// datastore backed books key threads by, so pages are listed by ordered key queries.
+func ThreadIndexLess(a, b thread.ID) bool {
+	return threadIndexKey(a) < threadIndexKey(b)
+}
+
+// PageThreads returns up to limit unique threads of ids in index order, starting right
+// after the given cursor. Pages of several indexes are merged by paging their union.
+func PageThreads(ids thread.IDSlice, after thread.ID, limit int) thread.IDSlice {
+	if limit <= 0 {
+		return thread.IDSlice{}
+	}
+	cursor := threadIndexKey(after)
+	keys := make(map[string]thread.ID, len(ids))
+	for _, id := range ids {
+		if k := threadIndexKey(id); k > cursor {
+			keys[k] = id
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	page := make(thread.IDSlice, len(sorted))
+	for i, k := range sorted {
+		page[i] = keys[k]
+	}
+	return page
+}
+
+func threadIndexKey(id thread.ID) string {
+	return base32.RawStdEncoding.EncodeToString(id.Bytes())
+}
@@ -43,6 +43,11 @@ type Logstore interface {
 	// Threads returns all threads in the store.
 	Threads() (thread.IDSlice, error)
 
+	// ThreadsAfter returns up to limit threads in index order, see ThreadIndexLess, starting
+	// right after the given cursor. Use thread.Undef as a cursor to start from the beginning
+	// of the index.
+	ThreadsAfter(after thread.ID, limit int) (thread.IDSlice, error)
+
 	// AddThread adds a thread.
 	AddThread(thread.Info) error
 
@@ -139,6 +144,10 @@ type KeyBook interface {
 	// ThreadsFromKeys returns a list of threads referenced in the book.
 	ThreadsFromKeys() (thread.IDSlice, error)
 
+	// ThreadsFromKeysAfter returns up to limit threads referenced in the book in index
+	// order, starting right after the given cursor.
+	ThreadsFromKeysAfter(after thread.ID, limit int) (thread.IDSlice, error)
+
 	// DumpKeys packs all stored keys.
 	DumpKeys() (DumpKeyBook, error)
 
@@ -178,6 +187,10 @@ type AddrBook interface {
 	// ThreadsFromAddrs returns a list of threads referenced in the book.
 	ThreadsFromAddrs() (thread.IDSlice, error)
 
+	// ThreadsFromAddrsAfter returns up to limit threads referenced in the book in index
+	// order, starting right after the given cursor.
+	ThreadsFromAddrsAfter(after thread.ID, limit int) (thread.IDSlice, error)
+
 	// AddrsEdge returns deterministic hash of all peer addresses of a given thread.
 	AddrsEdge(t thread.ID) (uint64, error)
 
@@ -0,0 +1,50 @@
+package net
+
+import (
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ThreadACL restricts the record bodies of a thread served to replicators, see Net.SetThreadACL.
+// Replicators without the capability receive the envelopes of restricted records, which keep
+// the log chain verifiable, but not their bodies.
+// The ACL is local metadata of the host which sets it, it isn't replicated. Readers receiving
+// restricted bodies store them like any other, and may serve them to peers in turn.
+type ThreadACL struct {
+	// RestrictedLogs are the logs whose record bodies are restricted.
+	RestrictedLogs []peer.ID
+	// RestrictedRecords are the records whose bodies are restricted, in addition to the
+	// records of restricted logs.
+	RestrictedRecords []cid.Cid
+	// Readers are the peers holding the capability to receive restricted bodies.
+	Readers []peer.ID
+}
+
+// Restricts returns whether the body of a log record is restricted.
+func (acl ThreadACL) Restricts(lid peer.ID, rid cid.Cid) bool {
+	for _, l := range acl.RestrictedLogs {
+		if l == lid {
+			return true
+		}
+	}
+	for _, r := range acl.RestrictedRecords {
+		if r.Equals(rid) {
+			return true
+		}
+	}
+	return false
+}
+
+// Withholds returns whether restricted bodies are withheld from a peer, i.e. the ACL restricts
+// some bodies and the peer isn't one of the readers.
+func (acl ThreadACL) Withholds(pid peer.ID) bool {
+	if len(acl.RestrictedLogs)+len(acl.RestrictedRecords) == 0 {
+		return false
+	}
+	for _, r := range acl.Readers {
+		if r == pid {
+			return false
+		}
+	}
+	return true
+}
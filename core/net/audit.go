@@ -0,0 +1,286 @@
+package net
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+// ThreadSample is a deterministic random sample of thread records along with
+// the proofs of their inclusion into the thread logs.
+type ThreadSample struct {
+	// ThreadID is the sampled thread.
+	ThreadID thread.ID
+	// Logs holds a sample entry for every non-empty log, ordered by log ID.
+	Logs []LogSample
+}
+
+// LogSample proves the inclusion of sampled records into a single log.
+type LogSample struct {
+	// ID is the log ID.
+	ID peer.ID
+	// PubKey is the marshaled public key of the log, it must match the ID.
+	PubKey []byte
+	// Head is the log head the proof is anchored to.
+	Head cid.Cid
+	// HeadExtensions are the encoded signed extensions of the head record, carrying
+	// its sequence number. They're empty if the head isn't numbered.
+	HeadExtensions []byte
+	// Length is the number of records in the log. It must match the sequence
+	// number signed into the head record.
+	Length int
+	// Indices are the sampled record positions counting from the head (zero is the head itself).
+	Indices []int
+	// Chain holds the encoded record nodes walking from the head down to the
+	// deepest sampled record, or the head alone if none was sampled. Logs without
+	// sequence numbers are included down to their first record, proving the length.
+	// Event blocks and bodies are not included.
+	Chain [][]byte
+}
+
+// Size returns the total number of sampled records.
+func (s ThreadSample) Size() int {
+	var n int
+	for _, l := range s.Logs {
+		n += len(l.Indices)
+	}
+	return n
+}
+
+// SampleIndices deterministically picks up to k positions across all logs. The
+// seed commits to the nonce, thread and the state of every log, so a prover can't
+// choose the positions while the auditor can recompute them.
+// Returned positions are sorted per log.
+func SampleIndices(id thread.ID, nonce []byte, k int, logs []LogSample) [][]int {
+	var (
+		total   int
+		seedBuf bytes.Buffer
+		num     [8]byte
+	)
+	seedBuf.Write(nonce)
+	seedBuf.Write(id.Bytes())
+	for _, ls := range logs {
+		seedBuf.Write([]byte(ls.ID))
+		seedBuf.Write(ls.Head.Bytes())
+		binary.BigEndian.PutUint64(num[:], uint64(ls.Length))
+		seedBuf.Write(num[:])
+		total += ls.Length
+	}
+	seed := sha256.Sum256(seedBuf.Bytes())
+
+	picked := make(map[int]struct{}, k)
+	if k >= total {
+		for i := 0; i < total; i++ {
+			picked[i] = struct{}{}
+		}
+	} else {
+		for ctr := uint64(0); len(picked) < k; ctr++ {
+			binary.BigEndian.PutUint64(num[:], ctr)
+			h := sha256.Sum256(append(seed[:], num[:]...))
+			picked[int(binary.BigEndian.Uint64(h[:8])%uint64(total))] = struct{}{}
+		}
+	}
+
+	// map global positions to the log positions
+	res := make([][]int, len(logs))
+	for pos := range picked {
+		for i, ls := range logs {
+			if pos < ls.Length {
+				res[i] = append(res[i], pos)
+				break
+			}
+			pos -= ls.Length
+		}
+	}
+	for _, r := range res {
+		sort.Ints(r)
+	}
+	return res
+}
+
+// VerificationBundle is a trust anchor for verifying thread records offline.
+// It is exported by a thread member and provisioned to auditors or gateways out of band.
+type VerificationBundle struct {
+	// ThreadID is the thread the bundle describes.
+	ThreadID thread.ID `json:"threadID"`
+	// ServiceKeyHash is the SHA-256 hash of the thread service key.
+	ServiceKeyHash []byte `json:"serviceKeyHash"`
+	// Logs holds the verification keys of the thread logs, ordered by log ID.
+	Logs []LogKey `json:"logs"`
+}
+
+// LogKey binds a log ID to its public key.
+type LogKey struct {
+	// ID is the log ID.
+	ID peer.ID `json:"id"`
+	// PubKey is the marshaled public key of the log, it must match the ID.
+	PubKey []byte `json:"pubKey"`
+}
+
+// NewVerificationBundle returns the verification bundle of a thread with the given
+// service key and logs.
+func NewVerificationBundle(id thread.ID, sk *sym.Key, logs []thread.LogInfo) (VerificationBundle, error) {
+	bundle := VerificationBundle{ThreadID: id, ServiceKeyHash: ServiceKeyHash(sk)}
+	for _, lg := range logs {
+		pk, err := ic.MarshalPublicKey(lg.PubKey)
+		if err != nil {
+			return bundle, err
+		}
+		bundle.Logs = append(bundle.Logs, LogKey{ID: lg.ID, PubKey: pk})
+	}
+	sort.Slice(bundle.Logs, func(i, j int) bool { return bundle.Logs[i].ID < bundle.Logs[j].ID })
+	return bundle, nil
+}
+
+// ServiceKeyHash returns the hash of a thread service key held by verification bundles.
+func ServiceKeyHash(sk *sym.Key) []byte {
+	h := sha256.Sum256(sk.Bytes())
+	return h[:]
+}
+
+// Validate checks that the bundle is well-formed, i.e. the logs are ordered and every
+// bundled key matches its log ID.
+func (b VerificationBundle) Validate() error {
+	if err := b.ThreadID.Validate(); err != nil {
+		return err
+	}
+	if len(b.ServiceKeyHash) != sha256.Size {
+		return fmt.Errorf("bad service-key hash")
+	}
+	for i, l := range b.Logs {
+		if i > 0 && l.ID <= b.Logs[i-1].ID {
+			return fmt.Errorf("logs are not ordered by ID")
+		}
+		pk, err := ic.UnmarshalPublicKey(l.PubKey)
+		if err != nil {
+			return fmt.Errorf("log %s: bad public key: %w", l.ID, err)
+		}
+		if !l.ID.MatchesPublicKey(pk) {
+			return fmt.Errorf("log %s: public key doesn't match log ID", l.ID)
+		}
+	}
+	return nil
+}
+
+// CheckServiceKey fails unless the bundle was exported for the service key.
+func (b VerificationBundle) CheckServiceKey(sk *sym.Key) error {
+	if !bytes.Equal(ServiceKeyHash(sk), b.ServiceKeyHash) {
+		return fmt.Errorf("service-key doesn't match the bundle")
+	}
+	return nil
+}
+
+// CheckLogKey fails with ErrUntrustedLog if the bundle holds another key of the log.
+// It returns whether the log is in the bundle.
+func (b VerificationBundle) CheckLogKey(lid peer.ID, pk ic.PubKey) (bool, error) {
+	trusted := b.LogPubKey(lid)
+	if trusted == nil {
+		return false, nil
+	}
+	raw, err := ic.MarshalPublicKey(pk)
+	if err != nil {
+		return true, err
+	}
+	if !bytes.Equal(trusted, raw) {
+		return true, fmt.Errorf("log %s: %w", lid, ErrUntrustedLog)
+	}
+	return true, nil
+}
+
+// LogPubKey returns the marshaled public key of a log, or nil if the log is not in the bundle.
+func (b VerificationBundle) LogPubKey(lid peer.ID) []byte {
+	for _, l := range b.Logs {
+		if l.ID == lid {
+			return l.PubKey
+		}
+	}
+	return nil
+}
+
+// DamageKind is the kind of damage found in a log, see Net.VerifyThread.
+type DamageKind int
+
+const (
+	// DamageMissingBlock is a record, event, header or body block missing from the local blockstore.
+	DamageMissingBlock DamageKind = iota
+	// DamageBadSignature is a record which isn't signed by the log key.
+	DamageBadSignature
+	// DamageBrokenLink is a record or event block which can't be decoded, so the blocks it links to
+	// can't be reached.
+	DamageBrokenLink
+)
+
+func (k DamageKind) String() string {
+	switch k {
+	case DamageMissingBlock:
+		return "missing block"
+	case DamageBadSignature:
+		return "bad signature"
+	case DamageBrokenLink:
+		return "broken link"
+	default:
+		return "unknown"
+	}
+}
+
+// ThreadVerification is the outcome of walking every log of a thread from the heads to genesis.
+type ThreadVerification struct {
+	// ThreadID is the verified thread.
+	ThreadID thread.ID
+	// Logs holds an entry for every non-empty log, ordered by log ID.
+	Logs []LogVerification
+}
+
+// LogVerification is the outcome of walking a single log.
+type LogVerification struct {
+	// ID is the log ID.
+	ID peer.ID
+	// Heads are the log heads the walk started from.
+	Heads []cid.Cid
+	// Records is the number of records reached by the walk.
+	Records int
+	// Damage lists the damage found, in walk order.
+	Damage []LogDamage
+}
+
+// LogDamage is a damaged record of a log.
+type LogDamage struct {
+	// Kind is the kind of damage.
+	Kind DamageKind
+	// Record is the damaged record.
+	Record cid.Cid
+	// Block is the missing block, either the record itself or one of its event blocks.
+	Block cid.Cid
+	// Repaired is whether the damage was fixed by re-fetching the record from replicators.
+	Repaired bool
+}
+
+// HasDamage returns whether the damage was found in the log already.
+func (v LogVerification) HasDamage(d LogDamage) bool {
+	for _, x := range v.Damage {
+		if x.Kind == d.Kind && x.Record.Equals(d.Record) && x.Block.Equals(d.Block) {
+			return true
+		}
+	}
+	return false
+}
+
+// OK returns whether no damage is left in the thread.
+func (v ThreadVerification) OK() bool {
+	for _, l := range v.Logs {
+		for _, d := range l.Damage {
+			if !d.Repaired {
+				return false
+			}
+		}
+	}
+	return true
+}
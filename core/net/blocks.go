@@ -0,0 +1,20 @@
+package net
+
+// BlockStats describes how the blocks of the stored threads are shared. Blocks are content
+// addressed, so a body referenced by several events, e.g., of the same record added to
+// several threads, is stored once.
+type BlockStats struct {
+	// Threads is the number of walked threads.
+	Threads int
+	// Events is the number of distinct events referenced by the records of the threads.
+	Events int
+	// Bodies is the number of distinct bodies referenced by the events.
+	Bodies int
+	// SharedBodies is the number of bodies referenced by more than one event or thread.
+	SharedBodies int
+	// SharedThreads is the number of threads referencing a body shared with another thread.
+	SharedThreads int
+	// DuplicateRefs is the number of body references beyond the first one of every body,
+	// i.e., the number of bodies not stored thanks to content addressing.
+	DuplicateRefs int
+}
@@ -0,0 +1,23 @@
+package net
+
+import (
+	"context"
+
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+// RecordCipher seals the headers of record events, which carry the single-use keys
+// of record bodies. By default, headers are encrypted with the thread read key, a cipher
+// lets applications use other schemes, e.g., per-recipient envelope encryption, age or
+// HPKE. Record and event formats are the same with any scheme.
+type RecordCipher interface {
+	// Encrypter returns the scheme sealing headers of new records of the thread.
+	// The read key is nil if the host doesn't have it.
+	Encrypter(ctx context.Context, id thread.ID, readKey *sym.Key) (crypto.Encrypter, error)
+
+	// Decrypter returns the scheme opening headers of records of the thread, or nil
+	// if the host can't read them. The read key is nil if the host doesn't have it.
+	Decrypter(ctx context.Context, id thread.ID, readKey *sym.Key) (crypto.Decrypter, error)
+}
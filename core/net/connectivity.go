@@ -0,0 +1,49 @@
+package net
+
+import (
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ConnectivityStatus describes how the host is reached by peers.
+type ConnectivityStatus struct {
+	// Reachability tells whether the host is publicly dialable, as last reported by AutoNAT.
+	Reachability network.Reachability
+	// ReachableAddrs are the addresses of the host observed by peers, either dialed directly
+	// or reported by identify. Addresses which stop being observed expire.
+	ReachableAddrs []ma.Multiaddr
+	// Peers describes the connections to the replicators of the threads.
+	Peers map[peer.ID]PeerConnectivity
+	// HolePunch counts the upgrades of relayed connections to direct ones.
+	HolePunch HolePunchStatus
+}
+
+// PeerConnectivity describes the connections to a peer.
+type PeerConnectivity struct {
+	// Connected tells whether the host has any open connection to the peer.
+	Connected bool
+	// Direct tells whether any connection to the peer isn't relayed.
+	Direct bool
+	// Relays are the relays of the circuits in use to reach the peer.
+	Relays []peer.ID
+}
+
+// HolePunchStatus describes the attempts to replace relayed connections with direct ones.
+// An attempt is counted for every peer first reached over a relay, and a success once
+// the peer is connected directly. Counters are kept since the host start.
+type HolePunchStatus struct {
+	// Attempts is the number of peers first reached over a relay.
+	Attempts int
+	// Successes is the number of those peers later connected directly.
+	Successes int
+}
+
+// Rate returns the share of attempts ending with a direct connection, or zero if there
+// were no attempts.
+func (s HolePunchStatus) Rate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Attempts)
+}
@@ -0,0 +1,27 @@
+package net
+
+import (
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DeadLetter describes a record of a thread the app failed to handle, see app.App.HandleNetRecord.
+// The log head is kept before the record, so it's handled again once received from peers,
+// until the attempts are exhausted. The record is then skipped and added to the log
+// without being handled, and kept as a dead letter until replayed or discarded.
+type DeadLetter struct {
+	// LogID is the log of the record.
+	LogID peer.ID
+	// RecordID is the record.
+	RecordID cid.Cid
+	// Attempts is the number of failed attempts to handle the record.
+	Attempts int
+	// Err is the error of the last attempt.
+	Err string
+	// FailedAt is the time of the last attempt.
+	FailedAt time.Time
+	// Skipped tells whether the attempts were exhausted and the log advanced past the record.
+	Skipped bool
+}
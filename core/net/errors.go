@@ -0,0 +1,45 @@
+package net
+
+import "errors"
+
+var (
+	// ErrUntrustedLog indicates that a log key doesn't match the thread trust anchor.
+	ErrUntrustedLog = errors.New("log key doesn't match the trust anchor")
+
+	// ErrTokenChallengeNotFound indicates that a token challenge wasn't issued, expired, or was already redeemed.
+	ErrTokenChallengeNotFound = errors.New("token challenge not found")
+
+	// ErrTokenRevoked indicates a token revoked with RevokeToken.
+	ErrTokenRevoked = errors.New("thread token revoked")
+
+	// ErrIdentityRevoked indicates an identity revoked with RevokeIdentity.
+	ErrIdentityRevoked = errors.New("identity revoked")
+
+	// ErrNotReplicated indicates a thread whose records aren't held by any of its peers.
+	ErrNotReplicated = errors.New("thread is not replicated")
+
+	// ErrDeadLetterNotFound indicates a record which isn't a dead letter of the thread.
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrKeyNotRecovered indicates that the shares collected from the recovery peers
+	// didn't reassemble the escrowed thread key.
+	ErrKeyNotRecovered = errors.New("thread key not recovered")
+
+	// ErrLogHandedOff indicates a log which was sealed for a handoff to another peer.
+	ErrLogHandedOff = errors.New("log was handed off")
+
+	// ErrInvalidHandoff indicates a handoff with a seal or key not matching the log.
+	ErrInvalidHandoff = errors.New("invalid log handoff")
+
+	// ErrInvalidInvite indicates an invite which can't be decoded or has an invalid signature.
+	ErrInvalidInvite = errors.New("invalid invite")
+
+	// ErrInviteExpired indicates an invite accepted after its expiration.
+	ErrInviteExpired = errors.New("invite expired")
+
+	// ErrInviteRedeemed indicates a single-use invite which was already accepted, or isn't known to the inviter.
+	ErrInviteRedeemed = errors.New("invite already redeemed")
+
+	// ErrQuotaExceeded indicates that records don't fit into the storage quota of a thread or log.
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+)
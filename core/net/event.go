@@ -17,13 +17,13 @@ type Event interface {
 
 	// GetHeader loads and optionally decrypts the event header.
 	// If no key is given, the header time and key methods will return an error.
-	GetHeader(context.Context, format.DAGService, crypto.DecryptionKey) (EventHeader, error)
+	GetHeader(context.Context, format.DAGService, crypto.Decrypter) (EventHeader, error)
 
 	// BodyID returns the cid of the event body.
 	BodyID() cid.Cid
 
 	// GetBody loads and optionally decrypts the event body.
-	GetBody(context.Context, format.DAGService, crypto.DecryptionKey) (format.Node, error)
+	GetBody(context.Context, format.DAGService, crypto.Decrypter) (format.Node, error)
 }
 
 // EventHeader is the format of the event's header object
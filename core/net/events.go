@@ -0,0 +1,112 @@
+package net
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// EventType is the type of a network lifecycle event.
+type EventType int
+
+const (
+	// ThreadAdded is emitted when a thread is created, added or imported.
+	ThreadAdded EventType = iota
+	// ThreadDeleted is emitted when a thread deletion has finished.
+	ThreadDeleted
+	// LogAdded is emitted when a log is added to a thread, either created by
+	// the host or received from peers.
+	LogAdded
+	// ReplicatorAdded is emitted when PeerID was added as a thread replicator.
+	ReplicatorAdded
+	// PullCompleted is emitted when records of a thread were pulled. PeerID is
+	// undefined if all the thread peers were pulled.
+	PullCompleted
+	// PullFailed is emitted when pulling records of a thread failed with Err.
+	PullFailed
+	// PeerConnected is emitted when the host connects to PeerID. The event isn't
+	// related to a thread, so it's not delivered to subscriptions filtered by threads.
+	PeerConnected
+	// HeadsChanged is emitted when the heads of log LogID advanced to RecordID, either
+	// by records created by the host or received from PeerID.
+	HeadsChanged
+	// PushFailed is emitted when pushing records up to RecordID to PeerID failed with Err.
+	// The records are queued for redelivery.
+	PushFailed
+	// RecordRejected is emitted when records of log LogID received from PeerID were
+	// rejected with Err, e.g., for a bad signature or by an accept hook.
+	RecordRejected
+	// ReplicatorRemoved is emitted when PeerID was removed from the thread replicators.
+	ReplicatorRemoved
+	// LinkedThreadUpdated is emitted when the heads of log LogID of the thread LinkedID, which
+	// is linked from the thread, advanced to RecordID. See Net.LinkThread.
+	LinkedThreadUpdated
+	// QuotaWarning is emitted when the records stored of a thread, or of log LogID if set,
+	// exceeded a share of their quota, see net.QuotaWarningRatio. Further records are
+	// rejected once the quota is used up.
+	QuotaWarning
+)
+
+var eventTypeNames = map[EventType]string{
+	ThreadAdded:         "ThreadAdded",
+	ThreadDeleted:       "ThreadDeleted",
+	LogAdded:            "LogAdded",
+	ReplicatorAdded:     "ReplicatorAdded",
+	PullCompleted:       "PullCompleted",
+	PullFailed:          "PullFailed",
+	PeerConnected:       "PeerConnected",
+	HeadsChanged:        "HeadsChanged",
+	PushFailed:          "PushFailed",
+	RecordRejected:      "RecordRejected",
+	ReplicatorRemoved:   "ReplicatorRemoved",
+	LinkedThreadUpdated: "LinkedThreadUpdated",
+	QuotaWarning:        "QuotaWarning",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// LifecycleEvent is a network lifecycle event. Fields which don't apply to the event type are left empty.
+type LifecycleEvent struct {
+	Type     EventType
+	Time     time.Time
+	ThreadID thread.ID
+	LinkedID thread.ID
+	LogID    peer.ID
+	PeerID   peer.ID
+	RecordID cid.Cid
+	Err      error
+}
+
+// String returns a single line description of the event, listing the fields which are set.
+func (e LifecycleEvent) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", e.Time.Format(time.RFC3339Nano), e.Type)
+	if e.ThreadID.Defined() {
+		fmt.Fprintf(&b, " thread=%s", e.ThreadID)
+	}
+	if e.LinkedID.Defined() {
+		fmt.Fprintf(&b, " linked=%s", e.LinkedID)
+	}
+	if e.LogID != "" {
+		fmt.Fprintf(&b, " log=%s", e.LogID)
+	}
+	if e.PeerID != "" {
+		fmt.Fprintf(&b, " peer=%s", e.PeerID)
+	}
+	if e.RecordID.Defined() {
+		fmt.Fprintf(&b, " record=%s", e.RecordID)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, " err=%q", e.Err.Error())
+	}
+	return b.String()
+}
@@ -0,0 +1,21 @@
+package net
+
+import "github.com/textileio/go-threads/core/thread"
+
+// LinkKind is the relationship of a thread to a linked thread.
+type LinkKind string
+
+const (
+	// LinkChild links a thread to its child thread.
+	LinkChild LinkKind = "child"
+	// LinkMount links a thread to a thread mounted into it, e.g., a shared dataset.
+	LinkMount LinkKind = "mount"
+)
+
+// ThreadLink is a link from a thread to another thread, see Net.LinkThread.
+type ThreadLink struct {
+	// ID of the linked thread.
+	ID thread.ID
+	// Kind of the link.
+	Kind LinkKind
+}
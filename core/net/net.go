@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipld-format"
@@ -22,6 +23,281 @@ type Net interface {
 
 	// Host provides a network identity.
 	Host() host.Host
+
+	// Topics returns the threads with joined pubsub topics.
+	Topics(ctx context.Context) ([]thread.ID, error)
+
+	// SyncStatus returns the outbound record delivery status for every peer
+	// with records pending or recently pushed.
+	SyncStatus(ctx context.Context) (map[peer.ID]PeerSyncStatus, error)
+
+	// PublishStatus returns the counters of records published over pubsub.
+	PublishStatus(ctx context.Context) (PublishStatus, error)
+
+	// ValidationStatus returns the counters of records received over pubsub by thread topic.
+	ValidationStatus(ctx context.Context) (map[thread.ID]TopicValidationStatus, error)
+
+	// CompressionStatus returns the counters of compressed messages exchanged with peers.
+	CompressionStatus(ctx context.Context) (CompressionStatus, error)
+
+	// CallQueueStatus returns the status of the queue of pulls scheduled from peers for
+	// every kind of pull, i.e., "logs" and "records".
+	CallQueueStatus(ctx context.Context) (map[string]CallQueueStatus, error)
+
+	// RPCStatus returns the counters of gRPC calls exchanged with peers by full method name.
+	RPCStatus(ctx context.Context) (map[string]RPCStatus, error)
+
+	// Connectivity returns how the host is reached by peers, i.e., whether it's publicly
+	// dialable and the relays in use to reach thread replicators.
+	Connectivity(ctx context.Context) (ConnectivityStatus, error)
+
+	// RecentEvents returns the latest lifecycle events of the host, oldest first. Events are
+	// kept in a bounded ring, see net.Config.EventLogSize.
+	RecentEvents(ctx context.Context) ([]LifecycleEvent, error)
+
+	// DumpEvents writes the recent events to w, one per line.
+	DumpEvents(ctx context.Context, w io.Writer) error
+
+	// RemoveReplicator stops replicating a thread on a host added with AddReplicator.
+	// The host address is removed from the managed logs, which are pushed to the remaining
+	// peers, and the host is no longer pulled.
+	RemoveReplicator(ctx context.Context, id thread.ID, pid peer.ID, opts ...ThreadOption) error
+
+	// PeerCapabilities returns the optional services advertised by a peer, e.g., whether
+	// it relays threads it can't read, so it can be added as a replicator with the service key only.
+	PeerCapabilities(ctx context.Context, pid peer.ID) (Capabilities, error)
+
+	// SetPeerLocality tags a peer with its region and zone. The host prefers replicators in its
+	// own region for pushes and pulls, see net.TopologyConfig. An empty locality removes the tag.
+	SetPeerLocality(ctx context.Context, pid peer.ID, loc Locality) error
+
+	// PeerReputations returns the sync track record of every peer the host has exchanged
+	// edges with or pulled records from. Banned peers are skipped by pulls, and peers with
+	// failed calls are backed off from, see net.Config.PeerBanThreshold.
+	PeerReputations(ctx context.Context) (map[peer.ID]PeerReputation, error)
+
+	// ResetPeerReputation forgets the track record of a peer, lifting a ban.
+	ResetPeerReputation(ctx context.Context, pid peer.ID) error
+
+	// RevokeToken revokes a token issued by the host, so it's no longer accepted.
+	RevokeToken(ctx context.Context, token thread.Token) error
+
+	// RevokeIdentity revokes all tokens of an identity, and refuses to issue it new ones. The revocation
+	// expires after ttl, zero means never. It's propagated to the peers of the single-writer threads
+	// written by the host, so a compromised identity is locked out of them too.
+	RevokeIdentity(ctx context.Context, identity thread.PubKey, ttl time.Duration) error
+
+	// RestoreIdentity lifts a revocation of an identity, also in the threads it was propagated to.
+	RestoreIdentity(ctx context.Context, identity thread.PubKey) error
+
+	// EscrowThreadKey splits the key of a thread into Shamir shares, and deposits one with each of
+	// the recovery peers for the recovery identity. Any threshold of the peers can return their shares
+	// to a holder of the identity, so the key can be recovered with RecoverThreadKey, e.g., after losing
+	// the local keystore or the host key.
+	EscrowThreadKey(ctx context.Context, id thread.ID, peers []peer.ID, threshold int, recovery thread.Identity, opts ...ThreadOption) error
+
+	// RecoverThreadKey reassembles a thread key escrowed for the recovery identity from the shares of the recovery peers.
+	RecoverThreadKey(ctx context.Context, id thread.ID, peers []peer.ID, recovery thread.Identity) (thread.Key, error)
+
+	// SubscribePeer subscribes to new records of threads at a peer instead of pulling them. At least
+	// one thread filter is required. Received records are added to the threads before delivery.
+	SubscribePeer(ctx context.Context, pid peer.ID, opts ...SubOption) (<-chan ThreadRecord, error)
+
+	// HandoffLog transfers the write ownership of a log to another peer, e.g., a new device of the
+	// same user, which continues the log from its final head. The host appends a handoff record
+	// naming the new owner and stops writing to the log. The log key is kept until the new owner
+	// acknowledges the handoff, so a failed handoff is retried by calling HandoffLog again.
+	HandoffLog(ctx context.Context, id thread.ID, lid peer.ID, newOwner peer.ID, opts ...ThreadOption) error
+
+	// AwaitRecord returns a record of a thread once it has been added locally, or right away if it's
+	// already known, so clients can wait for a response record without polling GetRecord.
+	AwaitRecord(ctx context.Context, id thread.ID, rid cid.Cid, opts ...ThreadOption) (Record, error)
+
+	// GetLogForIdentity returns the log of a thread written by identity.
+	GetLogForIdentity(ctx context.Context, id thread.ID, identity thread.PubKey, opts ...ThreadOption) (thread.LogInfo, error)
+
+	// ListIdentities returns the identities writing to the logs of a thread, e.g., to render
+	// record authors. Logs without records written by an unknown identity are omitted.
+	ListIdentities(ctx context.Context, id thread.ID, opts ...ThreadOption) (map[peer.ID]thread.PubKey, error)
+
+	// ThreadLocks returns the threads with held or awaited update locks, e.g., for
+	// debugging operations which are stuck behind a deadlocked update.
+	ThreadLocks(ctx context.Context) (map[thread.ID]ThreadLockStatus, error)
+
+	// UpdateConfig applies the sync tuning at runtime, e.g., pull and queue poll intervals,
+	// without restarting the host. Zero fields are reset to the defaults.
+	UpdateConfig(ctx context.Context, cfg SyncConfig) error
+
+	// PullStatus returns the inbound sync progress of every log of a thread.
+	PullStatus(ctx context.Context, id thread.ID, opts ...ThreadOption) (map[peer.ID]LogPullStatus, error)
+
+	// SampleRecords deterministically picks up to k records of a thread using an
+	// auditor-provided nonce as a seed, and returns them with inclusion proofs.
+	SampleRecords(ctx context.Context, id thread.ID, nonce []byte, k int, opts ...ThreadOption) (ThreadSample, error)
+
+	// Records returns the records of all logs of a thread stored on the host, oldest first. Records
+	// are interleaved in the order of their lamport hints, i.e., their clocks raised to follow the
+	// previous records of the log, or their positions in the logs for records without clocks, then
+	// of their log IDs, so the order is the same on hosts holding the same records. The channel is closed once all records are returned, or ctx is done.
+	Records(ctx context.Context, id thread.ID, opts ...RecordsOption) (<-chan ThreadRecord, error)
+
+	// LinkThread declares a link from a thread to another thread held by the host, e.g., to a child
+	// thread or a thread mounted into it. PullThread pulls linked threads along with the thread, and
+	// head changes of linked threads are emitted as LinkedThreadUpdated events of the thread. Links
+	// are kept on the host, linking a thread again changes the link kind.
+	LinkThread(ctx context.Context, id, linked thread.ID, kind LinkKind, opts ...ThreadOption) error
+
+	// UnlinkThread removes the link from a thread to a linked thread.
+	UnlinkThread(ctx context.Context, id, linked thread.ID, opts ...ThreadOption) error
+
+	// ThreadLinks returns the links declared from a thread, sorted by linked thread ID.
+	ThreadLinks(ctx context.Context, id thread.ID, opts ...ThreadOption) ([]ThreadLink, error)
+
+	// SetThreadACL replaces the ACL of a thread, which restricts the record bodies the host serves
+	// to replicators. Restricted records aren't published over pubsub, and peers receiving them
+	// without bodies keep the envelopes only. Restricted bodies are kept out of the blockstore
+	// served over bitswap. The ACL is kept on the host, an empty one restricts nothing, and it
+	// doesn't bind readers, which may serve the bodies they received.
+	SetThreadACL(ctx context.Context, id thread.ID, acl ThreadACL, opts ...ThreadOption) error
+
+	// ThreadACL returns the ACL of a thread.
+	ThreadACL(ctx context.Context, id thread.ID, opts ...ThreadOption) (ThreadACL, error)
+
+	// MintCapability returns a capability granting rights on a thread hosted by the host, which is
+	// restricted by the caveats. The holder attaches it to the requests to the host, and may restrict
+	// it further before delegating it, see thread.Capability.
+	MintCapability(ctx context.Context, id thread.ID, caveats []thread.Caveat, opts ...ThreadOption) (thread.Capability, error)
+
+	// AddCapability keeps a capability granted by a host, which is attached to the requests for
+	// its thread from then on.
+	AddCapability(ctx context.Context, capability thread.Capability) error
+
+	// RevokeCapabilities invalidates all capabilities minted for a thread.
+	RevokeCapabilities(ctx context.Context, id thread.ID, opts ...ThreadOption) error
+
+	// SetThreadMetadata signs the metadata of a thread with the log of the host, and replicates
+	// it to the thread peers. The latest metadata set by any thread log wins.
+	SetThreadMetadata(ctx context.Context, id thread.ID, md thread.Metadata, opts ...ThreadOption) (thread.Metadata, error)
+
+	// GetThreadMetadata returns the metadata of a thread, empty if none is set.
+	GetThreadMetadata(ctx context.Context, id thread.ID, opts ...ThreadOption) (thread.Metadata, error)
+
+	// DeadLetters returns the records of a thread the app failed to handle.
+	DeadLetters(ctx context.Context, id thread.ID, opts ...ThreadOption) ([]DeadLetter, error)
+
+	// ReplayDeadLetter hands a skipped record to the app again, and drops it from the dead
+	// letters once handled.
+	ReplayDeadLetter(ctx context.Context, id thread.ID, rid cid.Cid, opts ...ThreadOption) error
+
+	// DiscardDeadLetter drops a record from the dead letters. A record which wasn't skipped
+	// yet gets all attempts again.
+	DiscardDeadLetter(ctx context.Context, id thread.ID, rid cid.Cid, opts ...ThreadOption) error
+
+	// VerifyThread walks every log of a thread from the heads to genesis, verifying record signatures,
+	// prev links and the availability of record blocks in the local blockstore. With WithRepair,
+	// damaged logs are re-fetched from the thread peers, and damage which was fixed is marked repaired.
+	VerifyThread(ctx context.Context, id thread.ID, opts ...ThreadOption) (ThreadVerification, error)
+
+	// ExportVerificationBundle returns the log verification keys of a thread along with
+	// the service key hash, which can be provisioned to auditors and gateways as a trust anchor.
+	ExportVerificationBundle(ctx context.Context, id thread.ID, opts ...ThreadOption) (VerificationBundle, error)
+
+	// ImportVerificationBundle trusts a bundle exported by another host. Afterwards, logs of the
+	// thread listed in the bundle are only accepted from peers with the bundled keys, and
+	// other logs only if they're signed by their owner.
+	ImportVerificationBundle(ctx context.Context, bundle VerificationBundle, opts ...ThreadOption) error
+
+	// AddAttachment stores binary data as a separate DAG, which may be linked from the thread record bodies.
+	AddAttachment(ctx context.Context, id thread.ID, r io.Reader, opts ...AttachmentOption) (cid.Cid, error)
+
+	// GetAttachment returns a reader of the attachment data.
+	// Chunks missing locally are fetched from the network while reading.
+	GetAttachment(ctx context.Context, id thread.ID, aid cid.Cid, opts ...AttachmentOption) (io.Reader, error)
+
+	// CreateCheckpoint creates a record with the application-provided state summary of a thread
+	// in the host's log, and marks it as the log checkpoint.
+	CreateCheckpoint(ctx context.Context, id thread.ID, state format.Node, opts ...ThreadOption) (ThreadRecord, error)
+
+	// CreateRecords creates and adds a chain of new records with bodies to a thread by id.
+	// The records are created atomically in the host's log and pushed to peers in one batch.
+	CreateRecords(ctx context.Context, id thread.ID, bodies []format.Node, opts ...ThreadOption) ([]ThreadRecord, error)
+
+	// CreateRecordsAtomic creates a chain of new records like CreateRecords, but either all records become
+	// part of the host's log and are pushed to peers, or none do. Failed deliveries are retried in the background.
+	CreateRecordsAtomic(ctx context.Context, id thread.ID, bodies []format.Node, opts ...ThreadOption) ([]ThreadRecord, error)
+
+	// CreateRecordAsync creates and adds a new record with body to a thread by id like CreateRecord,
+	// but returns once the record is stored locally. The record is pushed to peers in the background,
+	// and the returned future reports the outcome of the push to each of them.
+	CreateRecordAsync(ctx context.Context, id thread.ID, body format.Node, opts ...ThreadOption) (RecordFuture, error)
+
+	// RecordReplicationStatus returns which thread peers acknowledged persisting a record pushed by the host.
+	// Hosts keep acknowledgements for a while if configured to track them.
+	RecordReplicationStatus(ctx context.Context, id thread.ID, rid cid.Cid, opts ...ThreadOption) (RecordReplication, error)
+
+	// ExportThread writes all records of a thread along with their events, headers and bodies
+	// into a CAR archive. The archive root is a manifest with the log metadata.
+	ExportThread(ctx context.Context, id thread.ID, w io.Writer, opts ...ExportOption) error
+
+	// ImportThread adds a thread from a CAR archive written by ExportThread, which is streamed
+	// rather than loaded into memory. The thread key must be provided with WithThreadKey if the
+	// archive doesn't include keys.
+	ImportThread(ctx context.Context, r io.Reader, opts ...NewThreadOption) (thread.Info, error)
+
+	// CompactThread locally drops the log records which are older than the latest checkpoints.
+	// Pulls from peers are served starting from the checkpoint records afterwards.
+	CompactThread(ctx context.Context, id thread.ID, opts ...ThreadOption) error
+
+	// SetRetentionPolicy replaces the retention policy of a thread, which is enforced right away
+	// and then periodically by a background reaper. A zero policy keeps all records.
+	SetRetentionPolicy(ctx context.Context, id thread.ID, policy RetentionPolicy, opts ...ThreadOption) error
+
+	// ThreadStats reports the records stored of a thread, its retention policy and quota.
+	ThreadStats(ctx context.Context, id thread.ID, opts ...ThreadOption) (ThreadStats, error)
+
+	// SetThreadQuota replaces the byte budget of the records stored of a thread, which overrides
+	// the default quota of the host. Zero restores the default, a negative value lifts the quota.
+	SetThreadQuota(ctx context.Context, id thread.ID, bytes int64, opts ...ThreadOption) error
+
+	// UnloadThread releases the in-memory state of a thread without deleting its data, e.g., to keep
+	// a bounded working set of threads. The thread is loaded again once it's pulled or written to.
+	UnloadThread(ctx context.Context, id thread.ID, opts ...ThreadOption) error
+
+	// ArchiveThread locally drops the block data of a thread, keeping its keys, logs and heads.
+	// A thread peer must hold the records first. Records are fetched again as they're read, pulled
+	// or written to.
+	ArchiveThread(ctx context.Context, id thread.ID, opts ...ThreadOption) error
+
+	// CreateInvite returns a signed invite to a thread, which can be shared with the invitee.
+	// The invite includes the host addresses and the thread keys of the granted role.
+	CreateInvite(ctx context.Context, id thread.ID, opts ...InviteOption) (string, error)
+
+	// AcceptInvite adds the thread of an invite created by another host, which is used
+	// as the bootstrap replicator of the thread.
+	AcceptInvite(ctx context.Context, invite string, opts ...AcceptInviteOption) (thread.Info, error)
+
+	// AddThreads adds existing threads from multiaddresses, like AddThread. Threads shared
+	// with a peer are added over a single connection, and their edges are exchanged with
+	// the peer in packs, so records are pulled only for the threads which diverged.
+	// Use WithThreadKeys to pass the keys of the threads.
+	AddThreads(ctx context.Context, addrs []ma.Multiaddr, opts ...NewThreadOption) ([]thread.Info, error)
+
+	// PullThreads requests new records of the threads from their hosts, like PullThread. Edges
+	// of threads sharing a peer are exchanged in packs, and records are pulled only for the
+	// threads which diverged.
+	PullThreads(ctx context.Context, ids []thread.ID, opts ...ThreadOption) error
+
+	// SubscribeEvents returns a read-only channel of lifecycle events, e.g., threads being
+	// added or pulled. Use WithSubFilter to only receive events of the given threads.
+	// Events are dropped for subscribers which don't keep up with the network.
+	SubscribeEvents(ctx context.Context, opts ...SubOption) (<-chan LifecycleEvent, error)
+
+	// GC removes blocks of records and events which are not reachable from any stored
+	// thread, and returns the number of removed blocks.
+	GC(ctx context.Context) (int, error)
+
+	// BlockStats walks the stored threads and reports the bodies shared between their events.
+	BlockStats(ctx context.Context) (BlockStats, error)
 }
 
 // API is the network interface for thread orchestration.
@@ -35,6 +311,15 @@ type API interface {
 	// CreateThread, AddThread, etc.
 	GetToken(ctx context.Context, identity thread.Identity) (thread.Token, error)
 
+	// GetTokenChallenge returns a challenge for a public key and its expiry. Unlike GetToken, the
+	// challenge can be signed out of band, e.g., by a hardware wallet, and redeemed with
+	// GetTokenWithChallenge before it expires. Challenges are single-use.
+	GetTokenChallenge(ctx context.Context, key thread.PubKey) ([]byte, time.Time, error)
+
+	// GetTokenWithChallenge returns a signed token for a public key given a signature
+	// of a challenge issued by GetTokenChallenge.
+	GetTokenWithChallenge(ctx context.Context, key thread.PubKey, challenge, sig []byte) (thread.Token, error)
+
 	// CreateThread creates and adds a new thread with id and opts.
 	CreateThread(ctx context.Context, id thread.ID, opts ...NewThreadOption) (thread.Info, error)
 
@@ -1,15 +1,25 @@
 package net
 
 import (
+	"time"
+
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/textileio/go-threads/core/thread"
 )
 
 // NewThreadOptions defines options to be used when creating / adding a thread.
 type NewThreadOptions struct {
-	ThreadKey thread.Key
-	LogKey    crypto.Key
-	Token     thread.Token
+	ThreadKey    thread.Key
+	ThreadKeys   map[thread.ID]thread.Key
+	LogKey       crypto.Key
+	Token        thread.Token
+	SingleWriter bool
+	Writer       peer.ID
+	Flags        []string
+	Ephemeral    bool
+	Identity     string
 }
 
 // NewThreadOption specifies new thread options.
@@ -22,6 +32,14 @@ func WithThreadKey(key thread.Key) NewThreadOption {
 	}
 }
 
+// WithThreadKeys sets the keys of threads added at once with Net.AddThreads, by thread ID.
+// Threads missing from the map are added with the WithThreadKey one.
+func WithThreadKeys(keys map[thread.ID]thread.Key) NewThreadOption {
+	return func(args *NewThreadOptions) {
+		args.ThreadKeys = keys
+	}
+}
+
 // WithLogKey is the public or private key used to write log records.
 // If this is just a public key, the service itself won't be able to create records.
 // In other words, all records must be pre-created and added with AddRecord.
@@ -39,10 +57,61 @@ func WithNewThreadToken(t thread.Token) NewThreadOption {
 	}
 }
 
+// WithSingleWriter creates a thread where only the creator's log may contain records.
+// Records and logs of other writers are rejected, so there is nothing to merge.
+// Hosts adding the thread declare its writer with WithThreadWriter.
+func WithSingleWriter() NewThreadOption {
+	return func(args *NewThreadOptions) {
+		args.SingleWriter = true
+	}
+}
+
+// WithThreadFlags sets the flags of a new thread, see thread.Flags. The flags are replicated
+// to hosts adding the thread. Hosts adding the thread may pass the flags they expect,
+// so joining a thread with other flags fails.
+func WithThreadFlags(flags ...string) NewThreadOption {
+	return func(args *NewThreadOptions) {
+		args.Flags = append(args.Flags, flags...)
+	}
+}
+
+// WithThreadWriter declares the only log which may contain records of a single-writer thread being added.
+// The host doesn't create its own log in the thread, unless it's given the writer's log key.
+func WithThreadWriter(lid peer.ID) NewThreadOption {
+	return func(args *NewThreadOptions) {
+		args.Writer = lid
+	}
+}
+
+// WithEphemeral keeps the thread in memory only, e.g., for high-rate transient data like
+// cursors and presence. Its records and logs never reach the blockstore or logstore, so
+// they're lost on shutdown, unless peers replicate them. Hosts adding or importing the
+// thread decide whether to keep it in memory too.
+func WithEphemeral() NewThreadOption {
+	return func(args *NewThreadOptions) {
+		args.Ephemeral = true
+	}
+}
+
+// WithNewThreadIdentity makes the host own its log in the thread as a named identity
+// held by its keystore, instead of the host identity. A token identity takes precedence.
+func WithNewThreadIdentity(name string) NewThreadOption {
+	return func(args *NewThreadOptions) {
+		args.Identity = name
+	}
+}
+
 // ThreadOptions defines options for interacting with a thread.
 type ThreadOptions struct {
-	Token    thread.Token
-	APIToken Token
+	Token      thread.Token
+	APIToken   Token
+	Extensions map[string][]byte
+	// WriteQuorum is the number of peers which have to acknowledge a new record.
+	WriteQuorum int
+	// Repair makes VerifyThread re-fetch damaged records from replicators.
+	Repair bool
+	// Identity is the name of a keystore identity acting in the thread.
+	Identity string
 }
 
 // ThreadOption specifies thread options.
@@ -55,6 +124,14 @@ func WithThreadToken(t thread.Token) ThreadOption {
 	}
 }
 
+// WithIdentity makes the host create records as a named identity held by its keystore,
+// instead of the host identity. A token identity takes precedence.
+func WithIdentity(name string) ThreadOption {
+	return func(args *ThreadOptions) {
+		args.Identity = name
+	}
+}
+
 // WithAPIToken provides additional authorization for interacting
 // with a thread as an application.
 // For example, this is used by a db.DB to ensure that only it can
@@ -65,10 +142,62 @@ func WithAPIToken(t Token) ThreadOption {
 	}
 }
 
+// WithRecordExtensions attaches signed extension fields to a new record.
+// Extensions are only delivered to peers supporting the v2 record envelope.
+func WithRecordExtensions(fields map[string][]byte) ThreadOption {
+	return func(args *ThreadOptions) {
+		args.Extensions = mergeExtensions(args.Extensions, fields)
+	}
+}
+
+// WithRecordAnnotations attaches small signed key/value metadata to a new record, e.g.,
+// the content type or references to records of other threads. Annotations are carried
+// as record extensions, so they can be read without decrypting the body, and they're
+// bound by MaxAnnotationsSize.
+func WithRecordAnnotations(annotations map[string]string) ThreadOption {
+	return func(args *ThreadOptions) {
+		fields := make(map[string][]byte, len(annotations))
+		for k, v := range annotations {
+			fields[AnnotationPrefix+k] = []byte(v)
+		}
+		args.Extensions = mergeExtensions(args.Extensions, fields)
+	}
+}
+
+// mergeExtensions returns the extension fields with the other fields added. The passed maps aren't modified.
+func mergeExtensions(fields, other map[string][]byte) map[string][]byte {
+	merged := make(map[string][]byte, len(fields)+len(other))
+	for k, v := range fields {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+// WithWriteQuorum makes CreateRecord block until n of the thread peers the new record is pushed to
+// acknowledged persisting it. The record is stored locally either way, CreateRecord fails if it's
+// pushed to fewer peers, or the quorum isn't reached before the context is done.
+func WithWriteQuorum(n int) ThreadOption {
+	return func(args *ThreadOptions) {
+		args.WriteQuorum = n
+	}
+}
+
+// WithRepair makes VerifyThread re-fetch damaged log segments from the thread peers.
+func WithRepair() ThreadOption {
+	return func(args *ThreadOptions) {
+		args.Repair = true
+	}
+}
+
 // SubOptions defines options for a thread subscription.
 type SubOptions struct {
-	ThreadIDs thread.IDSlice
-	Token     thread.Token
+	ThreadIDs   thread.IDSlice
+	LogIDs      []peer.ID
+	Annotations map[string]string
+	Token       thread.Token
 }
 
 // SubOption is a thread subscription option.
@@ -82,9 +211,200 @@ func WithSubFilter(id thread.ID) SubOption {
 	}
 }
 
+// WithSubLogFilter restricts the subscription to a given log.
+// Use this option multiple times to subscribe to multiple logs.
+func WithSubLogFilter(id peer.ID) SubOption {
+	return func(args *SubOptions) {
+		args.LogIDs = append(args.LogIDs, id)
+	}
+}
+
+// WithSubAnnotationFilter restricts the subscription to records annotated with the key.
+// If the value is not empty, the annotation must have the value as well. Use this option
+// multiple times to require multiple annotations.
+func WithSubAnnotationFilter(key, value string) SubOption {
+	return func(args *SubOptions) {
+		if args.Annotations == nil {
+			args.Annotations = make(map[string]string)
+		}
+		args.Annotations[key] = value
+	}
+}
+
 // WithSubToken provides authorization for a subscription.
 func WithSubToken(t thread.Token) SubOption {
 	return func(args *SubOptions) {
 		args.Token = t
 	}
 }
+
+// RecordsOptions defines options for iterating over the records of a thread.
+type RecordsOptions struct {
+	Token   thread.Token
+	Since   cid.Cid
+	Limit   int
+	Reverse bool
+}
+
+// RecordsOption specifies record iteration options.
+type RecordsOption func(*RecordsOptions)
+
+// WithRecordsToken provides authorization for reading the records.
+func WithRecordsToken(t thread.Token) RecordsOption {
+	return func(args *RecordsOptions) {
+		args.Token = t
+	}
+}
+
+// WithRecordsSince starts the iteration right after the given record.
+func WithRecordsSince(rid cid.Cid) RecordsOption {
+	return func(args *RecordsOptions) {
+		args.Since = rid
+	}
+}
+
+// WithRecordsLimit returns at most limit records. Zero means no limit.
+func WithRecordsLimit(limit int) RecordsOption {
+	return func(args *RecordsOptions) {
+		args.Limit = limit
+	}
+}
+
+// WithRecordsReverse iterates from the newest records to the oldest ones.
+func WithRecordsReverse() RecordsOption {
+	return func(args *RecordsOptions) {
+		args.Reverse = true
+	}
+}
+
+// AttachmentOptions defines options for adding / getting attachments.
+type AttachmentOptions struct {
+	Token thread.Token
+	Plain bool
+}
+
+// AttachmentOption specifies attachment options.
+type AttachmentOption func(*AttachmentOptions)
+
+// WithAttachmentToken provides authorization for accessing thread attachments.
+func WithAttachmentToken(t thread.Token) AttachmentOption {
+	return func(args *AttachmentOptions) {
+		args.Token = t
+	}
+}
+
+// WithPlainAttachment stores the attachment data as is.
+// By default, attachment data is encrypted with the thread read key.
+func WithPlainAttachment() AttachmentOption {
+	return func(args *AttachmentOptions) {
+		args.Plain = true
+	}
+}
+
+// ExportOptions defines options for exporting a thread.
+type ExportOptions struct {
+	Token thread.Token
+	Keys  bool
+}
+
+// ExportOption specifies thread export options.
+type ExportOption func(*ExportOptions)
+
+// WithExportToken provides authorization for exporting a thread.
+func WithExportToken(t thread.Token) ExportOption {
+	return func(args *ExportOptions) {
+		args.Token = t
+	}
+}
+
+// WithExportKeys includes the thread key and log private keys in the archive manifest.
+// Anyone holding such an archive is able to read and write the thread.
+func WithExportKeys() ExportOption {
+	return func(args *ExportOptions) {
+		args.Keys = true
+	}
+}
+
+// InviteRole is the role granted by a thread invite.
+type InviteRole int32
+
+const (
+	// InviteMember joins the thread with the full thread key, so the invitee can read and write records.
+	InviteMember InviteRole = iota
+	// InviteReplicator joins the thread with the service key only, so the invitee
+	// stores and serves records without being able to read them.
+	InviteReplicator
+)
+
+// InviteOptions defines options for creating a thread invite.
+type InviteOptions struct {
+	Token     thread.Token
+	Role      InviteRole
+	SingleUse bool
+	TTL       time.Duration
+	Recipient thread.PubKey
+}
+
+// InviteOption specifies thread invite options.
+type InviteOption func(*InviteOptions)
+
+// WithInviteToken provides authorization for inviting to a thread.
+func WithInviteToken(t thread.Token) InviteOption {
+	return func(args *InviteOptions) {
+		args.Token = t
+	}
+}
+
+// WithInviteRole sets the role granted by the invite. By default, invitees join as members.
+func WithInviteRole(role InviteRole) InviteOption {
+	return func(args *InviteOptions) {
+		args.Role = role
+	}
+}
+
+// WithSingleUseInvite keeps the thread keys on the inviting host until the invite
+// is accepted for the first time, so it can't be accepted again.
+func WithSingleUseInvite() InviteOption {
+	return func(args *InviteOptions) {
+		args.SingleUse = true
+	}
+}
+
+// WithInviteTTL makes the invite expire after a duration.
+func WithInviteTTL(ttl time.Duration) InviteOption {
+	return func(args *InviteOptions) {
+		args.TTL = ttl
+	}
+}
+
+// WithInviteRecipient encrypts the thread keys of the invite for a recipient.
+// Otherwise, anyone holding the invite is able to accept it.
+func WithInviteRecipient(pk thread.PubKey) InviteOption {
+	return func(args *InviteOptions) {
+		args.Recipient = pk
+	}
+}
+
+// AcceptInviteOptions defines options for accepting a thread invite.
+type AcceptInviteOptions struct {
+	Token    thread.Token
+	Identity thread.Identity
+}
+
+// AcceptInviteOption specifies options for accepting a thread invite.
+type AcceptInviteOption func(*AcceptInviteOptions)
+
+// WithAcceptToken provides authorization for adding the invited thread.
+func WithAcceptToken(t thread.Token) AcceptInviteOption {
+	return func(args *AcceptInviteOptions) {
+		args.Token = t
+	}
+}
+
+// WithAcceptIdentity decrypts invites sent to the identity's public key.
+// By default, invites are decrypted with the host key.
+func WithAcceptIdentity(identity thread.Identity) AcceptInviteOption {
+	return func(args *AcceptInviteOptions) {
+		args.Identity = identity
+	}
+}
@@ -0,0 +1,47 @@
+package net
+
+// ProtocolVersion is the version of the thread protocol spoken by the host, negotiated with
+// peers along with the optional Features. Peers predating the negotiation report version zero.
+const ProtocolVersion = 1
+
+// Optional protocol features, which are only used with peers advertising them.
+const (
+	// FeatureCompression is the support of compressed messages and record bodies.
+	FeatureCompression = "compression"
+	// FeatureSubscribe is the support of streaming subscriptions to new records, see Net.SubscribePeer.
+	FeatureSubscribe = "subscribe"
+	// FeatureCapabilityTokens is the support of thread capabilities attached to requests.
+	FeatureCapabilityTokens = "capability-tokens"
+	// FeatureMultiHead is the support of forked logs with more than one head.
+	FeatureMultiHead = "multi-head"
+	// FeatureEnvelopeV2 is the support of v2 record envelopes carrying signed extensions.
+	FeatureEnvelopeV2 = "envelope-v2"
+)
+
+// Features lists the optional protocol features implemented by the host. Hosts only advertise
+// the ones enabled by their config, e.g., compression.
+var Features = []string{
+	FeatureCompression,
+	FeatureSubscribe,
+	FeatureCapabilityTokens,
+	FeatureMultiHead,
+	FeatureEnvelopeV2,
+}
+
+// ProtocolInfo is the protocol version and features negotiated with a peer.
+type ProtocolInfo struct {
+	// Version is the protocol version of the peer, zero if it predates the negotiation.
+	Version int
+	// Features lists the optional protocol features the peer supports.
+	Features []string
+}
+
+// Supports returns whether the peer supports the protocol feature.
+func (p ProtocolInfo) Supports(feature string) bool {
+	for _, f := range p.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,32 @@ package net
 
 import (
 	"context"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipld-format"
 	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+const (
+	// AnnotationPrefix namespaces the record extension fields carrying annotations.
+	AnnotationPrefix = "annotation:"
+
+	// MaxAnnotationsSize is the byte limit on the keys and values of the annotations of a record.
+	MaxAnnotationsSize = 1024
+
+	// ClockExtension is the record extension field carrying the logical timestamp of the record.
+	ClockExtension = "clock"
+
+	// SeqExtension is the record extension field carrying the position of the record in its log.
+	SeqExtension = "seq"
+
+	// HandoffExtension is the record extension field carrying the new owner of a log, see
+	// Net.HandoffLog. The record carrying it is the last one added by the previous owner.
+	HandoffExtension = "handoff"
 )
 
 // Record is the most basic component of a log.
@@ -31,6 +51,88 @@ type Record interface {
 
 	// Verify returns a nil error if the node signature is valid.
 	Verify(key crypto.PubKey) error
+
+	// Annotations returns the metadata attached to the record by its author, see
+	// WithRecordAnnotations. It's carried next to the record, so reading it doesn't
+	// require the read key.
+	Annotations() map[string]string
+
+	// Clock returns the logical timestamp of the record, see RecordClock. It's covered by the
+	// extensions signature, and zero if the author didn't assign one, e.g., running an older
+	// version, or the record was relayed by a peer not supporting extensions.
+	Clock() uint64
+
+	// Seq returns the position of the record in its log, starting at one with the first record.
+	// It's covered by the extensions signature, and zero if the author didn't assign one, the log
+	// has records without one, or the record was relayed by a peer not supporting extensions.
+	Seq() uint64
+}
+
+// HasAnnotations returns whether the record carries all the annotations.
+// An empty value matches any value of the annotation.
+func HasAnnotations(rec Record, annotations map[string]string) bool {
+	if len(annotations) == 0 {
+		return true
+	}
+	have := rec.Annotations()
+	for k, v := range annotations {
+		if hv, ok := have[k]; !ok || (v != "" && hv != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExtendedRecord is a record which may carry extension fields, e.g., timestamps or codecs.
+// Extensions are signed by the log key, but aren't a part of the record node, so they
+// don't change record IDs and are dropped for peers which only support the v1 envelope.
+type ExtendedRecord interface {
+	Record
+
+	// Extensions returns the record extension fields.
+	Extensions() map[string][]byte
+}
+
+// CommitHook inspects the decoded body of a record before it's committed to a thread,
+// returning an error vetoes the record. Hooks run for records created locally and for
+// records received from peers, provided the host has the thread read key.
+type CommitHook func(ctx context.Context, id thread.ID, body format.Node, author thread.PubKey) error
+
+// AcceptHook inspects the author of a record received from peers before its body is processed,
+// returning an error rejects the record. Unlike commit hooks, accept hooks don't need the thread
+// read key, and with header sync, bodies of rejected records aren't downloaded at all.
+type AcceptHook func(ctx context.Context, id thread.ID, lid peer.ID, author thread.PubKey) error
+
+// PersistHooks are called around the persistence of the records of every thread, created
+// locally or received from peers, e.g., for audit logging, content indexing or scanning.
+// Hooks are called in log order under the thread lock, so they shouldn't block for long.
+// Nil hooks are skipped.
+type PersistHooks struct {
+	// OnBeforePersist is called before a record is added to its log. Returning an error
+	// rejects the record. Records received from peers are processed again once received.
+	OnBeforePersist func(ctx context.Context, rec ThreadRecord) error
+	// OnAfterPersist is called once a record was added to its log.
+	OnAfterPersist func(ctx context.Context, rec ThreadRecord)
+	// OnBroadcast is called before a record created or added by the host is pushed to the
+	// thread peers.
+	OnBroadcast func(ctx context.Context, rec ThreadRecord, peers []peer.ID)
+}
+
+// KeyRotationHook is called once a replicator is removed from a thread, so the application can
+// rotate the thread service key, and distribute the new key to the remaining members out of band.
+// A returned key replaces the service key of the thread on the host, so the removed peer can't
+// read records created afterwards. Returning nil keeps the current key.
+type KeyRotationHook func(ctx context.Context, id thread.ID, removed peer.ID) (*sym.Key, error)
+
+// RecordClock assigns logical timestamps to new records of a thread. Timestamps of the records
+// seen by the host are witnessed, so a new record is stamped after all of them, and records of
+// different logs can be ordered without trusting wall clocks.
+type RecordClock interface {
+	// Tick returns the timestamp of a new record of the thread.
+	Tick(id thread.ID) (uint64, error)
+
+	// Witness notes the timestamp of a record of the thread stored by the host.
+	Witness(id thread.ID, clock uint64) error
 }
 
 // ThreadRecord wraps Record within a thread and log context.
@@ -43,4 +145,52 @@ type ThreadRecord interface {
 
 	// LogID returns the record's log ID.
 	LogID() peer.ID
+
+	// Source returns how and when the record reached the host.
+	Source() RecordSource
+}
+
+// RecordSourceKind is the way a record reached the host.
+type RecordSourceKind int
+
+const (
+	// SourceUnknown is a record of unknown origin, e.g., one read from the store.
+	SourceUnknown RecordSourceKind = iota
+	// SourceLocal is a record created, added or imported by the host.
+	SourceLocal
+	// SourcePush is a record pushed directly by a peer.
+	SourcePush
+	// SourcePull is a record pulled from peers.
+	SourcePull
+	// SourcePubSub is a record received over pubsub.
+	SourcePubSub
+	// SourceSubscription is a record streamed by a peer subscription, see SubscribePeer.
+	SourceSubscription
+)
+
+func (k RecordSourceKind) String() string {
+	switch k {
+	case SourceLocal:
+		return "local"
+	case SourcePush:
+		return "push"
+	case SourcePull:
+		return "pull"
+	case SourcePubSub:
+		return "pubsub"
+	case SourceSubscription:
+		return "subscription"
+	default:
+		return "unknown"
+	}
+}
+
+// RecordSource is the provenance of a record.
+type RecordSource struct {
+	// Kind is the way the record reached the host.
+	Kind RecordSourceKind
+	// Peer is the peer which sent the record, if known.
+	Peer peer.ID
+	// ReceivedAt is the time the host received or created the record.
+	ReceivedAt time.Time
 }
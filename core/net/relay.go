@@ -0,0 +1,25 @@
+package net
+
+import "time"
+
+// Capabilities describe the optional services a peer advertises.
+type Capabilities struct {
+	// Relay is set if the peer stores and serves records of threads it can't read.
+	Relay *RelayCapability
+	// Compression lists the codecs of compressed messages the peer reads.
+	Compression []string
+	// BodyCompression lists the codecs of compressed record bodies the peer reads.
+	BodyCompression []string
+	// Protocol is the protocol version and features negotiated with the peer.
+	Protocol ProtocolInfo
+}
+
+// RelayCapability describes the quotas and retention of a relay peer.
+type RelayCapability struct {
+	// MaxThreads is the number of relayed threads the peer accepts, zero if unlimited.
+	MaxThreads int
+	// MaxThreadBytes is the storage quota of a single relayed thread, zero if unlimited.
+	MaxThreadBytes int64
+	// Retention is the duration a relayed thread is kept without updates, zero if forever.
+	Retention time.Duration
+}
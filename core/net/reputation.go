@@ -0,0 +1,39 @@
+package net
+
+import "time"
+
+// PeerReputation is the sync track record of a peer, kept by the host across restarts.
+type PeerReputation struct {
+	// Exchanges is the number of edge exchanges with the peer, ExchangeFailures the failed ones.
+	Exchanges        int
+	ExchangeFailures int
+	// Pulls is the number of record pulls from the peer, PullFailures the failed ones.
+	Pulls        int
+	PullFailures int
+	// Latency is the moving average of the exchange and pull round trips.
+	Latency time.Duration
+	// Misbehaviors is the number of responses with records which failed verification
+	// or exceeded the size limits.
+	Misbehaviors int
+	// ConsecutiveFailures is the number of failed calls since the last successful one.
+	// The pull scheduler backs off from the peer while it's non-zero.
+	ConsecutiveFailures int
+	// LastFailure is the time of the last failed call or misbehavior.
+	LastFailure time.Time
+	// BannedUntil is the time the peer is skipped by pulls until, zero if it's not banned.
+	BannedUntil time.Time
+}
+
+// SuccessRate returns the ratio of successful exchanges and pulls, or one if there were none.
+func (r PeerReputation) SuccessRate() float64 {
+	total := r.Exchanges + r.Pulls
+	if total == 0 {
+		return 1
+	}
+	return 1 - float64(r.ExchangeFailures+r.PullFailures)/float64(total)
+}
+
+// Banned returns whether the peer is banned at the given time.
+func (r PeerReputation) Banned(now time.Time) bool {
+	return now.Before(r.BannedUntil)
+}
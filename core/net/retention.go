@@ -0,0 +1,46 @@
+package net
+
+import "time"
+
+// RetentionPolicy bounds the records a host keeps of every log of a thread, see
+// Net.SetRetentionPolicy. Records past any of the limits, counting back from the log head,
+// are below the retention horizon and pruned locally like compacted records. The heads are
+// always kept, and so is the latest checkpoint of a log, so the state of the thread can
+// still be rebuilt from its snapshot. Logs without a checkpoint, see Net.CreateCheckpoint,
+// are never pruned. Zero limits are unbounded.
+type RetentionPolicy struct {
+	// MaxRecords is the number of most recent records kept of every log.
+	MaxRecords int
+	// MaxBytes is the total size of the most recent records kept of every log, counting
+	// their record, event, header and body blocks.
+	MaxBytes int64
+	// MaxAge is how long records are kept after the host first noticed them. Ages are
+	// tracked by the retention reaper, so records may be kept up to an interval longer.
+	MaxAge time.Duration
+}
+
+// IsZero returns whether the policy keeps all records.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxRecords <= 0 && p.MaxBytes <= 0 && p.MaxAge <= 0
+}
+
+// ThreadStats describes the records a host keeps of a thread.
+type ThreadStats struct {
+	// Logs is the number of logs of the thread.
+	Logs int
+	// Records is the number of records stored locally, down to the compaction boundaries.
+	Records int
+	// Bytes is the size of the stored records, counted like RetentionPolicy.MaxBytes.
+	Bytes int64
+	// Retention is the retention policy of the thread, if any.
+	Retention RetentionPolicy
+	// Pruned is the number of records pruned by the retention policy.
+	Pruned int
+	// LastPruned is the time records were last pruned by the retention policy.
+	LastPruned time.Time
+	// Quota is the byte budget of the thread records, zero if unlimited.
+	Quota int64
+	// QuotaUsed is the size of the records charged against the quotas of the thread and its logs.
+	// Records stored while no quota applied aren't counted.
+	QuotaUsed int64
+}
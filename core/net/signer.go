@@ -0,0 +1,84 @@
+package net
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// Signer signs records with a log key. By default, log keys are kept in the logstore and
+// records are signed in memory, a signer lets the key live elsewhere, e.g., in an HSM, an
+// OS keychain or a remote KMS.
+type Signer interface {
+	// PubKey returns the public key of the signing key.
+	PubKey() crypto.PubKey
+
+	// Sign returns the signature of the message. It should give up once ctx is done.
+	Sign(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+// AsyncSigner signs records like Signer, but delivers signatures once they complete, e.g.,
+// for requests queued by a remote KMS. Use NewAsyncSigner to use it as a Signer.
+type AsyncSigner interface {
+	// PubKey returns the public key of the signing key.
+	PubKey() crypto.PubKey
+
+	// SignAsync starts signing the message and returns a channel of the result. The channel
+	// must be buffered, since the result isn't received once ctx is done.
+	SignAsync(ctx context.Context, msg []byte) <-chan SignResult
+}
+
+// SignResult is the result of an asynchronous signature.
+type SignResult struct {
+	Sig []byte
+	Err error
+}
+
+// SignerProvider returns the signers of logs whose private keys aren't kept in the logstore,
+// i.e., logs created with a public log key, see WithLogKey.
+type SignerProvider interface {
+	// Signer returns the signer of the log key of the thread, or nil if the provider
+	// doesn't hold the key.
+	Signer(ctx context.Context, id thread.ID, pk crypto.PubKey) (Signer, error)
+}
+
+// NewKeySigner returns a signer of an in-memory private key.
+func NewKeySigner(sk crypto.PrivKey) Signer {
+	return keySigner{sk: sk}
+}
+
+type keySigner struct {
+	sk crypto.PrivKey
+}
+
+func (s keySigner) PubKey() crypto.PubKey {
+	return s.sk.GetPublic()
+}
+
+func (s keySigner) Sign(_ context.Context, msg []byte) ([]byte, error) {
+	return s.sk.Sign(msg)
+}
+
+// NewAsyncSigner returns a signer waiting for the signatures of an asynchronous signer,
+// until they complete or ctx is done.
+func NewAsyncSigner(s AsyncSigner) Signer {
+	return asyncSigner{s: s}
+}
+
+type asyncSigner struct {
+	s AsyncSigner
+}
+
+func (s asyncSigner) PubKey() crypto.PubKey {
+	return s.s.PubKey()
+}
+
+func (s asyncSigner) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	select {
+	case res := <-s.s.SignAsync(ctx, msg):
+		return res.Sig, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,191 @@
+package net
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// SyncConfig tunes the synchronization of threads with peers. Zero fields mean the package
+// defaults of the network implementation. It can be changed at runtime with UpdateConfig.
+type SyncConfig struct {
+	// PullInterval is the interval between automatic edge exchanges with thread peers.
+	// Pulls scheduled from peers are spawned within the interval as well.
+	PullInterval time.Duration
+	// InitialPullInterval is the interval of the first edge exchange after the start.
+	InitialPullInterval time.Duration
+	// MaxPullLimit is the maximum number of records pulled from or served to a peer at once.
+	MaxPullLimit int
+	// MaxConcurrentPulls is the maximum number of peers a thread is pulled from at once,
+	// and of its logs which are stored at once.
+	MaxConcurrentPulls int
+	// QueuePollInterval is the polling interval of the queues of scheduled pulls.
+	QueuePollInterval time.Duration
+	// EventBusCapacity is the buffer size of local record listeners. Changes apply to new listeners.
+	EventBusCapacity int
+}
+
+// PeerSyncStatus describes the outbound record delivery to a single peer.
+type PeerSyncStatus struct {
+	// Pending is the number of records waiting for a retry.
+	Pending int
+	// Attempts is the number of consecutive failed delivery attempts.
+	Attempts int
+	// LastAttempt is the time of the last delivery attempt.
+	LastAttempt time.Time
+	// LastSuccess is the time of the last successful delivery.
+	LastSuccess time.Time
+	// NextAttempt is the time of the next scheduled retry, if any records are pending.
+	NextAttempt time.Time
+	// LastError is the error of the last failed delivery attempt.
+	LastError error
+	// Dropped is the number of records given up after failing every delivery attempt.
+	Dropped int
+}
+
+// PublishStatus describes records published over pubsub. Counters are kept since the host start.
+type PublishStatus struct {
+	// Queued is the number of logs with a record waiting for the next publishing round.
+	Queued int
+	// Published is the number of published records.
+	Published int
+	// Coalesced is the number of records replaced by a later record of the same log before publishing.
+	Coalesced int
+	// Dropped is the number of records not published since the queue was full.
+	Dropped int
+	// Failed is the number of records which failed to publish.
+	Failed int
+}
+
+// TopicValidationStatus describes the validation of records received over a thread pubsub topic.
+// Counters are kept since the topic is joined.
+type TopicValidationStatus struct {
+	// Accepted is the number of records signed by their log key, which are processed and
+	// forwarded to peers.
+	Accepted int
+	// Rejected is the number of malformed records, or records not signed by their log key.
+	Rejected int
+	// Replayed is the number of records dropped since they were seen or stored before.
+	Replayed int
+	// UnknownLog is the number of dropped records of logs unknown to the host. Such records
+	// usually beat their logs, which are sent directly along with the records.
+	UnknownLog int
+}
+
+// CompressionStatus describes the compressed messages exchanged with peers. Counters are kept since the host start.
+type CompressionStatus struct {
+	// SentMessages is the number of compressed messages sent to peers.
+	SentMessages int
+	// SentBytes is the size of the sent messages before compression.
+	SentBytes int64
+	// SentWireBytes is the size of the sent messages on the wire.
+	SentWireBytes int64
+	// ReceivedMessages is the number of compressed messages received from peers.
+	ReceivedMessages int
+	// ReceivedBytes is the size of the received messages after decompression.
+	ReceivedBytes int64
+	// ReceivedWireBytes is the size of the received messages on the wire.
+	ReceivedWireBytes int64
+}
+
+// Ratio returns the ratio of the message sizes to their sizes on the wire, or zero
+// if no compressed messages were exchanged.
+func (s CompressionStatus) Ratio() float64 {
+	wire := s.SentWireBytes + s.ReceivedWireBytes
+	if wire == 0 {
+		return 0
+	}
+	return float64(s.SentBytes+s.ReceivedBytes) / float64(wire)
+}
+
+// CallQueueStatus describes the pulls of a kind scheduled from peers. Counters are kept since the host start.
+type CallQueueStatus struct {
+	// Waiting is the number of scheduled pulls waiting to be spawned.
+	Waiting int
+	// InFlight is the number of running pulls.
+	InFlight int
+	// Spawned is the number of scheduled pulls spawned.
+	Spawned int
+	// Overdue is the number of pulls spawned after their deadline.
+	Overdue int
+	// TotalWait is the time spawned pulls waited in the queue.
+	TotalWait time.Duration
+	// MaxWait is the longest time a spawned pull waited in the queue.
+	MaxWait time.Duration
+}
+
+// MeanWait returns the mean time spawned pulls waited in the queue.
+func (s CallQueueStatus) MeanWait() time.Duration {
+	if s.Spawned == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Spawned)
+}
+
+// RPCStatus describes the calls of a gRPC method served and issued by the host. Counters are kept since the host start.
+type RPCStatus struct {
+	// Served is the number of calls served to peers.
+	Served int
+	// ServedErrors is the number of served calls which returned an error.
+	ServedErrors int
+	// ServedTime is the time spent serving calls.
+	ServedTime time.Duration
+	// Issued is the number of calls issued to peers.
+	Issued int
+	// IssuedErrors is the number of issued calls which returned an error.
+	IssuedErrors int
+	// IssuedTime is the time spent waiting for issued calls.
+	IssuedTime time.Duration
+	// Panics is the number of served calls which panicked.
+	Panics int
+}
+
+// LogPullStatus describes the inbound sync progress of a single thread log.
+type LogPullStatus struct {
+	// LocalHead is the local head of the log.
+	LocalHead cid.Cid
+	// RemoteHead is the latest head of the log seen on peers, undefined if unknown.
+	RemoteHead cid.Cid
+	// RecordsBehind is the number of records seen on peers which are not applied locally.
+	// Peers may have more records which were not pulled yet, so it's a lower bound.
+	RecordsBehind int
+	// LastExchange is the time of the last successful edge exchange or record pull.
+	LastExchange time.Time
+	// LastError is the error of the last failed exchange or pull, if it wasn't followed by a success.
+	LastError error
+}
+
+// ThreadLockStatus describes the holders and waiters of a thread update lock.
+type ThreadLockStatus struct {
+	// Holders is the number of updates holding the lock.
+	Holders int
+	// Waiters is the number of updates waiting for the lock, they're served in arrival order.
+	Waiters int
+	// HeldSince is the time the oldest holder acquired the lock.
+	HeldSince time.Time
+	// WaitingSince is the time the oldest waiter started waiting, zero if there are no waiters.
+	WaitingSince time.Time
+}
+
+// RecordReplication is the replication status of a record pushed by the host.
+type RecordReplication struct {
+	// Acked holds the peers which acknowledged persisting the record, and when.
+	Acked map[peer.ID]time.Time
+	// Pending holds the thread peers which didn't acknowledge the record yet.
+	Pending []peer.ID
+}
+
+// RecordFuture tracks the replication of a record created with CreateRecordAsync.
+type RecordFuture interface {
+	// Record returns the locally stored record.
+	Record() ThreadRecord
+	// Done is closed once the record push to every known thread peer has finished.
+	Done() <-chan struct{}
+	// Wait blocks until the push has finished or the context is done. It returns the outcome
+	// of the push to each peer, where a nil error is an acknowledgement. Failed deliveries
+	// are retried in the background, see SyncStatus. The returned error is set if the push
+	// couldn't be started at all.
+	Wait(ctx context.Context) (map[peer.ID]error, error)
+}
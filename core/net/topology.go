@@ -0,0 +1,12 @@
+package net
+
+// Locality places a peer within a multi-region deployment.
+type Locality struct {
+	Region string
+	Zone   string
+}
+
+// Defined returns whether the locality is known.
+func (l Locality) Defined() bool {
+	return l.Region != ""
+}
@@ -0,0 +1,221 @@
+package thread
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	mbase "github.com/multiformats/go-multibase"
+)
+
+// KeyBundleVersion is the version of the KeyBundle encoding written by MarshalBinary.
+const KeyBundleVersion = 1
+
+var (
+	// ErrInvalidKeyBundle indicates bytes which can't be decoded into a KeyBundle.
+	ErrInvalidKeyBundle = fmt.Errorf("invalid key bundle")
+	// ErrKeyBundleVersion indicates a KeyBundle encoding version which is not supported.
+	ErrKeyBundleVersion = fmt.Errorf("unsupported key bundle version")
+)
+
+// KeyBundle holds the credentials needed to join a thread on another host.
+// The thread key is mandatory, while the log key is only included if the
+// recipient should write to an existing log, e.g., when moving an identity
+// between devices.
+type KeyBundle struct {
+	ThreadID ID
+	Key      Key
+	LogKey   crypto.PrivKey
+	Addrs    []ma.Multiaddr
+}
+
+// NewKeyBundle returns a bundle with the thread ID, key and addresses of info.
+func NewKeyBundle(info Info) KeyBundle {
+	return KeyBundle{
+		ThreadID: info.ID,
+		Key:      info.Key,
+		Addrs:    info.Addrs,
+	}
+}
+
+// Info returns the thread info described by the bundle.
+// The bundled log, if any, is included with its keys.
+func (b KeyBundle) Info() (Info, error) {
+	info := Info{
+		ID:    b.ThreadID,
+		Key:   b.Key,
+		Addrs: b.Addrs,
+	}
+	if b.LogKey != nil {
+		lid, err := peer.IDFromPrivateKey(b.LogKey)
+		if err != nil {
+			return info, err
+		}
+		info.Logs = []LogInfo{{
+			ID:      lid,
+			PubKey:  b.LogKey.GetPublic(),
+			PrivKey: b.LogKey,
+			Managed: true,
+		}}
+	}
+	return info, nil
+}
+
+// MarshalBinary implements BinaryMarshaler.
+// The encoding starts with the version followed by length-prefixed thread ID,
+// thread key, log key and addresses. An absent log key has zero length.
+func (b KeyBundle) MarshalBinary() ([]byte, error) {
+	if !b.ThreadID.Defined() {
+		return nil, fmt.Errorf("%w: thread ID is undefined", ErrInvalidKeyBundle)
+	}
+	if !b.Key.Defined() {
+		return nil, fmt.Errorf("%w: thread key is undefined", ErrInvalidKeyBundle)
+	}
+	var lk []byte
+	if b.LogKey != nil {
+		var err error
+		if lk, err = crypto.MarshalPrivateKey(b.LogKey); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := appendUvarint(nil, KeyBundleVersion)
+	for _, field := range [][]byte{b.ThreadID.Bytes(), b.Key.Bytes(), lk} {
+		buf = appendField(buf, field)
+	}
+	buf = appendUvarint(buf, uint64(len(b.Addrs)))
+	for _, addr := range b.Addrs {
+		buf = appendField(buf, addr.Bytes())
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements BinaryUnmarshaler.
+func (b *KeyBundle) UnmarshalBinary(data []byte) error {
+	version, data, err := readUvarint(data)
+	if err != nil {
+		return err
+	}
+	if version != KeyBundleVersion {
+		return fmt.Errorf("%w: %d", ErrKeyBundleVersion, version)
+	}
+
+	var fields [3][]byte
+	for i := range fields {
+		if fields[i], data, err = readField(data); err != nil {
+			return err
+		}
+	}
+	var res KeyBundle
+	if res.ThreadID, err = Cast(fields[0]); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKeyBundle, err)
+	}
+	if res.Key, err = KeyFromBytes(fields[1]); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKeyBundle, err)
+	}
+	if len(fields[2]) > 0 {
+		if res.LogKey, err = crypto.UnmarshalPrivateKey(fields[2]); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidKeyBundle, err)
+		}
+	}
+
+	count, data, err := readUvarint(data)
+	if err != nil {
+		return err
+	}
+	if count > uint64(len(data)) {
+		return fmt.Errorf("%w: too many addresses", ErrInvalidKeyBundle)
+	}
+	for i := uint64(0); i < count; i++ {
+		var ab []byte
+		if ab, data, err = readField(data); err != nil {
+			return err
+		}
+		addr, err := ma.NewMultiaddrBytes(ab)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidKeyBundle, err)
+		}
+		res.Addrs = append(res.Addrs, addr)
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("%w: trailing bytes", ErrInvalidKeyBundle)
+	}
+	*b = res
+	return nil
+}
+
+// String returns the base32-encoded string representation of the bundle bytes.
+func (b KeyBundle) String() string {
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return ""
+	}
+	str, err := mbase.Encode(mbase.Base32, data)
+	if err != nil {
+		panic("should not error with hardcoded mbase: " + err.Error())
+	}
+	return str
+}
+
+// KeyBundleFromString returns a bundle by decoding a multibase-encoded string.
+func KeyBundleFromString(s string) (b KeyBundle, err error) {
+	_, data, err := mbase.Decode(s)
+	if err != nil {
+		return b, err
+	}
+	err = b.UnmarshalBinary(data)
+	return b, err
+}
+
+// Encrypt returns the bundle bytes encrypted for the recipient, so they can be
+// shared over untrusted channels.
+func (b KeyBundle) Encrypt(recipient PubKey) ([]byte, error) {
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return recipient.Encrypt(data)
+}
+
+// DecryptKeyBundle returns a bundle encrypted for the identity with KeyBundle.Encrypt.
+func DecryptKeyBundle(ctx context.Context, identity Identity, ciphertext []byte) (b KeyBundle, err error) {
+	data, err := identity.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return b, err
+	}
+	err = b.UnmarshalBinary(data)
+	return b, err
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendField(buf, field []byte) []byte {
+	buf = appendUvarint(buf, uint64(len(field)))
+	return append(buf, field...)
+}
+
+func readUvarint(data []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: bad varint", ErrInvalidKeyBundle)
+	}
+	return v, data[n:], nil
+}
+
+func readField(data []byte) ([]byte, []byte, error) {
+	l, data, err := readUvarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l > uint64(len(data)) {
+		return nil, nil, fmt.Errorf("%w: field exceeds data", ErrInvalidKeyBundle)
+	}
+	return data[:l], data[l:], nil
+}
@@ -0,0 +1,118 @@
+package thread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestKeyBundle_Marshal(t *testing.T) {
+	addr, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4006")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1 := KeyBundle{
+		ThreadID: NewIDV1(Raw, 32),
+		Key:      NewRandomKey(),
+		LogKey:   lk,
+		Addrs:    []ma.Multiaddr{addr},
+	}
+
+	b2, err := KeyBundleFromString(b1.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b2.ThreadID.Equals(b1.ThreadID) {
+		t.Fatal("thread IDs are not equal")
+	}
+	if !bytes.Equal(b2.Key.Bytes(), b1.Key.Bytes()) {
+		t.Fatal("thread keys are not equal")
+	}
+	if b2.LogKey == nil || !b2.LogKey.Equals(b1.LogKey) {
+		t.Fatal("log keys are not equal")
+	}
+	if len(b2.Addrs) != 1 || !b2.Addrs[0].Equal(addr) {
+		t.Fatal("addresses are not equal")
+	}
+
+	t.Run("without log key", func(t *testing.T) {
+		b1.LogKey = nil
+		data, err := b1.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b2 KeyBundle
+		if err = b2.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if b2.LogKey != nil {
+			t.Fatal("log key should be nil")
+		}
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		data, err := b1.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[0] = KeyBundleVersion + 1
+		var b2 KeyBundle
+		if err = b2.UnmarshalBinary(data); !errors.Is(err, ErrKeyBundleVersion) {
+			t.Fatalf("expected version error, got %v", err)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data, err := b1.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b2 KeyBundle
+		if err = b2.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidKeyBundle) {
+			t.Fatalf("expected invalid bundle error, got %v", err)
+		}
+	})
+}
+
+func TestKeyBundle_Encrypt(t *testing.T) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := NewLibp2pIdentity(sk)
+	b1 := KeyBundle{
+		ThreadID: NewIDV1(Raw, 32),
+		Key:      NewRandomServiceKey(),
+	}
+
+	ciphertext, err := b1.Encrypt(identity.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := DecryptKeyBundle(context.Background(), identity, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b2.ThreadID.Equals(b1.ThreadID) || !bytes.Equal(b2.Key.Bytes(), b1.Key.Bytes()) {
+		t.Fatal("decrypted bundle doesn't match")
+	}
+	if b2.Key.CanRead() {
+		t.Fatal("read key should be nil")
+	}
+
+	other, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecryptKeyBundle(context.Background(), NewLibp2pIdentity(other), ciphertext); err == nil {
+		t.Fatal("expected decryption with another identity to fail")
+	}
+}
@@ -0,0 +1,268 @@
+package thread
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"google.golang.org/grpc/metadata"
+)
+
+// CapabilityRights is a set of rights a capability grants on a thread.
+type CapabilityRights int
+
+const (
+	// RightRead allows fetching the logs and records of the thread, and exchanging its edges.
+	RightRead CapabilityRights = 1 << iota
+	// RightAppend allows pushing logs and records to the thread.
+	RightAppend
+	// RightReplicate allows fetching records and pushing records of known logs, but not
+	// adding logs to the thread.
+	RightReplicate
+
+	// RightAll grants all rights.
+	RightAll = RightRead | RightAppend | RightReplicate
+)
+
+var (
+	// ErrInvalidCapability indicates a malformed capability, one which wasn't minted for the thread,
+	// or one whose caveats aren't met.
+	ErrInvalidCapability = fmt.Errorf("invalid thread capability")
+
+	// ErrCapabilityExpired indicates a capability past its expiration time.
+	ErrCapabilityExpired = fmt.Errorf("thread capability expired")
+)
+
+const (
+	// capabilityIDBytes is the byte length of random capability IDs.
+	capabilityIDBytes = 16
+	// capabilityMDKey is the request metadata key of capabilities.
+	capabilityMDKey = "x-thread-capability"
+)
+
+// Caveat restricts the use of a capability. Caveats are added by the thread owner minting
+// a capability, or by any holder delegating it further, but they can't be removed.
+type Caveat string
+
+// CaveatRights restricts the rights granted by a capability.
+func CaveatRights(rights CapabilityRights) Caveat {
+	return Caveat("rights = " + strconv.Itoa(int(rights)))
+}
+
+// CaveatExpires restricts the use of a capability to before t.
+func CaveatExpires(t time.Time) Caveat {
+	return Caveat("expires < " + strconv.FormatInt(t.Unix(), 10))
+}
+
+// CaveatPeer restricts the use of a capability to the peer.
+func CaveatPeer(pid peer.ID) Caveat {
+	return Caveat("peer = " + pid.String())
+}
+
+// caveatThread binds a capability to the thread it was minted for.
+func caveatThread(id ID) Caveat {
+	return Caveat("thread = " + id.String())
+}
+
+// Capability is a macaroon-style bearer token, which grants rights on a thread to its holder.
+// It's a chain of caveats, each one signed with the signature of the chain before it, and
+// the first one with a root key only the thread owner knows. A holder may add caveats, but
+// only the owner can verify the capability.
+type Capability string
+
+type capability struct {
+	ID      []byte   `json:"id"`
+	Caveats []Caveat `json:"caveats"`
+	Sig     []byte   `json:"sig"`
+}
+
+// CapabilityClaims are the claims of a verified capability.
+type CapabilityClaims struct {
+	// Thread is the thread the capability was minted for.
+	Thread ID
+	// Rights are the rights granted on the thread.
+	Rights CapabilityRights
+	// ExpiresAt is the time the capability expires, zero if it never expires.
+	ExpiresAt time.Time
+}
+
+// Allows returns whether any of the rights is granted.
+func (c CapabilityClaims) Allows(rights CapabilityRights) bool {
+	return c.Rights&rights != 0
+}
+
+// NewCapability mints a capability for the thread, signed with the root key.
+func NewCapability(root []byte, id ID, caveats ...Caveat) (Capability, error) {
+	nonce := make([]byte, capabilityIDBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	c := capability{ID: nonce, Sig: capabilitySig(root, nonce)}
+	return c.attenuate(append([]Caveat{caveatThread(id)}, caveats...))
+}
+
+// Attenuate returns the capability with the caveats added.
+func (c Capability) Attenuate(caveats ...Caveat) (Capability, error) {
+	dc, err := c.decode()
+	if err != nil {
+		return "", err
+	}
+	return dc.attenuate(caveats)
+}
+
+// Thread returns the thread the capability was minted for.
+// Note: This does NOT verify the capability.
+func (c Capability) Thread() (ID, error) {
+	dc, err := c.decode()
+	if err != nil {
+		return Undef, err
+	}
+	id, ok := dc.thread()
+	if !ok {
+		return Undef, ErrInvalidCapability
+	}
+	return id, nil
+}
+
+// Verify checks the capability was attenuated from one minted with the root key, and that
+// its caveats are met by the peer at the time. The claims of the capability are returned.
+func (c Capability) Verify(root []byte, pid peer.ID, now time.Time) (res CapabilityClaims, err error) {
+	dc, err := c.decode()
+	if err != nil {
+		return
+	}
+	sig := capabilitySig(root, dc.ID)
+	for _, cav := range dc.Caveats {
+		sig = capabilitySig(sig, []byte(cav))
+	}
+	if !hmac.Equal(sig, dc.Sig) {
+		return res, ErrInvalidCapability
+	}
+	id, ok := dc.thread()
+	if !ok {
+		return res, ErrInvalidCapability
+	}
+	res = CapabilityClaims{Thread: id, Rights: RightAll}
+	for _, cav := range dc.Caveats {
+		parts := strings.SplitN(string(cav), " ", 3)
+		if len(parts) != 3 {
+			return CapabilityClaims{}, ErrInvalidCapability
+		}
+		switch name, op, value := parts[0], parts[1], parts[2]; {
+		case name == "thread" && op == "=":
+			if value != id.String() {
+				return CapabilityClaims{}, ErrInvalidCapability
+			}
+		case name == "rights" && op == "=":
+			rights, err := strconv.Atoi(value)
+			if err != nil {
+				return CapabilityClaims{}, ErrInvalidCapability
+			}
+			res.Rights &= CapabilityRights(rights)
+		case name == "expires" && op == "<":
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return CapabilityClaims{}, ErrInvalidCapability
+			}
+			if t := time.Unix(sec, 0); res.ExpiresAt.IsZero() || t.Before(res.ExpiresAt) {
+				res.ExpiresAt = t
+			}
+		case name == "peer" && op == "=":
+			if value != pid.String() {
+				return CapabilityClaims{}, fmt.Errorf("%w: issued to another peer", ErrInvalidCapability)
+			}
+		default:
+			// caveats which can't be checked are never met
+			return CapabilityClaims{}, fmt.Errorf("%w: unknown caveat %s", ErrInvalidCapability, cav)
+		}
+	}
+	if !res.ExpiresAt.IsZero() && !now.Before(res.ExpiresAt) {
+		return CapabilityClaims{}, ErrCapabilityExpired
+	}
+	return res, nil
+}
+
+// Defined returns true if capability is not empty.
+func (c Capability) Defined() bool {
+	return c != ""
+}
+
+func (c Capability) decode() (dc capability, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return dc, ErrInvalidCapability
+	}
+	if err = json.Unmarshal(data, &dc); err != nil || len(dc.Caveats) == 0 {
+		return dc, ErrInvalidCapability
+	}
+	return dc, nil
+}
+
+func (c capability) attenuate(caveats []Caveat) (Capability, error) {
+	for _, cav := range caveats {
+		c.Caveats = append(c.Caveats, cav)
+		c.Sig = capabilitySig(c.Sig, []byte(cav))
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return Capability(base64.RawURLEncoding.EncodeToString(data)), nil
+}
+
+// thread returns the thread of the first caveat, which is added once minted.
+func (c capability) thread() (ID, bool) {
+	value := strings.TrimPrefix(string(c.Caveats[0]), "thread = ")
+	if value == string(c.Caveats[0]) {
+		return Undef, false
+	}
+	id, err := Decode(value)
+	return id, err == nil
+}
+
+func capabilitySig(key, msg []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+// NewCapabilitiesFromMD returns the capabilities from the given context, if present.
+func NewCapabilitiesFromMD(ctx context.Context) []Capability {
+	md, _ := metadata.FromIncomingContext(ctx)
+	vals := md.Get(capabilityMDKey)
+	caps := make([]Capability, len(vals))
+	for i, v := range vals {
+		caps[i] = Capability(v)
+	}
+	return caps
+}
+
+// NewCapabilityContext adds capabilities to a context.
+func NewCapabilityContext(ctx context.Context, caps ...Capability) context.Context {
+	if len(caps) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey("capabilities"), caps)
+}
+
+// CapabilitiesFromContext returns the capabilities from a context.
+func CapabilitiesFromContext(ctx context.Context) []Capability {
+	caps, _ := ctx.Value(ctxKey("capabilities")).([]Capability)
+	return caps
+}
+
+// OutgoingCapabilityContext adds capabilities to the metadata of outgoing requests.
+func OutgoingCapabilityContext(ctx context.Context, caps ...Capability) context.Context {
+	for _, c := range caps {
+		ctx = metadata.AppendToOutgoingContext(ctx, capabilityMDKey, string(c))
+	}
+	return ctx
+}
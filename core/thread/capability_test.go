@@ -0,0 +1,73 @@
+package thread
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/test"
+)
+
+func TestCapability_Attenuate(t *testing.T) {
+	root := []byte("root key")
+	id := NewIDV1(Raw, 32)
+	pid, err := test.RandPeerID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := test.RandPeerID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	c, err := NewCapability(root, id, CaveatRights(RightRead|RightReplicate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tid, err := c.Thread(); err != nil || !tid.Equals(id) {
+		t.Fatalf("expected thread %s got %s (%v)", id, tid, err)
+	}
+	claims, err := c.Verify(root, pid, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Allows(RightRead) || claims.Allows(RightAppend) || !claims.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if _, err = c.Verify([]byte("other root key"), pid, now); !errors.Is(err, ErrInvalidCapability) {
+		t.Fatalf("expected capability of another root to be invalid, got %v", err)
+	}
+
+	// rights are narrowed down, and can't be widened
+	dc, err := c.Attenuate(CaveatRights(RightRead|RightAppend), CaveatPeer(pid), CaveatExpires(now.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err = dc.Verify(root, pid, now); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Rights != RightRead || claims.ExpiresAt.Unix() != now.Add(time.Hour).Unix() {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if _, err = dc.Verify(root, other, now); !errors.Is(err, ErrInvalidCapability) {
+		t.Fatalf("expected capability of another peer to be invalid, got %v", err)
+	}
+	if _, err = dc.Verify(root, pid, now.Add(2*time.Hour)); !errors.Is(err, ErrCapabilityExpired) {
+		t.Fatalf("expected capability to expire, got %v", err)
+	}
+
+	// caveats can't be dropped
+	stripped, err := dc.decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripped.Caveats = stripped.Caveats[:1]
+	tampered, err := stripped.attenuate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tampered.Verify(root, other, now); !errors.Is(err, ErrInvalidCapability) {
+		t.Fatalf("expected tampered capability to be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package thread
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FlagSingleWriter marks a thread where only the creator's log may contain records.
+const FlagSingleWriter = "single-writer"
+
+var (
+	// ErrInvalidFlags indicates malformed thread flags.
+	ErrInvalidFlags = fmt.Errorf("invalid thread flags")
+
+	flagName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+)
+
+// Flags is a set of thread-wide switches, which is stored with a thread and replicated
+// to its peers. A flag is either a name, e.g., "archive", or a name=value pair, e.g.,
+// "app=notes". Flags other than FlagSingleWriter are only carried along for applications.
+// Flags are kept sorted, so equal sets have the same encoding.
+type Flags []string
+
+// NewFlags returns validated flags from a list of flags.
+func NewFlags(flags ...string) (Flags, error) {
+	f := make(Flags, 0, len(flags))
+	for _, flag := range flags {
+		// repeated flags are dropped, while conflicting values are refused below
+		var dup bool
+		for _, x := range f {
+			dup = dup || x == flag
+		}
+		if !dup {
+			f = append(f, flag)
+		}
+	}
+	sort.Strings(f)
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FlagsFromString returns flags from their encoding returned by String.
+func FlagsFromString(s string) (Flags, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return NewFlags(strings.Split(s, ",")...)
+}
+
+// Has returns whether the flag is set.
+func (f Flags) Has(name string) bool {
+	for _, flag := range f {
+		if flagKey(flag) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the value of a name=value flag, and whether the flag is set.
+func (f Flags) Value(name string) (string, bool) {
+	for _, flag := range f {
+		if flagKey(flag) == name {
+			return strings.TrimPrefix(flag[len(name):], "="), true
+		}
+	}
+	return "", false
+}
+
+// Validate returns an error if a flag is malformed or set twice, or if FlagSingleWriter has a value.
+func (f Flags) Validate() error {
+	seen := make(map[string]struct{}, len(f))
+	for _, flag := range f {
+		name := flagKey(flag)
+		if !flagName.MatchString(name) {
+			return fmt.Errorf("%w: bad name %q", ErrInvalidFlags, name)
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("%w: %s is set twice", ErrInvalidFlags, name)
+		}
+		seen[name] = struct{}{}
+		value, hasValue := strings.TrimPrefix(flag[len(name):], "="), len(flag) > len(name)
+		if hasValue && (value == "" || strings.ContainsAny(value, ", \n")) {
+			return fmt.Errorf("%w: bad value of %s", ErrInvalidFlags, name)
+		}
+		if name == FlagSingleWriter && hasValue {
+			return fmt.Errorf("%w: %s doesn't take a value", ErrInvalidFlags, name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether two sets of flags are equal.
+func (f Flags) Equal(o Flags) bool {
+	return f.String() == o.String()
+}
+
+// String returns the flags separated by commas.
+func (f Flags) String() string {
+	return strings.Join(f, ",")
+}
+
+func flagKey(flag string) string {
+	if i := strings.IndexByte(flag, '='); i >= 0 {
+		return flag[:i]
+	}
+	return flag
+}
@@ -0,0 +1,48 @@
+package thread
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewFlags(t *testing.T) {
+	f, err := NewFlags("archive", "app=notes", FlagSingleWriter, "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.String() != "app=notes,archive,"+FlagSingleWriter {
+		t.Fatalf("expected sorted flags without duplicates, got %s", f)
+	}
+	if !f.Has("archive") || f.Has("other") {
+		t.Fatal("bad flags set")
+	}
+	if v, ok := f.Value("app"); !ok || v != "notes" {
+		t.Fatalf("bad app value %s", v)
+	}
+
+	f2, err := FlagsFromString(f.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Equal(f2) {
+		t.Fatalf("expected decoded flags %s to equal %s", f2, f)
+	}
+}
+
+func TestFlags_Validate(t *testing.T) {
+	for _, flags := range [][]string{
+		{"Public"},
+		{""},
+		{"custom="},
+		{"custom=a,b"},
+		{"custom=a", "custom=b"},
+		{"single-writer=yes"},
+	} {
+		if _, err := NewFlags(flags...); !errors.Is(err, ErrInvalidFlags) {
+			t.Fatalf("expected flags %v to be invalid, got %v", flags, err)
+		}
+	}
+	if _, err := NewFlags("custom-flag=1", "other"); err != nil {
+		t.Fatalf("expected custom flags to be valid, got %v", err)
+	}
+}
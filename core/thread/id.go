@@ -345,12 +345,13 @@ func (s IDSlice) Len() int           { return len(s) }
 func (s IDSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s IDSlice) Less(i, j int) bool { return s[i] < s[j] }
 
-// Info holds thread logs, keys and addresses.
+// Info holds thread logs, keys, addresses and flags.
 type Info struct {
 	ID    ID
 	Key   Key
 	Logs  []LogInfo
 	Addrs []ma.Multiaddr
+	Flags Flags
 }
 
 // GetFirstPrivKeyLog returns the first log found with a private key.
@@ -377,6 +378,9 @@ type LogInfo struct {
 	Addrs []ma.Multiaddr
 	// Head is the log's current head.
 	Head cid.Cid
+	// Heads are all of the log's current heads, including Head.
+	// A log has more than one head if its records were forked.
+	Heads []cid.Cid
 	// Managed logs are any logs directly added/created by the host, and/or logs for which we have the private key
 	Managed bool
 }
@@ -2,7 +2,9 @@ package thread
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"strings"
@@ -168,17 +170,51 @@ var ErrTokenNotFound = fmt.Errorf("thread token not found")
 // ErrInvalidToken indicates the token is invalid.
 var ErrInvalidToken = fmt.Errorf("invalid thread token")
 
+// ErrTokenExpired indicates the token is past its expiration time.
+var ErrTokenExpired = fmt.Errorf("thread token expired")
+
+// tokenIDBytes is the byte length of random token IDs.
+const tokenIDBytes = 16
+
+// TokenClaims are the claims of a validated token.
+type TokenClaims struct {
+	// ID identifies the token, e.g., for revocation. It's empty for tokens issued by older versions.
+	ID string
+	// PubKey is the identity the token was issued to.
+	PubKey PubKey
+	// IssuedAt is the time the token was issued.
+	IssuedAt time.Time
+	// ExpiresAt is the time the token expires, zero if it never expires.
+	ExpiresAt time.Time
+}
+
 // NewToken issues a new JWT token from issuer for the given pubic key.
+// The token never expires.
 func NewToken(issuer crypto.PrivKey, key PubKey) (tok Token, err error) {
+	return NewTokenWithTTL(issuer, key, 0)
+}
+
+// NewTokenWithTTL issues a new JWT token from issuer for the given public key,
+// which expires after ttl. Zero ttl means that the token never expires.
+func NewTokenWithTTL(issuer crypto.PrivKey, key PubKey, ttl time.Duration) (tok Token, err error) {
 	var ok bool
 	issuer, ok = issuer.(*crypto.Ed25519PrivateKey)
 	if !ok {
 		log.Fatal("issuer must be an Ed25519PrivateKey")
 	}
+	id := make([]byte, tokenIDBytes)
+	if _, err = rand.Read(id); err != nil {
+		return
+	}
+	now := time.Now()
 	claims := jwt.StandardClaims{
+		Id:       hex.EncodeToString(id),
 		Subject:  key.String(),
 		Issuer:   NewLibp2pIdentity(issuer).GetPublic().String(),
-		IssuedAt: time.Now().Unix(),
+		IssuedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		claims.ExpiresAt = now.Add(ttl).Unix()
 	}
 	str, err := jwt.NewWithClaims(jwted25519.SigningMethodEd25519i, claims).SignedString(issuer)
 	if err != nil {
@@ -213,8 +249,15 @@ func (t Token) PubKey() (PubKey, error) {
 // If token is present and was issued by issuer (is valid), the embedded public key is returned.
 // If token is not present, both the returned public key and error will be nil.
 func (t Token) Validate(issuer crypto.PrivKey) (PubKey, error) {
+	claims, err := t.Claims(issuer)
+	return claims.PubKey, err
+}
+
+// Claims validates token against an issuer like Validate, and returns its claims.
+// If token is not present, the returned claims are empty.
+func (t Token) Claims(issuer crypto.PrivKey) (res TokenClaims, err error) {
 	if issuer == nil {
-		return nil, fmt.Errorf("cannot validate with nil issuer")
+		return res, fmt.Errorf("cannot validate with nil issuer")
 	}
 	var ok bool
 	issuer, ok = issuer.(*crypto.Ed25519PrivateKey)
@@ -222,7 +265,7 @@ func (t Token) Validate(issuer crypto.PrivKey) (PubKey, error) {
 		log.Fatal("issuer must be an Ed25519PrivateKey")
 	}
 	if t == "" {
-		return nil, nil
+		return
 	}
 	keyfunc := func(*jwt.Token) (interface{}, error) {
 		return issuer.GetPublic(), nil
@@ -230,17 +273,27 @@ func (t Token) Validate(issuer crypto.PrivKey) (PubKey, error) {
 	var claims jwt.StandardClaims
 	tok, err := jwt.ParseWithClaims(string(t), &claims, keyfunc)
 	if err != nil {
-		if tok == nil {
-			return nil, ErrTokenNotFound
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors == jwt.ValidationErrorExpired {
+			return res, ErrTokenExpired
+		} else if tok == nil {
+			return res, ErrTokenNotFound
 		} else {
-			return nil, ErrInvalidToken
+			return res, ErrInvalidToken
 		}
 	}
 	key := &Libp2pPubKey{}
 	if err = key.UnmarshalString(claims.Subject); err != nil {
-		return nil, err
+		return
 	}
-	return key, nil
+	res = TokenClaims{
+		ID:       claims.Id,
+		PubKey:   key,
+		IssuedAt: time.Unix(claims.IssuedAt, 0),
+	}
+	if claims.ExpiresAt != 0 {
+		res.ExpiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+	return res, nil
 }
 
 // Defined returns true if token is not empty.
@@ -0,0 +1,48 @@
+package thread
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func TestToken_Expiry(t *testing.T) {
+	issuer, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := NewLibp2pPubKey(sk.GetPublic())
+
+	tok, err := NewTokenWithTTL(issuer, key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tok.Claims(issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID == "" || !claims.PubKey.Equals(key) {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	defer func() { jwt.TimeFunc = time.Now }()
+	jwt.TimeFunc = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err = tok.Validate(issuer); err != ErrTokenExpired {
+		t.Fatalf("expected token to expire, got %v", err)
+	}
+
+	tok, err = NewToken(issuer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tok.Validate(issuer); err != nil {
+		t.Fatalf("expected token without ttl not to expire, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package thread
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// MaxMetadataSize is the maximum total byte length of the fields of thread metadata.
+const MaxMetadataSize = 16 << 10
+
+// ErrInvalidMetadata indicates malformed thread metadata, or metadata with a bad signature.
+var ErrInvalidMetadata = fmt.Errorf("invalid thread metadata")
+
+// Metadata describes a thread to applications, so they can discover what a thread is without
+// an external registry. Metadata is signed by the log which last set it and replicated to the
+// thread peers, where the latest version wins.
+type Metadata struct {
+	// Name is a human-readable name of the thread.
+	Name string
+	// Description is a human-readable description of the thread.
+	Description string
+	// Schema hints at the schema of the thread records, e.g., a URL or a CID.
+	Schema string
+	// UpdatedAt is the time the metadata was set.
+	UpdatedAt time.Time
+	// Signer is the key of the log which signed the metadata.
+	Signer crypto.PubKey
+	// Sig is the signature of the metadata by Signer.
+	Sig []byte
+}
+
+type metadataPayload struct {
+	Thread      string `json:"thread"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema,omitempty"`
+	UpdatedAt   int64  `json:"updatedAt"`
+}
+
+type metadataJSON struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema,omitempty"`
+	UpdatedAt   int64  `json:"updatedAt"`
+	Signer      []byte `json:"signer"`
+	Sig         []byte `json:"sig"`
+}
+
+// MetadataFromBytes returns metadata from its encoding returned by Marshal.
+func MetadataFromBytes(data []byte) (m Metadata, err error) {
+	var mj metadataJSON
+	if err = json.Unmarshal(data, &mj); err != nil {
+		return m, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	signer, err := crypto.UnmarshalPublicKey(mj.Signer)
+	if err != nil {
+		return m, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	m = Metadata{
+		Name:        mj.Name,
+		Description: mj.Description,
+		Schema:      mj.Schema,
+		UpdatedAt:   time.Unix(0, mj.UpdatedAt),
+		Signer:      signer,
+		Sig:         mj.Sig,
+	}
+	return m, m.Validate()
+}
+
+// Marshal returns the encoding of signed metadata.
+func (m Metadata) Marshal() ([]byte, error) {
+	if m.Signer == nil {
+		return nil, fmt.Errorf("%w: unsigned", ErrInvalidMetadata)
+	}
+	signer, err := crypto.MarshalPublicKey(m.Signer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(metadataJSON{
+		Name:        m.Name,
+		Description: m.Description,
+		Schema:      m.Schema,
+		UpdatedAt:   m.UpdatedAt.UnixNano(),
+		Signer:      signer,
+		Sig:         m.Sig,
+	})
+}
+
+// Payload returns the bytes signed by Signer. The signature binds the metadata to the
+// thread, so it can't be replayed in another one.
+func (m Metadata) Payload(id ID) ([]byte, error) {
+	return json.Marshal(metadataPayload{
+		Thread:      id.String(),
+		Name:        m.Name,
+		Description: m.Description,
+		Schema:      m.Schema,
+		UpdatedAt:   m.UpdatedAt.UnixNano(),
+	})
+}
+
+// Validate checks the metadata fields aren't over MaxMetadataSize.
+func (m Metadata) Validate() error {
+	if size := len(m.Name) + len(m.Description) + len(m.Schema); size > MaxMetadataSize {
+		return fmt.Errorf("%w: %d bytes over limit of %d", ErrInvalidMetadata, size, MaxMetadataSize)
+	}
+	return nil
+}
+
+// Verify checks the metadata of the thread was signed by Signer.
+func (m Metadata) Verify(id ID) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if m.Signer == nil || len(m.Sig) == 0 {
+		return fmt.Errorf("%w: unsigned", ErrInvalidMetadata)
+	}
+	payload, err := m.Payload(id)
+	if err != nil {
+		return err
+	}
+	ok, err := m.Signer.Verify(payload, m.Sig)
+	if err != nil || !ok {
+		return fmt.Errorf("%w: bad signature", ErrInvalidMetadata)
+	}
+	return nil
+}
+
+// After returns whether the metadata supersedes the other one. The latest update wins, and
+// concurrent updates are ordered by signature, so all peers settle on the same version.
+func (m Metadata) After(o Metadata) bool {
+	if !m.UpdatedAt.Equal(o.UpdatedAt) {
+		return m.UpdatedAt.After(o.UpdatedAt)
+	}
+	return bytes.Compare(m.Sig, o.Sig) > 0
+}
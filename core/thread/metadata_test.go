@@ -0,0 +1,81 @@
+package thread
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func TestMetadata_Verify(t *testing.T) {
+	sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := NewIDV1(Raw, 32)
+	m := Metadata{Name: "notes", Description: "shared notes", Schema: "https://example.com/note.json", UpdatedAt: time.Now()}
+	sign := func(m Metadata) Metadata {
+		payload, err := m.Payload(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Signer = pk
+		if m.Sig, err = sk.Sign(payload); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	m = sign(m)
+	if err = m.Verify(id); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := MetadataFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m2.Verify(id); err != nil {
+		t.Fatal(err)
+	}
+	if m2.Name != m.Name || m2.Schema != m.Schema || !m2.UpdatedAt.Equal(m.UpdatedAt) {
+		t.Fatalf("expected decoded metadata %+v to equal %+v", m2, m)
+	}
+
+	// signatures are bound to the thread and the fields
+	if err = m.Verify(NewIDV1(Raw, 32)); !errors.Is(err, ErrInvalidMetadata) {
+		t.Fatalf("expected metadata of another thread to be invalid, got %v", err)
+	}
+	tampered := m
+	tampered.Name = "other"
+	if err = tampered.Verify(id); !errors.Is(err, ErrInvalidMetadata) {
+		t.Fatalf("expected tampered metadata to be invalid, got %v", err)
+	}
+	tampered = m
+	tampered.Description = strings.Repeat("x", MaxMetadataSize)
+	if err = tampered.Validate(); !errors.Is(err, ErrInvalidMetadata) {
+		t.Fatalf("expected oversized metadata to be invalid, got %v", err)
+	}
+}
+
+func TestMetadata_After(t *testing.T) {
+	now := time.Now()
+	older := Metadata{UpdatedAt: now, Sig: []byte{2}}
+	newer := Metadata{UpdatedAt: now.Add(time.Second), Sig: []byte{1}}
+	if !newer.After(older) || older.After(newer) {
+		t.Fatal("expected the latest update to win")
+	}
+	tie := Metadata{UpdatedAt: now, Sig: []byte{3}}
+	if !tie.After(older) || older.After(tie) {
+		t.Fatal("expected concurrent updates to be ordered by signature")
+	}
+	if older.After(older) {
+		t.Fatal("expected metadata not to supersede itself")
+	}
+}
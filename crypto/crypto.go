@@ -8,10 +8,21 @@ import (
 	"github.com/textileio/go-threads/crypto/symmetric"
 )
 
-// EncryptionKey represents a key that can be used for encryption.
-type EncryptionKey interface {
+// Encrypter encrypts bytes, e.g., with a key or a scheme of multiple keys.
+type Encrypter interface {
 	// Encrypt bytes.
 	Encrypt([]byte) ([]byte, error)
+}
+
+// Decrypter decrypts bytes sealed by an Encrypter.
+type Decrypter interface {
+	// Decrypt bytes.
+	Decrypt([]byte) ([]byte, error)
+}
+
+// EncryptionKey represents a key that can be used for encryption.
+type EncryptionKey interface {
+	Encrypter
 
 	// Marshal to bytes.
 	MarshalBinary() ([]byte, error)
@@ -20,9 +31,7 @@ type EncryptionKey interface {
 // EncryptionKey represents a key that can be used for decryption.
 type DecryptionKey interface {
 	EncryptionKey
-
-	// Decrypt bytes.
-	Decrypt([]byte) ([]byte, error)
+	Decrypter
 }
 
 // EncryptionKeyFromBytes returns an EncryptionKey from k.
@@ -0,0 +1,135 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8). Every byte of a secret
+// is the constant term of a random polynomial of degree threshold-1. A share holds the values
+// of the polynomials at a distinct non-zero point, which is appended as the last byte.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// MaxParts is the maximal number of shares of a secret.
+const MaxParts = 255
+
+// ErrInvalidShares indicates that shares are malformed or don't belong to the same secret.
+var ErrInvalidShares = errors.New("invalid shares")
+
+// exp and logs are the exponent and logarithm tables of GF(2^8) with the
+// AES polynomial and generator 3.
+var (
+	exp  [510]byte
+	logs [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		exp[i+255] = x
+		logs[x] = byte(i)
+		// multiply by the generator: x*3 = x*2 ^ x
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x = x2 ^ x
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return exp[int(logs[a])+int(logs[b])]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return exp[int(logs[a])+255-int(logs[b])]
+}
+
+// Split divides a secret into parts shares, any threshold of which recover it.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	switch {
+	case len(secret) == 0:
+		return nil, fmt.Errorf("secret is empty")
+	case parts < threshold:
+		return nil, fmt.Errorf("parts can't be less than the threshold")
+	case parts > MaxParts:
+		return nil, fmt.Errorf("parts can't exceed %d", MaxParts)
+	case threshold < 2:
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+	coeffs := make([]byte, threshold)
+	for j, b := range secret {
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		coeffs[0] = b
+		for _, share := range shares {
+			share[j] = eval(coeffs, share[len(secret)])
+		}
+	}
+	return shares, nil
+}
+
+// eval evaluates a polynomial at x using Horner's method.
+func eval(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// Combine recovers a secret from at least threshold of its shares. Combining fewer shares
+// doesn't fail, but returns garbage, so the recovered secret should be checked by the caller.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least 2 shares are required: %w", ErrInvalidShares)
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, ErrInvalidShares
+	}
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]struct{}, len(shares))
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, fmt.Errorf("shares differ in length: %w", ErrInvalidShares)
+		}
+		x := share[size-1]
+		if _, ok := seen[x]; ok || x == 0 {
+			return nil, fmt.Errorf("duplicate share: %w", ErrInvalidShares)
+		}
+		seen[x] = struct{}{}
+		xs[i] = x
+	}
+
+	// Lagrange interpolation at zero, addition and subtraction are both xor
+	secret := make([]byte, size-1)
+	for j := range secret {
+		var y byte
+		for i, xi := range xs {
+			basis := byte(1)
+			for k, xk := range xs {
+				if k != i {
+					basis = mul(basis, div(xk, xk^xi))
+				}
+			}
+			y ^= mul(shares[i][j], basis)
+		}
+		secret[j] = y
+	}
+	return secret, nil
+}
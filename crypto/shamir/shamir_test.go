@@ -0,0 +1,57 @@
+package shamir_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/textileio/go-threads/crypto/shamir"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("thread key material")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, got %d", len(shares))
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var picked [][]byte
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		res, err := Combine(picked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(res, secret) {
+			t.Fatalf("shares %v recovered wrong secret", subset)
+		}
+	}
+
+	res, err := Combine(shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(res, secret) {
+		t.Fatal("expected fewer shares than the threshold not to recover the secret")
+	}
+}
+
+func TestCombine_Invalid(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Fatal("expected duplicate shares to fail")
+	}
+	if _, err = Combine([][]byte{shares[0], shares[1][1:]}); err == nil {
+		t.Fatal("expected shares of different length to fail")
+	}
+	if _, err = Split([]byte("secret"), 2, 3); err == nil {
+		t.Fatal("expected threshold above parts to fail")
+	}
+}
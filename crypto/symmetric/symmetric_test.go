@@ -47,3 +47,23 @@ func TestDecrypt(t *testing.T) {
 		t.Error("decrypt AES with bad key succeeded")
 	}
 }
+
+func TestDecryptWithAD(t *testing.T) {
+	ciphertext, err := symmetricTestData.key.EncryptWithAD(symmetricTestData.plaintext, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := symmetricTestData.key.DecryptWithAD(ciphertext, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(symmetricTestData.plaintext) != string(plaintext) {
+		t.Error("decrypt AES with additional data failed")
+	}
+	if _, err = symmetricTestData.key.DecryptWithAD(ciphertext, []byte("other")); err == nil {
+		t.Error("decrypt AES with bad additional data succeeded")
+	}
+	if _, err = symmetricTestData.key.Decrypt(ciphertext); err == nil {
+		t.Error("decrypt AES without additional data succeeded")
+	}
+}
@@ -289,6 +289,16 @@ func (c *Collection) ModifiedSince(time int64, opts ...TxnOption) (ids []core.In
 	return
 }
 
+// ModifiedSinceClock returns a list of all instances that have been modified (and/or touched)
+// by records with clocks greater than `clock`.
+func (c *Collection) ModifiedSinceClock(clock uint64, opts ...TxnOption) (ids []core.InstanceID, err error) {
+	_ = c.ReadTxn(func(txn *Txn) error {
+		ids, err = txn.ModifiedSinceClock(clock)
+		return err
+	}, opts...)
+	return
+}
+
 // validInstance validates the json object against the collection schema.
 func (c *Collection) validInstance(v []byte) error {
 	r, err := gojsonschema.Validate(c.schemaLoader, gojsonschema.NewBytesLoader(v))
@@ -642,12 +652,16 @@ func (t *Txn) Commit() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), createNetRecordTimeout)
 	defer cancel()
-	if _, err = t.collection.db.connector.CreateNetRecord(ctx, node, t.token); err != nil {
+	rec, err := t.collection.db.connector.CreateNetRecord(ctx, node, t.token)
+	if err != nil {
 		return err
 	}
 	if err = t.collection.db.dispatcher.Dispatch(events); err != nil {
 		return err
 	}
+	if err = t.collection.db.indexClock(rec.Value().Clock(), events); err != nil {
+		return err
+	}
 	return t.collection.db.notifyTxnEvents(node, t.token)
 }
 
@@ -2,6 +2,7 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
@@ -11,6 +12,7 @@ import (
 
 	logging "github.com/ipfs/go-log"
 	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/util"
 	"github.com/xeipuuv/gojsonschema"
 )
@@ -958,6 +960,57 @@ func TestModifiedSince(t *testing.T) {
 	})
 }
 
+func TestModifiedSinceClock(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	alice, err := c.Create(util.JSONFromInstance(Person{Name: "Alice", Age: 42}))
+	checkErr(t, err)
+	recs, err := db.connector.Net.Records(context.Background(), db.connector.ThreadID(), net.WithRecordsReverse())
+	checkErr(t, err)
+	clock := (<-recs).Value().Clock()
+	for range recs {
+	}
+	if clock == 0 {
+		t.Fatal("expected record to have a clock")
+	}
+	bill, err := c.Create(util.JSONFromInstance(Person{Name: "Bill", Age: 33}))
+	checkErr(t, err)
+
+	mods, err := c.ModifiedSinceClock(clock - 1)
+	checkErr(t, err)
+	if len(mods) != 2 {
+		t.Fatalf("should have had %d modified instances", 2)
+	}
+	mods, err = c.ModifiedSinceClock(clock)
+	checkErr(t, err)
+	if len(mods) != 1 || mods[0] != bill {
+		t.Fatalf("should have modified id %s only, not %s", bill, alice)
+	}
+
+	// instances modified by records without a clock can't be ordered, so they're always returned
+	carl := core.NewInstanceID()
+	events, _, err := db.eventcodec.Create([]core.Action{{
+		Type:           core.Create,
+		InstanceID:     carl,
+		CollectionName: c.name,
+		Current:        util.JSONFromInstance(Person{ID: carl, Name: "Carl", Age: 21}),
+	}})
+	checkErr(t, err)
+	checkErr(t, db.indexClock(0, events))
+	mods, err = c.ModifiedSinceClock(clock)
+	checkErr(t, err)
+	if len(mods) != 2 {
+		t.Fatalf("should have modified ids %s and %s, got %v", bill, carl, mods)
+	}
+}
+
 func TestVerifyInstance(t *testing.T) {
 	t.Parallel()
 	t.Run("WithoutWriteValidator", func(t *testing.T) {
@@ -50,6 +50,8 @@ var (
 	ErrInvalidCollectionSchema = errors.New("the collection schema _id property must be a string")
 	// ErrCannotIndexIDField indicates a custom index was specified on the ID field.
 	ErrCannotIndexIDField = errors.New("cannot create custom index on " + idFieldName)
+	// ErrResolverMismatch indicates the db was created with another conflict resolver.
+	ErrResolverMismatch = errors.New("db was created with another conflict resolver")
 
 	nameRx *regexp.Regexp
 
@@ -59,6 +61,9 @@ var (
 	dsIndexes    = dsPrefix.ChildString("index")
 	dsValidators = dsPrefix.ChildString("validator")
 	dsFilters    = dsPrefix.ChildString("filter")
+	dsClock      = dsPrefix.ChildString("clock")
+	dsUnclocked  = dsPrefix.ChildString("unclocked")
+	dsResolver   = dsPrefix.ChildString("resolver")
 )
 
 func init() {
@@ -215,7 +220,13 @@ func newDB(s kt.TxnDatastoreExtended, n app.Net, id thread.ID, opts *NewOptions)
 	}
 	d.dispatcher.Register(d)
 
-	connector, err := n.ConnectApp(d, id)
+	if opts.Resolver == nil {
+		opts.Resolver = app.LastWriterWins
+	}
+	if err := d.saveResolver(opts.Resolver); err != nil {
+		return nil, err
+	}
+	connector, err := n.ConnectApp(d, id, app.WithConflictResolver(opts.Resolver))
 	if err != nil {
 		return nil, err
 	}
@@ -252,6 +263,21 @@ func (d *DB) loadName() error {
 	return nil
 }
 
+// saveResolver saves the name of the conflict resolver, failing with ErrResolverMismatch
+// if the db was created with another one, since instances resolved differently diverge.
+func (d *DB) saveResolver(r app.ConflictResolver) error {
+	name, err := d.datastore.Get(dsResolver)
+	if errors.Is(err, ds.ErrNotFound) {
+		return d.datastore.Put(dsResolver, []byte(r.Name()))
+	} else if err != nil {
+		return err
+	}
+	if string(name) != r.Name() {
+		return fmt.Errorf("%w %s", ErrResolverMismatch, name)
+	}
+	return nil
+}
+
 // reCreateCollections loads and registers schemas from the datastore.
 func (d *DB) reCreateCollections() error {
 	d.lock.Lock()
@@ -458,8 +484,8 @@ func (d *DB) saveCollection(c *Collection) error {
 
 // GetCollection returns a collection by name.
 func (d *DB) GetCollection(name string, opts ...Option) *Collection {
-	d.lock.Lock()
-	defer d.lock.Unlock()
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	args := &Options{}
 	for _, opt := range opts {
 		opt(args)
@@ -472,8 +498,8 @@ func (d *DB) GetCollection(name string, opts ...Option) *Collection {
 
 // ListCollections returns all collections.
 func (d *DB) ListCollections(opts ...Option) []*Collection {
-	d.lock.Lock()
-	defer d.lock.Unlock()
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	args := &Options{}
 	for _, opt := range opts {
 		opt(args)
@@ -528,11 +554,13 @@ func (d *DB) DeleteCollection(name string, opts ...Option) error {
 	return nil
 }
 
+// Close closes the db. Transactions hold the txnlock while reducing events, which
+// looks up collections under the lock, so the txnlock is always acquired first.
 func (d *DB) Close() error {
-	d.lock.Lock()
-	defer d.lock.Unlock()
 	d.txnlock.Lock()
 	defer d.txnlock.Unlock()
+	d.lock.Lock()
+	defer d.lock.Unlock()
 
 	if d.closed {
 		return nil
@@ -544,7 +572,15 @@ func (d *DB) Close() error {
 }
 
 func (d *DB) Reduce(events []core.Event) error {
-	codecActions, err := d.eventcodec.Reduce(events, d.datastore, baseKey, defaultIndexFunc(d))
+	var (
+		codecActions []core.ReduceAction
+		err          error
+	)
+	if rc, ok := d.eventcodec.(core.ResolvingEventCodec); ok {
+		codecActions, err = rc.ReduceResolved(events, d.datastore, baseKey, defaultIndexFunc(d), d.resolve)
+	} else {
+		codecActions, err = d.eventcodec.Reduce(events, d.datastore, baseKey, defaultIndexFunc(d))
+	}
 	if err != nil {
 		return err
 	}
@@ -567,6 +603,23 @@ func (d *DB) Reduce(events []core.Event) error {
 	return nil
 }
 
+// resolve reconciles a field of a stored instance with a concurrent update using the thread's
+// conflict resolver. Local changes are resolved too, so every replica applies the same rules.
+func (d *DB) resolve(
+	key ds.Key,
+	field string,
+	current []byte,
+	currentTime int64,
+	incoming []byte,
+	incomingTime int64,
+) ([]byte, int64, error) {
+	res, err := d.connector.ResolveConflict(
+		app.Update{Key: key.String(), Field: field, Value: current, Time: currentTime},
+		app.Update{Key: key.String(), Field: field, Value: incoming, Time: incomingTime},
+	)
+	return res.Value, res.Time, err
+}
+
 func defaultIndexFunc(d *DB) func(collection string, key ds.Key, oldData, newData []byte, txn ds.Txn) error {
 	return func(collection string, key ds.Key, oldData, newData []byte, txn ds.Txn) error {
 		c := d.GetCollection(collection)
@@ -619,7 +672,14 @@ func (d *DB) HandleNetRecord(ctx context.Context, rec net.ThreadRecord, key thre
 			return fmt.Errorf("error when decoding block to event: %v", err)
 		}
 	}
-	body, err := event.GetBody(ctx, d.connector.Net, key.Read())
+	dec, err := d.connector.Net.RecordDecrypter(ctx, rec.ThreadID())
+	if err != nil {
+		return fmt.Errorf("error when getting decrypter of thread %s: %v", rec.ThreadID(), err)
+	}
+	if dec == nil {
+		return fmt.Errorf("read key of thread %s not found", rec.ThreadID())
+	}
+	body, err := event.GetBody(ctx, d.connector.Net, dec)
 	if err != nil {
 		return fmt.Errorf("error when getting body of event on thread %s/%s: %v", d.connector.ThreadID(), rec.LogID(), err)
 	}
@@ -628,7 +688,27 @@ func (d *DB) HandleNetRecord(ctx context.Context, rec net.ThreadRecord, key thre
 		return fmt.Errorf("error when unmarshaling event from bytes: %v", err)
 	}
 	log.Debugf("dispatching new record: %s/%s", rec.ThreadID(), rec.LogID())
-	return d.dispatch(events)
+	if err = d.dispatch(events); err != nil {
+		return err
+	}
+	return d.indexClock(rec.Value().Clock(), events)
+}
+
+// indexClock indexes the instances touched by the events of a record under the record clock,
+// see Txn.ModifiedSinceClock. Instances touched by records without a clock are indexed apart.
+// Key format: <clock>/<collection>:<instance-id>
+func (d *DB) indexClock(clock uint64, events []core.Event) error {
+	prefix := dsClock.ChildString(fmt.Sprintf("%020d", clock))
+	if clock == 0 {
+		prefix = dsUnclocked
+	}
+	for _, e := range events {
+		key := prefix.ChildString(e.Collection()).Instance(e.InstanceID().String())
+		if err := d.datastore.Put(key, []byte{}); err != nil {
+			return fmt.Errorf("indexing clock of instance %s: %w", e.InstanceID(), err)
+		}
+	}
+	return nil
 }
 
 // getBlockWithRetry gets a record block with exponential backoff.
@@ -649,6 +729,7 @@ func (d *DB) getBlockWithRetry(ctx context.Context, rec net.Record) (format.Node
 
 // dispatch applies external events to the db. This function guarantee
 // no interference with registered collection states, and viceversa.
+// Events are reconciled with concurrent updates of the instances.
 func (d *DB) dispatch(events []core.Event) error {
 	d.txnlock.Lock()
 	defer d.txnlock.Unlock()
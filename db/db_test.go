@@ -124,6 +124,102 @@ func TestE2EWithThreads(t *testing.T) {
 	}
 }
 
+func TestConcurrentWritesWithThreads(t *testing.T) {
+	t.Parallel()
+
+	tmpDir1, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer os.RemoveAll(tmpDir1)
+	n1, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(tmpDir1),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+		common.WithNetDebug(true),
+	)
+	checkErr(t, err)
+	defer n1.Close()
+	store1, err := util.NewBadgerDatastore(tmpDir1, "eventstore", false)
+	checkErr(t, err)
+	defer store1.Close()
+
+	cc := CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	}
+	id1 := thread.NewIDV1(thread.Raw, 32)
+	d1, err := NewDB(context.Background(), store1, n1, id1, WithNewCollections(cc))
+	checkErr(t, err)
+	defer d1.Close()
+
+	peer1ID, err := multiaddr.NewComponent("p2p", n1.Host().ID().String())
+	checkErr(t, err)
+	threadComp, err := multiaddr.NewComponent("thread", id1.String())
+	checkErr(t, err)
+	addr := n1.Host().Addrs()[0].Encapsulate(peer1ID).Encapsulate(threadComp)
+	ti, err := n1.GetThread(context.Background(), id1)
+	checkErr(t, err)
+
+	tmpDir2, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer os.RemoveAll(tmpDir2)
+	n2, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(tmpDir2),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+		common.WithNetDebug(true),
+	)
+	checkErr(t, err)
+	defer n2.Close()
+	store2, err := util.NewBadgerDatastore(tmpDir2, "eventstore", false)
+	checkErr(t, err)
+	defer store2.Close()
+	d2, err := NewDBFromAddr(context.Background(), store2, n2, addr, ti.Key, WithNewCollections(cc))
+	checkErr(t, err)
+	defer d2.Close()
+
+	// Records pushed by peer2 are handled by db1 while it writes records of its own.
+	const writes = 50
+	var wg sync.WaitGroup
+	for _, d := range []*DB{d1, d2} {
+		c := d.GetCollection("dummy")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				if _, err := c.Create(util.JSONFromInstance(dummy{Name: "Textile", Counter: i})); err != nil {
+					t.Errorf("creating instance: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("concurrent writes didn't complete")
+	}
+
+	// closing must not wait for records still being handled
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	closed := make(chan error, 2)
+	go func() { closed <- d1.Close() }()
+	go func() { closed <- d2.Close() }()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-closed:
+			checkErr(t, err)
+		case <-ctx.Done():
+			t.Fatal("closing dbs didn't complete")
+		}
+	}
+}
+
 func TestMissingCollection(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@ package db
 
 import (
 	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/db"
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/jsonpatcher"
@@ -19,6 +20,7 @@ type NewOptions struct {
 	Collections []CollectionConfig
 	Block       bool
 	EventCodec  core.EventCodec
+	Resolver    app.ConflictResolver
 	Token       thread.Token
 	Debug       bool
 }
@@ -76,6 +78,16 @@ func WithNewEventCodec(ec core.EventCodec) NewOption {
 	}
 }
 
+// WithNewConflictResolver sets the strategy reconciling instances updated concurrently
+// by peers, if the event codec supports it. Defaults to app.LastWriterWins. Every replica
+// of the db must use the same resolver, and reopening the db with another one fails with
+// ErrResolverMismatch.
+func WithNewConflictResolver(r app.ConflictResolver) NewOption {
+	return func(o *NewOptions) {
+		o.Resolver = r
+	}
+}
+
 // WithNewToken provides authorization for interacting with a db.
 func WithNewToken(t thread.Token) NewOption {
 	return func(o *NewOptions) {
@@ -538,3 +538,61 @@ func (t *Txn) ModifiedSince(time int64) (ids []core.InstanceID, err error) {
 	}
 	return ids, nil
 }
+
+// ModifiedSinceClock returns a list of all instances that have been modified (and/or touched) by
+// records with clocks greater than `clock`, see net.Record.Clock. Unlike ModifiedSince, it doesn't
+// depend on the wall clocks of the hosts writing to the thread. Records without a clock, e.g.,
+// created by hosts running older versions, can't be ordered, so the instances they modified are
+// always returned.
+func (t *Txn) ModifiedSinceClock(clock uint64) (ids []core.InstanceID, err error) {
+	txn, err := t.collection.db.datastore.NewTransactionExtended(true)
+	if err != nil {
+		return nil, err
+	}
+	defer txn.Discard()
+
+	set := make(map[core.InstanceID]struct{})
+	queries := []dse.QueryExt{{
+		Query: query.Query{
+			Prefix: dsClock.String(),
+			Filters: []query.Filter{
+				filter{
+					Collection: t.collection.name,
+				},
+			},
+			KeysOnly: true,
+		},
+		SeekPrefix: dsClock.ChildString(fmt.Sprintf("%020d", clock+1)).String(),
+	}, {
+		Query: query.Query{
+			Prefix: dsUnclocked.String(),
+			Filters: []query.Filter{
+				filter{
+					Collection: t.collection.name,
+					Time:       -1,
+				},
+			},
+			KeysOnly: true,
+		},
+	}}
+	for _, q := range queries {
+		res, err := txn.QueryExtended(q)
+		if err != nil {
+			return nil, err
+		}
+		for r := range res.Next() {
+			if r.Error != nil {
+				res.Close()
+				return nil, r.Error
+			}
+			id := ds.NewKey(r.Key)
+			set[core.InstanceID(id.Name())] = struct{}{}
+		}
+		res.Close()
+	}
+	ids = make([]core.InstanceID, 0, len(set))
+	for k := range set {
+		ids = append(ids, k)
+	}
+	return ids, nil
+}
@@ -40,6 +40,7 @@ func (ot operationType) String() (s string) {
 
 var (
 	log                           = logging.Logger("jsonpatcher")
+	updatedPrefix                 = ds.NewKey("_updated")
 	errCantCreateExistingInstance = errors.New("cant't create already existent instance")
 	errUnknownOperation           = errors.New("unknown operation type")
 )
@@ -50,9 +51,15 @@ type operation struct {
 	JSONPatch  []byte
 }
 
+var actionTypes = map[operationType]core.ActionType{
+	create: core.Create,
+	save:   core.Save,
+	del:    core.Delete,
+}
+
 type jsonPatcher struct{}
 
-var _ core.EventCodec = (*jsonPatcher)(nil)
+var _ core.ResolvingEventCodec = (*jsonPatcher)(nil)
 
 func init() {
 	cbornode.RegisterCborType(patchEvent{})
@@ -108,6 +115,26 @@ func (jp *jsonPatcher) Reduce(
 	store ds.TxnDatastore,
 	baseKey ds.Key,
 	indexFunc core.IndexFunc,
+) ([]core.ReduceAction, error) {
+	return jp.reduce(events, store, baseKey, indexFunc, nil)
+}
+
+func (jp *jsonPatcher) ReduceResolved(
+	events []core.Event,
+	store ds.TxnDatastore,
+	baseKey ds.Key,
+	indexFunc core.IndexFunc,
+	resolve core.ResolveFunc,
+) ([]core.ReduceAction, error) {
+	return jp.reduce(events, store, baseKey, indexFunc, resolve)
+}
+
+func (jp *jsonPatcher) reduce(
+	events []core.Event,
+	store ds.TxnDatastore,
+	baseKey ds.Key,
+	indexFunc core.IndexFunc,
+	resolve core.ResolveFunc,
 ) ([]core.ReduceAction, error) {
 	txn, err := store.NewTransaction(false)
 	if err != nil {
@@ -126,65 +153,138 @@ func (jp *jsonPatcher) Reduce(
 		return ei.time().Before(ej.time())
 	})
 
-	actions := make([]core.ReduceAction, len(events))
-	for i, e := range events {
+	strict := resolve == nil
+	if strict {
+		resolve = lastWriterWins
+	}
+	actions := make([]core.ReduceAction, 0, len(events))
+	for _, e := range events {
 		je, ok := e.(patchEvent)
 		if !ok {
 			return nil, fmt.Errorf("event unrecognized for jsonpatcher eventcodec")
 		}
 		key := baseKey.ChildString(e.Collection()).ChildString(e.InstanceID().String())
+		prev, err := txn.Get(key)
+		if errors.Is(err, ds.ErrNotFound) {
+			prev = nil
+		} else if err != nil {
+			return nil, err
+		}
+		fields, err := objectFields(prev)
+		if err != nil {
+			return nil, err
+		}
+		times, err := getUpdated(txn, key, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		// changes holds the resulting values of the top-level fields changed by the event,
+		// nil for removed fields
+		updated := je.nanos()
+		changes := make(map[string]json.RawMessage)
 		switch je.Patch.Type {
 		case create:
-			exist, err := txn.Has(key)
-			if err != nil {
-				return nil, err
-			}
-			if exist {
+			if prev != nil && strict {
 				return nil, errCantCreateExistingInstance
 			}
-			if err := txn.Put(key, je.Patch.JSONPatch); err != nil {
-				return nil, fmt.Errorf("error when reducing create event: %w", err)
-			}
-			if err := indexFunc(e.Collection(), key, nil, je.Patch.JSONPatch, txn); err != nil {
-				return nil, fmt.Errorf("error when indexing created data: %w", err)
+			if changes, err = objectFields(je.Patch.JSONPatch); err != nil {
+				return nil, err
 			}
-			actions[i] = core.ReduceAction{Type: core.Create, Collection: e.Collection(), InstanceID: e.InstanceID()}
-			log.Debug("\tcreate operation applied")
+			// creations replace fields which were set before
+			times.kill(updated - 1)
 		case save:
-			value, err := txn.Get(key)
-			if errors.Is(err, ds.ErrNotFound) {
-				value = []byte("{}")
-			} else if err != nil {
-				return nil, err
+			base := prev
+			if base == nil {
+				base = []byte("{}")
 			}
-			patchedValue, err := jsonpatch.MergePatch(value, je.Patch.JSONPatch)
+			value, err := jsonpatch.MergePatch(base, je.Patch.JSONPatch)
 			if err != nil {
 				return nil, fmt.Errorf("error when reducing save event: %w", err)
 			}
-			if err = txn.Put(key, patchedValue); err != nil {
+			merged, err := objectFields(value)
+			if err != nil {
 				return nil, err
 			}
-			if err := indexFunc(e.Collection(), key, value, patchedValue, txn); err != nil {
-				return nil, fmt.Errorf("error when indexing created data: %w", err)
-			}
-			actions[i] = core.ReduceAction{Type: core.Save, Collection: e.Collection(), InstanceID: e.InstanceID()}
-			log.Debug("\tsave operation applied")
-		case del:
-			value, err := txn.Get(key)
+			patch, err := objectFields(je.Patch.JSONPatch)
 			if err != nil {
 				return nil, err
 			}
-			if err := txn.Delete(key); err != nil {
-				return nil, err
+			for field := range patch {
+				changes[field] = merged[field]
 			}
-			if err := indexFunc(e.Collection(), key, value, nil, txn); err != nil {
-				return nil, fmt.Errorf("error when removing index: %w", err)
+		case del:
+			if prev == nil && strict {
+				return nil, ds.ErrNotFound
 			}
-			actions[i] = core.ReduceAction{Type: core.Delete, Collection: e.Collection(), InstanceID: e.InstanceID()}
-			log.Debug("\tdelete operation applied")
+			times.kill(updated)
 		default:
 			return nil, errUnknownOperation
 		}
+
+		for field, t := range times.Fields {
+			if t <= times.Deleted {
+				delete(fields, field)
+			}
+		}
+		for field, v := range changes {
+			if updated <= times.Deleted {
+				// superseded by a later deletion or creation
+				break
+			}
+			var current []byte
+			if c, ok := fields[field]; ok {
+				current = c
+			}
+			var incoming []byte
+			if v != nil {
+				incoming = v
+			}
+			value, t, err := resolve(key, field, current, times.Fields[field], incoming, updated)
+			if err != nil {
+				return nil, fmt.Errorf("error when resolving %s event: %w", je.Patch.Type, err)
+			}
+			times.Fields[field] = t
+			if value == nil || t <= times.Deleted {
+				delete(fields, field)
+			} else {
+				fields[field] = value
+			}
+		}
+		if err = putUpdated(txn, key, times); err != nil {
+			return nil, err
+		}
+
+		var value []byte
+		if len(fields) > 0 {
+			if value, err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
+		}
+		if bytes.Equal(prev, value) && (prev == nil) == (value == nil) {
+			log.Debugf("	%s operation superseded", je.Patch.Type)
+			continue
+		}
+		var actionType core.ActionType
+		switch {
+		case value == nil:
+			actionType = core.Delete
+			err = txn.Delete(key)
+		case prev == nil:
+			actionType = core.Create
+			err = txn.Put(key, value)
+		default:
+			actionType = core.Save
+			err = txn.Put(key, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error when reducing %s event: %w", je.Patch.Type, err)
+		}
+		if err := indexFunc(e.Collection(), key, prev, value, txn); err != nil {
+			return nil, fmt.Errorf("error when indexing %s data: %w", je.Patch.Type, err)
+		}
+		actions = append(actions, core.ReduceAction{Type: actionType, Collection: e.Collection(), InstanceID: e.InstanceID()})
+		log.Debugf("	%s operation applied", je.Patch.Type)
 	}
 	if err := txn.Commit(); err != nil {
 		return nil, err
@@ -193,6 +293,82 @@ func (jp *jsonPatcher) Reduce(
 	return actions, nil
 }
 
+// lastWriterWins resolves updates of instances reduced without a resolver by their times.
+func lastWriterWins(_ ds.Key, _ string, current []byte, currentTime int64, incoming []byte, incomingTime int64) ([]byte, int64, error) {
+	if incomingTime > currentTime ||
+		incomingTime == currentTime && bytes.Compare(incoming, current) > 0 {
+		return incoming, incomingTime, nil
+	}
+	return current, currentTime, nil
+}
+
+// objectFields returns the top-level fields of a JSON object, empty if data is nil.
+func objectFields(data []byte) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if data == nil {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("instance isn't a JSON object: %w", err)
+	}
+	return fields, nil
+}
+
+// updateTimes are the times of the updates of an instance. Concurrent updates are resolved
+// field by field, so replicas end up with the same fields regardless of the order they
+// receive updates in. Deletions are kept as tombstones.
+type updateTimes struct {
+	// Deleted is the time of the latest deletion. Fields updated at or before it are gone.
+	Deleted int64 `json:"d,omitempty"`
+	// Fields are the times of the latest updates of the top-level fields, also of removed ones.
+	Fields map[string]int64 `json:"f,omitempty"`
+}
+
+// kill removes the fields updated at or before t.
+func (ut *updateTimes) kill(t int64) {
+	if t > ut.Deleted {
+		ut.Deleted = t
+	}
+}
+
+// getUpdated returns the update times of an instance with the given fields.
+// Instances reduced before fields were tracked take the time of their latest update for every field.
+func getUpdated(txn ds.Txn, key ds.Key, fields map[string]json.RawMessage) (*updateTimes, error) {
+	times := &updateTimes{Fields: make(map[string]int64)}
+	v, err := txn.Get(updatedPrefix.Child(key))
+	if errors.Is(err, ds.ErrNotFound) {
+		return times, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(v) == 8 {
+		updated := int64(binary.BigEndian.Uint64(v))
+		if len(fields) == 0 {
+			times.Deleted = updated
+		}
+		for field := range fields {
+			times.Fields[field] = updated
+		}
+		return times, nil
+	}
+	if err = json.Unmarshal(v, times); err != nil {
+		return nil, fmt.Errorf("invalid update times of %s: %w", key, err)
+	}
+	if times.Fields == nil {
+		times.Fields = make(map[string]int64)
+	}
+	return times, nil
+}
+
+// putUpdated saves the update times of an instance.
+func putUpdated(txn ds.Txn, key ds.Key, times *updateTimes) error {
+	v, err := json.Marshal(times)
+	if err != nil {
+		return err
+	}
+	return txn.Put(updatedPrefix.Child(key), v)
+}
+
 type recordEvents struct {
 	Patches []patchEvent
 }
@@ -261,6 +437,21 @@ func (je patchEvent) Time() []byte {
 	return buf.Bytes()
 }
 
+// nanos returns the timestamp in nanoseconds, also if it was decoded as an unsigned integer.
+func (je patchEvent) nanos() int64 {
+	switch ts := je.Timestamp.(type) {
+	case time.Time:
+		return ts.UnixNano()
+	case int64:
+		return ts
+	case int:
+		return int64(ts)
+	case uint64:
+		return int64(ts)
+	}
+	return 0
+}
+
 func (je patchEvent) time() (t time.Time) {
 	switch ts := je.Timestamp.(type) {
 	case time.Time:
@@ -3,12 +3,19 @@ package jsonpatcher
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
+	ds "github.com/ipfs/go-datastore"
 	cbornode "github.com/ipfs/go-ipld-cbor"
 	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/util"
 )
 
 type patchEventOld struct {
@@ -79,3 +86,156 @@ func TestJsonPatcher_Migration(t *testing.T) {
 		t.Error("encodable time should be equal to input")
 	}
 }
+
+func TestJsonPatcher_ReduceResolved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := util.NewBadgerDatastore(dir, "eventstore", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	jp := New().(core.ResolvingEventCodec)
+	baseKey := ds.NewKey("/db")
+	noIndex := func(string, ds.Key, []byte, []byte, ds.Txn) error { return nil }
+	resolve := resolveWith(app.LastWriterWins)
+	mustOp := func(op *operation, err error) *operation {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return op
+	}
+	event := func(ts int64, op *operation) []core.Event {
+		return []core.Event{patchEvent{Timestamp: ts, ID: "123", CollectionName: "abc", Patch: *op}}
+	}
+	reduce := func(events []core.Event, expected ...core.ActionType) {
+		actions, err := jp.ReduceResolved(events, store, baseKey, noIndex, resolve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actions) != len(expected) {
+			t.Fatalf("expected %d actions, got %d", len(expected), len(actions))
+		}
+		for i := range actions {
+			if actions[i].Type != expected[i] {
+				t.Fatalf("expected action %v, got %v", expected[i], actions[i].Type)
+			}
+		}
+	}
+	checkValue := func(expected string) {
+		v, err := store.Get(baseKey.ChildString("abc").ChildString("123"))
+		if expected == "" {
+			if !errors.Is(err, ds.ErrNotFound) {
+				t.Fatalf("expected instance to be deleted, got %s", v)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != expected {
+			t.Fatalf("expected value %s, got %s", expected, v)
+		}
+	}
+
+	reduce(event(10, mustOp(createEvent("123", []byte(`{"a":1}`)))), core.Create)
+	checkValue(`{"a":1}`)
+
+	// older concurrent updates are superseded
+	reduce(event(5, mustOp(saveEvent("123", []byte(`{"a":1}`), []byte(`{"a":2}`)))))
+	checkValue(`{"a":1}`)
+
+	reduce(event(20, mustOp(saveEvent("123", []byte(`{"a":1}`), []byte(`{"a":3}`)))), core.Save)
+	checkValue(`{"a":3}`)
+
+	reduce(event(15, mustOp(deleteEvent("123"))))
+	checkValue(`{"a":3}`)
+
+	reduce(event(30, mustOp(deleteEvent("123"))), core.Delete)
+	checkValue("")
+
+	// the deletion is kept as a tombstone
+	reduce(event(25, mustOp(createEvent("123", []byte(`{"a":4}`)))))
+	checkValue("")
+
+	reduce(event(40, mustOp(createEvent("123", []byte(`{"a":5}`)))), core.Create)
+	checkValue(`{"a":5}`)
+}
+
+func TestJsonPatcher_ReduceResolvedConverges(t *testing.T) {
+	jp := New().(core.ResolvingEventCodec)
+	baseKey := ds.NewKey("/db")
+	noIndex := func(string, ds.Key, []byte, []byte, ds.Txn) error { return nil }
+	mustOp := func(op *operation, err error) *operation {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return op
+	}
+	event := func(ts int64, op *operation) core.Event {
+		return patchEvent{Timestamp: ts, ID: "123", CollectionName: "abc", Patch: *op}
+	}
+	created := event(10, mustOp(createEvent("123", []byte(`{"_id":"123","x":0}`))))
+	// concurrent saves of different fields, and of the same field
+	saveX := event(20, mustOp(saveEvent("123", []byte(`{"_id":"123","x":0}`), []byte(`{"_id":"123","x":1}`))))
+	saveY := event(30, mustOp(saveEvent("123", []byte(`{"_id":"123","x":0}`), []byte(`{"_id":"123","x":0,"y":2}`))))
+	dropX := event(15, mustOp(saveEvent("123", []byte(`{"_id":"123","x":0}`), []byte(`{"_id":"123"}`))))
+
+	for name, resolver := range map[string]app.ConflictResolver{
+		"last-writer-wins": app.LastWriterWins,
+		"field-merge": app.FieldMerge("max", func(_, _ string, current, incoming json.RawMessage) (json.RawMessage, error) {
+			if bytes.Compare(current, incoming) > 0 {
+				return current, nil
+			}
+			return incoming, nil
+		}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var results []string
+			for _, order := range [][]core.Event{
+				{created, saveX, saveY, dropX},
+				{created, dropX, saveY, saveX},
+				{saveY, created, dropX, saveX},
+			} {
+				dir, err := ioutil.TempDir("", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(dir)
+				store, err := util.NewBadgerDatastore(dir, "eventstore", false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer store.Close()
+				for _, e := range order {
+					if _, err := jp.ReduceResolved([]core.Event{e}, store, baseKey, noIndex, resolveWith(resolver)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				v, err := store.Get(baseKey.ChildString("abc").ChildString("123"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				results = append(results, string(v))
+			}
+			for _, res := range results {
+				if res != `{"_id":"123","x":1,"y":2}` {
+					t.Fatalf("expected replicas to converge, got %v", results)
+				}
+			}
+		})
+	}
+}
+
+func resolveWith(r app.ConflictResolver) core.ResolveFunc {
+	return func(key ds.Key, field string, current []byte, currentTime int64, incoming []byte, incomingTime int64) ([]byte, int64, error) {
+		res, err := r.Resolve(
+			app.Update{Key: key.String(), Field: field, Value: current, Time: currentTime},
+			app.Update{Key: key.String(), Field: field, Value: incoming, Time: incomingTime})
+		return res.Value, res.Time, err
+	}
+}
@@ -0,0 +1,270 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/keystore"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+const (
+	hostKeyFile    = "host"
+	identitiesDir  = "identities"
+	threadsDir     = "threads"
+	logsDir        = "logs"
+	readKeyFile    = "read"
+	serviceKeyFile = "service"
+)
+
+// fileKeystore keeps every key in a file of its own under the root directory:
+//
+//	host
+//	identities/<name>
+//	threads/<thread>/read
+//	threads/<thread>/service
+//	threads/<thread>/logs/<log>
+type fileKeystore struct {
+	sync.RWMutex
+	root string
+}
+
+var _ core.Keystore = (*fileKeystore)(nil)
+
+// NewFileKeystore returns a keystore keeping keys in files under the directory,
+// which is created if it doesn't exist. Files are readable by the owner only.
+func NewFileKeystore(dir string) (core.Keystore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileKeystore{root: dir}, nil
+}
+
+func (f *fileKeystore) HostKey() (crypto.PrivKey, error) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.readPrivKey(filepath.Join(f.root, hostKeyFile))
+}
+
+func (f *fileKeystore) SetHostKey(key crypto.PrivKey) error {
+	if key == nil {
+		return errNilKey
+	}
+	f.Lock()
+	defer f.Unlock()
+	return f.writePrivKey(filepath.Join(f.root, hostKeyFile), key)
+}
+
+func (f *fileKeystore) AddIdentity(name string, key crypto.PrivKey) error {
+	if err := checkIdentity(name, key); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+	return f.writePrivKey(filepath.Join(f.root, identitiesDir, name), key)
+}
+
+func (f *fileKeystore) Identity(name string) (crypto.PrivKey, error) {
+	if !validIdentity(name) {
+		return nil, core.ErrIdentityNotFound
+	}
+	f.RLock()
+	defer f.RUnlock()
+	key, err := f.readPrivKey(filepath.Join(f.root, identitiesDir, name))
+	if err == nil && key == nil {
+		err = core.ErrIdentityNotFound
+	}
+	return key, err
+}
+
+func (f *fileKeystore) Identities() ([]string, error) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.list(filepath.Join(f.root, identitiesDir))
+}
+
+func (f *fileKeystore) LogKey(t thread.ID, l peer.ID) (crypto.PrivKey, error) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.readPrivKey(f.logPath(t, l))
+}
+
+func (f *fileKeystore) AddLogKey(t thread.ID, l peer.ID, key crypto.PrivKey) error {
+	if err := checkLogKey(l, key); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+	return f.writePrivKey(f.logPath(t, l), key)
+}
+
+func (f *fileKeystore) ReadKey(t thread.ID) (*sym.Key, error) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.readSymKey(filepath.Join(f.threadPath(t), readKeyFile))
+}
+
+func (f *fileKeystore) AddReadKey(t thread.ID, key *sym.Key) error {
+	if key == nil {
+		return errNilKey
+	}
+	f.Lock()
+	defer f.Unlock()
+	return f.write(filepath.Join(f.threadPath(t), readKeyFile), key.Bytes())
+}
+
+func (f *fileKeystore) ServiceKey(t thread.ID) (*sym.Key, error) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.readSymKey(filepath.Join(f.threadPath(t), serviceKeyFile))
+}
+
+func (f *fileKeystore) AddServiceKey(t thread.ID, key *sym.Key) error {
+	if key == nil {
+		return errNilKey
+	}
+	f.Lock()
+	defer f.Unlock()
+	return f.write(filepath.Join(f.threadPath(t), serviceKeyFile), key.Bytes())
+}
+
+func (f *fileKeystore) Logs(t thread.ID) (peer.IDSlice, error) {
+	f.RLock()
+	defer f.RUnlock()
+	names, err := f.list(filepath.Join(f.threadPath(t), logsDir))
+	if err != nil {
+		return nil, err
+	}
+	lids := make(peer.IDSlice, 0, len(names))
+	for _, name := range names {
+		lid, err := peer.Decode(name)
+		if err != nil {
+			log.Warnf("skipping unexpected key file %s: %v", name, err)
+			continue
+		}
+		lids = append(lids, lid)
+	}
+	return lids, nil
+}
+
+func (f *fileKeystore) Threads() (thread.IDSlice, error) {
+	f.RLock()
+	defer f.RUnlock()
+	names, err := f.list(filepath.Join(f.root, threadsDir))
+	if err != nil {
+		return nil, err
+	}
+	tids := make(thread.IDSlice, 0, len(names))
+	for _, name := range names {
+		tid, err := thread.Decode(name)
+		if err != nil {
+			log.Warnf("skipping unexpected key directory %s: %v", name, err)
+			continue
+		}
+		tids = append(tids, tid)
+	}
+	return tids, nil
+}
+
+func (f *fileKeystore) ClearThread(t thread.ID) error {
+	f.Lock()
+	defer f.Unlock()
+	return os.RemoveAll(f.threadPath(t))
+}
+
+func (f *fileKeystore) ClearLog(t thread.ID, l peer.ID) error {
+	f.Lock()
+	defer f.Unlock()
+	if err := os.Remove(f.logPath(t, l)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *fileKeystore) threadPath(t thread.ID) string {
+	return filepath.Join(f.root, threadsDir, t.String())
+}
+
+func (f *fileKeystore) logPath(t thread.ID, l peer.ID) string {
+	return filepath.Join(f.threadPath(t), logsDir, l.String())
+}
+
+func (f *fileKeystore) readPrivKey(pth string) (crypto.PrivKey, error) {
+	data, err := f.read(pth)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return crypto.UnmarshalPrivateKey(data)
+}
+
+func (f *fileKeystore) writePrivKey(pth string, key crypto.PrivKey) error {
+	data, err := crypto.MarshalPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return f.write(pth, data)
+}
+
+func (f *fileKeystore) readSymKey(pth string) (*sym.Key, error) {
+	data, err := f.read(pth)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return sym.FromBytes(data)
+}
+
+// read returns the contents of a key file, nil if it doesn't exist.
+func (f *fileKeystore) read(pth string) ([]byte, error) {
+	data, err := ioutil.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// write replaces a key file atomically, so a crash never leaves a partially written key.
+func (f *fileKeystore) write(pth string, data []byte) error {
+	dir := filepath.Dir(pth)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), pth)
+}
+
+// list returns the names of key files or directories under dir, skipping temporary files.
+func (f *fileKeystore) list(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if name := info.Name(); name[0] != '.' {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
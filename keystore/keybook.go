@@ -0,0 +1,228 @@
+package keystore
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/keystore"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+// keyBook keeps public keys in the wrapped key book, and secret keys in the keystore.
+// Secret keys added to the key book before it was wrapped are still read from it.
+type keyBook struct {
+	lstore.KeyBook
+	ks core.Keystore
+}
+
+var _ lstore.KeyBook = (*keyBook)(nil)
+
+// NewKeyBook wraps a key book, so the private keys of logs and thread keys are added to
+// the keystore instead, e.g., to build a logstore with lstore.NewLogstore.
+func NewKeyBook(kb lstore.KeyBook, ks core.Keystore) lstore.KeyBook {
+	return &keyBook{KeyBook: kb, ks: ks}
+}
+
+func (b *keyBook) PrivKey(t thread.ID, l peer.ID) (crypto.PrivKey, error) {
+	key, err := b.ks.LogKey(t, l)
+	if err != nil || key != nil {
+		return key, err
+	}
+	return b.KeyBook.PrivKey(t, l)
+}
+
+func (b *keyBook) AddPrivKey(t thread.ID, l peer.ID, key crypto.PrivKey) error {
+	return b.ks.AddLogKey(t, l, key)
+}
+
+func (b *keyBook) ReadKey(t thread.ID) (*sym.Key, error) {
+	key, err := b.ks.ReadKey(t)
+	if err != nil || key != nil {
+		return key, err
+	}
+	return b.KeyBook.ReadKey(t)
+}
+
+func (b *keyBook) AddReadKey(t thread.ID, key *sym.Key) error {
+	return b.ks.AddReadKey(t, key)
+}
+
+func (b *keyBook) ServiceKey(t thread.ID) (*sym.Key, error) {
+	key, err := b.ks.ServiceKey(t)
+	if err != nil || key != nil {
+		return key, err
+	}
+	return b.KeyBook.ServiceKey(t)
+}
+
+func (b *keyBook) AddServiceKey(t thread.ID, key *sym.Key) error {
+	return b.ks.AddServiceKey(t, key)
+}
+
+func (b *keyBook) ClearKeys(t thread.ID) error {
+	if err := b.ks.ClearThread(t); err != nil {
+		return err
+	}
+	return b.KeyBook.ClearKeys(t)
+}
+
+func (b *keyBook) ClearLogKeys(t thread.ID, l peer.ID) error {
+	if err := b.ks.ClearLog(t, l); err != nil {
+		return err
+	}
+	return b.KeyBook.ClearLogKeys(t, l)
+}
+
+func (b *keyBook) LogsWithKeys(t thread.ID) (peer.IDSlice, error) {
+	lids, err := b.KeyBook.LogsWithKeys(t)
+	if err != nil {
+		return nil, err
+	}
+	held, err := b.ks.Logs(t)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[peer.ID]struct{}, len(lids))
+	for _, lid := range lids {
+		set[lid] = struct{}{}
+	}
+	for _, lid := range held {
+		if _, ok := set[lid]; !ok {
+			lids = append(lids, lid)
+		}
+	}
+	return lids, nil
+}
+
+func (b *keyBook) ThreadsFromKeys() (thread.IDSlice, error) {
+	tids, err := b.KeyBook.ThreadsFromKeys()
+	if err != nil {
+		return nil, err
+	}
+	held, err := b.ks.Threads()
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[thread.ID]struct{}, len(tids))
+	for _, tid := range tids {
+		set[tid] = struct{}{}
+	}
+	for _, tid := range held {
+		if _, ok := set[tid]; !ok {
+			tids = append(tids, tid)
+		}
+	}
+	return tids, nil
+}
+
+// ThreadsFromKeysAfter merges the page of the wrapped key book with the threads
+// holding secret keys in the keystore.
+func (b *keyBook) ThreadsFromKeysAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	tids, err := b.KeyBook.ThreadsFromKeysAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	held, err := b.ks.Threads()
+	if err != nil {
+		return nil, err
+	}
+	return lstore.PageThreads(append(tids, held...), after, limit), nil
+}
+
+// DumpKeys packs the public keys of the wrapped key book along with the secret keys
+// of both the key book and the keystore.
+func (b *keyBook) DumpKeys() (lstore.DumpKeyBook, error) {
+	dump, err := b.KeyBook.DumpKeys()
+	if err != nil {
+		return dump, err
+	}
+	if dump.Data.Private == nil {
+		dump.Data.Private = make(map[thread.ID]map[peer.ID]crypto.PrivKey)
+	}
+	if dump.Data.Read == nil {
+		dump.Data.Read = make(map[thread.ID][]byte)
+	}
+	if dump.Data.Service == nil {
+		dump.Data.Service = make(map[thread.ID][]byte)
+	}
+	tids, err := b.ks.Threads()
+	if err != nil {
+		return dump, err
+	}
+	for _, tid := range tids {
+		lids, err := b.ks.Logs(tid)
+		if err != nil {
+			return dump, err
+		}
+		for _, lid := range lids {
+			key, err := b.ks.LogKey(tid, lid)
+			if err != nil {
+				return dump, err
+			} else if key == nil {
+				continue
+			}
+			if dump.Data.Private[tid] == nil {
+				dump.Data.Private[tid] = make(map[peer.ID]crypto.PrivKey, len(lids))
+			}
+			dump.Data.Private[tid][lid] = key
+		}
+		if rk, err := b.ks.ReadKey(tid); err != nil {
+			return dump, err
+		} else if rk != nil {
+			dump.Data.Read[tid] = rk.Bytes()
+		}
+		if sk, err := b.ks.ServiceKey(tid); err != nil {
+			return dump, err
+		} else if sk != nil {
+			dump.Data.Service[tid] = sk.Bytes()
+		}
+	}
+	return dump, nil
+}
+
+// RestoreKeys restores public keys into the wrapped key book, and adds secret keys
+// to the keystore. Secret keys missing from the dump are kept in the keystore.
+func (b *keyBook) RestoreKeys(dump lstore.DumpKeyBook) error {
+	var public lstore.DumpKeyBook
+	public.Data.Public = dump.Data.Public
+	public.Data.Private = make(map[thread.ID]map[peer.ID]crypto.PrivKey)
+	public.Data.Read = make(map[thread.ID][]byte)
+	public.Data.Service = make(map[thread.ID][]byte)
+	if err := b.KeyBook.RestoreKeys(public); errors.Is(err, lstore.ErrEmptyDump) {
+		if len(dump.Data.Private) == 0 && len(dump.Data.Read) == 0 && len(dump.Data.Service) == 0 {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	for tid, logs := range dump.Data.Private {
+		for lid, key := range logs {
+			if err := b.ks.AddLogKey(tid, lid, key); err != nil {
+				return err
+			}
+		}
+	}
+	for tid, raw := range dump.Data.Read {
+		key, err := sym.FromBytes(raw)
+		if err != nil {
+			return err
+		}
+		if err = b.ks.AddReadKey(tid, key); err != nil {
+			return err
+		}
+	}
+	for tid, raw := range dump.Data.Service {
+		key, err := sym.FromBytes(raw)
+		if err != nil {
+			return err
+		}
+		if err = b.ks.AddServiceKey(tid, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package keystore provides keystores holding the secret keys of a node, and a key book
+// keeping the secret keys of a logstore in a keystore.
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var (
+	log = logging.Logger("keystore")
+
+	errNilKey = errors.New("key is nil")
+)
+
+// validIdentity returns whether the name of an identity is usable as a file name.
+func validIdentity(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, "/\\")
+}
+
+func checkIdentity(name string, key crypto.PrivKey) error {
+	if !validIdentity(name) {
+		return fmt.Errorf("invalid identity name: %q", name)
+	}
+	if key == nil {
+		return errNilKey
+	}
+	return nil
+}
+
+func checkLogKey(l peer.ID, key crypto.PrivKey) error {
+	if key == nil {
+		return errNilKey
+	}
+	if !l.MatchesPrivateKey(key) {
+		return errors.New("ID does not match PrivateKey")
+	}
+	return nil
+}
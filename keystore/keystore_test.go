@@ -0,0 +1,192 @@
+package keystore_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/keystore"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/keystore"
+	"github.com/textileio/go-threads/logstore/lstoremem"
+)
+
+func TestMemKeystore(t *testing.T) {
+	testKeystore(t, keystore.NewMemKeystore())
+}
+
+func TestFileKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks, err := keystore.NewFileKeystore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testKeystore(t, ks)
+
+	// keys survive reopening
+	reopened, err := keystore.NewFileKeystore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names, err := reopened.Identities(); err != nil || len(names) != 1 || names[0] != "alice" {
+		t.Fatalf("expected identity to be kept, got %v (%v)", names, err)
+	}
+}
+
+func testKeystore(t *testing.T, ks core.Keystore) {
+	if key, err := ks.HostKey(); err != nil || key != nil {
+		t.Fatalf("expected no host key, got %v (%v)", key, err)
+	}
+	host := genKey(t)
+	if err := ks.SetHostKey(host); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := ks.HostKey(); err != nil || !key.Equals(host) {
+		t.Fatalf("expected host key, got %v (%v)", key, err)
+	}
+
+	alice := genKey(t)
+	if err := ks.AddIdentity("alice", alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.AddIdentity("../alice", alice); err == nil {
+		t.Fatal("expected invalid identity name to be rejected")
+	}
+	if key, err := ks.Identity("alice"); err != nil || !key.Equals(alice) {
+		t.Fatalf("expected identity key, got %v (%v)", key, err)
+	}
+	if _, err := ks.Identity("bob"); !errors.Is(err, core.ErrIdentityNotFound) {
+		t.Fatalf("expected missing identity, got %v", err)
+	}
+
+	tid := thread.NewIDV1(thread.Raw, 32)
+	lk := genKey(t)
+	lid, err := peer.IDFromPrivateKey(lk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ks.AddLogKey(tid, lid, alice); err == nil {
+		t.Fatal("expected mismatching log key to be rejected")
+	}
+	if err = ks.AddLogKey(tid, lid, lk); err != nil {
+		t.Fatal(err)
+	}
+	rk, sk := sym.New(), sym.New()
+	if err = ks.AddReadKey(tid, rk); err != nil {
+		t.Fatal(err)
+	}
+	if err = ks.AddServiceKey(tid, sk); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := ks.LogKey(tid, lid); err != nil || !key.Equals(lk) {
+		t.Fatalf("expected log key, got %v (%v)", key, err)
+	}
+	if key, err := ks.ReadKey(tid); err != nil || !bytes.Equal(key.Bytes(), rk.Bytes()) {
+		t.Fatalf("expected read key, got %v (%v)", key, err)
+	}
+	if key, err := ks.ServiceKey(tid); err != nil || !bytes.Equal(key.Bytes(), sk.Bytes()) {
+		t.Fatalf("expected service key, got %v (%v)", key, err)
+	}
+	if tids, err := ks.Threads(); err != nil || len(tids) != 1 || tids[0] != tid {
+		t.Fatalf("expected thread %s, got %v (%v)", tid, tids, err)
+	}
+	if lids, err := ks.Logs(tid); err != nil || len(lids) != 1 || lids[0] != lid {
+		t.Fatalf("expected log %s, got %v (%v)", lid, lids, err)
+	}
+
+	if err = ks.ClearLog(tid, lid); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := ks.LogKey(tid, lid); err != nil || key != nil {
+		t.Fatalf("expected log key to be cleared, got %v (%v)", key, err)
+	}
+	if err = ks.ClearThread(tid); err != nil {
+		t.Fatal(err)
+	}
+	if tids, err := ks.Threads(); err != nil || len(tids) != 0 {
+		t.Fatalf("expected no threads, got %v (%v)", tids, err)
+	}
+}
+
+func TestKeyBook(t *testing.T) {
+	var (
+		kb  = lstoremem.NewKeyBook()
+		ks  = keystore.NewMemKeystore()
+		tid = thread.NewIDV1(thread.Raw, 32)
+		old = thread.NewIDV1(thread.Raw, 32)
+		lk  = genKey(t)
+		rk  = sym.New()
+	)
+	lid, err := peer.IDFromPrivateKey(lk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// keys added before the key book was wrapped are still read
+	if err = kb.AddReadKey(old, rk); err != nil {
+		t.Fatal(err)
+	}
+
+	book := keystore.NewKeyBook(kb, ks)
+	if err = book.AddPubKey(tid, lid, lk.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	if err = book.AddPrivKey(tid, lid, lk); err != nil {
+		t.Fatal(err)
+	}
+	if err = book.AddReadKey(tid, rk); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := kb.PrivKey(tid, lid); err != nil || key != nil {
+		t.Fatalf("expected private key to be kept out of the key book, got %v (%v)", key, err)
+	}
+	if key, err := ks.LogKey(tid, lid); err != nil || !key.Equals(lk) {
+		t.Fatalf("expected private key in the keystore, got %v (%v)", key, err)
+	}
+	if key, err := book.ReadKey(old); err != nil || !bytes.Equal(key.Bytes(), rk.Bytes()) {
+		t.Fatalf("expected read key from the key book, got %v (%v)", key, err)
+	}
+	if tids, err := book.ThreadsFromKeys(); err != nil || len(tids) != 1 || tids[0] != tid {
+		t.Fatalf("expected thread %s, got %v (%v)", tid, tids, err)
+	}
+
+	// dumps carry secret keys, which are restored into the keystore
+	dump, err := book.DumpKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump.Data.Private[tid][lid] == nil || dump.Data.Read[tid] == nil || dump.Data.Read[old] == nil {
+		t.Fatalf("expected secret keys in the dump: %+v", dump.Data)
+	}
+	other := keystore.NewMemKeystore()
+	if err = keystore.NewKeyBook(lstoremem.NewKeyBook(), other).RestoreKeys(dump); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := other.LogKey(tid, lid); err != nil || !key.Equals(lk) {
+		t.Fatalf("expected restored private key, got %v (%v)", key, err)
+	}
+
+	if err = book.ClearKeys(tid); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := book.PrivKey(tid, lid); err != nil || key != nil {
+		t.Fatalf("expected cleared private key, got %v (%v)", key, err)
+	}
+}
+
+func genKey(t *testing.T) crypto.PrivKey {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sk
+}
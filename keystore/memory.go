@@ -0,0 +1,182 @@
+package keystore
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/keystore"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+type memKeystore struct {
+	sync.RWMutex
+
+	host       crypto.PrivKey
+	identities map[string]crypto.PrivKey
+	logs       map[thread.ID]map[peer.ID]crypto.PrivKey
+	read       map[thread.ID]*sym.Key
+	service    map[thread.ID]*sym.Key
+}
+
+var _ core.Keystore = (*memKeystore)(nil)
+
+// NewMemKeystore returns a keystore holding keys in memory, e.g., for tests
+// and nodes which don't outlive the process.
+func NewMemKeystore() core.Keystore {
+	return &memKeystore{
+		identities: make(map[string]crypto.PrivKey),
+		logs:       make(map[thread.ID]map[peer.ID]crypto.PrivKey),
+		read:       make(map[thread.ID]*sym.Key),
+		service:    make(map[thread.ID]*sym.Key),
+	}
+}
+
+func (m *memKeystore) HostKey() (crypto.PrivKey, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.host, nil
+}
+
+func (m *memKeystore) SetHostKey(key crypto.PrivKey) error {
+	if key == nil {
+		return errNilKey
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.host = key
+	return nil
+}
+
+func (m *memKeystore) AddIdentity(name string, key crypto.PrivKey) error {
+	if err := checkIdentity(name, key); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.identities[name] = key
+	return nil
+}
+
+func (m *memKeystore) Identity(name string) (crypto.PrivKey, error) {
+	m.RLock()
+	defer m.RUnlock()
+	key, ok := m.identities[name]
+	if !ok {
+		return nil, core.ErrIdentityNotFound
+	}
+	return key, nil
+}
+
+func (m *memKeystore) Identities() ([]string, error) {
+	m.RLock()
+	defer m.RUnlock()
+	names := make([]string, 0, len(m.identities))
+	for name := range m.identities {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m *memKeystore) LogKey(t thread.ID, l peer.ID) (crypto.PrivKey, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.logs[t][l], nil
+}
+
+func (m *memKeystore) AddLogKey(t thread.ID, l peer.ID, key crypto.PrivKey) error {
+	if err := checkLogKey(l, key); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.logs[t] == nil {
+		m.logs[t] = make(map[peer.ID]crypto.PrivKey, 1)
+	}
+	m.logs[t][l] = key
+	return nil
+}
+
+func (m *memKeystore) ReadKey(t thread.ID) (*sym.Key, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.read[t], nil
+}
+
+func (m *memKeystore) AddReadKey(t thread.ID, key *sym.Key) error {
+	if key == nil {
+		return errNilKey
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.read[t] = key
+	return nil
+}
+
+func (m *memKeystore) ServiceKey(t thread.ID) (*sym.Key, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.service[t], nil
+}
+
+func (m *memKeystore) AddServiceKey(t thread.ID, key *sym.Key) error {
+	if key == nil {
+		return errNilKey
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.service[t] = key
+	return nil
+}
+
+func (m *memKeystore) Logs(t thread.ID) (peer.IDSlice, error) {
+	m.RLock()
+	defer m.RUnlock()
+	lids := make(peer.IDSlice, 0, len(m.logs[t]))
+	for lid := range m.logs[t] {
+		lids = append(lids, lid)
+	}
+	return lids, nil
+}
+
+func (m *memKeystore) Threads() (thread.IDSlice, error) {
+	m.RLock()
+	defer m.RUnlock()
+	set := make(map[thread.ID]struct{}, len(m.service))
+	for t := range m.logs {
+		set[t] = struct{}{}
+	}
+	for t := range m.read {
+		set[t] = struct{}{}
+	}
+	for t := range m.service {
+		set[t] = struct{}{}
+	}
+	tids := make(thread.IDSlice, 0, len(set))
+	for t := range set {
+		tids = append(tids, t)
+	}
+	return tids, nil
+}
+
+func (m *memKeystore) ClearThread(t thread.ID) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.logs, t)
+	delete(m.read, t)
+	delete(m.service, t)
+	return nil
+}
+
+func (m *memKeystore) ClearLog(t thread.ID, l peer.ID) error {
+	m.Lock()
+	defer m.Unlock()
+	if logs := m.logs[t]; logs != nil {
+		delete(logs, l)
+		if len(logs) == 0 {
+			delete(m.logs, t)
+		}
+	}
+	return nil
+}
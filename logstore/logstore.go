@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -87,6 +88,27 @@ func (ls *logstore) Threads() (thread.IDSlice, error) {
 	return ids, nil
 }
 
+// ThreadsAfter returns a page of at most limit thread IDs following the cursor in index order.
+// The books are paged from the cursor, so the page is the merge of their pages.
+func (ls *logstore) ThreadsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	if limit <= 0 {
+		return thread.IDSlice{}, nil
+	}
+
+	ls.RLock()
+	defer ls.RUnlock()
+
+	threadsFromKeys, err := ls.ThreadsFromKeysAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	threadsFromAddrs, err := ls.ThreadsFromAddrsAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return core.PageThreads(append(threadsFromKeys, threadsFromAddrs...), after, limit), nil
+}
+
 // AddThread adds a thread with keys.
 func (ls *logstore) AddThread(info thread.Info) error {
 	ls.Lock()
@@ -233,7 +255,11 @@ func (ls *logstore) AddLog(id thread.ID, lg thread.LogInfo) error {
 	if err = ls.AddAddrs(id, lg.ID, lg.Addrs, pstore.PermanentAddrTTL); err != nil {
 		return err
 	}
-	if lg.Head.Defined() {
+	if len(lg.Heads) > 0 {
+		if err = ls.SetHeads(id, lg.ID, lg.Heads); err != nil {
+			return err
+		}
+	} else if lg.Head.Defined() {
 		if err = ls.SetHead(id, lg.ID, lg.Head); err != nil {
 			return err
 		}
@@ -287,7 +313,12 @@ func (ls *logstore) getLog(id thread.ID, lid peer.ID) (info thread.LogInfo, err
 	info.PrivKey = sk
 	info.Addrs = addrs
 	if len(heads) > 0 {
+		// order heads deterministically, as head books may keep them in a set
+		sort.Slice(heads, func(i, j int) bool {
+			return heads[i].KeyString() < heads[j].KeyString()
+		})
 		info.Head = heads[0]
+		info.Heads = heads
 	}
 	return
 }
@@ -223,6 +223,15 @@ func (ab *DsAddrBook) ThreadsFromAddrs() (thread.IDSlice, error) {
 	return ids, nil
 }
 
+func (ab *DsAddrBook) ThreadsFromAddrsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	// log addresses: /thread/addrs/<thread>/<log>
+	ids, err := threadsAfter(ab.ds, logBookBase, 4, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving thread from addresses: %w", err)
+	}
+	return ids, nil
+}
+
 func (ab *DsAddrBook) AddrsEdge(t thread.ID) (uint64, error) {
 	var key = dsThreadKey(t, logBookEdge)
 	if v, err := ab.ds.Get(key); err == nil {
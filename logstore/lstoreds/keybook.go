@@ -223,6 +223,16 @@ func (kb *dsKeyBook) ThreadsFromKeys() (thread.IDSlice, error) {
 	return ids, nil
 }
 
+// ThreadsFromKeysAfter returns a page of threads referenced in the book in index order.
+func (kb *dsKeyBook) ThreadsFromKeysAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	// log keys: /thread/keys/<thread>/<log>/(pub|priv)
+	ids, err := threadsAfter(kb.ds, kbBase, 5, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving threads from keys: %v", err)
+	}
+	return ids, nil
+}
+
 func (kb *dsKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 	var (
 		dump core.DumpKeyBook
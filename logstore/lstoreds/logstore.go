@@ -7,8 +7,11 @@ import (
 	ds "github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
 	"github.com/libp2p/go-libp2p-core/peer"
+	dse "github.com/textileio/go-datastore-extensions"
+	kcore "github.com/textileio/go-threads/core/keystore"
 	core "github.com/textileio/go-threads/core/logstore"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/keystore"
 	lstore "github.com/textileio/go-threads/logstore"
 	"github.com/whyrusleeping/base32"
 )
@@ -30,6 +33,9 @@ type Options struct {
 	// Initial delay before GC processes start. Intended to give the system breathing room to fully boot
 	// before starting GC.
 	GCInitialDelay time.Duration
+
+	// Keystore holds the private keys of logs and thread keys instead of the datastore if set.
+	Keystore kcore.Keystore
 }
 
 // DefaultOpts returns the default options for a persistent peerstore, with the full-purge GC algorithm:
@@ -56,6 +62,9 @@ func NewLogstore(ctx context.Context, store ds.Batching, opts Options) (core.Log
 	if err != nil {
 		return nil, err
 	}
+	if opts.Keystore != nil {
+		keyBook = keystore.NewKeyBook(keyBook, opts.Keystore)
+	}
 
 	threadMetadata := NewThreadMetadata(store)
 
@@ -100,6 +109,69 @@ func uniqueThreadIds(ds ds.Datastore, prefix ds.Key, extractor func(result query
 	return ids, nil
 }
 
+// threadsAfter returns up to limit unique thread IDs from database keys under the prefix,
+// starting right after the given thread in index order, see core.ThreadIndexLess. Keys are
+// queried in order from the cursor on, so a page doesn't list every thread of the book.
+// Only keys with the given number of namespaces are taken into account.
+func threadsAfter(store ds.Datastore, prefix ds.Key, depth int, after thread.ID, limit int) (thread.IDSlice, error) {
+	if limit <= 0 {
+		return thread.IDSlice{}, nil
+	}
+	var (
+		q = query.Query{
+			Prefix:   prefix.String(),
+			Orders:   []query.Order{query.OrderByKey{}},
+			KeysOnly: true,
+		}
+		start   = prefix
+		skip    string
+		results query.Results
+		err     error
+	)
+	if after.Defined() {
+		start = dsThreadKey(after, prefix)
+		skip = start.Name()
+	}
+	if ext, ok := store.(dse.QueryExtensions); ok {
+		results, err = ext.QueryExtended(dse.QueryExt{Query: q, SeekPrefix: start.String()})
+	} else {
+		q.Filters = []query.Filter{query.FilterKeyCompare{Op: query.GreaterThan, Key: start.String()}}
+		results, err = store.Query(q)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var (
+		ids  = make(thread.IDSlice, 0, limit)
+		last string
+	)
+	for result := range results.Next() {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		kns := ds.RawKey(result.Key).Namespaces()
+		if len(kns) != depth {
+			continue
+		}
+		// keys of a thread are adjacent in the index
+		name := kns[2]
+		if name == last || name == skip {
+			continue
+		}
+		last = name
+		id, err := parseThreadID(name)
+		if err != nil {
+			continue
+		}
+		if ids = append(ids, id); len(ids) == limit {
+			break
+		}
+	}
+	return ids, nil
+}
+
 // uniqueLogIds extracts and returns unique thread IDs from database keys.
 func uniqueLogIds(ds ds.Datastore, prefix ds.Key, extractor func(result query.Result) string) (peer.IDSlice, error) {
 	var (
@@ -181,6 +181,10 @@ func (l *lstore) ThreadsFromKeys() (thread.IDSlice, error) {
 	return l.inMem.ThreadsFromKeys()
 }
 
+func (l *lstore) ThreadsFromKeysAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	return l.inMem.ThreadsFromKeysAfter(after, limit)
+}
+
 func (l *lstore) AddAddr(tid thread.ID, lid peer.ID, addr ma.Multiaddr, dur time.Duration) error {
 	if err := l.persist.AddAddr(tid, lid, addr, dur); err != nil {
 		return err
@@ -239,6 +243,10 @@ func (l *lstore) ThreadsFromAddrs() (thread.IDSlice, error) {
 	return l.inMem.ThreadsFromAddrs()
 }
 
+func (l *lstore) ThreadsFromAddrsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	return l.inMem.ThreadsFromAddrsAfter(after, limit)
+}
+
 func (l *lstore) AddrsEdge(t thread.ID) (uint64, error) {
 	return l.inMem.AddrsEdge(t)
 }
@@ -290,6 +298,10 @@ func (l *lstore) Threads() (thread.IDSlice, error) {
 	return l.inMem.Threads()
 }
 
+func (l *lstore) ThreadsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	return l.inMem.ThreadsAfter(after, limit)
+}
+
 func (l *lstore) AddThread(info thread.Info) error {
 	if err := l.persist.AddThread(info); err != nil {
 		return err
@@ -326,6 +326,14 @@ func (mab *memoryAddrBook) ThreadsFromAddrs() (thread.IDSlice, error) {
 	return tids, nil
 }
 
+func (mab *memoryAddrBook) ThreadsFromAddrsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	tids, err := mab.ThreadsFromAddrs()
+	if err != nil {
+		return nil, err
+	}
+	return core.PageThreads(tids, after, limit), nil
+}
+
 // AddAddr calls AddAddrs(t, p, []ma.Multiaddr{addr}, ttl)
 func (mab *memoryAddrBook) AddAddr(t thread.ID, p peer.ID, addr ma.Multiaddr, ttl time.Duration) error {
 	return mab.AddAddrs(t, p, []ma.Multiaddr{addr}, ttl)
@@ -195,6 +195,14 @@ func (mkb *memoryKeyBook) ThreadsFromKeys() (thread.IDSlice, error) {
 	return tids, nil
 }
 
+func (mkb *memoryKeyBook) ThreadsFromKeysAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	tids, err := mkb.ThreadsFromKeys()
+	if err != nil {
+		return nil, err
+	}
+	return core.PageThreads(tids, after, limit), nil
+}
+
 func (mkb *memoryKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 	mkb.RLock()
 	defer mkb.RUnlock()
@@ -1,7 +1,9 @@
 package lstoremem
 
 import (
+	kcore "github.com/textileio/go-threads/core/keystore"
 	core "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/keystore"
 	lstore "github.com/textileio/go-threads/logstore"
 )
 
@@ -16,3 +18,13 @@ func NewLogstore() core.Logstore {
 		NewHeadBook(),
 		NewThreadMetadata())
 }
+
+// NewLogstoreWithKeystore creates an in-memory threadsafe collection of thread logs,
+// which holds the private keys of logs and thread keys in the keystore.
+func NewLogstoreWithKeystore(ks kcore.Keystore) core.Logstore {
+	return lstore.NewLogstore(
+		keystore.NewKeyBook(NewKeyBook(), ks),
+		NewAddrBook(),
+		NewHeadBook(),
+		NewThreadMetadata())
+}
@@ -0,0 +1,333 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+var (
+	// ErrWriteQuorum indicates a record which wasn't acknowledged by enough peers, see core.WithWriteQuorum.
+	ErrWriteQuorum = errors.New("write quorum not reached")
+
+	// ErrAcksNotTracked indicates a host which doesn't keep acknowledgements, see Config.TrackAcks.
+	ErrAcksNotTracked = errors.New("acknowledgements are not tracked")
+
+	// AckTTL is the duration acknowledgements are kept for.
+	AckTTL = time.Hour * 24
+
+	// AckPruneInterval is the interval between removals of expired acknowledgements.
+	AckPruneInterval = time.Minute * 10
+
+	ackPrefix = ds.NewKey("/ack")
+)
+
+// ackBook records the peers which acknowledged persisting the records pushed by the host.
+// Acknowledgements are kept in the datastore for AckTTL if tracked, so they survive restarts.
+// Otherwise, only acknowledgements of the records awaited by a write quorum are kept, until
+// the quorum is decided.
+type ackBook struct {
+	store ds.Datastore
+	clock clock.Clock
+	track bool
+
+	mx      sync.Mutex
+	waiters map[cid.Cid][]chan struct{}
+	watched map[cid.Cid]int
+}
+
+func newAckBook(store ds.Datastore, clk clock.Clock, track bool) *ackBook {
+	return &ackBook{
+		store:   store,
+		clock:   clk,
+		track:   track,
+		waiters: make(map[cid.Cid][]chan struct{}),
+		watched: make(map[cid.Cid]int),
+	}
+}
+
+// Watch keeps the acknowledgements of a record until the returned function is called,
+// even if acknowledgements aren't tracked. Untracked acknowledgements are removed then.
+func (b *ackBook) Watch(tid thread.ID, rid cid.Cid) func() {
+	b.mx.Lock()
+	b.watched[rid]++
+	b.mx.Unlock()
+	return func() {
+		b.mx.Lock()
+		b.watched[rid]--
+		last := b.watched[rid] == 0
+		if last {
+			delete(b.watched, rid)
+		}
+		b.mx.Unlock()
+		if last && !b.track {
+			if err := b.purge(ackPrefix.ChildString(tid.String()).ChildString(rid.String())); err != nil {
+				log.Errorf("removing acks of record %s: %v", rid, err)
+			}
+		}
+	}
+}
+
+// Ack records that the peer persisted the record, if its acknowledgements are kept.
+func (b *ackBook) Ack(tid thread.ID, rid cid.Cid, pid peer.ID) error {
+	b.mx.Lock()
+	keep := b.track || b.watched[rid] > 0
+	b.mx.Unlock()
+	if !keep {
+		return nil
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(b.clock.Now().UnixNano()))
+	if err := b.store.Put(ackKey(tid, rid, pid), value); err != nil {
+		return err
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	for _, w := range b.waiters[rid] {
+		close(w)
+	}
+	delete(b.waiters, rid)
+	return nil
+}
+
+// Acks returns the peers which acknowledged the record, and when. Expired acknowledgements
+// are skipped.
+func (b *ackBook) Acks(tid thread.ID, rid cid.Cid) (map[peer.ID]time.Time, error) {
+	prefix := ackPrefix.ChildString(tid.String()).ChildString(rid.String())
+	res, err := b.store.Query(query.Query{Prefix: prefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var (
+		acks    = make(map[peer.ID]time.Time)
+		expired = b.clock.Now().Add(-AckTTL)
+	)
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		pid, err := peer.Decode(ds.RawKey(r.Key).BaseNamespace())
+		if err != nil || len(r.Value) < 8 {
+			log.Warnf("skipping malformed ack entry %s", r.Key)
+			continue
+		}
+		if at := time.Unix(0, int64(binary.BigEndian.Uint64(r.Value))); at.After(expired) {
+			acks[pid] = at
+		}
+	}
+	return acks, nil
+}
+
+// Wait blocks until the record is acknowledged by n of the given peers or the context is done.
+// The record must be watched, see Watch.
+func (b *ackBook) Wait(ctx context.Context, tid thread.ID, rid cid.Cid, peers []peer.ID, n int) error {
+	for {
+		// register before counting, so no acknowledgement is missed in between
+		w := make(chan struct{})
+		b.mx.Lock()
+		b.waiters[rid] = append(b.waiters[rid], w)
+		b.mx.Unlock()
+
+		acks, err := b.Acks(tid, rid)
+		if err != nil {
+			b.forget(rid, w)
+			return err
+		}
+		var acked int
+		for _, pid := range peers {
+			if _, ok := acks[pid]; ok {
+				acked++
+			}
+		}
+		if acked >= n {
+			b.forget(rid, w)
+			return nil
+		}
+		select {
+		case <-w:
+		case <-ctx.Done():
+			b.forget(rid, w)
+			return fmt.Errorf("%w: %d of %d acknowledgements: %v", ErrWriteQuorum, acked, n, ctx.Err())
+		}
+	}
+}
+
+// PurgeThread removes all acknowledgements of the thread.
+func (b *ackBook) PurgeThread(tid thread.ID) error {
+	return b.purge(ackPrefix.ChildString(tid.String()))
+}
+
+// Prune removes the expired acknowledgements.
+func (b *ackBook) Prune() (int, error) {
+	res, err := b.store.Query(query.Query{Prefix: ackPrefix.String()})
+	if err != nil {
+		return 0, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return 0, err
+	}
+	var (
+		pruned  int
+		expired = b.clock.Now().Add(-AckTTL)
+	)
+	for _, e := range entries {
+		if len(e.Value) >= 8 && time.Unix(0, int64(binary.BigEndian.Uint64(e.Value))).After(expired) {
+			continue
+		}
+		if err := b.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+func (b *ackBook) purge(prefix ds.Key) error {
+	res, err := b.store.Query(query.Query{Prefix: prefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := b.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *ackBook) forget(rid cid.Cid, w chan struct{}) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	ws := b.waiters[rid]
+	for i, x := range ws {
+		if x == w {
+			ws = append(ws[:i], ws[i+1:]...)
+			break
+		}
+	}
+	if len(ws) == 0 {
+		delete(b.waiters, rid)
+	} else {
+		b.waiters[rid] = ws
+	}
+}
+
+func ackKey(tid thread.ID, rid cid.Cid, pid peer.ID) ds.Key {
+	return ackPrefix.ChildString(tid.String()).ChildString(rid.String()).ChildString(pid.Pretty())
+}
+
+// RecordReplicationStatus returns which thread peers acknowledged persisting a record
+// pushed by the host, and which didn't yet.
+func (n *net) RecordReplicationStatus(
+	_ context.Context,
+	id thread.ID,
+	rid cid.Cid,
+	opts ...core.ThreadOption,
+) (status core.RecordReplication, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	if !n.acks.track {
+		return status, ErrAcksNotTracked
+	}
+	if status.Acked, err = n.acks.Acks(id, rid); err != nil {
+		return
+	}
+	peers, err := n.server.threadPeers(id)
+	if err != nil {
+		return
+	}
+	for _, pid := range peers {
+		if _, ok := status.Acked[pid]; !ok {
+			status.Pending = append(status.Pending, pid)
+		}
+	}
+	return status, nil
+}
+
+// startAckPruning periodically removes expired acknowledgements until the network is closed.
+func (n *net) startAckPruning() {
+	tick := n.clock.NewTicker(AckPruneInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.Chan():
+			if pruned, err := n.acks.Prune(); err != nil {
+				log.Errorf("pruning acks: %v", err)
+			} else if pruned > 0 {
+				log.Debugf("pruned %d expired acks", pruned)
+			}
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// awaitWriteQuorum blocks until quorum of the peers a new record was pushed to acknowledged
+// it. Peers which failed are redelivered the record in the background, which may still
+// complete the quorum. The record must be watched, see ackBook.Watch.
+func (n *net) awaitWriteQuorum(ctx context.Context, id thread.ID, rid cid.Cid, outcomes <-chan peerAck, quorum int) error {
+	var (
+		pushed []peer.ID
+		acked  int
+	)
+	for done := false; !done; {
+		select {
+		case o, ok := <-outcomes:
+			if !ok {
+				done = true
+				break
+			}
+			pushed = append(pushed, o.pid)
+			if o.err == nil {
+				acked++
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d of %d acknowledgements: %v", ErrWriteQuorum, acked, quorum, ctx.Err())
+		}
+	}
+	if len(pushed) < quorum {
+		return fmt.Errorf("%w: record was pushed to %d peers, %d required", ErrWriteQuorum, len(pushed), quorum)
+	}
+	if acked >= quorum {
+		return nil
+	}
+	return n.acks.Wait(ctx, id, rid, pushed, quorum)
+}
+
+// checkWriteQuorum returns an error if the thread has fewer peers than the quorum, so writes
+// fail up front instead of storing records which can never reach the quorum.
+func (n *net) checkWriteQuorum(id thread.ID, quorum int) error {
+	peers, err := n.server.threadPeers(id)
+	if err != nil {
+		return err
+	}
+	if len(peers) < quorum {
+		return fmt.Errorf("%w: thread has %d peers, %d required", ErrWriteQuorum, len(peers), quorum)
+	}
+	return nil
+}
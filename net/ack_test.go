@@ -0,0 +1,78 @@
+package net
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+func TestNet_AckBookExpiry(t *testing.T) {
+	t.Parallel()
+	var (
+		store = syncds.MutexWrap(ds.NewMapDatastore())
+		clk   = clock.NewMock(time.Now())
+		book  = newAckBook(store, clk, true)
+		tid   = thread.NewIDV1(thread.Raw, 32)
+		rid   = generateSequence(cid.Undef, 1)[0].Cid()
+		pid   = tu.RandPeerIDFatal(t)
+	)
+	if err := book.Ack(tid, rid, pid); err != nil {
+		t.Fatal(err)
+	}
+	if acks, err := book.Acks(tid, rid); err != nil || len(acks) != 1 {
+		t.Fatalf("expected 1 ack, got %v (%v)", acks, err)
+	}
+
+	clk.Add(AckTTL + time.Second)
+	if acks, err := book.Acks(tid, rid); err != nil || len(acks) != 0 {
+		t.Fatalf("expected expired ack to be skipped, got %v (%v)", acks, err)
+	}
+	if pruned, err := book.Prune(); err != nil || pruned != 1 {
+		t.Fatalf("expected 1 pruned ack, got %d (%v)", pruned, err)
+	}
+}
+
+func TestNet_AckBookUntracked(t *testing.T) {
+	t.Parallel()
+	var (
+		store = syncds.MutexWrap(ds.NewMapDatastore())
+		book  = newAckBook(store, clock.New(), false)
+		tid   = thread.NewIDV1(thread.Raw, 32)
+		rid   = generateSequence(cid.Undef, 1)[0].Cid()
+		p1    = tu.RandPeerIDFatal(t)
+		p2    = tu.RandPeerIDFatal(t)
+	)
+	// acks of records nobody waits for are dropped
+	if err := book.Ack(tid, rid, p1); err != nil {
+		t.Fatal(err)
+	}
+	if acks, err := book.Acks(tid, rid); err != nil || len(acks) != 0 {
+		t.Fatalf("expected no acks, got %v (%v)", acks, err)
+	}
+
+	release := book.Watch(tid, rid)
+	if err := book.Ack(tid, rid, p1); err != nil {
+		t.Fatal(err)
+	}
+	// only acks of the awaited peers count
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if err := book.Wait(ctx, tid, rid, []peer.ID{p2}, 1); err == nil {
+		t.Fatal("expected quorum of other peers to fail")
+	}
+	if err := book.Wait(context.Background(), tid, rid, []peer.ID{p1, p2}, 1); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if acks, err := book.Acks(tid, rid); err != nil || len(acks) != 0 {
+		t.Fatalf("expected acks to be removed once released, got %v (%v)", acks, err)
+	}
+}
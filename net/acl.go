@@ -0,0 +1,130 @@
+package net
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// aclKey is the metadata key of the thread ACL, stored as JSON.
+	aclKey = "/acl"
+	// bodylessSuffix is appended to the record ID to name the metadata flag of records
+	// stored without the body, which was withheld by the thread ACL of a peer.
+	bodylessSuffix = "/bodyless"
+)
+
+func (n *net) SetThreadACL(ctx context.Context, id thread.ID, acl core.ThreadACL, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err := util.PutMetadataJSON(n.store, id, aclKey, acl); err != nil {
+		return err
+	}
+	// bodies of stored records are withheld from bitswap as well, lifted restrictions don't
+	// return them, since they're served by GetRecordBodies to the peers the ACL allows
+	return n.withholdThread(ctx, id, acl)
+}
+
+func (n *net) ThreadACL(_ context.Context, id thread.ID, opts ...core.ThreadOption) (core.ThreadACL, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return core.ThreadACL{}, err
+	}
+	return n.threadACL(id)
+}
+
+func (n *net) threadACL(id thread.ID) (core.ThreadACL, error) {
+	var acl core.ThreadACL
+	return acl, util.GetMetadataJSON(n.store, id, aclKey, &acl)
+}
+
+// bodyRestriction returns whether the ACL of a thread withholds the body of a log record
+// from the peer, or nil if the peer may receive all bodies.
+func (n *net) bodyRestriction(id thread.ID, pid peer.ID) (func(lid peer.ID, rid cid.Cid) bool, error) {
+	acl, err := n.threadACL(id)
+	if err != nil || !acl.Withholds(pid) {
+		return nil, err
+	}
+	return acl.Restricts, nil
+}
+
+// isRestricted returns whether the ACL of a thread withholds the body of a log record
+// from any peer.
+func (n *net) isRestricted(id thread.ID, lid peer.ID, rid cid.Cid) bool {
+	acl, err := n.threadACL(id)
+	if err != nil {
+		log.Errorf("getting ACL of thread %s: %v", id, err)
+		return false
+	}
+	return acl.Restricts(lid, rid)
+}
+
+// restrictRecords strips the bodies of the records withheld from the peer by the thread ACL.
+// The passed records are not modified.
+func (n *net) restrictRecords(tid thread.ID, lid, pid peer.ID, recs []*pb.Log_Record) []*pb.Log_Record {
+	restricted, err := n.bodyRestriction(tid, pid)
+	if err != nil {
+		log.Errorf("getting ACL of thread %s: %v", tid, err)
+	}
+	if restricted == nil {
+		return recs
+	}
+	out := make([]*pb.Log_Record, len(recs))
+	for i, r := range recs {
+		out[i] = r
+		if r.Restricted {
+			continue
+		}
+		rid, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: mh.SHA2_256}.Sum(r.RecordNode)
+		if err != nil || restricted(lid, rid) {
+			stripped := *r
+			stripped.BodyNode = nil
+			stripped.Restricted = true
+			out[i] = &stripped
+		}
+	}
+	return out
+}
+
+// recordToProto returns a proto version of a record for transport. Records stored without
+// the body are sent restricted.
+func (n *net) recordToProto(ctx context.Context, tid thread.ID, rec core.Record) (*pb.Log_Record, error) {
+	if n.isBodyless(tid, rec.Cid()) {
+		return cbor.RestrictedRecordToProto(ctx, n, rec)
+	}
+	return cbor.RecordToProto(ctx, n, rec)
+}
+
+// isBodyless returns whether the record is stored without the body.
+func (n *net) isBodyless(tid thread.ID, rid cid.Cid) bool {
+	bodyless, err := n.store.GetBool(tid, rid.String()+bodylessSuffix)
+	if err != nil {
+		log.Errorf("getting body flag of record %s: %v", rid, err)
+		return false
+	}
+	return bodyless != nil && *bodyless
+}
+
+// isRestrictedRecord returns whether the record was received without the body, see cbor.Record.Restricted.
+func isRestrictedRecord(rec core.Record) bool {
+	r, ok := rec.(interface{ Restricted() bool })
+	return ok && r.Restricted()
+}
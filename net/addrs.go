@@ -0,0 +1,214 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+// metadata suffix for the latest address set signed by the owner of an external log
+const signedAddrsSuffix = "/signed-addrs"
+
+// ErrInvalidAddrsSig indicates log addresses with a signature not matching the log key.
+var ErrInvalidAddrsSig = errors.New("invalid log addresses signature")
+
+// peerLog is a log received from a peer along with the owner's signature of the log addresses.
+type peerLog struct {
+	thread.LogInfo
+	addrsSeq uint64
+	addrsSig []byte
+}
+
+// peerLogFromProto returns a peer log from a proto log.
+func peerLogFromProto(l *pb.Log) peerLog {
+	return peerLog{
+		LogInfo:  logFromProto(l),
+		addrsSeq: l.AddrsSeq,
+		addrsSig: l.AddrsSig,
+	}
+}
+
+// signedLogToProto returns a proto log with addresses signed by the log owner.
+// Logs owned by the host are signed on the fly, or with their Signer once their
+// addresses change, while addresses of external logs are replaced with the latest
+// set signed by their owner, if any.
+func (n *net) signedLogToProto(ctx context.Context, tid thread.ID, lg thread.LogInfo) (*pb.Log, error) {
+	pl := logToProto(lg)
+	sk, err := n.store.PrivKey(tid, lg.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sk != nil {
+		pl.AddrsSeq = uint64(time.Now().UnixNano())
+		pl.AddrsSig, err = sk.Sign(logAddrsPayload(tid, lg.ID, pl.AddrsSeq, lg.Addrs))
+		if err != nil {
+			return nil, fmt.Errorf("signing log addresses: %w", err)
+		}
+		return pl, nil
+	}
+	signed, err := n.signedLogAddrs(tid, lg.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n.signerManaged(lg) && (signed == nil || !sameAddrs(addrsFromProto(signed.Addrs), lg.Addrs)) {
+		return n.signManagedLogAddrs(ctx, tid, lg, pl)
+	}
+	if signed == nil {
+		return pl, nil
+	}
+	pl.Addrs, pl.AddrsSeq, pl.AddrsSig = signed.Addrs, signed.AddrsSeq, signed.AddrsSig
+	return pl, nil
+}
+
+// signerManaged returns whether the host owns a log without its private key, i.e., its
+// records and addresses are signed by one of the Signers.
+func (n *net) signerManaged(lg thread.LogInfo) bool {
+	return lg.PrivKey == nil && lg.Managed && n.signers != nil
+}
+
+// signManagedLogAddrs signs the addresses of a log with its Signer. The signed set is kept
+// like the ones of external logs, so the signer is only asked again once the addresses change.
+func (n *net) signManagedLogAddrs(ctx context.Context, tid thread.ID, lg thread.LogInfo, pl *pb.Log) (*pb.Log, error) {
+	signer, err := n.logSigner(ctx, tid, lg)
+	if err != nil {
+		return nil, err
+	}
+	pl.AddrsSeq = uint64(time.Now().UnixNano())
+	pl.AddrsSig, err = signer.Sign(ctx, logAddrsPayload(tid, lg.ID, pl.AddrsSeq, lg.Addrs))
+	if err != nil {
+		return nil, fmt.Errorf("signing log addresses: %w", err)
+	}
+	data, err := (&pb.Log{Addrs: pl.Addrs, AddrsSeq: pl.AddrsSeq, AddrsSig: pl.AddrsSig}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err = n.store.PutBytes(tid, lg.ID.Pretty()+signedAddrsSuffix, data); err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+// sameAddrs returns whether both address sets hold the same addresses in the same order.
+func sameAddrs(a, b []ma.Multiaddr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// putLogAddrs saves the addresses of an existing log received from a peer.
+func (n *net) putLogAddrs(tid thread.ID, lg peerLog) error {
+	addrs, replace, err := n.verifyLogAddrs(tid, lg)
+	if err != nil {
+		return err
+	}
+	if replace {
+		// setting addresses only refreshes the given ones, the stale ones are dropped first
+		if err = n.store.ClearAddrs(tid, lg.ID); err != nil {
+			return err
+		}
+	}
+	if len(addrs) > 0 {
+		return n.store.AddAddrs(tid, lg.ID, addrs, pstore.PermanentAddrTTL)
+	}
+	return nil
+}
+
+// verifyLogAddrs returns the addresses of a log received from a peer which may be saved,
+// and whether they replace the known ones. Once the owner has signed the log addresses,
+// unsigned and stale address sets are dropped, so other peers can't redirect log traffic.
+// Logs of owners which don't sign addresses keep accumulating the received addresses.
+func (n *net) verifyLogAddrs(tid thread.ID, lg peerLog) ([]ma.Multiaddr, bool, error) {
+	if local, err := n.store.GetLog(tid, lg.ID); err != nil && !errors.Is(err, lstore.ErrLogNotFound) {
+		return nil, false, err
+	} else if err == nil && (local.PrivKey != nil || n.signerManaged(local)) {
+		// addresses of own logs are only changed locally
+		return nil, false, nil
+	}
+	known, err := n.signedLogAddrs(tid, lg.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if lg.addrsSig == nil {
+		if known != nil {
+			log.Debugf("ignoring unsigned addresses of log %s (thread=%s)", lg.ID, tid)
+			return nil, false, nil
+		}
+		return lg.Addrs, false, nil
+	}
+
+	pk := lg.PubKey
+	if pk == nil {
+		if pk, err = n.store.PubKey(tid, lg.ID); err != nil {
+			return nil, false, err
+		} else if pk == nil {
+			return nil, false, fmt.Errorf("log %s: %w: unknown log key", lg.ID, ErrInvalidAddrsSig)
+		}
+	}
+	if ok, err := pk.Verify(logAddrsPayload(tid, lg.ID, lg.addrsSeq, lg.Addrs), lg.addrsSig); err != nil || !ok {
+		return nil, false, fmt.Errorf("log %s: %w", lg.ID, ErrInvalidAddrsSig)
+	}
+	if known != nil && lg.addrsSeq <= known.AddrsSeq {
+		return nil, false, nil
+	}
+
+	data, err := (&pb.Log{
+		Addrs:    addrsToProto(lg.Addrs),
+		AddrsSeq: lg.addrsSeq,
+		AddrsSig: lg.addrsSig,
+	}).Marshal()
+	if err != nil {
+		return nil, false, err
+	}
+	if err = n.store.PutBytes(tid, lg.ID.Pretty()+signedAddrsSuffix, data); err != nil {
+		return nil, false, err
+	}
+	return lg.Addrs, true, nil
+}
+
+// signedLogAddrs returns the latest signed address set of an external log, or nil if the owner hasn't signed any.
+func (n *net) signedLogAddrs(tid thread.ID, lid peer.ID) (*pb.Log, error) {
+	data, err := n.store.GetBytes(tid, lid.Pretty()+signedAddrsSuffix)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	signed := &pb.Log{}
+	if err = signed.Unmarshal(*data); err != nil {
+		return nil, fmt.Errorf("decoding signed addresses of log %s: %w", lid, err)
+	}
+	return signed, nil
+}
+
+// logAddrsPayload returns the bytes signed by log owners, which bind
+// the address set and its sequence number to the log and thread.
+func logAddrsPayload(tid thread.ID, lid peer.ID, seq uint64, addrs []ma.Multiaddr) []byte {
+	var buf []byte
+	put := func(b []byte) {
+		var l [binary.MaxVarintLen64]byte
+		buf = append(buf, l[:binary.PutUvarint(l[:], uint64(len(b)))]...)
+		buf = append(buf, b...)
+	}
+	put(tid.Bytes())
+	put([]byte(lid))
+	var s [8]byte
+	binary.BigEndian.PutUint64(s[:], seq)
+	put(s[:])
+	for _, addr := range addrs {
+		put(addr.Bytes())
+	}
+	return buf
+}
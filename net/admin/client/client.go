@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/admin/pb"
+	"google.golang.org/grpc"
+)
+
+// Client provides the admin client api.
+type Client struct {
+	c    pb.AdminClient
+	conn *grpc.ClientConn
+}
+
+// Params describes the host of a network.
+type Params struct {
+	HostID    peer.ID
+	HostAddrs []ma.Multiaddr
+	// Values are the host settings, e.g., whether pubsub is enabled.
+	Values map[string]string
+}
+
+// PeerInfo describes a peer connected to the host, or with pushed records.
+type PeerInfo struct {
+	ID        peer.ID
+	Addrs     []ma.Multiaddr
+	Connected bool
+	// Pending is the number of records waiting for a retry.
+	Pending int
+	// LastSuccess is the time of the last successful record delivery.
+	LastSuccess time.Time
+	// LastError is the error of the last failed record delivery.
+	LastError string
+}
+
+// ThreadInfo describes a thread stored on the host.
+type ThreadInfo struct {
+	ID         thread.ID
+	Logs       int
+	Readable   bool
+	Subscribed bool
+}
+
+// Metrics summarizes the state of the host.
+type Metrics struct {
+	Threads        int
+	Topics         int
+	ConnectedPeers int
+	PendingRecords int
+	Uptime         time.Duration
+
+	// records published over pubsub, see net.PublishConfig
+	PublishedRecords int
+	CoalescedRecords int
+	DroppedRecords   int
+
+	// pulls scheduled from peers, see net.Config.MaxPeerCalls
+	WaitingPulls int
+	OverduePulls int
+	MeanPullWait time.Duration
+	MaxPullWait  time.Duration
+}
+
+// NewClient starts the client.
+// Use grpc.WithPerRPCCredentials(TokenCredentials{...}) if the service requires a token.
+func NewClient(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c:    pb.NewAdminClient(conn),
+		conn: conn,
+	}, nil
+}
+
+// Close closes the client's grpc connection and cancels any active requests.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GetParams returns the host identity and settings.
+func (c *Client) GetParams(ctx context.Context) (params Params, err error) {
+	resp, err := c.c.GetParams(ctx, &pb.GetParamsRequest{})
+	if err != nil {
+		return
+	}
+	if params.HostID, err = peer.IDFromBytes(resp.HostID); err != nil {
+		return
+	}
+	if params.HostAddrs, err = addrsFromBytes(resp.HostAddrs); err != nil {
+		return
+	}
+	params.Values = resp.Params
+	return params, nil
+}
+
+// ListPeers returns the peers connected to the host, and the peers with records pushed by the host.
+func (c *Client) ListPeers(ctx context.Context) ([]PeerInfo, error) {
+	resp, err := c.c.ListPeers(ctx, &pb.ListPeersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]PeerInfo, len(resp.Peers))
+	for i, p := range resp.Peers {
+		pid, err := peer.IDFromBytes(p.PeerID)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := addrsFromBytes(p.Addrs)
+		if err != nil {
+			return nil, err
+		}
+		peers[i] = PeerInfo{
+			ID:        pid,
+			Addrs:     addrs,
+			Connected: p.Connected,
+			Pending:   int(p.PendingRecords),
+			LastError: p.LastError,
+		}
+		if p.LastSuccess > 0 {
+			peers[i].LastSuccess = time.Unix(p.LastSuccess, 0)
+		}
+	}
+	return peers, nil
+}
+
+// ListThreads returns the threads stored on the host.
+func (c *Client) ListThreads(ctx context.Context) ([]ThreadInfo, error) {
+	resp, err := c.c.ListThreads(ctx, &pb.ListThreadsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	threads := make([]ThreadInfo, len(resp.Threads))
+	for i, t := range resp.Threads {
+		id, err := thread.Cast(t.ThreadID)
+		if err != nil {
+			return nil, err
+		}
+		threads[i] = ThreadInfo{
+			ID:         id,
+			Logs:       int(t.Logs),
+			Readable:   t.Readable,
+			Subscribed: t.Subscribed,
+		}
+	}
+	return threads, nil
+}
+
+// PullThread requests new records of a thread from its peers.
+func (c *Client) PullThread(ctx context.Context, id thread.ID) error {
+	_, err := c.c.PullThread(ctx, &pb.PullThreadRequest{
+		ThreadID: id.Bytes(),
+	})
+	return err
+}
+
+// CompactThread drops the records of a thread which are older than the latest checkpoints.
+func (c *Client) CompactThread(ctx context.Context, id thread.ID) error {
+	_, err := c.c.CompactThread(ctx, &pb.CompactThreadRequest{
+		ThreadID: id.Bytes(),
+	})
+	return err
+}
+
+// DeleteThread removes a thread from the host.
+func (c *Client) DeleteThread(ctx context.Context, id thread.ID) error {
+	_, err := c.c.DeleteThread(ctx, &pb.DeleteThreadRequest{
+		ThreadID: id.Bytes(),
+	})
+	return err
+}
+
+// VerifyThread walks every log of a thread stored on the host, and reports damaged records.
+// With repair, damaged records are re-fetched from the thread peers.
+func (c *Client) VerifyThread(ctx context.Context, id thread.ID, repair bool) (v net.ThreadVerification, err error) {
+	resp, err := c.c.VerifyThread(ctx, &pb.VerifyThreadRequest{
+		ThreadID: id.Bytes(),
+		Repair:   repair,
+	})
+	if err != nil {
+		return
+	}
+	v.ThreadID = id
+	v.Logs = make([]net.LogVerification, len(resp.Logs))
+	for i, l := range resp.Logs {
+		lv := net.LogVerification{Records: int(l.Records)}
+		if lv.ID, err = peer.IDFromBytes(l.LogID); err != nil {
+			return
+		}
+		for _, d := range l.Damage {
+			ld := net.LogDamage{Kind: net.DamageKind(d.Kind), Repaired: d.Repaired}
+			if ld.Record, err = cid.Cast(d.Record); err != nil {
+				return
+			}
+			if ld.Block, err = cid.Cast(d.Block); err != nil {
+				return
+			}
+			lv.Damage = append(lv.Damage, ld)
+		}
+		v.Logs[i] = lv
+	}
+	return v, nil
+}
+
+// GC removes orphaned blocks from the host, and returns the number of removed blocks.
+func (c *Client) GC(ctx context.Context) (int, error) {
+	resp, err := c.c.GC(ctx, &pb.GCRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.Removed), nil
+}
+
+// GetMetrics returns a summary of the host state.
+func (c *Client) GetMetrics(ctx context.Context) (Metrics, error) {
+	resp, err := c.c.GetMetrics(ctx, &pb.GetMetricsRequest{})
+	if err != nil {
+		return Metrics{}, err
+	}
+	return Metrics{
+		Threads:        int(resp.Threads),
+		Topics:         int(resp.Topics),
+		ConnectedPeers: int(resp.ConnectedPeers),
+		PendingRecords: int(resp.PendingRecords),
+		Uptime:         time.Duration(resp.Uptime) * time.Second,
+
+		PublishedRecords: int(resp.PublishedRecords),
+		CoalescedRecords: int(resp.CoalescedRecords),
+		DroppedRecords:   int(resp.DroppedRecords),
+
+		WaitingPulls: int(resp.WaitingPulls),
+		OverduePulls: int(resp.OverduePulls),
+		MeanPullWait: time.Duration(resp.MeanPullWait) * time.Millisecond,
+		MaxPullWait:  time.Duration(resp.MaxPullWait) * time.Millisecond,
+	}, nil
+}
+
+// TokenCredentials implements PerRPCCredentials, adding the admin token to request metadata.
+type TokenCredentials struct {
+	Token  string
+	Secure bool
+}
+
+func (c TokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "bearer " + c.Token}, nil
+}
+
+func (c TokenCredentials) RequireTransportSecurity() bool {
+	return c.Secure
+}
+
+func addrsFromBytes(bs [][]byte) ([]ma.Multiaddr, error) {
+	addrs := make([]ma.Multiaddr, len(bs))
+	for i, b := range bs {
+		addr, err := ma.NewMultiaddrBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = addr
+	}
+	return addrs, nil
+}
@@ -0,0 +1,207 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/phayes/freeport"
+	"github.com/textileio/go-threads/common"
+	"github.com/textileio/go-threads/core/thread"
+	. "github.com/textileio/go-threads/net/admin/client"
+	"github.com/textileio/go-threads/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const token = "secret"
+
+func TestClient_Auth(t *testing.T) {
+	t.Parallel()
+	_, target, done := makeServer(t)
+	defer done()
+
+	client, err := NewClient(target, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err = client.GetParams(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated error, got %v", err)
+	}
+
+	bad, err := NewClient(target, grpc.WithInsecure(), grpc.WithPerRPCCredentials(TokenCredentials{Token: "wrong"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if _, err = bad.GetParams(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated error, got %v", err)
+	}
+}
+
+func TestClient_RequiresAuth(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	_, err = common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+		common.WithNetAdminAddr(freeAddr(t)),
+	)
+	if err == nil {
+		t.Fatal("expected admin API without authentication to be refused")
+	}
+}
+
+func TestClient_GetParams(t *testing.T) {
+	t.Parallel()
+	n, client, done := setup(t)
+	defer done()
+
+	params, err := client.GetParams(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.HostID != n.Host().ID() {
+		t.Fatal("got bad host ID")
+	}
+	if len(params.HostAddrs) == 0 {
+		t.Fatal("expected host addresses")
+	}
+	if params.Values["pubsub"] != "true" {
+		t.Fatalf("expected pubsub param to be true, got %s", params.Values["pubsub"])
+	}
+}
+
+func TestClient_ThreadLifecycle(t *testing.T) {
+	t.Parallel()
+	n, client, done := setup(t)
+	defer done()
+
+	ctx := context.Background()
+	id := thread.NewIDV1(thread.Raw, 32)
+	if _, err := n.CreateThread(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	threads, err := client.ListThreads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 1 || !threads[0].ID.Equals(id) {
+		t.Fatalf("expected thread %s to be listed", id)
+	}
+	if threads[0].Logs != 1 || !threads[0].Readable {
+		t.Fatal("got bad thread info")
+	}
+	if err = client.PullThread(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.GC(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.CreateRecord(ctx, id, body); err != nil {
+		t.Fatal(err)
+	}
+	v, err := client.VerifyThread(ctx, id, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.OK() || len(v.Logs) != 1 || v.Logs[0].Records != 1 {
+		t.Fatalf("expected intact log of 1 record, got %+v", v)
+	}
+
+	metrics, err := client.GetMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics.Threads != 1 {
+		t.Fatalf("expected 1 thread, got %d", metrics.Threads)
+	}
+
+	if err = client.DeleteThread(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if threads, err = client.ListThreads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 0 {
+		t.Fatal("expected thread to be deleted")
+	}
+}
+
+func TestClient_ListPeers(t *testing.T) {
+	t.Parallel()
+	_, client, done := setup(t)
+	defer done()
+
+	if _, err := client.ListPeers(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setup(t *testing.T) (common.NetBoostrapper, *Client, func()) {
+	n, target, shutdown := makeServer(t)
+	client, err := NewClient(target, grpc.WithInsecure(), grpc.WithPerRPCCredentials(TokenCredentials{Token: token}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n, client, func() {
+		_ = client.Close()
+		shutdown()
+	}
+}
+
+func makeServer(t *testing.T) (common.NetBoostrapper, string, func()) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	n, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+		common.WithNetPubSub(true),
+		common.WithNetAdminAddr(addr),
+		common.WithNetAdminToken(token),
+		common.WithNetDebug(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := util.TCPAddrFromMultiAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n, target, func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func freeAddr(t *testing.T) ma.Multiaddr {
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return util.MustParseAddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", port))
+}
@@ -0,0 +1,1496 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: admin.proto
+
+package threads_admin_pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type GetParamsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetParamsRequest) Reset()         { *m = GetParamsRequest{} }
+func (m *GetParamsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetParamsRequest) ProtoMessage()    {}
+func (*GetParamsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{0}
+}
+
+func (m *GetParamsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetParamsRequest.Unmarshal(m, b)
+}
+func (m *GetParamsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetParamsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetParamsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetParamsRequest.Merge(m, src)
+}
+func (m *GetParamsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetParamsRequest.Size(m)
+}
+func (m *GetParamsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetParamsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetParamsRequest proto.InternalMessageInfo
+
+type GetParamsReply struct {
+	HostID               []byte            `protobuf:"bytes,1,opt,name=hostID,proto3" json:"hostID,omitempty"`
+	HostAddrs            [][]byte          `protobuf:"bytes,2,rep,name=hostAddrs,proto3" json:"hostAddrs,omitempty"`
+	Params               map[string]string `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *GetParamsReply) Reset()         { *m = GetParamsReply{} }
+func (m *GetParamsReply) String() string { return proto.CompactTextString(m) }
+func (*GetParamsReply) ProtoMessage()    {}
+func (*GetParamsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{1}
+}
+
+func (m *GetParamsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetParamsReply.Unmarshal(m, b)
+}
+func (m *GetParamsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetParamsReply.Marshal(b, m, deterministic)
+}
+func (m *GetParamsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetParamsReply.Merge(m, src)
+}
+func (m *GetParamsReply) XXX_Size() int {
+	return xxx_messageInfo_GetParamsReply.Size(m)
+}
+func (m *GetParamsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetParamsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetParamsReply proto.InternalMessageInfo
+
+func (m *GetParamsReply) GetHostID() []byte {
+	if m != nil {
+		return m.HostID
+	}
+	return nil
+}
+
+func (m *GetParamsReply) GetHostAddrs() [][]byte {
+	if m != nil {
+		return m.HostAddrs
+	}
+	return nil
+}
+
+func (m *GetParamsReply) GetParams() map[string]string {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type ListPeersRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPeersRequest) Reset()         { *m = ListPeersRequest{} }
+func (m *ListPeersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPeersRequest) ProtoMessage()    {}
+func (*ListPeersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{2}
+}
+
+func (m *ListPeersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPeersRequest.Unmarshal(m, b)
+}
+func (m *ListPeersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPeersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListPeersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPeersRequest.Merge(m, src)
+}
+func (m *ListPeersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListPeersRequest.Size(m)
+}
+func (m *ListPeersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPeersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPeersRequest proto.InternalMessageInfo
+
+type ListPeersReply struct {
+	Peers                []*ListPeersReply_Peer `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *ListPeersReply) Reset()         { *m = ListPeersReply{} }
+func (m *ListPeersReply) String() string { return proto.CompactTextString(m) }
+func (*ListPeersReply) ProtoMessage()    {}
+func (*ListPeersReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{3}
+}
+
+func (m *ListPeersReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPeersReply.Unmarshal(m, b)
+}
+func (m *ListPeersReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPeersReply.Marshal(b, m, deterministic)
+}
+func (m *ListPeersReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPeersReply.Merge(m, src)
+}
+func (m *ListPeersReply) XXX_Size() int {
+	return xxx_messageInfo_ListPeersReply.Size(m)
+}
+func (m *ListPeersReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPeersReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPeersReply proto.InternalMessageInfo
+
+func (m *ListPeersReply) GetPeers() []*ListPeersReply_Peer {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+type ListPeersReply_Peer struct {
+	PeerID               []byte   `protobuf:"bytes,1,opt,name=peerID,proto3" json:"peerID,omitempty"`
+	Addrs                [][]byte `protobuf:"bytes,2,rep,name=addrs,proto3" json:"addrs,omitempty"`
+	Connected            bool     `protobuf:"varint,3,opt,name=connected,proto3" json:"connected,omitempty"`
+	PendingRecords       int64    `protobuf:"varint,4,opt,name=pendingRecords,proto3" json:"pendingRecords,omitempty"`
+	LastSuccess          int64    `protobuf:"varint,5,opt,name=lastSuccess,proto3" json:"lastSuccess,omitempty"`
+	LastError            string   `protobuf:"bytes,6,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPeersReply_Peer) Reset()         { *m = ListPeersReply_Peer{} }
+func (m *ListPeersReply_Peer) String() string { return proto.CompactTextString(m) }
+func (*ListPeersReply_Peer) ProtoMessage()    {}
+func (*ListPeersReply_Peer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{3, 0}
+}
+
+func (m *ListPeersReply_Peer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPeersReply_Peer.Unmarshal(m, b)
+}
+func (m *ListPeersReply_Peer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPeersReply_Peer.Marshal(b, m, deterministic)
+}
+func (m *ListPeersReply_Peer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPeersReply_Peer.Merge(m, src)
+}
+func (m *ListPeersReply_Peer) XXX_Size() int {
+	return xxx_messageInfo_ListPeersReply_Peer.Size(m)
+}
+func (m *ListPeersReply_Peer) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPeersReply_Peer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPeersReply_Peer proto.InternalMessageInfo
+
+func (m *ListPeersReply_Peer) GetPeerID() []byte {
+	if m != nil {
+		return m.PeerID
+	}
+	return nil
+}
+
+func (m *ListPeersReply_Peer) GetAddrs() [][]byte {
+	if m != nil {
+		return m.Addrs
+	}
+	return nil
+}
+
+func (m *ListPeersReply_Peer) GetConnected() bool {
+	if m != nil {
+		return m.Connected
+	}
+	return false
+}
+
+func (m *ListPeersReply_Peer) GetPendingRecords() int64 {
+	if m != nil {
+		return m.PendingRecords
+	}
+	return 0
+}
+
+func (m *ListPeersReply_Peer) GetLastSuccess() int64 {
+	if m != nil {
+		return m.LastSuccess
+	}
+	return 0
+}
+
+func (m *ListPeersReply_Peer) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+type ListThreadsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListThreadsRequest) Reset()         { *m = ListThreadsRequest{} }
+func (m *ListThreadsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListThreadsRequest) ProtoMessage()    {}
+func (*ListThreadsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{4}
+}
+
+func (m *ListThreadsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListThreadsRequest.Unmarshal(m, b)
+}
+func (m *ListThreadsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListThreadsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListThreadsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListThreadsRequest.Merge(m, src)
+}
+func (m *ListThreadsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListThreadsRequest.Size(m)
+}
+func (m *ListThreadsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListThreadsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListThreadsRequest proto.InternalMessageInfo
+
+type ListThreadsReply struct {
+	Threads              []*ListThreadsReply_Thread `protobuf:"bytes,1,rep,name=threads,proto3" json:"threads,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *ListThreadsReply) Reset()         { *m = ListThreadsReply{} }
+func (m *ListThreadsReply) String() string { return proto.CompactTextString(m) }
+func (*ListThreadsReply) ProtoMessage()    {}
+func (*ListThreadsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{5}
+}
+
+func (m *ListThreadsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListThreadsReply.Unmarshal(m, b)
+}
+func (m *ListThreadsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListThreadsReply.Marshal(b, m, deterministic)
+}
+func (m *ListThreadsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListThreadsReply.Merge(m, src)
+}
+func (m *ListThreadsReply) XXX_Size() int {
+	return xxx_messageInfo_ListThreadsReply.Size(m)
+}
+func (m *ListThreadsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListThreadsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListThreadsReply proto.InternalMessageInfo
+
+func (m *ListThreadsReply) GetThreads() []*ListThreadsReply_Thread {
+	if m != nil {
+		return m.Threads
+	}
+	return nil
+}
+
+type ListThreadsReply_Thread struct {
+	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
+	Logs                 int32    `protobuf:"varint,2,opt,name=logs,proto3" json:"logs,omitempty"`
+	Readable             bool     `protobuf:"varint,3,opt,name=readable,proto3" json:"readable,omitempty"`
+	Subscribed           bool     `protobuf:"varint,4,opt,name=subscribed,proto3" json:"subscribed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListThreadsReply_Thread) Reset()         { *m = ListThreadsReply_Thread{} }
+func (m *ListThreadsReply_Thread) String() string { return proto.CompactTextString(m) }
+func (*ListThreadsReply_Thread) ProtoMessage()    {}
+func (*ListThreadsReply_Thread) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{5, 0}
+}
+
+func (m *ListThreadsReply_Thread) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListThreadsReply_Thread.Unmarshal(m, b)
+}
+func (m *ListThreadsReply_Thread) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListThreadsReply_Thread.Marshal(b, m, deterministic)
+}
+func (m *ListThreadsReply_Thread) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListThreadsReply_Thread.Merge(m, src)
+}
+func (m *ListThreadsReply_Thread) XXX_Size() int {
+	return xxx_messageInfo_ListThreadsReply_Thread.Size(m)
+}
+func (m *ListThreadsReply_Thread) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListThreadsReply_Thread.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListThreadsReply_Thread proto.InternalMessageInfo
+
+func (m *ListThreadsReply_Thread) GetThreadID() []byte {
+	if m != nil {
+		return m.ThreadID
+	}
+	return nil
+}
+
+func (m *ListThreadsReply_Thread) GetLogs() int32 {
+	if m != nil {
+		return m.Logs
+	}
+	return 0
+}
+
+func (m *ListThreadsReply_Thread) GetReadable() bool {
+	if m != nil {
+		return m.Readable
+	}
+	return false
+}
+
+func (m *ListThreadsReply_Thread) GetSubscribed() bool {
+	if m != nil {
+		return m.Subscribed
+	}
+	return false
+}
+
+type PullThreadRequest struct {
+	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PullThreadRequest) Reset()         { *m = PullThreadRequest{} }
+func (m *PullThreadRequest) String() string { return proto.CompactTextString(m) }
+func (*PullThreadRequest) ProtoMessage()    {}
+func (*PullThreadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{6}
+}
+
+func (m *PullThreadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PullThreadRequest.Unmarshal(m, b)
+}
+func (m *PullThreadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PullThreadRequest.Marshal(b, m, deterministic)
+}
+func (m *PullThreadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PullThreadRequest.Merge(m, src)
+}
+func (m *PullThreadRequest) XXX_Size() int {
+	return xxx_messageInfo_PullThreadRequest.Size(m)
+}
+func (m *PullThreadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PullThreadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PullThreadRequest proto.InternalMessageInfo
+
+func (m *PullThreadRequest) GetThreadID() []byte {
+	if m != nil {
+		return m.ThreadID
+	}
+	return nil
+}
+
+type PullThreadReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PullThreadReply) Reset()         { *m = PullThreadReply{} }
+func (m *PullThreadReply) String() string { return proto.CompactTextString(m) }
+func (*PullThreadReply) ProtoMessage()    {}
+func (*PullThreadReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{7}
+}
+
+func (m *PullThreadReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PullThreadReply.Unmarshal(m, b)
+}
+func (m *PullThreadReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PullThreadReply.Marshal(b, m, deterministic)
+}
+func (m *PullThreadReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PullThreadReply.Merge(m, src)
+}
+func (m *PullThreadReply) XXX_Size() int {
+	return xxx_messageInfo_PullThreadReply.Size(m)
+}
+func (m *PullThreadReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PullThreadReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PullThreadReply proto.InternalMessageInfo
+
+type CompactThreadRequest struct {
+	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompactThreadRequest) Reset()         { *m = CompactThreadRequest{} }
+func (m *CompactThreadRequest) String() string { return proto.CompactTextString(m) }
+func (*CompactThreadRequest) ProtoMessage()    {}
+func (*CompactThreadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{8}
+}
+
+func (m *CompactThreadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompactThreadRequest.Unmarshal(m, b)
+}
+func (m *CompactThreadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompactThreadRequest.Marshal(b, m, deterministic)
+}
+func (m *CompactThreadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactThreadRequest.Merge(m, src)
+}
+func (m *CompactThreadRequest) XXX_Size() int {
+	return xxx_messageInfo_CompactThreadRequest.Size(m)
+}
+func (m *CompactThreadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactThreadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactThreadRequest proto.InternalMessageInfo
+
+func (m *CompactThreadRequest) GetThreadID() []byte {
+	if m != nil {
+		return m.ThreadID
+	}
+	return nil
+}
+
+type CompactThreadReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompactThreadReply) Reset()         { *m = CompactThreadReply{} }
+func (m *CompactThreadReply) String() string { return proto.CompactTextString(m) }
+func (*CompactThreadReply) ProtoMessage()    {}
+func (*CompactThreadReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{9}
+}
+
+func (m *CompactThreadReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompactThreadReply.Unmarshal(m, b)
+}
+func (m *CompactThreadReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompactThreadReply.Marshal(b, m, deterministic)
+}
+func (m *CompactThreadReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactThreadReply.Merge(m, src)
+}
+func (m *CompactThreadReply) XXX_Size() int {
+	return xxx_messageInfo_CompactThreadReply.Size(m)
+}
+func (m *CompactThreadReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactThreadReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactThreadReply proto.InternalMessageInfo
+
+type DeleteThreadRequest struct {
+	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteThreadRequest) Reset()         { *m = DeleteThreadRequest{} }
+func (m *DeleteThreadRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteThreadRequest) ProtoMessage()    {}
+func (*DeleteThreadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{10}
+}
+
+func (m *DeleteThreadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteThreadRequest.Unmarshal(m, b)
+}
+func (m *DeleteThreadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteThreadRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteThreadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteThreadRequest.Merge(m, src)
+}
+func (m *DeleteThreadRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteThreadRequest.Size(m)
+}
+func (m *DeleteThreadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteThreadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteThreadRequest proto.InternalMessageInfo
+
+func (m *DeleteThreadRequest) GetThreadID() []byte {
+	if m != nil {
+		return m.ThreadID
+	}
+	return nil
+}
+
+type DeleteThreadReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteThreadReply) Reset()         { *m = DeleteThreadReply{} }
+func (m *DeleteThreadReply) String() string { return proto.CompactTextString(m) }
+func (*DeleteThreadReply) ProtoMessage()    {}
+func (*DeleteThreadReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{11}
+}
+
+func (m *DeleteThreadReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteThreadReply.Unmarshal(m, b)
+}
+func (m *DeleteThreadReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteThreadReply.Marshal(b, m, deterministic)
+}
+func (m *DeleteThreadReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteThreadReply.Merge(m, src)
+}
+func (m *DeleteThreadReply) XXX_Size() int {
+	return xxx_messageInfo_DeleteThreadReply.Size(m)
+}
+func (m *DeleteThreadReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteThreadReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteThreadReply proto.InternalMessageInfo
+
+type GCRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GCRequest) Reset()         { *m = GCRequest{} }
+func (m *GCRequest) String() string { return proto.CompactTextString(m) }
+func (*GCRequest) ProtoMessage()    {}
+func (*GCRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{12}
+}
+
+func (m *GCRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GCRequest.Unmarshal(m, b)
+}
+func (m *GCRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GCRequest.Marshal(b, m, deterministic)
+}
+func (m *GCRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GCRequest.Merge(m, src)
+}
+func (m *GCRequest) XXX_Size() int {
+	return xxx_messageInfo_GCRequest.Size(m)
+}
+func (m *GCRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GCRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GCRequest proto.InternalMessageInfo
+
+type GCReply struct {
+	Removed              int64    `protobuf:"varint,1,opt,name=removed,proto3" json:"removed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GCReply) Reset()         { *m = GCReply{} }
+func (m *GCReply) String() string { return proto.CompactTextString(m) }
+func (*GCReply) ProtoMessage()    {}
+func (*GCReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{13}
+}
+
+func (m *GCReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GCReply.Unmarshal(m, b)
+}
+func (m *GCReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GCReply.Marshal(b, m, deterministic)
+}
+func (m *GCReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GCReply.Merge(m, src)
+}
+func (m *GCReply) XXX_Size() int {
+	return xxx_messageInfo_GCReply.Size(m)
+}
+func (m *GCReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GCReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GCReply proto.InternalMessageInfo
+
+func (m *GCReply) GetRemoved() int64 {
+	if m != nil {
+		return m.Removed
+	}
+	return 0
+}
+
+type GetMetricsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetMetricsRequest) Reset()         { *m = GetMetricsRequest{} }
+func (m *GetMetricsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetMetricsRequest) ProtoMessage()    {}
+func (*GetMetricsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{14}
+}
+
+func (m *GetMetricsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMetricsRequest.Unmarshal(m, b)
+}
+func (m *GetMetricsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMetricsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetMetricsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMetricsRequest.Merge(m, src)
+}
+func (m *GetMetricsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetMetricsRequest.Size(m)
+}
+func (m *GetMetricsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMetricsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetMetricsRequest proto.InternalMessageInfo
+
+type GetMetricsReply struct {
+	Threads              int64    `protobuf:"varint,1,opt,name=threads,proto3" json:"threads,omitempty"`
+	Topics               int64    `protobuf:"varint,2,opt,name=topics,proto3" json:"topics,omitempty"`
+	ConnectedPeers       int64    `protobuf:"varint,3,opt,name=connectedPeers,proto3" json:"connectedPeers,omitempty"`
+	PendingRecords       int64    `protobuf:"varint,4,opt,name=pendingRecords,proto3" json:"pendingRecords,omitempty"`
+	Uptime               int64    `protobuf:"varint,5,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	PublishedRecords     int64    `protobuf:"varint,6,opt,name=publishedRecords,proto3" json:"publishedRecords,omitempty"`
+	CoalescedRecords     int64    `protobuf:"varint,7,opt,name=coalescedRecords,proto3" json:"coalescedRecords,omitempty"`
+	DroppedRecords       int64    `protobuf:"varint,8,opt,name=droppedRecords,proto3" json:"droppedRecords,omitempty"`
+	WaitingPulls         int64    `protobuf:"varint,9,opt,name=waitingPulls,proto3" json:"waitingPulls,omitempty"`
+	OverduePulls         int64    `protobuf:"varint,10,opt,name=overduePulls,proto3" json:"overduePulls,omitempty"`
+	MeanPullWait         int64    `protobuf:"varint,11,opt,name=meanPullWait,proto3" json:"meanPullWait,omitempty"`
+	MaxPullWait          int64    `protobuf:"varint,12,opt,name=maxPullWait,proto3" json:"maxPullWait,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetMetricsReply) Reset()         { *m = GetMetricsReply{} }
+func (m *GetMetricsReply) String() string { return proto.CompactTextString(m) }
+func (*GetMetricsReply) ProtoMessage()    {}
+func (*GetMetricsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{15}
+}
+
+func (m *GetMetricsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMetricsReply.Unmarshal(m, b)
+}
+func (m *GetMetricsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMetricsReply.Marshal(b, m, deterministic)
+}
+func (m *GetMetricsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMetricsReply.Merge(m, src)
+}
+func (m *GetMetricsReply) XXX_Size() int {
+	return xxx_messageInfo_GetMetricsReply.Size(m)
+}
+func (m *GetMetricsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMetricsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetMetricsReply proto.InternalMessageInfo
+
+func (m *GetMetricsReply) GetThreads() int64 {
+	if m != nil {
+		return m.Threads
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetTopics() int64 {
+	if m != nil {
+		return m.Topics
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetConnectedPeers() int64 {
+	if m != nil {
+		return m.ConnectedPeers
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetPendingRecords() int64 {
+	if m != nil {
+		return m.PendingRecords
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetUptime() int64 {
+	if m != nil {
+		return m.Uptime
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetPublishedRecords() int64 {
+	if m != nil {
+		return m.PublishedRecords
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetCoalescedRecords() int64 {
+	if m != nil {
+		return m.CoalescedRecords
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetDroppedRecords() int64 {
+	if m != nil {
+		return m.DroppedRecords
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetWaitingPulls() int64 {
+	if m != nil {
+		return m.WaitingPulls
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetOverduePulls() int64 {
+	if m != nil {
+		return m.OverduePulls
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetMeanPullWait() int64 {
+	if m != nil {
+		return m.MeanPullWait
+	}
+	return 0
+}
+
+func (m *GetMetricsReply) GetMaxPullWait() int64 {
+	if m != nil {
+		return m.MaxPullWait
+	}
+	return 0
+}
+
+type VerifyThreadRequest struct {
+	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
+	Repair               bool     `protobuf:"varint,2,opt,name=repair,proto3" json:"repair,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyThreadRequest) Reset()         { *m = VerifyThreadRequest{} }
+func (m *VerifyThreadRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyThreadRequest) ProtoMessage()    {}
+func (*VerifyThreadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{16}
+}
+
+func (m *VerifyThreadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyThreadRequest.Unmarshal(m, b)
+}
+func (m *VerifyThreadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyThreadRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyThreadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyThreadRequest.Merge(m, src)
+}
+func (m *VerifyThreadRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyThreadRequest.Size(m)
+}
+func (m *VerifyThreadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyThreadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyThreadRequest proto.InternalMessageInfo
+
+func (m *VerifyThreadRequest) GetThreadID() []byte {
+	if m != nil {
+		return m.ThreadID
+	}
+	return nil
+}
+
+func (m *VerifyThreadRequest) GetRepair() bool {
+	if m != nil {
+		return m.Repair
+	}
+	return false
+}
+
+type VerifyThreadReply struct {
+	Logs                 []*VerifyThreadReply_Log `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *VerifyThreadReply) Reset()         { *m = VerifyThreadReply{} }
+func (m *VerifyThreadReply) String() string { return proto.CompactTextString(m) }
+func (*VerifyThreadReply) ProtoMessage()    {}
+func (*VerifyThreadReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{17}
+}
+
+func (m *VerifyThreadReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyThreadReply.Unmarshal(m, b)
+}
+func (m *VerifyThreadReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyThreadReply.Marshal(b, m, deterministic)
+}
+func (m *VerifyThreadReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyThreadReply.Merge(m, src)
+}
+func (m *VerifyThreadReply) XXX_Size() int {
+	return xxx_messageInfo_VerifyThreadReply.Size(m)
+}
+func (m *VerifyThreadReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyThreadReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyThreadReply proto.InternalMessageInfo
+
+func (m *VerifyThreadReply) GetLogs() []*VerifyThreadReply_Log {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+type VerifyThreadReply_Log struct {
+	LogID                []byte                      `protobuf:"bytes,1,opt,name=logID,proto3" json:"logID,omitempty"`
+	Records              int64                       `protobuf:"varint,2,opt,name=records,proto3" json:"records,omitempty"`
+	Damage               []*VerifyThreadReply_Damage `protobuf:"bytes,3,rep,name=damage,proto3" json:"damage,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
+	XXX_unrecognized     []byte                      `json:"-"`
+	XXX_sizecache        int32                       `json:"-"`
+}
+
+func (m *VerifyThreadReply_Log) Reset()         { *m = VerifyThreadReply_Log{} }
+func (m *VerifyThreadReply_Log) String() string { return proto.CompactTextString(m) }
+func (*VerifyThreadReply_Log) ProtoMessage()    {}
+func (*VerifyThreadReply_Log) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{17, 0}
+}
+
+func (m *VerifyThreadReply_Log) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyThreadReply_Log.Unmarshal(m, b)
+}
+func (m *VerifyThreadReply_Log) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyThreadReply_Log.Marshal(b, m, deterministic)
+}
+func (m *VerifyThreadReply_Log) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyThreadReply_Log.Merge(m, src)
+}
+func (m *VerifyThreadReply_Log) XXX_Size() int {
+	return xxx_messageInfo_VerifyThreadReply_Log.Size(m)
+}
+func (m *VerifyThreadReply_Log) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyThreadReply_Log.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyThreadReply_Log proto.InternalMessageInfo
+
+func (m *VerifyThreadReply_Log) GetLogID() []byte {
+	if m != nil {
+		return m.LogID
+	}
+	return nil
+}
+
+func (m *VerifyThreadReply_Log) GetRecords() int64 {
+	if m != nil {
+		return m.Records
+	}
+	return 0
+}
+
+func (m *VerifyThreadReply_Log) GetDamage() []*VerifyThreadReply_Damage {
+	if m != nil {
+		return m.Damage
+	}
+	return nil
+}
+
+type VerifyThreadReply_Damage struct {
+	Kind                 int32    `protobuf:"varint,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Record               []byte   `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	Block                []byte   `protobuf:"bytes,3,opt,name=block,proto3" json:"block,omitempty"`
+	Repaired             bool     `protobuf:"varint,4,opt,name=repaired,proto3" json:"repaired,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyThreadReply_Damage) Reset()         { *m = VerifyThreadReply_Damage{} }
+func (m *VerifyThreadReply_Damage) String() string { return proto.CompactTextString(m) }
+func (*VerifyThreadReply_Damage) ProtoMessage()    {}
+func (*VerifyThreadReply_Damage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{17, 1}
+}
+
+func (m *VerifyThreadReply_Damage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyThreadReply_Damage.Unmarshal(m, b)
+}
+func (m *VerifyThreadReply_Damage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyThreadReply_Damage.Marshal(b, m, deterministic)
+}
+func (m *VerifyThreadReply_Damage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyThreadReply_Damage.Merge(m, src)
+}
+func (m *VerifyThreadReply_Damage) XXX_Size() int {
+	return xxx_messageInfo_VerifyThreadReply_Damage.Size(m)
+}
+func (m *VerifyThreadReply_Damage) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyThreadReply_Damage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyThreadReply_Damage proto.InternalMessageInfo
+
+func (m *VerifyThreadReply_Damage) GetKind() int32 {
+	if m != nil {
+		return m.Kind
+	}
+	return 0
+}
+
+func (m *VerifyThreadReply_Damage) GetRecord() []byte {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (m *VerifyThreadReply_Damage) GetBlock() []byte {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *VerifyThreadReply_Damage) GetRepaired() bool {
+	if m != nil {
+		return m.Repaired
+	}
+	return false
+}
+func init() {
+	proto.RegisterType((*GetParamsRequest)(nil), "threads.admin.pb.GetParamsRequest")
+	proto.RegisterType((*GetParamsReply)(nil), "threads.admin.pb.GetParamsReply")
+	proto.RegisterMapType((map[string]string)(nil), "threads.admin.pb.GetParamsReply.ParamsEntry")
+	proto.RegisterType((*ListPeersRequest)(nil), "threads.admin.pb.ListPeersRequest")
+	proto.RegisterType((*ListPeersReply)(nil), "threads.admin.pb.ListPeersReply")
+	proto.RegisterType((*ListPeersReply_Peer)(nil), "threads.admin.pb.ListPeersReply.Peer")
+	proto.RegisterType((*ListThreadsRequest)(nil), "threads.admin.pb.ListThreadsRequest")
+	proto.RegisterType((*ListThreadsReply)(nil), "threads.admin.pb.ListThreadsReply")
+	proto.RegisterType((*ListThreadsReply_Thread)(nil), "threads.admin.pb.ListThreadsReply.Thread")
+	proto.RegisterType((*PullThreadRequest)(nil), "threads.admin.pb.PullThreadRequest")
+	proto.RegisterType((*PullThreadReply)(nil), "threads.admin.pb.PullThreadReply")
+	proto.RegisterType((*CompactThreadRequest)(nil), "threads.admin.pb.CompactThreadRequest")
+	proto.RegisterType((*CompactThreadReply)(nil), "threads.admin.pb.CompactThreadReply")
+	proto.RegisterType((*DeleteThreadRequest)(nil), "threads.admin.pb.DeleteThreadRequest")
+	proto.RegisterType((*DeleteThreadReply)(nil), "threads.admin.pb.DeleteThreadReply")
+	proto.RegisterType((*GCRequest)(nil), "threads.admin.pb.GCRequest")
+	proto.RegisterType((*GCReply)(nil), "threads.admin.pb.GCReply")
+	proto.RegisterType((*GetMetricsRequest)(nil), "threads.admin.pb.GetMetricsRequest")
+	proto.RegisterType((*GetMetricsReply)(nil), "threads.admin.pb.GetMetricsReply")
+	proto.RegisterType((*VerifyThreadRequest)(nil), "threads.admin.pb.VerifyThreadRequest")
+	proto.RegisterType((*VerifyThreadReply)(nil), "threads.admin.pb.VerifyThreadReply")
+	proto.RegisterType((*VerifyThreadReply_Log)(nil), "threads.admin.pb.VerifyThreadReply.Log")
+	proto.RegisterType((*VerifyThreadReply_Damage)(nil), "threads.admin.pb.VerifyThreadReply.Damage")
+}
+
+func init() { proto.RegisterFile("admin.proto", fileDescriptor_73a7fc70dcc2027c) }
+
+var fileDescriptor_73a7fc70dcc2027c = []byte{
+	// 988 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xdd, 0x6e, 0xe2, 0x46,
+	0x14, 0x5e, 0xe3, 0xe0, 0xc0, 0x81, 0x66, 0xc9, 0x24, 0x5a, 0xb9, 0xee, 0x8f, 0x58, 0x67, 0xbb,
+	0xa5, 0xab, 0x8a, 0xaa, 0xe9, 0x4d, 0x7f, 0xa4, 0x95, 0x12, 0x88, 0x68, 0xa4, 0x6c, 0x15, 0x4d,
+	0x56, 0x5b, 0x55, 0xaa, 0x14, 0x19, 0x7b, 0x96, 0x58, 0x31, 0xd8, 0x1d, 0x0f, 0xe9, 0xf2, 0x0a,
+	0x7d, 0x83, 0xde, 0xf6, 0x15, 0x7a, 0xd5, 0xbe, 0x40, 0xaf, 0xfa, 0x4e, 0xab, 0x33, 0xc7, 0x18,
+	0x03, 0x16, 0x70, 0xe7, 0xef, 0xe3, 0x3b, 0x67, 0xce, 0x7c, 0x33, 0xe7, 0x0c, 0xd0, 0xf0, 0x82,
+	0x71, 0x38, 0xe9, 0x26, 0x32, 0x56, 0x31, 0x6b, 0xa9, 0x3b, 0x29, 0xbc, 0x20, 0xed, 0x66, 0xe4,
+	0xd0, 0x65, 0xd0, 0x1a, 0x08, 0x75, 0xed, 0x49, 0x6f, 0x9c, 0x72, 0xf1, 0xdb, 0x54, 0xa4, 0xca,
+	0xfd, 0xcf, 0x80, 0x83, 0x02, 0x99, 0x44, 0x33, 0xf6, 0x04, 0xac, 0xbb, 0x38, 0x55, 0x97, 0x7d,
+	0xdb, 0x68, 0x1b, 0x9d, 0x26, 0xcf, 0x10, 0xfb, 0x18, 0xea, 0xf8, 0x75, 0x16, 0x04, 0x32, 0xb5,
+	0x2b, 0x6d, 0xb3, 0xd3, 0xe4, 0x0b, 0x82, 0xf5, 0xc1, 0x4a, 0x74, 0x12, 0xdb, 0x6c, 0x9b, 0x9d,
+	0xc6, 0xe9, 0x97, 0xdd, 0xd5, 0xf5, 0xbb, 0xcb, 0xeb, 0x74, 0xe9, 0xfb, 0x62, 0xa2, 0xe4, 0x8c,
+	0x67, 0xb1, 0xce, 0x77, 0xd0, 0x28, 0xd0, 0xac, 0x05, 0xe6, 0xbd, 0x98, 0xe9, 0x3a, 0xea, 0x1c,
+	0x3f, 0xd9, 0x31, 0x54, 0x1f, 0xbc, 0x68, 0x2a, 0xec, 0x8a, 0xe6, 0x08, 0x7c, 0x5f, 0xf9, 0xd6,
+	0xc0, 0xdd, 0x5d, 0x85, 0xa9, 0xba, 0x16, 0x42, 0xe6, 0xbb, 0xfb, 0xa3, 0x02, 0x07, 0x05, 0x12,
+	0x77, 0xf7, 0x03, 0x54, 0x13, 0x44, 0xb6, 0xa1, 0xcb, 0xfc, 0x6c, 0xbd, 0xcc, 0xe5, 0x80, 0x2e,
+	0x7e, 0x72, 0x8a, 0x71, 0xfe, 0x35, 0x60, 0x0f, 0x31, 0x7a, 0x84, 0xcc, 0xc2, 0x23, 0x42, 0x58,
+	0x9e, 0x57, 0xf0, 0x87, 0x00, 0x3a, 0xe7, 0xc7, 0x93, 0x89, 0xf0, 0x95, 0x08, 0x6c, 0xb3, 0x6d,
+	0x74, 0x6a, 0x7c, 0x41, 0xb0, 0xe7, 0x70, 0x90, 0x88, 0x49, 0x10, 0x4e, 0x46, 0x5c, 0xf8, 0xb1,
+	0x0c, 0x52, 0x7b, 0xaf, 0x6d, 0x74, 0x4c, 0xbe, 0xc2, 0xb2, 0x36, 0x34, 0x22, 0x2f, 0x55, 0x37,
+	0x53, 0xdf, 0x17, 0x69, 0x6a, 0x57, 0xb5, 0xa8, 0x48, 0xe1, 0x3a, 0x08, 0x2f, 0xa4, 0x8c, 0xa5,
+	0x6d, 0x69, 0x83, 0x16, 0x84, 0x7b, 0x0c, 0x0c, 0xb7, 0xf6, 0x9a, 0xf6, 0x3b, 0xb7, 0xe8, 0x7f,
+	0x03, 0x5a, 0x4b, 0x34, 0x9a, 0xd4, 0x83, 0xfd, 0xcc, 0x96, 0xcc, 0xa6, 0x2f, 0xca, 0x6d, 0x2a,
+	0x06, 0x75, 0x09, 0xf0, 0x79, 0xa4, 0xa3, 0xc0, 0x22, 0x8a, 0x39, 0x50, 0x23, 0x32, 0xf7, 0x2b,
+	0xc7, 0x8c, 0xc1, 0x5e, 0x14, 0x8f, 0x52, 0x7d, 0x9e, 0x55, 0xae, 0xbf, 0x51, 0x8f, 0xbf, 0x7a,
+	0xc3, 0x48, 0x64, 0x76, 0xe5, 0x98, 0x7d, 0x0a, 0x90, 0x4e, 0x87, 0xa9, 0x2f, 0xc3, 0xa1, 0x08,
+	0xb4, 0x53, 0x35, 0x5e, 0x60, 0xdc, 0xaf, 0xe0, 0xf0, 0x7a, 0x1a, 0x45, 0x59, 0x31, 0xb4, 0xc9,
+	0x4d, 0x05, 0xb8, 0x87, 0xf0, 0xb8, 0x18, 0x90, 0x44, 0x33, 0xf7, 0x14, 0x8e, 0x7b, 0xf1, 0x38,
+	0xf1, 0x7c, 0xb5, 0x7b, 0x9a, 0x63, 0x60, 0x2b, 0x31, 0x98, 0xe9, 0x6b, 0x38, 0xea, 0x8b, 0x48,
+	0x28, 0xb1, 0x7b, 0xa2, 0x23, 0x38, 0x5c, 0x0e, 0xc1, 0x3c, 0x0d, 0xa8, 0x0f, 0x7a, 0xf3, 0x23,
+	0x3b, 0x81, 0xfd, 0x41, 0x8f, 0x0e, 0xca, 0x86, 0x7d, 0x29, 0xc6, 0xf1, 0x83, 0x08, 0x74, 0x1e,
+	0x93, 0xcf, 0x21, 0xa6, 0x19, 0x08, 0xf5, 0x4a, 0x28, 0x19, 0xfa, 0xf9, 0x61, 0xff, 0x63, 0xc2,
+	0xe3, 0x22, 0x9b, 0xa5, 0x58, 0x9c, 0xb5, 0x4e, 0x91, 0x41, 0xbc, 0xe4, 0x2a, 0x4e, 0x42, 0x9f,
+	0x0e, 0xc7, 0xe4, 0x19, 0xc2, 0x0b, 0x9b, 0xdf, 0x5e, 0xdd, 0x28, 0xfa, 0x90, 0x4c, 0xbe, 0xc2,
+	0xee, 0x7c, 0xb1, 0x9f, 0x80, 0x35, 0x4d, 0x54, 0x38, 0x16, 0xd9, 0x9d, 0xce, 0x10, 0x7b, 0x01,
+	0xad, 0x64, 0x3a, 0x8c, 0xc2, 0xf4, 0x4e, 0x04, 0xf3, 0x0c, 0x96, 0x56, 0xac, 0xf1, 0xa8, 0xf5,
+	0x63, 0x2f, 0x12, 0xa9, 0xbf, 0xd0, 0xee, 0x93, 0x76, 0x95, 0xc7, 0xba, 0x02, 0x19, 0x27, 0xc9,
+	0x42, 0x59, 0xa3, 0xba, 0x96, 0x59, 0xe6, 0x42, 0xf3, 0x77, 0x2f, 0x54, 0xe1, 0x64, 0x84, 0x17,
+	0x24, 0xb5, 0xeb, 0x5a, 0xb5, 0xc4, 0xa1, 0x26, 0x7e, 0x10, 0x32, 0x98, 0x0a, 0xd2, 0x00, 0x69,
+	0x8a, 0x1c, 0x6a, 0xc6, 0xc2, 0x9b, 0x20, 0xf8, 0xd9, 0x0b, 0x95, 0xdd, 0x20, 0x4d, 0x91, 0xc3,
+	0xe6, 0x1e, 0x7b, 0xef, 0x72, 0x49, 0x93, 0x9a, 0xbb, 0x40, 0xb9, 0x97, 0x70, 0xf4, 0x46, 0xc8,
+	0xf0, 0xed, 0x6c, 0xe7, 0xab, 0x84, 0xc6, 0x4a, 0x91, 0x78, 0xa1, 0xd4, 0x07, 0x58, 0xe3, 0x19,
+	0x72, 0xff, 0xae, 0xc0, 0xe1, 0x72, 0x2e, 0x9a, 0x8c, 0xd4, 0x89, 0xd4, 0xf1, 0x9f, 0xaf, 0x77,
+	0xfc, 0x5a, 0x48, 0xf7, 0x2a, 0x1e, 0x51, 0xcb, 0x3a, 0x33, 0x30, 0xaf, 0xe2, 0x11, 0xce, 0xbf,
+	0x28, 0x1e, 0xe5, 0xa5, 0x10, 0xa0, 0x5b, 0x4a, 0x4e, 0x57, 0xe6, 0xb7, 0x94, 0x2c, 0x3e, 0x07,
+	0x2b, 0xf0, 0xc6, 0xde, 0x48, 0x64, 0xaf, 0xc6, 0x8b, 0x5d, 0x56, 0xed, 0xeb, 0x08, 0x9e, 0x45,
+	0x3a, 0x6f, 0xc1, 0x22, 0x06, 0x67, 0xc9, 0x7d, 0x38, 0xa1, 0x56, 0xa8, 0x72, 0xfd, 0x4d, 0x1e,
+	0xe0, 0x62, 0x7a, 0xe9, 0x26, 0xcf, 0x10, 0x56, 0x3a, 0x8c, 0x62, 0xff, 0x5e, 0xdf, 0xdd, 0x26,
+	0x27, 0x40, 0x93, 0x07, 0x3d, 0xca, 0x67, 0x4b, 0x8e, 0x4f, 0xff, 0xb4, 0xa0, 0x7a, 0x86, 0x55,
+	0xb1, 0x1b, 0xa8, 0xe7, 0x6f, 0x19, 0x73, 0x37, 0x3e, 0x74, 0xfa, 0x90, 0x9c, 0xf6, 0xb6, 0xc7,
+	0xd0, 0x7d, 0x84, 0x49, 0xf3, 0x97, 0xa7, 0x2c, 0xe9, 0xea, 0xe3, 0xe6, 0xb4, 0x37, 0x6a, 0x28,
+	0xe9, 0x2f, 0xd0, 0x28, 0xcc, 0x69, 0xf6, 0x6c, 0xcb, 0x18, 0xa7, 0xc4, 0xee, 0xf6, 0x61, 0xef,
+	0x3e, 0x62, 0x6f, 0x00, 0x16, 0x73, 0x93, 0x9d, 0xac, 0xc7, 0xac, 0x8d, 0x61, 0xe7, 0xe9, 0x66,
+	0x11, 0xe5, 0xbd, 0x85, 0x0f, 0x96, 0x06, 0x29, 0x7b, 0xbe, 0x1e, 0x55, 0x36, 0x9d, 0x9d, 0x67,
+	0x5b, 0x75, 0xb4, 0xc0, 0xaf, 0xd0, 0x2c, 0x0e, 0x58, 0x56, 0xf2, 0x17, 0xa0, 0x64, 0x66, 0x3b,
+	0x27, 0xdb, 0x64, 0x94, 0xfd, 0x25, 0x54, 0x06, 0x3d, 0xf6, 0x51, 0xc9, 0x81, 0xcf, 0xe7, 0xb7,
+	0xf3, 0x61, 0xf9, 0x8f, 0xb9, 0xad, 0x8b, 0x09, 0x5d, 0x66, 0xeb, 0xda, 0x54, 0x77, 0x9e, 0x6e,
+	0x16, 0xe5, 0xbb, 0x2e, 0x76, 0x52, 0xd9, 0xae, 0x4b, 0xc6, 0x8b, 0x73, 0xb2, 0x4d, 0xa6, 0xb3,
+	0x9f, 0xbf, 0x84, 0x4f, 0xc2, 0xb8, 0xab, 0xc4, 0x3b, 0x15, 0x46, 0x62, 0x1e, 0x72, 0xab, 0x43,
+	0x6e, 0x47, 0x32, 0xf1, 0xcf, 0x9b, 0xa4, 0x4f, 0x75, 0x03, 0x5d, 0x1b, 0x7f, 0x55, 0x9a, 0xaf,
+	0x7f, 0xe4, 0x17, 0x67, 0xfd, 0x9b, 0xb3, 0xfe, 0xab, 0xcb, 0x9f, 0x86, 0x96, 0xfe, 0xcf, 0xfa,
+	0xcd, 0xfb, 0x01, 0x00, 0x0c, 0x2c, 0x8e, 0xe3, 0xc2, 0x0a, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	GetParams(ctx context.Context, in *GetParamsRequest, opts ...grpc.CallOption) (*GetParamsReply, error)
+	ListPeers(ctx context.Context, in *ListPeersRequest, opts ...grpc.CallOption) (*ListPeersReply, error)
+	ListThreads(ctx context.Context, in *ListThreadsRequest, opts ...grpc.CallOption) (*ListThreadsReply, error)
+	PullThread(ctx context.Context, in *PullThreadRequest, opts ...grpc.CallOption) (*PullThreadReply, error)
+	CompactThread(ctx context.Context, in *CompactThreadRequest, opts ...grpc.CallOption) (*CompactThreadReply, error)
+	DeleteThread(ctx context.Context, in *DeleteThreadRequest, opts ...grpc.CallOption) (*DeleteThreadReply, error)
+	GC(ctx context.Context, in *GCRequest, opts ...grpc.CallOption) (*GCReply, error)
+	GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*GetMetricsReply, error)
+	VerifyThread(ctx context.Context, in *VerifyThreadRequest, opts ...grpc.CallOption) (*VerifyThreadReply, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) GetParams(ctx context.Context, in *GetParamsRequest, opts ...grpc.CallOption) (*GetParamsReply, error) {
+	out := new(GetParamsReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/GetParams", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListPeers(ctx context.Context, in *ListPeersRequest, opts ...grpc.CallOption) (*ListPeersReply, error) {
+	out := new(ListPeersReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/ListPeers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListThreads(ctx context.Context, in *ListThreadsRequest, opts ...grpc.CallOption) (*ListThreadsReply, error) {
+	out := new(ListThreadsReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/ListThreads", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) PullThread(ctx context.Context, in *PullThreadRequest, opts ...grpc.CallOption) (*PullThreadReply, error) {
+	out := new(PullThreadReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/PullThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CompactThread(ctx context.Context, in *CompactThreadRequest, opts ...grpc.CallOption) (*CompactThreadReply, error) {
+	out := new(CompactThreadReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/CompactThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteThread(ctx context.Context, in *DeleteThreadRequest, opts ...grpc.CallOption) (*DeleteThreadReply, error) {
+	out := new(DeleteThreadReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/DeleteThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GC(ctx context.Context, in *GCRequest, opts ...grpc.CallOption) (*GCReply, error) {
+	out := new(GCReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/GC", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*GetMetricsReply, error) {
+	out := new(GetMetricsReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/GetMetrics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) VerifyThread(ctx context.Context, in *VerifyThreadRequest, opts ...grpc.CallOption) (*VerifyThreadReply, error) {
+	out := new(VerifyThreadReply)
+	err := c.cc.Invoke(ctx, "/threads.admin.pb.Admin/VerifyThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	GetParams(context.Context, *GetParamsRequest) (*GetParamsReply, error)
+	ListPeers(context.Context, *ListPeersRequest) (*ListPeersReply, error)
+	ListThreads(context.Context, *ListThreadsRequest) (*ListThreadsReply, error)
+	PullThread(context.Context, *PullThreadRequest) (*PullThreadReply, error)
+	CompactThread(context.Context, *CompactThreadRequest) (*CompactThreadReply, error)
+	DeleteThread(context.Context, *DeleteThreadRequest) (*DeleteThreadReply, error)
+	GC(context.Context, *GCRequest) (*GCReply, error)
+	GetMetrics(context.Context, *GetMetricsRequest) (*GetMetricsReply, error)
+	VerifyThread(context.Context, *VerifyThreadRequest) (*VerifyThreadReply, error)
+}
+
+// UnimplementedAdminServer can be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (*UnimplementedAdminServer) GetParams(ctx context.Context, req *GetParamsRequest) (*GetParamsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetParams not implemented")
+}
+func (*UnimplementedAdminServer) ListPeers(ctx context.Context, req *ListPeersRequest) (*ListPeersReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPeers not implemented")
+}
+func (*UnimplementedAdminServer) ListThreads(ctx context.Context, req *ListThreadsRequest) (*ListThreadsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListThreads not implemented")
+}
+func (*UnimplementedAdminServer) PullThread(ctx context.Context, req *PullThreadRequest) (*PullThreadReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PullThread not implemented")
+}
+func (*UnimplementedAdminServer) CompactThread(ctx context.Context, req *CompactThreadRequest) (*CompactThreadReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompactThread not implemented")
+}
+func (*UnimplementedAdminServer) DeleteThread(ctx context.Context, req *DeleteThreadRequest) (*DeleteThreadReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteThread not implemented")
+}
+func (*UnimplementedAdminServer) GC(ctx context.Context, req *GCRequest) (*GCReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GC not implemented")
+}
+func (*UnimplementedAdminServer) GetMetrics(ctx context.Context, req *GetMetricsRequest) (*GetMetricsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetrics not implemented")
+}
+func (*UnimplementedAdminServer) VerifyThread(ctx context.Context, req *VerifyThreadRequest) (*VerifyThreadReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyThread not implemented")
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_GetParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/GetParams",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetParams(ctx, req.(*GetParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPeersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/ListPeers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListPeers(ctx, req.(*ListPeersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListThreads_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListThreadsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListThreads(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/ListThreads",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListThreads(ctx, req.(*ListThreadsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_PullThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PullThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).PullThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/PullThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).PullThread(ctx, req.(*PullThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CompactThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CompactThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/CompactThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CompactThread(ctx, req.(*CompactThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/DeleteThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteThread(ctx, req.(*DeleteThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GCRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/GC",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GC(ctx, req.(*GCRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/GetMetrics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetMetrics(ctx, req.(*GetMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_VerifyThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).VerifyThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.admin.pb.Admin/VerifyThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).VerifyThread(ctx, req.(*VerifyThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "threads.admin.pb.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetParams",
+			Handler:    _Admin_GetParams_Handler,
+		},
+		{
+			MethodName: "ListPeers",
+			Handler:    _Admin_ListPeers_Handler,
+		},
+		{
+			MethodName: "ListThreads",
+			Handler:    _Admin_ListThreads_Handler,
+		},
+		{
+			MethodName: "PullThread",
+			Handler:    _Admin_PullThread_Handler,
+		},
+		{
+			MethodName: "CompactThread",
+			Handler:    _Admin_CompactThread_Handler,
+		},
+		{
+			MethodName: "DeleteThread",
+			Handler:    _Admin_DeleteThread_Handler,
+		},
+		{
+			MethodName: "GC",
+			Handler:    _Admin_GC_Handler,
+		},
+		{
+			MethodName: "GetMetrics",
+			Handler:    _Admin_GetMetrics_Handler,
+		},
+		{
+			MethodName: "VerifyThread",
+			Handler:    _Admin_VerifyThread_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
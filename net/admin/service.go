@@ -0,0 +1,343 @@
+// Package admin is the node administration API. It contains the protobuf definition (under /pb), a Go client (under /client) and a gRPC service backed by the threads network.
+// Unlike the network API, the admin API acts with the authority of the host, so it must be served
+// behind client certificates or a token.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/admin/pb"
+	tutil "github.com/textileio/go-threads/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	log = logging.Logger("netadmin")
+)
+
+// Network is a thread network managed by the service.
+type Network interface {
+	net.Net
+
+	// Store returns the logstore of the network.
+	Store() lstore.Logstore
+}
+
+// Service is a gRPC service for the administration of a thread network host.
+type Service struct {
+	net    Network
+	token  string
+	params map[string]string
+	start  time.Time
+}
+
+// Config specifies service settings.
+type Config struct {
+	Debug bool
+
+	// Token is required from clients in the authorization metadata if set.
+	Token string
+
+	// Params are the host settings reported to clients.
+	Params map[string]string
+}
+
+// NewService returns a new service.
+func NewService(network Network, conf Config) (*Service, error) {
+	var err error
+	if conf.Debug {
+		err = tutil.SetLogLevels(map[string]logging.LogLevel{
+			"netadmin": logging.LevelDebug,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Service{
+		net:    network,
+		token:  conf.Token,
+		params: conf.Params,
+		start:  time.Now(),
+	}, nil
+}
+
+// UnaryInterceptor rejects requests without the service token.
+func (s *Service) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := s.authorize(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects streams without the service token.
+func (s *Service) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := s.authorize(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (s *Service) authorize(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing admin token")
+	}
+	for _, auth := range md.Get("authorization") {
+		parts := strings.SplitN(auth, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "bearer") &&
+			subtle.ConstantTimeCompare([]byte(parts[1]), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid admin token")
+}
+
+func (s *Service) GetParams(_ context.Context, _ *pb.GetParamsRequest) (*pb.GetParamsReply, error) {
+	log.Debugf("received get params request")
+
+	host := s.net.Host()
+	addrs := make([][]byte, len(host.Addrs()))
+	for i, addr := range host.Addrs() {
+		addrs[i] = addr.Bytes()
+	}
+	return &pb.GetParamsReply{
+		HostID:    marshalPeerID(host.ID()),
+		HostAddrs: addrs,
+		Params:    s.params,
+	}, nil
+}
+
+func (s *Service) ListPeers(ctx context.Context, _ *pb.ListPeersRequest) (*pb.ListPeersReply, error) {
+	log.Debugf("received list peers request")
+
+	sync, err := s.net.SyncStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	host := s.net.Host()
+	pids := host.Network().Peers()
+	for pid := range sync {
+		if host.Network().Connectedness(pid) != network.Connected {
+			pids = append(pids, pid)
+		}
+	}
+
+	peers := make([]*pb.ListPeersReply_Peer, len(pids))
+	for i, pid := range pids {
+		addrs := host.Peerstore().Addrs(pid)
+		p := &pb.ListPeersReply_Peer{
+			PeerID:    marshalPeerID(pid),
+			Addrs:     make([][]byte, len(addrs)),
+			Connected: host.Network().Connectedness(pid) == network.Connected,
+		}
+		for j, addr := range addrs {
+			p.Addrs[j] = addr.Bytes()
+		}
+		if st, ok := sync[pid]; ok {
+			p.PendingRecords = int64(st.Pending)
+			if !st.LastSuccess.IsZero() {
+				p.LastSuccess = st.LastSuccess.Unix()
+			}
+			if st.LastError != nil {
+				p.LastError = st.LastError.Error()
+			}
+		}
+		peers[i] = p
+	}
+	return &pb.ListPeersReply{Peers: peers}, nil
+}
+
+func (s *Service) ListThreads(ctx context.Context, _ *pb.ListThreadsRequest) (*pb.ListThreadsReply, error) {
+	log.Debugf("received list threads request")
+
+	ids, err := s.net.Store().Threads()
+	if err != nil {
+		return nil, err
+	}
+	topics, err := s.net.Topics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := make(map[thread.ID]struct{}, len(topics))
+	for _, id := range topics {
+		subscribed[id] = struct{}{}
+	}
+
+	threads := make([]*pb.ListThreadsReply_Thread, 0, len(ids))
+	for _, id := range ids {
+		info, err := s.net.Store().GetThread(id)
+		if err != nil {
+			log.Errorf("error getting thread %s: %v", id, err)
+			continue
+		}
+		_, sub := subscribed[id]
+		threads = append(threads, &pb.ListThreadsReply_Thread{
+			ThreadID:   id.Bytes(),
+			Logs:       int32(len(info.Logs)),
+			Readable:   info.Key.CanRead(),
+			Subscribed: sub,
+		})
+	}
+	return &pb.ListThreadsReply{Threads: threads}, nil
+}
+
+func (s *Service) PullThread(ctx context.Context, req *pb.PullThreadRequest) (*pb.PullThreadReply, error) {
+	log.Debugf("received pull thread request")
+
+	id, err := thread.Cast(req.ThreadID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = s.net.PullThread(ctx, id); err != nil {
+		return nil, err
+	}
+	return &pb.PullThreadReply{}, nil
+}
+
+func (s *Service) CompactThread(ctx context.Context, req *pb.CompactThreadRequest) (*pb.CompactThreadReply, error) {
+	log.Debugf("received compact thread request")
+
+	id, err := thread.Cast(req.ThreadID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = s.net.CompactThread(ctx, id); err != nil {
+		return nil, err
+	}
+	return &pb.CompactThreadReply{}, nil
+}
+
+func (s *Service) DeleteThread(ctx context.Context, req *pb.DeleteThreadRequest) (*pb.DeleteThreadReply, error) {
+	log.Debugf("received delete thread request")
+
+	id, err := thread.Cast(req.ThreadID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = s.net.DeleteThread(ctx, id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteThreadReply{}, nil
+}
+
+func (s *Service) VerifyThread(ctx context.Context, req *pb.VerifyThreadRequest) (*pb.VerifyThreadReply, error) {
+	log.Debugf("received verify thread request")
+
+	id, err := thread.Cast(req.ThreadID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var opts []net.ThreadOption
+	if req.Repair {
+		opts = append(opts, net.WithRepair())
+	}
+	v, err := s.net.VerifyThread(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.VerifyThreadReply{Logs: make([]*pb.VerifyThreadReply_Log, len(v.Logs))}
+	for i, l := range v.Logs {
+		pl := &pb.VerifyThreadReply_Log{
+			LogID:   []byte(l.ID),
+			Records: int64(l.Records),
+			Damage:  make([]*pb.VerifyThreadReply_Damage, len(l.Damage)),
+		}
+		for j, d := range l.Damage {
+			pl.Damage[j] = &pb.VerifyThreadReply_Damage{
+				Kind:     int32(d.Kind),
+				Record:   d.Record.Bytes(),
+				Block:    d.Block.Bytes(),
+				Repaired: d.Repaired,
+			}
+		}
+		reply.Logs[i] = pl
+	}
+	return reply, nil
+}
+
+func (s *Service) GC(ctx context.Context, _ *pb.GCRequest) (*pb.GCReply, error) {
+	log.Debugf("received gc request")
+
+	removed, err := s.net.GC(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GCReply{Removed: int64(removed)}, nil
+}
+
+func (s *Service) GetMetrics(ctx context.Context, _ *pb.GetMetricsRequest) (*pb.GetMetricsReply, error) {
+	log.Debugf("received get metrics request")
+
+	ids, err := s.net.Store().Threads()
+	if err != nil {
+		return nil, err
+	}
+	topics, err := s.net.Topics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sync, err := s.net.SyncStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	publish, err := s.net.PublishStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calls, err := s.net.CallQueueStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending int64
+	for _, st := range sync {
+		pending += int64(st.Pending)
+	}
+	var pulls net.CallQueueStatus
+	for _, st := range calls {
+		pulls.Waiting += st.Waiting
+		pulls.Spawned += st.Spawned
+		pulls.Overdue += st.Overdue
+		pulls.TotalWait += st.TotalWait
+		if st.MaxWait > pulls.MaxWait {
+			pulls.MaxWait = st.MaxWait
+		}
+	}
+	return &pb.GetMetricsReply{
+		Threads:          int64(len(ids)),
+		Topics:           int64(len(topics)),
+		ConnectedPeers:   int64(len(s.net.Host().Network().Peers())),
+		PendingRecords:   pending,
+		Uptime:           int64(time.Since(s.start).Seconds()),
+		PublishedRecords: int64(publish.Published),
+		CoalescedRecords: int64(publish.Coalesced),
+		DroppedRecords:   int64(publish.Dropped),
+		WaitingPulls:     int64(pulls.Waiting),
+		OverduePulls:     int64(pulls.Overdue),
+		MeanPullWait:     pulls.MeanWait().Milliseconds(),
+		MaxPullWait:      pulls.MaxWait.Milliseconds(),
+	}, nil
+}
+
+func marshalPeerID(id peer.ID) []byte {
+	b, _ := id.Marshal() // This will never return an error
+	return b
+}
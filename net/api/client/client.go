@@ -2,9 +2,11 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipld-format"
@@ -17,10 +19,13 @@ import (
 	"github.com/textileio/go-threads/crypto"
 	"github.com/textileio/go-threads/crypto/symmetric"
 	"github.com/textileio/go-threads/net"
+	"github.com/textileio/go-threads/net/api"
 	pb "github.com/textileio/go-threads/net/api/pb"
 	"github.com/textileio/go-threads/net/util"
+	tu "github.com/textileio/go-threads/util"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 )
 
@@ -33,7 +38,13 @@ type Client struct {
 var _ core.API = (*Client)(nil)
 
 // NewClient starts the client.
+// Errors returned by the service are reconstructed with api.FromStatus, so callers can
+// distinguish them with errors.Is, e.g., logstore.ErrThreadNotFound or api.ErrTransient.
 func NewClient(target string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unaryErrorInterceptor),
+		grpc.WithChainStreamInterceptor(streamErrorInterceptor),
+	}, opts...)
 	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
 		return nil, err
@@ -44,11 +55,44 @@ func NewClient(target string, opts ...grpc.DialOption) (*Client, error) {
 	}, nil
 }
 
+// NewGatewayClient starts a client of the network API served on net.Config.ListenAddr.
+// The connection is secured with tlsConf if set, and token is sent as the access token
+// if the gateway requires one, see net.Config.ListenToken.
+func NewGatewayClient(addr ma.Multiaddr, tlsConf *tls.Config, token string, opts ...grpc.DialOption) (*Client, error) {
+	target, err := tu.TCPAddrFromMultiAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConf != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(AccessTokenCredentials{Token: token, Secure: tlsConf != nil}))
+	}
+	return NewClient(target, append(opts, grpc.WithPerRPCCredentials(thread.Credentials{}))...)
+}
+
 // Close closes the client's grpc connection and cancels any active requests.
 func (c *Client) Close() error {
 	return c.conn.Close()
 }
 
+// AccessTokenCredentials implements PerRPCCredentials, adding the gateway access token to request metadata.
+type AccessTokenCredentials struct {
+	Token  string
+	Secure bool
+}
+
+func (c AccessTokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{api.AccessTokenKey: c.Token}, nil
+}
+
+func (c AccessTokenCredentials) RequireTransportSecurity() bool {
+	return c.Secure
+}
+
 func (c *Client) GetHostID(ctx context.Context) (peer.ID, error) {
 	resp, err := c.c.GetHostID(ctx, &pb.GetHostIDRequest{})
 	if err != nil {
@@ -118,6 +162,28 @@ func (c *Client) GetToken(ctx context.Context, identity thread.Identity) (tok th
 	return tok, nil
 }
 
+func (c *Client) GetTokenChallenge(ctx context.Context, key thread.PubKey) ([]byte, time.Time, error) {
+	resp, err := c.c.GetTokenChallenge(ctx, &pb.GetTokenChallengeRequest{
+		Key: key.String(),
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return resp.Challenge, time.Unix(resp.Expires, 0), nil
+}
+
+func (c *Client) GetTokenWithChallenge(ctx context.Context, key thread.PubKey, challenge, sig []byte) (thread.Token, error) {
+	resp, err := c.c.GetTokenWithChallenge(ctx, &pb.GetTokenWithChallengeRequest{
+		Key:       key.String(),
+		Challenge: challenge,
+		Signature: sig,
+	})
+	if err != nil {
+		return "", err
+	}
+	return thread.Token(resp.Token), nil
+}
+
 func (c *Client) CreateThread(ctx context.Context, id thread.ID, opts ...core.NewThreadOption) (info thread.Info, err error) {
 	args := &core.NewThreadOptions{}
 	for _, opt := range opts {
@@ -332,6 +398,45 @@ func (c *Client) Subscribe(ctx context.Context, opts ...core.SubOption) (<-chan
 	return channel, nil
 }
 
+func unaryErrorInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	return api.FromStatus(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+func streamErrorInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, api.FromStatus(err)
+	}
+	return &errorStream{ClientStream: s}, nil
+}
+
+// errorStream reconstructs the typed errors of a client stream.
+type errorStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorStream) SendMsg(m interface{}) error {
+	return api.FromStatus(s.ClientStream.SendMsg(m))
+}
+
+func (s *errorStream) RecvMsg(m interface{}) error {
+	return api.FromStatus(s.ClientStream.RecvMsg(m))
+}
+
 func getThreadKeys(args *core.NewThreadOptions) (*pb.Keys, error) {
 	keys := &pb.Keys{
 		ThreadKey: args.ThreadKey.Bytes(),
@@ -403,11 +508,16 @@ func threadInfoFromProto(reply *pb.ThreadInfoReply) (info thread.Info, err error
 			return info, err
 		}
 	}
+	flags, err := thread.NewFlags(reply.Flags...)
+	if err != nil {
+		return
+	}
 	return thread.Info{
 		ID:    threadID,
 		Key:   k,
 		Logs:  logs,
 		Addrs: addrs,
+		Flags: flags,
 	}, nil
 }
 
@@ -427,5 +537,14 @@ func threadRecordFromProto(reply *pb.NewRecordReply, key crypto.DecryptionKey) (
 	if err = threadID.Validate(); err != nil {
 		return nil, err
 	}
-	return net.NewRecord(rec, threadID, logID), nil
+	src := core.RecordSource{Kind: core.RecordSourceKind(reply.SourceKind)}
+	if len(reply.SourcePeer) > 0 {
+		if src.Peer, err = peer.IDFromBytes(reply.SourcePeer); err != nil {
+			return nil, err
+		}
+	}
+	if reply.ReceivedAt != 0 {
+		src.ReceivedAt = time.Unix(0, reply.ReceivedAt)
+	}
+	return net.NewRecordFrom(rec, threadID, logID, src), nil
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/phayes/freeport"
 	"github.com/textileio/go-threads/cbor"
 	"github.com/textileio/go-threads/common"
+	lstore "github.com/textileio/go-threads/core/logstore"
 	core "github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
 	sym "github.com/textileio/go-threads/crypto/symmetric"
@@ -31,6 +32,8 @@ import (
 	pb "github.com/textileio/go-threads/net/api/pb"
 	"github.com/textileio/go-threads/util"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestClient_GetHostID(t *testing.T) {
@@ -63,6 +66,53 @@ func TestClient_GetToken(t *testing.T) {
 	})
 }
 
+func TestClient_GetTokenWithChallenge(t *testing.T) {
+	t.Parallel()
+	_, client, done := setup(t)
+	defer done()
+
+	identity := createIdentity(t)
+	ctx := context.Background()
+	challenge, expires, err := client.GetTokenChallenge(ctx, identity.GetPublic())
+	if err != nil {
+		t.Fatalf("failed to get token challenge: %v", err)
+	}
+	if expires.Before(time.Now()) {
+		t.Fatal("expected challenge to be pending")
+	}
+	sig, err := identity.Sign(ctx, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test get token with wrong key", func(t *testing.T) {
+		other := createIdentity(t)
+		if _, err := client.GetTokenWithChallenge(ctx, other.GetPublic(), challenge, sig); err == nil {
+			t.Fatal("expected challenge issued to another key to be refused")
+		}
+	})
+
+	t.Run("test get token with challenge", func(t *testing.T) {
+		// the refused attempt wiped the challenge
+		if challenge, _, err = client.GetTokenChallenge(ctx, identity.GetPublic()); err != nil {
+			t.Fatal(err)
+		}
+		if sig, err = identity.Sign(ctx, challenge); err != nil {
+			t.Fatal(err)
+		}
+		tok, err := client.GetTokenWithChallenge(ctx, identity.GetPublic(), challenge, sig)
+		if err != nil {
+			t.Fatalf("failed to get token with challenge: %v", err)
+		}
+		if tok == "" {
+			t.Fatal("empty token")
+		}
+		if _, err = client.GetTokenWithChallenge(ctx, identity.GetPublic(), challenge, sig); err == nil {
+			t.Fatal("expected redeemed challenge to be refused")
+		}
+	})
+}
+
 func TestClient_CreateThread(t *testing.T) {
 	t.Parallel()
 	_, client, done := setup(t)
@@ -374,6 +424,64 @@ func TestClient_Subscribe(t *testing.T) {
 	})
 }
 
+func TestClient_Errors(t *testing.T) {
+	t.Parallel()
+	_, client, done := setup(t)
+	defer done()
+
+	info := createThread(t, client)
+	ctx := context.Background()
+
+	t.Run("test thread not found", func(t *testing.T) {
+		_, err := client.GetThread(ctx, thread.NewIDV1(thread.Raw, 32))
+		if !errors.Is(err, lstore.ErrThreadNotFound) {
+			t.Fatalf("expected thread not found, got %v", err)
+		}
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("expected code %s, got %s", codes.NotFound, status.Code(err))
+		}
+		var aerr *api.Error
+		if !errors.As(err, &aerr) || aerr.Code != pb.ErrorCode_THREAD_NOT_FOUND {
+			t.Fatalf("expected error code %s, got %v", pb.ErrorCode_THREAD_NOT_FOUND, err)
+		}
+	})
+
+	t.Run("test invalid token", func(t *testing.T) {
+		sk, _, err := crypto.GenerateEd25519Key(crand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok, err := thread.NewToken(sk, createIdentity(t).GetPublic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.GetThread(ctx, info.ID, core.WithThreadToken(tok))
+		if !errors.Is(err, thread.ErrInvalidToken) {
+			t.Fatalf("expected invalid token, got %v", err)
+		}
+		if errors.Is(err, lstore.ErrThreadNotFound) {
+			t.Fatal("expected unauthorized to be distinct from not found")
+		}
+	})
+
+	t.Run("test invalid argument", func(t *testing.T) {
+		_, err := client.GetThread(ctx, thread.ID(""))
+		if !errors.Is(err, api.ErrInvalidArgument) {
+			t.Fatalf("expected invalid argument, got %v", err)
+		}
+	})
+
+	t.Run("test transient", func(t *testing.T) {
+		cctx, cancel := context.WithTimeout(ctx, time.Nanosecond)
+		defer cancel()
+		<-cctx.Done()
+		_, err := client.GetThread(cctx, info.ID)
+		if !errors.Is(err, api.ErrTransient) {
+			t.Fatalf("expected transient failure, got %v", err)
+		}
+	})
+}
+
 func TestClient_Close(t *testing.T) {
 	t.Parallel()
 	_, addr, shutdown := makeServer(t)
@@ -394,6 +502,83 @@ func TestClient_Close(t *testing.T) {
 	})
 }
 
+func TestClient_Gateway(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := util.MustParseAddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", port))
+	n, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+		common.WithNetListenAddr(addr),
+		common.WithNetListenToken("secret"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	ctx := context.Background()
+	bad, err := NewGatewayClient(addr, nil, "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if _, err := bad.GetHostID(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated error, got %v", err)
+	}
+
+	client, err := NewGatewayClient(addr, nil, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	id, err := client.GetHostID(ctx)
+	if err != nil {
+		t.Fatalf("failed to get host ID: %v", err)
+	}
+	if id != n.Host().ID() {
+		t.Fatalf("expected host ID %s, got %s", n.Host().ID(), id)
+	}
+	info, err := client.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatalf("failed to create thread: %v", err)
+	}
+	if _, err := n.GetThread(ctx, info.ID); err != nil {
+		t.Fatalf("thread created over the gateway not found: %v", err)
+	}
+}
+
+func TestClient_GatewayPublicAddr(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+		common.WithNetListenAddr(util.MustParseAddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port))),
+	)
+	if err == nil {
+		n.Close()
+		t.Fatal("expected unauthenticated network API on a public address to be refused")
+	}
+}
+
 func setup(t *testing.T) (ma.Multiaddr, *Client, func()) {
 	host, addr, shutdown := makeServer(t)
 	target, err := util.TCPAddrFromMultiAddr(addr)
@@ -442,7 +627,10 @@ func makeServer(t *testing.T) (ma.Multiaddr, ma.Multiaddr, func()) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	server := grpc.NewServer()
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(api.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(api.StreamServerInterceptor()),
+	)
 	listener, err := net.Listen("tcp", target)
 	if err != nil {
 		t.Fatal(err)
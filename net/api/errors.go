@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/api/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serviceMethodPrefix is the prefix of the full method names of the API service.
+const serviceMethodPrefix = "/threads.net.pb.API/"
+
+var (
+	// ErrInvalidArgument indicates a request which was refused as malformed.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrTransient indicates a failure which may succeed if retried, e.g., a timeout
+	// or an unavailable host.
+	ErrTransient = errors.New("transient failure")
+)
+
+// Error is a failed request with a stable error code. It unwraps to the typed error of its code,
+// e.g., logstore.ErrThreadNotFound, so callers can use errors.Is on errors returned by the client.
+type Error struct {
+	Code   pb.ErrorCode
+	status *status.Status
+}
+
+func (e *Error) Error() string {
+	return e.status.Err().Error()
+}
+
+// Unwrap returns the typed error of the code, or nil if the code is unknown.
+func (e *Error) Unwrap() error {
+	switch e.Code {
+	case pb.ErrorCode_THREAD_NOT_FOUND:
+		return lstore.ErrThreadNotFound
+	case pb.ErrorCode_LOG_NOT_FOUND:
+		return lstore.ErrLogNotFound
+	case pb.ErrorCode_THREAD_EXISTS:
+		return lstore.ErrThreadExists
+	case pb.ErrorCode_LOG_EXISTS:
+		return lstore.ErrLogExists
+	case pb.ErrorCode_UNAUTHORIZED:
+		return thread.ErrInvalidToken
+	case pb.ErrorCode_INVALID_ARGUMENT:
+		return ErrInvalidArgument
+	case pb.ErrorCode_TRANSIENT:
+		return ErrTransient
+	default:
+		return nil
+	}
+}
+
+// GRPCStatus returns the gRPC status of the error, so status.FromError and status.Code keep working.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// ToStatus converts an error returned by the network into a gRPC status error carrying its code.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	var (
+		st   *status.Status
+		code pb.ErrorCode
+	)
+	if s, ok := status.FromError(err); ok {
+		st, code = s, codeFromStatus(s.Code())
+	} else {
+		var c codes.Code
+		c, code = codeFromError(err)
+		st = status.New(c, err.Error())
+	}
+	if code == pb.ErrorCode_UNKNOWN {
+		return st.Err()
+	}
+	if ds, err := st.WithDetails(&pb.ErrorDetail{Code: code}); err == nil {
+		st = ds
+	}
+	return st.Err()
+}
+
+// FromStatus reconstructs the typed error of a gRPC status error returned by the service.
+// Errors without a known code are returned as-is.
+func FromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	code := pb.ErrorCode_UNKNOWN
+	for _, d := range st.Details() {
+		if ed, ok := d.(*pb.ErrorDetail); ok {
+			code = ed.Code
+			break
+		}
+	}
+	if code == pb.ErrorCode_UNKNOWN {
+		// transport failures don't reach the service
+		if code = codeFromStatus(st.Code()); code != pb.ErrorCode_TRANSIENT {
+			return err
+		}
+	}
+	return &Error{Code: code, status: st}
+}
+
+func codeFromError(err error) (codes.Code, pb.ErrorCode) {
+	switch {
+	case errors.Is(err, lstore.ErrThreadNotFound):
+		return codes.NotFound, pb.ErrorCode_THREAD_NOT_FOUND
+	case errors.Is(err, lstore.ErrLogNotFound):
+		return codes.NotFound, pb.ErrorCode_LOG_NOT_FOUND
+	case errors.Is(err, lstore.ErrThreadExists):
+		return codes.AlreadyExists, pb.ErrorCode_THREAD_EXISTS
+	case errors.Is(err, lstore.ErrLogExists):
+		return codes.AlreadyExists, pb.ErrorCode_LOG_EXISTS
+	case errors.Is(err, thread.ErrInvalidToken), errors.Is(err, thread.ErrTokenNotFound):
+		return codes.Unauthenticated, pb.ErrorCode_UNAUTHORIZED
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded, pb.ErrorCode_TRANSIENT
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled, pb.ErrorCode_UNKNOWN
+	default:
+		return codes.Unknown, pb.ErrorCode_UNKNOWN
+	}
+}
+
+func codeFromStatus(c codes.Code) pb.ErrorCode {
+	switch c {
+	case codes.InvalidArgument:
+		return pb.ErrorCode_INVALID_ARGUMENT
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return pb.ErrorCode_UNAUTHORIZED
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return pb.ErrorCode_TRANSIENT
+	default:
+		return pb.ErrorCode_UNKNOWN
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor converting the errors of the API service with ToStatus.
+// Methods of other services are left untouched, so it can be installed on a shared server.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		res, err := handler(ctx, req)
+		if strings.HasPrefix(info.FullMethod, serviceMethodPrefix) {
+			err = ToStatus(err)
+		}
+		return res, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		err := handler(srv, ss)
+		if strings.HasPrefix(info.FullMethod, serviceMethodPrefix) {
+			err = ToStatus(err)
+		}
+		return err
+	}
+}
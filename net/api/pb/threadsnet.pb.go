@@ -24,6 +24,49 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type ErrorCode int32
+
+const (
+	ErrorCode_UNKNOWN          ErrorCode = 0
+	ErrorCode_THREAD_NOT_FOUND ErrorCode = 1
+	ErrorCode_LOG_NOT_FOUND    ErrorCode = 2
+	ErrorCode_THREAD_EXISTS    ErrorCode = 3
+	ErrorCode_LOG_EXISTS       ErrorCode = 4
+	ErrorCode_UNAUTHORIZED     ErrorCode = 5
+	ErrorCode_INVALID_ARGUMENT ErrorCode = 6
+	ErrorCode_TRANSIENT        ErrorCode = 7
+)
+
+var ErrorCode_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "THREAD_NOT_FOUND",
+	2: "LOG_NOT_FOUND",
+	3: "THREAD_EXISTS",
+	4: "LOG_EXISTS",
+	5: "UNAUTHORIZED",
+	6: "INVALID_ARGUMENT",
+	7: "TRANSIENT",
+}
+
+var ErrorCode_value = map[string]int32{
+	"UNKNOWN":          0,
+	"THREAD_NOT_FOUND": 1,
+	"LOG_NOT_FOUND":    2,
+	"THREAD_EXISTS":    3,
+	"LOG_EXISTS":       4,
+	"UNAUTHORIZED":     5,
+	"INVALID_ARGUMENT": 6,
+	"TRANSIENT":        7,
+}
+
+func (x ErrorCode) String() string {
+	return proto.EnumName(ErrorCode_name, int32(x))
+}
+
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0a395cd12426f651, []int{0}
+}
+
 type GetHostIDRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -254,6 +297,186 @@ func (*GetTokenReply) XXX_OneofWrappers() []interface{} {
 	}
 }
 
+type GetTokenChallengeRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTokenChallengeRequest) Reset()         { *m = GetTokenChallengeRequest{} }
+func (m *GetTokenChallengeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTokenChallengeRequest) ProtoMessage()    {}
+func (*GetTokenChallengeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a395cd12426f651, []int{4}
+}
+
+func (m *GetTokenChallengeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTokenChallengeRequest.Unmarshal(m, b)
+}
+func (m *GetTokenChallengeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTokenChallengeRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTokenChallengeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTokenChallengeRequest.Merge(m, src)
+}
+func (m *GetTokenChallengeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTokenChallengeRequest.Size(m)
+}
+func (m *GetTokenChallengeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTokenChallengeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTokenChallengeRequest proto.InternalMessageInfo
+
+func (m *GetTokenChallengeRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type GetTokenChallengeReply struct {
+	Challenge            []byte   `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	Expires              int64    `protobuf:"varint,2,opt,name=expires,proto3" json:"expires,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTokenChallengeReply) Reset()         { *m = GetTokenChallengeReply{} }
+func (m *GetTokenChallengeReply) String() string { return proto.CompactTextString(m) }
+func (*GetTokenChallengeReply) ProtoMessage()    {}
+func (*GetTokenChallengeReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a395cd12426f651, []int{5}
+}
+
+func (m *GetTokenChallengeReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTokenChallengeReply.Unmarshal(m, b)
+}
+func (m *GetTokenChallengeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTokenChallengeReply.Marshal(b, m, deterministic)
+}
+func (m *GetTokenChallengeReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTokenChallengeReply.Merge(m, src)
+}
+func (m *GetTokenChallengeReply) XXX_Size() int {
+	return xxx_messageInfo_GetTokenChallengeReply.Size(m)
+}
+func (m *GetTokenChallengeReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTokenChallengeReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTokenChallengeReply proto.InternalMessageInfo
+
+func (m *GetTokenChallengeReply) GetChallenge() []byte {
+	if m != nil {
+		return m.Challenge
+	}
+	return nil
+}
+
+func (m *GetTokenChallengeReply) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+type GetTokenWithChallengeRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Challenge            []byte   `protobuf:"bytes,2,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	Signature            []byte   `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTokenWithChallengeRequest) Reset()         { *m = GetTokenWithChallengeRequest{} }
+func (m *GetTokenWithChallengeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTokenWithChallengeRequest) ProtoMessage()    {}
+func (*GetTokenWithChallengeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a395cd12426f651, []int{6}
+}
+
+func (m *GetTokenWithChallengeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTokenWithChallengeRequest.Unmarshal(m, b)
+}
+func (m *GetTokenWithChallengeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTokenWithChallengeRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTokenWithChallengeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTokenWithChallengeRequest.Merge(m, src)
+}
+func (m *GetTokenWithChallengeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTokenWithChallengeRequest.Size(m)
+}
+func (m *GetTokenWithChallengeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTokenWithChallengeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTokenWithChallengeRequest proto.InternalMessageInfo
+
+func (m *GetTokenWithChallengeRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *GetTokenWithChallengeRequest) GetChallenge() []byte {
+	if m != nil {
+		return m.Challenge
+	}
+	return nil
+}
+
+func (m *GetTokenWithChallengeRequest) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type GetTokenWithChallengeReply struct {
+	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTokenWithChallengeReply) Reset()         { *m = GetTokenWithChallengeReply{} }
+func (m *GetTokenWithChallengeReply) String() string { return proto.CompactTextString(m) }
+func (*GetTokenWithChallengeReply) ProtoMessage()    {}
+func (*GetTokenWithChallengeReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a395cd12426f651, []int{7}
+}
+
+func (m *GetTokenWithChallengeReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTokenWithChallengeReply.Unmarshal(m, b)
+}
+func (m *GetTokenWithChallengeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTokenWithChallengeReply.Marshal(b, m, deterministic)
+}
+func (m *GetTokenWithChallengeReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTokenWithChallengeReply.Merge(m, src)
+}
+func (m *GetTokenWithChallengeReply) XXX_Size() int {
+	return xxx_messageInfo_GetTokenWithChallengeReply.Size(m)
+}
+func (m *GetTokenWithChallengeReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTokenWithChallengeReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTokenWithChallengeReply proto.InternalMessageInfo
+
+func (m *GetTokenWithChallengeReply) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
 type CreateThreadRequest struct {
 	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
 	Keys                 *Keys    `protobuf:"bytes,2,opt,name=keys,proto3" json:"keys,omitempty"`
@@ -266,7 +489,7 @@ func (m *CreateThreadRequest) Reset()         { *m = CreateThreadRequest{} }
 func (m *CreateThreadRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateThreadRequest) ProtoMessage()    {}
 func (*CreateThreadRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{4}
+	return fileDescriptor_0a395cd12426f651, []int{8}
 }
 
 func (m *CreateThreadRequest) XXX_Unmarshal(b []byte) error {
@@ -313,7 +536,7 @@ func (m *Keys) Reset()         { *m = Keys{} }
 func (m *Keys) String() string { return proto.CompactTextString(m) }
 func (*Keys) ProtoMessage()    {}
 func (*Keys) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{5}
+	return fileDescriptor_0a395cd12426f651, []int{9}
 }
 
 func (m *Keys) XXX_Unmarshal(b []byte) error {
@@ -353,6 +576,7 @@ type ThreadInfoReply struct {
 	ThreadKey            []byte     `protobuf:"bytes,2,opt,name=threadKey,proto3" json:"threadKey,omitempty"`
 	Logs                 []*LogInfo `protobuf:"bytes,3,rep,name=logs,proto3" json:"logs,omitempty"`
 	Addrs                [][]byte   `protobuf:"bytes,4,rep,name=addrs,proto3" json:"addrs,omitempty"`
+	Flags                []string   `protobuf:"bytes,5,rep,name=flags,proto3" json:"flags,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
 	XXX_unrecognized     []byte     `json:"-"`
 	XXX_sizecache        int32      `json:"-"`
@@ -362,7 +586,7 @@ func (m *ThreadInfoReply) Reset()         { *m = ThreadInfoReply{} }
 func (m *ThreadInfoReply) String() string { return proto.CompactTextString(m) }
 func (*ThreadInfoReply) ProtoMessage()    {}
 func (*ThreadInfoReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{6}
+	return fileDescriptor_0a395cd12426f651, []int{10}
 }
 
 func (m *ThreadInfoReply) XXX_Unmarshal(b []byte) error {
@@ -411,6 +635,13 @@ func (m *ThreadInfoReply) GetAddrs() [][]byte {
 	return nil
 }
 
+func (m *ThreadInfoReply) GetFlags() []string {
+	if m != nil {
+		return m.Flags
+	}
+	return nil
+}
+
 type LogInfo struct {
 	ID                   []byte   `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
 	PubKey               []byte   `protobuf:"bytes,2,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
@@ -426,7 +657,7 @@ func (m *LogInfo) Reset()         { *m = LogInfo{} }
 func (m *LogInfo) String() string { return proto.CompactTextString(m) }
 func (*LogInfo) ProtoMessage()    {}
 func (*LogInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{7}
+	return fileDescriptor_0a395cd12426f651, []int{11}
 }
 
 func (m *LogInfo) XXX_Unmarshal(b []byte) error {
@@ -494,7 +725,7 @@ func (m *AddThreadRequest) Reset()         { *m = AddThreadRequest{} }
 func (m *AddThreadRequest) String() string { return proto.CompactTextString(m) }
 func (*AddThreadRequest) ProtoMessage()    {}
 func (*AddThreadRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{8}
+	return fileDescriptor_0a395cd12426f651, []int{12}
 }
 
 func (m *AddThreadRequest) XXX_Unmarshal(b []byte) error {
@@ -540,7 +771,7 @@ func (m *GetThreadRequest) Reset()         { *m = GetThreadRequest{} }
 func (m *GetThreadRequest) String() string { return proto.CompactTextString(m) }
 func (*GetThreadRequest) ProtoMessage()    {}
 func (*GetThreadRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{9}
+	return fileDescriptor_0a395cd12426f651, []int{13}
 }
 
 func (m *GetThreadRequest) XXX_Unmarshal(b []byte) error {
@@ -579,7 +810,7 @@ func (m *PullThreadRequest) Reset()         { *m = PullThreadRequest{} }
 func (m *PullThreadRequest) String() string { return proto.CompactTextString(m) }
 func (*PullThreadRequest) ProtoMessage()    {}
 func (*PullThreadRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{10}
+	return fileDescriptor_0a395cd12426f651, []int{14}
 }
 
 func (m *PullThreadRequest) XXX_Unmarshal(b []byte) error {
@@ -617,7 +848,7 @@ func (m *PullThreadReply) Reset()         { *m = PullThreadReply{} }
 func (m *PullThreadReply) String() string { return proto.CompactTextString(m) }
 func (*PullThreadReply) ProtoMessage()    {}
 func (*PullThreadReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{11}
+	return fileDescriptor_0a395cd12426f651, []int{15}
 }
 
 func (m *PullThreadReply) XXX_Unmarshal(b []byte) error {
@@ -649,7 +880,7 @@ func (m *DeleteThreadRequest) Reset()         { *m = DeleteThreadRequest{} }
 func (m *DeleteThreadRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteThreadRequest) ProtoMessage()    {}
 func (*DeleteThreadRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{12}
+	return fileDescriptor_0a395cd12426f651, []int{16}
 }
 
 func (m *DeleteThreadRequest) XXX_Unmarshal(b []byte) error {
@@ -687,7 +918,7 @@ func (m *DeleteThreadReply) Reset()         { *m = DeleteThreadReply{} }
 func (m *DeleteThreadReply) String() string { return proto.CompactTextString(m) }
 func (*DeleteThreadReply) ProtoMessage()    {}
 func (*DeleteThreadReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{13}
+	return fileDescriptor_0a395cd12426f651, []int{17}
 }
 
 func (m *DeleteThreadReply) XXX_Unmarshal(b []byte) error {
@@ -720,7 +951,7 @@ func (m *AddReplicatorRequest) Reset()         { *m = AddReplicatorRequest{} }
 func (m *AddReplicatorRequest) String() string { return proto.CompactTextString(m) }
 func (*AddReplicatorRequest) ProtoMessage()    {}
 func (*AddReplicatorRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{14}
+	return fileDescriptor_0a395cd12426f651, []int{18}
 }
 
 func (m *AddReplicatorRequest) XXX_Unmarshal(b []byte) error {
@@ -766,7 +997,7 @@ func (m *AddReplicatorReply) Reset()         { *m = AddReplicatorReply{} }
 func (m *AddReplicatorReply) String() string { return proto.CompactTextString(m) }
 func (*AddReplicatorReply) ProtoMessage()    {}
 func (*AddReplicatorReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{15}
+	return fileDescriptor_0a395cd12426f651, []int{19}
 }
 
 func (m *AddReplicatorReply) XXX_Unmarshal(b []byte) error {
@@ -806,7 +1037,7 @@ func (m *CreateRecordRequest) Reset()         { *m = CreateRecordRequest{} }
 func (m *CreateRecordRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateRecordRequest) ProtoMessage()    {}
 func (*CreateRecordRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{16}
+	return fileDescriptor_0a395cd12426f651, []int{20}
 }
 
 func (m *CreateRecordRequest) XXX_Unmarshal(b []byte) error {
@@ -845,6 +1076,9 @@ type NewRecordReply struct {
 	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
 	LogID                []byte   `protobuf:"bytes,2,opt,name=logID,proto3" json:"logID,omitempty"`
 	Record               *Record  `protobuf:"bytes,3,opt,name=record,proto3" json:"record,omitempty"`
+	SourceKind           int32    `protobuf:"varint,4,opt,name=sourceKind,proto3" json:"sourceKind,omitempty"`
+	SourcePeer           []byte   `protobuf:"bytes,5,opt,name=sourcePeer,proto3" json:"sourcePeer,omitempty"`
+	ReceivedAt           int64    `protobuf:"varint,6,opt,name=receivedAt,proto3" json:"receivedAt,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -854,7 +1088,7 @@ func (m *NewRecordReply) Reset()         { *m = NewRecordReply{} }
 func (m *NewRecordReply) String() string { return proto.CompactTextString(m) }
 func (*NewRecordReply) ProtoMessage()    {}
 func (*NewRecordReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{17}
+	return fileDescriptor_0a395cd12426f651, []int{21}
 }
 
 func (m *NewRecordReply) XXX_Unmarshal(b []byte) error {
@@ -896,6 +1130,27 @@ func (m *NewRecordReply) GetRecord() *Record {
 	return nil
 }
 
+func (m *NewRecordReply) GetSourceKind() int32 {
+	if m != nil {
+		return m.SourceKind
+	}
+	return 0
+}
+
+func (m *NewRecordReply) GetSourcePeer() []byte {
+	if m != nil {
+		return m.SourcePeer
+	}
+	return nil
+}
+
+func (m *NewRecordReply) GetReceivedAt() int64 {
+	if m != nil {
+		return m.ReceivedAt
+	}
+	return 0
+}
+
 type AddRecordRequest struct {
 	ThreadID             []byte   `protobuf:"bytes,1,opt,name=threadID,proto3" json:"threadID,omitempty"`
 	LogID                []byte   `protobuf:"bytes,2,opt,name=logID,proto3" json:"logID,omitempty"`
@@ -909,7 +1164,7 @@ func (m *AddRecordRequest) Reset()         { *m = AddRecordRequest{} }
 func (m *AddRecordRequest) String() string { return proto.CompactTextString(m) }
 func (*AddRecordRequest) ProtoMessage()    {}
 func (*AddRecordRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{18}
+	return fileDescriptor_0a395cd12426f651, []int{22}
 }
 
 func (m *AddRecordRequest) XXX_Unmarshal(b []byte) error {
@@ -965,7 +1220,7 @@ func (m *Record) Reset()         { *m = Record{} }
 func (m *Record) String() string { return proto.CompactTextString(m) }
 func (*Record) ProtoMessage()    {}
 func (*Record) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{19}
+	return fileDescriptor_0a395cd12426f651, []int{23}
 }
 
 func (m *Record) XXX_Unmarshal(b []byte) error {
@@ -1024,7 +1279,7 @@ func (m *AddRecordReply) Reset()         { *m = AddRecordReply{} }
 func (m *AddRecordReply) String() string { return proto.CompactTextString(m) }
 func (*AddRecordReply) ProtoMessage()    {}
 func (*AddRecordReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{20}
+	return fileDescriptor_0a395cd12426f651, []int{24}
 }
 
 func (m *AddRecordReply) XXX_Unmarshal(b []byte) error {
@@ -1057,7 +1312,7 @@ func (m *GetRecordRequest) Reset()         { *m = GetRecordRequest{} }
 func (m *GetRecordRequest) String() string { return proto.CompactTextString(m) }
 func (*GetRecordRequest) ProtoMessage()    {}
 func (*GetRecordRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{21}
+	return fileDescriptor_0a395cd12426f651, []int{25}
 }
 
 func (m *GetRecordRequest) XXX_Unmarshal(b []byte) error {
@@ -1103,7 +1358,7 @@ func (m *GetRecordReply) Reset()         { *m = GetRecordReply{} }
 func (m *GetRecordReply) String() string { return proto.CompactTextString(m) }
 func (*GetRecordReply) ProtoMessage()    {}
 func (*GetRecordReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{22}
+	return fileDescriptor_0a395cd12426f651, []int{26}
 }
 
 func (m *GetRecordReply) XXX_Unmarshal(b []byte) error {
@@ -1142,7 +1397,7 @@ func (m *SubscribeRequest) Reset()         { *m = SubscribeRequest{} }
 func (m *SubscribeRequest) String() string { return proto.CompactTextString(m) }
 func (*SubscribeRequest) ProtoMessage()    {}
 func (*SubscribeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0a395cd12426f651, []int{23}
+	return fileDescriptor_0a395cd12426f651, []int{27}
 }
 
 func (m *SubscribeRequest) XXX_Unmarshal(b []byte) error {
@@ -1170,11 +1425,55 @@ func (m *SubscribeRequest) GetThreadIDs() [][]byte {
 	return nil
 }
 
+type ErrorDetail struct {
+	Code                 ErrorCode `protobuf:"varint,1,opt,name=code,proto3,enum=threads.net.pb.ErrorCode" json:"code,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ErrorDetail) Reset()         { *m = ErrorDetail{} }
+func (m *ErrorDetail) String() string { return proto.CompactTextString(m) }
+func (*ErrorDetail) ProtoMessage()    {}
+func (*ErrorDetail) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a395cd12426f651, []int{28}
+}
+
+func (m *ErrorDetail) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ErrorDetail.Unmarshal(m, b)
+}
+func (m *ErrorDetail) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ErrorDetail.Marshal(b, m, deterministic)
+}
+func (m *ErrorDetail) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ErrorDetail.Merge(m, src)
+}
+func (m *ErrorDetail) XXX_Size() int {
+	return xxx_messageInfo_ErrorDetail.Size(m)
+}
+func (m *ErrorDetail) XXX_DiscardUnknown() {
+	xxx_messageInfo_ErrorDetail.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ErrorDetail proto.InternalMessageInfo
+
+func (m *ErrorDetail) GetCode() ErrorCode {
+	if m != nil {
+		return m.Code
+	}
+	return ErrorCode_UNKNOWN
+}
+
 func init() {
+	proto.RegisterEnum("threads.net.pb.ErrorCode", ErrorCode_name, ErrorCode_value)
 	proto.RegisterType((*GetHostIDRequest)(nil), "threads.net.pb.GetHostIDRequest")
 	proto.RegisterType((*GetHostIDReply)(nil), "threads.net.pb.GetHostIDReply")
 	proto.RegisterType((*GetTokenRequest)(nil), "threads.net.pb.GetTokenRequest")
 	proto.RegisterType((*GetTokenReply)(nil), "threads.net.pb.GetTokenReply")
+	proto.RegisterType((*GetTokenChallengeRequest)(nil), "threads.net.pb.GetTokenChallengeRequest")
+	proto.RegisterType((*GetTokenChallengeReply)(nil), "threads.net.pb.GetTokenChallengeReply")
+	proto.RegisterType((*GetTokenWithChallengeRequest)(nil), "threads.net.pb.GetTokenWithChallengeRequest")
+	proto.RegisterType((*GetTokenWithChallengeReply)(nil), "threads.net.pb.GetTokenWithChallengeReply")
 	proto.RegisterType((*CreateThreadRequest)(nil), "threads.net.pb.CreateThreadRequest")
 	proto.RegisterType((*Keys)(nil), "threads.net.pb.Keys")
 	proto.RegisterType((*ThreadInfoReply)(nil), "threads.net.pb.ThreadInfoReply")
@@ -1195,67 +1494,87 @@ func init() {
 	proto.RegisterType((*GetRecordRequest)(nil), "threads.net.pb.GetRecordRequest")
 	proto.RegisterType((*GetRecordReply)(nil), "threads.net.pb.GetRecordReply")
 	proto.RegisterType((*SubscribeRequest)(nil), "threads.net.pb.SubscribeRequest")
+	proto.RegisterType((*ErrorDetail)(nil), "threads.net.pb.ErrorDetail")
 }
 
 func init() { proto.RegisterFile("threadsnet.proto", fileDescriptor_0a395cd12426f651) }
 
 var fileDescriptor_0a395cd12426f651 = []byte{
-	// 872 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xcd, 0x72, 0xda, 0x48,
-	0x10, 0x46, 0xfc, 0x5a, 0x6d, 0x8c, 0xf1, 0x98, 0xf2, 0x52, 0xec, 0x2e, 0x8b, 0x67, 0xf7, 0x40,
-	0xd5, 0xa6, 0x88, 0x43, 0x2e, 0x39, 0xe4, 0x10, 0x1c, 0x1c, 0x43, 0x9c, 0x22, 0x44, 0x26, 0xa9,
-	0x54, 0xf9, 0xe0, 0x12, 0x68, 0x82, 0x29, 0xab, 0x18, 0x22, 0x0d, 0x4e, 0xb8, 0xe6, 0x01, 0xf2,
-	0x10, 0x79, 0xcd, 0x5c, 0x52, 0x33, 0x23, 0x09, 0xfd, 0x19, 0xe4, 0xaa, 0xdc, 0xd4, 0x3d, 0xdd,
-	0x5f, 0xff, 0x4c, 0xf7, 0x37, 0x82, 0x32, 0xbb, 0xb1, 0x88, 0x6e, 0xd8, 0x73, 0xc2, 0x5a, 0x0b,
-	0x8b, 0x32, 0x8a, 0x4a, 0x8e, 0xa6, 0x25, 0x54, 0x63, 0x8c, 0xa0, 0x7c, 0x4e, 0x58, 0x8f, 0xda,
-	0xac, 0xdf, 0xd5, 0xc8, 0xe7, 0x25, 0xb1, 0x19, 0x6e, 0x42, 0xc9, 0xa7, 0x5b, 0x98, 0x2b, 0x74,
-	0x04, 0xf9, 0x05, 0x21, 0x56, 0xbf, 0x5b, 0x55, 0x1a, 0x4a, 0xb3, 0xa8, 0x39, 0x12, 0x1e, 0xc2,
-	0xfe, 0x39, 0x61, 0x23, 0x7a, 0x4b, 0xe6, 0x8e, 0x33, 0x42, 0x90, 0xb9, 0x25, 0x2b, 0x61, 0xa7,
-	0xf6, 0x52, 0x1a, 0x17, 0x50, 0x1d, 0x54, 0x7b, 0x36, 0x9d, 0xeb, 0x6c, 0x69, 0x91, 0x6a, 0x9a,
-	0x23, 0xf4, 0x52, 0xda, 0x5a, 0x75, 0xaa, 0x42, 0x61, 0xa1, 0xaf, 0x4c, 0xaa, 0x1b, 0x58, 0x83,
-	0xbd, 0x35, 0x22, 0x0f, 0x5d, 0x07, 0x75, 0x72, 0xa3, 0x9b, 0x26, 0x99, 0x4f, 0x89, 0x8c, 0xce,
-	0x7d, 0x3d, 0x15, 0x3a, 0x82, 0x1c, 0xe3, 0xd6, 0x02, 0x97, 0x47, 0x94, 0xa2, 0x1f, 0xf3, 0x0a,
-	0x0e, 0x5f, 0x5a, 0x44, 0x67, 0x64, 0x24, 0x6a, 0x77, 0x33, 0xad, 0xc1, 0x8e, 0x6c, 0x86, 0x57,
-	0x96, 0x27, 0xa3, 0x26, 0x64, 0x6f, 0xc9, 0xca, 0x16, 0xa0, 0xbb, 0xed, 0x4a, 0x2b, 0xd8, 0xb5,
-	0xd6, 0x05, 0x59, 0xd9, 0x9a, 0xb0, 0xc0, 0xcf, 0x21, 0xcb, 0x25, 0xf4, 0x17, 0xa8, 0xd2, 0xe8,
-	0xc2, 0xa9, 0xbe, 0xa8, 0xad, 0x15, 0xbc, 0x81, 0x26, 0x9d, 0xf2, 0xa3, 0xb4, 0x6c, 0xa0, 0x94,
-	0xf0, 0x77, 0x05, 0xf6, 0x65, 0x56, 0xfd, 0xf9, 0x27, 0x2a, 0x2b, 0xde, 0x94, 0x57, 0x20, 0x4a,
-	0x3a, 0x1c, 0xe5, 0x7f, 0xc8, 0x9a, 0x74, 0x6a, 0x57, 0x33, 0x8d, 0x4c, 0x73, 0xb7, 0xfd, 0x47,
-	0x38, 0xeb, 0x37, 0x74, 0x2a, 0xa2, 0x08, 0x23, 0x54, 0x81, 0x9c, 0x6e, 0x18, 0x96, 0x5d, 0xcd,
-	0x36, 0x32, 0xcd, 0xa2, 0x26, 0x05, 0xbc, 0x84, 0x82, 0x63, 0x86, 0x4a, 0x90, 0xf6, 0x32, 0x48,
-	0xf7, 0xbb, 0x62, 0x08, 0x96, 0x63, 0x5f, 0x0d, 0x52, 0x42, 0x55, 0x28, 0x2c, 0xac, 0xd9, 0x1d,
-	0x3f, 0xc8, 0x88, 0x03, 0x57, 0x8c, 0x0f, 0x81, 0x10, 0x64, 0x6f, 0x88, 0x6e, 0x54, 0x73, 0xc2,
-	0x58, 0x7c, 0xe3, 0x21, 0x94, 0x3b, 0x86, 0x11, 0xbc, 0x1f, 0x04, 0x59, 0xee, 0xe0, 0x64, 0x20,
-	0xbe, 0x1f, 0x70, 0x2f, 0x2d, 0x31, 0xd8, 0x89, 0x6f, 0x1c, 0x3f, 0x86, 0x83, 0xe1, 0xd2, 0x34,
-	0x93, 0x3b, 0x1c, 0xc0, 0xbe, 0xdf, 0x61, 0x61, 0xae, 0xf0, 0x13, 0x38, 0xec, 0x12, 0x93, 0x3c,
-	0x60, 0xd0, 0xf0, 0x21, 0x1c, 0x04, 0x5d, 0x38, 0xce, 0x2b, 0xa8, 0x74, 0x0c, 0xf1, 0x3d, 0x9b,
-	0xe8, 0x8c, 0x5a, 0x49, 0x26, 0xd6, 0xed, 0x56, 0x7a, 0xdd, 0x2d, 0xfc, 0x08, 0x50, 0x08, 0x67,
-	0xd3, 0x32, 0x9f, 0xb9, 0x6b, 0xa2, 0x91, 0x09, 0xb5, 0x8c, 0x84, 0x41, 0xc7, 0xd4, 0x70, 0x07,
-	0x42, 0x7c, 0x63, 0x0b, 0x4a, 0x03, 0xf2, 0xc5, 0xc5, 0xd8, 0x36, 0xd0, 0x15, 0xc8, 0x99, 0x74,
-	0xda, 0xef, 0x3a, 0x10, 0x52, 0x40, 0x2d, 0xc8, 0x5b, 0x02, 0x40, 0x4c, 0xd4, 0x6e, 0xfb, 0x28,
-	0x7c, 0xd1, 0x0e, 0xbc, 0x63, 0x85, 0x99, 0x18, 0x9f, 0xe4, 0x79, 0xff, 0x9e, 0xa8, 0xdf, 0x14,
-	0xc8, 0x4b, 0x15, 0xaa, 0x03, 0x48, 0xe5, 0x80, 0x1a, 0x0e, 0x4d, 0x69, 0x3e, 0x0d, 0xdf, 0x5b,
-	0x72, 0x47, 0xe6, 0x4c, 0x1c, 0x3b, 0x7b, 0xeb, 0x29, 0xb8, 0x37, 0xdf, 0x02, 0x62, 0x89, 0x63,
-	0xb9, 0x44, 0x3e, 0x0d, 0x2f, 0x85, 0xb7, 0x56, 0x9c, 0x66, 0x65, 0x29, 0xae, 0x8c, 0xcb, 0x50,
-	0xf2, 0x95, 0xce, 0xa7, 0xe7, 0xb5, 0x98, 0xfc, 0xe4, 0xcd, 0xa8, 0xc1, 0x8e, 0xcc, 0xd4, 0xeb,
-	0x87, 0x27, 0xe3, 0x17, 0xe2, 0x29, 0xf0, 0x5f, 0xe6, 0xba, 0x49, 0x4a, 0xa2, 0x26, 0x9d, 0x40,
-	0xf9, 0x72, 0x39, 0xb6, 0x27, 0xd6, 0x6c, 0x4c, 0xdc, 0x6c, 0x3c, 0x16, 0xeb, 0x77, 0xed, 0xaa,
-	0x22, 0xb8, 0x61, 0xad, 0x68, 0xff, 0x2c, 0x40, 0xa6, 0x33, 0xec, 0xa3, 0xb7, 0xa0, 0x7a, 0xcf,
-	0x10, 0x6a, 0x84, 0xc3, 0x84, 0x5f, 0xad, 0x5a, 0x7d, 0x83, 0x05, 0x6f, 0x4b, 0x0a, 0x0d, 0x61,
-	0xc7, 0x7d, 0x5b, 0xd0, 0x3f, 0x31, 0xd6, 0xfe, 0x77, 0xac, 0xf6, 0xf7, 0xfd, 0x06, 0x02, 0xad,
-	0xa9, 0x9c, 0x28, 0xe8, 0x03, 0x14, 0xfd, 0x2f, 0x0b, 0xfa, 0x37, 0xec, 0x14, 0xf3, 0xee, 0xd4,
-	0x22, 0xa1, 0x43, 0x0f, 0x80, 0xc8, 0x54, 0xf5, 0xe8, 0x30, 0x5a, 0x7a, 0x98, 0x29, 0x13, 0x22,
-	0x7a, 0x74, 0x18, 0xdb, 0xcc, 0x07, 0x23, 0x6a, 0x00, 0x6b, 0xfe, 0x43, 0xc7, 0x61, 0x87, 0x08,
-	0x99, 0x46, 0x31, 0xc3, 0xf4, 0x99, 0x42, 0x1f, 0xa1, 0xe8, 0x67, 0xc3, 0x68, 0x3f, 0x63, 0xe8,
-	0xb5, 0x76, 0xbc, 0xd9, 0x48, 0x22, 0x5f, 0xc1, 0x5e, 0x80, 0x0a, 0xd1, 0x7f, 0x31, 0x5d, 0x8d,
-	0x30, 0x6e, 0x0d, 0x6f, 0xb1, 0x92, 0xe0, 0xef, 0xdd, 0x31, 0x70, 0xd8, 0xe0, 0x9e, 0x31, 0x08,
-	0xac, 0x64, 0x74, 0x5e, 0x83, 0xac, 0x89, 0x53, 0x7c, 0x01, 0xbc, 0xd5, 0x8e, 0x9d, 0x82, 0x2d,
-	0x80, 0x21, 0x5e, 0x48, 0x39, 0x1b, 0x75, 0x1f, 0x60, 0x98, 0x34, 0x62, 0x37, 0x2a, 0x08, 0xf8,
-	0x0e, 0x54, 0x6f, 0xb9, 0xa3, 0x80, 0xe1, 0xbd, 0xdf, 0x5e, 0xf2, 0x89, 0x72, 0xfa, 0x0c, 0xfe,
-	0x9c, 0xd1, 0x16, 0x23, 0x5f, 0xd9, 0xcc, 0x24, 0xae, 0xfd, 0xf5, 0x9c, 0xb0, 0xeb, 0xa9, 0xb5,
-	0x98, 0x9c, 0x82, 0xbc, 0x56, 0x7b, 0x40, 0xd8, 0x50, 0xf9, 0x91, 0x86, 0x51, 0x4f, 0x3b, 0xeb,
-	0x74, 0x2f, 0x07, 0x67, 0xa3, 0x71, 0x5e, 0xfc, 0xe1, 0x3e, 0xfd, 0x15, 0x00, 0x00, 0xff, 0xff,
-	0xc0, 0xd3, 0x78, 0x20, 0xf5, 0x0a, 0x00, 0x00,
+	// 1174 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xcd, 0x72, 0xe3, 0x44,
+	0x10, 0xb6, 0xfc, 0x97, 0xb8, 0xe3, 0x38, 0xca, 0x24, 0x04, 0x21, 0x42, 0xd6, 0x3b, 0x50, 0x94,
+	0x6b, 0x09, 0x26, 0x98, 0x0b, 0x87, 0x3d, 0xe0, 0x44, 0xde, 0x44, 0x24, 0xc8, 0x46, 0xb1, 0x77,
+	0xb7, 0xd8, 0x43, 0x4a, 0xb6, 0x66, 0x1d, 0x55, 0x54, 0x96, 0x91, 0xe4, 0xb0, 0xbe, 0xf2, 0x1a,
+	0xdc, 0x28, 0x9e, 0x88, 0x1b, 0x6f, 0x43, 0xcd, 0x8c, 0x24, 0xcb, 0xf2, 0x9f, 0x52, 0xb5, 0x37,
+	0x77, 0xcf, 0xd7, 0x5f, 0xff, 0x4c, 0xab, 0x7b, 0x0c, 0xa2, 0x7f, 0xef, 0x12, 0xc3, 0xf4, 0x46,
+	0xc4, 0xaf, 0x8f, 0x5d, 0xc7, 0x77, 0x50, 0x25, 0xd0, 0xd4, 0x99, 0xaa, 0x8f, 0x11, 0x88, 0x97,
+	0xc4, 0xbf, 0x72, 0x3c, 0x5f, 0x55, 0x74, 0xf2, 0xfb, 0x84, 0x78, 0x3e, 0xae, 0x41, 0x25, 0xa6,
+	0x1b, 0xdb, 0x53, 0x74, 0x04, 0xc5, 0x31, 0x21, 0xae, 0xaa, 0x48, 0x42, 0x55, 0xa8, 0x95, 0xf5,
+	0x40, 0xc2, 0x1d, 0xd8, 0xbb, 0x24, 0x7e, 0xd7, 0x79, 0x20, 0xa3, 0xc0, 0x18, 0x21, 0xc8, 0x3d,
+	0x90, 0x29, 0xc3, 0x95, 0xae, 0x32, 0x3a, 0x15, 0xd0, 0x09, 0x94, 0x3c, 0x6b, 0x38, 0x32, 0xfc,
+	0x89, 0x4b, 0xa4, 0x2c, 0x65, 0xb8, 0xca, 0xe8, 0x33, 0xd5, 0x79, 0x09, 0xb6, 0xc6, 0xc6, 0xd4,
+	0x76, 0x0c, 0x13, 0xeb, 0xb0, 0x3b, 0x63, 0xa4, 0xae, 0x4f, 0xa0, 0x34, 0xb8, 0x37, 0x6c, 0x9b,
+	0x8c, 0x86, 0x44, 0x12, 0x42, 0xdb, 0x48, 0x85, 0x8e, 0xa0, 0xe0, 0x53, 0xb4, 0x94, 0x0d, 0x3c,
+	0x72, 0x31, 0xce, 0x79, 0x0a, 0x52, 0xc8, 0x79, 0x11, 0xda, 0x85, 0xe1, 0x8a, 0xb1, 0x70, 0x59,
+	0xb0, 0xb8, 0x03, 0x47, 0x4b, 0xd0, 0x34, 0x94, 0xe3, 0x85, 0x50, 0xe2, 0x81, 0x48, 0xb0, 0x45,
+	0x3e, 0x8c, 0x2d, 0x97, 0x78, 0x2c, 0x94, 0x9c, 0x1e, 0x8a, 0xd8, 0x86, 0xe3, 0x90, 0xf1, 0x8d,
+	0xe5, 0xdf, 0x6f, 0x8e, 0x61, 0xde, 0x53, 0x36, 0xe9, 0xe9, 0x38, 0x5e, 0xce, 0x1c, 0x3f, 0x8d,
+	0x14, 0xb8, 0x01, 0xf2, 0x0a, 0x6f, 0x34, 0x87, 0xc3, 0xb0, 0x5c, 0xdc, 0x1b, 0x17, 0xf0, 0x3b,
+	0x38, 0xb8, 0x70, 0x89, 0xe1, 0x93, 0x2e, 0xeb, 0x8e, 0x30, 0x30, 0x19, 0xb6, 0x79, 0xbb, 0x44,
+	0x17, 0x1f, 0xc9, 0xa8, 0x06, 0xf9, 0x07, 0x32, 0xe5, 0xb9, 0xee, 0x34, 0x0e, 0xeb, 0xf3, 0x7d,
+	0x55, 0xbf, 0x26, 0x53, 0x4f, 0x67, 0x08, 0xfc, 0x12, 0xf2, 0x54, 0xa2, 0x61, 0x73, 0xd0, 0x75,
+	0x90, 0x6c, 0x59, 0x9f, 0x29, 0x68, 0x8b, 0xd9, 0xce, 0x90, 0x1e, 0xf1, 0x7c, 0x03, 0x09, 0xff,
+	0x23, 0xc0, 0x1e, 0x8f, 0x4a, 0x1d, 0xbd, 0x77, 0x78, 0x12, 0xeb, 0xe2, 0x9a, 0xf3, 0x92, 0x4d,
+	0x7a, 0xf9, 0x06, 0xf2, 0xb6, 0x33, 0xf4, 0xa4, 0x5c, 0x35, 0x57, 0xdb, 0x69, 0x7c, 0x9a, 0x8c,
+	0xfa, 0xc6, 0x19, 0x32, 0x2f, 0x0c, 0x44, 0x6b, 0x65, 0x98, 0xa6, 0xeb, 0x49, 0xf9, 0x6a, 0xae,
+	0x56, 0xd6, 0xb9, 0x40, 0xb5, 0xef, 0x6d, 0x63, 0xe8, 0x49, 0x85, 0x6a, 0x8e, 0x56, 0x90, 0x09,
+	0x78, 0x02, 0x5b, 0x81, 0x31, 0xaa, 0x40, 0x36, 0x8a, 0x2b, 0xab, 0x2a, 0xec, 0xe3, 0x99, 0xf4,
+	0x63, 0x99, 0x71, 0x89, 0x36, 0xcc, 0xd8, 0xb5, 0x1e, 0xe9, 0x01, 0xbf, 0xc4, 0x50, 0x5c, 0xe1,
+	0x18, 0x41, 0xfe, 0x9e, 0x18, 0xa6, 0x54, 0x60, 0x60, 0xf6, 0x1b, 0x77, 0x40, 0x6c, 0x9a, 0xe6,
+	0xfc, 0xad, 0x21, 0xc8, 0x53, 0x83, 0x20, 0x02, 0xf6, 0xfb, 0x09, 0xb7, 0x55, 0x67, 0x03, 0x21,
+	0x75, 0x1f, 0xe0, 0xef, 0x60, 0xbf, 0x33, 0xb1, 0xed, 0xf4, 0x06, 0xfb, 0xb0, 0x17, 0x37, 0x18,
+	0xdb, 0x53, 0xfc, 0x3d, 0x1c, 0x28, 0xc4, 0x26, 0x4f, 0x68, 0x3f, 0x7c, 0x00, 0xfb, 0xf3, 0x26,
+	0x94, 0xe7, 0x15, 0x1c, 0x36, 0x4d, 0xf6, 0xdb, 0x1a, 0x18, 0xbe, 0xe3, 0xa6, 0xe9, 0xe3, 0xb0,
+	0x5a, 0xd9, 0x59, 0xb5, 0xf0, 0x29, 0xa0, 0x04, 0xcf, 0xba, 0x21, 0xd8, 0x0a, 0x3f, 0x1e, 0x9d,
+	0x0c, 0x1c, 0xd7, 0x4c, 0xe9, 0xb4, 0xef, 0x98, 0x61, 0x43, 0xb0, 0xdf, 0xf8, 0x5f, 0x01, 0x2a,
+	0x1a, 0xf9, 0x23, 0x24, 0xd9, 0xd4, 0xe7, 0x87, 0x50, 0xb0, 0x9d, 0xa1, 0xaa, 0x04, 0x1c, 0x5c,
+	0x40, 0x75, 0x28, 0xba, 0x8c, 0x80, 0xb5, 0xd4, 0x4e, 0xe3, 0x28, 0x79, 0xd3, 0x01, 0x7d, 0x80,
+	0x42, 0x27, 0x00, 0x9e, 0x33, 0x71, 0x07, 0xe4, 0xda, 0x1a, 0x99, 0x52, 0xbe, 0x2a, 0xd4, 0x0a,
+	0x7a, 0x4c, 0x33, 0x3b, 0xef, 0x10, 0xe2, 0x06, 0x9d, 0x17, 0xd3, 0xd0, 0x73, 0x97, 0x0c, 0x88,
+	0xf5, 0x48, 0xcc, 0xa6, 0x2f, 0x15, 0xd9, 0xdc, 0x8b, 0x69, 0xb0, 0xcf, 0xfa, 0x33, 0x7d, 0x61,
+	0x3e, 0x4a, 0x56, 0xf8, 0x4f, 0x01, 0x8a, 0x7a, 0x94, 0x20, 0x57, 0x6a, 0x8e, 0x19, 0x0e, 0xed,
+	0x98, 0x86, 0x8e, 0x0b, 0xf2, 0x48, 0x46, 0x3e, 0x3b, 0x0e, 0xc6, 0x45, 0xa4, 0xa0, 0xd6, 0xf4,
+	0x33, 0x23, 0x2e, 0x3b, 0xe6, 0x5f, 0x69, 0x4c, 0x43, 0x53, 0xa1, 0x77, 0xc7, 0x4e, 0xf3, 0x3c,
+	0x95, 0x50, 0xc6, 0x22, 0x54, 0x62, 0xa9, 0xd3, 0xf6, 0xfc, 0x99, 0x7d, 0x5a, 0xe9, 0x8b, 0x21,
+	0xc3, 0x36, 0x8f, 0x34, 0xaa, 0x47, 0x24, 0xe3, 0x9f, 0xa0, 0x12, 0xe3, 0xa2, 0xcd, 0x32, 0x2b,
+	0x92, 0x90, 0xaa, 0x48, 0x67, 0x20, 0xde, 0x4e, 0xfa, 0xde, 0xc0, 0xb5, 0xfa, 0xd1, 0x26, 0x8a,
+	0x86, 0xa7, 0xaa, 0x78, 0x92, 0xc0, 0x86, 0xcf, 0x4c, 0x81, 0x5f, 0xc2, 0x4e, 0xcb, 0x75, 0x1d,
+	0x57, 0x21, 0xbe, 0x61, 0xd9, 0xe8, 0x5b, 0xc8, 0x0f, 0xc2, 0xa2, 0x56, 0x1a, 0x9f, 0x25, 0xdd,
+	0x31, 0xe8, 0x85, 0x63, 0x12, 0x9d, 0xc1, 0x5e, 0xfc, 0x25, 0x40, 0x29, 0xd2, 0xa1, 0x1d, 0xd8,
+	0xea, 0x69, 0xd7, 0x5a, 0xfb, 0x8d, 0x26, 0x66, 0xd0, 0x21, 0x88, 0xdd, 0x2b, 0xbd, 0xd5, 0x54,
+	0xee, 0xb4, 0x76, 0xf7, 0xee, 0x55, 0xbb, 0xa7, 0x29, 0xa2, 0x80, 0xf6, 0x61, 0xf7, 0xa6, 0x7d,
+	0x19, 0x53, 0x65, 0xa9, 0x2a, 0x00, 0xb6, 0xde, 0xaa, 0xb7, 0xdd, 0x5b, 0x31, 0x87, 0x2a, 0x00,
+	0x14, 0x15, 0xc8, 0x79, 0x24, 0x42, 0xb9, 0xa7, 0x35, 0x7b, 0xdd, 0xab, 0xb6, 0xae, 0xfe, 0xd6,
+	0x52, 0xc4, 0x02, 0x65, 0x57, 0xb5, 0xd7, 0xcd, 0x1b, 0x55, 0xb9, 0x6b, 0xea, 0x97, 0xbd, 0x5f,
+	0x5a, 0x5a, 0x57, 0x2c, 0xa2, 0x5d, 0x28, 0x75, 0xf5, 0xa6, 0x76, 0xab, 0x52, 0x71, 0xab, 0xf1,
+	0x5f, 0x09, 0x72, 0xcd, 0x8e, 0x8a, 0xda, 0x50, 0x8a, 0xde, 0x3e, 0xa8, 0x9a, 0xcc, 0x29, 0xf9,
+	0x54, 0x92, 0x4f, 0xd6, 0x20, 0xe8, 0x95, 0x67, 0x50, 0x07, 0xb6, 0xc3, 0x75, 0x8c, 0x9e, 0x2d,
+	0x41, 0xc7, 0x1f, 0x4f, 0xf2, 0x17, 0xab, 0x01, 0x8c, 0xad, 0x26, 0x9c, 0x09, 0x68, 0x08, 0xfb,
+	0x0b, 0x0f, 0x14, 0x54, 0x5b, 0x65, 0x99, 0x7c, 0x6d, 0xc8, 0x5f, 0xa7, 0x40, 0xf2, 0xd0, 0x3d,
+	0xf8, 0x64, 0xe9, 0x4b, 0x02, 0x9d, 0xae, 0xa2, 0x58, 0xf6, 0xbc, 0x91, 0x5f, 0xa4, 0x44, 0x73,
+	0xa7, 0xaf, 0xa1, 0x1c, 0x7f, 0x8a, 0xa0, 0x2f, 0x93, 0xd6, 0x4b, 0x1e, 0x2a, 0xf2, 0x42, 0x61,
+	0x13, 0x2f, 0x06, 0x76, 0x0f, 0xa5, 0x68, 0x53, 0x2e, 0x5e, 0x6c, 0x72, 0x89, 0xa6, 0x64, 0x8c,
+	0x36, 0xe5, 0xd2, 0x56, 0x79, 0x32, 0xa3, 0x0e, 0x30, 0x5b, 0x8d, 0xe8, 0x79, 0xd2, 0x60, 0x61,
+	0xcf, 0xca, 0xcf, 0xd6, 0x41, 0x38, 0xe7, 0x5b, 0x28, 0xc7, 0x17, 0xe5, 0x62, 0x3d, 0x97, 0x6c,
+	0x5e, 0xf9, 0xf9, 0x7a, 0x10, 0x67, 0x7e, 0x07, 0xbb, 0x73, 0x5b, 0x12, 0x7d, 0xb5, 0xa4, 0xaa,
+	0x0b, 0xcb, 0x58, 0xc6, 0x1b, 0x50, 0x9c, 0xbc, 0x17, 0xb6, 0x41, 0x30, 0xc7, 0x57, 0xb4, 0xc1,
+	0xdc, 0x30, 0x5d, 0xfc, 0x1a, 0xe7, 0xf7, 0x29, 0xce, 0xd0, 0xcf, 0x3b, 0x1a, 0xca, 0x4b, 0xbb,
+	0x60, 0x03, 0x61, 0x62, 0xa2, 0x67, 0x82, 0x79, 0xb1, 0x8a, 0x30, 0x39, 0xee, 0xe5, 0x93, 0x35,
+	0x08, 0x4e, 0xf8, 0x2b, 0x94, 0xa2, 0xb1, 0xbc, 0x48, 0x98, 0x9c, 0xd8, 0x9b, 0x53, 0x3e, 0x13,
+	0xce, 0x7f, 0x84, 0xcf, 0x2d, 0xa7, 0xee, 0x93, 0x0f, 0xbe, 0x65, 0x93, 0x10, 0x7f, 0x37, 0x22,
+	0xfe, 0xdd, 0xd0, 0x1d, 0x0f, 0xce, 0x81, 0x5f, 0xab, 0xa7, 0x11, 0xbf, 0x23, 0xfc, 0x9d, 0x05,
+	0x3e, 0x61, 0x6f, 0xb5, 0x56, 0xb7, 0x5f, 0x64, 0x7f, 0x1a, 0x7f, 0xf8, 0x7f, 0x00, 0x4b, 0xff,
+	0x7d, 0x36, 0x48, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1272,6 +1591,8 @@ const _ = grpc.SupportPackageIsVersion4
 type APIClient interface {
 	GetHostID(ctx context.Context, in *GetHostIDRequest, opts ...grpc.CallOption) (*GetHostIDReply, error)
 	GetToken(ctx context.Context, opts ...grpc.CallOption) (API_GetTokenClient, error)
+	GetTokenChallenge(ctx context.Context, in *GetTokenChallengeRequest, opts ...grpc.CallOption) (*GetTokenChallengeReply, error)
+	GetTokenWithChallenge(ctx context.Context, in *GetTokenWithChallengeRequest, opts ...grpc.CallOption) (*GetTokenWithChallengeReply, error)
 	CreateThread(ctx context.Context, in *CreateThreadRequest, opts ...grpc.CallOption) (*ThreadInfoReply, error)
 	AddThread(ctx context.Context, in *AddThreadRequest, opts ...grpc.CallOption) (*ThreadInfoReply, error)
 	GetThread(ctx context.Context, in *GetThreadRequest, opts ...grpc.CallOption) (*ThreadInfoReply, error)
@@ -1332,6 +1653,24 @@ func (x *aPIGetTokenClient) Recv() (*GetTokenReply, error) {
 	return m, nil
 }
 
+func (c *aPIClient) GetTokenChallenge(ctx context.Context, in *GetTokenChallengeRequest, opts ...grpc.CallOption) (*GetTokenChallengeReply, error) {
+	out := new(GetTokenChallengeReply)
+	err := c.cc.Invoke(ctx, "/threads.net.pb.API/GetTokenChallenge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetTokenWithChallenge(ctx context.Context, in *GetTokenWithChallengeRequest, opts ...grpc.CallOption) (*GetTokenWithChallengeReply, error) {
+	out := new(GetTokenWithChallengeReply)
+	err := c.cc.Invoke(ctx, "/threads.net.pb.API/GetTokenWithChallenge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) CreateThread(ctx context.Context, in *CreateThreadRequest, opts ...grpc.CallOption) (*ThreadInfoReply, error) {
 	out := new(ThreadInfoReply)
 	err := c.cc.Invoke(ctx, "/threads.net.pb.API/CreateThread", in, out, opts...)
@@ -1449,6 +1788,8 @@ func (x *aPISubscribeClient) Recv() (*NewRecordReply, error) {
 type APIServer interface {
 	GetHostID(context.Context, *GetHostIDRequest) (*GetHostIDReply, error)
 	GetToken(API_GetTokenServer) error
+	GetTokenChallenge(context.Context, *GetTokenChallengeRequest) (*GetTokenChallengeReply, error)
+	GetTokenWithChallenge(context.Context, *GetTokenWithChallengeRequest) (*GetTokenWithChallengeReply, error)
 	CreateThread(context.Context, *CreateThreadRequest) (*ThreadInfoReply, error)
 	AddThread(context.Context, *AddThreadRequest) (*ThreadInfoReply, error)
 	GetThread(context.Context, *GetThreadRequest) (*ThreadInfoReply, error)
@@ -1471,6 +1812,12 @@ func (*UnimplementedAPIServer) GetHostID(ctx context.Context, req *GetHostIDRequ
 func (*UnimplementedAPIServer) GetToken(srv API_GetTokenServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetToken not implemented")
 }
+func (*UnimplementedAPIServer) GetTokenChallenge(ctx context.Context, req *GetTokenChallengeRequest) (*GetTokenChallengeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTokenChallenge not implemented")
+}
+func (*UnimplementedAPIServer) GetTokenWithChallenge(ctx context.Context, req *GetTokenWithChallengeRequest) (*GetTokenWithChallengeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTokenWithChallenge not implemented")
+}
 func (*UnimplementedAPIServer) CreateThread(ctx context.Context, req *CreateThreadRequest) (*ThreadInfoReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateThread not implemented")
 }
@@ -1550,6 +1897,42 @@ func (x *aPIGetTokenServer) Recv() (*GetTokenRequest, error) {
 	return m, nil
 }
 
+func _API_GetTokenChallenge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenChallengeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetTokenChallenge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.net.pb.API/GetTokenChallenge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetTokenChallenge(ctx, req.(*GetTokenChallengeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetTokenWithChallenge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenWithChallengeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetTokenWithChallenge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.net.pb.API/GetTokenWithChallenge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetTokenWithChallenge(ctx, req.(*GetTokenWithChallengeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_CreateThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateThreadRequest)
 	if err := dec(in); err != nil {
@@ -1741,6 +2124,14 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetHostID",
 			Handler:    _API_GetHostID_Handler,
 		},
+		{
+			MethodName: "GetTokenChallenge",
+			Handler:    _API_GetTokenChallenge_Handler,
+		},
+		{
+			MethodName: "GetTokenWithChallenge",
+			Handler:    _API_GetTokenWithChallenge_Handler,
+		},
 		{
 			MethodName: "CreateThread",
 			Handler:    _API_CreateThread_Handler,
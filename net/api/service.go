@@ -155,6 +155,39 @@ func (s *Service) GetToken(server pb.API_GetTokenServer) error {
 	})
 }
 
+func (s *Service) GetTokenChallenge(ctx context.Context, req *pb.GetTokenChallengeRequest) (*pb.GetTokenChallengeReply, error) {
+	log.Debugf("received get token challenge request")
+
+	key := &thread.Libp2pPubKey{}
+	if err := key.UnmarshalString(req.Key); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	challenge, expires, err := s.net.GetTokenChallenge(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetTokenChallengeReply{
+		Challenge: challenge,
+		Expires:   expires.Unix(),
+	}, nil
+}
+
+func (s *Service) GetTokenWithChallenge(ctx context.Context, req *pb.GetTokenWithChallengeRequest) (*pb.GetTokenWithChallengeReply, error) {
+	log.Debugf("received get token with challenge request")
+
+	key := &thread.Libp2pPubKey{}
+	if err := key.UnmarshalString(req.Key); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	tok, err := s.net.GetTokenWithChallenge(ctx, key, req.Challenge, req.Signature)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &pb.GetTokenWithChallengeReply{
+		Token: string(tok),
+	}, nil
+}
+
 func (s *Service) CreateThread(ctx context.Context, req *pb.CreateThreadRequest) (*pb.ThreadInfoReply, error) {
 	log.Debugf("received create thread request")
 
@@ -305,11 +338,7 @@ func (s *Service) CreateRecord(ctx context.Context, req *pb.CreateRecordRequest)
 	if err != nil {
 		return nil, err
 	}
-	return &pb.NewRecordReply{
-		ThreadID: rec.ThreadID().Bytes(),
-		LogID:    marshalPeerID(rec.LogID()),
-		Record:   util.RecFromServiceRec(prec),
-	}, nil
+	return newRecordReply(rec, util.RecFromServiceRec(prec)), nil
 }
 
 func (s *Service) AddRecord(ctx context.Context, req *pb.AddRecordRequest) (*pb.AddRecordReply, error) {
@@ -396,17 +425,29 @@ func (s *Service) Subscribe(req *pb.SubscribeRequest, server pb.API_SubscribeSer
 		if err != nil {
 			return err
 		}
-		if err := server.Send(&pb.NewRecordReply{
-			ThreadID: rec.ThreadID().Bytes(),
-			LogID:    marshalPeerID(rec.LogID()),
-			Record:   util.RecFromServiceRec(prec),
-		}); err != nil {
+		if err := server.Send(newRecordReply(rec, util.RecFromServiceRec(prec))); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func newRecordReply(rec net.ThreadRecord, prec *pb.Record) *pb.NewRecordReply {
+	reply := &pb.NewRecordReply{
+		ThreadID:   rec.ThreadID().Bytes(),
+		LogID:      marshalPeerID(rec.LogID()),
+		Record:     prec,
+		SourceKind: int32(rec.Source().Kind),
+	}
+	if src := rec.Source(); src.Peer != "" {
+		reply.SourcePeer = marshalPeerID(src.Peer)
+	}
+	if at := rec.Source().ReceivedAt; !at.IsZero() {
+		reply.ReceivedAt = at.UnixNano()
+	}
+	return reply
+}
+
 func marshalPeerID(id peer.ID) []byte {
 	b, _ := id.Marshal() // This will never return an error
 	return b
@@ -472,5 +513,6 @@ func threadInfoToProto(info thread.Info) (*pb.ThreadInfoReply, error) {
 		ThreadKey: info.Key.Bytes(),
 		Logs:      logs,
 		Addrs:     addrs,
+		Flags:     info.Flags,
 	}, nil
 }
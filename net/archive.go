@@ -0,0 +1,136 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+func (n *net) ExportThread(ctx context.Context, id thread.ID, w io.Writer, opts ...core.ExportOption) error {
+	args := &core.ExportOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return fmt.Errorf("a service-key is required to export a thread")
+	}
+
+	manifest := util.ArchiveManifest{Thread: id.Bytes()}
+	if args.Keys {
+		manifest.Key = info.Key.Bytes()
+	}
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return fmt.Errorf("log %s: %w", lg.ID, err)
+		}
+		al, err := util.NewArchiveLog(lg, boundary, args.Keys)
+		if err != nil {
+			return fmt.Errorf("log %s: %w", lg.ID, err)
+		}
+		manifest.Logs = append(manifest.Logs, al)
+	}
+	written, err := util.WriteArchive(ctx, w, manifest, n, sk)
+	if err != nil {
+		return err
+	}
+	log.Debugf("exported %d records (thread=%s)", written, id)
+	return nil
+}
+
+// ImportThread streams the archive blocks. Record nodes are kept until their chains are
+// complete, while events, headers and bodies are added to the blockstore as they're read.
+// Archives must list the manifest first, and records before the ones they link to, like
+// ExportThread writes them.
+func (n *net) ImportThread(ctx context.Context, r io.Reader, opts ...core.NewThreadOption) (info thread.Info, err error) {
+	args := &core.NewThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+
+	cr, manifest, err := util.ReadArchiveManifest(r)
+	if err != nil {
+		return
+	}
+	id, err := thread.Cast(manifest.Thread)
+	if err != nil {
+		return
+	}
+	if _, err = n.Validate(id, args.Token, false); err != nil {
+		return
+	}
+	key := args.ThreadKey
+	if !key.Defined() && manifest.Key != nil {
+		if key, err = thread.KeyFromBytes(manifest.Key); err != nil {
+			return
+		}
+	}
+	if !key.Defined() {
+		return info, fmt.Errorf("a thread key is required to import a thread archive without keys")
+	}
+	logs := make([]peerLog, len(manifest.Logs))
+	for i, al := range manifest.Logs {
+		if logs[i].LogInfo, err = al.LogInfo(); err != nil {
+			return
+		}
+	}
+
+	if args.Ephemeral {
+		if err = n.addEphemeralThread(id); err != nil {
+			return
+		}
+	}
+	if err = n.addThread(thread.Info{ID: id, Key: key}); err != nil {
+		return
+	}
+	if err = n.createExternalLogsIfNotExist(id, logs); err != nil {
+		return
+	}
+
+	// blocks added before their records are processed must survive garbage collection
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	archived, err := util.ReadArchivedRecords(ctx, cr, n.dagFor(id), manifest.Logs, key.Service())
+	if err != nil {
+		return
+	}
+	for i, al := range manifest.Logs {
+		lid := logs[i].ID
+		for _, head := range al.Heads {
+			chain := archived.Chain(head, al.Boundary)
+			if len(chain) == 0 {
+				continue
+			}
+			if chain[0].Cid().Equals(al.Boundary) {
+				if err = n.adoptBoundary(id, lid, chain[0]); err != nil {
+					return info, err
+				}
+			}
+			if err = archived.CheckChain(ctx, n, chain); err != nil {
+				return info, fmt.Errorf("log %s: %w", lid, err)
+			}
+			if err = n.putChains(ctx, id, lid, chain, n.localSource()); err != nil {
+				return info, fmt.Errorf("log %s: %w", lid, err)
+			}
+		}
+	}
+	if n.server.ps != nil {
+		if err = n.server.ps.Add(id); err != nil {
+			return
+		}
+	}
+	log.Debugf("imported thread %s with %d logs", id, len(logs))
+	return n.getThreadWithAddrs(id)
+}
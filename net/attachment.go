@@ -0,0 +1,111 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+)
+
+func (n *net) AddAttachment(
+	ctx context.Context,
+	id thread.ID,
+	r io.Reader,
+	opts ...core.AttachmentOption,
+) (cid.Cid, error) {
+	args := &core.AttachmentOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return cid.Undef, err
+	}
+
+	var key crypto.EncryptionKey
+	if !args.Plain {
+		rk, err := n.store.ReadKey(id)
+		if err != nil {
+			return cid.Undef, err
+		}
+		if rk == nil {
+			return cid.Undef, fmt.Errorf("a read-key is required to add encrypted attachments")
+		}
+		key = rk
+	}
+	root, err := cbor.CreateAttachment(ctx, n, r, key)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return root.Cid(), nil
+}
+
+func (n *net) GetAttachment(
+	ctx context.Context,
+	id thread.ID,
+	aid cid.Cid,
+	opts ...core.AttachmentOption,
+) (io.Reader, error) {
+	args := &core.AttachmentOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+
+	var key crypto.DecryptionKey
+	rk, err := n.store.ReadKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if rk != nil {
+		key = rk
+	}
+	return cbor.GetAttachment(ctx, n, aid, key)
+}
+
+// fetchAttachments loads all attachments linked from the record body into
+// the local blockstore. Only hosts holding the thread read key can discover
+// attachments, as record bodies are encrypted.
+func (n *net) fetchAttachments(ctx context.Context, tid thread.ID, rec core.Record) error {
+	dec, err := n.recordDecrypter(ctx, tid)
+	if err != nil || dec == nil {
+		return err
+	}
+	block, err := rec.GetBlock(ctx, n)
+	if err != nil {
+		return err
+	}
+	event, ok := block.(*cbor.Event)
+	if !ok {
+		if event, err = cbor.EventFromNode(block); err != nil {
+			return err
+		}
+	}
+	body, err := event.GetBody(ctx, n, dec)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range body.Links() {
+		node, err := n.Get(ctx, l.Cid)
+		if err != nil {
+			return fmt.Errorf("getting linked node %s: %w", l.Cid, err)
+		}
+		chunks, err := cbor.AttachmentChunks(node)
+		if err != nil {
+			continue // not an attachment
+		}
+		for opt := range n.GetMany(ctx, chunks) {
+			if opt.Err != nil {
+				return fmt.Errorf("fetching attachment %s: %w", l.Cid, opt.Err)
+			}
+		}
+	}
+	return nil
+}
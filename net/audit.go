@@ -0,0 +1,239 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+	"github.com/textileio/go-threads/net/util"
+)
+
+func (n *net) SampleRecords(
+	ctx context.Context,
+	id thread.ID,
+	nonce []byte,
+	k int,
+	opts ...core.ThreadOption,
+) (sample core.ThreadSample, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	if k <= 0 {
+		return sample, fmt.Errorf("sample size must be positive")
+	}
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return
+	}
+	if sk == nil {
+		return sample, fmt.Errorf("a service-key is required to sample records")
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+
+	// Collect record IDs of every log, walking back from the current heads.
+	// Heads are snapshotted here, so records added concurrently aren't sampled.
+	var (
+		chains     [][]cid.Cid
+		fullChains = make(map[peer.ID]struct{})
+	)
+	sample.ThreadID = id
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		var (
+			chain   []cid.Cid
+			headExt []byte
+		)
+		for rid := lg.Head; rid.Defined(); {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			rec, err := cbor.GetRecord(ctx, n, rid, sk)
+			if err != nil {
+				return sample, fmt.Errorf("getting record %s: %w", rid, err)
+			}
+			if rid == lg.Head {
+				if err = n.loadExtensions(id, rec); err != nil {
+					return sample, err
+				}
+				if rec.Seq() != 0 {
+					headExt = rec.(*cbor.Record).RawExtensions()
+				}
+			}
+			chain = append(chain, rid)
+			rid = rec.PrevID()
+		}
+		numbered := headExt != nil
+		if !numbered {
+			// the whole chain proves the length of logs without sequence numbers
+			fullChains[lg.ID] = struct{}{}
+		}
+		pk, err := ic.MarshalPublicKey(lg.PubKey)
+		if err != nil {
+			return sample, err
+		}
+		sample.Logs = append(sample.Logs, core.LogSample{
+			ID:             lg.ID,
+			PubKey:         pk,
+			Head:           lg.Head,
+			HeadExtensions: headExt,
+			Length:         len(chain),
+		})
+		chains = append(chains, chain)
+	}
+	sort.Sort(logSamplesByID{sample.Logs, chains})
+
+	indices := core.SampleIndices(id, nonce, k, sample.Logs)
+	for i := range sample.Logs {
+		ls := &sample.Logs[i]
+		ls.Indices = indices[i]
+		// the head is included anyway, its sequence number proves the log length
+		depth := len(chains[i])
+		if _, ok := fullChains[ls.ID]; !ok {
+			depth = 1
+			if len(ls.Indices) > 0 {
+				depth = ls.Indices[len(ls.Indices)-1] + 1
+			}
+		}
+		ls.Chain = make([][]byte, 0, depth)
+		for _, rid := range chains[i][:depth] {
+			node, err := n.Get(ctx, rid)
+			if err != nil {
+				return sample, fmt.Errorf("getting record node %s: %w", rid, err)
+			}
+			ls.Chain = append(ls.Chain, node.RawData())
+		}
+	}
+	return sample, nil
+}
+
+// VerifySample checks a thread sample produced for the given nonce and sample size.
+// It ensures the sampled positions follow from the nonce, every chain is hash-linked
+// to the log head, every record in the chain is signed by the log key, and the claimed
+// log lengths match the signed sequence numbers of the heads. Samples of logs without
+// sequence numbers must carry the whole log down to its first record instead.
+// The service key is required to decode the record nodes.
+func VerifySample(sample core.ThreadSample, nonce []byte, k int, key crypto.DecryptionKey) error {
+	if k <= 0 {
+		return fmt.Errorf("sample size must be positive")
+	}
+	for i, ls := range sample.Logs {
+		if i > 0 && ls.ID <= sample.Logs[i-1].ID {
+			return fmt.Errorf("logs are not ordered by ID")
+		}
+		if ls.Length <= 0 || !ls.Head.Defined() {
+			return fmt.Errorf("log %s: empty log in sample", ls.ID)
+		}
+	}
+
+	expected := core.SampleIndices(sample.ThreadID, nonce, k, sample.Logs)
+	for i, ls := range sample.Logs {
+		if !equalIndices(expected[i], ls.Indices) {
+			return fmt.Errorf("log %s: sampled positions don't match the nonce", ls.ID)
+		}
+		if err := verifyLogSample(ls, key); err != nil {
+			return fmt.Errorf("log %s: %w", ls.ID, err)
+		}
+	}
+	return nil
+}
+
+func verifyLogSample(ls core.LogSample, key crypto.DecryptionKey) error {
+	pk, err := ic.UnmarshalPublicKey(ls.PubKey)
+	if err != nil {
+		return fmt.Errorf("bad public key: %w", err)
+	}
+	if !ls.ID.MatchesPublicKey(pk) {
+		return fmt.Errorf("public key doesn't match log ID")
+	}
+
+	want := 1
+	if len(ls.Indices) > 0 {
+		want = ls.Indices[len(ls.Indices)-1] + 1
+	}
+	if len(ls.Chain) != want && len(ls.Chain) != ls.Length {
+		return fmt.Errorf("expected chain of %d records, got %d", want, len(ls.Chain))
+	}
+
+	var numbered bool
+	next := ls.Head
+	for i, raw := range ls.Chain {
+		node, err := cbornode.Decode(raw, mh.SHA2_256, -1)
+		if err != nil {
+			return fmt.Errorf("decoding record %d: %w", i, err)
+		}
+		if !node.Cid().Equals(next) {
+			return fmt.Errorf("record %d is not linked to the chain", i)
+		}
+		rec, err := cbor.RecordFromNode(node, key)
+		if err != nil {
+			return fmt.Errorf("decoding record %d: %w", i, err)
+		}
+		if i == 0 {
+			r, ok := rec.(*cbor.Record)
+			if !ok {
+				return fmt.Errorf("unexpected record type %T", rec)
+			}
+			r.SetRawExtensions(ls.HeadExtensions)
+		}
+		if err := util.VerifyRecordSig(rec, pk); err != nil {
+			return fmt.Errorf("record %s: %w", node.Cid(), err)
+		}
+		if i == 0 {
+			numbered = rec.Seq() != 0
+			if numbered && rec.Seq() != uint64(ls.Length) {
+				return fmt.Errorf("head is numbered %d, claimed length is %d", rec.Seq(), ls.Length)
+			}
+		}
+		next = rec.PrevID()
+		if !next.Defined() && i < ls.Length-1 {
+			return fmt.Errorf("log ends at record %d, claimed length is %d", i, ls.Length)
+		}
+	}
+	if !numbered && (len(ls.Chain) != ls.Length || next.Defined()) {
+		return fmt.Errorf("log without sequence numbers doesn't reach its first record")
+	}
+	return nil
+}
+
+func equalIndices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// logSamplesByID sorts log samples along with their record chains.
+type logSamplesByID struct {
+	logs   []core.LogSample
+	chains [][]cid.Cid
+}
+
+func (s logSamplesByID) Len() int           { return len(s.logs) }
+func (s logSamplesByID) Less(i, j int) bool { return s.logs[i].ID < s.logs[j].ID }
+func (s logSamplesByID) Swap(i, j int) {
+	s.logs[i], s.logs[j] = s.logs[j], s.logs[i]
+	s.chains[i], s.chains[j] = s.chains[j], s.chains[i]
+}
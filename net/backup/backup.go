@@ -0,0 +1,240 @@
+// Package backup streams thread records to a remote target as they're added, complementing the
+// one-shot CAR export with incremental backups. Backed up records are marked, and the latest one of
+// every log is kept as the log cursor, so a restarted backup resumes where it stopped, and records
+// added meanwhile are backed up before newer ones, on every branch of forked logs.
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+var log = logging.Logger("backup")
+
+var (
+	// cursorPrefix is the datastore prefix of the log cursors.
+	cursorPrefix = ds.NewKey("/backup/cursor")
+	// recordPrefix is the datastore prefix of the marks of backed up records.
+	recordPrefix = ds.NewKey("/backup/record")
+)
+
+// Target receives the backed up records.
+type Target interface {
+	// PutRecord stores the envelope of a record of a log, see RecordFromEnvelope. Records of a log
+	// are put in order, and a record may be put again if the backup was interrupted.
+	PutRecord(ctx context.Context, id thread.ID, lid peer.ID, rid cid.Cid, envelope []byte) error
+}
+
+// Config specifies backup settings.
+type Config struct {
+	// Threads to back up. Records added while the backup wasn't running are backed up on start.
+	// If empty, records of all threads are backed up as they're added, along with the records
+	// missing since the log cursors.
+	Threads []thread.ID
+
+	// Token authorizes access to the threads.
+	Token thread.Token
+}
+
+// Backup streams records of a network to a target.
+type Backup struct {
+	net     core.Net
+	target  Target
+	cursors ds.Datastore
+	conf    Config
+}
+
+// NewBackup returns a backup of the network records to the target, keeping log cursors in the datastore.
+func NewBackup(network core.Net, target Target, cursors ds.Datastore, conf Config) *Backup {
+	return &Backup{net: network, target: target, cursors: cursors, conf: conf}
+}
+
+// Run backs up records until the context is canceled. It first catches up on records of
+// Config.Threads added since the previous run, and then backs up records as they're added.
+func (b *Backup) Run(ctx context.Context) error {
+	opts := []core.SubOption{core.WithSubToken(b.conf.Token)}
+	for _, id := range b.conf.Threads {
+		opts = append(opts, core.WithSubFilter(id))
+	}
+	// subscribe before catching up, so no records are missed in between
+	sub, err := b.net.Subscribe(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	for _, id := range b.conf.Threads {
+		if err := b.catchUp(ctx, id); err != nil {
+			return fmt.Errorf("catching up on thread %s: %w", id, err)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case rec, ok := <-sub:
+			if !ok {
+				return nil
+			}
+			if err := b.backupLog(ctx, rec.ThreadID(), rec.LogID(), rec.Value().Cid()); err != nil {
+				log.Errorf("error backing up record %s: %v", rec.Value().Cid(), err)
+			}
+		}
+	}
+}
+
+// Cursor returns the latest record of a log backed up, undefined if there's none.
+func (b *Backup) Cursor(id thread.ID, lid peer.ID) (cid.Cid, error) {
+	data, err := b.cursors.Get(cursorKey(id, lid))
+	if err == ds.ErrNotFound {
+		return cid.Undef, nil
+	} else if err != nil {
+		return cid.Undef, err
+	}
+	return cid.Cast(data)
+}
+
+// catchUp backs up the records of every head of every log of a thread which weren't backed up yet.
+func (b *Backup) catchUp(ctx context.Context, id thread.ID) error {
+	info, err := b.net.GetThread(ctx, id, core.WithThreadToken(b.conf.Token))
+	if err != nil {
+		return err
+	}
+	for _, lg := range info.Logs {
+		heads := lg.Heads
+		if len(heads) == 0 && lg.Head.Defined() {
+			heads = []cid.Cid{lg.Head}
+		}
+		for _, head := range heads {
+			if err := b.backupLog(ctx, id, lg.ID, head); err != nil {
+				return fmt.Errorf("log %s: %w", lg.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// backupLog puts the records of a log up to the given one, starting after the latest record
+// backed up on its branch.
+func (b *Backup) backupLog(ctx context.Context, id thread.ID, lid peer.ID, head cid.Cid) error {
+	// walk the log back to a backed up record, which isn't the cursor on other branches
+	var missing []core.Record
+	for rid := head; rid.Defined(); {
+		done, err := b.cursors.Has(recordKey(id, rid))
+		if err != nil {
+			return err
+		} else if done {
+			break
+		}
+		rec, err := b.net.GetRecord(ctx, id, rid, core.WithThreadToken(b.conf.Token))
+		if err != nil {
+			return err
+		}
+		missing = append(missing, rec)
+		rid = rec.PrevID()
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		envelope, err := b.envelope(ctx, missing[i])
+		if err != nil {
+			return fmt.Errorf("encoding record %s: %w", missing[i].Cid(), err)
+		}
+		if err = b.target.PutRecord(ctx, id, lid, missing[i].Cid(), envelope); err != nil {
+			return fmt.Errorf("putting record %s: %w", missing[i].Cid(), err)
+		}
+		if err = b.cursors.Put(recordKey(id, missing[i].Cid()), nil); err != nil {
+			return err
+		}
+		if err = b.cursors.Put(cursorKey(id, lid), missing[i].Cid().Bytes()); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		log.Debugf("backed up %d records of log %s", len(missing), lid)
+	}
+	return nil
+}
+
+// envelope returns the record encoded as it's sent to peers, i.e., with its event, header and
+// body nodes, and the signed extensions, along with the chunks of its body if it's chunked.
+func (b *Backup) envelope(ctx context.Context, rec core.Record) ([]byte, error) {
+	pbrec, err := cbor.RecordToProto(ctx, b.net, rec)
+	if err != nil {
+		return nil, err
+	}
+	env := &pb.RecordEnvelope{Record: pbrec}
+	if len(pbrec.BodyNode) > 0 {
+		body, err := cbornode.Decode(pbrec.BodyNode, mh.SHA2_256, -1)
+		if err != nil {
+			return nil, err
+		}
+		if chunks, _, err := cbor.BodyChunks(body); err == nil {
+			if env.BodyChunks, err = b.bodyChunks(ctx, chunks); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return env.Marshal()
+}
+
+// bodyChunks returns the raw data of the chunks of a body, in order.
+func (b *Backup) bodyChunks(ctx context.Context, chunks []cid.Cid) ([][]byte, error) {
+	// GetMany doesn't preserve the order
+	nodes := make(map[cid.Cid][]byte, len(chunks))
+	for opt := range b.net.GetMany(ctx, chunks) {
+		if opt.Err != nil {
+			return nil, fmt.Errorf("getting body chunk: %w", opt.Err)
+		}
+		nodes[opt.Node.Cid()] = opt.Node.RawData()
+	}
+	raw := make([][]byte, len(chunks))
+	for i, id := range chunks {
+		data, ok := nodes[id]
+		if !ok {
+			return nil, fmt.Errorf("missing body chunk %s", id)
+		}
+		raw[i] = data
+	}
+	return raw, nil
+}
+
+// RecordFromEnvelope decodes a backed up record with the thread service key. The chunks of
+// a chunked body are returned too, they must be added to the dag service along with the record.
+func RecordFromEnvelope(envelope []byte, key crypto.DecryptionKey) (core.Record, []format.Node, error) {
+	env := &pb.RecordEnvelope{}
+	if err := env.Unmarshal(envelope); err != nil {
+		return nil, nil, err
+	}
+	if env.Record == nil {
+		return nil, nil, fmt.Errorf("envelope is missing the record")
+	}
+	rec, err := cbor.RecordFromProto(env.Record, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunks := make([]format.Node, len(env.BodyChunks))
+	for i, raw := range env.BodyChunks {
+		if chunks[i], err = cbornode.Decode(raw, mh.SHA2_256, -1); err != nil {
+			return nil, nil, fmt.Errorf("decoding body chunk: %w", err)
+		}
+	}
+	return rec, chunks, nil
+}
+
+func cursorKey(id thread.ID, lid peer.ID) ds.Key {
+	return cursorPrefix.ChildString(id.String()).ChildString(lid.String())
+}
+
+func recordKey(id thread.ID, rid cid.Cid) ds.Key {
+	return recordPrefix.ChildString(id.String()).ChildString(rid.String())
+}
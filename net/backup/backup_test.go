@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/common"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util"
+)
+
+func TestBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	n, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	ctx := context.Background()
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newBody := func(v interface{}) format.Node {
+		body, err := cbornode.WrapObject(map[string]interface{}{"v": v}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	add := func(v interface{}) cid.Cid {
+		r, err := n.CreateRecord(ctx, info.ID, newBody(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.Value().Cid()
+	}
+	var added []cid.Cid
+	added = append(added, add(1), add(2))
+
+	var (
+		store   = newFakeObjectStore()
+		cursors = dssync.MutexWrap(ds.NewMapDatastore())
+		prefix  = "backups"
+		lid     = info.Logs[0].ID
+	)
+	run := func() (*Backup, context.CancelFunc, chan error) {
+		b := NewBackup(n, NewObjectTarget(store, prefix), cursors, Config{Threads: []thread.ID{info.ID}})
+		rctx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- b.Run(rctx) }()
+		return b, cancel, done
+	}
+	await := func(b *Backup, rid cid.Cid) {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			cursor, err := b.Cursor(info.ID, lid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cursor.Equals(rid) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected cursor at %s, got %s", rid, cursor)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	awaitPut := func(rid cid.Cid) {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			if _, ok := store.get(ObjectKey(prefix, info.ID, lid, rid)); ok {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected record %s to be backed up", rid)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// records added before the start are caught up on, new ones are streamed
+	b, cancel, done := run()
+	await(b, added[1])
+	added = append(added, add(3))
+	await(b, added[2])
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// a restarted backup resumes from the cursor
+	added = append(added, add(4))
+	b, cancel, done = run()
+	await(b, added[3])
+	added = append(added, add(5))
+	await(b, added[4])
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// records added meanwhile on every branch of a forked log are caught up on, along with the
+	// chunks of large bodies
+	large := add(make([]byte, 2*cbor.BodyChunkSize))
+	added = append(added, large)
+	event, err := cbor.CreateEvent(ctx, n, newBody(6), info.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := info.GetFirstPrivKeyLog()
+	fork, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+		Block:      event,
+		Prev:       added[0],
+		Key:        own.PrivKey,
+		PubKey:     thread.NewLibp2pPubKey(n.Host().Peerstore().PrivKey(n.Host().ID()).GetPublic()),
+		ServiceKey: info.Key.Service(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.AddRecord(ctx, info.ID, own.ID, fork); err != nil {
+		t.Fatal(err)
+	}
+	added = append(added, fork.Cid())
+	_, cancel, done = run()
+	awaitPut(large)
+	awaitPut(fork.Cid())
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if puts := store.puts(); puts != len(added) {
+		t.Fatalf("expected every record to be put once, got %d puts of %d records", puts, len(added))
+	}
+	for _, rid := range added {
+		envelope, ok := store.get(ObjectKey(prefix, info.ID, lid, rid))
+		if !ok {
+			t.Fatalf("expected record %s to be backed up", rid)
+		}
+		rec, chunks, err := RecordFromEnvelope(envelope, info.Key.Service())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rec.Cid().Equals(rid) {
+			t.Fatalf("expected envelope of record %s, got %s", rid, rec.Cid())
+		}
+		if rid.Equals(large) && len(chunks) < 2 {
+			t.Fatalf("expected the body chunks of record %s, got %d", rid, len(chunks))
+		} else if !rid.Equals(large) && len(chunks) != 0 {
+			t.Fatalf("expected no body chunks of record %s, got %d", rid, len(chunks))
+		}
+	}
+}
+
+type fakeObjectStore struct {
+	sync.Mutex
+	objects map[string][]byte
+	count   int
+}
+
+func newFakeObjectStore() *fakeObjectStore {
+	return &fakeObjectStore{objects: make(map[string][]byte)}
+}
+
+func (s *fakeObjectStore) PutObject(_ context.Context, key string, data []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	s.objects[key] = data
+	s.count++
+	return nil
+}
+
+func (s *fakeObjectStore) get(key string) ([]byte, bool) {
+	s.Lock()
+	defer s.Unlock()
+	data, ok := s.objects[key]
+	return data, ok
+}
+
+func (s *fakeObjectStore) puts() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.count
+}
@@ -0,0 +1,75 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// ObjectWriter writes objects to an S3-style object store.
+type ObjectWriter interface {
+	// PutObject writes the object data under the key, replacing an existing object.
+	PutObject(ctx context.Context, key string, data []byte) error
+}
+
+type objectTarget struct {
+	w      ObjectWriter
+	prefix string
+}
+
+// NewObjectTarget returns a target writing the envelope of every record to an object
+// keyed by <prefix>/<thread ID>/<log ID>/<record ID>.
+func NewObjectTarget(w ObjectWriter, prefix string) Target {
+	return &objectTarget{w: w, prefix: prefix}
+}
+
+func (t *objectTarget) PutRecord(ctx context.Context, id thread.ID, lid peer.ID, rid cid.Cid, envelope []byte) error {
+	return t.w.PutObject(ctx, ObjectKey(t.prefix, id, lid, rid), envelope)
+}
+
+// ObjectKey returns the object key of a record written by an object target.
+func ObjectKey(prefix string, id thread.ID, lid peer.ID, rid cid.Cid) string {
+	return path.Join(prefix, id.String(), lid.String(), rid.String())
+}
+
+type netTarget struct {
+	api   core.API
+	token thread.Token
+}
+
+// NewNetTarget returns a target adding records to another host, e.g., a backup host reached with
+// the API client. The host must hold the threads along with their logs, e.g., added from the
+// backed up host, and accept records, i.e., not run read-only. Chunks of record bodies are added
+// first if the API is a dag service, e.g., a local network, otherwise the host fetches them.
+func NewNetTarget(api core.API, token thread.Token) Target {
+	return &netTarget{api: api, token: token}
+}
+
+func (t *netTarget) PutRecord(ctx context.Context, id thread.ID, lid peer.ID, rid cid.Cid, envelope []byte) error {
+	info, err := t.api.GetThread(ctx, id, core.WithThreadToken(t.token))
+	if err != nil {
+		return err
+	}
+	if info.Key.Service() == nil {
+		return fmt.Errorf("a service-key is required to add records")
+	}
+	rec, chunks, err := RecordFromEnvelope(envelope, info.Key.Service())
+	if err != nil {
+		return err
+	}
+	if !rec.Cid().Equals(rid) {
+		return fmt.Errorf("envelope of record %s holds record %s", rid, rec.Cid())
+	}
+	if adder, ok := t.api.(format.NodeAdder); ok && len(chunks) > 0 {
+		if err = adder.AddMany(ctx, chunks); err != nil {
+			return err
+		}
+	}
+	return t.api.AddRecord(ctx, id, lid, rec, core.WithThreadToken(t.token))
+}
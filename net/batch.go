@@ -0,0 +1,250 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/queue"
+)
+
+func (n *net) AddThreads(
+	ctx context.Context,
+	addrs []ma.Multiaddr,
+	opts ...core.NewThreadOption,
+) ([]thread.Info, error) {
+	args := &core.NewThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+
+	var (
+		ids    = make([]thread.ID, 0, len(addrs))
+		peers  = make(map[peer.ID]*peer.AddrInfo)
+		byPeer = make(map[peer.ID][]thread.ID)
+	)
+	for _, addr := range addrs {
+		targs := *args
+		if id, err := thread.FromAddr(addr); err == nil {
+			if key, ok := args.ThreadKeys[id]; ok {
+				targs.ThreadKey = key
+			}
+		}
+		id, addri, addFromSelf, err := n.addThreadLocally(addr, &targs)
+		if err != nil {
+			return nil, fmt.Errorf("adding thread from %s: %w", addr, err)
+		}
+		ids = append(ids, id)
+		if addFromSelf {
+			continue
+		}
+		if ai, ok := peers[addri.ID]; ok {
+			ai.Addrs = append(ai.Addrs, addri.Addrs...)
+		} else {
+			peers[addri.ID] = addri
+		}
+		byPeer[addri.ID] = append(byPeer[addri.ID], id)
+	}
+
+	// peers are connected once, and logs of their threads are fetched one after another
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		firstErr error
+	)
+	for pid, tids := range byPeer {
+		wg.Add(1)
+		go func(ai *peer.AddrInfo, tids []thread.ID) {
+			defer wg.Done()
+			err := n.host.Connect(ctx, *ai)
+			for i := 0; err == nil && i < len(tids); i++ {
+				err = n.getThreadLogs(ai.ID, tids[i])
+			}
+			if err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("getting logs from %s: %w", ai.ID, err)
+				}
+				errLock.Unlock()
+			}
+		}(peers[pid], tids)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// records of the threads which diverged are pulled in the background
+	n.exchangeThreads(ctx, byPeer)
+
+	infos := make([]thread.Info, len(ids))
+	for i, id := range ids {
+		n.discoverThreadAsync(id)
+		info, err := n.getThreadWithAddrs(id)
+		if err != nil {
+			return nil, err
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+func (n *net) PullThreads(ctx context.Context, ids []thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+
+	var (
+		byPeer = make(map[peer.ID][]thread.ID)
+		pull   = make(map[thread.ID]struct{})
+	)
+	for _, id := range ids {
+		if _, err := n.Validate(id, args.Token, true); err != nil {
+			return err
+		}
+		if err := n.loadThread(id); err != nil {
+			return err
+		}
+		if err := n.rehydrateThread(ctx, id); err != nil {
+			return err
+		}
+		_, peers, err := n.threadOffsets(id)
+		if err != nil {
+			return err
+		}
+		for _, pid := range peers {
+			byPeer[pid] = append(byPeer[pid], id)
+		}
+		// heads learned along with the logs match the peer edges before records are pulled
+		if behind, err := n.hasUnknownHeads(id); err != nil {
+			return err
+		} else if behind {
+			pull[id] = struct{}{}
+		}
+	}
+	n.connectPeers(ctx, byPeer)
+	for id := range n.exchangeThreads(ctx, byPeer) {
+		pull[id] = struct{}{}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, n.syncConfig().MaxConcurrentPulls)
+	)
+	for _, id := range ids {
+		if _, ok := pull[id]; !ok {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(id thread.ID) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := n.pullThread(ctx, id); err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("pulling thread %s: %w", id, err)
+				}
+				errLock.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	for _, id := range ids {
+		n.pullLinkedThreads(ctx, id)
+	}
+	return nil
+}
+
+// hasUnknownHeads returns whether records of any log head of the thread weren't received yet.
+func (n *net) hasUnknownHeads(tid thread.ID) (bool, error) {
+	info, err := n.store.GetThread(tid)
+	if err != nil {
+		return false, err
+	}
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		if known, err := n.isKnown(lg.Head); err != nil {
+			return false, err
+		} else if !known {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// connectPeers connects to the peers concurrently, so calls to peers sharing threads
+// don't dial them one after another.
+func (n *net) connectPeers(ctx context.Context, peers map[peer.ID][]thread.ID) {
+	var wg sync.WaitGroup
+	for pid := range peers {
+		if n.host.Network().Connectedness(pid) == network.Connected {
+			continue
+		}
+		wg.Add(1)
+		go func(pid peer.ID) {
+			defer wg.Done()
+			if err := n.host.Connect(ctx, n.host.Peerstore().PeerInfo(pid)); err != nil {
+				log.Debugf("connecting to %s failed: %v", pid, err)
+			}
+		}(pid)
+	}
+	wg.Wait()
+}
+
+// exchangeThreads exchanges edges of the threads with their peers, packing the threads
+// shared with a peer into as few requests as possible. Pulls are scheduled for the threads
+// which diverged, and the threads are returned along with the ones which failed the exchange.
+func (n *net) exchangeThreads(ctx context.Context, peers map[peer.ID][]thread.ID) map[thread.ID]struct{} {
+	pctx, cancel := context.WithCancel(ctx)
+	packer := queue.NewThreadPacker(pctx, n.clock, MaxThreadsExchanged, ExchangeCompressionTimeout)
+	packs := packer.Run()
+	go func() {
+		// the packer flushes the remaining packs once canceled
+		defer cancel()
+		for pid, tids := range peers {
+			for _, tid := range tids {
+				packer.Add(pid, tid)
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		mx       sync.Mutex
+		diverged = make(map[thread.ID]struct{})
+	)
+	for pack := range packs {
+		wg.Add(1)
+		go func(p queue.ThreadPack) {
+			defer wg.Done()
+			tids, err := n.server.exchangeEdges(ctx, p.Peer, p.Threads)
+			if err != nil {
+				log.Debugf("exchanging edges with %s failed: %v", p.Peer, err)
+				tids = p.Threads
+			}
+			mx.Lock()
+			for _, tid := range tids {
+				diverged[tid] = struct{}{}
+			}
+			mx.Unlock()
+		}(pack)
+	}
+	wg.Wait()
+	return diverged
+}
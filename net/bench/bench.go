@@ -0,0 +1,528 @@
+// Package bench runs networks of in-process thread hosts under a configurable
+// write load, and reports record sync latency, throughput and resource usage.
+package bench
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/common"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util"
+)
+
+var log = logging.Logger("netbench")
+
+// ResourceSampleInterval is the interval of process resource usage sampling.
+var ResourceSampleInterval = 100 * time.Millisecond
+
+// Config describes the benchmarked network and its load.
+type Config struct {
+	// Nodes is the number of hosts, two by default.
+	Nodes int
+	// Threads is the number of threads replicated by every host, one by default.
+	Threads int
+	// Writers is the number of hosts writing to every thread, one by default.
+	// Threads are spread over the hosts, so writes are balanced if Writers < Nodes.
+	Writers int
+	// Records is the number of records every writer creates in every thread.
+	Records int
+	// Rate is the number of records per second every writer creates in every thread.
+	// Zero creates records as fast as possible.
+	Rate float64
+	// RecordSize is the size of the random payload of every record body in bytes.
+	RecordSize int
+	// PubSub enables record delivery over pubsub in addition to direct pushes.
+	PubSub bool
+	// Timeout bounds the wait for records to sync after the last write.
+	Timeout time.Duration
+	// Dir is where the host repos are created, the system temporary directory by default.
+	// The repos are removed after the run.
+	Dir string
+}
+
+// Result summarizes a benchmark run.
+type Result struct {
+	Config Config
+	// Records is the number of records written.
+	Records int
+	// Deliveries is the number of records received by hosts other than the writer.
+	Deliveries int
+	// Expected is the number of deliveries needed for full replication.
+	Expected int
+	// Elapsed is the time from the first write until the last delivery.
+	Elapsed time.Duration
+	// Throughput is the number of records written and replicated per second.
+	Throughput float64
+	// Bandwidth is the number of payload bytes delivered per second.
+	Bandwidth float64
+	// Latency is the time from the start of a write until a host receives the record.
+	Latency Latency
+	// Resources is the usage of the process, which runs every host.
+	Resources Resources
+}
+
+// Latency describes the distribution of record sync latencies.
+type Latency struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// Resources describes the usage of the benchmark process.
+type Resources struct {
+	// PeakHeap is the largest sampled size of the in-use heap in bytes.
+	PeakHeap uint64
+	// TotalAlloc is the number of bytes allocated during the run.
+	TotalAlloc uint64
+	// NumGC is the number of garbage collections during the run.
+	NumGC uint32
+	// PeakGoroutines is the largest sampled number of goroutines.
+	PeakGoroutines int
+	// DiskUsage is the size of the host repos in bytes after the run.
+	DiskUsage int64
+}
+
+func (r Result) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "nodes: %d, threads: %d, writers: %d, record size: %d B\n",
+		r.Config.Nodes, r.Config.Threads, r.Config.Writers, r.Config.RecordSize)
+	fmt.Fprintf(&b, "records: %d, deliveries: %d/%d, elapsed: %s\n",
+		r.Records, r.Deliveries, r.Expected, r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(&b, "throughput: %.1f rec/s, %.1f KiB/s\n", r.Throughput, r.Bandwidth/1024)
+	l := r.Latency
+	fmt.Fprintf(&b, "latency: min %s, mean %s, p50 %s, p90 %s, p99 %s, max %s\n",
+		l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	res := r.Resources
+	fmt.Fprintf(&b, "resources: peak heap %.1f MiB, allocated %.1f MiB, %d GCs, peak goroutines %d, disk %.1f MiB",
+		mib(res.PeakHeap), mib(res.TotalAlloc), res.NumGC, res.PeakGoroutines, mib(uint64(res.DiskUsage)))
+	return b.String()
+}
+
+// Run starts the hosts, connects them to the benchmarked threads, writes the records,
+// and waits until they are replicated to every host or the timeout elapses.
+// Missing deliveries after the timeout don't fail the run, see Result.Deliveries.
+func Run(ctx context.Context, conf Config) (res Result, err error) {
+	if err = setDefaults(&conf); err != nil {
+		return
+	}
+	res.Config = conf
+
+	dir, err := ioutil.TempDir(conf.Dir, "threadsbench")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	nodes, err := startNodes(conf, dir)
+	defer func() {
+		for _, n := range nodes {
+			if cerr := n.Close(); cerr != nil {
+				log.Errorf("closing node: %v", cerr)
+			}
+		}
+	}()
+	if err != nil {
+		return
+	}
+	threads, err := createThreads(ctx, conf, nodes)
+	if err != nil {
+		return
+	}
+	if err = warmup(ctx, conf, nodes, threads); err != nil {
+		return
+	}
+
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tr := newTracker(conf.Records * conf.Writers * conf.Threads * (conf.Nodes - 1))
+	for i, n := range nodes {
+		sub, err := n.Subscribe(sctx)
+		if err != nil {
+			return res, err
+		}
+		go func(node int, sub <-chan core.ThreadRecord) {
+			for rec := range sub {
+				tr.received(node, rec.Value().Cid())
+			}
+		}(i, sub)
+	}
+
+	stopSampling := make(chan struct{})
+	sampled := sampleResources(stopSampling)
+
+	start := time.Now()
+	if err = writeAll(ctx, conf, nodes, threads, tr); err != nil {
+		close(stopSampling)
+		return
+	}
+	select {
+	case <-tr.done:
+	case <-time.After(conf.Timeout):
+		log.Warnf("timed out waiting for sync")
+	case <-ctx.Done():
+		close(stopSampling)
+		return res, ctx.Err()
+	}
+	close(stopSampling)
+	res.Resources = <-sampled
+
+	res.Records, res.Deliveries, res.Expected = tr.counts()
+	latencies, last := tr.results()
+	if res.Deliveries > 0 {
+		res.Elapsed = last.Sub(start)
+		res.Throughput = float64(res.Records) / res.Elapsed.Seconds()
+		res.Bandwidth = float64(res.Deliveries*conf.RecordSize) / res.Elapsed.Seconds()
+	}
+	res.Latency = summarize(latencies)
+	res.Resources.DiskUsage, err = dirSize(dir)
+	return res, err
+}
+
+func setDefaults(conf *Config) error {
+	if conf.Nodes == 0 {
+		conf.Nodes = 2
+	}
+	if conf.Threads == 0 {
+		conf.Threads = 1
+	}
+	if conf.Writers == 0 {
+		conf.Writers = 1
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = time.Minute
+	}
+	if conf.Nodes < 2 {
+		return errors.New("at least two nodes are required")
+	}
+	if conf.Writers > conf.Nodes {
+		return fmt.Errorf("writers (%d) can't exceed nodes (%d)", conf.Writers, conf.Nodes)
+	}
+	if conf.Records < 1 {
+		return errors.New("at least one record per writer is required")
+	}
+	if conf.Threads < 1 || conf.Writers < 1 || conf.RecordSize < 0 || conf.Rate < 0 {
+		return errors.New("invalid config")
+	}
+	return nil
+}
+
+func startNodes(conf Config, dir string) ([]common.NetBoostrapper, error) {
+	nodes := make([]common.NetBoostrapper, 0, conf.Nodes)
+	for i := 0; i < conf.Nodes; i++ {
+		n, err := common.DefaultNetwork(
+			common.WithNetBadgerPersistence(filepath.Join(dir, fmt.Sprintf("node%d", i))),
+			common.WithNetHostAddr(util.FreeLocalAddr()),
+			common.WithNetPubSub(conf.PubSub),
+		)
+		if err != nil {
+			return nodes, fmt.Errorf("starting node %d: %w", i, err)
+		}
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		for _, p := range nodes {
+			if n != p {
+				n.Host().Peerstore().AddAddrs(p.Host().ID(), p.Host().Addrs(), peerstore.PermanentAddrTTL)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// createThreads creates every thread on one host, round-robin, and adds it to the others.
+func createThreads(ctx context.Context, conf Config, nodes []common.NetBoostrapper) ([]thread.ID, error) {
+	threads := make([]thread.ID, conf.Threads)
+	for i := range threads {
+		owner := nodes[i%len(nodes)]
+		info, err := owner.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+		if err != nil {
+			return nil, err
+		}
+		addr, err := ma.NewMultiaddr("/p2p/" + owner.Host().ID().String() + "/thread/" + info.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			if n == owner {
+				continue
+			}
+			if _, err = n.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+				return nil, fmt.Errorf("adding thread %s: %w", info.ID, err)
+			}
+		}
+		threads[i] = info.ID
+	}
+	return threads, nil
+}
+
+// writers returns the hosts writing to the i-th thread, starting with its owner.
+func writers(conf Config, nodes []common.NetBoostrapper, i int) []int {
+	ws := make([]int, conf.Writers)
+	for j := range ws {
+		ws[j] = (i + j) % len(nodes)
+	}
+	return ws
+}
+
+// warmup creates the logs of the writers, and makes every host learn about them,
+// so the measured records are pushed to every host.
+func warmup(ctx context.Context, conf Config, nodes []common.NetBoostrapper, threads []thread.ID) error {
+	for i, id := range threads {
+		for _, w := range writers(conf, nodes, i) {
+			body, err := newBody(-1, conf.RecordSize)
+			if err != nil {
+				return err
+			}
+			if _, err = nodes[w].CreateRecord(ctx, id, body); err != nil {
+				return fmt.Errorf("warming up thread %s: %w", id, err)
+			}
+		}
+	}
+	for _, n := range nodes {
+		for _, id := range threads {
+			if err := n.PullThread(ctx, id); err != nil {
+				return fmt.Errorf("warming up thread %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeAll(ctx context.Context, conf Config, nodes []common.NetBoostrapper, threads []thread.ID, tr *tracker) error {
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		werr error
+	)
+	for i, id := range threads {
+		for _, w := range writers(conf, nodes, i) {
+			wg.Add(1)
+			go func(w int, id thread.ID) {
+				defer wg.Done()
+				if err := writeRecords(ctx, conf, nodes[w], w, id, tr); err != nil {
+					once.Do(func() { werr = fmt.Errorf("writing to thread %s: %w", id, err) })
+				}
+			}(w, id)
+		}
+	}
+	wg.Wait()
+	return werr
+}
+
+func writeRecords(ctx context.Context, conf Config, n common.NetBoostrapper, node int, id thread.ID, tr *tracker) error {
+	var tick <-chan time.Time
+	if conf.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / conf.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for i := 0; i < conf.Records; i++ {
+		if tick != nil && i > 0 {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		body, err := newBody(i, conf.RecordSize)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		rec, err := n.CreateRecord(ctx, id, body)
+		if err != nil {
+			return err
+		}
+		tr.sent(node, rec.Value().Cid(), start)
+	}
+	return nil
+}
+
+func newBody(seq, size int) (*cbornode.Node, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	return cbornode.WrapObject(map[string]interface{}{
+		"seq":  seq,
+		"data": data,
+	}, mh.SHA2_256, -1)
+}
+
+type send struct {
+	node  int
+	start time.Time
+}
+
+type arrival struct {
+	node int
+	at   time.Time
+}
+
+// tracker matches records received by hosts with their writes. Records may be
+// received before CreateRecord returns to the writer, so arrivals of unknown
+// records are kept until the write is reported.
+type tracker struct {
+	sends     map[cid.Cid]send
+	early     map[cid.Cid][]arrival
+	latencies []time.Duration
+	last      time.Time
+	expected  int
+	done      chan struct{}
+	mu        sync.Mutex
+}
+
+func newTracker(expected int) *tracker {
+	return &tracker{
+		sends:    make(map[cid.Cid]send),
+		early:    make(map[cid.Cid][]arrival),
+		expected: expected,
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *tracker) sent(node int, id cid.Cid, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := send{node: node, start: start}
+	t.sends[id] = w
+	for _, a := range t.early[id] {
+		t.deliver(w, a)
+	}
+	delete(t.early, id)
+}
+
+func (t *tracker) received(node int, id cid.Cid) {
+	a := arrival{node: node, at: time.Now()}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if w, ok := t.sends[id]; ok {
+		t.deliver(w, a)
+	} else {
+		t.early[id] = append(t.early[id], a)
+	}
+}
+
+func (t *tracker) deliver(w send, a arrival) {
+	if w.node == a.node {
+		return
+	}
+	t.latencies = append(t.latencies, a.at.Sub(w.start))
+	if a.at.After(t.last) {
+		t.last = a.at
+	}
+	if len(t.latencies) == t.expected {
+		close(t.done)
+	}
+}
+
+func (t *tracker) counts() (records, deliveries, expected int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sends), len(t.latencies), t.expected
+}
+
+func (t *tracker) results() ([]time.Duration, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	latencies := make([]time.Duration, len(t.latencies))
+	copy(latencies, t.latencies)
+	return latencies, t.last
+}
+
+func summarize(latencies []time.Duration) (l Latency) {
+	if len(latencies) == 0 {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	percentile := func(p int) time.Duration {
+		return latencies[(len(latencies)-1)*p/100]
+	}
+	return Latency{
+		Min:  latencies[0],
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// sampleResources samples the process resource usage until stop is closed,
+// and returns the usage during the sampling.
+func sampleResources(stop <-chan struct{}) <-chan Resources {
+	out := make(chan Resources, 1)
+	go func() {
+		var (
+			res        Resources
+			before, ms runtime.MemStats
+			ticker     = time.NewTicker(ResourceSampleInterval)
+		)
+		defer ticker.Stop()
+		runtime.ReadMemStats(&before)
+		sample := func() {
+			runtime.ReadMemStats(&ms)
+			if ms.HeapInuse > res.PeakHeap {
+				res.PeakHeap = ms.HeapInuse
+			}
+			if g := runtime.NumGoroutine(); g > res.PeakGoroutines {
+				res.PeakGoroutines = g
+			}
+		}
+		for {
+			sample()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				sample()
+				res.TotalAlloc = ms.TotalAlloc - before.TotalAlloc
+				res.NumGC = ms.NumGC - before.NumGC
+				out <- res
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func dirSize(dir string) (size int64, err error) {
+	err = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return
+}
+
+func mib(b uint64) float64 {
+	return float64(b) / (1 << 20)
+}
@@ -0,0 +1,68 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	res, err := Run(context.Background(), Config{
+		Nodes:      3,
+		Threads:    2,
+		Writers:    2,
+		Records:    5,
+		Rate:       50,
+		RecordSize: 256,
+		Timeout:    30 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(res)
+
+	if res.Records != 20 {
+		t.Fatalf("expected 20 records, got %d", res.Records)
+	}
+	if res.Expected != 40 {
+		t.Fatalf("expected 40 deliveries for full replication, got %d", res.Expected)
+	}
+	// replication is bounded by the timeout, so the run only has to report sane counts
+	if res.Deliveries == 0 || res.Deliveries > res.Expected {
+		t.Fatalf("got bad deliveries %d/%d", res.Deliveries, res.Expected)
+	}
+	if res.Latency.Min <= 0 || res.Latency.Max < res.Latency.P50 {
+		t.Fatalf("got bad latency %+v", res.Latency)
+	}
+	if res.Throughput <= 0 || res.Resources.PeakHeap == 0 || res.Resources.DiskUsage == 0 {
+		t.Fatal("expected throughput and resource usage to be reported")
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	t.Parallel()
+	if _, err := Run(context.Background(), Config{Nodes: 1, Records: 1}); err == nil {
+		t.Fatal("expected single node to be refused")
+	}
+	if _, err := Run(context.Background(), Config{Nodes: 2, Writers: 3, Records: 1}); err == nil {
+		t.Fatal("expected more writers than nodes to be refused")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[len(latencies)-1-i] = time.Duration(i+1) * time.Millisecond
+	}
+	l := summarize(latencies)
+	if l.Min != time.Millisecond || l.Max != 100*time.Millisecond {
+		t.Fatalf("got bad bounds %+v", l)
+	}
+	if l.P50 != 50*time.Millisecond || l.P90 != 90*time.Millisecond || l.P99 != 99*time.Millisecond {
+		t.Fatalf("got bad percentiles %+v", l)
+	}
+	if l.Mean != 50500*time.Microsecond {
+		t.Fatalf("got bad mean %s", l.Mean)
+	}
+}
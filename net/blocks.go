@@ -0,0 +1,178 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/textileio/go-threads/cbor"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// blockRefsPrefix keys the threads referencing a block as /refs/<block>/<thread>, see Config.SharedBlocks.
+var blockRefsPrefix = ds.NewKey("/refs")
+
+// BlockStats walks all stored threads, so it's as expensive as marking the live blocks by GC.
+func (n *net) BlockStats(ctx context.Context) (core.BlockStats, error) {
+	var (
+		stats  core.BlockStats
+		events = make(map[cid.Cid]struct{})
+		// references of every body by thread and event
+		bodies = make(map[cid.Cid]map[thread.ID]map[cid.Cid]struct{})
+		cursor = newThreadCursor(n.store)
+	)
+	for {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			return stats, err
+		} else if !ok {
+			break
+		}
+		// threads are walked separately, so records shared with a walked thread are counted
+		visited := make(map[cid.Cid]struct{})
+		if err = n.walkThread(ctx, tid, visited, func(rid cid.Cid, ev *cbor.Event) {
+			visited[rid] = struct{}{}
+			events[ev.Cid()] = struct{}{}
+			refs, ok := bodies[ev.BodyID()]
+			if !ok {
+				refs = make(map[thread.ID]map[cid.Cid]struct{})
+				bodies[ev.BodyID()] = refs
+			}
+			if refs[tid] == nil {
+				refs[tid] = make(map[cid.Cid]struct{})
+			}
+			refs[tid][ev.Cid()] = struct{}{}
+		}); errors.Is(err, lstore.ErrThreadNotFound) {
+			continue // deleted meanwhile
+		} else if err != nil {
+			return stats, fmt.Errorf("thread %s: %w", tid, err)
+		}
+		stats.Threads++
+	}
+
+	shared := make(map[thread.ID]struct{})
+	for _, refs := range bodies {
+		var count int
+		for _, evs := range refs {
+			count += len(evs)
+		}
+		if count > 1 {
+			stats.SharedBodies++
+			stats.DuplicateRefs += count - 1
+		}
+		if len(refs) > 1 {
+			for tid := range refs {
+				shared[tid] = struct{}{}
+			}
+		}
+	}
+	stats.Events = len(events)
+	stats.Bodies = len(bodies)
+	stats.SharedThreads = len(shared)
+	return stats, nil
+}
+
+// refBlocks records that the blocks of the records are referenced by the thread.
+// It's a no-op unless Config.SharedBlocks is enabled.
+func (n *net) refBlocks(ctx context.Context, tid thread.ID, recs []core.Record) error {
+	if n.blockRefs == nil {
+		return nil
+	}
+	for _, rec := range recs {
+		ev, err := cbor.EventFromRecord(ctx, n, rec)
+		if err != nil {
+			return err
+		}
+		for _, id := range []cid.Cid{rec.Cid(), ev.Cid(), ev.HeaderID(), ev.BodyID()} {
+			if err = n.blockRefs.Put(blockRefKey(id, tid), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unrefBlocks drops the references of the thread to the blocks,
+// and returns the blocks not referenced by any other thread.
+func (n *net) unrefBlocks(tid thread.ID, ids []cid.Cid) ([]cid.Cid, error) {
+	unused := make([]cid.Cid, 0, len(ids))
+	for _, id := range ids {
+		if err := n.blockRefs.Delete(blockRefKey(id, tid)); err != nil {
+			return nil, err
+		}
+		if referenced, err := n.isReferenced(id); err != nil {
+			return nil, err
+		} else if !referenced {
+			unused = append(unused, id)
+		}
+	}
+	return unused, nil
+}
+
+// isReferenced returns whether a thread references the block.
+func (n *net) isReferenced(id cid.Cid) (bool, error) {
+	res, err := n.blockRefs.Query(query.Query{
+		Prefix:   blockRefsPrefix.ChildString(id.String()).String(),
+		KeysOnly: true,
+		Limit:    1,
+	})
+	if err != nil {
+		return false, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return false, err
+	}
+	return len(entries) > 0, nil
+}
+
+// forgetBlockRefs drops all references to a block removed by GC, which are left behind
+// by records which failed processing.
+func (n *net) forgetBlockRefs(id cid.Cid) error {
+	if n.blockRefs == nil {
+		return nil
+	}
+	res, err := n.blockRefs.Query(query.Query{
+		Prefix:   blockRefsPrefix.ChildString(id.String()).String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err = n.blockRefs.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeRecordBlocks removes the blocks of a record of the thread which aren't
+// referenced by other threads. Chunks of a body are removed along with it.
+func (n *net) removeRecordBlocks(ctx context.Context, tid thread.ID, rec core.Record, ev *cbor.Event) error {
+	unused, err := n.unrefBlocks(tid, []cid.Cid{rec.Cid(), ev.Cid(), ev.HeaderID(), ev.BodyID()})
+	if err != nil {
+		return err
+	}
+	for _, id := range unused {
+		if id.Equals(ev.BodyID()) {
+			unused = append(unused, util.LocalBodyChunks(n.bstore, id)...)
+			break
+		}
+	}
+	return n.RemoveMany(ctx, unused)
+}
+
+func blockRefKey(id cid.Cid, tid thread.ID) ds.Key {
+	return blockRefsPrefix.ChildString(id.String()).ChildString(tid.String())
+}
@@ -0,0 +1,152 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+// bodyChunkBatch is the number of body chunks requested from a peer at once, so
+// replies stay well below message limits with the default chunk size.
+const bodyChunkBatch = 8
+
+// fetchBodyChunks loads the chunks of a chunked record body into the local blockstore,
+// so hosts without the read key can serve them too. Other bodies are left as is.
+func (n *net) fetchBodyChunks(ctx context.Context, body format.Node) error {
+	chunks, size, err := cbor.BodyChunks(body)
+	if err != nil {
+		return nil // not chunked
+	}
+	if err = n.checkBodySize(size); err != nil {
+		return err
+	}
+	for opt := range n.GetMany(ctx, chunks) {
+		if opt.Err != nil {
+			return fmt.Errorf("fetching body chunk: %w", opt.Err)
+		}
+		if err = n.checkNodeSize("body chunk", opt.Node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBodyChunks requests the missing chunks of record bodies from the peer which sent
+// the records, so they don't depend on the dag service being able to fetch them.
+// Records are returned up to the first one with a body exceeding the size limit, along
+// with the error, since the rest of the log can't be linked without it. Likewise, chunks
+// are only requested for records fitting into the storage quotas of the log, and the
+// rest are refused with core.ErrQuotaExceeded or ErrRelayQuotaExceeded.
+func (s *server) loadBodyChunks(
+	ctx context.Context,
+	pid peer.ID,
+	tid thread.ID,
+	lid peer.ID,
+	serviceKey *sym.Key,
+	recs []core.Record,
+) ([]core.Record, error) {
+	quota, err := s.net.reserveQuota(tid, lid)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		missing []cid.Cid
+		refused error
+	)
+	for i, rec := range recs {
+		if isRestrictedRecord(rec) {
+			continue // the body is withheld by the thread ACL
+		}
+		block, err := rec.GetBlock(ctx, s.net)
+		if err != nil {
+			return nil, err
+		}
+		event, ok := block.(*cbor.Event)
+		if !ok {
+			if event, err = cbor.EventFromNode(block); err != nil {
+				return nil, fmt.Errorf("invalid event: %w", err)
+			}
+		}
+		body, err := event.GetBody(ctx, s.net, nil)
+		if err != nil {
+			return nil, err
+		}
+		chunks, size, err := cbor.BodyChunks(body)
+		if err != nil {
+			continue // not chunked
+		}
+		if err = s.net.checkBodySize(size); err != nil {
+			return recs[:i], err
+		}
+		if refused = quota.add(size); refused != nil {
+			s.net.emitRejected(tid, lid, pid, rec.Cid(), refused)
+			recs = recs[:i]
+			break
+		}
+		for _, id := range chunks {
+			if known, err := s.net.isKnown(id); err != nil {
+				return nil, err
+			} else if !known {
+				missing = append(missing, id)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return recs, refused
+	}
+
+	client, release, err := s.dial(pid)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s failed: %w", pid, err)
+	}
+	defer release()
+	for start := 0; start < len(missing); start += bodyChunkBatch {
+		end := start + bodyChunkBatch
+		if end > len(missing) {
+			end = len(missing)
+		}
+		ids := make([]pb.ProtoCid, end-start)
+		for i, id := range missing[start:end] {
+			ids[i] = pb.ProtoCid{Cid: id}
+		}
+		reply, err := client.GetRecordBodies(ctx, &pb.GetRecordBodiesRequest{
+			Body: &pb.GetRecordBodiesRequest_Body{
+				ThreadID:   &pb.ProtoThreadID{ID: tid},
+				ServiceKey: &pb.ProtoKey{Key: serviceKey},
+				Bodies:     ids,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting body chunks: %w", err)
+		}
+		if len(reply.Bodies) != len(ids) {
+			return nil, fmt.Errorf("expected %d body chunks, got %d", len(ids), len(reply.Bodies))
+		}
+		nodes := make([]format.Node, len(ids))
+		for i, raw := range reply.Bodies {
+			if err = s.net.checkSize("body chunk", len(raw)); err != nil {
+				return nil, err
+			}
+			if nodes[i], err = cbornode.Decode(raw, mh.SHA2_256, -1); err != nil {
+				return nil, err
+			}
+			if !nodes[i].Cid().Equals(ids[i].Cid) {
+				return nil, fmt.Errorf("got body chunk %s, expected %s", nodes[i].Cid(), ids[i].Cid)
+			}
+		}
+		if err = s.net.dagFor(tid).AddMany(ctx, nodes); err != nil {
+			return nil, err
+		}
+	}
+	return recs, refused
+}
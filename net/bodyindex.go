@@ -0,0 +1,136 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+var bodyIndexPrefix = ds.NewKey("/bodyindex")
+
+// bodyIndex maps the bodies of records, and the chunks of chunked bodies, to the records and
+// logs they were added with, so peers are only served bodies of the threads they are authorized
+// for, and which the thread ACL doesn't withhold. Blocks are shared by all threads in the
+// blockstore, which can't tell them apart.
+type bodyIndex struct {
+	store ds.Datastore
+}
+
+func newBodyIndex(store ds.Datastore) *bodyIndex {
+	return &bodyIndex{store: store}
+}
+
+// Record returns the log and the record of the thread the body was added with,
+// or false if it isn't a body of the thread.
+func (x *bodyIndex) Record(tid thread.ID, id cid.Cid) (peer.ID, cid.Cid, bool, error) {
+	v, err := x.store.Get(bodyIndexKey(tid, id))
+	if err == ds.ErrNotFound {
+		return "", cid.Undef, false, nil
+	} else if err != nil {
+		return "", cid.Undef, false, err
+	}
+	size, n := binary.Uvarint(v)
+	if n <= 0 || uint64(len(v)-n) < size {
+		return "", cid.Undef, false, fmt.Errorf("malformed index of body %s", id)
+	}
+	lid, err := peer.IDFromBytes(v[n : n+int(size)])
+	if err != nil {
+		return "", cid.Undef, false, err
+	}
+	rid, err := cid.Cast(v[n+int(size):])
+	if err != nil {
+		return "", cid.Undef, false, err
+	}
+	return lid, rid, true, nil
+}
+
+// Put indexes bodies or body chunks added with the record to the log.
+func (x *bodyIndex) Put(tid thread.ID, lid peer.ID, rid cid.Cid, ids ...cid.Cid) error {
+	lidb, err := lid.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	v := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(lidb)+rid.ByteLen())
+	v = append(append(v[:binary.PutUvarint(v, uint64(len(lidb)))], lidb...), rid.Bytes()...)
+	for _, id := range ids {
+		if err = x.store.Put(bodyIndexKey(tid, id), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeThread removes the index of all bodies of the thread.
+func (x *bodyIndex) PurgeThread(tid thread.ID) error {
+	res, err := x.store.Query(query.Query{Prefix: bodyIndexPrefix.ChildString(tid.String()).String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := x.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bodyIndexKey(tid thread.ID, id cid.Cid) ds.Key {
+	return bodyIndexPrefix.ChildString(tid.String()).ChildString(id.String())
+}
+
+// indexBodies indexes the bodies of records added to a log, along with their chunks.
+// Bodies withheld by the thread ACL of the sender aren't stored, so they are skipped.
+func (n *net) indexBodies(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record) error {
+	for _, rec := range recs {
+		if isRestrictedRecord(rec) {
+			continue
+		}
+		ev, err := cbor.EventFromRecord(ctx, n.dagFor(tid), rec)
+		if err != nil {
+			return err
+		}
+		ids := append([]cid.Cid{ev.BodyID()}, util.LocalBodyChunks(n.bstore, ev.BodyID())...)
+		if err = n.bodies.Put(tid, lid, rec.Cid(), ids...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateBodyIndex indexes the bodies of records stored before bodies were indexed.
+func (n *net) migrateBodyIndex(tid thread.ID) error {
+	info, err := n.store.GetThread(tid)
+	if err != nil {
+		return err
+	} else if info.Key.Service() == nil {
+		return nil // records aren't readable
+	}
+	var (
+		visited = make(map[cid.Cid]struct{})
+		ierr    error
+	)
+	err = n.walkLogs(n.ctx, tid, visited, func(lid peer.ID, rid cid.Cid, ev *cbor.Event) {
+		visited[rid] = struct{}{}
+		ids := append([]cid.Cid{ev.BodyID()}, util.LocalBodyChunks(n.bstore, ev.BodyID())...)
+		if err := n.bodies.Put(tid, lid, rid, ids...); err != nil && ierr == nil {
+			ierr = err
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return ierr
+}
@@ -0,0 +1,24 @@
+package net
+
+import (
+	"context"
+
+	core "github.com/textileio/go-threads/core/net"
+)
+
+// CallQueueStatus returns the wait times of pulls scheduled from peers by their kind.
+func (n *net) CallQueueStatus(_ context.Context) (map[string]core.CallQueueStatus, error) {
+	stats := n.calls.Stats()
+	status := make(map[string]core.CallQueueStatus, len(stats))
+	for lane, s := range stats {
+		status[lane] = core.CallQueueStatus{
+			Waiting:   s.Waiting,
+			InFlight:  s.InFlight,
+			Spawned:   s.Spawned,
+			Overdue:   s.Overdue,
+			TotalWait: s.TotalWait,
+			MaxWait:   s.MaxWait,
+		}
+	}
+	return status, nil
+}
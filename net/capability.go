@@ -0,0 +1,261 @@
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// capabilityRootBytes is the byte length of capability root keys.
+const capabilityRootBytes = 32
+
+var (
+	capabilityRootPrefix    = ds.NewKey("/capability/root")
+	capabilityGrantedPrefix = ds.NewKey("/capability/granted")
+)
+
+// rights needed by the requests of the thread service
+const (
+	fetchRights   = thread.RightRead | thread.RightReplicate
+	pushRights    = thread.RightAppend | thread.RightReplicate
+	pushLogRights = thread.RightAppend
+)
+
+// capabilities keeps the root keys of the capabilities the host minted for its threads, and
+// the capabilities other hosts granted to the host, by thread.
+type capabilities struct {
+	mx    sync.Mutex
+	store ds.Datastore
+}
+
+func newCapabilities(store ds.Datastore) *capabilities {
+	return &capabilities{store: store}
+}
+
+// Root returns the root key of the thread capabilities, or false if none were minted.
+func (c *capabilities) Root(tid thread.ID) ([]byte, bool, error) {
+	root, err := c.store.Get(capabilityRootPrefix.ChildString(tid.String()))
+	if err == ds.ErrNotFound {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return root, true, nil
+}
+
+// RootOrCreate returns the root key of the thread capabilities, creating it if none were minted.
+func (c *capabilities) RootOrCreate(tid thread.ID) ([]byte, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if root, ok, err := c.Root(tid); err != nil || ok {
+		return root, err
+	}
+	return c.rotate(tid)
+}
+
+// Rotate replaces the root key of the thread capabilities, invalidating all minted ones.
+func (c *capabilities) Rotate(tid thread.ID) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	_, err := c.rotate(tid)
+	return err
+}
+
+func (c *capabilities) rotate(tid thread.ID) ([]byte, error) {
+	root := make([]byte, capabilityRootBytes)
+	if _, err := rand.Read(root); err != nil {
+		return nil, err
+	}
+	return root, c.store.Put(capabilityRootPrefix.ChildString(tid.String()), root)
+}
+
+// Grant keeps a capability granted for the thread, replacing a previous one.
+func (c *capabilities) Grant(tid thread.ID, capability thread.Capability) error {
+	return c.store.Put(capabilityGrantedPrefix.ChildString(tid.String()), []byte(capability))
+}
+
+// Granted returns the capability granted for the thread, or false if there is none.
+func (c *capabilities) Granted(tid thread.ID) (thread.Capability, bool, error) {
+	v, err := c.store.Get(capabilityGrantedPrefix.ChildString(tid.String()))
+	if err == ds.ErrNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return thread.Capability(v), true, nil
+}
+
+// PurgeThread removes the root key and the granted capability of the thread.
+func (c *capabilities) PurgeThread(tid thread.ID) error {
+	for _, prefix := range []ds.Key{capabilityRootPrefix, capabilityGrantedPrefix} {
+		if err := c.store.Delete(prefix.ChildString(tid.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *net) MintCapability(
+	_ context.Context,
+	id thread.ID,
+	caveats []thread.Caveat,
+	opts ...core.ThreadOption,
+) (thread.Capability, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return "", err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return "", err
+	}
+	root, err := n.capabilities.RootOrCreate(id)
+	if err != nil {
+		return "", err
+	}
+	return thread.NewCapability(root, id, caveats...)
+}
+
+func (n *net) AddCapability(_ context.Context, capability thread.Capability) error {
+	id, err := capability.Thread()
+	if err != nil {
+		return err
+	}
+	return n.capabilities.Grant(id, capability)
+}
+
+func (n *net) RevokeCapabilities(_ context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok, err := n.capabilities.Root(id); err != nil || !ok {
+		return err
+	}
+	return n.capabilities.Rotate(id)
+}
+
+// checkCapability checks the capability for the thread passed along a request needing any
+// of the rights. It returns whether the request was authorized by the capability.
+// Capabilities are sent to every peer of a thread, including replicas which didn't mint
+// them, so ones that can't be verified are ignored, and the request falls back to the
+// service key. It only fails if the thread requires a capability granting the rights.
+func (s *server) checkCapability(ctx context.Context, pid peer.ID, tid thread.ID, rights thread.CapabilityRights) (bool, error) {
+	root, minted, err := s.net.capabilities.Root(tid)
+	if err != nil {
+		return false, status.Error(codes.Internal, err.Error())
+	}
+	refused := status.Error(codes.Unauthenticated, "a capability is required")
+	for _, c := range thread.CapabilitiesFromContext(ctx) {
+		if !minted {
+			break
+		}
+		if ctid, err := c.Thread(); err != nil || !ctid.Equals(tid) {
+			continue
+		}
+		claims, err := c.Verify(root, pid, s.net.clock.Now())
+		if err != nil {
+			log.Debugf("ignoring capability of thread %s from %s: %v", tid, pid, err)
+			refused = status.Error(codes.Unauthenticated, err.Error())
+			continue
+		}
+		if !claims.Allows(rights) {
+			refused = status.Error(codes.PermissionDenied, "capability doesn't grant the request")
+			continue
+		}
+		return true, nil
+	}
+	if minted && s.net.requireCapabilities {
+		if info, ok := s.net.protocols.get(pid); ok && !info.Supports(core.FeatureCapabilityTokens) {
+			return false, status.Errorf(codes.FailedPrecondition, "a capability is required, but %s doesn't support %s", pid, core.FeatureCapabilityTokens)
+		}
+		return false, refused
+	}
+	return false, nil
+}
+
+// capabilityRequired returns whether peers need a capability to be served the thread.
+func (n *net) capabilityRequired(tid thread.ID) (bool, error) {
+	if !n.requireCapabilities {
+		return false, nil
+	}
+	_, minted, err := n.capabilities.Root(tid)
+	return minted, err
+}
+
+// capabilityClientInterceptor attaches the capabilities granted for the threads of requests.
+func (n *net) capabilityClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(n.capabilityContext(ctx, requestThreads(req)...), method, req, reply, cc, opts...)
+	}
+}
+
+// capabilityContext adds the capabilities granted for the threads to the metadata of outgoing requests.
+func (n *net) capabilityContext(ctx context.Context, tids ...thread.ID) context.Context {
+	for _, tid := range tids {
+		if c, ok, err := n.capabilities.Granted(tid); err != nil {
+			log.Errorf("getting capability of thread %s: %v", tid, err)
+		} else if ok {
+			ctx = thread.OutgoingCapabilityContext(ctx, c)
+		}
+	}
+	return ctx
+}
+
+// requestThreads returns the threads of a thread service request.
+func requestThreads(req interface{}) []thread.ID {
+	var ids []*pb.ProtoThreadID
+	switch r := req.(type) {
+	case *pb.GetLogsRequest:
+		if r.Body != nil {
+			ids = append(ids, r.Body.ThreadID)
+		}
+	case *pb.PushLogRequest:
+		if r.Body != nil {
+			ids = append(ids, r.Body.ThreadID)
+		}
+	case *pb.GetRecordsRequest:
+		if r.Body != nil {
+			ids = append(ids, r.Body.ThreadID)
+		}
+	case *pb.GetRecordBodiesRequest:
+		if r.Body != nil {
+			ids = append(ids, r.Body.ThreadID)
+		}
+	case *pb.PushRecordRequest:
+		if r.Body != nil {
+			ids = append(ids, r.Body.ThreadID)
+		}
+	case *pb.PushRecordsRequest:
+		if r.Body != nil {
+			ids = append(ids, r.Body.ThreadID)
+		}
+	case *pb.ExchangeEdgesRequest:
+		if r.Body != nil {
+			for _, e := range r.Body.Threads {
+				ids = append(ids, e.ThreadID)
+			}
+		}
+	}
+	tids := make([]thread.ID, 0, len(ids))
+	for _, id := range ids {
+		if id != nil {
+			tids = append(tids, id.ID)
+		}
+	}
+	return tids
+}
@@ -0,0 +1,44 @@
+package net
+
+import (
+	"context"
+
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+)
+
+// RecordDecrypter returns the scheme opening record headers of the thread, i.e., the read key
+// unless Config.RecordCipher is set. It's nil if the host can't read records of the thread.
+func (n *net) RecordDecrypter(ctx context.Context, id thread.ID) (crypto.Decrypter, error) {
+	return n.recordDecrypter(ctx, id)
+}
+
+// recordEncrypter returns the scheme sealing record headers of the thread, or nil if the
+// host can't create readable records.
+func (n *net) recordEncrypter(ctx context.Context, id thread.ID) (crypto.Encrypter, error) {
+	rk, err := n.store.ReadKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if n.cipher != nil {
+		return n.cipher.Encrypter(ctx, id, rk)
+	}
+	if rk == nil {
+		return nil, nil
+	}
+	return rk, nil
+}
+
+func (n *net) recordDecrypter(ctx context.Context, id thread.ID) (crypto.Decrypter, error) {
+	rk, err := n.store.ReadKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if n.cipher != nil {
+		return n.cipher.Decrypter(ctx, id, rk)
+	}
+	if rk == nil {
+		return nil, nil
+	}
+	return rk, nil
+}
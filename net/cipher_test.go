@@ -0,0 +1,105 @@
+package net
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+)
+
+// sharedKeyCipher seals record headers with a key shared out of band instead of the read key.
+type sharedKeyCipher struct {
+	key *sym.Key
+}
+
+func (c sharedKeyCipher) Encrypter(context.Context, thread.ID, *sym.Key) (crypto.Encrypter, error) {
+	return c.key, nil
+}
+
+func (c sharedKeyCipher) Decrypter(context.Context, thread.ID, *sym.Key) (crypto.Decrypter, error) {
+	return c.key, nil
+}
+
+func TestNet_RecordCipher(t *testing.T) {
+	t.Parallel()
+	cipher := sharedKeyCipher{key: sym.New()}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{RecordCipher: cipher}).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{RecordCipher: cipher}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	// the receiving host opens bodies with the cipher to run hooks
+	received := make(chan format.Node, 1)
+	n2.commitHooks = []core.CommitHook{
+		func(_ context.Context, _ thread.ID, body format.Node, _ thread.PubKey) error {
+			received <- body
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if !got.Cid().Equals(body.Cid()) {
+			t.Fatalf("expected body %s, got %s", body.Cid(), got.Cid())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("record wasn't received")
+	}
+
+	// headers are sealed by the cipher, not by the read key
+	block, err := n1.Get(ctx, r.Value().BlockID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.EventFromNode(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = event.GetHeader(ctx, n1, info.Key.Read()); err == nil {
+		t.Fatal("expected header not to open with the read key")
+	}
+	if event, err = cbor.EventFromNode(block); err != nil {
+		t.Fatal(err)
+	}
+	dec, err := n1.RecordDecrypter(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := event.GetBody(ctx, n1, dec); err != nil {
+		t.Fatal(err)
+	} else if !got.Cid().Equals(body.Cid()) {
+		t.Fatalf("expected body %s, got %s", body.Cid(), got.Cid())
+	}
+}
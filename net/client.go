@@ -11,7 +11,6 @@ import (
 	"github.com/gogo/status"
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
-	pstore "github.com/libp2p/go-libp2p-core/peerstore"
 	gostream "github.com/libp2p/go-libp2p-gostream"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/textileio/go-threads/cbor"
@@ -22,7 +21,6 @@ import (
 	pb "github.com/textileio/go-threads/net/pb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/connectivity"
 )
 
 var (
@@ -30,55 +28,120 @@ var (
 	DialTimeout = time.Second * 10
 	PushTimeout = time.Second * 10
 	PullTimeout = time.Second * 10
+
+	// LogsPageSize is the number of logs requested from a peer at once.
+	LogsPageSize = 100
 )
 
-// getLogs in a thread.
-func (s *server) getLogs(ctx context.Context, id thread.ID, pid peer.ID) ([]thread.LogInfo, error) {
+// getLogs in a thread page by page, along with the thread flags known to the peer and their signature.
+// Pages are passed to handle as they arrive, so threads with many logs aren't held in memory at once.
+// The thread metadata known to the peer is merged once all logs are handled, since it's signed by one of them.
+func (s *server) getLogs(
+	ctx context.Context,
+	id thread.ID,
+	pid peer.ID,
+	handle func(lgs []peerLog, flags thread.Flags, sig *signedFlags) error,
+) error {
 	sk, err := s.net.store.ServiceKey(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if sk == nil {
-		return nil, fmt.Errorf("a service-key is required to request logs")
-	}
-
-	body := &pb.GetLogsRequest_Body{
-		ThreadID:   &pb.ProtoThreadID{ID: id},
-		ServiceKey: &pb.ProtoKey{Key: sk},
-	}
-	req := &pb.GetLogsRequest{
-		Body: body,
+		return fmt.Errorf("a service-key is required to request logs")
 	}
 
 	log.Debugf("getting %s logs from %s...", id, pid)
 
-	client, err := s.dial(pid)
-	if err != nil {
-		return nil, err
-	}
-	cctx, cancel := context.WithTimeout(ctx, PullTimeout)
-	defer cancel()
-	reply, err := client.GetLogs(cctx, req)
+	client, release, err := s.dial(pid)
 	if err != nil {
-		log.Warnf("get logs from %s failed: %s", pid, err)
-		return nil, err
+		return err
 	}
+	defer release()
+	var (
+		after *pb.ProtoPeerID
+		md    []byte
+	)
+	for {
+		req := &pb.GetLogsRequest{
+			Body: &pb.GetLogsRequest_Body{
+				ThreadID:   &pb.ProtoThreadID{ID: id},
+				ServiceKey: &pb.ProtoKey{Key: sk},
+				After:      after,
+				Limit:      int32(LogsPageSize),
+			},
+		}
+		cctx, cancel := context.WithTimeout(ctx, PullTimeout)
+		reply, err := client.GetLogs(cctx, req)
+		cancel()
+		if err != nil {
+			log.Warnf("get logs from %s failed: %s", pid, err)
+			return err
+		}
 
-	log.Debugf("received %d logs from %s", len(reply.Logs), pid)
+		log.Debugf("received %d logs from %s", len(reply.Logs), pid)
 
-	lgs := make([]thread.LogInfo, len(reply.Logs))
-	for i, l := range reply.Logs {
-		lgs[i] = logFromProto(l)
+		lgs := make([]peerLog, len(reply.Logs))
+		for i, l := range reply.Logs {
+			lgs[i] = peerLogFromProto(l)
+		}
+		flags, err := thread.NewFlags(reply.Flags...)
+		if err != nil {
+			return fmt.Errorf("bad flags from %s: %w", pid, err)
+		}
+		var sig *signedFlags
+		if len(reply.FlagsSig) != 0 {
+			sig = &signedFlags{Signer: reply.FlagsSigner, Sig: reply.FlagsSig}
+		}
+		if err = handle(lgs, flags, sig); err != nil {
+			return err
+		}
+		if len(reply.Metadata) != 0 {
+			md = reply.Metadata
+		}
+		// peers without paging return all logs at once
+		if reply.Next == nil || len(reply.Logs) == 0 {
+			if md == nil {
+				return nil
+			}
+			if err = s.net.mergeThreadMetadata(id, md); err != nil {
+				return fmt.Errorf("bad metadata from %s: %w", pid, err)
+			}
+			return nil
+		}
+		after = reply.Next
 	}
-
-	return lgs, nil
 }
 
 // pushLog to a peer.
 func (s *server) pushLog(ctx context.Context, id thread.ID, lg thread.LogInfo, pid peer.ID, sk *sym.Key, rk *sym.Key) error {
+	pblg, err := s.net.signedLogToProto(ctx, id, lg)
+	if err != nil {
+		return err
+	}
+	pblg = s.net.downgradeLog(pid, pblg)
+	md, err := s.net.threadMetadataBytes(id)
+	if err != nil {
+		return err
+	}
+	rot, err := s.net.keyRotationBytes(id)
+	if err != nil {
+		return err
+	}
+	flags, err := s.net.threadFlags(id)
+	if err != nil {
+		return err
+	}
 	body := &pb.PushLogRequest_Body{
-		ThreadID: &pb.ProtoThreadID{ID: id},
-		Log:      logToProto(lg),
+		ThreadID:    &pb.ProtoThreadID{ID: id},
+		Log:         pblg,
+		Metadata:    md,
+		KeyRotation: rot,
+		Flags:       flags,
+	}
+	if sig, err := s.net.threadFlagsSig(id); err != nil {
+		return err
+	} else if sig != nil {
+		body.FlagsSigner, body.FlagsSig = sig.Signer, sig.Sig
 	}
 	if sk != nil {
 		body.ServiceKey = &pb.ProtoKey{Key: sk}
@@ -92,10 +155,11 @@ func (s *server) pushLog(ctx context.Context, id thread.ID, lg thread.LogInfo, p
 
 	log.Debugf("pushing log %s to %s...", lg.ID, pid)
 
-	client, err := s.dial(pid)
+	client, release, err := s.dial(pid)
 	if err != nil {
 		return fmt.Errorf("dial %s failed: %w", pid, err)
 	}
+	defer release()
 	cctx, cancel := context.WithTimeout(ctx, PushTimeout)
 	defer cancel()
 	_, err = client.PushLog(cctx, lreq)
@@ -105,8 +169,10 @@ func (s *server) pushLog(ctx context.Context, id thread.ID, lg thread.LogInfo, p
 	return err
 }
 
-// getRecords from specified peers.
+// getRecords from specified peers, at most Config.Sync.MaxConcurrentPulls of them at once.
+// Responses of the peers overlap, records are deduplicated by their CIDs.
 func (s *server) getRecords(
+	ctx context.Context,
 	peers []peer.ID,
 	tid thread.ID,
 	offsets map[peer.ID]cid.Cid,
@@ -118,17 +184,31 @@ func (s *server) getRecords(
 	}
 
 	var (
-		rc = newRecordCollector()
-		wg sync.WaitGroup
+		rc = newRecordCollector(func(rid cid.Cid) bool {
+			known, err := s.net.isKnown(rid)
+			return err == nil && known
+		})
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, s.net.syncConfig().MaxConcurrentPulls)
 	)
 
 	// Pull from every peer
 	for _, p := range peers {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 		wg.Add(1)
 
 		go withErrLog(p, func(pid peer.ID) error {
-			defer wg.Done()
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
 
+			// the queue's context bounds the call, it outlives the caller once scheduled;
+			// the caller stops starting pulls and returns when its context is done
 			return s.net.queueGetRecords.Call(pid, tid, func(ctx context.Context, pid peer.ID, tid thread.ID) error {
 				recs, err := s.getRecordsFromPeer(ctx, tid, pid, req, sk)
 				if err != nil {
@@ -143,7 +223,17 @@ func (s *server) getRecords(
 			})
 		})
 	}
-	wg.Wait()
+	// pulls already scheduled drain into the collector in the background
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
 	return rc.List()
 }
@@ -164,17 +254,27 @@ func (s *server) buildGetRecordsRequest(
 
 	var pblgs = make([]*pb.GetRecordsRequest_Body_LogEntry, 0, len(offsets))
 	for lid, offset := range offsets {
-		pblgs = append(pblgs, &pb.GetRecordsRequest_Body_LogEntry{
+		entry := &pb.GetRecordsRequest_Body_LogEntry{
 			LogID:  &pb.ProtoPeerID{ID: lid},
 			Offset: &pb.ProtoCid{Cid: offset},
 			Limit:  int32(limit),
-		})
+		}
+		if offset.Defined() {
+			// let the recipient skip records of the other branches we already have
+			heads, err := s.net.knownHeads(tid, lid)
+			if err != nil {
+				return nil, nil, fmt.Errorf("getting heads of log %s: %w", lid, err)
+			}
+			entry.Heads = headsToProto(heads)
+		}
+		pblgs = append(pblgs, entry)
 	}
 
 	body := &pb.GetRecordsRequest_Body{
-		ThreadID:   &pb.ProtoThreadID{ID: tid},
-		ServiceKey: &pb.ProtoKey{Key: serviceKey},
-		Logs:       pblgs,
+		ThreadID:    &pb.ProtoThreadID{ID: tid},
+		ServiceKey:  &pb.ProtoKey{Key: serviceKey},
+		Logs:        pblgs,
+		HeadersOnly: s.net.headerSync,
 	}
 
 	req = &pb.GetRecordsRequest{
@@ -192,17 +292,31 @@ func (s *server) getRecordsFromPeer(
 	serviceKey *sym.Key,
 ) (map[peer.ID][]core.Record, error) {
 	log.Debugf("getting records from %s...", pid)
-	client, err := s.dial(pid)
+	recs := make(map[peer.ID][]core.Record)
+	if s.isThrottled(pid) {
+		log.Debugf("skipping records from %s: asked to slow down", pid)
+		return recs, nil
+	}
+	if s.net.reputation.banned(pid) {
+		log.Debugf("skipping records from %s: banned", pid)
+		return recs, nil
+	}
+	client, release, err := s.dial(pid)
 	if err != nil {
-		return nil, fmt.Errorf("dial %s failed: %w", pid, err)
+		err = fmt.Errorf("dial %s failed: %w", pid, err)
+		s.net.reputation.called(pid, callPull, 0, err)
+		return nil, err
 	}
+	defer release()
 
-	recs := make(map[peer.ID][]core.Record)
 	cctx, cancel := context.WithTimeout(ctx, PullTimeout)
 	defer cancel()
+	start := s.net.clock.Now()
 	reply, err := client.GetRecords(cctx, req)
+	s.net.reputation.called(pid, callPull, s.net.clock.Now().Sub(start), err)
 	if err != nil {
 		log.Warnf("get records from %s failed: %s", pid, err)
+		s.throttle(pid, err)
 		return recs, nil
 	}
 
@@ -210,12 +324,6 @@ func (s *server) getRecordsFromPeer(
 		var logID = l.LogID.ID
 		log.Debugf("received %d records in log %s from %s", len(l.Records), logID, pid)
 
-		if l.Log != nil && len(l.Log.Addrs) > 0 {
-			if err = s.net.store.AddAddrs(tid, logID, addrsFromProto(l.Log.Addrs), pstore.PermanentAddrTTL); err != nil {
-				return nil, err
-			}
-		}
-
 		pk, err := s.net.store.PubKey(tid, logID)
 		if err != nil {
 			return nil, err
@@ -226,46 +334,133 @@ func (s *server) getRecordsFromPeer(
 				// cannot verify received records
 				continue
 			}
+			if err := s.net.checkTrustedLogKey(tid, peerLogFromProto(l.Log)); err != nil {
+				log.Warnf("skipping records in log %s from %s: %v", logID, pid, err)
+				continue
+			}
 			if err := s.net.store.AddPubKey(tid, logID, l.Log.PubKey); err != nil {
 				return nil, err
 			}
 			pk = l.Log.PubKey
 		}
 
+		if l.Log != nil && len(l.Log.Addrs) > 0 {
+			if err = s.net.putLogAddrs(tid, peerLog{
+				LogInfo:  thread.LogInfo{ID: logID, PubKey: pk, Addrs: addrsFromProto(l.Log.Addrs)},
+				addrsSeq: l.Log.AddrsSeq,
+				addrsSig: l.Log.AddrsSig,
+			}); errors.Is(err, ErrInvalidAddrsSig) {
+				log.Warnf("ignoring addresses of log %s from %s: %v", logID, pid, err)
+			} else if err != nil {
+				return nil, err
+			}
+		}
+
+		var (
+			prs   = make([]*pb.Log_Record, 0, len(l.Records))
+			lrecs = make([]core.Record, 0, len(l.Records))
+		)
 		for _, r := range l.Records {
+			if err = s.net.checkProtoRecordSize(r); err != nil {
+				// the rest of the log can't be linked without this record
+				log.Warnf("skipping records in log %s from %s: %v", logID, pid, err)
+				s.net.reputation.misbehaved(pid, err)
+				break
+			}
 			rec, err := cbor.RecordFromProto(r, serviceKey)
 			if err != nil {
 				return nil, err
 			}
-			if err = rec.Verify(pk); err != nil {
-				return nil, err
+			prs = append(prs, r)
+			lrecs = append(lrecs, rec)
+		}
+		if err = s.net.verifyRecords(ctx, lrecs, pk); err != nil {
+			s.net.emitRejected(tid, logID, pid, cid.Undef, err)
+			s.net.reputation.misbehaved(pid, err)
+			return nil, err
+		}
+		for i, rec := range lrecs {
+			if req.Body.HeadersOnly {
+				// don't download bodies of records which would be rejected anyway
+				if err = s.net.runAcceptHooks(ctx, tid, logID, rec); err != nil {
+					s.net.emitRejected(tid, logID, pid, rec.Cid(), err)
+					log.Warnf("skipping records in log %s from %s: %v", logID, pid, err)
+					prs, lrecs = prs[:i], lrecs[:i]
+					break
+				}
+			}
+			if l.Boundary != nil && rec.Cid().Equals(l.Boundary.Cid) {
+				// peer has compacted the log, older records can't be fetched
+				if err = s.net.adoptBoundary(tid, logID, rec); err != nil {
+					return nil, err
+				}
 			}
-			recs[logID] = append(recs[logID], rec)
+		}
+		if req.Body.HeadersOnly {
+			if lrecs, err = s.loadRecordBodies(cctx, client, tid, serviceKey, prs, lrecs); err != nil {
+				log.Warnf("get record bodies from %s failed: %s", pid, err)
+				continue
+			}
+		}
+		if lrecs, err = s.loadBodyChunks(cctx, pid, tid, logID, serviceKey, lrecs); errors.Is(err, ErrRecordTooLarge) {
+			log.Warnf("skipping records in log %s from %s: %v", logID, pid, err)
+			s.net.reputation.misbehaved(pid, err)
+		} else if isQuotaExceeded(err) {
+			log.Warnf("skipping records in log %s from %s: %v", logID, pid, err)
+		} else if err != nil {
+			log.Warnf("get body chunks from %s failed: %s", pid, err)
+			continue
+		}
+		if len(lrecs) > 0 {
+			recs[logID] = append(recs[logID], lrecs...)
 		}
 	}
 
 	return recs, nil
 }
 
-// pushRecord to log addresses and thread topic.
-func (s *server) pushRecord(ctx context.Context, tid thread.ID, lid peer.ID, rec core.Record) error {
-	// Collect known writers
+// threadPeers returns the known writers of a thread.
+func (s *server) threadPeers(tid thread.ID) ([]peer.ID, error) {
 	addrs := make([]ma.Multiaddr, 0)
 	info, err := s.net.store.GetThread(tid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, l := range info.Logs {
 		addrs = append(addrs, l.Addrs...)
 	}
 	peers, err := s.net.uniquePeers(addrs)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return s.net.withoutRemovedReplicators(tid, peers)
+}
 
-	pbrec, err := cbor.RecordToProto(ctx, s.net, rec)
+// pushRecord to log addresses and thread topic.
+func (s *server) pushRecord(ctx context.Context, tid thread.ID, lid peer.ID, rec core.Record) error {
+	_, err := s.pushRecordAcked(ctx, tid, lid, rec)
+	return err
+}
+
+// peerAck is the outcome of pushing a record to a peer.
+type peerAck struct {
+	pid peer.ID
+	err error
+}
+
+// pushRecordAcked pushes a record like pushRecord, and returns a channel receiving
+// the outcome of the push to every peer. The channel is closed once all pushes finish.
+func (s *server) pushRecordAcked(ctx context.Context, tid thread.ID, lid peer.ID, rec core.Record) (<-chan peerAck, error) {
+	// Collect known writers
+	peers, err := s.threadPeers(tid)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	peers = s.net.topology.prefer(peers)
+
+	pbrec, err := s.net.recordToProto(ctx, tid, rec)
+	if err != nil {
+		return nil, err
 	}
 	body := &pb.PushRecordRequest_Body{
 		ThreadID: &pb.ProtoThreadID{ID: tid},
@@ -275,24 +470,46 @@ func (s *server) pushRecord(ctx context.Context, tid thread.ID, lid peer.ID, rec
 	req := &pb.PushRecordRequest{
 		Body: body,
 	}
+	s.net.onBroadcast(ctx, NewRecord(rec, tid, lid), peers)
 
-	// Push to each address
+	// Push to each address, failed deliveries are queued for a retry
+	var (
+		acks = make(chan peerAck, len(peers))
+		wg   sync.WaitGroup
+	)
 	for _, p := range peers {
+		wg.Add(1)
 		go func(pid peer.ID) {
+			defer wg.Done()
 			if err := s.pushRecordToPeer(req, pid, tid, lid); err != nil {
-				log.Errorf("pushing record to %s (thread: %s, log: %s) failed: %v", pid, tid, lid, err)
+				log.Debugf("pushing record to %s (thread: %s, log: %s) failed, queueing for redelivery: %v", pid, tid, lid, err)
+				s.net.emit(core.LifecycleEvent{Type: core.PushFailed, ThreadID: tid, LogID: lid, PeerID: pid, RecordID: rec.Cid(), Err: err})
+				if err := s.net.deliveries.Add(pid, tid, lid, rec.Cid(), err); err != nil {
+					log.Errorf("queueing record %s for %s failed: %v", rec.Cid(), pid, err)
+				}
+				acks <- peerAck{pid: pid, err: err}
+				return
 			}
+			s.net.deliveries.Delivered(pid)
+			if err := s.net.acks.Ack(tid, rec.Cid(), pid); err != nil {
+				log.Errorf("recording ack of record %s by %s failed: %v", rec.Cid(), pid, err)
+			}
+			acks <- peerAck{pid: pid}
 		}(p)
 	}
+	go func() {
+		wg.Wait()
+		close(acks)
+	}()
 
-	// Finally, publish to the thread's topic
-	if s.ps != nil {
+	// Finally, publish to the thread's topic, restricted records are pushed directly only
+	if s.ps != nil && !s.net.isRestricted(tid, lid, rec.Cid()) {
 		if err = s.ps.Publish(ctx, tid, req); err != nil {
 			log.Errorf("error publishing record: %s", err)
 		}
 	}
 
-	return nil
+	return acks, nil
 }
 
 func (s *server) pushRecordToPeer(
@@ -301,10 +518,24 @@ func (s *server) pushRecordToPeer(
 	tid thread.ID,
 	lid peer.ID,
 ) error {
-	client, err := s.dial(pid)
+	if s.isThrottled(pid) {
+		return fmt.Errorf("%s asked to slow down", pid)
+	}
+	client, release, err := s.dial(pid)
 	if err != nil {
 		return fmt.Errorf("dial failed: %w", err)
 	}
+	defer release()
+	// bodies withheld by the thread ACL are stripped for the peer
+	restricted := *req.Body
+	restricted.Record = s.net.restrictRecords(tid, lid, pid, []*pb.Log_Record{req.Body.Record})[0]
+	req = &pb.PushRecordRequest{Body: &restricted}
+	if version := s.net.peerEnvelopeVersion(pid); req.Body.Record.Version > version {
+		// peer doesn't support the record envelope, push the downgraded one
+		body := *req.Body
+		body.Record = cbor.DowngradeRecord(body.Record, version)
+		req = &pb.PushRecordRequest{Body: &body}
+	}
 	rctx, cancel := context.WithTimeout(context.Background(), PushTimeout)
 	defer cancel()
 	_, err = client.PushRecord(rctx, req)
@@ -314,37 +545,304 @@ func (s *server) pushRecordToPeer(
 
 	switch status.Convert(err).Code() {
 	case codes.Unavailable:
-		log.Debugf("%s unavailable, skip pushing the record", pid)
-		return nil
+		return fmt.Errorf("%s unavailable: %w", pid, err)
+
+	case codes.ResourceExhausted:
+		s.throttle(pid, err)
+		return fmt.Errorf("%s rate limited: %w", pid, err)
 
 	case codes.NotFound:
 		// send the missing log
-		lctx, cancel := context.WithTimeout(s.net.ctx, PushTimeout)
-		defer cancel()
-		lg, err := s.net.store.GetLog(tid, lid)
-		if err != nil {
-			return fmt.Errorf("getting log information: %w", err)
+		if err = s.pushMissingLog(client, pid, tid, lid); err != nil || !s.net.embedded {
+			return err
 		}
-		body := &pb.PushLogRequest_Body{
+		// peers can't pull from an embedded host, so the record is pushed again
+		rctx, cancel := context.WithTimeout(context.Background(), PushTimeout)
+		defer cancel()
+		_, err = client.PushRecord(rctx, req)
+		return err
+
+	default:
+		return err
+	}
+}
+
+// pushMissingLog sends log information to a peer that rejected records of an unknown log.
+func (s *server) pushMissingLog(client pb.ServiceClient, pid peer.ID, tid thread.ID, lid peer.ID) error {
+	lctx, cancel := context.WithTimeout(s.net.ctx, PushTimeout)
+	defer cancel()
+	lg, err := s.net.store.GetLog(tid, lid)
+	if err != nil {
+		return fmt.Errorf("getting log information: %w", err)
+	}
+	pblg, err := s.net.signedLogToProto(lctx, tid, lg)
+	if err != nil {
+		return err
+	}
+	pblg = s.net.downgradeLog(pid, pblg)
+	body := &pb.PushLogRequest_Body{
+		ThreadID: &pb.ProtoThreadID{ID: tid},
+		Log:      pblg,
+	}
+	lreq := &pb.PushLogRequest{
+		Body: body,
+	}
+	if _, err = client.PushLog(lctx, lreq); err != nil {
+		return fmt.Errorf("pushing missing log: %w", err)
+	}
+	return nil
+}
+
+// redeemInvite returns the key bundle of a single-use invite from the inviter.
+func (s *server) redeemInvite(ctx context.Context, pid peer.ID, tid thread.ID, nonce []byte) ([]byte, error) {
+	client, release, err := s.dial(pid)
+	if err != nil {
+		return nil, fmt.Errorf("dial failed: %w", err)
+	}
+	defer release()
+	rctx, cancel := context.WithTimeout(ctx, PullTimeout)
+	defer cancel()
+	reply, err := client.RedeemInvite(rctx, &pb.RedeemInviteRequest{
+		Body: &pb.RedeemInviteRequest_Body{
 			ThreadID: &pb.ProtoThreadID{ID: tid},
-			Log:      logToProto(lg),
+			Nonce:    nonce,
+		},
+	})
+	switch status.Convert(err).Code() {
+	case codes.OK:
+		return reply.Bundle, nil
+	case codes.NotFound:
+		return nil, core.ErrInviteRedeemed
+	case codes.FailedPrecondition:
+		return nil, core.ErrInviteExpired
+	default:
+		return nil, fmt.Errorf("redeeming invite: %w", err)
+	}
+}
+
+// pushRecords to log addresses as a single batch, and to the thread topic one by one.
+// Records must be a chain in the log, oldest first.
+func (s *server) pushRecords(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record) error {
+	push, err := s.preparePushRecords(ctx, tid, lid, recs)
+	if err != nil {
+		return err
+	}
+	s.sendPushRecords(ctx, push)
+	return nil
+}
+
+// recordsPush is a batch of records prepared for pushing to the thread peers.
+type recordsPush struct {
+	tid    thread.ID
+	lid    peer.ID
+	recs   []core.Record
+	pbrecs []*pb.Log_Record
+	peers  []peer.ID
+}
+
+// preparePushRecords resolves the thread peers and encodes the records, so sending
+// them can't fail afterwards.
+func (s *server) preparePushRecords(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record) (*recordsPush, error) {
+	peers, err := s.threadPeers(tid)
+	if err != nil {
+		return nil, err
+	}
+	push := &recordsPush{
+		tid:    tid,
+		lid:    lid,
+		recs:   recs,
+		pbrecs: make([]*pb.Log_Record, len(recs)),
+		peers:  s.net.topology.prefer(peers),
+	}
+	for i, rec := range recs {
+		if push.pbrecs[i], err = s.net.recordToProto(ctx, tid, rec); err != nil {
+			return nil, err
 		}
-		lreq := &pb.PushLogRequest{
-			Body: body,
+	}
+	return push, nil
+}
+
+// sendPushRecords pushes a prepared batch in the background. Failed deliveries are queued
+// for a retry record by record, so it doesn't return an error.
+func (s *server) sendPushRecords(ctx context.Context, push *recordsPush) {
+	var (
+		tid, lid, recs = push.tid, push.lid, push.recs
+		req            = &pb.PushRecordsRequest{
+			Body: &pb.PushRecordsRequest_Body{
+				ThreadID: &pb.ProtoThreadID{ID: tid},
+				LogID:    &pb.ProtoPeerID{ID: lid},
+				Records:  push.pbrecs,
+			},
+		}
+	)
+	for _, rec := range recs {
+		s.net.onBroadcast(ctx, NewRecord(rec, tid, lid), push.peers)
+	}
+
+	// Push to each address, failed deliveries are queued for a retry record by record
+	for _, p := range push.peers {
+		go func(pid peer.ID) {
+			if err := s.pushRecordsToPeer(req, pid, tid, lid); err != nil {
+				log.Debugf("pushing %d records to %s (thread: %s, log: %s) failed, queueing for redelivery: %v", len(recs), pid, tid, lid, err)
+				s.net.emit(core.LifecycleEvent{Type: core.PushFailed, ThreadID: tid, LogID: lid, PeerID: pid, RecordID: recs[len(recs)-1].Cid(), Err: err})
+				for _, rec := range recs {
+					if err := s.net.deliveries.Add(pid, tid, lid, rec.Cid(), err); err != nil {
+						log.Errorf("queueing record %s for %s failed: %v", rec.Cid(), pid, err)
+					}
+				}
+				return
+			}
+			s.net.deliveries.Delivered(pid)
+			for _, rec := range recs {
+				if err := s.net.acks.Ack(tid, rec.Cid(), pid); err != nil {
+					log.Errorf("recording ack of record %s by %s failed: %v", rec.Cid(), pid, err)
+				}
+			}
+		}(p)
+	}
+
+	// Finally, publish to the thread's topic, restricted records are pushed directly only
+	if s.ps != nil {
+		for i, pbrec := range push.pbrecs {
+			if s.net.isRestricted(tid, lid, recs[i].Cid()) {
+				continue
+			}
+			preq := &pb.PushRecordRequest{
+				Body: &pb.PushRecordRequest_Body{
+					ThreadID: &pb.ProtoThreadID{ID: tid},
+					LogID:    &pb.ProtoPeerID{ID: lid},
+					Record:   pbrec,
+				},
+			}
+			if err := s.ps.Publish(ctx, tid, preq); err != nil {
+				log.Errorf("error publishing record: %s", err)
+			}
+		}
+	}
+}
+
+func (s *server) pushRecordsToPeer(
+	req *pb.PushRecordsRequest,
+	pid peer.ID,
+	tid thread.ID,
+	lid peer.ID,
+) error {
+	if s.isThrottled(pid) {
+		return fmt.Errorf("%s asked to slow down", pid)
+	}
+	client, release, err := s.dial(pid)
+	if err != nil {
+		return fmt.Errorf("dial failed: %w", err)
+	}
+	defer release()
+	// bodies withheld by the thread ACL are stripped for the peer
+	restricted := *req.Body
+	restricted.Records = s.net.restrictRecords(tid, lid, pid, req.Body.Records)
+	req = &pb.PushRecordsRequest{Body: &restricted}
+	if version := s.net.peerEnvelopeVersion(pid); hasNewerRecord(req.Body.Records, version) {
+		// peer doesn't support the record envelope, push the downgraded ones
+		body := *req.Body
+		body.Records = make([]*pb.Log_Record, len(req.Body.Records))
+		for i, rec := range req.Body.Records {
+			body.Records[i] = cbor.DowngradeRecord(rec, version)
 		}
-		if _, err = client.PushLog(lctx, lreq); err != nil {
-			return fmt.Errorf("pushing missing log: %w", err)
+		req = &pb.PushRecordsRequest{Body: &body}
+	}
+	rctx, cancel := context.WithTimeout(context.Background(), PushTimeout)
+	defer cancel()
+	_, err = client.PushRecords(rctx, req)
+	if err == nil {
+		return nil
+	}
+
+	switch status.Convert(err).Code() {
+	case codes.Unimplemented:
+		// peer doesn't support batches, push the records one by one
+		for _, rec := range req.Body.Records {
+			rreq := &pb.PushRecordRequest{
+				Body: &pb.PushRecordRequest_Body{
+					ThreadID: req.Body.ThreadID,
+					LogID:    req.Body.LogID,
+					Record:   rec,
+				},
+			}
+			if err := s.pushRecordToPeer(rreq, pid, tid, lid); err != nil {
+				return err
+			}
 		}
 		return nil
 
+	case codes.Unavailable:
+		return fmt.Errorf("%s unavailable: %w", pid, err)
+
+	case codes.ResourceExhausted:
+		s.throttle(pid, err)
+		return fmt.Errorf("%s rate limited: %w", pid, err)
+
+	case codes.NotFound:
+		// send the missing log, records will be pulled by the peer
+		if err = s.pushMissingLog(client, pid, tid, lid); err != nil || !s.net.embedded {
+			return err
+		}
+		// unless the host is embedded, then they are pushed again
+		rctx, cancel := context.WithTimeout(context.Background(), PushTimeout)
+		defer cancel()
+		_, err = client.PushRecords(rctx, req)
+		return err
+
 	default:
 		return err
 	}
 }
 
-// exchangeEdges of specified threads with a peer.
-func (s *server) exchangeEdges(ctx context.Context, pid peer.ID, tids []thread.ID) error {
+func hasNewerRecord(recs []*pb.Log_Record, version int32) bool {
+	for _, rec := range recs {
+		if rec.Version > version {
+			return true
+		}
+	}
+	return false
+}
+
+// redeliverRecord pushes a locally stored record to a peer after a failed delivery.
+func (s *server) redeliverRecord(ctx context.Context, pid peer.ID, tid thread.ID, lid peer.ID, rid cid.Cid) error {
+	sk, err := s.net.store.ServiceKey(tid)
+	if err != nil {
+		return err
+	}
+	if sk == nil {
+		// thread was deleted, nothing to deliver
+		return nil
+	}
+	rec, err := s.net.getRecord(ctx, tid, rid)
+	if err != nil {
+		return fmt.Errorf("getting record: %w", err)
+	}
+	pbrec, err := cbor.RecordToProto(ctx, s.net, rec)
+	if err != nil {
+		return err
+	}
+	req := &pb.PushRecordRequest{
+		Body: &pb.PushRecordRequest_Body{
+			ThreadID: &pb.ProtoThreadID{ID: tid},
+			LogID:    &pb.ProtoPeerID{ID: lid},
+			Record:   pbrec,
+		},
+	}
+	if err = s.pushRecordToPeer(req, pid, tid, lid); err != nil {
+		return err
+	}
+	return s.net.acks.Ack(tid, rid, pid)
+}
+
+// exchangeEdges of specified threads with a peer. It returns the threads whose records
+// diverged from the peer ones, with pulls from the peer scheduled.
+func (s *server) exchangeEdges(ctx context.Context, pid peer.ID, tids []thread.ID) ([]thread.ID, error) {
 	log.Debugf("exchanging edges of %d threads with %s...", len(tids), pid)
+	if s.net.reputation.banned(pid) {
+		log.Debugf("skipping edge exchange with %s: banned", pid)
+		return nil, nil
+	}
 	var body = &pb.ExchangeEdgesRequest_Body{}
 
 	// fill local edges
@@ -356,13 +854,14 @@ func (s *server) exchangeEdges(ctx context.Context, pid peer.ID, tids []thread.I
 				ThreadID:    &pb.ProtoThreadID{ID: tid},
 				HeadsEdge:   headsEdge,
 				AddressEdge: addrsEdge,
+				LogSeqs:     s.net.localLogSeqs(tid),
 			})
 		default:
 			log.Errorf("getting local edges for %s failed: %v", tid, err)
 		}
 	}
 	if len(body.Threads) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	req := &pb.ExchangeEdgesRequest{
@@ -370,14 +869,27 @@ func (s *server) exchangeEdges(ctx context.Context, pid peer.ID, tids []thread.I
 	}
 
 	// send request
-	client, err := s.dial(pid)
+	client, release, err := s.dial(pid)
 	if err != nil {
-		return fmt.Errorf("dial %s failed: %w", pid, err)
+		err = fmt.Errorf("dial %s failed: %w", pid, err)
+		s.net.reputation.called(pid, callExchange, 0, err)
+		for _, tid := range tids {
+			s.net.trackExchange(tid, false, err)
+		}
+		return nil, err
 	}
+	defer release()
 	cctx, cancel := context.WithTimeout(ctx, PullTimeout)
 	defer cancel()
+	start := s.net.clock.Now()
 	reply, err := client.ExchangeEdges(cctx, req)
+	if status.Code(err) != codes.Unimplemented {
+		s.net.reputation.called(pid, callExchange, s.net.clock.Now().Sub(start), err)
+	}
 	if err != nil {
+		for _, tid := range tids {
+			s.net.trackExchange(tid, false, err)
+		}
 		if st, ok := status.FromError(err); ok {
 			switch st.Code() {
 			case codes.Unimplemented:
@@ -387,15 +899,17 @@ func (s *server) exchangeEdges(ctx context.Context, pid peer.ID, tids []thread.I
 						log.Debugf("record update for thread %s from %s scheduled", tid, pid)
 					}
 				}
-				return nil
+				return tids, nil
 			case codes.Unavailable:
 				log.Debugf("%s unavailable, skip edge exchange", pid)
-				return nil
+				return nil, nil
 			}
 		}
-		return err
+		return nil, err
 	}
 
+	var diverged []thread.ID
+
 	for _, e := range reply.GetEdges() {
 		tid := e.ThreadID.ID
 
@@ -418,39 +932,29 @@ func (s *server) exchangeEdges(ctx context.Context, pid peer.ID, tids []thread.I
 		}
 
 		responseEdge = e.GetHeadsEdge()
+		s.net.trackExchange(tid, responseEdge == headsEdgeLocal, nil)
 		// We only update the records if we got non empty values and different hashes for heads
 		if responseEdge != lstoreds.EmptyEdgeValue && responseEdge != headsEdgeLocal {
-			if s.net.queueGetRecords.Schedule(pid, tid, callPriorityLow, s.net.updateRecordsFromPeer) {
+			diverged = append(diverged, tid)
+			if s.net.scheduleRecordsUpdate(pid, tid, e.LogSeqs) {
 				log.Debugf("record update for thread %s from %s scheduled", tid, pid)
 			}
 		}
 	}
 
-	return nil
+	return diverged, nil
 }
 
-// dial attempts to open a gRPC connection over libp2p to a peer.
-func (s *server) dial(peerID peer.ID) (pb.ServiceClient, error) {
-	s.Lock()
-	defer s.Unlock()
-	conn, ok := s.conns[peerID]
-	if ok {
-		if conn.GetState() == connectivity.Shutdown {
-			if err := conn.Close(); err != nil {
-				log.Errorf("error closing connection: %v", err)
-			}
-		} else {
-			return pb.NewServiceClient(conn), nil
-		}
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), DialTimeout)
-	defer cancel()
-	conn, err := grpc.DialContext(ctx, peerID.Pretty(), s.opts...)
+// dial attempts to open a gRPC connection over libp2p to a peer. Connections are kept
+// in the pool, see ConnPoolConfig. The returned function must be called once the calls
+// are made, the connection isn't closed before.
+func (s *server) dial(peerID peer.ID) (pb.ServiceClient, func(), error) {
+	conn, release, err := s.conns.get(peerID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	s.conns[peerID] = conn
-	return pb.NewServiceClient(conn), nil
+	s.net.negotiateInBackground(peerID)
+	return pb.NewServiceClient(conn), release, nil
 }
 
 // getLibp2pDialer returns a WithContextDialer option for libp2p dialing.
@@ -460,6 +964,9 @@ func (s *server) getLibp2pDialer() grpc.DialOption {
 		if err != nil {
 			return nil, fmt.Errorf("grpc tried to dial non peerID: %w", err)
 		}
+		if err = s.net.findPeer(ctx, id); err != nil && !errors.Is(err, errNoPeerRouting) {
+			log.Debugf("looking up peer %s: %v", id, err)
+		}
 
 		conn, err := gostream.Dial(ctx, s.net.host, id, thread.Protocol)
 		if err != nil {
@@ -0,0 +1,255 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// archivedKey is the metadata key marking a thread with dropped block data.
+const archivedKey = "/archived"
+
+// ArchiveThread drops the block data of a thread, keeping its logstore metadata, i.e., keys,
+// logs and heads. A thread peer must serve the head records of every log first, so the
+// dropped records can be fetched again. The thread is marked as archived, the log heads are
+// restored once they're needed, e.g., by GetRecord, PullThread or new records, and older
+// records are fetched through the dag service as they're read. Archived threads don't serve
+// records to peers.
+func (n *net) ArchiveThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot archive thread: %w", app.ErrThreadInUse)
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return fmt.Errorf("a service-key is required to archive a thread")
+	}
+	if err = n.confirmReplicated(ctx, info); err != nil {
+		return err
+	}
+
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	return n.withThreadWriteLock(id, func() error {
+		current, err := n.store.GetThread(id)
+		if err != nil {
+			return err
+		}
+		if !util.SameHeads(info, current) {
+			return fmt.Errorf("cannot archive thread: log heads changed while confirming replicas")
+		}
+		// the thread is marked first, so an interrupted archival is completed by rehydration
+		if err = n.store.PutBool(id, archivedKey, true); err != nil {
+			return err
+		}
+		for _, lg := range current.Logs {
+			boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+			if err != nil {
+				return err
+			}
+			for _, head := range lg.Heads {
+				limit := math.MaxInt32
+				if _, err = n.deleteBranch(ctx, id, head, boundary, sk, &limit); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// confirmReplicated returns core.ErrNotReplicated unless a thread peer serves the head records of
+// every log of the thread. Peers store records in log order, so they hold the older records
+// as well.
+func (n *net) confirmReplicated(ctx context.Context, info thread.Info) error {
+	_, peers, err := n.threadOffsets(info.ID)
+	if err != nil {
+		return err
+	}
+	for _, pid := range peers {
+		if ok, err := n.servesHeads(ctx, info, pid); err != nil {
+			log.Debugf("confirming replica %s of thread %s: %v", pid, info.ID, err)
+		} else if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no peer of thread %s serves its log heads", core.ErrNotReplicated, info.ID)
+}
+
+// servesHeads returns whether a peer serves the head records of every log of the thread.
+// Heads are requested one by one, skipping the branches of the other heads.
+func (n *net) servesHeads(ctx context.Context, info thread.Info, pid peer.ID) (bool, error) {
+	sk := info.Key.Service()
+	for _, lg := range info.Logs {
+		for i, head := range lg.Heads {
+			others := make([]cid.Cid, 0, len(lg.Heads)-1)
+			others = append(append(others, lg.Heads[:i]...), lg.Heads[i+1:]...)
+			req := &pb.GetRecordsRequest{
+				Body: &pb.GetRecordsRequest_Body{
+					ThreadID:   &pb.ProtoThreadID{ID: info.ID},
+					ServiceKey: &pb.ProtoKey{Key: sk},
+					Logs: []*pb.GetRecordsRequest_Body_LogEntry{{
+						LogID:  &pb.ProtoPeerID{ID: lg.ID},
+						Offset: &pb.ProtoCid{Cid: cid.Undef},
+						Heads:  headsToProto(others),
+						Limit:  1,
+					}},
+				},
+			}
+			recs, err := n.server.getRecordsFromPeer(ctx, info.ID, pid, req, sk)
+			if err != nil {
+				return false, err
+			}
+			var served bool
+			for _, r := range recs[lg.ID] {
+				served = served || r.Cid().Equals(head)
+			}
+			if !served {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// isArchived returns whether the block data of a thread was dropped with ArchiveThread.
+func (n *net) isArchived(id thread.ID) (bool, error) {
+	archived, err := n.store.GetBool(id, archivedKey)
+	if err != nil || archived == nil {
+		return false, err
+	}
+	return *archived, nil
+}
+
+// rehydrateThread restores the log heads of an archived thread, and clears the mark. Older
+// records are fetched through the dag service once they're read. Heads are fetched from the
+// thread peers before the locks are taken, so a slow peer doesn't block the thread.
+// It's a no-op for threads which aren't archived, and must not be called while owning the
+// gc read lock.
+func (n *net) rehydrateThread(ctx context.Context, id thread.ID) error {
+	if archived, err := n.isArchived(id); err != nil || !archived {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return fmt.Errorf("a service-key is required to rehydrate a thread")
+	}
+	fetched, err := n.fetchHeads(ctx, info)
+	if err != nil {
+		return err
+	}
+
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	return n.withThreadLock(id, func() error {
+		// the thread may have been rehydrated concurrently
+		if archived, err := n.isArchived(id); err != nil || !archived {
+			return err
+		}
+		current, err := n.store.GetThread(id)
+		if err != nil {
+			return err
+		}
+		var restored int
+		for _, lg := range current.Logs {
+			for _, head := range lg.Heads {
+				nodes, ok := fetched[head]
+				if !ok {
+					return fmt.Errorf("rehydrating log %s: head %s changed meanwhile", lg.ID, head)
+				}
+				if err = n.dagFor(id).AddMany(ctx, nodes); err != nil {
+					return err
+				}
+				rec := nodes[0].(core.Record)
+				if err = n.refBlocks(ctx, id, []core.Record{rec}); err != nil {
+					return err
+				}
+				restored++
+			}
+		}
+		log.Debugf("rehydrated %d log heads of thread %s", restored, id)
+		return n.store.PutBool(id, archivedKey, false)
+	})
+}
+
+// fetchHeads gets the head records of every log of a thread along with their event, header
+// and body nodes, requesting the latest records from the thread peers first, see util.RecordBlocks.
+func (n *net) fetchHeads(ctx context.Context, info thread.Info) (map[cid.Cid][]format.Node, error) {
+	sk := info.Key.Service()
+	_, peers, err := n.threadOffsets(info.ID)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[peer.ID]cid.Cid, len(info.Logs))
+	for _, lg := range info.Logs {
+		offsets[lg.ID] = cid.Undef
+	}
+	served := make(map[cid.Cid]core.Record)
+	if len(peers) > 0 {
+		recs, err := n.server.getRecords(ctx, peers, info.ID, offsets, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, rs := range recs {
+			for _, r := range rs {
+				served[r.Cid()] = r
+			}
+		}
+	}
+	fetched := make(map[cid.Cid][]format.Node)
+	for _, lg := range info.Logs {
+		for _, head := range lg.Heads {
+			rec, ok := served[head]
+			if !ok {
+				if rec, err = n.fetchRecord(ctx, head, sk); err != nil {
+					return nil, fmt.Errorf("rehydrating head %s of log %s: %w", head, lg.ID, err)
+				}
+			}
+			if fetched[head], err = util.RecordBlocks(ctx, n, rec); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fetched, nil
+}
+
+// fetchRecord gets a record missing locally through the dag service.
+func (n *net) fetchRecord(ctx context.Context, rid cid.Cid, sk *sym.Key) (core.Record, error) {
+	fctx, cancel := context.WithTimeout(ctx, PullTimeout)
+	defer cancel()
+	return cbor.GetRecord(fctx, n, rid, sk)
+}
+
+// restoreRecord stores the record, event, header and body nodes of a thread record.
+func (n *net) restoreRecord(ctx context.Context, tid thread.ID, rec core.Record) error {
+	nodes, err := util.RecordBlocks(ctx, n, rec)
+	if err != nil {
+		return err
+	}
+	return n.dagFor(tid).AddMany(ctx, nodes)
+}
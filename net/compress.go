@@ -0,0 +1,386 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+)
+
+const (
+	// zstdName is the gRPC encoding name of the zstd compressor.
+	zstdName = "zstd"
+	// snappyName is the gRPC encoding name of the snappy compressor.
+	snappyName = "snappy"
+	// zstdMaxDecodedSize bounds the decompressed size of a single message.
+	zstdMaxDecodedSize = 64 << 20
+	// gRPC metadata key for advertising the supported message compression
+	compressionHeader = "x-threads-compression"
+	// gRPC metadata key for advertising the supported record body compression
+	bodyCompressionHeader = "x-threads-body-compression"
+	// peerstore key of the message compression supported by a peer
+	compressionKey = "threads/compression"
+	// peerstore key of the record body compression supported by a peer
+	bodyCompressionKey = "threads/body-compression"
+)
+
+// DefaultCompressionCodec is the codec of compressed messages and record bodies
+// if Config.CompressionCodec is not set.
+const DefaultCompressionCodec = zstdName
+
+// messageCodecs are the message compressors supported by the host. Older peers
+// only read the first advertised one, so zstd must stay in front.
+var messageCodecs = []string{zstdName, snappyName}
+
+// compressedMethods are the methods exchanging edges and records, which are compressed
+// if both the host and the peer support it.
+var compressedMethods = map[string]struct{}{
+	"/net.pb.Service/GetRecords":      {},
+	"/net.pb.Service/PushRecord":      {},
+	"/net.pb.Service/PushRecords":     {},
+	"/net.pb.Service/ExchangeEdges":   {},
+	"/net.pb.Service/GetRecordBodies": {},
+}
+
+func init() {
+	encoding.RegisterCompressor(newZstdCompressor())
+	encoding.RegisterCompressor(&snappyCompressor{})
+}
+
+// zstdCompressor implements encoding.Compressor with zstd. Messages are buffered and
+// coded at once, so a single encoder and decoder are shared by all calls.
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() *zstdCompressor {
+	// options are valid, so errors are not expected
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		panic(err)
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(zstdMaxDecodedSize))
+	if err != nil {
+		panic(err)
+	}
+	return &zstdCompressor{encoder: enc, decoder: dec}
+}
+
+func (c *zstdCompressor) Name() string {
+	return zstdName
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &zstdWriter{w: w, encoder: c.encoder}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	compressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// zstdWriter buffers a message and writes it compressed on Close.
+type zstdWriter struct {
+	bytes.Buffer
+	w       io.Writer
+	encoder *zstd.Encoder
+}
+
+func (z *zstdWriter) Close() error {
+	_, err := z.w.Write(z.encoder.EncodeAll(z.Bytes(), nil))
+	return err
+}
+
+// snappyCompressor implements encoding.Compressor with the snappy block format.
+type snappyCompressor struct{}
+
+func (c *snappyCompressor) Name() string {
+	return snappyName
+}
+
+func (c *snappyCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &snappyWriter{w: w}, nil
+}
+
+func (c *snappyCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	compressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if size, err := snappy.DecodedLen(compressed); err != nil {
+		return nil, err
+	} else if size > zstdMaxDecodedSize {
+		return nil, fmt.Errorf("decoded message size %d exceeds the limit", size)
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// snappyWriter buffers a message and writes it compressed on Close.
+type snappyWriter struct {
+	bytes.Buffer
+	w io.Writer
+}
+
+func (s *snappyWriter) Close() error {
+	_, err := s.w.Write(snappy.Encode(nil, s.Bytes()))
+	return err
+}
+
+// peerCodecs returns the codecs advertised by a peer under the peerstore key.
+func (n *net) peerCodecs(pid peer.ID, key string) []string {
+	v, err := n.host.Peerstore().Get(pid, key)
+	if err != nil {
+		return nil
+	}
+	switch codecs := v.(type) {
+	case []string:
+		return codecs
+	case string:
+		// saved by older versions
+		return []string{codecs}
+	default:
+		return nil
+	}
+}
+
+// peerCompression returns whether a peer advertised support of compressed messages.
+func (n *net) peerCompression(pid peer.ID) bool {
+	return len(n.peerCodecs(pid, compressionKey)) > 0
+}
+
+// messageCodec returns the compressor of messages sent to a peer, the configured one
+// if the peer supports it, or zstd supported by all peers advertising compression.
+func (n *net) messageCodec(pid peer.ID) string {
+	if !n.compression {
+		return ""
+	}
+	// peers predating the negotiation may still advertise codecs in metadata
+	if info, ok := n.protocols.get(pid); ok && info.Version > 0 && !info.Supports(core.FeatureCompression) {
+		return ""
+	}
+	codecs := n.peerCodecs(pid, compressionKey)
+	if hasCodec(codecs, n.compressionCodec) {
+		return n.compressionCodec
+	} else if hasCodec(codecs, zstdName) {
+		return zstdName
+	}
+	return ""
+}
+
+// peerBodyCompression returns whether a peer advertised support of record bodies
+// compressed with the codec.
+func (n *net) peerBodyCompression(pid peer.ID, codec string) bool {
+	return hasCodec(n.peerCodecs(pid, bodyCompressionKey), codec)
+}
+
+// bodyCodec returns the codec bodies of new records of the thread are compressed with.
+// Bodies are compressed only if every known peer of the thread can read them.
+func (n *net) bodyCodec(id thread.ID) string {
+	if !n.bodyCompression {
+		return ""
+	}
+	peers, err := n.server.threadPeers(id)
+	if err != nil {
+		log.Errorf("getting peers of thread %s: %v", id, err)
+		return ""
+	}
+	for _, pid := range peers {
+		if !n.peerBodyCompression(pid, n.compressionCodec) {
+			return ""
+		}
+	}
+	return n.compressionCodec
+}
+
+// setPeerCompression saves the compression advertised in gRPC metadata.
+func (n *net) setPeerCompression(pid peer.ID, md metadata.MD) {
+	n.setPeerCodecs(pid, compressionKey, md.Get(compressionHeader))
+	n.setPeerCodecs(pid, bodyCompressionKey, md.Get(bodyCompressionHeader))
+}
+
+func (n *net) setPeerCodecs(pid peer.ID, key string, codecs []string) {
+	if len(codecs) == 0 || equalCodecs(n.peerCodecs(pid, key), codecs) {
+		return
+	}
+	if err := n.host.Peerstore().Put(pid, key, append([]string(nil), codecs...)); err != nil {
+		log.Errorf("saving compression of %s: %v", pid, err)
+	}
+}
+
+// compressionMetadata advertises the compression supported by the host.
+func compressionMetadata() []string {
+	kv := make([]string, 0, 2*(len(messageCodecs)+len(cbor.BodyCodecs)))
+	for _, c := range messageCodecs {
+		kv = append(kv, compressionHeader, c)
+	}
+	for _, c := range cbor.BodyCodecs {
+		kv = append(kv, bodyCompressionHeader, c)
+	}
+	return kv
+}
+
+func hasCodec(codecs []string, codec string) bool {
+	for _, c := range codecs {
+		if c == codec {
+			return true
+		}
+	}
+	return false
+}
+
+func equalCodecs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compressionClientInterceptor advertises the compression supported by the host, and compresses
+// edge and record messages sent to peers which advertised support of it before.
+func (n *net) compressionClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		pid, perr := peer.Decode(cc.Target())
+		if _, ok := compressedMethods[method]; ok && perr == nil {
+			if codec := n.messageCodec(pid); codec != "" {
+				opts = append(opts, grpc.UseCompressor(codec))
+			}
+		}
+		var header metadata.MD
+		ctx = metadata.AppendToOutgoingContext(ctx, compressionMetadata()...)
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		if perr == nil {
+			n.setPeerCompression(pid, header)
+		}
+		return err
+	}
+}
+
+// compressionServerInterceptor learns the compression supported by calling peers and
+// advertises the compression supported by the host in response headers. Responses are
+// compressed by gRPC like the requests.
+func (n *net) compressionServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if pid, err := peerIDFromContext(ctx); err == nil {
+				n.setPeerCompression(pid, md)
+			}
+		}
+		header := metadata.Pairs(compressionMetadata()...)
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			log.Debugf("setting compression header: %v", err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// compressionStats counts the sizes of compressed messages exchanged with peers.
+// It's installed as the gRPC stats handler of the service and peer connections.
+type compressionStats struct {
+	mx     sync.Mutex
+	status core.CompressionStatus
+}
+
+var _ stats.Handler = (*compressionStats)(nil)
+
+type compressedRPCKey struct{}
+
+// compressedRPC marks an RPC with compressed messages.
+type compressedRPC struct {
+	mx         sync.Mutex
+	compressed bool
+}
+
+// Status returns the counters.
+func (c *compressionStats) Status() core.CompressionStatus {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.status
+}
+
+func (c *compressionStats) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, compressedRPCKey{}, &compressedRPC{})
+}
+
+func (c *compressionStats) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	rpc, ok := ctx.Value(compressedRPCKey{}).(*compressedRPC)
+	if !ok {
+		return
+	}
+	rpc.mx.Lock()
+	defer rpc.mx.Unlock()
+	switch s := s.(type) {
+	case *stats.InHeader:
+		rpc.compressed = rpc.compressed || hasCodec(messageCodecs, s.Compression)
+	case *stats.OutHeader:
+		rpc.compressed = rpc.compressed || hasCodec(messageCodecs, s.Compression)
+	case *stats.InPayload:
+		if rpc.compressed {
+			c.mx.Lock()
+			c.status.ReceivedMessages++
+			c.status.ReceivedBytes += int64(s.Length)
+			c.status.ReceivedWireBytes += int64(s.WireLength)
+			c.mx.Unlock()
+		}
+	case *stats.OutPayload:
+		if rpc.compressed {
+			c.mx.Lock()
+			c.status.SentMessages++
+			c.status.SentBytes += int64(s.Length)
+			c.status.SentWireBytes += int64(s.WireLength)
+			c.mx.Unlock()
+		}
+	}
+}
+
+func (c *compressionStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (c *compressionStats) HandleConn(context.Context, stats.ConnStats) {}
+
+// CompressionStatus returns the counters of compressed messages exchanged with peers.
+func (n *net) CompressionStatus(_ context.Context) (core.CompressionStatus, error) {
+	return n.compressionStats.Status(), nil
+}
@@ -0,0 +1,278 @@
+package net
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+// ReachableAddrTTL is how long an address observed by peers is considered reachable
+// once the host stops announcing it and no peer dials it anymore.
+var ReachableAddrTTL = 40 * time.Minute
+
+// connectivityTracker follows how the host is reached by peers: the reachability reported
+// by AutoNAT, the addresses observed by peers, and the relayed connections replaced by
+// direct ones.
+type connectivityTracker struct {
+	mx           sync.Mutex
+	clock        clock.Clock
+	reachability network.Reachability
+	observed     map[string]observedAddr
+	punching     map[peer.ID]struct{} // peers reached over a relay only
+	holePunch    core.HolePunchStatus
+}
+
+// observedAddr is an address of the host observed by peers, either dialed directly
+// or reported by identify.
+type observedAddr struct {
+	addr ma.Multiaddr
+	seen time.Time
+}
+
+func newConnectivityTracker(clk clock.Clock) *connectivityTracker {
+	return &connectivityTracker{
+		clock:    clk,
+		observed: make(map[string]observedAddr),
+		punching: make(map[peer.ID]struct{}),
+	}
+}
+
+// startConnectivity starts tracking the connections, addresses and reachability of the host.
+func (n *net) startConnectivity() error {
+	n.host.Network().Notify(&network.NotifyBundle{
+		ConnectedF:    n.connectivity.connected,
+		DisconnectedF: n.connectivity.disconnected,
+	})
+	sub, err := n.host.EventBus().Subscribe([]interface{}{
+		new(event.EvtLocalReachabilityChanged),
+		new(event.EvtLocalAddressesUpdated),
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer sub.Close()
+		tick := n.clock.NewTicker(ReachableAddrTTL / 2)
+		defer tick.Stop()
+		for {
+			select {
+			case <-n.ctx.Done():
+				return
+			case <-tick.Chan():
+				n.connectivity.refresh(n.activeAddrs())
+			case ev, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				switch ev := ev.(type) {
+				case event.EvtLocalReachabilityChanged:
+					n.connectivity.mx.Lock()
+					n.connectivity.reachability = ev.Reachability
+					n.connectivity.mx.Unlock()
+				case event.EvtLocalAddressesUpdated:
+					n.connectivity.addrsUpdated(ev, n.listenAddrs())
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// listenAddrs returns the interface addresses the host listens on.
+func (n *net) listenAddrs() []ma.Multiaddr {
+	addrs, err := n.host.Network().InterfaceListenAddresses()
+	if err != nil {
+		return n.host.Network().ListenAddresses()
+	}
+	return addrs
+}
+
+// activeAddrs returns the addresses the host currently announces or is dialed on.
+func (n *net) activeAddrs() []ma.Multiaddr {
+	addrs := n.host.Addrs()
+	for _, conn := range n.host.Network().Conns() {
+		if conn.Stat().Direction == network.DirInbound && !isRelayed(conn.RemoteMultiaddr()) {
+			addrs = append(addrs, conn.LocalMultiaddr())
+		}
+	}
+	return addrs
+}
+
+func (c *connectivityTracker) connected(nw network.Network, conn network.Conn) {
+	pid := conn.RemotePeer()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if isRelayed(conn.RemoteMultiaddr()) {
+		for _, other := range nw.ConnsToPeer(pid) {
+			if !isRelayed(other.RemoteMultiaddr()) {
+				return
+			}
+		}
+		if _, ok := c.punching[pid]; !ok {
+			c.punching[pid] = struct{}{}
+			c.holePunch.Attempts++
+		}
+		return
+	}
+	if _, ok := c.punching[pid]; ok {
+		delete(c.punching, pid)
+		c.holePunch.Successes++
+	}
+	if conn.Stat().Direction == network.DirInbound {
+		c.observe(conn.LocalMultiaddr())
+	}
+}
+
+// addrsUpdated records the addresses announced by the host besides the listen addresses,
+// i.e., the public addresses observed by peers through identify or mapped by NAT, and
+// forgets the ones the host stopped announcing.
+func (c *connectivityTracker) addrsUpdated(ev event.EvtLocalAddressesUpdated, listen []ma.Multiaddr) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	for _, ua := range ev.Current {
+		if !isRelayed(ua.Address) && !containsAddr(listen, ua.Address) {
+			c.observe(ua.Address)
+		}
+	}
+	for _, ua := range ev.Removed {
+		delete(c.observed, string(ua.Address.Bytes()))
+	}
+}
+
+// refresh marks the active addresses as observed again, if they were observed before,
+// and expires the addresses not observed within ReachableAddrTTL.
+func (c *connectivityTracker) refresh(active []ma.Multiaddr) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	for _, addr := range active {
+		if _, ok := c.observed[string(addr.Bytes())]; ok {
+			c.observe(addr)
+		}
+	}
+	c.expire()
+}
+
+// observe records an observed address. It must be called with the lock held.
+func (c *connectivityTracker) observe(addr ma.Multiaddr) {
+	c.observed[string(addr.Bytes())] = observedAddr{addr: addr, seen: c.clock.Now()}
+}
+
+// expire drops the addresses not observed within ReachableAddrTTL. It must be called
+// with the lock held.
+func (c *connectivityTracker) expire() {
+	deadline := c.clock.Now().Add(-ReachableAddrTTL)
+	for k, oa := range c.observed {
+		if oa.seen.Before(deadline) {
+			delete(c.observed, k)
+		}
+	}
+}
+
+func (c *connectivityTracker) disconnected(nw network.Network, conn network.Conn) {
+	pid := conn.RemotePeer()
+	if len(nw.ConnsToPeer(pid)) > 0 {
+		return
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	delete(c.punching, pid) // attempt failed
+}
+
+// reachableAddrs returns the addresses observed by peers among the passed addresses.
+// Listen and relayed addresses are returned as is. It returns all addresses if none
+// were observed yet.
+func (c *connectivityTracker) reachableAddrs(addrs, listen []ma.Multiaddr) []ma.Multiaddr {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.expire()
+	if len(c.observed) == 0 {
+		return addrs
+	}
+	res := make([]ma.Multiaddr, 0, len(addrs))
+	for _, addr := range addrs {
+		if _, ok := c.observed[string(addr.Bytes())]; ok || isRelayed(addr) || containsAddr(listen, addr) {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+// Connectivity returns how the host is reached by peers. Only the connections to the
+// replicators of the threads are reported.
+func (n *net) Connectivity(_ context.Context) (core.ConnectivityStatus, error) {
+	peers, err := n.threadPeers()
+	if err != nil {
+		return core.ConnectivityStatus{}, err
+	}
+	status := core.ConnectivityStatus{Peers: make(map[peer.ID]core.PeerConnectivity, len(peers))}
+	n.connectivity.mx.Lock()
+	n.connectivity.expire()
+	status.Reachability = n.connectivity.reachability
+	status.HolePunch = n.connectivity.holePunch
+	for _, oa := range n.connectivity.observed {
+		status.ReachableAddrs = append(status.ReachableAddrs, oa.addr)
+	}
+	n.connectivity.mx.Unlock()
+	sort.Slice(status.ReachableAddrs, func(i, j int) bool {
+		return status.ReachableAddrs[i].String() < status.ReachableAddrs[j].String()
+	})
+
+	for pid := range peers {
+		var pc core.PeerConnectivity
+		for _, conn := range n.host.Network().ConnsToPeer(pid) {
+			pc.Connected = true
+			if relay, ok := circuitRelay(conn.RemoteMultiaddr()); ok {
+				pc.Relays = append(pc.Relays, relay)
+			} else {
+				pc.Direct = true
+			}
+		}
+		status.Peers[pid] = pc
+	}
+	return status, nil
+}
+
+// containsAddr returns whether the address is among the addresses.
+func containsAddr(addrs []ma.Multiaddr, addr ma.Multiaddr) bool {
+	for _, a := range addrs {
+		if a.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRelayed returns whether the address goes through a relay circuit.
+func isRelayed(addr ma.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(ma.P_CIRCUIT)
+	return err == nil
+}
+
+// circuitRelay returns the relay of a circuit address, i.e., the peer preceding the circuit.
+func circuitRelay(addr ma.Multiaddr) (peer.ID, bool) {
+	var (
+		relay   peer.ID
+		circuit bool
+	)
+	ma.ForEach(addr, func(c ma.Component) bool {
+		switch c.Protocol().Code {
+		case ma.P_P2P:
+			if pid, err := peer.IDFromBytes(c.RawValue()); err == nil {
+				relay = pid
+			}
+		case ma.P_CIRCUIT:
+			circuit = true
+			return false
+		}
+		return true
+	})
+	return relay, circuit && relay != ""
+}
@@ -0,0 +1,311 @@
+package net
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/util/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+var (
+	// DefaultMaxConns is the default number of gRPC client connections kept open to peers.
+	DefaultMaxConns = 512
+
+	// DefaultConnIdleTimeout is the default time a connection without calls is kept open.
+	DefaultConnIdleTimeout = time.Minute * 5
+
+	// DefaultDialBackoff is the default pause before redialing a peer after a failed connection.
+	DefaultDialBackoff = time.Second
+
+	// DefaultMaxDialBackoff is the default upper bound of the pause between redials of a peer.
+	DefaultMaxDialBackoff = time.Minute * 5
+)
+
+// errDialBackoff indicates a peer isn't dialed since connecting to it failed recently.
+var errDialBackoff = errors.New("peer dial backed off")
+
+// ConnPoolConfig bounds the gRPC client connections to peers, so hosts replicating
+// with many peers don't exhaust file descriptors.
+type ConnPoolConfig struct {
+	// MaxConns is the number of connections kept open. Opening another one closes the least
+	// recently used connection without calls in flight. Connections running calls or streams,
+	// or about to, aren't closed, so the bound may be exceeded for a while. Zero means DefaultMaxConns,
+	// a negative value doesn't bound connections.
+	MaxConns int
+
+	// IdleTimeout is the time a connection without calls in flight is kept open. Zero means
+	// DefaultConnIdleTimeout, a negative value keeps idle connections open.
+	IdleTimeout time.Duration
+
+	// DialBackoff is the pause before redialing a peer after its connection failed, doubled
+	// on every consecutive failure. Zero means DefaultDialBackoff, a negative value redials
+	// right away.
+	DialBackoff time.Duration
+
+	// MaxDialBackoff bounds the pause between redials. Zero means DefaultMaxDialBackoff.
+	MaxDialBackoff time.Duration
+}
+
+type pooledConn struct {
+	pid    peer.ID
+	conn   *grpc.ClientConn
+	elem   *list.Element
+	used   time.Time
+	active int // calls and streams in flight, and references taken by get
+}
+
+type dialFailure struct {
+	failures int
+	retry    time.Time
+}
+
+// connPool keeps gRPC client connections to peers. Connections in shutdown or failure
+// state are dropped once requested, and peers are redialed with exponential backoff.
+// Idle connections are closed by a janitor driven by the clock.
+type connPool struct {
+	ctx    context.Context
+	clock  clock.Clock
+	conf   ConnPoolConfig
+	opts   []grpc.DialOption
+	mx     sync.Mutex
+	conns  map[peer.ID]*pooledConn
+	lru    *list.List // of peer IDs, most recently used first
+	failed map[peer.ID]*dialFailure
+}
+
+func newConnPool(ctx context.Context, clk clock.Clock, conf ConnPoolConfig, opts ...grpc.DialOption) *connPool {
+	if conf.MaxConns == 0 {
+		conf.MaxConns = DefaultMaxConns
+	}
+	if conf.IdleTimeout == 0 {
+		conf.IdleTimeout = DefaultConnIdleTimeout
+	}
+	if conf.DialBackoff == 0 {
+		conf.DialBackoff = DefaultDialBackoff
+	}
+	if conf.MaxDialBackoff == 0 {
+		conf.MaxDialBackoff = DefaultMaxDialBackoff
+	}
+	p := &connPool{
+		ctx:    ctx,
+		clock:  clock.OrNew(clk),
+		conf:   conf,
+		conns:  make(map[peer.ID]*pooledConn),
+		lru:    list.New(),
+		failed: make(map[peer.ID]*dialFailure),
+	}
+	p.opts = append(opts,
+		grpc.WithChainUnaryInterceptor(p.unaryInterceptor()),
+		grpc.WithChainStreamInterceptor(p.streamInterceptor()))
+	if conf.IdleTimeout > 0 {
+		go p.closeIdle()
+	}
+	return p
+}
+
+// get returns an open connection to the peer, dialing it if needed, along with a function
+// releasing it. The connection is counted as in use until it's released, so it isn't evicted
+// or closed as idle before the calls made with it start.
+func (p *connPool) get(pid peer.ID) (*grpc.ClientConn, func(), error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	now := p.clock.Now()
+	if pc, ok := p.conns[pid]; ok {
+		switch state := pc.conn.GetState(); state {
+		case connectivity.Shutdown, connectivity.TransientFailure:
+			log.Debugf("dropping connection to %s: %s", pid, state)
+			p.remove(pc)
+			p.backoff(pid, now)
+		default:
+			if state == connectivity.Ready {
+				delete(p.failed, pid)
+			}
+			pc.used = now
+			pc.active++
+			p.lru.MoveToFront(pc.elem)
+			return pc.conn, p.releaseFunc(pc.conn), nil
+		}
+	}
+	if f, ok := p.failed[pid]; ok && now.Before(f.retry) {
+		return nil, nil, fmt.Errorf("%w: %s until %s", errDialBackoff, pid, f.retry.Format(time.RFC3339))
+	}
+
+	if p.conf.MaxConns > 0 {
+		for e := p.lru.Back(); e != nil && len(p.conns) >= p.conf.MaxConns; {
+			pc := p.conns[e.Value.(peer.ID)]
+			e = e.Prev()
+			if pc.active == 0 {
+				log.Debugf("evicting connection to %s: pool is full", pc.pid)
+				p.remove(pc)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, DialTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, pid.Pretty(), p.opts...)
+	if err != nil {
+		p.backoff(pid, now)
+		return nil, nil, err
+	}
+	pc := &pooledConn{pid: pid, conn: conn, used: now, active: 1}
+	pc.elem = p.lru.PushFront(pid)
+	p.conns[pid] = pc
+	return conn, p.releaseFunc(conn), nil
+}
+
+// releaseFunc returns a function releasing a connection returned by get once.
+func (p *connPool) releaseFunc(cc *grpc.ClientConn) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { p.release(cc) })
+	}
+}
+
+// closeAll closes all connections.
+func (p *connPool) closeAll() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	for _, pc := range p.conns {
+		p.remove(pc)
+	}
+}
+
+// size returns the number of open connections.
+func (p *connPool) size() int {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return len(p.conns)
+}
+
+// remove closes the connection and drops it from the pool, the pool lock must be held.
+func (p *connPool) remove(pc *pooledConn) {
+	delete(p.conns, pc.pid)
+	p.lru.Remove(pc.elem)
+	if err := pc.conn.Close(); err != nil {
+		log.Errorf("error closing connection: %v", err)
+	}
+}
+
+// backoff postpones the next dial of the peer, the pool lock must be held.
+func (p *connPool) backoff(pid peer.ID, now time.Time) {
+	if p.conf.DialBackoff < 0 {
+		return
+	}
+	f, ok := p.failed[pid]
+	if !ok {
+		f = &dialFailure{}
+		p.failed[pid] = f
+	}
+	pause := p.conf.DialBackoff << f.failures
+	if pause > p.conf.MaxDialBackoff || pause <= 0 {
+		pause = p.conf.MaxDialBackoff
+	} else {
+		f.failures++
+	}
+	f.retry = now.Add(pause)
+}
+
+func (p *connPool) closeIdle() {
+	tick := p.clock.NewTicker(p.conf.IdleTimeout / 2)
+	defer tick.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-tick.Chan():
+			p.mx.Lock()
+			now := p.clock.Now()
+			for _, pc := range p.conns {
+				if pc.active == 0 && now.Sub(pc.used) >= p.conf.IdleTimeout {
+					log.Debugf("closing idle connection to %s", pc.pid)
+					p.remove(pc)
+				}
+			}
+			for pid, f := range p.failed {
+				if now.After(f.retry.Add(p.conf.MaxDialBackoff)) {
+					delete(p.failed, pid) // peer wasn't dialed for long
+				}
+			}
+			p.mx.Unlock()
+		}
+	}
+}
+
+// acquire marks a call on the connection in flight.
+func (p *connPool) acquire(cc *grpc.ClientConn) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if pc := p.lookup(cc); pc != nil {
+		pc.active++
+		pc.used = p.clock.Now()
+	}
+}
+
+// release marks a call on the connection done.
+func (p *connPool) release(cc *grpc.ClientConn) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if pc := p.lookup(cc); pc != nil {
+		pc.active--
+		pc.used = p.clock.Now()
+	}
+}
+
+// lookup returns the pooled connection, or nil if it was dropped, the pool lock must be held.
+func (p *connPool) lookup(cc *grpc.ClientConn) *pooledConn {
+	pid, err := peer.Decode(cc.Target())
+	if err != nil {
+		return nil
+	}
+	if pc, ok := p.conns[pid]; ok && pc.conn == cc {
+		return pc
+	}
+	return nil
+}
+
+func (p *connPool) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		p.acquire(cc)
+		defer p.release(cc)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (p *connPool) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		p.acquire(cc)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			p.release(cc)
+			return nil, err
+		}
+		// stream context is canceled once the stream is finished
+		go func() {
+			<-cs.Context().Done()
+			p.release(cc)
+		}()
+		return cs, nil
+	}
+}
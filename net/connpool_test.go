@@ -0,0 +1,176 @@
+package net
+
+import (
+	"context"
+	"errors"
+	nnet "net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	"github.com/textileio/go-threads/util/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestConnPool(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		mock        = clock.NewMock(time.Unix(0, 0))
+		down        = map[string]bool{}
+		// connections to peers which are down fail, others keep connecting
+		dialer = grpc.WithContextDialer(func(ctx context.Context, target string) (nnet.Conn, error) {
+			if down[target] {
+				return nil, errors.New("peer is down")
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		p = newConnPool(ctx, mock, ConnPoolConfig{
+			MaxConns:       2,
+			IdleTimeout:    time.Minute,
+			DialBackoff:    time.Second,
+			MaxDialBackoff: 2 * time.Second,
+		}, grpc.WithInsecure(), dialer)
+		p1 = tu.RandPeerIDFatal(t)
+		p2 = tu.RandPeerIDFatal(t)
+		p3 = tu.RandPeerIDFatal(t)
+		p4 = tu.RandPeerIDFatal(t)
+	)
+	defer cancel()
+	defer p.closeAll()
+	down[p4.Pretty()] = true
+	mock.BlockUntil(1)
+
+	c1, r1, err := p.get(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1()
+	c2, r2, err := p.get(p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2()
+	c, r, err := p.get(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r()
+	if c != c1 {
+		t.Fatal("expected connection to be reused")
+	}
+
+	// connections with calls in flight aren't evicted, others in the order of use
+	release := make(chan struct{})
+	go func() {
+		_ = p.unaryInterceptor()(ctx, "call", nil, nil, c2, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			<-release
+			return nil
+		})
+	}()
+	waitFor(t, func() bool { return activeCalls(p, p2) == 1 })
+	if _, r, err = p.get(p3); err != nil {
+		t.Fatal(err)
+	}
+	r()
+	if c1.GetState() != connectivity.Shutdown {
+		t.Fatal("expected least recently used idle connection to be evicted")
+	}
+	if c2.GetState() == connectivity.Shutdown {
+		t.Fatal("expected connection with a call in flight to be kept")
+	}
+
+	// idle connections are closed by the janitor
+	mock.Add(time.Minute)
+	waitFor(t, func() bool { return p.size() == 1 })
+	close(release)
+	waitFor(t, func() bool { return activeCalls(p, p2) == 0 })
+	mock.Add(time.Minute)
+	waitFor(t, func() bool { return p.size() == 0 })
+
+	// failed connections are dropped and redialed with backoff
+	for i, pause := range []time.Duration{time.Second, 2 * time.Second, 2 * time.Second} {
+		c, r, err := p.get(p4)
+		if err != nil {
+			t.Fatalf("redial %d: %v", i, err)
+		}
+		r()
+		waitFor(t, func() bool { return c.GetState() == connectivity.TransientFailure })
+		if _, _, err = p.get(p4); !errors.Is(err, errDialBackoff) {
+			t.Fatalf("expected dial to be backed off, got %v", err)
+		}
+		mock.Add(pause - time.Millisecond)
+		if _, _, err = p.get(p4); !errors.Is(err, errDialBackoff) {
+			t.Fatalf("expected dial to be backed off for %s, got %v", pause, err)
+		}
+		mock.Add(time.Millisecond)
+	}
+}
+
+func TestConnPool_Release(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		dialer      = grpc.WithContextDialer(func(ctx context.Context, target string) (nnet.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		p  = newConnPool(ctx, nil, ConnPoolConfig{MaxConns: 1, IdleTimeout: -1}, grpc.WithInsecure(), dialer)
+		p1 = tu.RandPeerIDFatal(t)
+		p2 = tu.RandPeerIDFatal(t)
+		p3 = tu.RandPeerIDFatal(t)
+	)
+	defer cancel()
+	defer p.closeAll()
+
+	// connections returned by get aren't evicted before they're released
+	c1, r1, err := p.get(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, r2, err := p.get(p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.GetState() == connectivity.Shutdown {
+		t.Fatal("expected connection in use to be kept")
+	}
+	r1()
+	r1() // releasing again is a no-op
+	if n := activeCalls(p, p1); n != 0 {
+		t.Fatalf("expected released connection, got %d references", n)
+	}
+	if n := activeCalls(p, p2); n != 1 {
+		t.Fatalf("expected connection in use, got %d references", n)
+	}
+	if _, r3, err := p.get(p3); err != nil {
+		t.Fatal(err)
+	} else {
+		r3()
+	}
+	if c1.GetState() != connectivity.Shutdown {
+		t.Fatal("expected released connection to be evicted")
+	}
+	if c2.GetState() == connectivity.Shutdown {
+		t.Fatal("expected connection in use to be kept")
+	}
+	r2()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func activeCalls(p *connPool, pid peer.ID) int {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.conns[pid].active
+}
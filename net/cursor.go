@@ -0,0 +1,57 @@
+package net
+
+import (
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// threadCursorPage is the number of thread IDs a cursor loads at once.
+const threadCursorPage = 256
+
+// threadCursor iterates over the thread index of a logstore in index order.
+// The index is loaded in bounded pages following the last returned thread, so a pass
+// never holds more than a page of IDs. Threads added meanwhile behind the cursor are
+// visited by the next pass.
+// Not a thread-safe structure.
+type threadCursor struct {
+	store lstore.Logstore
+	ids   thread.IDSlice
+	pos   int
+	last  thread.ID
+	done  bool
+}
+
+func newThreadCursor(store lstore.Logstore) *threadCursor {
+	return &threadCursor{store: store}
+}
+
+// Next returns the next thread ID and false once the pass is complete.
+func (c *threadCursor) Next() (thread.ID, bool, error) {
+	if c.pos >= len(c.ids) {
+		if c.done {
+			return thread.Undef, false, nil
+		}
+		ids, err := c.store.ThreadsAfter(c.last, threadCursorPage)
+		if err != nil {
+			return thread.Undef, false, err
+		}
+		c.ids, c.pos, c.done = ids, 0, len(ids) < threadCursorPage
+		if len(ids) == 0 {
+			return thread.Undef, false, nil
+		}
+	}
+	tid := c.ids[c.pos]
+	c.pos++
+	c.last = tid
+	return tid, true, nil
+}
+
+// Buffered returns the number of loaded thread IDs left in the page.
+func (c *threadCursor) Buffered() int {
+	return len(c.ids) - c.pos
+}
+
+// Reset rewinds the cursor to the beginning of the index.
+func (c *threadCursor) Reset() {
+	c.ids, c.pos, c.last, c.done = nil, 0, thread.Undef, false
+}
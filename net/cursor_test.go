@@ -0,0 +1,98 @@
+package net
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+)
+
+func TestThreadCursor(t *testing.T) {
+	store := tstore.NewLogstore()
+	defer store.Close()
+
+	// an empty index completes the pass right away
+	cursor := newThreadCursor(store)
+	for i := 0; i < 2; i++ {
+		if _, ok, err := cursor.Next(); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Fatal("expected empty pass")
+		}
+		cursor.Reset()
+	}
+
+	count := 2*threadCursorPage + 3
+	for i := 0; i < count; i++ {
+		addRemoteThread(t, store)
+	}
+	pass := func(stop int) []thread.ID {
+		var ids []thread.ID
+		for len(ids) != stop {
+			tid, ok, err := cursor.Next()
+			if err != nil {
+				t.Fatal(err)
+			} else if !ok {
+				break
+			}
+			if cursor.Buffered() >= threadCursorPage {
+				t.Fatalf("expected at most a page of buffered threads, got %d", cursor.Buffered())
+			}
+			ids = append(ids, tid)
+		}
+		return ids
+	}
+
+	// a pass spans several pages, and visits every thread once in index order
+	ids := pass(-1)
+	if len(ids) != count {
+		t.Fatalf("expected %d threads, got %d", count, len(ids))
+	}
+	for i := 1; i < len(ids); i++ {
+		if !lstore.ThreadIndexLess(ids[i-1], ids[i]) {
+			t.Fatalf("expected threads in index order, got %s before %s", ids[i-1], ids[i])
+		}
+	}
+	if _, ok, err := cursor.Next(); err != nil || ok {
+		t.Fatalf("expected completed pass, got %v", err)
+	}
+
+	// reset rewinds an interrupted pass to the beginning of the index
+	cursor.Reset()
+	if part := pass(threadCursorPage + 1); part[0] != ids[0] {
+		t.Fatalf("expected pass from %s, got %s", ids[0], part[0])
+	}
+	cursor.Reset()
+	if again := pass(-1); len(again) != count || again[0] != ids[0] || again[count-1] != ids[count-1] {
+		t.Fatalf("expected the same pass after reset, got %d threads", len(again))
+	}
+}
+
+// addRemoteThread adds a thread with a single log of a peer which is unreachable.
+func addRemoteThread(t *testing.T, store lstore.Logstore) thread.ID {
+	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := ma.NewMultiaddr("/p2p/" + pid.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := thread.Info{ID: thread.NewIDV1(thread.Raw, 32), Key: thread.NewRandomKey()}
+	if err = store.AddThread(info); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.AddLog(info.ID, thread.LogInfo{ID: pid, PubKey: pk, Addrs: []ma.Multiaddr{addr}}); err != nil {
+		t.Fatal(err)
+	}
+	return info.ID
+}
@@ -0,0 +1,206 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+var (
+	// DefaultDeadLetterAttempts is the default number of attempts to handle a record
+	// before it's skipped, see Config.DeadLetterAttempts.
+	DefaultDeadLetterAttempts = 5
+
+	deadLetterPrefix = ds.NewKey("/deadletter")
+)
+
+// deadLetters keeps the records the app failed to handle, along with the failed attempts.
+// Entries are kept in the datastore, so attempts are counted across restarts.
+type deadLetters struct {
+	store    ds.Datastore
+	clock    clock.Clock
+	attempts int
+}
+
+func newDeadLetters(store ds.Datastore, clk clock.Clock, attempts int) *deadLetters {
+	if attempts == 0 {
+		attempts = DefaultDeadLetterAttempts
+	}
+	return &deadLetters{store: store, clock: clk, attempts: attempts}
+}
+
+// Fail records a failed attempt to handle the record, and returns whether the attempts
+// are exhausted, so the record must be skipped.
+func (d *deadLetters) Fail(tid thread.ID, lid peer.ID, rid cid.Cid, cause error) (bool, error) {
+	dl, _, err := d.Get(tid, rid)
+	if err != nil {
+		return false, err
+	}
+	dl.LogID = lid
+	dl.RecordID = rid
+	dl.Attempts++
+	dl.Err = cause.Error()
+	dl.FailedAt = d.clock.Now()
+	if d.attempts > 0 && dl.Attempts >= d.attempts {
+		dl.Skipped = true
+	}
+	value, err := json.Marshal(dl)
+	if err != nil {
+		return false, err
+	}
+	return dl.Skipped, d.store.Put(deadLetterKey(tid, rid), value)
+}
+
+// Get returns the dead letter of the record, or false if there is none.
+func (d *deadLetters) Get(tid thread.ID, rid cid.Cid) (core.DeadLetter, bool, error) {
+	var dl core.DeadLetter
+	value, err := d.store.Get(deadLetterKey(tid, rid))
+	if err == ds.ErrNotFound {
+		return dl, false, nil
+	} else if err != nil {
+		return dl, false, err
+	}
+	return dl, true, json.Unmarshal(value, &dl)
+}
+
+// List returns the dead letters of the thread, most recently failed first.
+func (d *deadLetters) List(tid thread.ID) ([]core.DeadLetter, error) {
+	res, err := d.store.Query(query.Query{Prefix: deadLetterPrefix.ChildString(tid.String()).String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	var dls []core.DeadLetter
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, e.Error
+		}
+		var dl core.DeadLetter
+		if err = json.Unmarshal(e.Value, &dl); err != nil {
+			log.Warnf("skipping malformed dead letter %s: %v", e.Key, err)
+			continue
+		}
+		dls = append(dls, dl)
+	}
+	sort.Slice(dls, func(i, j int) bool { return dls[i].FailedAt.After(dls[j].FailedAt) })
+	return dls, nil
+}
+
+// Delete drops the dead letter of the record if there is one.
+func (d *deadLetters) Delete(tid thread.ID, rid cid.Cid) error {
+	key := deadLetterKey(tid, rid)
+	if exists, err := d.store.Has(key); err != nil || !exists {
+		return err
+	}
+	return d.store.Delete(key)
+}
+
+// PurgeThread removes all dead letters of the thread.
+func (d *deadLetters) PurgeThread(tid thread.ID) error {
+	res, err := d.store.Query(query.Query{Prefix: deadLetterPrefix.ChildString(tid.String()).String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := d.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deadLetterKey(tid thread.ID, rid cid.Cid) ds.Key {
+	return deadLetterPrefix.ChildString(tid.String()).ChildString(rid.String())
+}
+
+func (n *net) DeadLetters(_ context.Context, id thread.ID, opts ...core.ThreadOption) ([]core.DeadLetter, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	return n.deadLetters.List(id)
+}
+
+func (n *net) ReplayDeadLetter(ctx context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	con, ok := n.getConnectorProtected(id, args.APIToken)
+	if !ok {
+		return fmt.Errorf("cannot replay record: %w", app.ErrThreadInUse)
+	} else if con == nil {
+		return fmt.Errorf("cannot replay record: thread %s has no app connected", id)
+	}
+
+	// replays are ordered with records handled by the log updates. Local writes aren't blocked,
+	// since the app may create records while handling the replayed one, and a replay doesn't
+	// advance heads or outlive a deletion, which purges the dead letters holding the thread lock.
+	ts, err := n.lockThread(id)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+
+	dl, ok, err := n.deadLetters.Get(id, rid)
+	if err != nil {
+		return err
+	} else if !ok {
+		return core.ErrDeadLetterNotFound
+	} else if !dl.Skipped {
+		return fmt.Errorf("cannot replay record %s: it's handled again once received", rid)
+	}
+	rec, err := n.getRecord(ctx, id, rid)
+	if err != nil {
+		return err
+	}
+	if err = con.HandleNetRecord(ctx, NewRecord(rec, id, dl.LogID)); err != nil {
+		if _, ferr := n.deadLetters.Fail(id, dl.LogID, rid, err); ferr != nil {
+			log.Errorf("recording failure of record %s: %v", rid, ferr)
+		}
+		return fmt.Errorf("handling record failed: %w", err)
+	}
+	return n.deadLetters.Delete(id, rid)
+}
+
+func (n *net) DiscardDeadLetter(_ context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	ts, err := n.lockThread(id)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+
+	if _, ok, err := n.deadLetters.Get(id, rid); err != nil {
+		return err
+	} else if !ok {
+		return core.ErrDeadLetterNotFound
+	}
+	return n.deadLetters.Delete(id, rid)
+}
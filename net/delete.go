@@ -0,0 +1,242 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// DeleteChunkSize is the maximum number of records removed under a single hold of the thread lock.
+var DeleteChunkSize = 1000
+
+// deletingKey is the metadata key marking a thread which is being deleted.
+const deletingKey = "/deleting"
+
+// deleteThread cleans up all the persistent and in-memory bits of a thread. This includes:
+// - Removing all record and event nodes.
+// - Deleting all logstore keys, addresses, and heads.
+// - Cancelling the pubsub subscription and topic.
+// - Dropping pending record deliveries.
+// Local subscriptions will not be cancelled and will simply stop reporting.
+// Records are removed in chunks, and the thread lock is released between them, so
+// deleting a long history doesn't block other threads' pulls and updates for long.
+// Local writes are blocked along with the thread lock, so records created concurrently
+// either land before a chunk or fail once the thread is marked.
+// The thread is marked as deleted first, and an interrupted deletion is resumed on startup.
+func (n *net) deleteThread(ctx context.Context, id thread.ID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := n.withThreadWriteLock(id, func() error {
+		return n.beginDelete(id)
+	}); err != nil {
+		return err
+	}
+
+	for done := false; !done; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := n.withThreadWriteLock(id, func() (err error) {
+			done, err = n.deleteChunk(ctx, id, DeleteChunkSize)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := n.withThreadWriteLock(id, func() error {
+		return n.store.DeleteThread(id) // Delete logstore keys, addresses, heads, and metadata
+	}); err != nil {
+		return err
+	}
+	if err := n.forgetEphemeral(id); err != nil {
+		return err
+	}
+	n.forgetRelayed(id)
+	n.emit(core.LifecycleEvent{Type: core.ThreadDeleted, ThreadID: id})
+	return nil
+}
+
+// withThreadLock runs f holding the thread semaphore.
+func (n *net) withThreadLock(id thread.ID, f func() error) error {
+	ts, err := n.lockThread(id)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+	return f()
+}
+
+// beginDelete marks a thread as deleted, so it stops receiving updates, and drops its in-memory state.
+func (n *net) beginDelete(id thread.ID) error {
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err := n.store.PutBool(id, deletingKey, true); err != nil {
+		return err
+	}
+	if n.server.ps != nil {
+		if err := n.server.ps.Remove(id); err != nil {
+			return err
+		}
+	}
+	return n.purgeThreadState(id)
+}
+
+// purgeThreadState drops the entries of a thread kept apart from the logstore and blockstore.
+func (n *net) purgeThreadState(id thread.ID) error {
+	if err := n.deliveries.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.acks.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.bodies.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.extensions.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.recIndex.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.deadLetters.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.journal.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.capabilities.PurgeThread(id); err != nil {
+		return err
+	}
+	if err := n.revocations.PurgeThread(id); err != nil {
+		return err
+	}
+	n.pulls.forget(id)
+	n.unloaded.forget(id)
+	return nil
+}
+
+// deleteChunk removes up to limit records of a thread, and returns whether all records were removed.
+// The progress is persisted by moving the log heads past the removed records.
+func (n *net) deleteChunk(ctx context.Context, id thread.ID, limit int) (bool, error) {
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return false, err
+	}
+	for _, lg := range info.Logs {
+		if limit, err = n.deleteLogChunk(ctx, id, lg, info.Key.Service(), limit); err != nil {
+			return false, err
+		} else if limit == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// deleteLogChunk removes up to limit records of a log walking back from its heads,
+// and returns the number of records which may still be removed in this chunk.
+func (n *net) deleteLogChunk(
+	ctx context.Context,
+	id thread.ID,
+	lg thread.LogInfo,
+	sk *sym.Key,
+	limit int,
+) (int, error) {
+	boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+	if err != nil {
+		return limit, err
+	}
+	var remaining []cid.Cid
+	for _, head := range lg.Heads {
+		if err == nil && limit > 0 {
+			head, err = n.deleteBranch(ctx, id, head, boundary, sk, &limit)
+		}
+		if head.Defined() {
+			remaining = append(remaining, head)
+		}
+	}
+	// persist the progress even if a record couldn't be removed
+	if serr := n.store.SetHeads(id, lg.ID, remaining); serr != nil && err == nil {
+		err = serr
+	}
+	return limit, err
+}
+
+// deleteBranch removes up to limit records walking back from head. It returns the latest
+// record which wasn't removed, or an undefined cid if the whole branch was removed.
+func (n *net) deleteBranch(
+	ctx context.Context,
+	id thread.ID,
+	head cid.Cid,
+	boundary cid.Cid,
+	sk *sym.Key,
+	limit *int,
+) (cid.Cid, error) {
+	for head.Defined() && *limit > 0 {
+		// branches of a forked log stop at the already deleted fork point
+		if known, err := n.isKnown(head); err != nil {
+			return head, err
+		} else if !known {
+			return cid.Undef, nil
+		}
+		prev, err := n.deleteRecord(ctx, id, head, sk)
+		if err != nil {
+			return head, err
+		}
+		*limit--
+		if head.Equals(boundary) {
+			return cid.Undef, nil // older records are dropped by compaction
+		}
+		head = prev
+	}
+	return head, nil
+}
+
+// isDeleting returns whether a thread is being deleted.
+func (n *net) isDeleting(id thread.ID) (bool, error) {
+	deleting, err := n.store.GetBool(id, deletingKey)
+	if err != nil || deleting == nil {
+		return false, err
+	}
+	return *deleting, nil
+}
+
+// checkNotDeleting returns an error if a thread is being deleted, so it isn't updated anymore.
+func (n *net) checkNotDeleting(id thread.ID) error {
+	if deleting, err := n.isDeleting(id); err != nil {
+		return err
+	} else if deleting {
+		return fmt.Errorf("thread %s is being deleted: %w", id, lstore.ErrThreadNotFound)
+	}
+	return nil
+}
+
+// resumeDeletes finishes deletions of threads interrupted by a shutdown.
+func (n *net) resumeDeletes() {
+	cursor := newThreadCursor(n.store)
+	for {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			log.Errorf("error listing threads: %s", err)
+			return
+		} else if !ok {
+			return
+		}
+		if deleting, err := n.isDeleting(tid); err != nil {
+			log.Errorf("error getting thread %s: %s", tid, err)
+		} else if deleting {
+			log.Infof("resuming deletion of thread %s", tid)
+			if err = n.deleteThread(n.ctx, tid); err != nil && n.ctx.Err() == nil {
+				log.Errorf("error deleting thread %s: %s", tid, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// DeliveryPollInterval is the interval for checking peers with pending record deliveries.
+	DeliveryPollInterval = time.Second
+
+	// DeliveryInitialBackoff is the pause before the first retry of a failed record delivery.
+	DeliveryInitialBackoff = time.Second * 5
+
+	// DeliveryMaxBackoff is the maximum pause between record delivery retries.
+	DeliveryMaxBackoff = time.Minute * 10
+
+	// DeliveryMaxAttempts is the number of redeliveries of a record refused by the peer after
+	// which it's dropped from the queue, so it doesn't hold back the records queued after it.
+	// Attempts failing to reach the peer don't count.
+	DeliveryMaxAttempts = 8
+
+	deliveryPrefix = ds.NewKey("/delivery")
+)
+
+// deliverFunc pushes a single record to the peer.
+type deliverFunc func(ctx context.Context, pid peer.ID, tid thread.ID, lid peer.ID, rid cid.Cid) error
+
+// deliveryEntry is a record waiting for delivery to the peer.
+type deliveryEntry struct {
+	tid   thread.ID
+	lid   peer.ID
+	rid   cid.Cid
+	added int64
+}
+
+// deliveryQueue keeps records that failed to reach a peer and replays them with
+// exponential backoff until delivered. Pending entries are persisted in the
+// datastore, so deliveries survive restarts.
+type deliveryQueue struct {
+	ctx     context.Context
+	clock   clock.Clock
+	store   ds.Datastore
+	deliver deliverFunc
+
+	mx       sync.Mutex
+	status   map[peer.ID]*core.PeerSyncStatus
+	active   map[peer.ID]struct{}
+	attempts map[ds.Key]int // failed redeliveries of entries since the start
+}
+
+func newDeliveryQueue(ctx context.Context, clk clock.Clock, store ds.Datastore, deliver deliverFunc) (*deliveryQueue, error) {
+	q := &deliveryQueue{
+		ctx:      ctx,
+		clock:    clock.OrNew(clk),
+		store:    store,
+		deliver:  deliver,
+		status:   make(map[peer.ID]*core.PeerSyncStatus),
+		active:   make(map[peer.ID]struct{}),
+		attempts: make(map[ds.Key]int),
+	}
+
+	// restore peers with pending deliveries left from the previous run
+	res, err := store.Query(query.Query{Prefix: deliveryPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		pid, _, _, err := parseDeliveryKey(ds.RawKey(r.Key))
+		if err != nil {
+			log.Warnf("skipping malformed delivery entry %s: %v", r.Key, err)
+			continue
+		}
+		st := q.peerStatus(pid)
+		st.Pending++
+		st.NextAttempt = q.clock.Now()
+	}
+	return q, nil
+}
+
+// Add a record to the peer's delivery queue.
+func (q *deliveryQueue) Add(pid peer.ID, tid thread.ID, lid peer.ID, rid cid.Cid, cause error) error {
+	key := deliveryKey(pid, tid, rid)
+	exist, err := q.store.Has(key)
+	if err != nil {
+		return err
+	} else if !exist {
+		value := make([]byte, 8, 8+len(lid))
+		binary.BigEndian.PutUint64(value, uint64(q.clock.Now().UnixNano()))
+		value = append(value, lid...)
+		if err := q.store.Put(key, value); err != nil {
+			return err
+		}
+	}
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	st := q.peerStatus(pid)
+	if !exist {
+		st.Pending++
+	}
+	q.failed(st, cause)
+	return nil
+}
+
+// Delivered reports a successful direct delivery to the peer.
+// Pending deliveries are retried right away, since the peer is reachable again.
+func (q *deliveryQueue) Delivered(pid peer.ID) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	st := q.peerStatus(pid)
+	q.succeeded(st)
+	st.NextAttempt = st.LastSuccess
+}
+
+// PurgeThread removes all pending deliveries of the thread.
+func (q *deliveryQueue) PurgeThread(tid thread.ID) error {
+	res, err := q.store.Query(query.Query{Prefix: deliveryPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	for _, e := range entries {
+		pid, etid, _, err := parseDeliveryKey(ds.RawKey(e.Key))
+		if err != nil || etid != tid {
+			continue
+		}
+		if err := q.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+		delete(q.attempts, ds.RawKey(e.Key))
+		if st, ok := q.status[pid]; ok && st.Pending > 0 {
+			st.Pending--
+		}
+	}
+	return nil
+}
+
+// Status returns a snapshot of the delivery status of all known peers.
+func (q *deliveryQueue) Status() map[peer.ID]core.PeerSyncStatus {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	res := make(map[peer.ID]core.PeerSyncStatus, len(q.status))
+	for pid, st := range q.status {
+		res[pid] = *st
+	}
+	return res
+}
+
+// Run replays pending deliveries until the context is cancelled.
+func (q *deliveryQueue) Run() {
+	tick := q.clock.NewTicker(DeliveryPollInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-tick.Chan():
+			var (
+				now   = q.clock.Now()
+				ready []peer.ID
+			)
+			q.mx.Lock()
+			for pid, st := range q.status {
+				if _, busy := q.active[pid]; busy || st.Pending == 0 || st.NextAttempt.After(now) {
+					continue
+				}
+				q.active[pid] = struct{}{}
+				ready = append(ready, pid)
+			}
+			q.mx.Unlock()
+
+			for _, pid := range ready {
+				go func(pid peer.ID) {
+					q.replay(pid)
+					q.mx.Lock()
+					delete(q.active, pid)
+					q.mx.Unlock()
+				}(pid)
+			}
+		}
+	}
+}
+
+// replay pending deliveries for the peer in the order they were added,
+// stopping at the first failure. Records failing DeliveryMaxAttempts times
+// are dropped, and the replay moves on to the next one.
+func (q *deliveryQueue) replay(pid peer.ID) {
+	entries, err := q.pending(pid)
+	if err != nil {
+		log.Errorf("loading pending deliveries for %s failed: %v", pid, err)
+		return
+	}
+
+	for _, e := range entries {
+		key := deliveryKey(pid, e.tid, e.rid)
+		err := q.deliver(q.ctx, pid, e.tid, e.lid, e.rid)
+
+		q.mx.Lock()
+		st := q.peerStatus(pid)
+		if err != nil {
+			q.failed(st, err)
+			if refusedByPeer(err) {
+				q.attempts[key]++
+			}
+			attempts := q.attempts[key]
+			q.mx.Unlock()
+			if attempts < DeliveryMaxAttempts {
+				log.Debugf("redelivery of record %s to %s failed (attempt %d): %v", e.rid, pid, attempts, err)
+				return
+			}
+			log.Errorf("dropping record %s for %s after %d failed deliveries: %v", e.rid, pid, attempts, err)
+			q.remove(pid, key, true)
+			continue
+		}
+		q.succeeded(st)
+		q.mx.Unlock()
+
+		q.remove(pid, key, false)
+		log.Debugf("record %s redelivered to %s", e.rid, pid)
+	}
+}
+
+// remove deletes a delivered or dropped entry of the peer.
+func (q *deliveryQueue) remove(pid peer.ID, key ds.Key, dropped bool) {
+	if err := q.store.Delete(key); err != nil {
+		log.Errorf("removing delivery entry %s failed: %v", key, err)
+		return
+	}
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	delete(q.attempts, key)
+	st := q.peerStatus(pid)
+	if st.Pending > 0 {
+		st.Pending--
+	}
+	if dropped {
+		st.Dropped++
+	}
+}
+
+func (q *deliveryQueue) pending(pid peer.ID) ([]deliveryEntry, error) {
+	res, err := q.store.Query(query.Query{Prefix: deliveryPrefix.ChildString(pid.Pretty()).String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var entries []deliveryEntry
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		_, tid, rid, err := parseDeliveryKey(ds.RawKey(r.Key))
+		if err != nil || len(r.Value) < 8 {
+			log.Warnf("skipping malformed delivery entry %s", r.Key)
+			continue
+		}
+		lid, err := peer.IDFromBytes(r.Value[8:])
+		if err != nil {
+			log.Warnf("skipping delivery entry %s with bad log ID: %v", r.Key, err)
+			continue
+		}
+		entries = append(entries, deliveryEntry{
+			tid:   tid,
+			lid:   lid,
+			rid:   rid,
+			added: int64(binary.BigEndian.Uint64(r.Value[:8])),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].added < entries[j].added })
+	return entries, nil
+}
+
+// Should be called with the lock held.
+func (q *deliveryQueue) peerStatus(pid peer.ID) *core.PeerSyncStatus {
+	st, ok := q.status[pid]
+	if !ok {
+		st = &core.PeerSyncStatus{}
+		q.status[pid] = st
+	}
+	return st
+}
+
+// Should be called with the lock held.
+func (q *deliveryQueue) succeeded(st *core.PeerSyncStatus) {
+	st.Attempts = 0
+	st.LastError = nil
+	st.LastAttempt = q.clock.Now()
+	st.LastSuccess = st.LastAttempt
+}
+
+// Should be called with the lock held.
+func (q *deliveryQueue) failed(st *core.PeerSyncStatus, err error) {
+	st.Attempts++
+	st.LastError = err
+	st.LastAttempt = q.clock.Now()
+
+	backoff := DeliveryInitialBackoff
+	for i := 1; i < st.Attempts && backoff < DeliveryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > DeliveryMaxBackoff {
+		backoff = DeliveryMaxBackoff
+	}
+	st.NextAttempt = st.LastAttempt.Add(backoff)
+}
+
+// refusedByPeer returns whether a delivery failed with an error returned by the peer,
+// rather than failing to reach it.
+func refusedByPeer(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if s, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+			switch s.GRPCStatus().Code() {
+			case codes.OK, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted:
+				return false
+			default:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func deliveryKey(pid peer.ID, tid thread.ID, rid cid.Cid) ds.Key {
+	return deliveryPrefix.ChildString(pid.Pretty()).ChildString(tid.String()).ChildString(rid.String())
+}
+
+func parseDeliveryKey(key ds.Key) (pid peer.ID, tid thread.ID, rid cid.Cid, err error) {
+	parts := key.Namespaces()
+	if len(parts) != 4 {
+		err = fmt.Errorf("unexpected key length %d", len(parts))
+		return
+	}
+	if pid, err = peer.Decode(parts[1]); err != nil {
+		return
+	}
+	if tid, err = thread.Decode(parts[2]); err != nil {
+		return
+	}
+	rid, err = cid.Decode(parts[3])
+	return
+}
@@ -0,0 +1,218 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	"github.com/textileio/go-threads/core/thread"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNet_DeliveryQueueReplay(t *testing.T) {
+	DeliveryPollInterval = time.Millisecond * 10
+	DeliveryInitialBackoff = time.Millisecond * 10
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		store       = syncds.MutexWrap(ds.NewMapDatastore())
+		pid         = tu.RandPeerIDFatal(t)
+		lid         = tu.RandPeerIDFatal(t)
+		tid         = thread.NewIDV1(thread.Raw, 32)
+		seq         = generateSequence(cid.Undef, 5)
+
+		mx        sync.Mutex
+		online    bool
+		delivered []cid.Cid
+	)
+	defer cancel()
+
+	deliver := func(_ context.Context, _ peer.ID, _ thread.ID, _ peer.ID, rid cid.Cid) error {
+		mx.Lock()
+		defer mx.Unlock()
+		if !online {
+			return errors.New("offline")
+		}
+		delivered = append(delivered, rid)
+		return nil
+	}
+
+	q, err := newDeliveryQueue(ctx, nil, store, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range seq {
+		if err := q.Add(pid, tid, lid, rec.Cid(), errors.New("offline")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// re-adding the same record must not be counted twice
+	if err := q.Add(pid, tid, lid, seq[0].Cid(), errors.New("offline")); err != nil {
+		t.Fatal(err)
+	}
+	if st := q.Status()[pid]; st.Pending != len(seq) || st.LastError == nil {
+		t.Fatalf("unexpected status before replay: %+v", st)
+	}
+
+	// pending deliveries must be restored from the datastore
+	q, err = newDeliveryQueue(ctx, nil, store, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := q.Status()[pid]; st.Pending != len(seq) {
+		t.Fatalf("expected %d restored deliveries, got %d", len(seq), st.Pending)
+	}
+
+	mx.Lock()
+	online = true
+	mx.Unlock()
+	go q.Run()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for q.Status()[pid].Pending > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("deliveries not replayed in time: %+v", q.Status()[pid])
+		}
+		time.Sleep(DeliveryPollInterval)
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+	if len(delivered) != len(seq) {
+		t.Fatalf("expected %d delivered records, got %d", len(seq), len(delivered))
+	}
+	for i, rec := range seq {
+		if !delivered[i].Equals(rec.Cid()) {
+			t.Fatalf("record %d delivered out of order", i)
+		}
+	}
+	if st := q.Status()[pid]; st.LastError != nil || st.LastSuccess.IsZero() {
+		t.Fatalf("unexpected status after replay: %+v", st)
+	}
+}
+
+func TestNet_DeliveryQueuePurgeThread(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = syncds.MutexWrap(ds.NewMapDatastore())
+		pid   = tu.RandPeerIDFatal(t)
+		lid   = tu.RandPeerIDFatal(t)
+		tid1  = thread.NewIDV1(thread.Raw, 32)
+		tid2  = thread.NewIDV1(thread.Raw, 32)
+		seq   = generateSequence(cid.Undef, 4)
+	)
+
+	q, err := newDeliveryQueue(ctx, nil, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rec := range seq {
+		tid := tid1
+		if i%2 == 1 {
+			tid = tid2
+		}
+		if err := q.Add(pid, tid, lid, rec.Cid(), errors.New("offline")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := q.PurgeThread(tid1); err != nil {
+		t.Fatal(err)
+	}
+	if st := q.Status()[pid]; st.Pending != 2 {
+		t.Fatalf("expected 2 pending deliveries, got %d", st.Pending)
+	}
+	entries, err := q.pending(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.tid != tid2 {
+			t.Fatalf("delivery of purged thread %s left in the queue", e.tid)
+		}
+	}
+}
+
+func TestNet_DeliveryQueuePoisonEntry(t *testing.T) {
+	DeliveryPollInterval = time.Millisecond * 10
+	DeliveryInitialBackoff = time.Millisecond * 10
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		store       = syncds.MutexWrap(ds.NewMapDatastore())
+		pid         = tu.RandPeerIDFatal(t)
+		lid         = tu.RandPeerIDFatal(t)
+		tid         = thread.NewIDV1(thread.Raw, 32)
+		seq         = generateSequence(cid.Undef, 3)
+
+		mx        sync.Mutex
+		online    bool
+		delivered []cid.Cid
+	)
+	defer cancel()
+
+	// the peer refuses the first record, and is offline at first
+	deliver := func(_ context.Context, _ peer.ID, _ thread.ID, _ peer.ID, rid cid.Cid) error {
+		mx.Lock()
+		defer mx.Unlock()
+		if !online {
+			return errors.New("offline")
+		}
+		if rid.Equals(seq[0].Cid()) {
+			return status.Error(codes.InvalidArgument, "refused")
+		}
+		delivered = append(delivered, rid)
+		return nil
+	}
+
+	q, err := newDeliveryQueue(ctx, nil, store, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range seq {
+		if err := q.Add(pid, tid, lid, rec.Cid(), errors.New("offline")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go q.Run()
+
+	// failing to reach the peer doesn't count against the records
+	time.Sleep(DeliveryInitialBackoff * time.Duration(DeliveryMaxAttempts+2))
+	if st := q.Status()[pid]; st.Pending != len(seq) || st.Dropped != 0 {
+		t.Fatalf("unexpected status while offline: %+v", st)
+	}
+
+	// a direct delivery resets the backoff
+	q.Delivered(pid)
+	if st := q.Status()[pid]; st.Attempts != 0 || st.LastError != nil || st.NextAttempt.After(time.Now()) {
+		t.Fatalf("unexpected status after direct delivery: %+v", st)
+	}
+
+	mx.Lock()
+	online = true
+	mx.Unlock()
+	deadline := time.Now().Add(time.Second * 10)
+	for q.Status()[pid].Pending > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("deliveries not replayed in time: %+v", q.Status()[pid])
+		}
+		time.Sleep(DeliveryPollInterval)
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+	if len(delivered) != 2 || !delivered[0].Equals(seq[1].Cid()) || !delivered[1].Equals(seq[2].Cid()) {
+		t.Fatalf("expected the records after the refused one to be delivered, got %v", delivered)
+	}
+	if st := q.Status()[pid]; st.Dropped != 1 {
+		t.Fatalf("expected the refused record to be dropped, got %+v", st)
+	}
+}
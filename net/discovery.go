@@ -0,0 +1,134 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var (
+	// DiscoveryInterval is the interval between advertising stored threads and
+	// looking up their replicators, if peer routing is configured.
+	DiscoveryInterval = time.Hour
+
+	// DiscoveryTimeout is the maximum duration of advertising a single thread and looking up its replicators.
+	DiscoveryTimeout = time.Minute
+
+	// MaxDiscoveredReplicators is the maximum number of replicators looked up per thread.
+	MaxDiscoveredReplicators = 20
+
+	errNoPeerRouting = errors.New("peer routing is not configured")
+)
+
+// rendezvousPrefix namespaces thread rendezvous keys in the content routing.
+const rendezvousPrefix = "/threads/rendezvous/"
+
+// findPeer resolves the addresses of a peer with the configured peer routing,
+// unless they are already known.
+func (n *net) findPeer(ctx context.Context, pid peer.ID) error {
+	if len(n.host.Peerstore().Addrs(pid)) > 0 {
+		return nil
+	}
+	if n.routing == nil {
+		return errNoPeerRouting
+	}
+	ai, err := n.routing.FindPeer(ctx, pid)
+	if err != nil {
+		return err
+	}
+	n.host.Peerstore().AddAddrs(ai.ID, ai.Addrs, pstore.AddressTTL)
+	return nil
+}
+
+// threadRendezvous returns the key advertised by replicators of a thread. The key is derived
+// from the service key, so only thread participants can tell which thread it belongs to.
+func (n *net) threadRendezvous(tid thread.ID) (cid.Cid, bool, error) {
+	sk, err := n.store.ServiceKey(tid)
+	if err != nil || sk == nil {
+		return cid.Undef, false, err
+	}
+	data := append([]byte(rendezvousPrefix), tid.Bytes()...)
+	h, err := mh.Sum(append(data, sk.Bytes()...), mh.SHA2_256, -1)
+	if err != nil {
+		return cid.Undef, false, err
+	}
+	return cid.NewCidV1(cid.Raw, h), true, nil
+}
+
+// discoverThread advertises participation in a thread and schedules
+// log and record updates from the other replicators found.
+func (n *net) discoverThread(ctx context.Context, tid thread.ID) error {
+	key, ok, err := n.threadRendezvous(tid)
+	if err != nil || !ok {
+		return err
+	}
+	if err = n.routing.Provide(ctx, key, true); err != nil {
+		return err
+	}
+	for ai := range n.routing.FindProvidersAsync(ctx, key, MaxDiscoveredReplicators) {
+		if ai.ID == n.host.ID() {
+			continue
+		}
+		log.Debugf("discovered replicator %s (thread=%s)", ai.ID, tid)
+		n.host.Peerstore().AddAddrs(ai.ID, ai.Addrs, pstore.AddressTTL)
+		if n.queueGetLogs.Schedule(ai.ID, tid, callPriorityLow, n.updateLogsFromPeer) {
+			log.Debugf("log information update for thread %s from %s scheduled", tid, ai.ID)
+		}
+		if n.queueGetRecords.Schedule(ai.ID, tid, callPriorityLow, n.updateRecordsFromPeer) {
+			log.Debugf("record update for thread %s from %s scheduled", tid, ai.ID)
+		}
+	}
+	return ctx.Err()
+}
+
+// discoverThreadAsync runs discovery of a single thread in the background, e.g., once it's created or added.
+func (n *net) discoverThreadAsync(tid thread.ID) {
+	if n.routing == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(n.ctx, DiscoveryTimeout)
+		defer cancel()
+		if err := n.discoverThread(ctx, tid); err != nil && n.ctx.Err() == nil {
+			log.Warnf("discovering replicators of thread %s: %v", tid, err)
+		}
+	}()
+}
+
+// startDiscovery periodically advertises all threads and looks up their replicators.
+// Advertisements expire in the content routing, so they're refreshed on every cycle.
+func (n *net) startDiscovery() {
+	tick := n.clock.NewTicker(DiscoveryInterval)
+	defer tick.Stop()
+
+	cursor := newThreadCursor(n.store)
+	for {
+		cursor.Reset()
+		for {
+			tid, ok, err := cursor.Next()
+			if err != nil {
+				log.Errorf("error listing threads: %s", err)
+				return
+			} else if !ok {
+				break
+			}
+			ctx, cancel := context.WithTimeout(n.ctx, DiscoveryTimeout)
+			if err = n.discoverThread(ctx, tid); err != nil && n.ctx.Err() == nil {
+				log.Warnf("discovering replicators of thread %s: %v", tid, err)
+			}
+			cancel()
+		}
+
+		select {
+		case <-tick.Chan():
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
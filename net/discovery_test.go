@@ -0,0 +1,167 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/routing"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+func TestNet_Discovery(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+	n3 := makeNetwork(t).(*net)
+	defer n3.Close()
+
+	table := newMockRoutingTable()
+	for _, n := range []*net{n1, n2, n3} {
+		n.routing = table.router(n.host)
+	}
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.discoverThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// n2 only knows the thread key, replicators are found by the rendezvous key
+	if err = n2.addThread(thread.Info{ID: info.ID, Key: info.Key}); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.discoverThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	info2, err := n2.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info2.Logs) != 1 || info2.Logs[0].ID != info.Logs[0].ID {
+		t.Fatalf("expected log of the discovered replicator, got %d logs", len(info2.Logs))
+	}
+
+	// threads with other keys have other rendezvous keys
+	other := createThread(t, ctx, n3)
+	k1, _, err := n1.threadRendezvous(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k3, _, err := n3.threadRendezvous(other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1.Equals(k3) {
+		t.Fatal("expected rendezvous keys to differ")
+	}
+
+	// peers without known addresses are looked up
+	if len(n2.host.Peerstore().Addrs(n3.host.ID())) != 0 {
+		t.Fatal("expected no addresses of the peer")
+	}
+	if err = n2.findPeer(ctx, n3.host.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if len(n2.host.Peerstore().Addrs(n3.host.ID())) == 0 {
+		t.Fatal("expected addresses of the peer to be found")
+	}
+}
+
+// mockRoutingTable is an in-memory routing shared by test hosts.
+type mockRoutingTable struct {
+	sync.Mutex
+	hosts     map[peer.ID]host.Host
+	providers map[cid.Cid][]peer.ID
+}
+
+func newMockRoutingTable() *mockRoutingTable {
+	return &mockRoutingTable{
+		hosts:     make(map[peer.ID]host.Host),
+		providers: make(map[cid.Cid][]peer.ID),
+	}
+}
+
+func (t *mockRoutingTable) router(h host.Host) routing.Routing {
+	t.Lock()
+	defer t.Unlock()
+	t.hosts[h.ID()] = h
+	return &mockRouter{table: t, self: h.ID()}
+}
+
+type mockRouter struct {
+	table *mockRoutingTable
+	self  peer.ID
+}
+
+var _ routing.Routing = (*mockRouter)(nil)
+
+func (r *mockRouter) Provide(_ context.Context, key cid.Cid, _ bool) error {
+	r.table.Lock()
+	defer r.table.Unlock()
+	for _, p := range r.table.providers[key] {
+		if p == r.self {
+			return nil
+		}
+	}
+	r.table.providers[key] = append(r.table.providers[key], r.self)
+	return nil
+}
+
+func (r *mockRouter) FindProvidersAsync(_ context.Context, key cid.Cid, count int) <-chan peer.AddrInfo {
+	r.table.Lock()
+	defer r.table.Unlock()
+	ch := make(chan peer.AddrInfo, len(r.table.providers[key]))
+	for i, p := range r.table.providers[key] {
+		if i == count {
+			break
+		}
+		ch <- peer.AddrInfo{ID: p, Addrs: r.table.hosts[p].Addrs()}
+	}
+	close(ch)
+	return ch
+}
+
+func (r *mockRouter) FindPeer(_ context.Context, pid peer.ID) (peer.AddrInfo, error) {
+	r.table.Lock()
+	defer r.table.Unlock()
+	h, ok := r.table.hosts[pid]
+	if !ok {
+		return peer.AddrInfo{}, routing.ErrNotFound
+	}
+	return peer.AddrInfo{ID: pid, Addrs: h.Addrs()}, nil
+}
+
+func (r *mockRouter) PutValue(context.Context, string, []byte, ...routing.Option) error {
+	return routing.ErrNotSupported
+}
+
+func (r *mockRouter) GetValue(context.Context, string, ...routing.Option) ([]byte, error) {
+	return nil, routing.ErrNotSupported
+}
+
+func (r *mockRouter) SearchValue(context.Context, string, ...routing.Option) (<-chan []byte, error) {
+	return nil, routing.ErrNotSupported
+}
+
+func (r *mockRouter) Bootstrap(context.Context) error {
+	return nil
+}
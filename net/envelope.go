@@ -0,0 +1,206 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// gRPC metadata key for advertising the supported record envelope version
+	envelopeVersionHeader = "x-threads-envelope"
+	// peerstore key of the envelope version supported by a peer
+	envelopeVersionKey = "threads/envelope"
+	// legacy metadata prefix for the record extensions
+	extensionsPrefix = "ext/"
+)
+
+// MaxExtensionsSize is the byte limit on the encoded extensions of a record. Records
+// carrying larger extensions are refused.
+var MaxExtensionsSize = 8 << 10
+
+var extensionsStorePrefix = ds.NewKey("/extensions")
+
+// recordExtensions keeps the extensions of records, as the record node itself doesn't
+// carry them. Extensions are dropped along with the records or threads.
+type recordExtensions struct {
+	store ds.Datastore
+}
+
+func newRecordExtensions(store ds.Datastore) *recordExtensions {
+	return &recordExtensions{store: store}
+}
+
+// Get returns the extensions of the record, or nil if it has none.
+func (x *recordExtensions) Get(tid thread.ID, rid cid.Cid) ([]byte, error) {
+	v, err := x.store.Get(extensionsKey(tid, rid))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	return v, err
+}
+
+// Put saves the extensions of the record.
+func (x *recordExtensions) Put(tid thread.ID, rid cid.Cid, ext []byte) error {
+	if len(ext) > MaxExtensionsSize {
+		return fmt.Errorf("record extensions size %d exceeds the limit of %d bytes", len(ext), MaxExtensionsSize)
+	}
+	return x.store.Put(extensionsKey(tid, rid), ext)
+}
+
+// Delete removes the extensions of the record.
+func (x *recordExtensions) Delete(tid thread.ID, rid cid.Cid) error {
+	return x.store.Delete(extensionsKey(tid, rid))
+}
+
+// PurgeThread removes the extensions of all records of the thread.
+func (x *recordExtensions) PurgeThread(tid thread.ID) error {
+	res, err := x.store.Query(query.Query{Prefix: extensionsStorePrefix.ChildString(tid.String()).String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := x.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extensionsKey(tid thread.ID, rid cid.Cid) ds.Key {
+	return extensionsStorePrefix.ChildString(tid.String()).ChildString(rid.String())
+}
+
+// saveExtensions keeps record extensions, as the record node itself doesn't carry them.
+func (n *net) saveExtensions(tid thread.ID, rec core.Record) error {
+	r, ok := rec.(interface{ RawExtensions() []byte })
+	if !ok || len(r.RawExtensions()) == 0 {
+		return nil
+	}
+	return n.extensions.Put(tid, rec.Cid(), r.RawExtensions())
+}
+
+// loadExtensions attaches saved extensions to a record loaded from the blockstore.
+// Extensions saved in the thread metadata by older versions are still read.
+func (n *net) loadExtensions(tid thread.ID, rec core.Record) error {
+	r, ok := rec.(*cbor.Record)
+	if !ok {
+		return nil
+	}
+	ext, err := n.extensions.Get(tid, rec.Cid())
+	if err != nil {
+		return err
+	}
+	if ext == nil {
+		legacy, err := n.store.GetBytes(tid, extensionsPrefix+rec.Cid().String())
+		if err != nil || legacy == nil {
+			return err
+		}
+		ext = *legacy
+	}
+	r.SetRawExtensions(ext)
+	return nil
+}
+
+// peerEnvelopeVersion returns the record envelope version supported by a peer, as
+// advertised by the peer or negotiated with Hello. Peers which predate the negotiation
+// only support v1. Others are assumed to support the current version until they
+// advertise otherwise.
+func (n *net) peerEnvelopeVersion(pid peer.ID) int32 {
+	v, err := n.host.Peerstore().Get(pid, envelopeVersionKey)
+	if err == nil {
+		if version, ok := v.(int32); ok {
+			return version
+		}
+	}
+	if !n.peerSupports(pid, core.FeatureEnvelopeV2) {
+		return cbor.EnvelopeV1
+	}
+	return cbor.EnvelopeVersion
+}
+
+// setPeerEnvelopeVersion saves the envelope version advertised in gRPC metadata.
+func (n *net) setPeerEnvelopeVersion(pid peer.ID, md metadata.MD) {
+	vals := md.Get(envelopeVersionHeader)
+	if len(vals) == 0 {
+		return
+	}
+	version, err := strconv.Atoi(vals[0])
+	if err != nil || version < cbor.EnvelopeV1 {
+		return
+	}
+	if err := n.host.Peerstore().Put(pid, envelopeVersionKey, int32(version)); err != nil {
+		log.Errorf("saving envelope version of %s: %v", pid, err)
+	}
+}
+
+// downgradeRecords adapts the records to the envelope version supported by a peer.
+func (n *net) downgradeRecords(pid peer.ID, recs []*pb.Log_Record) []*pb.Log_Record {
+	version := n.peerEnvelopeVersion(pid)
+	if version >= cbor.EnvelopeVersion {
+		return recs
+	}
+	down := make([]*pb.Log_Record, len(recs))
+	for i, r := range recs {
+		down[i] = cbor.DowngradeRecord(r, version)
+	}
+	return down
+}
+
+// envelopeServerInterceptor learns the envelope version of calling peers and
+// advertises the version supported by the host in response headers.
+func (n *net) envelopeServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if pid, err := peerIDFromContext(ctx); err == nil {
+				n.setPeerEnvelopeVersion(pid, md)
+			}
+		}
+		header := metadata.Pairs(envelopeVersionHeader, strconv.Itoa(cbor.EnvelopeVersion))
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			log.Debugf("setting envelope version header: %v", err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// envelopeClientInterceptor advertises the envelope version supported by the
+// host and learns the version of the called peer from response headers.
+func (n *net) envelopeClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		var header metadata.MD
+		ctx = metadata.AppendToOutgoingContext(ctx, envelopeVersionHeader, strconv.Itoa(cbor.EnvelopeVersion))
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		if pid, perr := peer.Decode(cc.Target()); perr == nil {
+			n.setPeerEnvelopeVersion(pid, header)
+		}
+		return err
+	}
+}
@@ -0,0 +1,527 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	syncds "github.com/ipfs/go-datastore/sync"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	format "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/logstore/lstoremem"
+)
+
+var ephemeralPrefix = datastore.NewKey("/ephemeral")
+
+// ephemeral keeps threads created or added with core.WithEphemeral in memory only.
+// Their logstore entries and blocks never reach the persistent stores, so they're
+// lost on shutdown. Threads are marked in the datastore though, so the entries other
+// stores keep for them, e.g., body indexes, are purged on startup.
+type ephemeral struct {
+	store  *ephemeralLogstore
+	bstore bs.Blockstore
+	dag    format.DAGService
+	marks  datastore.Datastore
+}
+
+func newEphemeral(persist lstore.Logstore, marks datastore.Datastore) *ephemeral {
+	mbs := bs.NewBlockstore(syncds.MutexWrap(datastore.NewMapDatastore()))
+	return &ephemeral{
+		store: &ephemeralLogstore{
+			Logstore: persist,
+			mem:      lstoremem.NewLogstore(),
+			threads:  make(map[thread.ID]struct{}),
+		},
+		bstore: mbs,
+		dag:    dag.NewDAGService(bserv.New(mbs, offline.Exchange(mbs))),
+		marks:  marks,
+	}
+}
+
+func ephemeralKey(id thread.ID) datastore.Key {
+	return ephemeralPrefix.ChildString(id.String())
+}
+
+// isEphemeral returns whether or not the thread is kept in memory only.
+func (n *net) isEphemeral(id thread.ID) bool {
+	return n.ephemeral.store.has(id)
+}
+
+// dagFor returns the dag service new blocks of the thread are added to.
+func (n *net) dagFor(id thread.ID) format.DAGService {
+	if n.isEphemeral(id) {
+		return n.ephemeral.dag
+	}
+	return n
+}
+
+// addEphemeralThread marks a new thread as ephemeral, before it's added to the logstore.
+func (n *net) addEphemeralThread(id thread.ID) error {
+	if !n.isEphemeral(id) {
+		if _, err := n.ephemeral.store.Logstore.GetThread(id); err == nil {
+			return fmt.Errorf("thread %s is already stored persistently", id)
+		}
+	}
+	if err := n.ephemeral.marks.Put(ephemeralKey(id), []byte{}); err != nil {
+		return err
+	}
+	n.ephemeral.store.add(id)
+	return nil
+}
+
+// forgetEphemeral drops the mark of a deleted thread, if it was ephemeral.
+func (n *net) forgetEphemeral(id thread.ID) error {
+	if err := n.ephemeral.marks.Delete(ephemeralKey(id)); err != nil && !errors.Is(err, datastore.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// purgeLostEphemeral purges the state of ephemeral threads lost on the last shutdown.
+func (n *net) purgeLostEphemeral() error {
+	res, err := n.ephemeral.marks.Query(query.Query{Prefix: ephemeralPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		id, err := thread.Decode(datastore.RawKey(e.Key).BaseNamespace())
+		if err != nil {
+			return fmt.Errorf("decoding ephemeral thread %s: %w", e.Key, err)
+		}
+		if n.isEphemeral(id) {
+			continue
+		}
+		if err = n.purgeThreadState(id); err != nil {
+			return err
+		}
+		if err = n.forgetEphemeral(id); err != nil {
+			return err
+		}
+		log.Debugf("purged lost ephemeral thread %s", id)
+	}
+	return nil
+}
+
+// ephemeralDAG reads blocks of ephemeral threads from memory, and other blocks
+// from the wrapped dag service. Blocks are added to the wrapped dag service,
+// blocks of ephemeral threads must be added with dagFor.
+type ephemeralDAG struct {
+	format.DAGService
+	mem *ephemeral
+}
+
+func (d *ephemeralDAG) Get(ctx context.Context, id cid.Cid) (format.Node, error) {
+	if node, err := d.mem.dag.Get(ctx, id); err == nil {
+		return node, nil
+	}
+	return d.DAGService.Get(ctx, id)
+}
+
+func (d *ephemeralDAG) GetMany(ctx context.Context, ids []cid.Cid) <-chan *format.NodeOption {
+	var missing []cid.Cid
+	out := make(chan *format.NodeOption, len(ids))
+	for _, id := range ids {
+		if node, err := d.mem.dag.Get(ctx, id); err == nil {
+			out <- &format.NodeOption{Node: node}
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		close(out)
+		return out
+	}
+	go func() {
+		defer close(out)
+		for opt := range d.DAGService.GetMany(ctx, missing) {
+			select {
+			case out <- opt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (d *ephemeralDAG) Remove(ctx context.Context, id cid.Cid) error {
+	if has, _ := d.mem.bstore.Has(id); has {
+		return d.mem.dag.Remove(ctx, id)
+	}
+	return d.DAGService.Remove(ctx, id)
+}
+
+func (d *ephemeralDAG) RemoveMany(ctx context.Context, ids []cid.Cid) error {
+	var mem, persist []cid.Cid
+	for _, id := range ids {
+		if has, _ := d.mem.bstore.Has(id); has {
+			mem = append(mem, id)
+		} else {
+			persist = append(persist, id)
+		}
+	}
+	if len(mem) > 0 {
+		if err := d.mem.dag.RemoveMany(ctx, mem); err != nil {
+			return err
+		}
+	}
+	if len(persist) > 0 {
+		return d.DAGService.RemoveMany(ctx, persist)
+	}
+	return nil
+}
+
+// ephemeralBlockstore reads blocks of ephemeral threads from memory, and other
+// blocks from the wrapped blockstore. Keys of ephemeral blocks are not listed,
+// so they're never collected by GC.
+type ephemeralBlockstore struct {
+	bs.Blockstore
+	mem *ephemeral
+}
+
+func (b *ephemeralBlockstore) Has(id cid.Cid) (bool, error) {
+	if has, err := b.mem.bstore.Has(id); err != nil || has {
+		return has, err
+	}
+	return b.Blockstore.Has(id)
+}
+
+func (b *ephemeralBlockstore) Get(id cid.Cid) (blocks.Block, error) {
+	if block, err := b.mem.bstore.Get(id); err == nil {
+		return block, nil
+	}
+	return b.Blockstore.Get(id)
+}
+
+func (b *ephemeralBlockstore) GetSize(id cid.Cid) (int, error) {
+	if size, err := b.mem.bstore.GetSize(id); err == nil {
+		return size, nil
+	}
+	return b.Blockstore.GetSize(id)
+}
+
+func (b *ephemeralBlockstore) DeleteBlock(id cid.Cid) error {
+	if has, _ := b.mem.bstore.Has(id); has {
+		return b.mem.bstore.DeleteBlock(id)
+	}
+	return b.Blockstore.DeleteBlock(id)
+}
+
+var _ lstore.Logstore = (*ephemeralLogstore)(nil)
+
+// ephemeralLogstore keeps ephemeral threads in an in-memory logstore, and other
+// threads in the wrapped logstore. Dumps cover persistent threads only.
+type ephemeralLogstore struct {
+	lstore.Logstore
+	mem lstore.Logstore
+
+	mx      sync.RWMutex
+	threads map[thread.ID]struct{}
+}
+
+func (l *ephemeralLogstore) has(id thread.ID) bool {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+	_, ok := l.threads[id]
+	return ok
+}
+
+func (l *ephemeralLogstore) add(id thread.ID) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.threads[id] = struct{}{}
+}
+
+// route returns the logstore keeping the thread.
+func (l *ephemeralLogstore) route(id thread.ID) lstore.Logstore {
+	if l.has(id) {
+		return l.mem
+	}
+	return l.Logstore
+}
+
+// ephemeralIDs returns the sorted IDs of ephemeral threads.
+func (l *ephemeralLogstore) ephemeralIDs() thread.IDSlice {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+	ids := make(thread.IDSlice, 0, len(l.threads))
+	for id := range l.threads {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (l *ephemeralLogstore) Close() error {
+	if err := l.Logstore.Close(); err != nil {
+		return err
+	}
+	return l.mem.Close()
+}
+
+func (l *ephemeralLogstore) Threads() (thread.IDSlice, error) {
+	ids, err := l.Logstore.Threads()
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, l.ephemeralIDs()...), nil
+}
+
+func (l *ephemeralLogstore) ThreadsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	page, err := l.Logstore.ThreadsAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lstore.PageThreads(append(page, l.ephemeralIDs()...), after, limit), nil
+}
+
+func (l *ephemeralLogstore) ThreadsFromKeys() (thread.IDSlice, error) {
+	ids, err := l.Logstore.ThreadsFromKeys()
+	if err != nil {
+		return nil, err
+	}
+	mids, err := l.mem.ThreadsFromKeys()
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, mids...), nil
+}
+
+func (l *ephemeralLogstore) ThreadsFromAddrs() (thread.IDSlice, error) {
+	ids, err := l.Logstore.ThreadsFromAddrs()
+	if err != nil {
+		return nil, err
+	}
+	mids, err := l.mem.ThreadsFromAddrs()
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, mids...), nil
+}
+
+func (l *ephemeralLogstore) ThreadsFromKeysAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	page, err := l.Logstore.ThreadsFromKeysAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	mpage, err := l.mem.ThreadsFromKeysAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lstore.PageThreads(append(page, mpage...), after, limit), nil
+}
+
+func (l *ephemeralLogstore) ThreadsFromAddrsAfter(after thread.ID, limit int) (thread.IDSlice, error) {
+	page, err := l.Logstore.ThreadsFromAddrsAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	mpage, err := l.mem.ThreadsFromAddrsAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lstore.PageThreads(append(page, mpage...), after, limit), nil
+}
+
+func (l *ephemeralLogstore) AddThread(info thread.Info) error {
+	return l.route(info.ID).AddThread(info)
+}
+
+func (l *ephemeralLogstore) GetThread(id thread.ID) (thread.Info, error) {
+	return l.route(id).GetThread(id)
+}
+
+// DeleteThread deletes the thread, and forgets it was ephemeral.
+func (l *ephemeralLogstore) DeleteThread(id thread.ID) error {
+	if err := l.route(id).DeleteThread(id); err != nil {
+		return err
+	}
+	l.mx.Lock()
+	delete(l.threads, id)
+	l.mx.Unlock()
+	return nil
+}
+
+func (l *ephemeralLogstore) AddLog(id thread.ID, info thread.LogInfo) error {
+	return l.route(id).AddLog(id, info)
+}
+
+func (l *ephemeralLogstore) GetLog(id thread.ID, lid peer.ID) (thread.LogInfo, error) {
+	return l.route(id).GetLog(id, lid)
+}
+
+func (l *ephemeralLogstore) GetManagedLogs(id thread.ID) ([]thread.LogInfo, error) {
+	return l.route(id).GetManagedLogs(id)
+}
+
+func (l *ephemeralLogstore) DeleteLog(id thread.ID, lid peer.ID) error {
+	return l.route(id).DeleteLog(id, lid)
+}
+
+func (l *ephemeralLogstore) GetInt64(id thread.ID, key string) (*int64, error) {
+	return l.route(id).GetInt64(id, key)
+}
+
+func (l *ephemeralLogstore) PutInt64(id thread.ID, key string, val int64) error {
+	return l.route(id).PutInt64(id, key, val)
+}
+
+func (l *ephemeralLogstore) GetString(id thread.ID, key string) (*string, error) {
+	return l.route(id).GetString(id, key)
+}
+
+func (l *ephemeralLogstore) PutString(id thread.ID, key string, val string) error {
+	return l.route(id).PutString(id, key, val)
+}
+
+func (l *ephemeralLogstore) GetBool(id thread.ID, key string) (*bool, error) {
+	return l.route(id).GetBool(id, key)
+}
+
+func (l *ephemeralLogstore) PutBool(id thread.ID, key string, val bool) error {
+	return l.route(id).PutBool(id, key, val)
+}
+
+func (l *ephemeralLogstore) GetBytes(id thread.ID, key string) (*[]byte, error) {
+	return l.route(id).GetBytes(id, key)
+}
+
+func (l *ephemeralLogstore) PutBytes(id thread.ID, key string, val []byte) error {
+	return l.route(id).PutBytes(id, key, val)
+}
+
+func (l *ephemeralLogstore) ClearMetadata(id thread.ID) error {
+	return l.route(id).ClearMetadata(id)
+}
+
+func (l *ephemeralLogstore) PubKey(id thread.ID, lid peer.ID) (crypto.PubKey, error) {
+	return l.route(id).PubKey(id, lid)
+}
+
+func (l *ephemeralLogstore) AddPubKey(id thread.ID, lid peer.ID, key crypto.PubKey) error {
+	return l.route(id).AddPubKey(id, lid, key)
+}
+
+func (l *ephemeralLogstore) PrivKey(id thread.ID, lid peer.ID) (crypto.PrivKey, error) {
+	return l.route(id).PrivKey(id, lid)
+}
+
+func (l *ephemeralLogstore) AddPrivKey(id thread.ID, lid peer.ID, key crypto.PrivKey) error {
+	return l.route(id).AddPrivKey(id, lid, key)
+}
+
+func (l *ephemeralLogstore) ReadKey(id thread.ID) (*sym.Key, error) {
+	return l.route(id).ReadKey(id)
+}
+
+func (l *ephemeralLogstore) AddReadKey(id thread.ID, key *sym.Key) error {
+	return l.route(id).AddReadKey(id, key)
+}
+
+func (l *ephemeralLogstore) ServiceKey(id thread.ID) (*sym.Key, error) {
+	return l.route(id).ServiceKey(id)
+}
+
+func (l *ephemeralLogstore) AddServiceKey(id thread.ID, key *sym.Key) error {
+	return l.route(id).AddServiceKey(id, key)
+}
+
+func (l *ephemeralLogstore) ClearKeys(id thread.ID) error {
+	return l.route(id).ClearKeys(id)
+}
+
+func (l *ephemeralLogstore) ClearLogKeys(id thread.ID, lid peer.ID) error {
+	return l.route(id).ClearLogKeys(id, lid)
+}
+
+func (l *ephemeralLogstore) LogsWithKeys(id thread.ID) (peer.IDSlice, error) {
+	return l.route(id).LogsWithKeys(id)
+}
+
+func (l *ephemeralLogstore) AddAddr(id thread.ID, lid peer.ID, addr ma.Multiaddr, ttl time.Duration) error {
+	return l.route(id).AddAddr(id, lid, addr, ttl)
+}
+
+func (l *ephemeralLogstore) AddAddrs(id thread.ID, lid peer.ID, addrs []ma.Multiaddr, ttl time.Duration) error {
+	return l.route(id).AddAddrs(id, lid, addrs, ttl)
+}
+
+func (l *ephemeralLogstore) SetAddr(id thread.ID, lid peer.ID, addr ma.Multiaddr, ttl time.Duration) error {
+	return l.route(id).SetAddr(id, lid, addr, ttl)
+}
+
+func (l *ephemeralLogstore) SetAddrs(id thread.ID, lid peer.ID, addrs []ma.Multiaddr, ttl time.Duration) error {
+	return l.route(id).SetAddrs(id, lid, addrs, ttl)
+}
+
+func (l *ephemeralLogstore) UpdateAddrs(id thread.ID, lid peer.ID, oldTTL time.Duration, newTTL time.Duration) error {
+	return l.route(id).UpdateAddrs(id, lid, oldTTL, newTTL)
+}
+
+func (l *ephemeralLogstore) Addrs(id thread.ID, lid peer.ID) ([]ma.Multiaddr, error) {
+	return l.route(id).Addrs(id, lid)
+}
+
+func (l *ephemeralLogstore) AddrStream(ctx context.Context, id thread.ID, lid peer.ID) (<-chan ma.Multiaddr, error) {
+	return l.route(id).AddrStream(ctx, id, lid)
+}
+
+func (l *ephemeralLogstore) ClearAddrs(id thread.ID, lid peer.ID) error {
+	return l.route(id).ClearAddrs(id, lid)
+}
+
+func (l *ephemeralLogstore) LogsWithAddrs(id thread.ID) (peer.IDSlice, error) {
+	return l.route(id).LogsWithAddrs(id)
+}
+
+func (l *ephemeralLogstore) AddrsEdge(id thread.ID) (uint64, error) {
+	return l.route(id).AddrsEdge(id)
+}
+
+func (l *ephemeralLogstore) AddHead(id thread.ID, lid peer.ID, head cid.Cid) error {
+	return l.route(id).AddHead(id, lid, head)
+}
+
+func (l *ephemeralLogstore) AddHeads(id thread.ID, lid peer.ID, heads []cid.Cid) error {
+	return l.route(id).AddHeads(id, lid, heads)
+}
+
+func (l *ephemeralLogstore) SetHead(id thread.ID, lid peer.ID, head cid.Cid) error {
+	return l.route(id).SetHead(id, lid, head)
+}
+
+func (l *ephemeralLogstore) SetHeads(id thread.ID, lid peer.ID, heads []cid.Cid) error {
+	return l.route(id).SetHeads(id, lid, heads)
+}
+
+func (l *ephemeralLogstore) Heads(id thread.ID, lid peer.ID) ([]cid.Cid, error) {
+	return l.route(id).Heads(id, lid)
+}
+
+func (l *ephemeralLogstore) ClearHeads(id thread.ID, lid peer.ID) error {
+	return l.route(id).ClearHeads(id, lid)
+}
+
+func (l *ephemeralLogstore) HeadsEdge(id thread.ID) (uint64, error) {
+	return l.route(id).HeadsEdge(id)
+}
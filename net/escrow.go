@@ -0,0 +1,266 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/net/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var escrowPrefix = ds.NewKey("/escrow")
+
+// EscrowThreadKey splits the key of a thread with Shamir secret sharing and deposits a share with
+// every recovery peer, which must have Config.KeyEscrow enabled. The shares are kept for the
+// recovery identity, independent of the host key, and only returned to a holder of it, so any
+// threshold of the peers can recover the key with RecoverThreadKey, but fewer learn nothing about
+// it. Escrowing the key again replaces the shares held by the peers.
+func (n *net) EscrowThreadKey(
+	ctx context.Context,
+	id thread.ID,
+	peers []peer.ID,
+	threshold int,
+	recovery thread.Identity,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if err := n.checkRecoveryPeers(peers); err != nil {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	if !info.Key.Defined() {
+		return fmt.Errorf("a service-key is required to escrow a thread key")
+	}
+
+	shares, err := util.SplitThreadKey(info.Key, len(peers), threshold)
+	if err != nil {
+		return err
+	}
+	for i, pid := range peers {
+		pk, sig, err := util.KeySharePayload{
+			Thread:    id.String(),
+			Peer:      pid.String(),
+			Share:     shares[i].Share,
+			Threshold: threshold,
+			KeyHash:   shares[i].KeyHash,
+		}.Sign(ctx, recovery)
+		if err != nil {
+			return err
+		}
+		req := &pb.PutKeyShareRequest{
+			Body: &pb.PutKeyShareRequest_Body{
+				ThreadID:    &pb.ProtoThreadID{ID: id},
+				Share:       shares[i].Share,
+				Threshold:   int32(threshold),
+				KeyHash:     shares[i].KeyHash,
+				RecoveryKey: pk,
+				Sig:         sig,
+			},
+		}
+		client, release, err := n.server.dial(pid)
+		if err != nil {
+			return fmt.Errorf("dial %s failed: %w", pid, err)
+		}
+		cctx, cancel := context.WithTimeout(ctx, PushTimeout)
+		_, err = client.PutKeyShare(cctx, req)
+		cancel()
+		release()
+		if err != nil {
+			return fmt.Errorf("escrowing key share with %s failed: %w", pid, err)
+		}
+	}
+	return nil
+}
+
+// RecoverThreadKey collects the shares of a thread key escrowed for the recovery identity from
+// the recovery peers, and reassembles the key, e.g., after losing the local keystore or the host
+// key. Shares are grouped by the key hash and threshold they were escrowed with, and the group of
+// most peers is combined, so a few stale or forged shares don't prevent the recovery. The key is
+// added to the thread if it's stored by the host, otherwise it can be passed to AddThread.
+func (n *net) RecoverThreadKey(
+	ctx context.Context,
+	id thread.ID,
+	peers []peer.ID,
+	recovery thread.Identity,
+) (thread.Key, error) {
+	if err := n.checkRecoveryPeers(peers); err != nil {
+		return thread.Key{}, err
+	}
+
+	var shares []util.KeyShare
+	for _, pid := range peers {
+		share, err := n.getKeyShare(ctx, id, pid, recovery)
+		if err != nil {
+			log.Warnf("getting key share of thread %s from %s: %v", id, pid, err)
+			continue
+		}
+		shares = append(shares, share)
+	}
+	key, groups, err := util.CombineKeyShares(shares)
+	if err != nil {
+		return thread.Key{}, err
+	}
+	if groups > 1 {
+		log.Warnf("key shares of thread %s were escrowed with %d keys", id, groups)
+	}
+
+	if err = n.withThreadLock(id, func() error {
+		if _, err := n.store.GetThread(id); errors.Is(err, lstore.ErrThreadNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := n.store.AddServiceKey(id, key.Service()); err != nil {
+			return err
+		}
+		if key.CanRead() {
+			return n.store.AddReadKey(id, key.Read())
+		}
+		return nil
+	}); err != nil {
+		return thread.Key{}, fmt.Errorf("adding recovered key: %w", err)
+	}
+	n.refreshThreadTopic(id)
+	return key, nil
+}
+
+func (n *net) checkRecoveryPeers(peers []peer.ID) error {
+	seen := make(map[peer.ID]struct{}, len(peers))
+	for _, pid := range peers {
+		if pid == n.host.ID() {
+			return fmt.Errorf("the host can't be a recovery peer")
+		}
+		if _, ok := seen[pid]; ok {
+			return fmt.Errorf("duplicate recovery peer %s", pid)
+		}
+		seen[pid] = struct{}{}
+	}
+	return nil
+}
+
+func (n *net) getKeyShare(ctx context.Context, id thread.ID, pid peer.ID, recovery thread.Identity) (util.KeyShare, error) {
+	pk, sig, err := util.KeySharePayload{Thread: id.String(), Peer: pid.String()}.Sign(ctx, recovery)
+	if err != nil {
+		return util.KeyShare{}, err
+	}
+	client, release, err := n.server.dial(pid)
+	if err != nil {
+		return util.KeyShare{}, fmt.Errorf("dial failed: %w", err)
+	}
+	defer release()
+	cctx, cancel := context.WithTimeout(ctx, PullTimeout)
+	defer cancel()
+	reply, err := client.GetKeyShare(cctx, &pb.GetKeyShareRequest{
+		Body: &pb.GetKeyShareRequest_Body{
+			ThreadID:    &pb.ProtoThreadID{ID: id},
+			RecoveryKey: pk,
+			Sig:         sig,
+		},
+	})
+	if err != nil {
+		return util.KeyShare{}, err
+	}
+	if reply.Threshold < 2 || len(reply.Share) == 0 {
+		return util.KeyShare{}, fmt.Errorf("malformed key share")
+	}
+	return util.KeyShare{Share: reply.Share, Threshold: int(reply.Threshold), KeyHash: reply.KeyHash}, nil
+}
+
+// PutKeyShare stores a thread key share escrowed for the signing recovery identity.
+func (s *server) PutKeyShare(ctx context.Context, req *pb.PutKeyShareRequest) (*pb.PutKeyShareReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.net.escrow == nil {
+		return nil, status.Error(codes.Unimplemented, "key escrow is disabled")
+	}
+	if req.Body == nil || req.Body.ThreadID == nil || len(req.Body.Share) == 0 || req.Body.Threshold < 2 {
+		return nil, status.Error(codes.InvalidArgument, "thread, share and threshold are required")
+	}
+	log.Debugf("received key share of thread %s from %s", req.Body.ThreadID.ID, pid)
+	recovery, err := util.KeySharePayload{
+		Thread:    req.Body.ThreadID.ID.String(),
+		Peer:      s.net.host.ID().String(),
+		Share:     req.Body.Share,
+		Threshold: int(req.Body.Threshold),
+		KeyHash:   req.Body.KeyHash,
+	}.Verify(req.Body.RecoveryKey, req.Body.Sig)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	data, err := json.Marshal(util.KeyShare{
+		Share:     req.Body.Share,
+		Threshold: int(req.Body.Threshold),
+		KeyHash:   req.Body.KeyHash,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err = s.net.escrow.Put(escrowKey(req.Body.ThreadID.ID, recovery), data); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.PutKeyShareReply{}, nil
+}
+
+// GetKeyShare returns a thread key share escrowed for the signing recovery identity.
+func (s *server) GetKeyShare(ctx context.Context, req *pb.GetKeyShareRequest) (*pb.GetKeyShareReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.net.escrow == nil {
+		return nil, status.Error(codes.Unimplemented, "key escrow is disabled")
+	}
+	if req.Body == nil || req.Body.ThreadID == nil {
+		return nil, status.Error(codes.InvalidArgument, "thread is required")
+	}
+	log.Debugf("received key share request of thread %s from %s", req.Body.ThreadID.ID, pid)
+
+	// shares are keyed by the recovery identity, so only its holder can collect them
+	recovery, err := util.KeySharePayload{
+		Thread: req.Body.ThreadID.ID.String(),
+		Peer:   s.net.host.ID().String(),
+	}.Verify(req.Body.RecoveryKey, req.Body.Sig)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	data, err := s.net.escrow.Get(escrowKey(req.Body.ThreadID.ID, recovery))
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "no key share escrowed")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var share util.KeyShare
+	if err = json.Unmarshal(data, &share); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.GetKeyShareReply{
+		Share:     share.Share,
+		Threshold: int32(share.Threshold),
+		KeyHash:   share.KeyHash,
+	}, nil
+}
+
+func escrowKey(id thread.ID, recovery thread.PubKey) ds.Key {
+	return escrowPrefix.ChildString(id.String()).ChildString(recovery.String())
+}
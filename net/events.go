@@ -0,0 +1,128 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// LifecycleBusCapacity is the buffer size of lifecycle event listeners.
+// Events are dropped for listeners with a full buffer, so emitting never blocks the network.
+var LifecycleBusCapacity = 64
+
+// DefaultEventLogSize is the number of recent events kept if Config.EventLogSize is not set.
+var DefaultEventLogSize = 256
+
+func (n *net) SubscribeEvents(ctx context.Context, opts ...core.SubOption) (<-chan core.LifecycleEvent, error) {
+	args := &core.SubOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+
+	filter := make(map[thread.ID]struct{})
+	for _, id := range args.ThreadIDs {
+		if err := id.Validate(); err != nil {
+			return nil, err
+		}
+		if id.Defined() {
+			if _, err := n.Validate(id, args.Token, true); err != nil {
+				return nil, err
+			}
+			filter[id] = struct{}{}
+		}
+	}
+
+	channel := make(chan core.LifecycleEvent)
+	listener := n.events.Listen()
+	go func() {
+		defer close(channel)
+		defer listener.Discard()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case i, ok := <-listener.Channel():
+				if !ok {
+					return
+				}
+				ev := i.(core.LifecycleEvent)
+				if len(filter) > 0 {
+					if _, ok := filter[ev.ThreadID]; !ok {
+						continue
+					}
+				}
+				select {
+				case channel <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return channel, nil
+}
+
+func (n *net) RecentEvents(_ context.Context) ([]core.LifecycleEvent, error) {
+	return n.recentEvents.List(), nil
+}
+
+func (n *net) DumpEvents(_ context.Context, w io.Writer) error {
+	for _, ev := range n.recentEvents.List() {
+		if _, err := fmt.Fprintln(w, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emit sends a lifecycle event to subscribers, and keeps it in the recent events.
+func (n *net) emit(ev core.LifecycleEvent) {
+	ev.Time = n.clock.Now()
+	n.recentEvents.Add(ev)
+	if err := n.events.Send(ev); err != nil {
+		log.Debugf("dropped %s event (thread=%s): %v", ev.Type, ev.ThreadID, err)
+	}
+}
+
+// emitPull sends the outcome of a thread pull from pid, or from all the thread peers if pid is empty.
+func (n *net) emitPull(tid thread.ID, pid peer.ID, err error) {
+	if err != nil {
+		n.emit(core.LifecycleEvent{Type: core.PullFailed, ThreadID: tid, PeerID: pid, Err: err})
+	} else {
+		n.emit(core.LifecycleEvent{Type: core.PullCompleted, ThreadID: tid, PeerID: pid})
+	}
+}
+
+// emitHeadsChanged sends an advance of log heads to rid, by records received from pid if set.
+func (n *net) emitHeadsChanged(tid thread.ID, lid, pid peer.ID, rid cid.Cid) {
+	n.emit(core.LifecycleEvent{Type: core.HeadsChanged, ThreadID: tid, LogID: lid, PeerID: pid, RecordID: rid})
+	n.emitLinkedUpdated(tid, lid, pid, rid)
+}
+
+// emitRejected sends a rejection of records of a log received from pid.
+func (n *net) emitRejected(tid thread.ID, lid, pid peer.ID, rid cid.Cid, err error) {
+	n.emit(core.LifecycleEvent{Type: core.RecordRejected, ThreadID: tid, LogID: lid, PeerID: pid, RecordID: rid, Err: err})
+}
+
+// notifyConnections emits PeerConnected events on the first connection to a peer.
+func (n *net) notifyConnections() {
+	n.host.Network().Notify(&network.NotifyBundle{
+		ConnectedF: func(nw network.Network, c network.Conn) {
+			if len(nw.ConnsToPeer(c.RemotePeer())) == 1 {
+				n.emit(core.LifecycleEvent{Type: core.PeerConnected, PeerID: c.RemotePeer()})
+			}
+		},
+		DisconnectedF: func(nw network.Network, c network.Conn) {
+			// the peer may come back running another version
+			if len(nw.ConnsToPeer(c.RemotePeer())) == 0 {
+				n.protocols.forget(c.RemotePeer())
+			}
+		},
+	})
+}
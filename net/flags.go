@@ -0,0 +1,167 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+const (
+	// flagsKey is the metadata key of the thread flags, stored in their string encoding.
+	flagsKey = "/flags"
+	// flagsSigKey is the metadata key of the flags signature by the thread owner, see signedFlags.
+	flagsSigKey = "/flags-sig"
+)
+
+var (
+	// ErrFlagsMismatch indicates a thread peer with other flags than the ones of the local thread.
+	ErrFlagsMismatch = errors.New("thread flags mismatch")
+
+	// ErrInvalidFlagsSig indicates thread flags with a bad signature.
+	ErrInvalidFlagsSig = errors.New("invalid thread flags signature")
+)
+
+// signedFlags is the signature of the thread flags by the log of the thread creator. The
+// creator's log is the writer of a single-writer thread.
+type signedFlags struct {
+	Signer []byte `json:"signer"`
+	Sig    []byte `json:"sig"`
+}
+
+type flagsPayload struct {
+	Thread string `json:"thread"`
+	Flags  string `json:"flags"`
+}
+
+func threadFlagsPayload(tid thread.ID, flags thread.Flags) ([]byte, error) {
+	return json.Marshal(flagsPayload{Thread: tid.String(), Flags: flags.String()})
+}
+
+// threadFlags returns the flags of a thread, empty if none are known.
+func (n *net) threadFlags(tid thread.ID) (thread.Flags, error) {
+	data, err := n.store.GetBytes(tid, flagsKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return thread.FlagsFromString(string(*data))
+}
+
+// threadFlagsSig returns the signature of the thread flags, nil if they aren't signed.
+func (n *net) threadFlagsSig(tid thread.ID) (*signedFlags, error) {
+	data, err := n.store.GetBytes(tid, flagsSigKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var sf signedFlags
+	if err = json.Unmarshal(*data, &sf); err != nil {
+		return nil, err
+	}
+	return &sf, nil
+}
+
+// setThreadFlags declares the flags of a thread. Once set, the flags can't be changed, so
+// declaring other flags fails with ErrFlagsMismatch. The signature is kept if given, so the
+// flags can be verified by the peers they're served to.
+func (n *net) setThreadFlags(tid thread.ID, flags thread.Flags, sig *signedFlags) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	current, err := n.threadFlags(tid)
+	if err != nil {
+		return err
+	}
+	if len(current) != 0 && !current.Equal(flags) {
+		return fmt.Errorf("%w: thread %s has flags %q, got %q", ErrFlagsMismatch, tid, current, flags)
+	}
+	if sig != nil {
+		if current, err := n.threadFlagsSig(tid); err != nil || current != nil {
+			return err
+		}
+		data, err := json.Marshal(sig)
+		if err != nil {
+			return err
+		}
+		if err = n.store.PutBytes(tid, flagsSigKey, data); err != nil {
+			return err
+		}
+	}
+	if len(current) != 0 {
+		return nil
+	}
+	return n.store.PutBytes(tid, flagsKey, []byte(flags.String()))
+}
+
+// signThreadFlags declares the flags of a new thread signed by the creator's log.
+func (n *net) signThreadFlags(ctx context.Context, tid thread.ID, lg thread.LogInfo, flags thread.Flags) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	signer, err := n.logSigner(ctx, tid, lg)
+	if err != nil {
+		return err
+	}
+	payload, err := threadFlagsPayload(tid, flags)
+	if err != nil {
+		return err
+	}
+	sig, err := signer.Sign(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("signing thread flags: %w", err)
+	}
+	pk, err := ic.MarshalPublicKey(signer.PubKey())
+	if err != nil {
+		return err
+	}
+	return n.setThreadFlags(tid, flags, &signedFlags{Signer: pk, Sig: sig})
+}
+
+// mergeThreadFlags verifies flags received from a peer, and declares them. Unsigned flags,
+// e.g., served by hosts of threads created before flags were signed, are ignored. The signer
+// of single-writer flags becomes the thread writer, so joiners enforce it as well.
+// The caller must hold the thread semaphore.
+func (n *net) mergeThreadFlags(tid thread.ID, flags thread.Flags, sig *signedFlags) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	if sig == nil {
+		log.Debugf("ignoring unsigned flags of thread %s", tid)
+		return nil
+	}
+	signer, err := ic.UnmarshalPublicKey(sig.Signer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlagsSig, err)
+	}
+	payload, err := threadFlagsPayload(tid, flags)
+	if err != nil {
+		return err
+	}
+	if ok, err := signer.Verify(payload, sig.Sig); err != nil || !ok {
+		return ErrInvalidFlagsSig
+	}
+	lid, err := peer.IDFromPublicKey(signer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlagsSig, err)
+	}
+	if flags.Has(thread.FlagSingleWriter) {
+		if err = n.setThreadWriter(tid, lid); err != nil {
+			return fmt.Errorf("%w: %v", ErrFlagsMismatch, err)
+		}
+	}
+	return n.setThreadFlags(tid, flags, sig)
+}
+
+// newThreadFlags returns the validated flags of the new thread options.
+func newThreadFlags(singleWriter bool, flags []string) (thread.Flags, error) {
+	if singleWriter {
+		flags = append(flags, thread.FlagSingleWriter)
+	}
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	return thread.NewFlags(flags...)
+}
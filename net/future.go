@@ -0,0 +1,60 @@
+package net
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+)
+
+var _ core.RecordFuture = (*recordFuture)(nil)
+
+// recordFuture is the handle of a record pushed to peers in the background.
+// Results are only written before done is closed.
+type recordFuture struct {
+	rec  core.ThreadRecord
+	done chan struct{}
+	acks map[peer.ID]error
+	err  error
+}
+
+func newRecordFuture(rec core.ThreadRecord) *recordFuture {
+	return &recordFuture{
+		rec:  rec,
+		done: make(chan struct{}),
+		acks: make(map[peer.ID]error),
+	}
+}
+
+func (f *recordFuture) Record() core.ThreadRecord {
+	return f.rec
+}
+
+func (f *recordFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *recordFuture) Wait(ctx context.Context) (map[peer.ID]error, error) {
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	res := make(map[peer.ID]error, len(f.acks))
+	for pid, err := range f.acks {
+		res[pid] = err
+	}
+	return res, f.err
+}
+
+// complete collects the push outcomes and resolves the future.
+func (f *recordFuture) complete(acks <-chan peerAck, err error) {
+	defer close(f.done)
+	if err != nil {
+		f.err = err
+		return
+	}
+	for ack := range acks {
+		f.acks[ack.pid] = ack.err
+	}
+}
@@ -0,0 +1,222 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/connmgr"
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GaterRefreshInterval is the age of the thread peer set of ConnGater after which it is
+// rebuilt in the background. Requests are checked against the cached set meanwhile.
+var GaterRefreshInterval = 30 * time.Second
+
+var _ connmgr.ConnectionGater = (*ConnGater)(nil)
+
+// ConnGater is a libp2p connection gater which refuses connections of peers on the deny
+// list. Other connections are admitted, so the host keeps serving DHT and bitswap, but the
+// thread protocol only serves peers with a log in at least one thread stored on the host,
+// peers on the allow list, and requests on threads stored on the host, so peers may join
+// them with AddThread.
+// The gater must be passed to the host with libp2p.ConnectionGater, and to the network with
+// Config.ConnGater, which binds it to the logstore. Thread protocol requests of peers which
+// are not allowed explicitly are refused until then.
+type ConnGater struct {
+	net        *net
+	allow      map[peer.ID]struct{}
+	deny       map[peer.ID]struct{}
+	peers      map[peer.ID]struct{}
+	refreshed  time.Time
+	refreshing bool
+	lk         sync.RWMutex
+}
+
+// NewConnGater returns a gater with the given allow and deny lists.
+func NewConnGater(allow, deny []peer.ID) *ConnGater {
+	g := &ConnGater{
+		allow: make(map[peer.ID]struct{}, len(allow)),
+		deny:  make(map[peer.ID]struct{}, len(deny)),
+		peers: make(map[peer.ID]struct{}),
+	}
+	for _, p := range allow {
+		g.allow[p] = struct{}{}
+	}
+	for _, p := range deny {
+		g.deny[p] = struct{}{}
+	}
+	return g
+}
+
+// Allow adds peers to the allow list and removes them from the deny list.
+func (g *ConnGater) Allow(peers ...peer.ID) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	for _, p := range peers {
+		g.allow[p] = struct{}{}
+		delete(g.deny, p)
+	}
+}
+
+// Deny adds peers to the deny list and removes them from the allow list.
+// Existing connections of the peers are not closed.
+func (g *ConnGater) Deny(peers ...peer.ID) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	for _, p := range peers {
+		g.deny[p] = struct{}{}
+		delete(g.allow, p)
+	}
+}
+
+func (g *ConnGater) InterceptPeerDial(p peer.ID) bool {
+	return !g.denied(p)
+}
+
+func (g *ConnGater) InterceptAddrDial(p peer.ID, _ ma.Multiaddr) bool {
+	return !g.denied(p)
+}
+
+func (g *ConnGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	// the remote peer is only known once the connection is secured
+	return true
+}
+
+func (g *ConnGater) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	return !g.denied(p)
+}
+
+func (g *ConnGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+func (g *ConnGater) bind(n *net) {
+	g.lk.Lock()
+	g.net = n
+	g.lk.Unlock()
+	g.refresh()
+}
+
+func (g *ConnGater) denied(p peer.ID) bool {
+	g.lk.RLock()
+	defer g.lk.RUnlock()
+	_, ok := g.deny[p]
+	return ok
+}
+
+// admitted reports whether the peer may be served thread protocol requests on the threads.
+// Peers are admitted if they are allowed explicitly, have a log in a stored thread, or
+// request a thread stored on the host.
+func (g *ConnGater) admitted(p peer.ID, tids []thread.ID) bool {
+	g.lk.RLock()
+	_, allowed := g.allow[p]
+	_, known := g.peers[p]
+	_, denied := g.deny[p]
+	n := g.net
+	stale := n != nil && n.clock.Now().Sub(g.refreshed) >= GaterRefreshInterval
+	g.lk.RUnlock()
+	if stale {
+		go g.refresh()
+	}
+	switch {
+	case denied || n == nil:
+		return allowed && !denied
+	case allowed || known:
+		return true
+	}
+	for _, id := range tids {
+		if _, err := n.store.GetThread(id); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh rebuilds the thread peer set, unless a rebuild is running already.
+func (g *ConnGater) refresh() {
+	g.lk.Lock()
+	n := g.net
+	if n == nil || g.refreshing {
+		g.lk.Unlock()
+		return
+	}
+	g.refreshing = true
+	g.lk.Unlock()
+
+	peers, err := n.threadPeers()
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	g.refreshing = false
+	if err != nil {
+		log.Errorf("error listing thread peers: %v", err)
+		return
+	}
+	g.peers = peers
+	g.refreshed = n.clock.Now()
+}
+
+// gaterInterceptor refuses thread protocol requests of peers which are not admitted
+// by the connection gater.
+func (n *net) gaterInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if n.connGater == nil || !gatedRequest(req) {
+			return handler(ctx, req)
+		}
+		pid, err := peerIDFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !n.connGater.admitted(pid, requestThreads(req)) {
+			log.Debugf("refusing %s from peer %s outside of stored threads", info.FullMethod, pid)
+			return nil, status.Error(codes.PermissionDenied, "peer is not admitted by the gater")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// gatedRequest returns whether the request reads or writes thread data. Handshakes and
+// requests authenticated by other means are served to any peer which isn't denied.
+func gatedRequest(req interface{}) bool {
+	switch req.(type) {
+	case *pb.HelloRequest, *pb.GetCapabilitiesRequest, *pb.PutKeyShareRequest, *pb.GetKeyShareRequest:
+		return false
+	}
+	return true
+}
+
+// threadPeers returns the peers with a log in any of the stored threads.
+func (n *net) threadPeers() (map[peer.ID]struct{}, error) {
+	ids, err := n.store.Threads()
+	if err != nil {
+		return nil, err
+	}
+	peers := make(map[peer.ID]struct{})
+	for _, id := range ids {
+		info, err := n.store.GetThread(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, lg := range info.Logs {
+			for _, addr := range lg.Addrs {
+				if pid, ok, err := n.callablePeer(addr); err == nil && ok {
+					peers[pid] = struct{}{}
+				}
+			}
+		}
+	}
+	return peers, nil
+}
@@ -0,0 +1,88 @@
+package net
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+func TestNet_ConnGater(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	var (
+		stranger = tu.RandPeerIDFatal(t)
+		denied   = tu.RandPeerIDFatal(t)
+		p2       = n2.Host().ID()
+		g        = NewConnGater(nil, []peer.ID{denied})
+	)
+	if g.admitted(p2, nil) {
+		t.Fatal("expected thread protocol requests to be refused before binding")
+	}
+	g.bind(n1)
+
+	n1.Host().Peerstore().AddAddrs(p2, n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	other := thread.NewIDV1(thread.Raw, 32)
+	if !g.admitted(stranger, []thread.ID{info.ID}) {
+		t.Fatal("expected requests of first-time peers on stored threads to be admitted")
+	}
+	if g.admitted(stranger, []thread.ID{other}) || g.admitted(stranger, nil) {
+		t.Fatal("expected requests of strangers outside of stored threads to be refused")
+	}
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// let n1 learn about the log of n2
+	if _, err = n2.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	g.refresh()
+
+	if !g.admitted(p2, nil) {
+		t.Fatal("expected thread peer to be admitted")
+	}
+	if !g.InterceptSecured(network.DirInbound, stranger, nil) || !g.InterceptPeerDial(stranger) {
+		t.Fatal("expected connections of strangers to be allowed")
+	}
+	if !gatedRequest(&pb.GetRecordsRequest{}) || gatedRequest(&pb.HelloRequest{}) {
+		t.Fatal("expected only thread data requests to be gated")
+	}
+	if g.InterceptSecured(network.DirInbound, denied, nil) || g.InterceptPeerDial(denied) {
+		t.Fatal("expected connections to denied peer to be refused")
+	}
+
+	g.Allow(stranger)
+	if !g.admitted(stranger, []thread.ID{other}) {
+		t.Fatal("expected allowed peer to be admitted")
+	}
+	g.Deny(p2)
+	if g.admitted(p2, []thread.ID{info.ID}) || g.InterceptSecured(network.DirInbound, p2, nil) {
+		t.Fatal("expected denied thread peer to be refused")
+	}
+}
@@ -0,0 +1,127 @@
+package net
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	nnet "net"
+	"strconv"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/net/admin"
+	adminpb "github.com/textileio/go-threads/net/admin/pb"
+	"github.com/textileio/go-threads/net/api"
+	apipb "github.com/textileio/go-threads/net/api/pb"
+	tu "github.com/textileio/go-threads/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// startGateway serves the network API over plain TCP, optionally secured with TLS,
+// so that processes without a libp2p host can drive the network with the API client.
+// Like the admin API, it acts with the authority of the host, so it's only served to
+// local clients, unless clients are authenticated with certificates or a token.
+func (n *net) startGateway(conf Config) error {
+	mtls := conf.ListenTLS != nil && conf.ListenTLS.ClientAuth == tls.RequireAndVerifyClientCert
+	if !mtls && conf.ListenToken == "" && !isLoopbackAddr(conf.ListenAddr) {
+		return fmt.Errorf("network API on %s requires verified client certificates or a token", conf.ListenAddr)
+	}
+	target, err := tu.TCPAddrFromMultiAddr(conf.ListenAddr)
+	if err != nil {
+		return err
+	}
+	service, err := api.NewService(n, api.Config{Debug: conf.Debug})
+	if err != nil {
+		return err
+	}
+	listener, err := nnet.Listen("tcp", target)
+	if err != nil {
+		return err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(api.UnaryAuthInterceptor(conf.ListenToken), api.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(api.StreamAuthInterceptor(conf.ListenToken), api.StreamServerInterceptor()),
+	}
+	if conf.ListenTLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(conf.ListenTLS)))
+	} else if conf.ListenToken != "" {
+		log.Warnf("network API on %s isn't secured with TLS, the token is sent in plain text", listener.Addr())
+	}
+	n.gateway = grpc.NewServer(opts...)
+	apipb.RegisterAPIServer(n.gateway, service)
+	go func() {
+		if err := n.gateway.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Errorf("gateway serve error: %v", err)
+		}
+	}()
+	log.Infof("serving network API on %s", listener.Addr())
+	return nil
+}
+
+// isLoopbackAddr returns whether the address only accepts connections from the local host.
+func isLoopbackAddr(addr ma.Multiaddr) bool {
+	for _, code := range []int{ma.P_IP4, ma.P_IP6} {
+		if v, err := addr.ValueForProtocol(code); err == nil {
+			ip := nnet.ParseIP(v)
+			return ip != nil && ip.IsLoopback()
+		}
+	}
+	return false
+}
+
+// startAdmin serves the admin API over TCP. The admin API acts with the authority of the host,
+// so it's refused unless clients are authenticated with certificates or a token.
+func (n *net) startAdmin(conf Config) error {
+	mtls := conf.AdminTLS != nil && conf.AdminTLS.ClientAuth == tls.RequireAndVerifyClientCert
+	if !mtls && conf.AdminToken == "" {
+		return fmt.Errorf("admin API requires verified client certificates or a token")
+	}
+	target, err := tu.TCPAddrFromMultiAddr(conf.AdminAddr)
+	if err != nil {
+		return err
+	}
+	params := map[string]string{
+		"pubsub":            strconv.FormatBool(conf.PubSub),
+		"fetchAttachments":  strconv.FormatBool(conf.FetchAttachments),
+		"maxRecordSize":     strconv.Itoa(conf.MaxRecordSize),
+		"maxRecordBodySize": strconv.Itoa(conf.MaxRecordBodySize),
+		"gcInterval":        conf.GCInterval.String(),
+		"publishInterval":   conf.Publish.Interval.String(),
+		"discovery":         strconv.FormatBool(conf.Routing != nil),
+	}
+	if conf.ListenAddr != nil {
+		params["listenAddr"] = conf.ListenAddr.String()
+	}
+	service, err := admin.NewService(n, admin.Config{
+		Debug:  conf.Debug,
+		Token:  conf.AdminToken,
+		Params: params,
+	})
+	if err != nil {
+		return err
+	}
+	listener, err := nnet.Listen("tcp", target)
+	if err != nil {
+		return err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(service.UnaryInterceptor()),
+		grpc.StreamInterceptor(service.StreamInterceptor()),
+	}
+	if conf.AdminTLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(conf.AdminTLS)))
+	} else {
+		log.Warnf("admin API on %s isn't secured with TLS, the token is sent in plain text", listener.Addr())
+	}
+	n.admin = grpc.NewServer(opts...)
+	adminpb.RegisterAdminServer(n.admin, service)
+	go func() {
+		if err := n.admin.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Errorf("admin serve error: %v", err)
+		}
+	}()
+	log.Infof("serving admin API on %s", listener.Addr())
+	return nil
+}
@@ -0,0 +1,267 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// GC removes the record envelope, event, header and body blocks which are not
+// reachable from the heads of any stored thread, e.g., left behind by records which
+// failed processing, by dropped branches of forked logs or by interrupted thread
+// deletions. Chunks of bodies are removed too.
+// The blockstore may be shared with other applications, so only blocks of orphaned
+// events, and envelopes encrypted with the service key of a stored thread, are swept.
+// Attachments and foreign blocks are never touched.
+// Record processing is paused while the live blocks are marked.
+func (n *net) GC(ctx context.Context) (int, error) {
+	// Blocks added later belong to records in flight, so they are not candidates.
+	candidates, others, err := n.orphanCandidates(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("listing events: %w", err)
+	}
+	if len(candidates) == 0 && len(others) == 0 {
+		return 0, nil
+	}
+
+	n.gcLock.Lock()
+	defer n.gcLock.Unlock()
+
+	live, keys, err := n.markLive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("marking live blocks: %w", err)
+	}
+	var swept int
+	sweep := func(id cid.Cid) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := live[id]; ok {
+			return nil
+		}
+		if err := n.bstore.DeleteBlock(id); errors.Is(err, bs.ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("deleting block %s: %w", id, err)
+		}
+		if err := n.forgetBlockRefs(id); err != nil {
+			return fmt.Errorf("dropping references of block %s: %w", id, err)
+		}
+		swept++
+		return nil
+	}
+	for _, ev := range candidates {
+		if _, ok := live[ev.Cid()]; ok {
+			continue
+		}
+		ids := append([]cid.Cid{ev.Cid(), ev.HeaderID(), ev.BodyID()}, util.LocalBodyChunks(n.bstore, ev.BodyID())...)
+		for _, id := range ids {
+			if err := sweep(id); err != nil {
+				return swept, err
+			}
+		}
+	}
+	var envelopes int
+	for _, id := range others {
+		if _, ok := live[id]; ok || !n.isEnvelope(id, keys) {
+			continue
+		}
+		if err := sweep(id); err != nil {
+			return swept, err
+		}
+		envelopes++
+	}
+	log.Debugf("gc swept %d blocks of %d orphaned events and %d envelopes", swept, len(candidates), envelopes)
+	return swept, nil
+}
+
+// orphanCandidates returns all events found in the blockstore, along with the other
+// dag-cbor blocks, which may be record envelopes.
+func (n *net) orphanCandidates(ctx context.Context) ([]*cbor.Event, []cid.Cid, error) {
+	keys, err := n.bstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		events []*cbor.Event
+		others []cid.Cid
+	)
+	for key := range keys {
+		// keys are listed by multihash as raw cids, while events are dag-cbor nodes
+		id := cid.NewCidV1(cid.DagCBOR, key.Hash())
+		block, err := n.bstore.Get(id)
+		if errors.Is(err, bs.ErrNotFound) {
+			continue // removed meanwhile
+		} else if err != nil {
+			return nil, nil, err
+		}
+		node, err := cbornode.DecodeBlock(block)
+		if err != nil {
+			continue
+		}
+		// records, headers and bodies are encrypted, only events have a recognizable shape
+		ev, err := cbor.EventFromNode(node)
+		if err != nil || !ev.HeaderID().Defined() || !ev.BodyID().Defined() {
+			others = append(others, id)
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, others, ctx.Err()
+}
+
+// isEnvelope returns whether the block is a record envelope encrypted with one of the keys.
+func (n *net) isEnvelope(id cid.Cid, keys []*sym.Key) bool {
+	block, err := n.bstore.Get(id)
+	if err != nil {
+		return false
+	}
+	node, err := cbornode.DecodeBlock(block)
+	if err != nil {
+		return false
+	}
+	for _, key := range keys {
+		if rec, err := cbor.RecordFromNode(node, key); err == nil && rec.BlockID().Defined() {
+			return true
+		}
+	}
+	return false
+}
+
+// markLive returns the record envelope, event, header and body blocks reachable
+// from the heads of all stored threads, along with the service keys of the threads.
+// This method is internal and *not* thread-safe. It assumes we currently own the gc lock.
+func (n *net) markLive(ctx context.Context) (map[cid.Cid]struct{}, []*sym.Key, error) {
+	var (
+		live   = make(map[cid.Cid]struct{})
+		keys   []*sym.Key
+		cursor = newThreadCursor(n.store)
+	)
+	for {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			return nil, nil, err
+		} else if !ok {
+			return live, keys, nil
+		}
+		if err := n.markThread(ctx, tid, live); err != nil {
+			return nil, nil, fmt.Errorf("thread %s: %w", tid, err)
+		}
+		sk, err := n.store.ServiceKey(tid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("thread %s: %w", tid, err)
+		}
+		keys = append(keys, sk)
+	}
+}
+
+// markThread adds the record envelope, event, header and body blocks of the thread to live.
+func (n *net) markThread(ctx context.Context, tid thread.ID, live map[cid.Cid]struct{}) error {
+	return n.walkThread(ctx, tid, live, func(rid cid.Cid, ev *cbor.Event) {
+		for _, id := range []cid.Cid{rid, ev.Cid(), ev.HeaderID(), ev.BodyID()} {
+			live[id] = struct{}{}
+		}
+	})
+}
+
+// walkThread visits the records of every log of the thread from its heads down to the compaction
+// boundary. Branches stop at records in visited, which must be added to it by visit.
+func (n *net) walkThread(
+	ctx context.Context,
+	tid thread.ID,
+	visited map[cid.Cid]struct{},
+	visit func(rid cid.Cid, ev *cbor.Event),
+) error {
+	// the thread can't be deleted while it's being walked
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+
+	return n.walkLogs(ctx, tid, visited, func(_ peer.ID, rid cid.Cid, ev *cbor.Event) {
+		visit(rid, ev)
+	})
+}
+
+// walkLogs is like walkThread, passing the log of the visited records along.
+// The caller must hold the thread lock.
+func (n *net) walkLogs(
+	ctx context.Context,
+	tid thread.ID,
+	visited map[cid.Cid]struct{},
+	visit func(lid peer.ID, rid cid.Cid, ev *cbor.Event),
+) error {
+	info, err := n.store.GetThread(tid)
+	if err != nil {
+		return err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		// events of the thread can't be resolved, abort instead of sweeping them
+		return errors.New("missing service key")
+	}
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, tid, lg.ID, boundarySuffix)
+		if err != nil {
+			return err
+		}
+		for _, head := range lg.Heads {
+			for rid := head; rid.Defined(); {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if _, ok := visited[rid]; ok {
+					break // fork point of an already walked branch
+				}
+				// stop at records missing locally, otherwise the dag service would fetch them
+				if known, err := n.isKnown(rid); err != nil {
+					return err
+				} else if !known {
+					break
+				}
+				rec, err := cbor.GetRecord(ctx, n, rid, sk)
+				if err != nil {
+					return err
+				}
+				ev, err := cbor.EventFromRecord(ctx, n, rec)
+				if err != nil {
+					return err
+				}
+				visit(lg.ID, rid, ev)
+				if rid.Equals(boundary) {
+					break
+				}
+				rid = rec.PrevID()
+			}
+		}
+	}
+	return nil
+}
+
+// startGC periodically collects orphaned blocks until the network is closed.
+func (n *net) startGC(interval time.Duration) {
+	tick := n.clock.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.Chan():
+			if _, err := n.GC(n.ctx); err != nil && n.ctx.Err() == nil {
+				log.Errorf("gc failed: %v", err)
+			}
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
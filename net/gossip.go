@@ -0,0 +1,88 @@
+package net
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/logstore/lstoreds"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+// gossipEdges publishes the local edges of a thread to the edge gossip topic, and returns
+// the peers gossiping edges of the thread, which don't need a pairwise edge exchange.
+// It returns nil if edge gossip is disabled or the edges couldn't be published.
+func (n *net) gossipEdges(tid thread.ID) map[peer.ID]struct{} {
+	if !n.edgeGossip || n.server.ps == nil {
+		return nil
+	}
+	addrsEdge, headsEdge, err := n.server.localEdges(tid)
+	if err != nil && err != errNoAddrsEdge && err != errNoHeadsEdge {
+		log.Errorf("getting local edges for %s failed: %v", tid, err)
+		return nil
+	}
+	if err = n.server.ps.PublishEdges(n.ctx, tid, &pb.ExchangeEdgesRequest_Body_ThreadEntry{
+		ThreadID:    &pb.ProtoThreadID{ID: tid},
+		HeadsEdge:   headsEdge,
+		AddressEdge: addrsEdge,
+		LogSeqs:     n.localLogSeqs(tid),
+	}); err != nil {
+		log.Debugf("gossiping edges of %s failed: %v", tid, err)
+		return nil
+	}
+
+	peers := n.server.ps.EdgePeers(tid)
+	gossiping := make(map[peer.ID]struct{}, len(peers))
+	for _, pid := range peers {
+		gossiping[pid] = struct{}{}
+	}
+	return gossiping
+}
+
+// edgeGossipMAC returns the MAC of thread edges gossiped by a peer keyed by the service
+// key of the thread, so only peers holding the key can gossip edges of the thread.
+func (n *net) edgeGossipMAC(id thread.ID, from peer.ID, entry []byte) ([]byte, error) {
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return nil, err
+	} else if sk == nil {
+		return nil, fmt.Errorf("a service-key is required to gossip edges of thread %s", id)
+	}
+	mac := hmac.New(sha256.New, sk.Bytes())
+	mac.Write(id.Bytes())
+	mac.Write([]byte(from))
+	mac.Write(entry)
+	return mac.Sum(nil), nil
+}
+
+// edgeGossipHandler compares thread edges gossiped by a peer with the local ones,
+// and schedules pulling logs or records from the peer if they differ.
+func (s *server) edgeGossipHandler(_ context.Context, pid peer.ID, entry *pb.ExchangeEdgesRequest_Body_ThreadEntry) {
+	tid := entry.ThreadID.ID
+	log.Debugf("received edge gossip of %s from %s", tid, pid)
+
+	addrsEdgeLocal, headsEdgeLocal, err := s.localEdges(tid)
+	if err != nil && err != errNoAddrsEdge && err != errNoHeadsEdge {
+		log.Errorf("getting local edges for %s failed: %v", tid, err)
+		return
+	}
+
+	if entry.AddressEdge != lstoreds.EmptyEdgeValue && entry.AddressEdge != addrsEdgeLocal {
+		if s.net.queueGetLogs.Schedule(pid, tid, callPriorityLow, s.net.updateLogsFromPeer) {
+			log.Debugf("log information update for thread %s from %s scheduled", tid, pid)
+		}
+	}
+
+	if headsEdgeLocal != lstoreds.EmptyEdgeValue {
+		s.net.trackExchange(tid, headsEdgeLocal == entry.HeadsEdge, nil)
+	}
+
+	if entry.HeadsEdge != lstoreds.EmptyEdgeValue && entry.HeadsEdge != headsEdgeLocal {
+		if s.net.scheduleRecordsUpdate(pid, tid, entry.LogSeqs) {
+			log.Debugf("record update for thread %s from %s scheduled", tid, pid)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package net
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+func TestNet_EdgeGossip(t *testing.T) {
+	t.Parallel()
+	conf := Config{PubSub: true, EdgeGossip: true}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	gossiping := func(n *net, tid thread.ID, pid peer.ID) bool {
+		for _, p := range n.server.ps.EdgePeers(tid) {
+			if p == pid {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; !gossiping(n1, info.ID, n2.Host().ID()) || !gossiping(n2, info.ID, n1.Host().ID()); i++ {
+		if i == 100 {
+			t.Fatal("expected peers to join the edge gossip topic")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// store a record without pushing it, so n2 only learns about it from the gossip
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := n1.createRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := n1.gossipEdges(info.ID)[n2.Host().ID()]; !ok {
+		t.Fatal("expected gossiping peer to be skipped by the edge exchange")
+	}
+	for i := 0; ; i++ {
+		if _, err = n2.GetRecord(ctx, info.ID, tr.Value().Cid()); err == nil {
+			break
+		} else if i == 100 {
+			t.Fatalf("expected record to be pulled after the gossip: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// gossip is authenticated with the service key, and bound to the peer gossiping it
+	entry, err := (&pb.ExchangeEdgesRequest_Body_ThreadEntry{
+		ThreadID:  &pb.ProtoThreadID{ID: info.ID},
+		HeadsEdge: 1,
+	}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac, err := n1.edgeGossipMAC(info.ID, n1.Host().ID(), entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := hmac.New(sha256.New, sym.New().Bytes())
+	forged.Write(entry)
+	for _, c := range []struct {
+		from peer.ID
+		mac  []byte
+		res  pubsub.ValidationResult
+	}{
+		{from: n1.Host().ID(), mac: mac, res: pubsub.ValidationAccept},
+		{from: n1.Host().ID(), mac: forged.Sum(nil), res: pubsub.ValidationReject},
+		{from: n1.Host().ID(), res: pubsub.ValidationReject},
+		{from: n2.Host().ID(), mac: mac, res: pubsub.ValidationReject},
+	} {
+		data, err := (&pb.EdgeGossip{Entry: entry, Mac: c.mac}).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, res := n2.server.ps.validateEdges(c.from, info.ID, data); res != c.res {
+			t.Fatalf("expected validation result %d of gossip from %s, got %d", c.res, c.from, res)
+		}
+	}
+}
+
+func TestNet_PullClock(t *testing.T) {
+	t.Parallel()
+	mock := clock.NewMock(time.Now())
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Clock: mock}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	// store a record without pushing it, so n2 only gets it with the scheduled pull
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := n1.createRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pull loop doesn't start until the clock is advanced
+	time.Sleep(100 * time.Millisecond)
+	if _, err = n2.GetRecord(ctx, info.ID, tr.Value().Cid()); err == nil {
+		t.Fatal("expected record not to be pulled before the clock is advanced")
+	}
+	for i := 0; ; i++ {
+		mock.Add(QueuePollInterval)
+		if _, err = n2.GetRecord(ctx, info.ID, tr.Value().Cid()); err == nil {
+			break
+		} else if i == 500 {
+			t.Fatalf("expected record to be pulled once the clock is advanced: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,411 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto/asymmetric"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// metadata suffix marking an own log as sealed for a handoff, holding the new owner
+const handoffSuffix = "/handoff"
+
+// HandoffLog transfers the write ownership of a log to another peer, e.g., a new device of
+// the same user. A handoff record naming the new owner is appended to the log, which seals it.
+// The log private key is sent wrapped for the new owner along with the final heads sealed by
+// the log key, so the new owner continues the log instead of starting a new one. The host keeps
+// the key until the new owner acknowledges, and no longer adds records to the log.
+func (n *net) HandoffLog(
+	ctx context.Context,
+	id thread.ID,
+	lid peer.ID,
+	newOwner peer.ID,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return err
+	}
+	if identity == nil {
+		identity = thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	}
+	if newOwner == n.host.ID() {
+		return fmt.Errorf("cannot hand off a log to the host")
+	}
+	opk, err := newOwner.ExtractPublicKey()
+	if err != nil || opk == nil {
+		if opk = n.host.Peerstore().PubKey(newOwner); opk == nil {
+			return fmt.Errorf("public key of %s is unknown", newOwner)
+		}
+	}
+	ek, err := asymmetric.FromPubKey(opk)
+	if err != nil {
+		return err
+	}
+
+	lg, err := n.store.GetLog(id, lid)
+	if err != nil {
+		return err
+	}
+	if lg.PrivKey == nil {
+		return fmt.Errorf("a private-key is required to hand off a log")
+	}
+	// a log sealed for the same owner is a handoff being retried
+	if owner, err := n.logHandoff(id, lid); err != nil {
+		return err
+	} else if owner != "" && owner != newOwner {
+		return fmt.Errorf("%w to %s", core.ErrLogHandedOff, owner)
+	} else if owner == "" {
+		if err = n.appendHandoffRecord(ctx, id, lid, newOwner); err != nil {
+			return err
+		}
+	}
+	if lg, err = n.store.GetLog(id, lid); err != nil {
+		return err
+	}
+	// the log stays sealed, and the key is kept for a retry until the new owner acknowledges
+	if err = n.sendHandoff(ctx, id, lg, newOwner, ek); err != nil {
+		return err
+	}
+
+	addr, err := ma.NewMultiaddr("/" + ma.ProtocolWithCode(ma.P_P2P).Name + "/" + newOwner.String())
+	if err != nil {
+		return err
+	}
+	return n.withThreadLock(id, func() error {
+		if err := n.store.ClearLogKeys(id, lid); err != nil {
+			return err
+		}
+		if err := n.store.AddPubKey(id, lid, lg.PubKey); err != nil {
+			return err
+		}
+		if lidb, err := n.store.GetBytes(id, identity.String()); err != nil {
+			return err
+		} else if lidb != nil && peer.ID(*lidb) == lid {
+			// the next record of the identity starts a new log
+			if err = n.store.PutBytes(id, identity.String(), []byte{}); err != nil {
+				return err
+			}
+		}
+		if err := n.store.ClearAddrs(id, lid); err != nil {
+			return err
+		}
+		return n.store.AddAddr(id, lid, addr, pstore.PermanentAddrTTL)
+	})
+}
+
+// appendHandoffRecord appends a record naming the new owner to an own log, and seals the
+// log along with it, so no records follow the handoff record.
+func (n *net) appendHandoffRecord(ctx context.Context, id thread.ID, lid peer.ID, newOwner peer.ID) error {
+	identity, err := n.logIdentity(id, lid)
+	if err != nil {
+		return err
+	}
+	if identity == nil {
+		identity = thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"handoff": newOwner.String()}, mh.SHA2_256, -1)
+	if err != nil {
+		return err
+	}
+	ext := map[string][]byte{core.HandoffExtension: []byte(newOwner)}
+	_, _, err = n.createRecordChain(ctx, id, []format.Node{body}, identity, ext, func(clid peer.ID, _ []core.Record) error {
+		if clid != lid {
+			return fmt.Errorf("log %s isn't the log of identity %s", lid, identity)
+		}
+		return n.store.PutBytes(id, lid.Pretty()+handoffSuffix, []byte(newOwner))
+	})
+	return err
+}
+
+// handoffOwner returns the new owner named by a handoff record, if the record is one.
+func handoffOwner(rec core.Record) (peer.ID, bool) {
+	r, ok := rec.(interface{ Extensions() map[string][]byte })
+	if !ok {
+		return "", false
+	}
+	owner, ok := r.Extensions()[core.HandoffExtension]
+	if !ok {
+		return "", false
+	}
+	pid, err := peer.IDFromBytes(owner)
+	return pid, err == nil
+}
+
+// sendHandoff sends the sealed log heads and the wrapped log key to the new owner.
+func (n *net) sendHandoff(
+	ctx context.Context,
+	id thread.ID,
+	lg thread.LogInfo,
+	newOwner peer.ID,
+	ek *asymmetric.EncryptionKey,
+) error {
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return err
+	}
+	if sk == nil {
+		return fmt.Errorf("a service-key is required to hand off a log")
+	}
+	raw, err := crypto.MarshalPrivateKey(lg.PrivKey)
+	if err != nil {
+		return err
+	}
+	key, err := ek.Encrypt(raw)
+	if err != nil {
+		return err
+	}
+	sig, err := lg.PrivKey.Sign(handoffPayload(id, lg.ID, lg.Heads, newOwner))
+	if err != nil {
+		return fmt.Errorf("sealing log heads: %w", err)
+	}
+
+	client, release, err := n.server.dial(newOwner)
+	if err != nil {
+		return fmt.Errorf("dial %s failed: %w", newOwner, err)
+	}
+	defer release()
+	body := &pb.HandoffLogRequest_Body{
+		ThreadID:   &pb.ProtoThreadID{ID: id},
+		ServiceKey: &pb.ProtoKey{Key: sk},
+		LogID:      &pb.ProtoPeerID{ID: lg.ID},
+		Key:        key,
+		Sig:        sig,
+	}
+	if lg.Head.Defined() {
+		body.Head = &pb.ProtoCid{Cid: lg.Head}
+	}
+	for _, h := range lg.Heads {
+		body.Heads = append(body.Heads, pb.ProtoCid{Cid: h})
+	}
+	if _, err = client.HandoffLog(ctx, &pb.HandoffLogRequest{Body: body}); err != nil {
+		return fmt.Errorf("handing off log %s to %s: %w", lg.ID, newOwner, err)
+	}
+	return nil
+}
+
+// logHandoff returns the peer an own log was sealed for, if any.
+func (n *net) logHandoff(id thread.ID, lid peer.ID) (peer.ID, error) {
+	owner, err := n.store.GetBytes(id, lid.Pretty()+handoffSuffix)
+	if err != nil || owner == nil {
+		return "", err
+	}
+	return peer.ID(*owner), nil
+}
+
+// takeOverLog verifies a log handoff sent by the previous owner and makes the host the log owner.
+// Records up to the sealed heads are pulled from the previous owner first, so the host continues
+// the log from its final head, which has to be the handoff record naming the host.
+func (n *net) takeOverLog(
+	ctx context.Context,
+	pid peer.ID,
+	tid thread.ID,
+	lid peer.ID,
+	head cid.Cid,
+	heads []cid.Cid,
+	key, sig []byte,
+) error {
+	pk, err := n.store.PubKey(tid, lid)
+	if err != nil {
+		return err
+	}
+	if pk == nil {
+		if err = n.queueGetLogs.Call(pid, tid, n.updateLogsFromPeer); err != nil {
+			return err
+		}
+		if pk, err = n.store.PubKey(tid, lid); err != nil {
+			return err
+		} else if pk == nil {
+			return lstore.ErrLogNotFound
+		}
+	}
+	if ok, err := pk.Verify(handoffPayload(tid, lid, heads, n.host.ID()), sig); err != nil || !ok {
+		return fmt.Errorf("%w: bad seal", core.ErrInvalidHandoff)
+	}
+	var sealed bool
+	for _, h := range heads {
+		sealed = sealed || h.Equals(head)
+	}
+	if !head.Defined() || !sealed {
+		return fmt.Errorf("%w: head isn't sealed", core.ErrInvalidHandoff)
+	}
+	dk, err := asymmetric.FromPrivKey(n.getPrivKey())
+	if err != nil {
+		return err
+	}
+	raw, err := dk.Decrypt(key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", core.ErrInvalidHandoff, err)
+	}
+	sk, err := crypto.UnmarshalPrivateKey(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", core.ErrInvalidHandoff, err)
+	}
+	if !sk.GetPublic().Equals(pk) {
+		return fmt.Errorf("%w: key doesn't match the log", core.ErrInvalidHandoff)
+	}
+
+	for _, h := range heads {
+		if known, err := n.isKnown(h); err != nil {
+			return err
+		} else if !known {
+			if err = n.queueGetRecords.Call(pid, tid, n.updateRecordsFromPeer); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	for _, h := range heads {
+		if known, err := n.isKnown(h); err != nil {
+			return err
+		} else if !known {
+			return fmt.Errorf("sealed head %s of log %s is missing", h, lid)
+		}
+	}
+	rec, err := n.getRecord(ctx, tid, head)
+	if err != nil {
+		return err
+	}
+	if owner, ok := handoffOwner(rec); !ok || owner != n.host.ID() {
+		return fmt.Errorf("%w: head isn't a handoff record to the host", core.ErrInvalidHandoff)
+	}
+
+	identity := thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	addr, err := ma.NewMultiaddr("/" + ma.ProtocolWithCode(ma.P_P2P).Name + "/" + n.host.ID().String())
+	if err != nil {
+		return err
+	}
+	if err = n.withThreadLock(tid, func() error {
+		if err := n.store.AddPrivKey(tid, lid, sk); err != nil {
+			return err
+		}
+		if err := n.putLogIdentity(tid, lid, identity); err != nil {
+			return err
+		}
+		if owner, err := n.logHandoff(tid, lid); err != nil {
+			return err
+		} else if owner != "" {
+			// the log was handed off by the host before
+			if err = n.store.PutBytes(tid, lid.Pretty()+handoffSuffix, []byte{}); err != nil {
+				return err
+			}
+		}
+		if err := n.store.ClearAddrs(tid, lid); err != nil {
+			return err
+		}
+		return n.store.AddAddr(tid, lid, addr, pstore.PermanentAddrTTL)
+	}); err != nil {
+		return err
+	}
+
+	// let thread peers know the new log addresses
+	go n.announceLog(tid, lid)
+	return nil
+}
+
+// announceLog pushes an own log to the peers of the thread.
+func (n *net) announceLog(tid thread.ID, lid peer.ID) {
+	info, err := n.store.GetThread(tid)
+	if err != nil {
+		log.Errorf("error getting thread %s: %v", tid, err)
+		return
+	}
+	lg, err := n.store.GetLog(tid, lid)
+	if err != nil {
+		log.Errorf("error getting log %s (thread=%s): %v", lid, tid, err)
+		return
+	}
+	var addrs []ma.Multiaddr
+	for _, l := range info.Logs {
+		addrs = append(addrs, l.Addrs...)
+	}
+	peers, err := n.uniquePeers(addrs)
+	if err != nil {
+		log.Errorf("error getting peers of thread %s: %v", tid, err)
+		return
+	}
+	for _, pid := range peers {
+		if err = n.server.pushLog(n.ctx, tid, lg, pid, nil, nil); err != nil {
+			log.Errorf("error pushing log %s to %s: %v", lid, pid, err)
+		}
+	}
+}
+
+// HandoffLog receives the write ownership of a log from its previous owner.
+func (s *server) HandoffLog(ctx context.Context, req *pb.HandoffLogRequest) (*pb.HandoffLogReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("received handoff log request from %s", pid)
+
+	if req.Body == nil || req.Body.ThreadID == nil || req.Body.LogID == nil {
+		return nil, status.Error(codes.InvalidArgument, "thread and log are required")
+	}
+	tid, lid := req.Body.ThreadID.ID, req.Body.LogID.ID
+	if err = s.checkServiceKey(tid, req.Body.ServiceKey); err != nil {
+		return nil, err
+	}
+	if err = s.net.checkWritable(); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	head := cid.Undef
+	if req.Body.Head != nil {
+		head = req.Body.Head.Cid
+	}
+	heads := make([]cid.Cid, len(req.Body.Heads))
+	for i, h := range req.Body.Heads {
+		heads[i] = h.Cid
+	}
+	if err = s.net.takeOverLog(ctx, pid, tid, lid, head, heads, req.Body.Key, req.Body.Sig); err != nil {
+		switch {
+		case errors.Is(err, core.ErrInvalidHandoff):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errors.Is(err, lstore.ErrLogNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		default:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+	}
+	return &pb.HandoffLogReply{}, nil
+}
+
+// handoffPayload returns the bytes signed by the previous owner of a log, which bind
+// the final heads of the log to the new owner.
+func handoffPayload(tid thread.ID, lid peer.ID, heads []cid.Cid, newOwner peer.ID) []byte {
+	var buf []byte
+	put := func(b []byte) {
+		var l [binary.MaxVarintLen64]byte
+		buf = append(buf, l[:binary.PutUvarint(l[:], uint64(len(b)))]...)
+		buf = append(buf, b...)
+	}
+	put(tid.Bytes())
+	put([]byte(lid))
+	var l [binary.MaxVarintLen64]byte
+	buf = append(buf, l[:binary.PutUvarint(l[:], uint64(len(heads)))]...)
+	for _, h := range heads {
+		put(h.Bytes())
+	}
+	put([]byte(newOwner))
+	return buf
+}
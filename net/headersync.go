@@ -0,0 +1,156 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// runAcceptHooks applies the configured accept hooks to the author of a record, stopping at the first rejection.
+func (n *net) runAcceptHooks(ctx context.Context, tid thread.ID, lid peer.ID, rec core.Record) error {
+	if len(n.acceptHooks) == 0 {
+		return nil
+	}
+	author := &thread.Libp2pPubKey{}
+	if err := author.UnmarshalBinary(rec.PubKey()); err != nil {
+		return err
+	}
+	for _, hook := range n.acceptHooks {
+		if err := hook(ctx, tid, lid, author); err != nil {
+			return fmt.Errorf("record rejected by accept hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetRecordBodies receives a request for bodies of records pulled with headers only.
+func (s *server) GetRecordBodies(ctx context.Context, req *pb.GetRecordBodiesRequest) (*pb.GetRecordBodiesReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("received get record bodies request from %s", pid)
+
+	if req.Body == nil || req.Body.ThreadID == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing thread ID")
+	}
+	if granted, err := s.checkCapability(ctx, pid, req.Body.ThreadID.ID, fetchRights); err != nil {
+		return nil, err
+	} else if !granted {
+		if err := s.checkServiceKey(req.Body.ThreadID.ID, req.Body.ServiceKey); err != nil {
+			return nil, err
+		}
+	}
+	if limit := s.net.syncConfig().MaxPullLimit; len(req.Body.Bodies) > limit {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d bodies can be requested", limit)
+	}
+
+	restricted, err := s.net.bodyRestriction(req.Body.ThreadID.ID, pid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	reply := &pb.GetRecordBodiesReply{Bodies: make([][]byte, len(req.Body.Bodies))}
+	for i, id := range req.Body.Bodies {
+		// blocks of other threads are refused as if they were missing
+		lid, rid, ok, err := s.net.bodies.Record(req.Body.ThreadID.ID, id.Cid)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		} else if !ok {
+			return nil, status.Errorf(codes.NotFound, "body %s not found", id.Cid)
+		}
+		if restricted != nil && restricted(lid, rid) {
+			return nil, status.Errorf(codes.PermissionDenied, "body %s is restricted", id.Cid)
+		}
+		block, err := s.net.bstore.Get(id.Cid)
+		if errors.Is(err, bs.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "body %s not found", id.Cid)
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		reply.Bodies[i] = block.RawData()
+	}
+	return reply, nil
+}
+
+// loadRecordBodies requests the bodies of records which were pulled with headers only,
+// and returns the records with bodies. Records are returned up to the first one with
+// a body exceeding the size limit, since the rest of the log can't be linked without it.
+func (s *server) loadRecordBodies(
+	ctx context.Context,
+	client pb.ServiceClient,
+	tid thread.ID,
+	serviceKey *sym.Key,
+	prs []*pb.Log_Record,
+	recs []core.Record,
+) ([]core.Record, error) {
+	var (
+		missing []int
+		ids     []pb.ProtoCid
+	)
+	for i, pr := range prs {
+		if len(pr.BodyNode) > 0 || pr.Restricted {
+			continue // peers without header sync send bodies anyway, restricted ones are withheld
+		}
+		block, err := recs[i].GetBlock(ctx, s.net)
+		if err != nil {
+			return nil, err
+		}
+		event, ok := block.(*cbor.Event)
+		if !ok {
+			if event, err = cbor.EventFromNode(block); err != nil {
+				return nil, fmt.Errorf("invalid event: %w", err)
+			}
+		}
+		missing = append(missing, i)
+		ids = append(ids, pb.ProtoCid{Cid: event.BodyID()})
+	}
+	if len(missing) == 0 {
+		return recs, nil
+	}
+
+	reply, err := client.GetRecordBodies(ctx, &pb.GetRecordBodiesRequest{
+		Body: &pb.GetRecordBodiesRequest_Body{
+			ThreadID:   &pb.ProtoThreadID{ID: tid},
+			ServiceKey: &pb.ProtoKey{Key: serviceKey},
+			Bodies:     ids,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting record bodies: %w", err)
+	}
+	if len(reply.Bodies) != len(ids) {
+		return nil, fmt.Errorf("expected %d record bodies, got %d", len(ids), len(reply.Bodies))
+	}
+
+	for j, i := range missing {
+		pr := *prs[i]
+		pr.BodyNode = reply.Bodies[j]
+		if err = s.net.checkProtoRecordSize(&pr); err != nil {
+			log.Warnf("skipping records of thread %s: %v", tid, err)
+			return recs[:i], nil
+		}
+		body, err := cbornode.Decode(pr.BodyNode, mh.SHA2_256, -1)
+		if err != nil {
+			return nil, err
+		}
+		if !body.Cid().Equals(ids[j].Cid) {
+			return nil, fmt.Errorf("got record body %s, expected %s", body.Cid(), ids[j].Cid)
+		}
+		if recs[i], err = cbor.RecordFromProto(&pr, serviceKey); err != nil {
+			return nil, err
+		}
+	}
+	return recs, nil
+}
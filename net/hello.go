@@ -0,0 +1,174 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrUnsupportedFeature indicates a peer doesn't support the protocol feature a request needs.
+var ErrUnsupportedFeature = errors.New("protocol feature not supported by peer")
+
+// peerProtocols keeps the protocol negotiated with peers. Entries are dropped once a peer
+// disconnects, since it may come back running another version.
+type peerProtocols struct {
+	lk      sync.Mutex
+	peers   map[peer.ID]core.ProtocolInfo
+	pending map[peer.ID]struct{}
+}
+
+func newPeerProtocols() *peerProtocols {
+	return &peerProtocols{
+		peers:   make(map[peer.ID]core.ProtocolInfo),
+		pending: make(map[peer.ID]struct{}),
+	}
+}
+
+// get returns the protocol negotiated with a peer, or false if it wasn't negotiated yet.
+func (p *peerProtocols) get(pid peer.ID) (core.ProtocolInfo, bool) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	info, ok := p.peers[pid]
+	return info, ok
+}
+
+func (p *peerProtocols) set(pid peer.ID, info core.ProtocolInfo) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.peers[pid] = info
+}
+
+func (p *peerProtocols) forget(pid peer.ID) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	delete(p.peers, pid)
+}
+
+// begin marks a negotiation with a peer in flight, and returns false if the protocol
+// was negotiated already or another negotiation is in flight.
+func (p *peerProtocols) begin(pid peer.ID) bool {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	if _, ok := p.peers[pid]; ok {
+		return false
+	}
+	if _, ok := p.pending[pid]; ok {
+		return false
+	}
+	p.pending[pid] = struct{}{}
+	return true
+}
+
+func (p *peerProtocols) end(pid peer.ID) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	delete(p.pending, pid)
+}
+
+// Hello receives the protocol version and features of a peer, and replies with the host ones.
+func (s *server) Hello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("received hello from %s (version %d)", pid, req.Version)
+
+	s.net.protocols.set(pid, core.ProtocolInfo{
+		Version:  int(req.Version),
+		Features: req.Features,
+	})
+	return &pb.HelloReply{
+		Version:  core.ProtocolVersion,
+		Features: s.net.features,
+	}, nil
+}
+
+// negotiateProtocol returns the protocol negotiated with a peer, saying hello to the peer if
+// it wasn't negotiated yet. Peers predating the negotiation get version zero and no features.
+func (n *net) negotiateProtocol(ctx context.Context, pid peer.ID) (core.ProtocolInfo, error) {
+	if info, ok := n.protocols.get(pid); ok {
+		return info, nil
+	}
+	client, release, err := n.server.dial(pid)
+	if err != nil {
+		return core.ProtocolInfo{}, fmt.Errorf("dial %s failed: %w", pid, err)
+	}
+	defer release()
+	cctx, cancel := context.WithTimeout(ctx, PullTimeout)
+	defer cancel()
+	reply, err := client.Hello(cctx, &pb.HelloRequest{
+		Version:  core.ProtocolVersion,
+		Features: n.features,
+	})
+	var info core.ProtocolInfo
+	if status.Code(err) == codes.Unimplemented {
+		log.Debugf("%s predates protocol negotiation", pid)
+	} else if err != nil {
+		return info, fmt.Errorf("hello to %s failed: %w", pid, err)
+	} else {
+		info = core.ProtocolInfo{Version: int(reply.Version), Features: reply.Features}
+	}
+	n.protocols.set(pid, info)
+	return info, nil
+}
+
+// negotiateInBackground negotiates the protocol with a peer unless it was negotiated already,
+// so features can be checked without waiting on the peer afterwards.
+func (n *net) negotiateInBackground(pid peer.ID) {
+	if !n.protocols.begin(pid) {
+		return
+	}
+	go func() {
+		defer n.protocols.end(pid)
+		if _, err := n.negotiateProtocol(n.ctx, pid); err != nil && n.ctx.Err() == nil {
+			log.Debugf("negotiating protocol with %s: %v", pid, err)
+		}
+	}()
+}
+
+// hostFeatures returns the optional protocol features advertised by the host, as enabled by the config.
+func hostFeatures(conf Config) []string {
+	features := make([]string, 0, len(core.Features))
+	for _, f := range core.Features {
+		if f == core.FeatureCompression && !conf.Compression && !conf.BodyCompression {
+			continue
+		}
+		features = append(features, f)
+	}
+	return features
+}
+
+// peerSupports returns whether a peer supports the protocol feature, without waiting on the peer.
+// Peers the protocol wasn't negotiated with yet are assumed to support it.
+func (n *net) peerSupports(pid peer.ID, feature string) bool {
+	info, ok := n.protocols.get(pid)
+	return !ok || info.Supports(feature)
+}
+
+// downgradeLog drops the heads of forked branches from a log sent to a peer which doesn't
+// support multi-head logs, so the peer only follows the main branch.
+func (n *net) downgradeLog(pid peer.ID, pl *pb.Log) *pb.Log {
+	if len(pl.Heads) > 1 && !n.peerSupports(pid, core.FeatureMultiHead) {
+		pl.Heads = nil
+	}
+	return pl
+}
+
+// requireFeature fails with ErrUnsupportedFeature if a peer doesn't support the protocol feature.
+func (n *net) requireFeature(ctx context.Context, pid peer.ID, feature string) error {
+	info, err := n.negotiateProtocol(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if !info.Supports(feature) {
+		return fmt.Errorf("%w: %s (peer %s, version %d)", ErrUnsupportedFeature, feature, pid, info.Version)
+	}
+	return nil
+}
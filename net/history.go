@@ -0,0 +1,184 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// historyRecord is a record along with its lamport hint, i.e., its clock or position in the log.
+type historyRecord struct {
+	core.ThreadRecord
+	lamport uint64
+}
+
+func (n *net) Records(
+	ctx context.Context,
+	id thread.ID,
+	opts ...core.RecordsOption,
+) (<-chan core.ThreadRecord, error) {
+	args := &core.RecordsOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	if args.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return nil, err
+	}
+
+	recs, err := n.historyRecords(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if args.Reverse {
+		for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
+			recs[i], recs[j] = recs[j], recs[i]
+		}
+	}
+	if args.Since.Defined() {
+		pos := -1
+		for i, r := range recs {
+			if r.Value().Cid().Equals(args.Since) {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			return nil, fmt.Errorf("record %s not found in thread %s", args.Since, id)
+		}
+		recs = recs[pos+1:]
+	}
+	if args.Limit > 0 && len(recs) > args.Limit {
+		recs = recs[:args.Limit]
+	}
+
+	ch := make(chan core.ThreadRecord)
+	go func() {
+		defer close(ch)
+		for _, r := range recs {
+			select {
+			case ch <- r.ThreadRecord:
+			case <-ctx.Done():
+				return
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// historyRecords loads the stored records of every thread log, and orders them by their
+// lamport hints, log IDs and IDs. Branches of forked logs are walked down to the fork point.
+func (n *net) historyRecords(ctx context.Context, id thread.ID) ([]historyRecord, error) {
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if sk == nil {
+		return nil, fmt.Errorf("a service-key is required to get records")
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []historyRecord
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return nil, err
+		}
+		loaded := make(map[cid.Cid]core.Record)
+		for _, head := range lg.Heads {
+			for cursor := head; cursor.Defined(); {
+				if _, ok := loaded[cursor]; ok {
+					// other branches stop at the fork point
+					break
+				}
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				r, err := cbor.GetRecord(ctx, n, cursor, sk)
+				if err != nil {
+					return nil, fmt.Errorf("getting record %s: %w", cursor, err)
+				}
+				if err = n.loadExtensions(id, r); err != nil {
+					return nil, err
+				}
+				loaded[cursor] = r
+				if cursor.Equals(boundary) {
+					// older records are dropped by compaction
+					break
+				}
+				cursor = r.PrevID()
+			}
+		}
+		all = append(all, lamportOrder(id, lg.ID, loaded)...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.lamport != b.lamport {
+			return a.lamport < b.lamport
+		}
+		if a.LogID() != b.LogID() {
+			return a.LogID() < b.LogID()
+		}
+		return a.Value().Cid().KeyString() < b.Value().Cid().KeyString()
+	})
+	return all, nil
+}
+
+// lamportOrder returns the loaded records of a log with their lamport hints, i.e., their clocks, or
+// their positions counting from the oldest loaded record if greater. Records linking to one missing from the set, e.g., dropped by compaction, come first.
+func lamportOrder(tid thread.ID, lid peer.ID, loaded map[cid.Cid]core.Record) []historyRecord {
+	positions := make(map[cid.Cid]uint64, len(loaded))
+	position := func(rid cid.Cid) uint64 {
+		var (
+			chain []cid.Cid
+			p     uint64
+		)
+		for cursor := rid; ; {
+			r, ok := loaded[cursor]
+			if !ok {
+				break
+			}
+			if known, ok := positions[cursor]; ok {
+				p = known
+				break
+			}
+			chain = append(chain, cursor)
+			cursor = r.PrevID()
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			// clocks raised to follow the previous record keep the log order
+			if p++; p < loaded[chain[i]].Clock() {
+				p = loaded[chain[i]].Clock()
+			}
+			positions[chain[i]] = p
+		}
+		return positions[rid]
+	}
+
+	recs := make([]historyRecord, 0, len(loaded))
+	for rid, r := range loaded {
+		recs = append(recs, historyRecord{
+			ThreadRecord: NewRecordFrom(r, tid, lid, core.RecordSource{Kind: core.SourceUnknown}),
+			lamport:      position(rid),
+		})
+	}
+	return recs
+}
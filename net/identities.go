@@ -0,0 +1,138 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// metadata suffix for the identity writing to an own log
+const identitySuffix = "/identity"
+
+// GetLogForIdentity returns the log written by identity. Logs created by the host are resolved
+// with the local identity mapping, other logs by the author of their head record.
+func (n *net) GetLogForIdentity(
+	ctx context.Context,
+	id thread.ID,
+	identity thread.PubKey,
+	opts ...core.ThreadOption,
+) (info thread.LogInfo, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	if identity == nil {
+		return info, fmt.Errorf("an identity is required")
+	}
+	lidb, err := n.store.GetBytes(id, identity.String())
+	if err != nil {
+		return
+	}
+	if lidb != nil && len(*lidb) > 0 {
+		lid, err := peer.IDFromBytes(*lidb)
+		if err != nil {
+			return info, err
+		}
+		return n.store.GetLog(id, lid)
+	}
+
+	identities, err := n.logIdentities(ctx, id)
+	if err != nil {
+		return
+	}
+	for lid, ident := range identities {
+		if ident.Equals(identity) {
+			return n.store.GetLog(id, lid)
+		}
+	}
+	return info, lstore.ErrLogNotFound
+}
+
+// ListIdentities returns the identities writing to the logs of a thread.
+// Logs without records written by an unknown identity are omitted.
+func (n *net) ListIdentities(
+	ctx context.Context,
+	id thread.ID,
+	opts ...core.ThreadOption,
+) (map[peer.ID]thread.PubKey, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	return n.logIdentities(ctx, id)
+}
+
+// logIdentities returns the identities writing to the logs of a thread. Own logs are resolved
+// with the saved identity, external ones by the author of the head record, since the identity
+// mapping is only kept by the log owner.
+func (n *net) logIdentities(ctx context.Context, id thread.ID) (map[peer.ID]thread.PubKey, error) {
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return nil, err
+	}
+	identities := make(map[peer.ID]thread.PubKey, len(info.Logs))
+	for _, lg := range info.Logs {
+		if lg.PrivKey != nil {
+			identity, err := n.logIdentity(id, lg.ID)
+			if err != nil {
+				return nil, err
+			} else if identity != nil {
+				identities[lg.ID] = identity
+				continue
+			}
+		}
+		if !lg.Head.Defined() {
+			continue
+		}
+		rec, err := n.getRecord(ctx, id, lg.Head)
+		if err != nil {
+			return nil, fmt.Errorf("getting head of log %s: %w", lg.ID, err)
+		}
+		author := &thread.Libp2pPubKey{}
+		if err = author.UnmarshalBinary(rec.PubKey()); err != nil {
+			return nil, fmt.Errorf("decoding author of log %s: %w", lg.ID, err)
+		}
+		identities[lg.ID] = author
+	}
+	return identities, nil
+}
+
+// logIdentity returns the saved identity of an own log, or nil if it's unknown,
+// e.g., for logs created before identities were saved.
+func (n *net) logIdentity(id thread.ID, lid peer.ID) (thread.PubKey, error) {
+	data, err := n.store.GetBytes(id, lid.Pretty()+identitySuffix)
+	if err != nil || data == nil || len(*data) == 0 {
+		return nil, err
+	}
+	identity := &thread.Libp2pPubKey{}
+	if err = identity.UnmarshalBinary(*data); err != nil {
+		return nil, fmt.Errorf("decoding identity of log %s: %w", lid, err)
+	}
+	return identity, nil
+}
+
+// putLogIdentity maps identity to an own log, and saves the identity under the log.
+func (n *net) putLogIdentity(id thread.ID, lid peer.ID, identity thread.PubKey) error {
+	lidb, err := lid.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err = n.store.PutBytes(id, identity.String(), lidb); err != nil {
+		return err
+	}
+	data, err := identity.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return n.store.PutBytes(id, lid.Pretty()+identitySuffix, data)
+}
@@ -0,0 +1,194 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServerInterceptors are added to the gRPC server of the thread service. They run after
+// the default chain, which collects metrics, logs requests, recovers from panics and
+// extracts thread tokens and capabilities from the request metadata, see
+// thread.TokenFromContext and thread.CapabilitiesFromContext.
+type ServerInterceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// ClientInterceptors are added to the gRPC connections to peers. They run after the
+// default chain, which collects metrics, logs requests and attaches the capabilities
+// granted for the threads of requests.
+type ClientInterceptors struct {
+	Unary  []grpc.UnaryClientInterceptor
+	Stream []grpc.StreamClientInterceptor
+}
+
+// rpcStats counts the gRPC calls exchanged with peers by method.
+type rpcStats struct {
+	mx      sync.Mutex
+	methods map[string]*core.RPCStatus
+}
+
+func newRPCStats() *rpcStats {
+	return &rpcStats{methods: make(map[string]*core.RPCStatus)}
+}
+
+// Status returns the counters by method.
+func (s *rpcStats) Status() map[string]core.RPCStatus {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	res := make(map[string]core.RPCStatus, len(s.methods))
+	for m, st := range s.methods {
+		res[m] = *st
+	}
+	return res
+}
+
+func (s *rpcStats) served(method string, took time.Duration, err error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	st := s.method(method)
+	st.Served++
+	st.ServedTime += took
+	if err != nil {
+		st.ServedErrors++
+	}
+}
+
+func (s *rpcStats) issued(method string, took time.Duration, err error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	st := s.method(method)
+	st.Issued++
+	st.IssuedTime += took
+	if err != nil {
+		st.IssuedErrors++
+	}
+}
+
+func (s *rpcStats) panicked(method string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.method(method).Panics++
+}
+
+// method returns the counters of the method, the lock must be held.
+func (s *rpcStats) method(method string) *core.RPCStatus {
+	st, ok := s.methods[method]
+	if !ok {
+		st = &core.RPCStatus{}
+		s.methods[method] = st
+	}
+	return st
+}
+
+// RPCStatus returns the counters of gRPC calls exchanged with peers by full method name.
+func (n *net) RPCStatus(_ context.Context) (map[string]core.RPCStatus, error) {
+	return n.rpcStats.Status(), nil
+}
+
+// serverInterceptors returns the default unary and stream chains of the thread service,
+// followed by the configured interceptors.
+func (n *net) serverInterceptors(conf ServerInterceptors) (grpc.ServerOption, grpc.ServerOption) {
+	recovery := grpc_recovery.WithRecoveryHandlerContext(func(ctx context.Context, p interface{}) error {
+		method, _ := grpc.Method(ctx)
+		n.rpcStats.panicked(method)
+		log.Errorf("recovered from panic serving %s: %v", method, p)
+		return status.Errorf(codes.Internal, "panic serving %s", method)
+	})
+	unary := append([]grpc.UnaryServerInterceptor{
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			res, err := handler(ctx, req)
+			n.rpcStats.served(info.FullMethod, time.Since(start), err)
+			logServed(ctx, info.FullMethod, start, err)
+			return res, err
+		},
+		grpc_recovery.UnaryServerInterceptor(recovery),
+		func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := tokenContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		},
+		n.rateLimitInterceptor(),
+		n.gaterInterceptor(),
+		n.envelopeServerInterceptor(),
+		n.compressionServerInterceptor(),
+	}, conf.Unary...)
+	stream := append([]grpc.StreamServerInterceptor{
+		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			n.rpcStats.served(info.FullMethod, time.Since(start), err)
+			logServed(ss.Context(), info.FullMethod, start, err)
+			return err
+		},
+		grpc_recovery.StreamServerInterceptor(recovery),
+		func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := tokenContext(ss.Context())
+			if err != nil {
+				return err
+			}
+			wrapped := grpc_middleware.WrapServerStream(ss)
+			wrapped.WrappedContext = ctx
+			return handler(srv, wrapped)
+		},
+	}, conf.Stream...)
+	return grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...)
+}
+
+// clientInterceptors returns the default unary and stream chains of peer connections,
+// followed by the configured interceptors.
+func (n *net) clientInterceptors(conf ClientInterceptors) (grpc.DialOption, grpc.DialOption) {
+	unary := append([]grpc.UnaryClientInterceptor{
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			start := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			n.rpcStats.issued(method, time.Since(start), err)
+			log.Debugf("called %s on %s in %s: %v", method, cc.Target(), time.Since(start), status.Code(err))
+			return err
+		},
+		n.capabilityClientInterceptor(),
+		n.envelopeClientInterceptor(),
+		n.compressionClientInterceptor(),
+	}, conf.Unary...)
+	stream := append([]grpc.StreamClientInterceptor{
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			// streams are counted once opened, they may outlive the call
+			start := time.Now()
+			cs, err := streamer(ctx, desc, cc, method, opts...)
+			n.rpcStats.issued(method, time.Since(start), err)
+			log.Debugf("opened %s on %s: %v", method, cc.Target(), status.Code(err))
+			return cs, err
+		},
+	}, conf.Stream...)
+	return grpc.WithChainUnaryInterceptor(unary...), grpc.WithChainStreamInterceptor(stream...)
+}
+
+// tokenContext adds the thread token and capabilities passed in the request metadata to the context.
+func tokenContext(ctx context.Context) (context.Context, error) {
+	token, err := thread.NewTokenFromMD(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = thread.NewCapabilityContext(ctx, thread.NewCapabilitiesFromMD(ctx)...)
+	return thread.NewTokenContext(ctx, token), nil
+}
+
+func logServed(ctx context.Context, method string, start time.Time, err error) {
+	var from string
+	if pid, perr := peerIDFromContext(ctx); perr == nil {
+		from = pid.String()
+	}
+	log.Debugf("served %s to %s in %s: %v", method, from, time.Since(start), status.Code(err))
+}
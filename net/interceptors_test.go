@@ -0,0 +1,99 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNet_Interceptors(t *testing.T) {
+	t.Parallel()
+	const getLogs = "/net.pb.Service/GetLogs"
+	var (
+		mx     sync.Mutex
+		tokens []thread.Token
+		panics = true
+	)
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		Debug: true,
+		ServerInterceptors: ServerInterceptors{
+			Unary: []grpc.UnaryServerInterceptor{
+				func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+					mx.Lock()
+					defer mx.Unlock()
+					if token, ok := thread.TokenFromContext(ctx); ok {
+						tokens = append(tokens, token)
+					}
+					if panics && info.FullMethod == getLogs {
+						panic("interceptor panic")
+					}
+					return handler(ctx, req)
+				},
+			},
+		},
+	}).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		Debug: true,
+		ClientInterceptors: ClientInterceptors{
+			Unary: []grpc.UnaryClientInterceptor{
+				func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+					ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "bearer token")
+					return invoker(ctx, method, req, reply, cc, opts...)
+				},
+			},
+		},
+	}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// panics are recovered and reported to the caller
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err == nil {
+		t.Fatal("expected panicking call to fail")
+	}
+	mx.Lock()
+	panics = false
+	mx.Unlock()
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	mx.Lock()
+	if len(tokens) == 0 || tokens[0] != "token" {
+		t.Fatalf("expected token to be extracted, got %v", tokens)
+	}
+	mx.Unlock()
+
+	served, err := n1.RPCStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := served[getLogs]; st.Served == 0 || st.ServedErrors != 1 || st.Panics != 1 || st.ServedTime <= 0 {
+		t.Fatalf("unexpected served calls: %+v", st)
+	}
+	issued, err := n2.RPCStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := issued[getLogs]; st.Issued == 0 || st.IssuedErrors != 1 {
+		t.Fatalf("unexpected issued calls: %+v", st)
+	}
+}
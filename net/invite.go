@@ -0,0 +1,145 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	mbase "github.com/multiformats/go-multibase"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/net/util"
+)
+
+func (n *net) CreateInvite(
+	_ context.Context,
+	id thread.ID,
+	opts ...core.InviteOption,
+) (string, error) {
+	args := &core.InviteOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return "", err
+	}
+	info, err := n.getThreadWithAddrs(id)
+	if err != nil {
+		return "", err
+	}
+
+	bundle := thread.NewKeyBundle(info)
+	switch args.Role {
+	case core.InviteMember:
+		if !info.Key.CanRead() {
+			return "", fmt.Errorf("inviting members requires the thread read key")
+		}
+	case core.InviteReplicator:
+		bundle.Key = thread.NewServiceKey(info.Key.Service())
+	default:
+		return "", fmt.Errorf("unknown invite role %d", args.Role)
+	}
+	data, err := bundle.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	if args.Recipient != nil {
+		if data, err = args.Recipient.Encrypt(data); err != nil {
+			return "", fmt.Errorf("encrypting invite: %w", err)
+		}
+	}
+
+	body := &pb.Invite_Body{
+		ThreadID:  &pb.ProtoThreadID{ID: id},
+		Inviter:   &pb.ProtoPeerID{ID: n.host.ID()},
+		Addrs:     addrsToProto(n.host.Addrs()),
+		Role:      int32(args.Role),
+		Bundle:    data,
+		Encrypted: args.Recipient != nil,
+	}
+	if args.TTL > 0 {
+		body.Expires = n.clock.Now().Add(args.TTL).Unix()
+	}
+	if args.SingleUse {
+		if err = util.PutPendingInvite(n.store, body); err != nil {
+			return "", err
+		}
+	}
+
+	bb, err := body.Marshal()
+	if err != nil {
+		return "", err
+	}
+	sig, err := n.getPrivKey().Sign(bb)
+	if err != nil {
+		return "", fmt.Errorf("signing invite: %w", err)
+	}
+	inv, err := (&pb.Invite{Body: bb, Sig: sig}).Marshal()
+	if err != nil {
+		return "", err
+	}
+	return mbase.Encode(mbase.Base32, inv)
+}
+
+func (n *net) AcceptInvite(
+	ctx context.Context,
+	invite string,
+	opts ...core.AcceptInviteOption,
+) (info thread.Info, err error) {
+	args := &core.AcceptInviteOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	body, err := util.DecodeInvite(invite)
+	if err != nil {
+		return
+	}
+	if body.Expires > 0 && n.clock.Now().Unix() > body.Expires {
+		return info, core.ErrInviteExpired
+	}
+	id, inviter := body.ThreadID.ID, body.Inviter.ID
+
+	// the inviter bootstraps the thread
+	n.host.Peerstore().AddAddrs(inviter, addrsFromProto(body.Addrs), pstore.PermanentAddrTTL)
+	data := body.Bundle
+	if body.Nonce != nil {
+		if data, err = n.server.redeemInvite(ctx, inviter, id, body.Nonce); err != nil {
+			return
+		}
+	}
+
+	var bundle thread.KeyBundle
+	if body.Encrypted {
+		identity := args.Identity
+		if identity == nil {
+			identity = thread.NewLibp2pIdentity(n.getPrivKey())
+		}
+		if bundle, err = thread.DecryptKeyBundle(ctx, identity, data); err != nil {
+			return info, fmt.Errorf("decrypting invite: %w", err)
+		}
+	} else if err = bundle.UnmarshalBinary(data); err != nil {
+		return
+	}
+	if !bundle.ThreadID.Equals(id) {
+		return info, fmt.Errorf("%w: thread ID doesn't match the key bundle", core.ErrInvalidInvite)
+	}
+
+	addr, err := ma.NewMultiaddr("/" + ma.ProtocolWithCode(ma.P_P2P).Name + "/" + inviter.String() +
+		"/" + thread.Name + "/" + id.String())
+	if err != nil {
+		return
+	}
+	return n.AddThread(ctx, addr, core.WithThreadKey(bundle.Key), core.WithNewThreadToken(args.Token))
+}
+
+// redeemPendingInvite returns the key bundle of a single-use invite and wipes it, so the invite can't be redeemed again.
+func (n *net) redeemPendingInvite(tid thread.ID, nonce []byte) ([]byte, error) {
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return nil, err
+	}
+	defer ts.Release()
+	return util.RedeemPendingInvite(n.store, tid, nonce, n.clock.Now())
+}
@@ -0,0 +1,149 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var headJournalPrefix = ds.NewKey("/headjournal")
+
+// headJournal is a write-ahead journal of log head updates. Heads are advanced before the
+// records below them are added to the blockstore, so an update is journaled first and
+// committed once the records are stored. Updates left in the journal by a crash are
+// reconciled with the blockstore on startup, see net.recoverHeads.
+type headJournal struct {
+	store ds.Datastore
+}
+
+// headUpdate is a journaled update of log heads from Prev to Next.
+type headUpdate struct {
+	Thread thread.ID `json:"-"`
+	Log    peer.ID   `json:"-"`
+	Prev   []cid.Cid `json:"prev"`
+	Next   []cid.Cid `json:"next"`
+}
+
+func newHeadJournal(store ds.Datastore) *headJournal {
+	return &headJournal{store: store}
+}
+
+// Begin journals an update of the log heads, replacing a pending update of the log.
+func (j *headJournal) Begin(tid thread.ID, lid peer.ID, prev, next []cid.Cid) error {
+	value, err := json.Marshal(headUpdate{Prev: prev, Next: next})
+	if err != nil {
+		return err
+	}
+	return j.store.Put(headJournalKey(tid, lid), value)
+}
+
+// Commit drops the pending update of the log heads once its records are stored.
+func (j *headJournal) Commit(tid thread.ID, lid peer.ID) error {
+	return j.store.Delete(headJournalKey(tid, lid))
+}
+
+// Pending returns the updates which weren't committed.
+func (j *headJournal) Pending() ([]headUpdate, error) {
+	res, err := j.store.Query(query.Query{Prefix: headJournalPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	var updates []headUpdate
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, e.Error
+		}
+		key := ds.RawKey(e.Key)
+		tid, err := thread.Decode(key.Parent().BaseNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("decoding thread of journal entry %s: %w", key, err)
+		}
+		lid, err := peer.Decode(key.BaseNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("decoding log of journal entry %s: %w", key, err)
+		}
+		u := headUpdate{Thread: tid, Log: lid}
+		if err = json.Unmarshal(e.Value, &u); err != nil {
+			return nil, fmt.Errorf("decoding journal entry %s: %w", key, err)
+		}
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
+
+// PurgeThread removes the pending updates of all logs of the thread.
+func (j *headJournal) PurgeThread(tid thread.ID) error {
+	res, err := j.store.Query(query.Query{Prefix: headJournalPrefix.ChildString(tid.String()).String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := j.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func headJournalKey(tid thread.ID, lid peer.ID) ds.Key {
+	return headJournalPrefix.ChildString(tid.String()).ChildString(lid.String())
+}
+
+// recoverHeads reconciles the log heads with the blockstore after a crash. An update whose
+// records were all stored is completed, any other one is rolled back, so records received
+// again are processed from the previous heads.
+func (n *net) recoverHeads(ctx context.Context) error {
+	updates, err := n.journal.Pending()
+	if err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if _, err := n.store.GetThread(u.Thread); errors.Is(err, lstore.ErrThreadNotFound) {
+			// deleted meanwhile
+			if err = n.journal.Commit(u.Thread, u.Log); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		heads := u.Next
+		for _, h := range u.Next {
+			if known, err := n.isKnown(h); err != nil {
+				return err
+			} else if !known {
+				heads = u.Prev
+				break
+			}
+		}
+		if err = n.setHeads(ctx, u.Thread, u.Log, heads); err != nil {
+			return fmt.Errorf("recovering heads of log %s/%s: %w", u.Thread, u.Log, err)
+		}
+		if err = n.journal.Commit(u.Thread, u.Log); err != nil {
+			return err
+		}
+		log.Warnf("recovered heads of log %s/%s after an interrupted update", u.Thread, u.Log)
+	}
+	return nil
+}
+
+// commitHeads commits the journaled update of the log heads. A failure is logged only, the
+// update is then reconciled with the blockstore on the next startup.
+func (n *net) commitHeads(tid thread.ID, lid peer.ID) {
+	if err := n.journal.Commit(tid, lid); err != nil {
+		log.Errorf("committing heads of log %s/%s: %v", tid, lid, err)
+	}
+}
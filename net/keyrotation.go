@@ -0,0 +1,202 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+const (
+	// keyEpochKey is the metadata key of the number of service key rotations of a thread.
+	keyEpochKey = "/key-epoch"
+	// keyRotationKey is the metadata key of the latest signed key rotation, stored in its encoding.
+	keyRotationKey = "/key-rotation"
+)
+
+// ErrInvalidKeyRotation indicates a malformed key rotation, or one with a bad signature.
+var ErrInvalidKeyRotation = errors.New("invalid key rotation")
+
+// keyRotation replaces the service key of a thread. Rotations are signed by a log of the
+// thread and numbered by epoch, so peers only take newer keys from the thread writers.
+type keyRotation struct {
+	Epoch      int64
+	ServiceKey *sym.Key
+	Signer     ic.PubKey
+	Sig        []byte
+}
+
+type keyRotationPayload struct {
+	Thread     string `json:"thread"`
+	Epoch      int64  `json:"epoch"`
+	ServiceKey []byte `json:"serviceKey"`
+}
+
+type keyRotationJSON struct {
+	Epoch      int64  `json:"epoch"`
+	ServiceKey []byte `json:"serviceKey"`
+	Signer     []byte `json:"signer"`
+	Sig        []byte `json:"sig"`
+}
+
+func keyRotationFromBytes(data []byte) (r keyRotation, err error) {
+	var rj keyRotationJSON
+	if err = json.Unmarshal(data, &rj); err != nil {
+		return r, fmt.Errorf("%w: %v", ErrInvalidKeyRotation, err)
+	}
+	if r.ServiceKey, err = sym.FromBytes(rj.ServiceKey); err != nil {
+		return r, fmt.Errorf("%w: %v", ErrInvalidKeyRotation, err)
+	}
+	if r.Signer, err = ic.UnmarshalPublicKey(rj.Signer); err != nil {
+		return r, fmt.Errorf("%w: %v", ErrInvalidKeyRotation, err)
+	}
+	r.Epoch, r.Sig = rj.Epoch, rj.Sig
+	return r, nil
+}
+
+func (r keyRotation) marshal() ([]byte, error) {
+	signer, err := ic.MarshalPublicKey(r.Signer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(keyRotationJSON{
+		Epoch:      r.Epoch,
+		ServiceKey: r.ServiceKey.Bytes(),
+		Signer:     signer,
+		Sig:        r.Sig,
+	})
+}
+
+// payload returns the bytes signed by Signer, bound to the thread.
+func (r keyRotation) payload(id thread.ID) ([]byte, error) {
+	return json.Marshal(keyRotationPayload{
+		Thread:     id.String(),
+		Epoch:      r.Epoch,
+		ServiceKey: r.ServiceKey.Bytes(),
+	})
+}
+
+func (r keyRotation) verify(id thread.ID) error {
+	if r.Signer == nil || len(r.Sig) == 0 {
+		return fmt.Errorf("%w: unsigned", ErrInvalidKeyRotation)
+	}
+	payload, err := r.payload(id)
+	if err != nil {
+		return err
+	}
+	if ok, err := r.Signer.Verify(payload, r.Sig); err != nil || !ok {
+		return fmt.Errorf("%w: bad signature", ErrInvalidKeyRotation)
+	}
+	return nil
+}
+
+// keyEpoch returns the number of service key rotations of a thread.
+func (n *net) keyEpoch(tid thread.ID) (int64, error) {
+	epoch, err := n.store.GetInt64(tid, keyEpochKey)
+	if err != nil || epoch == nil {
+		return 0, err
+	}
+	return *epoch, nil
+}
+
+// keyRotationBytes returns the encoded latest key rotation of a thread, nil if none is stored.
+func (n *net) keyRotationBytes(tid thread.ID) ([]byte, error) {
+	data, err := n.store.GetBytes(tid, keyRotationKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return *data, nil
+}
+
+// rotateServiceKey replaces the service key of a thread with one signed by the host log, so
+// it's carried along with the logs pushed to the thread peers.
+func (n *net) rotateServiceKey(ctx context.Context, id thread.ID, sk *sym.Key) error {
+	lg, err := n.getOrCreateLog(id, nil)
+	if err != nil {
+		return err
+	}
+	signer, err := n.logSigner(ctx, id, lg)
+	if err != nil {
+		return err
+	}
+	epoch, err := n.keyEpoch(id)
+	if err != nil {
+		return err
+	}
+	r := keyRotation{Epoch: epoch + 1, ServiceKey: sk, Signer: signer.PubKey()}
+	payload, err := r.payload(id)
+	if err != nil {
+		return err
+	}
+	if r.Sig, err = signer.Sign(ctx, payload); err != nil {
+		return fmt.Errorf("signing key rotation: %w", err)
+	}
+	if _, err = n.putKeyRotation(id, r); err != nil {
+		return err
+	}
+	n.refreshThreadTopic(id)
+	return nil
+}
+
+// mergeKeyRotation verifies an encoded key rotation received from a peer, and takes the key
+// if the rotation is newer than the local key.
+func (n *net) mergeKeyRotation(tid thread.ID, data []byte) error {
+	r, err := keyRotationFromBytes(data)
+	if err != nil {
+		return err
+	}
+	if err = r.verify(tid); err != nil {
+		return err
+	}
+	// only the writers of the thread may rotate its key
+	lid, err := peer.IDFromPublicKey(r.Signer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKeyRotation, err)
+	}
+	if pk, err := n.store.PubKey(tid, lid); err != nil {
+		return err
+	} else if pk == nil || !pk.Equals(r.Signer) {
+		return fmt.Errorf("%w: signer %s isn't a thread log", ErrInvalidKeyRotation, lid)
+	}
+	if err = n.checkThreadWriter(tid, lid); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKeyRotation, err)
+	}
+	updated, err := n.putKeyRotation(tid, r)
+	if err != nil {
+		return err
+	}
+	if updated {
+		log.Debugf("thread %s service key rotated to epoch %d by log %s", tid, r.Epoch, lid)
+		n.refreshThreadTopic(tid)
+	}
+	return nil
+}
+
+// putKeyRotation stores the key of a rotation newer than the current epoch. It returns
+// whether the key was stored.
+func (n *net) putKeyRotation(tid thread.ID, r keyRotation) (updated bool, err error) {
+	data, err := r.marshal()
+	if err != nil {
+		return false, err
+	}
+	err = n.withThreadLock(tid, func() error {
+		epoch, err := n.keyEpoch(tid)
+		if err != nil || r.Epoch <= epoch {
+			return err
+		}
+		if err = n.store.AddServiceKey(tid, r.ServiceKey); err != nil {
+			return err
+		}
+		if err = n.store.PutBytes(tid, keyRotationKey, data); err != nil {
+			return err
+		}
+		updated = true
+		return n.store.PutInt64(tid, keyEpochKey, r.Epoch)
+	})
+	return updated, err
+}
@@ -0,0 +1,148 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// metadata key for the greatest record clock seen in a thread
+const lamportKey = "/lamport"
+
+var (
+	// MaxClockJump is the maximum distance between the clock of a received record and the
+	// clock of its previous record, or the greatest clock seen in the thread if larger.
+	MaxClockJump uint64 = 1 << 32
+
+	// ErrInvalidClock indicates a record clock that doesn't follow the clock of its log.
+	ErrInvalidClock = errors.New("invalid record clock")
+)
+
+var _ core.RecordClock = (*lamportClock)(nil)
+
+// lamportClock is the default record clock. It keeps the greatest timestamp seen in every
+// thread in the thread metadata, and stamps a new record right after it.
+type lamportClock struct {
+	store lstore.Logstore
+	lock  sync.Mutex
+}
+
+func newLamportClock(store lstore.Logstore) *lamportClock {
+	return &lamportClock{store: store}
+}
+
+func (c *lamportClock) Tick(id thread.ID) (uint64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	seen, err := c.seen(id)
+	if err != nil {
+		return 0, err
+	}
+	if seen >= math.MaxInt64 {
+		return 0, fmt.Errorf("%w: thread %s clock exhausted", ErrInvalidClock, id)
+	}
+	return seen + 1, c.store.PutInt64(id, lamportKey, int64(seen+1))
+}
+
+func (c *lamportClock) Witness(id thread.ID, clock uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	seen, err := c.seen(id)
+	if err != nil || clock <= seen {
+		return err
+	}
+	if clock > math.MaxInt64 {
+		return fmt.Errorf("%w: clock %d out of range", ErrInvalidClock, clock)
+	}
+	return c.store.PutInt64(id, lamportKey, int64(clock))
+}
+
+func (c *lamportClock) seen(id thread.ID) (uint64, error) {
+	v, err := c.store.GetInt64(id, lamportKey)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	return uint64(*v), nil
+}
+
+// nextClock returns the clock of a new record of the thread.
+func (n *net) nextClock(id thread.ID) (uint64, error) {
+	clock, err := n.recordClock.Tick(id)
+	if err != nil {
+		return 0, fmt.Errorf("ticking record clock: %w", err)
+	}
+	return clock, nil
+}
+
+// checkClock verifies the clock of a record received from a peer against the clock of its
+// previous record, zero if unknown. Clocks must grow along a log, and may only jump ahead of
+// the previous record, or the greatest clock seen in the thread, by MaxClockJump, so a peer
+// can't push the thread clock to overflow. Records without a clock, e.g., created by hosts
+// running older versions, are accepted.
+func (n *net) checkClock(id thread.ID, rec core.Record, prev uint64) error {
+	clock := rec.Clock()
+	if clock == 0 {
+		return nil
+	}
+	if clock <= prev {
+		return fmt.Errorf("%w: record %s has clock %d, not after %d of the previous record", ErrInvalidClock, rec.Cid(), clock, prev)
+	}
+	base := prev
+	if seen, err := n.seenClock(id); err != nil {
+		return err
+	} else if seen > base {
+		base = seen
+	}
+	if clock > base && clock-base > MaxClockJump {
+		return fmt.Errorf("%w: record %s has clock %d, more than %d ahead of %d", ErrInvalidClock, rec.Cid(), clock, MaxClockJump, base)
+	}
+	return nil
+}
+
+// prevStamps returns the clock and sequence number of the local record preceding a received
+// one. The clock is zero if there's none, the sequence number is zero for the first record of a
+// log, and unknownSeq if the previous record isn't stored locally.
+func (n *net) prevStamps(ctx context.Context, id thread.ID, rec core.Record) (uint64, uint64, error) {
+	prev := rec.PrevID()
+	if !prev.Defined() {
+		return 0, 0, nil
+	}
+	if known, err := n.isKnown(prev); err != nil || !known {
+		return 0, unknownSeq, err
+	}
+	r, err := n.getRecord(ctx, id, prev)
+	if err != nil {
+		return 0, 0, err
+	}
+	return r.Clock(), r.Seq(), nil
+}
+
+// seenClock returns the greatest clock seen in a thread, zero if the record clock isn't the
+// default one.
+func (n *net) seenClock(id thread.ID) (uint64, error) {
+	c, ok := n.recordClock.(*lamportClock)
+	if !ok {
+		return 0, nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.seen(id)
+}
+
+// witnessClock notes the clock of a record stored by the host. Clocks only order records,
+// so errors are logged.
+func (n *net) witnessClock(id thread.ID, rec core.Record) {
+	clock := rec.Clock()
+	if clock == 0 {
+		return
+	}
+	if err := n.recordClock.Witness(id, clock); err != nil {
+		log.Errorf("witnessing clock of record %s (thread=%s): %v", rec.Cid(), id, err)
+	}
+}
@@ -0,0 +1,189 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// linksKey is the metadata key of the links declared from a thread, stored as JSON.
+	linksKey = "/links"
+	// linkedByKey is the metadata key of the threads linking to a thread, stored as JSON.
+	linkedByKey = "/linked-by"
+)
+
+// DefaultLinkDepth is the depth PullThread follows thread links to if Config.LinkDepth is not set.
+var DefaultLinkDepth = 2
+
+func (n *net) LinkThread(
+	_ context.Context,
+	id, linked thread.ID,
+	kind core.LinkKind,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if linked == id {
+		return errors.New("thread can't link to itself")
+	}
+	if kind == "" {
+		return errors.New("link kind is required")
+	}
+	if _, err := n.store.GetThread(linked); err != nil {
+		return fmt.Errorf("linked thread %s: %w", linked, err)
+	}
+
+	n.linkLock.Lock()
+	defer n.linkLock.Unlock()
+	links, err := n.threadLinks(id)
+	if err != nil {
+		return err
+	}
+	links = append(util.WithoutLink(links, linked), core.ThreadLink{ID: linked, Kind: kind})
+	sort.Slice(links, func(i, j int) bool { return links[i].ID < links[j].ID })
+	if err = util.PutMetadataJSON(n.store, id, linksKey, links); err != nil {
+		return err
+	}
+	parents, err := n.linkingThreads(linked)
+	if err != nil {
+		return err
+	}
+	for _, p := range parents {
+		if p == id {
+			return nil
+		}
+	}
+	return util.PutMetadataJSON(n.store, linked, linkedByKey, append(parents, id))
+}
+
+func (n *net) UnlinkThread(_ context.Context, id, linked thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+
+	n.linkLock.Lock()
+	defer n.linkLock.Unlock()
+	links, err := n.threadLinks(id)
+	if err != nil {
+		return err
+	}
+	if err = util.PutMetadataJSON(n.store, id, linksKey, util.WithoutLink(links, linked)); err != nil {
+		return err
+	}
+	parents, err := n.linkingThreads(linked)
+	if err != nil {
+		return err
+	}
+	remaining := parents[:0]
+	for _, p := range parents {
+		if p != id {
+			remaining = append(remaining, p)
+		}
+	}
+	return util.PutMetadataJSON(n.store, linked, linkedByKey, remaining)
+}
+
+func (n *net) ThreadLinks(_ context.Context, id thread.ID, opts ...core.ThreadOption) ([]core.ThreadLink, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	return n.threadLinks(id)
+}
+
+// threadLinks returns the links declared from a thread.
+func (n *net) threadLinks(id thread.ID) ([]core.ThreadLink, error) {
+	var links []core.ThreadLink
+	return links, util.GetMetadataJSON(n.store, id, linksKey, &links)
+}
+
+// linkingThreads returns the threads declaring links to a thread.
+func (n *net) linkingThreads(id thread.ID) ([]thread.ID, error) {
+	var parents []thread.ID
+	return parents, util.GetMetadataJSON(n.store, id, linkedByKey, &parents)
+}
+
+// pullLinkedThreads pulls the threads linked from a thread, level by level down to the link depth.
+// Every thread is pulled once, so link cycles are fine. Linked threads which can't be pulled,
+// e.g., deleted after linking, are skipped, failed pulls are emitted as PullFailed events.
+func (n *net) pullLinkedThreads(ctx context.Context, id thread.ID) {
+	visited := map[thread.ID]struct{}{id: {}}
+	level := []thread.ID{id}
+	for depth := 0; depth < n.linkDepth && len(level) > 0; depth++ {
+		var next []thread.ID
+		for _, tid := range level {
+			links, err := n.threadLinks(tid)
+			if err != nil {
+				log.Errorf("error getting links of thread %s: %v", tid, err)
+				continue
+			}
+			for _, l := range links {
+				if _, ok := visited[l.ID]; ok {
+					continue
+				}
+				visited[l.ID] = struct{}{}
+				if err := ctx.Err(); err != nil {
+					return
+				}
+				if err := n.pullLinkedThread(ctx, l.ID); err != nil {
+					log.Debugf("error pulling thread %s linked from %s: %v", l.ID, tid, err)
+					continue
+				}
+				next = append(next, l.ID)
+			}
+		}
+		level = next
+	}
+}
+
+func (n *net) pullLinkedThread(ctx context.Context, id thread.ID) error {
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err := n.loadThread(id); err != nil {
+		return err
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return err
+	}
+	return n.pullThread(ctx, id)
+}
+
+// emitLinkedUpdated sends a head change of a thread to the threads linking to it.
+func (n *net) emitLinkedUpdated(tid thread.ID, lid, pid peer.ID, rid cid.Cid) {
+	parents, err := n.linkingThreads(tid)
+	if err != nil {
+		log.Errorf("error getting threads linking to %s: %v", tid, err)
+		return
+	}
+	for _, p := range parents {
+		n.emit(core.LifecycleEvent{
+			Type:     core.LinkedThreadUpdated,
+			ThreadID: p,
+			LinkedID: tid,
+			LogID:    lid,
+			PeerID:   pid,
+			RecordID: rid,
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package net
+
+import (
+	"context"
+	"math/bits"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+// metadata suffix for the log sequence number
+const logSeqSuffix = "/seq"
+
+// logSeq returns the sequence number of a log, i.e., the number of records appended
+// to it on the host. It's equal on hosts holding the same records of the log, so
+// exchanged along with edges, it tells how far behind a peer the host is.
+func (n *net) logSeq(tid thread.ID, lid peer.ID) (uint64, error) {
+	v, err := n.store.GetInt64(tid, lid.Pretty()+logSeqSuffix)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	return uint64(*v), nil
+}
+
+// advanceLogSeq adds records appended to a log to its sequence number. Sequence numbers
+// only guide pull scheduling, so errors are logged.
+func (n *net) advanceLogSeq(tid thread.ID, lid peer.ID, appended int) {
+	if appended == 0 {
+		return
+	}
+	n.seqLock.Lock()
+	defer n.seqLock.Unlock()
+	seq, err := n.logSeq(tid, lid)
+	if err == nil {
+		err = n.store.PutInt64(tid, lid.Pretty()+logSeqSuffix, int64(seq)+int64(appended))
+	}
+	if err != nil {
+		log.Errorf("advancing sequence number of log %s (thread=%s): %v", lid, tid, err)
+	}
+}
+
+// localLogSeqs returns the sequence numbers of the thread logs, which are exchanged with edges.
+func (n *net) localLogSeqs(tid thread.ID) []*pb.LogSeq {
+	info, err := n.store.GetThread(tid)
+	if err != nil {
+		// the thread may be unknown, edges are exchanged anyway
+		return nil
+	}
+	seqs := make([]*pb.LogSeq, 0, len(info.Logs))
+	for _, lg := range info.Logs {
+		seq, err := n.logSeq(tid, lg.ID)
+		if err != nil {
+			log.Errorf("getting sequence number of log %s (thread=%s): %v", lg.ID, tid, err)
+			continue
+		}
+		if seq > 0 {
+			seqs = append(seqs, &pb.LogSeq{LogID: &pb.ProtoPeerID{ID: lg.ID}, Seq: seq})
+		}
+	}
+	return seqs
+}
+
+// recordsBehind returns the number of records the host misses in every log of the peer,
+// judging by sequence numbers. Logs the host isn't behind in are left out.
+func (n *net) recordsBehind(tid thread.ID, remote []*pb.LogSeq) map[peer.ID]uint64 {
+	lags := make(map[peer.ID]uint64, len(remote))
+	for _, rs := range remote {
+		if rs.LogID == nil {
+			continue
+		}
+		seq, err := n.logSeq(tid, rs.LogID.ID)
+		if err != nil {
+			log.Errorf("getting sequence number of log %s (thread=%s): %v", rs.LogID.ID, tid, err)
+			continue
+		}
+		if rs.Seq > seq {
+			lags[rs.LogID.ID] = rs.Seq - seq
+		}
+	}
+	return lags
+}
+
+// scheduleRecordsUpdate schedules pulling records of the thread from a peer, whose edges
+// differ from the local ones. Threads the host is further behind in are pulled first, and
+// logs are requested with page sizes fitting the missing records if they're known.
+func (n *net) scheduleRecordsUpdate(pid peer.ID, tid thread.ID, remote []*pb.LogSeq) bool {
+	if removed, err := n.isRemovedReplicator(tid, pid); err != nil || removed {
+		return false
+	}
+	lags := n.recordsBehind(tid, remote)
+	if len(lags) == 0 {
+		// peer is running an older version, or sequence numbers don't tell
+		return n.queueGetRecords.Schedule(pid, tid, callPriorityLow, n.updateRecordsFromPeer)
+	}
+	var behind uint64
+	for _, lag := range lags {
+		behind += lag
+	}
+	return n.queueGetRecords.Schedule(pid, tid, lagPriority(behind),
+		func(ctx context.Context, pid peer.ID, tid thread.ID) error {
+			return n.pullRecordsFromPeer(ctx, pid, tid, lags)
+		})
+}
+
+// lagPriority returns the priority of pulling records of a thread the host is the given
+// number of records behind in, growing with the order of magnitude of the lag.
+func lagPriority(behind uint64) int {
+	return callPriorityLow + bits.Len64(behind)
+}
+
+// applyPageLimits sets the page sizes of requested logs to the numbers of records missing
+// in them, provided these fit into a single pull. Other logs are probed with a single record,
+// as sequence numbers of compacted logs may differ. Peers serve pages of even sizes otherwise.
+func applyPageLimits(req *pb.GetRecordsRequest, lags map[peer.ID]uint64, limit int) {
+	var total uint64
+	for _, lg := range req.Body.Logs {
+		if lag := lags[lg.LogID.ID]; lag > 0 {
+			total += lag
+		} else {
+			total++
+		}
+		if total > uint64(limit) {
+			return
+		}
+	}
+	for _, lg := range req.Body.Logs {
+		if lag := lags[lg.LogID.ID]; lag > 0 {
+			lg.Limit = int32(lag)
+		} else {
+			lg.Limit = 1
+		}
+	}
+}
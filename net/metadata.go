@@ -0,0 +1,204 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// metadataKey is the metadata key of the signed thread metadata, stored in its encoding.
+const metadataKey = "/thread-metadata"
+
+// MaxMetadataClockSkew bounds how far ahead of the local clock thread metadata received from
+// peers may be dated. Later metadata is refused, so a log can't pin the metadata of a thread,
+// or make updates overflow, by dating it far in the future.
+var MaxMetadataClockSkew = time.Hour
+
+func (n *net) SetThreadMetadata(
+	ctx context.Context,
+	id thread.ID,
+	md thread.Metadata,
+	opts ...core.ThreadOption,
+) (thread.Metadata, error) {
+	if err := n.checkWritable(); err != nil {
+		return md, err
+	}
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return md, err
+	}
+	if identity == nil {
+		if identity, err = n.localIdentity(args.Identity); err != nil {
+			return md, err
+		}
+	}
+	if err = md.Validate(); err != nil {
+		return md, err
+	}
+	lg, err := n.getOrCreateLog(id, identity)
+	if err != nil {
+		return md, err
+	}
+	if err = n.checkThreadWriter(id, lg.ID); err != nil {
+		return md, err
+	}
+	signer, err := n.logSigner(ctx, id, lg)
+	if err != nil {
+		return md, err
+	}
+
+	// updates follow the current metadata, even if the local clock is behind
+	current, ok, err := n.threadMetadata(id)
+	if err != nil {
+		return md, err
+	}
+	md.UpdatedAt = n.clock.Now()
+	if ok && !md.UpdatedAt.After(current.UpdatedAt) {
+		md.UpdatedAt = current.UpdatedAt.Add(1)
+	}
+	payload, err := md.Payload(id)
+	if err != nil {
+		return md, err
+	}
+	md.Signer = signer.PubKey()
+	if md.Sig, err = signer.Sign(ctx, payload); err != nil {
+		return md, fmt.Errorf("signing thread metadata: %w", err)
+	}
+	if _, err = n.putThreadMetadata(id, md); err != nil {
+		return md, err
+	}
+	n.pushThreadMetadata(ctx, id, lg)
+	return md, nil
+}
+
+func (n *net) GetThreadMetadata(
+	_ context.Context,
+	id thread.ID,
+	opts ...core.ThreadOption,
+) (thread.Metadata, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return thread.Metadata{}, err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return thread.Metadata{}, err
+	}
+	md, _, err := n.threadMetadata(id)
+	return md, err
+}
+
+// threadMetadata returns the metadata of a thread, or false if none is set.
+func (n *net) threadMetadata(tid thread.ID) (thread.Metadata, bool, error) {
+	data, err := n.threadMetadataBytes(tid)
+	if err != nil || data == nil {
+		return thread.Metadata{}, false, err
+	}
+	md, err := thread.MetadataFromBytes(data)
+	if err != nil {
+		return thread.Metadata{}, false, err
+	}
+	return md, true, nil
+}
+
+// threadMetadataBytes returns the encoded metadata of a thread, nil if none is set.
+func (n *net) threadMetadataBytes(tid thread.ID) ([]byte, error) {
+	data, err := n.store.GetBytes(tid, metadataKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return *data, nil
+}
+
+// mergeThreadMetadata verifies encoded metadata received from a peer, and keeps it if it
+// supersedes the metadata of the thread.
+func (n *net) mergeThreadMetadata(tid thread.ID, data []byte) error {
+	md, err := thread.MetadataFromBytes(data)
+	if err != nil {
+		return err
+	}
+	if err = md.Verify(tid); err != nil {
+		return err
+	}
+	// only the logs of the thread may describe it
+	lid, err := peer.IDFromPublicKey(md.Signer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", thread.ErrInvalidMetadata, err)
+	}
+	if pk, err := n.store.PubKey(tid, lid); err != nil {
+		return err
+	} else if pk == nil {
+		return fmt.Errorf("%w: signer %s isn't a thread log", thread.ErrInvalidMetadata, lid)
+	}
+	if err = n.checkThreadWriter(tid, lid); err != nil {
+		return fmt.Errorf("%w: %v", thread.ErrInvalidMetadata, err)
+	}
+	if limit := n.clock.Now().Add(MaxMetadataClockSkew); md.UpdatedAt.After(limit) {
+		return fmt.Errorf("%w: updated at %s, ahead of the local clock", thread.ErrInvalidMetadata, md.UpdatedAt)
+	}
+	updated, err := n.putThreadMetadata(tid, md)
+	if updated {
+		log.Debugf("thread %s metadata updated by log %s", tid, lid)
+	}
+	return err
+}
+
+// putThreadMetadata stores signed metadata, unless the thread metadata supersedes it.
+// It returns whether the metadata was stored.
+func (n *net) putThreadMetadata(tid thread.ID, md thread.Metadata) (updated bool, err error) {
+	data, err := md.Marshal()
+	if err != nil {
+		return false, err
+	}
+	err = n.withThreadLock(tid, func() error {
+		current, ok, err := n.threadMetadata(tid)
+		if err != nil || (ok && !md.After(current)) {
+			return err
+		}
+		updated = true
+		return n.store.PutBytes(tid, metadataKey, data)
+	})
+	return updated, err
+}
+
+// pushThreadMetadata sends the log which signed new metadata to the thread peers, which
+// carries the metadata along.
+func (n *net) pushThreadMetadata(ctx context.Context, id thread.ID, lg thread.LogInfo) {
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		log.Errorf("getting thread %s: %v", id, err)
+		return
+	}
+	var addrs []ma.Multiaddr
+	for _, l := range info.Logs {
+		addrs = append(addrs, l.Addrs...)
+	}
+	peers, err := n.uniquePeers(addrs)
+	if err != nil {
+		log.Errorf("getting peers of thread %s: %v", id, err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, p := range peers {
+		wg.Add(1)
+		go func(pid peer.ID) {
+			defer wg.Done()
+			if err := n.server.pushLog(ctx, id, lg, pid, nil, nil); err != nil {
+				log.Errorf("error pushing metadata of thread %s to %s: %v", id, pid, err)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,242 @@
+package net
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// schemaVersionKey is the key of the logstore schema version. The version of all stored
+// threads is kept in the host datastore, while threads migrated ahead of the others, or
+// added at the current version, keep their own in the thread metadata.
+const schemaVersionKey = "/schema-version"
+
+// migration upgrades the logstore state of a single thread to the next schema version.
+// Migrations must be idempotent, a thread is migrated again if the host stops before
+// its new version is saved.
+type migration struct {
+	name string
+	run  func(n *net, tid thread.ID) error
+}
+
+// migrations are applied in order. The schema version of a thread is the
+// number of migrations applied to it, so new ones must only be appended.
+var migrations = []migration{
+	{name: "index own log", run: (*net).migrateOwnLog},
+	{name: "index record bodies", run: (*net).migrateBodyIndex},
+}
+
+// loadSchemaVersion loads the schema version of all stored threads on startup. Hosts
+// with threads at an older version migrate them lazily, see migrate.
+func (n *net) loadSchemaVersion() error {
+	b, err := n.schemaStore.Get(datastore.NewKey(schemaVersionKey))
+	if errors.Is(err, datastore.ErrNotFound) {
+		// threads of new hosts are added at the current version
+		if ids, err := n.store.Threads(); err != nil || len(ids) > 0 {
+			return err
+		}
+		return n.saveSchemaVersion()
+	} else if err != nil {
+		return err
+	}
+	version, err := decodeSchemaVersion(b)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than supported %d", version, len(migrations))
+	}
+	n.schemaLock.Lock()
+	n.storedVersion = version
+	n.schemaLock.Unlock()
+	return nil
+}
+
+// migrate brings the threads stored at an older schema version to the current one
+// in the background, and saves the version for all threads once done. Threads
+// locked in the meantime are migrated by lockThread, so their requests see the
+// current schema.
+func (n *net) migrate() {
+	n.schemaLock.RLock()
+	current := n.storedVersion == len(migrations)
+	n.schemaLock.RUnlock()
+	if current {
+		return
+	}
+
+	var (
+		cursor = newThreadCursor(n.store)
+		failed bool
+	)
+	for n.ctx.Err() == nil {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			log.Errorf("listing threads to migrate failed: %v", err)
+			return
+		} else if !ok {
+			break
+		}
+		ts, err := n.lockThread(tid)
+		if err != nil {
+			log.Errorf("migrating thread %s failed: %v", tid, err)
+			failed = true
+			continue
+		}
+		ts.Release()
+	}
+	if failed || n.ctx.Err() != nil {
+		return // retried on the next start
+	}
+
+	if err := n.saveSchemaVersion(); err != nil {
+		log.Errorf("saving schema version failed: %v", err)
+		return
+	}
+	log.Infof("migrated logstore to schema version %d", len(migrations))
+}
+
+// saveSchemaVersion saves the current schema version as the version of all stored threads.
+func (n *net) saveSchemaVersion() error {
+	n.schemaLock.Lock()
+	defer n.schemaLock.Unlock()
+	if err := n.schemaStore.Put(datastore.NewKey(schemaVersionKey), encodeSchemaVersion(len(migrations))); err != nil {
+		return err
+	}
+	n.storedVersion = len(migrations)
+	return nil
+}
+
+// migrateThread applies the pending migrations to a thread.
+// The caller must hold the thread semaphore.
+func (n *net) migrateThread(tid thread.ID) error {
+	n.schemaLock.RLock()
+	defer n.schemaLock.RUnlock()
+	if n.storedVersion == len(migrations) {
+		return nil
+	}
+
+	version, err := n.schemaVersion(tid)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than supported %d", version, len(migrations))
+	} else if version == len(migrations) {
+		return nil
+	}
+	// the thread may be about to be added
+	if _, err = n.store.GetThread(tid); errors.Is(err, lstore.ErrThreadNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for v := version; v < len(migrations); v++ {
+		if err := migrations[v].run(n, tid); err != nil {
+			return fmt.Errorf("migration %q: %w", migrations[v].name, err)
+		}
+		if err := n.setSchemaVersion(tid, v+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateThreadLocked applies the pending migrations to a thread, taking the thread
+// semaphore only if there are any.
+func (n *net) migrateThreadLocked(tid thread.ID) error {
+	n.schemaLock.RLock()
+	migrated := n.storedVersion == len(migrations)
+	n.schemaLock.RUnlock()
+	if migrated {
+		return nil
+	}
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return err
+	}
+	ts.Release()
+	return nil
+}
+
+// schemaVersion returns the logstore schema version of a thread. The caller must hold
+// the schema lock. Threads without a version of their own are at the stored version.
+func (n *net) schemaVersion(tid thread.ID) (int, error) {
+	b, err := n.store.GetBytes(tid, schemaVersionKey)
+	if err != nil || b == nil {
+		return n.storedVersion, err
+	}
+	version, err := decodeSchemaVersion(*b)
+	if err != nil || version < n.storedVersion {
+		return n.storedVersion, err
+	}
+	return version, nil
+}
+
+func (n *net) setSchemaVersion(tid thread.ID, version int) error {
+	return n.store.PutBytes(tid, schemaVersionKey, encodeSchemaVersion(version))
+}
+
+func encodeSchemaVersion(version int) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, uint64(version))]
+}
+
+func decodeSchemaVersion(b []byte) (int, error) {
+	v, l := binary.Uvarint(b)
+	if l <= 0 {
+		return 0, fmt.Errorf("invalid schema version")
+	}
+	return int(v), nil
+}
+
+// addThread adds a thread to the logstore at the current schema version. Threads which are
+// stored already, e.g., added again with another identity, keep their version and aren't announced.
+func (n *net) addThread(info thread.Info) error {
+	_, err := n.store.GetThread(info.ID)
+	if err == nil {
+		if err = n.store.AddThread(info); err != nil {
+			return err
+		}
+		// the thread may be added again with another key
+		n.refreshThreadTopic(info.ID)
+		return nil
+	} else if !errors.Is(err, lstore.ErrThreadNotFound) {
+		return err
+	}
+	if err = n.store.AddThread(info); err != nil {
+		return err
+	}
+	if err = n.setSchemaVersion(info.ID, len(migrations)); err != nil {
+		return err
+	}
+	n.emit(core.LifecycleEvent{Type: core.ThreadAdded, ThreadID: info.ID})
+	return nil
+}
+
+// migrateOwnLog indexes the old-style "own" log of the host, which was created
+// before logs were indexed by identity, so it's found by getOrCreateLog.
+func (n *net) migrateOwnLog(tid thread.ID) error {
+	identity := thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	if lidb, err := n.store.GetBytes(tid, identity.String()); err != nil || lidb != nil {
+		return err
+	}
+	info, err := n.store.GetThread(tid)
+	if err != nil {
+		return err
+	}
+	own := info.GetFirstPrivKeyLog()
+	if own == nil {
+		return nil
+	}
+	lidb, err := own.ID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	log.Debugf("indexing own log %s (thread=%s)", own.ID, tid)
+	return n.store.PutBytes(tid, identity.String(), lidb)
+}
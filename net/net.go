@@ -1,17 +1,21 @@
-//Package net implements the network layer for go-threads. Nodes exchange messages with each other via gRPC, and the format is defined under /pb.
+// Package net implements the network layer for go-threads. Nodes exchange messages with each other via gRPC, and the format is defined under /pb.
 package net
 
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
+	datastore "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
 	bs "github.com/ipfs/go-ipfs-blockstore"
 	format "github.com/ipfs/go-ipld-format"
 	logging "github.com/ipfs/go-log"
@@ -19,35 +23,47 @@ import (
 	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/peer"
 	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-libp2p-core/routing"
 	gostream "github.com/libp2p/go-libp2p-gostream"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/textileio/go-threads/broadcast"
 	"github.com/textileio/go-threads/cbor"
 	"github.com/textileio/go-threads/core/app"
+	"github.com/textileio/go-threads/core/keystore"
 	lstore "github.com/textileio/go-threads/core/logstore"
 	core "github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	tcrypto "github.com/textileio/go-threads/crypto"
 	sym "github.com/textileio/go-threads/crypto/symmetric"
 	pb "github.com/textileio/go-threads/net/pb"
 	"github.com/textileio/go-threads/net/queue"
 	"github.com/textileio/go-threads/net/util"
 	tu "github.com/textileio/go-threads/util"
+	"github.com/textileio/go-threads/util/clock"
 	"google.golang.org/grpc"
 )
 
 var (
 	log = logging.Logger("net")
 
-	// MaxPullLimit is the maximum page size for pulling records.
+	// MaxPullLimit is the default maximum page size for pulling records, see Config.Sync.
 	MaxPullLimit = 10000
 
+	// MaxHeadCollapseDepth is the maximum number of records walked back from a new record
+	// to find the heads of a forked log it descends from.
+	MaxHeadCollapseDepth = 1000
+
+	// MaxConcurrentPulls is the default maximum number of peers pulled from at once by
+	// a thread pull, see Config.Sync.
+	MaxConcurrentPulls = 8
+
 	// PullStartAfter is the pause before exchange edges starts.
 	PullStartAfter = time.Second
 
-	// InitialPullInterval is the interval for the first iteration of edge exchange.
+	// InitialPullInterval is the default interval for the first iteration of edge exchange, see Config.Sync.
 	InitialPullInterval = time.Second
 
-	// PullInterval is the interval between automatic edge exchanges.
+	// PullInterval is the default interval between automatic edge exchanges, see Config.Sync.
 	PullInterval = time.Second * 10
 
 	// MaxThreadsExchanged is the maximum number of threads for the single edge exchange.
@@ -56,13 +72,21 @@ var (
 	// ExchangeCompressionTimeout is the maximum duration of collecting threads for the exchange edges request.
 	ExchangeCompressionTimeout = PullTimeout / 2
 
-	// QueuePollInterval is the polling interval for the call queue.
+	// PubSubJoinInterval is the pause between joining topics of stored threads on startup.
+	PubSubJoinInterval = time.Millisecond * 10
+
+	// QueuePollInterval is the default polling interval for the call queue, see Config.Sync.
 	QueuePollInterval = time.Millisecond * 500
 
-	// EventBusCapacity is the buffer size of local event bus listeners.
+	// MaxPeerCalls is the default maximum number of scheduled pulls from a peer running at once,
+	// see Config.MaxPeerCalls.
+	MaxPeerCalls = 4
+
+	// EventBusCapacity is the default buffer size of local event bus listeners, see Config.Sync.
 	EventBusCapacity = 1
 
-	// notifyTimeout is the duration to wait for a subscriber to read a new record.
+	// notifyTimeout is the duration to wait for a subscriber to read a new record, measured
+	// by the host clock, see Config.Clock.
 	notifyTimeout = time.Second * 5
 
 	// tokenChallengeBytes is the byte length of token challenges.
@@ -75,37 +99,194 @@ var (
 const (
 	callPriorityLow  = 1
 	callPriorityHigh = 3
+
+	// lanes of the call queue, log pulls are weighted higher since records
+	// of unknown logs can't be pulled
+	callLaneLogs      = "logs"
+	callLaneRecords   = "records"
+	callWeightLogs    = 2
+	callWeightRecords = 1
 )
 
 var (
 	_ util.SemaphoreKey = (*semaThreadUpdate)(nil)
+	_ util.SemaphoreKey = (*semaLogUpdate)(nil)
+	_ util.SemaphoreKey = (*semaLocalWrites)(nil)
 )
 
 // semaphore protecting thread info updates
 type semaThreadUpdate thread.ID
 
 func (t semaThreadUpdate) Key() string {
-	return "tu:" + string(t)
+	return semaThreadUpdatePrefix + thread.ID(t).String()
+}
+
+const semaThreadUpdatePrefix = "tu:"
+
+// semaphore protecting log head updates, which are serialized even if
+// Config.ThreadLockWidth lets updates of a thread run concurrently
+type semaLogUpdate struct {
+	tid thread.ID
+	key string // log ID, or the identity of a log being created
+}
+
+func (l semaLogUpdate) Key() string {
+	return "lu:" + l.tid.String() + "/" + l.key
+}
+
+// lock holding off records created locally while a thread is deleted, unloaded, archived or
+// pruned, since creations don't take the thread semaphore
+type semaLocalWrites thread.ID
+
+func (t semaLocalWrites) Key() string {
+	return "lw:" + thread.ID(t).String()
+}
+
+var (
+	// datastore prefixes of the persisted call queues, see Config.PersistCallQueues
+	queueGetLogsPrefix    = datastore.NewKey("/queue/logs")
+	queueGetRecordsPrefix = datastore.NewKey("/queue/records")
+)
+
+// lockThread acquires the thread update semaphore, failing with util.ErrSemaphoreTimeout
+// if it isn't acquired within Config.ThreadLockTimeout. The caller must release it.
+// Threads stored at an older schema version are migrated first, see migrate.
+func (n *net) lockThread(id thread.ID) (*util.Semaphore, error) {
+	ts, err := n.semaphores.Acquire(semaThreadUpdate(id))
+	if err != nil {
+		return nil, err
+	}
+	if err = n.migrateThread(id); err != nil {
+		ts.Release()
+		return nil, fmt.Errorf("migrating thread %s: %w", id, err)
+	}
+	return ts, nil
+}
+
+// lockLog acquires the head update semaphore of a log. Records received from peers are put
+// holding the thread semaphore too, records created locally only hold the log semaphore and
+// the shared local writes lock, see blockLocalWrites.
+func (n *net) lockLog(tid thread.ID, lid peer.ID) (*util.Semaphore, error) {
+	return n.logSemaphores.Acquire(semaLogUpdate{tid: tid, key: lid.String()})
+}
+
+// blockLocalWrites waits for the records being created locally on a thread, and holds off
+// further ones until the returned function is called. The caller must hold the thread semaphore.
+func (n *net) blockLocalWrites(id thread.ID) (release func()) {
+	return n.localWrites.Lock(semaLocalWrites(id))
+}
+
+// withThreadWriteLock runs f holding the thread semaphore, with local writes blocked.
+func (n *net) withThreadWriteLock(id thread.ID, f func() error) error {
+	return n.withThreadLock(id, func() error {
+		defer n.blockLocalWrites(id)()
+		return f()
+	})
 }
 
 // net is an implementation of app.Net.
 type net struct {
 	format.DAGService
-	host   host.Host
-	bstore bs.Blockstore
+	host    host.Host
+	bstore  bs.Blockstore
+	routing routing.Routing
+
+	store     lstore.Logstore
+	ephemeral *ephemeral
 
-	store lstore.Logstore
+	rpc     *grpc.Server
+	gateway *grpc.Server
+	admin   *grpc.Server
+	ws      *wsGateway
+	server  *server
+	bus     *broadcast.Broadcaster
+	events  *broadcast.Broadcaster
 
-	rpc    *grpc.Server
-	server *server
-	bus    *broadcast.Broadcaster
+	recentEvents *util.EventRing
 
 	connectors map[thread.ID]*app.Connector
 	connLock   sync.RWMutex
 
 	semaphores      *util.SemaphorePool
+	logSemaphores   *util.SemaphorePool
+	localWrites     *util.RWLockPool
+	gcLock          sync.RWMutex
+	calls           *queue.PriorityQueue
 	queueGetLogs    queue.CallQueue
 	queueGetRecords queue.CallQueue
+	deliveries      *deliveryQueue
+	acks            *ackBook
+	pulls           *pullTracker
+	unloaded        *unloadedFlags
+	peerLimiter     *rateLimiter
+	threadLimiter   *rateLimiter
+	byteLimiter     *rateLimiter
+	challenges      *tokenChallenges
+
+	prefetchAttachments bool
+	maxRecordSize       int
+	maxRecordBodySize   int
+	linkDepth           int
+	commitHooks         []core.CommitHook
+	acceptHooks         []core.AcceptHook
+	persistHooks        []core.PersistHooks
+	keyRotationHook     core.KeyRotationHook
+	recordClock         core.RecordClock
+	cipher              core.RecordCipher
+	signers             core.SignerProvider
+	signingTimeout      time.Duration
+	headerSync          bool
+	edgeGossip          bool
+	privateTopics       bool
+	requireCapabilities bool
+	compression         bool
+	compressionCodec    string
+	bodyCompression     bool
+	compressionStats    *compressionStats
+	connectivity        *connectivityTracker
+	rpcStats            *rpcStats
+	embedded            bool
+
+	checkpointVerification bool
+	lazyLogs               bool
+
+	connGater *ConnGater
+
+	relay     RelayConfig
+	relayed   map[thread.ID]struct{}
+	relayLock sync.Mutex
+
+	quotas QuotaConfig
+
+	topology     *topology
+	reputation   *reputations
+	revocations  *revocations
+	capabilities *capabilities
+	protocols    *peerProtocols
+	features     []string
+	escrow       datastore.Datastore
+	recIndex     *recordIndex
+	bodies       *bodyIndex
+	extensions   *recordExtensions
+	withheld     *withheld
+	deadLetters  *deadLetters
+	journal      *headJournal
+	tokenTTL     time.Duration
+	keystore     keystore.Keystore
+	readOnly     bool
+	blockRefs    datastore.Datastore
+	schemaStore  datastore.Datastore
+	clock        clock.Clock
+
+	sync      core.SyncConfig
+	syncLock  sync.RWMutex
+	seqLock   sync.Mutex
+	linkLock  sync.Mutex
+	quotaLock sync.Mutex
+
+	// schema version of all stored threads, see migrate
+	storedVersion int
+	schemaLock    sync.RWMutex
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -115,6 +296,253 @@ type net struct {
 type Config struct {
 	Debug  bool
 	PubSub bool
+
+	// FetchAttachments makes the host load attachments linked from the bodies of
+	// received records eagerly. Otherwise, attachments are fetched on first read.
+	FetchAttachments bool
+
+	// Datastore keeps network state which must survive restarts, e.g., pending
+	// record deliveries. If not set, an in-memory datastore is used.
+	Datastore datastore.Datastore
+
+	// PersistCallQueues keeps the log and record pulls scheduled from peers in Datastore,
+	// so they survive restarts. Otherwise, pulls scheduled before a restart are lost.
+	PersistCallQueues bool
+
+	// MaxPeerCalls is the maximum number of log and record pulls scheduled from a peer which
+	// run at once. Zero means MaxPeerCalls, a negative value disables the limit.
+	MaxPeerCalls int
+
+	// ListenAddr additionally exposes the network API over TCP, e.g., for
+	// clients running in other processes without a libp2p host, see client.NewGatewayClient.
+	// The network API acts with the authority of the host, so addresses other than
+	// loopback ones require ListenTLS with verified client certificates, or ListenToken.
+	ListenAddr ma.Multiaddr
+
+	// ListenTLS secures connections to ListenAddr. Plain TCP is used if not set.
+	ListenTLS *tls.Config
+
+	// ListenToken is required from network API clients as an access token if set.
+	ListenToken string
+
+	// WebSocketAddr exposes the thread service to peers without a libp2p host, e.g., browsers,
+	// over WebSockets, see WebSocketProtocolJSON and WebSocketProtocolBinary. Peers authenticate
+	// with their libp2p keys and are subject to ConnGater.
+	WebSocketAddr ma.Multiaddr
+
+	// WebSocketTLS secures connections to WebSocketAddr. Plain TCP is used if not set.
+	WebSocketTLS *tls.Config
+
+	// RateLimits protect the host from peers sending too many requests or records.
+	RateLimits RateLimits
+
+	// MaxRecordSize is the byte limit on every node of a record received from
+	// peers. Zero means DefaultMaxRecordSize, a negative value disables the limit.
+	MaxRecordSize int
+
+	// MaxRecordBodySize is the byte limit on the whole (coded) body of every created or
+	// received record. Bodies larger than cbor.BodyChunkSize are split into chunks, so they
+	// aren't bound by MaxRecordSize. Zero means DefaultMaxRecordBodySize, a negative value
+	// disables the limit.
+	MaxRecordBodySize int
+
+	// EventLogSize is the number of recent lifecycle events kept for RecentEvents and DumpEvents.
+	// Zero means DefaultEventLogSize, a negative value disables the event log.
+	EventLogSize int
+
+	// LinkDepth bounds how deep PullThread follows links to other threads, see core.Net.LinkThread.
+	// Zero means DefaultLinkDepth, a negative value disables pulling linked threads.
+	LinkDepth int
+
+	// PeerBanThreshold is the number of misbehaviors, e.g., responses with records which fail
+	// verification or exceed the size limits, after which a peer is skipped by pulls for
+	// PeerBanDuration. Zero means DefaultPeerBanThreshold, a negative value disables bans.
+	PeerBanThreshold int
+
+	// PeerBanDuration is the duration of peer bans. Zero means DefaultPeerBanDuration.
+	PeerBanDuration time.Duration
+
+	// TokenTTL is the lifetime of tokens issued by GetToken. Zero issues tokens which never expire.
+	TokenTTL time.Duration
+
+	// KeyEscrow makes the host hold thread key shares deposited by peers with EscrowThreadKey,
+	// and return them to the depositing peers on recovery. Shares are kept in Datastore.
+	KeyEscrow bool
+
+	// SharedBlocks keeps track of the threads referencing every record, event, header and body
+	// block in Datastore, so deleting or compacting a thread keeps blocks still referenced by
+	// other threads. Blocks stored before it was enabled aren't tracked. See also BlockStats.
+	SharedBlocks bool
+
+	// ReadOnly makes the host refuse to create threads and logs, and to create or add records
+	// locally with ErrReadOnly, e.g., for dedicated replication or backup nodes. Records of
+	// other peers are still pulled, served and relayed.
+	ReadOnly bool
+
+	// GCInterval schedules collection of orphaned blocks, see GC. Zero disables scheduled runs.
+	GCInterval time.Duration
+
+	// CommitHooks are run in order on every record body before it's committed,
+	// so policies apply to local and remote writes alike.
+	CommitHooks []core.CommitHook
+
+	// AcceptHooks are run in order on the author of every record received from peers.
+	AcceptHooks []core.AcceptHook
+
+	// PersistHooks are a chain of hooks run in order around the persistence and broadcast
+	// of records, see core.PersistHooks.
+	PersistHooks []core.PersistHooks
+
+	// KeyRotationHook is called once a replicator is removed with RemoveReplicator,
+	// so the application can rotate the thread service key. The new key is signed by the
+	// host log and pushed to the remaining peers, which take it if the host is a writer.
+	KeyRotationHook core.KeyRotationHook
+
+	// RecordClock assigns logical timestamps to new records. A lamport clock kept in the
+	// thread metadata is used if not set.
+	RecordClock core.RecordClock
+
+	// Keystore holds the named identities the host may act as in threads, see
+	// core.WithIdentity. Only the host identity is available if not set.
+	Keystore keystore.Keystore
+
+	// RecordCipher replaces the thread read key in sealing and opening record headers,
+	// which carry the keys of record bodies. All hosts of a thread must use the same scheme.
+	RecordCipher core.RecordCipher
+
+	// Signers sign records of logs whose private keys aren't kept in the logstore, i.e., logs
+	// created with a public log key, e.g., keys held in an HSM, an OS keychain or a remote KMS.
+	// Records are signed while the thread is locked, since they chain on the log head, so a slow
+	// signer holds up the sync of the thread and blocks GC until it's done or SigningTimeout.
+	Signers core.SignerProvider
+
+	// SigningTimeout bounds the time Signers have to sign a record. 0 means DefaultSigningTimeout.
+	SigningTimeout time.Duration
+
+	// TrackAcks makes the host keep the peers which acknowledged the records it pushed for
+	// AckTTL, see RecordReplicationStatus. Acknowledgements of records awaited by a write
+	// quorum are kept until the quorum is decided either way.
+	TrackAcks bool
+
+	// HeaderSync makes the host pull record headers first, and request bodies only for
+	// records accepted by AcceptHooks. It saves bandwidth if many records are rejected.
+	HeaderSync bool
+
+	// EdgeGossip makes the host periodically gossip thread edges over pubsub instead of
+	// exchanging them with every peer. Edges are still exchanged with peers which don't
+	// gossip, e.g., running older versions. Gossip is authenticated with the service key,
+	// so only peers holding it are pulled from. It requires PubSub.
+	EdgeGossip bool
+
+	// PrivateTopics names thread topics by a keyed hash of the thread ID under the service key
+	// instead of the thread ID, so only peers holding the key can tell the thread of a topic.
+	// Topics change once the service key is rotated. Peers of a thread must agree on it to
+	// exchange records over pubsub. It requires PubSub.
+	PrivateTopics bool
+
+	// RequireCapabilities makes the host serve threads it minted capabilities for only to peers
+	// presenting a capability, which grants the rights the request needs, see MintCapability.
+	// It covers logs, records, record bodies and subscriptions, while records of such threads
+	// received over pubsub are dropped, since they can't carry a capability. The service key
+	// alone no longer authorizes peers then. Capabilities presented for other threads are
+	// checked as well, and let peers fetch logs and records without the service key, but
+	// ones the host can't verify, e.g., minted by another replica, are ignored.
+	RequireCapabilities bool
+
+	// CheckpointVerification makes the host verify the signature of the newest record of every
+	// chain received from peers only, older records are verified by their hash links to it, down to
+	// the last record verified locally. It saves most of the signature checks of nodes catching up
+	// with long logs, e.g., after a long downtime.
+	CheckpointVerification bool
+
+	// LazyLogs makes the host skip logs without records while updating logs from peers, which
+	// saves storing hundreds of idle logs of large collaborations. Such logs are added along with
+	// their first records pulled or pushed by peers.
+	LazyLogs bool
+
+	// Compression compresses edge and record messages exchanged with peers with CompressionCodec.
+	// It's used with peers advertising support of it only, so older peers keep working.
+	Compression bool
+
+	// CompressionCodec is the codec of compressed messages and record bodies, zstd or snappy.
+	// Empty means DefaultCompressionCodec. Messages sent to peers which don't support it are
+	// compressed with zstd.
+	CompressionCodec string
+
+	// BodyCompression compresses bodies of new records with CompressionCodec before encryption,
+	// provided every known peer of the thread advertised support of compressed bodies, as
+	// older peers can't read such records. It saves bandwidth and storage for text-heavy bodies.
+	BodyCompression bool
+
+	// Embedded runs the host as a client-only peer, e.g., on devices which should never serve
+	// records to others. The thread protocol isn't served, so the host only syncs by pulling
+	// records from peers and pushing its own ones. It disables PubSub and can't be combined
+	// with Relay. The network API is still exposed on ListenAddr if set.
+	Embedded bool
+
+	// Routing resolves addresses of peers, e.g., replicators added by ID only, and
+	// discovers other replicators of stored threads. Discovery is disabled if not set.
+	Routing routing.Routing
+
+	// AdminAddr exposes the admin API over TCP, e.g., for the CLI and dashboards.
+	// The admin API acts with the authority of the host, so it requires AdminTLS
+	// with verified client certificates, or AdminToken.
+	AdminAddr ma.Multiaddr
+
+	// AdminTLS secures connections to AdminAddr.
+	AdminTLS *tls.Config
+
+	// AdminToken is required from admin clients as a bearer token if set.
+	AdminToken string
+
+	// ThreadLockWidth is the number of updates of a single thread which may run
+	// concurrently. Zero means one, i.e., thread updates are serialized.
+	// Head updates of a single log are always serialized.
+	ThreadLockWidth int
+
+	// ThreadLockTimeout bounds the wait for a thread lock, so operations stuck behind
+	// a deadlocked update fail with a descriptive error. Zero waits forever.
+	ThreadLockTimeout time.Duration
+
+	// ConnGater refuses connections of denied peers and restricts the thread protocol
+	// to peers of stored threads. It must be the gater passed to the host, see NewConnGater.
+	ConnGater *ConnGater
+
+	// Relay makes the host store records of threads pushed by peers without the read key
+	// within quotas, and advertise it to peers.
+	Relay RelayConfig
+
+	// Quotas bound the blockstore space taken by the records of every thread and log.
+	Quotas QuotaConfig
+
+	// Topology makes the host prefer replicators in its own region for pushes and pulls.
+	Topology TopologyConfig
+
+	// Publish bounds publishing of records over pubsub. It requires PubSub.
+	Publish PublishConfig
+
+	// ConnPool bounds the gRPC client connections to peers, closing idle ones.
+	ConnPool ConnPoolConfig
+
+	// DeadLetterAttempts is the number of attempts to handle a record received from peers
+	// before it's skipped and kept as a dead letter, see core.DeadLetter. Zero means
+	// DefaultDeadLetterAttempts, a negative value retries records until handled.
+	DeadLetterAttempts int
+
+	// ServerInterceptors are added to the default interceptor chain of the thread service.
+	ServerInterceptors ServerInterceptors
+
+	// ClientInterceptors are added to the default interceptor chain of peer connections.
+	ClientInterceptors ClientInterceptors
+
+	// Sync tunes the synchronization with peers. Zero fields mean the package defaults,
+	// e.g., PullInterval. It can be changed at runtime with UpdateConfig.
+	Sync core.SyncConfig
+
+	// Clock drives the pull loop, joining topics of stored threads, the call queues, the edge
+	// exchange compressor, the retention reaper and subscriber notification timeouts, so tests
+	// can advance time deterministically with a clock.Mock. A real clock is used if not set.
+	Clock clock.Clock
 }
 
 // NewNetwork creates an instance of net from the given host and thread store.
@@ -138,55 +566,282 @@ func NewNetwork(
 		}
 	}
 
+	if conf.MaxRecordSize == 0 {
+		conf.MaxRecordSize = DefaultMaxRecordSize
+	}
+	if conf.MaxRecordBodySize == 0 {
+		conf.MaxRecordBodySize = DefaultMaxRecordBodySize
+	}
+	if conf.EventLogSize == 0 {
+		conf.EventLogSize = DefaultEventLogSize
+	}
+	if conf.LinkDepth == 0 {
+		conf.LinkDepth = DefaultLinkDepth
+	}
+	if conf.PeerBanThreshold == 0 {
+		conf.PeerBanThreshold = DefaultPeerBanThreshold
+	}
+	if conf.PeerBanDuration == 0 {
+		conf.PeerBanDuration = DefaultPeerBanDuration
+	}
+	if conf.CompressionCodec == "" {
+		conf.CompressionCodec = DefaultCompressionCodec
+	} else if !hasCodec(messageCodecs, conf.CompressionCodec) || !cbor.IsBodyCodec(conf.CompressionCodec) {
+		return nil, fmt.Errorf("unknown compression codec %s", conf.CompressionCodec)
+	}
+	if conf.ThreadLockWidth <= 0 {
+		conf.ThreadLockWidth = 1
+	}
+	if conf.MaxPeerCalls == 0 {
+		conf.MaxPeerCalls = MaxPeerCalls
+	}
+	if conf.SigningTimeout == 0 {
+		conf.SigningTimeout = DefaultSigningTimeout
+	}
+	if err = validateSyncConfig(conf.Sync); err != nil {
+		return nil, err
+	}
+	if conf.Embedded {
+		if conf.Relay.Enabled {
+			return nil, fmt.Errorf("relay requires serving peers, it can't be enabled in embedded mode")
+		}
+		// pubsub delivers records from peers, and forwards records of others
+		conf.PubSub = false
+	}
+	conf.Sync = withSyncDefaults(conf.Sync)
+	clk := clock.OrNew(conf.Clock)
+
+	if conf.Datastore == nil {
+		conf.Datastore = syncds.MutexWrap(datastore.NewMapDatastore())
+	}
+	eph := newEphemeral(ls, conf.Datastore)
+	held := newWithheld(conf.Datastore, bstore)
 	ctx, cancel := context.WithCancel(ctx)
 	t := &net{
-		DAGService:      ds,
-		host:            h,
-		bstore:          bstore,
-		store:           ls,
-		rpc:             grpc.NewServer(serverOptions...),
-		bus:             broadcast.NewBroadcaster(EventBusCapacity),
-		connectors:      make(map[thread.ID]*app.Connector),
-		ctx:             ctx,
-		cancel:          cancel,
-		semaphores:      util.NewSemaphorePool(1),
-		queueGetLogs:    queue.NewFFQueue(ctx, QueuePollInterval, PullInterval),
-		queueGetRecords: queue.NewFFQueue(ctx, QueuePollInterval, PullInterval),
-	}
-
-	t.server, err = newServer(t, conf.PubSub, dialOptions...)
+		DAGService:    &ephemeralDAG{DAGService: &withheldDAG{DAGService: ds, w: held}, mem: eph},
+		host:          h,
+		bstore:        &ephemeralBlockstore{Blockstore: &withheldBlockstore{Blockstore: bstore, w: held}, mem: eph},
+		withheld:      held,
+		routing:       conf.Routing,
+		store:         eph.store,
+		ephemeral:     eph,
+		bus:           broadcast.NewBroadcasterWithClock(conf.Sync.EventBusCapacity, clk),
+		clock:         clk,
+		events:        broadcast.NewBroadcaster(LifecycleBusCapacity),
+		recentEvents:  util.NewEventRing(conf.EventLogSize),
+		connectors:    make(map[thread.ID]*app.Connector),
+		ctx:           ctx,
+		cancel:        cancel,
+		semaphores:    util.NewSemaphorePool(conf.ThreadLockWidth, conf.ThreadLockTimeout),
+		logSemaphores: util.NewSemaphorePool(1, conf.ThreadLockTimeout),
+		localWrites:   util.NewRWLockPool(),
+		pulls:         newPullTracker(clk),
+		unloaded:      newUnloadedFlags(),
+		peerLimiter:   newRateLimiter(clk, conf.RateLimits.PeerRPCRate, conf.RateLimits.PeerRPCBurst),
+		threadLimiter: newRateLimiter(clk, conf.RateLimits.ThreadRecordRate, conf.RateLimits.ThreadRecordBurst),
+		byteLimiter:   newRateLimiter(clk, conf.RateLimits.ThreadByteRate, conf.RateLimits.ThreadByteBurst),
+		challenges:    newTokenChallenges(clk),
+		protocols:     newPeerProtocols(),
+
+		prefetchAttachments:    conf.FetchAttachments,
+		maxRecordSize:          conf.MaxRecordSize,
+		maxRecordBodySize:      conf.MaxRecordBodySize,
+		linkDepth:              conf.LinkDepth,
+		commitHooks:            conf.CommitHooks,
+		acceptHooks:            conf.AcceptHooks,
+		persistHooks:           conf.PersistHooks,
+		keyRotationHook:        conf.KeyRotationHook,
+		cipher:                 conf.RecordCipher,
+		signers:                conf.Signers,
+		signingTimeout:         conf.SigningTimeout,
+		headerSync:             conf.HeaderSync,
+		edgeGossip:             conf.EdgeGossip && conf.PubSub,
+		privateTopics:          conf.PrivateTopics && conf.PubSub,
+		requireCapabilities:    conf.RequireCapabilities,
+		compression:            conf.Compression,
+		compressionCodec:       conf.CompressionCodec,
+		bodyCompression:        conf.BodyCompression,
+		checkpointVerification: conf.CheckpointVerification,
+		lazyLogs:               conf.LazyLogs,
+		embedded:               conf.Embedded,
+		compressionStats:       &compressionStats{},
+		connectivity:           newConnectivityTracker(clk),
+		features:               hostFeatures(conf),
+		rpcStats:               newRPCStats(),
+
+		relay:   conf.Relay,
+		relayed: make(map[thread.ID]struct{}),
+		quotas:  conf.Quotas,
+		sync:    conf.Sync,
+	}
+
+	if t.topology, err = newTopology(conf.Topology, conf.Datastore); err != nil {
+		return nil, fmt.Errorf("loading peer localities: %w", err)
+	}
+	if t.reputation, err = newReputations(conf.Datastore, clk, conf.PeerBanThreshold, conf.PeerBanDuration); err != nil {
+		return nil, fmt.Errorf("loading peer reputations: %w", err)
+	}
+	if conf.KeyEscrow {
+		t.escrow = conf.Datastore
+	}
+	if t.revocations, err = newRevocations(conf.Datastore, clk); err != nil {
+		return nil, fmt.Errorf("loading revocations: %w", err)
+	}
+	t.capabilities = newCapabilities(conf.Datastore)
+	t.tokenTTL = conf.TokenTTL
+	t.keystore = conf.Keystore
+	if t.recordClock = conf.RecordClock; t.recordClock == nil {
+		t.recordClock = newLamportClock(t.store)
+	}
+	t.readOnly = conf.ReadOnly
+	if conf.SharedBlocks {
+		t.blockRefs = conf.Datastore
+	}
+	t.calls = queue.NewPriorityQueue(ctx, t.clock, conf.Sync.QueuePollInterval, conf.Sync.PullInterval, conf.MaxPeerCalls)
+	t.queueGetLogs = t.calls.Lane(callLaneLogs, callWeightLogs)
+	t.queueGetRecords = t.calls.Lane(callLaneRecords, callWeightRecords)
+	if conf.PersistCallQueues {
+		if t.queueGetLogs, err = queue.WrapDatastore(t.queueGetLogs, t.clock, conf.Datastore,
+			queueGetLogsPrefix, t.restoreLogsUpdate); err != nil {
+			return nil, fmt.Errorf("restoring scheduled log pulls: %w", err)
+		}
+		if t.queueGetRecords, err = queue.WrapDatastore(t.queueGetRecords, t.clock, conf.Datastore,
+			queueGetRecordsPrefix, t.updateRecordsFromPeer); err != nil {
+			return nil, fmt.Errorf("restoring scheduled record pulls: %w", err)
+		}
+	}
+
+	if conf.ConnGater != nil {
+		t.connGater = conf.ConnGater
+		conf.ConnGater.bind(t)
+	}
+
+	t.schemaStore = conf.Datastore
+	if err = t.loadSchemaVersion(); err != nil {
+		return nil, fmt.Errorf("loading logstore schema version: %w", err)
+	}
+	if conf.Relay.Enabled {
+		if err = t.loadRelayed(); err != nil {
+			return nil, fmt.Errorf("loading relayed threads: %w", err)
+		}
+	}
+
+	t.server, err = newServer(t, conf.PubSub, conf.Publish, conf.ConnPool, conf.ClientInterceptors, dialOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	listener, err := gostream.Listen(h, thread.Protocol)
+	t.deliveries, err = newDeliveryQueue(ctx, clk, conf.Datastore, t.server.redeliverRecord)
 	if err != nil {
 		return nil, err
 	}
-	go func() {
-		pb.RegisterServiceServer(t.rpc, t.server)
-		if err := t.rpc.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
-			log.Fatalf("serve error: %v", err)
+	go t.deliveries.Run()
+	t.acks = newAckBook(conf.Datastore, clk, conf.TrackAcks)
+	t.recIndex = newRecordIndex(conf.Datastore)
+	t.bodies = newBodyIndex(conf.Datastore)
+	t.extensions = newRecordExtensions(conf.Datastore)
+	t.deadLetters = newDeadLetters(conf.Datastore, clk, conf.DeadLetterAttempts)
+	t.journal = newHeadJournal(conf.Datastore)
+	if err = t.recoverHeads(ctx); err != nil {
+		return nil, fmt.Errorf("recovering log heads: %w", err)
+	}
+
+	if !conf.Embedded {
+		if err = t.serve(conf.ServerInterceptors, serverOptions); err != nil {
+			return nil, err
 		}
-	}()
+	}
 
+	if conf.ListenAddr != nil {
+		if err = t.startGateway(conf); err != nil {
+			return nil, fmt.Errorf("starting gateway: %w", err)
+		}
+	}
+	if conf.WebSocketAddr != nil {
+		if err = t.startWebSocket(conf.WebSocketAddr, conf.WebSocketTLS); err != nil {
+			return nil, fmt.Errorf("starting WebSocket gateway: %w", err)
+		}
+	}
+	if conf.AdminAddr != nil {
+		if err = t.startAdmin(conf); err != nil {
+			return nil, fmt.Errorf("starting admin API: %w", err)
+		}
+	}
+
+	t.notifyConnections()
+	if err = t.startConnectivity(); err != nil {
+		return nil, fmt.Errorf("tracking connectivity: %w", err)
+	}
+	if t.server.ps != nil {
+		go t.joinThreadTopics()
+	}
+	if err = t.purgeLostEphemeral(); err != nil {
+		return nil, fmt.Errorf("purging ephemeral threads: %w", err)
+	}
+	go t.resumeDeletes()
+	go t.migrate()
+	if conf.GCInterval > 0 {
+		go t.startGC(conf.GCInterval)
+	}
+	if conf.Routing != nil {
+		go t.startDiscovery()
+	}
+	if conf.Relay.Enabled && conf.Relay.Retention > 0 {
+		go t.startRelayRetention()
+	}
+	go t.startRetention()
+	go t.startAckPruning()
 	go t.startPulling()
 	return t, nil
 }
 
+// serve starts serving the thread protocol to peers.
+func (n *net) serve(interceptors ServerInterceptors, serverOptions []grpc.ServerOption) error {
+	unary, stream := n.serverInterceptors(interceptors)
+	n.rpc = grpc.NewServer(append([]grpc.ServerOption{
+		unary,
+		stream,
+		grpc.StatsHandler(n.compressionStats),
+	}, serverOptions...)...)
+	listener, err := gostream.Listen(n.host, thread.Protocol)
+	if err != nil {
+		return err
+	}
+	// the service is registered before serving, since other listeners may be served meanwhile
+	pb.RegisterServiceServer(n.rpc, n.server)
+	go func() {
+		if err := n.rpc.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Fatalf("serve error: %v", err)
+		}
+	}()
+	return nil
+}
+
 func (n *net) Close() (err error) {
+	// Shutdown the servers first, since requests waiting for a thread semaphore
+	// would never get it once the semaphores are held below
+	if n.ws != nil {
+		if err = n.ws.Close(); err != nil {
+			log.Errorf("error closing WebSocket gateway: %v", err)
+		}
+	}
+	if n.rpc != nil {
+		n.rpc.GracefulStop()
+	}
+	if n.gateway != nil {
+		n.gateway.GracefulStop()
+	}
+	if n.admin != nil {
+		n.admin.GracefulStop()
+	}
+
 	// Wait for all thread pulls to finish
 	n.semaphores.Stop()
 
-	// Close peer connections and shutdown the server
+	// Close peer connections
 	n.server.Lock()
 	defer n.server.Unlock()
-	for _, c := range n.server.conns {
-		if err = c.Close(); err != nil {
-			log.Errorf("error closing connection: %v", err)
-		}
-	}
-	n.rpc.GracefulStop()
+	n.server.conns.closeAll()
 
 	var errs []error
 	weakClose := func(name string, c interface{}) {
@@ -204,6 +859,7 @@ func (n *net) Close() (err error) {
 	}
 
 	n.bus.Discard()
+	n.events.Discard()
 	n.cancel()
 	return nil
 }
@@ -220,6 +876,51 @@ func (n *net) GetHostID(_ context.Context) (peer.ID, error) {
 	return n.host.ID(), nil
 }
 
+func (n *net) Topics(_ context.Context) ([]thread.ID, error) {
+	if n.server.ps == nil {
+		return nil, ErrPubSubDisabled
+	}
+	return n.server.ps.Topics(), nil
+}
+
+func (n *net) SyncStatus(_ context.Context) (map[peer.ID]core.PeerSyncStatus, error) {
+	return n.deliveries.Status(), nil
+}
+
+func (n *net) PublishStatus(_ context.Context) (core.PublishStatus, error) {
+	if n.server.ps == nil {
+		return core.PublishStatus{}, ErrPubSubDisabled
+	}
+	return n.server.ps.PublishStatus(), nil
+}
+
+func (n *net) ValidationStatus(_ context.Context) (map[thread.ID]core.TopicValidationStatus, error) {
+	if n.server.ps == nil {
+		return nil, ErrPubSubDisabled
+	}
+	return n.server.ps.ValidationStatus(), nil
+}
+
+func (n *net) ThreadLocks(_ context.Context) (map[thread.ID]core.ThreadLockStatus, error) {
+	locks := make(map[thread.ID]core.ThreadLockStatus)
+	for _, s := range n.semaphores.Status() {
+		if !strings.HasPrefix(s.Key, semaThreadUpdatePrefix) {
+			continue
+		}
+		id, err := thread.Decode(strings.TrimPrefix(s.Key, semaThreadUpdatePrefix))
+		if err != nil {
+			return nil, err
+		}
+		locks[id] = core.ThreadLockStatus{
+			Holders:      s.Holders,
+			Waiters:      s.Waiters,
+			HeldSince:    s.HeldSince,
+			WaitingSince: s.WaitingSince,
+		}
+	}
+	return locks, nil
+}
+
 func (n *net) GetToken(ctx context.Context, identity thread.Identity) (tok thread.Token, err error) {
 	msg := make([]byte, tokenChallengeBytes)
 	if _, err = rand.Read(msg); err != nil {
@@ -235,14 +936,25 @@ func (n *net) GetToken(ctx context.Context, identity thread.Identity) (tok threa
 	if ok, err := key.Verify(msg, sig); !ok || err != nil {
 		return tok, fmt.Errorf("bad signature")
 	}
-	return thread.NewToken(n.getPrivKey(), key)
+	return n.issueToken(key)
+}
+
+// issueToken returns a new token for the key, unless the key was revoked.
+func (n *net) issueToken(key thread.PubKey) (thread.Token, error) {
+	if err := n.revocations.checkIdentity(key); err != nil {
+		return "", err
+	}
+	return thread.NewTokenWithTTL(n.getPrivKey(), key, n.tokenTTL)
 }
 
 func (n *net) CreateThread(
-	_ context.Context,
+	ctx context.Context,
 	id thread.ID,
 	opts ...core.NewThreadOption,
 ) (info thread.Info, err error) {
+	if err = n.checkWritable(); err != nil {
+		return
+	}
 	args := &core.NewThreadOptions{}
 	for _, opt := range opts {
 		opt(args)
@@ -252,15 +964,25 @@ func (n *net) CreateThread(
 	if err != nil {
 		return
 	}
-	if identity != nil {
-		log.Debugf("creating thread with identity: %s", identity)
-	} else {
-		identity = thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	if identity == nil {
+		if identity, err = n.localIdentity(args.Identity); err != nil {
+			return
+		}
 	}
+	log.Debugf("creating thread with identity: %s", identity)
 
+	flags, err := newThreadFlags(args.SingleWriter, args.Flags)
+	if err != nil {
+		return
+	}
 	if err = n.ensureUniqueLog(id, args.LogKey, identity); err != nil {
 		return
 	}
+	if args.Ephemeral {
+		if err = n.addEphemeralThread(id); err != nil {
+			return
+		}
+	}
 
 	info = thread.Info{
 		ID:  id,
@@ -269,10 +991,19 @@ func (n *net) CreateThread(
 	if !info.Key.Defined() {
 		info.Key = thread.NewRandomKey()
 	}
-	if err = n.store.AddThread(info); err != nil {
+	if err = n.addThread(info); err != nil {
 		return
 	}
-	if _, err = n.createLog(id, args.LogKey, identity); err != nil {
+	lg, err := n.createLog(id, args.LogKey, identity)
+	if err != nil {
+		return
+	}
+	if flags.Has(thread.FlagSingleWriter) {
+		if err = n.setThreadWriter(id, lg.ID); err != nil {
+			return
+		}
+	}
+	if err = n.signThreadFlags(ctx, id, lg, flags); err != nil {
 		return
 	}
 	if n.server.ps != nil {
@@ -280,6 +1011,7 @@ func (n *net) CreateThread(
 			return
 		}
 	}
+	n.discoverThreadAsync(id)
 	return n.getThreadWithAddrs(id)
 }
 
@@ -293,7 +1025,32 @@ func (n *net) AddThread(
 		opt(args)
 	}
 
-	id, err := thread.FromAddr(addr)
+	id, addri, addFromSelf, err := n.addThreadLocally(addr, args)
+	if err != nil {
+		return
+	}
+
+	// Skip if trying to dial ourselves (already have the logs)
+	if !addFromSelf {
+		if err = n.Host().Connect(ctx, *addri); err != nil {
+			return
+		}
+		if err = n.getThreadLogs(addri.ID, id); err != nil {
+			return
+		}
+	}
+	n.discoverThreadAsync(id)
+	return n.getThreadWithAddrs(id)
+}
+
+// addThreadLocally adds the thread of the address to the store along with a log for the host
+// if needed, and returns the peer to fetch the thread logs from. It returns true if the peer
+// is the host.
+func (n *net) addThreadLocally(
+	addr ma.Multiaddr,
+	args *core.NewThreadOptions,
+) (id thread.ID, addri *peer.AddrInfo, addFromSelf bool, err error) {
+	id, err = thread.FromAddr(addr)
 	if err != nil {
 		return
 	}
@@ -301,12 +1058,22 @@ func (n *net) AddThread(
 	if err != nil {
 		return
 	}
-	if identity != nil {
-		log.Debugf("adding thread with identity: %s", identity)
-	} else {
-		identity = thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	if identity == nil {
+		if identity, err = n.localIdentity(args.Identity); err != nil {
+			return
+		}
 	}
+	log.Debugf("adding thread with identity: %s", identity)
 
+	flags, err := newThreadFlags(false, args.Flags)
+	if err != nil {
+		return
+	}
+	if args.LogKey != nil {
+		if err = n.checkWritable(); err != nil {
+			return
+		}
+	}
 	if err = n.ensureUniqueLog(id, args.LogKey, identity); err != nil {
 		return
 	}
@@ -316,13 +1083,12 @@ func (n *net) AddThread(
 		return
 	}
 	peerAddr := addr.Decapsulate(threadComp)
-	addri, err := peer.AddrInfoFromP2pAddr(peerAddr)
-	if err != nil {
+	if addri, err = peer.AddrInfoFromP2pAddr(peerAddr); err != nil {
 		return
 	}
 
 	// Check if we're trying to dial ourselves (regardless of addr)
-	addFromSelf := addri.ID == n.host.ID()
+	addFromSelf = addri.ID == n.host.ID()
 	if addFromSelf {
 		// Error if we don't have the thread locally
 		if _, err = n.store.GetThread(id); errors.Is(err, lstore.ErrThreadNotFound) {
@@ -331,38 +1097,51 @@ func (n *net) AddThread(
 		}
 	}
 
+	if args.Ephemeral {
+		if err = n.addEphemeralThread(id); err != nil {
+			return
+		}
+	}
 	// Even if we already have the thread locally, we might still need to add a new log
-	if err = n.store.AddThread(thread.Info{
+	if err = n.addThread(thread.Info{
 		ID:  id,
 		Key: args.ThreadKey,
 	}); err != nil {
 		return
 	}
-	if args.ThreadKey.CanRead() || args.LogKey != nil {
-		if _, err = n.createLog(id, args.LogKey, identity); err != nil {
+	// flags expected by the host are checked against the ones of the peers
+	if err = n.setThreadFlags(id, flags, nil); err != nil {
+		return
+	}
+	if args.Writer != "" {
+		if err = n.setThreadWriter(id, args.Writer); err != nil {
 			return
 		}
 	}
-
-	// Skip if trying to dial ourselves (already have the logs)
-	if !addFromSelf {
-		if err = n.Host().Connect(ctx, *addri); err != nil {
+	writer, err := n.threadWriter(id)
+	if err != nil {
+		return
+	}
+	// readers of a single-writer thread and read-only hosts don't need a log
+	if args.LogKey != nil || args.ThreadKey.CanRead() && writer == "" && !n.readOnly {
+		if _, err = n.createLog(id, args.LogKey, identity); err != nil {
 			return
 		}
+	}
+	return id, addri, addFromSelf, nil
+}
 
-		if err = n.queueGetLogs.Call(addri.ID, id, func(ctx context.Context, p peer.ID, t thread.ID) error {
-			if err := n.updateLogsFromPeer(ctx, p, t); err != nil {
-				return err
-			}
-			if n.server.ps != nil {
-				return n.server.ps.Add(id)
-			}
-			return nil
-		}); err != nil {
-			return
+// getThreadLogs fetches the thread logs from the peer and joins the thread topic.
+func (n *net) getThreadLogs(pid peer.ID, id thread.ID) error {
+	return n.queueGetLogs.Call(pid, id, func(ctx context.Context, p peer.ID, t thread.ID) error {
+		if err := n.updateLogsFromPeer(ctx, p, t); err != nil {
+			return err
 		}
-	}
-	return n.getThreadWithAddrs(id)
+		if n.server.ps != nil {
+			return n.server.ps.Add(id)
+		}
+		return nil
+	})
 }
 
 func (n *net) GetThread(_ context.Context, id thread.ID, opts ...core.ThreadOption) (info thread.Info, err error) {
@@ -392,12 +1171,15 @@ func (n *net) getThreadWithAddrs(id thread.ID) (info thread.Info, err error) {
 	if err != nil {
 		return
 	}
-	addrs := n.host.Addrs()
+	addrs := n.connectivity.reachableAddrs(n.host.Addrs(), n.listenAddrs())
 	res := make([]ma.Multiaddr, len(addrs))
 	for i := range addrs {
 		res[i] = addrs[i].Encapsulate(peerID).Encapsulate(threadID)
 	}
 	tinfo.Addrs = res
+	if tinfo.Flags, err = n.threadFlags(id); err != nil {
+		return
+	}
 	return tinfo, nil
 }
 
@@ -409,29 +1191,70 @@ func (n *net) PullThread(ctx context.Context, id thread.ID, opts ...core.ThreadO
 	if _, err := n.Validate(id, args.Token, true); err != nil {
 		return err
 	}
-	return n.pullThread(ctx, id)
+	if err := n.loadThread(id); err != nil {
+		return err
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return err
+	}
+	if err := n.pullThread(ctx, id); err != nil {
+		return err
+	}
+	n.pullLinkedThreads(ctx, id)
+	return nil
 }
 
 // pullThread for the new records. This method is thread-safe.
-func (n *net) pullThread(ctx context.Context, tid thread.ID) error {
+func (n *net) pullThread(ctx context.Context, tid thread.ID) (err error) {
+	if deleting, err := n.isDeleting(tid); err != nil || deleting {
+		return err
+	}
+	defer func() { n.emitPull(tid, "", err) }()
 	offsets, peers, err := n.threadOffsets(tid)
 	if err != nil {
 		return err
 	}
 
 	// Pull from peers
-	recs, err := n.server.getRecords(peers, tid, offsets, MaxPullLimit)
+	cfg := n.syncConfig()
+	recs, err := n.server.getRecords(ctx, peers, tid, offsets, cfg.MaxPullLimit)
 	if err != nil {
 		return err
 	}
 
+	// logs are stored concurrently, so records of one log are loaded and
+	// verified while another one holds the thread lock to advance its heads
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, cfg.MaxConcurrentPulls)
+	)
 	for lid, rs := range recs {
-		if err = n.putRecords(ctx, tid, lid, rs); err != nil {
-			return err
+		if ctx.Err() != nil {
+			break
 		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(lid peer.ID, rs []core.Record) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := n.putPulledRecords(ctx, tid, lid, rs, ""); err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errLock.Unlock()
+			}
+		}(lid, rs)
 	}
-
-	return nil
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
 }
 
 func (n *net) DeleteThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
@@ -447,44 +1270,7 @@ func (n *net) DeleteThread(ctx context.Context, id thread.ID, opts ...core.Threa
 	}
 
 	log.Debugf("deleting thread %s...", id)
-	ts := n.semaphores.Get(semaThreadUpdate(id))
-
-	// Must block in case the thread is being pulled
-	ts.Acquire()
-	err := n.deleteThread(ctx, id)
-	ts.Release()
-
-	return err
-}
-
-// deleteThread cleans up all the persistent and in-memory bits of a thread. This includes:
-// - Removing all record and event nodes.
-// - Deleting all logstore keys, addresses, and heads.
-// - Cancelling the pubsub subscription and topic.
-// Local subscriptions will not be cancelled and will simply stop reporting.
-// This method is internal and *not* thread-safe. It assumes we currently own the thread-lock.
-func (n *net) deleteThread(ctx context.Context, id thread.ID) error {
-	if n.server.ps != nil {
-		if err := n.server.ps.Remove(id); err != nil {
-			return err
-		}
-	}
-
-	info, err := n.store.GetThread(id)
-	if err != nil {
-		return err
-	}
-	for _, lg := range info.Logs { // Walk logs, removing record and event nodes
-		head := lg.Head
-		for head.Defined() {
-			head, err = n.deleteRecord(ctx, head, info.Key.Service())
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return n.store.DeleteThread(id) // Delete logstore keys, addresses, heads, and metadata
+	return n.deleteThread(ctx, id)
 }
 
 func (n *net) AddReplicator(
@@ -515,6 +1301,10 @@ func (n *net) AddReplicator(
 	if err != nil {
 		return
 	}
+	// the peer may be added back after its removal
+	if err = n.store.PutBool(id, pid.Pretty()+removedReplicatorSuffix, false); err != nil {
+		return
+	}
 
 	// Update local addresses
 	addr, err := ma.NewMultiaddr("/" + ma.ProtocolWithCode(ma.P_P2P).Name + "/" + p2p)
@@ -542,8 +1332,8 @@ func (n *net) AddReplicator(
 		dialable, err = getDialable(paddr)
 		if err == nil {
 			n.host.Peerstore().AddAddr(pid, dialable, pstore.PermanentAddrTTL)
-		} else {
-			log.Warnf("peer %s address requires a DHT lookup", pid)
+		} else if ferr := n.findPeer(ctx, pid); ferr != nil {
+			log.Warnf("peer %s address lookup failed: %v", pid, ferr)
 		}
 
 		// Send all logs to the new replicator
@@ -572,6 +1362,10 @@ func (n *net) AddReplicator(
 	if err != nil {
 		return
 	}
+	// logs must carry the updated addresses, since a signed set replaces the one known to peers
+	if managedLogs, err = n.store.GetManagedLogs(info.ID); err != nil {
+		return
+	}
 
 	var wg sync.WaitGroup
 	for _, p := range peers {
@@ -587,6 +1381,7 @@ func (n *net) AddReplicator(
 	}
 
 	wg.Wait()
+	n.emit(core.LifecycleEvent{Type: core.ReplicatorAdded, ThreadID: id, PeerID: pid})
 	return pid, nil
 }
 
@@ -643,44 +1438,405 @@ func (n *net) CreateRecord(
 	for _, opt := range opts {
 		opt(args)
 	}
-	identity, err := n.Validate(id, args.Token, false)
+	if args.WriteQuorum > 0 {
+		if err = n.checkWriteQuorum(id, args.WriteQuorum); err != nil {
+			return
+		}
+	}
+	tr, err = n.createRecord(ctx, id, body, opts...)
 	if err != nil {
 		return
 	}
-	if identity == nil {
-		identity = thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
-	}
-	con, ok := n.getConnectorProtected(id, args.APIToken)
-	if !ok {
-		return nil, fmt.Errorf("cannot create record: %w", app.ErrThreadInUse)
-	} else if con != nil {
-		if err = con.ValidateNetRecordBody(ctx, body, identity); err != nil {
+	if args.WriteQuorum == 0 {
+		if err = n.server.pushRecord(ctx, id, tr.LogID(), tr.Value()); err != nil {
 			return
 		}
+		return tr, nil
+	}
+	defer n.acks.Watch(id, tr.Value().Cid())()
+	outcomes, err := n.server.pushRecordAcked(ctx, id, tr.LogID(), tr.Value())
+	if err != nil {
+		return
+	}
+	if err = n.awaitWriteQuorum(ctx, id, tr.Value().Cid(), outcomes, args.WriteQuorum); err != nil {
+		return
 	}
+	return tr, nil
+}
 
-	lg, err := n.getOrCreateLog(id, identity)
+func (n *net) CreateRecordAsync(
+	ctx context.Context,
+	id thread.ID,
+	body format.Node,
+	opts ...core.ThreadOption,
+) (core.RecordFuture, error) {
+	tr, err := n.createRecord(ctx, id, body, opts...)
 	if err != nil {
+		return nil, err
+	}
+	f := newRecordFuture(tr)
+	go func() {
+		// the push outlives the request context
+		acks, err := n.server.pushRecordAcked(n.ctx, id, tr.LogID(), tr.Value())
+		f.complete(acks, err)
+	}()
+	return f, nil
+}
+
+// createRecord validates, creates and stores a new record locally, and notifies subscribers.
+func (n *net) createRecord(
+	ctx context.Context,
+	id thread.ID,
+	body format.Node,
+	opts ...core.ThreadOption,
+) (tr core.ThreadRecord, err error) {
+	if err = n.checkWritable(); err != nil {
 		return
 	}
-	r, err := n.newRecord(ctx, id, lg, body, identity)
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.Validate(id, args.Token, false)
 	if err != nil {
 		return
 	}
-	tr = NewRecord(r, id, lg.ID)
-	if err = n.store.SetHead(id, lg.ID, tr.Value().Cid()); err != nil {
+	if identity == nil {
+		if identity, err = n.localIdentity(args.Identity); err != nil {
+			return
+		}
+	}
+	con, ok := n.getConnectorProtected(id, args.APIToken)
+	if !ok {
+		return nil, fmt.Errorf("cannot create record: %w", app.ErrThreadInUse)
+	} else if con != nil {
+		if err = con.ValidateNetRecordBody(ctx, body, identity); err != nil {
+			return
+		}
+	}
+	if err = n.runCommitHooks(ctx, id, body, identity); err != nil {
 		return
 	}
-	log.Debugf("created record %s (thread=%s, log=%s)", tr.Value().Cid(), id, lg.ID)
-	if err = n.bus.SendWithTimeout(tr, notifyTimeout); err != nil {
+
+	lid, recs, err := n.createRecordChain(ctx, id, []format.Node{body}, identity, args.Extensions, nil)
+	if err != nil {
 		return
 	}
-	if err = n.server.pushRecord(ctx, id, lg.ID, tr.Value()); err != nil {
+	tr = NewRecordFrom(recs[0], id, lid, n.localSource())
+	log.Debugf("created record %s (thread=%s, log=%s)", tr.Value().Cid(), id, lid)
+	if err = n.notify(tr); err != nil {
 		return
 	}
 	return tr, nil
 }
 
+func (n *net) CreateRecords(
+	ctx context.Context,
+	id thread.ID,
+	bodies []format.Node,
+	opts ...core.ThreadOption,
+) ([]core.ThreadRecord, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.validateBodies(ctx, id, bodies, args)
+	if err != nil || len(bodies) == 0 {
+		return nil, err
+	}
+
+	lid, recs, err := n.createRecordChain(ctx, id, bodies, identity, args.Extensions, nil)
+	if err != nil {
+		return nil, err
+	}
+	trs := make([]core.ThreadRecord, len(recs))
+	src := n.localSource()
+	for i, r := range recs {
+		trs[i] = NewRecordFrom(r, id, lid, src)
+		if err = n.notify(trs[i]); err != nil {
+			return nil, err
+		}
+	}
+	log.Debugf("created %d records (thread=%s, log=%s)", len(recs), id, lid)
+	if err = n.server.pushRecords(ctx, id, lid, recs); err != nil {
+		return nil, err
+	}
+	return trs, nil
+}
+
+// CreateRecordsAtomic creates a chain of new records like CreateRecords, but either all records
+// are added to the log and pushed to peers, or none are. Everything which may fail is done
+// before the log head advances, afterwards failed deliveries are only retried.
+func (n *net) CreateRecordsAtomic(
+	ctx context.Context,
+	id thread.ID,
+	bodies []format.Node,
+	opts ...core.ThreadOption,
+) ([]core.ThreadRecord, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.validateBodies(ctx, id, bodies, args)
+	if err != nil || len(bodies) == 0 {
+		return nil, err
+	}
+
+	var push *recordsPush
+	lid, recs, err := n.createRecordChain(ctx, id, bodies, identity, args.Extensions,
+		func(lid peer.ID, recs []core.Record) (err error) {
+			push, err = n.server.preparePushRecords(ctx, id, lid, recs)
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+	trs := make([]core.ThreadRecord, len(recs))
+	src := n.localSource()
+	for i, r := range recs {
+		trs[i] = NewRecordFrom(r, id, lid, src)
+		// the records are committed, so slow listeners don't fail the write
+		if err = n.notify(trs[i]); err != nil {
+			log.Errorf("error notifying listeners of record %s: %v", r.Cid(), err)
+		}
+	}
+	log.Debugf("created %d records atomically (thread=%s, log=%s)", len(recs), id, lid)
+	n.server.sendPushRecords(ctx, push)
+	return trs, nil
+}
+
+// validateBodies checks the write access of the token and runs the app validation and commit
+// hooks on the bodies of new records. It returns the identity of the author.
+func (n *net) validateBodies(
+	ctx context.Context,
+	id thread.ID,
+	bodies []format.Node,
+	args *core.ThreadOptions,
+) (thread.PubKey, error) {
+	if err := n.checkWritable(); err != nil {
+		return nil, err
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		if identity, err = n.localIdentity(args.Identity); err != nil {
+			return nil, err
+		}
+	}
+	con, ok := n.getConnectorProtected(id, args.APIToken)
+	if !ok {
+		return nil, fmt.Errorf("cannot create records: %w", app.ErrThreadInUse)
+	}
+	for _, body := range bodies {
+		if con != nil {
+			if err = con.ValidateNetRecordBody(ctx, body, identity); err != nil {
+				return nil, err
+			}
+		}
+		if err = n.runCommitHooks(ctx, id, body, identity); err != nil {
+			return nil, err
+		}
+	}
+	return identity, nil
+}
+
+// createRecordChain creates records with bodies on top of the identity's log head.
+// Log heads are advanced once the whole chain is created, so a failure leaves the log untouched.
+// If set, prepare is run on the chain right before, and a failure aborts the chain as well.
+// Blocks of an aborted chain are left to GC.
+// The chain is built atomically under the log semaphore rather than the thread semaphore, as
+// CreateRecord always did: received records are handed to the app holding the thread semaphore,
+// and apps like db create records while holding the locks their handlers wait for, so taking it
+// here deadlocks them. The shared local writes lock keeps the thread from being deleted,
+// unloaded, archived or pruned meanwhile, see blockLocalWrites.
+func (n *net) createRecordChain(
+	ctx context.Context,
+	id thread.ID,
+	bodies []format.Node,
+	identity thread.PubKey,
+	ext map[string][]byte,
+	prepare func(lid peer.ID, recs []core.Record) error,
+) (peer.ID, []core.Record, error) {
+	if err := n.loadThread(id); err != nil {
+		return "", nil, err
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return "", nil, err
+	}
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	if err := n.migrateThreadLocked(id); err != nil {
+		return "", nil, err
+	}
+
+	chain, err := n.newRecordChain(ctx, id, bodies, identity, ext)
+	if err != nil {
+		return "", nil, err
+	}
+	defer chain.release()
+	// blocks of rejected records are left to GC
+	src := n.localSource()
+	for _, r := range chain.recs {
+		if err = n.beforePersist(ctx, NewRecordFrom(r, id, chain.lid, src)); err != nil {
+			return "", nil, err
+		}
+	}
+	if prepare != nil {
+		if err = prepare(chain.lid, chain.recs); err != nil {
+			return "", nil, err
+		}
+		if err = ctx.Err(); err != nil {
+			return "", nil, err
+		}
+	}
+	next := chain.nextHeads()
+	if err = n.journal.Begin(id, chain.lid, chain.heads, next); err != nil {
+		return "", nil, err
+	}
+	defer n.commitHeads(id, chain.lid)
+	if err = n.setHeads(ctx, id, chain.lid, next); err != nil {
+		return "", nil, err
+	}
+	n.advanceLogSeq(id, chain.lid, len(chain.recs))
+	n.chargeCreated(ctx, id, chain.lid, chain.recs)
+	n.emitHeadsChanged(id, chain.lid, "", chain.recs[len(chain.recs)-1].Cid())
+	for _, r := range chain.recs {
+		n.afterPersist(ctx, NewRecordFrom(r, id, chain.lid, src))
+	}
+	return chain.lid, chain.recs, nil
+}
+
+// recordChain is a chain of new records on top of a log head, which isn't advanced yet.
+type recordChain struct {
+	lid   peer.ID
+	head  cid.Cid
+	heads []cid.Cid
+	recs  []core.Record
+	lock  *util.Semaphore // log semaphore, held until the heads are advanced
+
+	releaseWrites func() // releases the shared local writes lock of the thread
+}
+
+// release releases the log semaphore and the local writes lock of the chain.
+func (c *recordChain) release() {
+	c.lock.Release()
+	c.releaseWrites()
+}
+
+// nextHeads returns the log heads with the chain appended. The chain extends the primary head,
+// other branches of a forked log are kept.
+func (c *recordChain) nextHeads() []cid.Cid {
+	return advanceHeads(c.heads, c.head, c.recs[len(c.recs)-1].Cid())
+}
+
+// newRecordChain creates and stores records with bodies on top of the identity's log head,
+// leaving the log heads untouched. The chain holds the log semaphore and the shared local writes
+// lock of the thread, so the thread isn't deleted, unloaded, archived or pruned in the meantime.
+// The caller must release the chain once the heads are advanced.
+func (n *net) newRecordChain(
+	ctx context.Context,
+	id thread.ID,
+	bodies []format.Node,
+	identity thread.PubKey,
+	ext map[string][]byte,
+) (*recordChain, error) {
+	if err := n.checkWritable(); err != nil {
+		return nil, err
+	}
+	releaseWrites := n.localWrites.RLock(semaLocalWrites(id))
+	// the thread may have been deleted before the lock was acquired
+	if err := n.checkNotDeleting(id); err != nil {
+		releaseWrites()
+		return nil, err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		releaseWrites()
+		return nil, err
+	}
+	lg, err := n.getOrCreateLogLocked(id, identity)
+	if err != nil {
+		releaseWrites()
+		return nil, err
+	}
+	lock, err := n.lockLog(id, lg.ID)
+	if err != nil {
+		releaseWrites()
+		return nil, err
+	}
+	chain := &recordChain{
+		lid:           lg.ID,
+		recs:          make([]core.Record, 0, len(bodies)),
+		lock:          lock,
+		releaseWrites: releaseWrites,
+	}
+	// the heads may have advanced while waiting for the log semaphore
+	if lg, err = n.store.GetLog(id, lg.ID); err != nil {
+		chain.release()
+		return nil, err
+	}
+	chain.head, chain.heads = lg.Head, lg.Heads
+	if err = n.appendRecordChain(ctx, id, chain, lg, bodies, identity, ext); err != nil {
+		chain.release()
+		return nil, err
+	}
+	return chain, nil
+}
+
+// appendRecordChain creates and stores records with bodies on top of the chain.
+func (n *net) appendRecordChain(
+	ctx context.Context,
+	id thread.ID,
+	chain *recordChain,
+	lg thread.LogInfo,
+	bodies []format.Node,
+	identity thread.PubKey,
+	ext map[string][]byte,
+) error {
+	if owner, err := n.logHandoff(id, lg.ID); err != nil {
+		return err
+	} else if owner != "" {
+		return fmt.Errorf("%w to %s", core.ErrLogHandedOff, owner)
+	}
+	seq, err := n.headSeq(ctx, id, lg.Head)
+	if err != nil {
+		return err
+	}
+	for _, body := range bodies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		clock, err := n.nextClock(id)
+		if err != nil {
+			return err
+		}
+		if seq, err = nextSeq(seq, lg.Head); err != nil {
+			return err
+		}
+		r, err := n.newRecord(ctx, id, lg, body, identity, clock, seq, ext)
+		if err != nil {
+			return err
+		}
+		if err = n.saveExtensions(id, r); err != nil {
+			return err
+		}
+		// references are taken before the head advances, stale ones are dropped by GC
+		if err = n.refBlocks(ctx, id, []core.Record{r}); err != nil {
+			return err
+		}
+		if err = n.indexBodies(ctx, id, lg.ID, []core.Record{r}); err != nil {
+			return err
+		}
+		if err = n.withholdBodies(ctx, id, lg.ID, []core.Record{r}); err != nil {
+			return err
+		}
+		chain.recs = append(chain.recs, r)
+		lg.Head = r.Cid()
+	}
+	return nil
+}
+
 func (n *net) AddRecord(
 	ctx context.Context,
 	id thread.ID,
@@ -688,6 +1844,9 @@ func (n *net) AddRecord(
 	rec core.Record,
 	opts ...core.ThreadOption,
 ) error {
+	if err := n.checkWritable(); err != nil {
+		return err
+	}
 	args := &core.ThreadOptions{}
 	for _, opt := range opts {
 		opt(args)
@@ -695,6 +1854,9 @@ func (n *net) AddRecord(
 	if _, err := n.Validate(id, args.Token, false); err != nil {
 		return err
 	}
+	if err := n.loadThread(id); err != nil {
+		return err
+	}
 
 	logpk, err := n.store.PubKey(id, lid)
 	if err != nil {
@@ -713,7 +1875,7 @@ func (n *net) AddRecord(
 	if err = rec.Verify(logpk); err != nil {
 		return err
 	}
-	if err = n.putRecords(ctx, id, lid, []core.Record{rec}); err != nil {
+	if err = n.putRecords(ctx, id, lid, []core.Record{rec}, n.localSource()); err != nil {
 		return err
 	}
 	return n.server.pushRecord(ctx, id, lid, rec)
@@ -732,9 +1894,54 @@ func (n *net) GetRecord(
 	if _, err := n.Validate(id, args.Token, true); err != nil {
 		return nil, err
 	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return nil, err
+	}
 	return n.getRecord(ctx, id, rid)
 }
 
+// AwaitRecord returns a record of a thread once it's available locally. It returns right away
+// if the record is already known, otherwise it waits until the record is added to the thread.
+func (n *net) AwaitRecord(
+	ctx context.Context,
+	id thread.ID,
+	rid cid.Cid,
+	opts ...core.ThreadOption,
+) (core.Record, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// listen first, so the record isn't missed while the blockstore is checked
+	listener := n.bus.Listen()
+	defer listener.Discard()
+	if known, err := n.isKnown(rid); err != nil {
+		return nil, err
+	} else if known {
+		return n.getRecord(ctx, id, rid)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case i, ok := <-listener.Channel():
+			if !ok {
+				return nil, fmt.Errorf("network closed while awaiting record %s", rid)
+			}
+			if rec, ok := i.(*Record); ok && rec.threadID == id && rec.Cid().Equals(rid) {
+				return rec, nil
+			}
+		}
+	}
+}
+
 func (n *net) getRecord(ctx context.Context, id thread.ID, rid cid.Cid) (core.Record, error) {
 	sk, err := n.store.ServiceKey(id)
 	if err != nil {
@@ -743,7 +1950,11 @@ func (n *net) getRecord(ctx context.Context, id thread.ID, rid cid.Cid) (core.Re
 	if sk == nil {
 		return nil, fmt.Errorf("a service-key is required to get records")
 	}
-	return cbor.GetRecord(ctx, n, rid, sk)
+	rec, err := cbor.GetRecord(ctx, n, rid, sk)
+	if err != nil {
+		return nil, err
+	}
+	return rec, n.loadExtensions(id, rec)
 }
 
 // Record implements core.Record. The most basic component of a Log.
@@ -751,6 +1962,7 @@ type Record struct {
 	core.Record
 	threadID thread.ID
 	logID    peer.ID
+	source   core.RecordSource
 }
 
 // NewRecord returns a record with the given values.
@@ -758,10 +1970,52 @@ func NewRecord(r core.Record, id thread.ID, lid peer.ID) core.ThreadRecord {
 	return &Record{Record: r, threadID: id, logID: lid}
 }
 
+// NewRecordFrom returns a record with the given values, attributed to the source.
+func NewRecordFrom(r core.Record, id thread.ID, lid peer.ID, src core.RecordSource) core.ThreadRecord {
+	return &Record{Record: r, threadID: id, logID: lid, source: src}
+}
+
+// notify hands a new record to the local subscribers. The bus runs on the host clock, so a
+// subscriber not reading records holds the host up to notifyTimeout of that clock.
+func (n *net) notify(rec core.ThreadRecord) error {
+	return n.bus.SendWithTimeout(rec, notifyTimeout)
+}
+
+// localSource attributes records to the host.
+func (n *net) localSource() core.RecordSource {
+	return core.RecordSource{Kind: core.SourceLocal, ReceivedAt: n.clock.Now()}
+}
+
+// peerSource attributes records to a peer they were received from.
+func (n *net) peerSource(kind core.RecordSourceKind, pid peer.ID) core.RecordSource {
+	return core.RecordSource{Kind: kind, Peer: pid, ReceivedAt: n.clock.Now()}
+}
+
 func (r *Record) Value() core.Record {
 	return r
 }
 
+// Extensions returns the extension fields of the underlying record, if any.
+func (r *Record) Extensions() map[string][]byte {
+	if er, ok := r.Record.(core.ExtendedRecord); ok {
+		return er.Extensions()
+	}
+	return nil
+}
+
+// RawExtensions returns the encoded extensions of the underlying record, if any.
+func (r *Record) RawExtensions() []byte {
+	if er, ok := r.Record.(interface{ RawExtensions() []byte }); ok {
+		return er.RawExtensions()
+	}
+	return nil
+}
+
+// Restricted returns whether the underlying record was received without the body.
+func (r *Record) Restricted() bool {
+	return isRestrictedRecord(r.Record)
+}
+
 func (r *Record) ThreadID() thread.ID {
 	return r.threadID
 }
@@ -770,6 +2024,10 @@ func (r *Record) LogID() peer.ID {
 	return r.logID
 }
 
+func (r *Record) Source() core.RecordSource {
+	return r.source
+}
+
 func (n *net) Subscribe(ctx context.Context, opts ...core.SubOption) (<-chan core.ThreadRecord, error) {
 	args := &core.SubOptions{}
 	for _, opt := range opts {
@@ -788,14 +2046,24 @@ func (n *net) Subscribe(ctx context.Context, opts ...core.SubOption) (<-chan cor
 			filter[id] = struct{}{}
 		}
 	}
-	return n.subscribe(ctx, filter)
+	logs := make(map[peer.ID]struct{}, len(args.LogIDs))
+	for _, lid := range args.LogIDs {
+		logs[lid] = struct{}{}
+	}
+	return n.subscribe(ctx, filter, logs, args.Annotations)
 }
 
-func (n *net) subscribe(ctx context.Context, filter map[thread.ID]struct{}) (<-chan core.ThreadRecord, error) {
+func (n *net) subscribe(
+	ctx context.Context,
+	filter map[thread.ID]struct{},
+	logs map[peer.ID]struct{},
+	annotations map[string]string,
+) (<-chan core.ThreadRecord, error) {
 	channel := make(chan core.ThreadRecord)
+	// listen right away, so records created once the method returns are delivered
+	listener := n.bus.Listen()
 	go func() {
 		defer close(channel)
-		listener := n.bus.Listen()
 		defer listener.Discard()
 		for {
 			select {
@@ -806,13 +2074,16 @@ func (n *net) subscribe(ctx context.Context, filter map[thread.ID]struct{}) (<-c
 					return
 				}
 				if rec, ok := i.(*Record); ok {
-					if len(filter) > 0 {
-						if _, ok := filter[rec.threadID]; ok {
-							channel <- rec
-						}
-					} else {
-						channel <- rec
+					if _, ok := filter[rec.threadID]; len(filter) > 0 && !ok {
+						continue
+					}
+					if _, ok := logs[rec.logID]; len(logs) > 0 && !ok {
+						continue
 					}
+					if !core.HasAnnotations(rec, annotations) {
+						continue
+					}
+					channel <- rec
 				} else {
 					log.Warn("listener received a non-record value")
 				}
@@ -822,7 +2093,7 @@ func (n *net) subscribe(ctx context.Context, filter map[thread.ID]struct{}) (<-c
 	return channel, nil
 }
 
-func (n *net) ConnectApp(a app.App, id thread.ID) (*app.Connector, error) {
+func (n *net) ConnectApp(a app.App, id thread.ID, opts ...app.ConnectOption) (*app.Connector, error) {
 	if err := id.Validate(); err != nil {
 		return nil, err
 	}
@@ -830,7 +2101,7 @@ func (n *net) ConnectApp(a app.App, id thread.ID) (*app.Connector, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error getting thread %s: %v", id, err)
 	}
-	con, err := app.NewConnector(a, n, info)
+	con, err := app.NewConnector(a, n, info, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error making connector %s: %v", id, err)
 	}
@@ -843,7 +2114,14 @@ func (n *net) Validate(id thread.ID, token thread.Token, readOnly bool) (thread.
 	if err := id.Validate(); err != nil {
 		return nil, err
 	}
-	return token.Validate(n.getPrivKey())
+	claims, err := token.Claims(n.getPrivKey())
+	if err != nil || claims.PubKey == nil {
+		return nil, err
+	}
+	if err = n.revocations.checkToken(id, token, claims); err != nil {
+		return nil, err
+	}
+	return claims.PubKey, nil
 }
 
 func (n *net) addConnector(id thread.ID, conn *app.Connector) {
@@ -878,98 +2156,246 @@ func (n *net) PutRecord(ctx context.Context, id thread.ID, lid peer.ID, rec core
 	if err := id.Validate(); err != nil {
 		return err
 	}
-	return n.putRecords(ctx, id, lid, []core.Record{rec})
+	return n.putRecords(ctx, id, lid, []core.Record{rec}, n.localSource())
 }
 
-// putRecords adds existing records. This method is thread-safe.
-func (n *net) putRecords(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record) error {
-	chain, head, err := n.loadRecords(ctx, tid, lid, recs)
-	if err != nil {
+// putRecords adds existing records received from src. This method is thread-safe.
+func (n *net) putRecords(
+	ctx context.Context,
+	tid thread.ID,
+	lid peer.ID,
+	recs []core.Record,
+	src core.RecordSource,
+) error {
+	// older records are needed to merge new ones into the log heads
+	if err := n.rehydrateThread(ctx, tid); err != nil {
+		return err
+	}
+	// blocks of records being processed must not be collected
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	return n.putChains(ctx, tid, lid, recs, src)
+}
+
+// putChains adds existing records.
+// This method is internal and *not* thread-safe. It assumes we currently own the gc read lock.
+func (n *net) putChains(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record, src core.RecordSource) error {
+	// records of a forked log arrive as several chains, each one following the chain it branches off
+	for _, chain := range splitChains(recs) {
+		if err := n.putChain(ctx, tid, lid, chain, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putChain processes a linear chain of log records, merging it into the log heads.
+func (n *net) putChain(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record, src core.RecordSource) error {
+	chain, err := n.loadRecords(ctx, tid, lid, recs, src)
+	if err != nil && (len(chain) == 0 || !isQuotaExceeded(err)) {
 		return fmt.Errorf("loading records failed: %w", err)
 	} else if len(chain) == 0 {
 		return nil
 	}
+	// records fitting into the quota are added before the refusal is returned
+	refused := err
 
-	ts := n.semaphores.Get(semaThreadUpdate(tid))
-	ts.Acquire()
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return err
+	}
 	defer ts.Release()
+	ls, err := n.lockLog(tid, lid)
+	if err != nil {
+		return err
+	}
+	defer ls.Release()
 
-	// check the head again, as some other process could change the log concurrently
-	if current, err := n.currentHead(tid, lid); err != nil {
-		return fmt.Errorf("fetching head failed: %w", err)
-	} else if !current.Equals(head) {
-		// fast-forward the chain up to the updated head
-		var headReached bool
-		for i := 0; i < len(chain); i++ {
-			if chain[i].Value().Cid().Equals(current) {
-				chain = chain[i+1:]
-				headReached = true
-				break
-			}
-		}
-		if !headReached {
-			// entire chain already processed
-			return nil
-		}
+	heads, err := n.currentHeads(tid, lid)
+	if err != nil {
+		return fmt.Errorf("fetching heads failed: %w", err)
+	}
+	// skip records processed concurrently while the chain was loading
+	if processed, err := n.processedRecords(ctx, tid, heads, chain); err != nil {
+		return err
+	} else if chain = chain[processed:]; len(chain) == 0 {
+		return nil
+	}
+	if boundary, err := util.LogMarker(n.store, tid, lid, boundarySuffix); err != nil {
+		return err
+	} else if chain[0].Value().Cid().Equals(boundary) {
+		// local records are older than the adopted compaction boundary
+		heads = nil
 	}
 
+	var (
+		advanced cid.Cid
+		appended int
+	)
+	defer func() {
+		if advanced.Defined() {
+			n.advanceLogSeq(tid, lid, appended)
+			n.emitHeadsChanged(tid, lid, src.Peer, advanced)
+		}
+	}()
 	connector, appConnected := n.getConnector(tid)
 	for _, record := range chain {
-		if err := n.store.SetHead(tid, lid, record.Value().Cid()); err != nil {
-			return fmt.Errorf("setting log head failed: %w", err)
-		}
-
-		if appConnected {
-			if err := connector.HandleNetRecord(ctx, record); err != nil {
-				// Future improvement notes.
-				// If record handling fails there are two options available:
-				// 1. Just interrupt and return error (current behaviour). Log head remains moved and some events
-				//    from the record possibly won't reach reducers/listeners or even get dispatched.
-				// 2. Rollback log head to the previous record. In this case record handling will be retried until
-				//    success, but reducers must guarantee its idempotence and there is a chance of getting stuck
-				//    with bad event and not making any progress at all.
-				return fmt.Errorf("handling record failed: %w", err)
+		// records are processed one by one, so the log stays consistent if interrupted
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := n.beforePersist(ctx, record); err != nil {
+			return err
+		}
+		size, err := n.checkQuota(ctx, tid, lid, record.Value())
+		if err != nil {
+			n.emitRejected(tid, lid, src.Peer, record.Value().Cid(), err)
+			return err
+		}
+		relayedSize, err := n.checkRelayed(ctx, tid, record.Value())
+		if err != nil {
+			n.emitRejected(tid, lid, src.Peer, record.Value().Cid(), err)
+			return err
+		}
+		if err := n.refBlocks(ctx, tid, []core.Record{record.Value()}); err != nil {
+			return err
+		}
+		if err := n.indexBodies(ctx, tid, lid, []core.Record{record.Value()}); err != nil {
+			return err
+		}
+		prevHeads := heads
+		heads = advanceHeads(heads, record.Value().PrevID(), record.Value().Cid())
+		if heads, err = n.collapseHeads(ctx, tid, heads, record.Value()); err != nil {
+			return err
+		}
+		// the update is committed once the record is added to the blockstore below
+		if err := n.journal.Begin(tid, lid, prevHeads, heads); err != nil {
+			return fmt.Errorf("journaling log heads failed: %w", err)
+		}
+		// records are indexed once they're added to the blockstore below
+		if err := n.store.SetHeads(tid, lid, heads); err != nil {
+			return fmt.Errorf("setting log heads failed: %w", err)
+		}
+		// the log head is rolled back, so the record is processed again once received
+		rollback := func(err error) error {
+			if herr := n.store.SetHeads(tid, lid, prevHeads); herr != nil {
+				return fmt.Errorf("rolling back log heads failed: %w", herr)
+			}
+			n.commitHeads(tid, lid)
+			return err
+		}
+
+		restricted := isRestrictedRecord(record.Value())
+		// handoff records don't carry application events
+		newOwner, handoff := handoffOwner(record.Value())
+		if appConnected && !restricted && !handoff {
+			if err := n.handleRecord(ctx, connector, tid, lid, record); err != nil {
+				return rollback(fmt.Errorf("handling record failed: %w", err))
 			}
 		}
 
+		// extensions are saved first, so they are available once the record is processed
+		if err := n.saveExtensions(tid, record.Value()); err != nil {
+			return rollback(fmt.Errorf("saving record extensions failed: %w", err))
+		}
+		n.witnessClock(tid, record.Value())
+		if restricted {
+			if err := n.store.PutBool(tid, record.Value().Cid().String()+bodylessSuffix, true); err != nil {
+				return rollback(fmt.Errorf("flagging record without body failed: %w", err))
+			}
+		}
+		// the handoff record is signed by the log key, so replicas know who continues the log
+		if handoff {
+			if err := n.store.PutBytes(tid, lid.Pretty()+handoffSuffix, []byte(newOwner)); err != nil {
+				return rollback(fmt.Errorf("saving log handoff failed: %w", err))
+			}
+		}
 		// add record envelope to the blockstore, indicating it was successfully processed
-		if err := n.Add(ctx, record.Value()); err != nil {
-			return fmt.Errorf("adding record to the blockstore failed: %w", err)
+		if err := n.dagFor(tid).Add(ctx, record.Value()); err != nil {
+			return rollback(fmt.Errorf("adding record to the blockstore failed: %w", err))
+		}
+		n.commitHeads(tid, lid)
+		n.indexLog(ctx, tid, lid, heads)
+		advanced = record.Value().Cid()
+		appended++
+		if err := n.withholdBodies(ctx, tid, lid, []core.Record{record.Value()}); err != nil {
+			log.Errorf("withholding body of record %s failed: %v", record.Value().Cid(), err)
+		}
+		if err := n.chargeQuota(tid, lid, size); err != nil {
+			log.Errorf("charging quota of thread %s failed: %v", tid, err)
+		}
+		if err := n.chargeRelayed(tid, relayedSize); err != nil {
+			log.Errorf("charging relay quota of thread %s failed: %v", tid, err)
+		}
+		n.afterPersist(ctx, record)
+
+		if n.prefetchAttachments && !restricted {
+			go func(rec core.Record) {
+				if err := n.fetchAttachments(n.ctx, tid, rec); err != nil {
+					log.Errorf("fetching attachments of record %s failed: %v", rec.Cid(), err)
+				}
+			}(record.Value())
 		}
 
 		// Generally broadcasting should not block for too long, i.e. we have to run it
 		// under the semaphore to ensure consistent order seen by the listeners. Record
 		// bursts could be overcome by adjusting listener buffers (EventBusCapacity).
-		if err = n.bus.SendWithTimeout(record, notifyTimeout); err != nil {
+		if err = n.notify(record); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return refused
 }
 
-// Load, validate and cache all records in log between last provided and currentHead.
+// handleRecord hands the record to the app. Failed attempts are kept as dead letters, and once
+// they are exhausted the record is skipped, so a record the app can't handle doesn't stall the log.
+// Reducers must be idempotent, since a record is handled again after a failed attempt.
+func (n *net) handleRecord(ctx context.Context, connector *app.Connector, tid thread.ID, lid peer.ID, rec core.ThreadRecord) error {
+	rid := rec.Value().Cid()
+	err := connector.HandleNetRecord(ctx, rec)
+	if err == nil {
+		return n.deadLetters.Delete(tid, rid)
+	} else if ctx.Err() != nil {
+		return err // interrupted, not an attempt
+	}
+	skip, ferr := n.deadLetters.Fail(tid, lid, rid, err)
+	if ferr != nil {
+		return fmt.Errorf("recording failure failed: %w", ferr)
+	} else if skip {
+		log.Errorf("skipping record %s of log %s/%s the app failed to handle: %v", rid, tid, lid, err)
+		return nil
+	}
+	return err
+}
+
+// Load, validate and cache all records in log between last provided and the
+// last processed one, which is either one of the heads or a fork point.
+// Records are checked against the storage and relay quotas before their blocks are stored, and
+// once one doesn't fit, the records before it are returned with core.ErrQuotaExceeded or ErrRelayQuotaExceeded.
 func (n *net) loadRecords(
 	ctx context.Context,
 	tid thread.ID,
 	lid peer.ID,
 	recs []core.Record,
-) ([]core.ThreadRecord, cid.Cid, error) {
+	src core.RecordSource,
+) ([]core.ThreadRecord, error) {
 	if len(recs) == 0 {
-		return nil, cid.Undef, errors.New("cannot load empty record chain")
+		return nil, errors.New("cannot load empty record chain")
+	}
+	if err := n.checkThreadWriter(tid, lid); err != nil {
+		return nil, err
+	}
+	if err := n.checkNotDeleting(tid); err != nil {
+		return nil, err
 	}
 
 	// check if the last record was already loaded and processed
 	var last = recs[len(recs)-1]
 	if exist, err := n.isKnown(last.Cid()); err != nil {
-		return nil, cid.Undef, err
+		return nil, err
 	} else if exist || !last.Cid().Defined() {
-		return nil, cid.Undef, nil
-	}
-
-	head, err := n.currentHead(tid, lid)
-	if err != nil {
-		return nil, head, err
+		return nil, nil
 	}
 
 	var (
@@ -979,7 +2405,12 @@ func (n *net) loadRecords(
 
 	for i := len(recs) - 1; i >= 0; i-- {
 		var next = recs[i]
-		if c := next.Cid(); !c.Defined() || c.Equals(head) {
+		if c := next.Cid(); !c.Defined() {
+			complete = true
+			break
+		} else if known, err := n.isKnown(c); err != nil {
+			return nil, err
+		} else if known {
 			complete = true
 			break
 		}
@@ -987,126 +2418,391 @@ func (n *net) loadRecords(
 	}
 
 	if !complete {
-		// bridge the gap between the last provided record and current head
+		boundary, err := util.LogMarker(n.store, tid, lid, boundarySuffix)
+		if err != nil {
+			return nil, err
+		}
+
+		// bridge the gap between the last provided record and the last processed one,
+		// records before the compaction boundary are not available
 		var c = chain[len(chain)-1].PrevID()
+		if chain[len(chain)-1].Cid().Equals(boundary) {
+			c = cid.Undef
+		}
 		for c.Defined() {
-			if c.Equals(head) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if known, err := n.isKnown(c); err != nil {
+				return nil, err
+			} else if known {
 				break
 			}
 
 			r, err := n.getRecord(ctx, tid, c)
 			if err != nil {
-				return nil, head, err
+				return nil, err
 			}
 
 			chain = append(chain, r)
+			if c.Equals(boundary) {
+				break
+			}
 			c = r.PrevID()
 		}
 	}
 
 	if len(chain) == 0 {
 		// fast path
-		return nil, head, nil
+		return nil, nil
 	}
 
 	var (
 		connector, appConnected = n.getConnector(tid)
 		identity                = &thread.Libp2pPubKey{}
 		tRecords                = make([]core.ThreadRecord, 0, len(chain))
-		readKey                 *sym.Key
-		validate                bool
+		dec                     tcrypto.Decrypter
 	)
 
-	if appConnected {
+	if appConnected || len(n.commitHooks) > 0 {
 		var err error
-		if readKey, err = n.store.ReadKey(tid); err != nil {
-			return nil, head, err
-		} else if readKey != nil {
-			validate = true
+		if dec, err = n.recordDecrypter(ctx, tid); err != nil {
+			return nil, err
+		}
+	}
+
+	quota, err := n.reserveQuota(tid, lid)
+	if err != nil {
+		return nil, err
+	}
+	prevClock, prevSeq, err := n.prevStamps(ctx, tid, chain[len(chain)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		var r = chain[i]
+		if err := n.checkNodeSize("record", r); err != nil {
+			return nil, err
+		}
+		if err := n.checkClock(tid, r, prevClock); err != nil {
+			n.emitRejected(tid, lid, src.Peer, r.Cid(), err)
+			return nil, err
+		}
+		if clock := r.Clock(); clock != 0 {
+			prevClock = clock
+		}
+		if err := checkSeq(r, prevSeq); err != nil {
+			n.emitRejected(tid, lid, src.Peer, r.Cid(), err)
+			return nil, err
+		}
+		prevSeq = r.Seq()
+		if err := n.runAcceptHooks(ctx, tid, lid, r); err != nil {
+			n.emitRejected(tid, lid, src.Peer, r.Cid(), err)
+			return nil, err
 		}
-	}
-
-	for i := len(chain) - 1; i >= 0; i-- {
-		var r = chain[i]
 		block, err := r.GetBlock(ctx, n)
 		if err != nil {
-			return nil, head, err
+			return nil, err
+		}
+		if err = n.checkNodeSize("event", block); err != nil {
+			return nil, err
 		}
 
 		event, ok := block.(*cbor.Event)
 		if !ok {
 			event, err = cbor.EventFromNode(block)
 			if err != nil {
-				return nil, head, fmt.Errorf("invalid event: %w", err)
+				return nil, fmt.Errorf("invalid event: %w", err)
 			}
 		}
 
 		header, err := event.GetHeader(ctx, n, nil)
 		if err != nil {
-			return nil, head, err
+			return nil, err
+		}
+		if err = n.checkNodeSize("header", header); err != nil {
+			return nil, err
+		}
+		size := int64(len(r.RawData()) + len(block.RawData()) + len(header.RawData()))
+		if isRestrictedRecord(r) {
+			if err = quota.add(size); err != nil {
+				n.emitRejected(tid, lid, src.Peer, r.Cid(), err)
+				return tRecords, err
+			}
+			// the body is withheld by the thread ACL of the peer, the envelope keeps the log verifiable
+			if err = n.dagFor(tid).AddMany(ctx, []format.Node{event, header}); err != nil {
+				return nil, err
+			}
+			tRecords = append(tRecords, NewRecordFrom(r, tid, lid, src))
+			continue
 		}
 
 		body, err := event.GetBody(ctx, n, nil)
 		if err != nil {
-			return nil, head, err
+			return nil, err
+		}
+		if err = n.checkNodeSize("body", body); err != nil {
+			return nil, err
+		}
+		if err = quota.add(size + util.BodyBytes(body)); err != nil {
+			n.emitRejected(tid, lid, src.Peer, r.Cid(), err)
+			return tRecords, err
+		}
+		if err = n.fetchBodyChunks(ctx, body); err != nil {
+			return nil, err
 		}
 
-		if validate {
-			dbody, err := event.GetBody(ctx, n, readKey)
+		if dec != nil {
+			dbody, err := event.GetBody(ctx, n, dec)
 			if err != nil {
-				return nil, head, err
+				return nil, err
 			}
 
 			if err = identity.UnmarshalBinary(r.PubKey()); err != nil {
-				return nil, head, err
+				return nil, err
 			}
 
-			if err = connector.ValidateNetRecordBody(ctx, dbody, identity); err != nil {
-				return nil, head, err
+			if appConnected {
+				if err = connector.ValidateNetRecordBody(ctx, dbody, identity); err != nil {
+					return nil, err
+				}
+			}
+			if err = n.runCommitHooks(ctx, tid, dbody, identity); err != nil {
+				return nil, err
 			}
 		}
 
 		// store internal blocks locally, record envelope will be added by the caller after successful processing
-		if err = n.AddMany(ctx, []format.Node{event, header, body}); err != nil {
-			return nil, head, err
+		if err = n.dagFor(tid).AddMany(ctx, []format.Node{event, header, body}); err != nil {
+			return nil, err
+		}
+
+		tRecords = append(tRecords, NewRecordFrom(r, tid, lid, src))
+	}
+
+	return tRecords, nil
+}
+
+// runCommitHooks applies the configured commit hooks to a record body, stopping at the first veto.
+func (n *net) runCommitHooks(ctx context.Context, tid thread.ID, body format.Node, author thread.PubKey) error {
+	for _, hook := range n.commitHooks {
+		if err := hook(ctx, tid, body, author); err != nil {
+			return fmt.Errorf("record vetoed by commit hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// beforePersist runs the configured persist hooks on a record about to be added to its log,
+// stopping at the first rejection.
+func (n *net) beforePersist(ctx context.Context, rec core.ThreadRecord) error {
+	for _, hooks := range n.persistHooks {
+		if hooks.OnBeforePersist == nil {
+			continue
 		}
+		if err := hooks.OnBeforePersist(ctx, rec); err != nil {
+			return fmt.Errorf("record rejected by persist hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// afterPersist runs the configured persist hooks on a record added to its log.
+func (n *net) afterPersist(ctx context.Context, rec core.ThreadRecord) {
+	for _, hooks := range n.persistHooks {
+		if hooks.OnAfterPersist != nil {
+			hooks.OnAfterPersist(ctx, rec)
+		}
+	}
+}
 
-		tRecords = append(tRecords, NewRecord(r, tid, lid))
+// onBroadcast runs the configured persist hooks on a record about to be pushed to peers.
+func (n *net) onBroadcast(ctx context.Context, rec core.ThreadRecord, peers []peer.ID) {
+	for _, hooks := range n.persistHooks {
+		if hooks.OnBroadcast != nil {
+			hooks.OnBroadcast(ctx, rec, peers)
+		}
 	}
+}
 
-	return tRecords, head, nil
+// processedRecords returns the number of leading records of a chain which are already processed,
+// i.e., reachable from the log heads. Records fetched to bridge gaps in pushed chains are added to
+// the blockstore before they are processed, so being known doesn't tell. The caller must hold the
+// log semaphore.
+func (n *net) processedRecords(ctx context.Context, tid thread.ID, heads []cid.Cid, chain []core.ThreadRecord) (int, error) {
+	pos := make(map[cid.Cid]int, len(chain))
+	for i, r := range chain {
+		pos[r.Value().Cid()] = i + 1
+	}
+	var (
+		base      = chain[0].Value().PrevID()
+		processed int
+		visited   = make(map[cid.Cid]struct{})
+	)
+	for _, head := range heads {
+		// heads usually stay at the base of the chain, otherwise they're walked back to it
+		for c := head; c.Defined() && !c.Equals(base); {
+			// heads of logs added from a peer are saved before their records are fetched,
+			// and records below the compaction boundary are gone
+			if known, err := n.isKnown(c); err != nil {
+				return 0, err
+			} else if !known {
+				break
+			}
+			if p, ok := pos[c]; ok {
+				if p > processed {
+					processed = p
+				}
+				break
+			}
+			if _, ok := visited[c]; ok {
+				break
+			}
+			visited[c] = struct{}{}
+			r, err := n.getRecord(ctx, tid, c)
+			if err != nil {
+				return 0, err
+			}
+			c = r.PrevID()
+		}
+	}
+	return processed, nil
 }
 
 func (n *net) isKnown(rec cid.Cid) (bool, error) {
 	return n.bstore.Has(rec)
 }
 
-func (n *net) currentHead(tid thread.ID, lid peer.ID) (cid.Cid, error) {
-	var head cid.Cid
-	heads, err := n.store.Heads(tid, lid)
+func (n *net) currentHeads(tid thread.ID, lid peer.ID) ([]cid.Cid, error) {
+	return n.store.Heads(tid, lid)
+}
+
+// knownHeads returns the log heads available in the local blockstore.
+// Heads of logs added from a peer are saved before their records are fetched.
+func (n *net) knownHeads(tid thread.ID, lid peer.ID) ([]cid.Cid, error) {
+	heads, err := n.currentHeads(tid, lid)
 	if err != nil {
-		return head, err
+		return nil, err
+	}
+	known := heads[:0]
+	for _, h := range heads {
+		if has, err := n.isKnown(h); err != nil {
+			return nil, err
+		} else if has {
+			known = append(known, h)
+		}
 	}
+	return known, nil
+}
 
-	if len(heads) > 0 {
-		head = heads[0]
-	} else {
-		head = cid.Undef
+// advanceHeads replaces the parent of a processed record with the record in
+// the log heads. Records branching off an older record fork the log, so they
+// are added next to the existing heads.
+func advanceHeads(heads []cid.Cid, prev, rid cid.Cid) []cid.Cid {
+	next := make([]cid.Cid, 0, len(heads)+1)
+	for _, h := range heads {
+		if !h.Equals(prev) && !h.Equals(rid) {
+			next = append(next, h)
+		}
+	}
+	return append(next, rid)
+}
+
+// collapseHeads drops the heads which are ancestors of a new record, so branches of a forked
+// log which the record descends from are merged into it. The walk stops once the record clocks
+// fall behind the clocks of the heads, or after MaxHeadCollapseDepth records.
+func (n *net) collapseHeads(ctx context.Context, tid thread.ID, heads []cid.Cid, rec core.Record) ([]cid.Cid, error) {
+	if len(heads) < 2 {
+		return heads, nil
+	}
+	others := make(map[cid.Cid]struct{}, len(heads)-1)
+	var floor uint64 = math.MaxUint64
+	for _, h := range heads {
+		if h.Equals(rec.Cid()) {
+			continue
+		}
+		others[h] = struct{}{}
+		clock := uint64(0)
+		if known, err := n.isKnown(h); err != nil {
+			return nil, err
+		} else if known {
+			r, err := n.getRecord(ctx, tid, h)
+			if err != nil {
+				return nil, err
+			}
+			clock = r.Clock()
+		}
+		if clock < floor {
+			floor = clock
+		}
+	}
+
+	ancestors := make(map[cid.Cid]struct{})
+	for rid, depth := rec.PrevID(), 0; rid.Defined() && depth < MaxHeadCollapseDepth; depth++ {
+		if _, ok := others[rid]; ok {
+			ancestors[rid] = struct{}{}
+			if len(ancestors) == len(others) {
+				break
+			}
+		}
+		if known, err := n.isKnown(rid); err != nil {
+			return nil, err
+		} else if !known {
+			break
+		}
+		r, err := n.getRecord(ctx, tid, rid)
+		if err != nil {
+			return nil, err
+		}
+		if clock := r.Clock(); clock != 0 && clock <= floor {
+			break
+		}
+		rid = r.PrevID()
+	}
+	if len(ancestors) == 0 {
+		return heads, nil
 	}
+	collapsed := heads[:0:0]
+	for _, h := range heads {
+		if _, ok := ancestors[h]; !ok {
+			collapsed = append(collapsed, h)
+		}
+	}
+	return collapsed, nil
+}
 
-	return head, nil
+// splitChains splits records into linear chains, breaking wherever a record
+// doesn't follow the previous one.
+func splitChains(recs []core.Record) [][]core.Record {
+	var (
+		chains [][]core.Record
+		start  int
+	)
+	for i := 1; i <= len(recs); i++ {
+		if i == len(recs) || !recs[i].PrevID().Equals(recs[i-1].Cid()) {
+			chains = append(chains, recs[start:i])
+			start = i
+		}
+	}
+	return chains
 }
 
-// newRecord creates a new record with the given body as a new event body.
+// newRecord creates a new record with the given body as a new event body, clock, sequence number
+// and optional extensions.
 func (n *net) newRecord(
 	ctx context.Context,
 	id thread.ID,
 	lg thread.LogInfo,
 	body format.Node,
 	pk thread.PubKey,
+	clock uint64,
+	seq uint64,
+	ext map[string][]byte,
 ) (core.Record, error) {
-	if lg.PrivKey == nil {
-		return nil, fmt.Errorf("a private-key is required to create records")
+	signer, err := n.logSigner(ctx, id, lg)
+	if err != nil {
+		return nil, err
 	}
 	sk, err := n.store.ServiceKey(id)
 	if err != nil {
@@ -1115,23 +2811,30 @@ func (n *net) newRecord(
 	if sk == nil {
 		return nil, fmt.Errorf("a service-key is required to create records")
 	}
-	rk, err := n.store.ReadKey(id)
+	enc, err := n.recordEncrypter(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	if rk == nil {
+	if enc == nil {
 		return nil, fmt.Errorf("a read-key is required to create records")
 	}
-	event, err := cbor.CreateEvent(ctx, n, body, rk)
+	if err = n.checkBodySize(int64(len(body.RawData()))); err != nil {
+		return nil, err
+	}
+	dag := n.dagFor(id)
+	event, err := cbor.CreateCompressedEvent(ctx, dag, body, enc, n.bodyCodec(id))
 	if err != nil {
 		return nil, err
 	}
-	return cbor.CreateRecord(ctx, n, cbor.CreateRecordConfig{
+	return cbor.CreateRecord(ctx, dag, cbor.CreateRecordConfig{
 		Block:      event,
 		Prev:       lg.Head,
-		Key:        lg.PrivKey,
+		Signer:     signer,
 		PubKey:     pk,
 		ServiceKey: sk,
+		Extensions: ext,
+		Clock:      clock,
+		Seq:        seq,
 	})
 }
 
@@ -1140,25 +2843,51 @@ func (n *net) getPrivKey() crypto.PrivKey {
 	return n.host.Peerstore().PrivKey(n.host.ID())
 }
 
+// localIdentity returns the named keystore identity the host acts as,
+// or the host identity if the name is empty.
+func (n *net) localIdentity(name string) (thread.PubKey, error) {
+	if name == "" {
+		return thread.NewLibp2pPubKey(n.getPrivKey().GetPublic()), nil
+	}
+	if n.keystore == nil {
+		return nil, fmt.Errorf("identity %s: %w", name, keystore.ErrIdentityNotFound)
+	}
+	key, err := n.keystore.Identity(name)
+	if err != nil {
+		return nil, fmt.Errorf("identity %s: %w", name, err)
+	}
+	return thread.NewLibp2pPubKey(key.GetPublic()), nil
+}
+
 // getLocalRecords returns local records from the given thread that are ahead of
-// offset but not farther than limit.
-// It is possible to reach limit before offset, meaning that the caller
+// offsets but not farther than limit. Records of every log head are returned,
+// branches of a forked log following the records they branch off.
+// It is possible to reach limit before offsets, meaning that the caller
 // will be responsible for the remaining traversal.
 func (n *net) getLocalRecords(
 	ctx context.Context,
 	id thread.ID,
 	lid peer.ID,
-	offset cid.Cid,
+	offsets []cid.Cid,
 	limit int,
 ) ([]core.Record, error) {
-	if offset.Defined() {
-		// ensure that we know about requested offset
+	boundary, err := util.LogMarker(n.store, id, lid, boundarySuffix)
+	if err != nil {
+		return nil, err
+	}
+	// ensure that we know about requested offsets
+	var stop = make(map[cid.Cid]struct{}, len(offsets))
+	for _, offset := range offsets {
 		if knownRecord, err := n.isKnown(offset); err != nil {
 			return nil, err
-		} else if !knownRecord {
-			return nil, nil
+		} else if knownRecord {
+			stop[offset] = struct{}{}
 		}
 	}
+	if len(offsets) > 0 && len(stop) == 0 && !boundary.Defined() {
+		return nil, nil
+	}
+	// otherwise offsets could be dropped by compaction, serve from the boundary
 
 	lg, err := n.store.GetLog(id, lid)
 	if err != nil {
@@ -1172,33 +2901,62 @@ func (n *net) getLocalRecords(
 		return nil, fmt.Errorf("a service-key is required to get records")
 	}
 
-	var (
-		cursor = lg.Head
-		recs   []core.Record
-	)
+	if recs, ok := n.indexedRecords(ctx, id, lid, lg.Heads, stop, boundary, limit, sk); ok {
+		return recs, nil
+	}
 
-	for len(recs) < limit {
-		if !cursor.Defined() || cursor.String() == offset.String() {
-			break
-		}
-		r, err := cbor.GetRecord(ctx, n, cursor, sk) // Important invariant: heads are always in blockstore
-		if err != nil {
-			// return records fetched so far
-			return recs, err
+	var recs []core.Record
+	for _, head := range lg.Heads {
+		var (
+			cursor = head
+			branch []core.Record
+		)
+		for len(recs)+len(branch) < limit {
+			if _, ok := stop[cursor]; !cursor.Defined() || ok {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return append(recs, branch...), err
+			}
+			r, err := cbor.GetRecord(ctx, n, cursor, sk) // Important invariant: heads are always in blockstore
+			if err != nil {
+				// return records fetched so far
+				return append(recs, branch...), err
+			}
+			if err = n.loadExtensions(id, r); err != nil {
+				return append(recs, branch...), err
+			}
+			branch = append([]core.Record{r}, branch...)
+			// other branches stop at the fork point
+			stop[cursor] = struct{}{}
+			if cursor.Equals(boundary) {
+				// older records are dropped by compaction
+				break
+			}
+			cursor = r.PrevID()
 		}
-		recs = append([]core.Record{r}, recs...)
-		cursor = r.PrevID()
+		recs = append(recs, branch...)
 	}
 
 	return recs, nil
 }
 
 // deleteRecord remove a record from the dag service.
-func (n *net) deleteRecord(ctx context.Context, rid cid.Cid, sk *sym.Key) (prev cid.Cid, err error) {
+func (n *net) deleteRecord(ctx context.Context, tid thread.ID, rid cid.Cid, sk *sym.Key) (prev cid.Cid, err error) {
 	rec, err := cbor.GetRecord(ctx, n, rid, sk)
 	if err != nil {
 		return
 	}
+	if err = n.extensions.Delete(tid, rid); err != nil {
+		return
+	}
+	if n.blockRefs != nil {
+		event, err := cbor.EventFromRecord(ctx, n, rec)
+		if err != nil {
+			return cid.Undef, err
+		}
+		return rec.PrevID(), n.removeRecordBlocks(ctx, tid, rec, event)
+	}
 	if err = cbor.RemoveRecord(ctx, n, rec); err != nil {
 		return
 	}
@@ -1212,79 +2970,165 @@ func (n *net) deleteRecord(ctx context.Context, rid cid.Cid, sk *sym.Key) (prev
 	return rec.PrevID(), nil
 }
 
-// startPulling periodically pulls on all threads.
+// joinThreadTopics reconciles pubsub topics with the logstore by joining the topic
+// of every stored thread. Joins are spread out, so hosts with many threads don't
+// flood the pubsub router on startup.
+func (n *net) joinThreadTopics() {
+	var (
+		cursor = newThreadCursor(n.store)
+		timer  = n.clock.NewTimer(0)
+		joined int
+	)
+	defer timer.Stop()
+	<-timer.Chan()
+
+	for {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			log.Errorf("error listing threads: %s", err)
+			return
+		} else if !ok {
+			break
+		}
+
+		timer.Reset(PubSubJoinInterval)
+		select {
+		case <-timer.Chan():
+		case <-n.ctx.Done():
+			return
+		}
+
+		if err := n.joinThreadTopic(tid); err != nil {
+			log.Errorf("error joining topic of thread %s: %s", tid, err)
+			continue
+		}
+		joined++
+	}
+	log.Debugf("joined topics of %d stored threads", joined)
+}
+
+// joinThreadTopic joins the thread topic unless the thread was deleted meanwhile.
+func (n *net) joinThreadTopic(tid thread.ID) error {
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+
+	if _, err := n.store.GetThread(tid); errors.Is(err, lstore.ErrThreadNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if deleting, err := n.isDeleting(tid); err != nil || deleting {
+		return err
+	}
+	if unloaded, err := n.isUnloaded(tid); err != nil || unloaded {
+		return err
+	}
+	return n.server.ps.Add(tid)
+}
+
+// startPulling periodically pulls on all threads. Thread IDs are listed once per
+// cycle, and thread state is only loaded once a thread is pulled.
 func (n *net) startPulling() {
 	select {
-	case <-time.After(PullStartAfter):
+	case <-n.clock.After(PullStartAfter):
 	case <-n.ctx.Done():
 		return
 	}
 
-	// set pull cycle interval into initial value,
-	// it will be redefined on the next iteration
-	var interval = InitialPullInterval
+	// the first pull cycle runs with the initial interval, the following ones with
+	// the pull interval, both are read on every wait to pick up config updates
+	var initial = true
+	interval := func() time.Duration {
+		cfg := n.syncConfig()
+		if initial {
+			return cfg.InitialPullInterval
+		}
+		return cfg.PullInterval
+	}
 
 	// group threads by peers and exchange edges efficiently
-	var compressor = queue.NewThreadPacker(n.ctx, MaxThreadsExchanged, ExchangeCompressionTimeout)
+	var compressor = queue.NewThreadPacker(n.ctx, n.clock, MaxThreadsExchanged, ExchangeCompressionTimeout)
 	go n.startExchange(compressor)
 
-PullCycle:
+	var (
+		cursor = newThreadCursor(n.store)
+		timer  = n.clock.NewTimer(0)
+		// number of threads seen during the previous cycle
+		total int
+	)
+	<-timer.Chan()
+
 	for {
-		ts, err := n.store.Threads()
-		if err != nil {
-			log.Errorf("error listing threads: %s", err)
-			return
-		}
+		var processed int
+		cursor.Reset()
 
-		if len(ts) == 0 {
-			// if there are no threads served, just wait and retry
+		for {
+			tid, ok, err := cursor.Next()
+			if err != nil {
+				log.Errorf("error listing threads: %s", err)
+				return
+			} else if !ok {
+				break
+			}
+			if unloaded, err := n.isUnloaded(tid); err != nil {
+				log.Errorf("error getting thread state %s: %s", tid, err)
+				continue
+			} else if unloaded {
+				continue
+			}
+			processed++
+
+			// spread pulls uniformly over the interval, while the first cycle
+			// is still in progress estimate total from the listed threads
+			var estimate = total
+			if seen := processed + cursor.Buffered(); seen > estimate {
+				estimate = seen
+			}
+			timer.Reset(interval() / time.Duration(estimate))
 			select {
-			case <-time.After(interval):
-				interval = PullInterval
-				continue PullCycle
+			case <-timer.Chan():
 			case <-n.ctx.Done():
+				timer.Stop()
 				return
 			}
-		}
-
-		var (
-			period = interval / time.Duration(len(ts))
-			ticker = time.NewTicker(period)
-			idx    = 0
-		)
 
-		for {
-			select {
-			case <-ticker.C:
-				var tid = ts[idx]
-				if _, peers, err := n.threadOffsets(tid); err != nil {
-					log.Errorf("error getting thread info %s: %s", tid, err)
-					return
-				} else {
-					for _, pid := range peers {
+			if _, peers, err := n.threadOffsets(tid); err != nil {
+				log.Errorf("error getting thread info %s: %s", tid, err)
+				return
+			} else {
+				// peers gossiping edges learn about divergence from the gossip
+				gossiping := n.gossipEdges(tid)
+				for _, pid := range n.topology.prefer(peers) {
+					if _, ok := gossiping[pid]; !ok && n.reputation.schedulable(pid) {
 						compressor.Add(pid, tid)
 					}
 				}
+			}
+		}
 
-				idx++
-				if idx >= len(ts) {
-					ticker.Stop()
-					interval = PullInterval
-					continue PullCycle
-				}
-
+		if processed == 0 {
+			// if there are no threads served, just wait and retry
+			timer.Reset(interval())
+			select {
+			case <-timer.Chan():
 			case <-n.ctx.Done():
-				ticker.Stop()
+				timer.Stop()
 				return
 			}
 		}
+
+		total = processed
+		initial = false
 	}
 }
 
 func (n *net) startExchange(compressor queue.ThreadPacker) {
 	for pack := range compressor.Run() {
 		go func(p queue.ThreadPack) {
-			if err := n.server.exchangeEdges(n.ctx, p.Peer, p.Threads); err != nil {
+			if _, err := n.server.exchangeEdges(n.ctx, p.Peer, p.Threads); err != nil {
 				log.Errorf("exchangeEdges with %s failed: %v", p.Peer, err)
 			}
 		}(pack)
@@ -1308,6 +3152,9 @@ func (n *net) createLog(id thread.ID, key crypto.Key, identity thread.PubKey) (i
 	if err != nil {
 		return
 	}
+	if err = n.checkThreadWriter(id, info.ID); err != nil {
+		return
+	}
 	addr, err := ma.NewMultiaddr("/" + ma.ProtocolWithCode(ma.P_P2P).Name + "/" + n.host.ID().String())
 	if err != nil {
 		return
@@ -1320,11 +3167,8 @@ func (n *net) createLog(id thread.ID, key crypto.Key, identity thread.PubKey) (i
 	if err = n.store.AddLog(id, info); err != nil {
 		return info, err
 	}
-	lidb, err := info.ID.MarshalBinary()
-	if err != nil {
-		return info, err
-	}
-	if err = n.store.PutBytes(id, identity.String(), lidb); err != nil {
+	n.emit(core.LifecycleEvent{Type: core.LogAdded, ThreadID: id, LogID: info.ID})
+	if err = n.putLogIdentity(id, info.ID, identity); err != nil {
 		return info, err
 	}
 	return info, nil
@@ -1340,17 +3184,8 @@ func (n *net) getOrCreateLog(id thread.ID, identity thread.PubKey) (info thread.
 	if err != nil {
 		return info, err
 	}
-	// Check if we have an old-style "own" (unindexed) log
-	if lidb == nil && identity.Equals(thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())) {
-		thrd, err := n.store.GetThread(id)
-		if err != nil {
-			return info, err
-		}
-		ownLog := thrd.GetFirstPrivKeyLog()
-		if ownLog != nil {
-			return *ownLog, nil
-		}
-	} else if lidb != nil {
+	// the mapping is emptied once the log is handed off to another peer
+	if lidb != nil && len(*lidb) > 0 {
 		lid, err := peer.IDFromBytes(*lidb)
 		if err != nil {
 			return info, err
@@ -1360,27 +3195,76 @@ func (n *net) getOrCreateLog(id thread.ID, identity thread.PubKey) (info thread.
 	return n.createLog(id, nil, identity)
 }
 
+// getOrCreateLogLocked is getOrCreateLog serialized per identity, so concurrent
+// updates of a thread don't create several logs of an identity.
+func (n *net) getOrCreateLogLocked(id thread.ID, identity thread.PubKey) (thread.LogInfo, error) {
+	if identity == nil {
+		identity = thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	}
+	ls, err := n.logSemaphores.Acquire(semaLogUpdate{tid: id, key: identity.String()})
+	if err != nil {
+		return thread.LogInfo{}, err
+	}
+	defer ls.Release()
+	return n.getOrCreateLog(id, identity)
+}
+
 // createExternalLogsIfNotExist creates an external logs if doesn't exists. The created
-// logs will have cid.Undef as the current head. Is thread-safe.
+// logs will have cid.Undef as the current head. Log addresses are verified against the
+// owner's signature, see verifyLogAddrs. Is thread-safe.
 func (n *net) createExternalLogsIfNotExist(
 	tid thread.ID,
-	lis []thread.LogInfo,
+	lis []peerLog,
 ) error {
-	ts := n.semaphores.Get(semaThreadUpdate(tid))
-	ts.Acquire()
+	return n.createExternalLogs(tid, lis, false)
+}
+
+// createExternalLogs creates external logs like createExternalLogsIfNotExist. If lazy, logs without
+// records aren't created, they are added along with their first records pulled or pushed by peers.
+func (n *net) createExternalLogs(tid thread.ID, lis []peerLog, lazy bool) error {
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return err
+	}
 	defer ts.Release()
 
 	for _, li := range lis {
+		if err := n.checkThreadWriter(tid, li.ID); err != nil {
+			log.Debugf("skipping log %s (thread=%s): %v", li.ID, tid, err)
+			continue
+		}
+		if err := n.checkTrustedLogKey(tid, li); err != nil {
+			return err
+		}
 		if currHeads, err := n.Store().Heads(tid, li.ID); err != nil {
 			return err
 		} else if len(currHeads) == 0 {
+			if lazy && !li.Head.Defined() && len(li.Heads) == 0 {
+				// logs known locally are updated anyway
+				if pk, err := n.store.PubKey(tid, li.ID); err != nil {
+					return err
+				} else if pk == nil {
+					continue
+				}
+			}
+			var replace bool
+			if li.Addrs, replace, err = n.verifyLogAddrs(tid, li); err != nil {
+				return err
+			}
+			// logs without heads may be known already, and adding them only refreshes addresses
+			if replace {
+				if err = n.store.ClearAddrs(tid, li.ID); err != nil {
+					return err
+				}
+			}
 			li.Head = cid.Undef
-			if err = n.Store().AddLog(tid, li); err != nil {
+			if err = n.Store().AddLog(tid, li.LogInfo); err != nil {
 				return err
 			}
+			n.emit(core.LifecycleEvent{Type: core.LogAdded, ThreadID: tid, LogID: li.ID})
 		} else {
 			// update log addresses
-			if err = n.Store().AddAddrs(tid, li.ID, li.Addrs, pstore.PermanentAddrTTL); err != nil {
+			if err = n.putLogAddrs(tid, li); err != nil {
 				return err
 			}
 		}
@@ -1391,7 +3275,7 @@ func (n *net) createExternalLogsIfNotExist(
 // ensureUniqueLog returns a non-nil error if a log with key already exists,
 // or if a log for identity already exists for the given thread.
 func (n *net) ensureUniqueLog(id thread.ID, key crypto.Key, identity thread.PubKey) (err error) {
-	thrd, err := n.store.GetThread(id)
+	_, err = n.store.GetThread(id)
 	if errors.Is(err, lstore.ErrThreadNotFound) {
 		return nil
 	}
@@ -1419,13 +3303,7 @@ func (n *net) ensureUniqueLog(id thread.ID, key crypto.Key, identity thread.PubK
 		if err != nil {
 			return err
 		}
-		if lidb == nil {
-			// Check if we have an old-style "own" (unindexed) log
-			if identity.Equals(thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())) {
-				if thrd.GetFirstPrivKeyLog().PrivKey != nil {
-					return lstore.ErrThreadExists
-				}
-			}
+		if lidb == nil || len(*lidb) == 0 {
 			return nil
 		}
 		lid, err = peer.IDFromBytes(*lidb)
@@ -1445,20 +3323,31 @@ func (n *net) ensureUniqueLog(id thread.ID, key crypto.Key, identity thread.PubK
 
 // updateRecordsFromPeer fetches new logs & records from the peer and adds them in the local peer store.
 func (n *net) updateRecordsFromPeer(ctx context.Context, pid peer.ID, tid thread.ID) error {
+	return n.pullRecordsFromPeer(ctx, pid, tid, nil)
+}
+
+// pullRecordsFromPeer is like updateRecordsFromPeer, but requests logs with page sizes
+// fitting the numbers of missing records if they are known, see applyPageLimits.
+func (n *net) pullRecordsFromPeer(ctx context.Context, pid peer.ID, tid thread.ID, lags map[peer.ID]uint64) (err error) {
+	defer func() { n.emitPull(tid, pid, err) }()
 	offsets, _, err := n.threadOffsets(tid)
 	if err != nil {
 		return fmt.Errorf("getting offsets for thread %s failed: %w", tid, err)
 	}
-	req, sk, err := n.server.buildGetRecordsRequest(tid, offsets, MaxPullLimit)
+	limit := n.syncConfig().MaxPullLimit
+	req, sk, err := n.server.buildGetRecordsRequest(tid, offsets, limit)
 	if err != nil {
 		return fmt.Errorf("building GetRecords request for thread %s failed: %w", tid, err)
 	}
+	if len(lags) > 0 {
+		applyPageLimits(req, lags, limit)
+	}
 	recs, err := n.server.getRecordsFromPeer(ctx, tid, pid, req, sk)
 	if err != nil {
 		return fmt.Errorf("getting records for thread %s from %s failed: %w", tid, pid, err)
 	}
 	for lid, rs := range recs {
-		if err = n.putRecords(ctx, tid, lid, rs); err != nil {
+		if err = n.putPulledRecords(ctx, tid, lid, rs, pid); err != nil {
 			return fmt.Errorf("putting records from log %s (thread %s) failed: %w", lid, tid, err)
 		}
 	}
@@ -1467,11 +3356,30 @@ func (n *net) updateRecordsFromPeer(ctx context.Context, pid peer.ID, tid thread
 
 // updateLogsFromPeer gets new logs information from the peer and adds it in the local peer store.
 func (n *net) updateLogsFromPeer(ctx context.Context, pid peer.ID, tid thread.ID) error {
-	lgs, err := n.server.getLogs(ctx, tid, pid)
-	if err != nil {
+	first := true
+	return n.server.getLogs(ctx, tid, pid, func(lgs []peerLog, flags thread.Flags, sig *signedFlags) error {
+		if first {
+			first = false
+			if err := n.withThreadLock(tid, func() error {
+				return n.mergeThreadFlags(tid, flags, sig)
+			}); err != nil {
+				return fmt.Errorf("logs from %s: %w", pid, err)
+			}
+		}
+		return n.createExternalLogs(tid, lgs, n.lazyLogs)
+	})
+}
+
+// restoreLogsUpdate updates logs of a thread from the peer for a call persisted before a restart.
+// The thread topic is joined as well, since the call may have been scheduled for a new thread.
+func (n *net) restoreLogsUpdate(ctx context.Context, pid peer.ID, tid thread.ID) error {
+	if err := n.updateLogsFromPeer(ctx, pid, tid); err != nil {
 		return err
 	}
-	return n.createExternalLogsIfNotExist(tid, lgs)
+	if n.server.ps != nil {
+		return n.joinThreadTopic(tid)
+	}
+	return nil
 }
 
 // returns offsets and involved peers for all known thread's logs.
@@ -1503,5 +3411,8 @@ func (n *net) threadOffsets(tid thread.ID) (map[peer.ID]cid.Cid, []peer.ID, erro
 	if err != nil {
 		return nil, nil, err
 	}
+	if peers, err = n.withoutRemovedReplicators(tid, peers); err != nil {
+		return nil, nil, err
+	}
 	return offsets, peers, nil
 }
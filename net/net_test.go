@@ -1,29 +1,52 @@
 package net
 
 import (
+	"bytes"
 	"context"
 	rand "crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
 	ds "github.com/ipfs/go-datastore"
 	syncds "github.com/ipfs/go-datastore/sync"
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 	offline "github.com/ipfs/go-ipfs-exchange-offline"
 	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
 	dag "github.com/ipfs/go-merkledag"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/peerstore"
+	tu "github.com/libp2p/go-libp2p-core/test"
 	ma "github.com/multiformats/go-multiaddr"
 	mh "github.com/multiformats/go-multihash"
 	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	corekeystore "github.com/textileio/go-threads/core/keystore"
 	"github.com/textileio/go-threads/core/logstore"
 	core "github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/keystore"
 	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+	pb "github.com/textileio/go-threads/net/pb"
+	nu "github.com/textileio/go-threads/net/util"
 	"github.com/textileio/go-threads/util"
+	"github.com/textileio/go-threads/util/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNet_GetToken(t *testing.T) {
@@ -45,6 +68,45 @@ func TestNet_GetToken(t *testing.T) {
 	}
 }
 
+func TestNet_GetTokenWithChallenge(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+	ctx := context.Background()
+
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := thread.NewLibp2pPubKey(sk.GetPublic())
+	challenge, _, err := n.GetTokenChallenge(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.GetTokenWithChallenge(ctx, key, challenge, []byte("bad")); err == nil {
+		t.Fatal("expected bad signature to be refused")
+	}
+
+	challenge, _, err = n.GetTokenChallenge(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sk.Sign(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := n.GetTokenWithChallenge(ctx, key, challenge, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok == "" {
+		t.Fatal("bad token")
+	}
+	if _, err = n.GetTokenWithChallenge(ctx, key, challenge, sig); !errors.Is(err, core.ErrTokenChallengeNotFound) {
+		t.Fatalf("expected redeemed challenge to be refused, got %v", err)
+	}
+}
+
 func TestNet_CreateRecord(t *testing.T) {
 	t.Parallel()
 	n := makeNetwork(t)
@@ -109,11 +171,73 @@ func TestNet_CreateRecord(t *testing.T) {
 	})
 }
 
-func TestNet_AddThread(t *testing.T) {
+func TestNet_CreateRecords(t *testing.T) {
 	t.Parallel()
-	n1 := makeNetwork(t)
+	n := makeNetwork(t)
+	defer n.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n)
+
+	sub, err := n.Subscribe(ctx, core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// records are emitted before CreateRecords returns
+	emitted := make(chan core.ThreadRecord, 3)
+	go func() {
+		for r := range sub {
+			emitted <- r
+		}
+	}()
+
+	var bodies []format.Node
+	for i := 0; i < 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, body)
+	}
+	recs, err := n.CreateRecords(ctx, info.ID, bodies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != len(bodies) {
+		t.Fatalf("expected %d records, got %d", len(bodies), len(recs))
+	}
+	for i := 1; i < len(recs); i++ {
+		if !recs[i].Value().PrevID().Equals(recs[i-1].Value().Cid()) {
+			t.Fatalf("expected record %d to follow record %d", i, i-1)
+		}
+	}
+
+	// records are emitted in order
+	for i := range recs {
+		select {
+		case r := <-emitted:
+			if !r.Value().Cid().Equals(recs[i].Value().Cid()) {
+				t.Fatalf("expected record %d to be emitted, got %s", i, r.Value().Cid())
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("record %d was not emitted", i)
+		}
+	}
+
+	lg, err := n.(*net).store.GetLog(info.ID, recs[0].LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := recs[len(recs)-1].Value().Cid(); !lg.Head.Equals(last) || len(lg.Heads) != 1 {
+		t.Fatalf("expected log head %s, got %v", last, lg.Heads)
+	}
+}
+
+func TestNet_CreateRecordsAtomic(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
 	defer n1.Close()
-	n2 := makeNetwork(t)
+	n2 := makeNetwork(t).(*net)
 	defer n2.Close()
 
 	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
@@ -121,207 +245,295 @@ func TestNet_AddThread(t *testing.T) {
 
 	ctx := context.Background()
 	info := createThread(t, ctx, n1)
-
-	body, err := cbornode.WrapObject(map[string]interface{}{
-		"msg": "yo!",
-	}, mh.SHA2_256, -1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
 		t.Fatal(err)
 	}
-
-	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
-	if err != nil {
+	var bodies []format.Node
+	for i := 0; i < 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, body)
+	}
+	// let n1 learn about the log of n2
+	if _, err = n2.CreateRecord(ctx, info.ID, bodies[0]); err != nil {
 		t.Fatal(err)
 	}
+	time.Sleep(time.Second)
 
-	info2, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key))
+	recs, err := n1.CreateRecordsAtomic(ctx, info.ID, bodies)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := n2.PullThread(ctx, info2.ID); err != nil {
-		t.Fatal(err)
-	}
-	if len(info2.Logs) != 2 {
-		t.Fatalf("expected 2 logs got %d", len(info2.Logs))
+	if len(recs) != len(bodies) {
+		t.Fatalf("expected %d records, got %d", len(bodies), len(recs))
 	}
-	if len(info2.Addrs) == 0 {
-		t.Fatalf("expected more than 0 addresses got %d", len(info2.Addrs))
+	// every record is pushed, the last one is awaited first as records arrive in order
+	for i := len(recs) - 1; i >= 0; i-- {
+		r := recs[i]
+		actx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		_, err := n2.AwaitRecord(actx, info.ID, r.Value().Cid())
+		cancel()
+		if err != nil {
+			t.Fatalf("expected record %s to be pushed: %v", r.Value().Cid(), err)
+		}
 	}
+	lid, head := recs[0].LogID(), recs[len(recs)-1].Value().Cid()
 
-	body2, err := cbornode.WrapObject(map[string]interface{}{
-		"msg": "yo back!",
-	}, mh.SHA2_256, -1)
+	// a failure while creating the chain leaves the log untouched
+	large, err := cbornode.WrapObject(map[string]interface{}{"msg": strings.Repeat("yo! ", 1000)}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err = n2.CreateRecord(ctx, info2.ID, body2); err != nil {
-		t.Fatal(err)
+	n1.maxRecordBodySize = 1024
+	if _, err = n1.CreateRecordsAtomic(ctx, info.ID, []format.Node{bodies[0], large}); err == nil {
+		t.Fatal("expected oversized body to fail the batch")
 	}
-	time.Sleep(time.Second)
-
-	info3, err := n1.GetThread(context.Background(), info.ID)
+	// as does a failure to prepare the push
+	errPrepare := errors.New("prepare failed")
+	_, _, err = n1.createRecordChain(ctx, info.ID, bodies, thread.NewLibp2pPubKey(n1.getPrivKey().GetPublic()), nil,
+		func(peer.ID, []core.Record) error { return errPrepare })
+	if !errors.Is(err, errPrepare) {
+		t.Fatalf("expected prepare error, got %v", err)
+	}
+	lg, err := n1.store.GetLog(info.ID, lid)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(info3.Logs) != 2 {
-		t.Fatalf("expected 2 logs got %d", len(info3.Logs))
-	}
-	if len(info3.Addrs) == 0 {
-		t.Fatalf("expected more than 0 addresses got %d", len(info3.Addrs))
+	if !lg.Head.Equals(head) || len(lg.Heads) != 1 {
+		t.Fatalf("expected log head %s, got %v", head, lg.Heads)
 	}
 }
 
-func TestNet_CreateThreadManaged(t *testing.T) {
+func TestNet_CreateRecordsAcross(t *testing.T) {
 	t.Parallel()
-	n := makeNetwork(t)
+	n := makeNetwork(t).(*net)
 	defer n.Close()
 
 	ctx := context.Background()
-	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	index := createThread(t, ctx, n)
+	data := createThread(t, ctx, n)
+	var bodies []format.Node
+	for i := 0; i < 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, body)
+	}
+	if err := n.SetThreadQuota(ctx, data.ID, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err := n.CreateRecordsAcross(ctx, []app.ThreadWrite{
+		{ID: index.ID, Bodies: bodies[:1]},
+		{ID: data.ID, Bodies: bodies},
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if len(recs) != 2 || len(recs[0]) != 1 || len(recs[1]) != len(bodies) {
+		t.Fatalf("expected 1 and %d records, got %v", len(bodies), recs)
+	}
+	heads := make(map[thread.ID]thread.LogInfo)
+	for i, id := range []thread.ID{index.ID, data.ID} {
+		last := recs[i][len(recs[i])-1]
+		lg, err := n.store.GetLog(id, last.LogID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !lg.Head.Equals(last.Value().Cid()) {
+			t.Fatalf("expected head %s of thread %s, got %s", last.Value().Cid(), id, lg.Head)
+		}
+		heads[id] = lg
+	}
+	// records are charged against the quotas like those created one thread at a time
+	stats, err := n.ThreadStats(ctx, data.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Should work if trying to re-create thread with different private key
-	_, err = n.CreateThread(ctx, info.ID, core.WithLogKey(sk), core.WithThreadKey(info.Key))
+	if stats.QuotaUsed == 0 || stats.QuotaUsed != stats.Bytes {
+		t.Fatalf("expected records to be charged, got %+v", stats)
+	}
+
+	// a failure in one thread leaves the logs of all threads untouched
+	large, err := cbornode.WrapObject(map[string]interface{}{"msg": strings.Repeat("yo! ", 1000)}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Should fail if trying to re-create thread with wrong (default created) read/service keys
-	_, err = n.CreateThread(ctx, info.ID)
-	if err == nil {
-		t.Fatalf("expected to fail when using wrong thread key(s)")
+	n.maxRecordBodySize = 1024
+	if _, err = n.CreateRecordsAcross(ctx, []app.ThreadWrite{
+		{ID: index.ID, Bodies: bodies[:1]},
+		{ID: data.ID, Bodies: []format.Node{large}},
+	}); err == nil {
+		t.Fatal("expected oversized body to fail the transaction")
 	}
-	// Should work if only going to 'manage' re-created thread/log
-	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if _, err = n.CreateRecordsAcross(ctx, []app.ThreadWrite{
+		{ID: index.ID, Bodies: bodies[:1]},
+		{ID: index.ID, Bodies: bodies[:1]},
+	}); err == nil {
+		t.Fatal("expected thread written twice to fail the transaction")
+	}
+	// as do heads which were already advanced
+	ts, err := n.lockThread(index.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = n.CreateThread(ctx, info.ID, core.WithLogKey(pk), core.WithThreadKey(info.Key))
+	chain, err := n.newRecordChain(ctx, index.ID, bodies[:1], thread.NewLibp2pPubKey(n.getPrivKey().GetPublic()), nil)
+	if err == nil {
+		err = n.store.SetHeads(index.ID, chain.lid, chain.nextHeads())
+	}
+	if err == nil {
+		n.rollbackHeads([]app.ThreadWrite{{ID: index.ID}}, []*recordChain{chain})
+		chain.release()
+	}
+	ts.Release()
 	if err != nil {
 		t.Fatal(err)
 	}
+	for id, prev := range heads {
+		lg, err := n.store.GetLog(id, prev.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !lg.Head.Equals(prev.Head) || len(lg.Heads) != 1 {
+			t.Fatalf("expected log head %s of thread %s, got %v", prev.Head, id, lg.Heads)
+		}
+	}
 }
 
-func TestNet_AddThreadManaged(t *testing.T) {
+func TestNet_AwaitRecord(t *testing.T) {
 	t.Parallel()
-	n1 := makeNetwork(t)
+	n1 := makeNetwork(t).(*net)
 	defer n1.Close()
-	n2 := makeNetwork(t)
+	n2 := makeNetwork(t).(*net)
 	defer n2.Close()
 
-	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
 	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
 
 	ctx := context.Background()
-	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	r, err := n1.CreateRecord(ctx, info.ID, body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+
+	// known records are returned right away
+	rec, err := n1.AwaitRecord(ctx, info.ID, r.Value().Cid())
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key))
-	if err != nil {
-		t.Fatal(err)
+	if !rec.Cid().Equals(r.Value().Cid()) {
+		t.Fatal("awaited record does not equal created record")
 	}
-	// Should work if trying to re-create thread with different private key
-	_, err = n2.AddThread(ctx, addr, core.WithLogKey(sk), core.WithThreadKey(info.Key))
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Should fail if trying to re-create thread with wrong/missing read/service keys
-	_, err = n2.AddThread(ctx, addr)
-	if err == nil {
-		t.Fatalf("expected to fail when using wrong thread key(s)")
-	}
-	// Should work if only going to 'manage' re-created thread/log
-	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
-	if err != nil {
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
 		t.Fatal(err)
 	}
-	_, err = n2.AddThread(ctx, addr, core.WithLogKey(pk), core.WithThreadKey(info.Key))
+
+	// the record may already reach n2 over pubsub, so a node which is no record is awaited instead
+	unknown, err := cbornode.WrapObject(map[string]interface{}{"foo": "baz"}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Should work if trying to add new managed log to 'self' (note we're using n1 here)
-	sk, pk, err = crypto.GenerateEd25519Key(rand.Reader)
-	if err != nil {
-		t.Fatal(err)
+	actx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err = n2.AwaitRecord(actx, info.ID, unknown.Cid()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline to be exceeded, got %v", err)
 	}
-	_, err = n1.AddThread(ctx, addr, core.WithLogKey(pk), core.WithThreadKey(info.Key))
-	if err != nil {
+
+	done := make(chan error, 1)
+	go func() {
+		rec, err := n2.AwaitRecord(ctx, info.ID, r.Value().Cid())
+		if err == nil && !rec.Cid().Equals(r.Value().Cid()) {
+			err = fmt.Errorf("awaited record does not equal created record")
+		}
+		done <- err
+	}()
+	if err = n2.PullThread(ctx, info.ID); err != nil {
 		t.Fatal(err)
 	}
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out awaiting pulled record")
+	}
 }
 
-func TestNet_AddReplicator(t *testing.T) {
+func TestNet_ListIdentities(t *testing.T) {
 	t.Parallel()
-	n1 := makeNetwork(t)
+	n1 := makeNetwork(t).(*net)
 	defer n1.Close()
-	n2 := makeNetwork(t)
+	n2 := makeNetwork(t).(*net)
 	defer n2.Close()
 
-	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
 	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
 
 	ctx := context.Background()
 	info := createThread(t, ctx, n1)
-
-	body, err := cbornode.WrapObject(map[string]interface{}{
-		"msg": "yo!",
-	}, mh.SHA2_256, -1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := n1.CreateRecord(ctx, info.ID, body); err != nil {
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
 		t.Fatal(err)
 	}
+	identity := thread.NewLibp2pPubKey(n1.getPrivKey().GetPublic())
 
-	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
 		t.Fatal(err)
 	}
-
-	info2, err := n1.GetThread(context.Background(), info.ID)
-	if err != nil {
+	if err = n2.PullThread(ctx, info.ID); err != nil {
 		t.Fatal(err)
 	}
-	if len(info2.Logs) != 1 {
-		t.Fatalf("expected 1 log got %d", len(info2.Logs))
-	}
-	if len(info2.Logs[0].Addrs) != 2 {
-		t.Fatalf("expected 2 addresses got %d", len(info2.Logs[0].Addrs))
-	}
 
-	info3, err := n2.GetThread(context.Background(), info.ID)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(info3.Logs) != 1 {
-		t.Fatalf("expected 1 log got %d", len(info2.Logs))
+	// the owner resolves the log with the identity mapping, other peers with the head record
+	for _, n := range []*net{n1, n2} {
+		identities, err := n.ListIdentities(ctx, info.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ident, ok := identities[r.LogID()]; !ok || !ident.Equals(identity) {
+			t.Fatalf("expected log %s to be written by %s", r.LogID(), identity)
+		}
+		lg, err := n.GetLogForIdentity(ctx, info.ID, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lg.ID != r.LogID() {
+			t.Fatalf("expected log %s, got %s", r.LogID(), lg.ID)
+		}
 	}
-	if len(info3.Logs[0].Addrs) != 2 {
-		t.Fatalf("expected 2 addresses got %d", len(info3.Logs[0].Addrs))
+
+	// the log created by n2 for the thread has no records yet
+	other := thread.NewLibp2pPubKey(n2.getPrivKey().GetPublic())
+	if _, err = n1.GetLogForIdentity(ctx, info.ID, other); !errors.Is(err, logstore.ErrLogNotFound) {
+		t.Fatalf("expected ErrLogNotFound, got %v", err)
 	}
 }
 
-func TestNet_AddReplicatorManaged(t *testing.T) {
+func TestNet_CreateRecordAsync(t *testing.T) {
 	t.Parallel()
 	n1 := makeNetwork(t)
 	defer n1.Close()
@@ -331,133 +543,5906 @@ func TestNet_AddReplicatorManaged(t *testing.T) {
 	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
 	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
 
-	// Create managed thread
-	tid := thread.NewIDV1(thread.Raw, 32)
 	ctx := context.Background()
-	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
 	if err != nil {
 		t.Fatal(err)
 	}
-	info, err := n1.CreateThread(ctx, tid, core.WithLogKey(pk))
-	if err != nil {
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
 		t.Fatal(err)
 	}
-
-	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+	// let n1 learn about the log of n2
+	if _, err = n2.CreateRecord(ctx, info.ID, body); err != nil {
 		t.Fatal(err)
 	}
+	time.Sleep(time.Second)
 
-	info2, err := n1.GetThread(context.Background(), info.ID)
+	f, err := n1.CreateRecordAsync(ctx, info.ID, body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(info2.Logs) != 1 {
-		t.Fatalf("expected 1 log got %d", len(info2.Logs))
-	}
-	if len(info2.Logs[0].Addrs) != 2 {
-		t.Fatalf("expected 2 addresses got %d", len(info2.Logs[0].Addrs))
+	// the record is stored before the push finishes
+	if _, err = n1.GetRecord(ctx, info.ID, f.Record().Value().Cid()); err != nil {
+		t.Fatal(err)
 	}
 
-	info3, err := n2.GetThread(context.Background(), info.ID)
+	wctx, cancel := context.WithTimeout(ctx, PushTimeout)
+	defer cancel()
+	acks, err := f.Wait(wctx)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(info3.Logs) != 1 {
-		t.Fatalf("expected 1 log got %d", len(info2.Logs))
+	if ackErr, ok := acks[n2.Host().ID()]; !ok || ackErr != nil {
+		t.Fatalf("expected push to be acknowledged by peer, got %v", acks)
 	}
-	if len(info3.Logs[0].Addrs) != 2 {
-		t.Fatalf("expected 2 addresses got %d", len(info3.Logs[0].Addrs))
+	select {
+	case <-f.Done():
+	default:
+		t.Fatal("expected future to be done")
 	}
-
-	// Should be able to add self as replicator
-	if _, err = n2.AddReplicator(ctx, info.ID, addr); err != nil {
+	if _, err = n2.GetRecord(ctx, info.ID, f.Record().Value().Cid()); err != nil {
 		t.Fatal(err)
 	}
 }
 
-func TestNet_DeleteThread(t *testing.T) {
+func TestNet_WriteQuorum(t *testing.T) {
 	t.Parallel()
-	n := makeNetwork(t)
-	defer n.Close()
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{TrackAcks: true})
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
 
 	ctx := context.Background()
-	info := createThread(t, ctx, n)
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// let n1 learn about the log of n2
+	if _, err = n2.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	t.Run("test unreachable quorum", func(t *testing.T) {
+		if _, err := n1.CreateRecord(ctx, info.ID, body, core.WithWriteQuorum(2)); !errors.Is(err, ErrWriteQuorum) {
+			t.Fatalf("expected write quorum error, got %v", err)
+		}
+	})
+
+	t.Run("test acknowledged write", func(t *testing.T) {
+		wctx, cancel := context.WithTimeout(ctx, PushTimeout)
+		defer cancel()
+		tr, err := n1.CreateRecord(wctx, info.ID, body, core.WithWriteQuorum(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = n2.GetRecord(ctx, info.ID, tr.Value().Cid()); err != nil {
+			t.Fatal(err)
+		}
+		st, err := n1.RecordReplicationStatus(ctx, info.ID, tr.Value().Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := st.Acked[n2.Host().ID()]; !ok || len(st.Pending) != 0 {
+			t.Fatalf("expected record to be acknowledged by peer, got %+v", st)
+		}
+	})
+
+	t.Run("test untracked acks", func(t *testing.T) {
+		wctx, cancel := context.WithTimeout(ctx, PushTimeout)
+		defer cancel()
+		tr, err := n2.CreateRecord(wctx, info.ID, body, core.WithWriteQuorum(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = n2.RecordReplicationStatus(ctx, info.ID, tr.Value().Cid()); !errors.Is(err, ErrAcksNotTracked) {
+			t.Fatalf("expected acks not to be tracked, got %v", err)
+		}
+		if acks, err := n2.(*net).acks.Acks(info.ID, tr.Value().Cid()); err != nil || len(acks) != 0 {
+			t.Fatalf("expected acks to be dropped once the quorum is reached, got %v (%v)", acks, err)
+		}
+	})
+}
+
+func TestNet_RecordSource(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := n1.Subscribe(ctx, core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
 	body, err := cbornode.WrapObject(map[string]interface{}{
-		"foo": "bar",
-		"baz": []byte("howdy"),
+		"msg": "yo!",
 	}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+
+	t.Run("test local record", func(t *testing.T) {
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src := r.Source(); src.Kind != core.SourceLocal || src.ReceivedAt.IsZero() {
+			t.Fatalf("expected local record, got %+v", src)
+		}
+		rec := <-sub
+		if src := rec.Source(); src.Kind != core.SourceLocal || src.Peer != "" {
+			t.Fatalf("expected subscribed record to be local, got %+v", src)
+		}
+	})
+
+	t.Run("test peer record", func(t *testing.T) {
+		// let n1 learn about the log of n2, the first record may be pulled along with the log
+		if _, err := n2.CreateRecord(ctx, info.ID, body); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-sub:
+		case <-time.After(PushTimeout):
+			t.Fatal("expected record from peer")
+		}
+
+		r, err := n2.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case rec := <-sub:
+			if !rec.Value().Cid().Equals(r.Value().Cid()) {
+				t.Fatalf("expected record %s, got %s", r.Value().Cid(), rec.Value().Cid())
+			}
+			src := rec.Source()
+			if src.Kind != core.SourcePush && src.Kind != core.SourcePubSub {
+				t.Fatalf("expected pushed record, got %s", src.Kind)
+			}
+			if src.Peer != n2.Host().ID() || src.ReceivedAt.IsZero() {
+				t.Fatalf("expected record received from %s, got %+v", n2.Host().ID(), src)
+			}
+		case <-time.After(PushTimeout):
+			t.Fatal("expected record from peer")
+		}
+	})
+}
+
+func TestNet_CommitHooks(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	errForbidden := errors.New("forbidden body")
+	n.(*net).commitHooks = []core.CommitHook{
+		func(_ context.Context, id thread.ID, body format.Node, author thread.PubKey) error {
+			if !id.Equals(info.ID) || author == nil {
+				return errors.New("unexpected hook arguments")
+			}
+			if _, _, err := body.Resolve([]string{"forbidden"}); err == nil {
+				return errForbidden
+			}
+			return nil
+		},
+	}
+
+	allowed, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+	if _, err = n.CreateRecord(ctx, info.ID, allowed); err != nil {
 		t.Fatal(err)
 	}
 
-	if err = n.DeleteThread(ctx, info.ID); err != nil {
+	vetoed, err := cbornode.WrapObject(map[string]interface{}{"forbidden": true}, mh.SHA2_256, -1)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := n.GetThread(ctx, info.ID); err != logstore.ErrThreadNotFound {
-		t.Fatal("thread was not deleted")
+	if _, err = n.CreateRecord(ctx, info.ID, vetoed); !errors.Is(err, errForbidden) {
+		t.Fatalf("expected record to be vetoed, got %v", err)
+	}
+	if _, err = n.CreateRecords(ctx, info.ID, []format.Node{allowed, vetoed}); !errors.Is(err, errForbidden) {
+		t.Fatalf("expected records to be vetoed, got %v", err)
+	}
+
+	// vetoed writes don't touch the log
+	lg, err := n.(*net).getOrCreateLog(info.ID, thread.NewLibp2pPubKey(n.(*net).getPrivKey().GetPublic()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := n.GetRecord(ctx, info.ID, lg.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.PrevID().Defined() {
+		t.Fatal("expected a single record in the log")
 	}
 }
 
-func TestClose(t *testing.T) {
+func TestNet_PersistHooks(t *testing.T) {
 	t.Parallel()
-	n := makeNetwork(t)
+	var (
+		lock     sync.Mutex
+		events   []string
+		reject   = map[string]bool{}
+		errAudit = errors.New("rejected by audit")
+	)
+	hooks := func(name string) core.PersistHooks {
+		return core.PersistHooks{
+			OnBeforePersist: func(_ context.Context, rec core.ThreadRecord) error {
+				lock.Lock()
+				defer lock.Unlock()
+				events = append(events, name+" before "+rec.Value().Cid().String())
+				if reject[name] {
+					return errAudit
+				}
+				return nil
+			},
+			OnAfterPersist: func(_ context.Context, rec core.ThreadRecord) {
+				lock.Lock()
+				defer lock.Unlock()
+				events = append(events, name+" after "+rec.Value().Cid().String())
+			},
+			OnBroadcast: func(_ context.Context, rec core.ThreadRecord, peers []peer.ID) {
+				lock.Lock()
+				defer lock.Unlock()
+				events = append(events, fmt.Sprintf("%s broadcast %s %d", name, rec.Value().Cid(), len(peers)))
+			},
+		}
+	}
+	setReject := func(name string, r bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		reject[name] = r
+	}
+	popEvents := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		e := events
+		events = nil
+		return e
+	}
+	awaitEvents := func(count int) []string {
+		for deadline := time.Now().Add(10 * time.Second); ; {
+			lock.Lock()
+			got := len(events)
+			lock.Unlock()
+			if got >= count {
+				return popEvents()
+			} else if time.Now().After(deadline) {
+				t.Fatalf("expected %d events, got %v", count, popEvents())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{PersistHooks: []core.PersistHooks{hooks("n1")}})
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{PersistHooks: []core.PersistHooks{hooks("n2")}})
+	defer n2.Close()
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// n1 learns about n2 from its log
+	if _, err = n2.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	awaitEvents(5)
+
+	// the record pushed to n2 is rejected by its hook
+	setReject("n2", true)
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rid := r.Value().Cid().String()
+	expected := []string{"n1 before " + rid, "n1 after " + rid, "n1 broadcast " + rid + " 1", "n2 before " + rid}
+	if got := awaitEvents(len(expected)); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected events %v, got %v", expected, got)
+	}
+	if known, err := n2.(*net).isKnown(r.Value().Cid()); err != nil || known {
+		t.Fatalf("expected rejected record not to be persisted: %v", err)
+	}
+
+	// rejected records are processed again once received
+	setReject("n2", false)
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := popEvents(); !reflect.DeepEqual(got, []string{"n2 before " + rid, "n2 after " + rid}) {
+		t.Fatalf("unexpected events %v", got)
+	}
+	if known, err := n2.(*net).isKnown(r.Value().Cid()); err != nil || !known {
+		t.Fatalf("expected pulled record to be persisted: %v", err)
+	}
+
+	// local records rejected by a hook are not added to the log
+	setReject("n1", true)
+	if _, err = n1.CreateRecord(ctx, info.ID, body); !errors.Is(err, errAudit) {
+		t.Fatalf("expected record to be rejected, got %v", err)
+	}
+	lg, err := n1.(*net).store.GetLog(info.ID, r.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lg.Head.Equals(r.Value().Cid()) {
+		t.Fatalf("expected head %s, got %s", r.Value().Cid(), lg.Head)
+	}
+
+	// so are records of transactions
+	write := []app.ThreadWrite{{ID: info.ID, Bodies: []format.Node{body}}}
+	if _, err = n1.(*net).CreateRecordsAcross(ctx, write); !errors.Is(err, errAudit) {
+		t.Fatalf("expected transaction to be rejected, got %v", err)
+	}
+	if lg, err = n1.(*net).store.GetLog(info.ID, r.LogID()); err != nil {
+		t.Fatal(err)
+	}
+	if !lg.Head.Equals(r.Value().Cid()) {
+		t.Fatalf("expected head %s, got %s", r.Value().Cid(), lg.Head)
+	}
+	setReject("n1", false)
+	popEvents()
+	trs, err := n1.(*net).CreateRecordsAcross(ctx, write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rid = trs[0][0].Value().Cid().String()
+	if got := awaitEvents(2); got[0] != "n1 before "+rid || got[1] != "n1 after "+rid {
+		t.Fatalf("expected transaction records to run the hooks, got %v", got)
+	}
+}
+
+func TestNet_SignedLogAddrs(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
 	defer n.Close()
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
 
-	t.Run("test close", func(t *testing.T) {
-		if err := n.Close(); err != nil {
+	// own logs are signed on the fly
+	own, err := n.getOrCreateLog(info.ID, thread.NewLibp2pPubKey(n.getPrivKey().GetPublic()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pblg, err := n.signedLogToProto(ctx, info.ID, own)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := own.PubKey.Verify(logAddrsPayload(info.ID, own.ID, pblg.AddrsSeq, own.Addrs), pblg.AddrsSig); err != nil || !ok {
+		t.Fatal("expected own log addresses to be signed")
+	}
+
+	sk, pk, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lid, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := func(seq uint64, addrs ...string) peerLog {
+		lg := peerLog{LogInfo: thread.LogInfo{ID: lid, PubKey: pk}, addrsSeq: seq}
+		for _, a := range addrs {
+			lg.Addrs = append(lg.Addrs, util.MustParseAddr(a))
+		}
+		if lg.addrsSig, err = sk.Sign(logAddrsPayload(info.ID, lid, seq, lg.Addrs)); err != nil {
 			t.Fatal(err)
 		}
-	})
+		return lg
+	}
+	checkAddrs := func(expected ...string) {
+		addrs, err := n.store.Addrs(info.ID, lid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(addrs) != len(expected) {
+			t.Fatalf("expected addresses %v, got %v", expected, addrs)
+		}
+		for i, a := range expected {
+			if !addrs[i].Equal(util.MustParseAddr(a)) {
+				t.Fatalf("expected addresses %v, got %v", expected, addrs)
+			}
+		}
+	}
+
+	owner := "/ip4/1.1.1.1/tcp/4006"
+	if err = n.createExternalLogsIfNotExist(info.ID, []peerLog{signed(2, owner)}); err != nil {
+		t.Fatal(err)
+	}
+	head, err := cbornode.WrapObject(map[string]interface{}{"head": true}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.store.SetHeads(info.ID, lid, []cid.Cid{head.Cid()}); err != nil {
+		t.Fatal(err)
+	}
+	checkAddrs(owner)
+
+	// unsigned and stale address sets are ignored
+	attacker := "/ip4/6.6.6.6/tcp/4006"
+	unsigned := peerLog{LogInfo: thread.LogInfo{ID: lid, PubKey: pk, Addrs: []ma.Multiaddr{util.MustParseAddr(attacker)}}}
+	if err = n.createExternalLogsIfNotExist(info.ID, []peerLog{unsigned, signed(1, attacker)}); err != nil {
+		t.Fatal(err)
+	}
+	checkAddrs(owner)
+
+	// forged signatures are rejected
+	forged := signed(3, owner)
+	forged.Addrs = unsigned.Addrs
+	if err = n.createExternalLogsIfNotExist(info.ID, []peerLog{forged}); !errors.Is(err, ErrInvalidAddrsSig) {
+		t.Fatalf("expected invalid signature error, got %v", err)
+	}
+	checkAddrs(owner)
+
+	// newer signed sets replace the known addresses
+	moved := "/ip4/2.2.2.2/tcp/4006"
+	if err = n.createExternalLogsIfNotExist(info.ID, []peerLog{signed(3, moved)}); err != nil {
+		t.Fatal(err)
+	}
+	checkAddrs(moved)
+
+	// the signed set is forwarded to other peers as is
+	lg, err := n.store.GetLog(info.ID, lid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pblg, err = n.signedLogToProto(ctx, info.ID, lg); err != nil {
+		t.Fatal(err)
+	}
+	if pblg.AddrsSeq != 3 || !bytes.Equal(pblg.AddrsSig, signed(3, moved).addrsSig) {
+		t.Fatal("expected the owner's signed addresses to be forwarded")
+	}
 }
 
-func makeNetwork(t *testing.T) core.Net {
-	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+func TestNet_GC(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := n.CreateRecord(ctx, info.ID, body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	addr := util.MustParseAddr("/ip4/127.0.0.1/tcp/0")
 
-	host, err := libp2p.New(
-		context.Background(),
-		libp2p.ListenAddrs(addr),
-		libp2p.Identity(sk),
-	)
+	// an event left behind by a record which was never processed
+	orphan, err := cbornode.WrapObject(map[string]interface{}{"foo": "baz"}, mh.SHA2_256, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
-	bs := bstore.NewBlockstore(syncds.MutexWrap(ds.NewMapDatastore()))
-	bsrv := bserv.New(bs, offline.Exchange(bs))
-	n, err := NewNetwork(
-		context.Background(),
-		host,
-		bsrv.Blockstore(),
-		dag.NewDAGService(bsrv),
-		tstore.NewLogstore(),
-		Config{
-			Debug:  true,
-			PubSub: true,
-		}, nil, nil)
+	event, err := cbor.CreateEvent(ctx, n, orphan, info.Key.Read())
 	if err != nil {
 		t.Fatal(err)
 	}
-	return n
-}
+	// the envelope of a record on a dropped branch of the log
+	lg, err := n.(*net).getOrCreateLog(info.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := cbor.CreateRecord(ctx, n, cbor.CreateRecordConfig{
+		Block:      event,
+		Prev:       tr.Value().Cid(),
+		Key:        lg.PrivKey,
+		PubKey:     thread.NewLibp2pPubKey(n.Host().Peerstore().PrivKey(n.Host().ID()).GetPublic()),
+		ServiceKey: info.Key.Service(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.Add(ctx, envelope); err != nil {
+		t.Fatal(err)
+	}
 
-func createThread(t *testing.T, ctx context.Context, api core.API) thread.Info {
-	info, err := api.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	swept, err := n.GC(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
-	return info
+	if swept != 4 {
+		t.Fatalf("expected 4 swept blocks, got %d", swept)
+	}
+	bs := n.(*net).bstore
+	for _, id := range []cid.Cid{envelope.Cid(), event.Cid(), event.HeaderID(), event.BodyID()} {
+		if has, err := bs.Has(id); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatalf("expected orphaned block %s to be swept", id)
+		}
+	}
+
+	// live records are kept
+	if _, err = n.GetRecord(ctx, info.ID, tr.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+	ev, err := cbor.EventFromRecord(ctx, n, tr.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ev.GetBody(ctx, n, info.Key.Read()); err != nil {
+		t.Fatal(err)
+	}
+	if swept, err = n.GC(ctx); err != nil {
+		t.Fatal(err)
+	} else if swept != 0 {
+		t.Fatalf("expected nothing to be swept, got %d", swept)
+	}
+}
+
+func TestNet_SharedBlocks(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{SharedBlocks: true}).(*net)
+	defer n.Close()
+	ctx := context.Background()
+
+	// threads with the same key share an event
+	info1 := createThread(t, ctx, n)
+	info2, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithThreadKey(info1.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.CreateEvent(ctx, n, body, info1.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// records are created aside, so they're added as unknown records
+	bs := bstore.NewBlockstore(syncds.MutexWrap(ds.NewMapDatastore()))
+	aside := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+	identity := thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	recs := make(map[thread.ID]core.Record)
+	for _, info := range []thread.Info{info1, info2} {
+		lg, err := n.getOrCreateLog(info.ID, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := cbor.CreateRecord(ctx, aside, cbor.CreateRecordConfig{
+			Block:      event,
+			Prev:       lg.Head,
+			Key:        lg.PrivKey,
+			PubKey:     identity,
+			ServiceKey: info.Key.Service(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = n.AddRecord(ctx, info.ID, lg.ID, rec); err != nil {
+			t.Fatal(err)
+		}
+		recs[info.ID] = rec
+	}
+
+	stats, err := n.BlockStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := core.BlockStats{Threads: 2, Events: 1, Bodies: 1, SharedBodies: 1, SharedThreads: 2, DuplicateRefs: 1}
+	if stats != expected {
+		t.Fatalf("expected stats %+v, got %+v", expected, stats)
+	}
+
+	// blocks referenced by the other thread survive its deletion
+	if err = n.DeleteThread(ctx, info2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.GetRecord(ctx, info1.ID, recs[info1.ID].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []cid.Cid{event.Cid(), event.HeaderID(), event.BodyID()} {
+		if has, err := n.bstore.Has(id); err != nil {
+			t.Fatal(err)
+		} else if !has {
+			t.Fatalf("expected shared block %s to be kept", id)
+		}
+	}
+	if has, err := n.bstore.Has(recs[info2.ID].Cid()); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatal("expected record of the deleted thread to be removed")
+	}
+	if stats, err = n.BlockStats(ctx); err != nil {
+		t.Fatal(err)
+	} else if stats.SharedBodies != 0 || stats.Bodies != 1 {
+		t.Fatalf("expected no shared bodies, got %+v", stats)
+	}
+
+	// the last reference is gone with the other thread
+	if err = n.DeleteThread(ctx, info1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := n.bstore.Has(event.BodyID()); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatal("expected unreferenced body to be removed")
+	}
+}
+
+func TestNet_Topics(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	topics, err := n1.Topics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || !topics[0].Equals(info.ID) {
+		t.Fatalf("expected topic of thread %s, got %v", info.ID, topics)
+	}
+
+	// a host started over an existing logstore joins topics of stored threads
+	n2 := makeNetworkWithLogstore(t, n1.(*net).store)
+	defer n2.Close()
+	for i := 0; ; i++ {
+		topics, err := n2.Topics(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(topics) == 1 && topics[0].Equals(info.ID) {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("expected topic of stored thread %s, got %v", info.ID, topics)
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+}
+
+func TestNet_PullPasses(t *testing.T) {
+	t.Parallel()
+	mock := clock.NewMock(time.Now())
+	interval := 100 * time.Millisecond
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		Clock: mock,
+		Sync:  core.SyncConfig{PullInterval: interval, InitialPullInterval: interval},
+	}).(*net)
+	defer n.Close()
+
+	start := mock.Now()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				mock.Add(interval / 10)
+			}
+		}
+	}()
+	// passes over an empty index just wait for the next one
+	waitFor(t, func() bool {
+		return mock.Now().Sub(start) > PullStartAfter+3*interval
+	})
+
+	// threads added meanwhile are visited by the next passes, which span several pages
+	tids := make([]thread.ID, threadCursorPage+5)
+	for i := range tids {
+		tids[i] = addRemoteThread(t, n.store)
+	}
+	deadline := time.Now().Add(20 * time.Second)
+	for _, tid := range tids {
+		for n.pulls.thread(tid).thread.LastError == nil {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected edges of thread %s to be exchanged", tid)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestNet_PullStatus(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"msg": "yo!"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := n2.PullStatus(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 {
+		t.Fatalf("expected status of 2 logs, got %d", len(status))
+	}
+	st, ok := status[tr.LogID()]
+	if !ok {
+		t.Fatalf("expected status of log %s", tr.LogID())
+	}
+	rid := tr.Value().Cid()
+	if !st.LocalHead.Equals(rid) || !st.RemoteHead.Equals(rid) {
+		t.Fatalf("expected local and remote head %s, got %s and %s", rid, st.LocalHead, st.RemoteHead)
+	}
+	if st.RecordsBehind != 0 {
+		t.Fatalf("expected log to be in sync, got %d records behind", st.RecordsBehind)
+	}
+	if st.LastExchange.IsZero() || st.LastError != nil {
+		t.Fatalf("expected a successful pull, got %v at %s", st.LastError, st.LastExchange)
+	}
+}
+
+func TestNet_Migrations(t *testing.T) {
+	t.Parallel()
+	ls := tstore.NewLogstore()
+
+	// a thread stored before own logs were indexed by identity
+	id := thread.NewIDV1(thread.Raw, 32)
+	if err := ls.AddThread(thread.Info{ID: id, Key: thread.NewRandomKey()}); err != nil {
+		t.Fatal(err)
+	}
+	sk, pk, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lid, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ls.AddLog(id, thread.LogInfo{ID: lid, PubKey: pk, PrivKey: sk, Managed: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	n := makeNetworkWithLogstore(t, ls).(*net)
+	defer n.Close()
+
+	// threads are migrated when locked, or in the background
+	ts, err := n.lockThread(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Release()
+	if v, err := ls.GetBytes(id, schemaVersionKey); err != nil {
+		t.Fatal(err)
+	} else if v == nil {
+		t.Fatal("expected thread to be migrated")
+	} else if v, err := decodeSchemaVersion(*v); err != nil || v != len(migrations) {
+		t.Fatalf("expected schema version %d, got %d (%v)", len(migrations), v, err)
+	}
+	lg, err := n.getOrCreateLog(id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lg.ID != lid {
+		t.Fatalf("expected own log %s, got %s", lid, lg.ID)
+	}
+
+	// new threads start at the current version
+	info := createThread(t, context.Background(), n)
+	n.schemaLock.RLock()
+	v, err := n.schemaVersion(info.ID)
+	n.schemaLock.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	} else if v != len(migrations) {
+		t.Fatalf("expected schema version %d, got %d", len(migrations), v)
+	}
+
+	// the version of all threads is saved once they're migrated
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n.schemaLock.RLock()
+		v = n.storedVersion
+		n.schemaLock.RUnlock()
+		if v == len(migrations) {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected stored schema version %d, got %d", len(migrations), v)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNet_AddThread(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info2, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PullThread(ctx, info2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(info2.Logs) != 2 {
+		t.Fatalf("expected 2 logs got %d", len(info2.Logs))
+	}
+	if len(info2.Addrs) == 0 {
+		t.Fatalf("expected more than 0 addresses got %d", len(info2.Addrs))
+	}
+
+	body2, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo back!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.CreateRecord(ctx, info2.ID, body2); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	info3, err := n1.GetThread(context.Background(), info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info3.Logs) != 2 {
+		t.Fatalf("expected 2 logs got %d", len(info3.Logs))
+	}
+	if len(info3.Addrs) == 0 {
+		t.Fatalf("expected more than 0 addresses got %d", len(info3.Addrs))
+	}
+}
+
+func TestNet_BatchThreads(t *testing.T) {
+	t.Parallel()
+	// records are only received when pulled
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true})
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true})
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	createRecords := func(ids []thread.ID) []core.ThreadRecord {
+		recs := make([]core.ThreadRecord, len(ids))
+		for i, id := range ids {
+			body, err := cbornode.WrapObject(map[string]interface{}{
+				"msg": fmt.Sprintf("yo %d!", i),
+			}, mh.SHA2_256, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recs[i], err = n1.CreateRecord(ctx, id, body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return recs
+	}
+	hasRecords := func(ids []thread.ID, recs []core.ThreadRecord) bool {
+		for i, id := range ids {
+			if _, err := n2.GetRecord(ctx, id, recs[i].Value().Cid()); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	var (
+		addrs []ma.Multiaddr
+		ids   []thread.ID
+		keys  = make(map[thread.ID]thread.Key)
+	)
+	for i := 0; i < 3; i++ {
+		info := createThread(t, ctx, n1)
+		addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, addr)
+		ids = append(ids, info.ID)
+		keys[info.ID] = info.Key
+	}
+	recs := createRecords(ids)
+
+	infos, err := n2.AddThreads(ctx, addrs, core.WithThreadKeys(keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != len(ids) {
+		t.Fatalf("expected %d threads got %d", len(ids), len(infos))
+	}
+	for i, info := range infos {
+		if !info.ID.Equals(ids[i]) {
+			t.Fatalf("expected thread %s got %s", ids[i], info.ID)
+		}
+		if len(info.Logs) != 2 {
+			t.Fatalf("expected 2 logs got %d", len(info.Logs))
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			// following records are found by the edge exchange
+			recs = createRecords(ids)
+		}
+		if err := n2.PullThreads(ctx, ids); err != nil {
+			t.Fatal(err)
+		}
+		if !hasRecords(ids, recs) {
+			t.Fatal("expected records to be pulled")
+		}
+	}
+
+	if _, err := n2.AddThreads(ctx, addrs[:1]); err == nil {
+		t.Fatal("adding a thread without its key should fail")
+	}
+}
+
+func TestNet_SingleWriter(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithSingleWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Logs) != 1 {
+		t.Fatalf("expected 1 log got %d", len(info.Logs))
+	}
+	writer := info.Logs[0].ID
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	// other identities can't create logs
+	sk, pk, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.(*net).getOrCreateLog(info.ID, thread.NewLibp2pPubKey(pk)); !errors.Is(err, ErrNotThreadWriter) {
+		t.Fatalf("expected not thread writer error, got %v", err)
+	}
+
+	// external logs of other writers are skipped
+	lid, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.(*net).createExternalLogsIfNotExist(info.ID, []peerLog{{LogInfo: thread.LogInfo{ID: lid, PubKey: pk}}}); err != nil {
+		t.Fatal(err)
+	}
+	info1, err := n1.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info1.Logs) != 1 {
+		t.Fatalf("expected 1 log got %d", len(info1.Logs))
+	}
+
+	// readers only replicate the writer log
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key), core.WithThreadWriter(writer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PullThread(ctx, info2.ID); err != nil {
+		t.Fatal(err)
+	}
+	info2, err = n2.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info2.Logs) != 1 || info2.Logs[0].ID != writer {
+		t.Fatalf("expected only the writer log, got %d logs", len(info2.Logs))
+	}
+	if !info2.Logs[0].Head.Defined() {
+		t.Fatal("expected writer log to be pulled")
+	}
+	if _, err = n2.CreateRecord(ctx, info.ID, body); !errors.Is(err, ErrNotThreadWriter) {
+		t.Fatalf("expected not thread writer error, got %v", err)
+	}
+}
+
+func TestNet_ThreadFlags(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+	n3 := makeNetwork(t)
+	defer n3.Close()
+
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n3.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	if _, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithThreadFlags("single-writer=yes")); !errors.Is(err, thread.ErrInvalidFlags) {
+		t.Fatalf("expected invalid flags error, got %v", err)
+	}
+	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithSingleWriter(), core.WithThreadFlags("archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Flags.Has("archive") || !info.Flags.Has(thread.FlagSingleWriter) {
+		t.Fatalf("expected archive single-writer flags, got %s", info.Flags)
+	}
+
+	// peers adding the thread receive its flags
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info2.Flags.Equal(info.Flags) {
+		t.Fatalf("expected flags %s, got %s", info.Flags, info2.Flags)
+	}
+	// the signer of single-writer flags is the writer of joiners too
+	if writer, err := n2.(*net).threadWriter(info.ID); err != nil || writer != info.Logs[0].ID {
+		t.Fatalf("expected writer %s, got %s (%v)", info.Logs[0].ID, writer, err)
+	}
+
+	// joining with other flags than expected fails
+	if _, err = n3.AddThread(ctx, addr, core.WithThreadKey(info.Key), core.WithThreadFlags("other")); !errors.Is(err, ErrFlagsMismatch) {
+		t.Fatalf("expected flags mismatch error, got %v", err)
+	}
+
+	// flags signed by others than the signer are refused, unsigned flags are ignored
+	sig, err := n1.(*net).threadFlagsSig(info.ID)
+	if err != nil || sig == nil {
+		t.Fatalf("expected signed flags (%v)", err)
+	}
+	other := thread.NewIDV1(thread.Raw, 32)
+	if err = n3.(*net).mergeThreadFlags(other, info.Flags, sig); !errors.Is(err, ErrInvalidFlagsSig) {
+		t.Fatalf("expected bad flags signature error, got %v", err)
+	}
+	if err = n3.(*net).mergeThreadFlags(other, info.Flags, nil); err != nil {
+		t.Fatal(err)
+	}
+	if flags, err := n3.(*net).threadFlags(other); err != nil || len(flags) != 0 {
+		t.Fatalf("expected unsigned flags to be ignored, got %s (%v)", flags, err)
+	}
+}
+
+func TestNet_Invites(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+	n3 := makeNetwork(t)
+	defer n3.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	// invites carry the inviter addresses, so peers don't need to know each other
+	recipient := thread.NewLibp2pPubKey(n2.Host().Peerstore().PubKey(n2.Host().ID()))
+	invite, err := n1.CreateInvite(ctx, info.ID, core.WithInviteRecipient(recipient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n3.AcceptInvite(ctx, invite); err == nil {
+		t.Fatal("expected accepting an invite encrypted for another peer to fail")
+	}
+
+	invite, err = n1.CreateInvite(ctx, info.ID, core.WithSingleUseInvite(), core.WithInviteRecipient(recipient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2, err := n2.AcceptInvite(ctx, invite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info2.Key.CanRead() {
+		t.Fatal("expected members to get the read key")
+	}
+	if len(info2.Logs) != 2 {
+		t.Fatalf("expected 2 logs got %d", len(info2.Logs))
+	}
+	if _, err = n2.AcceptInvite(ctx, invite); !errors.Is(err, core.ErrInviteRedeemed) {
+		t.Fatalf("expected invite to be redeemed, got %v", err)
+	}
+
+	// replicators only get the service key
+	invite, err = n1.CreateInvite(ctx, info.ID, core.WithInviteRole(core.InviteReplicator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info3, err := n3.AcceptInvite(ctx, invite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info3.Key.CanRead() {
+		t.Fatal("expected replicators not to get the read key")
+	}
+
+	// tampered invites are rejected
+	if _, err = n3.AcceptInvite(ctx, invite[:len(invite)-4]+"aaaa"); !errors.Is(err, core.ErrInvalidInvite) {
+		t.Fatalf("expected invalid invite error, got %v", err)
+	}
+}
+
+func TestNet_CreateThreadManaged(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	ctx := context.Background()
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Should work if trying to re-create thread with different private key
+	_, err = n.CreateThread(ctx, info.ID, core.WithLogKey(sk), core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Should fail if trying to re-create thread with wrong (default created) read/service keys
+	_, err = n.CreateThread(ctx, info.ID)
+	if err == nil {
+		t.Fatalf("expected to fail when using wrong thread key(s)")
+	}
+	// Should work if only going to 'manage' re-created thread/log
+	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = n.CreateThread(ctx, info.ID, core.WithLogKey(pk), core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_AddThreadManaged(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Should work if trying to re-create thread with different private key
+	_, err = n2.AddThread(ctx, addr, core.WithLogKey(sk), core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Should fail if trying to re-create thread with wrong/missing read/service keys
+	_, err = n2.AddThread(ctx, addr)
+	if err == nil {
+		t.Fatalf("expected to fail when using wrong thread key(s)")
+	}
+	// Should work if only going to 'manage' re-created thread/log
+	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = n2.AddThread(ctx, addr, core.WithLogKey(pk), core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Should work if trying to add new managed log to 'self' (note we're using n1 here)
+	sk, pk, err = crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = n1.AddThread(ctx, addr, core.WithLogKey(pk), core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_AddReplicator(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	info2, err := n1.GetThread(context.Background(), info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info2.Logs) != 1 {
+		t.Fatalf("expected 1 log got %d", len(info2.Logs))
+	}
+	if len(info2.Logs[0].Addrs) != 2 {
+		t.Fatalf("expected 2 addresses got %d", len(info2.Logs[0].Addrs))
+	}
+
+	info3, err := n2.GetThread(context.Background(), info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info3.Logs) != 1 {
+		t.Fatalf("expected 1 log got %d", len(info2.Logs))
+	}
+	if len(info3.Logs[0].Addrs) != 2 {
+		t.Fatalf("expected 2 addresses got %d", len(info3.Logs[0].Addrs))
+	}
+}
+
+func TestNet_AddReplicatorManaged(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	// Create managed thread
+	tid := thread.NewIDV1(thread.Raw, 32)
+	ctx := context.Background()
+	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := n1.CreateThread(ctx, tid, core.WithLogKey(pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	info2, err := n1.GetThread(context.Background(), info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info2.Logs) != 1 {
+		t.Fatalf("expected 1 log got %d", len(info2.Logs))
+	}
+	if len(info2.Logs[0].Addrs) != 2 {
+		t.Fatalf("expected 2 addresses got %d", len(info2.Logs[0].Addrs))
+	}
+
+	info3, err := n2.GetThread(context.Background(), info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info3.Logs) != 1 {
+		t.Fatalf("expected 1 log got %d", len(info2.Logs))
+	}
+	if len(info3.Logs[0].Addrs) != 2 {
+		t.Fatalf("expected 2 addresses got %d", len(info3.Logs[0].Addrs))
+	}
+
+	// Should be able to add self as replicator
+	if _, err = n2.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_RemoveReplicator(t *testing.T) {
+	t.Parallel()
+	var (
+		rotated = make(chan peer.ID, 1)
+		newKey  = sym.New()
+	)
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		KeyRotationHook: func(_ context.Context, _ thread.ID, removed peer.ID) (*sym.Key, error) {
+			rotated <- removed
+			return newKey, nil
+		},
+	}).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+	n3 := makeNetwork(t)
+	defer n3.Close()
+
+	for _, n := range []core.Net{n2, n3} {
+		n1.Host().Peerstore().AddAddrs(n.Host().ID(), n.Host().Addrs(), peerstore.PermanentAddrTTL)
+		n.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	}
+	n2.Host().Peerstore().AddAddrs(n3.Host().ID(), n3.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n3.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	for _, n := range []core.Net{n2, n3} {
+		addr, err := ma.NewMultiaddr("/p2p/" + n.Host().ID().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed := n3.Host().ID()
+	if err := n1.RemoveReplicator(ctx, info.ID, n1.Host().ID()); err == nil {
+		t.Fatal("expected removing the host to fail")
+	}
+	if err := n1.RemoveReplicator(ctx, info.ID, removed); err != nil {
+		t.Fatal(err)
+	}
+
+	// the remaining replicator gets the log without the removed peer
+	for _, n := range []core.Net{n1, n2} {
+		ti, err := n.GetThread(ctx, info.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ti.Logs) != 1 {
+			t.Fatalf("expected 1 log, got %d", len(ti.Logs))
+		}
+		for _, lg := range ti.Logs {
+			if len(lg.Addrs) != 2 {
+				t.Fatalf("expected 2 addresses, got %v", lg.Addrs)
+			}
+			if len(addrsWithoutPeer(lg.Addrs, removed)) != len(lg.Addrs) {
+				t.Fatalf("expected address of %s to be removed, got %v", removed, lg.Addrs)
+			}
+		}
+	}
+
+	// the removed peer isn't pulled anymore
+	_, peers, err := n1.threadOffsets(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range peers {
+		if p == removed {
+			t.Fatal("expected removed replicator not to be pulled")
+		}
+	}
+
+	select {
+	case p := <-rotated:
+		if p != removed {
+			t.Fatalf("expected key rotation for %s, got %s", removed, p)
+		}
+	default:
+		t.Fatal("expected key rotation hook to be called")
+	}
+	if sk, err := n1.store.ServiceKey(info.ID); err != nil || !bytes.Equal(sk.Bytes(), newKey.Bytes()) {
+		t.Fatalf("expected service key to be rotated (%v)", err)
+	}
+
+	// the remaining replicator takes the rotated key, the removed one doesn't learn it
+	nn2, nn3 := n2.(*net), n3.(*net)
+	if sk, err := nn2.store.ServiceKey(info.ID); err != nil || !bytes.Equal(sk.Bytes(), newKey.Bytes()) {
+		t.Fatalf("expected service key of replicator to be rotated (%v)", err)
+	}
+	if epoch, err := nn2.keyEpoch(info.ID); err != nil || epoch != 1 {
+		t.Fatalf("expected key epoch 1, got %d (%v)", epoch, err)
+	}
+	if sk, err := nn3.store.ServiceKey(info.ID); err != nil || bytes.Equal(sk.Bytes(), newKey.Bytes()) {
+		t.Fatalf("expected service key of removed replicator to be kept (%v)", err)
+	}
+
+	// rotations signed by keys other than the thread logs are refused
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := keyRotation{Epoch: 2, ServiceKey: sym.New(), Signer: sk.GetPublic()}
+	payload, err := forged.payload(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forged.Sig, err = sk.Sign(payload); err != nil {
+		t.Fatal(err)
+	}
+	data, err := forged.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = nn2.mergeKeyRotation(info.ID, data); !errors.Is(err, ErrInvalidKeyRotation) {
+		t.Fatalf("expected forged key rotation to be refused, got %v", err)
+	}
+	// replayed rotations don't roll the key back
+	stale, err := n1.keyRotationBytes(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = nn2.putKeyRotation(info.ID, keyRotation{Epoch: 2, ServiceKey: sym.New(), Signer: sk.GetPublic()}); err != nil {
+		t.Fatal(err)
+	}
+	if err = nn2.mergeKeyRotation(info.ID, stale); err != nil {
+		t.Fatal(err)
+	}
+	if sk, err := nn2.store.ServiceKey(info.ID); err != nil || bytes.Equal(sk.Bytes(), newKey.Bytes()) {
+		t.Fatalf("expected stale key rotation to be ignored (%v)", err)
+	}
+}
+
+func TestNet_DeleteThread(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"foo": "bar",
+		"baz": []byte("howdy"),
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = n.DeleteThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.GetThread(ctx, info.ID); err != logstore.ErrThreadNotFound {
+		t.Fatal("thread was not deleted")
+	}
+}
+
+func TestNet_HandoffLog(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a handoff the new owner doesn't acknowledge keeps the key, and is retried
+	n3 := makeNetwork(t)
+	defer n3.Close()
+	n3.Close()
+	n1.Host().Peerstore().AddAddrs(n3.Host().ID(), n3.Host().Addrs(), peerstore.PermanentAddrTTL)
+	if err = n1.HandoffLog(ctx, info.ID, r1.LogID(), n3.Host().ID()); err == nil {
+		t.Fatal("expected handoff to a closed peer to fail")
+	}
+	if sk, err := n1.store.PrivKey(info.ID, r1.LogID()); err != nil || sk == nil {
+		t.Fatalf("expected log key to be kept (%v)", err)
+	}
+	if err = n1.HandoffLog(ctx, info.ID, r1.LogID(), n2.Host().ID()); !errors.Is(err, core.ErrLogHandedOff) {
+		t.Fatalf("expected log sealed for another owner, got %v", err)
+	}
+	if err = n1.store.PutBytes(info.ID, r1.LogID().Pretty()+handoffSuffix, []byte{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = n1.HandoffLog(ctx, info.ID, r1.LogID(), n2.Host().ID()); err != nil {
+		t.Fatal(err)
+	}
+	if sk, err := n1.store.PrivKey(info.ID, r1.LogID()); err != nil {
+		t.Fatal(err)
+	} else if sk != nil {
+		t.Fatal("expected log key to be released by the previous owner")
+	}
+
+	r2, err := n2.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.LogID() != r1.LogID() {
+		t.Fatalf("expected record in log %s, got %s", r1.LogID(), r2.LogID())
+	}
+	// the handoff records name the new owners, the last one is the sealed head
+	lg, err := n1.store.GetLog(info.ID, r1.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := n1.getRecord(ctx, info.ID, lg.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner, ok := handoffOwner(sealed); !ok || owner != n2.Host().ID() {
+		t.Fatalf("expected handoff record to %s, got %s", n2.Host().ID(), owner)
+	}
+	if !r2.Value().PrevID().Equals(lg.Head) {
+		t.Fatal("expected record to follow the sealed head")
+	}
+	if owner, err := n2.logHandoff(info.ID, r1.LogID()); err != nil || owner != "" {
+		t.Fatalf("expected new owner to unseal the log, got %s (%v)", owner, err)
+	}
+
+	// the previous owner starts a new log
+	r3, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r3.LogID() == r1.LogID() {
+		t.Fatal("expected previous owner to write to a new log")
+	}
+
+	// handing off an external log fails
+	if err = n1.HandoffLog(ctx, info.ID, r1.LogID(), n2.Host().ID()); err == nil {
+		t.Fatal("expected handoff of an external log to fail")
+	}
+}
+
+func TestNet_EphemeralThread(t *testing.T) {
+	t.Parallel()
+	ls := tstore.NewLogstore()
+	n1 := makeNetworkWithLogstore(t, ls).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithEphemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"cursor": 42}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the thread is only kept in memory
+	if _, err = ls.GetThread(info.ID); !errors.Is(err, logstore.ErrThreadNotFound) {
+		t.Fatalf("expected thread to be missing from the logstore, got %v", err)
+	}
+	persisted := n1.bstore.(*ephemeralBlockstore).Blockstore
+	if has, err := persisted.Has(r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatal("expected record to be missing from the blockstore")
+	}
+	if _, err = n1.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+	threads, err := n1.store.Threads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 1 || threads[0] != info.ID {
+		t.Fatalf("expected ephemeral thread to be listed, got %v", threads)
+	}
+
+	// peers may replicate the thread
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = n1.DeleteThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n1.isEphemeral(info.ID) {
+		t.Fatal("expected deleted thread to be forgotten")
+	}
+	if _, err = n1.GetThread(ctx, info.ID); !errors.Is(err, logstore.ErrThreadNotFound) {
+		t.Fatalf("expected thread to be deleted, got %v", err)
+	}
+}
+
+func TestNet_EphemeralThreadLost(t *testing.T) {
+	t.Parallel()
+	store := syncds.MutexWrap(ds.NewMapDatastore())
+	conf := Config{Debug: true, Datastore: store}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+
+	ctx := context.Background()
+	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithEphemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"presence": true}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.EventFromRecord(ctx, n1, r.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok, err := n1.bodies.Record(info.ID, event.BodyID()); err != nil || !ok {
+		t.Fatalf("expected body to be indexed, got %v", err)
+	}
+
+	// archives of ephemeral threads are imported in memory
+	var archive bytes.Buffer
+	if err = n1.ExportThread(ctx, info.ID, &archive, core.WithExportKeys()); err != nil {
+		t.Fatal(err)
+	}
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+	if _, err = n2.ImportThread(ctx, &archive, core.WithEphemeral()); err != nil {
+		t.Fatal(err)
+	}
+	persisted := n2.bstore.(*ephemeralBlockstore).Blockstore
+	if has, err := persisted.Has(event.BodyID()); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatal("expected imported body to be missing from the blockstore")
+	}
+
+	// the thread is lost on shutdown, and its leftovers are purged on startup
+	if err = n1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	n3 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n3.Close()
+	if _, _, ok, err := n3.bodies.Record(info.ID, event.BodyID()); err != nil || ok {
+		t.Fatalf("expected body index to be purged, got %v", err)
+	}
+	if has, err := store.Has(ephemeralKey(info.ID)); err != nil || has {
+		t.Fatalf("expected ephemeral mark to be dropped, got %v", err)
+	}
+}
+
+func TestNet_DeleteThreadCanceled(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err = n.DeleteThread(cctx, info.ID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got %v", err)
+	}
+	if _, err := n.GetThread(ctx, info.ID); err != nil {
+		t.Fatalf("thread was deleted: %v", err)
+	}
+
+	if err = n.DeleteThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.GetThread(ctx, info.ID); err != logstore.ErrThreadNotFound {
+		t.Fatal("thread was not deleted")
+	}
+}
+
+func TestNet_DeleteThreadChunked(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := n.CreateRecords(ctx, info.ID, []format.Node{body, body, body, body, body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = n.withThreadLock(info.ID, func() error { return n.beginDelete(info.ID) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.CreateRecord(ctx, info.ID, body); !errors.Is(err, logstore.ErrThreadNotFound) {
+		t.Fatalf("expected thread being deleted not to accept records, got %v", err)
+	}
+
+	// the log head follows the removed records
+	done, err := n.deleteChunk(ctx, info.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done {
+		t.Fatal("expected records to remain")
+	}
+	lg, err := n.store.GetLog(info.ID, recs[0].LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lg.Head.Equals(recs[2].Value().Cid()) {
+		t.Fatalf("expected log head %s, got %s", recs[2].Value().Cid(), lg.Head)
+	}
+	if _, err = n.GetRecord(ctx, info.ID, recs[4].Value().Cid()); err == nil {
+		t.Fatal("expected record to be removed")
+	}
+
+	// interrupted deletions are resumed
+	n.resumeDeletes()
+	if _, err := n.GetThread(ctx, info.ID); err != logstore.ErrThreadNotFound {
+		t.Fatal("thread was not deleted")
+	}
+	if known, err := n.isKnown(recs[0].Value().Cid()); err != nil || known {
+		t.Fatal("expected all records to be removed")
+	}
+}
+
+func TestNet_DeleteThreadDuringCreate(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a record being created holds off the deletion until its heads are advanced
+	identity := thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	chain, err := n.newRecordChain(ctx, info.ID, []format.Node{body}, identity, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := make(chan error, 1)
+	go func() {
+		deleted <- n.DeleteThread(ctx, info.ID)
+	}()
+	select {
+	case err = <-deleted:
+		t.Fatalf("expected deletion to wait for the record, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err = n.setHeads(ctx, info.ID, chain.lid, chain.nextHeads()); err != nil {
+		t.Fatal(err)
+	}
+	chain.release()
+	if err = <-deleted; err != nil {
+		t.Fatal(err)
+	}
+
+	// records created once the thread is gone don't bring it back
+	if _, err = n.CreateRecord(ctx, info.ID, body); err == nil {
+		t.Fatal("expected record creation on a deleted thread to fail")
+	}
+	if _, err := n.GetThread(ctx, info.ID); err != logstore.ErrThreadNotFound {
+		t.Fatalf("expected deleted thread, got %v", err)
+	}
+}
+
+func TestNet_Records(t *testing.T) {
+	t.Parallel()
+	ks := keystore.NewMemKeystore()
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ks.AddIdentity("alice", sk); err != nil {
+		t.Fatal(err)
+	}
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Keystore: ks})
+	defer n.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n)
+
+	// records of two logs are interleaved by their clocks
+	var created []cid.Cid
+	for i, identity := range []string{"", "alice", "", "alice", ""} {
+		body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n.CreateRecord(ctx, info.ID, body, core.WithIdentity(identity))
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, r.Value().Cid())
+	}
+
+	collect := func(opts ...core.RecordsOption) []core.ThreadRecord {
+		ch, err := n.Records(ctx, info.ID, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var recs []core.ThreadRecord
+		for r := range ch {
+			recs = append(recs, r)
+		}
+		return recs
+	}
+	all := collect()
+	if len(all) != 5 {
+		t.Fatalf("expected 5 records, got %d", len(all))
+	}
+	for i, r := range all {
+		if !r.Value().Cid().Equals(created[i]) || r.Value().Clock() != uint64(i+1) {
+			t.Fatalf("expected record %d with clock %d, got clock %d", i, i+1, r.Value().Clock())
+		}
+	}
+
+	reversed := collect(core.WithRecordsReverse())
+	for i := range all {
+		if !reversed[i].Value().Cid().Equals(all[len(all)-1-i].Value().Cid()) {
+			t.Fatalf("expected reversed order at %d", i)
+		}
+	}
+	page := collect(core.WithRecordsSince(all[1].Value().Cid()), core.WithRecordsLimit(2))
+	if len(page) != 2 || !page[0].Value().Cid().Equals(all[2].Value().Cid()) ||
+		!page[1].Value().Cid().Equals(all[3].Value().Cid()) {
+		t.Fatal("expected records following the since record")
+	}
+	if _, err = n.Records(ctx, info.ID, core.WithRecordsSince(cid.Undef), core.WithRecordsLimit(-1)); err == nil {
+		t.Fatal("expected negative limit to be rejected")
+	}
+}
+
+func TestNet_PutChainBridged(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n)
+	lg, err := n.getOrCreateLog(info.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := thread.NewLibp2pPubKey(n.getPrivKey().GetPublic())
+	var recs []core.Record
+	for i, prev := 0, lg.Head; i < 2; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := cbor.CreateEvent(ctx, n, body, info.Key.Read())
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+			Block:      event,
+			Prev:       prev,
+			Key:        lg.PrivKey,
+			PubKey:     pk,
+			ServiceKey: info.Key.Service(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+		prev = rec.Cid()
+	}
+
+	// the first record is fetched by a concurrent put of a chain bridged to it,
+	// so it's in the blockstore before it's processed
+	var once sync.Once
+	n.acceptHooks = []core.AcceptHook{func(ctx context.Context, _ thread.ID, _ peer.ID, _ thread.PubKey) error {
+		var err error
+		once.Do(func() { err = n.Add(ctx, recs[0]) })
+		return err
+	}}
+	sub, err := n.Subscribe(ctx, core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.putChain(ctx, info.ID, lg.ID, recs, n.peerSource(core.SourcePush, n.host.ID())); err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range recs {
+		select {
+		case r := <-sub:
+			if !r.Value().Cid().Equals(rec.Cid()) {
+				t.Fatalf("expected record %s, got %s", rec.Cid(), r.Value().Cid())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("record %s was not processed", rec.Cid())
+		}
+	}
+}
+
+func TestNet_RecordClock(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+
+	for i := 1; i <= 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Value().Clock() != uint64(i) {
+			t.Fatalf("expected clock %d, got %d", i, r.Value().Clock())
+		}
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// a record of another log follows the pulled ones
+	body, err := cbornode.WrapObject(map[string]interface{}{"i": 4}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n2.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Value().Clock() != 4 {
+		t.Fatalf("expected clock 4, got %d", r.Value().Clock())
+	}
+
+	// clocks of received records must grow along the log, within bounds
+	lg, err := n1.(*net).getOrCreateLog(info.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamp := func(clock, seq uint64) core.Record {
+		event, err := cbor.CreateEvent(ctx, n1, body, info.Key.Read())
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+			Block:      event,
+			Prev:       lg.Head,
+			Key:        lg.PrivKey,
+			PubKey:     thread.NewLibp2pPubKey(n1.Host().Peerstore().PrivKey(n1.Host().ID()).GetPublic()),
+			ServiceKey: info.Key.Service(),
+			Clock:      clock,
+			Seq:        seq,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	for _, clock := range []uint64{3, 4 + MaxClockJump} {
+		if err = n1.(*net).PutRecord(ctx, info.ID, lg.ID, stamp(clock, 4)); !errors.Is(err, ErrInvalidClock) {
+			t.Fatalf("expected clock %d to be refused, got %v", clock, err)
+		}
+	}
+	// sequence numbers must follow the log head
+	for _, seq := range []uint64{1, 3, 5} {
+		if err = n1.(*net).PutRecord(ctx, info.ID, lg.ID, stamp(10, seq)); !errors.Is(err, ErrInvalidSeq) {
+			t.Fatalf("expected sequence number %d to be refused, got %v", seq, err)
+		}
+	}
+	if err = n1.(*net).PutRecord(ctx, info.ID, lg.ID, stamp(10, 4)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_NotifyClock(t *testing.T) {
+	t.Parallel()
+	mock := clock.NewMock(time.Now())
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Clock: mock}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a listener which never reads fills up its buffer with the first records
+	l := n.bus.Listen()
+	defer l.Discard()
+	for i := 0; i < n.syncConfig().EventBusCapacity; i++ {
+		if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := n.CreateRecord(ctx, info.ID, body)
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("expected the notification to wait for the clock, got %v", err)
+	default:
+	}
+	for i := 0; ; i++ {
+		mock.Add(notifyTimeout)
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatal("expected the notification to time out")
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+			if i == 500 {
+				t.Fatal("notification didn't time out once the clock was advanced")
+			}
+		}
+	}
+}
+
+func TestNet_EventClock(t *testing.T) {
+	t.Parallel()
+	mock := clock.NewMock(time.Unix(1000, 0))
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Clock: mock}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	events, err := n.RecentEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) == 0 {
+		t.Fatal("expected recent events")
+	}
+	for _, ev := range events {
+		if !ev.Time.Equal(mock.Now()) {
+			t.Fatalf("expected %s event at %s, got %s", ev.Type, mock.Now(), ev.Time)
+		}
+	}
+}
+
+func TestNet_RecordIndex(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n)
+
+	var (
+		created []cid.Cid
+		lid     peer.ID
+	)
+	for i := 0; i < 5; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, r.Value().Cid())
+		lid = r.LogID()
+	}
+	lg, err := n.store.GetLog(info.ID, lid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rid := range created {
+		if height, ok := n.indexedHeight(info.ID, lid, rid); !ok || height != uint64(i+1) {
+			t.Fatalf("expected record %d at height %d, got %d (indexed: %v)", i, i+1, height, ok)
+		}
+	}
+
+	// slices are served from the index like by walking the log
+	sk, err := n.store.ServiceKey(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		offset   cid.Cid
+		limit    int
+		expected []cid.Cid
+	}{
+		{offset: cid.Undef, limit: 10, expected: created},
+		{offset: created[1], limit: 10, expected: created[2:]},
+		{offset: created[1], limit: 2, expected: created[3:]},
+		{offset: created[4], limit: 10, expected: nil},
+	} {
+		stop := make(map[cid.Cid]struct{})
+		if c.offset.Defined() {
+			stop[c.offset] = struct{}{}
+		}
+		indexed, ok := n.indexedRecords(ctx, info.ID, lid, lg.Heads, stop, cid.Undef, c.limit, sk)
+		if !ok {
+			t.Fatalf("expected records after %s to be served from the index", c.offset)
+		}
+		var offsets []cid.Cid
+		if c.offset.Defined() {
+			offsets = append(offsets, c.offset)
+		}
+		walked, err := n.getLocalRecords(ctx, info.ID, lid, offsets, c.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(indexed) != len(c.expected) || len(walked) != len(c.expected) {
+			t.Fatalf("expected %d records, got %d indexed and %d served", len(c.expected), len(indexed), len(walked))
+		}
+		for i, rid := range c.expected {
+			if !indexed[i].Cid().Equals(rid) || !walked[i].Cid().Equals(rid) {
+				t.Fatalf("unexpected record %d after %s", i, c.offset)
+			}
+		}
+	}
+
+	// offsets which aren't indexed aren't served from the index
+	other, err := cbornode.WrapObject(map[string]interface{}{"other": true}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := n.indexedRecords(ctx, info.ID, lid, lg.Heads, map[cid.Cid]struct{}{other.Cid(): {}}, cid.Undef, 10, sk); ok {
+		t.Fatal("expected unknown offset not to be served from the index")
+	}
+
+	// the index is removed along with the thread
+	if err = n.DeleteThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := n.recIndex.Height(info.ID, lid, created[0]); err != nil || ok {
+		t.Fatalf("expected index to be removed, got %v", err)
+	}
+}
+
+func TestNet_RecordIndexPulled(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	var (
+		created []cid.Cid
+		lid     peer.ID
+	)
+	for i := 0; i < 5; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, r.Value().Cid())
+		lid = r.LogID()
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	// pulled records are indexed once they're stored
+	for i, rid := range created {
+		if height, ok := n2.indexedHeight(info.ID, lid, rid); !ok || height != uint64(i+1) {
+			t.Fatalf("expected pulled record %d at height %d, got %d (indexed: %v)", i, i+1, height, ok)
+		}
+	}
+}
+
+func TestNet_Capabilities(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true, RequireCapabilities: true}).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true}).(*net)
+	defer n2.Close()
+	n3 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true}).(*net)
+	defer n3.Close()
+
+	for _, n := range []*net{n2, n3} {
+		n.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+		n1.Host().Peerstore().AddAddrs(n.Host().ID(), n.Host().Addrs(), peerstore.PermanentAddrTTL)
+	}
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := n1.MintCapability(ctx, info.ID, []thread.Caveat{
+		thread.CaveatRights(thread.RightRead),
+		thread.CaveatPeer(n2.Host().ID()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.AddCapability(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, rec.Value().Cid()); err != nil {
+		t.Fatalf("getting record: %v", err)
+	}
+
+	// the service key alone isn't enough
+	if _, err = n3.AddThread(ctx, addr, core.WithThreadKey(info.Key)); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected adding thread without capability to be unauthenticated, got %v", err)
+	}
+
+	cctx := thread.NewCapabilityContext(ctx, c)
+	if _, err = n1.server.checkCapability(cctx, n2.Host().ID(), info.ID, pushRights); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected push to be denied, got %v", err)
+	}
+	if _, err = n1.server.checkCapability(cctx, n3.Host().ID(), info.ID, fetchRights); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected capability of another peer to be unauthenticated, got %v", err)
+	}
+	// replicas which didn't mint the capability ignore it, and fall back to the service key
+	if granted, err := n2.server.checkCapability(cctx, n3.Host().ID(), info.ID, fetchRights); err != nil || granted {
+		t.Fatalf("expected capability minted elsewhere to be ignored, got %v, %v", granted, err)
+	}
+	if err = n1.RevokeCapabilities(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.server.checkCapability(cctx, n2.Host().ID(), info.ID, fetchRights); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected revoked capability to be unauthenticated, got %v", err)
+	}
+}
+
+func TestNet_ThreadACL(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+
+	create := func(i int) core.ThreadRecord {
+		body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	hasBody := func(r core.ThreadRecord) bool {
+		event, err := cbor.EventFromRecord(ctx, n1, r.Value())
+		if err != nil {
+			t.Fatal(err)
+		}
+		has, err := n2.bstore.Has(event.BodyID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return has
+	}
+
+	first := create(1)
+	if err := n1.SetThreadACL(ctx, info.ID, core.ThreadACL{RestrictedLogs: []peer.ID{first.LogID()}}); err != nil {
+		t.Fatal(err)
+	}
+	acl, err := n1.ThreadACL(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acl.RestrictedLogs) != 1 || acl.RestrictedLogs[0] != first.LogID() {
+		t.Fatalf("unexpected ACL: %+v", acl)
+	}
+	second := create(2)
+
+	// replicators without the capability get the envelopes only
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []core.ThreadRecord{first, second} {
+		if known, err := n2.isKnown(r.Value().Cid()); err != nil {
+			t.Fatal(err)
+		} else if !known {
+			t.Fatalf("expected record %s to be replicated", r.Value().Cid())
+		}
+		if hasBody(r) || !n2.isBodyless(info.ID, r.Value().Cid()) {
+			t.Fatalf("expected body of record %s to be withheld", r.Value().Cid())
+		}
+	}
+
+	// restricted bodies are neither served over bitswap, nor requested by their IDs
+	client, release, err := n2.server.dial(n1.Host().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	for _, r := range []core.ThreadRecord{first, second} {
+		event, err := cbor.EventFromRecord(ctx, n1, r.Value())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shared, err := n1.withheld.shared.Has(event.BodyID()); err != nil {
+			t.Fatal(err)
+		} else if shared {
+			t.Fatalf("expected body of record %s to be withheld from bitswap", r.Value().Cid())
+		}
+		if _, err = n1.bstore.Get(event.BodyID()); err != nil {
+			t.Fatalf("expected withheld body to be readable: %v", err)
+		}
+		if _, err = client.GetRecordBodies(ctx, &pb.GetRecordBodiesRequest{
+			Body: &pb.GetRecordBodiesRequest_Body{
+				ThreadID:   &pb.ProtoThreadID{ID: info.ID},
+				ServiceKey: &pb.ProtoKey{Key: info.Key.Service()},
+				Bodies:     []pb.ProtoCid{{Cid: event.BodyID()}},
+			},
+		}); status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected restricted body to be denied, got %v", err)
+		}
+	}
+
+	// readers get the bodies
+	acl.Readers = []peer.ID{n2.Host().ID()}
+	if err = n1.SetThreadACL(ctx, info.ID, acl); err != nil {
+		t.Fatal(err)
+	}
+	third := create(3)
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !hasBody(third) || n2.isBodyless(info.ID, third.Value().Cid()) {
+		t.Fatal("expected body to be replicated to the reader")
+	}
+}
+
+func TestNet_Connectivity(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	// n2 dialed n1, so the dialed address is observed reachable and n2 is reported connected directly
+	waitFor(t, func() bool {
+		status, err := n1.Connectivity(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pc := status.Peers[n2.Host().ID()]
+		return pc.Connected && pc.Direct && len(pc.Relays) == 0 && len(status.ReachableAddrs) == 1
+	})
+	status, err := n1.Connectivity(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.ReachableAddrs[0].Equal(n1.Host().Addrs()[0]) {
+		t.Fatalf("expected dialed address to be reachable, got %s", status.ReachableAddrs[0])
+	}
+	if status.HolePunch.Attempts != 0 || status.HolePunch.Rate() != 0 {
+		t.Fatalf("expected no hole punch attempts, got %d", status.HolePunch.Attempts)
+	}
+
+	// thread addresses only include listen, observed addresses and relay circuits
+	relay := tu.RandPeerIDFatal(t)
+	listen := util.MustParseAddr("/ip4/127.0.0.1/tcp/4006")
+	public := util.MustParseAddr("/ip4/1.2.3.4/tcp/4006")
+	unseen := util.MustParseAddr("/ip4/10.0.0.1/tcp/4006")
+	circuit := util.MustParseAddr("/ip4/10.0.0.2/tcp/4006/p2p/" + relay.String() + "/p2p-circuit")
+	clk := clock.NewMock(time.Now())
+	ct := newConnectivityTracker(clk)
+	if addrs := ct.reachableAddrs([]ma.Multiaddr{unseen}, nil); len(addrs) != 1 {
+		t.Fatal("expected all addresses if none were observed")
+	}
+	ct.addrsUpdated(event.EvtLocalAddressesUpdated{
+		Diffs: true,
+		Current: []event.UpdatedAddress{
+			{Address: listen, Action: event.Maintained},
+			{Address: public, Action: event.Added},
+		},
+	}, []ma.Multiaddr{listen})
+	all := []ma.Multiaddr{listen, public, unseen, circuit}
+	addrs := ct.reachableAddrs(all, []ma.Multiaddr{listen})
+	if len(addrs) != 3 || !addrs[0].Equal(listen) || !addrs[1].Equal(public) || !addrs[2].Equal(circuit) {
+		t.Fatalf("expected listen, observed address and circuit, got %v", addrs)
+	}
+
+	// observed addresses still announced are kept, others expire
+	clk.Add(ReachableAddrTTL / 2)
+	ct.refresh([]ma.Multiaddr{listen, public})
+	clk.Add(ReachableAddrTTL/2 + time.Second)
+	if addrs = ct.reachableAddrs(all, []ma.Multiaddr{listen}); len(addrs) != 3 {
+		t.Fatalf("expected announced address to be kept, got %v", addrs)
+	}
+	clk.Add(ReachableAddrTTL + time.Second)
+	if addrs = ct.reachableAddrs([]ma.Multiaddr{public}, []ma.Multiaddr{listen}); len(addrs) != 1 {
+		t.Fatal("expected all addresses once the observed ones expired")
+	}
+	ct.addrsUpdated(event.EvtLocalAddressesUpdated{Diffs: true, Current: []event.UpdatedAddress{
+		{Address: public, Action: event.Added},
+	}}, nil)
+	ct.addrsUpdated(event.EvtLocalAddressesUpdated{Diffs: true, Removed: []event.UpdatedAddress{
+		{Address: public, Action: event.Removed},
+	}}, nil)
+	if len(ct.observed) != 0 {
+		t.Fatal("expected address removed by the host to be forgotten")
+	}
+	if pid, ok := circuitRelay(circuit); !ok || pid != relay {
+		t.Fatalf("expected circuit relay %s, got %s", relay, pid)
+	}
+	tinfo, err := n1.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tinfo.Addrs) != 1 {
+		t.Fatalf("expected 1 thread address, got %d", len(tinfo.Addrs))
+	}
+}
+
+func TestNet_RecoverHeads(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lid := rec.LogID()
+	if pending, err := n.journal.Pending(); err != nil {
+		t.Fatal(err)
+	} else if len(pending) != 0 {
+		t.Fatalf("expected no pending updates got %d", len(pending))
+	}
+	missing, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: mh.SHA2_256}.Sum([]byte("missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := []cid.Cid{rec.Value().Cid()}
+
+	// crashed before the record was stored
+	if err = n.journal.Begin(info.ID, lid, prev, []cid.Cid{missing}); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.store.SetHeads(info.ID, lid, []cid.Cid{missing}); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.recoverHeads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if heads, err := n.store.Heads(info.ID, lid); err != nil {
+		t.Fatal(err)
+	} else if len(heads) != 1 || !heads[0].Equals(prev[0]) {
+		t.Fatalf("expected heads to be rolled back to %s got %v", prev[0], heads)
+	}
+
+	// crashed after the record was stored
+	if err = n.journal.Begin(info.ID, lid, nil, prev); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.store.SetHeads(info.ID, lid, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.recoverHeads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if heads, err := n.store.Heads(info.ID, lid); err != nil {
+		t.Fatal(err)
+	} else if len(heads) != 1 || !heads[0].Equals(prev[0]) {
+		t.Fatalf("expected heads to be advanced to %s got %v", prev[0], heads)
+	}
+	if pending, err := n.journal.Pending(); err != nil {
+		t.Fatal(err)
+	} else if len(pending) != 0 {
+		t.Fatalf("expected no pending updates got %d", len(pending))
+	}
+}
+
+func TestNet_DeadLetters(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true}).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Debug: true, DeadLetterAttempts: 2}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	a := &failingApp{fail: true}
+	con, err := n2.ConnectApp(a, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rid := r.Value().Cid()
+
+	// failed records are retried with the log head kept before them
+	_ = n2.PullThread(ctx, info.ID)
+	dls, err := n2.DeadLetters(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dls) != 1 || !dls[0].RecordID.Equals(rid) || dls[0].LogID != r.LogID() || dls[0].Attempts != 1 || dls[0].Skipped {
+		t.Fatalf("expected a pending dead letter of the record, got %+v", dls)
+	}
+	heads, err := n2.store.Heads(info.ID, r.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heads) != 0 {
+		t.Fatalf("expected log head to be rolled back, got %v", heads)
+	}
+	if err = n2.ReplayDeadLetter(ctx, info.ID, rid, core.WithAPIToken(con.Token())); err == nil {
+		t.Fatal("expected pending record not to be replayed")
+	}
+
+	// once attempts are exhausted the record is skipped
+	_ = n2.PullThread(ctx, info.ID)
+	if dls, err = n2.DeadLetters(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(dls) != 1 || dls[0].Attempts != 2 || !dls[0].Skipped || dls[0].Err == "" {
+		t.Fatalf("expected a skipped dead letter of the record, got %+v", dls)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, rid); err != nil {
+		t.Fatalf("expected skipped record to be added to the log: %v", err)
+	}
+
+	// replayed records are dropped once handled
+	if err = n2.ReplayDeadLetter(ctx, info.ID, rid, core.WithAPIToken(con.Token())); err == nil {
+		t.Fatal("expected replay to fail")
+	}
+	a.setFail(false)
+	if err = n2.ReplayDeadLetter(ctx, info.ID, rid, core.WithAPIToken(con.Token())); err != nil {
+		t.Fatal(err)
+	}
+	if a.handledCount() != 1 {
+		t.Fatalf("expected record to be handled once, got %d", a.handledCount())
+	}
+	if dls, err = n2.DeadLetters(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	} else if len(dls) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(dls))
+	}
+	if err = n2.DiscardDeadLetter(ctx, info.ID, rid); !errors.Is(err, core.ErrDeadLetterNotFound) {
+		t.Fatalf("expected dead letter not to be found, got %v", err)
+	}
+}
+
+// failingApp fails to handle records while set to.
+type failingApp struct {
+	mx      sync.Mutex
+	fail    bool
+	handled int
+}
+
+func (a *failingApp) ValidateNetRecordBody(context.Context, format.Node, thread.PubKey) error {
+	return nil
+}
+
+func (a *failingApp) HandleNetRecord(context.Context, core.ThreadRecord, thread.Key) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if a.fail {
+		return errors.New("app failure")
+	}
+	a.handled++
+	return nil
+}
+
+func (a *failingApp) setFail(fail bool) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	a.fail = fail
+}
+
+func (a *failingApp) handledCount() int {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	return a.handled
+}
+
+func TestNet_ExternalSigner(t *testing.T) {
+	t.Parallel()
+	sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kms := &kmsSigner{sk: sk}
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		Debug:          true,
+		Signers:        kmsProvider{kms},
+		SigningTimeout: 100 * time.Millisecond,
+	}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithLogKey(pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Value().Verify(pk); err != nil {
+		t.Fatalf("expected record to be signed with the log key: %v", err)
+	}
+
+	// addresses of the log are signed once by the signer, and only changed locally
+	lg, err := n.store.GetLog(info.ID, r.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl, err := n.signedLogToProto(ctx, info.ID, lg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := pk.Verify(logAddrsPayload(info.ID, lg.ID, pl.AddrsSeq, lg.Addrs), pl.AddrsSig); err != nil || !ok {
+		t.Fatalf("expected addresses to be signed with the log key: %v", err)
+	}
+	again, err := n.signedLogToProto(ctx, info.ID, lg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.AddrsSeq != pl.AddrsSeq {
+		t.Fatal("expected signed addresses to be reused")
+	}
+	if addrs, replace, err := n.verifyLogAddrs(info.ID, peerLogFromProto(pl)); err != nil || replace || addrs != nil {
+		t.Fatalf("expected addresses of own log to be ignored, got %v, %v", addrs, err)
+	}
+
+	// signers running past the timeout fail the record
+	kms.setDelay(time.Second)
+	if _, err = n.CreateRecord(ctx, info.ID, body); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected signing to time out, got %v", err)
+	}
+	heads, err := n.store.Heads(info.ID, r.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heads) != 1 || !heads[0].Equals(r.Value().Cid()) {
+		t.Fatalf("expected log head not to advance, got %v", heads)
+	}
+}
+
+// kmsSigner signs asynchronously after a delay, like a remote KMS.
+type kmsSigner struct {
+	mx    sync.Mutex
+	sk    crypto.PrivKey
+	delay time.Duration
+}
+
+func (s *kmsSigner) PubKey() crypto.PubKey {
+	return s.sk.GetPublic()
+}
+
+func (s *kmsSigner) SignAsync(ctx context.Context, msg []byte) <-chan core.SignResult {
+	s.mx.Lock()
+	delay := s.delay
+	s.mx.Unlock()
+	res := make(chan core.SignResult, 1)
+	go func() {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			res <- core.SignResult{Err: ctx.Err()}
+			return
+		}
+		sig, err := s.sk.Sign(msg)
+		res <- core.SignResult{Sig: sig, Err: err}
+	}()
+	return res
+}
+
+func (s *kmsSigner) setDelay(delay time.Duration) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.delay = delay
+}
+
+// kmsProvider provides the signer of its key.
+type kmsProvider struct {
+	s *kmsSigner
+}
+
+func (p kmsProvider) Signer(_ context.Context, _ thread.ID, pk crypto.PubKey) (core.Signer, error) {
+	if !pk.Equals(p.s.PubKey()) {
+		return nil, nil
+	}
+	return core.NewAsyncSigner(p.s), nil
+}
+
+func TestNet_ThreadMetadata(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	if md, err := n1.GetThreadMetadata(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	} else if md.Name != "" || md.Signer != nil {
+		t.Fatalf("expected no metadata, got %+v", md)
+	}
+	md1, err := n1.SetThreadMetadata(ctx, info.ID, thread.Metadata{Name: "notes", Schema: "note/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = md1.Verify(info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// metadata is fetched along with the logs
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	md, err := n2.GetThreadMetadata(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Name != "notes" || md.Schema != "note/v1" || !md.UpdatedAt.Equal(md1.UpdatedAt) {
+		t.Fatalf("expected metadata of n1, got %+v", md)
+	}
+
+	// updates are pushed along with the log of the writer
+	md2, err := n2.SetThreadMetadata(ctx, info.ID, thread.Metadata{Name: "shared notes", Description: "notes of n1 and n2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !md2.After(md1) {
+		t.Fatal("expected update to supersede the current metadata")
+	}
+	if md, err = n1.GetThreadMetadata(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if md.Name != "shared notes" || md.Schema != "" || !md.Signer.Equals(md2.Signer) {
+		t.Fatalf("expected metadata of n2, got %+v", md)
+	}
+
+	// older metadata doesn't replace the latest one
+	data, err := md1.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.mergeThreadMetadata(info.ID, data); err != nil {
+		t.Fatal(err)
+	}
+	if md, err = n1.GetThreadMetadata(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	} else if md.Name != "shared notes" {
+		t.Fatalf("expected latest metadata to win, got %+v", md)
+	}
+
+	// metadata signed by keys outside the thread is refused
+	sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := thread.Metadata{Name: "forged", UpdatedAt: time.Now().Add(time.Hour), Signer: pk}
+	payload, err := forged.Payload(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forged.Sig, err = sk.Sign(payload); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = forged.Marshal(); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.mergeThreadMetadata(info.ID, data); !errors.Is(err, thread.ErrInvalidMetadata) {
+		t.Fatalf("expected forged metadata to be refused, got %v", err)
+	}
+
+	// so is metadata dated far ahead of the local clock
+	lg, err := n1.getOrCreateLog(info.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := thread.Metadata{
+		Name:      "pinned",
+		UpdatedAt: time.Now().Add(2 * MaxMetadataClockSkew),
+		Signer:    lg.PubKey,
+	}
+	if payload, err = future.Payload(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if future.Sig, err = lg.PrivKey.Sign(payload); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = future.Marshal(); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.mergeThreadMetadata(info.ID, data); !errors.Is(err, thread.ErrInvalidMetadata) {
+		t.Fatalf("expected metadata from the future to be refused, got %v", err)
+	}
+}
+
+func TestNet_SampleRecords(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	for i := 0; i < 10; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"index": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lg, err := n.(*net).getOrCreateLog(info.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := n.(*net).getRecord(ctx, info.ID, lg.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Seq() != 10 {
+		t.Fatalf("expected head numbered 10, got %d", head.Seq())
+	}
+
+	nonce := []byte("auditor nonce")
+	sample, err := n.SampleRecords(ctx, info.ID, nonce, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.Size() != 3 {
+		t.Fatalf("expected 3 sampled records, got %d", sample.Size())
+	}
+	if err := VerifySample(sample, nonce, 3, info.Key.Service()); err != nil {
+		t.Fatalf("valid sample rejected: %v", err)
+	}
+
+	again, err := n.SampleRecords(ctx, info.ID, nonce, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIndices(sample.Logs[0].Indices, again.Logs[0].Indices) {
+		t.Fatalf("sampling is not deterministic")
+	}
+
+	// find a nonce resulting in different positions, as some nonces collide on a short log
+	for i := 0; ; i++ {
+		other := []byte(fmt.Sprintf("other nonce %d", i))
+		if equalIndices(core.SampleIndices(info.ID, other, 3, sample.Logs)[0], sample.Logs[0].Indices) {
+			continue
+		}
+		if err := VerifySample(sample, other, 3, info.Key.Service()); err == nil {
+			t.Fatal("sample accepted for a different nonce")
+		}
+		break
+	}
+
+	// a shorter or longer log is refused even if the positions were chosen for it
+	for _, length := range []int{sample.Logs[0].Length - 1, sample.Logs[0].Length + 5} {
+		ls := sample.Logs[0]
+		ls.Length = length
+		if err := verifyLogSample(ls, info.Key.Service()); err == nil {
+			t.Fatalf("sample accepted with a claimed length of %d", length)
+		}
+	}
+
+	// the claimed length can't be proven without the head sequence number, unless the
+	// sampled chain reaches the first record
+	for i := 0; ; i++ {
+		short, err := n.SampleRecords(ctx, info.ID, []byte(fmt.Sprintf("short nonce %d", i)), 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ls := short.Logs[0]
+		if len(ls.Chain) == ls.Length {
+			continue
+		}
+		ls.HeadExtensions = nil
+		if err := verifyLogSample(ls, info.Key.Service()); err == nil {
+			t.Fatal("sample accepted without the head extensions")
+		}
+		break
+	}
+
+	sample.Logs[0].Chain[0] = sample.Logs[0].Chain[len(sample.Logs[0].Chain)-1]
+	if err := VerifySample(sample, nonce, 3, info.Key.Service()); err == nil {
+		t.Fatal("tampered sample accepted")
+	}
+
+	all, err := n.SampleRecords(ctx, info.ID, nonce, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Size() != 10 {
+		t.Fatalf("expected all 10 records sampled, got %d", all.Size())
+	}
+	if err := VerifySample(all, nonce, 20, info.Key.Service()); err != nil {
+		t.Fatalf("valid sample rejected: %v", err)
+	}
+}
+
+func TestNet_VerificationBundle(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"msg": "yo!"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := n1.ExportVerificationBundle(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Logs) != 1 {
+		t.Fatalf("expected 1 log in bundle, got %d", len(bundle.Logs))
+	}
+	if err := bundle.Validate(); err != nil {
+		t.Fatalf("valid bundle rejected: %v", err)
+	}
+
+	// a gateway provisioned with the bundle before knowing the thread
+	if err := n2.ImportVerificationBundle(ctx, bundle); err != nil {
+		t.Fatal(err)
+	}
+	lg, err := n1.(*net).store.GetLog(info.ID, bundle.Logs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.(*net).checkTrustedLogKey(info.ID, peerLog{LogInfo: lg}); err != nil {
+		t.Fatalf("bundled log key rejected: %v", err)
+	}
+	otherSk, other, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.(*net).checkTrustedLogKey(info.ID, peerLog{LogInfo: thread.LogInfo{ID: lg.ID, PubKey: other}}); err == nil {
+		t.Fatal("log key not matching log ID accepted")
+	}
+
+	// logs missing from the anchor must be signed by their owner
+	otherID, err := peer.IDFromPublicKey(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := peerLog{LogInfo: thread.LogInfo{ID: otherID, PubKey: other}}
+	if err := n2.(*net).checkTrustedLogKey(info.ID, unsigned); !errors.Is(err, core.ErrUntrustedLog) {
+		t.Fatalf("expected untrusted log error, got %v", err)
+	}
+	signed := unsigned
+	signed.addrsSeq = 1
+	if signed.addrsSig, err = otherSk.Sign(logAddrsPayload(info.ID, otherID, 1, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.(*net).checkTrustedLogKey(info.ID, signed); err != nil {
+		t.Fatalf("signed log rejected: %v", err)
+	}
+	signed.addrsSeq = 2
+	if err := n2.(*net).checkTrustedLogKey(info.ID, signed); !errors.Is(err, core.ErrUntrustedLog) {
+		t.Fatalf("expected untrusted log error, got %v", err)
+	}
+
+	forged := bundle
+	forged.Logs = []core.LogKey{{ID: lg.ID, PubKey: bundle.Logs[0].PubKey}}
+	if forged.Logs[0].PubKey, err = crypto.MarshalPublicKey(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := n1.ImportVerificationBundle(ctx, forged); err == nil {
+		t.Fatal("bundle with a forged log key accepted")
+	}
+
+	nonce := []byte("auditor nonce")
+	sample, err := n1.SampleRecords(ctx, info.ID, nonce, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBundleSample(bundle, sample, nonce, 1, info.Key.Service()); err != nil {
+		t.Fatalf("valid sample rejected: %v", err)
+	}
+	bundle.Logs = nil
+	if err := VerifyBundleSample(bundle, sample, nonce, 1, info.Key.Service()); !errors.Is(err, core.ErrUntrustedLog) {
+		t.Fatalf("expected untrusted log error, got %v", err)
+	}
+}
+
+func TestNet_Attachments(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	data := make([]byte, 3*cbor.AttachmentChunkSize+100)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []core.AttachmentOption
+	}{
+		{name: "encrypted"},
+		{name: "plain", opts: []core.AttachmentOption{core.WithPlainAttachment()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			aid, err := n.AddAttachment(ctx, info.ID, bytes.NewReader(data), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// attachment can be linked from a record body
+			body, err := cbornode.WrapObject(map[string]interface{}{"file": aid}, mh.SHA2_256, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := n.CreateRecord(ctx, info.ID, body); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := n.GetAttachment(ctx, info.ID, aid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			back, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, back) {
+				t.Fatal("attachment data does not match")
+			}
+		})
+	}
+}
+
+func TestNet_ChunkedBody(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	data := make([]byte, 3*cbor.BodyChunkSize+100)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"data": data}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := n2.GetRecord(ctx, info.ID, r.Value().Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.GetEvent(ctx, n2, r2.BlockID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := event.GetBody(ctx, n2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, _, err := cbor.BodyChunks(root)
+	if err != nil {
+		t.Fatalf("expected body to be chunked: %v", err)
+	}
+	for _, id := range chunks {
+		if known, err := n2.isKnown(id); err != nil {
+			t.Fatal(err)
+		} else if !known {
+			t.Fatalf("expected body chunk %s to be fetched", id)
+		}
+	}
+	back, err := event.GetBody(ctx, n2, info.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !back.Cid().Equals(body.Cid()) {
+		t.Fatal("retrieved body does not equal input body")
+	}
+
+	// declared sizes which don't fit the chunks are refused before allocating the body
+	for _, size := range []int64{-1, int64(len(chunks)*cbor.BodyChunkSize) + 1} {
+		bad, err := cbornode.WrapObject(map[string]interface{}{
+			"Type":   "threads/body",
+			"Size":   size,
+			"Chunks": chunks,
+		}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err = cbor.BodyChunks(bad); err == nil {
+			t.Fatalf("expected body of size %d to be refused", size)
+		}
+	}
+
+	n1.maxRecordBodySize = len(body.RawData()) - 1
+	if _, err = n1.CreateRecord(ctx, info.ID, body); !errors.Is(err, ErrRecordTooLarge) {
+		t.Fatalf("expected ErrRecordTooLarge, got %v", err)
+	}
+}
+
+func TestNet_ExportImportThread(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	var recs []core.ThreadRecord
+	for i := 0; i < 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+
+	var plain bytes.Buffer
+	if err := n1.ExportThread(ctx, info.ID, &plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n2.ImportThread(ctx, bytes.NewReader(plain.Bytes())); err == nil {
+		t.Fatal("archive without keys imported without a thread key")
+	}
+
+	var archive bytes.Buffer
+	if err := n1.ExportThread(ctx, info.ID, &archive, core.WithExportKeys()); err != nil {
+		t.Fatal(err)
+	}
+	info2, err := n2.ImportThread(ctx, &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info2.ID.Equals(info.ID) || !info2.Key.CanRead() {
+		t.Fatalf("expected thread %s with keys, got %s", info.ID, info2.ID)
+	}
+	for _, r := range recs {
+		if _, err := n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+			t.Fatalf("getting imported record %s: %v", r.Value().Cid(), err)
+		}
+	}
+	lg, err := n2.(*net).store.GetLog(info.ID, recs[0].LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := recs[len(recs)-1].Value().Cid(); !lg.Head.Equals(last) {
+		t.Fatalf("expected imported log head %s, got %s", last, lg.Head)
+	}
+	if lg.PrivKey == nil {
+		t.Fatal("expected log private key to be imported")
+	}
+
+	// archives are streamed, the import doesn't wait for the whole export
+	n3 := makeNetwork(t)
+	defer n3.Close()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(n1.ExportThread(ctx, info.ID, pw, core.WithExportKeys()))
+	}()
+	if _, err = n3.ImportThread(ctx, pr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n3.GetRecord(ctx, info.ID, recs[0].Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+
+	// records listed after the ones they link to are refused
+	if err = n1.ExportThread(ctx, info.ID, &archive, core.WithExportKeys()); err != nil {
+		t.Fatal(err)
+	}
+	cr, err := nu.NewCarReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nodes []format.Node
+	for {
+		node, err := nu.NextArchiveNode(cr)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	var reordered bytes.Buffer
+	cw, err := nu.NewCarWriter(&reordered, cr.Roots...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cw.Put(nodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	for i := len(nodes) - 1; i > 0; i-- {
+		if err = cw.Put(nodes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n4 := makeNetwork(t)
+	defer n4.Close()
+	if _, err = n4.ImportThread(ctx, &reordered); err == nil || !strings.Contains(err.Error(), "archived before") {
+		t.Fatalf("expected archive with records before their children to be refused, got %v", err)
+	}
+}
+
+func TestNet_CompactThread(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	var old []cid.Cid
+	for i := 0; i < 5; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"index": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old = append(old, r.Value().Cid())
+	}
+	state, err := cbornode.WrapObject(map[string]interface{}{"total": 5}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := n1.CreateCheckpoint(ctx, info.ID, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"index": 5}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n1.CompactThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, rid := range old {
+		if has, err := n1.(*net).isKnown(rid); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatalf("record %s was not pruned", rid)
+		}
+	}
+	if _, err := n1.GetRecord(ctx, info.ID, cp.Value().Cid()); err != nil {
+		t.Fatalf("checkpoint record was pruned: %v", err)
+	}
+
+	// new replica should be able to pull from the compaction boundary
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	lg, err := n2.(*net).store.GetLog(info.ID, cp.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lg.Head.Equals(last.Value().Cid()) {
+		t.Fatalf("expected head %s, got %s", last.Value().Cid(), lg.Head)
+	}
+	if _, err := n2.GetRecord(ctx, info.ID, cp.Value().Cid()); err != nil {
+		t.Fatalf("checkpoint record was not pulled: %v", err)
+	}
+}
+
+func TestNet_RetentionPolicy(t *testing.T) {
+	t.Parallel()
+	mock := clock.NewMock(time.Now())
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Clock: mock}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	addRecords := func(id thread.ID, count int) []cid.Cid {
+		var rids []cid.Cid
+		for i := 0; i < count; i++ {
+			body, err := cbornode.WrapObject(map[string]interface{}{"index": i}, mh.SHA2_256, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := n.CreateRecord(ctx, id, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rids = append(rids, r.Value().Cid())
+		}
+		return rids
+	}
+	checkKnown := func(rids []cid.Cid, expected bool) {
+		for _, rid := range rids {
+			if known, err := n.isKnown(rid); err != nil {
+				t.Fatal(err)
+			} else if known != expected {
+				t.Fatalf("expected record %s to be known: %v", rid, expected)
+			}
+		}
+	}
+
+	checkpoint := func(id thread.ID) cid.Cid {
+		state, err := cbornode.WrapObject(map[string]interface{}{"thread": id.String()}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cp, err := n.CreateCheckpoint(ctx, id, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cp.Value().Cid()
+	}
+
+	// logs without a checkpoint are kept whole
+	info := createThread(t, ctx, n)
+	rids := addRecords(info.ID, 6)
+	policy := core.RetentionPolicy{MaxRecords: 3}
+	if err := n.SetRetentionPolicy(ctx, info.ID, policy); err != nil {
+		t.Fatal(err)
+	}
+	checkKnown(rids, true)
+	stats, err := n.ThreadStats(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 6 || stats.Pruned != 0 || stats.Bytes == 0 || stats.Retention != policy {
+		t.Fatalf("unexpected thread stats %+v", stats)
+	}
+
+	// the latest checkpoint is kept along with the records after it
+	cp := checkpoint(info.ID)
+	last := addRecords(info.ID, 3)
+	if _, err = n.reapThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	checkKnown(rids, false)
+	checkKnown(append(last, cp), true)
+	if stats, err = n.ThreadStats(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 4 || stats.Pruned != 6 || stats.LastPruned.IsZero() {
+		t.Fatalf("unexpected thread stats %+v", stats)
+	}
+
+	// records kept from every head of a forked log are kept
+	info3 := createThread(t, ctx, n)
+	base := addRecords(info3.ID, 4)
+	lg, err := n.getOrCreateLog(info3.ID, thread.NewLibp2pPubKey(n.getPrivKey().GetPublic()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"index": "fork"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.CreateEvent(ctx, n, body, info3.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+		Block:      event,
+		Prev:       base[1],
+		Key:        lg.PrivKey,
+		PubKey:     thread.NewLibp2pPubKey(n.getPrivKey().GetPublic()),
+		ServiceKey: info3.Key.Service(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.PutRecord(ctx, info3.ID, lg.ID, fork); err != nil {
+		t.Fatal(err)
+	}
+	cp = checkpoint(info3.ID)
+	last = addRecords(info3.ID, 2)
+	if err = n.SetRetentionPolicy(ctx, info3.ID, core.RetentionPolicy{MaxRecords: 2}); err != nil {
+		t.Fatal(err)
+	}
+	checkKnown(base[:1], false)
+	checkKnown(append(append(base[1:], fork.Cid(), cp), last...), true)
+
+	// records past the max age are pruned by the reaper
+	info2 := createThread(t, ctx, n)
+	old := addRecords(info2.ID, 3)
+	checkpoint(info2.ID)
+	if err = n.SetRetentionPolicy(ctx, info2.ID, core.RetentionPolicy{MaxAge: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	recent := addRecords(info2.ID, 1)
+	checkKnown(old, true)
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		mock.Add(RetentionInterval)
+		if stats, err = n.ThreadStats(ctx, info2.ID); err != nil {
+			t.Fatal(err)
+		}
+		if stats.Pruned == len(old) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d records to be pruned, got stats %+v", len(old), stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	checkKnown(old, false)
+	checkKnown(recent, true)
+}
+
+func TestNet_ThreadQuota(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Quotas: QuotaConfig{ThreadBytes: 1}}).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	var rids []cid.Cid
+	addRecords := func(count int) {
+		for i := 0; i < count; i++ {
+			body, err := cbornode.WrapObject(map[string]interface{}{"msg": "yo!"}, mh.SHA2_256, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := n1.CreateRecord(ctx, info.ID, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rids = append(rids, r.Value().Cid())
+		}
+	}
+	// records created by the host are counted, but never refused
+	addRecords(2)
+	stats, err := n1.ThreadStats(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Quota != 1 || stats.QuotaUsed != stats.Bytes {
+		t.Fatalf("unexpected thread stats %+v", stats)
+	}
+	rec, err := n1.GetRecord(ctx, info.ID, rids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := n1.recordBytes(ctx, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	events, err := n2.SubscribeEvents(ctx, core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// records pulled before the quota applied aren't counted
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	quota := 3*size + size/5
+	if err = n2.SetThreadQuota(ctx, info.ID, quota); err != nil {
+		t.Fatal(err)
+	}
+	addRecords(5)
+	_ = n2.PullThread(ctx, info.ID)
+	if stats, err = n2.ThreadStats(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 5 || stats.Quota != quota || stats.QuotaUsed != 3*size {
+		t.Fatalf("expected records beyond the quota to be refused, got %+v", stats)
+	}
+	if known, err := n2.isKnown(rids[5]); err != nil {
+		t.Fatal(err)
+	} else if known {
+		t.Fatal("expected record beyond the quota to be refused")
+	}
+
+	timeout := time.After(5 * time.Second)
+	for warned := false; !warned; {
+		select {
+		case ev := <-events:
+			warned = ev.Type == core.QuotaWarning && ev.LogID == ""
+		case <-timeout:
+			t.Fatal("expected a quota warning")
+		}
+	}
+
+	// the quota can be lifted for a single thread
+	if err = n2.SetThreadQuota(ctx, info.ID, -1); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = n2.ThreadStats(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 7 || stats.Quota != 0 {
+		t.Fatalf("expected all records after lifting the quota, got %+v", stats)
+	}
+}
+
+func TestNet_ThreadQuotaChunkedBody(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.SetThreadQuota(ctx, info.ID, int64(cbor.BodyChunkSize)); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 3*cbor.BodyChunkSize)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"data": data}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.GetEvent(ctx, n1, r.Value().BlockID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := event.GetBody(ctx, n1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, _, err := cbor.BodyChunks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the declared body size is checked before the chunks are fetched
+	_ = n2.PullThread(ctx, info.ID)
+	for _, id := range append(chunks, r.Value().Cid()) {
+		if known, err := n2.isKnown(id); err != nil {
+			t.Fatal(err)
+		} else if known {
+			t.Fatalf("expected block %s beyond the quota not to be stored", id)
+		}
+	}
+}
+
+func TestNet_ForkedLog(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	var recs []core.ThreadRecord
+	for i := 0; i < 2; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+
+	// fork the log off the first record, as another device holding the log key would
+	lg, err := n1.(*net).store.GetLog(info.ID, recs[0].LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"n": "fork"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.CreateEvent(ctx, n1, body, info.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+		Block:      event,
+		Prev:       recs[0].Value().Cid(),
+		Key:        lg.PrivKey,
+		PubKey:     thread.NewLibp2pPubKey(n1.Host().Peerstore().PrivKey(n1.Host().ID()).GetPublic()),
+		ServiceKey: info.Key.Service(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n1.(*net).PutRecord(ctx, info.ID, lg.ID, fork); err != nil {
+		t.Fatal(err)
+	}
+
+	lg, err = n1.(*net).store.GetLog(info.ID, lg.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lg.Heads) != 2 {
+		t.Fatalf("expected 2 heads, got %d", len(lg.Heads))
+	}
+	expected := map[cid.Cid]bool{recs[1].Value().Cid(): true, fork.Cid(): true}
+	for _, h := range lg.Heads {
+		if !expected[h] {
+			t.Fatalf("unexpected head %s", h)
+		}
+	}
+
+	// both branches are replicated
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	lg2, err := n2.(*net).store.GetLog(info.ID, lg.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lg2.Heads) != 2 || !lg2.Heads[0].Equals(lg.Heads[0]) || !lg2.Heads[1].Equals(lg.Heads[1]) {
+		t.Fatalf("expected heads %v, got %v", lg.Heads, lg2.Heads)
+	}
+	for h := range expected {
+		if _, err := n2.GetRecord(ctx, info.ID, h); err != nil {
+			t.Fatalf("getting replicated head %s: %v", h, err)
+		}
+	}
+
+	// a record descending from a stale head merges it
+	if err := n1.(*net).store.SetHeads(info.ID, lg.ID, []cid.Cid{recs[0].Value().Cid(), fork.Cid()}); err != nil {
+		t.Fatal(err)
+	}
+	merge, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+		Block:      event,
+		Prev:       recs[1].Value().Cid(),
+		Key:        lg.PrivKey,
+		PubKey:     thread.NewLibp2pPubKey(n1.Host().Peerstore().PrivKey(n1.Host().ID()).GetPublic()),
+		ServiceKey: info.Key.Service(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n1.(*net).PutRecord(ctx, info.ID, lg.ID, merge); err != nil {
+		t.Fatal(err)
+	}
+	heads, err := n1.(*net).store.Heads(info.ID, lg.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// heads aren't ordered
+	if len(heads) != 2 ||
+		!((heads[0].Equals(fork.Cid()) && heads[1].Equals(merge.Cid())) ||
+			(heads[0].Equals(merge.Cid()) && heads[1].Equals(fork.Cid()))) {
+		t.Fatalf("expected heads %v, got %v", []cid.Cid{fork.Cid(), merge.Cid()}, heads)
+	}
+}
+
+func TestNet_RecordExtensions(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"msg": "yo!"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext := map[string][]byte{"time": []byte("1600000000")}
+	r, err := n1.CreateRecord(ctx, info.ID, body, core.WithRecordExtensions(ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// both peers advertise the v2 envelope, so extensions are delivered
+	rec, err := n2.GetRecord(ctx, info.ID, r.Value().Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	er, ok := rec.(core.ExtendedRecord)
+	if !ok {
+		t.Fatal("record doesn't support extensions")
+	}
+	if got := er.Extensions()["time"]; !bytes.Equal(got, ext["time"]) {
+		t.Fatalf("expected extension %q, got %q", ext["time"], got)
+	}
+	if v := n2.(*net).peerEnvelopeVersion(n1.Host().ID()); v != cbor.EnvelopeV2 {
+		t.Fatalf("expected negotiated envelope v2, got %d", v)
+	}
+
+	// unknown peers get v2 envelopes, unless they negotiated the protocol without it
+	other := tu.RandPeerIDFatal(t)
+	if v := n2.(*net).peerEnvelopeVersion(other); v != cbor.EnvelopeV2 {
+		t.Fatalf("expected envelope v2 for unknown peer, got %d", v)
+	}
+	n2.(*net).protocols.set(other, core.ProtocolInfo{Version: core.ProtocolVersion})
+	if v := n2.(*net).peerEnvelopeVersion(other); v != cbor.EnvelopeV1 {
+		t.Fatalf("expected envelope v1 for peer without the feature, got %d", v)
+	}
+
+	// extensions are bounded
+	big := map[string][]byte{"big": make([]byte, MaxExtensionsSize)}
+	if _, err = n1.CreateRecord(ctx, info.ID, body, core.WithRecordExtensions(big)); err == nil {
+		t.Fatal("expected oversized extensions to be refused")
+	}
+
+	// records are downgraded for peers only supporting v1
+	pbrec, err := cbor.RecordToProto(ctx, n1, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := cbor.DowngradeRecord(pbrec, cbor.EnvelopeV1)
+	if down.Version != cbor.EnvelopeV1 || down.Extensions != nil {
+		t.Fatal("record was not downgraded")
+	}
+	if pbrec.Extensions == nil {
+		t.Fatal("original record was modified")
+	}
+	plain, err := cbor.RecordFromProto(down, info.Key.Service())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plain.Cid().Equals(rec.Cid()) {
+		t.Fatal("downgraded record ID doesn't match")
+	}
+	// the clock and sequence number are carried by the extensions, so v1 peers can decode the record
+	if rec.Clock() == 0 || plain.Clock() != 0 {
+		t.Fatalf("expected clock %d to be dropped from the downgraded record", rec.Clock())
+	}
+	if rec.Seq() == 0 || plain.Seq() != 0 {
+		t.Fatalf("expected sequence number %d to be dropped from the downgraded record", rec.Seq())
+	}
+}
+
+func TestNet_RecordAnnotations(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+
+	// the replicator can't read bodies, but it can read and filter annotations
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n2.AddThread(ctx, addr, core.WithThreadKey(thread.NewServiceKey(info.Key.Service()))); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := n2.Subscribe(ctx, core.WithSubFilter(info.ID), core.WithSubAnnotationFilter("type", "image/png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"msg": "yo!"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateRecord(ctx, info.ID, body, core.WithRecordAnnotations(map[string]string{"type": "text/plain"})); err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{"type": "image/png", "version": "1.2.0"}
+	r, err := n1.CreateRecord(ctx, info.ID, body,
+		core.WithRecordAnnotations(annotations),
+		core.WithRecordExtensions(map[string][]byte{"time": []byte("1600000000")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Value().Annotations(); !reflect.DeepEqual(got, annotations) {
+		t.Fatalf("expected annotations %v, got %v", annotations, got)
+	}
+	if err := n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case rec := <-sub:
+		if !rec.Value().Cid().Equals(r.Value().Cid()) {
+			t.Fatalf("expected annotated record %s, got %s", r.Value().Cid(), rec.Value().Cid())
+		}
+		if got := rec.Value().Annotations(); !reflect.DeepEqual(got, annotations) {
+			t.Fatalf("expected annotations %v, got %v", annotations, got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("annotated record wasn't delivered")
+	}
+
+	// annotations are small
+	large := map[string]string{"data": strings.Repeat("x", core.MaxAnnotationsSize)}
+	if _, err = n1.CreateRecord(ctx, info.ID, body, core.WithRecordAnnotations(large)); err == nil {
+		t.Fatal("expected large annotations to be rejected")
+	}
+}
+
+func TestNet_KeystoreIdentity(t *testing.T) {
+	t.Parallel()
+	ks := keystore.NewMemKeystore()
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ks.AddIdentity("alice", sk); err != nil {
+		t.Fatal(err)
+	}
+	alice, err := thread.NewLibp2pPubKey(sk.GetPublic()).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := makeNetworkWithConfig(t, tstore.NewLogstoreWithKeystore(ks), Config{Keystore: ks}).(*net)
+	defer n.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithNewThreadIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := cbornode.WrapObject(map[string]interface{}{"msg": "yo!"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the identity authors records in the log it owns, whose key is held by the keystore
+	r, err := n.CreateRecord(ctx, info.ID, body, core.WithIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r.Value().PubKey(), alice) {
+		t.Fatal("expected record to be authored by the keystore identity")
+	}
+	if lk, err := ks.LogKey(info.ID, r.LogID()); err != nil || lk == nil {
+		t.Fatalf("expected log key in the keystore, got %v (%v)", lk, err)
+	}
+	if rk, err := ks.ReadKey(info.ID); err != nil || rk == nil {
+		t.Fatalf("expected read key in the keystore, got %v (%v)", rk, err)
+	}
+
+	// the host identity writes to a log of its own
+	r2, err := n.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.LogID() == r.LogID() {
+		t.Fatal("expected host identity to write to another log")
+	}
+
+	if _, err = n.CreateRecord(ctx, info.ID, body, core.WithIdentity("bob")); !errors.Is(err, corekeystore.ErrIdentityNotFound) {
+		t.Fatalf("expected unknown identity to be rejected, got %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	t.Run("test close", func(t *testing.T) {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func makeNetwork(t *testing.T) core.Net {
+	return makeNetworkWithLogstore(t, tstore.NewLogstore())
+}
+
+func makeNetworkWithLogstore(t *testing.T, ls logstore.Logstore) core.Net {
+	return makeNetworkWithConfig(t, ls, Config{
+		Debug:  true,
+		PubSub: true,
+	})
+}
+
+func makeNetworkWithConfig(t *testing.T, ls logstore.Logstore, conf Config) core.Net {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := util.MustParseAddr("/ip4/127.0.0.1/tcp/0")
+
+	host, err := libp2p.New(
+		context.Background(),
+		libp2p.ListenAddrs(addr),
+		libp2p.Identity(sk),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := bstore.NewBlockstore(syncds.MutexWrap(ds.NewMapDatastore()))
+	bsrv := bserv.New(bs, offline.Exchange(bs))
+	n, err := NewNetwork(
+		context.Background(),
+		host,
+		bsrv.Blockstore(),
+		dag.NewDAGService(bsrv),
+		ls,
+		conf,
+		nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func createThread(t *testing.T, ctx context.Context, api core.API) thread.Info {
+	info, err := api.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestNet_SubscribeEvents(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := n1.SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := func(types ...core.EventType) map[core.EventType]core.LifecycleEvent {
+		seen := make(map[core.EventType]core.LifecycleEvent)
+		timeout := time.After(time.Second * 10)
+		for len(seen) < len(types) {
+			select {
+			case ev := <-events:
+				for _, typ := range types {
+					if ev.Type == typ {
+						seen[typ] = ev
+					}
+				}
+			case <-timeout:
+				t.Fatalf("expected events %v, got %d", types, len(seen))
+			}
+		}
+		return seen
+	}
+
+	info := createThread(t, ctx, n1)
+	seen := expect(core.ThreadAdded, core.LogAdded)
+	if !seen[core.ThreadAdded].ThreadID.Equals(info.ID) {
+		t.Fatal("got bad thread of added event")
+	}
+	if seen[core.LogAdded].LogID != info.Logs[0].ID {
+		t.Fatal("got bad log of added event")
+	}
+
+	// another log of a stored thread doesn't announce the thread again
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateThread(ctx, info.ID, core.WithThreadKey(info.Key), core.WithLogKey(sk)); err != nil {
+		t.Fatal(err)
+	}
+	for logAdded := false; !logAdded; {
+		select {
+		case ev := <-events:
+			if ev.Type == core.ThreadAdded {
+				t.Fatal("got added event of a stored thread")
+			}
+			logAdded = ev.Type == core.LogAdded
+		case <-time.After(time.Second * 10):
+			t.Fatal("expected log added event")
+		}
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	seen = expect(core.PeerConnected, core.ReplicatorAdded)
+	if seen[core.ReplicatorAdded].PeerID != n2.Host().ID() {
+		t.Fatal("got bad peer of replicator event")
+	}
+
+	if err = n1.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	expect(core.PullCompleted)
+
+	// filtered subscriptions only receive events of the given threads
+	other := createThread(t, ctx, n1)
+	filtered, err := n1.SubscribeEvents(ctx, core.WithSubFilter(other.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.DeleteThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.DeleteThread(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-filtered:
+		if ev.Type != core.ThreadDeleted || !ev.ThreadID.Equals(other.ID) {
+			t.Fatalf("expected deletion of the filtered thread, got %s of %s", ev.Type, ev.ThreadID)
+		}
+	case <-time.After(time.Second * 10):
+		t.Fatal("expected thread deleted event")
+	}
+}
+
+func TestNet_LinkThread(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{LinkDepth: 1})
+	defer n.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parent := createThread(t, ctx, n)
+	child := createThread(t, ctx, n)
+	mounted := createThread(t, ctx, n)
+	for _, l := range []struct {
+		from, to thread.ID
+		kind     core.LinkKind
+	}{
+		{parent.ID, child.ID, core.LinkChild},
+		{child.ID, mounted.ID, core.LinkMount},
+		{child.ID, parent.ID, core.LinkMount},
+	} {
+		if err := n.LinkThread(ctx, l.from, l.to, l.kind); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := n.LinkThread(ctx, parent.ID, parent.ID, core.LinkChild); err == nil {
+		t.Fatal("expected linking a thread to itself to fail")
+	}
+	if err := n.LinkThread(ctx, parent.ID, thread.NewIDV1(thread.Raw, 32), core.LinkChild); err == nil {
+		t.Fatal("expected linking an unknown thread to fail")
+	}
+	links, err := n.ThreadLinks(ctx, parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0] != (core.ThreadLink{ID: child.ID, Kind: core.LinkChild}) {
+		t.Fatalf("expected link to the child thread, got %v", links)
+	}
+
+	events, err := n.SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// next returns the next event of the type, or nil if there's none for a while
+	next := func(typ core.EventType) *core.LifecycleEvent {
+		for {
+			select {
+			case ev := <-events:
+				if ev.Type == typ {
+					return &ev
+				}
+			case <-time.After(time.Second):
+				return nil
+			}
+		}
+	}
+
+	// linked threads are pulled down to the link depth, despite the cycle
+	if err = n.PullThread(ctx, parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	pulled := make(map[thread.ID]bool)
+	for ev := next(core.PullCompleted); ev != nil; ev = next(core.PullCompleted) {
+		pulled[ev.ThreadID] = true
+	}
+	if len(pulled) != 2 || !pulled[parent.ID] || !pulled[child.ID] {
+		t.Fatalf("expected the parent and the child threads to be pulled, got %v", pulled)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n.CreateRecord(ctx, child.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := next(core.LinkedThreadUpdated)
+	if ev == nil {
+		t.Fatal("expected update of the linked thread")
+	}
+	if ev.ThreadID != parent.ID || ev.LinkedID != child.ID || !ev.RecordID.Equals(r.Value().Cid()) {
+		t.Fatalf("got bad linked thread update: %s", ev)
+	}
+
+	if err = n.UnlinkThread(ctx, parent.ID, child.ID); err != nil {
+		t.Fatal(err)
+	}
+	if links, err = n.ThreadLinks(ctx, parent.ID); err != nil {
+		t.Fatal(err)
+	} else if len(links) != 0 {
+		t.Fatalf("expected no links, got %v", links)
+	}
+	if _, err = n.CreateRecord(ctx, child.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	if ev = next(core.LinkedThreadUpdated); ev != nil {
+		t.Fatalf("unexpected update of the unlinked thread: %s", ev)
+	}
+}
+
+func TestNet_HeaderSync(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+	n3 := makeNetwork(t).(*net)
+	defer n3.Close()
+
+	for _, n := range []*net{n2, n3} {
+		n1.Host().Peerstore().AddAddrs(n.Host().ID(), n.Host().Addrs(), peerstore.PermanentAddrTTL)
+		n.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	}
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := rec.Value().GetBlock(ctx, n1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.EventFromNode(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// n2 accepts the author, n3 rejects it
+	author := thread.NewLibp2pPubKey(n1.Host().Peerstore().PubKey(n1.Host().ID()))
+	errRejected := errors.New("author rejected")
+	n2.headerSync = true
+	n2.acceptHooks = []core.AcceptHook{
+		func(_ context.Context, _ thread.ID, _ peer.ID, _ thread.PubKey) error {
+			return nil
+		},
+	}
+	n3.headerSync = true
+	n3.acceptHooks = []core.AcceptHook{
+		func(_ context.Context, _ thread.ID, _ peer.ID, a thread.PubKey) error {
+			if a.Equals(author) {
+				return errRejected
+			}
+			return nil
+		},
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []*net{n2, n3} {
+		if _, err = n.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+			t.Fatal(err)
+		}
+		if err = n.PullThread(ctx, info.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = n2.GetRecord(ctx, info.ID, rec.Value().Cid()); err != nil {
+		t.Fatalf("expected accepted record to be pulled: %v", err)
+	}
+	if known, err := n2.isKnown(event.BodyID()); err != nil {
+		t.Fatal(err)
+	} else if !known {
+		t.Fatal("expected body of the accepted record to be stored")
+	}
+
+	if known, err := n3.isKnown(rec.Value().Cid()); err != nil {
+		t.Fatal(err)
+	} else if known {
+		t.Fatal("expected rejected record not to be stored")
+	}
+	if known, err := n3.isKnown(event.BodyID()); err != nil {
+		t.Fatal(err)
+	} else if known {
+		t.Fatal("expected body of the rejected record not to be downloaded")
+	}
+
+	// bodies are only served along with the thread they belong to
+	other := createThread(t, ctx, n1)
+	client, release, err := n2.server.dial(n1.Host().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	getBody := func(info thread.Info) error {
+		_, err := client.GetRecordBodies(ctx, &pb.GetRecordBodiesRequest{
+			Body: &pb.GetRecordBodiesRequest_Body{
+				ThreadID:   &pb.ProtoThreadID{ID: info.ID},
+				ServiceKey: &pb.ProtoKey{Key: info.Key.Service()},
+				Bodies:     []pb.ProtoCid{{Cid: event.BodyID()}},
+			},
+		})
+		return err
+	}
+	if err = getBody(info); err != nil {
+		t.Fatalf("expected body of the thread to be served: %v", err)
+	}
+	if err = getBody(other); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected body of another thread not to be found, got %v", err)
+	}
+}
+
+func TestNet_ThreadLocks(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+	n.semaphores = nu.NewSemaphorePool(1, 100*time.Millisecond)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	ts, err := n.lockThread(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var release sync.Once
+	// the network can't be closed while the lock is held
+	defer release.Do(ts.Release)
+
+	if err = n.SetThreadQuota(ctx, info.ID, 1<<20); !errors.Is(err, nu.ErrSemaphoreTimeout) {
+		t.Fatalf("expected lock timeout, got %v", err)
+	}
+
+	locks, err := n.ThreadLocks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, ok := locks[info.ID]
+	if !ok || len(locks) != 1 {
+		t.Fatal("expected held thread lock to be listed")
+	}
+	// background pulls may be waiting for the lock too
+	if lock.Holders != 1 || lock.HeldSince.IsZero() {
+		t.Fatalf("got bad lock status %+v", lock)
+	}
+
+	release.Do(ts.Release)
+	if err = n.SetThreadQuota(ctx, info.ID, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	// background pulls may hold the lock for a moment
+	for i := 0; ; i++ {
+		if locks, err = n.ThreadLocks(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(locks) == 0 {
+			break
+		} else if i == 10 {
+			t.Fatal("expected released thread lock to be omitted")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestNet_ThreadLockWidth(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{ThreadLockWidth: 4}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, err := cbornode.WrapObject(map[string]interface{}{"foo": i}, mh.SHA2_256, -1)
+			if err == nil {
+				_, err = n.CreateRecord(ctx, info.ID, body)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// concurrent updates of the thread are serialized per log, so the log isn't forked
+	info, err := n.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Logs) != 1 || len(info.Logs[0].Heads) != 1 {
+		t.Fatalf("expected a single log with a single head, got %+v", info.Logs)
+	}
+}
+
+func TestNet_UpdateConfig(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	if err := n.UpdateConfig(ctx, core.SyncConfig{PullInterval: -time.Second}); err == nil {
+		t.Fatal("expected negative interval to be refused")
+	}
+	if err := n.UpdateConfig(ctx, core.SyncConfig{
+		PullInterval:     time.Minute,
+		MaxPullLimit:     10,
+		EventBusCapacity: 5,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := n.syncConfig()
+	if cfg.PullInterval != time.Minute || cfg.MaxPullLimit != 10 {
+		t.Fatalf("expected updated config, got %+v", cfg)
+	}
+	if cfg.QueuePollInterval != QueuePollInterval || cfg.InitialPullInterval != InitialPullInterval ||
+		cfg.MaxConcurrentPulls != MaxConcurrentPulls {
+		t.Fatalf("expected defaults for unset fields, got %+v", cfg)
+	}
+	if err := n.UpdateConfig(ctx, core.SyncConfig{MaxConcurrentPulls: -1}); err == nil {
+		t.Fatal("expected negative concurrent pulls to be refused")
+	}
+	l := n.bus.Listen()
+	defer l.Discard()
+	if c := cap(l.Channel()); c != 5 {
+		t.Fatalf("expected new listeners with capacity 5, got %d", c)
+	}
+}
+
+func TestNet_Compression(t *testing.T) {
+	t.Parallel()
+	conf := Config{PubSub: true, Compression: true}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": strings.Repeat("yo! ", 1000),
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+
+	// n2 learns that n1 supports compression while adding the thread
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if !n2.(*net).peerCompression(n1.Host().ID()) {
+		t.Fatal("expected compression support to be negotiated")
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	status, err := n2.CompressionStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ReceivedMessages == 0 || status.ReceivedBytes == 0 || status.ReceivedWireBytes == 0 {
+		t.Fatalf("expected compressed records to be received, got %+v", status)
+	}
+
+	// text compresses well
+	c := newZstdCompressor()
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Repeat("yo! ", 1000)
+	if _, err = io.WriteString(w, text); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len()*10 > len(text) {
+		t.Fatalf("expected text to compress, got %d of %d bytes", buf.Len(), len(text))
+	}
+	r, err := c.Decompress(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadAll(r); err != nil || string(data) != text {
+		t.Fatalf("expected decompressed text, got error %v", err)
+	}
+}
+
+func TestNet_BodyCompression(t *testing.T) {
+	t.Parallel()
+	conf := Config{Compression: true, CompressionCodec: snappyName, BodyCompression: true}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	caps, err := n1.PeerCapabilities(ctx, n2.Host().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(caps.BodyCompression) == 0 || !n1.peerBodyCompression(n2.Host().ID(), snappyName) {
+		t.Fatalf("expected body compression support to be advertised, got %+v", caps)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": strings.Repeat("yo! ", 1000),
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedBodySize := func(rec core.Record) int {
+		block, err := n1.Get(ctx, rec.BlockID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := cbor.EventFromNode(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		coded, err := n1.Get(ctx, event.BodyID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(coded.RawData())
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := storedBodySize(r.Value()); size*10 > len(body.RawData()) {
+		t.Fatalf("expected body to be compressed, got %d of %d bytes", size, len(body.RawData()))
+	}
+
+	// the peer reads the compressed body
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n2.GetRecord(ctx, info.ID, r.Value().Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.EventFromRecord(ctx, n2, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back, err := event.GetBody(ctx, n2, info.Key.Read()); err != nil {
+		t.Fatal(err)
+	} else if !back.Cid().Equals(body.Cid()) {
+		t.Fatalf("expected body %s, got %s", body.Cid(), back.Cid())
+	}
+
+	// bodies aren't compressed if a peer of the thread may not read them
+	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.store.AddAddr(info.ID, info.Logs[0].ID, ma.StringCast("/p2p/"+unknown.String()), peerstore.PermanentAddrTTL); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	if size := storedBodySize(r.Value()); size < len(body.RawData()) {
+		t.Fatalf("expected body not to be compressed, got %d of %d bytes", size, len(body.RawData()))
+	}
+}
+
+func TestNet_UnloadThread(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = n.UnloadThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	topics, err := n.Topics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 0 {
+		t.Fatal("expected thread topic to be left")
+	}
+	if size := n.semaphores.Size(); size != 0 {
+		t.Fatalf("expected thread semaphores to be released, got %d", size)
+	}
+	if unloaded, err := n.isUnloaded(info.ID); err != nil {
+		t.Fatal(err)
+	} else if !unloaded {
+		t.Fatal("expected thread to be unloaded")
+	}
+	if _, err = n.GetRecord(ctx, info.ID, rec.Value().Cid()); err != nil {
+		t.Fatalf("expected records of unloaded thread to be kept: %v", err)
+	}
+
+	// writing loads the thread again
+	if _, err = n.CreateRecord(ctx, info.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	if topics, err = n.Topics(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || !topics[0].Equals(info.ID) {
+		t.Fatal("expected thread topic to be joined again")
+	}
+	if unloaded, err := n.isUnloaded(info.ID); err != nil {
+		t.Fatal(err)
+	} else if unloaded {
+		t.Fatal("expected thread to be loaded")
+	}
+}
+
+func TestNet_ArchiveThread(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	var recs []cid.Cid
+	for i := 0; i < 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"index": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r.Value().Cid())
+	}
+
+	// records without a replica aren't dropped
+	if err := n1.ArchiveThread(ctx, info.ID); !errors.Is(err, core.ErrNotReplicated) {
+		t.Fatalf("expected archival to be refused, got %v", err)
+	}
+	if known, err := n1.isKnown(recs[0]); err != nil || !known {
+		t.Fatalf("expected records to be kept: %v", err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		if known, err := n2.isKnown(recs[len(recs)-1]); err != nil {
+			t.Fatal(err)
+		} else if known {
+			break
+		} else if i == 100 {
+			t.Fatal("expected records to be replicated")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err = n1.ArchiveThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, rid := range recs {
+		if known, err := n1.isKnown(rid); err != nil {
+			t.Fatal(err)
+		} else if known {
+			t.Fatalf("record %s was not dropped", rid)
+		}
+	}
+	archived, err := n1.store.GetThread(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived.Logs) != 1 || !archived.Logs[0].Head.Equals(recs[len(recs)-1]) {
+		t.Fatal("expected log head to be kept")
+	}
+
+	// reading a record restores the log heads, older records are fetched through the dag service
+	if _, err = n1.GetRecord(ctx, info.ID, recs[len(recs)-1]); err != nil {
+		t.Fatalf("expected record to be rehydrated: %v", err)
+	}
+	if archived, err := n1.isArchived(info.ID); err != nil {
+		t.Fatal(err)
+	} else if archived {
+		t.Fatal("expected thread to be rehydrated")
+	}
+	for i, rid := range recs {
+		if known, err := n1.isKnown(rid); err != nil {
+			t.Fatal(err)
+		} else if known != (i == len(recs)-1) {
+			t.Fatalf("expected only the head to be restored, record %d known: %v", i, known)
+		}
+	}
+
+	// new records extend the rehydrated log
+	body, err := cbornode.WrapObject(map[string]interface{}{"index": 3}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Value().PrevID().Equals(recs[len(recs)-1]) {
+		t.Fatal("expected new record to follow the archived head")
+	}
+}
+
+func TestNet_Embedded(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{PubSub: true, Embedded: true}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the embedded host syncs by pulling from peers...
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, r1.Value().Cid()); err != nil {
+		t.Fatalf("expected record to be pulled: %v", err)
+	}
+	// ...and pushing its own records
+	r2, err := n2.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err = n1.AwaitRecord(actx, info.ID, r2.Value().Cid()); err != nil {
+		t.Fatalf("expected record to be pushed: %v", err)
+	}
+
+	// but doesn't serve the thread protocol
+	if _, err = n1.Host().NewStream(ctx, n2.Host().ID(), thread.Protocol); err == nil {
+		t.Fatal("expected embedded host not to serve peers")
+	}
+	if n2.server.ps != nil {
+		t.Fatal("expected pubsub to be disabled")
+	}
+}
+
+func TestNet_VerifyThread(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	var recs []core.ThreadRecord
+	for i := 0; i < 3; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := n2.VerifyThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.OK() || len(v.Logs) != 1 || v.Logs[0].Records != len(recs) {
+		t.Fatalf("expected intact log of %d records, got %+v", len(recs), v)
+	}
+
+	// drop the middle record, which hides the first one, and the body of the head
+	ev, err := cbor.EventFromRecord(ctx, n2, recs[2].Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []cid.Cid{recs[1].Value().Cid(), ev.BodyID()} {
+		if err = n2.bstore.DeleteBlock(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, err = n2.VerifyThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.OK() || v.Logs[0].Records != 1 || len(v.Logs[0].Damage) != 2 {
+		t.Fatalf("expected damage of 2 blocks, got %+v", v)
+	}
+	for _, d := range v.Logs[0].Damage {
+		if d.Kind != core.DamageMissingBlock {
+			t.Fatalf("expected missing block, got %s", d.Kind)
+		}
+	}
+
+	v, err = n2.VerifyThread(ctx, info.ID, core.WithRepair())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.OK() || v.Logs[0].Records != len(recs) || len(v.Logs[0].Damage) != 2 {
+		t.Fatalf("expected repaired log, got %+v", v)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, recs[0].Value().Cid()); err != nil {
+		t.Fatalf("expected first record to be reachable: %v", err)
+	}
+}
+
+func TestNet_CheckpointVerification(t *testing.T) {
+	t.Parallel()
+	conf := Config{CheckpointVerification: true}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trs, err := n1.CreateRecords(ctx, info.ID, []format.Node{body, body, body, body, body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// n2 catches up with the whole chain
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, tr := range trs {
+		if _, err = n2.GetRecord(ctx, info.ID, tr.Value().Cid()); err != nil {
+			t.Fatalf("expected record %s to be pulled, got %v", tr.Value().Cid(), err)
+		}
+	}
+
+	// the newest record is always verified
+	recs := make([]core.Record, len(trs))
+	for i, tr := range trs {
+		recs[i] = tr.Value()
+	}
+	lg, err := n1.store.GetLog(info.ID, trs[0].LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := lg.PubKey
+	if err = n2.verifyRecords(ctx, recs, pk); err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.verifyRecords(ctx, recs, other); err == nil {
+		t.Fatal("expected chain signed by another key to be rejected")
+	}
+	// unlinked records are verified one by one
+	if err = n2.verifyRecords(ctx, []core.Record{recs[0], recs[2]}, other); err == nil {
+		t.Fatal("expected unlinked records to be verified")
+	}
+}
+
+func TestNet_RecentEvents(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{EventLogSize: 3}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last cid.Cid
+	for i := 0; i < 3; i++ {
+		r, err := n.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = r.Value().Cid()
+	}
+
+	// older events are dropped from the ring
+	evs, err := n.RecentEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 3 {
+		t.Fatalf("expected 3 recent events, got %d", len(evs))
+	}
+	for _, ev := range evs {
+		if ev.Type != core.HeadsChanged || !ev.ThreadID.Equals(info.ID) {
+			t.Fatalf("expected heads of the thread to change, got %s", ev)
+		}
+	}
+	if !evs[2].RecordID.Equals(last) {
+		t.Fatalf("expected newest event last, got %s", evs[2])
+	}
+
+	var buf bytes.Buffer
+	if err = n.DumpEvents(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "HeadsChanged") || !strings.Contains(lines[2], last.String()) {
+		t.Fatalf("unexpected event dump:\n%s", buf.String())
+	}
+
+	// a negative size disables the event log
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{EventLogSize: -1}).(*net)
+	defer n2.Close()
+	createThread(t, ctx, n2)
+	if evs, err = n2.RecentEvents(ctx); err != nil || len(evs) != 0 {
+		t.Fatalf("expected no recent events, got %d (%v)", len(evs), err)
+	}
+}
+
+func TestNet_GetLogsPaging(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{LazyLogs: true}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// idle logs of a large collaboration
+	idle := LogsPageSize + LogsPageSize/2
+	lgs := make([]peerLog, idle)
+	for i := range lgs {
+		sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lid, err := peer.IDFromPrivateKey(sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lgs[i] = peerLog{LogInfo: thread.LogInfo{ID: lid, PubKey: pk}}
+	}
+	if err = n1.createExternalLogsIfNotExist(info.ID, lgs); err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	// logs are received in pages ordered by ID
+	var (
+		pages int
+		seen  = make(map[peer.ID]struct{})
+		last  peer.ID
+	)
+	if err = n2.server.getLogs(ctx, info.ID, n1.Host().ID(), func(page []peerLog, _ thread.Flags, _ *signedFlags) error {
+		pages++
+		for _, l := range page {
+			if l.ID <= last {
+				t.Errorf("expected logs ordered by ID, got %s after %s", l.ID, last)
+			}
+			last = l.ID
+			seen[l.ID] = struct{}{}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 || len(seen) != idle+1 {
+		t.Fatalf("expected %d logs in 2 pages, got %d logs in %d pages", idle+1, len(seen), pages)
+	}
+
+	// idle logs aren't created by lazy hosts
+	info2, err := n2.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info2.Logs) != 2 {
+		t.Fatalf("expected only the log with records and the host log, got %d logs", len(info2.Logs))
+	}
+	for _, l := range lgs {
+		if pk, err := n2.store.PubKey(info.ID, l.ID); err != nil || pk != nil {
+			t.Fatalf("expected idle log %s to be skipped", l.ID)
+		}
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_KeyEscrow(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	var escrows []*net
+	for i := 0; i < 3; i++ {
+		n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{KeyEscrow: true}).(*net)
+		defer n.Close()
+		n1.Host().Peerstore().AddAddrs(n.Host().ID(), n.Host().Addrs(), peerstore.PermanentAddrTTL)
+		n.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+		escrows = append(escrows, n)
+	}
+	n5 := makeNetwork(t).(*net)
+	defer n5.Close()
+	n1.Host().Peerstore().AddAddrs(n5.Host().ID(), n5.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery := thread.NewLibp2pIdentity(sk)
+	peers := []peer.ID{escrows[0].Host().ID(), escrows[1].Host().ID(), escrows[2].Host().ID()}
+	if err := n1.EscrowThreadKey(ctx, info.ID, append(peers, n5.Host().ID()), 2, recovery); status.Code(errors.Unwrap(err)) != codes.Unimplemented {
+		t.Fatalf("expected peers without key escrow to refuse shares, got %v", err)
+	}
+	if err := n1.EscrowThreadKey(ctx, info.ID, peers, 2, recovery); err != nil {
+		t.Fatal(err)
+	}
+
+	// a stale share returned first is outvoted by the others
+	data, err := json.Marshal(nu.KeyShare{Share: []byte("stale"), Threshold: 2, KeyHash: []byte("stale")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = escrows[2].escrow.Put(escrowKey(info.ID, recovery.GetPublic()), data); err != nil {
+		t.Fatal(err)
+	}
+	key, err := n1.RecoverThreadKey(ctx, info.ID, []peer.ID{peers[2], peers[1], peers[0]}, recovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Bytes(), info.Key.Bytes()) {
+		t.Fatal("recovered key doesn't match the thread key")
+	}
+
+	// shares are returned to any host holding the recovery identity
+	for _, n := range escrows[:2] {
+		n.Host().Peerstore().AddAddrs(n5.Host().ID(), n5.Host().Addrs(), peerstore.PermanentAddrTTL)
+		n5.Host().Peerstore().AddAddrs(n.Host().ID(), n.Host().Addrs(), peerstore.PermanentAddrTTL)
+	}
+	key, err = n5.RecoverThreadKey(ctx, info.ID, peers[:2], recovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Bytes(), info.Key.Bytes()) {
+		t.Fatal("recovered key doesn't match the thread key")
+	}
+
+	// but not to other identities
+	sk, _, err = crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n5.RecoverThreadKey(ctx, info.ID, peers[:2], thread.NewLibp2pIdentity(sk)); !errors.Is(err, core.ErrKeyNotRecovered) {
+		t.Fatalf("expected key not to be recovered by another identity, got %v", err)
+	}
+}
+
+func TestNet_RevokeToken(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info, err := n1.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32), core.WithSingleWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	// the replicator learns the writer from the signed flags pushed with the log
+	if writer, err := n2.threadWriter(info.ID); err != nil {
+		t.Fatal(err)
+	} else if writer != info.GetFirstPrivKeyLog().ID {
+		t.Fatalf("expected replicator to have writer %s, got %q", info.GetFirstPrivKeyLog().ID, writer)
+	}
+	other := createThread(t, ctx, n2)
+
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := thread.NewLibp2pIdentity(sk)
+	tok1, err := n1.GetToken(ctx, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok2, err := n1.GetToken(ctx, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tok1.Claims(n1.getPrivKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.ExpiresAt.IsZero() {
+		t.Fatal("expected token not to expire by default")
+	}
+
+	if err = n1.RevokeToken(ctx, tok1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.Validate(info.ID, tok1, true); !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("expected revoked token to be rejected, got %v", err)
+	}
+	if _, err = n1.Validate(info.ID, tok2, true); err != nil {
+		t.Fatalf("expected other tokens of the identity to be accepted, got %v", err)
+	}
+
+	// revoked identities are locked out of the threads written by the host on the replicators too
+	tok3, err := n2.GetToken(ctx, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.RevokeIdentity(ctx, identity.GetPublic(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.Validate(info.ID, tok2, true); !errors.Is(err, core.ErrIdentityRevoked) {
+		t.Fatalf("expected tokens of revoked identity to be rejected, got %v", err)
+	}
+	if _, err = n1.GetToken(ctx, identity); !errors.Is(err, core.ErrIdentityRevoked) {
+		t.Fatalf("expected revoked identity not to get a token, got %v", err)
+	}
+	if _, err = n2.Validate(info.ID, tok3, true); !errors.Is(err, core.ErrIdentityRevoked) {
+		t.Fatalf("expected revocation to be propagated, got %v", err)
+	}
+	if _, err = n2.Validate(other.ID, tok3, true); err != nil {
+		t.Fatalf("expected propagated revocation to apply to the thread only, got %v", err)
+	}
+
+	// revocations not signed by the thread writer are refused
+	raw, err := identity.GetPublic().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := &pb.PushRevocationRequest{
+		Body: &pb.PushRevocationRequest_Body{
+			Identity:  raw,
+			RevokedAt: time.Now().UnixNano(),
+			ThreadID:  &pb.ProtoThreadID{ID: info.ID},
+			Restored:  true,
+		},
+	}
+	payload, err := forged.Body.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forged.Sig, err = sk.Sign(payload); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.pushRevocation(ctx, n2.Host().ID(), forged); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected forged revocation to be refused, got %v", err)
+	}
+
+	// restored identities are accepted again
+	if err = n1.RestoreIdentity(ctx, identity.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.Validate(info.ID, tok2, true); err != nil {
+		t.Fatalf("expected tokens of restored identity to be accepted, got %v", err)
+	}
+	if _, err = n2.Validate(info.ID, tok3, true); err != nil {
+		t.Fatalf("expected restoration to be propagated, got %v", err)
+	}
+
+	// revocations expire
+	if err = n1.RevokeIdentity(ctx, identity.GetPublic(), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.Validate(info.ID, tok2, true); !errors.Is(err, core.ErrIdentityRevoked) {
+		t.Fatalf("expected tokens of revoked identity to be rejected, got %v", err)
+	}
+	time.Sleep(time.Second * 2)
+	if _, err = n1.Validate(info.ID, tok2, true); err != nil {
+		t.Fatalf("expected revocation to expire, got %v", err)
+	}
+}
+
+func TestNet_ReadOnly(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{ReadOnly: true}).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	if _, err := n2.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32)); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected read-only host not to create threads, got %v", err)
+	}
+
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	// the read-only host doesn't get a log of its own
+	addr, err = ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := n2.AddThread(ctx, addr, core.WithThreadKey(info.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own := added.GetFirstPrivKeyLog(); own != nil {
+		t.Fatalf("expected read-only host to have no own log, got %s", own.ID)
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.CreateRecord(ctx, info.ID, body); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected read-only host not to create records, got %v", err)
+	}
+	if _, err = n2.CreateRecords(ctx, info.ID, []format.Node{body}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected read-only host not to create records, got %v", err)
+	}
+
+	// records of peers are still replicated
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err = n2.AwaitRecord(actx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatalf("expected record to be replicated: %v", err)
+	}
+	if err = n2.AddRecord(ctx, info.ID, r.LogID(), r.Value()); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected read-only host not to add records, got %v", err)
+	}
+}
+
+func TestNet_PullThreadConcurrent(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+	// a single worker pulls peers and stores logs one by one
+	n3 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		Sync: core.SyncConfig{MaxConcurrentPulls: 1},
+	}).(*net)
+	defer n3.Close()
+
+	nets := []*net{n1, n2, n3}
+	for _, a := range nets {
+		for _, b := range nets {
+			if a != b {
+				a.Host().Peerstore().AddAddrs(b.Host().ID(), b.Host().Addrs(), peerstore.PermanentAddrTTL)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	var last []core.ThreadRecord
+	for _, n := range []*net{n1, n2} {
+		var r core.ThreadRecord
+		for i := 0; i < 5; i++ {
+			body, err := cbornode.WrapObject(map[string]interface{}{"n": i}, mh.SHA2_256, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r, err = n.CreateRecord(ctx, info.ID, body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		last = append(last, r)
+	}
+	actx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err = n1.AwaitRecord(actx, info.ID, last[1].Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = n3.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n3.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range last {
+		lg, err := n3.store.GetLog(info.ID, r.LogID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !lg.Head.Equals(r.Value().Cid()) {
+			t.Fatalf("expected log %s to be pulled up to %s, got %s", r.LogID(), r.Value().Cid(), lg.Head)
+		}
+	}
+}
+
+func TestNet_LogSeqs(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	lid := info.Logs[0].ID
+	createRecords := func(count int) {
+		for i := 0; i < count; i++ {
+			body, err := cbornode.WrapObject(map[string]interface{}{"i": i}, mh.SHA2_256, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = n1.CreateRecord(ctx, info.ID, body); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	checkSeq := func(n *net, expected uint64) {
+		if seq, err := n.logSeq(info.ID, lid); err != nil {
+			t.Fatal(err)
+		} else if seq != expected {
+			t.Fatalf("expected sequence number %d, got %d", expected, seq)
+		}
+	}
+
+	createRecords(5)
+	checkSeq(n1, 5)
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	checkSeq(n2, 5)
+
+	// the peer tells how far behind the host is
+	createRecords(3)
+	lags := n2.recordsBehind(info.ID, n1.localLogSeqs(info.ID))
+	if len(lags) != 1 || lags[lid] != 3 {
+		t.Fatalf("expected to be 3 records behind in log %s, got %v", lid, lags)
+	}
+	if lagPriority(3) <= lagPriority(1) || lagPriority(1) <= callPriorityLow {
+		t.Fatal("expected priority to grow with the lag")
+	}
+	if err = n2.pullRecordsFromPeer(ctx, n1.Host().ID(), info.ID, lags); err != nil {
+		t.Fatal(err)
+	}
+	checkSeq(n2, 8)
+	if len(n2.recordsBehind(info.ID, n1.localLogSeqs(info.ID))) != 0 {
+		t.Fatal("expected to catch up with the peer")
+	}
+
+	// page sizes fit the missing records if they fit into a single pull
+	req := &pb.GetRecordsRequest{Body: &pb.GetRecordsRequest_Body{Logs: []*pb.GetRecordsRequest_Body_LogEntry{
+		{LogID: &pb.ProtoPeerID{ID: lid}, Limit: 100},
+		{LogID: &pb.ProtoPeerID{ID: n2.Host().ID()}, Limit: 100},
+	}}}
+	applyPageLimits(req, map[peer.ID]uint64{lid: 3}, 100)
+	if req.Body.Logs[0].Limit != 3 || req.Body.Logs[1].Limit != 1 {
+		t.Fatalf("expected page sizes 3 and 1, got %d and %d", req.Body.Logs[0].Limit, req.Body.Logs[1].Limit)
+	}
+	req.Body.Logs[0].Limit, req.Body.Logs[1].Limit = 100, 100
+	applyPageLimits(req, map[peer.ID]uint64{lid: 300}, 100)
+	if req.Body.Logs[0].Limit != 100 || req.Body.Logs[1].Limit != 100 {
+		t.Fatal("expected page sizes to be left if the missing records don't fit")
+	}
+}
+
+func TestNet_Hello(t *testing.T) {
+	t.Parallel()
+	conf := Config{Compression: true}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	caps, err := n1.PeerCapabilities(ctx, n2.Host().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Protocol.Version != core.ProtocolVersion {
+		t.Fatalf("expected protocol version %d, got %d", core.ProtocolVersion, caps.Protocol.Version)
+	}
+	for _, f := range core.Features {
+		if !caps.Protocol.Supports(f) {
+			t.Fatalf("expected peer to support %s", f)
+		}
+	}
+	// the peer learns the host protocol from the hello
+	proto, ok := n2.protocols.get(n1.Host().ID())
+	if !ok || proto.Version != core.ProtocolVersion || !proto.Supports(core.FeatureSubscribe) {
+		t.Fatalf("expected peer to know the host protocol, got %+v", proto)
+	}
+
+	// features are only used with peers advertising them
+	info := createThread(t, ctx, n1)
+	n1.protocols.set(n2.Host().ID(), core.ProtocolInfo{})
+	if _, err := n1.SubscribePeer(ctx, n2.Host().ID(), core.WithSubFilter(info.ID)); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Fatalf("expected unsupported feature error, got %v", err)
+	}
+	if codec := n1.messageCodec(n2.Host().ID()); codec == "" {
+		t.Fatal("expected compression with a peer predating the negotiation advertising codecs")
+	}
+	n1.protocols.set(n2.Host().ID(), core.ProtocolInfo{Version: core.ProtocolVersion})
+	if codec := n1.messageCodec(n2.Host().ID()); codec != "" {
+		t.Fatalf("expected uncompressed messages, got codec %s", codec)
+	}
+	heads := []cid.Cid{generateSequence(cid.Undef, 1)[0].Cid(), generateSequence(cid.Undef, 1)[0].Cid()}
+	pl := n1.downgradeLog(n2.Host().ID(), logToProto(thread.LogInfo{Head: heads[0], Heads: heads}))
+	if len(pl.Heads) != 0 || !pl.Head.Cid.Equals(heads[0]) {
+		t.Fatalf("expected forked heads to be dropped for a peer without multi-head logs, got %v", pl.Heads)
+	}
+
+	// features disabled by the config aren't advertised
+	n3 := makeNetwork(t).(*net)
+	defer n3.Close()
+	n1.Host().Peerstore().AddAddrs(n3.Host().ID(), n3.Host().Addrs(), peerstore.PermanentAddrTTL)
+	if caps, err = n1.PeerCapabilities(ctx, n3.Host().ID()); err != nil {
+		t.Fatal(err)
+	}
+	if caps.Protocol.Supports(core.FeatureCompression) || !caps.Protocol.Supports(core.FeatureMultiHead) {
+		t.Fatalf("expected features enabled by the config, got %v", caps.Protocol.Features)
+	}
 }
@@ -0,0 +1,71 @@
+package netmock
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// aclKey is the metadata key of the thread ACL, stored as JSON.
+	aclKey = "/acl"
+	// bodylessSuffix is appended to the record ID to name the metadata flag of records
+	// stored without the body, which was withheld by the thread ACL of another host.
+	bodylessSuffix = "/bodyless"
+)
+
+// SetThreadACL sets the ACL of a thread. Bodies of the restricted records are withheld from
+// the hosts which aren't readers when they pull or are delivered the records.
+func (n *Net) SetThreadACL(_ context.Context, id thread.ID, acl core.ThreadACL, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	return util.PutMetadataJSON(n.store, id, aclKey, acl)
+}
+
+func (n *Net) ThreadACL(_ context.Context, id thread.ID, opts ...core.ThreadOption) (core.ThreadACL, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return core.ThreadACL{}, err
+	}
+	return n.threadACL(id)
+}
+
+func (n *Net) threadACL(id thread.ID) (core.ThreadACL, error) {
+	var acl core.ThreadACL
+	return acl, util.GetMetadataJSON(n.store, id, aclKey, &acl)
+}
+
+// bodyRestriction returns whether the ACL of a thread withholds the body of a log record
+// from the host pid, or nil if the host may receive all bodies.
+func (n *Net) bodyRestriction(id thread.ID, pid peer.ID) (func(lid peer.ID, rid cid.Cid) bool, error) {
+	acl, err := n.threadACL(id)
+	if err != nil || !acl.Withholds(pid) {
+		return nil, err
+	}
+	return acl.Restricts, nil
+}
+
+// isBodyless returns whether the record is stored without the body.
+func (n *Net) isBodyless(id thread.ID, rid cid.Cid) bool {
+	bodyless, err := n.store.GetBool(id, rid.String()+bodylessSuffix)
+	if err != nil {
+		log.Errorf("getting body flag of record %s: %v", rid, err)
+		return false
+	}
+	return bodyless != nil && *bodyless
+}
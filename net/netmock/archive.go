@@ -0,0 +1,148 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+func (n *Net) ExportThread(ctx context.Context, id thread.ID, w io.Writer, opts ...core.ExportOption) error {
+	args := &core.ExportOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return err
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return fmt.Errorf("a service-key is required to export a thread")
+	}
+	manifest := util.ArchiveManifest{Thread: id.Bytes()}
+	if args.Keys {
+		manifest.Key = info.Key.Bytes()
+	}
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return fmt.Errorf("log %s: %w", lg.ID, err)
+		}
+		al, err := util.NewArchiveLog(lg, boundary, args.Keys)
+		if err != nil {
+			return fmt.Errorf("log %s: %w", lg.ID, err)
+		}
+		manifest.Logs = append(manifest.Logs, al)
+	}
+	_, err = util.WriteArchive(ctx, w, manifest, n, sk)
+	return err
+}
+
+// ImportThread adds the thread of an archive written by ExportThread, of the in-memory net or the
+// net package. Records already known are skipped.
+func (n *Net) ImportThread(ctx context.Context, r io.Reader, opts ...core.NewThreadOption) (info thread.Info, err error) {
+	args := &core.NewThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	cr, manifest, err := util.ReadArchiveManifest(r)
+	if err != nil {
+		return
+	}
+	id, err := thread.Cast(manifest.Thread)
+	if err != nil {
+		return
+	}
+	if _, err = n.Validate(id, args.Token, false); err != nil {
+		return
+	}
+	key := args.ThreadKey
+	if !key.Defined() && manifest.Key != nil {
+		if key, err = thread.KeyFromBytes(manifest.Key); err != nil {
+			return
+		}
+	}
+	if !key.Defined() {
+		return info, fmt.Errorf("a thread key is required to import a thread archive without keys")
+	}
+	logs := make([]thread.LogInfo, len(manifest.Logs))
+	for i, al := range manifest.Logs {
+		if logs[i], err = al.LogInfo(); err != nil {
+			return
+		}
+	}
+
+	// blocks are staged before their records are added, so they must survive GC
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if _, err = n.store.GetThread(id); err != nil {
+		if err = n.store.AddThread(thread.Info{ID: id, Key: key}); err != nil {
+			return
+		}
+		n.emit(core.LifecycleEvent{Type: core.ThreadAdded, ThreadID: id})
+	}
+	for _, lg := range logs {
+		if pk, err := n.store.PubKey(id, lg.ID); err != nil {
+			return info, err
+		} else if pk != nil {
+			continue
+		}
+		lg.Managed = lg.PrivKey != nil
+		if err = n.store.AddLog(id, lg); err != nil {
+			return
+		}
+		n.emit(core.LifecycleEvent{Type: core.LogAdded, ThreadID: id, LogID: lg.ID})
+	}
+	archived, err := util.ReadArchivedRecords(ctx, cr, n, manifest.Logs, key.Service())
+	if err != nil {
+		return
+	}
+	for i, al := range manifest.Logs {
+		for _, head := range al.Heads {
+			chain, err := n.unknownRecords(archived.Chain(head, al.Boundary))
+			if err != nil {
+				return info, err
+			}
+			if err = archived.CheckChain(ctx, n, chain); err != nil {
+				return info, fmt.Errorf("log %s: %w", logs[i].ID, err)
+			}
+			if len(chain) > 0 && chain[0].Cid().Equals(al.Boundary) {
+				if err = n.adoptBoundary(id, logs[i].ID, chain[0]); err != nil {
+					return info, err
+				}
+			}
+			src := core.RecordSource{Kind: core.SourceLocal, ReceivedAt: time.Now()}
+			if err = n.putRecords(ctx, n, id, logs[i].ID, chain, src, nil); err != nil {
+				return info, fmt.Errorf("log %s: %w", logs[i].ID, err)
+			}
+		}
+	}
+	return n.getThread(id)
+}
+
+// unknownRecords drops the records of an archived chain which are known already.
+func (n *Net) unknownRecords(chain []core.Record) ([]core.Record, error) {
+	for len(chain) > 0 {
+		if known, err := n.bstore.Has(chain[0].Cid()); err != nil {
+			return nil, err
+		} else if !known {
+			break
+		}
+		chain = chain[1:]
+	}
+	return chain, nil
+}
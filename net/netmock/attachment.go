@@ -0,0 +1,85 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+)
+
+func (n *Net) AddAttachment(ctx context.Context, id thread.ID, r io.Reader, opts ...core.AttachmentOption) (cid.Cid, error) {
+	args := &core.AttachmentOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return cid.Undef, err
+	}
+	var key crypto.EncryptionKey
+	if !args.Plain {
+		rk, err := n.store.ReadKey(id)
+		if err != nil {
+			return cid.Undef, err
+		}
+		if rk == nil {
+			return cid.Undef, fmt.Errorf("a read-key is required to add encrypted attachments")
+		}
+		key = rk
+	}
+	root, err := cbor.CreateAttachment(ctx, n, r, key)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return root.Cid(), nil
+}
+
+// GetAttachment returns a reader of the attachment data. The dag service of the in-memory net is
+// offline, so attachments missing locally are copied from a host of the thread first.
+func (n *Net) GetAttachment(ctx context.Context, id thread.ID, aid cid.Cid, opts ...core.AttachmentOption) (io.Reader, error) {
+	args := &core.AttachmentOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	if err := n.fetchAttachment(ctx, id, aid); err != nil {
+		return nil, err
+	}
+	var key crypto.DecryptionKey
+	rk, err := n.store.ReadKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if rk != nil {
+		key = rk
+	}
+	return cbor.GetAttachment(ctx, n, aid, key)
+}
+
+// fetchAttachment copies the blocks of an attachment missing locally from the first host of the
+// thread holding it.
+func (n *Net) fetchAttachment(ctx context.Context, id thread.ID, aid cid.Cid) error {
+	if known, err := n.bstore.Has(aid); err != nil || known {
+		return err
+	}
+	for _, pid := range n.replicas(id) {
+		h, err := n.nw.dial(ctx, n.id, pid)
+		if err != nil {
+			continue
+		}
+		if err = h.checkCapability(n, id, fetchRights); err != nil {
+			continue
+		}
+		if known, err := h.bstore.Has(aid); err != nil || !known {
+			continue
+		}
+		return copyDAG(ctx, h, n, aid)
+	}
+	return fmt.Errorf("attachment %s not found", aid)
+}
@@ -0,0 +1,287 @@
+package netmock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// trustAnchorKey is the metadata key of the imported verification bundle of a thread.
+const trustAnchorKey = "/trust-anchor"
+
+// SampleRecords samples records of every log of a thread in the format of the net package.
+// Records of the in-memory net aren't numbered, so every sample carries its whole log.
+func (n *Net) SampleRecords(ctx context.Context, id thread.ID, nonce []byte, k int, opts ...core.ThreadOption) (sample core.ThreadSample, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	if k <= 0 {
+		return sample, fmt.Errorf("sample size must be positive")
+	}
+	if err = n.rehydrateThread(ctx, id); err != nil {
+		return
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return sample, fmt.Errorf("a service-key is required to sample records")
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	sample.ThreadID = id
+	chains := make(map[peer.ID][][]byte, len(info.Logs))
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		var chain [][]byte
+		for rid := lg.Head; rid.Defined(); {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			rec, err := cbor.GetRecord(ctx, n, rid, sk)
+			if err != nil {
+				return sample, fmt.Errorf("getting record %s: %w", rid, err)
+			}
+			node, err := n.Get(ctx, rid)
+			if err != nil {
+				return sample, fmt.Errorf("getting record node %s: %w", rid, err)
+			}
+			chain = append(chain, node.RawData())
+			rid = rec.PrevID()
+		}
+		pk, err := ic.MarshalPublicKey(lg.PubKey)
+		if err != nil {
+			return sample, err
+		}
+		sample.Logs = append(sample.Logs, core.LogSample{
+			ID:     lg.ID,
+			PubKey: pk,
+			Head:   lg.Head,
+			Length: len(chain),
+		})
+		chains[lg.ID] = chain
+	}
+	sort.Slice(sample.Logs, func(i, j int) bool { return sample.Logs[i].ID < sample.Logs[j].ID })
+
+	indices := core.SampleIndices(id, nonce, k, sample.Logs)
+	for i := range sample.Logs {
+		sample.Logs[i].Indices = indices[i]
+		sample.Logs[i].Chain = chains[sample.Logs[i].ID]
+	}
+	return sample, nil
+}
+
+// VerifyThread walks every log of a thread from the head down to the compaction boundary.
+// With the Repair option, damaged records are copied again from the other hosts of the thread.
+func (n *Net) VerifyThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) (v core.ThreadVerification, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, !args.Repair); err != nil {
+		return
+	}
+	if err = n.loadThread(id); err != nil {
+		return
+	}
+	// blocks of archived threads are missing on purpose
+	if err = n.rehydrateThread(ctx, id); err != nil {
+		return
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return v, fmt.Errorf("a service-key is required to verify a thread")
+	}
+	v.ThreadID = id
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return v, err
+		}
+		var lv core.LogVerification
+		if args.Repair {
+			lv, err = n.repairLog(ctx, id, lg, sk, boundary)
+		} else {
+			lv, err = n.verifyLog(ctx, id, lg, sk, boundary)
+		}
+		if err != nil {
+			return v, fmt.Errorf("verifying log %s: %w", lg.ID, err)
+		}
+		v.Logs = append(v.Logs, lv)
+	}
+	sort.Slice(v.Logs, func(i, j int) bool {
+		return v.Logs[i].ID < v.Logs[j].ID
+	})
+	return v, nil
+}
+
+// verifyLog walks a log down to the start or the compaction boundary. Bodies withheld by the ACL
+// of the sender aren't missing. The caller must hold the host lock.
+func (n *Net) verifyLog(ctx context.Context, id thread.ID, lg thread.LogInfo, sk *sym.Key, boundary cid.Cid) (core.LogVerification, error) {
+	return util.VerifyLog(ctx, n.bstore, lg, sk, boundary, func(rid cid.Cid) bool {
+		return n.isBodyless(id, rid)
+	})
+}
+
+// repairLog verifies a log and copies its damaged records again from the first host of the thread
+// holding them. The caller must hold the host lock.
+func (n *Net) repairLog(ctx context.Context, id thread.ID, lg thread.LogInfo, sk *sym.Key, boundary cid.Cid) (core.LogVerification, error) {
+	return util.RepairLog(func() (core.LogVerification, error) {
+		return n.verifyLog(ctx, id, lg, sk, boundary)
+	}, func(d core.LogDamage) error {
+		if err := n.repairRecord(ctx, id, d, sk); err != nil {
+			log.Warnf("repairing record %s of log %s (thread=%s): %v", d.Record, lg.ID, id, err)
+		}
+		return nil
+	})
+}
+
+// repairRecord copies the blocks of a damaged record again. Damaged blocks are dropped first,
+// since stored blocks aren't overwritten.
+func (n *Net) repairRecord(ctx context.Context, id thread.ID, d core.LogDamage, sk *sym.Key) error {
+	for _, pid := range n.replicas(id) {
+		h, err := n.nw.dial(ctx, n.id, pid)
+		if err != nil {
+			continue
+		}
+		if err = h.checkCapability(n, id, fetchRights); err != nil {
+			continue
+		}
+		if known, err := h.bstore.Has(d.Record); err != nil || !known {
+			continue
+		}
+		rec, err := cbor.GetRecord(ctx, h, d.Record, sk)
+		if err != nil {
+			continue
+		}
+		if d.Kind != core.DamageMissingBlock {
+			if err = n.bstore.DeleteBlock(d.Block); err != nil {
+				return err
+			}
+		}
+		if err = copyEvent(ctx, h, n, rec.BlockID(), !n.isBodyless(id, d.Record)); err != nil {
+			return err
+		}
+		return n.Add(ctx, rec)
+	}
+	return fmt.Errorf("no host of the thread holds the record")
+}
+
+func (n *Net) ExportVerificationBundle(_ context.Context, id thread.ID, opts ...core.ThreadOption) (bundle core.VerificationBundle, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+	if info.Key.Service() == nil {
+		return bundle, fmt.Errorf("a service-key is required to export a verification bundle")
+	}
+	return core.NewVerificationBundle(id, info.Key.Service(), info.Logs)
+}
+
+func (n *Net) ImportVerificationBundle(_ context.Context, bundle core.VerificationBundle, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(bundle.ThreadID, args.Token, false); err != nil {
+		return err
+	}
+	if err := bundle.Validate(); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	// the bundle must agree with what is already known about the thread
+	sk, err := n.store.ServiceKey(bundle.ThreadID)
+	if err != nil {
+		return err
+	}
+	if sk != nil {
+		if err = bundle.CheckServiceKey(sk); err != nil {
+			return err
+		}
+	}
+	for _, l := range bundle.Logs {
+		pk, err := n.store.PubKey(bundle.ThreadID, l.ID)
+		if err != nil {
+			return err
+		}
+		if pk == nil {
+			continue
+		}
+		if _, err = bundle.CheckLogKey(l.ID, pk); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	return n.store.PutBytes(bundle.ThreadID, trustAnchorKey, data)
+}
+
+// checkTrustedLogKey ensures that the key of a log pulled from another host matches the thread
+// trust anchor. Logs missing from the anchor are only accepted from their owner, i.e., a host
+// holding the log private key, standing in for the signed log addresses of the net package.
+func (n *Net) checkTrustedLogKey(from *Net, id thread.ID, lid peer.ID, pk ic.PubKey) error {
+	if pk == nil {
+		return nil
+	}
+	if !lid.MatchesPublicKey(pk) {
+		return fmt.Errorf("log %s: public key doesn't match log ID", lid)
+	}
+	data, err := n.store.GetBytes(id, trustAnchorKey)
+	if err != nil || data == nil {
+		return err
+	}
+	var bundle core.VerificationBundle
+	if err = json.Unmarshal(*data, &bundle); err != nil {
+		return fmt.Errorf("decoding trust anchor: %w", err)
+	}
+	if listed, err := bundle.CheckLogKey(lid, pk); listed || err != nil {
+		return err
+	}
+	if owned, err := from.store.PrivKey(id, lid); err != nil {
+		return err
+	} else if owned == nil {
+		return fmt.Errorf("log %s: %w: log isn't in the anchor", lid, core.ErrUntrustedLog)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package netmock
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// capabilityRootBytes is the byte length of capability root keys.
+const capabilityRootBytes = 32
+
+// Rights a capability must grant to the requests between hosts, like in the real net.
+const (
+	fetchRights   = thread.RightRead | thread.RightReplicate
+	pushRights    = thread.RightAppend | thread.RightReplicate
+	pushLogRights = thread.RightAppend
+)
+
+var (
+	// TokenChallengeTimeout is the time a token challenge may be answered in.
+	TokenChallengeTimeout = time.Minute
+
+	// ErrCapabilityRequired indicates a host which didn't present a capability for a thread
+	// to a host requiring it, see Network.RequireCapabilities.
+	ErrCapabilityRequired = errors.New("a capability is required")
+)
+
+// tokenChallenge is a challenge issued to a remote identity.
+type tokenChallenge struct {
+	key     thread.PubKey
+	expires time.Time
+}
+
+// revocation is a revoked identity, which expires unless the expiry is zero.
+type revocation struct {
+	at      time.Time
+	expires time.Time
+}
+
+// active returns whether the revocation applies at the given time.
+func (r revocation) active(now time.Time) bool {
+	return r.expires.IsZero() || now.Before(r.expires)
+}
+
+func (n *Net) GetTokenChallenge(_ context.Context, key thread.PubKey) ([]byte, time.Time, error) {
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		return nil, time.Time{}, err
+	}
+	expires := time.Now().Add(TokenChallengeTimeout)
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	n.challenges[string(msg)] = tokenChallenge{key: key, expires: expires}
+	return msg, expires, nil
+}
+
+func (n *Net) GetTokenWithChallenge(_ context.Context, key thread.PubKey, challenge, sig []byte) (tok thread.Token, err error) {
+	n.mx.Lock()
+	ch, ok := n.challenges[string(challenge)]
+	delete(n.challenges, string(challenge))
+	n.mx.Unlock()
+	if !ok || time.Now().After(ch.expires) {
+		return tok, core.ErrTokenChallengeNotFound
+	}
+	if !ch.key.Equals(key) {
+		return tok, fmt.Errorf("challenge was issued to another key")
+	}
+	if ok, err := key.Verify(challenge, sig); !ok || err != nil {
+		return tok, fmt.Errorf("bad signature")
+	}
+	return n.issueToken(key)
+}
+
+// issueToken returns a token for the identity, unless it was revoked.
+func (n *Net) issueToken(key thread.PubKey) (thread.Token, error) {
+	n.mx.Lock()
+	rev, ok := n.revokedIdentities[key.String()]
+	n.mx.Unlock()
+	if ok && rev.active(time.Now()) {
+		return "", core.ErrIdentityRevoked
+	}
+	return thread.NewToken(n.sk, key)
+}
+
+func (n *Net) RevokeToken(_ context.Context, token thread.Token) error {
+	claims, err := token.Claims(n.sk)
+	if errors.Is(err, thread.ErrTokenExpired) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if claims.PubKey == nil {
+		return thread.ErrTokenNotFound
+	}
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	n.revokedTokens[util.TokenID(token, claims)] = struct{}{}
+	return nil
+}
+
+// RevokeIdentity revokes an identity on the host, and in the threads the host writes to on
+// the other hosts of the threads. The in-memory net has no single-writer threads, so the
+// revocation reaches the peers of every thread with a log of the host.
+func (n *Net) RevokeIdentity(_ context.Context, identity thread.PubKey, ttl time.Duration) error {
+	if identity == nil {
+		return fmt.Errorf("identity is required")
+	}
+	rev := revocation{at: time.Now()}
+	if ttl > 0 {
+		rev.expires = rev.at.Add(ttl)
+	}
+	n.mx.Lock()
+	n.revokedIdentities[identity.String()] = rev
+	n.mx.Unlock()
+	n.propagateRevocation(identity, &rev)
+	return nil
+}
+
+// RestoreIdentity lifts a revocation of an identity, also in the threads it was propagated to.
+func (n *Net) RestoreIdentity(_ context.Context, identity thread.PubKey) error {
+	if identity == nil {
+		return fmt.Errorf("identity is required")
+	}
+	n.mx.Lock()
+	delete(n.revokedIdentities, identity.String())
+	n.mx.Unlock()
+	n.propagateRevocation(identity, nil)
+	return nil
+}
+
+// propagateRevocation applies a revocation of an identity to the reachable hosts of the threads
+// the host writes to, or lifts it if rev is nil. Unreachable hosts are skipped.
+func (n *Net) propagateRevocation(identity thread.PubKey, rev *revocation) {
+	ids, err := n.store.Threads()
+	if err != nil {
+		log.Errorf("listing threads: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if logs, err := n.store.LogsWithKeys(id); err != nil || len(logs) == 0 {
+			continue
+		}
+		for _, pid := range n.replicas(id) {
+			h, err := n.nw.dial(n.ctx, n.id, pid)
+			if err != nil {
+				log.Debugf("propagating revocation of %s to %s failed: %v", identity, pid, err)
+				continue
+			}
+			h.revokeMember(id, identity, rev)
+		}
+	}
+}
+
+// revokeMember applies a revocation of an identity in a thread, or lifts it if rev is nil.
+func (n *Net) revokeMember(id thread.ID, identity thread.PubKey, rev *revocation) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	members, ok := n.revokedMembers[id]
+	if !ok {
+		members = make(map[string]revocation)
+		n.revokedMembers[id] = members
+	}
+	if rev == nil {
+		delete(members, identity.String())
+	} else {
+		members[identity.String()] = *rev
+	}
+}
+
+// checkToken fails if a token or its identity was revoked, also by a writer of the thread.
+func (n *Net) checkToken(id thread.ID, token thread.Token, claims thread.TokenClaims) error {
+	now := time.Now()
+	key := claims.PubKey.String()
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if rev, ok := n.revokedIdentities[key]; ok && rev.active(now) {
+		return core.ErrIdentityRevoked
+	}
+	if rev, ok := n.revokedMembers[id][key]; ok && rev.active(now) {
+		return core.ErrIdentityRevoked
+	}
+	if _, ok := n.revokedTokens[util.TokenID(token, claims)]; ok {
+		return core.ErrTokenRevoked
+	}
+	return nil
+}
+
+func (n *Net) MintCapability(_ context.Context, id thread.ID, caveats []thread.Caveat, opts ...core.ThreadOption) (thread.Capability, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return "", err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return "", err
+	}
+	n.mx.Lock()
+	root, ok := n.capabilityRoots[id]
+	n.mx.Unlock()
+	if !ok {
+		var err error
+		if root, err = n.rotateCapabilities(id); err != nil {
+			return "", err
+		}
+	}
+	return thread.NewCapability(root, id, caveats...)
+}
+
+func (n *Net) AddCapability(_ context.Context, capability thread.Capability) error {
+	id, err := capability.Thread()
+	if err != nil {
+		return err
+	}
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	n.granted[id] = capability
+	return nil
+}
+
+func (n *Net) RevokeCapabilities(_ context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	n.mx.Lock()
+	_, ok := n.capabilityRoots[id]
+	n.mx.Unlock()
+	if !ok {
+		return nil
+	}
+	_, err := n.rotateCapabilities(id)
+	return err
+}
+
+// rotateCapabilities replaces the root key of the thread capabilities, invalidating all minted ones.
+func (n *Net) rotateCapabilities(id thread.ID) ([]byte, error) {
+	root := make([]byte, capabilityRootBytes)
+	if _, err := rand.Read(root); err != nil {
+		return nil, err
+	}
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	n.capabilityRoots[id] = root
+	return root, nil
+}
+
+// checkCapability fails if the host requires a capability granting any of the rights on a thread
+// it minted capabilities for, and the capability granted to the host from isn't one.
+func (n *Net) checkCapability(from *Net, id thread.ID, rights thread.CapabilityRights) error {
+	if !n.nw.requiresCapabilities() {
+		return nil
+	}
+	n.mx.Lock()
+	root, minted := n.capabilityRoots[id]
+	n.mx.Unlock()
+	if !minted {
+		return nil
+	}
+	from.mx.Lock()
+	c, ok := from.granted[id]
+	from.mx.Unlock()
+	if !ok {
+		return ErrCapabilityRequired
+	}
+	claims, err := c.Verify(root, from.id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !claims.Allows(rights) {
+		return fmt.Errorf("capability doesn't grant the request")
+	}
+	return nil
+}
+
+// removeThreadAuth drops the member revocations and capabilities of a deleted thread.
+func (n *Net) removeThreadAuth(id thread.ID) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	delete(n.revokedMembers, id)
+	delete(n.capabilityRoots, id)
+	delete(n.granted, id)
+}
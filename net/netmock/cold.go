@@ -0,0 +1,250 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// unloadedKey is the metadata key marking a thread with released in-memory state.
+	unloadedKey = "/unloaded"
+	// archivedKey is the metadata key marking a thread with dropped block data.
+	archivedKey = "/archived"
+)
+
+// UnloadThread marks a thread as unloaded, keeping its data. Hosts keep no in-memory state of
+// threads besides their pull status, which is dropped, and unloaded threads aren't listed by
+// Topics. The thread is loaded again once it's pulled or written to.
+func (n *Net) UnloadThread(_ context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot unload thread: %w", app.ErrThreadInUse)
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err := n.store.PutBool(id, unloadedKey, true); err != nil {
+		return err
+	}
+	n.sx.Lock()
+	delete(n.pullStatus, id)
+	n.sx.Unlock()
+	return nil
+}
+
+// loadThread clears the unloaded mark of a thread. It's a no-op for loaded threads.
+func (n *Net) loadThread(id thread.ID) error {
+	if unloaded, err := n.isUnloaded(id); err != nil || !unloaded {
+		return err
+	}
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return n.store.PutBool(id, unloadedKey, false)
+}
+
+// isUnloaded returns whether the thread was unloaded with UnloadThread.
+func (n *Net) isUnloaded(id thread.ID) (bool, error) {
+	unloaded, err := n.store.GetBool(id, unloadedKey)
+	if err != nil || unloaded == nil {
+		return false, err
+	}
+	return *unloaded, nil
+}
+
+// ArchiveThread drops the blocks of a thread, keeping its logstore metadata, i.e., keys, logs
+// and heads. A host of the thread must hold the log heads first, so the dropped records can be
+// copied again. The thread is marked as archived and its records are restored once they're
+// needed, e.g., by GetRecord, PullThread or new records. Archived threads don't serve records
+// to other hosts.
+func (n *Net) ArchiveThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot archive thread: %w", app.ErrThreadInUse)
+	}
+	if archived, err := n.isArchived(id); err != nil || archived {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	if info.Key.Service() == nil {
+		return fmt.Errorf("a service-key is required to archive a thread")
+	}
+	if err = n.confirmReplicated(ctx, info); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	current, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	if !util.SameHeads(info, current) {
+		return fmt.Errorf("cannot archive thread: log heads changed while confirming replicas")
+	}
+	var blocks []cid.Cid
+	if err = n.walkLogs(ctx, id, func(_ peer.ID, rid cid.Cid, ev *cbor.Event) {
+		blocks = append(append(blocks, rid, ev.Cid(), ev.HeaderID(), ev.BodyID()), util.LocalBodyChunks(n.bstore, ev.BodyID())...)
+	}); err != nil {
+		return err
+	}
+	// the thread is marked first, so its blocks aren't live anymore
+	if err = n.store.PutBool(id, archivedKey, true); err != nil {
+		return err
+	}
+	live, _, err := n.markLive(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = n.sweep(ctx, blocks, live)
+	return err
+}
+
+// confirmReplicated returns core.ErrNotReplicated unless a reachable host of the thread holds the
+// heads of every log of the thread. Hosts store records in log order, so they hold the older
+// records as well.
+func (n *Net) confirmReplicated(ctx context.Context, info thread.Info) error {
+	for _, pid := range n.replicas(info.ID) {
+		if err := n.servesHeads(ctx, info, pid); err != nil {
+			log.Debugf("confirming replica %s of thread %s: %v", pid, info.ID, err)
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: no host of thread %s holds its log heads", core.ErrNotReplicated, info.ID)
+}
+
+// servesHeads fails unless a host serves the records of the thread up to the heads of every log.
+func (n *Net) servesHeads(ctx context.Context, info thread.Info, pid peer.ID) error {
+	h, err := n.nw.dial(ctx, n.id, pid)
+	if err != nil {
+		return err
+	}
+	if err = h.checkCapability(n, info.ID, fetchRights); err != nil {
+		return err
+	}
+	if archived, err := h.isArchived(info.ID); err != nil {
+		return err
+	} else if archived {
+		return fmt.Errorf("thread is archived")
+	}
+	for _, lg := range info.Logs {
+		head, err := h.head(info.ID, lg.ID)
+		if err != nil {
+			return err
+		}
+		if !head.Equals(lg.Head) {
+			return fmt.Errorf("log %s is at %s", lg.ID, head)
+		}
+	}
+	return nil
+}
+
+// isArchived returns whether the blocks of a thread were dropped with ArchiveThread.
+func (n *Net) isArchived(id thread.ID) (bool, error) {
+	archived, err := n.store.GetBool(id, archivedKey)
+	if err != nil || archived == nil {
+		return false, err
+	}
+	return *archived, nil
+}
+
+// rehydrateThread copies the records of an archived thread back from a host of the thread, down
+// to the compaction boundaries, and clears the mark. The dag service of the in-memory net is
+// offline, so the whole logs are restored at once. It's a no-op for threads which aren't archived.
+func (n *Net) rehydrateThread(ctx context.Context, id thread.ID) error {
+	if archived, err := n.isArchived(id); err != nil || !archived {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	// the thread may have been rehydrated concurrently
+	if archived, err := n.isArchived(id); err != nil || !archived {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	if info.Key.Service() == nil {
+		return fmt.Errorf("a service-key is required to rehydrate a thread")
+	}
+	for _, pid := range n.replicas(id) {
+		if err = n.servesHeads(ctx, info, pid); err != nil {
+			log.Debugf("rehydrating thread %s from %s: %v", id, pid, err)
+			continue
+		}
+		h, err := n.nw.dial(ctx, n.id, pid)
+		if err != nil {
+			continue
+		}
+		if err = n.restoreLogs(ctx, h, info); err != nil {
+			log.Debugf("rehydrating thread %s from %s: %v", id, pid, err)
+			continue
+		}
+		return n.store.PutBool(id, archivedKey, false)
+	}
+	return fmt.Errorf("rehydrating thread %s: %w", id, core.ErrNotReplicated)
+}
+
+// restoreLogs copies the records of every log of a thread from another host, from the log heads
+// down to the local compaction boundaries. The caller must hold the host lock.
+func (n *Net) restoreLogs(ctx context.Context, from *Net, info thread.Info) error {
+	sk := info.Key.Service()
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, info.ID, lg.ID, boundarySuffix)
+		if err != nil {
+			return err
+		}
+		for rid := lg.Head; rid.Defined(); {
+			rec, err := cbor.GetRecord(ctx, from, rid, sk)
+			if err != nil {
+				return err
+			}
+			bodyless := n.isBodyless(info.ID, rid)
+			if !bodyless && from.isBodyless(info.ID, rid) {
+				if err = n.store.PutBool(info.ID, rid.String()+bodylessSuffix, true); err != nil {
+					return err
+				}
+				bodyless = true
+			}
+			if err = copyEvent(ctx, from, n, rec.BlockID(), !bodyless); err != nil {
+				return err
+			}
+			if err = n.Add(ctx, rec); err != nil {
+				return err
+			}
+			if rid.Equals(boundary) {
+				break
+			}
+			rid = rec.PrevID()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// deadLettersKey is the metadata key of the dead letters of a thread, stored as JSON by record ID.
+const deadLettersKey = "/dead-letters"
+
+var (
+	// DeadLetterAttempts is the number of attempts to handle a record received from another host
+	// before it's skipped. Records are retried until they're handled if it isn't positive.
+	DeadLetterAttempts = 5
+)
+
+func (n *Net) DeadLetters(_ context.Context, id thread.ID, opts ...core.ThreadOption) ([]core.DeadLetter, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	dls, err := n.deadLetters(id)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]core.DeadLetter, 0, len(dls))
+	for _, dl := range dls {
+		res = append(res, dl)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].FailedAt.After(res[j].FailedAt) })
+	return res, nil
+}
+
+func (n *Net) ReplayDeadLetter(ctx context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	con, ok := n.getConnectorProtected(id, args.APIToken)
+	if !ok {
+		return fmt.Errorf("cannot replay record: %w", app.ErrThreadInUse)
+	} else if con == nil {
+		return fmt.Errorf("cannot replay record: thread %s has no app connected", id)
+	}
+
+	n.lk.Lock()
+	dls, err := n.deadLetters(id)
+	n.lk.Unlock()
+	if err != nil {
+		return err
+	}
+	dl, ok := dls[rid.String()]
+	if !ok {
+		return core.ErrDeadLetterNotFound
+	} else if !dl.Skipped {
+		return fmt.Errorf("cannot replay record %s: it's handled again once received", rid)
+	}
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return err
+	}
+	rec, err := cbor.GetRecord(ctx, n, rid, sk)
+	if err != nil {
+		return err
+	}
+	// the host lock isn't held while handling, the app may create records meanwhile
+	tr := &Record{Record: rec, threadID: id, logID: dl.LogID, source: core.RecordSource{Kind: core.SourceUnknown}}
+	if err = con.HandleNetRecord(ctx, tr); err != nil {
+		n.lk.Lock()
+		if _, ferr := n.failDeadLetter(id, dl.LogID, rid, err); ferr != nil {
+			log.Errorf("recording failure of record %s: %v", rid, ferr)
+		}
+		n.lk.Unlock()
+		return fmt.Errorf("handling record failed: %w", err)
+	}
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return n.dropDeadLetter(id, rid)
+}
+
+func (n *Net) DiscardDeadLetter(_ context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	dls, err := n.deadLetters(id)
+	if err != nil {
+		return err
+	}
+	if _, ok := dls[rid.String()]; !ok {
+		return core.ErrDeadLetterNotFound
+	}
+	return n.dropDeadLetter(id, rid)
+}
+
+// handleRecord hands a record received from another host to the app. Failed attempts are kept as
+// dead letters, and fail the record until the attempts are exhausted, then the record is skipped.
+// The caller must hold the host lock.
+func (n *Net) handleRecord(ctx context.Context, con *app.Connector, tr *Record) error {
+	rid := tr.Value().Cid()
+	err := con.HandleNetRecord(ctx, tr)
+	if err == nil {
+		return n.dropDeadLetter(tr.threadID, rid)
+	}
+	skipped, ferr := n.failDeadLetter(tr.threadID, tr.logID, rid, err)
+	if ferr != nil {
+		return ferr
+	}
+	if !skipped {
+		return fmt.Errorf("handling record failed: %w", err)
+	}
+	log.Warnf("skipping record %s (thread=%s) after failed attempts: %v", rid, tr.threadID, err)
+	return nil
+}
+
+// deadLetters returns the dead letters of a thread by record ID. The caller must hold the host lock.
+func (n *Net) deadLetters(id thread.ID) (map[string]core.DeadLetter, error) {
+	dls := make(map[string]core.DeadLetter)
+	return dls, util.GetMetadataJSON(n.store, id, deadLettersKey, &dls)
+}
+
+// failDeadLetter records a failed attempt to handle a record, and returns whether the attempts
+// are exhausted, so the record must be skipped. The caller must hold the host lock.
+func (n *Net) failDeadLetter(id thread.ID, lid peer.ID, rid cid.Cid, cause error) (bool, error) {
+	dls, err := n.deadLetters(id)
+	if err != nil {
+		return false, err
+	}
+	dl := dls[rid.String()]
+	dl.LogID = lid
+	dl.RecordID = rid
+	dl.Attempts++
+	dl.Err = cause.Error()
+	dl.FailedAt = time.Now()
+	if DeadLetterAttempts > 0 && dl.Attempts >= DeadLetterAttempts {
+		dl.Skipped = true
+	}
+	dls[rid.String()] = dl
+	return dl.Skipped, util.PutMetadataJSON(n.store, id, deadLettersKey, dls)
+}
+
+// dropDeadLetter removes the dead letter of a record if there is one. The caller must hold the
+// host lock.
+func (n *Net) dropDeadLetter(id thread.ID, rid cid.Cid) error {
+	dls, err := n.deadLetters(id)
+	if err != nil {
+		return err
+	}
+	if _, ok := dls[rid.String()]; !ok {
+		return nil
+	}
+	delete(dls, rid.String())
+	return util.PutMetadataJSON(n.store, id, deadLettersKey, dls)
+}
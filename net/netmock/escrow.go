@@ -0,0 +1,181 @@
+package netmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// EscrowThreadKey splits the key of a thread with Shamir secret sharing and deposits a share with
+// every recovery host. The shares are kept for the recovery identity and only returned to a
+// holder of it, so any threshold of the hosts can recover the key with RecoverThreadKey.
+// Escrowing the key again replaces the shares held by the hosts.
+func (n *Net) EscrowThreadKey(
+	ctx context.Context,
+	id thread.ID,
+	peers []peer.ID,
+	threshold int,
+	recovery thread.Identity,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if err := n.checkRecoveryPeers(peers); err != nil {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	if !info.Key.Defined() {
+		return fmt.Errorf("a service-key is required to escrow a thread key")
+	}
+
+	shares, err := util.SplitThreadKey(info.Key, len(peers), threshold)
+	if err != nil {
+		return err
+	}
+	for i, pid := range peers {
+		share := shares[i]
+		pk, sig, err := util.KeySharePayload{
+			Thread:    id.String(),
+			Peer:      pid.String(),
+			Share:     share.Share,
+			Threshold: share.Threshold,
+			KeyHash:   share.KeyHash,
+		}.Sign(ctx, recovery)
+		if err != nil {
+			return err
+		}
+		h, err := n.nw.dial(ctx, n.id, pid)
+		if err != nil {
+			return fmt.Errorf("dial %s failed: %w", pid, err)
+		}
+		if err = h.putKeyShare(id, share, pk, sig); err != nil {
+			return fmt.Errorf("escrowing key share with %s failed: %w", pid, err)
+		}
+	}
+	return nil
+}
+
+// RecoverThreadKey collects the shares of a thread key escrowed for the recovery identity from
+// the recovery hosts, and reassembles the key. Shares are grouped by the key hash and threshold
+// they were escrowed with, and the group of most hosts is combined. The key is added to the
+// thread if it's stored by the host, otherwise it can be passed to AddThread.
+func (n *Net) RecoverThreadKey(ctx context.Context, id thread.ID, peers []peer.ID, recovery thread.Identity) (thread.Key, error) {
+	if err := n.checkRecoveryPeers(peers); err != nil {
+		return thread.Key{}, err
+	}
+
+	var shares []util.KeyShare
+	for _, pid := range peers {
+		share, err := n.getKeyShare(ctx, id, pid, recovery)
+		if err != nil {
+			log.Warnf("getting key share of thread %s from %s: %v", id, pid, err)
+			continue
+		}
+		shares = append(shares, share)
+	}
+	key, groups, err := util.CombineKeyShares(shares)
+	if err != nil {
+		return thread.Key{}, err
+	}
+	if groups > 1 {
+		log.Warnf("key shares of thread %s were escrowed with %d keys", id, groups)
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if _, err := n.store.GetThread(id); errors.Is(err, lstore.ErrThreadNotFound) {
+		return key, nil
+	} else if err != nil {
+		return thread.Key{}, err
+	}
+	if err = n.store.AddServiceKey(id, key.Service()); err != nil {
+		return thread.Key{}, fmt.Errorf("adding recovered key: %w", err)
+	}
+	if key.CanRead() {
+		if err = n.store.AddReadKey(id, key.Read()); err != nil {
+			return thread.Key{}, fmt.Errorf("adding recovered key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+func (n *Net) checkRecoveryPeers(peers []peer.ID) error {
+	seen := make(map[peer.ID]struct{}, len(peers))
+	for _, pid := range peers {
+		if pid == n.id {
+			return fmt.Errorf("the host can't be a recovery peer")
+		}
+		if _, ok := seen[pid]; ok {
+			return fmt.Errorf("duplicate recovery peer %s", pid)
+		}
+		seen[pid] = struct{}{}
+	}
+	return nil
+}
+
+func (n *Net) getKeyShare(ctx context.Context, id thread.ID, pid peer.ID, recovery thread.Identity) (util.KeyShare, error) {
+	pk, sig, err := util.KeySharePayload{Thread: id.String(), Peer: pid.String()}.Sign(ctx, recovery)
+	if err != nil {
+		return util.KeyShare{}, err
+	}
+	h, err := n.nw.dial(ctx, n.id, pid)
+	if err != nil {
+		return util.KeyShare{}, fmt.Errorf("dial failed: %w", err)
+	}
+	return h.keyShare(id, pk, sig)
+}
+
+// putKeyShare stores a thread key share escrowed for the signing recovery identity.
+func (n *Net) putKeyShare(id thread.ID, share util.KeyShare, pkb, sig []byte) error {
+	if len(share.Share) == 0 || share.Threshold < 2 {
+		return fmt.Errorf("share and threshold are required")
+	}
+	recovery, err := util.KeySharePayload{
+		Thread:    id.String(),
+		Peer:      n.id.String(),
+		Share:     share.Share,
+		Threshold: share.Threshold,
+		KeyHash:   share.KeyHash,
+	}.Verify(pkb, sig)
+	if err != nil {
+		return err
+	}
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	n.escrow[escrowKey(id, recovery)] = share
+	return nil
+}
+
+// keyShare returns a thread key share escrowed for the signing recovery identity.
+func (n *Net) keyShare(id thread.ID, pkb, sig []byte) (util.KeyShare, error) {
+	// shares are keyed by the recovery identity, so only its holder can collect them
+	recovery, err := util.KeySharePayload{Thread: id.String(), Peer: n.id.String()}.Verify(pkb, sig)
+	if err != nil {
+		return util.KeyShare{}, err
+	}
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	share, ok := n.escrow[escrowKey(id, recovery)]
+	if !ok {
+		return util.KeyShare{}, fmt.Errorf("no key share escrowed")
+	}
+	return share, nil
+}
+
+func escrowKey(id thread.ID, recovery thread.PubKey) string {
+	return id.String() + "/" + recovery.String()
+}
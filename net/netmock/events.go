@@ -0,0 +1,110 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var (
+	// LifecycleBusCapacity is the buffer size of lifecycle event listeners.
+	// Events are dropped for listeners with a full buffer, so emitting never blocks the host.
+	LifecycleBusCapacity = 64
+
+	// EventLogSize is the number of recent events kept by a host.
+	EventLogSize = 256
+)
+
+func (n *Net) SubscribeEvents(ctx context.Context, opts ...core.SubOption) (<-chan core.LifecycleEvent, error) {
+	args := &core.SubOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	filter := make(map[thread.ID]struct{})
+	for _, id := range args.ThreadIDs {
+		if id.Defined() {
+			if _, err := n.Validate(id, args.Token, true); err != nil {
+				return nil, err
+			}
+			filter[id] = struct{}{}
+		}
+	}
+
+	channel := make(chan core.LifecycleEvent)
+	listener := n.events.Listen()
+	go func() {
+		defer close(channel)
+		defer listener.Discard()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case i, ok := <-listener.Channel():
+				if !ok {
+					return
+				}
+				ev := i.(core.LifecycleEvent)
+				if _, ok := filter[ev.ThreadID]; len(filter) > 0 && !ok {
+					continue
+				}
+				select {
+				case channel <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return channel, nil
+}
+
+func (n *Net) RecentEvents(_ context.Context) ([]core.LifecycleEvent, error) {
+	return n.recentEvents.List(), nil
+}
+
+func (n *Net) DumpEvents(_ context.Context, w io.Writer) error {
+	for _, ev := range n.recentEvents.List() {
+		if _, err := fmt.Fprintln(w, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emit sends a lifecycle event to subscribers, and keeps it in the recent events.
+func (n *Net) emit(ev core.LifecycleEvent) {
+	ev.Time = time.Now()
+	n.recentEvents.Add(ev)
+	if err := n.events.Send(ev); err != nil {
+		log.Debugf("dropped %s event (thread=%s): %v", ev.Type, ev.ThreadID, err)
+	}
+}
+
+// emitPull sends the outcome of a thread pull from pid, or from all the thread peers if pid is empty.
+func (n *Net) emitPull(id thread.ID, pid peer.ID, err error) {
+	if err != nil {
+		n.emit(core.LifecycleEvent{Type: core.PullFailed, ThreadID: id, PeerID: pid, Err: err})
+	} else {
+		n.emit(core.LifecycleEvent{Type: core.PullCompleted, ThreadID: id, PeerID: pid})
+	}
+}
+
+// emitHeadsChanged sends an advance of log heads to rid, by records received from pid if set,
+// to the subscribers of the thread and of the threads linking to it.
+func (n *Net) emitHeadsChanged(id thread.ID, lid, pid peer.ID, rid cid.Cid) {
+	n.emit(core.LifecycleEvent{Type: core.HeadsChanged, ThreadID: id, LogID: lid, PeerID: pid, RecordID: rid})
+	parents, err := n.linkingThreads(id)
+	if err != nil {
+		log.Errorf("getting threads linking to %s: %v", id, err)
+		return
+	}
+	for _, p := range parents {
+		n.emit(core.LifecycleEvent{Type: core.LinkedThreadUpdated, ThreadID: p, LinkedID: id, LogID: lid, PeerID: pid, RecordID: rid})
+	}
+}
@@ -0,0 +1,221 @@
+package netmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// GC removes the record envelope, event, header and body blocks which are not reachable from the
+// heads of any stored thread, e.g., left behind by records the app failed to handle. Chunks of
+// bodies are removed too. Attachments and blocks of other records aren't touched.
+func (n *Net) GC(ctx context.Context) (int, error) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	live, keys, err := n.markLive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("marking live blocks: %w", err)
+	}
+	all, err := n.bstore.AllKeysChan(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var candidates []cid.Cid
+	for key := range all {
+		// keys are listed by multihash as raw cids, while records and events are dag-cbor nodes
+		id := cid.NewCidV1(cid.DagCBOR, key.Hash())
+		if _, ok := live[id]; ok {
+			continue
+		}
+		block, err := n.bstore.Get(id)
+		if errors.Is(err, bs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		node, err := cbornode.DecodeBlock(block)
+		if err != nil {
+			continue
+		}
+		// records, headers and bodies are encrypted, only events have a recognizable shape
+		if ev, err := cbor.EventFromNode(node); err == nil && ev.HeaderID().Defined() && ev.BodyID().Defined() {
+			candidates = append(append(candidates, id, ev.HeaderID(), ev.BodyID()), util.LocalBodyChunks(n.bstore, ev.BodyID())...)
+			continue
+		}
+		for _, key := range keys {
+			if rec, err := cbor.RecordFromNode(node, key); err == nil && rec.BlockID().Defined() {
+				candidates = append(candidates, id)
+				break
+			}
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+	return n.sweep(ctx, candidates, live)
+}
+
+// BlockStats walks all stored threads, so it's as expensive as marking the live blocks by GC.
+func (n *Net) BlockStats(ctx context.Context) (core.BlockStats, error) {
+	ids, err := n.store.Threads()
+	if err != nil {
+		return core.BlockStats{}, err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	var (
+		stats  core.BlockStats
+		events = make(map[cid.Cid]struct{})
+		// references of every body by thread and event
+		bodies = make(map[cid.Cid]map[thread.ID]map[cid.Cid]struct{})
+	)
+	for _, id := range ids {
+		if err = n.walkLogs(ctx, id, func(_ peer.ID, _ cid.Cid, ev *cbor.Event) {
+			events[ev.Cid()] = struct{}{}
+			refs, ok := bodies[ev.BodyID()]
+			if !ok {
+				refs = make(map[thread.ID]map[cid.Cid]struct{})
+				bodies[ev.BodyID()] = refs
+			}
+			if refs[id] == nil {
+				refs[id] = make(map[cid.Cid]struct{})
+			}
+			refs[id][ev.Cid()] = struct{}{}
+		}); err != nil {
+			return stats, fmt.Errorf("thread %s: %w", id, err)
+		}
+		stats.Threads++
+	}
+
+	shared := make(map[thread.ID]struct{})
+	for _, refs := range bodies {
+		var count int
+		for _, evs := range refs {
+			count += len(evs)
+		}
+		if count > 1 {
+			stats.SharedBodies++
+			stats.DuplicateRefs += count - 1
+		}
+		if len(refs) > 1 {
+			for id := range refs {
+				shared[id] = struct{}{}
+			}
+		}
+	}
+	stats.Events = len(events)
+	stats.Bodies = len(bodies)
+	stats.SharedThreads = len(shared)
+	return stats, nil
+}
+
+// markLive returns the record envelope, event, header and body blocks reachable from the heads
+// of all stored threads but the archived ones, along with the service keys of the threads. The caller must hold the
+// host lock.
+func (n *Net) markLive(ctx context.Context) (map[cid.Cid]struct{}, []*sym.Key, error) {
+	ids, err := n.store.Threads()
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		live = make(map[cid.Cid]struct{})
+		keys []*sym.Key
+	)
+	for _, id := range ids {
+		sk, err := n.store.ServiceKey(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("thread %s: %w", id, err)
+		}
+		keys = append(keys, sk)
+		// blocks left of archived threads are dropped
+		if archived, err := n.isArchived(id); err != nil {
+			return nil, nil, fmt.Errorf("thread %s: %w", id, err)
+		} else if archived {
+			continue
+		}
+		if err = n.walkLogs(ctx, id, func(_ peer.ID, rid cid.Cid, ev *cbor.Event) {
+			for _, b := range append([]cid.Cid{rid, ev.Cid(), ev.HeaderID(), ev.BodyID()}, util.LocalBodyChunks(n.bstore, ev.BodyID())...) {
+				live[b] = struct{}{}
+			}
+		}); err != nil {
+			return nil, nil, fmt.Errorf("thread %s: %w", id, err)
+		}
+	}
+	return live, keys, nil
+}
+
+// walkLogs visits the records of every log of a thread from its head down to the compaction
+// boundary, or to the first record missing locally, e.g., of archived threads. The caller must
+// hold the host lock.
+func (n *Net) walkLogs(ctx context.Context, id thread.ID, visit func(lid peer.ID, rid cid.Cid, ev *cbor.Event)) error {
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		// events of the thread can't be resolved, abort instead of sweeping them
+		return errors.New("missing service key")
+	}
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return err
+		}
+		for rid := lg.Head; rid.Defined(); {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if known, err := n.bstore.Has(rid); err != nil {
+				return err
+			} else if !known {
+				break
+			}
+			rec, err := cbor.GetRecord(ctx, n, rid, sk)
+			if err != nil {
+				return err
+			}
+			ev, err := cbor.EventFromRecord(ctx, n, rec)
+			if err != nil {
+				return err
+			}
+			visit(lg.ID, rid, ev)
+			if rid.Equals(boundary) {
+				break
+			}
+			rid = rec.PrevID()
+		}
+	}
+	return nil
+}
+
+// sweep deletes the blocks which aren't live, and returns the number of deleted blocks.
+func (n *Net) sweep(ctx context.Context, ids []cid.Cid, live map[cid.Cid]struct{}) (int, error) {
+	var swept int
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return swept, err
+		}
+		if _, ok := live[id]; ok {
+			continue
+		}
+		if err := n.bstore.DeleteBlock(id); errors.Is(err, bs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return swept, fmt.Errorf("deleting block %s: %w", id, err)
+		}
+		swept++
+	}
+	return swept, nil
+}
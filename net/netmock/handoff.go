@@ -0,0 +1,157 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// metadata suffix marking an own log as sealed for a handoff, holding the new owner
+const handoffSuffix = "/handoff"
+
+// HandoffLog transfers the write ownership of a log to another host. A handoff record naming the
+// new owner is appended to the log, which seals it. The new owner pulls the log up to the sealed
+// head and takes the log key over, so it continues the log instead of starting a new one. The
+// host no longer adds records to the log.
+func (n *Net) HandoffLog(ctx context.Context, id thread.ID, lid peer.ID, newOwner peer.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return err
+	}
+	identity = n.identityOrHost(identity)
+	if newOwner == n.id {
+		return fmt.Errorf("cannot hand off a log to the host")
+	}
+	lg, err := n.store.GetLog(id, lid)
+	if err != nil {
+		return err
+	}
+	if lg.PrivKey == nil {
+		return fmt.Errorf("a private-key is required to hand off a log")
+	}
+	// a log sealed for the same owner is a handoff being retried
+	if owner, err := n.logHandoff(id, lid); err != nil {
+		return err
+	} else if owner != "" && owner != newOwner {
+		return fmt.Errorf("%w to %s", core.ErrLogHandedOff, owner)
+	} else if owner == "" {
+		if err = n.appendHandoffRecord(ctx, id, lid, identity, newOwner); err != nil {
+			return err
+		}
+	}
+	h, err := n.nw.dial(ctx, n.id, newOwner)
+	if err != nil {
+		return fmt.Errorf("dial %s failed: %w", newOwner, err)
+	}
+	// the log stays sealed, and the key is kept for a retry until the new owner took it over
+	if err = h.takeOverLog(ctx, n, id, lid, lg.PrivKey); err != nil {
+		return fmt.Errorf("handing off log %s to %s: %w", lid, newOwner, err)
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if err = n.store.ClearLogKeys(id, lid); err != nil {
+		return err
+	}
+	if err = n.store.AddPubKey(id, lid, lg.PubKey); err != nil {
+		return err
+	}
+	if lidb, err := n.store.GetBytes(id, identity.String()); err != nil {
+		return err
+	} else if lidb != nil && peer.ID(*lidb) == lid {
+		// the next record of the identity starts a new log
+		return n.store.PutBytes(id, identity.String(), []byte{})
+	}
+	return nil
+}
+
+// appendHandoffRecord appends a record naming the new owner to the log of identity, and seals
+// the log along with it, so no records follow the handoff record.
+func (n *Net) appendHandoffRecord(ctx context.Context, id thread.ID, lid peer.ID, identity thread.PubKey, newOwner peer.ID) error {
+	body, err := cbornode.WrapObject(map[string]interface{}{"handoff": newOwner.String()}, mh.SHA2_256, -1)
+	if err != nil {
+		return err
+	}
+	ext := map[string][]byte{core.HandoffExtension: []byte(newOwner)}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if lidb, err := n.store.GetBytes(id, identity.String()); err != nil {
+		return err
+	} else if lidb == nil || peer.ID(*lidb) != lid {
+		return fmt.Errorf("log %s isn't the log of identity %s", lid, identity)
+	}
+	if _, _, err = n.createRecords(ctx, id, []format.Node{body}, identity, ext); err != nil {
+		return err
+	}
+	return n.store.PutBytes(id, lid.Pretty()+handoffSuffix, []byte(newOwner))
+}
+
+// logHandoff returns the host an own log was sealed for, if any.
+func (n *Net) logHandoff(id thread.ID, lid peer.ID) (peer.ID, error) {
+	owner, err := n.store.GetBytes(id, lid.Pretty()+handoffSuffix)
+	if err != nil || owner == nil {
+		return "", err
+	}
+	return peer.ID(*owner), nil
+}
+
+// takeOverLog makes the host the owner of a log sealed for it by the previous owner. Records up
+// to the sealed head are pulled from the previous owner first, so the host continues the log
+// from the handoff record.
+func (n *Net) takeOverLog(ctx context.Context, from *Net, id thread.ID, lid peer.ID, key crypto.PrivKey) error {
+	if owner, err := from.logHandoff(id, lid); err != nil {
+		return err
+	} else if owner != n.id {
+		return fmt.Errorf("%w: log isn't sealed for the host", core.ErrInvalidHandoff)
+	}
+	if err := from.checkCapability(n, id, fetchRights); err != nil {
+		return err
+	}
+	if err := n.pullLog(ctx, from, id, lid, core.SourcePush); err != nil {
+		return err
+	}
+	sealed, err := from.head(id, lid)
+	if err != nil {
+		return err
+	}
+	if head, err := n.head(id, lid); err != nil {
+		return err
+	} else if !head.Equals(sealed) {
+		return fmt.Errorf("sealed head %s of log %s is missing", sealed, lid)
+	}
+	pk, err := n.store.PubKey(id, lid)
+	if err != nil {
+		return err
+	}
+	if !key.GetPublic().Equals(pk) {
+		return fmt.Errorf("%w: key doesn't match the log", core.ErrInvalidHandoff)
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if err = n.store.AddPrivKey(id, lid, key); err != nil {
+		return err
+	}
+	if err = n.store.PutBytes(id, n.identityOrHost(nil).String(), []byte(lid)); err != nil {
+		return err
+	}
+	if owner, err := n.logHandoff(id, lid); err != nil {
+		return err
+	} else if owner != "" {
+		// the log was handed off by the host before
+		return n.store.PutBytes(id, lid.Pretty()+handoffSuffix, []byte{})
+	}
+	return nil
+}
@@ -0,0 +1,1514 @@
+package netmock
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/broadcast"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	tcrypto "github.com/textileio/go-threads/crypto"
+	"github.com/textileio/go-threads/logstore/lstoremem"
+	"github.com/textileio/go-threads/net/util"
+)
+
+var (
+	// EventBusCapacity is the buffer size of record subscribers.
+	EventBusCapacity = 1
+
+	// notifyTimeout bounds the wait for slow subscribers.
+	notifyTimeout = time.Second * 5
+)
+
+const (
+	// metadataKey is the logstore key of the signed thread metadata.
+	metadataKey = "/thread-metadata"
+
+	// removedReplicatorSuffix marks a host removed from the thread replicators.
+	removedReplicatorSuffix = "/removed-replicator"
+)
+
+// Net is an in-memory host implementing app.Net. Records are stored in an in-memory logstore and
+// blockstore, and exchanged with the other hosts of the network. Forked logs aren't supported.
+type Net struct {
+	format.DAGService
+
+	nw     *Network
+	id     peer.ID
+	sk     crypto.PrivKey
+	store  lstore.Logstore
+	bstore bs.Blockstore
+	bus    *broadcast.Broadcaster
+
+	// lk serializes updates of the host's threads
+	lk         sync.Mutex
+	connectors map[thread.ID]*app.Connector
+	connLock   sync.RWMutex
+
+	// mx guards the authorization state of the host
+	mx                sync.Mutex
+	challenges        map[string]tokenChallenge
+	revokedTokens     map[string]struct{}
+	revokedIdentities map[string]revocation
+	revokedMembers    map[thread.ID]map[string]revocation
+	capabilityRoots   map[thread.ID][]byte
+	granted           map[thread.ID]thread.Capability
+	escrow            map[string]util.KeyShare
+
+	// sx guards the sync state of the host
+	sx          sync.Mutex
+	syncConfig  core.SyncConfig
+	syncStatus  map[peer.ID]core.PeerSyncStatus
+	pullStatus  map[thread.ID]map[peer.ID]core.LogPullStatus
+	acks        map[thread.ID]map[cid.Cid]map[peer.ID]time.Time
+	reputations map[peer.ID]core.PeerReputation
+	localities  map[peer.ID]core.Locality
+
+	events       *broadcast.Broadcaster
+	recentEvents *util.EventRing
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ app.Net = (*Net)(nil)
+
+func newNet(nw *Network, id peer.ID, sk crypto.PrivKey) *Net {
+	dag, bstore := newDAG()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Net{
+		DAGService: dag,
+		nw:         nw,
+		id:         id,
+		sk:         sk,
+		store:      lstoremem.NewLogstore(),
+		bstore:     bstore,
+		bus:        broadcast.NewBroadcaster(EventBusCapacity),
+		connectors: make(map[thread.ID]*app.Connector),
+
+		challenges:        make(map[string]tokenChallenge),
+		revokedTokens:     make(map[string]struct{}),
+		revokedIdentities: make(map[string]revocation),
+		revokedMembers:    make(map[thread.ID]map[string]revocation),
+		capabilityRoots:   make(map[thread.ID][]byte),
+		granted:           make(map[thread.ID]thread.Capability),
+		escrow:            make(map[string]util.KeyShare),
+
+		syncStatus:  make(map[peer.ID]core.PeerSyncStatus),
+		pullStatus:  make(map[thread.ID]map[peer.ID]core.LogPullStatus),
+		acks:        make(map[thread.ID]map[cid.Cid]map[peer.ID]time.Time),
+		reputations: make(map[peer.ID]core.PeerReputation),
+		localities:  make(map[peer.ID]core.Locality),
+
+		events:       broadcast.NewBroadcaster(LifecycleBusCapacity),
+		recentEvents: util.NewEventRing(EventLogSize),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Close removes the host from the network, waiting for deliveries in flight.
+func (n *Net) Close() error {
+	n.nw.remove(n.id)
+	n.cancel()
+	n.wg.Wait()
+	n.bus.Discard()
+	n.events.Discard()
+	return n.store.Close()
+}
+
+// Host returns nil, the in-memory net doesn't run a libp2p host.
+func (n *Net) Host() host.Host {
+	return nil
+}
+
+func (n *Net) GetHostID(_ context.Context) (peer.ID, error) {
+	return n.id, nil
+}
+
+func (n *Net) GetToken(ctx context.Context, identity thread.Identity) (tok thread.Token, err error) {
+	msg := make([]byte, 64)
+	if _, err = rand.Read(msg); err != nil {
+		return
+	}
+	sig, err := identity.Sign(ctx, msg)
+	if err != nil {
+		return
+	}
+	key := identity.GetPublic()
+	if ok, err := key.Verify(msg, sig); !ok || err != nil {
+		return tok, fmt.Errorf("bad signature")
+	}
+	return n.issueToken(key)
+}
+
+func (n *Net) Validate(id thread.ID, token thread.Token, _ bool) (thread.PubKey, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	claims, err := token.Claims(n.sk)
+	if err != nil || claims.PubKey == nil {
+		return nil, err
+	}
+	if err = n.checkToken(id, token, claims); err != nil {
+		return nil, err
+	}
+	return claims.PubKey, nil
+}
+
+func (n *Net) CreateThread(_ context.Context, id thread.ID, opts ...core.NewThreadOption) (info thread.Info, err error) {
+	args := &core.NewThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return
+	}
+	info = thread.Info{ID: id, Key: args.ThreadKey}
+	if !info.Key.Defined() {
+		info.Key = thread.NewRandomKey()
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if _, err = n.store.GetThread(id); err == nil {
+		return info, fmt.Errorf("thread %s already exists", id)
+	}
+	if err = n.store.AddThread(info); err != nil {
+		return
+	}
+	n.emit(core.LifecycleEvent{Type: core.ThreadAdded, ThreadID: id})
+	if _, err = n.createLog(id, args.LogKey, identity); err != nil {
+		return
+	}
+	return n.getThread(id)
+}
+
+func (n *Net) AddThread(ctx context.Context, addr ma.Multiaddr, opts ...core.NewThreadOption) (info thread.Info, err error) {
+	args := &core.NewThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	id, err := thread.FromAddr(addr)
+	if err != nil {
+		return
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return
+	}
+	if !args.ThreadKey.Defined() {
+		return info, fmt.Errorf("a thread-key is required to add a thread")
+	}
+	p2p, err := addr.ValueForProtocol(ma.P_P2P)
+	if err != nil {
+		return
+	}
+	pid, err := peer.Decode(p2p)
+	if err != nil {
+		return
+	}
+
+	n.lk.Lock()
+	if err = n.store.AddThread(thread.Info{ID: id, Key: args.ThreadKey}); err != nil {
+		n.lk.Unlock()
+		return
+	}
+	n.emit(core.LifecycleEvent{Type: core.ThreadAdded, ThreadID: id})
+	if args.ThreadKey.CanRead() {
+		if _, err = n.getOrCreateLog(id, args.LogKey, identity); err != nil {
+			n.lk.Unlock()
+			return
+		}
+	}
+	n.lk.Unlock()
+
+	if pid != n.id {
+		if err = n.pullFrom(ctx, pid, id); err != nil {
+			return
+		}
+	}
+	return n.getThread(id)
+}
+
+func (n *Net) GetThread(_ context.Context, id thread.ID, opts ...core.ThreadOption) (info thread.Info, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	return n.getThread(id)
+}
+
+// getThread returns the thread with the address of the host, so it can be added by other hosts.
+func (n *Net) getThread(id thread.ID) (info thread.Info, err error) {
+	if info, err = n.store.GetThread(id); err != nil {
+		return
+	}
+	addr, err := ma.NewMultiaddr("/" + ma.ProtocolWithCode(ma.P_P2P).Name + "/" + n.id.String() + "/" + thread.Name + "/" + id.String())
+	if err != nil {
+		return
+	}
+	info.Addrs = []ma.Multiaddr{addr}
+	return info, nil
+}
+
+// PullThread pulls the logs of a thread from every reachable host storing it, then the threads
+// it links to.
+func (n *Net) PullThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return err
+	}
+	if err := n.pullThread(ctx, id); err != nil {
+		return err
+	}
+	n.pullLinkedThreads(ctx, id)
+	return nil
+}
+
+// pullThread pulls the logs of a thread from every reachable host storing it, loading
+// the thread if it was unloaded.
+func (n *Net) pullThread(ctx context.Context, id thread.ID) (err error) {
+	if _, err = n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err = n.loadThread(id); err != nil {
+		return err
+	}
+	defer func() { n.emitPull(id, "", err) }()
+	var pulled bool
+	peers := n.replicas(id)
+	for _, pid := range peers {
+		if err := n.pullFrom(ctx, pid, id); err != nil {
+			log.Debugf("pulling thread %s from %s failed: %v", id, pid, err)
+			continue
+		}
+		pulled = true
+	}
+	if !pulled && len(peers) > 0 {
+		return fmt.Errorf("no host of thread %s is reachable", id)
+	}
+	return nil
+}
+
+// AddThreads adds every thread in turn like AddThread. Thread keys given with
+// core.WithThreadKeys take precedence over the common one.
+func (n *Net) AddThreads(ctx context.Context, addrs []ma.Multiaddr, opts ...core.NewThreadOption) ([]thread.Info, error) {
+	args := &core.NewThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	infos := make([]thread.Info, len(addrs))
+	for i, addr := range addrs {
+		topts := opts
+		if id, err := thread.FromAddr(addr); err == nil {
+			if key, ok := args.ThreadKeys[id]; ok {
+				topts = append(opts[:len(opts):len(opts)], core.WithThreadKey(key))
+			}
+		}
+		info, err := n.AddThread(ctx, addr, topts...)
+		if err != nil {
+			return nil, fmt.Errorf("adding thread from %s: %w", addr, err)
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+// PullThreads pulls every thread in turn like PullThread.
+func (n *Net) PullThreads(ctx context.Context, ids []thread.ID, opts ...core.ThreadOption) error {
+	for _, id := range ids {
+		if err := n.PullThread(ctx, id, opts...); err != nil {
+			return fmt.Errorf("pulling thread %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (n *Net) DeleteThread(_ context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot delete thread: %w", app.ErrThreadInUse)
+	}
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if err := n.store.DeleteThread(id); err != nil {
+		return err
+	}
+	n.connLock.Lock()
+	delete(n.connectors, id)
+	n.connLock.Unlock()
+	n.removeThreadAuth(id)
+	n.forgetPullStatus(id)
+	n.emit(core.LifecycleEvent{Type: core.ThreadDeleted, ThreadID: id})
+	return nil
+}
+
+// AddReplicator adds the thread with the service key to another host of the network,
+// which pulls the thread right away.
+func (n *Net) AddReplicator(ctx context.Context, id thread.ID, paddr ma.Multiaddr, opts ...core.ThreadOption) (pid peer.ID, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+	p2p, err := paddr.ValueForProtocol(ma.P_P2P)
+	if err != nil {
+		return
+	}
+	if pid, err = peer.Decode(p2p); err != nil {
+		return
+	}
+	if pid == n.id {
+		return pid, nil
+	}
+	h, err := n.nw.dial(ctx, n.id, pid)
+	if err != nil {
+		return
+	}
+	h.lk.Lock()
+	if sk, err := h.store.ServiceKey(id); err != nil {
+		h.lk.Unlock()
+		return pid, err
+	} else if sk == nil {
+		if err = h.store.AddThread(thread.Info{ID: id, Key: thread.NewServiceKey(info.Key.Service())}); err != nil {
+			h.lk.Unlock()
+			return pid, err
+		}
+	}
+	h.lk.Unlock()
+	if err = h.pullFrom(ctx, n.id, id); err != nil {
+		return
+	}
+	n.emit(core.LifecycleEvent{Type: core.ReplicatorAdded, ThreadID: id, PeerID: pid})
+	return pid, nil
+}
+
+// RemoveReplicator stops delivering the records of a thread to a host, which is no longer pulled.
+func (n *Net) RemoveReplicator(_ context.Context, id thread.ID, pid peer.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return err
+	}
+	if pid == n.id {
+		return fmt.Errorf("cannot remove the host from replicators")
+	}
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err := n.store.PutBool(id, pid.String()+removedReplicatorSuffix, true); err != nil {
+		return err
+	}
+	n.emit(core.LifecycleEvent{Type: core.ReplicatorRemoved, ThreadID: id, PeerID: pid})
+	return nil
+}
+
+func (n *Net) CreateRecord(ctx context.Context, id thread.ID, body format.Node, opts ...core.ThreadOption) (core.ThreadRecord, error) {
+	recs, err := n.CreateRecords(ctx, id, []format.Node{body}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return recs[0], nil
+}
+
+// CreateRecords creates a chain of records in the host's log, and delivers it to the other
+// hosts of the thread in the background.
+func (n *Net) CreateRecords(ctx context.Context, id thread.ID, bodies []format.Node, opts ...core.ThreadOption) ([]core.ThreadRecord, error) {
+	trs, _, err := n.createAndDeliver(ctx, id, bodies, opts...)
+	return trs, err
+}
+
+// CreateRecordAsync creates a record like CreateRecord, returning a future which tracks
+// its delivery to the other hosts of the thread.
+func (n *Net) CreateRecordAsync(ctx context.Context, id thread.ID, body format.Node, opts ...core.ThreadOption) (core.RecordFuture, error) {
+	trs, d, err := n.createAndDeliver(ctx, id, []format.Node{body}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &recordFuture{rec: trs[0], delivery: d}, nil
+}
+
+func (n *Net) createAndDeliver(ctx context.Context, id thread.ID, bodies []format.Node, opts ...core.ThreadOption) ([]core.ThreadRecord, *delivery, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if len(bodies) == 0 {
+		return nil, nil, fmt.Errorf("at least one record body is required")
+	}
+	identity, err := n.validateBodies(ctx, id, bodies, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = n.loadThread(id); err != nil {
+		return nil, nil, err
+	}
+	if err = n.rehydrateThread(ctx, id); err != nil {
+		return nil, nil, err
+	}
+
+	n.lk.Lock()
+	lid, recs, err := n.createRecords(ctx, id, bodies, identity, args.Extensions)
+	n.lk.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	rids := make([]cid.Cid, len(recs))
+	for i, r := range recs {
+		rids[i] = r.Cid()
+	}
+	trs := make([]core.ThreadRecord, len(recs))
+	src := core.RecordSource{Kind: core.SourceLocal, ReceivedAt: time.Now()}
+	for i, r := range recs {
+		trs[i] = &Record{Record: r, threadID: id, logID: lid, source: src}
+		if err := n.bus.SendWithTimeout(trs[i], notifyTimeout); err != nil {
+			log.Errorf("error notifying listeners of record %s: %v", r.Cid(), err)
+		}
+	}
+	n.emitHeadsChanged(id, lid, "", recs[len(recs)-1].Cid())
+	return trs, n.deliver(id, lid, rids...), nil
+}
+
+// CreateRecordsAtomic creates records like CreateRecords, which are atomic in the in-memory net.
+func (n *Net) CreateRecordsAtomic(ctx context.Context, id thread.ID, bodies []format.Node, opts ...core.ThreadOption) ([]core.ThreadRecord, error) {
+	return n.CreateRecords(ctx, id, bodies, opts...)
+}
+
+// CreateRecordsAcross creates the chains of every write in turn. Unlike the real net,
+// chains written before a failure are kept.
+func (n *Net) CreateRecordsAcross(ctx context.Context, writes []app.ThreadWrite) ([][]core.ThreadRecord, error) {
+	trs := make([][]core.ThreadRecord, len(writes))
+	for i, w := range writes {
+		if len(w.Bodies) == 0 {
+			continue
+		}
+		recs, err := n.CreateRecords(ctx, w.ID, w.Bodies, w.Opts...)
+		if err != nil {
+			return nil, fmt.Errorf("thread %s: %w", w.ID, err)
+		}
+		trs[i] = recs
+	}
+	return trs, nil
+}
+
+// RecordDecrypter returns the read key of the thread, custom record ciphers aren't supported.
+func (n *Net) RecordDecrypter(_ context.Context, id thread.ID) (tcrypto.Decrypter, error) {
+	rk, err := n.store.ReadKey(id)
+	if err != nil || rk == nil {
+		return nil, err
+	}
+	return rk, nil
+}
+
+func (n *Net) validateBodies(ctx context.Context, id thread.ID, bodies []format.Node, args *core.ThreadOptions) (thread.PubKey, error) {
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return nil, err
+	}
+	con, ok := n.getConnectorProtected(id, args.APIToken)
+	if !ok {
+		return nil, fmt.Errorf("cannot create records: %w", app.ErrThreadInUse)
+	}
+	if con != nil {
+		for _, body := range bodies {
+			if err = con.ValidateNetRecordBody(ctx, body, n.identityOrHost(identity)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return identity, nil
+}
+
+// createRecords creates a chain of records with bodies on top of the identity's log head.
+// The records are charged against the thread quota, but never refused, and the retention
+// policy of the thread is enforced afterwards. The caller must hold the host lock.
+func (n *Net) createRecords(
+	ctx context.Context,
+	id thread.ID,
+	bodies []format.Node,
+	identity thread.PubKey,
+	ext map[string][]byte,
+) (peer.ID, []core.Record, error) {
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.Key.CanRead() {
+		return "", nil, fmt.Errorf("a read-key is required to create records")
+	}
+	lg, err := n.getOrCreateLog(id, nil, identity)
+	if err != nil {
+		return "", nil, err
+	}
+	if lg.PrivKey == nil {
+		return "", nil, fmt.Errorf("a private-key is required to create records")
+	}
+	if owner, err := n.logHandoff(id, lg.ID); err != nil {
+		return "", nil, err
+	} else if owner != "" {
+		return "", nil, fmt.Errorf("%w to %s", core.ErrLogHandedOff, owner)
+	}
+	recs := make([]core.Record, 0, len(bodies))
+	for _, body := range bodies {
+		event, err := cbor.CreateEvent(ctx, n, body, info.Key.Read())
+		if err != nil {
+			return "", nil, err
+		}
+		rec, err := cbor.CreateRecord(ctx, n, cbor.CreateRecordConfig{
+			Block:      event,
+			Prev:       lg.Head,
+			Key:        lg.PrivKey,
+			PubKey:     n.identityOrHost(identity),
+			ServiceKey: info.Key.Service(),
+			Extensions: ext,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		recs = append(recs, rec)
+		lg.Head = rec.Cid()
+	}
+	if err = n.store.SetHead(id, lg.ID, lg.Head); err != nil {
+		return "", nil, err
+	}
+	for _, rec := range recs {
+		size, err := n.quotaSize(ctx, n, id, rec, true)
+		if err != nil {
+			return "", nil, err
+		}
+		if err = n.chargeQuota(id, size); err != nil {
+			return "", nil, err
+		}
+	}
+	if _, err = n.reapThread(ctx, id); err != nil {
+		log.Errorf("enforcing retention of thread %s: %v", id, err)
+	}
+	return lg.ID, recs, nil
+}
+
+// AddRecord adds a record created elsewhere to a log of the thread.
+func (n *Net) AddRecord(ctx context.Context, id thread.ID, lid peer.ID, rec core.Record, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	pk, err := n.store.PubKey(id, lid)
+	if err != nil {
+		return err
+	}
+	if pk == nil {
+		return lstore.ErrLogNotFound
+	}
+	if known, err := n.bstore.Has(rec.Cid()); err != nil || known {
+		return err
+	}
+	if err = rec.Verify(pk); err != nil {
+		return err
+	}
+	from := n.nw.holder(rec.BlockID())
+	if from == nil {
+		return fmt.Errorf("blocks of record %s not found", rec.Cid())
+	}
+	n.lk.Lock()
+	err = n.putRecords(ctx, from, id, lid, []core.Record{rec}, core.RecordSource{Kind: core.SourceLocal, ReceivedAt: time.Now()}, nil)
+	n.lk.Unlock()
+	if err != nil {
+		return err
+	}
+	n.deliver(id, lid, rec.Cid())
+	return nil
+}
+
+func (n *Net) GetRecord(ctx context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) (core.Record, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if sk == nil {
+		return nil, fmt.Errorf("a service-key is required to get records")
+	}
+	if err = n.rehydrateThread(ctx, id); err != nil {
+		return nil, err
+	}
+	return cbor.GetRecord(ctx, n, rid, sk)
+}
+
+// Records returns the records of a thread ordered by their positions in the logs, log IDs and IDs.
+func (n *Net) Records(ctx context.Context, id thread.ID, opts ...core.RecordsOption) (<-chan core.ThreadRecord, error) {
+	args := &core.RecordsOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	if args.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return nil, err
+	}
+	recs, err := n.historyRecords(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if args.Reverse {
+		for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
+			recs[i], recs[j] = recs[j], recs[i]
+		}
+	}
+	if args.Since.Defined() {
+		pos := -1
+		for i, r := range recs {
+			if r.Value().Cid().Equals(args.Since) {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			return nil, fmt.Errorf("record %s not found in thread %s", args.Since, id)
+		}
+		recs = recs[pos+1:]
+	}
+	if args.Limit > 0 && len(recs) > args.Limit {
+		recs = recs[:args.Limit]
+	}
+
+	ch := make(chan core.ThreadRecord)
+	go func() {
+		defer close(ch)
+		for _, r := range recs {
+			select {
+			case ch <- r:
+			case <-ctx.Done():
+				return
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// historyRecords loads the records of every thread log down to the compaction boundaries,
+// ordered by their positions in the logs, log IDs and IDs.
+func (n *Net) historyRecords(ctx context.Context, id thread.ID) ([]*Record, error) {
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if sk == nil {
+		return nil, fmt.Errorf("a service-key is required to get records")
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		all       []*Record
+		positions = make(map[cid.Cid]int)
+	)
+	for _, lg := range info.Logs {
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return nil, err
+		}
+		var chain []*Record
+		for rid := lg.Head; rid.Defined(); {
+			rec, err := cbor.GetRecord(ctx, n, rid, sk)
+			if err != nil {
+				return nil, fmt.Errorf("getting record %s: %w", rid, err)
+			}
+			chain = append(chain, &Record{
+				Record:     rec,
+				threadID:   id,
+				logID:      lg.ID,
+				source:     core.RecordSource{Kind: core.SourceUnknown},
+				restricted: n.isBodyless(id, rid),
+			})
+			if rid.Equals(boundary) {
+				break
+			}
+			rid = rec.PrevID()
+		}
+		for i, r := range chain {
+			positions[r.Cid()] = len(chain) - i
+		}
+		all = append(all, chain...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if pa, pb := positions[a.Cid()], positions[b.Cid()]; pa != pb {
+			return pa < pb
+		}
+		if a.logID != b.logID {
+			return a.logID < b.logID
+		}
+		return a.Cid().KeyString() < b.Cid().KeyString()
+	})
+	return all, nil
+}
+
+func (n *Net) Subscribe(ctx context.Context, opts ...core.SubOption) (<-chan core.ThreadRecord, error) {
+	args := &core.SubOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	filter := make(map[thread.ID]struct{})
+	for _, id := range args.ThreadIDs {
+		if id.Defined() {
+			if _, err := n.Validate(id, args.Token, true); err != nil {
+				return nil, err
+			}
+			filter[id] = struct{}{}
+		}
+	}
+	logs := make(map[peer.ID]struct{}, len(args.LogIDs))
+	for _, lid := range args.LogIDs {
+		logs[lid] = struct{}{}
+	}
+
+	channel := make(chan core.ThreadRecord)
+	listener := n.bus.Listen()
+	go func() {
+		defer close(channel)
+		defer listener.Discard()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case i, ok := <-listener.Channel():
+				if !ok {
+					return
+				}
+				rec := i.(*Record)
+				if _, ok := filter[rec.threadID]; len(filter) > 0 && !ok {
+					continue
+				}
+				if _, ok := logs[rec.logID]; len(logs) > 0 && !ok {
+					continue
+				}
+				if !core.HasAnnotations(rec, args.Annotations) {
+					continue
+				}
+				select {
+				case channel <- rec:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return channel, nil
+}
+
+// AwaitRecord returns a record of a thread once it has been added locally.
+func (n *Net) AwaitRecord(ctx context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) (core.Record, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// subscribe first, so the record isn't missed while checking the store
+	sub, err := n.Subscribe(sctx, core.WithSubFilter(id), core.WithSubToken(args.Token))
+	if err != nil {
+		return nil, err
+	}
+	if known, err := n.bstore.Has(rid); err != nil {
+		return nil, err
+	} else if known {
+		return n.GetRecord(ctx, id, rid, opts...)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case rec, ok := <-sub:
+			if !ok {
+				return nil, fmt.Errorf("host closed")
+			}
+			if rec.Value().Cid().Equals(rid) {
+				return rec.Value(), nil
+			}
+		}
+	}
+}
+
+func (n *Net) GetLogForIdentity(_ context.Context, id thread.ID, identity thread.PubKey, opts ...core.ThreadOption) (thread.LogInfo, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return thread.LogInfo{}, err
+	}
+	lidb, err := n.store.GetBytes(id, identity.String())
+	if err != nil {
+		return thread.LogInfo{}, err
+	}
+	if lidb == nil || len(*lidb) == 0 {
+		return thread.LogInfo{}, lstore.ErrLogNotFound
+	}
+	lid, err := peer.IDFromBytes(*lidb)
+	if err != nil {
+		return thread.LogInfo{}, err
+	}
+	return n.store.GetLog(id, lid)
+}
+
+// ListIdentities returns the identities writing to the logs of a thread, i.e., the authors of
+// their head records. Logs without records are omitted.
+func (n *Net) ListIdentities(ctx context.Context, id thread.ID, opts ...core.ThreadOption) (map[peer.ID]thread.PubKey, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return nil, err
+	}
+	identities := make(map[peer.ID]thread.PubKey, len(info.Logs))
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		rec, err := cbor.GetRecord(ctx, n, lg.Head, info.Key.Service())
+		if err != nil {
+			return nil, fmt.Errorf("getting head of log %s: %w", lg.ID, err)
+		}
+		author := &thread.Libp2pPubKey{}
+		if err = author.UnmarshalBinary(rec.PubKey()); err != nil {
+			return nil, fmt.Errorf("decoding author of log %s: %w", lg.ID, err)
+		}
+		identities[lg.ID] = author
+	}
+	return identities, nil
+}
+
+// SetThreadMetadata signs the metadata of a thread with the log of the identity, and delivers it to
+// the other hosts of the thread. The latest metadata set by any thread log wins.
+func (n *Net) SetThreadMetadata(_ context.Context, id thread.ID, md thread.Metadata, opts ...core.ThreadOption) (thread.Metadata, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	identity, err := n.Validate(id, args.Token, false)
+	if err != nil {
+		return md, err
+	}
+	if err = md.Validate(); err != nil {
+		return md, err
+	}
+
+	n.lk.Lock()
+	lg, err := n.getOrCreateLog(id, nil, identity)
+	if err != nil {
+		n.lk.Unlock()
+		return md, err
+	}
+	if lg.PrivKey == nil {
+		n.lk.Unlock()
+		return md, fmt.Errorf("a private-key is required to set metadata")
+	}
+	// updates follow the current metadata, even if the local clock is behind
+	current, ok, err := n.threadMetadata(id)
+	if err != nil {
+		n.lk.Unlock()
+		return md, err
+	}
+	md.UpdatedAt = time.Now()
+	if ok && !md.UpdatedAt.After(current.UpdatedAt) {
+		md.UpdatedAt = current.UpdatedAt.Add(1)
+	}
+	payload, err := md.Payload(id)
+	if err != nil {
+		n.lk.Unlock()
+		return md, err
+	}
+	md.Signer = lg.PubKey
+	if md.Sig, err = lg.PrivKey.Sign(payload); err != nil {
+		n.lk.Unlock()
+		return md, fmt.Errorf("signing thread metadata: %w", err)
+	}
+	err = n.putMetadata(id, md)
+	n.lk.Unlock()
+	if err != nil {
+		return md, err
+	}
+	n.deliver(id, lg.ID)
+	return md, nil
+}
+
+// GetThreadMetadata returns the metadata of a thread, empty if none is set.
+func (n *Net) GetThreadMetadata(_ context.Context, id thread.ID, opts ...core.ThreadOption) (thread.Metadata, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return thread.Metadata{}, err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return thread.Metadata{}, err
+	}
+	md, _, err := n.threadMetadata(id)
+	return md, err
+}
+
+// threadMetadata returns the metadata of a thread, or false if none is set.
+func (n *Net) threadMetadata(id thread.ID) (thread.Metadata, bool, error) {
+	data, err := n.store.GetBytes(id, metadataKey)
+	if err != nil || data == nil {
+		return thread.Metadata{}, false, err
+	}
+	md, err := thread.MetadataFromBytes(*data)
+	if err != nil {
+		return thread.Metadata{}, false, err
+	}
+	return md, true, nil
+}
+
+// putMetadata stores signed metadata, unless the thread metadata supersedes it.
+// The caller must hold the host lock.
+func (n *Net) putMetadata(id thread.ID, md thread.Metadata) error {
+	current, ok, err := n.threadMetadata(id)
+	if err != nil || (ok && !md.After(current)) {
+		return err
+	}
+	data, err := md.Marshal()
+	if err != nil {
+		return err
+	}
+	return n.store.PutBytes(id, metadataKey, data)
+}
+
+// mergeMetadata verifies the thread metadata of another host, and keeps it if it supersedes
+// the local one. Only the logs of the thread may sign it.
+func (n *Net) mergeMetadata(from *Net, id thread.ID) error {
+	md, ok, err := from.threadMetadata(id)
+	if err != nil || !ok {
+		return err
+	}
+	if err = md.Verify(id); err != nil {
+		return err
+	}
+	lid, err := peer.IDFromPublicKey(md.Signer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", thread.ErrInvalidMetadata, err)
+	}
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if pk, err := n.store.PubKey(id, lid); err != nil {
+		return err
+	} else if pk == nil {
+		return fmt.Errorf("%w: signer %s isn't a thread log", thread.ErrInvalidMetadata, lid)
+	}
+	return n.putMetadata(id, md)
+}
+
+func (n *Net) ConnectApp(a app.App, id thread.ID, opts ...app.ConnectOption) (*app.Connector, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	info, err := n.getThread(id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting thread %s: %v", id, err)
+	}
+	con, err := app.NewConnector(a, n, info, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error making connector %s: %v", id, err)
+	}
+	n.connLock.Lock()
+	n.connectors[id] = con
+	n.connLock.Unlock()
+	return con, nil
+}
+
+func (n *Net) getConnector(id thread.ID) (*app.Connector, bool) {
+	n.connLock.RLock()
+	defer n.connLock.RUnlock()
+	con, ok := n.connectors[id]
+	return con, ok
+}
+
+// getConnectorProtected returns the connector tied to the thread if it exists
+// and whether or not the token is valid.
+func (n *Net) getConnectorProtected(id thread.ID, token core.Token) (*app.Connector, bool) {
+	con, ok := n.getConnector(id)
+	if !ok {
+		return nil, true
+	}
+	if !token.Equal(con.Token()) {
+		return nil, false
+	}
+	return con, true
+}
+
+func (n *Net) identityOrHost(identity thread.PubKey) thread.PubKey {
+	if identity == nil {
+		return thread.NewLibp2pPubKey(n.sk.GetPublic())
+	}
+	return identity
+}
+
+// getOrCreateLog returns the log of identity, creating it if needed. The caller must hold the host lock.
+func (n *Net) getOrCreateLog(id thread.ID, key crypto.Key, identity thread.PubKey) (thread.LogInfo, error) {
+	identity = n.identityOrHost(identity)
+	lidb, err := n.store.GetBytes(id, identity.String())
+	if err != nil {
+		return thread.LogInfo{}, err
+	}
+	if lidb != nil && len(*lidb) > 0 {
+		lid, err := peer.IDFromBytes(*lidb)
+		if err != nil {
+			return thread.LogInfo{}, err
+		}
+		return n.store.GetLog(id, lid)
+	}
+	return n.createLog(id, key, identity)
+}
+
+// createLog adds a log of identity to the thread. The caller must hold the host lock.
+func (n *Net) createLog(id thread.ID, key crypto.Key, identity thread.PubKey) (info thread.LogInfo, err error) {
+	identity = n.identityOrHost(identity)
+	var ok bool
+	if key == nil {
+		if info.PrivKey, info.PubKey, err = crypto.GenerateEd25519Key(rand.Reader); err != nil {
+			return
+		}
+	} else if info.PrivKey, ok = key.(crypto.PrivKey); ok {
+		info.PubKey = info.PrivKey.GetPublic()
+	} else if info.PubKey, ok = key.(crypto.PubKey); !ok {
+		return info, fmt.Errorf("invalid log-key")
+	}
+	if info.ID, err = peer.IDFromPublicKey(info.PubKey); err != nil {
+		return
+	}
+	info.Managed = true
+	if err = n.store.AddLog(id, info); err != nil {
+		return
+	}
+	if err = n.store.PutBytes(id, identity.String(), []byte(info.ID)); err != nil {
+		return
+	}
+	n.emit(core.LifecycleEvent{Type: core.LogAdded, ThreadID: id, LogID: info.ID})
+	return info, nil
+}
+
+// deliver pushes the latest records of a log, along with the thread metadata, to the other hosts
+// of the thread in the background. Hosts which are unreachable once the network latency passed
+// miss the records until they pull, deliveries aren't retried. The outcome of every push is kept
+// in the sync status of the host, and hosts which stored the records rids acknowledge them.
+func (n *Net) deliver(id thread.ID, lid peer.ID, rids ...cid.Cid) *delivery {
+	d := &delivery{done: make(chan struct{}), errs: make(map[peer.ID]error)}
+	var wg sync.WaitGroup
+	for _, pid := range n.replicas(id) {
+		wg.Add(1)
+		n.wg.Add(1)
+		go func(pid peer.ID) {
+			defer n.wg.Done()
+			defer wg.Done()
+			err := n.deliverTo(pid, id, lid)
+			if err != nil {
+				log.Debugf("delivering log %s (thread=%s) to %s failed: %v", lid, id, pid, err)
+			}
+			n.trackDelivery(pid, id, rids, err)
+			d.lk.Lock()
+			d.errs[pid] = err
+			d.lk.Unlock()
+		}(pid)
+	}
+	go func() {
+		wg.Wait()
+		close(d.done)
+	}()
+	return d
+}
+
+func (n *Net) deliverTo(pid peer.ID, id thread.ID, lid peer.ID) error {
+	h, err := n.nw.dial(n.ctx, n.id, pid)
+	if err != nil {
+		return err
+	}
+	rights := pushRights
+	if pk, err := h.store.PubKey(id, lid); err != nil {
+		return err
+	} else if pk == nil {
+		rights = pushLogRights
+	}
+	if err = h.checkCapability(n, id, rights); err != nil {
+		return err
+	}
+	if err = h.pullLog(h.ctx, n, id, lid, core.SourcePush); err != nil {
+		return err
+	}
+	return h.mergeMetadata(n, id)
+}
+
+// replicas returns the other hosts storing a thread, except the ones removed with RemoveReplicator.
+// Hosts in the region of the host come first, see SetPeerLocality.
+func (n *Net) replicas(id thread.ID) []peer.ID {
+	var pids []peer.ID
+	for _, pid := range n.nw.peers(n.id, id) {
+		if removed, err := n.store.GetBool(id, pid.String()+removedReplicatorSuffix); err != nil || removed == nil || !*removed {
+			pids = append(pids, pid)
+		}
+	}
+	n.sx.Lock()
+	region := n.localities[n.id].Region
+	local := make(map[peer.ID]bool, len(pids))
+	for _, pid := range pids {
+		local[pid] = region != "" && n.localities[pid].Region == region
+	}
+	n.sx.Unlock()
+	sort.SliceStable(pids, func(i, j int) bool {
+		return local[pids[i]] && !local[pids[j]]
+	})
+	return pids
+}
+
+// pullFrom pulls every log of a thread from another host. Hosts which archived the thread don't
+// serve its records.
+func (n *Net) pullFrom(ctx context.Context, pid peer.ID, id thread.ID) (err error) {
+	start := time.Now()
+	defer func() {
+		n.trackPull(pid, time.Since(start), err)
+		n.emitPull(id, pid, err)
+	}()
+	h, err := n.nw.dial(ctx, n.id, pid)
+	if err != nil {
+		return err
+	}
+	if err = h.checkCapability(n, id, fetchRights); err != nil {
+		return err
+	}
+	info, err := h.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	if archived, err := h.isArchived(id); err != nil {
+		return err
+	} else if archived {
+		return fmt.Errorf("thread %s is archived by %s", id, pid)
+	}
+	for _, lg := range info.Logs {
+		if err = n.pullLog(ctx, h, id, lg.ID, core.SourcePull); err != nil {
+			return fmt.Errorf("pulling log %s: %w", lg.ID, err)
+		}
+	}
+	return n.mergeMetadata(h, id)
+}
+
+// pullLog adds the records of a log at another host which are missing locally, oldest first,
+// up to the MaxPullLimit of the sync config. Records below the compaction boundary of the sender
+// aren't served, and bodies withheld from the host by the sender's ACL are left out. The progress
+// is kept in the pull status of the log.
+func (n *Net) pullLog(ctx context.Context, from *Net, id thread.ID, lid peer.ID, kind core.RecordSourceKind) (err error) {
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return err
+	}
+	if sk == nil {
+		return lstore.ErrThreadNotFound
+	}
+	// missing blocks of an archived thread would be taken for missing records
+	if err = n.rehydrateThread(ctx, id); err != nil {
+		return err
+	}
+	head, err := from.head(id, lid)
+	if err != nil {
+		return err
+	}
+	boundary, err := util.LogMarker(from.store, id, lid, boundarySuffix)
+	if err != nil {
+		return err
+	}
+	restricted, err := from.bodyRestriction(id, n.id)
+	if err != nil {
+		return err
+	}
+	var behind int
+	defer func() { n.trackLogPull(id, lid, head, behind, err) }()
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	// logs are added even without records, e.g., to verify the metadata they sign
+	if pk, err := n.store.PubKey(id, lid); err != nil {
+		return err
+	} else if pk == nil {
+		pk, err = from.store.PubKey(id, lid)
+		if err != nil {
+			return err
+		}
+		if err = n.checkTrustedLogKey(from, id, lid, pk); err != nil {
+			return err
+		}
+		if err = n.store.AddLog(id, thread.LogInfo{ID: lid, PubKey: pk}); err != nil {
+			return err
+		}
+		n.emit(core.LifecycleEvent{Type: core.LogAdded, ThreadID: id, LogID: lid})
+	}
+	var chain []core.Record
+	for rid := head; rid.Defined(); {
+		if known, err := n.bstore.Has(rid); err != nil {
+			return err
+		} else if known {
+			break
+		}
+		rec, err := cbor.GetRecord(ctx, from, rid, sk)
+		if err != nil {
+			return err
+		}
+		// verification signs over the block, load it from the sender
+		if _, err = rec.GetBlock(ctx, from); err != nil {
+			return err
+		}
+		chain = append(chain, rec)
+		if rid.Equals(boundary) {
+			break // older records were dropped by the sender
+		}
+		rid = rec.PrevID()
+	}
+	behind = len(chain)
+	if len(chain) == 0 {
+		return nil
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	if chain[0].Cid().Equals(boundary) {
+		if err = n.adoptBoundary(id, lid, chain[0]); err != nil {
+			return err
+		}
+	}
+	if limit := n.getSyncConfig().MaxPullLimit; limit > 0 && len(chain) > limit {
+		chain = chain[:limit]
+	}
+	withheld := make(map[cid.Cid]struct{})
+	for _, rec := range chain {
+		if (restricted != nil && restricted(lid, rec.Cid())) || from.isBodyless(id, rec.Cid()) {
+			withheld[rec.Cid()] = struct{}{}
+		}
+	}
+	if err = n.putRecords(ctx, from, id, lid, chain, core.RecordSource{Kind: kind, Peer: from.id, ReceivedAt: time.Now()}, withheld); err != nil {
+		return err
+	}
+	behind -= len(chain)
+	return nil
+}
+
+// putRecords verifies a chain of records and copies its blocks from another host, advancing the
+// log head record by record. Records in withheld are stored without their bodies, and aren't
+// handed to the app. Records received from peers are refused once they exceed the thread quota,
+// and records the app fails to handle become dead letters, see DeadLetterAttempts. The retention
+// policy of the thread is enforced afterwards. The caller must hold the host lock.
+func (n *Net) putRecords(
+	ctx context.Context,
+	from *Net,
+	id thread.ID,
+	lid peer.ID,
+	chain []core.Record,
+	src core.RecordSource,
+	withheld map[cid.Cid]struct{},
+) error {
+	pk, err := n.store.PubKey(id, lid)
+	if err != nil {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	connector, appConnected := n.getConnector(id)
+	for _, rec := range chain {
+		if err = rec.Verify(pk); err != nil {
+			n.trackMisbehavior(src.Peer)
+			return fmt.Errorf("record %s: %w", rec.Cid(), err)
+		}
+		_, bodyless := withheld[rec.Cid()]
+		size, err := n.quotaSize(ctx, from, id, rec, !bodyless)
+		if err != nil {
+			return err
+		}
+		if src.Kind != core.SourceLocal {
+			if err = n.fitsQuota(id, size); err != nil {
+				return err
+			}
+		}
+		// the record block is copied last, marking the record processed
+		if err = copyEvent(ctx, from, n, rec.BlockID(), !bodyless); err != nil {
+			return err
+		}
+		tr := &Record{Record: rec, threadID: id, logID: lid, source: src, restricted: bodyless}
+		if bodyless {
+			if err = n.store.PutBool(id, rec.Cid().String()+bodylessSuffix, true); err != nil {
+				return err
+			}
+		} else if appConnected && info.Key.CanRead() {
+			if err = n.handleRecord(ctx, connector, tr); err != nil {
+				return err
+			}
+		}
+		if err = n.Add(ctx, rec); err != nil {
+			return err
+		}
+		if err = n.store.SetHead(id, lid, rec.Cid()); err != nil {
+			return err
+		}
+		if err = n.chargeQuota(id, size); err != nil {
+			return err
+		}
+		if err = n.bus.SendWithTimeout(tr, notifyTimeout); err != nil {
+			log.Errorf("error notifying listeners of record %s: %v", rec.Cid(), err)
+		}
+	}
+	if len(chain) > 0 {
+		n.emitHeadsChanged(id, lid, src.Peer, chain[len(chain)-1].Cid())
+		if _, err = n.reapThread(ctx, id); err != nil {
+			log.Errorf("enforcing retention of thread %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// head returns the head of a log, which is undefined if the log is unknown.
+func (n *Net) head(id thread.ID, lid peer.ID) (cid.Cid, error) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	heads, err := n.store.Heads(id, lid)
+	if err != nil || len(heads) == 0 {
+		return cid.Undef, err
+	}
+	return heads[0], nil
+}
+
+// delivery collects the outcome of pushing a log to the other hosts of a thread.
+type delivery struct {
+	done chan struct{}
+	lk   sync.Mutex
+	errs map[peer.ID]error
+}
+
+// recordFuture tracks the delivery of a record created with CreateRecordAsync.
+type recordFuture struct {
+	rec core.ThreadRecord
+	*delivery
+}
+
+func (f *recordFuture) Record() core.ThreadRecord {
+	return f.rec
+}
+
+func (f *recordFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *recordFuture) Wait(ctx context.Context) (map[peer.ID]error, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-f.done:
+	}
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	errs := make(map[peer.ID]error, len(f.errs))
+	for pid, err := range f.errs {
+		errs[pid] = err
+	}
+	return errs, nil
+}
+
+// copyDAG copies a node and the nodes it links to from one host to another,
+// skipping nodes the receiver already has.
+func copyDAG(ctx context.Context, from, to *Net, id cid.Cid) error {
+	if known, err := to.bstore.Has(id); err != nil || known {
+		return err
+	}
+	node, err := from.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, l := range node.Links() {
+		if err = copyDAG(ctx, from, to, l.Cid); err != nil {
+			return err
+		}
+	}
+	return to.Add(ctx, node)
+}
+
+// copyEvent copies an event block along with its header from one host to another, and its body
+// unless withBody is false.
+func copyEvent(ctx context.Context, from, to *Net, id cid.Cid, withBody bool) error {
+	if withBody {
+		return copyDAG(ctx, from, to, id)
+	}
+	if known, err := to.bstore.Has(id); err != nil || known {
+		return err
+	}
+	node, err := from.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	ev, err := cbor.EventFromNode(node)
+	if err != nil {
+		return err
+	}
+	if err = copyDAG(ctx, from, to, ev.HeaderID()); err != nil {
+		return err
+	}
+	return to.Add(ctx, node)
+}
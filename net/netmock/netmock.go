@@ -0,0 +1,247 @@
+// Package netmock provides an in-memory implementation of app.Net for testing. Hosts of a
+// Network exchange records by calling each other directly, without libp2p or gRPC, so
+// applications can unit test against the thread semantics without spinning up real hosts.
+// Delivery latency and network partitions can be injected to exercise sync edge cases.
+//
+// Hosts implement every method of app.Net. There is no pubsub, gRPC or connection manager, so
+// the status methods of those transports report an idle host, and the dag service of hosts is
+// offline: blocks missing locally are copied from other hosts of the thread instead.
+package netmock
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	format "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var log = logging.Logger("netmock")
+
+// Network connects in-memory hosts. Records created by a host are delivered to the other
+// hosts of their threads after the configured latency, unless the hosts are partitioned.
+// Records missed while partitioned are caught up by pulling the thread once healed.
+type Network struct {
+	lk         sync.RWMutex
+	hosts      map[peer.ID]*Net
+	latency    time.Duration
+	partitions map[link]struct{}
+	// requireCaps makes hosts serve threads they minted capabilities for only to hosts
+	// presenting one
+	requireCaps bool
+}
+
+// link is an unordered pair of hosts.
+type link struct {
+	a, b peer.ID
+}
+
+func newLink(a, b peer.ID) link {
+	if b < a {
+		a, b = b, a
+	}
+	return link{a: a, b: b}
+}
+
+// NewNetwork returns an empty network without latency.
+func NewNetwork() *Network {
+	return &Network{
+		hosts:      make(map[peer.ID]*Net),
+		partitions: make(map[link]struct{}),
+	}
+}
+
+// NewNet adds a host with a random identity to the network.
+func (nw *Network) NewNet() (*Net, error) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return nw.NewNetWithKey(sk)
+}
+
+// NewNetWithKey adds a host with the given identity to the network.
+func (nw *Network) NewNetWithKey(sk crypto.PrivKey) (*Net, error) {
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	if _, ok := nw.hosts[id]; ok {
+		return nil, fmt.Errorf("host %s is already in the network", id)
+	}
+	n := newNet(nw, id, sk)
+	nw.hosts[id] = n
+	return n, nil
+}
+
+// SetLatency delays every delivery and pull between hosts by d.
+func (nw *Network) SetLatency(d time.Duration) {
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	nw.latency = d
+}
+
+// Partition cuts the link between two hosts. Records aren't delivered in either direction,
+// and pulls between the hosts fail until the partition is healed.
+func (nw *Network) Partition(a, b peer.ID) {
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	nw.partitions[newLink(a, b)] = struct{}{}
+}
+
+// Isolate cuts the links between a host and every other host of the network.
+func (nw *Network) Isolate(id peer.ID) {
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	for pid := range nw.hosts {
+		if pid != id {
+			nw.partitions[newLink(id, pid)] = struct{}{}
+		}
+	}
+}
+
+// Heal restores all links cut by Partition and Isolate.
+func (nw *Network) Heal() {
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	nw.partitions = make(map[link]struct{})
+}
+
+// RequireCapabilities makes hosts serve threads they minted capabilities for only to hosts
+// which were granted a capability with AddCapability, like net.Config.RequireCapabilities.
+func (nw *Network) RequireCapabilities(require bool) {
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	nw.requireCaps = require
+}
+
+func (nw *Network) requiresCapabilities() bool {
+	nw.lk.RLock()
+	defer nw.lk.RUnlock()
+	return nw.requireCaps
+}
+
+// connected returns whether two hosts can reach each other.
+func (nw *Network) connected(a, b peer.ID) bool {
+	nw.lk.RLock()
+	defer nw.lk.RUnlock()
+	_, cut := nw.partitions[newLink(a, b)]
+	return !cut
+}
+
+// dial returns a host reachable from the host from, after the network latency.
+func (nw *Network) dial(ctx context.Context, from, to peer.ID) (*Net, error) {
+	nw.lk.RLock()
+	h, ok := nw.hosts[to]
+	latency := nw.latency
+	nw.lk.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("host %s not found", to)
+	}
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+	// partitions are checked once the latency passed, like a link failing mid-flight
+	if !nw.connected(from, to) {
+		return nil, fmt.Errorf("host %s is unreachable from %s", to, from)
+	}
+	return h, nil
+}
+
+// peers returns the other hosts storing a thread.
+func (nw *Network) peers(self peer.ID, id thread.ID) []peer.ID {
+	nw.lk.RLock()
+	defer nw.lk.RUnlock()
+	var pids []peer.ID
+	for pid, h := range nw.hosts {
+		if pid == self {
+			continue
+		}
+		if sk, err := h.store.ServiceKey(id); err == nil && sk != nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// holder returns any host which has a block.
+func (nw *Network) holder(id cid.Cid) *Net {
+	nw.lk.RLock()
+	defer nw.lk.RUnlock()
+	for _, h := range nw.hosts {
+		if ok, err := h.bstore.Has(id); err == nil && ok {
+			return h
+		}
+	}
+	return nil
+}
+
+func (nw *Network) remove(id peer.ID) {
+	nw.lk.Lock()
+	defer nw.lk.Unlock()
+	delete(nw.hosts, id)
+}
+
+// newDAG returns a DAG service on top of an in-memory blockstore.
+func newDAG() (format.DAGService, bs.Blockstore) {
+	bstore := bs.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	return dag.NewDAGService(bserv.New(bstore, offline.Exchange(bstore))), bstore
+}
+
+// Record is a thread record delivered to subscribers.
+type Record struct {
+	core.Record
+	threadID thread.ID
+	logID    peer.ID
+	source   core.RecordSource
+	// restricted is set for records stored without their bodies, see SetThreadACL
+	restricted bool
+}
+
+// Value returns the underlying record.
+func (r *Record) Value() core.Record {
+	return r
+}
+
+// ThreadID returns the thread id the record belongs to.
+func (r *Record) ThreadID() thread.ID {
+	return r.threadID
+}
+
+// LogID returns the log id the record belongs to.
+func (r *Record) LogID() peer.ID {
+	return r.logID
+}
+
+// Source returns how and when the record reached the host.
+func (r *Record) Source() core.RecordSource {
+	return r.source
+}
+
+// Restricted returns whether the record is stored without the body, which was withheld by the
+// thread ACL of the host it was received from.
+func (r *Record) Restricted() bool {
+	return r.restricted
+}
@@ -0,0 +1,816 @@
+package netmock
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+func TestNet_Replication(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	r1, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "one"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the thread is pulled while adding it
+	if _, err = n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n2.GetRecord(ctx, info.ID, r1.Value().Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := cbor.EventFromRecord(ctx, n2, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := event.GetBody(ctx, n2, info.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, _, err := body.Resolve([]string{"msg"}); err != nil || msg != "one" {
+		t.Fatalf("expected decrypted body, got %v (%v)", msg, err)
+	}
+
+	// new records are delivered to subscribers of the other host
+	sub, err := n2.Subscribe(ctx, core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case tr := <-sub:
+		if !tr.Value().Cid().Equals(r2.Value().Cid()) || tr.LogID() != r2.LogID() {
+			t.Fatalf("expected record %s, got %s", r2.Value().Cid(), tr.Value().Cid())
+		}
+		if tr.Source().Kind != core.SourcePush || tr.Source().Peer != n1.id {
+			t.Fatalf("expected record pushed by %s, got %+v", n1.id, tr.Source())
+		}
+	case <-ctx.Done():
+		t.Fatal("record wasn't delivered")
+	}
+}
+
+func TestNet_Latency(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	latency := 200 * time.Millisecond
+	nw.SetLatency(latency)
+	start := time.Now()
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "slow"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AwaitRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Fatalf("expected delivery after %s, got %s", latency, elapsed)
+	}
+}
+
+func TestNet_Partition(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	nw.Partition(n1.id, n2.id)
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "lost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1.wg.Wait() // delivery attempts finished
+	if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err == nil {
+		t.Fatal("expected record not to cross the partition")
+	}
+	if err = n2.PullThread(ctx, info.ID); err == nil {
+		t.Fatal("expected pull across the partition to fail")
+	}
+
+	// records missed while partitioned are pulled once healed
+	nw.Heal()
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_AddReplicator(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "replicated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2, err := n2.getThread(info.ID)
+	if err == nil {
+		t.Fatalf("expected thread not to be stored, got %s", info2.ID)
+	}
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	// replicators store records without the read key
+	if info2, err = n2.getThread(info.ID); err != nil {
+		t.Fatal(err)
+	} else if info2.Key.CanRead() {
+		t.Fatal("expected replicator without the read key")
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_RemoveReplicator(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n2.id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.RemoveReplicator(ctx, info.ID, n2.id); err != nil {
+		t.Fatal(err)
+	}
+	f, err := n1.CreateRecordAsync(ctx, info.ID, makeBody(t, "kept"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs, err := f.Wait(ctx); err != nil {
+		t.Fatal(err)
+	} else if len(errs) != 0 {
+		t.Fatalf("expected no delivery to the removed replicator, got %v", errs)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, f.Record().Value().Cid()); err == nil {
+		t.Fatal("expected record not to reach the removed replicator")
+	}
+}
+
+func TestNet_CreateRecordAsync(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2, n3 := makeNet(t, nw), makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+	defer n3.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThreads(ctx, info.Addrs, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n3.AddThreads(ctx, info.Addrs, core.WithThreadKeys(map[thread.ID]thread.Key{info.ID: info.Key})); err != nil {
+		t.Fatal(err)
+	}
+
+	nw.Partition(n1.id, n3.id)
+	f, err := n1.CreateRecordAsync(ctx, info.ID, makeBody(t, "async"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := f.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := errs[n2.id]; !ok || err != nil {
+		t.Fatalf("expected delivery to %s, got %v", n2.id, errs)
+	}
+	if errs[n3.id] == nil {
+		t.Fatalf("expected delivery across the partition to fail, got %v", errs)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, f.Record().Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+
+	nw.Heal()
+	if err = n3.PullThreads(ctx, []thread.ID{info.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n3.GetRecord(ctx, info.ID, f.Record().Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_Records(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	r1, err := n1.CreateRecords(ctx, info.ID, []format.Node{makeBody(t, "one"), makeBody(t, "two")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := n2.CreateRecordAsync(ctx, info.ID, makeBody(t, "three"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	n1.wg.Wait()
+
+	// records are ordered by their positions in the logs
+	ch, err := n1.Records(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []core.ThreadRecord
+	for r := range ch {
+		recs = append(recs, r)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+	if !recs[2].Value().Cid().Equals(r1[1].Value().Cid()) {
+		t.Fatalf("expected last record %s, got %s", r1[1].Value().Cid(), recs[2].Value().Cid())
+	}
+	ch, err = n1.Records(ctx, info.ID, core.WithRecordsSince(recs[0].Value().Cid()), core.WithRecordsLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := <-ch; !r.Value().Cid().Equals(recs[1].Value().Cid()) {
+		t.Fatalf("expected record %s, got %s", recs[1].Value().Cid(), r.Value().Cid())
+	}
+
+	identities, err := n1.ListIdentities(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 2 {
+		t.Fatalf("expected 2 identities, got %d", len(identities))
+	}
+	if !identities[f.Record().LogID()].Equals(thread.NewLibp2pPubKey(n2.sk.GetPublic())) {
+		t.Fatalf("expected log of %s to be written by its host", n2.id)
+	}
+}
+
+func TestNet_ThreadMetadata(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n1.SetThreadMetadata(ctx, info.ID, thread.Metadata{Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	n1.wg.Wait()
+	if md, err := n2.GetThreadMetadata(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	} else if md.Name != "first" {
+		t.Fatalf("expected delivered metadata, got %q", md.Name)
+	}
+
+	// the latest metadata wins, even if missed while partitioned
+	nw.Partition(n1.id, n2.id)
+	if _, err := n2.SetThreadMetadata(ctx, info.ID, thread.Metadata{Name: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	n2.wg.Wait()
+	nw.Heal()
+	if err := n1.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if md, err := n1.GetThreadMetadata(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	} else if md.Name != "second" {
+		t.Fatalf("expected latest metadata, got %q", md.Name)
+	}
+}
+
+func TestNet_RevokeToken(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n := makeNet(t, nw)
+	defer n.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := thread.NewLibp2pIdentity(sk)
+	tok, err := n.GetToken(ctx, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := thread.NewIDV1(thread.Raw, 32)
+	if _, err = n.CreateThread(ctx, id, core.WithNewThreadToken(tok)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = n.RevokeToken(ctx, tok); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.GetThread(ctx, id, core.WithThreadToken(tok)); !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("expected revoked token, got %v", err)
+	}
+
+	if err = n.RevokeIdentity(ctx, identity.GetPublic(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.GetToken(ctx, identity); !errors.Is(err, core.ErrIdentityRevoked) {
+		t.Fatalf("expected revoked identity, got %v", err)
+	}
+	if err = n.RestoreIdentity(ctx, identity.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+	if tok, err = n.GetToken(ctx, identity); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n.GetThread(ctx, id, core.WithThreadToken(tok)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_Capabilities(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	nw.RequireCapabilities(true)
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	c, err := n1.MintCapability(ctx, info.ID, []thread.Caveat{thread.CaveatPeer(n2.id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); !errors.Is(err, ErrCapabilityRequired) {
+		t.Fatalf("expected a required capability, got %v", err)
+	}
+	if err = n2.AddCapability(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// rotating the root key invalidates the granted capability
+	if err = n1.RevokeCapabilities(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.PullThread(ctx, info.ID); err == nil {
+		t.Fatal("expected pull with a revoked capability to fail")
+	}
+}
+
+func TestNet_SubscribeEvents(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	events, err := n2.SubscribeEvents(ctx, core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "event"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type != core.HeadsChanged {
+				continue
+			}
+			if !ev.RecordID.Equals(r.Value().Cid()) || ev.PeerID != n1.id {
+				t.Fatalf("expected heads changed to %s by %s, got %s", r.Value().Cid(), n1.id, ev)
+			}
+			recent, err := n2.RecentEvents(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recent) == 0 || recent[0].Type != core.ThreadAdded {
+				t.Fatalf("expected the thread addition first in recent events, got %v", recent)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("heads change wasn't emitted")
+		}
+	}
+}
+
+func TestNet_LinkThread(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	parent, child := createThread(t, ctx, n1), createThread(t, ctx, n1)
+	for _, info := range []thread.Info{parent, child} {
+		if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := n2.LinkThread(ctx, parent.ID, child.ID, core.LinkChild); err != nil {
+		t.Fatal(err)
+	}
+	links, err := n2.ThreadLinks(ctx, parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].ID != child.ID || links[0].Kind != core.LinkChild {
+		t.Fatalf("expected link to %s, got %v", child.ID, links)
+	}
+
+	// pulling the parent thread pulls the linked one
+	nw.Partition(n1.id, n2.id)
+	r, err := n1.CreateRecord(ctx, child.ID, makeBody(t, "linked"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1.wg.Wait()
+	nw.Heal()
+	if err = n2.PullThread(ctx, parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, child.ID, r.Value().Cid()); err != nil {
+		t.Fatalf("expected linked thread to be pulled: %v", err)
+	}
+
+	if err = n2.UnlinkThread(ctx, parent.ID, child.ID); err != nil {
+		t.Fatal(err)
+	}
+	if links, err = n2.ThreadLinks(ctx, parent.ID); err != nil {
+		t.Fatal(err)
+	} else if len(links) != 0 {
+		t.Fatalf("expected no links, got %v", links)
+	}
+}
+
+func TestNet_HandoffLog(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	r1, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "one"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	lid := r1.LogID()
+	if err = n1.HandoffLog(ctx, info.ID, lid, n2.id); err != nil {
+		t.Fatal(err)
+	}
+
+	// the new owner continues the log, the previous owner starts a new one
+	r2, err := n2.CreateRecord(ctx, info.ID, makeBody(t, "two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.LogID() != lid {
+		t.Fatalf("expected record in handed off log %s, got %s", lid, r2.LogID())
+	}
+	r3, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "three"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r3.LogID() == lid {
+		t.Fatal("expected the previous owner to write to a new log")
+	}
+	if sk, err := n1.store.PrivKey(info.ID, lid); err != nil || sk != nil {
+		t.Fatalf("expected the previous owner to drop the log key, got %v (%v)", sk, err)
+	}
+}
+
+func TestNet_ExportThread(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	recs, err := n1.CreateRecords(ctx, info.ID, []format.Node{makeBody(t, "one"), makeBody(t, "two")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = n1.ExportThread(ctx, info.ID, &buf, core.WithExportKeys()); err != nil {
+		t.Fatal(err)
+	}
+
+	// hosts aren't connected, the archive carries the whole thread
+	nw.Partition(n1.id, n2.id)
+	imported, err := n2.ImportThread(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.ID != info.ID || !imported.Key.CanRead() {
+		t.Fatalf("expected thread %s with keys, got %s", info.ID, imported.ID)
+	}
+	for _, r := range recs {
+		if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+			t.Fatalf("expected imported record %s: %v", r.Value().Cid(), err)
+		}
+	}
+}
+
+func TestNet_Invite(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2, n3 := makeNet(t, nw), makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+	defer n3.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "invited"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invite, err := n1.CreateInvite(ctx, info.ID, core.WithSingleUseInvite())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AcceptInvite(ctx, invite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatalf("expected record pulled from the inviter: %v", err)
+	}
+	if _, err = n3.AcceptInvite(ctx, invite); !errors.Is(err, core.ErrInviteRedeemed) {
+		t.Fatalf("expected a redeemed invite, got %v", err)
+	}
+}
+
+func TestNet_VerifyThread(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "damaged"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.bstore.DeleteBlock(r.Value().BlockID()); err != nil {
+		t.Fatal(err)
+	}
+	v, err := n2.VerifyThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.OK() {
+		t.Fatal("expected the missing event to be reported")
+	}
+	if v, err = n2.VerifyThread(ctx, info.ID, core.WithRepair()); err != nil {
+		t.Fatal(err)
+	}
+	if !v.OK() || len(v.Logs) != 1 || len(v.Logs[0].Damage) != 1 || !v.Logs[0].Damage[0].Repaired {
+		t.Fatalf("expected the missing event to be repaired, got %+v", v)
+	}
+}
+
+func TestNet_ArchiveThread(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "archived"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.ArchiveThread(ctx, info.ID); !errors.Is(err, core.ErrNotReplicated) {
+		t.Fatalf("expected an unreplicated thread, got %v", err)
+	}
+	if _, err = n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.ArchiveThread(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if known, err := n1.bstore.Has(r.Value().Cid()); err != nil || known {
+		t.Fatalf("expected the record block to be dropped, got %v (%v)", known, err)
+	}
+
+	// reading the thread rehydrates it from the replica
+	if _, err = n1.GetRecord(ctx, info.ID, r.Value().Cid()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNet_ThreadQuota(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2 := makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	if _, err := n2.AddThread(ctx, info.Addrs[0], core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.SetThreadQuota(ctx, info.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// records exceeding the quota of the receiver are refused
+	nw.Partition(n1.id, n2.id)
+	r, err := n1.CreateRecord(ctx, info.ID, makeBody(t, "too big"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1.wg.Wait()
+	nw.Heal()
+	if err = n2.PullThread(ctx, info.ID); err == nil {
+		t.Fatal("expected pull to fail")
+	}
+	status, err := n2.PullStatus(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = status[r.LogID()].LastError; !errors.Is(err, core.ErrQuotaExceeded) {
+		t.Fatalf("expected an exceeded quota, got %v", err)
+	}
+	stats, err := n2.ThreadStats(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 0 {
+		t.Fatalf("expected no records stored, got %d", stats.Records)
+	}
+}
+
+func TestNet_EscrowThreadKey(t *testing.T) {
+	t.Parallel()
+	nw := NewNetwork()
+	n1, n2, n3 := makeNet(t, nw), makeNet(t, nw), makeNet(t, nw)
+	defer n1.Close()
+	defer n2.Close()
+	defer n3.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery := thread.NewLibp2pIdentity(sk)
+	peers := []peer.ID{n2.id, n3.id}
+	if err = n1.EscrowThreadKey(ctx, info.ID, peers, 2, recovery); err != nil {
+		t.Fatal(err)
+	}
+	key, err := n1.RecoverThreadKey(ctx, info.ID, peers, recovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Bytes(), info.Key.Bytes()) {
+		t.Fatal("expected the escrowed thread key to be recovered")
+	}
+
+	// shares are only returned to the recovery identity
+	other, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n1.RecoverThreadKey(ctx, info.ID, peers, thread.NewLibp2pIdentity(other)); err == nil {
+		t.Fatal("expected recovery with another identity to fail")
+	}
+}
+
+func makeNet(t *testing.T, nw *Network) *Net {
+	n, err := nw.NewNet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func createThread(t *testing.T, ctx context.Context, n *Net) thread.Info {
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func makeBody(t *testing.T, msg string) format.Node {
+	body, err := cbornode.WrapObject(map[string]interface{}{"msg": msg}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
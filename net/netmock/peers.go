@@ -0,0 +1,193 @@
+package netmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// latencyWeight is the weight of a new round trip in the moving average latency of a peer.
+const latencyWeight = 0.2
+
+// PeerCapabilities returns the protocol features of the in-memory net. Hosts don't relay
+// threads nor compress messages.
+func (n *Net) PeerCapabilities(ctx context.Context, pid peer.ID) (core.Capabilities, error) {
+	if _, err := n.nw.dial(ctx, n.id, pid); err != nil {
+		return core.Capabilities{}, fmt.Errorf("dial %s failed: %w", pid, err)
+	}
+	return core.Capabilities{
+		Protocol: core.ProtocolInfo{
+			Version:  core.ProtocolVersion,
+			Features: []string{core.FeatureSubscribe, core.FeatureCapabilityTokens, core.FeatureEnvelopeV2},
+		},
+	}, nil
+}
+
+// SetPeerLocality tags a host, or the host itself, with its locality. Replicas in the region of
+// the host are delivered to first. An undefined locality removes the tag.
+func (n *Net) SetPeerLocality(_ context.Context, pid peer.ID, loc core.Locality) error {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	if !loc.Defined() {
+		delete(n.localities, pid)
+	} else {
+		n.localities[pid] = loc
+	}
+	return nil
+}
+
+// SubscribePeer subscribes to new records of threads at another host, instead of pulling them.
+// Received records are added to the threads before they're delivered on the channel, along
+// with the records they follow which were missed.
+func (n *Net) SubscribePeer(ctx context.Context, pid peer.ID, opts ...core.SubOption) (<-chan core.ThreadRecord, error) {
+	args := &core.SubOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if len(args.ThreadIDs) == 0 {
+		return nil, errors.New("subscribing to a peer requires a thread filter")
+	}
+	h, err := n.nw.dial(ctx, n.id, pid)
+	if err != nil {
+		return nil, err
+	}
+	filter := make(map[thread.ID]struct{}, len(args.ThreadIDs))
+	for _, id := range args.ThreadIDs {
+		if _, err := n.Validate(id, args.Token, true); err != nil {
+			return nil, err
+		}
+		if err = h.checkCapability(n, id, fetchRights); err != nil {
+			return nil, err
+		}
+		filter[id] = struct{}{}
+	}
+	logs := make(map[peer.ID]struct{}, len(args.LogIDs))
+	for _, lid := range args.LogIDs {
+		logs[lid] = struct{}{}
+	}
+
+	channel := make(chan core.ThreadRecord)
+	listener := h.bus.Listen()
+	go func() {
+		defer close(channel)
+		defer listener.Discard()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case i, ok := <-listener.Channel():
+				if !ok {
+					return
+				}
+				rec := i.(*Record)
+				if _, ok := filter[rec.threadID]; !ok {
+					continue
+				}
+				if _, ok := logs[rec.logID]; len(logs) > 0 && !ok {
+					continue
+				}
+				if err := n.pullLog(ctx, h, rec.threadID, rec.logID, core.SourceSubscription); err != nil {
+					log.Errorf("adding record %s from %s failed: %v", rec.Value().Cid(), pid, err)
+					continue
+				}
+				tr := &Record{
+					Record:     rec.Value(),
+					threadID:   rec.threadID,
+					logID:      rec.logID,
+					source:     core.RecordSource{Kind: core.SourceSubscription, Peer: pid, ReceivedAt: time.Now()},
+					restricted: n.isBodyless(rec.threadID, rec.Value().Cid()),
+				}
+				select {
+				case channel <- tr:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return channel, nil
+}
+
+// UpdateConfig applies the sync tuning. Records are delivered and pulled directly, so only the
+// MaxPullLimit applies to the in-memory net, zero meaning no limit.
+func (n *Net) UpdateConfig(_ context.Context, cfg core.SyncConfig) error {
+	switch {
+	case cfg.PullInterval < 0, cfg.InitialPullInterval < 0, cfg.QueuePollInterval < 0:
+		return fmt.Errorf("sync intervals can't be negative")
+	case cfg.MaxPullLimit < 0:
+		return fmt.Errorf("max pull limit can't be negative")
+	case cfg.MaxConcurrentPulls < 0:
+		return fmt.Errorf("max concurrent pulls can't be negative")
+	case cfg.EventBusCapacity < 0:
+		return fmt.Errorf("event bus capacity can't be negative")
+	}
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	n.syncConfig = cfg
+	return nil
+}
+
+// getSyncConfig returns the current sync tuning.
+func (n *Net) getSyncConfig() core.SyncConfig {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	return n.syncConfig
+}
+
+// PeerReputations returns the track record of the hosts pulled from. Hosts aren't banned
+// after misbehaving, the in-memory net doesn't schedule pulls.
+func (n *Net) PeerReputations(_ context.Context) (map[peer.ID]core.PeerReputation, error) {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	res := make(map[peer.ID]core.PeerReputation, len(n.reputations))
+	for pid, rep := range n.reputations {
+		res[pid] = rep
+	}
+	return res, nil
+}
+
+func (n *Net) ResetPeerReputation(_ context.Context, pid peer.ID) error {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	delete(n.reputations, pid)
+	return nil
+}
+
+// trackPull keeps the outcome and round trip of a pull from a host.
+func (n *Net) trackPull(pid peer.ID, rtt time.Duration, err error) {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	rep := n.reputations[pid]
+	rep.Pulls++
+	if err != nil {
+		rep.PullFailures++
+		rep.ConsecutiveFailures++
+		rep.LastFailure = time.Now()
+	} else {
+		rep.ConsecutiveFailures = 0
+		if rep.Latency == 0 {
+			rep.Latency = rtt
+		} else {
+			rep.Latency += time.Duration(latencyWeight * float64(rtt-rep.Latency))
+		}
+	}
+	n.reputations[pid] = rep
+}
+
+// trackMisbehavior keeps a response of a host with invalid records. Local records are ignored.
+func (n *Net) trackMisbehavior(pid peer.ID) {
+	if pid == "" || pid == n.id {
+		return
+	}
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	rep := n.reputations[pid]
+	rep.Misbehaviors++
+	rep.LastFailure = time.Now()
+	n.reputations[pid] = rep
+}
@@ -0,0 +1,161 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+var (
+	// DefaultThreadQuota is the byte budget of threads without a quota set with SetThreadQuota.
+	// Zero is unlimited.
+	DefaultThreadQuota int64
+
+	// QuotaWarningRatio is the share of a quota which may be used before a core.QuotaWarning
+	// event is emitted.
+	QuotaWarningRatio = 0.9
+)
+
+const (
+	// quotaKey is the metadata key of the byte budget of a thread set with SetThreadQuota.
+	quotaKey = "/quota"
+	// quotaUsedKey is the metadata key of the bytes charged against the thread quota.
+	quotaUsedKey = "/quota/used"
+)
+
+// SetThreadQuota sets the byte budget of a thread, counting the record, event, header and body
+// nodes of its records. Records received from other hosts beyond the quota are refused with
+// core.ErrQuotaExceeded, while records created by the host are counted but never refused. Zero
+// restores DefaultThreadQuota, a negative quota is unlimited.
+func (n *Net) SetThreadQuota(_ context.Context, id thread.ID, bytes int64, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return n.store.PutInt64(id, quotaKey, bytes)
+}
+
+// threadQuota returns the byte budget of a thread, zero if unlimited.
+func (n *Net) threadQuota(id thread.ID) (int64, error) {
+	quota, err := n.store.GetInt64(id, quotaKey)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case quota == nil || *quota == 0:
+		return DefaultThreadQuota, nil
+	case *quota < 0:
+		return 0, nil
+	default:
+		return *quota, nil
+	}
+}
+
+// quotaUsed returns the bytes charged against the quota of a thread.
+func (n *Net) quotaUsed(id thread.ID) (int64, error) {
+	used, err := n.store.GetInt64(id, quotaUsedKey)
+	if err != nil || used == nil {
+		return 0, err
+	}
+	return *used, nil
+}
+
+// quotaSize returns the size charged for a record of a thread, with its blocks loaded from dag,
+// or zero if no quota applies.
+func (n *Net) quotaSize(ctx context.Context, dag format.DAGService, id thread.ID, rec core.Record, withBody bool) (int64, error) {
+	if quota, err := n.threadQuota(id); err != nil || quota == 0 {
+		return 0, err
+	}
+	block, err := rec.GetBlock(ctx, dag)
+	if err != nil {
+		return 0, err
+	}
+	event, err := cbor.EventFromNode(block)
+	if err != nil {
+		return 0, fmt.Errorf("invalid event: %w", err)
+	}
+	header, err := event.GetHeader(ctx, dag, nil)
+	if err != nil {
+		return 0, err
+	}
+	size := int64(len(rec.RawData()) + len(block.RawData()) + len(header.RawData()))
+	if !withBody {
+		return size, nil
+	}
+	body, err := event.GetBody(ctx, dag, nil)
+	if err != nil {
+		return 0, err
+	}
+	return size + util.BodyBytes(body), nil
+}
+
+// fitsQuota fails with core.ErrQuotaExceeded if size more bytes don't fit into the quota of a thread.
+// The caller must hold the host lock.
+func (n *Net) fitsQuota(id thread.ID, size int64) error {
+	quota, err := n.threadQuota(id)
+	if err != nil || quota == 0 {
+		return err
+	}
+	used, err := n.quotaUsed(id)
+	if err != nil {
+		return err
+	}
+	if used+size > quota {
+		return fmt.Errorf("thread %s would hold %d bytes of %d: %w", id, used+size, quota, core.ErrQuotaExceeded)
+	}
+	return nil
+}
+
+// chargeQuota adds the size of records added to a thread to the usage of its quota, and emits
+// a QuotaWarning once the usage passes QuotaWarningRatio of the quota. The caller must hold
+// the host lock.
+func (n *Net) chargeQuota(id thread.ID, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	quota, err := n.threadQuota(id)
+	if err != nil {
+		return err
+	}
+	used, err := n.quotaUsed(id)
+	if err != nil {
+		return err
+	}
+	if err = n.store.PutInt64(id, quotaUsedKey, used+size); err != nil {
+		return err
+	}
+	if mark := int64(float64(quota) * QuotaWarningRatio); quota > 0 && used <= mark && used+size > mark {
+		n.emit(core.LifecycleEvent{Type: core.QuotaWarning, ThreadID: id})
+	}
+	return nil
+}
+
+// releaseQuota subtracts the size of records pruned from a thread from the usage of its quota.
+// The caller must hold the host lock.
+func (n *Net) releaseQuota(id thread.ID, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	used, err := n.quotaUsed(id)
+	if err != nil {
+		return err
+	}
+	if used -= size; used < 0 {
+		used = 0
+	}
+	return n.store.PutInt64(id, quotaUsedKey, used)
+}
@@ -0,0 +1,220 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// retentionKey is the metadata key of the thread retention policy, stored as JSON.
+	retentionKey = "/retention"
+	// retentionStateKey is the metadata key of the reaper progress of a thread, stored as JSON.
+	retentionStateKey = "/retention-state"
+)
+
+// SetRetentionPolicy sets the retention policy of a thread. The in-memory net doesn't run a
+// reaper in the background: the policy is enforced whenever records are added to the thread.
+func (n *Net) SetRetentionPolicy(ctx context.Context, id thread.ID, policy core.RetentionPolicy, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot set retention policy: %w", app.ErrThreadInUse)
+	}
+	if policy.MaxRecords < 0 || policy.MaxBytes < 0 || policy.MaxAge < 0 {
+		return fmt.Errorf("invalid retention policy: negative limit")
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if err := util.PutMetadataJSON(n.store, id, retentionKey, policy); err != nil {
+		return err
+	}
+	_, err := n.reapThread(ctx, id)
+	return err
+}
+
+func (n *Net) ThreadStats(ctx context.Context, id thread.ID, opts ...core.ThreadOption) (core.ThreadStats, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return core.ThreadStats{}, err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return core.ThreadStats{}, err
+	}
+	stats := core.ThreadStats{Logs: len(info.Logs)}
+	if stats.Retention, err = n.retentionPolicy(id); err != nil {
+		return stats, err
+	}
+	var state util.RetentionState
+	if err = util.GetMetadataJSON(n.store, id, retentionStateKey, &state); err != nil {
+		return stats, err
+	}
+	stats.Pruned = state.Pruned
+	if state.LastPruned != 0 {
+		stats.LastPruned = time.Unix(0, state.LastPruned)
+	}
+	if stats.Quota, err = n.threadQuota(id); err != nil {
+		return stats, err
+	}
+	if stats.QuotaUsed, err = n.quotaUsed(id); err != nil {
+		return stats, err
+	}
+
+	var sizeErr error
+	err = n.walkLogs(ctx, id, func(_ peer.ID, rid cid.Cid, ev *cbor.Event) {
+		stats.Records++
+		size, err := util.RecordSize(n.bstore, rid, ev)
+		if err != nil && sizeErr == nil {
+			sizeErr = err
+		}
+		stats.Bytes += size
+	})
+	if err == nil {
+		err = sizeErr
+	}
+	return stats, err
+}
+
+func (n *Net) retentionPolicy(id thread.ID) (core.RetentionPolicy, error) {
+	var policy core.RetentionPolicy
+	return policy, util.GetMetadataJSON(n.store, id, retentionKey, &policy)
+}
+
+// reapThread prunes the records of the thread logs below the horizon of its retention policy,
+// and returns the number of pruned records. The caller must hold the host lock.
+func (n *Net) reapThread(ctx context.Context, id thread.ID) (int, error) {
+	policy, err := n.retentionPolicy(id)
+	if err != nil || policy.IsZero() {
+		return 0, err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return 0, err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return 0, nil
+	}
+	var state util.RetentionState
+	if err = util.GetMetadataJSON(n.store, id, retentionStateKey, &state); err != nil {
+		return 0, err
+	}
+	if state.Marks == nil {
+		state.Marks = make(map[string][]util.HeadMark)
+	}
+
+	now := time.Now()
+	var pruned int
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		expired := state.MarkHead(lg.ID, lg.Head, now, policy.MaxAge)
+		horizon, err := n.retentionHorizon(ctx, id, lg, policy, expired, sk)
+		if err != nil {
+			return pruned, err
+		}
+		if horizon == nil {
+			continue
+		}
+		p, err := n.pruneLog(ctx, id, lg.ID, horizon)
+		pruned += p
+		if err != nil {
+			return pruned, err
+		}
+	}
+	state.Pruned += pruned
+	if pruned > 0 {
+		state.LastPruned = now.UnixNano()
+	}
+	return pruned, util.PutMetadataJSON(n.store, id, retentionStateKey, state)
+}
+
+// retentionHorizon walks back from the log head, and returns the oldest record kept by the
+// policy if older records must be pruned, or nil otherwise. The head is always kept, and so is
+// the log checkpoint along with the records after it. Logs without a checkpoint are never
+// pruned, as the state of the thread couldn't be rebuilt without their records.
+func (n *Net) retentionHorizon(
+	ctx context.Context,
+	id thread.ID,
+	lg thread.LogInfo,
+	policy core.RetentionPolicy,
+	expired cid.Cid,
+	sk *sym.Key,
+) (core.Record, error) {
+	checkpoint, err := util.LogMarker(n.store, id, lg.ID, checkpointSuffix)
+	if err != nil || !checkpoint.Defined() {
+		return nil, err
+	}
+	boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		horizon   core.Record
+		count     int
+		size      int64
+		isExpired bool
+		kept      bool
+	)
+	for rid := lg.Head; ; count++ {
+		if !rid.Defined() || rid.Equals(boundary) {
+			return nil, nil // nothing is kept below
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// stop at records missing locally, e.g., compacted by another host
+		if known, err := n.bstore.Has(rid); err != nil || !known {
+			return nil, err
+		}
+		rec, err := cbor.GetRecord(ctx, n, rid, sk)
+		if err != nil {
+			return nil, err
+		}
+		ev, err := cbor.EventFromRecord(ctx, n, rec)
+		if err != nil {
+			return nil, err
+		}
+		recSize, err := util.RecordSize(n.bstore, rid, ev)
+		if err != nil {
+			return nil, err
+		}
+		size += recSize
+		isExpired = isExpired || rid.Equals(expired)
+		if horizon != nil && kept && (isExpired ||
+			(policy.MaxRecords > 0 && count >= policy.MaxRecords) ||
+			(policy.MaxBytes > 0 && size > policy.MaxBytes)) {
+			return horizon, nil
+		}
+		horizon = rec
+		kept = kept || rid.Equals(checkpoint)
+		rid = rec.PrevID()
+	}
+}
@@ -0,0 +1,156 @@
+package netmock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// metadata suffix for the latest log checkpoint
+	checkpointSuffix = "/checkpoint"
+	// metadata suffix for the oldest record kept in a compacted log
+	boundarySuffix = "/boundary"
+)
+
+func (n *Net) CreateCheckpoint(ctx context.Context, id thread.ID, state format.Node, opts ...core.ThreadOption) (core.ThreadRecord, error) {
+	tr, err := n.CreateRecord(ctx, id, state, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.store.PutBytes(id, tr.LogID().Pretty()+checkpointSuffix, tr.Value().Cid().Bytes()); err != nil {
+		return nil, fmt.Errorf("saving checkpoint: %w", err)
+	}
+	return tr, nil
+}
+
+// CompactThread drops the records of every log before its latest checkpoint. Compacted records
+// aren't served to other hosts anymore, which adopt the boundary when they pull.
+func (n *Net) CompactThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot compact thread: %w", app.ErrThreadInUse)
+	}
+	if err := n.rehydrateThread(ctx, id); err != nil {
+		return err
+	}
+
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	for _, lg := range info.Logs {
+		checkpoint, err := util.LogMarker(n.store, id, lg.ID, checkpointSuffix)
+		if err != nil {
+			return err
+		}
+		if !checkpoint.Defined() {
+			continue
+		}
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return err
+		}
+		if boundary.Equals(checkpoint) {
+			continue // already compacted
+		}
+		cp, err := cbor.GetRecord(ctx, n, checkpoint, info.Key.Service())
+		if err != nil {
+			return fmt.Errorf("getting checkpoint %s: %w", checkpoint, err)
+		}
+		if _, err = n.pruneLog(ctx, id, lg.ID, cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneLog moves the compaction boundary of a log to the given record, and deletes the records
+// before it, along with their blocks which aren't referenced by other records. It returns the
+// number of deleted records. The caller must hold the host lock.
+func (n *Net) pruneLog(ctx context.Context, id thread.ID, lid peer.ID, boundary core.Record) (int, error) {
+	// move the boundary first, so records being pruned are not served anymore
+	if err := n.store.PutBytes(id, lid.Pretty()+boundarySuffix, boundary.Cid().Bytes()); err != nil {
+		return 0, err
+	}
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		pruned int
+		freed  int64
+		blocks []cid.Cid
+	)
+	for rid := boundary.PrevID(); rid.Defined(); pruned++ {
+		// stop at records which are missing locally, e.g. dropped by the previous compaction
+		if known, err := n.bstore.Has(rid); err != nil {
+			return 0, err
+		} else if !known {
+			break
+		}
+		rec, err := cbor.GetRecord(ctx, n, rid, sk)
+		if err != nil {
+			return 0, err
+		}
+		ev, err := cbor.EventFromRecord(ctx, n, rec)
+		if err != nil {
+			return 0, err
+		}
+		size, err := util.RecordSize(n.bstore, rid, ev)
+		if err != nil {
+			return 0, err
+		}
+		freed += size
+		blocks = append(append(blocks, rid, ev.Cid(), ev.HeaderID(), ev.BodyID()), util.LocalBodyChunks(n.bstore, ev.BodyID())...)
+		rid = rec.PrevID()
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	if err = n.releaseQuota(id, freed); err != nil {
+		return 0, err
+	}
+	live, _, err := n.markLive(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = n.sweep(ctx, blocks, live); err != nil {
+		return 0, err
+	}
+	return pruned, nil
+}
+
+// adoptBoundary saves the compaction boundary of another host's log, if the local log can't be
+// connected to the records received from the boundary.
+func (n *Net) adoptBoundary(id thread.ID, lid peer.ID, boundary core.Record) error {
+	current, err := util.LogMarker(n.store, id, lid, boundarySuffix)
+	if err != nil {
+		return err
+	}
+	if current.Equals(boundary.Cid()) {
+		return nil
+	}
+	if prev := boundary.PrevID(); prev.Defined() {
+		if known, err := n.bstore.Has(prev); err != nil || known {
+			return err
+		}
+	}
+	return n.store.PutBytes(id, lid.Pretty()+boundarySuffix, boundary.Cid().Bytes())
+}
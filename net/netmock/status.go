@@ -0,0 +1,196 @@
+package netmock
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// Topics returns the threads the host is loaded for. Hosts don't run pubsub, records are
+// delivered directly, so loaded threads stand for the subscribed topics.
+func (n *Net) Topics(_ context.Context) ([]thread.ID, error) {
+	ids, err := n.store.Threads()
+	if err != nil {
+		return nil, err
+	}
+	var topics []thread.ID
+	for _, id := range ids {
+		if unloaded, err := n.isUnloaded(id); err != nil {
+			return nil, err
+		} else if !unloaded {
+			topics = append(topics, id)
+		}
+	}
+	return topics, nil
+}
+
+func (n *Net) SyncStatus(_ context.Context) (map[peer.ID]core.PeerSyncStatus, error) {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	res := make(map[peer.ID]core.PeerSyncStatus, len(n.syncStatus))
+	for pid, s := range n.syncStatus {
+		res[pid] = s
+	}
+	return res, nil
+}
+
+func (n *Net) PullStatus(_ context.Context, id thread.ID, opts ...core.ThreadOption) (map[peer.ID]core.LogPullStatus, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return nil, err
+	}
+
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	res := make(map[peer.ID]core.LogPullStatus, len(info.Logs))
+	for _, lg := range info.Logs {
+		st := n.pullStatus[id][lg.ID]
+		st.LocalHead = lg.Head
+		if st.RemoteHead.Defined() {
+			if known, err := n.bstore.Has(st.RemoteHead); err != nil {
+				return nil, err
+			} else if known {
+				st.RecordsBehind = 0
+			}
+		}
+		res[lg.ID] = st
+	}
+	return res, nil
+}
+
+// RecordReplicationStatus returns which thread hosts stored a record pushed by the host,
+// and which didn't yet.
+func (n *Net) RecordReplicationStatus(_ context.Context, id thread.ID, rid cid.Cid, opts ...core.ThreadOption) (core.RecordReplication, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return core.RecordReplication{}, err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return core.RecordReplication{}, err
+	}
+	pids := n.replicas(id)
+
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	status := core.RecordReplication{Acked: make(map[peer.ID]time.Time)}
+	for pid, at := range n.acks[id][rid] {
+		status.Acked[pid] = at
+	}
+	for _, pid := range pids {
+		if _, ok := status.Acked[pid]; !ok {
+			status.Pending = append(status.Pending, pid)
+		}
+	}
+	return status, nil
+}
+
+// trackDelivery keeps the outcome of a push of records to a host. Records of failed pushes are
+// dropped, since deliveries aren't retried, and records of successful ones are acknowledged.
+func (n *Net) trackDelivery(pid peer.ID, id thread.ID, rids []cid.Cid, err error) {
+	now := time.Now()
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	s := n.syncStatus[pid]
+	s.LastAttempt = now
+	if err != nil {
+		s.Attempts++
+		s.LastError = err
+		s.Dropped += len(rids)
+		n.syncStatus[pid] = s
+		return
+	}
+	s.Attempts = 0
+	s.LastError = nil
+	s.LastSuccess = now
+	n.syncStatus[pid] = s
+	if len(rids) == 0 {
+		return
+	}
+	acks, ok := n.acks[id]
+	if !ok {
+		acks = make(map[cid.Cid]map[peer.ID]time.Time)
+		n.acks[id] = acks
+	}
+	for _, rid := range rids {
+		if acks[rid] == nil {
+			acks[rid] = make(map[peer.ID]time.Time)
+		}
+		acks[rid][pid] = now
+	}
+}
+
+// trackLogPull keeps the outcome of a pull of a log up to head, which left the host behind
+// by the given number of records.
+func (n *Net) trackLogPull(id thread.ID, lid peer.ID, head cid.Cid, behind int, err error) {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	logs, ok := n.pullStatus[id]
+	if !ok {
+		logs = make(map[peer.ID]core.LogPullStatus)
+		n.pullStatus[id] = logs
+	}
+	st := logs[lid]
+	if err != nil {
+		st.LastError = err
+		logs[lid] = st
+		return
+	}
+	st.RemoteHead = head
+	st.RecordsBehind = behind
+	st.LastExchange = time.Now()
+	st.LastError = nil
+	logs[lid] = st
+}
+
+// forgetPullStatus drops the sync state of a deleted thread.
+func (n *Net) forgetPullStatus(id thread.ID) {
+	n.sx.Lock()
+	defer n.sx.Unlock()
+	delete(n.pullStatus, id)
+	delete(n.acks, id)
+}
+
+// Status methods of the transports below report an idle host, since hosts call each other
+// directly: there is no pubsub, gRPC, call queue or connection manager to report on.
+
+func (n *Net) PublishStatus(_ context.Context) (core.PublishStatus, error) {
+	return core.PublishStatus{}, nil
+}
+
+func (n *Net) ValidationStatus(_ context.Context) (map[thread.ID]core.TopicValidationStatus, error) {
+	return map[thread.ID]core.TopicValidationStatus{}, nil
+}
+
+func (n *Net) CompressionStatus(_ context.Context) (core.CompressionStatus, error) {
+	return core.CompressionStatus{}, nil
+}
+
+func (n *Net) CallQueueStatus(_ context.Context) (map[string]core.CallQueueStatus, error) {
+	return map[string]core.CallQueueStatus{}, nil
+}
+
+func (n *Net) RPCStatus(_ context.Context) (map[string]core.RPCStatus, error) {
+	return map[string]core.RPCStatus{}, nil
+}
+
+func (n *Net) Connectivity(_ context.Context) (core.ConnectivityStatus, error) {
+	return core.ConnectivityStatus{}, nil
+}
+
+func (n *Net) ThreadLocks(_ context.Context) (map[thread.ID]core.ThreadLockStatus, error) {
+	return map[thread.ID]core.ThreadLockStatus{}, nil
+}
@@ -0,0 +1,566 @@
+// Package nettest simulates networks of in-process thread hosts linked by a mock network.
+// Simulations create records at random hosts while links are partitioned, slowed down and
+// lossy, and check the hosts eventually converge to the same log heads.
+package nettest
+
+import (
+	"context"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	logging "github.com/ipfs/go-log"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/logstore/lstoremem"
+	tnet "github.com/textileio/go-threads/net"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var log = logging.Logger("nettest")
+
+// ErrNotConverged indicates hosts which didn't settle on the same log heads in time.
+var ErrNotConverged = errors.New("hosts didn't converge")
+
+// ConvergeInterval is the interval between pulls while waiting for hosts to converge.
+var ConvergeInterval = 100 * time.Millisecond
+
+// Config describes a simulation run by Run.
+type Config struct {
+	// Nodes is the number of hosts, three by default.
+	Nodes int
+	// Threads is the number of threads replicated by every host, one by default.
+	Threads int
+	// Rounds is the number of write rounds, one by default.
+	Rounds int
+	// Records is the number of records created at random hosts and threads every round.
+	Records int
+	// Partition splits the hosts into two random groups during every round. Links are
+	// healed between rounds.
+	Partition bool
+	// Latency is the latency of the links between hosts.
+	Latency time.Duration
+	// Loss is the probability of calls between hosts to be dropped, in [0, 1).
+	Loss float64
+	// PubSub enables record delivery over pubsub in addition to direct pushes.
+	PubSub bool
+	// Seed seeds the random choices of the simulation, so failing runs can be replayed.
+	// Zero picks a seed from the current time.
+	Seed int64
+	// Timeout bounds the wait for the hosts to converge after the last round, one minute
+	// by default.
+	Timeout time.Duration
+}
+
+// Result summarizes a simulation run.
+type Result struct {
+	Config Config
+	// Records is the number of records created.
+	Records int
+	// Partitions is the groups of hosts of every round, empty for rounds without a partition.
+	Partitions [][][]int
+	// Elapsed is the time from the last round until the hosts converged.
+	Elapsed time.Duration
+}
+
+// Run starts the hosts, writes records in rounds with the configured faults, and waits
+// until the hosts converge. It fails with ErrNotConverged if they don't in time.
+func Run(ctx context.Context, conf Config) (res Result, err error) {
+	if err = setDefaults(&conf); err != nil {
+		return
+	}
+	res.Config = conf
+
+	s, err := New(ctx, conf)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			log.Errorf("closing simulation: %v", cerr)
+		}
+	}()
+	s.SetLatency(conf.Latency)
+	s.SetLoss(conf.Loss)
+
+	for r := 0; r < conf.Rounds; r++ {
+		var groups [][]int
+		if conf.Partition {
+			if groups, err = s.RandomPartition(); err != nil {
+				return
+			}
+		}
+		res.Partitions = append(res.Partitions, groups)
+		n, err := s.Write(ctx, conf.Records)
+		res.Records += n
+		if err != nil {
+			return res, fmt.Errorf("round %d: %w", r, err)
+		}
+		if err = s.Heal(); err != nil {
+			return res, err
+		}
+	}
+
+	// faults stop once the hosts have to converge
+	s.SetLoss(0)
+	start := time.Now()
+	if err = s.Converge(ctx, conf.Timeout); err != nil {
+		return
+	}
+	res.Elapsed = time.Since(start)
+	return res, nil
+}
+
+func setDefaults(conf *Config) error {
+	if conf.Nodes == 0 {
+		conf.Nodes = 3
+	}
+	if conf.Threads == 0 {
+		conf.Threads = 1
+	}
+	if conf.Rounds == 0 {
+		conf.Rounds = 1
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = time.Minute
+	}
+	if conf.Seed == 0 {
+		conf.Seed = time.Now().UnixNano()
+	}
+	if conf.Nodes < 2 {
+		return errors.New("at least two nodes are required")
+	}
+	if conf.Loss < 0 || conf.Loss >= 1 {
+		return fmt.Errorf("loss (%v) must be in [0, 1)", conf.Loss)
+	}
+	if conf.Threads < 1 || conf.Rounds < 1 || conf.Records < 0 || conf.Latency < 0 {
+		return errors.New("invalid config")
+	}
+	return nil
+}
+
+// Sim is a network of thread hosts linked by a mock network.
+type Sim struct {
+	cancel  context.CancelFunc
+	mn      mocknet.Mocknet
+	hosts   []host.Host
+	nodes   []core.Net
+	threads []thread.ID
+
+	mx     sync.Mutex
+	rnd    *rand.Rand
+	loss   float64
+	groups map[peer.ID]int
+}
+
+// New starts the hosts of a simulation, linked to each other, and creates the threads,
+// which every host replicates and writes to. Only Nodes, Threads, PubSub and Seed are
+// used from the config, faults are injected with the Sim methods.
+func New(ctx context.Context, conf Config) (*Sim, error) {
+	if err := setDefaults(&conf); err != nil {
+		return nil, err
+	}
+	// the mock network and the hosts live until the simulation is closed
+	sctx, cancel := context.WithCancel(context.Background())
+	s := &Sim{
+		cancel: cancel,
+		mn:     mocknet.New(sctx),
+		rnd:    rand.New(rand.NewSource(conf.Seed)),
+	}
+	log.Infof("starting simulation with seed %d", conf.Seed)
+	if err := s.startNodes(sctx, conf); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	if err := s.createThreads(ctx, conf.Threads); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sim) startNodes(ctx context.Context, conf Config) error {
+	for i := 0; i < conf.Nodes; i++ {
+		sk, _, err := crypto.GenerateEd25519Key(crand.Reader)
+		if err != nil {
+			return err
+		}
+		addr, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/10.0.%d.%d/tcp/4006", i/256, i%256))
+		if err != nil {
+			return err
+		}
+		h, err := s.mn.AddPeer(sk, addr)
+		if err != nil {
+			return err
+		}
+		bs := bstore.NewBlockstore(syncds.MutexWrap(ds.NewMapDatastore()))
+		bsrv := bserv.New(bs, offline.Exchange(bs))
+		n, err := tnet.NewNetwork(
+			ctx,
+			h,
+			bsrv.Blockstore(),
+			dag.NewDAGService(bsrv),
+			lstoremem.NewLogstore(),
+			tnet.Config{
+				PubSub:             conf.PubSub,
+				ClientInterceptors: s.faultInterceptors(h.ID()),
+			},
+			nil,
+			nil)
+		if err != nil {
+			return fmt.Errorf("starting node %d: %w", i, err)
+		}
+		s.hosts = append(s.hosts, h)
+		s.nodes = append(s.nodes, n)
+	}
+	for _, h := range s.hosts {
+		for _, p := range s.hosts {
+			if h != p {
+				h.Peerstore().AddAddrs(p.ID(), p.Addrs(), peerstore.PermanentAddrTTL)
+			}
+		}
+	}
+	if err := s.mn.LinkAll(); err != nil {
+		return err
+	}
+	return s.mn.ConnectAllButSelf()
+}
+
+// createThreads creates every thread on one host, round-robin, and adds it to the others.
+// Logs of every host are created and exchanged before faults are injected, so records
+// written later only have to reach the hosts.
+func (s *Sim) createThreads(ctx context.Context, count int) error {
+	for i := 0; i < count; i++ {
+		owner := s.nodes[i%len(s.nodes)]
+		info, err := owner.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+		if err != nil {
+			return err
+		}
+		addr, err := ma.NewMultiaddr("/p2p/" + owner.Host().ID().String() + "/thread/" + info.ID.String())
+		if err != nil {
+			return err
+		}
+		for _, n := range s.nodes {
+			if n == owner {
+				continue
+			}
+			if _, err = n.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+				return fmt.Errorf("adding thread %s: %w", info.ID, err)
+			}
+		}
+		s.threads = append(s.threads, info.ID)
+	}
+	for _, id := range s.threads {
+		for i, n := range s.nodes {
+			body, err := newBody(i, -1)
+			if err != nil {
+				return err
+			}
+			if _, err = n.CreateRecord(ctx, id, body); err != nil {
+				return fmt.Errorf("creating log of node %d in thread %s: %w", i, id, err)
+			}
+		}
+	}
+	return s.Converge(ctx, time.Minute)
+}
+
+// Nodes returns the hosts of the simulation.
+func (s *Sim) Nodes() []core.Net {
+	return s.nodes
+}
+
+// Threads returns the threads replicated by every host.
+func (s *Sim) Threads() []thread.ID {
+	return s.threads
+}
+
+// Write creates records at random hosts and threads. It returns the number of records created.
+func (s *Sim) Write(ctx context.Context, records int) (int, error) {
+	for i := 0; i < records; i++ {
+		s.mx.Lock()
+		node, tid := s.rnd.Intn(len(s.nodes)), s.threads[s.rnd.Intn(len(s.threads))]
+		s.mx.Unlock()
+		body, err := newBody(node, i)
+		if err != nil {
+			return i, err
+		}
+		if _, err = s.nodes[node].CreateRecord(ctx, tid, body); err != nil {
+			return i, fmt.Errorf("creating record at node %d: %w", node, err)
+		}
+	}
+	return records, nil
+}
+
+// Partition unlinks and disconnects the hosts of different groups, by their indexes in Nodes.
+// Hosts which aren't in any group are isolated.
+func (s *Sim) Partition(groups ...[]int) error {
+	group := make(map[int]int)
+	for g, nodes := range groups {
+		for _, i := range nodes {
+			if i < 0 || i >= len(s.hosts) {
+				return fmt.Errorf("unknown node %d", i)
+			}
+			group[i] = g + 1
+		}
+	}
+	byPeer := make(map[peer.ID]int, len(s.hosts))
+	for i, h := range s.hosts {
+		if _, ok := group[i]; !ok {
+			group[i] = -i - 1
+		}
+		byPeer[h.ID()] = group[i]
+	}
+	// calls are refused first, since dials racing with the unlinking may still connect hosts
+	s.mx.Lock()
+	s.groups = byPeer
+	s.mx.Unlock()
+	for i := range s.hosts {
+		for j := i + 1; j < len(s.hosts); j++ {
+			if group[i] == group[j] {
+				continue
+			}
+			if err := s.unlink(s.hosts[i].ID(), s.hosts[j].ID()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RandomPartition splits the hosts into two random non-empty groups, and returns them.
+func (s *Sim) RandomPartition() ([][]int, error) {
+	s.mx.Lock()
+	perm := s.rnd.Perm(len(s.hosts))
+	cut := 1 + s.rnd.Intn(len(s.hosts)-1)
+	s.mx.Unlock()
+	groups := [][]int{perm[:cut], perm[cut:]}
+	for _, g := range groups {
+		sort.Ints(g)
+	}
+	return groups, s.Partition(groups...)
+}
+
+// Heal links every pair of hosts again, and connects them.
+func (s *Sim) Heal() error {
+	s.mx.Lock()
+	s.groups = nil
+	s.mx.Unlock()
+	for i := range s.hosts {
+		for j := i + 1; j < len(s.hosts); j++ {
+			a, b := s.hosts[i].ID(), s.hosts[j].ID()
+			if len(s.mn.LinksBetweenPeers(a, b)) != 0 {
+				continue
+			}
+			if _, err := s.mn.LinkPeers(a, b); err != nil {
+				return err
+			}
+		}
+	}
+	return s.mn.ConnectAllButSelf()
+}
+
+// SetLatency sets the latency of the links between hosts.
+func (s *Sim) SetLatency(latency time.Duration) {
+	opts := mocknet.LinkOptions{Latency: latency}
+	s.mn.SetLinkDefaults(opts)
+	for _, l := range s.mn.Links() {
+		for _, ls := range l {
+			for link := range ls {
+				link.SetOptions(opts)
+			}
+		}
+	}
+}
+
+// SetLoss sets the probability of calls between hosts to be dropped, in [0, 1).
+func (s *Sim) SetLoss(loss float64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.loss = loss
+}
+
+// Converge pulls every thread at every host until the hosts have the same log heads, or
+// the timeout elapses. It fails with ErrNotConverged listing the diverged heads.
+func (s *Sim) Converge(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tick := time.NewTicker(ConvergeInterval)
+	defer tick.Stop()
+	for {
+		diff, err := s.diverged(ctx)
+		if err != nil {
+			return err
+		} else if diff == "" {
+			return nil
+		}
+		s.pullAll(ctx)
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %s: %s", ErrNotConverged, timeout, diff)
+		}
+	}
+}
+
+// Heads returns the heads of the logs of a thread known to a host, in their string encoding.
+func (s *Sim) Heads(ctx context.Context, node int, id thread.ID) (map[peer.ID]string, error) {
+	info, err := s.nodes[node].GetThread(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(map[peer.ID]string, len(info.Logs))
+	for _, lg := range info.Logs {
+		heads[lg.ID] = headsString(lg.Heads)
+	}
+	return heads, nil
+}
+
+// Close stops the hosts and the mock network.
+func (s *Sim) Close() error {
+	var err error
+	for _, n := range s.nodes {
+		if cerr := n.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	s.cancel()
+	return err
+}
+
+// diverged describes the first log heads which differ between hosts, empty if all agree.
+func (s *Sim) diverged(ctx context.Context) (string, error) {
+	for _, id := range s.threads {
+		ref, err := s.Heads(ctx, 0, id)
+		if err != nil {
+			return "", err
+		}
+		for i := 1; i < len(s.nodes); i++ {
+			heads, err := s.Heads(ctx, i, id)
+			if err != nil {
+				return "", err
+			}
+			if len(heads) != len(ref) {
+				return fmt.Sprintf("thread %s: node 0 has %d logs, node %d has %d", id, len(ref), i, len(heads)), nil
+			}
+			for lid, h := range ref {
+				if heads[lid] != h {
+					return fmt.Sprintf("thread %s, log %s: node 0 has heads [%s], node %d has [%s]", id, lid, h, i, heads[lid]), nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+func (s *Sim) pullAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, n := range s.nodes {
+		for _, id := range s.threads {
+			wg.Add(1)
+			go func(i int, n core.Net, id thread.ID) {
+				defer wg.Done()
+				if err := n.PullThread(ctx, id); err != nil {
+					log.Debugf("node %d pulling thread %s: %v", i, id, err)
+				}
+			}(i, n, id)
+		}
+	}
+	wg.Wait()
+}
+
+// unlink removes the links between two hosts, closing their connections.
+func (s *Sim) unlink(a, b peer.ID) error {
+	if len(s.mn.LinksBetweenPeers(a, b)) == 0 {
+		return nil
+	}
+	if err := s.mn.UnlinkPeers(a, b); err != nil {
+		return err
+	}
+	return s.mn.DisconnectPeers(a, b)
+}
+
+// faultInterceptors fail the calls of a host to partitioned peers, and drop its other calls
+// with the configured probability.
+func (s *Sim) faultInterceptors(self peer.ID) tnet.ClientInterceptors {
+	fault := func(method, target string) error {
+		pid, err := peer.Decode(target)
+		if err != nil {
+			return err
+		}
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		if s.groups != nil && s.groups[self] != s.groups[pid] {
+			return status.Errorf(codes.Unavailable, "nettest: %s partitioned from %s", pid, self)
+		}
+		if s.loss > 0 && s.rnd.Float64() < s.loss {
+			return status.Errorf(codes.Unavailable, "nettest: %s dropped", method)
+		}
+		return nil
+	}
+	return tnet.ClientInterceptors{
+		Unary: []grpc.UnaryClientInterceptor{func(
+			ctx context.Context,
+			method string,
+			req, reply interface{},
+			cc *grpc.ClientConn,
+			invoker grpc.UnaryInvoker,
+			opts ...grpc.CallOption,
+		) error {
+			if err := fault(method, cc.Target()); err != nil {
+				return err
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}},
+		Stream: []grpc.StreamClientInterceptor{func(
+			ctx context.Context,
+			desc *grpc.StreamDesc,
+			cc *grpc.ClientConn,
+			method string,
+			streamer grpc.Streamer,
+			opts ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			if err := fault(method, cc.Target()); err != nil {
+				return nil, err
+			}
+			return streamer(ctx, desc, cc, method, opts...)
+		}},
+	}
+}
+
+func headsString(heads []cid.Cid) string {
+	hs := make([]string, len(heads))
+	for i, h := range heads {
+		hs[i] = h.String()
+	}
+	sort.Strings(hs)
+	return strings.Join(hs, ",")
+}
+
+func newBody(node, seq int) (*cbornode.Node, error) {
+	return cbornode.WrapObject(map[string]interface{}{
+		"node": node,
+		"seq":  seq,
+	}, mh.SHA2_256, -1)
+}
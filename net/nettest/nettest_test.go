@@ -0,0 +1,80 @@
+package nettest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	res, err := Run(context.Background(), Config{
+		Nodes:     4,
+		Threads:   2,
+		Rounds:    3,
+		Records:   10,
+		Partition: true,
+		Latency:   5 * time.Millisecond,
+		Loss:      0.1,
+		Timeout:   30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("seed %d: %v", res.Config.Seed, err)
+	}
+	if res.Records != 30 {
+		t.Fatalf("expected 30 records, got %d", res.Records)
+	}
+	if len(res.Partitions) != 3 {
+		t.Fatalf("expected a partition every round, got %v", res.Partitions)
+	}
+	for _, groups := range res.Partitions {
+		if len(groups) != 2 || len(groups[0]) == 0 || len(groups[1]) == 0 {
+			t.Fatalf("expected two non-empty groups, got %v", groups)
+		}
+	}
+}
+
+func TestSim_Partition(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s, err := New(ctx, Config{Nodes: 3, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// records written to an isolated host don't reach the others
+	if err = s.Partition([]int{0, 1}, []int{2}); err != nil {
+		t.Fatal(err)
+	}
+	id := s.Threads()[0]
+	body, err := newBody(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Nodes()[2].CreateRecord(ctx, id, body); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Converge(ctx, time.Second); !errors.Is(err, ErrNotConverged) {
+		t.Fatalf("expected partitioned hosts not to converge, got %v", err)
+	}
+
+	// until the partition heals
+	if err = s.Heal(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Converge(ctx, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	t.Parallel()
+	if _, err := Run(context.Background(), Config{Nodes: 1}); err == nil {
+		t.Fatal("expected single node to be refused")
+	}
+	if _, err := Run(context.Background(), Config{Loss: 1}); err == nil {
+		t.Fatal("expected total loss to be refused")
+	}
+}
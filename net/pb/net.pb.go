@@ -37,6 +37,12 @@ type Log struct {
 	Addrs []ProtoAddr `protobuf:"bytes,3,rep,name=addrs,proto3,customtype=ProtoAddr" json:"addrs,omitempty"`
 	// head of the log.
 	Head *ProtoCid `protobuf:"bytes,4,opt,name=head,proto3,customtype=ProtoCid" json:"head,omitempty"`
+	// heads of the log, including head. Forked logs have more than one head.
+	Heads []ProtoCid `protobuf:"bytes,5,rep,name=heads,proto3,customtype=ProtoCid" json:"heads,omitempty"`
+	// addrsSeq orders the address sets signed by the log owner, newer sets have a higher number.
+	AddrsSeq uint64 `protobuf:"varint,6,opt,name=addrsSeq,proto3" json:"addrsSeq,omitempty"`
+	// addrsSig is the log key signature over the thread ID, log ID, addrsSeq and addrs.
+	AddrsSig []byte `protobuf:"bytes,7,opt,name=addrsSig,proto3" json:"addrsSig,omitempty"`
 }
 
 func (m *Log) Reset()         { *m = Log{} }
@@ -72,6 +78,20 @@ func (m *Log) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Log proto.InternalMessageInfo
 
+func (m *Log) GetAddrsSeq() uint64 {
+	if m != nil {
+		return m.AddrsSeq
+	}
+	return 0
+}
+
+func (m *Log) GetAddrsSig() []byte {
+	if m != nil {
+		return m.AddrsSig
+	}
+	return nil
+}
+
 // Record is a thread record containing link data.
 type Log_Record struct {
 	// recordNode is the top-level node's raw data.
@@ -82,6 +102,12 @@ type Log_Record struct {
 	HeaderNode []byte `protobuf:"bytes,3,opt,name=headerNode,proto3" json:"headerNode,omitempty"`
 	// bodyNode is the body node's raw data.
 	BodyNode []byte `protobuf:"bytes,4,opt,name=bodyNode,proto3" json:"bodyNode,omitempty"`
+	// version of the record envelope, zero is treated as v1.
+	Version int32 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	// extensions are the signed record extension fields (v2 and above).
+	Extensions []byte `protobuf:"bytes,6,opt,name=extensions,proto3" json:"extensions,omitempty"`
+	// restricted indicates the body is withheld from the requester by the thread ACL.
+	Restricted bool `protobuf:"varint,7,opt,name=restricted,proto3" json:"restricted,omitempty"`
 }
 
 func (m *Log_Record) Reset()         { *m = Log_Record{} }
@@ -145,6 +171,27 @@ func (m *Log_Record) GetBodyNode() []byte {
 	return nil
 }
 
+func (m *Log_Record) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Log_Record) GetExtensions() []byte {
+	if m != nil {
+		return m.Extensions
+	}
+	return nil
+}
+
+func (m *Log_Record) GetRestricted() bool {
+	if m != nil {
+		return m.Restricted
+	}
+	return false
+}
+
 // GetLogsRequest is used to request thread logs.
 type GetLogsRequest struct {
 	// body is the message body.
@@ -196,6 +243,10 @@ type GetLogsRequest_Body struct {
 	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
 	// serviceKey for the thread.
 	ServiceKey *ProtoKey `protobuf:"bytes,2,opt,name=serviceKey,proto3,customtype=ProtoKey" json:"serviceKey,omitempty"`
+	// after is the paging cursor, only logs with a greater ID are returned.
+	After *ProtoPeerID `protobuf:"bytes,3,opt,name=after,proto3,customtype=ProtoPeerID" json:"after,omitempty"`
+	// limit is the max number of logs returned. Zero returns all logs.
+	Limit int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
 }
 
 func (m *GetLogsRequest_Body) Reset()         { *m = GetLogsRequest_Body{} }
@@ -231,10 +282,27 @@ func (m *GetLogsRequest_Body) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_GetLogsRequest_Body proto.InternalMessageInfo
 
+func (m *GetLogsRequest_Body) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
 // GetLogsReply is the response from a GetLogsRequest.
 type GetLogsReply struct {
 	// logs are the result of the request.
 	Logs []*Log `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	// flags are the thread flags.
+	Flags []string `protobuf:"bytes,2,rep,name=flags,proto3" json:"flags,omitempty"`
+	// next is the cursor of the next page, it is empty on the last page.
+	Next *ProtoPeerID `protobuf:"bytes,3,opt,name=next,proto3,customtype=ProtoPeerID" json:"next,omitempty"`
+	// metadata is the signed thread metadata, it is empty if none is set.
+	Metadata []byte `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// flagsSigner is the key of the log which signed the flags, it is empty if they aren't signed.
+	FlagsSigner []byte `protobuf:"bytes,5,opt,name=flagsSigner,proto3" json:"flagsSigner,omitempty"`
+	// flagsSig is the signature of the flags by flagsSigner.
+	FlagsSig []byte `protobuf:"bytes,6,opt,name=flagsSig,proto3" json:"flagsSig,omitempty"`
 }
 
 func (m *GetLogsReply) Reset()         { *m = GetLogsReply{} }
@@ -277,6 +345,34 @@ func (m *GetLogsReply) GetLogs() []*Log {
 	return nil
 }
 
+func (m *GetLogsReply) GetFlags() []string {
+	if m != nil {
+		return m.Flags
+	}
+	return nil
+}
+
+func (m *GetLogsReply) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *GetLogsReply) GetFlagsSigner() []byte {
+	if m != nil {
+		return m.FlagsSigner
+	}
+	return nil
+}
+
+func (m *GetLogsReply) GetFlagsSig() []byte {
+	if m != nil {
+		return m.FlagsSig
+	}
+	return nil
+}
+
 // PushLogRequest is used to push a thread log to a peer.
 type PushLogRequest struct {
 	// body is the message body.
@@ -332,6 +428,16 @@ type PushLogRequest_Body struct {
 	ReadKey *ProtoKey `protobuf:"bytes,3,opt,name=readKey,proto3,customtype=ProtoKey" json:"readKey,omitempty"`
 	// log is the actual log payload.
 	Log *Log `protobuf:"bytes,4,opt,name=log,proto3" json:"log,omitempty"`
+	// metadata is the signed thread metadata, it is empty if none is set.
+	Metadata []byte `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// keyRotation is the signed latest service key rotation, it is empty if the key was never rotated.
+	KeyRotation []byte `protobuf:"bytes,6,opt,name=keyRotation,proto3" json:"keyRotation,omitempty"`
+	// flags are the thread flags.
+	Flags []string `protobuf:"bytes,7,rep,name=flags,proto3" json:"flags,omitempty"`
+	// flagsSigner is the key of the log which signed the flags, it is empty if they aren't signed.
+	FlagsSigner []byte `protobuf:"bytes,8,opt,name=flagsSigner,proto3" json:"flagsSigner,omitempty"`
+	// flagsSig is the signature of the flags by flagsSigner.
+	FlagsSig []byte `protobuf:"bytes,9,opt,name=flagsSig,proto3" json:"flagsSig,omitempty"`
 }
 
 func (m *PushLogRequest_Body) Reset()         { *m = PushLogRequest_Body{} }
@@ -374,6 +480,41 @@ func (m *PushLogRequest_Body) GetLog() *Log {
 	return nil
 }
 
+func (m *PushLogRequest_Body) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *PushLogRequest_Body) GetKeyRotation() []byte {
+	if m != nil {
+		return m.KeyRotation
+	}
+	return nil
+}
+
+func (m *PushLogRequest_Body) GetFlags() []string {
+	if m != nil {
+		return m.Flags
+	}
+	return nil
+}
+
+func (m *PushLogRequest_Body) GetFlagsSigner() []byte {
+	if m != nil {
+		return m.FlagsSigner
+	}
+	return nil
+}
+
+func (m *PushLogRequest_Body) GetFlagsSig() []byte {
+	if m != nil {
+		return m.FlagsSig
+	}
+	return nil
+}
+
 // PushLogReply is the response from a PushLogRequest.
 type PushLogReply struct {
 }
@@ -464,6 +605,8 @@ type GetRecordsRequest_Body struct {
 	ServiceKey *ProtoKey `protobuf:"bytes,2,opt,name=serviceKey,proto3,customtype=ProtoKey" json:"serviceKey,omitempty"`
 	// List of requested logs.
 	Logs []*GetRecordsRequest_Body_LogEntry `protobuf:"bytes,3,rep,name=logs,proto3" json:"logs,omitempty"`
+	// headersOnly asks to omit record bodies, which are requested separately with GetRecordBodies.
+	HeadersOnly bool `protobuf:"varint,4,opt,name=headersOnly,proto3" json:"headersOnly,omitempty"`
 }
 
 func (m *GetRecordsRequest_Body) Reset()         { *m = GetRecordsRequest_Body{} }
@@ -506,6 +649,13 @@ func (m *GetRecordsRequest_Body) GetLogs() []*GetRecordsRequest_Body_LogEntry {
 	return nil
 }
 
+func (m *GetRecordsRequest_Body) GetHeadersOnly() bool {
+	if m != nil {
+		return m.HeadersOnly
+	}
+	return false
+}
+
 // LogEntry represents a single log.
 type GetRecordsRequest_Body_LogEntry struct {
 	// logID of this entry.
@@ -514,6 +664,8 @@ type GetRecordsRequest_Body_LogEntry struct {
 	Offset *ProtoCid `protobuf:"bytes,2,opt,name=offset,proto3,customtype=ProtoCid" json:"offset,omitempty"`
 	// limit indicates the max number of records to return.
 	Limit int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	// heads are all heads of the log known to the requester, including offset.
+	Heads []ProtoCid `protobuf:"bytes,4,rep,name=heads,proto3,customtype=ProtoCid" json:"heads,omitempty"`
 }
 
 func (m *GetRecordsRequest_Body_LogEntry) Reset()         { *m = GetRecordsRequest_Body_LogEntry{} }
@@ -610,6 +762,9 @@ type GetRecordsReply_LogEntry struct {
 	Records []*Log_Record `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
 	// log contains new log info that was missing from the request.
 	Log *Log `protobuf:"bytes,3,opt,name=log,proto3" json:"log,omitempty"`
+	// boundary is the oldest record kept by the sender if the log was compacted.
+	// Records before the boundary are not available anymore.
+	Boundary *ProtoCid `protobuf:"bytes,4,opt,name=boundary,proto3,customtype=ProtoCid" json:"boundary,omitempty"`
 }
 
 func (m *GetRecordsReply_LogEntry) Reset()         { *m = GetRecordsReply_LogEntry{} }
@@ -889,6 +1044,8 @@ type ExchangeEdgesRequest_Body_ThreadEntry struct {
 	AddressEdge uint64 `protobuf:"varint,2,opt,name=addressEdge,proto3" json:"addressEdge,omitempty"`
 	// headsEdge is the current hash of the log's heads stored on a requester.
 	HeadsEdge uint64 `protobuf:"varint,3,opt,name=headsEdge,proto3" json:"headsEdge,omitempty"`
+	// logSeqs are the sequence numbers of the logs stored on a requester.
+	LogSeqs []*LogSeq `protobuf:"bytes,4,rep,name=logSeqs,proto3" json:"logSeqs,omitempty"`
 }
 
 func (m *ExchangeEdgesRequest_Body_ThreadEntry) Reset()         { *m = ExchangeEdgesRequest_Body_ThreadEntry{} }
@@ -938,6 +1095,13 @@ func (m *ExchangeEdgesRequest_Body_ThreadEntry) GetHeadsEdge() uint64 {
 	return 0
 }
 
+func (m *ExchangeEdgesRequest_Body_ThreadEntry) GetLogSeqs() []*LogSeq {
+	if m != nil {
+		return m.LogSeqs
+	}
+	return nil
+}
+
 // ExchangeEdgesReply contains edges requested with an ExchangeEdgesRequest.
 type ExchangeEdgesReply struct {
 	// edges contains edge information about requested threads.
@@ -993,6 +1157,8 @@ type ExchangeEdgesReply_ThreadEdges struct {
 	AddressEdge uint64 `protobuf:"varint,3,opt,name=addressEdge,proto3" json:"addressEdge,omitempty"`
 	// headsEdge is the current hash of the log's heads stored on a respondent.
 	HeadsEdge uint64 `protobuf:"varint,4,opt,name=headsEdge,proto3" json:"headsEdge,omitempty"`
+	// logSeqs are the sequence numbers of the logs stored on a respondent.
+	LogSeqs []*LogSeq `protobuf:"bytes,5,rep,name=logSeqs,proto3" json:"logSeqs,omitempty"`
 }
 
 func (m *ExchangeEdgesReply_ThreadEdges) Reset()         { *m = ExchangeEdgesReply_ThreadEdges{} }
@@ -1049,1989 +1215,12080 @@ func (m *ExchangeEdgesReply_ThreadEdges) GetHeadsEdge() uint64 {
 	return 0
 }
 
-func init() {
-	proto.RegisterType((*Log)(nil), "net.pb.Log")
-	proto.RegisterType((*Log_Record)(nil), "net.pb.Log.Record")
-	proto.RegisterType((*GetLogsRequest)(nil), "net.pb.GetLogsRequest")
-	proto.RegisterType((*GetLogsRequest_Body)(nil), "net.pb.GetLogsRequest.Body")
-	proto.RegisterType((*GetLogsReply)(nil), "net.pb.GetLogsReply")
-	proto.RegisterType((*PushLogRequest)(nil), "net.pb.PushLogRequest")
-	proto.RegisterType((*PushLogRequest_Body)(nil), "net.pb.PushLogRequest.Body")
-	proto.RegisterType((*PushLogReply)(nil), "net.pb.PushLogReply")
-	proto.RegisterType((*GetRecordsRequest)(nil), "net.pb.GetRecordsRequest")
-	proto.RegisterType((*GetRecordsRequest_Body)(nil), "net.pb.GetRecordsRequest.Body")
-	proto.RegisterType((*GetRecordsRequest_Body_LogEntry)(nil), "net.pb.GetRecordsRequest.Body.LogEntry")
-	proto.RegisterType((*GetRecordsReply)(nil), "net.pb.GetRecordsReply")
-	proto.RegisterType((*GetRecordsReply_LogEntry)(nil), "net.pb.GetRecordsReply.LogEntry")
-	proto.RegisterType((*PushRecordRequest)(nil), "net.pb.PushRecordRequest")
-	proto.RegisterType((*PushRecordRequest_Body)(nil), "net.pb.PushRecordRequest.Body")
-	proto.RegisterType((*PushRecordReply)(nil), "net.pb.PushRecordReply")
-	proto.RegisterType((*ExchangeEdgesRequest)(nil), "net.pb.ExchangeEdgesRequest")
-	proto.RegisterType((*ExchangeEdgesRequest_Body)(nil), "net.pb.ExchangeEdgesRequest.Body")
-	proto.RegisterType((*ExchangeEdgesRequest_Body_ThreadEntry)(nil), "net.pb.ExchangeEdgesRequest.Body.ThreadEntry")
-	proto.RegisterType((*ExchangeEdgesReply)(nil), "net.pb.ExchangeEdgesReply")
-	proto.RegisterType((*ExchangeEdgesReply_ThreadEdges)(nil), "net.pb.ExchangeEdgesReply.ThreadEdges")
-}
-
-func init() { proto.RegisterFile("net.proto", fileDescriptor_a5b10ce944527a32) }
-
-var fileDescriptor_a5b10ce944527a32 = []byte{
-	// 880 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0x3d, 0x6f, 0x33, 0x45,
-	0x10, 0xf6, 0xde, 0x9d, 0x3f, 0x32, 0x76, 0x12, 0xbc, 0x8a, 0x5e, 0xcc, 0xf1, 0x72, 0x36, 0x06,
-	0x92, 0x08, 0x25, 0xb6, 0x64, 0xa0, 0x40, 0xd0, 0x60, 0x12, 0x45, 0x21, 0x11, 0x8a, 0x16, 0xfe,
-	0x80, 0xed, 0xdb, 0x9c, 0x2d, 0x39, 0x5e, 0x73, 0x77, 0x8e, 0x62, 0x09, 0x51, 0x50, 0xd1, 0x41,
-	0x41, 0x47, 0x49, 0x87, 0xf8, 0x11, 0x74, 0xd0, 0x20, 0xa5, 0xa0, 0x88, 0x5c, 0x18, 0x70, 0x2a,
-	0x7a, 0x0a, 0x4a, 0xb4, 0x1f, 0xf7, 0xe5, 0xaf, 0x28, 0x6f, 0x91, 0xce, 0x33, 0xcf, 0xcc, 0xdc,
-	0x3c, 0x33, 0xcf, 0xee, 0x1a, 0x36, 0x06, 0xd4, 0xaf, 0x0d, 0x5d, 0xe6, 0x33, 0x9c, 0x11, 0x3f,
-	0xdb, 0xe6, 0xa1, 0xd3, 0xf3, 0xbb, 0xa3, 0x76, 0xad, 0xc3, 0xae, 0xea, 0x0e, 0x73, 0x58, 0x5d,
-	0xc0, 0xed, 0xd1, 0xa5, 0xb0, 0x84, 0x21, 0x7e, 0xc9, 0xb4, 0xea, 0x0f, 0x1a, 0xe8, 0xe7, 0xcc,
-	0xc1, 0x65, 0xd0, 0x4e, 0x8f, 0x4a, 0xa8, 0x82, 0xf6, 0x0b, 0xcd, 0xed, 0xc9, 0xb4, 0x9c, 0xbf,
-	0xe0, 0xf0, 0x05, 0xa5, 0xee, 0xe9, 0x11, 0xd1, 0x4e, 0x8f, 0xf0, 0x1e, 0x64, 0x86, 0xa3, 0xf6,
-	0x19, 0x1d, 0x97, 0xb4, 0xf9, 0x20, 0xe1, 0x26, 0x0a, 0xc6, 0x6f, 0x40, 0xba, 0x65, 0xdb, 0xae,
-	0x57, 0xd2, 0x2b, 0xfa, 0x7e, 0xa1, 0xb9, 0x39, 0x99, 0x96, 0x37, 0x44, 0xdc, 0x47, 0xb6, 0xed,
-	0x12, 0x89, 0xe1, 0x0a, 0x18, 0x5d, 0xda, 0xb2, 0x4b, 0x86, 0xa8, 0x55, 0x98, 0x4c, 0xcb, 0x39,
-	0x11, 0xf3, 0x71, 0xcf, 0x26, 0x02, 0x31, 0xbf, 0x46, 0x90, 0x21, 0xb4, 0xc3, 0x5c, 0x1b, 0x5b,
-	0x00, 0xae, 0xf8, 0xf5, 0x29, 0xb3, 0xa9, 0xec, 0x91, 0xc4, 0x3c, 0xf8, 0x39, 0x6c, 0xd0, 0x6b,
-	0x3a, 0xf0, 0x05, 0x2c, 0xba, 0x23, 0x91, 0x83, 0x67, 0xf3, 0x82, 0xd4, 0x15, 0xb0, 0x2e, 0xb3,
-	0x23, 0x0f, 0x36, 0x21, 0xd7, 0x66, 0xf6, 0x58, 0xa0, 0xa2, 0x1d, 0x12, 0xda, 0xd5, 0x9f, 0x11,
-	0x6c, 0x9d, 0x50, 0xff, 0x9c, 0x39, 0x1e, 0xa1, 0x5f, 0x8c, 0xa8, 0xe7, 0xe3, 0x3a, 0x18, 0x1c,
-	0x16, 0xdf, 0xc9, 0x37, 0x5e, 0xad, 0xc9, 0xb1, 0xd7, 0x92, 0x51, 0xb5, 0x26, 0xb3, 0xc7, 0x44,
-	0x04, 0x9a, 0x1d, 0x30, 0xb8, 0x85, 0x0f, 0x21, 0xe7, 0x77, 0x5d, 0xda, 0xb2, 0xc3, 0x39, 0x17,
-	0x27, 0xd3, 0xf2, 0xa6, 0xa0, 0xfd, 0xb9, 0x02, 0x48, 0x18, 0x82, 0x0f, 0x00, 0x3c, 0xea, 0x5e,
-	0xf7, 0x3a, 0x34, 0x9a, 0x79, 0x34, 0x27, 0x3e, 0xf0, 0x18, 0xfe, 0x89, 0x91, 0x43, 0x2f, 0x69,
-	0xd5, 0x3a, 0x14, 0xc2, 0x3e, 0x86, 0xfd, 0x31, 0x2e, 0x83, 0xd1, 0x67, 0x8e, 0x57, 0x42, 0x15,
-	0x7d, 0x3f, 0xdf, 0xc8, 0x07, 0xbd, 0x9e, 0x33, 0x87, 0x08, 0xa0, 0xfa, 0x2f, 0x82, 0xad, 0x8b,
-	0x91, 0xd7, 0xe5, 0x9e, 0xf5, 0xfc, 0x92, 0x51, 0x71, 0x7e, 0x3f, 0xa1, 0x27, 0x20, 0x88, 0x77,
-	0x21, 0xcb, 0xf3, 0x78, 0xa8, 0xbe, 0x24, 0x34, 0x00, 0xf1, 0x6b, 0xa0, 0xf7, 0x99, 0x23, 0x16,
-	0x39, 0xc7, 0x98, 0xfb, 0xd5, 0x9c, 0xb6, 0xa0, 0x10, 0xf2, 0x19, 0xf6, 0xc7, 0xd5, 0x3f, 0x35,
-	0x28, 0x9e, 0x50, 0x5f, 0xca, 0x2d, 0xdc, 0x74, 0x23, 0x31, 0x09, 0x2b, 0xb6, 0xe9, 0x64, 0x60,
-	0x7c, 0x18, 0xdf, 0x6a, 0x4f, 0x31, 0x8c, 0x0f, 0xd4, 0x5e, 0x75, 0xb1, 0xd7, 0xbd, 0xf5, 0x9d,
-	0x71, 0xf2, 0xc7, 0x03, 0xdf, 0x1d, 0xcb, 0x9d, 0x9b, 0x57, 0x90, 0x0b, 0x3c, 0xf8, 0x2d, 0x48,
-	0xf7, 0x99, 0xb3, 0xfa, 0xe0, 0x4b, 0x14, 0xbf, 0x09, 0x19, 0x76, 0x79, 0xe9, 0x51, 0x7f, 0xa1,
-	0x33, 0x7e, 0x5e, 0x15, 0x86, 0x77, 0x20, 0xdd, 0xef, 0x5d, 0xf5, 0x7c, 0xb1, 0xa0, 0x34, 0x91,
-	0x86, 0x9a, 0xf8, 0xaf, 0x08, 0xb6, 0xe3, 0xed, 0x71, 0x75, 0xbe, 0x9b, 0x50, 0x67, 0x65, 0x19,
-	0x8b, 0x61, 0x7f, 0xa1, 0xfd, 0xaf, 0x1e, 0xdf, 0xfe, 0x01, 0xd7, 0x8e, 0xa8, 0x58, 0xd2, 0xc4,
-	0xb7, 0x70, 0x4c, 0x17, 0x35, 0xf9, 0x31, 0x12, 0x84, 0x04, 0x0a, 0xd2, 0x97, 0x2b, 0xa8, 0x7a,
-	0x87, 0xa0, 0xc8, 0xc5, 0xa3, 0xd2, 0xd6, 0x6b, 0x65, 0x21, 0x30, 0xae, 0x95, 0x6f, 0x5e, 0xf0,
-	0xe0, 0x84, 0xac, 0xb5, 0xb5, 0xac, 0xdf, 0x86, 0x8c, 0xa4, 0xa4, 0xa8, 0x2c, 0x23, 0xad, 0x22,
-	0xd4, 0x92, 0x8a, 0xb0, 0x1d, 0x6f, 0x98, 0x9f, 0x8c, 0x1f, 0x35, 0xd8, 0x39, 0xbe, 0xe9, 0x74,
-	0x5b, 0x03, 0x87, 0x1e, 0xdb, 0x0e, 0x0d, 0x0f, 0xc7, 0x7b, 0x09, 0xc2, 0xaf, 0x07, 0xb5, 0x97,
-	0xc5, 0xc6, 0x39, 0xff, 0x1e, 0x70, 0x3e, 0x81, 0xac, 0x24, 0x14, 0xec, 0xff, 0xf0, 0xc1, 0x12,
-	0x35, 0x39, 0x0b, 0x29, 0x86, 0x20, 0xdb, 0xfc, 0x12, 0xf2, 0x31, 0xff, 0x63, 0x67, 0x59, 0x81,
-	0x3c, 0x7f, 0x90, 0xa8, 0xe7, 0xf1, 0xcf, 0x09, 0x36, 0x06, 0x89, 0xbb, 0xf8, 0xe3, 0xc2, 0x1f,
-	0x0b, 0x89, 0xeb, 0x02, 0x8f, 0x1c, 0x6a, 0x70, 0xff, 0x20, 0xc0, 0x73, 0x6d, 0x73, 0x81, 0x7f,
-	0x08, 0x69, 0xca, 0x2d, 0xc5, 0x70, 0x77, 0x05, 0x43, 0x2e, 0x72, 0x45, 0x41, 0x38, 0x64, 0x92,
-	0xf9, 0x3d, 0x0a, 0x99, 0x71, 0xfb, 0xb1, 0xcc, 0x9e, 0x41, 0x86, 0xde, 0xf4, 0x3c, 0xdf, 0x13,
-	0xa4, 0x72, 0x44, 0x59, 0xf3, 0x8c, 0xf5, 0x07, 0x18, 0x1b, 0x73, 0x8c, 0x1b, 0x7f, 0x68, 0x90,
-	0xfd, 0x4c, 0x5e, 0x45, 0xf8, 0x7d, 0xc8, 0xaa, 0xf7, 0x06, 0x3f, 0x5b, 0xfe, 0x10, 0x9a, 0x3b,
-	0x0b, 0x7e, 0x2e, 0xab, 0x14, 0x4f, 0x55, 0x57, 0x70, 0x94, 0x9a, 0x7c, 0x63, 0xa2, 0xd4, 0xc4,
-	0x5d, 0x9d, 0xc2, 0x4d, 0x80, 0xe8, 0x8e, 0xc0, 0xaf, 0xac, 0xbc, 0xfd, 0xcc, 0x97, 0x57, 0x5c,
-	0x29, 0xb2, 0x46, 0x24, 0xf5, 0xa8, 0xc6, 0xc2, 0x79, 0x8d, 0x6a, 0xcc, 0x9f, 0x8c, 0x14, 0x3e,
-	0x83, 0xcd, 0xc4, 0x26, 0xf1, 0xf3, 0x75, 0x12, 0x36, 0xcd, 0xd5, 0xeb, 0xaf, 0xa6, 0x9a, 0x95,
-	0xff, 0xfe, 0xb6, 0xd0, 0x2f, 0x33, 0x0b, 0xfd, 0x36, 0xb3, 0xd0, 0xed, 0xcc, 0x42, 0x7f, 0xcd,
-	0x2c, 0xf4, 0xdd, 0xbd, 0x95, 0xba, 0xbd, 0xb7, 0x52, 0x77, 0xf7, 0x56, 0xaa, 0x9d, 0x11, 0x7f,
-	0xd8, 0xde, 0xf9, 0x3f, 0x00, 0x00, 0xff, 0xff, 0x06, 0x23, 0x7b, 0xac, 0xf4, 0x09, 0x00, 0x00,
+func (m *ExchangeEdgesReply_ThreadEdges) GetLogSeqs() []*LogSeq {
+	if m != nil {
+		return m.LogSeqs
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// ServiceClient is the client API for Service service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ServiceClient interface {
-	// GetLogs from a peer.
-	GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsReply, error)
-	// PushLog to a peer.
-	PushLog(ctx context.Context, in *PushLogRequest, opts ...grpc.CallOption) (*PushLogReply, error)
-	// GetRecords from a peer.
-	GetRecords(ctx context.Context, in *GetRecordsRequest, opts ...grpc.CallOption) (*GetRecordsReply, error)
-	// PushRecord to a peer.
-	PushRecord(ctx context.Context, in *PushRecordRequest, opts ...grpc.CallOption) (*PushRecordReply, error)
-	// ExchangeEdges with a peer.
-	ExchangeEdges(ctx context.Context, in *ExchangeEdgesRequest, opts ...grpc.CallOption) (*ExchangeEdgesReply, error)
+// Backpressure is attached to the ResourceExhausted errors of rate limited requests.
+type Backpressure struct {
+	// retryAfter is the time in milliseconds to wait before retrying the request.
+	RetryAfter int64 `protobuf:"varint,1,opt,name=retryAfter,proto3" json:"retryAfter,omitempty"`
 }
 
-type serviceClient struct {
-	cc *grpc.ClientConn
+func (m *Backpressure) Reset()         { *m = Backpressure{} }
+func (m *Backpressure) String() string { return proto.CompactTextString(m) }
+func (*Backpressure) ProtoMessage()    {}
+func (*Backpressure) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{11}
 }
-
-func NewServiceClient(cc *grpc.ClientConn) ServiceClient {
-	return &serviceClient{cc}
+func (m *Backpressure) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-func (c *serviceClient) GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsReply, error) {
-	out := new(GetLogsReply)
-	err := c.cc.Invoke(ctx, "/net.pb.Service/GetLogs", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *Backpressure) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Backpressure.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return out, nil
+}
+func (m *Backpressure) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Backpressure.Merge(m, src)
+}
+func (m *Backpressure) XXX_Size() int {
+	return m.Size()
+}
+func (m *Backpressure) XXX_DiscardUnknown() {
+	xxx_messageInfo_Backpressure.DiscardUnknown(m)
 }
 
-func (c *serviceClient) PushLog(ctx context.Context, in *PushLogRequest, opts ...grpc.CallOption) (*PushLogReply, error) {
-	out := new(PushLogReply)
-	err := c.cc.Invoke(ctx, "/net.pb.Service/PushLog", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_Backpressure proto.InternalMessageInfo
+
+func (m *Backpressure) GetRetryAfter() int64 {
+	if m != nil {
+		return m.RetryAfter
 	}
-	return out, nil
+	return 0
 }
 
-func (c *serviceClient) GetRecords(ctx context.Context, in *GetRecordsRequest, opts ...grpc.CallOption) (*GetRecordsReply, error) {
-	out := new(GetRecordsReply)
-	err := c.cc.Invoke(ctx, "/net.pb.Service/GetRecords", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+// PushRecordsRequest is used to push a batch of chained log records to a peer.
+type PushRecordsRequest struct {
+	// body is the message body.
+	Body *PushRecordsRequest_Body `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
 }
 
-func (c *serviceClient) PushRecord(ctx context.Context, in *PushRecordRequest, opts ...grpc.CallOption) (*PushRecordReply, error) {
-	out := new(PushRecordReply)
-	err := c.cc.Invoke(ctx, "/net.pb.Service/PushRecord", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *PushRecordsRequest) Reset()         { *m = PushRecordsRequest{} }
+func (m *PushRecordsRequest) String() string { return proto.CompactTextString(m) }
+func (*PushRecordsRequest) ProtoMessage()    {}
+func (*PushRecordsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{12}
+}
+func (m *PushRecordsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushRecordsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushRecordsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return out, nil
+}
+func (m *PushRecordsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRecordsRequest.Merge(m, src)
+}
+func (m *PushRecordsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PushRecordsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRecordsRequest.DiscardUnknown(m)
 }
 
-func (c *serviceClient) ExchangeEdges(ctx context.Context, in *ExchangeEdgesRequest, opts ...grpc.CallOption) (*ExchangeEdgesReply, error) {
-	out := new(ExchangeEdgesReply)
-	err := c.cc.Invoke(ctx, "/net.pb.Service/ExchangeEdges", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_PushRecordsRequest proto.InternalMessageInfo
+
+func (m *PushRecordsRequest) GetBody() *PushRecordsRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	return out, nil
+	return nil
 }
 
-// ServiceServer is the server API for Service service.
-type ServiceServer interface {
-	// GetLogs from a peer.
-	GetLogs(context.Context, *GetLogsRequest) (*GetLogsReply, error)
-	// PushLog to a peer.
-	PushLog(context.Context, *PushLogRequest) (*PushLogReply, error)
-	// GetRecords from a peer.
-	GetRecords(context.Context, *GetRecordsRequest) (*GetRecordsReply, error)
-	// PushRecord to a peer.
-	PushRecord(context.Context, *PushRecordRequest) (*PushRecordReply, error)
-	// ExchangeEdges with a peer.
-	ExchangeEdges(context.Context, *ExchangeEdgesRequest) (*ExchangeEdgesReply, error)
+type PushRecordsRequest_Body struct {
+	// threadID is the target thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// logID is the target log's ID.
+	LogID *ProtoPeerID `protobuf:"bytes,2,opt,name=logID,proto3,customtype=ProtoPeerID" json:"logID,omitempty"`
+	// records is the list of record payloads, oldest first.
+	Records []*Log_Record `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
 }
 
-// UnimplementedServiceServer can be embedded to have forward compatible implementations.
-type UnimplementedServiceServer struct {
+func (m *PushRecordsRequest_Body) Reset()         { *m = PushRecordsRequest_Body{} }
+func (m *PushRecordsRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*PushRecordsRequest_Body) ProtoMessage()    {}
+func (*PushRecordsRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{12, 0}
 }
-
-func (*UnimplementedServiceServer) GetLogs(ctx context.Context, req *GetLogsRequest) (*GetLogsReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
+func (m *PushRecordsRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-func (*UnimplementedServiceServer) PushLog(ctx context.Context, req *PushLogRequest) (*PushLogReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method PushLog not implemented")
+func (m *PushRecordsRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushRecordsRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
 }
-func (*UnimplementedServiceServer) GetRecords(ctx context.Context, req *GetRecordsRequest) (*GetRecordsReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetRecords not implemented")
+func (m *PushRecordsRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRecordsRequest_Body.Merge(m, src)
 }
-func (*UnimplementedServiceServer) PushRecord(ctx context.Context, req *PushRecordRequest) (*PushRecordReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method PushRecord not implemented")
+func (m *PushRecordsRequest_Body) XXX_Size() int {
+	return m.Size()
 }
-func (*UnimplementedServiceServer) ExchangeEdges(ctx context.Context, req *ExchangeEdgesRequest) (*ExchangeEdgesReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ExchangeEdges not implemented")
+func (m *PushRecordsRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRecordsRequest_Body.DiscardUnknown(m)
 }
 
-func RegisterServiceServer(s *grpc.Server, srv ServiceServer) {
-	s.RegisterService(&_Service_serviceDesc, srv)
-}
+var xxx_messageInfo_PushRecordsRequest_Body proto.InternalMessageInfo
 
-func _Service_GetLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetLogsRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(ServiceServer).GetLogs(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/net.pb.Service/GetLogs",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).GetLogs(ctx, req.(*GetLogsRequest))
+func (m *PushRecordsRequest_Body) GetRecords() []*Log_Record {
+	if m != nil {
+		return m.Records
 	}
-	return interceptor(ctx, in, info, handler)
+	return nil
 }
 
-func _Service_PushLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PushLogRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(ServiceServer).PushLog(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/net.pb.Service/PushLog",
+// PushRecordsReply is the response from a PushRecordsRequest.
+type PushRecordsReply struct {
+}
+
+func (m *PushRecordsReply) Reset()         { *m = PushRecordsReply{} }
+func (m *PushRecordsReply) String() string { return proto.CompactTextString(m) }
+func (*PushRecordsReply) ProtoMessage()    {}
+func (*PushRecordsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{13}
+}
+func (m *PushRecordsReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushRecordsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushRecordsReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).PushLog(ctx, req.(*PushLogRequest))
+}
+func (m *PushRecordsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRecordsReply.Merge(m, src)
+}
+func (m *PushRecordsReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *PushRecordsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRecordsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushRecordsReply proto.InternalMessageInfo
+
+// Invite is a thread invite signed by the inviting host.
+type Invite struct {
+	// body is the marshaled invite body.
+	Body []byte `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+	// sig is the inviter's signature of the body.
+	Sig []byte `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+}
+
+func (m *Invite) Reset()         { *m = Invite{} }
+func (m *Invite) String() string { return proto.CompactTextString(m) }
+func (*Invite) ProtoMessage()    {}
+func (*Invite) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{14}
+}
+func (m *Invite) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Invite) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Invite.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return interceptor(ctx, in, info, handler)
+}
+func (m *Invite) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Invite.Merge(m, src)
+}
+func (m *Invite) XXX_Size() int {
+	return m.Size()
+}
+func (m *Invite) XXX_DiscardUnknown() {
+	xxx_messageInfo_Invite.DiscardUnknown(m)
 }
 
-func _Service_GetRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRecordsRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+var xxx_messageInfo_Invite proto.InternalMessageInfo
+
+func (m *Invite) GetBody() []byte {
+	if m != nil {
+		return m.Body
 	}
-	if interceptor == nil {
-		return srv.(ServiceServer).GetRecords(ctx, in)
+	return nil
+}
+
+func (m *Invite) GetSig() []byte {
+	if m != nil {
+		return m.Sig
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/net.pb.Service/GetRecords",
+	return nil
+}
+
+type Invite_Body struct {
+	// threadID is the thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// inviter is the inviting host's peer ID.
+	Inviter *ProtoPeerID `protobuf:"bytes,2,opt,name=inviter,proto3,customtype=ProtoPeerID" json:"inviter,omitempty"`
+	// addrs are the inviter's addresses.
+	Addrs []ProtoAddr `protobuf:"bytes,3,rep,name=addrs,proto3,customtype=ProtoAddr" json:"addrs,omitempty"`
+	// role is the role granted to the invitee.
+	Role int32 `protobuf:"varint,4,opt,name=role,proto3" json:"role,omitempty"`
+	// expires is the expiration time in unix seconds, zero if the invite doesn't expire.
+	Expires int64 `protobuf:"varint,5,opt,name=expires,proto3" json:"expires,omitempty"`
+	// nonce identifies a single-use invite, the key bundle is then redeemed from the inviter.
+	Nonce []byte `protobuf:"bytes,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// bundle is the thread key bundle.
+	Bundle []byte `protobuf:"bytes,7,opt,name=bundle,proto3" json:"bundle,omitempty"`
+	// encrypted is whether the bundle is encrypted for the invitee.
+	Encrypted bool `protobuf:"varint,8,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+}
+
+func (m *Invite_Body) Reset()         { *m = Invite_Body{} }
+func (m *Invite_Body) String() string { return proto.CompactTextString(m) }
+func (*Invite_Body) ProtoMessage()    {}
+func (*Invite_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{14, 0}
+}
+func (m *Invite_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Invite_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Invite_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).GetRecords(ctx, req.(*GetRecordsRequest))
+}
+func (m *Invite_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Invite_Body.Merge(m, src)
+}
+func (m *Invite_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *Invite_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_Invite_Body.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Invite_Body proto.InternalMessageInfo
+
+func (m *Invite_Body) GetRole() int32 {
+	if m != nil {
+		return m.Role
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
 }
 
-func _Service_PushRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PushRecordRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+func (m *Invite_Body) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
 	}
-	if interceptor == nil {
-		return srv.(ServiceServer).PushRecord(ctx, in)
+	return 0
+}
+
+func (m *Invite_Body) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/net.pb.Service/PushRecord",
+	return nil
+}
+
+func (m *Invite_Body) GetBundle() []byte {
+	if m != nil {
+		return m.Bundle
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).PushRecord(ctx, req.(*PushRecordRequest))
+	return nil
+}
+
+func (m *Invite_Body) GetEncrypted() bool {
+	if m != nil {
+		return m.Encrypted
 	}
-	return interceptor(ctx, in, info, handler)
+	return false
 }
 
-func _Service_ExchangeEdges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ExchangeEdgesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+// RedeemInviteRequest is used to redeem a single-use invite.
+type RedeemInviteRequest struct {
+	// body is the message body.
+	Body *RedeemInviteRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (m *RedeemInviteRequest) Reset()         { *m = RedeemInviteRequest{} }
+func (m *RedeemInviteRequest) String() string { return proto.CompactTextString(m) }
+func (*RedeemInviteRequest) ProtoMessage()    {}
+func (*RedeemInviteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{15}
+}
+func (m *RedeemInviteRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RedeemInviteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RedeemInviteRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if interceptor == nil {
-		return srv.(ServiceServer).ExchangeEdges(ctx, in)
+}
+func (m *RedeemInviteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RedeemInviteRequest.Merge(m, src)
+}
+func (m *RedeemInviteRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *RedeemInviteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RedeemInviteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RedeemInviteRequest proto.InternalMessageInfo
+
+func (m *RedeemInviteRequest) GetBody() *RedeemInviteRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/net.pb.Service/ExchangeEdges",
+	return nil
+}
+
+type RedeemInviteRequest_Body struct {
+	// threadID is the invite's thread ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// nonce is the invite's nonce.
+	Nonce []byte `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (m *RedeemInviteRequest_Body) Reset()         { *m = RedeemInviteRequest_Body{} }
+func (m *RedeemInviteRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*RedeemInviteRequest_Body) ProtoMessage()    {}
+func (*RedeemInviteRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{15, 0}
+}
+func (m *RedeemInviteRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RedeemInviteRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RedeemInviteRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).ExchangeEdges(ctx, req.(*ExchangeEdgesRequest))
+}
+func (m *RedeemInviteRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RedeemInviteRequest_Body.Merge(m, src)
+}
+func (m *RedeemInviteRequest_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *RedeemInviteRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_RedeemInviteRequest_Body.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RedeemInviteRequest_Body proto.InternalMessageInfo
+
+func (m *RedeemInviteRequest_Body) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
 	}
-	return interceptor(ctx, in, info, handler)
+	return nil
 }
 
-var _Service_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "net.pb.Service",
-	HandlerType: (*ServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "GetLogs",
-			Handler:    _Service_GetLogs_Handler,
-		},
-		{
-			MethodName: "PushLog",
-			Handler:    _Service_PushLog_Handler,
-		},
-		{
-			MethodName: "GetRecords",
-			Handler:    _Service_GetRecords_Handler,
-		},
-		{
-			MethodName: "PushRecord",
-			Handler:    _Service_PushRecord_Handler,
-		},
-		{
-			MethodName: "ExchangeEdges",
-			Handler:    _Service_ExchangeEdges_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "net.proto",
+// RedeemInviteReply is the response from a RedeemInviteRequest.
+type RedeemInviteReply struct {
+	// bundle is the thread key bundle of the invite.
+	Bundle []byte `protobuf:"bytes,1,opt,name=bundle,proto3" json:"bundle,omitempty"`
 }
 
-func (m *Log) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *RedeemInviteReply) Reset()         { *m = RedeemInviteReply{} }
+func (m *RedeemInviteReply) String() string { return proto.CompactTextString(m) }
+func (*RedeemInviteReply) ProtoMessage()    {}
+func (*RedeemInviteReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{16}
+}
+func (m *RedeemInviteReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RedeemInviteReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RedeemInviteReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *RedeemInviteReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RedeemInviteReply.Merge(m, src)
+}
+func (m *RedeemInviteReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *RedeemInviteReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RedeemInviteReply.DiscardUnknown(m)
 }
 
-func (m *Log) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_RedeemInviteReply proto.InternalMessageInfo
+
+func (m *RedeemInviteReply) GetBundle() []byte {
+	if m != nil {
+		return m.Bundle
+	}
+	return nil
 }
 
-func (m *Log) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Head != nil {
-		{
-			size := m.Head.Size()
-			i -= size
-			if _, err := m.Head.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+// GetRecordBodiesRequest is used to request bodies of records pulled without them.
+type GetRecordBodiesRequest struct {
+	// body is the message body.
+	Body *GetRecordBodiesRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (m *GetRecordBodiesRequest) Reset()         { *m = GetRecordBodiesRequest{} }
+func (m *GetRecordBodiesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRecordBodiesRequest) ProtoMessage()    {}
+func (*GetRecordBodiesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{17}
+}
+func (m *GetRecordBodiesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetRecordBodiesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetRecordBodiesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x22
+		return b[:n], nil
 	}
-	if len(m.Addrs) > 0 {
-		for iNdEx := len(m.Addrs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size := m.Addrs[iNdEx].Size()
-				i -= size
-				if _, err := m.Addrs[iNdEx].MarshalTo(dAtA[i:]); err != nil {
-					return 0, err
-				}
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
+}
+func (m *GetRecordBodiesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecordBodiesRequest.Merge(m, src)
+}
+func (m *GetRecordBodiesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetRecordBodiesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecordBodiesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRecordBodiesRequest proto.InternalMessageInfo
+
+func (m *GetRecordBodiesRequest) GetBody() *GetRecordBodiesRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	if m.PubKey != nil {
-		{
-			size := m.PubKey.Size()
-			i -= size
-			if _, err := m.PubKey.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+	return nil
+}
+
+type GetRecordBodiesRequest_Body struct {
+	// threadID is the target thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// serviceKey for the thread.
+	ServiceKey *ProtoKey `protobuf:"bytes,2,opt,name=serviceKey,proto3,customtype=ProtoKey" json:"serviceKey,omitempty"`
+	// bodies are the requested body node IDs.
+	Bodies []ProtoCid `protobuf:"bytes,3,rep,name=bodies,proto3,customtype=ProtoCid" json:"bodies,omitempty"`
+}
+
+func (m *GetRecordBodiesRequest_Body) Reset()         { *m = GetRecordBodiesRequest_Body{} }
+func (m *GetRecordBodiesRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*GetRecordBodiesRequest_Body) ProtoMessage()    {}
+func (*GetRecordBodiesRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{17, 0}
+}
+func (m *GetRecordBodiesRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetRecordBodiesRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetRecordBodiesRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	if m.ID != nil {
-		{
-			size := m.ID.Size()
-			i -= size
-			if _, err := m.ID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+}
+func (m *GetRecordBodiesRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecordBodiesRequest_Body.Merge(m, src)
+}
+func (m *GetRecordBodiesRequest_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetRecordBodiesRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecordBodiesRequest_Body.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRecordBodiesRequest_Body proto.InternalMessageInfo
+
+// GetRecordBodiesReply contains bodies requested with a GetRecordBodiesRequest.
+type GetRecordBodiesReply struct {
+	// bodies are the raw body nodes, in the requested order.
+	Bodies [][]byte `protobuf:"bytes,1,rep,name=bodies,proto3" json:"bodies,omitempty"`
+}
+
+func (m *GetRecordBodiesReply) Reset()         { *m = GetRecordBodiesReply{} }
+func (m *GetRecordBodiesReply) String() string { return proto.CompactTextString(m) }
+func (*GetRecordBodiesReply) ProtoMessage()    {}
+func (*GetRecordBodiesReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{18}
+}
+func (m *GetRecordBodiesReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetRecordBodiesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetRecordBodiesReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0xa
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *GetRecordBodiesReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecordBodiesReply.Merge(m, src)
+}
+func (m *GetRecordBodiesReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetRecordBodiesReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecordBodiesReply.DiscardUnknown(m)
 }
 
-func (m *Log_Record) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_GetRecordBodiesReply proto.InternalMessageInfo
+
+func (m *GetRecordBodiesReply) GetBodies() [][]byte {
+	if m != nil {
+		return m.Bodies
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *Log_Record) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// GetCapabilitiesRequest is used to request the optional features supported by a peer.
+type GetCapabilitiesRequest struct {
 }
 
-func (m *Log_Record) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.BodyNode) > 0 {
-		i -= len(m.BodyNode)
-		copy(dAtA[i:], m.BodyNode)
-		i = encodeVarintNet(dAtA, i, uint64(len(m.BodyNode)))
-		i--
-		dAtA[i] = 0x22
+func (m *GetCapabilitiesRequest) Reset()         { *m = GetCapabilitiesRequest{} }
+func (m *GetCapabilitiesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetCapabilitiesRequest) ProtoMessage()    {}
+func (*GetCapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{19}
+}
+func (m *GetCapabilitiesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetCapabilitiesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetCapabilitiesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if len(m.HeaderNode) > 0 {
-		i -= len(m.HeaderNode)
-		copy(dAtA[i:], m.HeaderNode)
-		i = encodeVarintNet(dAtA, i, uint64(len(m.HeaderNode)))
-		i--
-		dAtA[i] = 0x1a
+}
+func (m *GetCapabilitiesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCapabilitiesRequest.Merge(m, src)
+}
+func (m *GetCapabilitiesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetCapabilitiesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCapabilitiesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCapabilitiesRequest proto.InternalMessageInfo
+
+// GetCapabilitiesReply advertises the optional features supported by a peer.
+type GetCapabilitiesReply struct {
+	// relay is set if the peer stores and serves records of threads it can't read.
+	Relay *GetCapabilitiesReply_Relay `protobuf:"bytes,1,opt,name=relay,proto3" json:"relay,omitempty"`
+	// compression lists the codecs of compressed messages the peer reads.
+	Compression []string `protobuf:"bytes,2,rep,name=compression,proto3" json:"compression,omitempty"`
+	// bodyCompression lists the codecs of compressed record bodies the peer reads.
+	BodyCompression []string `protobuf:"bytes,3,rep,name=bodyCompression,proto3" json:"bodyCompression,omitempty"`
+}
+
+func (m *GetCapabilitiesReply) Reset()         { *m = GetCapabilitiesReply{} }
+func (m *GetCapabilitiesReply) String() string { return proto.CompactTextString(m) }
+func (*GetCapabilitiesReply) ProtoMessage()    {}
+func (*GetCapabilitiesReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{20}
+}
+func (m *GetCapabilitiesReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetCapabilitiesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetCapabilitiesReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if len(m.EventNode) > 0 {
-		i -= len(m.EventNode)
-		copy(dAtA[i:], m.EventNode)
-		i = encodeVarintNet(dAtA, i, uint64(len(m.EventNode)))
-		i--
-		dAtA[i] = 0x12
+}
+func (m *GetCapabilitiesReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCapabilitiesReply.Merge(m, src)
+}
+func (m *GetCapabilitiesReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetCapabilitiesReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCapabilitiesReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCapabilitiesReply proto.InternalMessageInfo
+
+func (m *GetCapabilitiesReply) GetRelay() *GetCapabilitiesReply_Relay {
+	if m != nil {
+		return m.Relay
 	}
-	if len(m.RecordNode) > 0 {
-		i -= len(m.RecordNode)
-		copy(dAtA[i:], m.RecordNode)
-		i = encodeVarintNet(dAtA, i, uint64(len(m.RecordNode)))
-		i--
-		dAtA[i] = 0xa
+	return nil
+}
+
+func (m *GetCapabilitiesReply) GetCompression() []string {
+	if m != nil {
+		return m.Compression
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *GetLogsRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *GetCapabilitiesReply) GetBodyCompression() []string {
+	if m != nil {
+		return m.BodyCompression
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *GetLogsRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type GetCapabilitiesReply_Relay struct {
+	// maxThreads is the number of threads the peer relays at most, zero if unlimited.
+	MaxThreads int64 `protobuf:"varint,1,opt,name=maxThreads,proto3" json:"maxThreads,omitempty"`
+	// maxThreadBytes is the storage quota of a relayed thread, zero if unlimited.
+	MaxThreadBytes int64 `protobuf:"varint,2,opt,name=maxThreadBytes,proto3" json:"maxThreadBytes,omitempty"`
+	// retention is the time in seconds a relayed thread is kept without updates, zero if forever.
+	Retention int64 `protobuf:"varint,3,opt,name=retention,proto3" json:"retention,omitempty"`
 }
 
-func (m *GetLogsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Body != nil {
-		{
-			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *GetCapabilitiesReply_Relay) Reset()         { *m = GetCapabilitiesReply_Relay{} }
+func (m *GetCapabilitiesReply_Relay) String() string { return proto.CompactTextString(m) }
+func (*GetCapabilitiesReply_Relay) ProtoMessage()    {}
+func (*GetCapabilitiesReply_Relay) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{20, 0}
+}
+func (m *GetCapabilitiesReply_Relay) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetCapabilitiesReply_Relay) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetCapabilitiesReply_Relay.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *GetCapabilitiesReply_Relay) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCapabilitiesReply_Relay.Merge(m, src)
+}
+func (m *GetCapabilitiesReply_Relay) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetCapabilitiesReply_Relay) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCapabilitiesReply_Relay.DiscardUnknown(m)
 }
 
-func (m *GetLogsRequest_Body) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_GetCapabilitiesReply_Relay proto.InternalMessageInfo
+
+func (m *GetCapabilitiesReply_Relay) GetMaxThreads() int64 {
+	if m != nil {
+		return m.MaxThreads
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *GetLogsRequest_Body) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *GetCapabilitiesReply_Relay) GetMaxThreadBytes() int64 {
+	if m != nil {
+		return m.MaxThreadBytes
+	}
+	return 0
 }
 
-func (m *GetLogsRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.ServiceKey != nil {
-		{
-			size := m.ServiceKey.Size()
-			i -= size
-			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
-	}
-	if m.ThreadID != nil {
-		{
-			size := m.ThreadID.Size()
-			i -= size
-			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+func (m *GetCapabilitiesReply_Relay) GetRetention() int64 {
+	if m != nil {
+		return m.Retention
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func (m *GetLogsReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+// SubscribeRequest opens or updates a subscription to new records of threads.
+// Every request sent on the stream replaces the filters of the subscription.
+type SubscribeRequest struct {
+	// body is the message body.
+	Body *SubscribeRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
 }
 
-func (m *GetLogsReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *SubscribeRequest) Reset()         { *m = SubscribeRequest{} }
+func (m *SubscribeRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeRequest) ProtoMessage()    {}
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{21}
 }
-
-func (m *GetLogsReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+func (m *SubscribeRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubscribeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubscribeRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *SubscribeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeRequest.Merge(m, src)
+}
+func (m *SubscribeRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubscribeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeRequest.DiscardUnknown(m)
 }
 
-func (m *PushLogRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_SubscribeRequest proto.InternalMessageInfo
+
+func (m *SubscribeRequest) GetBody() *SubscribeRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *PushLogRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type SubscribeRequest_Body struct {
+	// filters are the subscribed threads.
+	Filters []*SubscribeRequest_Body_Filter `protobuf:"bytes,1,rep,name=filters,proto3" json:"filters,omitempty"`
+	// token authorizes the subscription to the threads.
+	Token string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
 }
 
-func (m *PushLogRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Body != nil {
-		{
-			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *SubscribeRequest_Body) Reset()         { *m = SubscribeRequest_Body{} }
+func (m *SubscribeRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*SubscribeRequest_Body) ProtoMessage()    {}
+func (*SubscribeRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{21, 0}
+}
+func (m *SubscribeRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubscribeRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubscribeRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *SubscribeRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeRequest_Body.Merge(m, src)
+}
+func (m *SubscribeRequest_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubscribeRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeRequest_Body.DiscardUnknown(m)
 }
 
-func (m *PushLogRequest_Body) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_SubscribeRequest_Body proto.InternalMessageInfo
+
+func (m *SubscribeRequest_Body) GetFilters() []*SubscribeRequest_Body_Filter {
+	if m != nil {
+		return m.Filters
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *PushLogRequest_Body) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *SubscribeRequest_Body) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
 }
 
-func (m *PushLogRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Log != nil {
-		{
-			size, err := m.Log.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x22
-	}
-	if m.ReadKey != nil {
-		{
-			size := m.ReadKey.Size()
-			i -= size
-			if _, err := m.ReadKey.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1a
-	}
-	if m.ServiceKey != nil {
-		{
-			size := m.ServiceKey.Size()
-			i -= size
-			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
-	}
-	if m.ThreadID != nil {
-		{
-			size := m.ThreadID.Size()
-			i -= size
-			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
+// Filter represents a single thread.
+type SubscribeRequest_Body_Filter struct {
+	// threadID is the subscribed thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// serviceKey for the thread.
+	ServiceKey *ProtoKey `protobuf:"bytes,2,opt,name=serviceKey,proto3,customtype=ProtoKey" json:"serviceKey,omitempty"`
+	// logIDs restrict the subscription to the given logs, all logs if empty.
+	LogIDs []ProtoPeerID `protobuf:"bytes,3,rep,name=logIDs,proto3,customtype=ProtoPeerID" json:"logIDs,omitempty"`
 }
 
-func (m *PushLogReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *SubscribeRequest_Body_Filter) Reset()         { *m = SubscribeRequest_Body_Filter{} }
+func (m *SubscribeRequest_Body_Filter) String() string { return proto.CompactTextString(m) }
+func (*SubscribeRequest_Body_Filter) ProtoMessage()    {}
+func (*SubscribeRequest_Body_Filter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{21, 0, 0}
+}
+func (m *SubscribeRequest_Body_Filter) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubscribeRequest_Body_Filter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubscribeRequest_Body_Filter.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *SubscribeRequest_Body_Filter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeRequest_Body_Filter.Merge(m, src)
+}
+func (m *SubscribeRequest_Body_Filter) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubscribeRequest_Body_Filter) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeRequest_Body_Filter.DiscardUnknown(m)
 }
 
-func (m *PushLogReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_SubscribeRequest_Body_Filter proto.InternalMessageInfo
+
+// SubscribeReply contains a new record of a subscribed thread.
+type SubscribeReply struct {
+	// threadID is the record's thread ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// logID is the record's log ID.
+	LogID *ProtoPeerID `protobuf:"bytes,2,opt,name=logID,proto3,customtype=ProtoPeerID" json:"logID,omitempty"`
+	// record is the actual record payload.
+	Record *Log_Record `protobuf:"bytes,3,opt,name=record,proto3" json:"record,omitempty"`
 }
 
-func (m *PushLogReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	return len(dAtA) - i, nil
+func (m *SubscribeReply) Reset()         { *m = SubscribeReply{} }
+func (m *SubscribeReply) String() string { return proto.CompactTextString(m) }
+func (*SubscribeReply) ProtoMessage()    {}
+func (*SubscribeReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{22}
+}
+func (m *SubscribeReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubscribeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubscribeReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SubscribeReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeReply.Merge(m, src)
+}
+func (m *SubscribeReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubscribeReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeReply.DiscardUnknown(m)
 }
 
-func (m *GetRecordsRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_SubscribeReply proto.InternalMessageInfo
+
+func (m *SubscribeReply) GetRecord() *Log_Record {
+	if m != nil {
+		return m.Record
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *GetRecordsRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// HandoffLogRequest is used to transfer the write ownership of a log to the receiving peer.
+type HandoffLogRequest struct {
+	// body is the message body.
+	Body *HandoffLogRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
 }
 
-func (m *GetRecordsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Body != nil {
-		{
-			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *HandoffLogRequest) Reset()         { *m = HandoffLogRequest{} }
+func (m *HandoffLogRequest) String() string { return proto.CompactTextString(m) }
+func (*HandoffLogRequest) ProtoMessage()    {}
+func (*HandoffLogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{23}
+}
+func (m *HandoffLogRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HandoffLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HandoffLogRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *HandoffLogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandoffLogRequest.Merge(m, src)
+}
+func (m *HandoffLogRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *HandoffLogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandoffLogRequest.DiscardUnknown(m)
 }
 
-func (m *GetRecordsRequest_Body) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_HandoffLogRequest proto.InternalMessageInfo
+
+func (m *HandoffLogRequest) GetBody() *HandoffLogRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *GetRecordsRequest_Body) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type HandoffLogRequest_Body struct {
+	// threadID is the target thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// serviceKey for the thread.
+	ServiceKey *ProtoKey `protobuf:"bytes,2,opt,name=serviceKey,proto3,customtype=ProtoKey" json:"serviceKey,omitempty"`
+	// logID is the handed off log's ID.
+	LogID *ProtoPeerID `protobuf:"bytes,3,opt,name=logID,proto3,customtype=ProtoPeerID" json:"logID,omitempty"`
+	// head is the handoff record of the previous owner, new records must follow it.
+	Head *ProtoCid `protobuf:"bytes,4,opt,name=head,proto3,customtype=ProtoCid" json:"head,omitempty"`
+	// key is the log private key encrypted for the receiving peer.
+	Key []byte `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	// sig is the log key's signature of the handoff, sealing the heads.
+	Sig []byte `protobuf:"bytes,6,opt,name=sig,proto3" json:"sig,omitempty"`
+	// heads are the heads of the log at the handoff, including head.
+	Heads []ProtoCid `protobuf:"bytes,7,rep,name=heads,proto3,customtype=ProtoCid" json:"heads,omitempty"`
 }
 
-func (m *GetRecordsRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
-	}
-	if m.ServiceKey != nil {
-		{
-			size := m.ServiceKey.Size()
-			i -= size
-			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *HandoffLogRequest_Body) Reset()         { *m = HandoffLogRequest_Body{} }
+func (m *HandoffLogRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*HandoffLogRequest_Body) ProtoMessage()    {}
+func (*HandoffLogRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{23, 0}
+}
+func (m *HandoffLogRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HandoffLogRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HandoffLogRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	if m.ThreadID != nil {
-		{
-			size := m.ThreadID.Size()
-			i -= size
-			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+}
+func (m *HandoffLogRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandoffLogRequest_Body.Merge(m, src)
+}
+func (m *HandoffLogRequest_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *HandoffLogRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandoffLogRequest_Body.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandoffLogRequest_Body proto.InternalMessageInfo
+
+func (m *HandoffLogRequest_Body) GetKey() []byte {
+	if m != nil {
+		return m.Key
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *GetRecordsRequest_Body_LogEntry) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *HandoffLogRequest_Body) GetSig() []byte {
+	if m != nil {
+		return m.Sig
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *GetRecordsRequest_Body_LogEntry) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// HandoffLogReply is a response to HandoffLogRequest.
+type HandoffLogReply struct {
 }
 
-func (m *GetRecordsRequest_Body_LogEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Limit != 0 {
-		i = encodeVarintNet(dAtA, i, uint64(m.Limit))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.Offset != nil {
-		{
-			size := m.Offset.Size()
-			i -= size
-			if _, err := m.Offset.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *HandoffLogReply) Reset()         { *m = HandoffLogReply{} }
+func (m *HandoffLogReply) String() string { return proto.CompactTextString(m) }
+func (*HandoffLogReply) ProtoMessage()    {}
+func (*HandoffLogReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{24}
+}
+func (m *HandoffLogReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HandoffLogReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HandoffLogReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	if m.LogID != nil {
-		{
-			size := m.LogID.Size()
-			i -= size
-			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+}
+func (m *HandoffLogReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandoffLogReply.Merge(m, src)
+}
+func (m *HandoffLogReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *HandoffLogReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandoffLogReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandoffLogReply proto.InternalMessageInfo
+
+// PutKeyShareRequest is used to escrow a share of a thread key with the receiving peer.
+type PutKeyShareRequest struct {
+	// body is the message body.
+	Body *PutKeyShareRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (m *PutKeyShareRequest) Reset()         { *m = PutKeyShareRequest{} }
+func (m *PutKeyShareRequest) String() string { return proto.CompactTextString(m) }
+func (*PutKeyShareRequest) ProtoMessage()    {}
+func (*PutKeyShareRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{25}
+}
+func (m *PutKeyShareRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PutKeyShareRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PutKeyShareRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0xa
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *PutKeyShareRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutKeyShareRequest.Merge(m, src)
+}
+func (m *PutKeyShareRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PutKeyShareRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutKeyShareRequest.DiscardUnknown(m)
 }
 
-func (m *GetRecordsReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_PutKeyShareRequest proto.InternalMessageInfo
+
+func (m *PutKeyShareRequest) GetBody() *PutKeyShareRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *GetRecordsReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type PutKeyShareRequest_Body struct {
+	// threadID is the thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// share is a Shamir share of the thread key.
+	Share []byte `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	// threshold is the number of shares needed to recover the key.
+	Threshold int32 `protobuf:"varint,3,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// keyHash is the hash of the thread key, verifying the recovered key.
+	KeyHash []byte `protobuf:"bytes,4,opt,name=keyHash,proto3" json:"keyHash,omitempty"`
+	// recoveryKey is the public key of the recovery identity the share is escrowed for.
+	RecoveryKey []byte `protobuf:"bytes,5,opt,name=recoveryKey,proto3" json:"recoveryKey,omitempty"`
+	// sig is the recovery identity signature over the thread ID, receiving peer ID and share.
+	Sig []byte `protobuf:"bytes,6,opt,name=sig,proto3" json:"sig,omitempty"`
+}
+
+func (m *PutKeyShareRequest_Body) Reset()         { *m = PutKeyShareRequest_Body{} }
+func (m *PutKeyShareRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*PutKeyShareRequest_Body) ProtoMessage()    {}
+func (*PutKeyShareRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{25, 0}
+}
+func (m *PutKeyShareRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-func (m *GetRecordsReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+func (m *PutKeyShareRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PutKeyShareRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *PutKeyShareRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutKeyShareRequest_Body.Merge(m, src)
+}
+func (m *PutKeyShareRequest_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *PutKeyShareRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutKeyShareRequest_Body.DiscardUnknown(m)
 }
 
-func (m *GetRecordsReply_LogEntry) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_PutKeyShareRequest_Body proto.InternalMessageInfo
+
+func (m *PutKeyShareRequest_Body) GetShare() []byte {
+	if m != nil {
+		return m.Share
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *GetRecordsReply_LogEntry) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *PutKeyShareRequest_Body) GetThreshold() int32 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
 }
 
-func (m *GetRecordsReply_LogEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Log != nil {
-		{
-			size, err := m.Log.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1a
-	}
-	if len(m.Records) > 0 {
-		for iNdEx := len(m.Records) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Records[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
-		}
+func (m *PutKeyShareRequest_Body) GetKeyHash() []byte {
+	if m != nil {
+		return m.KeyHash
 	}
-	if m.LogID != nil {
-		{
-			size := m.LogID.Size()
-			i -= size
-			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	return nil
+}
+
+func (m *PutKeyShareRequest_Body) GetRecoveryKey() []byte {
+	if m != nil {
+		return m.RecoveryKey
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *PushRecordRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *PutKeyShareRequest_Body) GetSig() []byte {
+	if m != nil {
+		return m.Sig
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *PushRecordRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// PutKeyShareReply is a response to PutKeyShareRequest.
+type PutKeyShareReply struct {
 }
 
-func (m *PushRecordRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Body != nil {
-		{
-			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *PutKeyShareReply) Reset()         { *m = PutKeyShareReply{} }
+func (m *PutKeyShareReply) String() string { return proto.CompactTextString(m) }
+func (*PutKeyShareReply) ProtoMessage()    {}
+func (*PutKeyShareReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{26}
+}
+func (m *PutKeyShareReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PutKeyShareReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PutKeyShareReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x12
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
 }
-
-func (m *PushRecordRequest_Body) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+func (m *PutKeyShareReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutKeyShareReply.Merge(m, src)
+}
+func (m *PutKeyShareReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *PutKeyShareReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutKeyShareReply.DiscardUnknown(m)
 }
 
-func (m *PushRecordRequest_Body) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_PutKeyShareReply proto.InternalMessageInfo
+
+// GetKeyShareRequest is used to get back a thread key share escrowed with the receiving peer.
+type GetKeyShareRequest struct {
+	// body is the message body.
+	Body *GetKeyShareRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
 }
 
-func (m *PushRecordRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Record != nil {
-		{
-			size, err := m.Record.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
+func (m *GetKeyShareRequest) Reset()         { *m = GetKeyShareRequest{} }
+func (m *GetKeyShareRequest) String() string { return proto.CompactTextString(m) }
+func (*GetKeyShareRequest) ProtoMessage()    {}
+func (*GetKeyShareRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{27}
+}
+func (m *GetKeyShareRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetKeyShareRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetKeyShareRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x1a
+		return b[:n], nil
 	}
-	if m.LogID != nil {
-		{
-			size := m.LogID.Size()
-			i -= size
-			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
+}
+func (m *GetKeyShareRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetKeyShareRequest.Merge(m, src)
+}
+func (m *GetKeyShareRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetKeyShareRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetKeyShareRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetKeyShareRequest proto.InternalMessageInfo
+
+func (m *GetKeyShareRequest) GetBody() *GetKeyShareRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	if m.ThreadID != nil {
-		{
-			size := m.ThreadID.Size()
-			i -= size
-			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
+	return nil
+}
+
+type GetKeyShareRequest_Body struct {
+	// threadID is the thread's ID.
+	ThreadID *ProtoThreadID `protobuf:"bytes,1,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// recoveryKey is the public key of the recovery identity the share was escrowed for.
+	RecoveryKey []byte `protobuf:"bytes,2,opt,name=recoveryKey,proto3" json:"recoveryKey,omitempty"`
+	// sig is the recovery identity signature over the thread ID and receiving peer ID.
+	Sig []byte `protobuf:"bytes,3,opt,name=sig,proto3" json:"sig,omitempty"`
+}
+
+func (m *GetKeyShareRequest_Body) Reset()         { *m = GetKeyShareRequest_Body{} }
+func (m *GetKeyShareRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*GetKeyShareRequest_Body) ProtoMessage()    {}
+func (*GetKeyShareRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{27, 0}
+}
+func (m *GetKeyShareRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetKeyShareRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetKeyShareRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0xa
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *GetKeyShareRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetKeyShareRequest_Body.Merge(m, src)
+}
+func (m *GetKeyShareRequest_Body) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetKeyShareRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetKeyShareRequest_Body.DiscardUnknown(m)
 }
 
-func (m *PushRecordReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_GetKeyShareRequest_Body proto.InternalMessageInfo
+
+func (m *GetKeyShareRequest_Body) GetRecoveryKey() []byte {
+	if m != nil {
+		return m.RecoveryKey
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *PushRecordReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *GetKeyShareRequest_Body) GetSig() []byte {
+	if m != nil {
+		return m.Sig
+	}
+	return nil
 }
 
-func (m *PushRecordReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	return len(dAtA) - i, nil
+// GetKeyShareReply is a response to GetKeyShareRequest.
+type GetKeyShareReply struct {
+	// share is the escrowed share of the thread key.
+	Share []byte `protobuf:"bytes,1,opt,name=share,proto3" json:"share,omitempty"`
+	// threshold is the number of shares needed to recover the key.
+	Threshold int32 `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// keyHash is the hash of the thread key, verifying the recovered key.
+	KeyHash []byte `protobuf:"bytes,3,opt,name=keyHash,proto3" json:"keyHash,omitempty"`
 }
 
-func (m *ExchangeEdgesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *GetKeyShareReply) Reset()         { *m = GetKeyShareReply{} }
+func (m *GetKeyShareReply) String() string { return proto.CompactTextString(m) }
+func (*GetKeyShareReply) ProtoMessage()    {}
+func (*GetKeyShareReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{28}
+}
+func (m *GetKeyShareReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetKeyShareReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetKeyShareReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *GetKeyShareReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetKeyShareReply.Merge(m, src)
+}
+func (m *GetKeyShareReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetKeyShareReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetKeyShareReply.DiscardUnknown(m)
 }
 
-func (m *ExchangeEdgesRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_GetKeyShareReply proto.InternalMessageInfo
+
+func (m *GetKeyShareReply) GetShare() []byte {
+	if m != nil {
+		return m.Share
+	}
+	return nil
 }
 
-func (m *ExchangeEdgesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Body != nil {
-		{
-			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
+func (m *GetKeyShareReply) GetThreshold() int32 {
+	if m != nil {
+		return m.Threshold
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func (m *ExchangeEdgesRequest_Body) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *GetKeyShareReply) GetKeyHash() []byte {
+	if m != nil {
+		return m.KeyHash
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *ExchangeEdgesRequest_Body) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// PushRevocationRequest is used to propagate a revoked identity of a thread to the receiving peer.
+type PushRevocationRequest struct {
+	// body is the message body.
+	Body *PushRevocationRequest_Body `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+	// sig is a signature of the body by the writer log of the thread.
+	Sig []byte `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
 }
 
-func (m *ExchangeEdgesRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Threads) > 0 {
-		for iNdEx := len(m.Threads) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Threads[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+func (m *PushRevocationRequest) Reset()         { *m = PushRevocationRequest{} }
+func (m *PushRevocationRequest) String() string { return proto.CompactTextString(m) }
+func (*PushRevocationRequest) ProtoMessage()    {}
+func (*PushRevocationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{29}
+}
+func (m *PushRevocationRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushRevocationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushRevocationRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
 }
-
-func (m *ExchangeEdgesRequest_Body_ThreadEntry) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+func (m *PushRevocationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRevocationRequest.Merge(m, src)
 }
-
-func (m *ExchangeEdgesRequest_Body_ThreadEntry) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *PushRevocationRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PushRevocationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRevocationRequest.DiscardUnknown(m)
 }
 
-func (m *ExchangeEdgesRequest_Body_ThreadEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.HeadsEdge != 0 {
-		i = encodeVarintNet(dAtA, i, uint64(m.HeadsEdge))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.AddressEdge != 0 {
-		i = encodeVarintNet(dAtA, i, uint64(m.AddressEdge))
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.ThreadID != nil {
-		{
-			size := m.ThreadID.Size()
-			i -= size
-			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+var xxx_messageInfo_PushRevocationRequest proto.InternalMessageInfo
+
+func (m *PushRevocationRequest) GetBody() *PushRevocationRequest_Body {
+	if m != nil {
+		return m.Body
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *ExchangeEdgesReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *PushRevocationRequest) GetSig() []byte {
+	if m != nil {
+		return m.Sig
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *ExchangeEdgesReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type PushRevocationRequest_Body struct {
+	// identity is the marshaled public key of the revoked identity.
+	Identity []byte `protobuf:"bytes,1,opt,name=identity,proto3" json:"identity,omitempty"`
+	// revokedAt is the unix time of the revocation, in nanoseconds.
+	RevokedAt int64 `protobuf:"varint,2,opt,name=revokedAt,proto3" json:"revokedAt,omitempty"`
+	// threadID is the ID of the thread the revocation applies to.
+	ThreadID *ProtoThreadID `protobuf:"bytes,3,opt,name=threadID,proto3,customtype=ProtoThreadID" json:"threadID,omitempty"`
+	// expiresAt is the unix time the revocation expires in nanoseconds, zero if it never expires.
+	ExpiresAt int64 `protobuf:"varint,4,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	// restored marks the revocation as lifted.
+	Restored bool `protobuf:"varint,5,opt,name=restored,proto3" json:"restored,omitempty"`
 }
 
-func (m *ExchangeEdgesReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Edges) > 0 {
-		for iNdEx := len(m.Edges) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Edges[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintNet(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+func (m *PushRevocationRequest_Body) Reset()         { *m = PushRevocationRequest_Body{} }
+func (m *PushRevocationRequest_Body) String() string { return proto.CompactTextString(m) }
+func (*PushRevocationRequest_Body) ProtoMessage()    {}
+func (*PushRevocationRequest_Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{29, 0}
+}
+func (m *PushRevocationRequest_Body) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushRevocationRequest_Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushRevocationRequest_Body.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
 }
-
-func (m *ExchangeEdgesReply_ThreadEdges) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+func (m *PushRevocationRequest_Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRevocationRequest_Body.Merge(m, src)
 }
-
-func (m *ExchangeEdgesReply_ThreadEdges) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *PushRevocationRequest_Body) XXX_Size() int {
+	return m.Size()
 }
-
-func (m *ExchangeEdgesReply_ThreadEdges) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.HeadsEdge != 0 {
-		i = encodeVarintNet(dAtA, i, uint64(m.HeadsEdge))
-		i--
-		dAtA[i] = 0x20
-	}
-	if m.AddressEdge != 0 {
-		i = encodeVarintNet(dAtA, i, uint64(m.AddressEdge))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.Exists {
-		i--
-		if m.Exists {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.ThreadID != nil {
-		{
-			size := m.ThreadID.Size()
-			i -= size
-			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-			i = encodeVarintNet(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
+func (m *PushRevocationRequest_Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRevocationRequest_Body.DiscardUnknown(m)
 }
 
-func encodeVarintNet(dAtA []byte, offset int, v uint64) int {
-	offset -= sovNet(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+var xxx_messageInfo_PushRevocationRequest_Body proto.InternalMessageInfo
+
+func (m *PushRevocationRequest_Body) GetIdentity() []byte {
+	if m != nil {
+		return m.Identity
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return nil
 }
-func NewPopulatedLog(r randyNet, easy bool) *Log {
-	this := &Log{}
-	this.ID = NewPopulatedProtoPeerID(r)
-	this.PubKey = NewPopulatedProtoPubKey(r)
-	v1 := r.Intn(10)
-	this.Addrs = make([]ProtoAddr, v1)
-	for i := 0; i < v1; i++ {
-		v2 := NewPopulatedProtoAddr(r)
-		this.Addrs[i] = *v2
-	}
-	this.Head = NewPopulatedProtoCid(r)
-	if !easy && r.Intn(10) != 0 {
+
+func (m *PushRevocationRequest_Body) GetRevokedAt() int64 {
+	if m != nil {
+		return m.RevokedAt
 	}
-	return this
+	return 0
 }
 
-func NewPopulatedLog_Record(r randyNet, easy bool) *Log_Record {
-	this := &Log_Record{}
-	v3 := r.Intn(100)
-	this.RecordNode = make([]byte, v3)
-	for i := 0; i < v3; i++ {
-		this.RecordNode[i] = byte(r.Intn(256))
-	}
-	v4 := r.Intn(100)
-	this.EventNode = make([]byte, v4)
-	for i := 0; i < v4; i++ {
-		this.EventNode[i] = byte(r.Intn(256))
-	}
-	v5 := r.Intn(100)
-	this.HeaderNode = make([]byte, v5)
-	for i := 0; i < v5; i++ {
-		this.HeaderNode[i] = byte(r.Intn(256))
-	}
-	v6 := r.Intn(100)
-	this.BodyNode = make([]byte, v6)
-	for i := 0; i < v6; i++ {
-		this.BodyNode[i] = byte(r.Intn(256))
-	}
-	if !easy && r.Intn(10) != 0 {
+func (m *PushRevocationRequest_Body) GetExpiresAt() int64 {
+	if m != nil {
+		return m.ExpiresAt
 	}
-	return this
+	return 0
 }
 
-func NewPopulatedGetLogsRequest(r randyNet, easy bool) *GetLogsRequest {
-	this := &GetLogsRequest{}
-	if r.Intn(5) != 0 {
-		this.Body = NewPopulatedGetLogsRequest_Body(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
+func (m *PushRevocationRequest_Body) GetRestored() bool {
+	if m != nil {
+		return m.Restored
 	}
-	return this
+	return false
 }
 
-func NewPopulatedGetLogsRequest_Body(r randyNet, easy bool) *GetLogsRequest_Body {
-	this := &GetLogsRequest_Body{}
-	this.ThreadID = NewPopulatedProtoThreadID(r)
-	this.ServiceKey = NewPopulatedProtoKey(r)
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+// PushRevocationReply is a response to PushRevocationRequest.
+type PushRevocationReply struct {
 }
 
-func NewPopulatedGetLogsReply(r randyNet, easy bool) *GetLogsReply {
-	this := &GetLogsReply{}
-	if r.Intn(5) != 0 {
-		v7 := r.Intn(5)
-		this.Logs = make([]*Log, v7)
-		for i := 0; i < v7; i++ {
-			this.Logs[i] = NewPopulatedLog(r, easy)
+func (m *PushRevocationReply) Reset()         { *m = PushRevocationReply{} }
+func (m *PushRevocationReply) String() string { return proto.CompactTextString(m) }
+func (*PushRevocationReply) ProtoMessage()    {}
+func (*PushRevocationReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{30}
+}
+func (m *PushRevocationReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushRevocationReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushRevocationReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
 }
-
-func NewPopulatedPushLogRequest(r randyNet, easy bool) *PushLogRequest {
-	this := &PushLogRequest{}
-	if r.Intn(5) != 0 {
-		this.Body = NewPopulatedPushLogRequest_Body(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+func (m *PushRevocationReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRevocationReply.Merge(m, src)
 }
-
-func NewPopulatedPushLogRequest_Body(r randyNet, easy bool) *PushLogRequest_Body {
-	this := &PushLogRequest_Body{}
-	this.ThreadID = NewPopulatedProtoThreadID(r)
-	this.ServiceKey = NewPopulatedProtoKey(r)
-	this.ReadKey = NewPopulatedProtoKey(r)
-	if r.Intn(5) != 0 {
-		this.Log = NewPopulatedLog(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+func (m *PushRevocationReply) XXX_Size() int {
+	return m.Size()
 }
-
-func NewPopulatedPushLogReply(r randyNet, easy bool) *PushLogReply {
-	this := &PushLogReply{}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+func (m *PushRevocationReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRevocationReply.DiscardUnknown(m)
 }
 
-func NewPopulatedGetRecordsRequest(r randyNet, easy bool) *GetRecordsRequest {
-	this := &GetRecordsRequest{}
-	if r.Intn(5) != 0 {
-		this.Body = NewPopulatedGetRecordsRequest_Body(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+var xxx_messageInfo_PushRevocationReply proto.InternalMessageInfo
+
+// LogSeq is the sequence number of a log, which only grows as records are appended.
+type LogSeq struct {
+	// logID is the log's ID.
+	LogID *ProtoPeerID `protobuf:"bytes,1,opt,name=logID,proto3,customtype=ProtoPeerID" json:"logID,omitempty"`
+	// seq is the number of records appended to the log on the peer.
+	Seq uint64 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
 }
 
-func NewPopulatedGetRecordsRequest_Body(r randyNet, easy bool) *GetRecordsRequest_Body {
-	this := &GetRecordsRequest_Body{}
-	this.ThreadID = NewPopulatedProtoThreadID(r)
-	this.ServiceKey = NewPopulatedProtoKey(r)
-	if r.Intn(5) != 0 {
-		v8 := r.Intn(5)
-		this.Logs = make([]*GetRecordsRequest_Body_LogEntry, v8)
-		for i := 0; i < v8; i++ {
-			this.Logs[i] = NewPopulatedGetRecordsRequest_Body_LogEntry(r, easy)
+func (m *LogSeq) Reset()         { *m = LogSeq{} }
+func (m *LogSeq) String() string { return proto.CompactTextString(m) }
+func (*LogSeq) ProtoMessage()    {}
+func (*LogSeq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{31}
+}
+func (m *LogSeq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LogSeq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LogSeq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+}
+func (m *LogSeq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogSeq.Merge(m, src)
+}
+func (m *LogSeq) XXX_Size() int {
+	return m.Size()
+}
+func (m *LogSeq) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogSeq.DiscardUnknown(m)
 }
 
-func NewPopulatedGetRecordsRequest_Body_LogEntry(r randyNet, easy bool) *GetRecordsRequest_Body_LogEntry {
-	this := &GetRecordsRequest_Body_LogEntry{}
-	this.LogID = NewPopulatedProtoPeerID(r)
-	this.Offset = NewPopulatedProtoCid(r)
-	this.Limit = int32(r.Int31())
-	if r.Intn(2) == 0 {
-		this.Limit *= -1
-	}
-	if !easy && r.Intn(10) != 0 {
+var xxx_messageInfo_LogSeq proto.InternalMessageInfo
+
+func (m *LogSeq) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
 	}
-	return this
+	return 0
 }
 
-func NewPopulatedGetRecordsReply(r randyNet, easy bool) *GetRecordsReply {
-	this := &GetRecordsReply{}
-	if r.Intn(5) != 0 {
-		v9 := r.Intn(5)
-		this.Logs = make([]*GetRecordsReply_LogEntry, v9)
-		for i := 0; i < v9; i++ {
-			this.Logs[i] = NewPopulatedGetRecordsReply_LogEntry(r, easy)
-		}
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+// HelloRequest is used to negotiate the protocol version and features with a peer.
+type HelloRequest struct {
+	// version is the protocol version of the requester.
+	Version int32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	// features lists the optional protocol features the requester supports.
+	Features []string `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
 }
 
-func NewPopulatedGetRecordsReply_LogEntry(r randyNet, easy bool) *GetRecordsReply_LogEntry {
-	this := &GetRecordsReply_LogEntry{}
-	this.LogID = NewPopulatedProtoPeerID(r)
-	if r.Intn(5) != 0 {
-		v10 := r.Intn(5)
-		this.Records = make([]*Log_Record, v10)
-		for i := 0; i < v10; i++ {
-			this.Records[i] = NewPopulatedLog_Record(r, easy)
+func (m *HelloRequest) Reset()         { *m = HelloRequest{} }
+func (m *HelloRequest) String() string { return proto.CompactTextString(m) }
+func (*HelloRequest) ProtoMessage()    {}
+func (*HelloRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{32}
+}
+func (m *HelloRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HelloRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HelloRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if r.Intn(5) != 0 {
-		this.Log = NewPopulatedLog(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
 }
-
-func NewPopulatedPushRecordRequest(r randyNet, easy bool) *PushRecordRequest {
-	this := &PushRecordRequest{}
-	if r.Intn(5) != 0 {
-		this.Body = NewPopulatedPushRecordRequest_Body(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+func (m *HelloRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HelloRequest.Merge(m, src)
+}
+func (m *HelloRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *HelloRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HelloRequest.DiscardUnknown(m)
 }
 
-func NewPopulatedPushRecordRequest_Body(r randyNet, easy bool) *PushRecordRequest_Body {
-	this := &PushRecordRequest_Body{}
-	this.ThreadID = NewPopulatedProtoThreadID(r)
-	this.LogID = NewPopulatedProtoPeerID(r)
-	if r.Intn(5) != 0 {
-		this.Record = NewPopulatedLog_Record(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
+var xxx_messageInfo_HelloRequest proto.InternalMessageInfo
+
+func (m *HelloRequest) GetVersion() int32 {
+	if m != nil {
+		return m.Version
 	}
-	return this
+	return 0
 }
 
-func NewPopulatedPushRecordReply(r randyNet, easy bool) *PushRecordReply {
-	this := &PushRecordReply{}
-	if !easy && r.Intn(10) != 0 {
+func (m *HelloRequest) GetFeatures() []string {
+	if m != nil {
+		return m.Features
 	}
-	return this
+	return nil
 }
 
-func NewPopulatedExchangeEdgesRequest(r randyNet, easy bool) *ExchangeEdgesRequest {
-	this := &ExchangeEdgesRequest{}
-	if r.Intn(5) != 0 {
-		this.Body = NewPopulatedExchangeEdgesRequest_Body(r, easy)
-	}
-	if !easy && r.Intn(10) != 0 {
-	}
-	return this
+// HelloReply describes the protocol version and features of the receiving peer.
+type HelloReply struct {
+	// version is the protocol version of the peer.
+	Version int32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	// features lists the optional protocol features the peer supports.
+	Features []string `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
 }
 
-func NewPopulatedExchangeEdgesRequest_Body(r randyNet, easy bool) *ExchangeEdgesRequest_Body {
-	this := &ExchangeEdgesRequest_Body{}
-	if r.Intn(5) != 0 {
-		v11 := r.Intn(5)
-		this.Threads = make([]*ExchangeEdgesRequest_Body_ThreadEntry, v11)
-		for i := 0; i < v11; i++ {
-			this.Threads[i] = NewPopulatedExchangeEdgesRequest_Body_ThreadEntry(r, easy)
+func (m *HelloReply) Reset()         { *m = HelloReply{} }
+func (m *HelloReply) String() string { return proto.CompactTextString(m) }
+func (*HelloReply) ProtoMessage()    {}
+func (*HelloReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{33}
+}
+func (m *HelloReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HelloReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HelloReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if !easy && r.Intn(10) != 0 {
+}
+func (m *HelloReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HelloReply.Merge(m, src)
+}
+func (m *HelloReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *HelloReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HelloReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HelloReply proto.InternalMessageInfo
+
+func (m *HelloReply) GetVersion() int32 {
+	if m != nil {
+		return m.Version
 	}
-	return this
+	return 0
 }
 
-func NewPopulatedExchangeEdgesRequest_Body_ThreadEntry(r randyNet, easy bool) *ExchangeEdgesRequest_Body_ThreadEntry {
-	this := &ExchangeEdgesRequest_Body_ThreadEntry{}
-	this.ThreadID = NewPopulatedProtoThreadID(r)
-	this.AddressEdge = uint64(uint64(r.Uint32()))
-	this.HeadsEdge = uint64(uint64(r.Uint32()))
-	if !easy && r.Intn(10) != 0 {
+func (m *HelloReply) GetFeatures() []string {
+	if m != nil {
+		return m.Features
 	}
-	return this
+	return nil
 }
 
-func NewPopulatedExchangeEdgesReply(r randyNet, easy bool) *ExchangeEdgesReply {
-	this := &ExchangeEdgesReply{}
-	if r.Intn(5) != 0 {
-		v12 := r.Intn(5)
-		this.Edges = make([]*ExchangeEdgesReply_ThreadEdges, v12)
-		for i := 0; i < v12; i++ {
-			this.Edges[i] = NewPopulatedExchangeEdgesReply_ThreadEdges(r, easy)
+// RecordEnvelope is a record along with the chunks of its body, as it's backed up.
+type RecordEnvelope struct {
+	// record is the thread record.
+	Record *Log_Record `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// bodyChunks are the raw data of the chunks of a chunked record body.
+	BodyChunks [][]byte `protobuf:"bytes,2,rep,name=bodyChunks,proto3" json:"bodyChunks,omitempty"`
+}
+
+func (m *RecordEnvelope) Reset()         { *m = RecordEnvelope{} }
+func (m *RecordEnvelope) String() string { return proto.CompactTextString(m) }
+func (*RecordEnvelope) ProtoMessage()    {}
+func (*RecordEnvelope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{34}
+}
+func (m *RecordEnvelope) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RecordEnvelope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RecordEnvelope.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if !easy && r.Intn(10) != 0 {
+}
+func (m *RecordEnvelope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecordEnvelope.Merge(m, src)
+}
+func (m *RecordEnvelope) XXX_Size() int {
+	return m.Size()
+}
+func (m *RecordEnvelope) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecordEnvelope.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecordEnvelope proto.InternalMessageInfo
+
+func (m *RecordEnvelope) GetRecord() *Log_Record {
+	if m != nil {
+		return m.Record
 	}
-	return this
+	return nil
 }
 
-func NewPopulatedExchangeEdgesReply_ThreadEdges(r randyNet, easy bool) *ExchangeEdgesReply_ThreadEdges {
-	this := &ExchangeEdgesReply_ThreadEdges{}
-	this.ThreadID = NewPopulatedProtoThreadID(r)
-	this.Exists = bool(bool(r.Intn(2) == 0))
-	this.AddressEdge = uint64(uint64(r.Uint32()))
-	this.HeadsEdge = uint64(uint64(r.Uint32()))
-	if !easy && r.Intn(10) != 0 {
+func (m *RecordEnvelope) GetBodyChunks() [][]byte {
+	if m != nil {
+		return m.BodyChunks
 	}
-	return this
+	return nil
 }
 
-type randyNet interface {
-	Float32() float32
-	Float64() float64
-	Int63() int64
-	Int31() int32
-	Uint32() uint32
-	Intn(n int) int
+// EdgeGossip carries the thread edges gossiped by a peer over pubsub.
+type EdgeGossip struct {
+	// entry is the marshaled ExchangeEdgesRequest.Body.ThreadEntry.
+	Entry []byte `protobuf:"bytes,1,opt,name=entry,proto3" json:"entry,omitempty"`
+	// mac authenticates the entry and the peer gossiping it with the thread service key.
+	Mac []byte `protobuf:"bytes,2,opt,name=mac,proto3" json:"mac,omitempty"`
 }
 
-func randUTF8RuneNet(r randyNet) rune {
-	ru := r.Intn(62)
-	if ru < 10 {
-		return rune(ru + 48)
-	} else if ru < 36 {
-		return rune(ru + 55)
+func (m *EdgeGossip) Reset()         { *m = EdgeGossip{} }
+func (m *EdgeGossip) String() string { return proto.CompactTextString(m) }
+func (*EdgeGossip) ProtoMessage()    {}
+func (*EdgeGossip) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5b10ce944527a32, []int{35}
+}
+func (m *EdgeGossip) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EdgeGossip) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EdgeGossip.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return rune(ru + 61)
 }
-func randStringNet(r randyNet) string {
-	v13 := r.Intn(100)
-	tmps := make([]rune, v13)
-	for i := 0; i < v13; i++ {
-		tmps[i] = randUTF8RuneNet(r)
+func (m *EdgeGossip) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EdgeGossip.Merge(m, src)
+}
+func (m *EdgeGossip) XXX_Size() int {
+	return m.Size()
+}
+func (m *EdgeGossip) XXX_DiscardUnknown() {
+	xxx_messageInfo_EdgeGossip.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EdgeGossip proto.InternalMessageInfo
+
+func (m *EdgeGossip) GetEntry() []byte {
+	if m != nil {
+		return m.Entry
 	}
-	return string(tmps)
+	return nil
 }
-func randUnrecognizedNet(r randyNet, maxFieldNumber int) (dAtA []byte) {
-	l := r.Intn(5)
-	for i := 0; i < l; i++ {
-		wire := r.Intn(4)
-		if wire == 3 {
-			wire = 5
-		}
-		fieldNumber := maxFieldNumber + r.Intn(100)
-		dAtA = randFieldNet(dAtA, r, fieldNumber, wire)
+
+func (m *EdgeGossip) GetMac() []byte {
+	if m != nil {
+		return m.Mac
 	}
-	return dAtA
+	return nil
 }
-func randFieldNet(dAtA []byte, r randyNet, fieldNumber int, wire int) []byte {
-	key := uint32(fieldNumber)<<3 | uint32(wire)
-	switch wire {
-	case 0:
-		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
-		v14 := r.Int63()
-		if r.Intn(2) == 0 {
-			v14 *= -1
-		}
-		dAtA = encodeVarintPopulateNet(dAtA, uint64(v14))
-	case 1:
-		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
-		dAtA = append(dAtA, byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)))
-	case 2:
-		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
-		ll := r.Intn(100)
-		dAtA = encodeVarintPopulateNet(dAtA, uint64(ll))
-		for j := 0; j < ll; j++ {
-			dAtA = append(dAtA, byte(r.Intn(256)))
-		}
-	default:
-		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
-		dAtA = append(dAtA, byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)))
-	}
-	return dAtA
+
+func init() {
+	proto.RegisterType((*Log)(nil), "net.pb.Log")
+	proto.RegisterType((*Log_Record)(nil), "net.pb.Log.Record")
+	proto.RegisterType((*GetLogsRequest)(nil), "net.pb.GetLogsRequest")
+	proto.RegisterType((*GetLogsRequest_Body)(nil), "net.pb.GetLogsRequest.Body")
+	proto.RegisterType((*GetLogsReply)(nil), "net.pb.GetLogsReply")
+	proto.RegisterType((*PushLogRequest)(nil), "net.pb.PushLogRequest")
+	proto.RegisterType((*PushLogRequest_Body)(nil), "net.pb.PushLogRequest.Body")
+	proto.RegisterType((*PushLogReply)(nil), "net.pb.PushLogReply")
+	proto.RegisterType((*GetRecordsRequest)(nil), "net.pb.GetRecordsRequest")
+	proto.RegisterType((*GetRecordsRequest_Body)(nil), "net.pb.GetRecordsRequest.Body")
+	proto.RegisterType((*GetRecordsRequest_Body_LogEntry)(nil), "net.pb.GetRecordsRequest.Body.LogEntry")
+	proto.RegisterType((*GetRecordsReply)(nil), "net.pb.GetRecordsReply")
+	proto.RegisterType((*GetRecordsReply_LogEntry)(nil), "net.pb.GetRecordsReply.LogEntry")
+	proto.RegisterType((*PushRecordRequest)(nil), "net.pb.PushRecordRequest")
+	proto.RegisterType((*PushRecordRequest_Body)(nil), "net.pb.PushRecordRequest.Body")
+	proto.RegisterType((*PushRecordReply)(nil), "net.pb.PushRecordReply")
+	proto.RegisterType((*ExchangeEdgesRequest)(nil), "net.pb.ExchangeEdgesRequest")
+	proto.RegisterType((*ExchangeEdgesRequest_Body)(nil), "net.pb.ExchangeEdgesRequest.Body")
+	proto.RegisterType((*ExchangeEdgesRequest_Body_ThreadEntry)(nil), "net.pb.ExchangeEdgesRequest.Body.ThreadEntry")
+	proto.RegisterType((*ExchangeEdgesReply)(nil), "net.pb.ExchangeEdgesReply")
+	proto.RegisterType((*ExchangeEdgesReply_ThreadEdges)(nil), "net.pb.ExchangeEdgesReply.ThreadEdges")
+	proto.RegisterType((*Backpressure)(nil), "net.pb.Backpressure")
+	proto.RegisterType((*PushRecordsRequest)(nil), "net.pb.PushRecordsRequest")
+	proto.RegisterType((*PushRecordsRequest_Body)(nil), "net.pb.PushRecordsRequest.Body")
+	proto.RegisterType((*PushRecordsReply)(nil), "net.pb.PushRecordsReply")
+	proto.RegisterType((*Invite)(nil), "net.pb.Invite")
+	proto.RegisterType((*Invite_Body)(nil), "net.pb.Invite.Body")
+	proto.RegisterType((*RedeemInviteRequest)(nil), "net.pb.RedeemInviteRequest")
+	proto.RegisterType((*RedeemInviteRequest_Body)(nil), "net.pb.RedeemInviteRequest.Body")
+	proto.RegisterType((*RedeemInviteReply)(nil), "net.pb.RedeemInviteReply")
+	proto.RegisterType((*GetRecordBodiesRequest)(nil), "net.pb.GetRecordBodiesRequest")
+	proto.RegisterType((*GetRecordBodiesRequest_Body)(nil), "net.pb.GetRecordBodiesRequest.Body")
+	proto.RegisterType((*GetRecordBodiesReply)(nil), "net.pb.GetRecordBodiesReply")
+	proto.RegisterType((*GetCapabilitiesRequest)(nil), "net.pb.GetCapabilitiesRequest")
+	proto.RegisterType((*GetCapabilitiesReply)(nil), "net.pb.GetCapabilitiesReply")
+	proto.RegisterType((*GetCapabilitiesReply_Relay)(nil), "net.pb.GetCapabilitiesReply.Relay")
+	proto.RegisterType((*SubscribeRequest)(nil), "net.pb.SubscribeRequest")
+	proto.RegisterType((*SubscribeRequest_Body)(nil), "net.pb.SubscribeRequest.Body")
+	proto.RegisterType((*SubscribeRequest_Body_Filter)(nil), "net.pb.SubscribeRequest.Body.Filter")
+	proto.RegisterType((*SubscribeReply)(nil), "net.pb.SubscribeReply")
+	proto.RegisterType((*HandoffLogRequest)(nil), "net.pb.HandoffLogRequest")
+	proto.RegisterType((*HandoffLogRequest_Body)(nil), "net.pb.HandoffLogRequest.Body")
+	proto.RegisterType((*HandoffLogReply)(nil), "net.pb.HandoffLogReply")
+	proto.RegisterType((*PutKeyShareRequest)(nil), "net.pb.PutKeyShareRequest")
+	proto.RegisterType((*PutKeyShareRequest_Body)(nil), "net.pb.PutKeyShareRequest.Body")
+	proto.RegisterType((*PutKeyShareReply)(nil), "net.pb.PutKeyShareReply")
+	proto.RegisterType((*GetKeyShareRequest)(nil), "net.pb.GetKeyShareRequest")
+	proto.RegisterType((*GetKeyShareRequest_Body)(nil), "net.pb.GetKeyShareRequest.Body")
+	proto.RegisterType((*GetKeyShareReply)(nil), "net.pb.GetKeyShareReply")
+	proto.RegisterType((*PushRevocationRequest)(nil), "net.pb.PushRevocationRequest")
+	proto.RegisterType((*PushRevocationRequest_Body)(nil), "net.pb.PushRevocationRequest.Body")
+	proto.RegisterType((*PushRevocationReply)(nil), "net.pb.PushRevocationReply")
+	proto.RegisterType((*LogSeq)(nil), "net.pb.LogSeq")
+	proto.RegisterType((*HelloRequest)(nil), "net.pb.HelloRequest")
+	proto.RegisterType((*HelloReply)(nil), "net.pb.HelloReply")
+	proto.RegisterType((*RecordEnvelope)(nil), "net.pb.RecordEnvelope")
+	proto.RegisterType((*EdgeGossip)(nil), "net.pb.EdgeGossip")
 }
-func encodeVarintPopulateNet(dAtA []byte, v uint64) []byte {
-	for v >= 1<<7 {
-		dAtA = append(dAtA, uint8(uint64(v)&0x7f|0x80))
-		v >>= 7
-	}
-	dAtA = append(dAtA, uint8(v))
-	return dAtA
+
+func init() { proto.RegisterFile("net.proto", fileDescriptor_a5b10ce944527a32) }
+
+var fileDescriptor_a5b10ce944527a32 = []byte{
+	// 2162 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x59, 0x4b, 0x6c, 0x1c, 0x49,
+	0x19, 0x76, 0x77, 0xcf, 0xcb, 0xff, 0x4c, 0xfc, 0xa8, 0xf5, 0x26, 0xb3, 0x9d, 0x64, 0x3c, 0x74,
+	0x42, 0x32, 0xc0, 0x66, 0x02, 0x4e, 0x78, 0x09, 0x84, 0x64, 0x27, 0xc6, 0x09, 0x8e, 0x96, 0x50,
+	0xde, 0x23, 0x07, 0x7a, 0xa6, 0xcb, 0xe3, 0x96, 0xdb, 0xdd, 0xe3, 0xee, 0x1e, 0xcb, 0x73, 0x46,
+	0x42, 0x3c, 0x04, 0xe2, 0x71, 0xe1, 0xc8, 0x69, 0x81, 0x1b, 0x07, 0x10, 0x17, 0xb4, 0xe2, 0xc0,
+	0x81, 0x13, 0x5a, 0x2e, 0x68, 0x15, 0x2d, 0x11, 0x24, 0x37, 0x24, 0x2e, 0x88, 0xc3, 0xde, 0x40,
+	0x7f, 0x55, 0x3f, 0xaa, 0x7b, 0xba, 0xc7, 0x89, 0x25, 0xc2, 0xc9, 0xf3, 0x3f, 0xea, 0xef, 0xfa,
+	0xbf, 0xff, 0x51, 0x7f, 0x95, 0x61, 0xd1, 0x65, 0x61, 0x7f, 0xec, 0x7b, 0xa1, 0x47, 0x6a, 0xfc,
+	0xe7, 0x40, 0xbf, 0x35, 0xb2, 0xc3, 0x83, 0xc9, 0xa0, 0x3f, 0xf4, 0x8e, 0x6e, 0x8f, 0xbc, 0x91,
+	0x77, 0x9b, 0x8b, 0x07, 0x93, 0x7d, 0x4e, 0x71, 0x82, 0xff, 0x12, 0xcb, 0x8c, 0x3f, 0x6b, 0xa0,
+	0x3d, 0xf2, 0x46, 0x64, 0x1d, 0xd4, 0x87, 0xf7, 0xdb, 0x4a, 0x57, 0xe9, 0xb5, 0xb6, 0x96, 0x9f,
+	0x3c, 0x5d, 0x6f, 0x3e, 0x46, 0xf1, 0x63, 0xc6, 0xfc, 0x87, 0xf7, 0xa9, 0xfa, 0xf0, 0x3e, 0xb9,
+	0x09, 0xb5, 0xf1, 0x64, 0xb0, 0xcb, 0xa6, 0x6d, 0x35, 0xaf, 0xc4, 0xd9, 0x34, 0x12, 0x93, 0x6b,
+	0x50, 0x35, 0x2d, 0xcb, 0x0f, 0xda, 0x5a, 0x57, 0xeb, 0xb5, 0xb6, 0x2e, 0x3c, 0x79, 0xba, 0xbe,
+	0xc8, 0xf5, 0x36, 0x2d, 0xcb, 0xa7, 0x42, 0x46, 0xba, 0x50, 0x39, 0x60, 0xa6, 0xd5, 0xae, 0x70,
+	0x5b, 0xad, 0x27, 0x4f, 0xd7, 0x1b, 0x5c, 0xe7, 0x9e, 0x6d, 0x51, 0x2e, 0x21, 0x06, 0x54, 0xf1,
+	0x6f, 0xd0, 0xae, 0x76, 0xb5, 0x19, 0x15, 0x21, 0x22, 0x3a, 0x34, 0xb8, 0xb9, 0x3d, 0x76, 0xdc,
+	0xae, 0x75, 0x95, 0x5e, 0x85, 0x26, 0x74, 0x2a, 0xb3, 0x47, 0xed, 0x3a, 0x7e, 0x85, 0x26, 0xb4,
+	0xfe, 0x81, 0x02, 0x35, 0xca, 0x86, 0x9e, 0x6f, 0x91, 0x0e, 0x80, 0xcf, 0x7f, 0xbd, 0xe5, 0x59,
+	0x4c, 0xf8, 0x4f, 0x25, 0x0e, 0xb9, 0x02, 0x8b, 0xec, 0x84, 0xb9, 0x21, 0x17, 0x73, 0xcf, 0x69,
+	0xca, 0xc0, 0xd5, 0xb8, 0x13, 0xe6, 0x73, 0xb1, 0x26, 0x56, 0xa7, 0x1c, 0xdc, 0xc4, 0xc0, 0xb3,
+	0xa6, 0x5c, 0x5a, 0x11, 0x9b, 0x88, 0x69, 0xd2, 0x86, 0xfa, 0x09, 0xf3, 0x03, 0xdb, 0x73, 0xdb,
+	0xd5, 0xae, 0xd2, 0xab, 0xd2, 0x98, 0x44, 0xab, 0xec, 0x34, 0x64, 0x2e, 0x12, 0x01, 0x77, 0xac,
+	0x45, 0x25, 0x8e, 0xd8, 0x73, 0x10, 0xfa, 0xf6, 0x30, 0x64, 0x16, 0x77, 0xae, 0x41, 0x25, 0x8e,
+	0xf1, 0x0f, 0x05, 0x96, 0x76, 0x58, 0xf8, 0xc8, 0x1b, 0x05, 0x94, 0x1d, 0x4f, 0x58, 0x10, 0x92,
+	0xdb, 0x50, 0xc1, 0x0f, 0x73, 0x0f, 0x9a, 0x1b, 0x97, 0xfb, 0x22, 0x59, 0xfa, 0x59, 0xad, 0xfe,
+	0x96, 0x67, 0x4d, 0x29, 0x57, 0xd4, 0x7f, 0xa6, 0x40, 0x05, 0x49, 0x72, 0x0b, 0x1a, 0xe1, 0x81,
+	0xcf, 0x4c, 0x2b, 0x49, 0x8f, 0xd5, 0x27, 0x4f, 0xd7, 0x2f, 0xf0, 0x50, 0xbc, 0x1d, 0x09, 0x68,
+	0xa2, 0x42, 0xde, 0x04, 0x08, 0x98, 0x7f, 0x62, 0x0f, 0x59, 0x9a, 0x2a, 0x69, 0xec, 0x30, 0x4f,
+	0x24, 0x39, 0xf9, 0x28, 0x54, 0xcd, 0xfd, 0x90, 0xf9, 0x6d, 0x2d, 0x9f, 0x53, 0x22, 0xf1, 0x84,
+	0x94, 0xac, 0x41, 0xd5, 0xb1, 0x8f, 0xec, 0x90, 0x63, 0x58, 0xa5, 0x82, 0xf8, 0x4a, 0xa5, 0xa1,
+	0xac, 0xa8, 0xc6, 0x1f, 0x14, 0x68, 0x25, 0x6e, 0x8c, 0x9d, 0x29, 0x59, 0x87, 0x8a, 0xe3, 0x8d,
+	0x82, 0xb6, 0xd2, 0xd5, 0x7a, 0xcd, 0x8d, 0x66, 0xec, 0xea, 0x23, 0x6f, 0x44, 0xb9, 0x00, 0xad,
+	0xed, 0x3b, 0xe6, 0x28, 0x68, 0xab, 0x5d, 0xad, 0xb7, 0x48, 0x05, 0x41, 0xae, 0x41, 0xc5, 0x65,
+	0xa7, 0x61, 0xd9, 0x4e, 0xb8, 0x10, 0xe3, 0x79, 0xc4, 0x42, 0xd3, 0x32, 0x43, 0x33, 0x8e, 0x67,
+	0x4c, 0x93, 0x2e, 0x34, 0xb9, 0xa5, 0x3d, 0x7b, 0xe4, 0x32, 0x9f, 0xc7, 0xb4, 0x45, 0x65, 0x16,
+	0xae, 0x8e, 0xc9, 0x28, 0xaa, 0x09, 0x6d, 0x7c, 0x4b, 0x83, 0xa5, 0xc7, 0x93, 0xe0, 0x00, 0xb7,
+	0x39, 0x3f, 0x66, 0x59, 0x2d, 0x39, 0x66, 0xbf, 0x53, 0x5f, 0x45, 0xcc, 0x6e, 0x40, 0x1d, 0xd7,
+	0xa1, 0xaa, 0x56, 0xa0, 0x1a, 0x0b, 0xc9, 0x55, 0xd0, 0x1c, 0x6f, 0xc4, 0x61, 0xca, 0x85, 0x01,
+	0xf9, 0x19, 0x28, 0xab, 0xb3, 0x50, 0x1e, 0xb2, 0x29, 0xf5, 0x42, 0x33, 0xc4, 0xf2, 0x10, 0x58,
+	0xc9, 0xac, 0x34, 0x86, 0x98, 0xfd, 0x49, 0x0c, 0x73, 0x21, 0x68, 0xcc, 0x0f, 0xc1, 0x62, 0x36,
+	0x04, 0x51, 0x3e, 0x2d, 0x41, 0x2b, 0x41, 0x78, 0xec, 0x4c, 0x8d, 0x77, 0x34, 0x58, 0xdd, 0x61,
+	0xa1, 0x68, 0x17, 0x49, 0x3d, 0x6d, 0x64, 0x62, 0xd3, 0x91, 0xea, 0x29, 0xab, 0x28, 0x87, 0xe7,
+	0x2f, 0xaf, 0x24, 0x3c, 0x5f, 0x88, 0xd2, 0x5f, 0xe3, 0xe9, 0x7f, 0x73, 0xfe, 0xce, 0x30, 0x1c,
+	0xdb, 0x6e, 0xe8, 0x4f, 0xa3, 0xd2, 0xe8, 0x42, 0x53, 0x74, 0xaf, 0xe0, 0xab, 0xae, 0x33, 0xe5,
+	0xb1, 0x6b, 0x50, 0x99, 0xa5, 0xff, 0x48, 0x81, 0x46, 0xbc, 0x08, 0xcb, 0xd7, 0xf1, 0x46, 0xe5,
+	0xe7, 0x86, 0x90, 0x92, 0xeb, 0x50, 0xf3, 0xf6, 0xf7, 0x03, 0x16, 0xce, 0x6c, 0x1e, 0x7b, 0x79,
+	0x24, 0x4b, 0x8b, 0x5c, 0x93, 0x8a, 0x3c, 0x3d, 0x06, 0x2a, 0xa5, 0xc7, 0x40, 0x14, 0xb8, 0x7f,
+	0x29, 0xb0, 0x2c, 0x7b, 0x89, 0xbd, 0xe0, 0x6e, 0xa6, 0x17, 0x74, 0x8b, 0xc0, 0x18, 0x3b, 0x79,
+	0x14, 0xf4, 0x5f, 0x9c, 0xc3, 0xc7, 0x37, 0xb1, 0x2a, 0xb8, 0x49, 0xde, 0x56, 0x9a, 0x1b, 0x44,
+	0xca, 0xf8, 0xbe, 0xf8, 0x1a, 0x8d, 0x55, 0xe2, 0xda, 0xd0, 0x4a, 0x6a, 0xa3, 0x87, 0xc7, 0xc6,
+	0xc4, 0xb5, 0x4c, 0x7f, 0x5a, 0x78, 0x42, 0x26, 0x52, 0xe3, 0x7d, 0x05, 0x56, 0x31, 0x5d, 0xa3,
+	0x0f, 0xcc, 0xcf, 0xce, 0x19, 0x45, 0x39, 0x3b, 0xbf, 0x7d, 0xce, 0x86, 0x9f, 0xe0, 0xa3, 0xce,
+	0xc5, 0xe7, 0xe3, 0x50, 0x13, 0xce, 0x47, 0x4e, 0x17, 0xc1, 0x13, 0x69, 0x44, 0xf1, 0x5c, 0x85,
+	0x65, 0x79, 0xc3, 0x58, 0x8b, 0x7f, 0x52, 0x61, 0x6d, 0xfb, 0x74, 0x78, 0x60, 0xba, 0x23, 0xb6,
+	0x6d, 0x8d, 0x58, 0x52, 0x8e, 0x9f, 0xce, 0x38, 0xfc, 0x91, 0xd8, 0x76, 0x91, 0xae, 0xec, 0xf3,
+	0x87, 0xb1, 0xcf, 0x3b, 0x50, 0x17, 0x0e, 0xc5, 0xa9, 0x72, 0xeb, 0x4c, 0x13, 0x7d, 0x81, 0x85,
+	0xc8, 0x9b, 0x78, 0xb5, 0xfe, 0x8e, 0x02, 0x4d, 0x49, 0xf0, 0xb2, 0x60, 0x76, 0xa1, 0x89, 0x43,
+	0x0a, 0x0b, 0x02, 0xfc, 0x1e, 0x77, 0xa7, 0x42, 0x65, 0x16, 0xce, 0x23, 0x3c, 0xe9, 0xb9, 0x5c,
+	0xe3, 0xf2, 0x94, 0x41, 0x7a, 0x50, 0x77, 0xbc, 0xd1, 0x1e, 0x3b, 0x16, 0xf5, 0xd2, 0xdc, 0x58,
+	0x92, 0x60, 0xde, 0x63, 0xc7, 0x34, 0x16, 0x47, 0x18, 0xff, 0x44, 0x05, 0x92, 0xf3, 0x10, 0xcb,
+	0xe6, 0x8b, 0x50, 0x65, 0x48, 0x45, 0x60, 0xdc, 0x28, 0x01, 0x03, 0x4b, 0x27, 0x72, 0x96, 0x33,
+	0xc4, 0x22, 0xfd, 0xdd, 0x14, 0x03, 0xa4, 0x5f, 0x16, 0x83, 0x8b, 0x50, 0x63, 0xa7, 0x76, 0x10,
+	0x06, 0xdc, 0xfd, 0x06, 0x8d, 0xa8, 0x3c, 0x36, 0xda, 0x19, 0xd8, 0x54, 0xe6, 0x60, 0x53, 0x9d,
+	0x8b, 0x8d, 0xd1, 0x87, 0xd6, 0x96, 0x39, 0x3c, 0x1c, 0xa3, 0xe1, 0x89, 0xcf, 0xc4, 0xbc, 0x15,
+	0xfa, 0xd3, 0x4d, 0x3e, 0xaa, 0xa0, 0x0b, 0x1a, 0x95, 0x38, 0xc6, 0x07, 0x0a, 0x90, 0x34, 0x55,
+	0x93, 0xa4, 0xbc, 0x93, 0x49, 0xca, 0xf5, 0xd9, 0x2a, 0x2c, 0x4a, 0xc9, 0xef, 0x96, 0x96, 0x61,
+	0x0a, 0x51, 0x01, 0x7e, 0xb9, 0x32, 0x8c, 0xaa, 0x6e, 0xa6, 0x1a, 0xe5, 0x36, 0xa5, 0x9d, 0xd9,
+	0xa6, 0xa2, 0x24, 0x21, 0xb0, 0x92, 0xd9, 0x33, 0x56, 0xe2, 0xaf, 0x54, 0xa8, 0x3d, 0x74, 0x4f,
+	0xec, 0x90, 0x11, 0x12, 0xb9, 0x29, 0x36, 0xc9, 0x7f, 0x93, 0x15, 0xd0, 0x02, 0x7b, 0x14, 0xed,
+	0x05, 0x7f, 0xea, 0xff, 0x39, 0x67, 0x7b, 0xf9, 0x18, 0xd4, 0x6d, 0xfe, 0x1d, 0xbf, 0xac, 0xc1,
+	0xc4, 0xf2, 0x17, 0xbb, 0x78, 0x10, 0xa8, 0xf8, 0x9e, 0xc3, 0xa2, 0x49, 0x92, 0xff, 0xc6, 0x49,
+	0x9c, 0x9d, 0x8e, 0x6d, 0x9f, 0x05, 0x7c, 0x12, 0xd1, 0x68, 0x4c, 0xe2, 0x99, 0xe4, 0x7a, 0xee,
+	0x90, 0x45, 0x23, 0x88, 0x20, 0x30, 0x43, 0x07, 0x13, 0xd7, 0x72, 0x58, 0x74, 0xb1, 0x88, 0x28,
+	0x7e, 0x57, 0x70, 0x87, 0xfe, 0x74, 0x8c, 0x63, 0x79, 0x83, 0x27, 0x6f, 0xca, 0x30, 0x7e, 0xaa,
+	0xc0, 0x6b, 0x94, 0x59, 0x8c, 0x1d, 0x09, 0xe0, 0xe2, 0x34, 0xb9, 0x2b, 0xe1, 0x27, 0x9d, 0x51,
+	0x05, 0xaa, 0x72, 0x9e, 0xec, 0x9e, 0x0f, 0xce, 0xc4, 0x21, 0x55, 0x72, 0xc8, 0xf8, 0x04, 0xac,
+	0x66, 0x3f, 0x87, 0x4d, 0x20, 0xf5, 0x52, 0x91, 0xbd, 0x34, 0xfe, 0xaa, 0xc0, 0xc5, 0xe4, 0x00,
+	0xdd, 0xf2, 0x2c, 0x3b, 0x6d, 0xc3, 0x9f, 0xcd, 0xb8, 0x72, 0x6d, 0xe6, 0xb8, 0xcd, 0x68, 0xcb,
+	0xde, 0x7c, 0xe7, 0x95, 0xdc, 0x36, 0xae, 0x43, 0x6d, 0xc0, 0x77, 0x10, 0x65, 0x48, 0x6e, 0x0e,
+	0x11, 0x32, 0xa3, 0x0f, 0x6b, 0x33, 0x1b, 0x8e, 0xf1, 0x10, 0xab, 0xb1, 0x2b, 0xb6, 0x12, 0xfd,
+	0x36, 0x87, 0xe3, 0x9e, 0x39, 0x36, 0x07, 0xb6, 0x63, 0x87, 0xa9, 0x83, 0xc6, 0xf7, 0x54, 0x58,
+	0x9b, 0x11, 0xa1, 0xa9, 0xcf, 0x41, 0xd5, 0x67, 0x8e, 0x19, 0x03, 0x65, 0x48, 0x40, 0xcd, 0x28,
+	0xf7, 0x29, 0x6a, 0x52, 0xb1, 0x00, 0x9b, 0xe0, 0xd0, 0x3b, 0xe2, 0x9d, 0x09, 0x27, 0x63, 0x71,
+	0x83, 0x91, 0x59, 0xa4, 0x07, 0xcb, 0x08, 0xe9, 0x3d, 0x49, 0x4b, 0xe3, 0x5a, 0x79, 0xb6, 0x7e,
+	0x04, 0x55, 0x6e, 0x1b, 0xfb, 0xdb, 0x91, 0x79, 0xfa, 0x76, 0x72, 0x00, 0xf2, 0xfe, 0x96, 0x72,
+	0xc8, 0x0d, 0x58, 0x4a, 0xa8, 0xad, 0x69, 0xc8, 0x44, 0x67, 0xd6, 0x68, 0x8e, 0x8b, 0xf9, 0xef,
+	0xb3, 0x90, 0xb9, 0xa1, 0xf8, 0x28, 0xaa, 0xa4, 0x0c, 0xe3, 0x37, 0x2a, 0xac, 0xec, 0x4d, 0x06,
+	0xc1, 0xd0, 0xb7, 0x07, 0x49, 0xf2, 0x7f, 0x2a, 0x93, 0x31, 0x57, 0x63, 0x20, 0xf2, 0x7a, 0x72,
+	0xae, 0xfc, 0x33, 0xce, 0x95, 0x2f, 0x41, 0x7d, 0xdf, 0x76, 0x42, 0xe6, 0xc7, 0xe7, 0xd4, 0xf5,
+	0xb9, 0xcb, 0xfb, 0x5f, 0xe6, 0xca, 0x34, 0x5e, 0x84, 0xb5, 0x10, 0x7a, 0x87, 0xcc, 0xe5, 0xde,
+	0x2c, 0x52, 0x41, 0xe8, 0x3f, 0x50, 0xa0, 0x26, 0x34, 0xff, 0xb7, 0xc9, 0x78, 0x13, 0x6a, 0xbc,
+	0x47, 0xc7, 0xc9, 0x38, 0xd3, 0xd7, 0x22, 0xb1, 0xf1, 0x63, 0x05, 0x96, 0x24, 0x87, 0x30, 0x7f,
+	0xfe, 0xef, 0x23, 0x9a, 0xf1, 0xae, 0x0a, 0xab, 0x0f, 0x4c, 0xd7, 0xf2, 0xf6, 0xf7, 0xa5, 0x1b,
+	0xeb, 0x46, 0x26, 0x9a, 0xc9, 0xdc, 0x39, 0xa3, 0x28, 0x87, 0xf3, 0xdf, 0xaf, 0xea, 0xa1, 0x41,
+	0x40, 0xa0, 0xcd, 0x85, 0xe0, 0xec, 0x67, 0xa9, 0x15, 0xd0, 0x0e, 0xd9, 0x34, 0xba, 0xb1, 0xe2,
+	0xcf, 0xf8, 0xac, 0xab, 0x25, 0x67, 0x5d, 0x7a, 0x67, 0xa9, 0x97, 0xde, 0x59, 0x70, 0xba, 0x95,
+	0x61, 0xc1, 0x33, 0xf5, 0x9b, 0x2a, 0x8e, 0x11, 0xe1, 0x2e, 0x9b, 0xee, 0x1d, 0x98, 0x3e, 0xcb,
+	0x8f, 0x11, 0x4a, 0x7e, 0x8c, 0xc8, 0x6b, 0xca, 0xa8, 0xfe, 0x56, 0x39, 0xf7, 0xf9, 0x10, 0xa0,
+	0xc9, 0xf8, 0x7c, 0xe0, 0x04, 0x16, 0x36, 0x6a, 0x04, 0x07, 0x9e, 0x63, 0x45, 0xd7, 0xb3, 0x94,
+	0x81, 0xc7, 0xe7, 0x21, 0x9b, 0x3e, 0x30, 0x83, 0x83, 0xe8, 0x4d, 0x24, 0x26, 0xb1, 0x5b, 0x61,
+	0xbe, 0x9c, 0x30, 0x7f, 0xba, 0x9b, 0x80, 0x26, 0xb3, 0x66, 0xc1, 0x13, 0xd3, 0x86, 0xe4, 0x1a,
+	0x22, 0xf3, 0x6b, 0x05, 0xc8, 0x0e, 0x7b, 0x51, 0x64, 0x76, 0xd8, 0x3c, 0x64, 0xec, 0xf3, 0x01,
+	0x93, 0x73, 0x45, 0x2d, 0x75, 0x45, 0x4b, 0x5d, 0xf9, 0x06, 0xac, 0x64, 0xf6, 0x82, 0xa5, 0x9b,
+	0x00, 0xac, 0x94, 0x02, 0xac, 0xce, 0x01, 0x58, 0xcb, 0x00, 0x6c, 0x7c, 0x5f, 0x85, 0xd7, 0xc5,
+	0x6c, 0x76, 0xe2, 0x0d, 0xf9, 0xcb, 0x48, 0x8c, 0xcd, 0x67, 0x32, 0xd8, 0x18, 0xd9, 0xe1, 0x33,
+	0xa7, 0x2c, 0xc1, 0x53, 0x30, 0xb9, 0xfd, 0x32, 0x4e, 0x25, 0x1d, 0x1a, 0xb6, 0x85, 0xcd, 0x3c,
+	0x8c, 0x87, 0xbd, 0x84, 0x16, 0xad, 0xff, 0xc4, 0x3b, 0x64, 0xd6, 0x66, 0x18, 0x9d, 0x0e, 0x29,
+	0x23, 0x83, 0xb5, 0x76, 0x36, 0xd6, 0x38, 0x47, 0x89, 0x01, 0x6c, 0x53, 0x3c, 0xf9, 0x69, 0x34,
+	0x65, 0xe0, 0x36, 0x7c, 0x16, 0x84, 0x9e, 0xcf, 0x2c, 0x9e, 0x51, 0x0d, 0x9a, 0xd0, 0xc6, 0xeb,
+	0xf0, 0x5a, 0xde, 0x43, 0xcc, 0x9f, 0x4d, 0xa8, 0x89, 0x19, 0xff, 0x45, 0x6f, 0xf3, 0x88, 0x02,
+	0x3b, 0x8e, 0xee, 0x5f, 0xf8, 0xd3, 0xb8, 0x0f, 0xad, 0x07, 0xcc, 0x71, 0xbc, 0x18, 0x5f, 0xe9,
+	0xf5, 0x56, 0xc9, 0xbe, 0xde, 0xe2, 0x13, 0x13, 0x33, 0xc3, 0x89, 0xcf, 0xe2, 0x17, 0xc6, 0x84,
+	0x36, 0xb6, 0x00, 0x22, 0x2b, 0x98, 0x0b, 0xe7, 0xb3, 0xf1, 0x75, 0x58, 0x12, 0xcd, 0x78, 0xdb,
+	0x3d, 0x61, 0x8e, 0x37, 0x66, 0x52, 0xe3, 0x56, 0xce, 0x6a, 0xdc, 0x78, 0xd6, 0xf3, 0x39, 0xe0,
+	0x60, 0xe2, 0x1e, 0x0a, 0xdb, 0x2d, 0x2a, 0x71, 0x8c, 0xbb, 0x00, 0x78, 0x5b, 0xda, 0xf1, 0x82,
+	0xc0, 0x1e, 0x63, 0xb6, 0x32, 0xbc, 0xc7, 0xc6, 0xd9, 0xca, 0x09, 0x44, 0xe7, 0xc8, 0x1c, 0xc6,
+	0x39, 0x72, 0x64, 0x0e, 0x37, 0x7e, 0xde, 0x80, 0xfa, 0x9e, 0xe8, 0xb6, 0xe4, 0xf3, 0x50, 0x8f,
+	0xde, 0x63, 0xc9, 0xc5, 0xe2, 0x77, 0x66, 0x7d, 0x6d, 0x86, 0x8f, 0x51, 0x5a, 0xc0, 0xa5, 0xd1,
+	0xdb, 0x5b, 0xba, 0x34, 0xfb, 0xdc, 0xa9, 0xaf, 0xcd, 0xf0, 0xc5, 0xd2, 0x2d, 0x80, 0xf4, 0x55,
+	0x87, 0xbc, 0x51, 0xfa, 0xec, 0xa5, 0x5f, 0x2a, 0x79, 0x04, 0x12, 0x36, 0xd2, 0x8b, 0x4e, 0x6a,
+	0x63, 0xe6, 0xd9, 0x44, 0xbf, 0x54, 0x24, 0x12, 0x36, 0x76, 0xe1, 0x42, 0xe6, 0x96, 0x4c, 0xae,
+	0xcc, 0x7b, 0x49, 0xd0, 0xf5, 0xf2, 0xab, 0xb5, 0xb1, 0x40, 0xb6, 0xa1, 0x99, 0x7e, 0x21, 0x20,
+	0x7a, 0xf9, 0x15, 0x52, 0x6f, 0x17, 0xca, 0x84, 0x99, 0x07, 0xd0, 0x92, 0xc7, 0x7b, 0x72, 0x79,
+	0xce, 0x1d, 0x43, 0x7f, 0xa3, 0x58, 0x28, 0x2c, 0x7d, 0x0d, 0x96, 0x73, 0xb3, 0x31, 0xe9, 0xcc,
+	0x9f, 0xf2, 0xf5, 0x2b, 0xa5, 0x72, 0xd9, 0xa4, 0x3c, 0xf6, 0x66, 0x4c, 0x16, 0xcc, 0xd5, 0xfa,
+	0x95, 0x52, 0xb9, 0x30, 0x79, 0x0f, 0x16, 0x93, 0x81, 0x89, 0xb4, 0xcb, 0x86, 0x42, 0xfd, 0x62,
+	0x81, 0x84, 0x1b, 0xe8, 0x29, 0x9f, 0x54, 0x30, 0x19, 0xd2, 0x03, 0x3a, 0x4d, 0x86, 0x99, 0x59,
+	0x46, 0xbf, 0x54, 0x24, 0x92, 0xe2, 0x97, 0x1c, 0x00, 0x72, 0xfc, 0xf2, 0x27, 0x94, 0xde, 0x2e,
+	0x94, 0x25, 0x66, 0x76, 0x58, 0x81, 0x99, 0x1d, 0x56, 0x6e, 0x26, 0x7f, 0xf0, 0x18, 0x0b, 0xe4,
+	0x2d, 0x58, 0xca, 0x36, 0x47, 0x72, 0x75, 0xee, 0xb1, 0xa0, 0x5f, 0x2e, 0x13, 0x0b, 0x7b, 0x77,
+	0xa0, 0xca, 0x9b, 0x19, 0x49, 0x6a, 0x52, 0xee, 0x90, 0x3a, 0xc9, 0x71, 0xf9, 0xa2, 0xad, 0xee,
+	0x87, 0x7f, 0xef, 0x28, 0xbf, 0x7f, 0xd6, 0x51, 0xfe, 0xf8, 0xac, 0xa3, 0xbc, 0xf7, 0xac, 0xa3,
+	0xfc, 0xed, 0x59, 0x47, 0xf9, 0xe1, 0xf3, 0xce, 0xc2, 0x7b, 0xcf, 0x3b, 0x0b, 0xef, 0x3f, 0xef,
+	0x2c, 0x0c, 0x6a, 0xfc, 0x1f, 0x93, 0x77, 0xfe, 0x3b, 0x00, 0x5c, 0x71, 0xf7, 0xbc, 0xdc, 0x1c,
+	0x00, 0x00,
 }
-func (m *Log) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.ID != nil {
-		l = m.ID.Size()
-		n += 1 + l + sovNet(uint64(l))
-	}
-	if m.PubKey != nil {
-		l = m.PubKey.Size()
-		n += 1 + l + sovNet(uint64(l))
-	}
-	if len(m.Addrs) > 0 {
-		for _, e := range m.Addrs {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
-		}
-	}
-	if m.Head != nil {
-		l = m.Head.Size()
-		n += 1 + l + sovNet(uint64(l))
-	}
-	return n
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ServiceClient is the client API for Service service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ServiceClient interface {
+	// GetLogs from a peer.
+	GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsReply, error)
+	// PushLog to a peer.
+	PushLog(ctx context.Context, in *PushLogRequest, opts ...grpc.CallOption) (*PushLogReply, error)
+	// GetRecords from a peer.
+	GetRecords(ctx context.Context, in *GetRecordsRequest, opts ...grpc.CallOption) (*GetRecordsReply, error)
+	// PushRecord to a peer.
+	PushRecord(ctx context.Context, in *PushRecordRequest, opts ...grpc.CallOption) (*PushRecordReply, error)
+	// ExchangeEdges with a peer.
+	ExchangeEdges(ctx context.Context, in *ExchangeEdgesRequest, opts ...grpc.CallOption) (*ExchangeEdgesReply, error)
+	// PushRecords to a peer.
+	PushRecords(ctx context.Context, in *PushRecordsRequest, opts ...grpc.CallOption) (*PushRecordsReply, error)
+	// RedeemInvite issued by a peer.
+	RedeemInvite(ctx context.Context, in *RedeemInviteRequest, opts ...grpc.CallOption) (*RedeemInviteReply, error)
+	// GetRecordBodies from a peer.
+	GetRecordBodies(ctx context.Context, in *GetRecordBodiesRequest, opts ...grpc.CallOption) (*GetRecordBodiesReply, error)
+	// GetCapabilities of a peer.
+	GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*GetCapabilitiesReply, error)
+	// Subscribe to new records of threads at a peer.
+	Subscribe(ctx context.Context, opts ...grpc.CallOption) (Service_SubscribeClient, error)
+	// HandoffLog to a peer.
+	HandoffLog(ctx context.Context, in *HandoffLogRequest, opts ...grpc.CallOption) (*HandoffLogReply, error)
+	// PutKeyShare with a peer.
+	PutKeyShare(ctx context.Context, in *PutKeyShareRequest, opts ...grpc.CallOption) (*PutKeyShareReply, error)
+	// GetKeyShare from a peer.
+	GetKeyShare(ctx context.Context, in *GetKeyShareRequest, opts ...grpc.CallOption) (*GetKeyShareReply, error)
+	// PushRevocation to a peer.
+	PushRevocation(ctx context.Context, in *PushRevocationRequest, opts ...grpc.CallOption) (*PushRevocationReply, error)
+	// Hello negotiates the protocol version and features with a peer.
+	Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error)
 }
 
-func (m *Log_Record) Size() (n int) {
-	if m == nil {
-		return 0
+type serviceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewServiceClient(cc *grpc.ClientConn) ServiceClient {
+	return &serviceClient{cc}
+}
+
+func (c *serviceClient) GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsReply, error) {
+	out := new(GetLogsReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/GetLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	l = len(m.RecordNode)
-	if l > 0 {
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) PushLog(ctx context.Context, in *PushLogRequest, opts ...grpc.CallOption) (*PushLogReply, error) {
+	out := new(PushLogReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/PushLog", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	l = len(m.EventNode)
-	if l > 0 {
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) GetRecords(ctx context.Context, in *GetRecordsRequest, opts ...grpc.CallOption) (*GetRecordsReply, error) {
+	out := new(GetRecordsReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/GetRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	l = len(m.HeaderNode)
-	if l > 0 {
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) PushRecord(ctx context.Context, in *PushRecordRequest, opts ...grpc.CallOption) (*PushRecordReply, error) {
+	out := new(PushRecordReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/PushRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	l = len(m.BodyNode)
-	if l > 0 {
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) ExchangeEdges(ctx context.Context, in *ExchangeEdgesRequest, opts ...grpc.CallOption) (*ExchangeEdgesReply, error) {
+	out := new(ExchangeEdgesReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/ExchangeEdges", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *GetLogsRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *serviceClient) PushRecords(ctx context.Context, in *PushRecordsRequest, opts ...grpc.CallOption) (*PushRecordsReply, error) {
+	out := new(PushRecordsReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/PushRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Body != nil {
-		l = m.Body.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) RedeemInvite(ctx context.Context, in *RedeemInviteRequest, opts ...grpc.CallOption) (*RedeemInviteReply, error) {
+	out := new(RedeemInviteReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/RedeemInvite", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *GetLogsRequest_Body) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *serviceClient) GetRecordBodies(ctx context.Context, in *GetRecordBodiesRequest, opts ...grpc.CallOption) (*GetRecordBodiesReply, error) {
+	out := new(GetRecordBodiesReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/GetRecordBodies", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ThreadID != nil {
-		l = m.ThreadID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*GetCapabilitiesReply, error) {
+	out := new(GetCapabilitiesReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/GetCapabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.ServiceKey != nil {
-		l = m.ServiceKey.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) Subscribe(ctx context.Context, opts ...grpc.CallOption) (Service_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Service_serviceDesc.Streams[0], "/net.pb.Service/Subscribe", opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	x := &serviceSubscribeClient{stream}
+	return x, nil
 }
 
-func (m *GetLogsReply) Size() (n int) {
-	if m == nil {
-		return 0
+type Service_SubscribeClient interface {
+	Send(*SubscribeRequest) error
+	Recv() (*SubscribeReply, error)
+	grpc.ClientStream
+}
+
+type serviceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceSubscribeClient) Send(m *SubscribeRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *serviceSubscribeClient) Recv() (*SubscribeReply, error) {
+	m := new(SubscribeReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for _, e := range m.Logs {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
-		}
+	return m, nil
+}
+
+func (c *serviceClient) HandoffLog(ctx context.Context, in *HandoffLogRequest, opts ...grpc.CallOption) (*HandoffLogReply, error) {
+	out := new(HandoffLogReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/HandoffLog", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *PushLogRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *serviceClient) PutKeyShare(ctx context.Context, in *PutKeyShareRequest, opts ...grpc.CallOption) (*PutKeyShareReply, error) {
+	out := new(PutKeyShareReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/PutKeyShare", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Body != nil {
-		l = m.Body.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) GetKeyShare(ctx context.Context, in *GetKeyShareRequest, opts ...grpc.CallOption) (*GetKeyShareReply, error) {
+	out := new(GetKeyShareReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/GetKeyShare", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *PushLogRequest_Body) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *serviceClient) PushRevocation(ctx context.Context, in *PushRevocationRequest, opts ...grpc.CallOption) (*PushRevocationReply, error) {
+	out := new(PushRevocationReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/PushRevocation", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ThreadID != nil {
-		l = m.ThreadID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+func (c *serviceClient) Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error) {
+	out := new(HelloReply)
+	err := c.cc.Invoke(ctx, "/net.pb.Service/Hello", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.ServiceKey != nil {
-		l = m.ServiceKey.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return out, nil
+}
+
+// ServiceServer is the server API for Service service.
+type ServiceServer interface {
+	// GetLogs from a peer.
+	GetLogs(context.Context, *GetLogsRequest) (*GetLogsReply, error)
+	// PushLog to a peer.
+	PushLog(context.Context, *PushLogRequest) (*PushLogReply, error)
+	// GetRecords from a peer.
+	GetRecords(context.Context, *GetRecordsRequest) (*GetRecordsReply, error)
+	// PushRecord to a peer.
+	PushRecord(context.Context, *PushRecordRequest) (*PushRecordReply, error)
+	// ExchangeEdges with a peer.
+	ExchangeEdges(context.Context, *ExchangeEdgesRequest) (*ExchangeEdgesReply, error)
+	// PushRecords to a peer.
+	PushRecords(context.Context, *PushRecordsRequest) (*PushRecordsReply, error)
+	// RedeemInvite issued by a peer.
+	RedeemInvite(context.Context, *RedeemInviteRequest) (*RedeemInviteReply, error)
+	// GetRecordBodies from a peer.
+	GetRecordBodies(context.Context, *GetRecordBodiesRequest) (*GetRecordBodiesReply, error)
+	// GetCapabilities of a peer.
+	GetCapabilities(context.Context, *GetCapabilitiesRequest) (*GetCapabilitiesReply, error)
+	// Subscribe to new records of threads at a peer.
+	Subscribe(Service_SubscribeServer) error
+	// HandoffLog to a peer.
+	HandoffLog(context.Context, *HandoffLogRequest) (*HandoffLogReply, error)
+	// PutKeyShare with a peer.
+	PutKeyShare(context.Context, *PutKeyShareRequest) (*PutKeyShareReply, error)
+	// GetKeyShare from a peer.
+	GetKeyShare(context.Context, *GetKeyShareRequest) (*GetKeyShareReply, error)
+	// PushRevocation to a peer.
+	PushRevocation(context.Context, *PushRevocationRequest) (*PushRevocationReply, error)
+	// Hello negotiates the protocol version and features with a peer.
+	Hello(context.Context, *HelloRequest) (*HelloReply, error)
+}
+
+// UnimplementedServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedServiceServer struct {
+}
+
+func (*UnimplementedServiceServer) GetLogs(ctx context.Context, req *GetLogsRequest) (*GetLogsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
+}
+func (*UnimplementedServiceServer) PushLog(ctx context.Context, req *PushLogRequest) (*PushLogReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushLog not implemented")
+}
+func (*UnimplementedServiceServer) GetRecords(ctx context.Context, req *GetRecordsRequest) (*GetRecordsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecords not implemented")
+}
+func (*UnimplementedServiceServer) PushRecord(ctx context.Context, req *PushRecordRequest) (*PushRecordReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushRecord not implemented")
+}
+func (*UnimplementedServiceServer) ExchangeEdges(ctx context.Context, req *ExchangeEdgesRequest) (*ExchangeEdgesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExchangeEdges not implemented")
+}
+func (*UnimplementedServiceServer) PushRecords(ctx context.Context, req *PushRecordsRequest) (*PushRecordsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushRecords not implemented")
+}
+func (*UnimplementedServiceServer) RedeemInvite(ctx context.Context, req *RedeemInviteRequest) (*RedeemInviteReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeemInvite not implemented")
+}
+func (*UnimplementedServiceServer) GetRecordBodies(ctx context.Context, req *GetRecordBodiesRequest) (*GetRecordBodiesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecordBodies not implemented")
+}
+func (*UnimplementedServiceServer) GetCapabilities(ctx context.Context, req *GetCapabilitiesRequest) (*GetCapabilitiesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
+func (*UnimplementedServiceServer) Subscribe(srv Service_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (*UnimplementedServiceServer) HandoffLog(ctx context.Context, req *HandoffLogRequest) (*HandoffLogReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandoffLog not implemented")
+}
+func (*UnimplementedServiceServer) PutKeyShare(ctx context.Context, req *PutKeyShareRequest) (*PutKeyShareReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutKeyShare not implemented")
+}
+func (*UnimplementedServiceServer) GetKeyShare(ctx context.Context, req *GetKeyShareRequest) (*GetKeyShareReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeyShare not implemented")
+}
+func (*UnimplementedServiceServer) PushRevocation(ctx context.Context, req *PushRevocationRequest) (*PushRevocationReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushRevocation not implemented")
+}
+func (*UnimplementedServiceServer) Hello(ctx context.Context, req *HelloRequest) (*HelloReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
+}
+
+func RegisterServiceServer(s *grpc.Server, srv ServiceServer) {
+	s.RegisterService(&_Service_serviceDesc, srv)
+}
+
+func _Service_GetLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.ReadKey != nil {
-		l = m.ReadKey.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).GetLogs(ctx, in)
 	}
-	if m.Log != nil {
-		l = m.Log.Size()
-		n += 1 + l + sovNet(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/GetLogs",
 	}
-	return n
-}
-
-func (m *PushLogReply) Size() (n int) {
-	if m == nil {
-		return 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetLogs(ctx, req.(*GetLogsRequest))
 	}
-	var l int
-	_ = l
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *GetRecordsRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_PushLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Body != nil {
-		l = m.Body.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).PushLog(ctx, in)
 	}
-	return n
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/PushLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).PushLog(ctx, req.(*PushLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *GetRecordsRequest_Body) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_GetRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ThreadID != nil {
-		l = m.ThreadID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).GetRecords(ctx, in)
 	}
-	if m.ServiceKey != nil {
-		l = m.ServiceKey.Size()
-		n += 1 + l + sovNet(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/GetRecords",
 	}
-	if len(m.Logs) > 0 {
-		for _, e := range m.Logs {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
-		}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetRecords(ctx, req.(*GetRecordsRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *GetRecordsRequest_Body_LogEntry) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_PushRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.LogID != nil {
-		l = m.LogID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).PushRecord(ctx, in)
 	}
-	if m.Offset != nil {
-		l = m.Offset.Size()
-		n += 1 + l + sovNet(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/PushRecord",
 	}
-	if m.Limit != 0 {
-		n += 1 + sovNet(uint64(m.Limit))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).PushRecord(ctx, req.(*PushRecordRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *GetRecordsReply) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_ExchangeEdges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeEdgesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for _, e := range m.Logs {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
-		}
+	if interceptor == nil {
+		return srv.(ServiceServer).ExchangeEdges(ctx, in)
 	}
-	return n
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/ExchangeEdges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ExchangeEdges(ctx, req.(*ExchangeEdgesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *GetRecordsReply_LogEntry) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_PushRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.LogID != nil {
-		l = m.LogID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).PushRecords(ctx, in)
 	}
-	if len(m.Records) > 0 {
-		for _, e := range m.Records {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
-		}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/PushRecords",
 	}
-	if m.Log != nil {
-		l = m.Log.Size()
-		n += 1 + l + sovNet(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).PushRecords(ctx, req.(*PushRecordsRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *PushRecordRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_RedeemInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeemInviteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Body != nil {
-		l = m.Body.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).RedeemInvite(ctx, in)
 	}
-	return n
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/RedeemInvite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RedeemInvite(ctx, req.(*RedeemInviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *PushRecordRequest_Body) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_GetRecordBodies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordBodiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ThreadID != nil {
-		l = m.ThreadID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).GetRecordBodies(ctx, in)
 	}
-	if m.LogID != nil {
-		l = m.LogID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/GetRecordBodies",
 	}
-	if m.Record != nil {
-		l = m.Record.Size()
-		n += 1 + l + sovNet(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetRecordBodies(ctx, req.(*GetRecordBodiesRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *PushRecordReply) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	return n
+	if interceptor == nil {
+		return srv.(ServiceServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/GetCapabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetCapabilities(ctx, req.(*GetCapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ExchangeEdgesRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ServiceServer).Subscribe(&serviceSubscribeServer{stream})
+}
+
+type Service_SubscribeServer interface {
+	Send(*SubscribeReply) error
+	Recv() (*SubscribeRequest, error)
+	grpc.ServerStream
+}
+
+type serviceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceSubscribeServer) Send(m *SubscribeReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *serviceSubscribeServer) Recv() (*SubscribeRequest, error) {
+	m := new(SubscribeRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Body != nil {
-		l = m.Body.Size()
-		n += 1 + l + sovNet(uint64(l))
+	return m, nil
+}
+
+func _Service_HandoffLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandoffLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return n
+	if interceptor == nil {
+		return srv.(ServiceServer).HandoffLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/HandoffLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).HandoffLog(ctx, req.(*HandoffLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ExchangeEdgesRequest_Body) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_PutKeyShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutKeyShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Threads) > 0 {
-		for _, e := range m.Threads {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
-		}
+	if interceptor == nil {
+		return srv.(ServiceServer).PutKeyShare(ctx, in)
 	}
-	return n
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/PutKeyShare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).PutKeyShare(ctx, req.(*PutKeyShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ExchangeEdgesRequest_Body_ThreadEntry) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_GetKeyShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetKeyShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ThreadID != nil {
-		l = m.ThreadID.Size()
-		n += 1 + l + sovNet(uint64(l))
+	if interceptor == nil {
+		return srv.(ServiceServer).GetKeyShare(ctx, in)
 	}
-	if m.AddressEdge != 0 {
-		n += 1 + sovNet(uint64(m.AddressEdge))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/GetKeyShare",
 	}
-	if m.HeadsEdge != 0 {
-		n += 1 + sovNet(uint64(m.HeadsEdge))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetKeyShare(ctx, req.(*GetKeyShareRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ExchangeEdgesReply) Size() (n int) {
-	if m == nil {
-		return 0
+func _Service_PushRevocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRevocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).PushRevocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/PushRevocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).PushRevocation(ctx, req.(*PushRevocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).Hello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/net.pb.Service/Hello",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).Hello(ctx, req.(*HelloRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Service_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "net.pb.Service",
+	HandlerType: (*ServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLogs",
+			Handler:    _Service_GetLogs_Handler,
+		},
+		{
+			MethodName: "PushLog",
+			Handler:    _Service_PushLog_Handler,
+		},
+		{
+			MethodName: "GetRecords",
+			Handler:    _Service_GetRecords_Handler,
+		},
+		{
+			MethodName: "PushRecord",
+			Handler:    _Service_PushRecord_Handler,
+		},
+		{
+			MethodName: "ExchangeEdges",
+			Handler:    _Service_ExchangeEdges_Handler,
+		},
+		{
+			MethodName: "PushRecords",
+			Handler:    _Service_PushRecords_Handler,
+		},
+		{
+			MethodName: "RedeemInvite",
+			Handler:    _Service_RedeemInvite_Handler,
+		},
+		{
+			MethodName: "GetRecordBodies",
+			Handler:    _Service_GetRecordBodies_Handler,
+		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _Service_GetCapabilities_Handler,
+		},
+		{
+			MethodName: "HandoffLog",
+			Handler:    _Service_HandoffLog_Handler,
+		},
+		{
+			MethodName: "PutKeyShare",
+			Handler:    _Service_PutKeyShare_Handler,
+		},
+		{
+			MethodName: "GetKeyShare",
+			Handler:    _Service_GetKeyShare_Handler,
+		},
+		{
+			MethodName: "PushRevocation",
+			Handler:    _Service_PushRevocation_Handler,
+		},
+		{
+			MethodName: "Hello",
+			Handler:    _Service_Hello_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Service_Subscribe_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "net.proto",
+}
+
+func (m *Log) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *Log) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Log) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Edges) > 0 {
-		for _, e := range m.Edges {
-			l = e.Size()
-			n += 1 + l + sovNet(uint64(l))
+	if len(m.AddrsSig) > 0 {
+		i -= len(m.AddrsSig)
+		copy(dAtA[i:], m.AddrsSig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.AddrsSig)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.AddrsSeq != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.AddrsSeq))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Heads) > 0 {
+		for iNdEx := len(m.Heads) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Heads[iNdEx].Size()
+				i -= size
+				if _, err := m.Heads[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.Head != nil {
+		{
+			size := m.Head.Size()
+			i -= size
+			if _, err := m.Head.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Addrs) > 0 {
+		for iNdEx := len(m.Addrs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Addrs[iNdEx].Size()
+				i -= size
+				if _, err := m.Addrs[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.PubKey != nil {
+		{
+			size := m.PubKey.Size()
+			i -= size
+			if _, err := m.PubKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ID != nil {
+		{
+			size := m.ID.Size()
+			i -= size
+			if _, err := m.ID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Log_Record) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Log_Record) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Log_Record) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Restricted {
+		i--
+		if m.Restricted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.Extensions) > 0 {
+		i -= len(m.Extensions)
+		copy(dAtA[i:], m.Extensions)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Extensions)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Version != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Version))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.BodyNode) > 0 {
+		i -= len(m.BodyNode)
+		copy(dAtA[i:], m.BodyNode)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.BodyNode)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.HeaderNode) > 0 {
+		i -= len(m.HeaderNode)
+		copy(dAtA[i:], m.HeaderNode)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.HeaderNode)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.EventNode) > 0 {
+		i -= len(m.EventNode)
+		copy(dAtA[i:], m.EventNode)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.EventNode)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.RecordNode) > 0 {
+		i -= len(m.RecordNode)
+		copy(dAtA[i:], m.RecordNode)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.RecordNode)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetLogsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetLogsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetLogsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetLogsRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetLogsRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetLogsRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.After != nil {
+		{
+			size := m.After.Size()
+			i -= size
+			if _, err := m.After.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.ServiceKey != nil {
+		{
+			size := m.ServiceKey.Size()
+			i -= size
+			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetLogsReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetLogsReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetLogsReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.FlagsSig) > 0 {
+		i -= len(m.FlagsSig)
+		copy(dAtA[i:], m.FlagsSig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.FlagsSig)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.FlagsSigner) > 0 {
+		i -= len(m.FlagsSigner)
+		copy(dAtA[i:], m.FlagsSigner)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.FlagsSigner)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.Metadata) > 0 {
+		i -= len(m.Metadata)
+		copy(dAtA[i:], m.Metadata)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Metadata)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Next != nil {
+		{
+			size := m.Next.Size()
+			i -= size
+			if _, err := m.Next.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Flags) > 0 {
+		for iNdEx := len(m.Flags) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Flags[iNdEx])
+			copy(dAtA[i:], m.Flags[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.Flags[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Logs) > 0 {
+		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushLogRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushLogRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushLogRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushLogRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushLogRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushLogRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.FlagsSig) > 0 {
+		i -= len(m.FlagsSig)
+		copy(dAtA[i:], m.FlagsSig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.FlagsSig)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.FlagsSigner) > 0 {
+		i -= len(m.FlagsSigner)
+		copy(dAtA[i:], m.FlagsSigner)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.FlagsSigner)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Flags) > 0 {
+		for iNdEx := len(m.Flags) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Flags[iNdEx])
+			copy(dAtA[i:], m.Flags[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.Flags[iNdEx])))
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.KeyRotation) > 0 {
+		i -= len(m.KeyRotation)
+		copy(dAtA[i:], m.KeyRotation)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.KeyRotation)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Metadata) > 0 {
+		i -= len(m.Metadata)
+		copy(dAtA[i:], m.Metadata)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Metadata)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Log != nil {
+		{
+			size, err := m.Log.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.ReadKey != nil {
+		{
+			size := m.ReadKey.Size()
+			i -= size
+			if _, err := m.ReadKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.ServiceKey != nil {
+		{
+			size := m.ServiceKey.Size()
+			i -= size
+			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushLogReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushLogReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushLogReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordsRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordsRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordsRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.HeadersOnly {
+		i--
+		if m.HeadersOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Logs) > 0 {
+		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.ServiceKey != nil {
+		{
+			size := m.ServiceKey.Size()
+			i -= size
+			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordsRequest_Body_LogEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordsRequest_Body_LogEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordsRequest_Body_LogEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Heads) > 0 {
+		for iNdEx := len(m.Heads) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Heads[iNdEx].Size()
+				i -= size
+				if _, err := m.Heads[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Limit != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Offset != nil {
+		{
+			size := m.Offset.Size()
+			i -= size
+			if _, err := m.Offset.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordsReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordsReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordsReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Logs) > 0 {
+		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordsReply_LogEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordsReply_LogEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordsReply_LogEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Boundary != nil {
+		{
+			size := m.Boundary.Size()
+			i -= size
+			if _, err := m.Boundary.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Log != nil {
+		{
+			size, err := m.Log.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Records) > 0 {
+		for iNdEx := len(m.Records) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Records[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRecordRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRecordRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRecordRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRecordRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRecordRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRecordRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Record != nil {
+		{
+			size, err := m.Record.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRecordReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRecordReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRecordReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *ExchangeEdgesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExchangeEdgesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExchangeEdgesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExchangeEdgesRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExchangeEdgesRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExchangeEdgesRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Threads) > 0 {
+		for iNdEx := len(m.Threads) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Threads[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExchangeEdgesRequest_Body_ThreadEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExchangeEdgesRequest_Body_ThreadEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExchangeEdgesRequest_Body_ThreadEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LogSeqs) > 0 {
+		for iNdEx := len(m.LogSeqs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LogSeqs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.HeadsEdge != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.HeadsEdge))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.AddressEdge != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.AddressEdge))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExchangeEdgesReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExchangeEdgesReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExchangeEdgesReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Edges) > 0 {
+		for iNdEx := len(m.Edges) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Edges[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExchangeEdgesReply_ThreadEdges) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExchangeEdgesReply_ThreadEdges) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExchangeEdgesReply_ThreadEdges) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LogSeqs) > 0 {
+		for iNdEx := len(m.LogSeqs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LogSeqs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.HeadsEdge != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.HeadsEdge))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.AddressEdge != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.AddressEdge))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Exists {
+		i--
+		if m.Exists {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Backpressure) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Backpressure) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Backpressure) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.RetryAfter != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.RetryAfter))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRecordsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRecordsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRecordsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRecordsRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRecordsRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRecordsRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Records) > 0 {
+		for iNdEx := len(m.Records) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Records[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRecordsReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRecordsReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRecordsReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *Invite) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Invite) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Invite) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Sig) > 0 {
+		i -= len(m.Sig)
+		copy(dAtA[i:], m.Sig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Sig)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Body) > 0 {
+		i -= len(m.Body)
+		copy(dAtA[i:], m.Body)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Body)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Invite_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Invite_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Invite_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Encrypted {
+		i--
+		if m.Encrypted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.Bundle) > 0 {
+		i -= len(m.Bundle)
+		copy(dAtA[i:], m.Bundle)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Bundle)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Nonce) > 0 {
+		i -= len(m.Nonce)
+		copy(dAtA[i:], m.Nonce)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Nonce)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Expires != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Expires))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Role != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Role))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Addrs) > 0 {
+		for iNdEx := len(m.Addrs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Addrs[iNdEx].Size()
+				i -= size
+				if _, err := m.Addrs[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.Inviter != nil {
+		{
+			size := m.Inviter.Size()
+			i -= size
+			if _, err := m.Inviter.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RedeemInviteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RedeemInviteRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RedeemInviteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RedeemInviteRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RedeemInviteRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RedeemInviteRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Nonce) > 0 {
+		i -= len(m.Nonce)
+		copy(dAtA[i:], m.Nonce)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Nonce)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RedeemInviteReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RedeemInviteReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RedeemInviteReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Bundle) > 0 {
+		i -= len(m.Bundle)
+		copy(dAtA[i:], m.Bundle)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Bundle)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordBodiesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordBodiesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordBodiesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordBodiesRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordBodiesRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordBodiesRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Bodies) > 0 {
+		for iNdEx := len(m.Bodies) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Bodies[iNdEx].Size()
+				i -= size
+				if _, err := m.Bodies[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.ServiceKey != nil {
+		{
+			size := m.ServiceKey.Size()
+			i -= size
+			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRecordBodiesReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRecordBodiesReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRecordBodiesReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Bodies) > 0 {
+		for iNdEx := len(m.Bodies) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Bodies[iNdEx])
+			copy(dAtA[i:], m.Bodies[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.Bodies[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetCapabilitiesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetCapabilitiesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetCapabilitiesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *GetCapabilitiesReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetCapabilitiesReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetCapabilitiesReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.BodyCompression) > 0 {
+		for iNdEx := len(m.BodyCompression) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.BodyCompression[iNdEx])
+			copy(dAtA[i:], m.BodyCompression[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.BodyCompression[iNdEx])))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Compression) > 0 {
+		for iNdEx := len(m.Compression) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Compression[iNdEx])
+			copy(dAtA[i:], m.Compression[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.Compression[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Relay != nil {
+		{
+			size, err := m.Relay.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetCapabilitiesReply_Relay) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetCapabilitiesReply_Relay) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetCapabilitiesReply_Relay) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Retention != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Retention))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.MaxThreadBytes != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.MaxThreadBytes))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.MaxThreads != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.MaxThreads))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SubscribeRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscribeRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscribeRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SubscribeRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscribeRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscribeRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Token) > 0 {
+		i -= len(m.Token)
+		copy(dAtA[i:], m.Token)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Token)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Filters) > 0 {
+		for iNdEx := len(m.Filters) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Filters[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SubscribeRequest_Body_Filter) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscribeRequest_Body_Filter) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscribeRequest_Body_Filter) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LogIDs) > 0 {
+		for iNdEx := len(m.LogIDs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.LogIDs[iNdEx].Size()
+				i -= size
+				if _, err := m.LogIDs[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.ServiceKey != nil {
+		{
+			size := m.ServiceKey.Size()
+			i -= size
+			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SubscribeReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscribeReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscribeReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Record != nil {
+		{
+			size, err := m.Record.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HandoffLogRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HandoffLogRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HandoffLogRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HandoffLogRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HandoffLogRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HandoffLogRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Heads) > 0 {
+		for iNdEx := len(m.Heads) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Heads[iNdEx].Size()
+				i -= size
+				if _, err := m.Heads[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintNet(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.Sig) > 0 {
+		i -= len(m.Sig)
+		copy(dAtA[i:], m.Sig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Sig)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Head != nil {
+		{
+			size := m.Head.Size()
+			i -= size
+			if _, err := m.Head.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.ServiceKey != nil {
+		{
+			size := m.ServiceKey.Size()
+			i -= size
+			if _, err := m.ServiceKey.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HandoffLogReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HandoffLogReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HandoffLogReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *PutKeyShareRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PutKeyShareRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PutKeyShareRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PutKeyShareRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PutKeyShareRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PutKeyShareRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Sig) > 0 {
+		i -= len(m.Sig)
+		copy(dAtA[i:], m.Sig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Sig)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.RecoveryKey) > 0 {
+		i -= len(m.RecoveryKey)
+		copy(dAtA[i:], m.RecoveryKey)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.RecoveryKey)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.KeyHash) > 0 {
+		i -= len(m.KeyHash)
+		copy(dAtA[i:], m.KeyHash)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.KeyHash)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Threshold != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Threshold))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Share) > 0 {
+		i -= len(m.Share)
+		copy(dAtA[i:], m.Share)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Share)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PutKeyShareReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PutKeyShareReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PutKeyShareReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *GetKeyShareRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetKeyShareRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetKeyShareRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetKeyShareRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetKeyShareRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetKeyShareRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Sig) > 0 {
+		i -= len(m.Sig)
+		copy(dAtA[i:], m.Sig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Sig)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.RecoveryKey) > 0 {
+		i -= len(m.RecoveryKey)
+		copy(dAtA[i:], m.RecoveryKey)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.RecoveryKey)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetKeyShareReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetKeyShareReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetKeyShareReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.KeyHash) > 0 {
+		i -= len(m.KeyHash)
+		copy(dAtA[i:], m.KeyHash)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.KeyHash)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Threshold != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Threshold))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Share) > 0 {
+		i -= len(m.Share)
+		copy(dAtA[i:], m.Share)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Share)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRevocationRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRevocationRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRevocationRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Sig) > 0 {
+		i -= len(m.Sig)
+		copy(dAtA[i:], m.Sig)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Sig)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Body != nil {
+		{
+			size, err := m.Body.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRevocationRequest_Body) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRevocationRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRevocationRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Restored {
+		i--
+		if m.Restored {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.ExpiresAt != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.ExpiresAt))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.ThreadID != nil {
+		{
+			size := m.ThreadID.Size()
+			i -= size
+			if _, err := m.ThreadID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.RevokedAt != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.RevokedAt))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Identity) > 0 {
+		i -= len(m.Identity)
+		copy(dAtA[i:], m.Identity)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Identity)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushRevocationReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushRevocationReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushRevocationReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *LogSeq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LogSeq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LogSeq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Seq != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Seq))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.LogID != nil {
+		{
+			size := m.LogID.Size()
+			i -= size
+			if _, err := m.LogID.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HelloRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HelloRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HelloRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Features) > 0 {
+		for iNdEx := len(m.Features) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Features[iNdEx])
+			copy(dAtA[i:], m.Features[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.Features[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Version != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Version))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HelloReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HelloReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HelloReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Features) > 0 {
+		for iNdEx := len(m.Features) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Features[iNdEx])
+			copy(dAtA[i:], m.Features[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.Features[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Version != 0 {
+		i = encodeVarintNet(dAtA, i, uint64(m.Version))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RecordEnvelope) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RecordEnvelope) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RecordEnvelope) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.BodyChunks) > 0 {
+		for iNdEx := len(m.BodyChunks) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.BodyChunks[iNdEx])
+			copy(dAtA[i:], m.BodyChunks[iNdEx])
+			i = encodeVarintNet(dAtA, i, uint64(len(m.BodyChunks[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Record != nil {
+		{
+			size, err := m.Record.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintNet(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *EdgeGossip) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EdgeGossip) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EdgeGossip) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Mac) > 0 {
+		i -= len(m.Mac)
+		copy(dAtA[i:], m.Mac)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Mac)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Entry) > 0 {
+		i -= len(m.Entry)
+		copy(dAtA[i:], m.Entry)
+		i = encodeVarintNet(dAtA, i, uint64(len(m.Entry)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintNet(dAtA []byte, offset int, v uint64) int {
+	offset -= sovNet(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func NewPopulatedLog(r randyNet, easy bool) *Log {
+	this := &Log{}
+	this.ID = NewPopulatedProtoPeerID(r)
+	this.PubKey = NewPopulatedProtoPubKey(r)
+	v1 := r.Intn(10)
+	this.Addrs = make([]ProtoAddr, v1)
+	for i := 0; i < v1; i++ {
+		v2 := NewPopulatedProtoAddr(r)
+		this.Addrs[i] = *v2
+	}
+	this.Head = NewPopulatedProtoCid(r)
+	v3 := r.Intn(10)
+	this.Heads = make([]ProtoCid, v3)
+	for i := 0; i < v3; i++ {
+		v4 := NewPopulatedProtoCid(r)
+		this.Heads[i] = *v4
+	}
+	this.AddrsSeq = uint64(uint64(r.Uint32()))
+	v5 := r.Intn(100)
+	this.AddrsSig = make([]byte, v5)
+	for i := 0; i < v5; i++ {
+		this.AddrsSig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedLog_Record(r randyNet, easy bool) *Log_Record {
+	this := &Log_Record{}
+	v6 := r.Intn(100)
+	this.RecordNode = make([]byte, v6)
+	for i := 0; i < v6; i++ {
+		this.RecordNode[i] = byte(r.Intn(256))
+	}
+	v7 := r.Intn(100)
+	this.EventNode = make([]byte, v7)
+	for i := 0; i < v7; i++ {
+		this.EventNode[i] = byte(r.Intn(256))
+	}
+	v8 := r.Intn(100)
+	this.HeaderNode = make([]byte, v8)
+	for i := 0; i < v8; i++ {
+		this.HeaderNode[i] = byte(r.Intn(256))
+	}
+	v9 := r.Intn(100)
+	this.BodyNode = make([]byte, v9)
+	for i := 0; i < v9; i++ {
+		this.BodyNode[i] = byte(r.Intn(256))
+	}
+	this.Version = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Version *= -1
+	}
+	v10 := r.Intn(100)
+	this.Extensions = make([]byte, v10)
+	for i := 0; i < v10; i++ {
+		this.Extensions[i] = byte(r.Intn(256))
+	}
+	this.Restricted = bool(bool(r.Intn(2) == 0))
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetLogsRequest(r randyNet, easy bool) *GetLogsRequest {
+	this := &GetLogsRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedGetLogsRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetLogsRequest_Body(r randyNet, easy bool) *GetLogsRequest_Body {
+	this := &GetLogsRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ServiceKey = NewPopulatedProtoKey(r)
+	this.After = NewPopulatedProtoPeerID(r)
+	this.Limit = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Limit *= -1
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetLogsReply(r randyNet, easy bool) *GetLogsReply {
+	this := &GetLogsReply{}
+	if r.Intn(5) != 0 {
+		v11 := r.Intn(5)
+		this.Logs = make([]*Log, v11)
+		for i := 0; i < v11; i++ {
+			this.Logs[i] = NewPopulatedLog(r, easy)
+		}
+	}
+	v12 := r.Intn(10)
+	this.Flags = make([]string, v12)
+	for i := 0; i < v12; i++ {
+		this.Flags[i] = string(randStringNet(r))
+	}
+	this.Next = NewPopulatedProtoPeerID(r)
+	v13 := r.Intn(100)
+	this.Metadata = make([]byte, v13)
+	for i := 0; i < v13; i++ {
+		this.Metadata[i] = byte(r.Intn(256))
+	}
+	v14 := r.Intn(100)
+	this.FlagsSigner = make([]byte, v14)
+	for i := 0; i < v14; i++ {
+		this.FlagsSigner[i] = byte(r.Intn(256))
+	}
+	v15 := r.Intn(100)
+	this.FlagsSig = make([]byte, v15)
+	for i := 0; i < v15; i++ {
+		this.FlagsSig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushLogRequest(r randyNet, easy bool) *PushLogRequest {
+	this := &PushLogRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedPushLogRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushLogRequest_Body(r randyNet, easy bool) *PushLogRequest_Body {
+	this := &PushLogRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ServiceKey = NewPopulatedProtoKey(r)
+	this.ReadKey = NewPopulatedProtoKey(r)
+	if r.Intn(5) != 0 {
+		this.Log = NewPopulatedLog(r, easy)
+	}
+	v16 := r.Intn(100)
+	this.Metadata = make([]byte, v16)
+	for i := 0; i < v16; i++ {
+		this.Metadata[i] = byte(r.Intn(256))
+	}
+	v17 := r.Intn(100)
+	this.KeyRotation = make([]byte, v17)
+	for i := 0; i < v17; i++ {
+		this.KeyRotation[i] = byte(r.Intn(256))
+	}
+	v18 := r.Intn(10)
+	this.Flags = make([]string, v18)
+	for i := 0; i < v18; i++ {
+		this.Flags[i] = string(randStringNet(r))
+	}
+	v19 := r.Intn(100)
+	this.FlagsSigner = make([]byte, v19)
+	for i := 0; i < v19; i++ {
+		this.FlagsSigner[i] = byte(r.Intn(256))
+	}
+	v20 := r.Intn(100)
+	this.FlagsSig = make([]byte, v20)
+	for i := 0; i < v20; i++ {
+		this.FlagsSig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushLogReply(r randyNet, easy bool) *PushLogReply {
+	this := &PushLogReply{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordsRequest(r randyNet, easy bool) *GetRecordsRequest {
+	this := &GetRecordsRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedGetRecordsRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordsRequest_Body(r randyNet, easy bool) *GetRecordsRequest_Body {
+	this := &GetRecordsRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ServiceKey = NewPopulatedProtoKey(r)
+	if r.Intn(5) != 0 {
+		v21 := r.Intn(5)
+		this.Logs = make([]*GetRecordsRequest_Body_LogEntry, v21)
+		for i := 0; i < v21; i++ {
+			this.Logs[i] = NewPopulatedGetRecordsRequest_Body_LogEntry(r, easy)
+		}
+	}
+	this.HeadersOnly = bool(bool(r.Intn(2) == 0))
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordsRequest_Body_LogEntry(r randyNet, easy bool) *GetRecordsRequest_Body_LogEntry {
+	this := &GetRecordsRequest_Body_LogEntry{}
+	this.LogID = NewPopulatedProtoPeerID(r)
+	this.Offset = NewPopulatedProtoCid(r)
+	this.Limit = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Limit *= -1
+	}
+	v22 := r.Intn(10)
+	this.Heads = make([]ProtoCid, v22)
+	for i := 0; i < v22; i++ {
+		v23 := NewPopulatedProtoCid(r)
+		this.Heads[i] = *v23
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordsReply(r randyNet, easy bool) *GetRecordsReply {
+	this := &GetRecordsReply{}
+	if r.Intn(5) != 0 {
+		v24 := r.Intn(5)
+		this.Logs = make([]*GetRecordsReply_LogEntry, v24)
+		for i := 0; i < v24; i++ {
+			this.Logs[i] = NewPopulatedGetRecordsReply_LogEntry(r, easy)
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordsReply_LogEntry(r randyNet, easy bool) *GetRecordsReply_LogEntry {
+	this := &GetRecordsReply_LogEntry{}
+	this.LogID = NewPopulatedProtoPeerID(r)
+	if r.Intn(5) != 0 {
+		v25 := r.Intn(5)
+		this.Records = make([]*Log_Record, v25)
+		for i := 0; i < v25; i++ {
+			this.Records[i] = NewPopulatedLog_Record(r, easy)
+		}
+	}
+	if r.Intn(5) != 0 {
+		this.Log = NewPopulatedLog(r, easy)
+	}
+	this.Boundary = NewPopulatedProtoCid(r)
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRecordRequest(r randyNet, easy bool) *PushRecordRequest {
+	this := &PushRecordRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedPushRecordRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRecordRequest_Body(r randyNet, easy bool) *PushRecordRequest_Body {
+	this := &PushRecordRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.LogID = NewPopulatedProtoPeerID(r)
+	if r.Intn(5) != 0 {
+		this.Record = NewPopulatedLog_Record(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRecordReply(r randyNet, easy bool) *PushRecordReply {
+	this := &PushRecordReply{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedExchangeEdgesRequest(r randyNet, easy bool) *ExchangeEdgesRequest {
+	this := &ExchangeEdgesRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedExchangeEdgesRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedExchangeEdgesRequest_Body(r randyNet, easy bool) *ExchangeEdgesRequest_Body {
+	this := &ExchangeEdgesRequest_Body{}
+	if r.Intn(5) != 0 {
+		v26 := r.Intn(5)
+		this.Threads = make([]*ExchangeEdgesRequest_Body_ThreadEntry, v26)
+		for i := 0; i < v26; i++ {
+			this.Threads[i] = NewPopulatedExchangeEdgesRequest_Body_ThreadEntry(r, easy)
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedExchangeEdgesRequest_Body_ThreadEntry(r randyNet, easy bool) *ExchangeEdgesRequest_Body_ThreadEntry {
+	this := &ExchangeEdgesRequest_Body_ThreadEntry{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.AddressEdge = uint64(uint64(r.Uint32()))
+	this.HeadsEdge = uint64(uint64(r.Uint32()))
+	if r.Intn(5) != 0 {
+		v27 := r.Intn(5)
+		this.LogSeqs = make([]*LogSeq, v27)
+		for i := 0; i < v27; i++ {
+			this.LogSeqs[i] = NewPopulatedLogSeq(r, easy)
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedExchangeEdgesReply(r randyNet, easy bool) *ExchangeEdgesReply {
+	this := &ExchangeEdgesReply{}
+	if r.Intn(5) != 0 {
+		v28 := r.Intn(5)
+		this.Edges = make([]*ExchangeEdgesReply_ThreadEdges, v28)
+		for i := 0; i < v28; i++ {
+			this.Edges[i] = NewPopulatedExchangeEdgesReply_ThreadEdges(r, easy)
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedExchangeEdgesReply_ThreadEdges(r randyNet, easy bool) *ExchangeEdgesReply_ThreadEdges {
+	this := &ExchangeEdgesReply_ThreadEdges{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.Exists = bool(bool(r.Intn(2) == 0))
+	this.AddressEdge = uint64(uint64(r.Uint32()))
+	this.HeadsEdge = uint64(uint64(r.Uint32()))
+	if r.Intn(5) != 0 {
+		v29 := r.Intn(5)
+		this.LogSeqs = make([]*LogSeq, v29)
+		for i := 0; i < v29; i++ {
+			this.LogSeqs[i] = NewPopulatedLogSeq(r, easy)
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedBackpressure(r randyNet, easy bool) *Backpressure {
+	this := &Backpressure{}
+	this.RetryAfter = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.RetryAfter *= -1
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRecordsRequest(r randyNet, easy bool) *PushRecordsRequest {
+	this := &PushRecordsRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedPushRecordsRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRecordsRequest_Body(r randyNet, easy bool) *PushRecordsRequest_Body {
+	this := &PushRecordsRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.LogID = NewPopulatedProtoPeerID(r)
+	if r.Intn(5) != 0 {
+		v30 := r.Intn(5)
+		this.Records = make([]*Log_Record, v30)
+		for i := 0; i < v30; i++ {
+			this.Records[i] = NewPopulatedLog_Record(r, easy)
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRecordsReply(r randyNet, easy bool) *PushRecordsReply {
+	this := &PushRecordsReply{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedInvite(r randyNet, easy bool) *Invite {
+	this := &Invite{}
+	v31 := r.Intn(100)
+	this.Body = make([]byte, v31)
+	for i := 0; i < v31; i++ {
+		this.Body[i] = byte(r.Intn(256))
+	}
+	v32 := r.Intn(100)
+	this.Sig = make([]byte, v32)
+	for i := 0; i < v32; i++ {
+		this.Sig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedInvite_Body(r randyNet, easy bool) *Invite_Body {
+	this := &Invite_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.Inviter = NewPopulatedProtoPeerID(r)
+	v33 := r.Intn(10)
+	this.Addrs = make([]ProtoAddr, v33)
+	for i := 0; i < v33; i++ {
+		v34 := NewPopulatedProtoAddr(r)
+		this.Addrs[i] = *v34
+	}
+	this.Role = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Role *= -1
+	}
+	this.Expires = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.Expires *= -1
+	}
+	v35 := r.Intn(100)
+	this.Nonce = make([]byte, v35)
+	for i := 0; i < v35; i++ {
+		this.Nonce[i] = byte(r.Intn(256))
+	}
+	v36 := r.Intn(100)
+	this.Bundle = make([]byte, v36)
+	for i := 0; i < v36; i++ {
+		this.Bundle[i] = byte(r.Intn(256))
+	}
+	this.Encrypted = bool(bool(r.Intn(2) == 0))
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedRedeemInviteRequest(r randyNet, easy bool) *RedeemInviteRequest {
+	this := &RedeemInviteRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedRedeemInviteRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedRedeemInviteRequest_Body(r randyNet, easy bool) *RedeemInviteRequest_Body {
+	this := &RedeemInviteRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	v37 := r.Intn(100)
+	this.Nonce = make([]byte, v37)
+	for i := 0; i < v37; i++ {
+		this.Nonce[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedRedeemInviteReply(r randyNet, easy bool) *RedeemInviteReply {
+	this := &RedeemInviteReply{}
+	v38 := r.Intn(100)
+	this.Bundle = make([]byte, v38)
+	for i := 0; i < v38; i++ {
+		this.Bundle[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordBodiesRequest(r randyNet, easy bool) *GetRecordBodiesRequest {
+	this := &GetRecordBodiesRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedGetRecordBodiesRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordBodiesRequest_Body(r randyNet, easy bool) *GetRecordBodiesRequest_Body {
+	this := &GetRecordBodiesRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ServiceKey = NewPopulatedProtoKey(r)
+	v39 := r.Intn(10)
+	this.Bodies = make([]ProtoCid, v39)
+	for i := 0; i < v39; i++ {
+		v40 := NewPopulatedProtoCid(r)
+		this.Bodies[i] = *v40
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetRecordBodiesReply(r randyNet, easy bool) *GetRecordBodiesReply {
+	this := &GetRecordBodiesReply{}
+	v41 := r.Intn(10)
+	this.Bodies = make([][]byte, v41)
+	for i := 0; i < v41; i++ {
+		v42 := r.Intn(100)
+		this.Bodies[i] = make([]byte, v42)
+		for j := 0; j < v42; j++ {
+			this.Bodies[i][j] = byte(r.Intn(256))
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetCapabilitiesRequest(r randyNet, easy bool) *GetCapabilitiesRequest {
+	this := &GetCapabilitiesRequest{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetCapabilitiesReply(r randyNet, easy bool) *GetCapabilitiesReply {
+	this := &GetCapabilitiesReply{}
+	if r.Intn(5) != 0 {
+		this.Relay = NewPopulatedGetCapabilitiesReply_Relay(r, easy)
+	}
+	v43 := r.Intn(10)
+	this.Compression = make([]string, v43)
+	for i := 0; i < v43; i++ {
+		this.Compression[i] = string(randStringNet(r))
+	}
+	v44 := r.Intn(10)
+	this.BodyCompression = make([]string, v44)
+	for i := 0; i < v44; i++ {
+		this.BodyCompression[i] = string(randStringNet(r))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetCapabilitiesReply_Relay(r randyNet, easy bool) *GetCapabilitiesReply_Relay {
+	this := &GetCapabilitiesReply_Relay{}
+	this.MaxThreads = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.MaxThreads *= -1
+	}
+	this.MaxThreadBytes = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.MaxThreadBytes *= -1
+	}
+	this.Retention = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.Retention *= -1
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedSubscribeRequest(r randyNet, easy bool) *SubscribeRequest {
+	this := &SubscribeRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedSubscribeRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedSubscribeRequest_Body(r randyNet, easy bool) *SubscribeRequest_Body {
+	this := &SubscribeRequest_Body{}
+	if r.Intn(5) != 0 {
+		v45 := r.Intn(5)
+		this.Filters = make([]*SubscribeRequest_Body_Filter, v45)
+		for i := 0; i < v45; i++ {
+			this.Filters[i] = NewPopulatedSubscribeRequest_Body_Filter(r, easy)
+		}
+	}
+	this.Token = string(randStringNet(r))
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedSubscribeRequest_Body_Filter(r randyNet, easy bool) *SubscribeRequest_Body_Filter {
+	this := &SubscribeRequest_Body_Filter{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ServiceKey = NewPopulatedProtoKey(r)
+	v46 := r.Intn(10)
+	this.LogIDs = make([]ProtoPeerID, v46)
+	for i := 0; i < v46; i++ {
+		v47 := NewPopulatedProtoPeerID(r)
+		this.LogIDs[i] = *v47
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedSubscribeReply(r randyNet, easy bool) *SubscribeReply {
+	this := &SubscribeReply{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.LogID = NewPopulatedProtoPeerID(r)
+	if r.Intn(5) != 0 {
+		this.Record = NewPopulatedLog_Record(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedHandoffLogRequest(r randyNet, easy bool) *HandoffLogRequest {
+	this := &HandoffLogRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedHandoffLogRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedHandoffLogRequest_Body(r randyNet, easy bool) *HandoffLogRequest_Body {
+	this := &HandoffLogRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ServiceKey = NewPopulatedProtoKey(r)
+	this.LogID = NewPopulatedProtoPeerID(r)
+	this.Head = NewPopulatedProtoCid(r)
+	v48 := r.Intn(100)
+	this.Key = make([]byte, v48)
+	for i := 0; i < v48; i++ {
+		this.Key[i] = byte(r.Intn(256))
+	}
+	v49 := r.Intn(100)
+	this.Sig = make([]byte, v49)
+	for i := 0; i < v49; i++ {
+		this.Sig[i] = byte(r.Intn(256))
+	}
+	v50 := r.Intn(10)
+	this.Heads = make([]ProtoCid, v50)
+	for i := 0; i < v50; i++ {
+		v51 := NewPopulatedProtoCid(r)
+		this.Heads[i] = *v51
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedHandoffLogReply(r randyNet, easy bool) *HandoffLogReply {
+	this := &HandoffLogReply{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPutKeyShareRequest(r randyNet, easy bool) *PutKeyShareRequest {
+	this := &PutKeyShareRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedPutKeyShareRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPutKeyShareRequest_Body(r randyNet, easy bool) *PutKeyShareRequest_Body {
+	this := &PutKeyShareRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	v52 := r.Intn(100)
+	this.Share = make([]byte, v52)
+	for i := 0; i < v52; i++ {
+		this.Share[i] = byte(r.Intn(256))
+	}
+	this.Threshold = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Threshold *= -1
+	}
+	v53 := r.Intn(100)
+	this.KeyHash = make([]byte, v53)
+	for i := 0; i < v53; i++ {
+		this.KeyHash[i] = byte(r.Intn(256))
+	}
+	v54 := r.Intn(100)
+	this.RecoveryKey = make([]byte, v54)
+	for i := 0; i < v54; i++ {
+		this.RecoveryKey[i] = byte(r.Intn(256))
+	}
+	v55 := r.Intn(100)
+	this.Sig = make([]byte, v55)
+	for i := 0; i < v55; i++ {
+		this.Sig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPutKeyShareReply(r randyNet, easy bool) *PutKeyShareReply {
+	this := &PutKeyShareReply{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetKeyShareRequest(r randyNet, easy bool) *GetKeyShareRequest {
+	this := &GetKeyShareRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedGetKeyShareRequest_Body(r, easy)
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetKeyShareRequest_Body(r randyNet, easy bool) *GetKeyShareRequest_Body {
+	this := &GetKeyShareRequest_Body{}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	v56 := r.Intn(100)
+	this.RecoveryKey = make([]byte, v56)
+	for i := 0; i < v56; i++ {
+		this.RecoveryKey[i] = byte(r.Intn(256))
+	}
+	v57 := r.Intn(100)
+	this.Sig = make([]byte, v57)
+	for i := 0; i < v57; i++ {
+		this.Sig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedGetKeyShareReply(r randyNet, easy bool) *GetKeyShareReply {
+	this := &GetKeyShareReply{}
+	v58 := r.Intn(100)
+	this.Share = make([]byte, v58)
+	for i := 0; i < v58; i++ {
+		this.Share[i] = byte(r.Intn(256))
+	}
+	this.Threshold = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Threshold *= -1
+	}
+	v59 := r.Intn(100)
+	this.KeyHash = make([]byte, v59)
+	for i := 0; i < v59; i++ {
+		this.KeyHash[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRevocationRequest(r randyNet, easy bool) *PushRevocationRequest {
+	this := &PushRevocationRequest{}
+	if r.Intn(5) != 0 {
+		this.Body = NewPopulatedPushRevocationRequest_Body(r, easy)
+	}
+	v60 := r.Intn(100)
+	this.Sig = make([]byte, v60)
+	for i := 0; i < v60; i++ {
+		this.Sig[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRevocationRequest_Body(r randyNet, easy bool) *PushRevocationRequest_Body {
+	this := &PushRevocationRequest_Body{}
+	v61 := r.Intn(100)
+	this.Identity = make([]byte, v61)
+	for i := 0; i < v61; i++ {
+		this.Identity[i] = byte(r.Intn(256))
+	}
+	this.RevokedAt = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.RevokedAt *= -1
+	}
+	this.ThreadID = NewPopulatedProtoThreadID(r)
+	this.ExpiresAt = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.ExpiresAt *= -1
+	}
+	this.Restored = bool(bool(r.Intn(2) == 0))
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedPushRevocationReply(r randyNet, easy bool) *PushRevocationReply {
+	this := &PushRevocationReply{}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedLogSeq(r randyNet, easy bool) *LogSeq {
+	this := &LogSeq{}
+	this.LogID = NewPopulatedProtoPeerID(r)
+	this.Seq = uint64(uint64(r.Uint32()))
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedHelloRequest(r randyNet, easy bool) *HelloRequest {
+	this := &HelloRequest{}
+	this.Version = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Version *= -1
+	}
+	v62 := r.Intn(10)
+	this.Features = make([]string, v62)
+	for i := 0; i < v62; i++ {
+		this.Features[i] = string(randStringNet(r))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedHelloReply(r randyNet, easy bool) *HelloReply {
+	this := &HelloReply{}
+	this.Version = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Version *= -1
+	}
+	v63 := r.Intn(10)
+	this.Features = make([]string, v63)
+	for i := 0; i < v63; i++ {
+		this.Features[i] = string(randStringNet(r))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedRecordEnvelope(r randyNet, easy bool) *RecordEnvelope {
+	this := &RecordEnvelope{}
+	if r.Intn(5) != 0 {
+		this.Record = NewPopulatedLog_Record(r, easy)
+	}
+	v64 := r.Intn(10)
+	this.BodyChunks = make([][]byte, v64)
+	for i := 0; i < v64; i++ {
+		v65 := r.Intn(100)
+		this.BodyChunks[i] = make([]byte, v65)
+		for j := 0; j < v65; j++ {
+			this.BodyChunks[i][j] = byte(r.Intn(256))
+		}
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+func NewPopulatedEdgeGossip(r randyNet, easy bool) *EdgeGossip {
+	this := &EdgeGossip{}
+	v66 := r.Intn(100)
+	this.Entry = make([]byte, v66)
+	for i := 0; i < v66; i++ {
+		this.Entry[i] = byte(r.Intn(256))
+	}
+	v67 := r.Intn(100)
+	this.Mac = make([]byte, v67)
+	for i := 0; i < v67; i++ {
+		this.Mac[i] = byte(r.Intn(256))
+	}
+	if !easy && r.Intn(10) != 0 {
+	}
+	return this
+}
+
+type randyNet interface {
+	Float32() float32
+	Float64() float64
+	Int63() int64
+	Int31() int32
+	Uint32() uint32
+	Intn(n int) int
+}
+
+func randUTF8RuneNet(r randyNet) rune {
+	ru := r.Intn(62)
+	if ru < 10 {
+		return rune(ru + 48)
+	} else if ru < 36 {
+		return rune(ru + 55)
+	}
+	return rune(ru + 61)
+}
+func randStringNet(r randyNet) string {
+	v18 := r.Intn(100)
+	tmps := make([]rune, v18)
+	for i := 0; i < v18; i++ {
+		tmps[i] = randUTF8RuneNet(r)
+	}
+	return string(tmps)
+}
+func randUnrecognizedNet(r randyNet, maxFieldNumber int) (dAtA []byte) {
+	l := r.Intn(5)
+	for i := 0; i < l; i++ {
+		wire := r.Intn(4)
+		if wire == 3 {
+			wire = 5
+		}
+		fieldNumber := maxFieldNumber + r.Intn(100)
+		dAtA = randFieldNet(dAtA, r, fieldNumber, wire)
+	}
+	return dAtA
+}
+func randFieldNet(dAtA []byte, r randyNet, fieldNumber int, wire int) []byte {
+	key := uint32(fieldNumber)<<3 | uint32(wire)
+	switch wire {
+	case 0:
+		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
+		v19 := r.Int63()
+		if r.Intn(2) == 0 {
+			v19 *= -1
+		}
+		dAtA = encodeVarintPopulateNet(dAtA, uint64(v19))
+	case 1:
+		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
+		dAtA = append(dAtA, byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)))
+	case 2:
+		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
+		ll := r.Intn(100)
+		dAtA = encodeVarintPopulateNet(dAtA, uint64(ll))
+		for j := 0; j < ll; j++ {
+			dAtA = append(dAtA, byte(r.Intn(256)))
+		}
+	default:
+		dAtA = encodeVarintPopulateNet(dAtA, uint64(key))
+		dAtA = append(dAtA, byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)))
+	}
+	return dAtA
+}
+func encodeVarintPopulateNet(dAtA []byte, v uint64) []byte {
+	for v >= 1<<7 {
+		dAtA = append(dAtA, uint8(uint64(v)&0x7f|0x80))
+		v >>= 7
+	}
+	dAtA = append(dAtA, uint8(v))
+	return dAtA
+}
+func (m *Log) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != nil {
+		l = m.ID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.PubKey != nil {
+		l = m.PubKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Addrs) > 0 {
+		for _, e := range m.Addrs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if m.Head != nil {
+		l = m.Head.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Heads) > 0 {
+		for _, e := range m.Heads {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if m.AddrsSeq != 0 {
+		n += 1 + sovNet(uint64(m.AddrsSeq))
+	}
+	l = len(m.AddrsSig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *Log_Record) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.RecordNode)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.EventNode)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.HeaderNode)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.BodyNode)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Version != 0 {
+		n += 1 + sovNet(uint64(m.Version))
+	}
+	l = len(m.Extensions)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Restricted {
+		n += 2
+	}
+	return n
+}
+
+func (m *GetLogsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetLogsRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ServiceKey != nil {
+		l = m.ServiceKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.After != nil {
+		l = m.After.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovNet(uint64(m.Limit))
+	}
+	return n
+}
+
+func (m *GetLogsReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Logs) > 0 {
+		for _, e := range m.Logs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if len(m.Flags) > 0 {
+		for _, s := range m.Flags {
+			l = len(s)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if m.Next != nil {
+		l = m.Next.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Metadata)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.FlagsSigner)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.FlagsSig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushLogRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushLogRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ServiceKey != nil {
+		l = m.ServiceKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ReadKey != nil {
+		l = m.ReadKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Log != nil {
+		l = m.Log.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Metadata)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.KeyRotation)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Flags) > 0 {
+		for _, s := range m.Flags {
+			l = len(s)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	l = len(m.FlagsSigner)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.FlagsSig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushLogReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetRecordsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetRecordsRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ServiceKey != nil {
+		l = m.ServiceKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Logs) > 0 {
+		for _, e := range m.Logs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if m.HeadersOnly {
+		n += 2
+	}
+	return n
+}
+
+func (m *GetRecordsRequest_Body_LogEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Offset != nil {
+		l = m.Offset.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovNet(uint64(m.Limit))
+	}
+	if len(m.Heads) > 0 {
+		for _, e := range m.Heads {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *GetRecordsReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Logs) > 0 {
+		for _, e := range m.Logs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *GetRecordsReply_LogEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Records) > 0 {
+		for _, e := range m.Records {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if m.Log != nil {
+		l = m.Log.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Boundary != nil {
+		l = m.Boundary.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushRecordRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushRecordRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Record != nil {
+		l = m.Record.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushRecordReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ExchangeEdgesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *ExchangeEdgesRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Threads) > 0 {
+		for _, e := range m.Threads {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExchangeEdgesRequest_Body_ThreadEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.AddressEdge != 0 {
+		n += 1 + sovNet(uint64(m.AddressEdge))
+	}
+	if m.HeadsEdge != 0 {
+		n += 1 + sovNet(uint64(m.HeadsEdge))
+	}
+	if len(m.LogSeqs) > 0 {
+		for _, e := range m.LogSeqs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExchangeEdgesReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Edges) > 0 {
+		for _, e := range m.Edges {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExchangeEdgesReply_ThreadEdges) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Exists {
+		n += 2
+	}
+	if m.AddressEdge != 0 {
+		n += 1 + sovNet(uint64(m.AddressEdge))
+	}
+	if m.HeadsEdge != 0 {
+		n += 1 + sovNet(uint64(m.HeadsEdge))
+	}
+	if len(m.LogSeqs) > 0 {
+		for _, e := range m.LogSeqs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Backpressure) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.RetryAfter != 0 {
+		n += 1 + sovNet(uint64(m.RetryAfter))
+	}
+	return n
+}
+
+func (m *PushRecordsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushRecordsRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Records) > 0 {
+		for _, e := range m.Records {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *PushRecordsReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *Invite) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Body)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Sig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *Invite_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Inviter != nil {
+		l = m.Inviter.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Addrs) > 0 {
+		for _, e := range m.Addrs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if m.Role != 0 {
+		n += 1 + sovNet(uint64(m.Role))
+	}
+	if m.Expires != 0 {
+		n += 1 + sovNet(uint64(m.Expires))
+	}
+	l = len(m.Nonce)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Bundle)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Encrypted {
+		n += 2
+	}
+	return n
+}
+
+func (m *RedeemInviteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *RedeemInviteRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Nonce)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *RedeemInviteReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Bundle)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetRecordBodiesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetRecordBodiesRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ServiceKey != nil {
+		l = m.ServiceKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Bodies) > 0 {
+		for _, e := range m.Bodies {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *GetRecordBodiesReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Bodies) > 0 {
+		for _, b := range m.Bodies {
+			l = len(b)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *GetCapabilitiesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetCapabilitiesReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Relay != nil {
+		l = m.Relay.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Compression) > 0 {
+		for _, s := range m.Compression {
+			l = len(s)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	if len(m.BodyCompression) > 0 {
+		for _, s := range m.BodyCompression {
+			l = len(s)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *GetCapabilitiesReply_Relay) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MaxThreads != 0 {
+		n += 1 + sovNet(uint64(m.MaxThreads))
+	}
+	if m.MaxThreadBytes != 0 {
+		n += 1 + sovNet(uint64(m.MaxThreadBytes))
+	}
+	if m.Retention != 0 {
+		n += 1 + sovNet(uint64(m.Retention))
+	}
+	return n
+}
+
+func (m *SubscribeRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *SubscribeRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Filters) > 0 {
+		for _, e := range m.Filters {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *SubscribeRequest_Body_Filter) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ServiceKey != nil {
+		l = m.ServiceKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.LogIDs) > 0 {
+		for _, e := range m.LogIDs {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *SubscribeReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Record != nil {
+		l = m.Record.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *HandoffLogRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *HandoffLogRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ServiceKey != nil {
+		l = m.ServiceKey.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Head != nil {
+		l = m.Head.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Sig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.Heads) > 0 {
+		for _, e := range m.Heads {
+			l = e.Size()
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *HandoffLogReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *PutKeyShareRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PutKeyShareRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Share)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Threshold != 0 {
+		n += 1 + sovNet(uint64(m.Threshold))
+	}
+	l = len(m.KeyHash)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.RecoveryKey)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Sig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PutKeyShareReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetKeyShareRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetKeyShareRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.RecoveryKey)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Sig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetKeyShareReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Share)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Threshold != 0 {
+		n += 1 + sovNet(uint64(m.Threshold))
+	}
+	l = len(m.KeyHash)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushRevocationRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = m.Body.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Sig)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func (m *PushRevocationRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Identity)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.RevokedAt != 0 {
+		n += 1 + sovNet(uint64(m.RevokedAt))
+	}
+	if m.ThreadID != nil {
+		l = m.ThreadID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.ExpiresAt != 0 {
+		n += 1 + sovNet(uint64(m.ExpiresAt))
+	}
+	if m.Restored {
+		n += 2
+	}
+	return n
+}
+
+func (m *PushRevocationReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *LogSeq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LogID != nil {
+		l = m.LogID.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if m.Seq != 0 {
+		n += 1 + sovNet(uint64(m.Seq))
+	}
+	return n
+}
+
+func (m *HelloRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Version != 0 {
+		n += 1 + sovNet(uint64(m.Version))
+	}
+	if len(m.Features) > 0 {
+		for _, s := range m.Features {
+			l = len(s)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *HelloReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Version != 0 {
+		n += 1 + sovNet(uint64(m.Version))
+	}
+	if len(m.Features) > 0 {
+		for _, s := range m.Features {
+			l = len(s)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *RecordEnvelope) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Record != nil {
+		l = m.Record.Size()
+		n += 1 + l + sovNet(uint64(l))
+	}
+	if len(m.BodyChunks) > 0 {
+		for _, b := range m.BodyChunks {
+			l = len(b)
+			n += 1 + l + sovNet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *EdgeGossip) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Entry)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	l = len(m.Mac)
+	if l > 0 {
+		n += 1 + l + sovNet(uint64(l))
+	}
+	return n
+}
+
+func sovNet(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozNet(x uint64) (n int) {
+	return sovNet(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Log) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Log: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Log: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.ID = &v
+			if err := m.ID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PubKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPubKey
+			m.PubKey = &v
+			if err := m.PubKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addrs", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoAddr
+			m.Addrs = append(m.Addrs, v)
+			if err := m.Addrs[len(m.Addrs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Head", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoCid
+			m.Head = &v
+			if err := m.Head.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Heads", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoCid
+			m.Heads = append(m.Heads, v)
+			if err := m.Heads[len(m.Heads)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddrsSeq", wireType)
+			}
+			m.AddrsSeq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AddrsSeq |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddrsSig", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AddrsSig = append(m.AddrsSig[:0], dAtA[iNdEx:postIndex]...)
+			if m.AddrsSig == nil {
+				m.AddrsSig = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Log_Record) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Record: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Record: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RecordNode", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RecordNode = append(m.RecordNode[:0], dAtA[iNdEx:postIndex]...)
+			if m.RecordNode == nil {
+				m.RecordNode = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventNode", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventNode = append(m.EventNode[:0], dAtA[iNdEx:postIndex]...)
+			if m.EventNode == nil {
+				m.EventNode = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeaderNode", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.HeaderNode = append(m.HeaderNode[:0], dAtA[iNdEx:postIndex]...)
+			if m.HeaderNode == nil {
+				m.HeaderNode = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BodyNode", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BodyNode = append(m.BodyNode[:0], dAtA[iNdEx:postIndex]...)
+			if m.BodyNode == nil {
+				m.BodyNode = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Extensions", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Extensions = append(m.Extensions[:0], dAtA[iNdEx:postIndex]...)
+			if m.Extensions == nil {
+				m.Extensions = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Restricted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Restricted = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetLogsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetLogsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetLogsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &GetLogsRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetLogsRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoKey
+			m.ServiceKey = &v
+			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.After = &v
+			if err := m.After.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetLogsReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetLogsReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetLogsReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Logs = append(m.Logs, &Log{})
+			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Flags", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Flags = append(m.Flags, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Next", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.Next = &v
+			if err := m.Next.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metadata = append(m.Metadata[:0], dAtA[iNdEx:postIndex]...)
+			if m.Metadata == nil {
+				m.Metadata = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlagsSigner", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FlagsSigner = append(m.FlagsSigner[:0], dAtA[iNdEx:postIndex]...)
+			if m.FlagsSigner == nil {
+				m.FlagsSigner = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlagsSig", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FlagsSig = append(m.FlagsSig[:0], dAtA[iNdEx:postIndex]...)
+			if m.FlagsSig == nil {
+				m.FlagsSig = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushLogRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PushLogRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PushLogRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &PushLogRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoKey
+			m.ServiceKey = &v
+			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoKey
+			m.ReadKey = &v
+			if err := m.ReadKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Log", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Log == nil {
+				m.Log = &Log{}
+			}
+			if err := m.Log.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metadata = append(m.Metadata[:0], dAtA[iNdEx:postIndex]...)
+			if m.Metadata == nil {
+				m.Metadata = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyRotation", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.KeyRotation = append(m.KeyRotation[:0], dAtA[iNdEx:postIndex]...)
+			if m.KeyRotation == nil {
+				m.KeyRotation = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Flags", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Flags = append(m.Flags, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlagsSigner", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FlagsSigner = append(m.FlagsSigner[:0], dAtA[iNdEx:postIndex]...)
+			if m.FlagsSigner == nil {
+				m.FlagsSigner = []byte{}
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlagsSig", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FlagsSig = append(m.FlagsSig[:0], dAtA[iNdEx:postIndex]...)
+			if m.FlagsSig == nil {
+				m.FlagsSig = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushLogReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PushLogReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PushLogReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRecordsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRecordsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &GetRecordsRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordsRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoKey
+			m.ServiceKey = &v
+			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Logs = append(m.Logs, &GetRecordsRequest_Body_LogEntry{})
+			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeadersOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.HeadersOnly = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordsRequest_Body_LogEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LogEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LogEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoCid
+			m.Offset = &v
+			if err := m.Offset.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Heads", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoCid
+			m.Heads = append(m.Heads, v)
+			if err := m.Heads[len(m.Heads)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordsReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRecordsReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRecordsReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Logs = append(m.Logs, &GetRecordsReply_LogEntry{})
+			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LogEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LogEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Records", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Records = append(m.Records, &Log_Record{})
+			if err := m.Records[len(m.Records)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Log", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Log == nil {
+				m.Log = &Log{}
+			}
+			if err := m.Log.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Boundary", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoCid
+			m.Boundary = &v
+			if err := m.Boundary.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushRecordRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PushRecordRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PushRecordRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &PushRecordRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Record == nil {
+				m.Record = &Log_Record{}
+			}
+			if err := m.Record.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushRecordReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PushRecordReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PushRecordReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExchangeEdgesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExchangeEdgesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExchangeEdgesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &ExchangeEdgesRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExchangeEdgesRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Threads", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Threads = append(m.Threads, &ExchangeEdgesRequest_Body_ThreadEntry{})
+			if err := m.Threads[len(m.Threads)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExchangeEdgesRequest_Body_ThreadEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ThreadEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ThreadEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddressEdge", wireType)
+			}
+			m.AddressEdge = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AddressEdge |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeadsEdge", wireType)
+			}
+			m.HeadsEdge = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HeadsEdge |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogSeqs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LogSeqs = append(m.LogSeqs, &LogSeq{})
+			if err := m.LogSeqs[len(m.LogSeqs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExchangeEdgesReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExchangeEdgesReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExchangeEdgesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Edges", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Edges = append(m.Edges, &ExchangeEdgesReply_ThreadEdges{})
+			if err := m.Edges[len(m.Edges)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExchangeEdgesReply_ThreadEdges) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ThreadEdges: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ThreadEdges: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exists", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Exists = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddressEdge", wireType)
+			}
+			m.AddressEdge = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AddressEdge |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeadsEdge", wireType)
+			}
+			m.HeadsEdge = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HeadsEdge |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogSeqs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LogSeqs = append(m.LogSeqs, &LogSeq{})
+			if err := m.LogSeqs[len(m.LogSeqs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Backpressure) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Backpressure: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Backpressure: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetryAfter", wireType)
+			}
+			m.RetryAfter = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RetryAfter |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushRecordsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PushRecordsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PushRecordsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &PushRecordsRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushRecordsRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Records", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Records = append(m.Records, &Log_Record{})
+			if err := m.Records[len(m.Records)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PushRecordsReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PushRecordsReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PushRecordsReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Invite) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Invite: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Invite: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Body = append(m.Body[:0], dAtA[iNdEx:postIndex]...)
+			if m.Body == nil {
+				m.Body = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sig", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sig = append(m.Sig[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sig == nil {
+				m.Sig = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Invite_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Inviter", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.Inviter = &v
+			if err := m.Inviter.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addrs", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoAddr
+			m.Addrs = append(m.Addrs, v)
+			if err := m.Addrs[len(m.Addrs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Role", wireType)
+			}
+			m.Role = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Role |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expires", wireType)
+			}
+			m.Expires = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Expires |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonce", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Nonce = append(m.Nonce[:0], dAtA[iNdEx:postIndex]...)
+			if m.Nonce == nil {
+				m.Nonce = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bundle", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Bundle = append(m.Bundle[:0], dAtA[iNdEx:postIndex]...)
+			if m.Bundle == nil {
+				m.Bundle = []byte{}
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encrypted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Encrypted = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RedeemInviteRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RedeemInviteRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RedeemInviteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &RedeemInviteRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RedeemInviteRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonce", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Nonce = append(m.Nonce[:0], dAtA[iNdEx:postIndex]...)
+			if m.Nonce == nil {
+				m.Nonce = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RedeemInviteReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RedeemInviteReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RedeemInviteReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bundle", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Bundle = append(m.Bundle[:0], dAtA[iNdEx:postIndex]...)
+			if m.Bundle == nil {
+				m.Bundle = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordBodiesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRecordBodiesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRecordBodiesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Body == nil {
+				m.Body = &GetRecordBodiesRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordBodiesRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoKey
+			m.ServiceKey = &v
+			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bodies", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoCid
+			m.Bodies = append(m.Bodies, v)
+			if err := m.Bodies[len(m.Bodies)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRecordBodiesReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRecordBodiesReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRecordBodiesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bodies", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Bodies = append(m.Bodies, make([]byte, postIndex-iNdEx))
+			copy(m.Bodies[len(m.Bodies)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetCapabilitiesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetCapabilitiesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetCapabilitiesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
 
-func (m *ExchangeEdgesReply_ThreadEdges) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.ThreadID != nil {
-		l = m.ThreadID.Size()
-		n += 1 + l + sovNet(uint64(l))
-	}
-	if m.Exists {
-		n += 2
-	}
-	if m.AddressEdge != 0 {
-		n += 1 + sovNet(uint64(m.AddressEdge))
-	}
-	if m.HeadsEdge != 0 {
-		n += 1 + sovNet(uint64(m.HeadsEdge))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func sovNet(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozNet(x uint64) (n int) {
-	return sovNet(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *Log) Unmarshal(dAtA []byte) error {
+func (m *GetCapabilitiesReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3054,17 +13311,17 @@ func (m *Log) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Log: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetCapabilitiesReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Log: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetCapabilitiesReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Relay", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3074,32 +13331,33 @@ func (m *Log) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoPeerID
-			m.ID = &v
-			if err := m.ID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Relay == nil {
+				m.Relay = &GetCapabilitiesReply_Relay{}
+			}
+			if err := m.Relay.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PubKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Compression", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3109,32 +13367,29 @@ func (m *Log) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoPubKey
-			m.PubKey = &v
-			if err := m.PubKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Compression = append(m.Compression, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Addrs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field BodyCompression", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3144,32 +13399,79 @@ func (m *Log) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoAddr
-			m.Addrs = append(m.Addrs, v)
-			if err := m.Addrs[len(m.Addrs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.BodyCompression = append(m.BodyCompression, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
 				return err
 			}
-			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Head", wireType)
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
 			}
-			var byteLen int
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetCapabilitiesReply_Relay) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Relay: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Relay: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxThreads", wireType)
+			}
+			m.MaxThreads = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3179,27 +13481,49 @@ func (m *Log) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.MaxThreads |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthNet
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxThreadBytes", wireType)
 			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthNet
+			m.MaxThreadBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxThreadBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Retention", wireType)
 			}
-			var v ProtoCid
-			m.Head = &v
-			if err := m.Head.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Retention = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Retention |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
@@ -3221,7 +13545,7 @@ func (m *Log) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Log_Record) Unmarshal(dAtA []byte) error {
+func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3244,17 +13568,17 @@ func (m *Log_Record) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Record: wiretype end group for non-group")
+			return fmt.Errorf("proto: SubscribeRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Record: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SubscribeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RecordNode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3264,65 +13588,83 @@ func (m *Log_Record) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.RecordNode = append(m.RecordNode[:0], dAtA[iNdEx:postIndex]...)
-			if m.RecordNode == nil {
-				m.RecordNode = []byte{}
+			if m.Body == nil {
+				m.Body = &SubscribeRequest_Body{}
 			}
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventNode", wireType)
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			var byteLen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowNet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				byteLen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			if byteLen < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthNet
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
 			}
-			if postIndex > l {
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SubscribeRequest_Body) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
 			}
-			m.EventNode = append(m.EventNode[:0], dAtA[iNdEx:postIndex]...)
-			if m.EventNode == nil {
-				m.EventNode = []byte{}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-			iNdEx = postIndex
-		case 3:
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HeaderNode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Filters", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3332,31 +13674,31 @@ func (m *Log_Record) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.HeaderNode = append(m.HeaderNode[:0], dAtA[iNdEx:postIndex]...)
-			if m.HeaderNode == nil {
-				m.HeaderNode = []byte{}
+			m.Filters = append(m.Filters, &SubscribeRequest_Body_Filter{})
+			if err := m.Filters[len(m.Filters)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
-		case 4:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BodyNode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3366,25 +13708,23 @@ func (m *Log_Record) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.BodyNode = append(m.BodyNode[:0], dAtA[iNdEx:postIndex]...)
-			if m.BodyNode == nil {
-				m.BodyNode = []byte{}
-			}
+			m.Token = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -3407,7 +13747,7 @@ func (m *Log_Record) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetLogsRequest) Unmarshal(dAtA []byte) error {
+func (m *SubscribeRequest_Body_Filter) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3430,17 +13770,52 @@ func (m *GetLogsRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetLogsRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: Filter: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetLogsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Filter: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3450,25 +13825,59 @@ func (m *GetLogsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Body == nil {
-				m.Body = &GetLogsRequest_Body{}
+			var v ProtoKey
+			m.ServiceKey = &v
+			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogIDs", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var v ProtoPeerID
+			m.LogIDs = append(m.LogIDs, v)
+			if err := m.LogIDs[len(m.LogIDs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3493,7 +13902,7 @@ func (m *GetLogsRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetLogsRequest_Body) Unmarshal(dAtA []byte) error {
+func (m *SubscribeReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3516,10 +13925,10 @@ func (m *GetLogsRequest_Body) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+			return fmt.Errorf("proto: SubscribeReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SubscribeReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -3559,7 +13968,7 @@ func (m *GetLogsRequest_Body) Unmarshal(dAtA []byte) error {
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -3586,9 +13995,45 @@ func (m *GetLogsRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoKey
-			m.ServiceKey = &v
-			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Record == nil {
+				m.Record = &Log_Record{}
+			}
+			if err := m.Record.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3613,7 +14058,7 @@ func (m *GetLogsRequest_Body) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetLogsReply) Unmarshal(dAtA []byte) error {
+func (m *HandoffLogRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3636,15 +14081,15 @@ func (m *GetLogsReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetLogsReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: HandoffLogRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetLogsReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HandoffLogRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3671,8 +14116,10 @@ func (m *GetLogsReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Logs = append(m.Logs, &Log{})
-			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Body == nil {
+				m.Body = &HandoffLogRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3697,7 +14144,7 @@ func (m *GetLogsReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PushLogRequest) Unmarshal(dAtA []byte) error {
+func (m *HandoffLogRequest_Body) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3720,17 +14167,17 @@ func (m *PushLogRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PushLogRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PushLogRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 2:
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3740,81 +14187,100 @@ func (m *PushLogRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Body == nil {
-				m.Body = &PushLogRequest_Body{}
-			}
-			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipNet(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
 			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			if (iNdEx + skippy) > l {
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowNet
+			var v ProtoKey
+			m.ServiceKey = &v
+			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if iNdEx >= l {
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: Body: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			iNdEx = postIndex
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Head", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -3841,15 +14307,15 @@ func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoThreadID
-			m.ThreadID = &v
-			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoCid
+			m.Head = &v
+			if err := m.Head.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 2:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -3876,15 +14342,14 @@ func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoKey
-			m.ServiceKey = &v
-			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
 			}
 			iNdEx = postIndex
-		case 3:
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sig", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -3911,17 +14376,16 @@ func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoKey
-			m.ReadKey = &v
-			if err := m.ReadKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Sig = append(m.Sig[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sig == nil {
+				m.Sig = []byte{}
 			}
 			iNdEx = postIndex
-		case 4:
+		case 7:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Log", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Heads", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -3931,25 +14395,24 @@ func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Log == nil {
-				m.Log = &Log{}
-			}
-			if err := m.Log.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoCid
+			m.Heads = append(m.Heads, v)
+			if err := m.Heads[len(m.Heads)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3974,7 +14437,7 @@ func (m *PushLogRequest_Body) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PushLogReply) Unmarshal(dAtA []byte) error {
+func (m *HandoffLogReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3997,10 +14460,10 @@ func (m *PushLogReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PushLogReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: HandoffLogReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PushLogReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HandoffLogReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -4024,7 +14487,7 @@ func (m *PushLogReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetRecordsRequest) Unmarshal(dAtA []byte) error {
+func (m *PutKeyShareRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4047,13 +14510,13 @@ func (m *GetRecordsRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetRecordsRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: PutKeyShareRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetRecordsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PutKeyShareRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 2:
+		case 1:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
 			}
@@ -4083,7 +14546,7 @@ func (m *GetRecordsRequest) Unmarshal(dAtA []byte) error {
 				return io.ErrUnexpectedEOF
 			}
 			if m.Body == nil {
-				m.Body = &GetRecordsRequest_Body{}
+				m.Body = &PutKeyShareRequest_Body{}
 			}
 			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
@@ -4110,7 +14573,7 @@ func (m *GetRecordsRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetRecordsRequest_Body) Unmarshal(dAtA []byte) error {
+func (m *PutKeyShareRequest_Body) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4176,7 +14639,128 @@ func (m *GetRecordsRequest_Body) Unmarshal(dAtA []byte) error {
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServiceKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Share", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Share = append(m.Share[:0], dAtA[iNdEx:postIndex]...)
+			if m.Share == nil {
+				m.Share = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Threshold", wireType)
+			}
+			m.Threshold = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Threshold |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyHash", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.KeyHash = append(m.KeyHash[:0], dAtA[iNdEx:postIndex]...)
+			if m.KeyHash == nil {
+				m.KeyHash = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RecoveryKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RecoveryKey = append(m.RecoveryKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.RecoveryKey == nil {
+				m.RecoveryKey = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sig", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -4200,18 +14784,117 @@ func (m *GetRecordsRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
-			if postIndex > l {
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sig = append(m.Sig[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sig == nil {
+				m.Sig = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PutKeyShareReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PutKeyShareReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PutKeyShareReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthNet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetKeyShareRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNet
+			}
+			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoKey
-			m.ServiceKey = &v
-			if err := m.ServiceKey.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-			iNdEx = postIndex
-		case 3:
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetKeyShareRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetKeyShareRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4238,8 +14921,10 @@ func (m *GetRecordsRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Logs = append(m.Logs, &GetRecordsRequest_Body_LogEntry{})
-			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Body == nil {
+				m.Body = &GetKeyShareRequest_Body{}
+			}
+			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -4264,7 +14949,7 @@ func (m *GetRecordsRequest_Body) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetRecordsRequest_Body_LogEntry) Unmarshal(dAtA []byte) error {
+func (m *GetKeyShareRequest_Body) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4287,15 +14972,15 @@ func (m *GetRecordsRequest_Body_LogEntry) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogEntry: wiretype end group for non-group")
+			return fmt.Errorf("proto: Body: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -4322,15 +15007,15 @@ func (m *GetRecordsRequest_Body_LogEntry) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoPeerID
-			m.LogID = &v
-			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field RecoveryKey", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -4357,86 +15042,16 @@ func (m *GetRecordsRequest_Body_LogEntry) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoCid
-			m.Offset = &v
-			if err := m.Offset.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.RecoveryKey = append(m.RecoveryKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.RecoveryKey == nil {
+				m.RecoveryKey = []byte{}
 			}
 			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
-			}
-			m.Limit = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowNet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Limit |= int32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipNet(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthNet
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *GetRecordsReply) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowNet
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: GetRecordsReply: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetRecordsReply: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sig", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -4446,24 +15061,24 @@ func (m *GetRecordsReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Logs = append(m.Logs, &GetRecordsReply_LogEntry{})
-			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Sig = append(m.Sig[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sig == nil {
+				m.Sig = []byte{}
 			}
 			iNdEx = postIndex
 		default:
@@ -4487,7 +15102,7 @@ func (m *GetRecordsReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
+func (m *GetKeyShareReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4510,15 +15125,15 @@ func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogEntry: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetKeyShareReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetKeyShareReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Share", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -4545,17 +15160,16 @@ func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoPeerID
-			m.LogID = &v
-			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Share = append(m.Share[:0], dAtA[iNdEx:postIndex]...)
+			if m.Share == nil {
+				m.Share = []byte{}
 			}
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Records", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Threshold", wireType)
 			}
-			var msglen int
+			m.Threshold = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -4565,31 +15179,16 @@ func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Threshold |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthNet
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthNet
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Records = append(m.Records, &Log_Record{})
-			if err := m.Records[len(m.Records)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Log", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyHash", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -4599,26 +15198,24 @@ func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Log == nil {
-				m.Log = &Log{}
-			}
-			if err := m.Log.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.KeyHash = append(m.KeyHash[:0], dAtA[iNdEx:postIndex]...)
+			if m.KeyHash == nil {
+				m.KeyHash = []byte{}
 			}
 			iNdEx = postIndex
 		default:
@@ -4642,7 +15239,7 @@ func (m *GetRecordsReply_LogEntry) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PushRecordRequest) Unmarshal(dAtA []byte) error {
+func (m *PushRevocationRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4665,13 +15262,13 @@ func (m *PushRecordRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PushRecordRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: PushRevocationRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PushRecordRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PushRevocationRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 2:
+		case 1:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
 			}
@@ -4701,12 +15298,46 @@ func (m *PushRecordRequest) Unmarshal(dAtA []byte) error {
 				return io.ErrUnexpectedEOF
 			}
 			if m.Body == nil {
-				m.Body = &PushRecordRequest_Body{}
+				m.Body = &PushRevocationRequest_Body{}
 			}
 			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sig", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sig = append(m.Sig[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sig == nil {
+				m.Sig = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
@@ -4728,7 +15359,7 @@ func (m *PushRecordRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
+func (m *PushRevocationRequest_Body) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4759,7 +15390,7 @@ func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Identity", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -4786,15 +15417,33 @@ func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoThreadID
-			m.ThreadID = &v
-			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Identity = append(m.Identity[:0], dAtA[iNdEx:postIndex]...)
+			if m.Identity == nil {
+				m.Identity = []byte{}
 			}
 			iNdEx = postIndex
 		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RevokedAt", wireType)
+			}
+			m.RevokedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RevokedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -4821,17 +15470,17 @@ func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoPeerID
-			m.LogID = &v
-			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoThreadID
+			m.ThreadID = &v
+			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpiresAt", wireType)
 			}
-			var msglen int
+			m.ExpiresAt = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -4841,28 +15490,31 @@ func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ExpiresAt |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthNet
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthNet
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Record == nil {
-				m.Record = &Log_Record{}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Restored", wireType)
 			}
-			if err := m.Record.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
+			m.Restored = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
@@ -4884,7 +15536,7 @@ func (m *PushRecordRequest_Body) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PushRecordReply) Unmarshal(dAtA []byte) error {
+func (m *PushRevocationReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4907,10 +15559,10 @@ func (m *PushRecordReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PushRecordReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: PushRevocationReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PushRecordReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PushRevocationReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -4934,7 +15586,7 @@ func (m *PushRecordReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExchangeEdgesRequest) Unmarshal(dAtA []byte) error {
+func (m *LogSeq) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4957,17 +15609,17 @@ func (m *ExchangeEdgesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExchangeEdgesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: LogSeq: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExchangeEdgesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LogSeq: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 2:
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LogID", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -4977,28 +15629,46 @@ func (m *ExchangeEdgesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Body == nil {
-				m.Body = &ExchangeEdgesRequest_Body{}
-			}
-			if err := m.Body.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			var v ProtoPeerID
+			m.LogID = &v
+			if err := m.LogID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
@@ -5020,7 +15690,7 @@ func (m *ExchangeEdgesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExchangeEdgesRequest_Body) Unmarshal(dAtA []byte) error {
+func (m *HelloRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5043,17 +15713,36 @@ func (m *ExchangeEdgesRequest_Body) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Body: wiretype end group for non-group")
+			return fmt.Errorf("proto: HelloRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Body: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HelloRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Threads", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -5063,25 +15752,23 @@ func (m *ExchangeEdgesRequest_Body) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthNet
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthNet
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Threads = append(m.Threads, &ExchangeEdgesRequest_Body_ThreadEntry{})
-			if err := m.Threads[len(m.Threads)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Features = append(m.Features, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -5104,7 +15791,7 @@ func (m *ExchangeEdgesRequest_Body) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExchangeEdgesRequest_Body_ThreadEntry) Unmarshal(dAtA []byte) error {
+func (m *HelloReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5127,17 +15814,17 @@ func (m *ExchangeEdgesRequest_Body_ThreadEntry) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ThreadEntry: wiretype end group for non-group")
+			return fmt.Errorf("proto: HelloReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ThreadEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HelloReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
 			}
-			var byteLen int
+			m.Version = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -5147,32 +15834,16 @@ func (m *ExchangeEdgesRequest_Body_ThreadEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.Version |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthNet
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthNet
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			var v ProtoThreadID
-			m.ThreadID = &v
-			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AddressEdge", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
 			}
-			m.AddressEdge = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -5182,30 +15853,24 @@ func (m *ExchangeEdgesRequest_Body_ThreadEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.AddressEdge |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HeadsEdge", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNet
 			}
-			m.HeadsEdge = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowNet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.HeadsEdge |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
+			m.Features = append(m.Features, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
@@ -5227,7 +15892,7 @@ func (m *ExchangeEdgesRequest_Body_ThreadEntry) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExchangeEdgesReply) Unmarshal(dAtA []byte) error {
+func (m *RecordEnvelope) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5250,15 +15915,15 @@ func (m *ExchangeEdgesReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExchangeEdgesReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: RecordEnvelope: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExchangeEdgesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RecordEnvelope: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Edges", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5285,11 +15950,45 @@ func (m *ExchangeEdgesReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Edges = append(m.Edges, &ExchangeEdgesReply_ThreadEdges{})
-			if err := m.Edges[len(m.Edges)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Record == nil {
+				m.Record = &Log_Record{}
+			}
+			if err := m.Record.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BodyChunks", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BodyChunks = append(m.BodyChunks, make([]byte, postIndex-iNdEx))
+			copy(m.BodyChunks[len(m.BodyChunks)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
@@ -5311,7 +16010,7 @@ func (m *ExchangeEdgesReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExchangeEdgesReply_ThreadEdges) Unmarshal(dAtA []byte) error {
+func (m *EdgeGossip) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5334,15 +16033,15 @@ func (m *ExchangeEdgesReply_ThreadEdges) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ThreadEdges: wiretype end group for non-group")
+			return fmt.Errorf("proto: EdgeGossip: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ThreadEdges: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: EdgeGossip: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ThreadID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Entry", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -5369,17 +16068,16 @@ func (m *ExchangeEdgesReply_ThreadEdges) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var v ProtoThreadID
-			m.ThreadID = &v
-			if err := m.ThreadID.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Entry = append(m.Entry[:0], dAtA[iNdEx:postIndex]...)
+			if m.Entry == nil {
+				m.Entry = []byte{}
 			}
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Exists", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mac", wireType)
 			}
-			var v int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNet
@@ -5389,50 +16087,26 @@ func (m *ExchangeEdgesReply_ThreadEdges) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Exists = bool(v != 0)
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AddressEdge", wireType)
+			if byteLen < 0 {
+				return ErrInvalidLengthNet
 			}
-			m.AddressEdge = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowNet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.AddressEdge |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthNet
 			}
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HeadsEdge", wireType)
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
-			m.HeadsEdge = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowNet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.HeadsEdge |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			m.Mac = append(m.Mac[:0], dAtA[iNdEx:postIndex]...)
+			if m.Mac == nil {
+				m.Mac = []byte{}
 			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNet(dAtA[iNdEx:])
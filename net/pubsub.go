@@ -2,47 +2,186 @@ package net
 
 import (
 	"context"
+	"crypto/hmac"
 	"errors"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	mh "github.com/multiformats/go-multihash"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
 	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+// ErrPubSubDisabled indicates that the network was started without pubsub.
+var ErrPubSubDisabled = errors.New("pubsub is disabled")
+
+var (
+	// errRecordKnown indicates a record pushed over pubsub is already stored by the host.
+	errRecordKnown = errors.New("record already known")
+
+	// errInvalidRecord indicates a record pushed over pubsub is malformed or not signed by its log key.
+	errInvalidRecord = errors.New("invalid record")
+)
+
+var (
+	// DefaultPublishInterval is the default pause between publishing rounds of queued records.
+	DefaultPublishInterval = time.Millisecond * 100
+
+	// DefaultPublishQueueSize is the default number of logs with a record waiting to be published.
+	DefaultPublishQueueSize = 1024
+)
+
+const (
+	// edgesTopicSuffix is appended to the thread ID to name the thread edge gossip topic.
+	edgesTopicSuffix = "/edges"
+
+	// replayCacheSize is the number of IDs of records received over pubsub kept to drop replays.
+	replayCacheSize = 4096
 )
 
 // Handler receives all pushed thread records.
-type Handler func(context.Context, *pb.PushRecordRequest)
+type Handler func(context.Context, peer.ID, *pb.PushRecordRequest)
+
+// RecordValidator checks a record pushed to a thread over pubsub before it's handled or forwarded
+// to peers. It returns an error wrapping errInvalidRecord if the record is malformed or isn't
+// signed by the log key, errRecordKnown if the record is stored already, and lstore.ErrLogNotFound
+// if the log is unknown. Records failing for other reasons are dropped, but not rejected.
+type RecordValidator func(ctx context.Context, from peer.ID, tid thread.ID, lid peer.ID, rec *pb.Log_Record) error
+
+// TopicNamer returns the name of the pubsub topic of a thread.
+type TopicNamer func(thread.ID) (string, error)
+
+// EdgeHandler receives thread edges gossiped by peers.
+type EdgeHandler func(context.Context, peer.ID, *pb.ExchangeEdgesRequest_Body_ThreadEntry)
+
+// EdgeAuthenticator returns the MAC of the marshaled thread edges gossiped by a peer,
+// so only peers holding the thread keys can gossip edges.
+type EdgeAuthenticator func(tid thread.ID, from peer.ID, entry []byte) ([]byte, error)
+
+// PublishConfig bounds publishing of records over pubsub, so hot threads don't overwhelm gossipsub.
+type PublishConfig struct {
+	// Interval is the pause between publishing rounds. Records of a log queued within a round
+	// are coalesced, i.e., only the latest head is announced, since direct pushes and pulls
+	// deliver the records it links to. Zero means DefaultPublishInterval, a negative value
+	// publishes every record right away.
+	Interval time.Duration
+
+	// QueueSize bounds the number of logs with a record waiting to be published. Records of
+	// other logs are dropped while the queue is full. Zero means DefaultPublishQueueSize.
+	QueueSize int
+}
 
 // PubSub manages thread pubsub topics.
 type PubSub struct {
 	sync.RWMutex
 
-	ctx     context.Context
-	host    peer.ID
-	ps      *pubsub.PubSub
-	handler Handler
-	m       map[thread.ID]*topic
+	ctx       context.Context
+	clock     clock.Clock
+	host      peer.ID
+	ps        *pubsub.PubSub
+	handler   Handler
+	validator RecordValidator
+	edges     EdgeHandler
+	edgeAuth  EdgeAuthenticator
+	names     TopicNamer
+	m         map[thread.ID]*topic
+
+	// IDs of the records received recently, and validation counters by topic
+	seen       *lru.Cache
+	vlk        sync.Mutex
+	validation map[thread.ID]*core.TopicValidationStatus
+
+	// records waiting for the next publishing round, by log
+	conf   PublishConfig
+	qlk    sync.Mutex
+	queue  map[publishKey]*pb.PushRecordRequest
+	order  []publishKey
+	status core.PublishStatus
+}
+
+type publishKey struct {
+	tid thread.ID
+	lid peer.ID
 }
 
 type topic struct {
-	t *pubsub.Topic
-	h *pubsub.TopicEventHandler
-	s *pubsub.Subscription
+	name string
+	t    *pubsub.Topic
+	h    *pubsub.TopicEventHandler
+	s    *pubsub.Subscription
+
+	// edge gossip topic, nil if disabled
+	et *pubsub.Topic
+	es *pubsub.Subscription
 
 	cancel context.CancelFunc
 }
 
-// NewPubSub returns a new thread topic manager.
-func NewPubSub(ctx context.Context, host peer.ID, ps *pubsub.PubSub, handler Handler) *PubSub {
-	return &PubSub{
-		ctx:     ctx,
-		host:    host,
-		ps:      ps,
-		handler: handler,
-		m:       make(map[thread.ID]*topic),
+// NewPubSub returns a new thread topic manager. Records received from peers are handled
+// once they pass the validator.
+func NewPubSub(
+	ctx context.Context,
+	clk clock.Clock,
+	host peer.ID,
+	ps *pubsub.PubSub,
+	handler Handler,
+	validator RecordValidator,
+	conf PublishConfig,
+) (*PubSub, error) {
+	if conf.Interval == 0 {
+		conf.Interval = DefaultPublishInterval
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = DefaultPublishQueueSize
 	}
+	seen, err := lru.New(replayCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	s := &PubSub{
+		ctx:        ctx,
+		clock:      clk,
+		host:       host,
+		ps:         ps,
+		handler:    handler,
+		validator:  validator,
+		m:          make(map[thread.ID]*topic),
+		seen:       seen,
+		validation: make(map[thread.ID]*core.TopicValidationStatus),
+		conf:       conf,
+		queue:      make(map[publishKey]*pb.PushRecordRequest),
+	}
+	if conf.Interval > 0 {
+		go s.publishQueued()
+	}
+	return s, nil
+}
+
+// EnableEdgeGossip joins an edge gossip topic along with every thread topic, and passes
+// edges received from peers to the handler. Gossip is authenticated with auth, and gossip
+// failing the check is neither handled nor forwarded. It must be called before adding topics.
+func (s *PubSub) EnableEdgeGossip(handler EdgeHandler, auth EdgeAuthenticator) {
+	s.Lock()
+	defer s.Unlock()
+	s.edges = handler
+	s.edgeAuth = auth
+}
+
+// EnablePrivateTopics names thread topics with the namer instead of thread IDs, so observers
+// can't tell the threads a peer takes part in. It must be called before adding topics.
+func (s *PubSub) EnablePrivateTopics(names TopicNamer) {
+	s.Lock()
+	defer s.Unlock()
+	s.names = names
 }
 
 // Add a new thread topic. This may be called repeatedly for the same thread.
@@ -52,11 +191,47 @@ func (s *PubSub) Add(id thread.ID) error {
 	if _, ok := s.m[id]; ok {
 		return nil
 	}
+	return s.add(id)
+}
 
+// Refresh joins the thread topic again if its name changed, e.g., once the key it's
+// derived from was rotated. It does nothing if the topic wasn't added.
+func (s *PubSub) Refresh(id thread.ID) error {
+	s.Lock()
+	defer s.Unlock()
+	topic, ok := s.m[id]
+	if !ok {
+		return nil
+	}
+	if name, err := s.topicName(id); err != nil {
+		return err
+	} else if name == topic.name {
+		return nil
+	}
+	if err := s.remove(id, topic); err != nil {
+		return err
+	}
+	return s.add(id)
+}
+
+// topicName returns the name of the thread topic, the lock must be held.
+func (s *PubSub) topicName(id thread.ID) (string, error) {
+	if s.names == nil {
+		return id.String(), nil
+	}
+	return s.names(id)
+}
+
+// add joins the thread topic, the lock must be held.
+func (s *PubSub) add(id thread.ID) error {
 	if err := id.Validate(); err != nil {
 		return err
 	}
-	pt, err := s.ps.Join(id.String())
+	name, err := s.topicName(id)
+	if err != nil {
+		return err
+	}
+	pt, err := s.ps.Join(name)
 	if err != nil {
 		return err
 	}
@@ -64,22 +239,53 @@ func (s *PubSub) Add(id thread.ID) error {
 	if err != nil {
 		return err
 	}
-	if err = s.ps.RegisterTopicValidator(id.String(), s.topicValidator); err != nil {
+	if err = s.ps.RegisterTopicValidator(name, s.topicValidator(id)); err != nil {
 		return err
 	}
 
+	var et *pubsub.Topic
+	if s.edges != nil {
+		if et, err = s.joinEdges(id, name+edgesTopicSuffix); err != nil {
+			h.Cancel()
+			_ = s.ps.UnregisterTopicValidator(name)
+			_ = pt.Close()
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(s.ctx)
 	topic := &topic{
+		name:   name,
 		t:      pt,
 		h:      h,
+		et:     et,
 		cancel: cancel,
 	}
 	s.m[id] = topic
+	s.vlk.Lock()
+	s.validation[id] = &core.TopicValidationStatus{}
+	s.vlk.Unlock()
 	go s.watch(ctx, id, topic)
 	go s.subscribe(ctx, id, topic)
+	if et != nil {
+		go s.subscribeEdges(ctx, id, topic)
+	}
 	return nil
 }
 
+// joinEdges joins the edge gossip topic of a thread, validating gossip received from peers.
+func (s *PubSub) joinEdges(id thread.ID, name string) (*pubsub.Topic, error) {
+	et, err := s.ps.Join(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.ps.RegisterTopicValidator(name, s.edgeValidator(id)); err != nil {
+		_ = et.Close()
+		return nil, err
+	}
+	return et, nil
+}
+
 // Remove a thread topic. This may be called repeatedly for the same thread.
 func (s *PubSub) Remove(id thread.ID) error {
 	s.Lock()
@@ -88,28 +294,166 @@ func (s *PubSub) Remove(id thread.ID) error {
 	if !ok {
 		return nil
 	}
-	topic.s.Cancel()
-	topic.h.Cancel()
-	if err := id.Validate(); err != nil {
-		return err
+	return s.remove(id, topic)
+}
+
+// remove leaves the thread topic, the lock must be held.
+func (s *PubSub) remove(id thread.ID, topic *topic) error {
+	// stop the subscription goroutine, it may not have subscribed yet
+	topic.cancel()
+	if topic.s != nil {
+		topic.s.Cancel()
+	}
+	if topic.es != nil {
+		topic.es.Cancel()
 	}
-	if err := s.ps.UnregisterTopicValidator(id.String()); err != nil {
+	topic.h.Cancel()
+	if err := s.ps.UnregisterTopicValidator(topic.name); err != nil {
 		return err
 	}
 	if err := topic.t.Close(); err != nil {
 		return err
 	}
-	topic.cancel()
+	if topic.et != nil {
+		if err := s.ps.UnregisterTopicValidator(topic.name + edgesTopicSuffix); err != nil {
+			return err
+		}
+		if err := topic.et.Close(); err != nil {
+			return err
+		}
+	}
 	delete(s.m, id)
+	s.vlk.Lock()
+	delete(s.validation, id)
+	s.vlk.Unlock()
 	return nil
 }
 
-func (s *PubSub) topicValidator(context.Context, peer.ID, *pubsub.Message) bool {
-	// @todo: determine if this is needed (related to host signatures)
-	return true
+// Topics returns the threads with joined topics, sorted by ID.
+func (s *PubSub) Topics() []thread.ID {
+	s.RLock()
+	defer s.RUnlock()
+	ids := make(thread.IDSlice, 0, len(s.m))
+	for id := range s.m {
+		ids = append(ids, id)
+	}
+	sort.Sort(ids)
+	return ids
+}
+
+// topicValidator returns the validator of records pushed to a thread topic. Records are checked
+// before they're handled or forwarded, so peers can't inject records not signed by their log keys,
+// or make the host process stale records again.
+func (s *PubSub) topicValidator(id thread.ID) pubsub.ValidatorEx {
+	return func(ctx context.Context, from peer.ID, m *pubsub.Message) pubsub.ValidationResult {
+		if from == s.host {
+			// records published by the host are validated on creation
+			return pubsub.ValidationAccept
+		}
+		return s.validate(ctx, from, id, m.Data)
+	}
+}
+
+func (s *PubSub) validate(ctx context.Context, from peer.ID, id thread.ID, data []byte) pubsub.ValidationResult {
+	req := new(pb.PushRecordRequest)
+	if err := proto.Unmarshal(data, req); err != nil || req.Body == nil || req.Body.Record == nil ||
+		req.Body.ThreadID == nil || req.Body.LogID == nil || req.Body.ThreadID.ID != id {
+		return s.countValidation(id, pubsub.ValidationReject, func(st *core.TopicValidationStatus) { st.Rejected++ })
+	}
+	// IDs of record nodes are their hashes, so replays are dropped before decoding
+	rid, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: mh.SHA2_256}.Sum(req.Body.Record.RecordNode)
+	if err != nil {
+		return s.countValidation(id, pubsub.ValidationReject, func(st *core.TopicValidationStatus) { st.Rejected++ })
+	}
+	if s.seen.Contains(rid) {
+		return s.countValidation(id, pubsub.ValidationIgnore, func(st *core.TopicValidationStatus) { st.Replayed++ })
+	}
+
+	err = s.validator(ctx, from, id, req.Body.LogID.ID, req.Body.Record)
+	switch {
+	case err == nil:
+		s.seen.Add(rid, nil)
+		return s.countValidation(id, pubsub.ValidationAccept, func(st *core.TopicValidationStatus) { st.Accepted++ })
+	case errors.Is(err, errRecordKnown):
+		s.seen.Add(rid, nil)
+		return s.countValidation(id, pubsub.ValidationIgnore, func(st *core.TopicValidationStatus) { st.Replayed++ })
+	case errors.Is(err, lstore.ErrLogNotFound):
+		return s.countValidation(id, pubsub.ValidationIgnore, func(st *core.TopicValidationStatus) { st.UnknownLog++ })
+	case errors.Is(err, errInvalidRecord):
+		log.Debugf("rejecting pubsub record %s from %s: %v", rid, from, err)
+		return s.countValidation(id, pubsub.ValidationReject, func(st *core.TopicValidationStatus) { st.Rejected++ })
+	default:
+		log.Errorf("error validating pubsub record %s from %s: %v", rid, from, err)
+		return pubsub.ValidationIgnore
+	}
+}
+
+// edgeValidator returns the validator of edges gossiped to a thread edge topic. Gossip is
+// checked before it's handled or forwarded, so peers without the thread keys can't make
+// the host pull from them, or gossip on behalf of other peers.
+func (s *PubSub) edgeValidator(id thread.ID) pubsub.ValidatorEx {
+	return func(ctx context.Context, from peer.ID, m *pubsub.Message) pubsub.ValidationResult {
+		if from == s.host {
+			return pubsub.ValidationAccept
+		}
+		_, res := s.validateEdges(from, id, m.Data)
+		return res
+	}
+}
+
+// validateEdges decodes the edges gossiped by a peer, and checks their MAC and thread.
+func (s *PubSub) validateEdges(
+	from peer.ID,
+	id thread.ID,
+	data []byte,
+) (*pb.ExchangeEdgesRequest_Body_ThreadEntry, pubsub.ValidationResult) {
+	gossip := new(pb.EdgeGossip)
+	if err := proto.Unmarshal(data, gossip); err != nil {
+		return nil, pubsub.ValidationReject
+	}
+	mac, err := s.edgeAuth(id, from, gossip.Entry)
+	if err != nil {
+		log.Errorf("error authenticating edge gossip of %s from %s: %v", id, from, err)
+		return nil, pubsub.ValidationIgnore
+	}
+	if !hmac.Equal(mac, gossip.Mac) {
+		log.Debugf("rejecting edge gossip of %s from %s: bad mac", id, from)
+		return nil, pubsub.ValidationReject
+	}
+	entry := new(pb.ExchangeEdgesRequest_Body_ThreadEntry)
+	if err = proto.Unmarshal(gossip.Entry, entry); err != nil || entry.ThreadID == nil || !entry.ThreadID.ID.Equals(id) {
+		return nil, pubsub.ValidationReject
+	}
+	return entry, pubsub.ValidationAccept
 }
 
-// Publish a record request to a thread.
+// countValidation updates the validation counters of a topic, and passes the result through.
+func (s *PubSub) countValidation(
+	id thread.ID,
+	res pubsub.ValidationResult,
+	update func(*core.TopicValidationStatus),
+) pubsub.ValidationResult {
+	s.vlk.Lock()
+	defer s.vlk.Unlock()
+	if st, ok := s.validation[id]; ok {
+		update(st)
+	}
+	return res
+}
+
+// ValidationStatus returns the validation counters of every joined topic.
+func (s *PubSub) ValidationStatus() map[thread.ID]core.TopicValidationStatus {
+	s.vlk.Lock()
+	defer s.vlk.Unlock()
+	status := make(map[thread.ID]core.TopicValidationStatus, len(s.validation))
+	for id, st := range s.validation {
+		status[id] = *st
+	}
+	return status
+}
+
+// Publish a record request to a thread. Unless records are published right away, the request
+// is queued for the next publishing round, replacing a queued request of the same log.
 func (s *PubSub) Publish(ctx context.Context, id thread.ID, req *pb.PushRecordRequest) error {
 	s.RLock()
 	defer s.RUnlock()
@@ -117,12 +461,118 @@ func (s *PubSub) Publish(ctx context.Context, id thread.ID, req *pb.PushRecordRe
 	if !ok {
 		return errors.New("thread topic not found")
 	}
+	if s.conf.Interval < 0 {
+		return s.publish(ctx, topic, req)
+	}
+
+	key := publishKey{tid: id, lid: req.Body.LogID.ID}
+	s.qlk.Lock()
+	defer s.qlk.Unlock()
+	if _, ok := s.queue[key]; ok {
+		s.status.Coalesced++
+	} else if len(s.queue) >= s.conf.QueueSize {
+		// peers still get the record with direct pushes
+		s.status.Dropped++
+		return nil
+	} else {
+		s.order = append(s.order, key)
+	}
+	s.queue[key] = req
+	return nil
+}
+
+// PublishStatus returns the counters of records published over pubsub.
+func (s *PubSub) PublishStatus() core.PublishStatus {
+	s.qlk.Lock()
+	defer s.qlk.Unlock()
+	status := s.status
+	status.Queued = len(s.queue)
+	return status
+}
+
+// publishQueued publishes the queued records every interval until the context is done.
+func (s *PubSub) publishQueued() {
+	ticker := s.clock.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.Chan():
+			s.flush()
+		}
+	}
+}
+
+// flush publishes the queued records in the order their logs were queued.
+func (s *PubSub) flush() {
+	s.qlk.Lock()
+	queue, order := s.queue, s.order
+	s.queue, s.order = make(map[publishKey]*pb.PushRecordRequest, len(queue)), nil
+	s.qlk.Unlock()
 
+	s.RLock()
+	defer s.RUnlock()
+	for _, key := range order {
+		topic, ok := s.m[key.tid]
+		if !ok {
+			continue // topic was removed after queueing
+		}
+		if err := s.publish(s.ctx, topic, queue[key]); err != nil {
+			log.Errorf("error publishing record to %s: %s", key.tid, err)
+		}
+	}
+}
+
+func (s *PubSub) publish(ctx context.Context, topic *topic, req *pb.PushRecordRequest) error {
 	data, err := req.Marshal()
 	if err != nil {
 		return err
 	}
-	return topic.t.Publish(ctx, data)
+	err = topic.t.Publish(ctx, data)
+	s.qlk.Lock()
+	defer s.qlk.Unlock()
+	if err != nil {
+		s.status.Failed++
+		return err
+	}
+	s.status.Published++
+	return nil
+}
+
+// PublishEdges gossips the local edges of a thread to peers.
+func (s *PubSub) PublishEdges(ctx context.Context, id thread.ID, entry *pb.ExchangeEdgesRequest_Body_ThreadEntry) error {
+	s.RLock()
+	defer s.RUnlock()
+	topic, ok := s.m[id]
+	if !ok || topic.et == nil {
+		return errors.New("thread edge topic not found")
+	}
+
+	data, err := entry.Marshal()
+	if err != nil {
+		return err
+	}
+	mac, err := s.edgeAuth(id, s.host, data)
+	if err != nil {
+		return err
+	}
+	gossip := &pb.EdgeGossip{Entry: data, Mac: mac}
+	if data, err = gossip.Marshal(); err != nil {
+		return err
+	}
+	return topic.et.Publish(ctx, data)
+}
+
+// EdgePeers returns the peers gossiping edges of a thread.
+func (s *PubSub) EdgePeers(id thread.ID) []peer.ID {
+	s.RLock()
+	defer s.RUnlock()
+	topic, ok := s.m[id]
+	if !ok || topic.et == nil {
+		return nil
+	}
+	return topic.et.ListPeers()
 }
 
 // watch peer events from a pubsub topic.
@@ -147,6 +597,11 @@ func (s *PubSub) watch(ctx context.Context, id thread.ID, topic *topic) {
 func (s *PubSub) subscribe(ctx context.Context, id thread.ID, topic *topic) {
 	var err error
 	s.Lock()
+	if ctx.Err() != nil {
+		// topic was removed before subscribing
+		s.Unlock()
+		return
+	}
 	topic.s, err = topic.t.Subscribe()
 	s.Unlock()
 	if err != nil {
@@ -168,7 +623,7 @@ func (s *PubSub) subscribe(ctx context.Context, id thread.ID, topic *topic) {
 		}
 		log.Debugf("received multicast record from %s", from)
 
-		s.handler(ctx, req)
+		s.handler(ctx, from, req)
 	}
 }
 
@@ -187,3 +642,46 @@ func (s *PubSub) handleMsg(m *pubsub.Message) (from peer.ID, rec *pb.PushRecordR
 	}
 	return from, req, nil
 }
+
+// subscribeEdges to a topic for thread edges gossiped by peers.
+func (s *PubSub) subscribeEdges(ctx context.Context, id thread.ID, topic *topic) {
+	var err error
+	s.Lock()
+	if ctx.Err() != nil {
+		// topic was removed before subscribing
+		s.Unlock()
+		return
+	}
+	topic.es, err = topic.et.Subscribe()
+	s.Unlock()
+	if err != nil {
+		log.Errorf("error subscribing to edge topic %s: %s", id, err)
+		return
+	}
+
+	for {
+		msg, err := topic.es.Next(ctx)
+		if err != nil {
+			break
+		}
+		from, err := peer.IDFromBytes(msg.From)
+		if err != nil {
+			log.Errorf("error handling edge gossip: %s", err)
+			continue
+		} else if from == s.host {
+			continue
+		}
+		// the MAC and thread were checked by the topic validator
+		gossip := new(pb.EdgeGossip)
+		if err = proto.Unmarshal(msg.Data, gossip); err != nil {
+			log.Errorf("error handling edge gossip from %s: %s", from, err)
+			continue
+		}
+		entry := new(pb.ExchangeEdgesRequest_Body_ThreadEntry)
+		if err = proto.Unmarshal(gossip.Entry, entry); err != nil {
+			log.Errorf("error handling edge gossip from %s: %s", from, err)
+			continue
+		}
+		s.edges(ctx, from, entry)
+	}
+}
@@ -0,0 +1,238 @@
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+func TestNet_PublishCoalescing(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		PubSub: true,
+		// rounds are run by the test
+		Publish: PublishConfig{Interval: time.Hour, QueueSize: 1},
+	}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info1 := createThread(t, ctx, n)
+	for i := 0; i < 3; i++ {
+		if _, err = n.CreateRecord(ctx, info1.ID, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status, err := n.PublishStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Queued != 1 || status.Coalesced != 2 {
+		t.Fatalf("expected 1 queued and 2 coalesced records, got %d and %d", status.Queued, status.Coalesced)
+	}
+
+	// the queue is full
+	info2 := createThread(t, ctx, n)
+	if _, err = n.CreateRecord(ctx, info2.ID, body); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = n.PublishStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if status.Dropped != 1 {
+		t.Fatalf("expected 1 dropped record, got %d", status.Dropped)
+	}
+
+	n.server.ps.flush()
+	if status, err = n.PublishStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if status.Queued != 0 || status.Published != 1 {
+		t.Fatalf("expected the latest head to be published, got %d queued and %d published", status.Queued, status.Published)
+	}
+}
+
+func TestNet_PubSubValidation(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{PubSub: true}).(*net)
+	defer n2.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbrec, err := cbor.RecordToProto(ctx, n1, r.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// n2 knows the thread and the log, but doesn't have the record yet
+	lg, err := n1.store.GetLog(info.ID, r.LogID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.store.AddThread(thread.Info{ID: info.ID, Key: info.Key}); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.store.AddLog(info.ID, thread.LogInfo{ID: lg.ID, PubKey: lg.PubKey}); err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID, err := peer.IDFromPublicKey(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.store.AddLog(info.ID, thread.LogInfo{ID: otherID, PubKey: other}); err != nil {
+		t.Fatal(err)
+	}
+	if err = n2.server.ps.Add(info.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	push := func(tid thread.ID, lid peer.ID) []byte {
+		req := &pb.PushRecordRequest{Body: &pb.PushRecordRequest_Body{
+			ThreadID: &pb.ProtoThreadID{ID: tid},
+			LogID:    &pb.ProtoPeerID{ID: lid},
+			Record:   pbrec,
+		}}
+		data, err := req.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	unknown, err := peer.Decode("12D3KooWDpJ7As7BWAwRMfu1VU2WCqNjvq387JEYKDBj4kx6nXTN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name     string
+		data     []byte
+		expected pubsub.ValidationResult
+	}{
+		{"malformed", []byte("garbage"), pubsub.ValidationReject},
+		{"other thread", push(thread.NewIDV1(thread.Raw, 32), lg.ID), pubsub.ValidationReject},
+		{"other log key", push(info.ID, otherID), pubsub.ValidationReject},
+		{"unknown log", push(info.ID, unknown), pubsub.ValidationIgnore},
+		{"valid", push(info.ID, lg.ID), pubsub.ValidationAccept},
+		{"replayed", push(info.ID, lg.ID), pubsub.ValidationIgnore},
+	} {
+		if res := n2.server.ps.validate(ctx, n1.host.ID(), info.ID, c.data); res != c.expected {
+			t.Fatalf("%s: expected result %d, got %d", c.name, c.expected, res)
+		}
+	}
+
+	status, err := n2.ValidationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := core.TopicValidationStatus{Accepted: 1, Rejected: 3, Replayed: 1, UnknownLog: 1}
+	if status[info.ID] != expected {
+		t.Fatalf("expected status %+v, got %+v", expected, status[info.ID])
+	}
+}
+
+func TestNet_PrivateTopics(t *testing.T) {
+	t.Parallel()
+	conf := Config{PubSub: true, PrivateTopics: true, Publish: PublishConfig{Interval: -1}}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n1.Close()
+	n2 := makeNetworkWithConfig(t, tstore.NewLogstore(), conf).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	// topics are named by the service key, not the thread ID
+	name := topicName(n1, info.ID)
+	if !strings.HasPrefix(name, privateTopicPrefix) || strings.Contains(name, info.ID.String()) {
+		t.Fatalf("expected private topic name, got %s", name)
+	}
+	if other := topicName(n2, info.ID); other != name {
+		t.Fatalf("expected key holders to share topic %s, got %s", name, other)
+	}
+	for _, tp := range n1.server.ps.ps.GetTopics() {
+		if strings.Contains(tp, info.ID.String()) {
+			t.Fatalf("expected no topic named by the thread ID, got %s", tp)
+		}
+	}
+
+	// records are delivered over the private topic, once the peers joined the mesh
+	body, err := cbornode.WrapObject(map[string]interface{}{"foo": "bar"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		if _, err := n1.CreateRecord(ctx, info.ID, body); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		status, err := n2.ValidationStatus(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status[info.ID].Accepted > 0
+	})
+
+	// topics are rotated along with the service key
+	if err = n1.store.AddServiceKey(info.ID, sym.New()); err != nil {
+		t.Fatal(err)
+	}
+	n1.refreshThreadTopic(info.ID)
+	rotated := topicName(n1, info.ID)
+	if rotated == name || !strings.HasPrefix(rotated, privateTopicPrefix) {
+		t.Fatalf("expected topic to be rotated, got %s", rotated)
+	}
+	for _, tp := range n1.server.ps.ps.GetTopics() {
+		if tp == name {
+			t.Fatal("expected previous topic to be left")
+		}
+	}
+}
+
+func topicName(n *net, id thread.ID) string {
+	n.server.ps.RLock()
+	defer n.server.ps.RUnlock()
+	return n.server.ps.m[id].name
+}
@@ -0,0 +1,195 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+func (n *net) PullStatus(
+	_ context.Context,
+	id thread.ID,
+	opts ...core.ThreadOption,
+) (map[peer.ID]core.LogPullStatus, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := n.pulls.thread(id)
+	res := make(map[peer.ID]core.LogPullStatus, len(info.Logs))
+	for _, lg := range info.Logs {
+		st := tracked.logs[lg.ID]
+		st.LocalHead = lg.Head
+		st.LastExchange, st.LastError = latestOutcome(tracked.thread, st)
+		if st.RemoteHead.Defined() {
+			if known, err := n.isKnown(st.RemoteHead); err != nil {
+				return nil, err
+			} else if known {
+				st.RecordsBehind = 0
+			}
+		}
+		res[lg.ID] = st
+	}
+	return res, nil
+}
+
+// putPulledRecords adds records pulled from a peer, tracking the sync progress of the log.
+// The peer is empty for records merged from pulls of several peers.
+func (n *net) putPulledRecords(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record, pid peer.ID) error {
+	var unknown int
+	for _, r := range recs {
+		if known, err := n.isKnown(r.Cid()); err != nil {
+			return err
+		} else if !known {
+			unknown++
+		}
+	}
+	n.pulls.received(tid, lid, recs, unknown)
+	err := n.putRecords(ctx, tid, lid, recs, n.peerSource(core.SourcePull, pid))
+	n.pulls.applied(tid, lid, err)
+	return err
+}
+
+// trackExchange saves the outcome of an edge exchange of a thread with a peer.
+func (n *net) trackExchange(tid thread.ID, inSync bool, err error) {
+	var heads map[peer.ID]cid.Cid
+	if err == nil && inSync {
+		info, err := n.store.GetThread(tid)
+		if err != nil {
+			log.Errorf("getting thread %s: %v", tid, err)
+			return
+		}
+		heads = make(map[peer.ID]cid.Cid, len(info.Logs))
+		for _, lg := range info.Logs {
+			heads[lg.ID] = lg.Head
+		}
+	}
+	n.pulls.exchanged(tid, heads, err)
+}
+
+// latestOutcome returns the result of the latest thread exchange or log pull.
+func latestOutcome(thrd, lg core.LogPullStatus) (time.Time, error) {
+	var (
+		last    = thrd.LastExchange
+		lastErr = thrd.LastError
+	)
+	if lg.LastExchange.After(last) {
+		last = lg.LastExchange
+	}
+	if lg.LastError != nil {
+		lastErr = lg.LastError
+	}
+	return last, lastErr
+}
+
+// threadPulls is the tracked sync progress of a thread. The thread entry
+// keeps results of edge exchanges, which are not specific to a single log.
+type threadPulls struct {
+	thread core.LogPullStatus
+	logs   map[peer.ID]core.LogPullStatus
+}
+
+// pullTracker keeps the inbound sync progress of threads in memory.
+type pullTracker struct {
+	sync.Mutex
+	clock   clock.Clock
+	threads map[thread.ID]*threadPulls
+}
+
+func newPullTracker(clk clock.Clock) *pullTracker {
+	return &pullTracker{clock: clk, threads: make(map[thread.ID]*threadPulls)}
+}
+
+// thread returns a copy of the thread progress.
+func (t *pullTracker) thread(tid thread.ID) threadPulls {
+	t.Lock()
+	defer t.Unlock()
+	res := threadPulls{logs: make(map[peer.ID]core.LogPullStatus)}
+	if tp, ok := t.threads[tid]; ok {
+		res.thread = tp.thread
+		for lid, st := range tp.logs {
+			res.logs[lid] = st
+		}
+	}
+	return res
+}
+
+func (t *pullTracker) get(tid thread.ID) *threadPulls {
+	tp, ok := t.threads[tid]
+	if !ok {
+		tp = &threadPulls{logs: make(map[peer.ID]core.LogPullStatus)}
+		t.threads[tid] = tp
+	}
+	return tp
+}
+
+// exchanged saves the outcome of an edge exchange. Matching heads edges mean
+// that the peer has the same heads, so the local heads are known remotely.
+func (t *pullTracker) exchanged(tid thread.ID, heads map[peer.ID]cid.Cid, err error) {
+	t.Lock()
+	defer t.Unlock()
+	tp := t.get(tid)
+	if err != nil {
+		tp.thread.LastError = err
+		return
+	}
+	tp.thread.LastExchange = t.clock.Now()
+	tp.thread.LastError = nil
+	for lid, head := range heads {
+		st := tp.logs[lid]
+		st.RemoteHead = head
+		st.RecordsBehind = 0
+		st.LastError = nil
+		tp.logs[lid] = st
+	}
+}
+
+// received saves a chain of log records pulled from a peer, oldest first.
+func (t *pullTracker) received(tid thread.ID, lid peer.ID, recs []core.Record, unknown int) {
+	if len(recs) == 0 {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	tp := t.get(tid)
+	st := tp.logs[lid]
+	st.RemoteHead = recs[len(recs)-1].Cid()
+	st.RecordsBehind = unknown
+	tp.logs[lid] = st
+}
+
+// applied saves the outcome of putting pulled records of a log.
+func (t *pullTracker) applied(tid thread.ID, lid peer.ID, err error) {
+	t.Lock()
+	defer t.Unlock()
+	tp := t.get(tid)
+	st := tp.logs[lid]
+	if err != nil {
+		st.LastError = err
+	} else {
+		st.LastExchange = t.clock.Now()
+		st.LastError = nil
+	}
+	tp.logs[lid] = st
+}
+
+// forget drops the progress of a deleted thread.
+func (t *pullTracker) forget(tid thread.ID) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.threads, tid)
+}
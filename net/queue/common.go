@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"time"
 
 	logging "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -19,6 +20,15 @@ type (
 
 		// Schedule call to be invoked later.
 		Schedule(p peer.ID, t thread.ID, priority int, c PeerCall) bool
+
+		// Schedule call to be invoked until the deadline, which is earlier than the spawn deadline.
+		ScheduleBy(p peer.ID, t thread.ID, priority int, deadline time.Time, c PeerCall) bool
+
+		// Remove calls scheduled for the thread with any peer.
+		Deschedule(t thread.ID)
+
+		// Change polling frequency and spawn deadline of scheduled calls.
+		SetIntervals(pollInterval, spawnDeadline time.Duration)
 	}
 )
 
@@ -29,7 +39,8 @@ type (
 	}
 
 	ThreadPacker interface {
-		// Add thread to peer's queue
+		// Add thread to peer's queue. Threads added before the context is canceled
+		// are packed once it's done, later ones are dropped.
 		Add(pid peer.ID, tid thread.ID)
 
 		// Start packing incoming thread requests
@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+var _ CallQueue = (*dsQueue)(nil)
+
+// dsQueue is a call queue keeping scheduled calls in the datastore.
+type dsQueue struct {
+	CallQueue
+	clock  clock.Clock
+	store  ds.Datastore
+	prefix ds.Key
+	mx     sync.Mutex
+	// last is the creation time of the latest persisted call, so entries are told apart.
+	last int64
+}
+
+// persistedCall is a scheduled call restored from the datastore.
+type persistedCall struct {
+	pid      peer.ID
+	tid      thread.ID
+	priority int
+	created  int64
+}
+
+// NewDatastoreQueue returns a queue operating like NewFFQueue, which additionally keeps
+// scheduled calls in the datastore under the prefix, so they survive restarts. Since calls
+// can't be persisted, calls left from the previous run are scheduled with the restore call.
+// Their priority and order are preserved.
+func NewDatastoreQueue(
+	ctx context.Context,
+	clk clock.Clock,
+	store ds.Datastore,
+	prefix ds.Key,
+	pollInterval time.Duration,
+	spawnDeadline time.Duration,
+	restore PeerCall,
+) (*dsQueue, error) {
+	return WrapDatastore(NewFFQueue(ctx, clk, pollInterval, spawnDeadline), clk, store, prefix, restore)
+}
+
+// WrapDatastore returns a queue scheduling calls with the queue, which additionally keeps
+// them in the datastore under the prefix like NewDatastoreQueue.
+func WrapDatastore(
+	cq CallQueue,
+	clk clock.Clock,
+	store ds.Datastore,
+	prefix ds.Key,
+	restore PeerCall,
+) (*dsQueue, error) {
+	q := &dsQueue{
+		CallQueue: cq,
+		clock:     clock.OrNew(clk),
+		store:     store,
+		prefix:    prefix,
+	}
+
+	res, err := store.Query(query.Query{Prefix: prefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	var calls []persistedCall
+	for r := range res.Next() {
+		if r.Error != nil {
+			_ = res.Close()
+			return nil, r.Error
+		}
+		c, err := q.parseEntry(ds.RawKey(r.Key), r.Value)
+		if err != nil {
+			log.Warnf("skipping malformed call entry %s: %v", r.Key, err)
+			continue
+		}
+		calls = append(calls, c)
+	}
+	if err = res.Close(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(calls, func(i, j int) bool { return calls[i].created < calls[j].created })
+	for _, c := range calls {
+		q.Schedule(c.pid, c.tid, c.priority, restore)
+	}
+	if len(calls) > 0 {
+		log.Debugf("restored %d calls scheduled under %s", len(calls), prefix)
+	}
+	return q, nil
+}
+
+func (q *dsQueue) Schedule(
+	pid peer.ID,
+	tid thread.ID,
+	priority int,
+	call PeerCall,
+) bool {
+	return q.persist(pid, tid, priority, func(created int64) bool {
+		return q.CallQueue.Schedule(pid, tid, priority, q.wrap(call, created))
+	})
+}
+
+// ScheduleBy persists the call like Schedule, the deadline isn't persisted.
+func (q *dsQueue) ScheduleBy(
+	pid peer.ID,
+	tid thread.ID,
+	priority int,
+	deadline time.Time,
+	call PeerCall,
+) bool {
+	return q.persist(pid, tid, priority, func(created int64) bool {
+		return q.CallQueue.ScheduleBy(pid, tid, priority, deadline, q.wrap(call, created))
+	})
+}
+
+// persist keeps the call scheduled with the queue in the datastore. The creation time of
+// the entry is passed to schedule, which identifies the entry of the call once it's done.
+func (q *dsQueue) persist(pid peer.ID, tid thread.ID, priority int, schedule func(created int64) bool) bool {
+	key := q.key(pid, tid)
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	created := q.clock.Now().UnixNano()
+	if created <= q.last {
+		created = q.last + 1
+	}
+	if !schedule(created) {
+		// the waiting call may have been replaced with a higher-priority one
+		value, err := q.store.Get(key)
+		if err == nil && len(value) == 16 && int(binary.BigEndian.Uint64(value[8:])) < priority {
+			binary.BigEndian.PutUint64(value[8:], uint64(priority))
+			if err = q.store.Put(key, value); err != nil {
+				log.Errorf("persisting call to [%s/%s] failed: %v", pid, tid, err)
+			}
+		}
+		return false
+	}
+
+	q.last = created
+	value := make([]byte, 16)
+	binary.BigEndian.PutUint64(value, uint64(created))
+	binary.BigEndian.PutUint64(value[8:], uint64(priority))
+	if err := q.store.Put(key, value); err != nil {
+		log.Errorf("persisting call to [%s/%s] failed: %v", pid, tid, err)
+	}
+	return true
+}
+
+func (q *dsQueue) Call(
+	pid peer.ID,
+	tid thread.ID,
+	call PeerCall,
+) error {
+	return q.CallQueue.Call(pid, tid, q.wrap(call, 0))
+}
+
+func (q *dsQueue) Deschedule(tid thread.ID) {
+	q.CallQueue.Deschedule(tid)
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	res, err := q.store.Query(query.Query{Prefix: q.prefix.ChildString(tid.String()).String(), KeysOnly: true})
+	if err != nil {
+		log.Errorf("listing calls of %s failed: %v", tid, err)
+		return
+	}
+	defer res.Close()
+	for r := range res.Next() {
+		if r.Error != nil {
+			log.Errorf("listing calls of %s failed: %v", tid, r.Error)
+			return
+		}
+		if err = q.store.Delete(ds.RawKey(r.Key)); err != nil {
+			log.Errorf("removing call %s failed: %v", r.Key, err)
+		}
+	}
+}
+
+// wrap removes the persisted call once it's done, unless the entry was replaced by a call
+// scheduled meanwhile. Entries are identified by their creation time, which is zero for calls
+// made directly, as they replace the entry persisted when they start. Calls interrupted by
+// the queue shutdown are kept, so they're restored on the next run.
+func (q *dsQueue) wrap(call PeerCall, created int64) PeerCall {
+	return func(ctx context.Context, pid peer.ID, tid thread.ID) error {
+		key := q.key(pid, tid)
+		entry := created
+		if entry == 0 {
+			q.mx.Lock()
+			entry = q.created(key)
+			q.mx.Unlock()
+		}
+		err := call(ctx, pid, tid)
+		if ctx.Err() != nil {
+			return err
+		}
+		q.mx.Lock()
+		defer q.mx.Unlock()
+		if current := q.created(key); current == 0 || current != entry {
+			return err // already removed, or persisted again by a newer call
+		}
+		if e := q.store.Delete(key); e != nil {
+			log.Errorf("removing call to [%s/%s] failed: %v", pid, tid, e)
+		}
+		return err
+	}
+}
+
+// created returns the creation time of the persisted call under the key, zero if there's none.
+func (q *dsQueue) created(key ds.Key) int64 {
+	value, err := q.store.Get(key)
+	if err != nil || len(value) != 16 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(value))
+}
+
+func (q *dsQueue) key(pid peer.ID, tid thread.ID) ds.Key {
+	return q.prefix.ChildString(tid.String()).ChildString(pid.Pretty())
+}
+
+func (q *dsQueue) parseEntry(key ds.Key, value []byte) (c persistedCall, err error) {
+	parts := key.Namespaces()
+	if len(parts) < 2 {
+		err = fmt.Errorf("unexpected key length %d", len(parts))
+		return
+	}
+	if len(value) != 16 {
+		err = fmt.Errorf("unexpected value length %d", len(value))
+		return
+	}
+	if c.tid, err = thread.Decode(parts[len(parts)-2]); err != nil {
+		return
+	}
+	if c.pid, err = peer.Decode(parts[len(parts)-1]); err != nil {
+		return
+	}
+	c.created = int64(binary.BigEndian.Uint64(value))
+	c.priority = int(binary.BigEndian.Uint64(value[8:]))
+	return
+}
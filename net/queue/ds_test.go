@@ -0,0 +1,155 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+func TestDatastoreQueue_Restore(t *testing.T) {
+	var (
+		store  = syncds.MutexWrap(ds.NewMapDatastore())
+		prefix = ds.NewKey("/queue")
+		pid    = tu.RandPeerIDFatal(t)
+		t1     = thread.NewIDV1(thread.Raw, 32)
+		t2     = thread.NewIDV1(thread.Raw, 32)
+		noop   = func(context.Context, peer.ID, thread.ID) error { return nil }
+	)
+
+	// scheduled calls are never spawned before the shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	q, err := NewDatastoreQueue(ctx, nil, store, prefix, time.Hour, time.Hour, noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Schedule(pid, t1, 1, noop) || !q.Schedule(pid, t2, 1, noop) {
+		t.Fatal("no indication of scheduling new calls")
+	}
+	if q.Schedule(pid, t1, 3, noop) {
+		t.Fatal("expected call to be replaced")
+	}
+	q.Deschedule(t2)
+	cancel()
+
+	value, err := store.Get(q.key(pid, t1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priority := binary.BigEndian.Uint64(value[8:]); priority != 3 {
+		t.Fatalf("expected persisted priority 3, got %d", priority)
+	}
+
+	restored := make(chan thread.ID, 2)
+	restore := func(_ context.Context, p peer.ID, tid thread.ID) error {
+		if p != pid {
+			t.Errorf("expected call to %s, got %s", pid, p)
+		}
+		restored <- tid
+		return nil
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	if _, err = NewDatastoreQueue(ctx, nil, store, prefix, 10*time.Millisecond, 10*time.Millisecond, restore); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case tid := <-restored:
+		if tid != t1 {
+			t.Fatalf("expected call of %s to be restored, got %s", t1, tid)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected scheduled call to be restored")
+	}
+
+	for i := 0; ; i++ {
+		res, err := store.Query(query.Query{Prefix: prefix.String(), KeysOnly: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := res.Rest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) == 0 {
+			break
+		} else if i == 100 {
+			t.Fatalf("expected completed calls to be removed, got %d entries", len(entries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case tid := <-restored:
+		t.Fatalf("unexpected call of %s", tid)
+	default:
+	}
+}
+
+func TestDatastoreQueue_RescheduledWhileRunning(t *testing.T) {
+	var (
+		store = syncds.MutexWrap(ds.NewMapDatastore())
+		pid   = tu.RandPeerIDFatal(t)
+		tid   = thread.NewIDV1(thread.Raw, 32)
+		noop  = func(context.Context, peer.ID, thread.ID) error { return nil }
+		cq    = &capturingQueue{}
+	)
+	q, err := WrapDatastore(cq, nil, store, ds.NewKey("/queue"), noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted := func() bool {
+		has, err := store.Has(q.key(pid, tid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return has
+	}
+
+	// the call is scheduled again after it was spawned, but before it's done
+	q.Schedule(pid, tid, 1, noop)
+	first := cq.calls[0]
+	q.Schedule(pid, tid, 1, noop)
+	second := cq.calls[1]
+	ctx := context.Background()
+	if err = first(ctx, pid, tid); err != nil {
+		t.Fatal(err)
+	}
+	if !persisted() {
+		t.Fatal("expected the newer call to stay persisted")
+	}
+	if err = second(ctx, pid, tid); err != nil {
+		t.Fatal(err)
+	}
+	if persisted() {
+		t.Fatal("expected the completed call to be removed")
+	}
+}
+
+// capturingQueue accepts every scheduled call, leaving it to the test to run them.
+type capturingQueue struct {
+	calls []PeerCall
+}
+
+func (q *capturingQueue) Call(p peer.ID, t thread.ID, c PeerCall) error {
+	return c(context.Background(), p, t)
+}
+
+func (q *capturingQueue) Schedule(_ peer.ID, _ thread.ID, _ int, c PeerCall) bool {
+	q.calls = append(q.calls, c)
+	return true
+}
+
+func (q *capturingQueue) ScheduleBy(p peer.ID, t thread.ID, priority int, _ time.Time, c PeerCall) bool {
+	return q.Schedule(p, t, priority, c)
+}
+
+func (q *capturingQueue) Deschedule(thread.ID) {}
+
+func (q *capturingQueue) SetIntervals(time.Duration, time.Duration) {}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
 )
 
 type linkedOperation struct {
@@ -23,45 +24,77 @@ type linkedOperation struct {
 type peerQueue struct {
 	index       map[thread.ID]*linkedOperation
 	first, last *linkedOperation
+	now         func() time.Time
 	sync.Mutex
 }
 
-// Simple FIFO-queue with O(1)-operations.
+// Simple FIFO-queue, calls of higher priority are placed ahead of the lower-priority ones.
+// Operations are O(1) as long as calls are added with the same priority.
 func newPeerQueue() *peerQueue {
-	return &peerQueue{index: make(map[thread.ID]*linkedOperation)}
+	return &peerQueue{index: make(map[thread.ID]*linkedOperation), now: time.Now}
 }
 
 // Add new call to the queue or replace existing one with lower priority.
 func (q *peerQueue) Add(tid thread.ID, call PeerCall, priority int) bool {
 	op, exist := q.index[tid]
 	if !exist {
-		// append new entry at the end
 		op = &linkedOperation{
 			tid:      tid,
 			call:     call,
 			priority: priority,
-			created:  time.Now().Unix(),
-		}
-		if q.last == nil {
-			// empty queue
-			q.first = op
-			q.last = op
-		} else {
-			q.last.next = op
-			op.prev = q.last
-			q.last = op
+			created:  q.now().Unix(),
 		}
+		q.insert(op)
 		q.index[tid] = op
 		return true
 	}
 
 	if op.priority < priority {
-		// just replace the call
+		// replace the call and move it ahead of lower-priority ones
 		op.call = call
+		op.priority = priority
+		q.unlink(op)
+		q.insert(op)
 	}
 	return false
 }
 
+// insert puts the operation behind the last one of the same or higher priority.
+func (q *peerQueue) insert(op *linkedOperation) {
+	prev := q.last
+	for prev != nil && prev.priority < op.priority {
+		prev = prev.prev
+	}
+	op.prev = prev
+	if prev == nil {
+		op.next = q.first
+		q.first = op
+	} else {
+		op.next = prev.next
+		prev.next = op
+	}
+	if op.next == nil {
+		q.last = op
+	} else {
+		op.next.prev = op
+	}
+}
+
+// unlink removes the operation from the list, leaving the index intact.
+func (q *peerQueue) unlink(op *linkedOperation) {
+	if op.prev == nil {
+		q.first = op.next
+	} else {
+		op.prev.next = op.next
+	}
+	if op.next == nil {
+		q.last = op.prev
+	} else {
+		op.next.prev = op.prev
+	}
+	op.prev, op.next = nil, nil
+}
+
 // Return previously added calls in FIFO order.
 func (q *peerQueue) Pop() (PeerCall, thread.ID, int64, bool) {
 	if q.first == nil {
@@ -86,27 +119,7 @@ func (q *peerQueue) Remove(tid thread.ID) bool {
 	if !exist {
 		return false
 	}
-
-	switch {
-	case q.last == op && q.first == op:
-		// single operation - empty the queue
-		q.first = nil
-		q.last = nil
-	case q.first == op:
-		// first operation
-		next := op.next
-		next.prev = nil
-		q.first = next
-	case q.last == op:
-		// last operation
-		prev := op.prev
-		prev.next = nil
-		q.last = prev
-	default:
-		prev, next := op.prev, op.next
-		prev.next = next
-		next.prev = prev
-	}
+	q.unlink(op)
 	delete(q.index, tid)
 	return true
 }
@@ -124,6 +137,7 @@ type ffQueue struct {
 	inflight map[uint64]struct{}
 	poll     time.Duration
 	deadline time.Duration
+	clock    clock.Clock
 	ctx      context.Context
 	mx       sync.Mutex
 }
@@ -132,14 +146,18 @@ type ffQueue struct {
 // Queue is polled with specified frequency and every scheduled call expected to be
 // spawned until its deadline. At every moment only one call for the peer/thread
 // pair exists in the queue. Scheduled operations could be replaced with a new ones
-// based on the priority value (new higher-priority call replaces waiting one).
+// based on the priority value (new higher-priority call replaces waiting one), and
+// higher-priority calls are spawned ahead of the waiting lower-priority ones.
+// Polling is driven by the clock, a real one is used if it's nil.
 func NewFFQueue(
 	ctx context.Context,
+	clk clock.Clock,
 	pollInterval time.Duration,
 	spawnDeadline time.Duration,
 ) *ffQueue {
 	return &ffQueue{
 		ctx:      ctx,
+		clock:    clock.OrNew(clk),
 		poll:     pollInterval,
 		deadline: spawnDeadline,
 		inflight: make(map[uint64]struct{}),
@@ -163,6 +181,7 @@ func (q *ffQueue) Schedule(
 	pq, exist := q.peers[pid]
 	if !exist {
 		pq = newPeerQueue()
+		pq.now = q.clock.Now
 		q.peers[pid] = pq
 		go q.pollQueue(pid, pq)
 	}
@@ -173,6 +192,32 @@ func (q *ffQueue) Schedule(
 	return pq.Add(tid, call, priority)
 }
 
+func (q *ffQueue) ScheduleBy(
+	pid peer.ID,
+	tid thread.ID,
+	priority int,
+	deadline time.Time,
+	call PeerCall,
+) bool {
+	added := q.Schedule(pid, tid, priority, call)
+	_, spawnDeadline := q.intervals()
+
+	q.mx.Lock()
+	pq, exist := q.peers[pid]
+	q.mx.Unlock()
+	if exist {
+		// calls are spawned by the deadline counted from their creation
+		pq.Lock()
+		if op, ok := pq.index[tid]; ok {
+			if created := deadline.Add(-spawnDeadline).Unix(); created < op.created {
+				op.created = created
+			}
+		}
+		pq.Unlock()
+	}
+	return added
+}
+
 func (q *ffQueue) Call(
 	pid peer.ID,
 	tid thread.ID,
@@ -200,8 +245,43 @@ func (q *ffQueue) Call(
 	return err
 }
 
+func (q *ffQueue) Deschedule(tid thread.ID) {
+	q.mx.Lock()
+	pqs := make(map[peer.ID]*peerQueue, len(q.peers))
+	for pid, pq := range q.peers {
+		pqs[pid] = pq
+	}
+	q.mx.Unlock()
+
+	for pid, pq := range pqs {
+		pq.Lock()
+		if pq.Remove(tid) {
+			log.Debugf("deschedule call to [%s/%s]: thread removed", pid, tid)
+		}
+		pq.Unlock()
+	}
+}
+
+// SetIntervals changes polling frequency and spawn deadline of scheduled calls.
+// Running pollers pick up the new values on their next tick.
+func (q *ffQueue) SetIntervals(pollInterval, spawnDeadline time.Duration) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.poll = pollInterval
+	q.deadline = spawnDeadline
+}
+
+func (q *ffQueue) intervals() (time.Duration, time.Duration) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.poll, q.deadline
+}
+
 func (q *ffQueue) pollQueue(pid peer.ID, pq *peerQueue) {
-	var tick = time.NewTicker(q.poll)
+	var (
+		poll, _ = q.intervals()
+		tick    = q.clock.NewTicker(poll)
+	)
 
 	for {
 		select {
@@ -209,10 +289,16 @@ func (q *ffQueue) pollQueue(pid peer.ID, pq *peerQueue) {
 			tick.Stop()
 			return
 
-		case <-tick.C:
+		case <-tick.Chan():
+			current, deadline := q.intervals()
+			if current != poll {
+				tick.Stop()
+				poll, tick = current, q.clock.NewTicker(current)
+			}
+
 			pq.Lock()
 			// every call scheduled before this moment is overdue now and should be spawned immediately
-			var deadlineBound = time.Now().Add(-q.deadline).Unix()
+			var deadlineBound = q.clock.Now().Add(-deadline).Unix()
 			for waiting := pq.Size(); waiting > 0; waiting-- {
 				call, tid, created, ok := pq.Pop()
 				if !ok {
@@ -260,7 +346,7 @@ func (q *ffQueue) pollQueue(pid peer.ID, pq *peerQueue) {
 				// meeting deadlines in general, nevertheless it's far from perfect. So if you are
 				// aware of any better approach - please, contribute it!
 
-				if remainIters := int(float64(created-deadlineBound) / q.poll.Seconds()); remainIters > 0 &&
+				if remainIters := int(float64(created-deadlineBound) / poll.Seconds()); remainIters > 0 &&
 					rand.Float64() > math.Sqrt(3*float64(waiting))/float64(remainIters) {
 					break
 				}
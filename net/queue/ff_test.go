@@ -3,9 +3,11 @@ package queue
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
 )
 
 func TestOperationQueue(t *testing.T) {
@@ -167,3 +169,68 @@ func TestOperationQueue_Pop(t *testing.T) {
 	checkedPop(false, thread.Undef)
 	checkedPop(false, thread.Undef)
 }
+
+func TestOperationQueue_Priority(t *testing.T) {
+	var (
+		q      = newPeerQueue()
+		t1     = thread.NewIDV1(thread.Raw, 32)
+		t2     = thread.NewIDV1(thread.Raw, 32)
+		t3     = thread.NewIDV1(thread.Raw, 32)
+		t4     = thread.NewIDV1(thread.Raw, 32)
+		popped = func() thread.ID {
+			_, tid, _, _ := q.Pop()
+			return tid
+		}
+	)
+
+	// higher-priority calls go ahead, calls of the same priority keep order
+	q.Add(t1, nil, 1)
+	q.Add(t2, nil, 3)
+	q.Add(t3, nil, 3)
+	q.Add(t4, nil, 2)
+	// raising the priority moves the call ahead
+	q.Add(t1, nil, 5)
+	for _, expected := range []thread.ID{t1, t2, t3, t4} {
+		if tid := popped(); tid != expected {
+			t.Fatalf("expected call for %s, got %s", expected, tid)
+		}
+	}
+	if q.Size() != 0 || popped() != thread.Undef {
+		t.Fatal("unexpected operations in the queue")
+	}
+}
+
+func TestFFQueue_SetIntervals(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		mock        = clock.NewMock(time.Unix(0, 0))
+		q           = NewFFQueue(ctx, mock, time.Second, time.Millisecond)
+		pid         = peer.ID("peer")
+		called      = make(chan thread.ID, 2)
+		call        = func(_ context.Context, _ peer.ID, tid thread.ID) error { called <- tid; return nil }
+		t1          = thread.NewIDV1(thread.Raw, 32)
+		t2          = thread.NewIDV1(thread.Raw, 32)
+	)
+	defer cancel()
+
+	q.Schedule(pid, t1, 1, call)
+	mock.BlockUntil(1)
+	q.SetIntervals(time.Minute, time.Millisecond)
+
+	// the running poller switches to the new interval on its next tick
+	mock.Add(time.Second)
+	if tid := <-called; tid != t1 {
+		t.Fatalf("expected call for %s, got %s", t1, tid)
+	}
+	q.Schedule(pid, t2, 1, call)
+	mock.Add(time.Second)
+	select {
+	case <-called:
+		t.Fatal("expected call to wait for the new poll interval")
+	case <-time.After(100 * time.Millisecond):
+	}
+	mock.Add(time.Minute)
+	if tid := <-called; tid != t2 {
+		t.Fatalf("expected call for %s, got %s", t2, tid)
+	}
+}
@@ -0,0 +1,351 @@
+package queue
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+// agingBoost is the priority gained by a call waiting for the whole spawn deadline.
+const agingBoost = 8
+
+// Stats describes the calls of a queue lane. Counters are kept since the queue start.
+type Stats struct {
+	// Waiting is the number of scheduled calls waiting to be spawned.
+	Waiting int
+	// InFlight is the number of running calls.
+	InFlight int
+	// Spawned is the number of scheduled calls spawned.
+	Spawned int
+	// Overdue is the number of calls spawned after their deadline.
+	Overdue int
+	// TotalWait is the time spawned calls waited in the queue.
+	TotalWait time.Duration
+	// MaxWait is the longest time a spawned call waited in the queue.
+	MaxWait time.Duration
+}
+
+type callKey struct {
+	lane int
+	pid  peer.ID
+	tid  thread.ID
+}
+
+type scheduledCall struct {
+	key      callKey
+	call     PeerCall
+	priority int
+	created  time.Time
+	deadline time.Time
+}
+
+type lane struct {
+	name   string
+	weight int
+	stats  Stats
+}
+
+// PriorityQueue is a queue shared by several kinds of calls, each scheduled on its own lane.
+// Calls are ranked by their priority multiplied by the lane weight, and they gain priority
+// while waiting, so low-priority calls aren't starved by a steady flow of high-priority ones.
+// Calls missing their deadline are spawned ahead of any other. The queue is polled with the
+// specified frequency, and every poll spawns a share of waiting calls, so they're spread
+// over the spawn deadline. At most maxPeerCalls calls run for a peer at once.
+// At every moment only one call for the lane/peer/thread exists in the queue.
+// Polling is driven by the clock, a real one is used if it's nil.
+type PriorityQueue struct {
+	ctx       context.Context
+	clock     clock.Clock
+	poll      time.Duration
+	deadline  time.Duration
+	peerCalls int
+	lanes     []*lane
+	waiting   map[callKey]*scheduledCall
+	inflight  map[callKey]int
+	peers     map[peer.ID]int
+	mx        sync.Mutex
+}
+
+// NewPriorityQueue returns a queue spawning waiting calls with the given frequency
+// until their deadline, running at most maxPeerCalls calls for a peer at once.
+func NewPriorityQueue(
+	ctx context.Context,
+	clk clock.Clock,
+	pollInterval time.Duration,
+	spawnDeadline time.Duration,
+	maxPeerCalls int,
+) *PriorityQueue {
+	q := &PriorityQueue{
+		ctx:       ctx,
+		clock:     clock.OrNew(clk),
+		poll:      pollInterval,
+		deadline:  spawnDeadline,
+		peerCalls: maxPeerCalls,
+		waiting:   make(map[callKey]*scheduledCall),
+		inflight:  make(map[callKey]int),
+		peers:     make(map[peer.ID]int),
+	}
+	go q.pollQueue()
+	return q
+}
+
+// Lane returns a queue of calls sharing the queue with other lanes.
+// The priority of its calls is multiplied by the weight.
+func (q *PriorityQueue) Lane(name string, weight int) CallQueue {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.lanes = append(q.lanes, &lane{name: name, weight: weight})
+	return &laneQueue{q: q, lane: len(q.lanes) - 1}
+}
+
+// SetIntervals changes polling frequency and spawn deadline of scheduled calls.
+// The poller picks up the new values on its next tick.
+func (q *PriorityQueue) SetIntervals(pollInterval, spawnDeadline time.Duration) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.poll = pollInterval
+	q.deadline = spawnDeadline
+}
+
+// Stats returns the stats of every lane by its name.
+func (q *PriorityQueue) Stats() map[string]Stats {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	stats := make(map[string]Stats, len(q.lanes))
+	for i, l := range q.lanes {
+		s := l.stats
+		for key := range q.waiting {
+			if key.lane == i {
+				s.Waiting++
+			}
+		}
+		for key, n := range q.inflight {
+			if key.lane == i {
+				s.InFlight += n
+			}
+		}
+		stats[l.name] = s
+	}
+	return stats
+}
+
+func (q *PriorityQueue) schedule(key callKey, priority int, deadline time.Time, call PeerCall) bool {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if _, inflight := q.inflight[key]; inflight {
+		log.Debugf("skip call to [%s/%s]: in-flight", key.pid, key.tid)
+		return false
+	}
+	if deadline.IsZero() {
+		deadline = q.clock.Now().Add(q.deadline)
+	}
+	if sc, exist := q.waiting[key]; exist {
+		// replace the call with a higher-priority one, and keep the earlier deadline
+		if sc.priority < priority {
+			sc.call = call
+			sc.priority = priority
+		}
+		if deadline.Before(sc.deadline) {
+			sc.deadline = deadline
+		}
+		return false
+	}
+	q.waiting[key] = &scheduledCall{
+		key:      key,
+		call:     call,
+		priority: priority,
+		created:  q.clock.Now(),
+		deadline: deadline,
+	}
+	return true
+}
+
+func (q *PriorityQueue) call(key callKey, call PeerCall) error {
+	q.mx.Lock()
+	if _, exist := q.waiting[key]; exist {
+		delete(q.waiting, key)
+		log.Debugf("deschedule call to [%s/%s]: directly invoked", key.pid, key.tid)
+	}
+	q.start(key)
+	q.mx.Unlock()
+
+	defer q.done(key)
+	return call(q.ctx, key.pid, key.tid)
+}
+
+func (q *PriorityQueue) deschedule(lane int, tid thread.ID) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	for key := range q.waiting {
+		if key.lane == lane && key.tid == tid {
+			delete(q.waiting, key)
+			log.Debugf("deschedule call to [%s/%s]: thread removed", key.pid, tid)
+		}
+	}
+}
+
+func (q *PriorityQueue) pollQueue() {
+	var (
+		poll = q.pollInterval()
+		tick = q.clock.NewTicker(poll)
+	)
+	for {
+		select {
+		case <-q.ctx.Done():
+			tick.Stop()
+			return
+
+		case <-tick.Chan():
+			if current := q.pollInterval(); current != poll {
+				tick.Stop()
+				poll, tick = current, q.clock.NewTicker(current)
+			}
+			q.spawnWaiting()
+		}
+	}
+}
+
+func (q *PriorityQueue) pollInterval() time.Duration {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.poll
+}
+
+// spawnWaiting spawns overdue calls and a share of other waiting calls in the order
+// of their rank, as long as their peers don't run too many calls already.
+func (q *PriorityQueue) spawnWaiting() {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if len(q.waiting) == 0 {
+		return
+	}
+
+	now := q.clock.Now()
+	calls := make([]*scheduledCall, 0, len(q.waiting))
+	for _, sc := range q.waiting {
+		calls = append(calls, sc)
+	}
+	sort.Slice(calls, func(i, j int) bool { return q.ahead(calls[i], calls[j], now) })
+
+	// every poll spawns a share of calls, so the queue is drained within the deadline
+	budget := len(calls)
+	if q.deadline > q.poll {
+		budget = int(math.Ceil(float64(len(calls)) * float64(q.poll) / float64(q.deadline)))
+	}
+	for _, sc := range calls {
+		overdue := !now.Before(sc.deadline)
+		if !overdue && budget == 0 {
+			break // overdue calls are ranked first
+		}
+		if q.peerCalls > 0 && q.peers[sc.key.pid] >= q.peerCalls {
+			continue
+		}
+		if _, inflight := q.inflight[sc.key]; inflight {
+			continue // invoked directly meanwhile
+		}
+		if !overdue {
+			budget--
+		}
+		q.spawn(sc, now)
+	}
+}
+
+// ahead returns whether the call a should be spawned before b. Overdue calls go first in
+// the order of their deadlines, others in the order of their rank and arrival.
+func (q *PriorityQueue) ahead(a, b *scheduledCall, now time.Time) bool {
+	aOverdue, bOverdue := !now.Before(a.deadline), !now.Before(b.deadline)
+	if aOverdue != bOverdue {
+		return aOverdue
+	} else if aOverdue {
+		return a.deadline.Before(b.deadline)
+	}
+	if ra, rb := q.rank(a, now), q.rank(b, now); ra != rb {
+		return ra > rb
+	}
+	return a.created.Before(b.created)
+}
+
+// rank returns the weighted priority of the call, raised by the time it waits.
+func (q *PriorityQueue) rank(sc *scheduledCall, now time.Time) float64 {
+	rank := float64(q.lanes[sc.key.lane].weight * sc.priority)
+	if q.deadline > 0 {
+		rank += agingBoost * float64(now.Sub(sc.created)) / float64(q.deadline)
+	}
+	return rank
+}
+
+func (q *PriorityQueue) spawn(sc *scheduledCall, now time.Time) {
+	delete(q.waiting, sc.key)
+	q.start(sc.key)
+
+	stats := &q.lanes[sc.key.lane].stats
+	wait := now.Sub(sc.created)
+	stats.Spawned++
+	stats.TotalWait += wait
+	if wait > stats.MaxWait {
+		stats.MaxWait = wait
+	}
+	if now.After(sc.deadline) {
+		stats.Overdue++
+	}
+
+	go func() {
+		defer q.done(sc.key)
+		if err := sc.call(q.ctx, sc.key.pid, sc.key.tid); err != nil {
+			log.Errorf("call to [%s/%s] failed: %v", sc.key.pid, sc.key.tid, err)
+		}
+	}()
+}
+
+// start marks the call as in-flight, the queue lock must be held.
+func (q *PriorityQueue) start(key callKey) {
+	q.inflight[key]++
+	q.peers[key.pid]++
+}
+
+func (q *PriorityQueue) done(key callKey) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.inflight[key]--; q.inflight[key] == 0 {
+		delete(q.inflight, key)
+	}
+	if q.peers[key.pid]--; q.peers[key.pid] == 0 {
+		delete(q.peers, key.pid)
+	}
+}
+
+/* Lane of the priority queue */
+
+var _ CallQueue = (*laneQueue)(nil)
+
+type laneQueue struct {
+	q    *PriorityQueue
+	lane int
+}
+
+func (l *laneQueue) Call(pid peer.ID, tid thread.ID, call PeerCall) error {
+	return l.q.call(callKey{lane: l.lane, pid: pid, tid: tid}, call)
+}
+
+func (l *laneQueue) Schedule(pid peer.ID, tid thread.ID, priority int, call PeerCall) bool {
+	return l.q.schedule(callKey{lane: l.lane, pid: pid, tid: tid}, priority, time.Time{}, call)
+}
+
+func (l *laneQueue) ScheduleBy(pid peer.ID, tid thread.ID, priority int, deadline time.Time, call PeerCall) bool {
+	return l.q.schedule(callKey{lane: l.lane, pid: pid, tid: tid}, priority, deadline, call)
+}
+
+func (l *laneQueue) Deschedule(tid thread.ID) {
+	l.q.deschedule(l.lane, tid)
+}
+
+// SetIntervals changes the intervals of the whole queue, which are shared by its lanes.
+func (l *laneQueue) SetIntervals(pollInterval, spawnDeadline time.Duration) {
+	l.q.SetIntervals(pollInterval, spawnDeadline)
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+func TestPriorityQueue_Order(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		mock        = clock.NewMock(time.Unix(0, 0))
+		q           = NewPriorityQueue(ctx, mock, time.Second, time.Minute, 1)
+		logs        = q.Lane("logs", 2)
+		records     = q.Lane("records", 1)
+		pid         = peer.ID("peer")
+		called      = make(chan thread.ID, 4)
+		release     = make(chan struct{})
+		call        = func(_ context.Context, _ peer.ID, tid thread.ID) error {
+			called <- tid
+			<-release
+			return nil
+		}
+		t1 = thread.NewIDV1(thread.Raw, 32)
+		t2 = thread.NewIDV1(thread.Raw, 32)
+		t3 = thread.NewIDV1(thread.Raw, 32)
+		t4 = thread.NewIDV1(thread.Raw, 32)
+	)
+	defer cancel()
+	mock.BlockUntil(1)
+
+	records.Schedule(pid, t1, 1, call)
+	records.Schedule(pid, t2, 3, call)
+	logs.Schedule(pid, t3, 1, call)
+	// the call is overdue on the next poll, so it goes ahead of any other
+	if !records.ScheduleBy(pid, t4, 1, mock.Now(), call) {
+		t.Fatal("expected call to be scheduled")
+	}
+	if records.Schedule(pid, t4, 1, call) {
+		t.Fatal("expected call to be scheduled once")
+	}
+
+	// a single call runs for the peer at once, and they're spawned in order of the
+	// deadline, the weighted priority and the arrival
+	for _, expected := range []thread.ID{t4, t2, t3, t1} {
+		mock.Add(time.Second)
+		select {
+		case tid := <-called:
+			if tid != expected {
+				t.Fatalf("expected call for %s, got %s", expected, tid)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected call for %s to be spawned", expected)
+		}
+		select {
+		case tid := <-called:
+			t.Fatalf("unexpected concurrent call for %s", tid)
+		case <-time.After(50 * time.Millisecond):
+		}
+		release <- struct{}{}
+		for s := q.Stats(); s["records"].InFlight+s["logs"].InFlight > 0; s = q.Stats() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	stats := q.Stats()
+	if s := stats["records"]; s.Spawned != 3 || s.Overdue != 1 || s.Waiting != 0 || s.MaxWait != 4*time.Second {
+		t.Fatalf("unexpected stats of records: %+v", s)
+	}
+	if s := stats["logs"]; s.Spawned != 1 || s.TotalWait != 3*time.Second {
+		t.Fatalf("unexpected stats of logs: %+v", s)
+	}
+}
+
+func TestPriorityQueue_Starvation(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		mock        = clock.NewMock(time.Unix(0, 0))
+		q           = NewPriorityQueue(ctx, mock, time.Second, 8*time.Second, 0)
+		lane        = q.Lane("records", 1)
+		pid         = peer.ID("peer")
+		called      = make(chan thread.ID, 1)
+		call        = func(_ context.Context, _ peer.ID, tid thread.ID) error { called <- tid; return nil }
+		low         = thread.NewIDV1(thread.Raw, 32)
+	)
+	defer cancel()
+	mock.BlockUntil(1)
+
+	lane.Schedule(pid, low, 1, call)
+	for i := 0; ; i++ {
+		// waiting calls gain priority, so it's spawned well before the deadline
+		if i == 4 {
+			t.Fatal("expected low-priority call to be spawned")
+		}
+		// a new higher-priority call arrives on every poll
+		lane.Schedule(pid, thread.NewIDV1(thread.Raw, 32), 3, call)
+		mock.Add(time.Second)
+		if tid := <-called; tid == low {
+			break
+		}
+	}
+}
+
+func TestPriorityQueue_Deschedule(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		mock        = clock.NewMock(time.Unix(0, 0))
+		q           = NewPriorityQueue(ctx, mock, time.Second, time.Millisecond, 0)
+		logs        = q.Lane("logs", 1)
+		records     = q.Lane("records", 1)
+		pid         = peer.ID("peer")
+		called      = make(chan thread.ID, 2)
+		call        = func(_ context.Context, _ peer.ID, tid thread.ID) error { called <- tid; return nil }
+		tid         = thread.NewIDV1(thread.Raw, 32)
+	)
+	defer cancel()
+	mock.BlockUntil(1)
+
+	// lanes keep their calls apart
+	logs.Schedule(pid, tid, 1, call)
+	records.Schedule(pid, tid, 1, call)
+	logs.Deschedule(tid)
+	if s := q.Stats(); s["logs"].Waiting != 0 || s["records"].Waiting != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+
+	// direct calls replace scheduled ones
+	if err := records.Call(pid, tid, call); err != nil {
+		t.Fatal(err)
+	}
+	<-called
+	mock.Add(time.Second)
+	select {
+	case <-called:
+		t.Fatal("expected descheduled calls not to be spawned")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
 )
 
 var (
@@ -36,13 +37,16 @@ type (
 		input       chan request
 		timeout     time.Duration
 		maxPackSize int
+		clock       clock.Clock
 	}
 )
 
 // Packer accumulates peer-related thread requests and packs it into the
 // limited-size containers during time-window constrained by provided timeout.
-func NewThreadPacker(ctx context.Context, maxPackSize int, timeout time.Duration) *threadPacker {
+// The time-window is measured by the clock, a real one is used if it's nil.
+func NewThreadPacker(ctx context.Context, clk clock.Clock, maxPackSize int, timeout time.Duration) *threadPacker {
 	return &threadPacker{
+		clock:       clock.OrNew(clk),
 		peers:       make(map[peer.ID][]tEntry),
 		input:       make(chan request, InBufSize),
 		timeout:     timeout,
@@ -52,10 +56,13 @@ func NewThreadPacker(ctx context.Context, maxPackSize int, timeout time.Duration
 }
 
 func (q *threadPacker) Add(pid peer.ID, tid thread.ID) {
-	q.input <- request{
+	select {
+	case q.input <- request{
 		pid:   pid,
 		tid:   tid,
-		added: time.Now().Unix(),
+		added: q.clock.Now().Unix(),
+	}:
+	case <-q.ctx.Done():
 	}
 }
 
@@ -63,21 +70,30 @@ func (q *threadPacker) Run() <-chan ThreadPack {
 	var sink = make(chan ThreadPack, OutBufSize)
 
 	go func() {
-		tm := time.NewTicker(q.timeout)
+		tm := q.clock.NewTicker(q.timeout)
 		defer tm.Stop()
 
 		for {
 			select {
 			case <-q.ctx.Done():
+				// requests added before the context was canceled are still packed
+				for drained := false; !drained; {
+					select {
+					case req := <-q.input:
+						q.peers[req.pid] = append(q.peers[req.pid], tEntry{tid: req.tid, added: req.added})
+					default:
+						drained = true
+					}
+				}
 				for pid := range q.peers {
 					q.drainPeerQueue(pid, sink)
 				}
 				close(sink)
 				return
 
-			case <-tm.C:
+			case <-tm.Chan():
 				// periodic check for inactive peer queues with overdue entries
-				var now = time.Now().Unix()
+				var now = q.clock.Now().Unix()
 				for pid, pq := range q.peers {
 					if len(pq) > 0 && now-pq[0].added >= int64(q.timeout/time.Second) {
 						q.drainPeerQueue(pid, sink)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/test"
+	"github.com/textileio/go-threads/util/clock"
 )
 
 func TestThreadPacker(t *testing.T) {
@@ -14,7 +15,7 @@ func TestThreadPacker(t *testing.T) {
 		maxPack     = 3
 		timeout     = 1 * time.Second
 		ctx, cancel = context.WithCancel(context.Background())
-		tp          = NewThreadPacker(ctx, maxPack, timeout)
+		tp          = NewThreadPacker(ctx, nil, maxPack, timeout)
 
 		pid  = test.GeneratePeerIDs(1)[0]
 		tids = make([]thread.ID, 2*maxPack+1)
@@ -76,3 +77,67 @@ func TestThreadPacker(t *testing.T) {
 		t.Error("unexpected final pack")
 	}
 }
+
+func TestThreadPacker_Clock(t *testing.T) {
+	var (
+		timeout     = time.Hour
+		mock        = clock.NewMock(time.Unix(0, 0))
+		ctx, cancel = context.WithCancel(context.Background())
+		tp          = NewThreadPacker(ctx, mock, 3, timeout)
+		sink        = tp.Run()
+
+		pid  = test.GeneratePeerIDs(1)[0]
+		tids = []thread.ID{thread.NewIDV1(thread.Raw, 32), thread.NewIDV1(thread.Raw, 32)}
+	)
+	defer cancel()
+
+	for _, tid := range tids {
+		tp.Add(pid, tid)
+	}
+	mock.BlockUntil(1)
+
+	// the incomplete pack is flushed once it's overdue, without waiting for the timeout
+	for i := 0; ; i++ {
+		if i == 10 {
+			t.Fatal("expected incomplete pack to be flushed")
+		}
+		mock.Add(timeout)
+		select {
+		case pack := <-sink:
+			if len(pack.Threads) != len(tids) {
+				t.Fatalf("expected pack of %d threads, got %d", len(tids), len(pack.Threads))
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestThreadPacker_Cancel(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		tp          = NewThreadPacker(ctx, nil, 10, time.Hour)
+		pids        = test.GeneratePeerIDs(2)
+		tids        = []thread.ID{thread.NewIDV1(thread.Raw, 32), thread.NewIDV1(thread.Raw, 32)}
+	)
+
+	sink := tp.Run()
+	for _, pid := range pids {
+		for _, tid := range tids {
+			tp.Add(pid, tid)
+		}
+	}
+	// requests added before canceling are packed
+	cancel()
+
+	var packed int
+	for p := range sink {
+		packed += len(p.Threads)
+	}
+	if packed != len(pids)*len(tids) {
+		t.Fatalf("expected %d packed threads, got %d", len(pids)*len(tids), packed)
+	}
+
+	// requests added afterwards don't block
+	tp.Add(pids[0], tids[0])
+}
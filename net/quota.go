@@ -0,0 +1,276 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// QuotaWarningRatio is the share of a quota which may be used before a core.QuotaWarning event is emitted.
+var QuotaWarningRatio = 0.9
+
+const (
+	// quotaKey is the metadata key of the byte budget of a thread set with SetThreadQuota.
+	quotaKey = "/quota"
+	// quotaUsedKey is the metadata key of the bytes charged against the thread quota.
+	quotaUsedKey = "/quota/used"
+	// quotaUsedSuffix is the log metadata suffix of the bytes charged against the log quota.
+	quotaUsedSuffix = "/quota-used"
+)
+
+// QuotaConfig bounds the blockstore space taken by thread records, counting their record, event,
+// header and body nodes, so threads replicated from untrusted peers can't fill the disk. Records
+// added beyond a quota are refused with core.ErrQuotaExceeded, while records created by the host are
+// counted but never refused. Records stored while no quota applied aren't counted.
+type QuotaConfig struct {
+	// ThreadBytes is the default byte budget of every thread, see core.Net.SetThreadQuota.
+	// Zero is unlimited.
+	ThreadBytes int64
+
+	// LogBytes is the byte budget of every log of a thread, so a single peer writing to
+	// a thread can't use up its whole budget. Zero is unlimited.
+	LogBytes int64
+}
+
+func (n *net) SetThreadQuota(ctx context.Context, id thread.ID, bytes int64, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	return n.withThreadLock(id, func() error {
+		return n.store.PutInt64(id, quotaKey, bytes)
+	})
+}
+
+// threadQuota returns the byte budget of a thread, zero if unlimited.
+func (n *net) threadQuota(id thread.ID) (int64, error) {
+	quota, err := n.store.GetInt64(id, quotaKey)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case quota == nil || *quota == 0:
+		return n.quotas.ThreadBytes, nil
+	case *quota < 0:
+		return 0, nil
+	default:
+		return *quota, nil
+	}
+}
+
+// quotaUsage returns the bytes charged against the quotas of a thread and one of its logs.
+func (n *net) quotaUsage(tid thread.ID, lid peer.ID) (used, logUsed int64, err error) {
+	v, err := n.store.GetInt64(tid, quotaUsedKey)
+	if err != nil {
+		return
+	} else if v != nil {
+		used = *v
+	}
+	if v, err = n.store.GetInt64(tid, lid.Pretty()+quotaUsedSuffix); err != nil {
+		return
+	} else if v != nil {
+		logUsed = *v
+	}
+	return
+}
+
+// hasQuota returns whether records added to a thread are charged against a quota.
+func (n *net) hasQuota(tid thread.ID) (bool, error) {
+	quota, err := n.threadQuota(tid)
+	if err != nil {
+		return false, err
+	}
+	return quota > 0 || n.quotas.LogBytes > 0, nil
+}
+
+// quotaSize returns the size charged for a record of a thread, or zero if no quota applies.
+func (n *net) quotaSize(ctx context.Context, tid thread.ID, rec core.Record) (int64, error) {
+	if ok, err := n.hasQuota(tid); err != nil || !ok {
+		return 0, err
+	}
+	return n.recordBytes(ctx, rec)
+}
+
+// checkQuota returns the size charged for a record about to be added to a log, failing with
+// core.ErrQuotaExceeded if it doesn't fit into the quotas of the thread or log.
+// It must be called holding the log semaphore. The thread usage may still grow by records
+// created locally on other logs meanwhile, which are charged but never refused.
+func (n *net) checkQuota(ctx context.Context, tid thread.ID, lid peer.ID, rec core.Record) (int64, error) {
+	size, err := n.quotaSize(ctx, tid, rec)
+	if err != nil || size == 0 {
+		return 0, err
+	}
+	if err = n.fitsQuota(tid, lid, size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// quotaReservation checks records against the storage quotas of a log, and the relay quota of
+// relayed threads, before their blocks are stored, counting the records checked before, which
+// aren't charged until added to the log.
+type quotaReservation struct {
+	n       *net
+	tid     thread.ID
+	lid     peer.ID
+	charged bool
+	relayed bool
+	pending int64
+}
+
+// reserveQuota returns a reservation for records about to be loaded into a log.
+func (n *net) reserveQuota(tid thread.ID, lid peer.ID) (*quotaReservation, error) {
+	charged, err := n.hasQuota(tid)
+	if err != nil {
+		return nil, err
+	}
+	return &quotaReservation{n: n, tid: tid, lid: lid, charged: charged, relayed: n.isRelayed(tid)}, nil
+}
+
+// add reserves size bytes, failing with core.ErrQuotaExceeded or ErrRelayQuotaExceeded if they don't fit.
+func (r *quotaReservation) add(size int64) error {
+	if r.charged {
+		if err := r.n.fitsQuota(r.tid, r.lid, r.pending+size); err != nil {
+			return err
+		}
+	}
+	if r.relayed {
+		if err := r.n.fitsRelayed(r.tid, r.pending+size); err != nil {
+			return err
+		}
+	}
+	r.pending += size
+	return nil
+}
+
+// fitsQuota fails with core.ErrQuotaExceeded if size more bytes don't fit into the quotas of the thread or log.
+func (n *net) fitsQuota(tid thread.ID, lid peer.ID, size int64) error {
+	quota, err := n.threadQuota(tid)
+	if err != nil {
+		return err
+	}
+	used, logUsed, err := n.quotaUsage(tid, lid)
+	if err != nil {
+		return err
+	}
+	if quota > 0 && used+size > quota {
+		return fmt.Errorf("thread %s would hold %d bytes of %d: %w", tid, used+size, quota, core.ErrQuotaExceeded)
+	}
+	if n.quotas.LogBytes > 0 && logUsed+size > n.quotas.LogBytes {
+		return fmt.Errorf("log %s would hold %d bytes of %d: %w", lid, logUsed+size, n.quotas.LogBytes, core.ErrQuotaExceeded)
+	}
+	return nil
+}
+
+// chargeCreated charges the records created by the host on a log against the quotas.
+// They're counted, but never refused, so failures are only logged.
+func (n *net) chargeCreated(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record) {
+	var size int64
+	for _, r := range recs {
+		s, err := n.quotaSize(ctx, tid, r)
+		if err != nil {
+			log.Errorf("sizing record %s failed: %v", r.Cid(), err)
+		}
+		size += s
+	}
+	if err := n.chargeQuota(tid, lid, size); err != nil {
+		log.Errorf("charging quota of thread %s failed: %v", tid, err)
+	}
+}
+
+// chargeQuota adds the size of records added to a log to the usage of the thread and log quotas,
+// and emits a QuotaWarning once the usage passes QuotaWarningRatio of a quota.
+func (n *net) chargeQuota(tid thread.ID, lid peer.ID, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	n.quotaLock.Lock()
+	defer n.quotaLock.Unlock()
+	quota, err := n.threadQuota(tid)
+	if err != nil {
+		return err
+	}
+	used, logUsed, err := n.quotaUsage(tid, lid)
+	if err != nil {
+		return err
+	}
+	if err = n.store.PutInt64(tid, quotaUsedKey, used+size); err != nil {
+		return err
+	}
+	if err = n.store.PutInt64(tid, lid.Pretty()+quotaUsedSuffix, logUsed+size); err != nil {
+		return err
+	}
+	if passedWarning(used, size, quota) {
+		n.emit(core.LifecycleEvent{Type: core.QuotaWarning, ThreadID: tid})
+	}
+	if passedWarning(logUsed, size, n.quotas.LogBytes) {
+		n.emit(core.LifecycleEvent{Type: core.QuotaWarning, ThreadID: tid, LogID: lid})
+	}
+	return nil
+}
+
+// passedWarning returns whether charging size on top of used passes the warning mark of a quota.
+func passedWarning(used, size, quota int64) bool {
+	if quota <= 0 {
+		return false
+	}
+	mark := int64(float64(quota) * QuotaWarningRatio)
+	return used <= mark && used+size > mark
+}
+
+// releaseQuota subtracts the size of records pruned from a log from the usage of the thread
+// and log quotas.
+func (n *net) releaseQuota(tid thread.ID, lid peer.ID, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	n.quotaLock.Lock()
+	defer n.quotaLock.Unlock()
+	used, logUsed, err := n.quotaUsage(tid, lid)
+	if err != nil {
+		return err
+	}
+	if err = n.store.PutInt64(tid, quotaUsedKey, nonNegative(used-size)); err != nil {
+		return err
+	}
+	return n.store.PutInt64(tid, lid.Pretty()+quotaUsedSuffix, nonNegative(logUsed-size))
+}
+
+// isQuotaExceeded returns whether records were refused for exceeding the relay or storage quotas.
+func isQuotaExceeded(err error) bool {
+	return errors.Is(err, ErrRelayQuotaExceeded) || errors.Is(err, core.ErrQuotaExceeded)
+}
+
+func nonNegative(v int64) int64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// storedSize returns the size of a stored record, counted like the quotas.
+func (n *net) storedSize(ctx context.Context, rid cid.Cid, sk *sym.Key) (int64, error) {
+	rec, err := cbor.GetRecord(ctx, n, rid, sk)
+	if err != nil {
+		return 0, err
+	}
+	ev, err := cbor.EventFromRecord(ctx, n, rec)
+	if err != nil {
+		return 0, err
+	}
+	return util.RecordSize(n.bstore, rid, ev)
+}
@@ -0,0 +1,216 @@
+package net
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gogo/status"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/util/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// MaxRateLimitBuckets is the number of buckets a limiter keeps before dropping
+// the ones which have been refilled completely.
+var MaxRateLimitBuckets = 4096
+
+// RateLimits specifies the load a single peer or thread may put on the host.
+// Zero rates disable the corresponding limit.
+type RateLimits struct {
+	// PeerRPCRate is the number of requests per second accepted from a peer.
+	PeerRPCRate float64
+	// PeerRPCBurst is the number of requests a peer may issue at once.
+	PeerRPCBurst int
+	// ThreadRecordRate is the number of records per second pushed by peers
+	// which are accepted into a thread.
+	ThreadRecordRate float64
+	// ThreadRecordBurst is the number of records which may be pushed into a thread at once.
+	ThreadRecordBurst int
+	// ThreadByteRate is the number of record bytes per second pushed by peers
+	// which are accepted into a thread.
+	ThreadByteRate float64
+	// ThreadByteBurst is the number of record bytes which may be pushed into a thread at once.
+	ThreadByteBurst int
+}
+
+// rateLimiter is a token bucket limiter keeping a bucket per key.
+// A nil limiter allows everything.
+type rateLimiter struct {
+	sync.Mutex
+	clock   clock.Clock
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter(clk clock.Clock, rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		clock:   clock.OrNew(clk),
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token from the key's bucket. If the bucket is empty, it
+// returns false along with the time left until a token is available.
+func (l *rateLimiter) Allow(key string) (bool, time.Duration) {
+	return l.AllowN(key, 1)
+}
+
+// AllowN takes n tokens from the key's bucket at once. Requests larger than
+// the burst cost the whole burst, so they can still pass eventually.
+func (l *rateLimiter) AllowN(key string, n int) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.clock.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= MaxRateLimitBuckets {
+			l.evict(now)
+		}
+		b = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+	cost := math.Min(l.burst, float64(n))
+	if b.tokens < cost {
+		wait := time.Duration((cost - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens -= cost
+	return true, 0
+}
+
+// refund puts n tokens taken by AllowN back into the key's bucket.
+func (l *rateLimiter) refund(key string, n int) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(l.burst, b.tokens+math.Min(l.burst, float64(n)))
+	}
+}
+
+// Forget drops the bucket of the key.
+func (l *rateLimiter) Forget(key string) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	delete(l.buckets, key)
+}
+
+// evict drops buckets which would be full by now, as new buckets start full anyway.
+func (l *rateLimiter) evict(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// chargeRecords takes verified records pushed into a thread from its record
+// and byte buckets. Nothing is taken if either bucket is short.
+func (n *net) chargeRecords(id thread.ID, count, size int) error {
+	key := id.String()
+	if ok, wait := n.threadLimiter.AllowN(key, count); !ok {
+		return backpressureError("thread record rate limit exceeded", wait)
+	}
+	if ok, wait := n.byteLimiter.AllowN(key, size); !ok {
+		n.threadLimiter.refund(key, count)
+		return backpressureError("thread byte rate limit exceeded", wait)
+	}
+	return nil
+}
+
+// backpressureError returns a ResourceExhausted error telling the peer when to retry.
+func backpressureError(msg string, retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, msg)
+	if dst, err := st.WithDetails(&pb.Backpressure{RetryAfter: retryAfter.Milliseconds()}); err == nil {
+		st = dst
+	}
+	return st.Err()
+}
+
+// backpressureDelay returns the retry delay requested by a rate limited peer, if any.
+func backpressureDelay(err error) (time.Duration, bool) {
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if bp, ok := d.(*pb.Backpressure); ok {
+			return time.Duration(bp.RetryAfter) * time.Millisecond, true
+		}
+	}
+	return 0, true
+}
+
+// rateLimitInterceptor rejects requests of peers exceeding the RPC rate limit.
+func (n *net) rateLimitInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if pid, err := peerIDFromContext(ctx); err == nil {
+			if ok, wait := n.peerLimiter.Allow(pid.String()); !ok {
+				log.Debugf("rate limiting %s from %s", info.FullMethod, pid)
+				return nil, backpressureError("peer rate limit exceeded", wait)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// throttle makes the host hold off calling a peer which asked to slow down.
+func (s *server) throttle(pid peer.ID, err error) {
+	wait, ok := backpressureDelay(err)
+	if !ok {
+		return
+	}
+	if wait <= 0 {
+		wait = PushTimeout
+	}
+	s.Lock()
+	s.throttled[pid] = s.net.clock.Now().Add(wait)
+	s.Unlock()
+}
+
+// isThrottled returns true if the peer asked to hold off calling it.
+func (s *server) isThrottled(pid peer.ID) bool {
+	s.Lock()
+	defer s.Unlock()
+	until, ok := s.throttled[pid]
+	if ok && s.net.clock.Now().After(until) {
+		delete(s.throttled, pid)
+		return false
+	}
+	return ok
+}
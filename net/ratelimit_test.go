@@ -0,0 +1,75 @@
+package net
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+func TestNet_RateLimiter(t *testing.T) {
+	clk := clock.NewMock(time.Now())
+	l := newRateLimiter(clk, 10, 2)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within burst was limited", i)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok {
+		t.Fatal("request exceeding burst was allowed")
+	}
+	if wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("unexpected wait time %v", wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("buckets are not independent")
+	}
+
+	clk.Add(wait)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("bucket was not refilled")
+	}
+
+	disabled := newRateLimiter(nil, 0, 0)
+	if ok, _ := disabled.Allow("a"); !ok {
+		t.Fatal("disabled limiter limited a request")
+	}
+}
+
+func TestNet_ChargeRecords(t *testing.T) {
+	n := &net{
+		threadLimiter: newRateLimiter(nil, 1, 2),
+		byteLimiter:   newRateLimiter(nil, 100, 1000),
+	}
+	id := thread.NewIDV1(thread.Raw, 32)
+	if err := n.chargeRecords(id, 1, 800); err != nil {
+		t.Fatalf("records within limits were refused: %v", err)
+	}
+	if _, ok := backpressureDelay(n.chargeRecords(id, 1, 800)); !ok {
+		t.Fatal("records exceeding the byte burst were accepted")
+	}
+	// the record token of the refused push must have been returned
+	if err := n.chargeRecords(id, 1, 100); err != nil {
+		t.Fatalf("record tokens were taken by a refused push: %v", err)
+	}
+	if _, ok := backpressureDelay(n.chargeRecords(id, 1, 0)); !ok {
+		t.Fatal("records exceeding the record burst were accepted")
+	}
+}
+
+func TestNet_Backpressure(t *testing.T) {
+	err := backpressureError("slow down", 1500*time.Millisecond)
+	wait, ok := backpressureDelay(err)
+	if !ok {
+		t.Fatal("backpressure was not detected")
+	}
+	if wait != 1500*time.Millisecond {
+		t.Fatalf("expected retry after 1.5s, got %v", wait)
+	}
+	if _, ok := backpressureDelay(errors.New("other")); ok {
+		t.Fatal("unrelated error treated as backpressure")
+	}
+}
@@ -0,0 +1,15 @@
+package net
+
+import "errors"
+
+// ErrReadOnly indicates a write refused by a host running with Config.ReadOnly.
+var ErrReadOnly = errors.New("host is read-only")
+
+// checkWritable fails if the host must not author threads, logs or records.
+// Records of other peers are still pulled, served and relayed.
+func (n *net) checkWritable() error {
+	if n.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
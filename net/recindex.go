@@ -0,0 +1,249 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+var recordIndexPrefix = ds.NewKey("/recindex")
+
+// recordIndex maps records of logs to their heights, i.e., positions counted from the start
+// of the log, and back, so slices of a log are located without walking it from the head.
+// Heights are relative, a log compacted before it was indexed starts at the boundary.
+// Branches of forked logs share heights, so records found by height must be checked
+// against the links of their successors.
+type recordIndex struct {
+	store ds.Datastore
+}
+
+func newRecordIndex(store ds.Datastore) *recordIndex {
+	return &recordIndex{store: store}
+}
+
+// Height returns the height of the record, or false if it isn't indexed.
+func (x *recordIndex) Height(tid thread.ID, lid peer.ID, rid cid.Cid) (uint64, bool, error) {
+	v, err := x.store.Get(recordIndexKey(tid, lid).ChildString("c").ChildString(rid.String()))
+	if err == ds.ErrNotFound {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	} else if len(v) != 8 {
+		return 0, false, fmt.Errorf("malformed height of record %s", rid)
+	}
+	return binary.BigEndian.Uint64(v), true, nil
+}
+
+// At returns the record indexed at the height, or false if there is none.
+func (x *recordIndex) At(tid thread.ID, lid peer.ID, height uint64) (cid.Cid, bool, error) {
+	v, err := x.store.Get(heightKey(tid, lid, height))
+	if err == ds.ErrNotFound {
+		return cid.Undef, false, nil
+	} else if err != nil {
+		return cid.Undef, false, err
+	}
+	rid, err := cid.Cast(v)
+	if err != nil {
+		return cid.Undef, false, err
+	}
+	return rid, true, nil
+}
+
+// Put indexes the record at the height.
+func (x *recordIndex) Put(tid thread.ID, lid peer.ID, rid cid.Cid, height uint64) error {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, height)
+	if err := x.store.Put(recordIndexKey(tid, lid).ChildString("c").ChildString(rid.String()), v); err != nil {
+		return err
+	}
+	return x.store.Put(heightKey(tid, lid, height), rid.Bytes())
+}
+
+// PurgeThread removes the index of all logs of the thread.
+func (x *recordIndex) PurgeThread(tid thread.ID) error {
+	res, err := x.store.Query(query.Query{Prefix: recordIndexPrefix.ChildString(tid.String()).String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := x.store.Delete(ds.RawKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordIndexKey(tid thread.ID, lid peer.ID) ds.Key {
+	return recordIndexPrefix.ChildString(tid.String()).ChildString(lid.String())
+}
+
+// heightKey returns the key of the height, zero-padded so keys are ordered by height.
+func heightKey(tid thread.ID, lid peer.ID, height uint64) ds.Key {
+	return recordIndexKey(tid, lid).ChildString("h").ChildString(fmt.Sprintf("%020d", height))
+}
+
+// setHeads sets the heads of the log and indexes the records added below them.
+// The records have to be in the blockstore already, see indexLog.
+func (n *net) setHeads(ctx context.Context, id thread.ID, lid peer.ID, heads []cid.Cid) error {
+	if err := n.store.SetHeads(id, lid, heads); err != nil {
+		return err
+	}
+	n.indexLog(ctx, id, lid, heads)
+	return nil
+}
+
+// indexLog indexes the records below the log heads. Records missing from the blockstore are
+// taken as compacted, so records have to be added before they are indexed.
+func (n *net) indexLog(ctx context.Context, id thread.ID, lid peer.ID, heads []cid.Cid) {
+	// the index only speeds up serving records, the log is walked without it
+	if err := n.indexHeads(ctx, id, lid, heads); err != nil {
+		log.Errorf("indexing records of log %s/%s: %v", id, lid, err)
+	}
+}
+
+// indexHeads walks back from the heads to the indexed records, and indexes the records on the way.
+func (n *net) indexHeads(ctx context.Context, id thread.ID, lid peer.ID, heads []cid.Cid) error {
+	var sk *sym.Key
+	for _, head := range heads {
+		var (
+			cursor = head
+			chain  []cid.Cid
+			base   uint64
+		)
+		for cursor.Defined() {
+			height, ok, err := n.recIndex.Height(id, lid, cursor)
+			if err != nil {
+				return err
+			} else if ok {
+				base = height
+				break
+			}
+			if sk == nil {
+				if sk, err = n.store.ServiceKey(id); err != nil {
+					return err
+				} else if sk == nil {
+					return nil // records aren't readable
+				}
+			}
+			if known, err := n.isKnown(cursor); err != nil {
+				return err
+			} else if !known {
+				break // compacted, heights start after it
+			}
+			r, err := cbor.GetRecord(ctx, n, cursor, sk)
+			if err != nil {
+				return err
+			}
+			chain = append(chain, cursor)
+			cursor = r.PrevID()
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			base++
+			if err := n.recIndex.Put(id, lid, chain[i], base); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// indexedRecords returns up to limit latest records of a log with a single head following the
+// known offsets, located by their heights. It returns false if the index can't tell the slice,
+// e.g., the log forked or an offset lies on another branch, so the log must be walked instead.
+func (n *net) indexedRecords(
+	ctx context.Context,
+	id thread.ID,
+	lid peer.ID,
+	heads []cid.Cid,
+	stop map[cid.Cid]struct{},
+	boundary cid.Cid,
+	limit int,
+	sk *sym.Key,
+) ([]core.Record, bool) {
+	if len(heads) != 1 || limit <= 0 {
+		return nil, false
+	}
+	top, ok := n.indexedHeight(id, lid, heads[0])
+	if !ok {
+		return nil, false
+	}
+	var low uint64 // the latest height not served
+	for offset := range stop {
+		height, ok := n.indexedHeight(id, lid, offset)
+		if !ok || height > top {
+			return nil, false
+		} else if height > low {
+			low = height
+		}
+	}
+	if boundary.Defined() {
+		height, ok := n.indexedHeight(id, lid, boundary)
+		if !ok {
+			return nil, false
+		} else if height-1 > low {
+			low = height - 1
+		}
+	}
+	if top > uint64(limit) && top-uint64(limit) > low {
+		low = top - uint64(limit)
+	}
+
+	var (
+		recs = make([]core.Record, 0, top-low)
+		prev cid.Cid
+	)
+	if low > 0 {
+		var err error
+		if prev, _, err = n.recIndex.At(id, lid, low); err != nil {
+			return nil, false
+		}
+	}
+	for height := low + 1; height <= top; height++ {
+		rid, ok, err := n.recIndex.At(id, lid, height)
+		if err != nil || !ok {
+			return nil, false
+		}
+		r, err := cbor.GetRecord(ctx, n, rid, sk)
+		if err != nil {
+			return nil, false
+		}
+		// records indexed by other branches don't link to their predecessors
+		if prev.Defined() && !r.PrevID().Equals(prev) && !rid.Equals(boundary) {
+			return nil, false
+		}
+		if err = n.loadExtensions(id, r); err != nil {
+			return nil, false
+		}
+		recs = append(recs, r)
+		prev = rid
+	}
+	if !prev.Equals(heads[0]) {
+		return nil, false
+	}
+	return recs, true
+}
+
+// indexedHeight returns the height of the record if it's the one indexed at its height.
+func (n *net) indexedHeight(id thread.ID, lid peer.ID, rid cid.Cid) (uint64, bool) {
+	height, ok, err := n.recIndex.Height(id, lid, rid)
+	if err != nil || !ok {
+		return 0, false
+	}
+	if at, ok, err := n.recIndex.At(id, lid, height); err != nil || !ok || !at.Equals(rid) {
+		return 0, false
+	}
+	return height, true
+}
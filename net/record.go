@@ -16,12 +16,14 @@ type linkedRecord interface {
 
 // Collector maintains an ordered list of records from multiple sources (thread-safe)
 type recordCollector struct {
-	rs   map[peer.ID]*recordSequence
-	lock sync.Mutex
+	rs    map[peer.ID]*recordSequence
+	known func(cid.Cid) bool
+	lock  sync.Mutex
 }
 
-func newRecordCollector() *recordCollector {
-	return &recordCollector{rs: make(map[peer.ID]*recordSequence)}
+// newRecordCollector creates a collector, known reports records already processed locally.
+func newRecordCollector(known func(cid.Cid) bool) *recordCollector {
+	return &recordCollector{rs: make(map[peer.ID]*recordSequence), known: known}
 }
 
 // Store the record of the log.
@@ -32,6 +34,7 @@ func (r *recordCollector) Store(lid peer.ID, rec core.Record) {
 	seq, found := r.rs[lid]
 	if !found {
 		seq = newRecordSequence()
+		seq.known = r.known
 		r.rs[lid] = seq
 	}
 
@@ -63,6 +66,8 @@ func (r *recordCollector) List() (map[peer.ID][]core.Record, error) {
 type recordSequence struct {
 	fragments [][]linkedRecord
 	set       map[cid.Cid]struct{}
+	// optional, reports records which sequence fragments could start from
+	known func(cid.Cid) bool
 }
 
 func newRecordSequence() *recordSequence {
@@ -91,7 +96,8 @@ func (s *recordSequence) Store(rec linkedRecord) {
 	s.fragments = append(s.fragments, []linkedRecord{rec})
 }
 
-// return reconstructed sequence and success flag
+// return reconstructed sequence and success flag. Branches of forked logs
+// are placed after the fragments they branch off.
 func (s *recordSequence) List() ([]linkedRecord, bool) {
 LOOP:
 	// avoid recursion as sequences could be pretty large
@@ -100,31 +106,72 @@ LOOP:
 			return s.fragments[0], true
 		}
 
-		// take a fragment ...
-		fragment := s.fragments[0]
-		fHead, fTail := fragment[len(fragment)-1], fragment[0]
-
-		// ... and try to compose it with another one
-		for i, candidate := range s.fragments[1:] {
-			cHead, cTail := candidate[len(candidate)-1], candidate[0]
-			// index shifted by slicing
-			i += 1
-
-			if fHead.Cid() == cTail.PrevID() {
-				// composition: (tail) <- fragment <- candidate <- (head)
-				s.fragments[0] = append(fragment, candidate...)
-				s.fragments = append(s.fragments[:i], s.fragments[i+1:]...)
-				continue LOOP
-
-			} else if fTail.PrevID() == cHead.Cid() {
-				// composition: (tail) <- candidate <- fragment <- (head)
-				s.fragments[i] = append(candidate, fragment...)
-				s.fragments = s.fragments[1:]
-				continue LOOP
+		for f, fragment := range s.fragments {
+			// take a fragment ...
+			fHead, fTail := fragment[len(fragment)-1], fragment[0]
+
+			// ... and try to compose it with another one
+			for i, candidate := range s.fragments {
+				if i == f {
+					continue
+				}
+				cHead, cTail := candidate[len(candidate)-1], candidate[0]
+
+				if fHead.Cid() == cTail.PrevID() {
+					// composition: (tail) <- fragment <- candidate <- (head)
+					s.fragments[f] = append(fragment, candidate...)
+					s.fragments = append(s.fragments[:i], s.fragments[i+1:]...)
+					continue LOOP
+
+				} else if fTail.PrevID() == cHead.Cid() {
+					// composition: (tail) <- candidate <- fragment <- (head)
+					s.fragments[i] = append(candidate, fragment...)
+					s.fragments = append(s.fragments[:f], s.fragments[f+1:]...)
+					continue LOOP
+				}
 			}
 		}
 
-		// no composition found, hence there are at least two disjoint fragments
-		return nil, false
+		// no composition found, remaining fragments are either branches or disjoint
+		return s.branches()
 	}
 }
+
+// branches orders fragments which can't be composed linearly, so that every
+// branch follows the fragment containing its fork point. Several fragments
+// without a fork point are only accepted if all of them start from known records.
+func (s *recordSequence) branches() ([]linkedRecord, bool) {
+	var owner = make(map[cid.Cid]int)
+	for i, fragment := range s.fragments {
+		for _, rec := range fragment {
+			owner[rec.Cid()] = i
+		}
+	}
+
+	var (
+		roots    []int
+		children = make(map[int][]int)
+	)
+	for i, fragment := range s.fragments {
+		if p, ok := owner[fragment[0].PrevID()]; ok {
+			children[p] = append(children[p], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+	if len(roots) > 1 {
+		for _, r := range roots {
+			prev := s.fragments[r][0].PrevID()
+			if prev.Defined() && (s.known == nil || !s.known(prev)) {
+				return nil, false
+			}
+		}
+	}
+
+	var ordered []linkedRecord
+	for queue := roots; len(queue) > 0; queue = queue[1:] {
+		ordered = append(ordered, s.fragments[queue[0]]...)
+		queue = append(queue, children[queue[0]]...)
+	}
+	return ordered, true
+}
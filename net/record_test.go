@@ -70,6 +70,40 @@ func TestNet_RecordSequenceGaps(t *testing.T) {
 	}
 }
 
+func TestNet_RecordSequenceFork(t *testing.T) {
+	seqLen := 10
+	trunk := generateSequence(cid.Undef, seqLen)
+	branch := make([]linkedRecord, seqLen/2)
+	for i, prev := 0, trunk[seqLen/2].Cid(); i < len(branch); i++ {
+		branch[i] = generateRecord([]byte(fmt.Sprintf("branch:%d", i)), prev)
+		prev = branch[i].Cid()
+	}
+	recs := newRecordSequence()
+
+	for _, rec := range branch {
+		recs.Store(rec)
+	}
+	for _, rec := range trunk {
+		recs.Store(rec)
+	}
+
+	collected, ok := recs.List()
+	if !ok {
+		t.Fatal("cannot reconstruct forked record sequence")
+	}
+	if len(collected) != len(trunk)+len(branch) {
+		t.Fatalf("expected %d records, got %d", len(trunk)+len(branch), len(collected))
+	}
+	// every record must follow its parent
+	seen := map[cid.Cid]struct{}{cid.Undef: {}}
+	for _, rec := range collected {
+		if _, ok := seen[rec.PrevID()]; !ok {
+			t.Fatalf("record precedes its parent: %s", formatSequence(collected))
+		}
+		seen[rec.Cid()] = struct{}{}
+	}
+}
+
 func generateSequence(from cid.Cid, size int) []linkedRecord {
 	var (
 		prev = from
@@ -0,0 +1,60 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+
+	format "github.com/ipfs/go-ipld-format"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+// DefaultMaxRecordSize is the byte limit on inbound record nodes used if
+// Config.MaxRecordSize is not set.
+var DefaultMaxRecordSize = 4 << 20
+
+// DefaultMaxRecordBodySize is the byte limit on record bodies used if
+// Config.MaxRecordBodySize is not set.
+var DefaultMaxRecordBodySize = 64 << 20
+
+// ErrRecordTooLarge indicates that a node of a record received from a peer exceeds the size limit.
+var ErrRecordTooLarge = errors.New("record exceeds size limit")
+
+// checkProtoRecordSize ensures that the raw nodes of a received record don't
+// exceed the limit, so oversized records are rejected before being decoded.
+func (n *net) checkProtoRecordSize(rec *pb.Log_Record) error {
+	if rec == nil {
+		return nil
+	}
+	for name, data := range map[string][]byte{
+		"record": rec.RecordNode,
+		"event":  rec.EventNode,
+		"header": rec.HeaderNode,
+		"body":   rec.BodyNode,
+	} {
+		if err := n.checkSize(name, len(data)); err != nil {
+			return err
+		}
+	}
+	return n.checkSize("extensions", len(rec.Extensions))
+}
+
+// checkNodeSize ensures that a record node loaded from the network doesn't exceed the limit.
+func (n *net) checkNodeSize(name string, node format.Node) error {
+	return n.checkSize(name, len(node.RawData()))
+}
+
+// checkBodySize ensures that the whole body of a record doesn't exceed the body limit.
+// Bodies are chunked, so it's checked separately from the limit on single nodes.
+func (n *net) checkBodySize(size int64) error {
+	if n.maxRecordBodySize > 0 && size > int64(n.maxRecordBodySize) {
+		return fmt.Errorf("body of %d bytes: %w", size, ErrRecordTooLarge)
+	}
+	return nil
+}
+
+func (n *net) checkSize(name string, size int) error {
+	if n.maxRecordSize > 0 && size > n.maxRecordSize {
+		return fmt.Errorf("%s node of %d bytes: %w", name, size, ErrRecordTooLarge)
+	}
+	return nil
+}
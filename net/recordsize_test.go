@@ -0,0 +1,32 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+func TestNet_CheckRecordSize(t *testing.T) {
+	n := &net{maxRecordSize: 16}
+	rec := &pb.Log_Record{
+		RecordNode: bytes.Repeat([]byte{1}, 16),
+		EventNode:  []byte{2},
+		HeaderNode: []byte{3},
+		BodyNode:   []byte{4},
+	}
+	if err := n.checkProtoRecordSize(rec); err != nil {
+		t.Fatalf("record within limit was rejected: %v", err)
+	}
+
+	rec.BodyNode = bytes.Repeat([]byte{4}, 17)
+	if err := n.checkProtoRecordSize(rec); !errors.Is(err, ErrRecordTooLarge) {
+		t.Fatalf("expected ErrRecordTooLarge, got %v", err)
+	}
+
+	n.maxRecordSize = -1
+	if err := n.checkProtoRecordSize(rec); err != nil {
+		t.Fatalf("disabled limit rejected a record: %v", err)
+	}
+}
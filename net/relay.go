@@ -0,0 +1,337 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/net/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RelayRetentionInterval is the interval between checks for relayed threads past retention.
+var RelayRetentionInterval = time.Minute * 10
+
+// ErrRelayQuotaExceeded indicates that a thread doesn't fit into the relay quotas.
+var ErrRelayQuotaExceeded = errors.New("relay quota exceeded")
+
+const (
+	// relayedKey is the metadata key marking a thread stored on behalf of peers.
+	relayedKey = "/relayed"
+	// relayBytesKey is the metadata key of the bytes stored for a relayed thread.
+	relayBytesKey = "/relay/bytes"
+	// relayUpdatedKey is the metadata key of the unix time a relayed thread was last updated.
+	relayUpdatedKey = "/relay/updated"
+)
+
+// RelayConfig makes the host a relay, which stores and serves opaque records of threads
+// pushed by peers without the read key, so replicators which are rarely online at the
+// same time can sync through it. Threads added by peers count against the quotas until
+// the host learns the read key. The mode is advertised to peers, see PeerCapabilities.
+type RelayConfig struct {
+	Enabled bool
+
+	// MaxThreads is the number of relayed threads accepted from peers. Zero is unlimited.
+	MaxThreads int
+
+	// MaxThreadBytes is the storage quota of a single relayed thread, counting the record,
+	// event, header and body nodes. Records beyond the quota are refused before their nodes are
+	// stored, and pruned records give their bytes back. Zero is unlimited.
+	MaxThreadBytes int64
+
+	// Retention is the duration a relayed thread is kept without receiving records,
+	// it's deleted afterwards. Zero keeps relayed threads forever.
+	Retention time.Duration
+}
+
+// GetCapabilities receives a request for the optional services of the host.
+func (s *server) GetCapabilities(ctx context.Context, _ *pb.GetCapabilitiesRequest) (*pb.GetCapabilitiesReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("received get capabilities request from %s", pid)
+
+	reply := &pb.GetCapabilitiesReply{
+		Compression:     messageCodecs,
+		BodyCompression: cbor.BodyCodecs,
+	}
+	if conf := s.net.relay; conf.Enabled {
+		reply.Relay = &pb.GetCapabilitiesReply_Relay{
+			MaxThreads:     int64(conf.MaxThreads),
+			MaxThreadBytes: conf.MaxThreadBytes,
+			Retention:      int64(conf.Retention / time.Second),
+		}
+	}
+	return reply, nil
+}
+
+// PeerCapabilities returns the optional services advertised by a peer.
+// Peers running versions without the advertisement report no capabilities.
+func (n *net) PeerCapabilities(ctx context.Context, pid peer.ID) (core.Capabilities, error) {
+	client, release, err := n.server.dial(pid)
+	if err != nil {
+		return core.Capabilities{}, fmt.Errorf("dial %s failed: %w", pid, err)
+	}
+	defer release()
+	cctx, cancel := context.WithTimeout(ctx, PullTimeout)
+	defer cancel()
+	protocol, err := n.negotiateProtocol(cctx, pid)
+	if err != nil {
+		return core.Capabilities{}, err
+	}
+	reply, err := client.GetCapabilities(cctx, &pb.GetCapabilitiesRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return core.Capabilities{Protocol: protocol}, nil
+	} else if err != nil {
+		return core.Capabilities{}, fmt.Errorf("get capabilities from %s failed: %w", pid, err)
+	}
+
+	// saved like the compression advertised in metadata
+	n.setPeerCodecs(pid, compressionKey, reply.Compression)
+	n.setPeerCodecs(pid, bodyCompressionKey, reply.BodyCompression)
+
+	caps := core.Capabilities{
+		Compression:     reply.Compression,
+		BodyCompression: reply.BodyCompression,
+		Protocol:        protocol,
+	}
+	if reply.Relay != nil {
+		caps.Relay = &core.RelayCapability{
+			MaxThreads:     int(reply.Relay.MaxThreads),
+			MaxThreadBytes: reply.Relay.MaxThreadBytes,
+			Retention:      time.Duration(reply.Relay.Retention) * time.Second,
+		}
+	}
+	return caps, nil
+}
+
+// loadRelayed restores the set of relayed threads from the logstore.
+func (n *net) loadRelayed() error {
+	cursor := newThreadCursor(n.store)
+	for {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+		if relayed, err := n.store.GetBool(tid, relayedKey); err != nil {
+			return err
+		} else if relayed != nil && *relayed {
+			n.relayed[tid] = struct{}{}
+		}
+	}
+}
+
+// admitRelayed counts a thread added by a peer against the relay quotas. It's a no-op
+// if the host isn't a relay, and fails with ErrRelayQuotaExceeded if the thread doesn't fit.
+func (n *net) admitRelayed(id thread.ID) error {
+	if !n.relay.Enabled {
+		return nil
+	}
+	n.relayLock.Lock()
+	defer n.relayLock.Unlock()
+	if _, ok := n.relayed[id]; ok {
+		return nil
+	}
+	if n.relay.MaxThreads > 0 && len(n.relayed) >= n.relay.MaxThreads {
+		return fmt.Errorf("relaying %d threads: %w", len(n.relayed), ErrRelayQuotaExceeded)
+	}
+	if err := n.store.PutBool(id, relayedKey, true); err != nil {
+		return err
+	}
+	if err := n.store.PutInt64(id, relayUpdatedKey, n.clock.Now().Unix()); err != nil {
+		return err
+	}
+	n.relayed[id] = struct{}{}
+	return nil
+}
+
+// releaseRelayed stops counting a thread against the relay quotas, e.g., once the read key is known.
+func (n *net) releaseRelayed(id thread.ID) error {
+	if !n.isRelayed(id) {
+		return nil
+	}
+	n.forgetRelayed(id)
+	if err := n.store.PutBool(id, relayedKey, false); err != nil {
+		return err
+	}
+	return n.store.PutInt64(id, relayBytesKey, 0)
+}
+
+// forgetRelayed drops a thread from the in-memory set of relayed threads.
+func (n *net) forgetRelayed(id thread.ID) {
+	n.relayLock.Lock()
+	delete(n.relayed, id)
+	n.relayLock.Unlock()
+}
+
+func (n *net) isRelayed(id thread.ID) bool {
+	n.relayLock.Lock()
+	defer n.relayLock.Unlock()
+	_, ok := n.relayed[id]
+	return ok
+}
+
+// relayUsage returns the bytes charged against the quota of a relayed thread.
+func (n *net) relayUsage(tid thread.ID) (int64, error) {
+	used, err := n.store.GetInt64(tid, relayBytesKey)
+	if err != nil || used == nil {
+		return 0, err
+	}
+	return *used, nil
+}
+
+// fitsRelayed fails with ErrRelayQuotaExceeded if size more bytes don't fit into the quota of a relayed thread.
+func (n *net) fitsRelayed(tid thread.ID, size int64) error {
+	if n.relay.MaxThreadBytes <= 0 {
+		return nil
+	}
+	used, err := n.relayUsage(tid)
+	if err != nil {
+		return err
+	}
+	if used+size > n.relay.MaxThreadBytes {
+		return fmt.Errorf("relayed thread %s would hold %d bytes: %w", tid, used+size, ErrRelayQuotaExceeded)
+	}
+	return nil
+}
+
+// checkRelayed returns the size charged for a record about to be added to a relayed thread, failing
+// with ErrRelayQuotaExceeded if the thread would exceed RelayConfig.MaxThreadBytes. It's zero if the
+// thread isn't relayed. Records of relayed threads are only received from peers, since the host
+// lacks their read key, so it must be called holding the thread and log semaphores.
+func (n *net) checkRelayed(ctx context.Context, tid thread.ID, rec core.Record) (int64, error) {
+	if !n.isRelayed(tid) {
+		return 0, nil
+	}
+	size, err := n.recordBytes(ctx, rec)
+	if err != nil {
+		return 0, err
+	}
+	if err = n.fitsRelayed(tid, size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// chargeRelayed adds the size of a record added to a relayed thread to its usage, and
+// notes the update for the retention.
+func (n *net) chargeRelayed(tid thread.ID, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	n.relayLock.Lock()
+	defer n.relayLock.Unlock()
+	used, err := n.relayUsage(tid)
+	if err != nil {
+		return err
+	}
+	if err = n.store.PutInt64(tid, relayBytesKey, used+size); err != nil {
+		return err
+	}
+	return n.store.PutInt64(tid, relayUpdatedKey, n.clock.Now().Unix())
+}
+
+// releaseRelayedBytes subtracts the size of records pruned from a relayed thread from its usage.
+func (n *net) releaseRelayedBytes(tid thread.ID, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	n.relayLock.Lock()
+	defer n.relayLock.Unlock()
+	used, err := n.relayUsage(tid)
+	if err != nil {
+		return err
+	}
+	return n.store.PutInt64(tid, relayBytesKey, nonNegative(used-size))
+}
+
+// recordBytes returns the total size of the record, event, header and body nodes of a record.
+// Chunked bodies are counted with their declared size.
+func (n *net) recordBytes(ctx context.Context, rec core.Record) (int64, error) {
+	block, err := rec.GetBlock(ctx, n)
+	if err != nil {
+		return 0, err
+	}
+	event, ok := block.(*cbor.Event)
+	if !ok {
+		if event, err = cbor.EventFromNode(block); err != nil {
+			return 0, fmt.Errorf("invalid event: %w", err)
+		}
+	}
+	header, err := event.GetHeader(ctx, n, nil)
+	if err != nil {
+		return 0, err
+	}
+	size := len(rec.RawData()) + len(block.RawData()) + len(header.RawData())
+	if isRestrictedRecord(rec) {
+		return int64(size), nil // the body isn't stored
+	}
+	body, err := event.GetBody(ctx, n, nil)
+	if err != nil {
+		return 0, err
+	}
+	return int64(size) + util.BodyBytes(body), nil
+}
+
+// startRelayRetention periodically deletes relayed threads which weren't updated within the retention.
+func (n *net) startRelayRetention() {
+	tick := n.clock.NewTicker(RelayRetentionInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.Chan():
+			n.expireRelayed()
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// expireRelayed deletes relayed threads past the retention, and releases
+// the threads whose read key was added meanwhile.
+func (n *net) expireRelayed() {
+	n.relayLock.Lock()
+	ids := make([]thread.ID, 0, len(n.relayed))
+	for id := range n.relayed {
+		ids = append(ids, id)
+	}
+	n.relayLock.Unlock()
+
+	for _, id := range ids {
+		if n.ctx.Err() != nil {
+			return
+		}
+		if rk, err := n.store.ReadKey(id); err != nil {
+			log.Errorf("error getting read key of thread %s: %v", id, err)
+			continue
+		} else if rk != nil {
+			if err = n.releaseRelayed(id); err != nil {
+				log.Errorf("error releasing relayed thread %s: %v", id, err)
+			}
+			continue
+		}
+		updated, err := n.store.GetInt64(id, relayUpdatedKey)
+		if err != nil {
+			log.Errorf("error getting update time of thread %s: %v", id, err)
+			continue
+		}
+		if updated != nil && n.clock.Now().Sub(time.Unix(*updated, 0)) < n.relay.Retention {
+			continue
+		}
+		log.Infof("deleting relayed thread %s past retention", id)
+		if err = n.deleteThread(n.ctx, id); err != nil && n.ctx.Err() == nil {
+			log.Errorf("error deleting relayed thread %s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+)
+
+func TestNet_Relay(t *testing.T) {
+	t.Parallel()
+	conf := RelayConfig{
+		Enabled:        true,
+		MaxThreads:     1,
+		MaxThreadBytes: 4096,
+		Retention:      time.Minute,
+	}
+	n1 := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{Relay: conf}).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx := context.Background()
+
+	caps, err := n2.PeerCapabilities(ctx, n1.Host().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Relay == nil || caps.Relay.MaxThreads != conf.MaxThreads ||
+		caps.Relay.MaxThreadBytes != conf.MaxThreadBytes || caps.Relay.Retention != conf.Retention {
+		t.Fatalf("got bad relay capability %+v", caps.Relay)
+	}
+	if caps, err = n1.PeerCapabilities(ctx, n2.Host().ID()); err != nil {
+		t.Fatal(err)
+	} else if caps.Relay != nil {
+		t.Fatal("expected peer not to be a relay")
+	}
+
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := createThread(t, ctx, n2)
+	if _, err = n2.AddReplicator(ctx, info.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	if !n1.isRelayed(info.ID) {
+		t.Fatal("expected thread to be relayed")
+	}
+	other := createThread(t, ctx, n2)
+	if _, err = n2.AddReplicator(ctx, other.ID, addr); err == nil || !strings.Contains(err.Error(), ErrRelayQuotaExceeded.Error()) {
+		t.Fatalf("expected relayed threads quota to be exceeded, got %v", err)
+	}
+
+	var recs []core.ThreadRecord
+	for i := 0; i < 10; i++ {
+		body, err := cbornode.WrapObject(map[string]interface{}{
+			"payload": strings.Repeat("x", 512),
+			"index":   i,
+		}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n2.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+	time.Sleep(time.Second)
+
+	used, err := n1.store.GetInt64(info.ID, relayBytesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used == nil || *used == 0 || *used > conf.MaxThreadBytes {
+		t.Fatalf("expected relayed bytes within the quota, got %v", used)
+	}
+
+	// blocks of records beyond the quota aren't stored
+	var kept []core.ThreadRecord
+	for _, r := range recs {
+		if known, err := n1.isKnown(r.Value().Cid()); err != nil {
+			t.Fatal(err)
+		} else if known {
+			kept = append(kept, r)
+		} else if has, err := n1.bstore.Has(r.Value().BlockID()); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatalf("expected event of refused record %s not to be stored", r.Value().Cid())
+		}
+	}
+	if len(kept) < 2 || len(kept) == len(recs) {
+		t.Fatalf("expected some records to be refused, kept %d of %d", len(kept), len(recs))
+	}
+
+	// pruned records give their bytes back
+	last := kept[len(kept)-1]
+	if err = n1.withThreadLock(info.ID, func() error {
+		_, err := n1.pruneLog(ctx, info.ID, last.LogID(), last.Value(), info.Key.Service())
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := n1.store.GetInt64(info.ID, relayBytesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned == nil || *pruned == 0 || *pruned >= *used {
+		t.Fatalf("expected pruned records to be released from %d bytes, got %v", *used, pruned)
+	}
+
+	// threads updated within the retention are kept
+	n1.expireRelayed()
+	if _, err = n1.store.GetThread(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = n1.store.PutInt64(info.ID, relayUpdatedKey, time.Now().Add(-time.Hour).Unix()); err != nil {
+		t.Fatal(err)
+	}
+	n1.expireRelayed()
+	if _, err = n1.store.GetThread(info.ID); !errors.Is(err, lstore.ErrThreadNotFound) {
+		t.Fatalf("expected relayed thread past retention to be deleted, got %v", err)
+	}
+	if n1.isRelayed(info.ID) {
+		t.Fatal("expected deleted thread not to be relayed")
+	}
+}
@@ -0,0 +1,148 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// metadata suffix marking a peer removed from the thread replicators
+const removedReplicatorSuffix = "/removed-replicator"
+
+// RemoveReplicator strips the peer address from the managed logs and pushes them to the
+// remaining peers, whose signed address sets are replaced. The peer is marked as removed,
+// so it isn't pulled even if external logs still list it. KeyRotationHook is called before
+// the logs are pushed, and the service key it returns replaces the thread one at the next
+// key epoch, carried along with the logs.
+func (n *net) RemoveReplicator(
+	ctx context.Context,
+	id thread.ID,
+	pid peer.ID,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return err
+	}
+	if pid == n.host.ID() {
+		return fmt.Errorf("cannot remove the host from replicators")
+	}
+
+	if err := n.withThreadLock(id, func() error {
+		if _, err := n.store.GetThread(id); err != nil {
+			return err
+		}
+		// the peer is marked first, so an interrupted removal doesn't pull from it anyway
+		if err := n.store.PutBool(id, pid.Pretty()+removedReplicatorSuffix, true); err != nil {
+			return err
+		}
+		logs, err := n.store.GetManagedLogs(id)
+		if err != nil {
+			return err
+		}
+		for _, lg := range logs {
+			addrs := addrsWithoutPeer(lg.Addrs, pid)
+			if len(addrs) == len(lg.Addrs) {
+				continue
+			}
+			// setting addresses only refreshes the given ones, the stale ones are dropped first
+			if err = n.store.ClearAddrs(id, lg.ID); err != nil {
+				return err
+			}
+			if err = n.store.AddAddrs(id, lg.ID, addrs, pstore.PermanentAddrTTL); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if n.keyRotationHook != nil {
+		sk, err := n.keyRotationHook(ctx, id, pid)
+		if err != nil {
+			return fmt.Errorf("rotating service key: %w", err)
+		}
+		if sk != nil {
+			if err = n.rotateServiceKey(ctx, id, sk); err != nil {
+				return fmt.Errorf("rotating service key: %w", err)
+			}
+		}
+	}
+	// the host log signing the rotation may have been created along with it
+	managedLogs, err := n.store.GetManagedLogs(id)
+	if err != nil {
+		return err
+	}
+
+	// Send the updated log(s) to the remaining peers
+	peers, err := n.server.threadPeers(id)
+	if err != nil {
+		return err
+	}
+	if peers, err = n.withoutRemovedReplicators(id, peers); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, p := range peers {
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			for _, lg := range managedLogs {
+				if err := n.server.pushLog(ctx, id, lg, p, nil, nil); err != nil {
+					log.Errorf("error pushing log %s to %s: %v", lg.ID, p, err)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	n.emit(core.LifecycleEvent{Type: core.ReplicatorRemoved, ThreadID: id, PeerID: pid})
+	return nil
+}
+
+// isRemovedReplicator returns whether the peer was removed from the thread replicators.
+func (n *net) isRemovedReplicator(tid thread.ID, pid peer.ID) (bool, error) {
+	removed, err := n.store.GetBool(tid, pid.Pretty()+removedReplicatorSuffix)
+	if err != nil || removed == nil {
+		return false, err
+	}
+	return *removed, nil
+}
+
+// withoutRemovedReplicators filters the peers removed from the thread replicators out.
+func (n *net) withoutRemovedReplicators(tid thread.ID, peers []peer.ID) ([]peer.ID, error) {
+	kept := peers[:0]
+	for _, p := range peers {
+		removed, err := n.isRemovedReplicator(tid, p)
+		if err != nil {
+			return nil, err
+		}
+		if !removed {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
+
+// addrsWithoutPeer returns the addresses which don't point to the peer.
+func addrsWithoutPeer(addrs []ma.Multiaddr, pid peer.ID) []ma.Multiaddr {
+	kept := make([]ma.Multiaddr, 0, len(addrs))
+	for _, addr := range addrs {
+		if p2p, err := addr.ValueForProtocol(ma.P_P2P); err == nil {
+			if p, err := peer.Decode(p2p); err == nil && p == pid {
+				continue
+			}
+		}
+		kept = append(kept, addr)
+	}
+	return kept
+}
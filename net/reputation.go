@@ -0,0 +1,205 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+var reputationPrefix = ds.NewKey("/reputation")
+
+var (
+	// DefaultPeerBanThreshold is the number of misbehaviors a peer is banned after
+	// if Config.PeerBanThreshold is not set.
+	DefaultPeerBanThreshold = 3
+
+	// DefaultPeerBanDuration is the duration of bans if Config.PeerBanDuration is not set.
+	DefaultPeerBanDuration = time.Hour
+
+	// PeerBackoff is the time the pull scheduler skips a peer for after a failed call. It's
+	// doubled with every consecutive failure, up to PeerMaxBackoff.
+	PeerBackoff    = 30 * time.Second
+	PeerMaxBackoff = 30 * time.Minute
+)
+
+// latencyWeight is the weight of a new round trip in the moving average latency of a peer.
+const latencyWeight = 0.2
+
+// peerCall is a kind of call tracked by the reputation table.
+type peerCall int
+
+const (
+	callExchange peerCall = iota
+	callPull
+)
+
+// reputations keeps the sync track record of peers. Every update is persisted,
+// so bans and backoffs survive restarts.
+type reputations struct {
+	store        ds.Datastore
+	clock        clock.Clock
+	banThreshold int
+	banDuration  time.Duration
+
+	mx    sync.Mutex
+	peers map[peer.ID]core.PeerReputation
+}
+
+func newReputations(store ds.Datastore, clk clock.Clock, banThreshold int, banDuration time.Duration) (*reputations, error) {
+	r := &reputations{
+		store:        store,
+		clock:        clk,
+		banThreshold: banThreshold,
+		banDuration:  banDuration,
+		peers:        make(map[peer.ID]core.PeerReputation),
+	}
+	res, err := store.Query(query.Query{Prefix: reputationPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, e.Error
+		}
+		pid, err := peer.Decode(ds.RawKey(e.Key).BaseNamespace())
+		if err != nil {
+			log.Warnf("skipping malformed reputation entry %s: %v", e.Key, err)
+			continue
+		}
+		var rep core.PeerReputation
+		if err = json.Unmarshal(e.Value, &rep); err != nil {
+			log.Warnf("skipping malformed reputation entry %s: %v", e.Key, err)
+			continue
+		}
+		r.peers[pid] = rep
+	}
+	return r, nil
+}
+
+func (n *net) PeerReputations(_ context.Context) (map[peer.ID]core.PeerReputation, error) {
+	return n.reputation.list(), nil
+}
+
+func (n *net) ResetPeerReputation(_ context.Context, pid peer.ID) error {
+	return n.reputation.reset(pid)
+}
+
+// called tracks the outcome and round trip of a call to a peer.
+func (r *reputations) called(pid peer.ID, kind peerCall, rtt time.Duration, err error) {
+	r.update(pid, func(rep *core.PeerReputation) {
+		switch kind {
+		case callExchange:
+			rep.Exchanges++
+			if err != nil {
+				rep.ExchangeFailures++
+			}
+		case callPull:
+			rep.Pulls++
+			if err != nil {
+				rep.PullFailures++
+			}
+		}
+		if err != nil {
+			rep.ConsecutiveFailures++
+			rep.LastFailure = r.clock.Now()
+			return
+		}
+		rep.ConsecutiveFailures = 0
+		if rep.Latency == 0 {
+			rep.Latency = rtt
+		} else {
+			rep.Latency += time.Duration(latencyWeight * float64(rtt-rep.Latency))
+		}
+	})
+}
+
+// misbehaved tracks a response of a peer with invalid records. Every
+// banThreshold-th misbehavior bans the peer for banDuration.
+func (r *reputations) misbehaved(pid peer.ID, err error) {
+	r.update(pid, func(rep *core.PeerReputation) {
+		now := r.clock.Now()
+		rep.Misbehaviors++
+		rep.LastFailure = now
+		if r.banThreshold > 0 && rep.Misbehaviors%r.banThreshold == 0 {
+			rep.BannedUntil = now.Add(r.banDuration)
+			log.Warnf("banning peer %s until %s after %d misbehaviors, last: %v",
+				pid, rep.BannedUntil.Format(time.RFC3339), rep.Misbehaviors, err)
+		}
+	})
+}
+
+// banned returns whether a peer is banned.
+func (r *reputations) banned(pid peer.ID) bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.peers[pid].Banned(r.clock.Now())
+}
+
+// schedulable returns whether the pull scheduler should call a peer,
+// i.e., the peer is neither banned nor backed off from after failed calls.
+func (r *reputations) schedulable(pid peer.ID) bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	rep, now := r.peers[pid], r.clock.Now()
+	if rep.Banned(now) {
+		return false
+	}
+	return rep.ConsecutiveFailures == 0 || !now.Before(rep.LastFailure.Add(backoff(rep.ConsecutiveFailures)))
+}
+
+// backoff returns the time to skip a peer for after the given number of consecutive failures.
+func backoff(failures int) time.Duration {
+	d := PeerBackoff
+	for i := 1; i < failures && d < PeerMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > PeerMaxBackoff {
+		d = PeerMaxBackoff
+	}
+	return d
+}
+
+func (r *reputations) list() map[peer.ID]core.PeerReputation {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	res := make(map[peer.ID]core.PeerReputation, len(r.peers))
+	for pid, rep := range r.peers {
+		res[pid] = rep
+	}
+	return res
+}
+
+func (r *reputations) reset(pid peer.ID) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	delete(r.peers, pid)
+	return r.store.Delete(r.key(pid))
+}
+
+func (r *reputations) update(pid peer.ID, fn func(rep *core.PeerReputation)) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	rep := r.peers[pid]
+	fn(&rep)
+	r.peers[pid] = rep
+	data, err := json.Marshal(rep)
+	if err != nil {
+		log.Errorf("encoding reputation of %s: %v", pid, err)
+		return
+	}
+	if err = r.store.Put(r.key(pid), data); err != nil {
+		log.Errorf("saving reputation of %s: %v", pid, err)
+	}
+}
+
+func (r *reputations) key(pid peer.ID) ds.Key {
+	return reputationPrefix.ChildString(pid.Pretty())
+}
@@ -0,0 +1,93 @@
+package net
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+func TestReputations_Backoff(t *testing.T) {
+	store := syncds.MutexWrap(ds.NewMapDatastore())
+	clk := clock.NewMock(time.Now())
+	reps, err := newReputations(store, clk, 3, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := tu.RandPeerIDFatal(t)
+
+	reps.called(pid, callExchange, 100*time.Millisecond, nil)
+	reps.called(pid, callPull, 0, errors.New("unavailable"))
+	reps.called(pid, callPull, 0, errors.New("unavailable"))
+	rep := reps.list()[pid]
+	if rep.Exchanges != 1 || rep.Pulls != 2 || rep.PullFailures != 2 || rep.ConsecutiveFailures != 2 {
+		t.Fatalf("unexpected reputation %+v", rep)
+	}
+	if rep.Latency != 100*time.Millisecond {
+		t.Fatalf("expected latency of successful calls only, got %s", rep.Latency)
+	}
+
+	// two consecutive failures double the backoff
+	if reps.schedulable(pid) {
+		t.Fatal("expected peer to be backed off")
+	}
+	clk.Add(PeerBackoff)
+	if reps.schedulable(pid) {
+		t.Fatal("expected backoff to double")
+	}
+	clk.Add(PeerBackoff)
+	if !reps.schedulable(pid) {
+		t.Fatal("expected backoff to expire")
+	}
+	reps.called(pid, callExchange, 100*time.Millisecond, nil)
+	if rep = reps.list()[pid]; rep.ConsecutiveFailures != 0 {
+		t.Fatalf("expected success to reset failures, got %d", rep.ConsecutiveFailures)
+	}
+}
+
+func TestReputations_Ban(t *testing.T) {
+	store := syncds.MutexWrap(ds.NewMapDatastore())
+	clk := clock.NewMock(time.Now())
+	reps, err := newReputations(store, clk, 3, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := tu.RandPeerIDFatal(t)
+
+	for i := 0; i < 3; i++ {
+		if reps.banned(pid) {
+			t.Fatalf("expected peer not to be banned after %d misbehaviors", i)
+		}
+		reps.misbehaved(pid, errors.New("bad signature"))
+	}
+	if !reps.banned(pid) || reps.schedulable(pid) {
+		t.Fatal("expected peer to be banned")
+	}
+
+	// bans survive restarts
+	if reps, err = newReputations(store, clk, 3, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if !reps.banned(pid) {
+		t.Fatal("expected ban to be restored")
+	}
+	clk.Add(time.Hour)
+	if reps.banned(pid) {
+		t.Fatal("expected ban to expire")
+	}
+
+	reps.misbehaved(pid, errors.New("bad signature"))
+	if err = reps.reset(pid); err != nil {
+		t.Fatal(err)
+	}
+	if reps, err = newReputations(store, clk, 3, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reps.list()[pid]; ok {
+		t.Fatal("expected reputation to be reset")
+	}
+}
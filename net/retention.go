@@ -0,0 +1,379 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// RetentionInterval is the interval between passes of the reaper enforcing thread retention policies.
+var RetentionInterval = time.Minute * 10
+
+const (
+	// retentionKey is the metadata key of the thread retention policy, stored as JSON.
+	retentionKey = "/retention"
+	// retentionStateKey is the metadata key of the reaper progress of a thread, stored as JSON.
+	retentionStateKey = "/retention-state"
+)
+
+func (n *net) SetRetentionPolicy(
+	ctx context.Context,
+	id thread.ID,
+	policy core.RetentionPolicy,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot set retention policy: %w", app.ErrThreadInUse)
+	}
+	if policy.MaxRecords < 0 || policy.MaxBytes < 0 || policy.MaxAge < 0 {
+		return fmt.Errorf("invalid retention policy: negative limit")
+	}
+	if _, err := n.store.GetThread(id); err != nil {
+		return err
+	}
+	if err := n.withThreadLock(id, func() error {
+		return util.PutMetadataJSON(n.store, id, retentionKey, policy)
+	}); err != nil {
+		return err
+	}
+	_, err := n.reapThread(ctx, id)
+	return err
+}
+
+func (n *net) ThreadStats(ctx context.Context, id thread.ID, opts ...core.ThreadOption) (core.ThreadStats, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return core.ThreadStats{}, err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return core.ThreadStats{}, err
+	}
+	stats := core.ThreadStats{Logs: len(info.Logs)}
+	if stats.Retention, err = n.retentionPolicy(id); err != nil {
+		return stats, err
+	}
+	var state util.RetentionState
+	if err = util.GetMetadataJSON(n.store, id, retentionStateKey, &state); err != nil {
+		return stats, err
+	}
+	stats.Pruned = state.Pruned
+	if state.LastPruned != 0 {
+		stats.LastPruned = time.Unix(0, state.LastPruned)
+	}
+	if stats.Quota, err = n.threadQuota(id); err != nil {
+		return stats, err
+	}
+	used, err := n.store.GetInt64(id, quotaUsedKey)
+	if err != nil {
+		return stats, err
+	} else if used != nil {
+		stats.QuotaUsed = *used
+	}
+
+	var sizeErr error
+	err = n.walkThread(ctx, id, make(map[cid.Cid]struct{}), func(rid cid.Cid, ev *cbor.Event) {
+		stats.Records++
+		size, err := util.RecordSize(n.bstore, rid, ev)
+		if err != nil && sizeErr == nil {
+			sizeErr = err
+		}
+		stats.Bytes += size
+	})
+	if err == nil {
+		err = sizeErr
+	}
+	return stats, err
+}
+
+func (n *net) retentionPolicy(id thread.ID) (core.RetentionPolicy, error) {
+	var policy core.RetentionPolicy
+	return policy, util.GetMetadataJSON(n.store, id, retentionKey, &policy)
+}
+
+// startRetention periodically enforces the thread retention policies until the network is closed.
+func (n *net) startRetention() {
+	tick := n.clock.NewTicker(RetentionInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.Chan():
+			n.reapThreads()
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// reapThreads enforces the retention policies of all stored threads.
+func (n *net) reapThreads() {
+	var (
+		cursor = newThreadCursor(n.store)
+		pruned int
+	)
+	for {
+		tid, ok, err := cursor.Next()
+		if err != nil {
+			log.Errorf("error listing threads: %s", err)
+			return
+		} else if !ok {
+			break
+		}
+		if n.ctx.Err() != nil {
+			return
+		}
+		p, err := n.reapThread(n.ctx, tid)
+		if err != nil {
+			log.Errorf("error enforcing retention of thread %s: %v", tid, err)
+			continue
+		}
+		pruned += p
+	}
+	if pruned > 0 {
+		log.Debugf("retention reaper pruned %d records", pruned)
+	}
+}
+
+// reapThread prunes the records of the thread logs below the horizon of its retention policy,
+// and returns the number of pruned records.
+func (n *net) reapThread(ctx context.Context, id thread.ID) (int, error) {
+	policy, err := n.retentionPolicy(id)
+	if err != nil || policy.IsZero() {
+		return 0, err
+	}
+	ts, err := n.lockThread(id)
+	if err != nil {
+		return 0, err
+	}
+	defer ts.Release()
+	defer n.blockLocalWrites(id)()
+
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return 0, err
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return 0, nil // records of the thread can't be resolved, e.g., relayed threads
+	}
+	var state util.RetentionState
+	if err = util.GetMetadataJSON(n.store, id, retentionStateKey, &state); err != nil {
+		return 0, err
+	}
+	if state.Marks == nil {
+		state.Marks = make(map[string][]util.HeadMark)
+	}
+
+	now := n.clock.Now()
+	var pruned int
+	for _, lg := range info.Logs {
+		if !lg.Head.Defined() {
+			continue
+		}
+		expired := state.MarkHead(lg.ID, lg.Head, now, policy.MaxAge)
+		horizon, err := n.retentionHorizon(ctx, id, lg, policy, expired, sk)
+		if err != nil {
+			return pruned, err
+		}
+		if horizon == nil {
+			continue
+		}
+		p, err := n.pruneLog(ctx, id, lg.ID, horizon, sk)
+		pruned += p
+		if err != nil {
+			return pruned, err
+		}
+		log.Debugf("retention of log %s (thread=%s): pruned %d records before %s", lg.ID, id, p, horizon.Cid())
+	}
+	state.Pruned += pruned
+	if pruned > 0 {
+		state.LastPruned = now.UnixNano()
+	}
+	return pruned, util.PutMetadataJSON(n.store, id, retentionStateKey, state)
+}
+
+// retentionHorizon walks back from every log head, and returns the oldest record kept by the
+// policy if older records must be pruned, or nil otherwise. The heads are always kept, and so is
+// the log checkpoint: the horizon is the latest record all the records kept from the heads and
+// the checkpoint descend from. Logs without a checkpoint are never pruned, as the state of the
+// thread couldn't be rebuilt without their records.
+func (n *net) retentionHorizon(
+	ctx context.Context,
+	tid thread.ID,
+	lg thread.LogInfo,
+	policy core.RetentionPolicy,
+	expired cid.Cid,
+	sk *sym.Key,
+) (core.Record, error) {
+	checkpoint, err := util.LogMarker(n.store, tid, lg.ID, checkpointSuffix)
+	if err != nil || !checkpoint.Defined() {
+		return nil, err
+	}
+	boundary, err := util.LogMarker(n.store, tid, lg.ID, boundarySuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := lg.Heads
+	if len(heads) == 0 {
+		heads = []cid.Cid{lg.Head}
+	}
+	kept := make([]cid.Cid, 0, len(heads)+1)
+	for _, head := range heads {
+		horizon, err := n.headHorizon(ctx, head, policy, expired, boundary, sk)
+		if err != nil || !horizon.Defined() {
+			return nil, err
+		}
+		kept = append(kept, horizon)
+	}
+	// the checkpoint may be missing, e.g., pruned by an earlier compaction
+	if known, err := n.isKnown(checkpoint); err != nil {
+		return nil, err
+	} else if known {
+		kept = append(kept, checkpoint)
+	}
+	horizon, err := n.commonAncestor(ctx, kept, boundary, sk)
+	if err != nil || !horizon.Defined() || horizon.Equals(boundary) {
+		return nil, err // nothing is kept below the boundary
+	}
+	return cbor.GetRecord(ctx, n, horizon, sk)
+}
+
+// headHorizon walks back from a log head, and returns the oldest record kept by the policy
+// if older records must be pruned, or cid.Undef otherwise.
+func (n *net) headHorizon(
+	ctx context.Context,
+	head cid.Cid,
+	policy core.RetentionPolicy,
+	expired cid.Cid,
+	boundary cid.Cid,
+	sk *sym.Key,
+) (cid.Cid, error) {
+	var (
+		horizon   cid.Cid
+		count     int
+		size      int64
+		isExpired bool
+		rid       = head
+	)
+	for ; rid.Defined(); count++ {
+		if err := ctx.Err(); err != nil {
+			return cid.Undef, err
+		}
+		if horizon.Defined() && horizon.Equals(boundary) {
+			return cid.Undef, nil // nothing is kept below the boundary
+		}
+		// stop at records missing locally, otherwise the dag service would fetch them
+		if known, err := n.isKnown(rid); err != nil {
+			return cid.Undef, err
+		} else if !known {
+			return cid.Undef, nil
+		}
+		rec, err := cbor.GetRecord(ctx, n, rid, sk)
+		if err != nil {
+			return cid.Undef, err
+		}
+		ev, err := cbor.EventFromRecord(ctx, n, rec)
+		if err != nil {
+			return cid.Undef, err
+		}
+		recSize, err := util.RecordSize(n.bstore, rid, ev)
+		if err != nil {
+			return cid.Undef, err
+		}
+		size += recSize
+		isExpired = isExpired || rid.Equals(expired)
+		if horizon.Defined() && (isExpired ||
+			(policy.MaxRecords > 0 && count >= policy.MaxRecords) ||
+			(policy.MaxBytes > 0 && size > policy.MaxBytes)) {
+			break
+		}
+		horizon = rid
+		rid = rec.PrevID()
+	}
+	if !rid.Defined() {
+		return cid.Undef, nil // the whole log is within the policy
+	}
+	return horizon, nil
+}
+
+// commonAncestor returns the latest record of a log the given records descend from, or
+// cid.Undef if it's missing locally, e.g., below the compaction boundary.
+func (n *net) commonAncestor(ctx context.Context, rids []cid.Cid, boundary cid.Cid, sk *sym.Key) (cid.Cid, error) {
+	if len(rids) == 1 {
+		return rids[0], nil
+	}
+	// ancestors of the first record, down to the boundary
+	var chain []cid.Cid
+	err := n.walkPrev(ctx, rids[0], boundary, sk, func(rid cid.Cid) bool {
+		chain = append(chain, rid)
+		return true
+	})
+	if err != nil {
+		return cid.Undef, err
+	}
+	for _, id := range rids[1:] {
+		index := make(map[cid.Cid]int, len(chain))
+		for i, rid := range chain {
+			index[rid] = i
+		}
+		found := -1
+		err = n.walkPrev(ctx, id, boundary, sk, func(rid cid.Cid) bool {
+			if i, ok := index[rid]; ok {
+				found = i
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return cid.Undef, err
+		} else if found < 0 {
+			return cid.Undef, nil
+		}
+		chain = chain[found:]
+	}
+	return chain[0], nil
+}
+
+// walkPrev calls visit with the record and its ancestors until visit returns false, down to the
+// boundary or the first record missing locally.
+func (n *net) walkPrev(ctx context.Context, rid, boundary cid.Cid, sk *sym.Key, visit func(cid.Cid) bool) error {
+	for rid.Defined() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if known, err := n.isKnown(rid); err != nil {
+			return err
+		} else if !known {
+			return nil
+		}
+		if !visit(rid) || rid.Equals(boundary) {
+			return nil
+		}
+		rec, err := cbor.GetRecord(ctx, n, rid, sk)
+		if err != nil {
+			return err
+		}
+		rid = rec.PrevID()
+	}
+	return nil
+}
@@ -0,0 +1,473 @@
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"github.com/textileio/go-threads/net/util"
+	"github.com/textileio/go-threads/util/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	revokedTokensPrefix        = ds.NewKey("/revoked/token")
+	revokedIdentitiesPrefix    = ds.NewKey("/revoked/identity")
+	revokedThreadMembersPrefix = ds.NewKey("/revoked/thread")
+)
+
+// revocation is a revoked identity. Revocations propagated by the writer of a thread apply to
+// the thread only, and may be lifted again by the writer, which is kept as a restored revocation,
+// so it supersedes older propagated revocations.
+type revocation struct {
+	at       time.Time
+	expires  time.Time
+	restored bool
+}
+
+// active returns whether the revocation applies at the given time.
+func (r revocation) active(now time.Time) bool {
+	return !r.restored && (r.expires.IsZero() || now.Before(r.expires))
+}
+
+func (r revocation) marshal() []byte {
+	b := make([]byte, 17)
+	binary.BigEndian.PutUint64(b, uint64(unixNano(r.at)))
+	binary.BigEndian.PutUint64(b[8:], uint64(unixNano(r.expires)))
+	if r.restored {
+		b[16] = 1
+	}
+	return b
+}
+
+func unmarshalRevocation(b []byte) (r revocation, ok bool) {
+	switch len(b) {
+	case 8: // revocations stored before they could expire, in seconds
+		return revocation{at: unixTime(int64(binary.BigEndian.Uint64(b)))}, true
+	case 17:
+		r.at = unixNanoTime(int64(binary.BigEndian.Uint64(b)))
+		r.expires = unixNanoTime(int64(binary.BigEndian.Uint64(b[8:])))
+		r.restored = b[16] == 1
+		return r, true
+	default:
+		return r, false
+	}
+}
+
+// revocations is the persisted list of revoked tokens and identities.
+// Revoked tokens are forgotten once they expire.
+type revocations struct {
+	store ds.Datastore
+	clock clock.Clock
+
+	mx         sync.RWMutex
+	tokens     map[string]time.Time
+	identities map[string]revocation
+	members    map[thread.ID]map[string]revocation
+}
+
+func newRevocations(store ds.Datastore, clk clock.Clock) (*revocations, error) {
+	r := &revocations{
+		store:      store,
+		clock:      clk,
+		tokens:     make(map[string]time.Time),
+		identities: make(map[string]revocation),
+		members:    make(map[thread.ID]map[string]revocation),
+	}
+	now := clk.Now()
+	res, err := store.Query(query.Query{Prefix: revokedTokensPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if len(e.Value) != 8 {
+			log.Warnf("skipping malformed revocation entry %s", e.Key)
+			continue
+		}
+		t := unixTime(int64(binary.BigEndian.Uint64(e.Value)))
+		if !t.IsZero() && now.After(t) {
+			// the token expired meanwhile, it's rejected anyway
+			if err = store.Delete(ds.NewKey(e.Key)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		r.tokens[ds.RawKey(e.Key).BaseNamespace()] = t
+	}
+
+	for _, prefix := range []ds.Key{revokedIdentitiesPrefix, revokedThreadMembersPrefix} {
+		res, err := store.Query(query.Query{Prefix: prefix.String()})
+		if err != nil {
+			return nil, err
+		}
+		entries, err := res.Rest()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			rev, ok := unmarshalRevocation(e.Value)
+			key := ds.RawKey(e.Key)
+			if !ok {
+				log.Warnf("skipping malformed revocation entry %s", e.Key)
+				continue
+			}
+			if prefix == revokedIdentitiesPrefix {
+				if !rev.active(now) {
+					if err = store.Delete(key); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				r.identities[key.BaseNamespace()] = rev
+				continue
+			}
+			tid, err := thread.Decode(key.Parent().BaseNamespace())
+			if err != nil {
+				log.Warnf("skipping malformed revocation entry %s", e.Key)
+				continue
+			}
+			if r.members[tid] == nil {
+				r.members[tid] = make(map[string]revocation)
+			}
+			r.members[tid][key.BaseNamespace()] = rev
+		}
+	}
+	return r, nil
+}
+
+// RevokeToken revokes a token issued by the host, so it's rejected by Validate from now on.
+// Other tokens of the same identity remain valid, see RevokeIdentity.
+func (n *net) RevokeToken(_ context.Context, token thread.Token) error {
+	claims, err := token.Claims(n.getPrivKey())
+	if errors.Is(err, thread.ErrTokenExpired) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if claims.PubKey == nil {
+		return thread.ErrTokenNotFound
+	}
+	return n.revocations.revokeToken(util.TokenID(token, claims), claims.ExpiresAt)
+}
+
+// RevokeIdentity rejects all tokens issued to an identity and refuses to issue it new ones, e.g.,
+// if its private key was compromised. The revocation expires after ttl, zero means never.
+// The revocation is propagated to the peers of the single-writer threads written by the host,
+// which lock out the identity from these threads. Failed propagations are logged only.
+func (n *net) RevokeIdentity(ctx context.Context, identity thread.PubKey, ttl time.Duration) error {
+	if identity == nil {
+		return fmt.Errorf("identity is required")
+	}
+	rev := revocation{at: n.clock.Now()}
+	if ttl > 0 {
+		rev.expires = rev.at.Add(ttl)
+	}
+	if err := n.revocations.revokeIdentity(identity, rev); err != nil {
+		return err
+	}
+	n.propagateRevocation(ctx, identity, rev)
+	return nil
+}
+
+// RestoreIdentity lifts a revocation of an identity, and propagates it like RevokeIdentity.
+func (n *net) RestoreIdentity(ctx context.Context, identity thread.PubKey) error {
+	if identity == nil {
+		return fmt.Errorf("identity is required")
+	}
+	if err := n.revocations.restoreIdentity(identity); err != nil {
+		return err
+	}
+	n.propagateRevocation(ctx, identity, revocation{at: n.clock.Now(), restored: true})
+	return nil
+}
+
+// propagateRevocation sends a revocation to the peers of the single-writer threads written by
+// the host, signed by the writer log. Other threads have no owner which peers could trust,
+// so revocations of their members aren't propagated.
+func (n *net) propagateRevocation(ctx context.Context, identity thread.PubKey, rev revocation) {
+	raw, err := identity.MarshalBinary()
+	if err != nil {
+		log.Errorf("marshaling revoked identity %s: %v", identity, err)
+		return
+	}
+	ids, err := n.store.Threads()
+	if err != nil {
+		log.Errorf("listing threads: %v", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		req, err := n.signedRevocation(ctx, id, raw, rev)
+		if err != nil {
+			log.Errorf("signing revocation of %s in thread %s: %v", identity, id, err)
+			continue
+		} else if req == nil {
+			continue
+		}
+		info, err := n.store.GetThread(id)
+		if err != nil {
+			log.Errorf("getting thread %s: %v", id, err)
+			continue
+		}
+		var addrs []ma.Multiaddr
+		for _, lg := range info.Logs {
+			addrs = append(addrs, lg.Addrs...)
+		}
+		peers, err := n.uniquePeers(addrs)
+		if err != nil {
+			log.Errorf("getting peers of thread %s: %v", id, err)
+			continue
+		}
+		for _, pid := range peers {
+			wg.Add(1)
+			go func(pid peer.ID, req *pb.PushRevocationRequest) {
+				defer wg.Done()
+				if err := n.pushRevocation(ctx, pid, req); err != nil {
+					log.Errorf("propagating revocation of %s to %s failed: %v", identity, pid, err)
+				}
+			}(pid, req)
+		}
+	}
+	wg.Wait()
+}
+
+// signedRevocation returns a revocation of a thread signed by its writer log, or nil if the host
+// doesn't write the thread.
+func (n *net) signedRevocation(
+	ctx context.Context,
+	id thread.ID,
+	identity []byte,
+	rev revocation,
+) (*pb.PushRevocationRequest, error) {
+	writer, err := n.threadWriter(id)
+	if err != nil || writer == "" {
+		return nil, err
+	}
+	lg, err := n.store.GetLog(id, writer)
+	if err != nil {
+		return nil, err
+	}
+	if lg.PrivKey == nil && !lg.Managed {
+		return nil, nil
+	}
+	signer, err := n.logSigner(ctx, id, lg)
+	if err != nil {
+		return nil, err
+	}
+	body := &pb.PushRevocationRequest_Body{
+		Identity:  identity,
+		RevokedAt: unixNano(rev.at),
+		ExpiresAt: unixNano(rev.expires),
+		ThreadID:  &pb.ProtoThreadID{ID: id},
+		Restored:  rev.restored,
+	}
+	payload, err := body.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PushRevocationRequest{Body: body, Sig: sig}, nil
+}
+
+func (n *net) pushRevocation(ctx context.Context, pid peer.ID, req *pb.PushRevocationRequest) error {
+	client, release, err := n.server.dial(pid)
+	if err != nil {
+		return fmt.Errorf("dial failed: %w", err)
+	}
+	defer release()
+	cctx, cancel := context.WithTimeout(ctx, PushTimeout)
+	defer cancel()
+	_, err = client.PushRevocation(cctx, req)
+	return err
+}
+
+// checkToken fails if a token or its identity was revoked, also by the writer of the thread.
+func (r *revocations) checkToken(id thread.ID, token thread.Token, claims thread.TokenClaims) error {
+	now := r.clock.Now()
+	key := claims.PubKey.String()
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	if rev, ok := r.identities[key]; ok && rev.active(now) {
+		return core.ErrIdentityRevoked
+	}
+	if rev, ok := r.members[id][key]; ok && rev.active(now) {
+		return core.ErrIdentityRevoked
+	}
+	if _, ok := r.tokens[util.TokenID(token, claims)]; ok {
+		return core.ErrTokenRevoked
+	}
+	return nil
+}
+
+// checkIdentity fails if an identity was revoked by the host.
+func (r *revocations) checkIdentity(identity thread.PubKey) error {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	if rev, ok := r.identities[identity.String()]; ok && rev.active(r.clock.Now()) {
+		return core.ErrIdentityRevoked
+	}
+	return nil
+}
+
+func (r *revocations) revokeToken(id string, expires time.Time) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if err := r.store.Put(revokedTokensPrefix.ChildString(id), unixBytes(expires)); err != nil {
+		return err
+	}
+	r.tokens[id] = expires
+	return nil
+}
+
+func (r *revocations) revokeIdentity(identity thread.PubKey, rev revocation) error {
+	key := identity.String()
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if err := r.store.Put(revokedIdentitiesPrefix.ChildString(key), rev.marshal()); err != nil {
+		return err
+	}
+	r.identities[key] = rev
+	return nil
+}
+
+func (r *revocations) restoreIdentity(identity thread.PubKey) error {
+	key := identity.String()
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if err := r.store.Delete(revokedIdentitiesPrefix.ChildString(key)); err != nil {
+		return err
+	}
+	delete(r.identities, key)
+	return nil
+}
+
+// revokeMember applies a revocation of an identity in a thread, unless a later one was applied.
+func (r *revocations) revokeMember(id thread.ID, identity thread.PubKey, rev revocation) error {
+	key := identity.String()
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if cur, ok := r.members[id][key]; ok && !rev.at.After(cur.at) {
+		return nil
+	}
+	dsKey := revokedThreadMembersPrefix.ChildString(id.String()).ChildString(key)
+	if err := r.store.Put(dsKey, rev.marshal()); err != nil {
+		return err
+	}
+	if r.members[id] == nil {
+		r.members[id] = make(map[string]revocation)
+	}
+	r.members[id][key] = rev
+	return nil
+}
+
+// PurgeThread drops the revocations of the members of a deleted thread.
+func (r *revocations) PurgeThread(id thread.ID) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for key := range r.members[id] {
+		dsKey := revokedThreadMembersPrefix.ChildString(id.String()).ChildString(key)
+		if err := r.store.Delete(dsKey); err != nil {
+			return err
+		}
+	}
+	delete(r.members, id)
+	return nil
+}
+
+// PushRevocation applies a revocation of a thread member propagated by a peer. Revocations are only
+// accepted if they are signed by the writer log of the single-writer thread, and apply to the thread
+// only. They aren't propagated any further.
+func (s *server) PushRevocation(ctx context.Context, req *pb.PushRevocationRequest) (*pb.PushRevocationReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body == nil || len(req.Body.Identity) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "identity is required")
+	}
+	identity := &thread.Libp2pPubKey{}
+	if err = identity.UnmarshalBinary(req.Body.Identity); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Body.ThreadID == nil {
+		return nil, status.Error(codes.InvalidArgument, "thread ID is required")
+	}
+	tid := req.Body.ThreadID.ID
+	log.Debugf("received revocation of %s in thread %s from %s", identity, tid, pid)
+
+	writer, err := s.net.threadWriter(tid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	} else if writer == "" {
+		return nil, status.Error(codes.PermissionDenied, "revocations are only accepted in single-writer threads")
+	}
+	pk, err := s.net.store.PubKey(tid, writer)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	} else if pk == nil {
+		return nil, status.Error(codes.NotFound, "writer log not found")
+	}
+	payload, err := req.Body.Marshal()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if ok, err := pk.Verify(payload, req.Sig); !ok || err != nil {
+		return nil, status.Error(codes.PermissionDenied, "revocation isn't signed by the thread writer")
+	}
+	rev := revocation{
+		at:       unixNanoTime(req.Body.RevokedAt),
+		expires:  unixNanoTime(req.Body.ExpiresAt),
+		restored: req.Body.Restored,
+	}
+	if err = s.net.revocations.revokeMember(tid, identity, rev); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.PushRevocationReply{}, nil
+}
+
+func unixBytes(t time.Time) []byte {
+	b := make([]byte, 8)
+	if !t.IsZero() {
+		binary.BigEndian.PutUint64(b, uint64(t.Unix()))
+	}
+	return b
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func unixNanoTime(nsec int64) time.Time {
+	if nsec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nsec)
+}
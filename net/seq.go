@@ -0,0 +1,67 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ipfs/go-cid"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// unknownSeq stands for the sequence number of a record which isn't stored locally.
+const unknownSeq = math.MaxUint64
+
+// ErrInvalidSeq indicates a record sequence number that doesn't follow its log.
+var ErrInvalidSeq = errors.New("invalid record sequence number")
+
+// headSeq returns the sequence number of the log head, zero if the log is empty or its
+// records aren't numbered.
+func (n *net) headSeq(ctx context.Context, id thread.ID, head cid.Cid) (uint64, error) {
+	if !head.Defined() {
+		return 0, nil
+	}
+	rec, err := n.getRecord(ctx, id, head)
+	if err != nil {
+		return 0, fmt.Errorf("getting log head: %w", err)
+	}
+	return rec.Seq(), nil
+}
+
+// nextSeq returns the sequence number of a record following the head with the given one.
+// Records of logs which started without sequence numbers stay unnumbered, so every numbered
+// record tells the length of the log up to it.
+func nextSeq(seq uint64, head cid.Cid) (uint64, error) {
+	switch {
+	case !head.Defined():
+		return 1, nil
+	case seq == 0:
+		return 0, nil
+	case seq == math.MaxInt64:
+		return 0, fmt.Errorf("%w: log sequence exhausted", ErrInvalidSeq)
+	default:
+		return seq + 1, nil
+	}
+}
+
+// checkSeq verifies the sequence number of a record received from a peer against the one of its
+// previous record, zero for the first record of a log, or unknownSeq if it isn't stored locally.
+// Records without a sequence number, e.g., created by hosts running older versions, are accepted.
+// So are records following one without, which may have lost it being relayed by such a host.
+func checkSeq(rec core.Record, prev uint64) error {
+	seq := rec.Seq()
+	if seq == 0 || prev == unknownSeq {
+		return nil
+	}
+	if !rec.PrevID().Defined() {
+		prev = 0
+	} else if prev == 0 {
+		return nil
+	}
+	if seq != prev+1 {
+		return fmt.Errorf("%w: record %s is numbered %d, not %d", ErrInvalidSeq, rec.Cid(), seq, prev+1)
+	}
+	return nil
+}
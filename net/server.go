@@ -5,7 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gogo/status"
 	"github.com/ipfs/go-cid"
@@ -14,10 +16,12 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/textileio/go-threads/cbor"
 	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/logstore/lstoreds"
 	pb "github.com/textileio/go-threads/net/pb"
-	"github.com/textileio/go-threads/util"
+	"github.com/textileio/go-threads/net/util"
+	tu "github.com/textileio/go-threads/util"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	grpcpeer "google.golang.org/grpc/peer"
@@ -33,25 +37,40 @@ type server struct {
 	sync.Mutex
 	net   *net
 	ps    *PubSub
-	opts  []grpc.DialOption
-	conns map[peer.ID]*grpc.ClientConn
+	conns *connPool
+	// peers which asked to hold off calling them until the given time
+	throttled map[peer.ID]time.Time
+	// number of open record subscriptions by peer
+	subs     map[peer.ID]int
+	subsLock sync.Mutex
 }
 
 // newServer creates a new network server.
-func newServer(n *net, enablePubSub bool, opts ...grpc.DialOption) (*server, error) {
+func newServer(
+	n *net,
+	enablePubSub bool,
+	publish PublishConfig,
+	pool ConnPoolConfig,
+	interceptors ClientInterceptors,
+	opts ...grpc.DialOption,
+) (*server, error) {
 	var (
 		s = &server{
-			net:   n,
-			conns: make(map[peer.ID]*grpc.ClientConn),
+			net:       n,
+			throttled: make(map[peer.ID]time.Time),
+			subs:      make(map[peer.ID]int),
 		}
 
 		defaultOpts = []grpc.DialOption{
 			s.getLibp2pDialer(),
 			grpc.WithInsecure(),
+			grpc.WithStatsHandler(n.compressionStats),
 		}
 	)
+	unary, stream := n.clientInterceptors(interceptors)
+	defaultOpts = append(defaultOpts, unary, stream)
 
-	s.opts = append(defaultOpts, opts...)
+	s.conns = newConnPool(n.ctx, n.clock, pool, append(defaultOpts, opts...)...)
 
 	if enablePubSub {
 		ps, err := pubsub.NewGossipSub(
@@ -62,33 +81,75 @@ func newServer(n *net, enablePubSub bool, opts ...grpc.DialOption) (*server, err
 		if err != nil {
 			return nil, err
 		}
-		s.ps = NewPubSub(n.ctx, n.host.ID(), ps, s.pubsubHandler)
-
-		ts, err := n.store.Threads()
-		if err != nil {
+		if s.ps, err = NewPubSub(n.ctx, n.clock, n.host.ID(), ps, s.pubsubHandler, s.validatePubSubRecord, publish); err != nil {
 			return nil, err
 		}
-		for _, id := range ts {
-			if err := s.ps.Add(id); err != nil {
-				return nil, err
-			}
+		if n.edgeGossip {
+			s.ps.EnableEdgeGossip(s.edgeGossipHandler, n.edgeGossipMAC)
+		}
+		if n.privateTopics {
+			s.ps.EnablePrivateTopics(n.privateTopicName)
 		}
 	}
 
 	return s, nil
 }
 
-// pubsubHandler receives records over pubsub.
-func (s *server) pubsubHandler(ctx context.Context, req *pb.PushRecordRequest) {
-	if _, err := s.PushRecord(ctx, req); err != nil {
-		// This error will be "log not found" if the record sent over pubsub
-		// beat the log, which has to be sent directly via the normal API.
-		// In this case, the record will arrive directly after the log via
-		// the normal API.
+// pubsubHandler receives records over pubsub. Capabilities can't be presented over pubsub,
+// so threads requiring them only take records pushed by peers directly.
+func (s *server) pubsubHandler(ctx context.Context, from peer.ID, req *pb.PushRecordRequest) {
+	if required, err := s.net.capabilityRequired(req.Body.ThreadID.ID); err != nil {
+		log.Errorf("checking capabilities of thread %s: %v", req.Body.ThreadID.ID, err)
+		return
+	} else if required {
+		log.Debugf("dropping pubsub record of thread %s from %s: a capability is required", req.Body.ThreadID.ID, from)
+		return
+	}
+	if _, err := s.putPushedRecord(ctx, from, req, core.SourcePubSub); err != nil {
+		// Records which beat their logs are dropped by the validator already,
+		// they arrive directly after the logs via the normal API.
 		log.Debugf("error handling pubsub record: %s", err)
 	}
 }
 
+// validatePubSubRecord checks a record received over pubsub against its log key, see RecordValidator.
+func (s *server) validatePubSubRecord(
+	_ context.Context,
+	from peer.ID,
+	tid thread.ID,
+	lid peer.ID,
+	pbrec *pb.Log_Record,
+) error {
+	logpk, err := s.net.store.PubKey(tid, lid)
+	if err != nil {
+		return err
+	}
+	if logpk == nil {
+		return lstore.ErrLogNotFound
+	}
+	if err = s.net.checkProtoRecordSize(pbrec); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidRecord, err)
+	}
+	key, err := s.net.store.ServiceKey(tid)
+	if err != nil {
+		return err
+	}
+	rec, err := cbor.RecordFromProto(pbrec, key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidRecord, err)
+	}
+	if known, err := s.net.isKnown(rec.Cid()); err != nil {
+		return err
+	} else if known {
+		return errRecordKnown
+	}
+	if err = rec.Verify(logpk); err != nil {
+		s.net.emitRejected(tid, lid, from, rec.Cid(), err)
+		return fmt.Errorf("%w: %v", errInvalidRecord, err)
+	}
+	return nil
+}
+
 // GetLogs receives a get logs request.
 func (s *server) GetLogs(ctx context.Context, req *pb.GetLogsRequest) (*pb.GetLogsReply, error) {
 	pid, err := peerIDFromContext(ctx)
@@ -98,25 +159,103 @@ func (s *server) GetLogs(ctx context.Context, req *pb.GetLogsRequest) (*pb.GetLo
 	log.Debugf("received get logs request from %s", pid)
 
 	pblgs := &pb.GetLogsReply{}
-	if err := s.checkServiceKey(req.Body.ThreadID.ID, req.Body.ServiceKey); err != nil {
+	if granted, err := s.checkCapability(ctx, pid, req.Body.ThreadID.ID, fetchRights); err != nil {
 		return pblgs, err
+	} else if !granted {
+		if err := s.checkServiceKey(req.Body.ThreadID.ID, req.Body.ServiceKey); err != nil {
+			return pblgs, err
+		}
 	}
 
-	info, err := s.net.store.GetThread(req.Body.ThreadID.ID) // Safe since putRecords will change head when fully-available
+	var after peer.ID
+	if req.Body.After != nil {
+		after = req.Body.After.ID
+	}
+	// Safe since putRecords will change head when fully-available
+	tid := req.Body.ThreadID.ID
+	lgs, next, err := s.logsPage(tid, after, int(req.Body.Limit))
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	if next != "" {
+		pblgs.Next = &pb.ProtoPeerID{ID: next}
+	}
 
-	pblgs.Logs = make([]*pb.Log, len(info.Logs))
-	for i, l := range info.Logs {
-		pblgs.Logs[i] = logToProto(l)
+	pblgs.Logs = make([]*pb.Log, len(lgs))
+	for i, l := range lgs {
+		pblg, err := s.net.signedLogToProto(ctx, tid, l)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		pblgs.Logs[i] = s.net.downgradeLog(pid, pblg)
+	}
+	flags, err := s.net.threadFlags(tid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	pblgs.Flags = flags
+	if sig, err := s.net.threadFlagsSig(tid); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	} else if sig != nil {
+		pblgs.FlagsSigner, pblgs.FlagsSig = sig.Signer, sig.Sig
+	}
+	if pblgs.Metadata, err = s.net.threadMetadataBytes(tid); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	log.Debugf("sending %d logs to %s", len(info.Logs), pid)
+	log.Debugf("sending %d logs to %s", len(lgs), pid)
 
 	return pblgs, nil
 }
 
+// logsPage returns up to limit logs of a thread following the given log ID, along with the
+// cursor of the next page, an empty ID on the last page. Zero limit returns all logs.
+// Pages are ordered by log ID, so logs added meanwhile don't shift the cursor, and only the
+// logs of the page are loaded.
+func (s *server) logsPage(tid thread.ID, after peer.ID, limit int) ([]thread.LogInfo, peer.ID, error) {
+	if sk, err := s.net.store.ServiceKey(tid); err != nil {
+		return nil, "", err
+	} else if sk == nil {
+		return nil, "", lstore.ErrThreadNotFound
+	}
+	withKeys, err := s.net.store.LogsWithKeys(tid)
+	if err != nil {
+		return nil, "", err
+	}
+	withAddrs, err := s.net.store.LogsWithAddrs(tid)
+	if err != nil {
+		return nil, "", err
+	}
+	set := make(map[peer.ID]struct{}, len(withKeys)+len(withAddrs))
+	for _, ids := range []peer.IDSlice{withKeys, withAddrs} {
+		for _, lid := range ids {
+			if lid > after {
+				set[lid] = struct{}{}
+			}
+		}
+	}
+	ids := make(peer.IDSlice, 0, len(set))
+	for lid := range set {
+		ids = append(ids, lid)
+	}
+	sort.Sort(ids)
+
+	var next peer.ID
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+		next = ids[limit-1]
+	}
+	lgs := make([]thread.LogInfo, 0, len(ids))
+	for _, lid := range ids {
+		lg, err := s.net.store.GetLog(tid, lid)
+		if err != nil {
+			return nil, "", err
+		}
+		lgs = append(lgs, lg)
+	}
+	return lgs, next, nil
+}
+
 // PushLog receives a push log request.
 // @todo: Don't overwrite info from non-owners
 func (s *server) PushLog(ctx context.Context, req *pb.PushLogRequest) (*pb.PushLogReply, error) {
@@ -126,6 +265,10 @@ func (s *server) PushLog(ctx context.Context, req *pb.PushLogRequest) (*pb.PushL
 	}
 	log.Debugf("received push log request from %s", pid)
 
+	if _, err = s.checkCapability(ctx, pid, req.Body.ThreadID.ID, pushLogRights); err != nil {
+		return nil, err
+	}
+
 	// Pick up missing keys
 	info, err := s.net.store.GetThread(req.Body.ThreadID.ID)
 	if err != nil && !errors.Is(err, lstore.ErrThreadNotFound) {
@@ -133,7 +276,19 @@ func (s *server) PushLog(ctx context.Context, req *pb.PushLogRequest) (*pb.PushL
 	}
 	if !info.Key.Defined() {
 		if req.Body.ServiceKey != nil && req.Body.ServiceKey.Key != nil {
+			if req.Body.ReadKey == nil || req.Body.ReadKey.Key == nil {
+				// the thread is stored on behalf of the peer
+				if err = s.net.admitRelayed(req.Body.ThreadID.ID); errors.Is(err, ErrRelayQuotaExceeded) {
+					return nil, status.Error(codes.ResourceExhausted, err.Error())
+				} else if err != nil {
+					return nil, status.Error(codes.Internal, err.Error())
+				}
+			}
 			if err = s.net.store.AddServiceKey(req.Body.ThreadID.ID, req.Body.ServiceKey.Key); err != nil {
+				s.net.forgetRelayed(req.Body.ThreadID.ID)
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			if err = s.net.setSchemaVersion(req.Body.ThreadID.ID, len(migrations)); err != nil {
 				return nil, status.Error(codes.Internal, err.Error())
 			}
 		} else {
@@ -144,13 +299,55 @@ func (s *server) PushLog(ctx context.Context, req *pb.PushLogRequest) (*pb.PushL
 			if err = s.net.store.AddReadKey(req.Body.ThreadID.ID, req.Body.ReadKey.Key); err != nil {
 				return nil, status.Error(codes.Internal, err.Error())
 			}
+			if err = s.net.releaseRelayed(req.Body.ThreadID.ID); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+	}
+
+	// the writer of a single-writer thread is declared by the signed flags,
+	// so replicators enforce it and the revocations signed by it
+	if len(req.Body.Flags) != 0 {
+		flags, err := thread.NewFlags(req.Body.Flags...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		var sig *signedFlags
+		if len(req.Body.FlagsSig) != 0 {
+			sig = &signedFlags{Signer: req.Body.FlagsSigner, Sig: req.Body.FlagsSig}
+		}
+		if err = s.net.withThreadLock(req.Body.ThreadID.ID, func() error {
+			return s.net.mergeThreadFlags(req.Body.ThreadID.ID, flags, sig)
+		}); errors.Is(err, ErrInvalidFlagsSig) || errors.Is(err, ErrFlagsMismatch) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
 		}
 	}
 
-	lg := logFromProto(req.Body.Log)
-	if err = s.net.createExternalLogsIfNotExist(req.Body.ThreadID.ID, []thread.LogInfo{lg}); err != nil {
+	lg := peerLogFromProto(req.Body.Log)
+	if err = s.net.checkThreadWriter(req.Body.ThreadID.ID, lg.ID); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err = s.net.createExternalLogsIfNotExist(req.Body.ThreadID.ID, []peerLog{lg}); errors.Is(err, ErrInvalidAddrsSig) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	if len(req.Body.Metadata) != 0 {
+		if err = s.net.mergeThreadMetadata(req.Body.ThreadID.ID, req.Body.Metadata); errors.Is(err, thread.ErrInvalidMetadata) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	if len(req.Body.KeyRotation) != 0 {
+		if err = s.net.mergeKeyRotation(req.Body.ThreadID.ID, req.Body.KeyRotation); errors.Is(err, ErrInvalidKeyRotation) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
 
 	if s.net.queueGetRecords.Schedule(pid, req.Body.ThreadID.ID, callPriorityLow, s.net.updateRecordsFromPeer) {
 		log.Debugf("record update for thread %s from %s scheduled", req.Body.ThreadID.ID, pid)
@@ -167,8 +364,18 @@ func (s *server) GetRecords(ctx context.Context, req *pb.GetRecordsRequest) (*pb
 	log.Debugf("received get records request from %s", pid)
 
 	var pbrecs = &pb.GetRecordsReply{}
-	if err := s.checkServiceKey(req.Body.ThreadID.ID, req.Body.ServiceKey); err != nil {
+	if granted, err := s.checkCapability(ctx, pid, req.Body.ThreadID.ID, fetchRights); err != nil {
 		return pbrecs, err
+	} else if !granted {
+		if err := s.checkServiceKey(req.Body.ThreadID.ID, req.Body.ServiceKey); err != nil {
+			return pbrecs, err
+		}
+	}
+	// records of archived threads aren't available locally
+	if archived, err := s.net.isArchived(req.Body.ThreadID.ID); err != nil {
+		return nil, err
+	} else if archived {
+		return pbrecs, nil
 	}
 
 	// fast check if requested offsets are equal with thread heads
@@ -191,59 +398,86 @@ func (s *server) GetRecords(ctx context.Context, req *pb.GetRecordsRequest) (*pb
 	pbrecs.Logs = make([]*pb.GetRecordsReply_LogEntry, 0, len(info.Logs))
 
 	var (
-		logRecordLimit = MaxPullLimit / len(info.Logs)
+		maxPullLimit   = s.net.syncConfig().MaxPullLimit
+		logRecordLimit = maxPullLimit / len(info.Logs)
+		requested      int
 		mx             sync.Mutex
 		wg             sync.WaitGroup
 	)
+	for _, l := range req.Body.Logs {
+		requested += int(l.Limit)
+	}
+	// page sizes of requesters knowing the numbers of missing records are
+	// kept if they fit into a single pull, the pull is split evenly otherwise
+	fitting := requested <= maxPullLimit
 
 	for _, lg := range info.Logs {
 		var (
-			offset cid.Cid
-			limit  int
-			pblg   *pb.Log
+			offsets []cid.Cid
+			limit   int
+			pblg    *pb.Log
 		)
 		if opts, ok := reqd[lg.ID]; ok {
-			offset = opts.Offset.Cid
-			limit = minInt(int(opts.Limit), logRecordLimit)
+			offsets = headsFromProto(opts.Offset, opts.Heads)
+			if fitting {
+				limit = int(opts.Limit)
+			} else {
+				limit = minInt(int(opts.Limit), logRecordLimit)
+			}
 		} else {
-			offset = cid.Undef
 			limit = logRecordLimit
-			pblg = logToProto(lg)
+			if pblg, err = s.net.signedLogToProto(ctx, info.ID, lg); err != nil {
+				return nil, err
+			}
+			pblg = s.net.downgradeLog(pid, pblg)
 		}
 
 		wg.Add(1)
-		go func(tid thread.ID, lid peer.ID, off cid.Cid, lim int) {
+		go func(tid thread.ID, lid peer.ID, offs []cid.Cid, lim int) {
 			defer wg.Done()
 
-			recs, err := s.net.getLocalRecords(ctx, tid, lid, off, lim)
+			recs, err := s.net.getLocalRecords(ctx, tid, lid, offs, lim)
 			if err != nil {
 				log.Errorf("getting local records (thread %s, log %s): %v", tid, lid, err)
 			}
+			boundary, err := util.LogMarker(s.net.store, tid, lid, boundarySuffix)
+			if err != nil {
+				log.Errorf("getting compaction boundary (thread %s, log %s): %v", tid, lid, err)
+			}
 
 			var prs = make([]*pb.Log_Record, 0, len(recs))
 			for _, r := range recs {
-				pr, err := cbor.RecordToProto(ctx, s.net, r)
+				pr, err := s.net.recordToProto(ctx, tid, r)
 				if err != nil {
 					log.Errorf("constructing proto-record %s (thread %s, log %s): %v", r.Cid(), tid, lid, err)
 					break
 				}
+				if req.Body.HeadersOnly {
+					pr.BodyNode = nil
+				}
 				prs = append(prs, pr)
 			}
 			if pblg == nil && len(prs) == 0 {
 				// do not include empty logs in reply
 				return
 			}
+			prs = s.net.downgradeRecords(pid, s.net.restrictRecords(tid, lid, pid, prs))
 
-			mx.Lock()
-			pbrecs.Logs = append(pbrecs.Logs, &pb.GetRecordsReply_LogEntry{
+			entry := &pb.GetRecordsReply_LogEntry{
 				LogID:   &pb.ProtoPeerID{ID: lid},
 				Records: prs,
 				Log:     pblg,
-			})
+			}
+			if boundary.Defined() {
+				entry.Boundary = &pb.ProtoCid{Cid: boundary}
+			}
+
+			mx.Lock()
+			pbrecs.Logs = append(pbrecs.Logs, entry)
 			mx.Unlock()
 
 			log.Debugf("sending %d records in log %s to %s", len(recs), lid, pid)
-		}(req.Body.ThreadID.ID, lg.ID, offset, limit)
+		}(req.Body.ThreadID.ID, lg.ID, offsets, limit)
 	}
 
 	wg.Wait()
@@ -257,7 +491,19 @@ func (s *server) PushRecord(ctx context.Context, req *pb.PushRecordRequest) (*pb
 		return nil, err
 	}
 	log.Debugf("received push record request from %s", pid)
+	if _, err = s.checkCapability(ctx, pid, req.Body.ThreadID.ID, pushRights); err != nil {
+		return nil, err
+	}
+	return s.putPushedRecord(ctx, pid, req, core.SourcePush)
+}
 
+// putPushedRecord adds a record pushed by a peer directly or over pubsub.
+func (s *server) putPushedRecord(
+	ctx context.Context,
+	pid peer.ID,
+	req *pb.PushRecordRequest,
+	kind core.RecordSourceKind,
+) (*pb.PushRecordReply, error) {
 	// A log is required to accept new records
 	logpk, err := s.net.store.PubKey(req.Body.ThreadID.ID, req.Body.LogID.ID)
 	if err != nil {
@@ -267,6 +513,10 @@ func (s *server) PushRecord(ctx context.Context, req *pb.PushRecordRequest) (*pb
 		return nil, status.Error(codes.NotFound, "log not found")
 	}
 
+	if err = s.net.checkProtoRecordSize(req.Body.Record); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	key, err := s.net.store.ServiceKey(req.Body.ThreadID.ID)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
@@ -280,16 +530,131 @@ func (s *server) PushRecord(ctx context.Context, req *pb.PushRecordRequest) (*pb
 	} else if knownRecord {
 		return &pb.PushRecordReply{}, nil
 	}
-
 	if err = rec.Verify(logpk); err != nil {
+		s.net.emitRejected(req.Body.ThreadID.ID, req.Body.LogID.ID, pid, rec.Cid(), err)
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
-	if err = s.net.PutRecord(ctx, req.Body.ThreadID.ID, req.Body.LogID.ID, rec); err != nil {
+	if err = s.net.chargeRecords(req.Body.ThreadID.ID, 1, req.Body.Record.Size()); err != nil {
+		return nil, err
+	}
+	if _, err = s.loadBodyChunks(ctx, pid, req.Body.ThreadID.ID, req.Body.LogID.ID, key, []core.Record{rec}); errors.Is(err, ErrRecordTooLarge) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if isQuotaExceeded(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	tid, lid := req.Body.ThreadID.ID, req.Body.LogID.ID
+	if err = tid.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = s.net.putRecords(ctx, tid, lid, []core.Record{rec}, s.net.peerSource(kind, pid)); isQuotaExceeded(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return &pb.PushRecordReply{}, nil
 }
 
+// PushRecords receives a push records request with a chain of records.
+func (s *server) PushRecords(ctx context.Context, req *pb.PushRecordsRequest) (*pb.PushRecordsReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("received push records request from %s", pid)
+
+	if _, err = s.checkCapability(ctx, pid, req.Body.ThreadID.ID, pushRights); err != nil {
+		return nil, err
+	}
+
+	// A log is required to accept new records
+	logpk, err := s.net.store.PubKey(req.Body.ThreadID.ID, req.Body.LogID.ID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if logpk == nil {
+		return nil, status.Error(codes.NotFound, "log not found")
+	}
+
+	for _, r := range req.Body.Records {
+		if err = s.net.checkProtoRecordSize(r); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	key, err := s.net.store.ServiceKey(req.Body.ThreadID.ID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var size int
+	recs := make([]core.Record, 0, len(req.Body.Records))
+	for _, r := range req.Body.Records {
+		rec, err := cbor.RecordFromProto(r, key)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if knownRecord, err := s.net.isKnown(rec.Cid()); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		} else if knownRecord {
+			continue
+		}
+		recs = append(recs, rec)
+		size += r.Size()
+	}
+	if len(recs) == 0 {
+		return &pb.PushRecordsReply{}, nil
+	}
+	if err = s.net.verifyRecords(ctx, recs, logpk); err != nil {
+		s.net.emitRejected(req.Body.ThreadID.ID, req.Body.LogID.ID, pid, cid.Undef, err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err = s.net.chargeRecords(req.Body.ThreadID.ID, len(recs), size); err != nil {
+		return nil, err
+	}
+	// records fitting into the quotas are added before the refusal is returned
+	recs, refused := s.loadBodyChunks(ctx, pid, req.Body.ThreadID.ID, req.Body.LogID.ID, key, recs)
+	if errors.Is(refused, ErrRecordTooLarge) {
+		return nil, status.Error(codes.InvalidArgument, refused.Error())
+	} else if refused != nil && !isQuotaExceeded(refused) {
+		return nil, status.Error(codes.Internal, refused.Error())
+	} else if len(recs) == 0 {
+		return nil, status.Error(codes.ResourceExhausted, refused.Error())
+	}
+
+	src := s.net.peerSource(core.SourcePush, pid)
+	if err = s.net.putRecords(ctx, req.Body.ThreadID.ID, req.Body.LogID.ID, recs, src); isQuotaExceeded(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	} else if refused != nil {
+		return nil, status.Error(codes.ResourceExhausted, refused.Error())
+	}
+	return &pb.PushRecordsReply{}, nil
+}
+
+// RedeemInvite receives a request to redeem a single-use invite.
+func (s *server) RedeemInvite(ctx context.Context, req *pb.RedeemInviteRequest) (*pb.RedeemInviteReply, error) {
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("received redeem invite request from %s", pid)
+
+	if req.Body == nil || req.Body.ThreadID == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing thread ID")
+	}
+	bundle, err := s.net.redeemPendingInvite(req.Body.ThreadID.ID, req.Body.Nonce)
+	if errors.Is(err, core.ErrInviteRedeemed) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if errors.Is(err, core.ErrInviteExpired) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.RedeemInviteReply{Bundle: bundle}, nil
+}
+
 // ExchangeEdges receives an exchange edges request.
 func (s *server) ExchangeEdges(ctx context.Context, req *pb.ExchangeEdgesRequest) (*pb.ExchangeEdgesReply, error) {
 	pid, err := peerIDFromContext(ctx)
@@ -301,6 +666,10 @@ func (s *server) ExchangeEdges(ctx context.Context, req *pb.ExchangeEdgesRequest
 	var reply pb.ExchangeEdgesReply
 	for _, entry := range req.Body.Threads {
 		var tid = entry.ThreadID.ID
+		if _, err := s.checkCapability(ctx, pid, tid, fetchRights); err != nil {
+			log.Debugf("skipping edges of thread %s for %s: %v", tid, pid, err)
+			continue
+		}
 		switch addrsEdgeLocal, headsEdgeLocal, err := s.localEdges(tid); err {
 		case errNoAddrsEdge, errNoHeadsEdge, nil:
 			var (
@@ -311,10 +680,13 @@ func (s *server) ExchangeEdges(ctx context.Context, req *pb.ExchangeEdgesRequest
 			// need to get new logs only if we have non empty addresses on remote and the hashes are different
 			if addrsEdgeRemote != lstoreds.EmptyEdgeValue && addrsEdgeLocal != addrsEdgeRemote {
 				prt := callPriorityLow
+				deadline := s.net.clock.Now().Add(s.net.syncConfig().PullInterval)
 				updateLogs := s.net.updateLogsFromPeer
 				// if we don't have the thread locally
 				if addrsEdgeLocal == lstoreds.EmptyEdgeValue {
 					prt = callPriorityHigh // we have to add thread in pubsub, not just update its logs
+					// the thread isn't synced at all until then, so it's pulled on the next poll
+					deadline = s.net.clock.Now()
 					updateLogs = func(ctx context.Context, p peer.ID, t thread.ID) error {
 						if err := s.net.updateLogsFromPeer(ctx, p, t); err != nil {
 							return err
@@ -325,14 +697,18 @@ func (s *server) ExchangeEdges(ctx context.Context, req *pb.ExchangeEdgesRequest
 						return nil
 					}
 				}
-				if s.net.queueGetLogs.Schedule(pid, tid, prt, updateLogs) {
+				if s.net.queueGetLogs.ScheduleBy(pid, tid, prt, deadline, updateLogs) {
 					log.Debugf("log information update for thread %s from %s scheduled", tid, pid)
 				}
 			}
 
+			if headsEdgeLocal != lstoreds.EmptyEdgeValue {
+				s.net.trackExchange(tid, headsEdgeLocal == headsEdgeRemote, nil)
+			}
+
 			// need to get new records only if we have non empty heads on remote and the hashes are different
 			if headsEdgeRemote != lstoreds.EmptyEdgeValue && headsEdgeLocal != headsEdgeRemote {
-				if s.net.queueGetRecords.Schedule(pid, tid, callPriorityLow, s.net.updateRecordsFromPeer) {
+				if s.net.scheduleRecordsUpdate(pid, tid, entry.LogSeqs) {
 					log.Debugf("record update for thread %s from %s scheduled", tid, pid)
 				}
 			}
@@ -349,6 +725,7 @@ func (s *server) ExchangeEdges(ctx context.Context, req *pb.ExchangeEdgesRequest
 				Exists:      exists,
 				AddressEdge: addrsEdgeLocal,
 				HeadsEdge:   headsEdgeLocal,
+				LogSeqs:     s.net.localLogSeqs(tid),
 			})
 
 		default:
@@ -379,14 +756,20 @@ func (s *server) checkServiceKey(id thread.ID, k *pb.ProtoKey) error {
 
 // headsChanged determines if thread heads are different from the requested offsets.
 func (s *server) headsChanged(req *pb.GetRecordsRequest) (bool, error) {
-	var reqHeads = make([]util.LogHead, len(req.Body.Logs))
-	for i, l := range req.Body.GetLogs() {
-		reqHeads[i] = util.LogHead{Head: l.Offset.Cid, LogID: l.LogID.ID}
+	var reqHeads = make([]tu.LogHead, 0, len(req.Body.Logs))
+	for _, l := range req.Body.GetLogs() {
+		if len(l.Heads) == 0 {
+			reqHeads = append(reqHeads, tu.LogHead{Head: l.Offset.Cid, LogID: l.LogID.ID})
+			continue
+		}
+		for _, h := range l.Heads {
+			reqHeads = append(reqHeads, tu.LogHead{Head: h.Cid, LogID: l.LogID.ID})
+		}
 	}
 	var currEdge, err = s.net.store.HeadsEdge(req.Body.ThreadID.ID)
 	switch {
 	case err == nil:
-		return util.ComputeHeadsEdge(reqHeads) != currEdge, nil
+		return tu.ComputeHeadsEdge(reqHeads) != currEdge, nil
 	case errors.Is(err, lstore.ErrThreadNotFound):
 		// no local heads, but there could be missing logs info in reply
 		return true, nil
@@ -438,6 +821,7 @@ func logToProto(l thread.LogInfo) *pb.Log {
 		PubKey: &pb.ProtoPubKey{PubKey: l.PubKey},
 		Addrs:  addrsToProto(l.Addrs),
 		Head:   &pb.ProtoCid{Cid: l.Head},
+		Heads:  headsToProto(l.Heads),
 	}
 }
 
@@ -448,7 +832,31 @@ func logFromProto(l *pb.Log) thread.LogInfo {
 		PubKey: l.PubKey.PubKey,
 		Addrs:  addrsFromProto(l.Addrs),
 		Head:   l.Head.Cid,
+		Heads:  headsFromProto(l.Head, l.Heads),
+	}
+}
+
+func headsToProto(heads []cid.Cid) []pb.ProtoCid {
+	phs := make([]pb.ProtoCid, len(heads))
+	for i, h := range heads {
+		phs[i] = pb.ProtoCid{Cid: h}
+	}
+	return phs
+}
+
+// headsFromProto returns all defined heads, falling back to the single head
+// sent by peers unaware of forked logs.
+func headsFromProto(head *pb.ProtoCid, phs []pb.ProtoCid) []cid.Cid {
+	heads := make([]cid.Cid, 0, len(phs))
+	for _, h := range phs {
+		if h.Cid.Defined() {
+			heads = append(heads, h.Cid)
+		}
+	}
+	if len(heads) == 0 && head != nil && head.Cid.Defined() {
+		heads = append(heads, head.Cid)
 	}
+	return heads
 }
 
 func addrsToProto(mas []ma.Multiaddr) []pb.ProtoAddr {
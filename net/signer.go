@@ -0,0 +1,62 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// DefaultSigningTimeout is the default time external signers have to sign a record,
+// see Config.SigningTimeout.
+var DefaultSigningTimeout = 10 * time.Second
+
+// logSigner returns the signer of records of a log owned by the host. Records of logs whose
+// private keys aren't kept in the logstore are signed by the configured signers.
+func (n *net) logSigner(ctx context.Context, id thread.ID, lg thread.LogInfo) (core.Signer, error) {
+	if lg.PrivKey != nil {
+		return core.NewKeySigner(lg.PrivKey), nil
+	}
+	if n.signers == nil || lg.PubKey == nil {
+		return nil, fmt.Errorf("a private-key is required to create records")
+	}
+	signer, err := n.signers.Signer(ctx, id, lg.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("getting signer of log %s: %w", lg.ID, err)
+	} else if signer == nil {
+		return nil, fmt.Errorf("a private-key or signer is required to create records")
+	} else if !signer.PubKey().Equals(lg.PubKey) {
+		return nil, fmt.Errorf("signer key doesn't match log %s", lg.ID)
+	}
+	return timeoutSigner{signer: signer, timeout: n.signingTimeout}, nil
+}
+
+// timeoutSigner bounds the time an external signer has to sign. Signatures are waited for
+// asynchronously, so a signer which doesn't give up once ctx is done can't stall the thread.
+type timeoutSigner struct {
+	signer  core.Signer
+	timeout time.Duration
+}
+
+func (s timeoutSigner) PubKey() ic.PubKey {
+	return s.signer.PubKey()
+}
+
+func (s timeoutSigner) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	res := make(chan core.SignResult, 1)
+	go func() {
+		sig, err := s.signer.Sign(ctx, msg)
+		res <- core.SignResult{Sig: sig, Err: err}
+	}()
+	select {
+	case r := <-res:
+		return r.Sig, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
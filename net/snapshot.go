@@ -0,0 +1,163 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+const (
+	// metadata suffix for the latest log checkpoint
+	checkpointSuffix = "/checkpoint"
+	// metadata suffix for the oldest record kept in a compacted log
+	boundarySuffix = "/boundary"
+)
+
+func (n *net) CreateCheckpoint(
+	ctx context.Context,
+	id thread.ID,
+	state format.Node,
+	opts ...core.ThreadOption,
+) (core.ThreadRecord, error) {
+	tr, err := n.CreateRecord(ctx, id, state, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.store.PutBytes(id, tr.LogID().Pretty()+checkpointSuffix, tr.Value().Cid().Bytes()); err != nil {
+		return nil, fmt.Errorf("saving checkpoint: %w", err)
+	}
+	log.Debugf("created checkpoint %s (thread=%s, log=%s)", tr.Value().Cid(), id, tr.LogID())
+	return tr, nil
+}
+
+func (n *net) CompactThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot compact thread: %w", app.ErrThreadInUse)
+	}
+
+	ts, err := n.lockThread(id)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+	defer n.blockLocalWrites(id)()
+
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	for _, lg := range info.Logs {
+		checkpoint, err := util.LogMarker(n.store, id, lg.ID, checkpointSuffix)
+		if err != nil {
+			return err
+		}
+		if !checkpoint.Defined() {
+			continue
+		}
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return err
+		}
+		if boundary.Equals(checkpoint) {
+			continue // already compacted
+		}
+
+		cp, err := n.getRecord(ctx, id, checkpoint)
+		if err != nil {
+			return fmt.Errorf("getting checkpoint %s: %w", checkpoint, err)
+		}
+		pruned, err := n.pruneLog(ctx, id, lg.ID, cp, info.Key.Service())
+		if err != nil {
+			return err
+		}
+		log.Debugf("compacted log %s (thread=%s): pruned %d records before %s", lg.ID, id, pruned, checkpoint)
+	}
+	return nil
+}
+
+// pruneLog moves the compaction boundary of a log to the given record, and deletes the
+// records before it. It returns the number of deleted records.
+func (n *net) pruneLog(ctx context.Context, tid thread.ID, lid peer.ID, boundary core.Record, sk *sym.Key) (int, error) {
+	// Move the boundary first, so records being pruned are not served anymore.
+	if err := n.store.PutBytes(tid, lid.Pretty()+boundarySuffix, boundary.Cid().Bytes()); err != nil {
+		return 0, err
+	}
+
+	// pruned records give their space back to the quotas, if they were counted
+	_, logUsed, err := n.quotaUsage(tid, lid)
+	if err != nil {
+		return 0, err
+	}
+	relayed := n.isRelayed(tid)
+	var freed int64
+	defer func() {
+		if err := n.releaseQuota(tid, lid, freed); err != nil {
+			log.Errorf("releasing quota of thread %s failed: %v", tid, err)
+		}
+		if !relayed {
+			return
+		}
+		if err := n.releaseRelayedBytes(tid, freed); err != nil {
+			log.Errorf("releasing relay quota of thread %s failed: %v", tid, err)
+		}
+	}()
+
+	var pruned int
+	for rid := boundary.PrevID(); rid.Defined(); pruned++ {
+		if err := ctx.Err(); err != nil {
+			return pruned, err
+		}
+		// Stop at records which are missing locally, e.g. dropped by the previous compaction,
+		// otherwise the dag service would try to fetch them from the network.
+		if known, err := n.isKnown(rid); err != nil {
+			return pruned, err
+		} else if !known {
+			break
+		}
+		if logUsed > 0 || relayed {
+			size, err := n.storedSize(ctx, rid, sk)
+			if err != nil {
+				return pruned, err
+			}
+			freed += size
+		}
+		var err error
+		if rid, err = n.deleteRecord(ctx, tid, rid, sk); err != nil {
+			return pruned, fmt.Errorf("pruning record: %w", err)
+		}
+	}
+	return pruned, nil
+}
+
+// adoptBoundary saves the compaction boundary of a peer's log, if the local
+// log can't be connected to the records received from the boundary.
+func (n *net) adoptBoundary(tid thread.ID, lid peer.ID, boundary core.Record) error {
+	current, err := util.LogMarker(n.store, tid, lid, boundarySuffix)
+	if err != nil {
+		return err
+	}
+	if current.Equals(boundary.Cid()) {
+		return nil
+	}
+	if prev := boundary.PrevID(); prev.Defined() {
+		if known, err := n.isKnown(prev); err != nil || known {
+			return err
+		}
+	}
+	log.Debugf("adopting compaction boundary %s (thread=%s, log=%s)", boundary.Cid(), tid, lid)
+	return n.store.PutBytes(tid, lid.Pretty()+boundarySuffix, boundary.Cid().Bytes())
+}
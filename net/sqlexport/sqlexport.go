@@ -0,0 +1,399 @@
+// Package sqlexport mirrors thread records into SQL tables, so thread activity can be queried with SQL.
+// Record metadata is always exported. Bodies are decrypted and flattened into table rows for threads
+// with a mapping only. Every record is exported exactly once, also across restarts.
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log"
+	"github.com/textileio/go-threads/cbor"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var log = logging.Logger("sqlexport")
+
+var (
+	// exportAttempts is the number of times a record is exported before the exporter stops.
+	exportAttempts = 3
+
+	// exportRetryDelay is the delay before the first retry of a failed export, doubled on every retry.
+	exportRetryDelay = time.Second
+
+	// catchUpPage is the number of threads listed at once while catching up on all threads.
+	catchUpPage = 100
+)
+
+// Dialect is the SQL dialect of the database.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+// DefaultRecordsTable is the name of the table with record metadata.
+const DefaultRecordsTable = "thread_records"
+
+// Column maps a value of the record body to a table column.
+type Column struct {
+	// Name of the column.
+	Name string
+
+	// Path to the value within the body, e.g., "user/name".
+	Path string
+
+	// Type is the SQL type of the column, e.g., "TEXT" or "BIGINT". Unlike names, which are
+	// quoted, it's written into the statements as is.
+	Type string
+}
+
+// Mapping flattens record bodies of a thread into rows of a table.
+// Rows are keyed by the record cid in the record_cid column.
+type Mapping struct {
+	Table   string
+	Columns []Column
+}
+
+// Config specifies exporter settings.
+type Config struct {
+	Dialect Dialect
+
+	// RecordsTable is the name of the table with record metadata. Defaults to DefaultRecordsTable.
+	RecordsTable string
+
+	// Threads to export. Records added while the exporter wasn't running are exported on start.
+	// If empty, all threads of the network are exported, which requires a network exposing
+	// its logstore to catch up on them, see NewExporter.
+	Threads []thread.ID
+
+	// Mappings of the threads with exported bodies. Bodies of other threads aren't exported.
+	Mappings map[thread.ID]Mapping
+
+	// Token authorizes access to the threads.
+	Token thread.Token
+}
+
+// Exporter writes records of a network into SQL tables.
+type Exporter struct {
+	net   core.Net
+	store lstore.Logstore
+	db    *sql.DB
+	conf  Config
+}
+
+// NewExporter creates the tables of the records and mappings if they don't exist.
+// Exporting all threads requires the network to expose its logstore with a Store method.
+func NewExporter(network core.Net, db *sql.DB, conf Config) (*Exporter, error) {
+	if conf.RecordsTable == "" {
+		conf.RecordsTable = DefaultRecordsTable
+	}
+	e := &Exporter{net: network, db: db, conf: conf}
+	if len(conf.Threads) == 0 {
+		s, ok := network.(interface{ Store() lstore.Logstore })
+		if !ok {
+			return nil, fmt.Errorf("exporting all threads requires the logstore of the network")
+		}
+		e.store = s.Store()
+	}
+	for _, stmt := range e.schema() {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("creating tables: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// Run exports records until the context is canceled. It first catches up on records of
+// the exported threads added since the previous run, and then exports records as they're added.
+// Records of a log are exported in order, so if a record can't be exported after retries,
+// the exporter stops with the error, and the record is exported by the catch-up of the next run.
+func (e *Exporter) Run(ctx context.Context) error {
+	opts := []core.SubOption{core.WithSubToken(e.conf.Token)}
+	for _, id := range e.conf.Threads {
+		opts = append(opts, core.WithSubFilter(id))
+	}
+	// subscribe before catching up, so no records are missed in between
+	sub, err := e.net.Subscribe(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	if err = e.catchUpAll(ctx); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case rec, ok := <-sub:
+			if !ok {
+				return nil
+			}
+			if err := e.exportWithRetry(ctx, rec); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("exporting record %s: %w", rec.Value().Cid(), err)
+			}
+		}
+	}
+}
+
+// exportWithRetry exports a record, retrying failed attempts with exponential backoff.
+func (e *Exporter) exportWithRetry(ctx context.Context, rec core.ThreadRecord) (err error) {
+	delay := exportRetryDelay
+	for i := 1; ; i++ {
+		if err = e.Export(ctx, rec.ThreadID(), rec.LogID().String(), rec.Value()); err == nil || i == exportAttempts {
+			return err
+		}
+		log.Warnf("error exporting record %s in attempt %d: %v", rec.Value().Cid(), i, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// catchUpAll catches up on Config.Threads, or on all threads of the logstore if none are set.
+func (e *Exporter) catchUpAll(ctx context.Context) error {
+	if len(e.conf.Threads) > 0 {
+		for _, id := range e.conf.Threads {
+			if err := e.catchUp(ctx, id); err != nil {
+				return fmt.Errorf("catching up on thread %s: %w", id, err)
+			}
+		}
+		return nil
+	}
+	for after := thread.Undef; ; {
+		ids, err := e.store.ThreadsAfter(after, catchUpPage)
+		if err != nil {
+			return fmt.Errorf("listing threads: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, id := range ids {
+			// threads deleted meanwhile are skipped
+			if err := e.catchUp(ctx, id); err != nil && !errors.Is(err, lstore.ErrThreadNotFound) {
+				return fmt.Errorf("catching up on thread %s: %w", id, err)
+			}
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// catchUp exports the records of every log of a thread which were added since the latest exported ones.
+func (e *Exporter) catchUp(ctx context.Context, id thread.ID) error {
+	info, err := e.net.GetThread(ctx, id, core.WithThreadToken(e.conf.Token))
+	if err != nil {
+		return err
+	}
+	for _, lg := range info.Logs {
+		heads := lg.Heads
+		if len(heads) == 0 {
+			heads = []cid.Cid{lg.Head}
+		}
+		// branches of a forked log are walked one by one, they stop at the
+		// common records exported along with the previous branches
+		var count int
+		for _, head := range heads {
+			exported, err := e.catchUpBranch(ctx, id, lg.ID.String(), head)
+			if err != nil {
+				return err
+			}
+			count += exported
+		}
+		if count > 0 {
+			log.Debugf("exported %d records of log %s", count, lg.ID)
+		}
+	}
+	return nil
+}
+
+// catchUpBranch walks a log back from a head to the latest exported record,
+// and exports the missing records from the oldest one.
+func (e *Exporter) catchUpBranch(ctx context.Context, id thread.ID, lid string, head cid.Cid) (int, error) {
+	var missing []core.Record
+	for rid := head; rid.Defined(); {
+		if exported, err := e.exported(ctx, rid); err != nil {
+			return 0, err
+		} else if exported {
+			break
+		}
+		rec, err := e.net.GetRecord(ctx, id, rid, core.WithThreadToken(e.conf.Token))
+		if err != nil {
+			return 0, err
+		}
+		missing = append(missing, rec)
+		rid = rec.PrevID()
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := e.Export(ctx, id, lid, missing[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(missing), nil
+}
+
+// Export writes a record, and its body if the thread has a mapping, within a single transaction.
+// It's a no-op for records which were already exported.
+func (e *Exporter) Export(ctx context.Context, id thread.ID, lid string, rec core.Record) error {
+	var row []interface{}
+	mapping, mapped := e.conf.Mappings[id]
+	if mapped {
+		body, err := e.body(ctx, id, rec)
+		if err != nil {
+			return fmt.Errorf("getting body: %w", err)
+		}
+		if row, err = flatten(body, mapping); err != nil {
+			return err
+		}
+	}
+
+	var author string
+	pk := &thread.Libp2pPubKey{}
+	if err := pk.UnmarshalBinary(rec.PubKey()); err == nil {
+		author = pk.String()
+	}
+	var prev string
+	if rec.PrevID().Defined() {
+		prev = rec.PrevID().String()
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, e.insertRecord(),
+		rec.Cid().String(), id.String(), lid, prev, author, time.Now().Unix())
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		_ = tx.Rollback()
+		return err
+	} else if n == 0 {
+		// exported before
+		return tx.Rollback()
+	}
+	if mapped {
+		args := append([]interface{}{rec.Cid().String()}, row...)
+		if _, err = tx.ExecContext(ctx, e.insertRow(mapping), args...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// exported returns whether a record was exported before.
+func (e *Exporter) exported(ctx context.Context, rid cid.Cid) (bool, error) {
+	var one int
+	err := e.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT 1 FROM %s WHERE cid = %s", quote(e.conf.RecordsTable), e.placeholder(1)),
+		rid.String()).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// body returns the decrypted body of a record.
+func (e *Exporter) body(ctx context.Context, id thread.ID, rec core.Record) (format.Node, error) {
+	info, err := e.net.GetThread(ctx, id, core.WithThreadToken(e.conf.Token))
+	if err != nil {
+		return nil, err
+	}
+	if !info.Key.CanRead() {
+		return nil, fmt.Errorf("read key of thread %s not found", id)
+	}
+	event, err := cbor.EventFromRecord(ctx, e.net, rec)
+	if err != nil {
+		return nil, err
+	}
+	return event.GetBody(ctx, e.net, info.Key.Read())
+}
+
+// flatten returns the column values of a body in the mapping order. Missing values are NULL.
+func flatten(body format.Node, mapping Mapping) ([]interface{}, error) {
+	row := make([]interface{}, len(mapping.Columns))
+	for i, col := range mapping.Columns {
+		val, rest, err := body.Resolve(strings.Split(col.Path, "/"))
+		if err != nil || len(rest) > 0 {
+			continue
+		}
+		switch v := val.(type) {
+		case string, []byte, bool, int, int64, uint64, float64, nil:
+			row[i] = v
+		default:
+			return nil, fmt.Errorf("value of column %s at %s isn't a scalar", col.Name, col.Path)
+		}
+	}
+	return row, nil
+}
+
+func (e *Exporter) schema() []string {
+	stmts := []string{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	cid TEXT PRIMARY KEY,
+	thread_id TEXT NOT NULL,
+	log_id TEXT NOT NULL,
+	prev TEXT NOT NULL,
+	author TEXT NOT NULL,
+	exported_at BIGINT NOT NULL
+)`, quote(e.conf.RecordsTable))}
+	for _, m := range e.conf.Mappings {
+		cols := []string{"record_cid TEXT PRIMARY KEY"}
+		for _, col := range m.Columns {
+			cols = append(cols, quote(col.Name)+" "+col.Type)
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quote(m.Table), strings.Join(cols, ", ")))
+	}
+	return stmts
+}
+
+func (e *Exporter) insertRecord() string {
+	return fmt.Sprintf(
+		"INSERT INTO %s (cid, thread_id, log_id, prev, author, exported_at) VALUES (%s) ON CONFLICT (cid) DO NOTHING",
+		quote(e.conf.RecordsTable), e.placeholders(6))
+}
+
+func (e *Exporter) insertRow(m Mapping) string {
+	cols := []string{"record_cid"}
+	for _, col := range m.Columns {
+		cols = append(cols, quote(col.Name))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(m.Table), strings.Join(cols, ", "), e.placeholders(len(cols)))
+}
+
+// quote returns a table or column name as a quoted identifier, which is understood by
+// both dialects, so names from the configuration can't alter the statements.
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (e *Exporter) placeholders(n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = e.placeholder(i + 1)
+	}
+	return strings.Join(ps, ", ")
+}
+
+func (e *Exporter) placeholder(i int) string {
+	if e.conf.Dialect == SQLite {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", i)
+}
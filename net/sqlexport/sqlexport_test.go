@@ -0,0 +1,378 @@
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	"github.com/textileio/go-threads/common"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util"
+)
+
+func TestExporter(t *testing.T) {
+	store := newFakeStore()
+	testExporter(t, sql.OpenDB(store), store.count, func() []string {
+		var names []string
+		for _, row := range store.rows("users") {
+			names = append(names, row[1].(string))
+		}
+		return names
+	})
+}
+
+// testExporter runs the exporter on a database, which is inspected with count and names.
+func testExporter(t *testing.T, db *sql.DB, count func(table string) int, names func() []string) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	n, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	ctx := context.Background()
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order int
+	newBody := func(name string) format.Node {
+		order++
+		body, err := cbornode.WrapObject(map[string]interface{}{
+			"user": map[string]interface{}{"name": name, "order": order},
+		}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	add := func(name string) core.ThreadRecord {
+		rec, err := n.CreateRecord(ctx, info.ID, newBody(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	first := add("alice")
+	add("bob")
+
+	// names are quoted, so reserved words can be used
+	conf := Config{
+		Dialect: SQLite,
+		Threads: []thread.ID{info.ID},
+		Mappings: map[thread.ID]Mapping{
+			info.ID: {Table: "users", Columns: []Column{
+				{Name: "name", Path: "user/name", Type: "TEXT"},
+				{Name: "order", Path: "user/order", Type: "BIGINT"},
+			}},
+		},
+	}
+	run := func() context.CancelFunc {
+		e, err := NewExporter(n, db, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rctx, cancel := context.WithCancel(ctx)
+		go func() {
+			if err := e.Run(rctx); err != nil {
+				t.Error(err)
+			}
+		}()
+		return cancel
+	}
+	waitRows := func(table string, c int) {
+		for i := 0; count(table) != c; i++ {
+			if i == 100 {
+				t.Fatalf("expected %d rows in %s, got %d", c, table, count(table))
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	cancel := run()
+	waitRows(DefaultRecordsTable, 2)
+	add("carol")
+	waitRows(DefaultRecordsTable, 3)
+	waitRows("users", 3)
+	cancel()
+
+	// records added meanwhile are exported on restart, also on a forked branch of the log
+	add("dave")
+	event, err := cbor.CreateEvent(ctx, n, newBody("erin"), info.Key.Read())
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := info.GetFirstPrivKeyLog()
+	fork, err := cbor.CreateRecord(ctx, nil, cbor.CreateRecordConfig{
+		Block:      event,
+		Prev:       first.Value().Cid(),
+		Key:        own.PrivKey,
+		PubKey:     thread.NewLibp2pPubKey(n.Host().Peerstore().PrivKey(n.Host().ID()).GetPublic()),
+		ServiceKey: info.Key.Service(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.AddRecord(ctx, info.ID, own.ID, fork); err != nil {
+		t.Fatal(err)
+	}
+	cancel = run()
+	defer cancel()
+	waitRows("users", 5)
+	time.Sleep(100 * time.Millisecond)
+	// exported ones aren't duplicated
+	if c := count(DefaultRecordsTable); c != 5 {
+		t.Fatalf("expected 5 exported records, got %d", c)
+	}
+	exported := make(map[string]bool)
+	for _, name := range names() {
+		exported[name] = true
+	}
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		if !exported[name] {
+			t.Fatalf("expected row of %s", name)
+		}
+	}
+}
+
+func TestExporter_FailedExport(t *testing.T) {
+	defer func(delay time.Duration) { exportRetryDelay = delay }(exportRetryDelay)
+	exportRetryDelay = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	n, err := common.DefaultNetwork(
+		common.WithNetBadgerPersistence(dir),
+		common.WithNetHostAddr(util.FreeLocalAddr()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	ctx := context.Background()
+	info, err := n.CreateThread(ctx, thread.NewIDV1(thread.Raw, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := func(name string) {
+		body, err := cbornode.WrapObject(map[string]interface{}{"name": name}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = n.CreateRecord(ctx, info.ID, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := newFakeStore()
+	db := sql.OpenDB(store)
+	run := func() (<-chan error, context.CancelFunc) {
+		// all threads are exported
+		e, err := NewExporter(n, db, Config{Dialect: SQLite})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rctx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- e.Run(rctx) }()
+		return done, cancel
+	}
+	waitRows := func(c int) {
+		for i := 0; store.count(DefaultRecordsTable) != c; i++ {
+			if i == 100 {
+				t.Fatalf("expected %d exported records, got %d", c, store.count(DefaultRecordsTable))
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	// records of threads added before the first run are caught up on
+	add("alice")
+	done, cancel := run()
+	defer cancel()
+	waitRows(1)
+
+	// the exporter stops once a record can't be exported
+	store.setFailing(true)
+	add("bob")
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the exporter to stop with an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the exporter to stop")
+	}
+	store.setFailing(false)
+	add("carol")
+
+	// the failed record is exported by the next run
+	done, cancel = run()
+	defer cancel()
+	waitRows(3)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeStore is a minimal database/sql driver, which understands the statements of the exporter.
+type fakeStore struct {
+	sync.Mutex
+	tables  map[string]map[string][]driver.Value
+	failing bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{tables: make(map[string]map[string][]driver.Value)}
+}
+
+func (s *fakeStore) Connect(context.Context) (driver.Conn, error) { return &fakeConn{s: s}, nil }
+func (s *fakeStore) Driver() driver.Driver                        { return nil }
+
+func (s *fakeStore) count(table string) int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.tables[table])
+}
+
+// setFailing makes inserts fail.
+func (s *fakeStore) setFailing(failing bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.failing = failing
+}
+
+func (s *fakeStore) rows(table string) [][]driver.Value {
+	s.Lock()
+	defer s.Unlock()
+	var rows [][]driver.Value
+	for _, row := range s.tables[table] {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+type fakeInsert struct {
+	table string
+	row   []driver.Value
+}
+
+type fakeConn struct {
+	s       *fakeStore
+	tx      bool
+	pending []fakeInsert
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.tx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.s.Lock()
+	defer c.s.Unlock()
+	for _, ins := range c.pending {
+		if c.s.tables[ins.table] == nil {
+			c.s.tables[ins.table] = make(map[string][]driver.Value)
+		}
+		c.s.tables[ins.table][ins.row[0].(string)] = ins.row
+	}
+	c.pending, c.tx = nil, false
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending, c.tx = nil, false
+	return nil
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "CREATE TABLE") {
+		return driver.RowsAffected(0), nil
+	}
+	if !strings.HasPrefix(s.query, "INSERT INTO ") {
+		return nil, fmt.Errorf("unexpected statement %s", s.query)
+	}
+	table := strings.Trim(strings.Fields(s.query)[2], `"`)
+	s.c.s.Lock()
+	_, exists := s.c.s.tables[table][args[0].(string)]
+	failing := s.c.s.failing
+	s.c.s.Unlock()
+	if failing {
+		return nil, errors.New("insert failed")
+	}
+	if exists {
+		if strings.Contains(s.query, "ON CONFLICT") {
+			return driver.RowsAffected(0), nil
+		}
+		return nil, errors.New("duplicate key")
+	}
+	s.c.pending = append(s.c.pending, fakeInsert{table: table, row: args})
+	if !s.c.tx {
+		return driver.RowsAffected(1), s.c.Commit()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fields := strings.Fields(s.query)
+	if len(fields) < 4 || fields[0] != "SELECT" {
+		return nil, fmt.Errorf("unexpected query %s", s.query)
+	}
+	s.c.s.Lock()
+	_, exists := s.c.s.tables[strings.Trim(fields[3], `"`)][args[0].(string)]
+	s.c.s.Unlock()
+	return &fakeRows{left: exists}, nil
+}
+
+type fakeRows struct {
+	left bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"1"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if !r.left {
+		return io.EOF
+	}
+	r.left = false
+	dest[0] = int64(1)
+	return nil
+}
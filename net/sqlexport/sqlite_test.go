@@ -0,0 +1,59 @@
+//go:build sqlite
+// +build sqlite
+
+package sqlexport
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestExporter_SQLite runs the exporter against a SQLite database, rather than the fake driver.
+// Run with: go test -tags sqlite ./net/sqlexport
+func TestExporter_SQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "export.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// SQLite doesn't allow concurrent writers
+	db.SetMaxOpenConns(1)
+
+	count := func(table string) int {
+		var c int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + quote(table)).Scan(&c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	names := func() []string {
+		rows, err := db.Query(`SELECT "name" FROM "users"`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+	testExporter(t, db, count, names)
+}
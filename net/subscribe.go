@@ -0,0 +1,349 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/gogo/status"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	// MaxPeerSubscriptions is the number of record subscriptions a peer may have open at once.
+	MaxPeerSubscriptions = 8
+
+	// SubscriptionQueueSize is the number of records buffered for a subscribed peer. Peers which
+	// don't keep up with new records are disconnected, so they don't stall the event bus.
+	SubscriptionQueueSize = 256
+)
+
+// subFilters maps subscribed threads to their subscribed logs, all logs if empty.
+type subFilters map[thread.ID]map[peer.ID]struct{}
+
+func (f subFilters) match(tid thread.ID, lid peer.ID) bool {
+	logs, ok := f[tid]
+	if !ok {
+		return false
+	}
+	if len(logs) == 0 {
+		return true
+	}
+	_, ok = logs[lid]
+	return ok
+}
+
+// Subscribe streams new records of threads to a peer. Every request received on the
+// stream replaces the filters of the subscription.
+func (s *server) Subscribe(stream pb.Service_SubscribeServer) error {
+	ctx := stream.Context()
+	pid, err := peerIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	log.Debugf("received subscribe request from %s", pid)
+
+	// records are only listened to once the first filters are authorized
+	req, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	initial, err := s.subscribeFilters(ctx, pid, req)
+	if err != nil {
+		return err
+	}
+	if !s.addSubscription(pid) {
+		return status.Errorf(codes.ResourceExhausted, "at most %d subscriptions per peer", MaxPeerSubscriptions)
+	}
+	defer s.removeSubscription(pid)
+
+	listener := s.net.bus.Listen()
+	defer listener.Discard()
+
+	var (
+		mx      sync.Mutex
+		filters = initial
+		errc    = make(chan error, 2)
+		queue   = make(chan *Record, SubscriptionQueueSize)
+	)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err == io.EOF {
+				return // the peer is done updating the filters
+			} else if err != nil {
+				errc <- err
+				return
+			}
+			f, err := s.subscribeFilters(ctx, pid, req)
+			if err != nil {
+				errc <- err
+				return
+			}
+			mx.Lock()
+			filters = f
+			mx.Unlock()
+		}
+	}()
+	// the bus is drained into the queue of the stream, so a slow peer only holds up itself
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case i, ok := <-listener.Channel():
+				if !ok {
+					close(queue)
+					return
+				}
+				rec, ok := i.(*Record)
+				if !ok {
+					log.Warn("listener received a non-record value")
+					continue
+				}
+				mx.Lock()
+				match := filters.match(rec.threadID, rec.logID)
+				mx.Unlock()
+				if !match {
+					continue
+				}
+				select {
+				case queue <- rec:
+				default:
+					errc <- status.Error(codes.ResourceExhausted, "subscriber is too slow")
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errc:
+			return err
+		case rec, ok := <-queue:
+			if !ok {
+				return nil
+			}
+			pbrec, err := s.net.recordToProto(ctx, rec.threadID, rec.Value())
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			pbrecs := s.net.restrictRecords(rec.threadID, rec.logID, pid, []*pb.Log_Record{pbrec})
+			if err = stream.Send(&pb.SubscribeReply{
+				ThreadID: &pb.ProtoThreadID{ID: rec.threadID},
+				LogID:    &pb.ProtoPeerID{ID: rec.logID},
+				Record:   s.net.downgradeRecords(pid, pbrecs)[0],
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addSubscription counts a subscription of a peer, returning false if it has too many already.
+func (s *server) addSubscription(pid peer.ID) bool {
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	if s.subs[pid] >= MaxPeerSubscriptions {
+		return false
+	}
+	s.subs[pid]++
+	return true
+}
+
+func (s *server) removeSubscription(pid peer.ID) {
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	if s.subs[pid]--; s.subs[pid] <= 0 {
+		delete(s.subs, pid)
+	}
+}
+
+// subscribeFilters checks the authorization of a subscribe request, returning its filters.
+// Capabilities are passed along with the stream, so they authorize every request on it.
+func (s *server) subscribeFilters(ctx context.Context, pid peer.ID, req *pb.SubscribeRequest) (subFilters, error) {
+	if req.Body == nil {
+		return nil, status.Error(codes.InvalidArgument, "request body is required")
+	}
+	filters := make(subFilters, len(req.Body.Filters))
+	for _, f := range req.Body.Filters {
+		if f.ThreadID == nil {
+			return nil, status.Error(codes.InvalidArgument, "thread ID is required")
+		}
+		tid := f.ThreadID.ID
+		if granted, err := s.checkCapability(ctx, pid, tid, fetchRights); err != nil {
+			return nil, err
+		} else if !granted {
+			if err := s.checkServiceKey(tid, f.ServiceKey); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := s.net.Validate(tid, thread.Token(req.Body.Token), true); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		logs := make(map[peer.ID]struct{}, len(f.LogIDs))
+		for _, lid := range f.LogIDs {
+			logs[lid.ID] = struct{}{}
+		}
+		filters[tid] = logs
+	}
+	return filters, nil
+}
+
+// subscribeToPeer opens a subscription to new records of threads at a peer.
+func (s *server) subscribeToPeer(
+	ctx context.Context,
+	pid peer.ID,
+	tids []thread.ID,
+	lids []peer.ID,
+	token thread.Token,
+) (pb.Service_SubscribeClient, error) {
+	body := &pb.SubscribeRequest_Body{Token: string(token)}
+	for _, tid := range tids {
+		sk, err := s.net.store.ServiceKey(tid)
+		if err != nil {
+			return nil, err
+		} else if sk == nil {
+			return nil, errors.New("a service-key is required to subscribe to records")
+		}
+		filter := &pb.SubscribeRequest_Body_Filter{
+			ThreadID:   &pb.ProtoThreadID{ID: tid},
+			ServiceKey: &pb.ProtoKey{Key: sk},
+		}
+		for _, lid := range lids {
+			filter.LogIDs = append(filter.LogIDs, pb.ProtoPeerID{ID: lid})
+		}
+		body.Filters = append(body.Filters, filter)
+	}
+	if err := s.net.requireFeature(ctx, pid, core.FeatureSubscribe); err != nil {
+		return nil, err
+	}
+
+	client, release, err := s.dial(pid)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	stream, err := client.Subscribe(s.net.capabilityContext(ctx, tids...))
+	if err != nil {
+		return nil, err
+	}
+	if err = stream.Send(&pb.SubscribeRequest{Body: body}); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// SubscribePeer subscribes to new records of threads at a peer, instead of pulling them.
+// Received records are added to the threads before they're delivered on the channel.
+// Records which can't be added, e.g., following missed ones, are pulled from the peer.
+func (n *net) SubscribePeer(ctx context.Context, pid peer.ID, opts ...core.SubOption) (<-chan core.ThreadRecord, error) {
+	args := &core.SubOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if len(args.ThreadIDs) == 0 {
+		return nil, errors.New("subscribing to a peer requires a thread filter")
+	}
+	for _, id := range args.ThreadIDs {
+		if _, err := n.Validate(id, args.Token, true); err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := n.server.subscribeToPeer(ctx, pid, args.ThreadIDs, args.LogIDs, args.Token)
+	if err != nil {
+		return nil, err
+	}
+	channel := make(chan core.ThreadRecord)
+	go func() {
+		defer close(channel)
+		for {
+			reply, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil && err != io.EOF {
+					log.Errorf("subscription to %s failed: %v", pid, err)
+				}
+				return
+			}
+			if reply.ThreadID == nil || reply.LogID == nil || reply.Record == nil {
+				log.Warnf("received malformed record from %s", pid)
+				continue
+			}
+			tid, lid := reply.ThreadID.ID, reply.LogID.ID
+			rec, err := n.putSubscribedRecord(ctx, pid, tid, lid, reply.Record)
+			if errors.Is(err, lstore.ErrLogNotFound) {
+				if n.queueGetLogs.Schedule(pid, tid, callPriorityLow, n.updateLogsFromPeer) {
+					log.Debugf("log update for thread %s from %s scheduled", tid, pid)
+				}
+				continue
+			} else if err != nil {
+				// previous records may have been missed, e.g., before the subscription was set up
+				log.Debugf("adding record from %s failed, pulling instead: %v", pid, err)
+				if n.queueGetRecords.Schedule(pid, tid, callPriorityLow, n.updateRecordsFromPeer) {
+					log.Debugf("record update for thread %s from %s scheduled", tid, pid)
+				}
+				continue
+			}
+			select {
+			case channel <- NewRecordFrom(rec, tid, lid, n.peerSource(core.SourceSubscription, pid)):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return channel, nil
+}
+
+// putSubscribedRecord verifies and adds a record received with a subscription.
+func (n *net) putSubscribedRecord(
+	ctx context.Context,
+	pid peer.ID,
+	tid thread.ID,
+	lid peer.ID,
+	pbrec *pb.Log_Record,
+) (core.Record, error) {
+	if err := n.checkProtoRecordSize(pbrec); err != nil {
+		return nil, err
+	}
+	logpk, err := n.store.PubKey(tid, lid)
+	if err != nil {
+		return nil, err
+	} else if logpk == nil {
+		return nil, lstore.ErrLogNotFound
+	}
+	sk, err := n.store.ServiceKey(tid)
+	if err != nil {
+		return nil, err
+	} else if sk == nil {
+		return nil, errors.New("a service-key is required to add records")
+	}
+	rec, err := cbor.RecordFromProto(pbrec, sk)
+	if err != nil {
+		return nil, err
+	}
+	if known, err := n.isKnown(rec.Cid()); err != nil {
+		return nil, err
+	} else if known {
+		return rec, nil
+	}
+	if err = rec.Verify(logpk); err != nil {
+		return nil, err
+	}
+	if _, err = n.server.loadBodyChunks(ctx, pid, tid, lid, sk, []core.Record{rec}); err != nil {
+		return nil, err
+	}
+	return rec, n.putRecords(ctx, tid, lid, []core.Record{rec}, n.peerSource(core.SourceSubscription, pid))
+}
@@ -0,0 +1,145 @@
+package net
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogo/status"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc/codes"
+)
+
+func TestNet_SubscribePeer(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := n2.SubscribePeer(ctx, n1.Host().ID(), core.WithSubFilter(info.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// n1 doesn't know about n2, so records only reach it with the subscription
+	for i := 0; ; i++ {
+		if i == 20 {
+			t.Fatal("expected record to be delivered with the subscription")
+		}
+		body, err := cbornode.WrapObject(map[string]interface{}{"index": i}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := n1.CreateRecord(ctx, info.ID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case rec := <-sub:
+			if !rec.Value().Cid().Equals(r.Value().Cid()) {
+				t.Fatalf("expected record %s, got %s", r.Value().Cid(), rec.Value().Cid())
+			}
+			if known, err := n2.isKnown(rec.Value().Cid()); err != nil {
+				t.Fatal(err)
+			} else if !known {
+				t.Fatal("expected subscribed record to be added")
+			}
+			if src := rec.Source(); src.Kind != core.SourceSubscription || src.Peer != n1.Host().ID() {
+				t.Fatalf("expected record from the subscription of %s, got %+v", n1.Host().ID(), src)
+			}
+			return
+		case <-time.After(500 * time.Millisecond):
+			// the filters may not have been applied yet
+		}
+	}
+}
+
+func TestNet_SubscribePeerInvalidKey(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+
+	client, release, err := n2.server.dial(n1.Host().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	stream, err := client.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.Send(&pb.SubscribeRequest{Body: &pb.SubscribeRequest_Body{
+		Filters: []*pb.SubscribeRequest_Body_Filter{{
+			ThreadID:   &pb.ProtoThreadID{ID: info.ID},
+			ServiceKey: &pb.ProtoKey{Key: sym.New()},
+		}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated error, got %v", err)
+	}
+}
+
+func TestNet_SubscribePeerLimit(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t).(*net)
+	defer n1.Close()
+	n2 := makeNetwork(t).(*net)
+	defer n2.Close()
+
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < MaxPeerSubscriptions; i++ {
+		if _, err = n2.server.subscribeToPeer(ctx, n1.Host().ID(), []thread.ID{info.ID}, nil, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// let the subscriptions be counted
+	time.Sleep(200 * time.Millisecond)
+	stream, err := n2.server.subscribeToPeer(ctx, n1.Host().ID(), []thread.ID{info.ID}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected subscription beyond the limit to be refused, got %v", err)
+	}
+}
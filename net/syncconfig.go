@@ -0,0 +1,71 @@
+package net
+
+import (
+	"context"
+	"fmt"
+
+	core "github.com/textileio/go-threads/core/net"
+)
+
+// syncConfig returns the current sync tuning.
+func (n *net) syncConfig() core.SyncConfig {
+	n.syncLock.RLock()
+	defer n.syncLock.RUnlock()
+	return n.sync
+}
+
+// UpdateConfig applies the sync tuning at runtime. The call queue and the pull loop pick up
+// new intervals on their next tick, the event bus capacity applies to new listeners.
+func (n *net) UpdateConfig(_ context.Context, cfg core.SyncConfig) error {
+	if err := validateSyncConfig(cfg); err != nil {
+		return err
+	}
+	cfg = withSyncDefaults(cfg)
+
+	n.syncLock.Lock()
+	n.sync = cfg
+	n.syncLock.Unlock()
+
+	n.calls.SetIntervals(cfg.QueuePollInterval, cfg.PullInterval)
+	n.bus.SetCapacity(cfg.EventBusCapacity)
+	log.Infof("updated sync config: %+v", cfg)
+	return nil
+}
+
+// validateSyncConfig returns an error if a field of the config is negative.
+func validateSyncConfig(cfg core.SyncConfig) error {
+	switch {
+	case cfg.PullInterval < 0, cfg.InitialPullInterval < 0, cfg.QueuePollInterval < 0:
+		return fmt.Errorf("sync intervals can't be negative")
+	case cfg.MaxPullLimit < 0:
+		return fmt.Errorf("max pull limit can't be negative")
+	case cfg.MaxConcurrentPulls < 0:
+		return fmt.Errorf("max concurrent pulls can't be negative")
+	case cfg.EventBusCapacity < 0:
+		return fmt.Errorf("event bus capacity can't be negative")
+	}
+	return nil
+}
+
+// withSyncDefaults returns the config with zero fields set to the package defaults.
+func withSyncDefaults(cfg core.SyncConfig) core.SyncConfig {
+	if cfg.PullInterval == 0 {
+		cfg.PullInterval = PullInterval
+	}
+	if cfg.InitialPullInterval == 0 {
+		cfg.InitialPullInterval = InitialPullInterval
+	}
+	if cfg.MaxPullLimit == 0 {
+		cfg.MaxPullLimit = MaxPullLimit
+	}
+	if cfg.MaxConcurrentPulls == 0 {
+		cfg.MaxConcurrentPulls = MaxConcurrentPulls
+	}
+	if cfg.QueuePollInterval == 0 {
+		cfg.QueuePollInterval = QueuePollInterval
+	}
+	if cfg.EventBusCapacity == 0 {
+		cfg.EventBusCapacity = EventBusCapacity
+	}
+	return cfg
+}
@@ -0,0 +1,105 @@
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/util/clock"
+)
+
+// MaxTokenChallenges bounds the number of pending token challenges, so unanswered
+// challenges can't exhaust the host memory.
+var MaxTokenChallenges = 10000
+
+var (
+	// ErrTooManyTokenChallenges indicates that MaxTokenChallenges are pending.
+	ErrTooManyTokenChallenges = errors.New("too many pending token challenges")
+)
+
+type tokenChallenge struct {
+	key     thread.PubKey
+	expires time.Time
+}
+
+// tokenChallenges keeps the challenges issued to remote identities until they're
+// redeemed with a signature or expire.
+type tokenChallenges struct {
+	sync.Mutex
+	clock   clock.Clock
+	pending map[string]tokenChallenge
+}
+
+func newTokenChallenges(clk clock.Clock) *tokenChallenges {
+	return &tokenChallenges{clock: clk, pending: make(map[string]tokenChallenge)}
+}
+
+// issue returns a new random challenge for the key along with its expiry.
+func (c *tokenChallenges) issue(key thread.PubKey) ([]byte, time.Time, error) {
+	msg := make([]byte, tokenChallengeBytes)
+	if _, err := rand.Read(msg); err != nil {
+		return nil, time.Time{}, err
+	}
+	now := c.clock.Now()
+	expires := now.Add(tokenChallengeTimeout)
+
+	c.Lock()
+	defer c.Unlock()
+	if len(c.pending) >= MaxTokenChallenges {
+		c.prune(now)
+		if len(c.pending) >= MaxTokenChallenges {
+			return nil, time.Time{}, ErrTooManyTokenChallenges
+		}
+	}
+	c.pending[string(msg)] = tokenChallenge{key: key, expires: expires}
+	return msg, expires, nil
+}
+
+// redeem wipes a pending challenge, failing if it wasn't issued to the key or expired.
+func (c *tokenChallenges) redeem(key thread.PubKey, msg []byte) error {
+	c.Lock()
+	ch, ok := c.pending[string(msg)]
+	delete(c.pending, string(msg))
+	c.Unlock()
+
+	if !ok || c.clock.Now().After(ch.expires) {
+		return core.ErrTokenChallengeNotFound
+	}
+	if !ch.key.Equals(key) {
+		return fmt.Errorf("challenge was issued to another key")
+	}
+	return nil
+}
+
+// prune drops expired challenges. It must be called holding the lock.
+func (c *tokenChallenges) prune(now time.Time) {
+	for msg, ch := range c.pending {
+		if now.After(ch.expires) {
+			delete(c.pending, msg)
+		}
+	}
+}
+
+func (n *net) GetTokenChallenge(_ context.Context, key thread.PubKey) ([]byte, time.Time, error) {
+	return n.challenges.issue(key)
+}
+
+func (n *net) GetTokenWithChallenge(
+	_ context.Context,
+	key thread.PubKey,
+	challenge []byte,
+	sig []byte,
+) (tok thread.Token, err error) {
+	if err = n.challenges.redeem(key, challenge); err != nil {
+		return
+	}
+	if ok, err := key.Verify(challenge, sig); !ok || err != nil {
+		return tok, fmt.Errorf("bad signature")
+	}
+	return n.issueToken(key)
+}
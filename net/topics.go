@@ -0,0 +1,37 @@
+package net
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// privateTopicPrefix names the private thread topics, see Config.PrivateTopics.
+const privateTopicPrefix = "/threads/private/"
+
+// privateTopicName returns the name of the thread topic keyed by the service key of
+// the thread, so only peers holding the key can compute it.
+func (n *net) privateTopicName(id thread.ID) (string, error) {
+	sk, err := n.store.ServiceKey(id)
+	if err != nil {
+		return "", err
+	} else if sk == nil {
+		return "", fmt.Errorf("a service-key is required to name the topic of thread %s", id)
+	}
+	mac := hmac.New(sha256.New, sk.Bytes())
+	mac.Write(id.Bytes())
+	return privateTopicPrefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// refreshThreadTopic joins the thread topic again once its service key changed.
+func (n *net) refreshThreadTopic(id thread.ID) {
+	if n.server == nil || n.server.ps == nil {
+		return
+	}
+	if err := n.server.ps.Refresh(id); err != nil {
+		log.Errorf("refreshing topic of thread %s: %v", id, err)
+	}
+}
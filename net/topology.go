@@ -0,0 +1,141 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"sync"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+)
+
+var localityPrefix = ds.NewKey("/locality")
+
+// TopologyConfig places the host within a multi-region deployment, so traffic between
+// replicators of a thread stays within a region where possible. Peers are tagged with
+// their locality using SetPeerLocality, untagged peers are never deprioritized.
+type TopologyConfig struct {
+	Locality core.Locality
+
+	// MaxCrossRegionFanout caps the number of peers in other regions which records are
+	// pushed to directly and edges are exchanged with, if a thread has replicators in the
+	// host region. The capped peers are picked at random and catch up through the region
+	// replicators or their own pulls. Zero is unlimited.
+	MaxCrossRegionFanout int
+}
+
+// topology keeps the localities of tagged peers.
+type topology struct {
+	conf  TopologyConfig
+	store ds.Datastore
+
+	mx    sync.RWMutex
+	peers map[peer.ID]core.Locality
+}
+
+func newTopology(conf TopologyConfig, store ds.Datastore) (*topology, error) {
+	t := &topology{
+		conf:  conf,
+		store: store,
+		peers: make(map[peer.ID]core.Locality),
+	}
+	res, err := store.Query(query.Query{Prefix: localityPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		pid, err := peer.Decode(ds.RawKey(r.Key).BaseNamespace())
+		if err != nil {
+			log.Warnf("skipping malformed locality entry %s: %v", r.Key, err)
+			continue
+		}
+		var loc core.Locality
+		if err = json.Unmarshal(r.Value, &loc); err != nil {
+			log.Warnf("skipping malformed locality entry %s: %v", r.Key, err)
+			continue
+		}
+		t.peers[pid] = loc
+	}
+	return t, nil
+}
+
+func (n *net) SetPeerLocality(_ context.Context, pid peer.ID, loc core.Locality) error {
+	return n.topology.set(pid, loc)
+}
+
+func (t *topology) set(pid peer.ID, loc core.Locality) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	key := localityPrefix.ChildString(pid.Pretty())
+	if !loc.Defined() {
+		delete(t.peers, pid)
+		return t.store.Delete(key)
+	}
+	data, err := json.Marshal(loc)
+	if err != nil {
+		return err
+	}
+	if err = t.store.Put(key, data); err != nil {
+		return err
+	}
+	t.peers[pid] = loc
+	return nil
+}
+
+// distance ranks a peer by its locality: zero for the host zone, one for the host region
+// and untagged peers, two for other regions.
+func (t *topology) distance(pid peer.ID) int {
+	loc, ok := t.peers[pid]
+	switch {
+	case !ok || !t.conf.Locality.Defined():
+		return 1
+	case loc.Region != t.conf.Locality.Region:
+		return 2
+	case loc.Zone == t.conf.Locality.Zone:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// prefer orders peers of a thread by their distance to the host, and caps the number of
+// peers in other regions as configured. Peers are returned as is if the host isn't tagged.
+func (t *topology) prefer(peers []peer.ID) []peer.ID {
+	if !t.conf.Locality.Defined() || len(peers) < 2 {
+		return peers
+	}
+	t.mx.RLock()
+	dist := make(map[peer.ID]int, len(peers))
+	for _, pid := range peers {
+		dist[pid] = t.distance(pid)
+	}
+	t.mx.RUnlock()
+
+	ordered := make([]peer.ID, len(peers))
+	copy(ordered, peers)
+	// shuffle first, so capping picks random peers of other regions
+	rand.Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+	sort.SliceStable(ordered, func(i, j int) bool { return dist[ordered[i]] < dist[ordered[j]] })
+
+	if t.conf.MaxCrossRegionFanout <= 0 || dist[ordered[0]] == 2 {
+		// no replicators in the host region to catch up through
+		return ordered
+	}
+	for i, pid := range ordered {
+		if dist[pid] == 2 {
+			if remote := len(ordered) - i; remote > t.conf.MaxCrossRegionFanout {
+				ordered = ordered[:i+t.conf.MaxCrossRegionFanout]
+			}
+			break
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,86 @@
+package net
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	core "github.com/textileio/go-threads/core/net"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+)
+
+func TestTopology_Prefer(t *testing.T) {
+	store := syncds.MutexWrap(ds.NewMapDatastore())
+	top, err := newTopology(TopologyConfig{
+		Locality:             core.Locality{Region: "eu", Zone: "a"},
+		MaxCrossRegionFanout: 1,
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		zone     = tu.RandPeerIDFatal(t)
+		region   = tu.RandPeerIDFatal(t)
+		untagged = tu.RandPeerIDFatal(t)
+		remote1  = tu.RandPeerIDFatal(t)
+		remote2  = tu.RandPeerIDFatal(t)
+	)
+	tags := map[peer.ID]core.Locality{
+		zone:    {Region: "eu", Zone: "a"},
+		region:  {Region: "eu", Zone: "b"},
+		remote1: {Region: "us", Zone: "a"},
+		remote2: {Region: "us", Zone: "b"},
+	}
+	for pid, loc := range tags {
+		if err = top.set(pid, loc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	peers := top.prefer([]peer.ID{remote1, remote2, untagged, region, zone})
+	if len(peers) != 4 {
+		t.Fatalf("expected cross-region peers to be capped, got %d peers", len(peers))
+	}
+	if peers[0] != zone {
+		t.Fatalf("expected same-zone peer first, got %s", peers[0])
+	}
+	if last := peers[3]; last != remote1 && last != remote2 {
+		t.Fatalf("expected cross-region peer last, got %s", last)
+	}
+	if peers = top.prefer([]peer.ID{remote1, remote2}); len(peers) != 2 {
+		t.Fatalf("expected cross-region peers to be kept without region replicators, got %d peers", len(peers))
+	}
+
+	// tags survive restarts
+	if top, err = newTopology(top.conf, store); err != nil {
+		t.Fatal(err)
+	}
+	if d := top.distance(remote1); d != 2 {
+		t.Fatalf("expected restored cross-region tag, got distance %d", d)
+	}
+	if err = top.set(remote1, core.Locality{}); err != nil {
+		t.Fatal(err)
+	}
+	if d := top.distance(remote1); d != 1 {
+		t.Fatalf("expected removed tag, got distance %d", d)
+	}
+}
+
+func TestNet_SetPeerLocality(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		Topology: TopologyConfig{Locality: core.Locality{Region: "eu"}},
+	}).(*net)
+	defer n.Close()
+
+	pid := tu.RandPeerIDFatal(t)
+	if err := n.SetPeerLocality(context.Background(), pid, core.Locality{Region: "us"}); err != nil {
+		t.Fatal(err)
+	}
+	if d := n.topology.distance(pid); d != 2 {
+		t.Fatalf("expected cross-region peer, got distance %d", d)
+	}
+}
@@ -0,0 +1,156 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+// CreateRecordsAcross creates chains of new records in multiple threads in one transaction, e.g., an index
+// thread along with a data thread. The semaphores of all threads are held while the chains are created and
+// their pushes prepared, and log heads only advance if all succeed. If advancing a head fails, the heads
+// advanced before are rolled back. Records are only announced to listeners and peers once committed.
+func (n *net) CreateRecordsAcross(ctx context.Context, writes []app.ThreadWrite) ([][]core.ThreadRecord, error) {
+	var (
+		identities = make([]thread.PubKey, len(writes))
+		exts       = make([]map[string][]byte, len(writes))
+		seen       = make(map[thread.ID]struct{}, len(writes))
+	)
+	for i, w := range writes {
+		if _, ok := seen[w.ID]; ok {
+			return nil, fmt.Errorf("thread %s is written twice", w.ID)
+		}
+		seen[w.ID] = struct{}{}
+		args := &core.ThreadOptions{}
+		for _, opt := range w.Opts {
+			opt(args)
+		}
+		identity, err := n.validateBodies(ctx, w.ID, w.Bodies, args)
+		if err != nil {
+			return nil, fmt.Errorf("thread %s: %w", w.ID, err)
+		}
+		identities[i], exts[i] = identity, args.Extensions
+		if err = n.loadThread(w.ID); err != nil {
+			return nil, err
+		}
+		if err = n.rehydrateThread(ctx, w.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	// semaphores are taken in the order of thread IDs, so concurrent transactions don't deadlock
+	order := make([]int, len(writes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return writes[order[a]].ID.String() < writes[order[b]].ID.String()
+	})
+	for _, i := range order {
+		ts, err := n.lockThread(writes[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		defer func(ts *util.Semaphore) { ts.Release() }(ts)
+	}
+
+	var (
+		chains = make([]*recordChain, len(writes))
+		pushes = make([]*recordsPush, len(writes))
+	)
+	for i, w := range writes {
+		if len(w.Bodies) == 0 {
+			continue
+		}
+		chain, err := n.newRecordChain(ctx, w.ID, w.Bodies, identities[i], exts[i])
+		if err != nil {
+			return nil, fmt.Errorf("thread %s: %w", w.ID, err)
+		}
+		defer chain.release()
+		if pushes[i], err = n.server.preparePushRecords(ctx, w.ID, chain.lid, chain.recs); err != nil {
+			return nil, fmt.Errorf("thread %s: %w", w.ID, err)
+		}
+		chains[i] = chain
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// any rejection aborts the whole transaction, blocks of the rejected records are left to GC
+	src := n.localSource()
+	for i, chain := range chains {
+		if chain == nil {
+			continue
+		}
+		for _, r := range chain.recs {
+			if err := n.beforePersist(ctx, NewRecordFrom(r, writes[i].ID, chain.lid, src)); err != nil {
+				return nil, fmt.Errorf("thread %s: %w", writes[i].ID, err)
+			}
+		}
+	}
+	for i, chain := range chains {
+		if chain == nil {
+			continue
+		}
+		next := chain.nextHeads()
+		if err := n.journal.Begin(writes[i].ID, chain.lid, chain.heads, next); err != nil {
+			n.rollbackHeads(writes[:i], chains[:i])
+			return nil, fmt.Errorf("thread %s: %w", writes[i].ID, err)
+		}
+		if err := n.setHeads(ctx, writes[i].ID, chain.lid, next); err != nil {
+			n.rollbackHeads(writes[:i+1], chains[:i+1])
+			return nil, fmt.Errorf("thread %s: %w", writes[i].ID, err)
+		}
+	}
+	for i, chain := range chains {
+		if chain != nil {
+			n.commitHeads(writes[i].ID, chain.lid)
+		}
+	}
+
+	trs := make([][]core.ThreadRecord, len(writes))
+	for i, chain := range chains {
+		if chain == nil {
+			continue
+		}
+		n.advanceLogSeq(writes[i].ID, chain.lid, len(chain.recs))
+		n.chargeCreated(ctx, writes[i].ID, chain.lid, chain.recs)
+		n.emitHeadsChanged(writes[i].ID, chain.lid, "", chain.recs[len(chain.recs)-1].Cid())
+		trs[i] = make([]core.ThreadRecord, len(chain.recs))
+		for j, r := range chain.recs {
+			trs[i][j] = NewRecordFrom(r, writes[i].ID, chain.lid, src)
+			n.afterPersist(ctx, trs[i][j])
+			// the records are committed, so slow listeners don't fail the write
+			if err := n.notify(trs[i][j]); err != nil {
+				log.Errorf("error notifying listeners of record %s: %v", r.Cid(), err)
+			}
+		}
+		log.Debugf("created %d records in transaction (thread=%s, log=%s)", len(chain.recs), writes[i].ID, chain.lid)
+	}
+	for _, push := range pushes {
+		if push != nil {
+			n.server.sendPushRecords(ctx, push)
+		}
+	}
+	return trs, nil
+}
+
+// rollbackHeads restores the log heads preceding the chains. The caller must hold the thread semaphores.
+func (n *net) rollbackHeads(writes []app.ThreadWrite, chains []*recordChain) {
+	for i, chain := range chains {
+		if chain == nil {
+			continue
+		}
+		if err := n.store.SetHeads(writes[i].ID, chain.lid, chain.heads); err != nil {
+			log.Errorf("error rolling back heads of log %s (thread=%s): %v", chain.lid, writes[i].ID, err)
+			continue
+		}
+		n.commitHeads(writes[i].ID, chain.lid)
+	}
+}
@@ -0,0 +1,150 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto"
+)
+
+// trustAnchorKey is the metadata key of the imported verification bundle of a thread.
+const trustAnchorKey = "/trust-anchor"
+
+func (n *net) ExportVerificationBundle(
+	_ context.Context,
+	id thread.ID,
+	opts ...core.ThreadOption,
+) (bundle core.VerificationBundle, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, true); err != nil {
+		return
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+	if info.Key.Service() == nil {
+		return bundle, fmt.Errorf("a service-key is required to export a verification bundle")
+	}
+
+	return core.NewVerificationBundle(id, info.Key.Service(), info.Logs)
+}
+
+func (n *net) ImportVerificationBundle(
+	_ context.Context,
+	bundle core.VerificationBundle,
+	opts ...core.ThreadOption,
+) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(bundle.ThreadID, args.Token, false); err != nil {
+		return err
+	}
+	if err := bundle.Validate(); err != nil {
+		return err
+	}
+
+	ts, err := n.lockThread(bundle.ThreadID)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+
+	// The bundle must agree with what is already known about the thread
+	sk, err := n.store.ServiceKey(bundle.ThreadID)
+	if err != nil {
+		return err
+	}
+	if sk != nil {
+		if err = bundle.CheckServiceKey(sk); err != nil {
+			return err
+		}
+	}
+	for _, l := range bundle.Logs {
+		pk, err := n.store.PubKey(bundle.ThreadID, l.ID)
+		if err != nil {
+			return err
+		}
+		if pk == nil {
+			continue
+		}
+		if _, err = bundle.CheckLogKey(l.ID, pk); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	if err = n.store.PutBytes(bundle.ThreadID, trustAnchorKey, data); err != nil {
+		return err
+	}
+	log.Debugf("imported verification bundle with %d logs (thread=%s)", len(bundle.Logs), bundle.ThreadID)
+	return nil
+}
+
+// checkTrustedLogKey ensures that a log key received from a peer matches the
+// thread trust anchor. Logs missing from the anchor are only accepted if they're
+// signed by their owner, i.e. the addresses are signed for the thread with the log key,
+// so peers can't bind keys they don't hold to an anchored thread.
+func (n *net) checkTrustedLogKey(tid thread.ID, lg peerLog) error {
+	lid, pk := lg.ID, lg.PubKey
+	if pk == nil {
+		return nil
+	}
+	if !lid.MatchesPublicKey(pk) {
+		return fmt.Errorf("log %s: public key doesn't match log ID", lid)
+	}
+	data, err := n.store.GetBytes(tid, trustAnchorKey)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+	var bundle core.VerificationBundle
+	if err = json.Unmarshal(*data, &bundle); err != nil {
+		return fmt.Errorf("decoding trust anchor: %w", err)
+	}
+	if listed, err := bundle.CheckLogKey(lid, pk); listed || err != nil {
+		return err
+	}
+	if lg.addrsSig == nil {
+		return fmt.Errorf("log %s: %w: unsigned log isn't in the anchor", lid, core.ErrUntrustedLog)
+	}
+	if ok, err := pk.Verify(logAddrsPayload(tid, lid, lg.addrsSeq, lg.Addrs), lg.addrsSig); err != nil || !ok {
+		return fmt.Errorf("log %s: %w: bad owner signature", lid, core.ErrUntrustedLog)
+	}
+	return nil
+}
+
+// VerifyBundleSample checks a thread sample against a trusted verification bundle.
+// Besides the checks of VerifySample, every sampled log must be listed in the bundle.
+func VerifyBundleSample(
+	bundle core.VerificationBundle,
+	sample core.ThreadSample,
+	nonce []byte,
+	k int,
+	key crypto.DecryptionKey,
+) error {
+	if !sample.ThreadID.Equals(bundle.ThreadID) {
+		return fmt.Errorf("sample is not from the bundled thread")
+	}
+	for _, ls := range sample.Logs {
+		trusted := bundle.LogPubKey(ls.ID)
+		if trusted == nil || !bytes.Equal(trusted, ls.PubKey) {
+			return fmt.Errorf("log %s: %w", ls.ID, core.ErrUntrustedLog)
+		}
+	}
+	return VerifySample(sample, nonce, k, key)
+}
@@ -0,0 +1,125 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/textileio/go-threads/core/app"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// unloadedKey is the metadata key marking a thread with released in-memory state.
+const unloadedKey = "/unloaded"
+
+// UnloadThread releases the in-memory state of a thread, keeping its data. The thread
+// topic is left, scheduled calls to peers and pull progress are dropped, and the thread
+// is skipped by background pulls, also after a restart. Semaphores are released by the
+// pool once unused. The thread is loaded again once it's pulled or written to.
+func (n *net) UnloadThread(ctx context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return err
+	}
+	if _, ok := n.getConnectorProtected(id, args.APIToken); !ok {
+		return fmt.Errorf("cannot unload thread: %w", app.ErrThreadInUse)
+	}
+
+	return n.withThreadWriteLock(id, func() error {
+		if _, err := n.store.GetThread(id); err != nil {
+			return err
+		}
+		if err := n.setUnloaded(id, true); err != nil {
+			return err
+		}
+		if n.server.ps != nil {
+			if err := n.server.ps.Remove(id); err != nil {
+				return err
+			}
+		}
+		n.queueGetLogs.Deschedule(id)
+		n.queueGetRecords.Deschedule(id)
+		n.pulls.forget(id)
+		n.threadLimiter.Forget(id.String())
+		n.byteLimiter.Forget(id.String())
+		return nil
+	})
+}
+
+// loadThread restores the in-memory state of an unloaded thread. It's a no-op for loaded threads.
+func (n *net) loadThread(id thread.ID) error {
+	if unloaded, err := n.isUnloaded(id); err != nil || !unloaded {
+		return err
+	}
+	return n.withThreadLock(id, func() error {
+		// the thread may have been loaded concurrently
+		if unloaded, err := n.isUnloaded(id); err != nil || !unloaded {
+			return err
+		}
+		if err := n.setUnloaded(id, false); err != nil {
+			return err
+		}
+		if n.server.ps != nil {
+			return n.server.ps.Add(id)
+		}
+		return nil
+	})
+}
+
+// isUnloaded returns whether the in-memory state of a thread was released with UnloadThread.
+func (n *net) isUnloaded(id thread.ID) (bool, error) {
+	if unloaded, ok := n.unloaded.get(id); ok {
+		return unloaded, nil
+	}
+	v, err := n.store.GetBool(id, unloadedKey)
+	if err != nil {
+		return false, err
+	}
+	unloaded := v != nil && *v
+	n.unloaded.set(id, unloaded)
+	return unloaded, nil
+}
+
+// setUnloaded saves the unloaded flag of a thread.
+func (n *net) setUnloaded(id thread.ID, unloaded bool) error {
+	if err := n.store.PutBool(id, unloadedKey, unloaded); err != nil {
+		n.unloaded.forget(id)
+		return err
+	}
+	n.unloaded.set(id, unloaded)
+	return nil
+}
+
+// unloadedFlags caches the unloaded flags of threads, so threads are loaded
+// before every write without reading the logstore.
+type unloadedFlags struct {
+	mx    sync.RWMutex
+	flags map[thread.ID]bool
+}
+
+func newUnloadedFlags() *unloadedFlags {
+	return &unloadedFlags{flags: make(map[thread.ID]bool)}
+}
+
+func (u *unloadedFlags) get(id thread.ID) (unloaded, ok bool) {
+	u.mx.RLock()
+	defer u.mx.RUnlock()
+	unloaded, ok = u.flags[id]
+	return
+}
+
+func (u *unloadedFlags) set(id thread.ID, unloaded bool) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.flags[id] = unloaded
+}
+
+func (u *unloadedFlags) forget(id thread.ID) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	delete(u.flags, id)
+}
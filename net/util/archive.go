@@ -0,0 +1,333 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+func init() {
+	cbornode.RegisterCborType(ArchiveManifest{})
+	cbornode.RegisterCborType(ArchiveLog{})
+}
+
+// ArchiveManifest is the root node of a thread archive.
+type ArchiveManifest struct {
+	Thread []byte
+	Key    []byte `refmt:",omitempty"`
+	Logs   []ArchiveLog
+}
+
+// ArchiveLog holds the metadata of an archived log.
+type ArchiveLog struct {
+	ID       []byte
+	PubKey   []byte
+	PrivKey  []byte `refmt:",omitempty"`
+	Addrs    [][]byte
+	Heads    []cid.Cid
+	Boundary cid.Cid `refmt:",omitempty"`
+}
+
+// NewArchiveLog returns the archive metadata of a log with the given compaction boundary.
+// The private key is only included with withKeys.
+func NewArchiveLog(lg thread.LogInfo, boundary cid.Cid, withKeys bool) (al ArchiveLog, err error) {
+	if al.ID, err = lg.ID.MarshalBinary(); err != nil {
+		return
+	}
+	if al.PubKey, err = ic.MarshalPublicKey(lg.PubKey); err != nil {
+		return
+	}
+	if withKeys && lg.PrivKey != nil {
+		if al.PrivKey, err = ic.MarshalPrivateKey(lg.PrivKey); err != nil {
+			return
+		}
+	}
+	for _, addr := range lg.Addrs {
+		al.Addrs = append(al.Addrs, addr.Bytes())
+	}
+	al.Heads = lg.Heads
+	if len(al.Heads) == 0 && lg.Head.Defined() {
+		al.Heads = []cid.Cid{lg.Head}
+	}
+	al.Boundary = boundary
+	return
+}
+
+// LogInfo decodes the log information of an archived log.
+func (al ArchiveLog) LogInfo() (lg thread.LogInfo, err error) {
+	if err = lg.ID.UnmarshalBinary(al.ID); err != nil {
+		return
+	}
+	pk, err := ic.UnmarshalPublicKey(al.PubKey)
+	if err != nil {
+		return lg, fmt.Errorf("log %s: bad public key: %w", lg.ID, err)
+	}
+	if !lg.ID.MatchesPublicKey(pk) {
+		return lg, fmt.Errorf("log %s: public key doesn't match log ID", lg.ID)
+	}
+	lg.PubKey = pk
+	if al.PrivKey != nil {
+		if lg.PrivKey, err = ic.UnmarshalPrivateKey(al.PrivKey); err != nil {
+			return lg, fmt.Errorf("log %s: bad private key: %w", lg.ID, err)
+		}
+	}
+	for _, b := range al.Addrs {
+		addr, err := ma.NewMultiaddrBytes(b)
+		if err != nil {
+			return lg, err
+		}
+		lg.Addrs = append(lg.Addrs, addr)
+	}
+	return lg, nil
+}
+
+// WriteArchive writes a thread archive, the manifest followed by the records of every branch
+// of the logs, newest first, down to the log compaction boundaries. Records shared by forked
+// branches are written once. It returns the number of written records.
+func WriteArchive(
+	ctx context.Context,
+	w io.Writer,
+	manifest ArchiveManifest,
+	dag format.DAGService,
+	sk *sym.Key,
+) (int, error) {
+	root, err := cbornode.WrapObject(manifest, mh.SHA2_256, -1)
+	if err != nil {
+		return 0, err
+	}
+	cw, err := NewCarWriter(w, root.Cid())
+	if err != nil {
+		return 0, err
+	}
+	if err = cw.Put(root); err != nil {
+		return 0, err
+	}
+
+	var written = make(map[cid.Cid]struct{})
+	for _, al := range manifest.Logs {
+		for _, head := range al.Heads {
+			for rid := head; rid.Defined(); {
+				if err := ctx.Err(); err != nil {
+					return len(written), err
+				}
+				if _, ok := written[rid]; ok {
+					break
+				}
+				rec, err := cbor.GetRecord(ctx, dag, rid, sk)
+				if err != nil {
+					return len(written), fmt.Errorf("getting record %s: %w", rid, err)
+				}
+				nodes, err := RecordBlocks(ctx, dag, rec)
+				if err != nil {
+					return len(written), fmt.Errorf("getting record %s: %w", rid, err)
+				}
+				for _, node := range nodes {
+					if err = cw.Put(node); err != nil {
+						return len(written), err
+					}
+				}
+				written[rid] = struct{}{}
+				if rid.Equals(al.Boundary) {
+					break // older records are dropped by compaction
+				}
+				rid = rec.PrevID()
+			}
+		}
+	}
+	return len(written), nil
+}
+
+// RecordBlocks returns the record node along with its event, header and body nodes.
+func RecordBlocks(ctx context.Context, dag format.DAGService, rec core.Record) ([]format.Node, error) {
+	block, err := rec.GetBlock(ctx, dag)
+	if err != nil {
+		return nil, err
+	}
+	event, ok := block.(*cbor.Event)
+	if !ok {
+		if event, err = cbor.EventFromNode(block); err != nil {
+			return nil, fmt.Errorf("invalid event: %w", err)
+		}
+	}
+	header, err := event.GetHeader(ctx, dag, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := event.GetBody(ctx, dag, nil)
+	if err != nil {
+		return nil, err
+	}
+	return []format.Node{rec, event, header, body}, nil
+}
+
+// ReadArchiveManifest reads the header and the manifest of an archive, which must be its
+// single root and first block.
+func ReadArchiveManifest(r io.Reader) (*CarReader, ArchiveManifest, error) {
+	var manifest ArchiveManifest
+	cr, err := NewCarReader(r)
+	if err != nil {
+		return nil, manifest, err
+	}
+	if len(cr.Roots) != 1 {
+		return nil, manifest, fmt.Errorf("expected a single archive root, got %d", len(cr.Roots))
+	}
+	root, err := NextArchiveNode(cr)
+	if errors.Is(err, io.EOF) || (err == nil && !root.Cid().Equals(cr.Roots[0])) {
+		return nil, manifest, fmt.Errorf("archive manifest not found")
+	} else if err != nil {
+		return nil, manifest, err
+	}
+	if err = cbornode.DecodeInto(root.RawData(), &manifest); err != nil {
+		return nil, manifest, fmt.Errorf("decoding archive manifest: %w", err)
+	}
+	return cr, manifest, nil
+}
+
+// ArchivedRecords are the records read from an archive, along with the IDs of the other
+// blocks, which were added to the dag service.
+type ArchivedRecords struct {
+	Records map[cid.Cid]core.Record
+	Staged  map[cid.Cid]struct{}
+}
+
+// ReadArchivedRecords reads the remaining blocks of an archive. Blocks are records if they're
+// log heads or linked to by a record read before, the others are added to the dag service.
+// Archives must list records before the ones they link to, like WriteArchive writes them.
+func ReadArchivedRecords(
+	ctx context.Context,
+	cr *CarReader,
+	dag format.NodeAdder,
+	als []ArchiveLog,
+	sk *sym.Key,
+) (ArchivedRecords, error) {
+	var (
+		ar = ArchivedRecords{
+			Records: make(map[cid.Cid]core.Record),
+			Staged:  make(map[cid.Cid]struct{}),
+		}
+		// wanted maps expected records to the index of their log
+		wanted = make(map[cid.Cid]int)
+	)
+	for i, al := range als {
+		for _, head := range al.Heads {
+			wanted[head] = i
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return ar, err
+		}
+		node, err := NextArchiveNode(cr)
+		if errors.Is(err, io.EOF) {
+			return ar, nil
+		} else if err != nil {
+			return ar, err
+		}
+		c := node.Cid()
+		i, ok := wanted[c]
+		if !ok {
+			if _, ok := ar.Records[c]; ok {
+				continue
+			}
+			if err = dag.Add(ctx, node); err != nil {
+				return ar, err
+			}
+			ar.Staged[c] = struct{}{}
+			continue
+		}
+		delete(wanted, c)
+		rec, err := cbor.RecordFromNode(node, sk)
+		if err != nil {
+			return ar, fmt.Errorf("decoding record %s: %w", c, err)
+		}
+		ar.Records[c] = rec
+		if c.Equals(als[i].Boundary) || !rec.PrevID().Defined() {
+			continue
+		}
+		if _, ok := ar.Staged[rec.PrevID()]; ok {
+			return ar, fmt.Errorf("record %s is archived before record %s linking to it", rec.PrevID(), c)
+		}
+		if _, ok := ar.Records[rec.PrevID()]; !ok {
+			wanted[rec.PrevID()] = i
+		}
+	}
+}
+
+// Chain returns the records of an archived log branch, oldest first.
+// The chain ends at the compaction boundary or at the first record missing from the archive,
+// which has to be known locally already.
+func (ar ArchivedRecords) Chain(head, boundary cid.Cid) []core.Record {
+	var chain []core.Record
+	for rid := head; rid.Defined(); {
+		rec, ok := ar.Records[rid]
+		if !ok {
+			break
+		}
+		chain = append(chain, rec)
+		if rid.Equals(boundary) {
+			break
+		}
+		rid = rec.PrevID()
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// CheckChain ensures the event, header and body blocks of archived records were added.
+// Events are loaded into the records, so they can be verified.
+func (ar ArchivedRecords) CheckChain(ctx context.Context, dag format.DAGService, chain []core.Record) error {
+	for _, rec := range chain {
+		if _, ok := ar.Staged[rec.BlockID()]; !ok {
+			return fmt.Errorf("event of record %s not found in archive", rec.Cid())
+		}
+		event, err := rec.GetBlock(ctx, dag)
+		if err != nil {
+			return err
+		}
+		ev, err := cbor.EventFromNode(event)
+		if err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		if _, ok := ar.Staged[ev.HeaderID()]; !ok {
+			return fmt.Errorf("header of record %s not found in archive", rec.Cid())
+		}
+		if _, ok := ar.Staged[ev.BodyID()]; !ok {
+			return fmt.Errorf("body of record %s not found in archive", rec.Cid())
+		}
+	}
+	return nil
+}
+
+// NextArchiveNode reads and decodes the next block of an archive.
+func NextArchiveNode(cr *CarReader) (format.Node, error) {
+	c, data, err := cr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("reading archive: %w", err)
+	}
+	blk, err := blocks.NewBlockWithCid(data, c)
+	if err != nil {
+		return nil, err
+	}
+	node, err := cbornode.DecodeBlock(blk)
+	if err != nil {
+		return nil, fmt.Errorf("decoding block %s: %w", c, err)
+	}
+	return node, nil
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"errors"
+
+	"github.com/ipfs/go-cid"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/textileio/go-threads/cbor"
+)
+
+// BodyBytes returns the size of a body node, adding the declared size of its chunks if
+// it's chunked, so the whole body can be accounted before the chunks are fetched.
+func BodyBytes(body format.Node) int64 {
+	size := int64(len(body.RawData()))
+	if _, chunked, err := cbor.BodyChunks(body); err == nil {
+		size += chunked
+	}
+	return size
+}
+
+// LocalBodyChunks returns the chunks of a body if it's chunked and stored locally.
+func LocalBodyChunks(bstore bs.Blockstore, id cid.Cid) []cid.Cid {
+	block, err := bstore.Get(id)
+	if err != nil {
+		return nil
+	}
+	node, err := cbornode.DecodeBlock(block)
+	if err != nil {
+		return nil
+	}
+	chunks, _, _ := cbor.BodyChunks(node)
+	return chunks
+}
+
+// RecordSize returns the size of the record, event, header and body blocks of a record,
+// along with the chunks of the body. Blocks missing locally, e.g., withheld bodies, are
+// not counted.
+func RecordSize(bstore bs.Blockstore, rid cid.Cid, ev *cbor.Event) (int64, error) {
+	var size int64
+	for _, id := range []cid.Cid{rid, ev.Cid(), ev.HeaderID()} {
+		s, err := bstore.GetSize(id)
+		if errors.Is(err, bs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return size, err
+		}
+		size += int64(s)
+	}
+	blk, err := bstore.Get(ev.BodyID())
+	if errors.Is(err, bs.ErrNotFound) {
+		return size, nil
+	} else if err != nil {
+		return size, err
+	}
+	body, err := cbornode.DecodeBlock(blk)
+	if err != nil {
+		return size + int64(len(blk.RawData())), nil
+	}
+	return size + BodyBytes(body), nil
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/multiformats/go-varint"
+)
+
+// carMaxSectionSize is the size limit of a single archive section.
+const carMaxSectionSize = 32 << 20
+
+func init() {
+	cbornode.RegisterCborType(carHeader{})
+}
+
+// carHeader is the header of a CARv1 archive.
+type carHeader struct {
+	Roots   []cid.Cid `refmt:"roots"`
+	Version uint64    `refmt:"version"`
+}
+
+// CarWriter writes blocks as a CARv1 archive, see https://ipld.io/specs/transport/car/carv1.
+type CarWriter struct {
+	w io.Writer
+}
+
+// NewCarWriter returns a writer of an archive with the given roots, writing its header.
+func NewCarWriter(w io.Writer, roots ...cid.Cid) (*CarWriter, error) {
+	header, err := cbornode.DumpObject(&carHeader{Roots: roots, Version: 1})
+	if err != nil {
+		return nil, err
+	}
+	cw := &CarWriter{w: w}
+	if err = cw.writeSection(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Put appends a block to the archive.
+func (cw *CarWriter) Put(node format.Node) error {
+	return cw.writeSection(node.Cid().Bytes(), node.RawData())
+}
+
+func (cw *CarWriter) writeSection(parts ...[]byte) error {
+	var size int
+	for _, p := range parts {
+		size += len(p)
+	}
+	if _, err := cw.w.Write(varint.ToUvarint(uint64(size))); err != nil {
+		return err
+	}
+	for _, p := range parts {
+		if _, err := cw.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CarReader reads blocks of a CARv1 archive.
+type CarReader struct {
+	r *bufio.Reader
+	// Roots are the archive roots listed in the header.
+	Roots []cid.Cid
+}
+
+// NewCarReader returns a reader of an archive, reading its header.
+func NewCarReader(r io.Reader) (*CarReader, error) {
+	cr := &CarReader{r: bufio.NewReader(r)}
+	data, err := cr.readSection()
+	if err != nil {
+		return nil, fmt.Errorf("reading archive header: %w", err)
+	}
+	var header carHeader
+	if err = cbornode.DecodeInto(data, &header); err != nil {
+		return nil, fmt.Errorf("decoding archive header: %w", err)
+	}
+	if header.Version != 1 {
+		return nil, fmt.Errorf("unsupported archive version %d", header.Version)
+	}
+	cr.Roots = header.Roots
+	return cr, nil
+}
+
+// Next returns the next block of the archive, or io.EOF once all blocks were read.
+// Block data is checked against the block CID.
+func (cr *CarReader) Next() (cid.Cid, []byte, error) {
+	data, err := cr.readSection()
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	n, c, err := cid.CidFromBytes(data)
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("decoding block cid: %w", err)
+	}
+	data = data[n:]
+	if actual, err := c.Prefix().Sum(data); err != nil {
+		return cid.Undef, nil, err
+	} else if !actual.Equals(c) {
+		return cid.Undef, nil, fmt.Errorf("block %s: data doesn't match cid", c)
+	}
+	return c, data, nil
+}
+
+func (cr *CarReader) readSection() ([]byte, error) {
+	size, err := varint.ReadUvarint(cr.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if size == 0 || size > carMaxSectionSize {
+		return nil, fmt.Errorf("bad archive section size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(cr.r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
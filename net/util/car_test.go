@@ -0,0 +1,80 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestCar_RoundTrip(t *testing.T) {
+	root, err := cbornode.WrapObject(map[string]string{"name": "root"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := cbornode.WrapObject(map[string]string{"name": "leaf"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	cw, err := NewCarWriter(&buf, root.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cw.Put(root); err != nil {
+		t.Fatal(err)
+	}
+	if err = cw.Put(leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	cr, err := NewCarReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cr.Roots) != 1 || !cr.Roots[0].Equals(root.Cid()) {
+		t.Fatalf("expected root %s, got %v", root.Cid(), cr.Roots)
+	}
+	for _, want := range []*cbornode.Node{root, leaf} {
+		node, err := NextArchiveNode(cr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !node.Cid().Equals(want.Cid()) || !bytes.Equal(node.RawData(), want.RawData()) {
+			t.Fatalf("expected block %s, got %s", want.Cid(), node.Cid())
+		}
+	}
+	if _, err = NextArchiveNode(cr); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected end of archive, got %v", err)
+	}
+}
+
+func TestCar_BadBlock(t *testing.T) {
+	node, err := cbornode.WrapObject(map[string]string{"name": "node"}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	cw, err := NewCarWriter(&buf, node.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cw.Put(node); err != nil {
+		t.Fatal(err)
+	}
+
+	// flip the last byte of the block data
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+	cr, err := NewCarReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = cr.Next(); err == nil {
+		t.Fatal("expected a block not matching its CID to be rejected")
+	}
+}
@@ -0,0 +1,129 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto/shamir"
+)
+
+// KeyShare is a share of a thread key escrowed for a recovery identity.
+type KeyShare struct {
+	Share     []byte
+	Threshold int
+	KeyHash   []byte
+}
+
+// SplitThreadKey splits a thread key into n shares with Shamir secret sharing, any threshold
+// of which reassemble the key with CombineKeyShares.
+func SplitThreadKey(key thread.Key, n, threshold int) ([]KeyShare, error) {
+	raw := key.Bytes()
+	parts, err := shamir.Split(raw, n, threshold)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(raw)
+	shares := make([]KeyShare, len(parts))
+	for i, p := range parts {
+		shares[i] = KeyShare{Share: p, Threshold: threshold, KeyHash: hash[:]}
+	}
+	return shares, nil
+}
+
+// CombineKeyShares reassembles a thread key from the shares collected from the recovery peers.
+// Shares are grouped by the key hash and threshold they were escrowed with, and the group of
+// most peers is combined, so a few stale or forged shares don't prevent the recovery. It also
+// returns the number of groups. It fails with core.ErrKeyNotRecovered if the key can't be
+// reassembled.
+func CombineKeyShares(shares []KeyShare) (thread.Key, int, error) {
+	type escrowGroup struct {
+		threshold int
+		hash      []byte
+		shares    [][]byte
+	}
+	var (
+		groups   = make(map[string]*escrowGroup)
+		majority *escrowGroup
+	)
+	for _, share := range shares {
+		gk := fmt.Sprintf("%d/%x", share.Threshold, share.KeyHash)
+		g, ok := groups[gk]
+		if !ok {
+			g = &escrowGroup{threshold: share.Threshold, hash: share.KeyHash}
+			groups[gk] = g
+		}
+		g.shares = append(g.shares, share.Share)
+		if majority == nil || len(g.shares) > len(majority.shares) {
+			majority = g
+		}
+	}
+	if majority == nil {
+		return thread.Key{}, 0, fmt.Errorf("%w: got no shares", core.ErrKeyNotRecovered)
+	}
+	threshold, hash, parts := majority.threshold, majority.hash, majority.shares
+	if len(parts) < threshold {
+		return thread.Key{}, len(groups), fmt.Errorf("%w: got %d of %d shares", core.ErrKeyNotRecovered, len(parts), threshold)
+	}
+	raw, err := shamir.Combine(parts[:threshold])
+	if err != nil {
+		return thread.Key{}, len(groups), fmt.Errorf("%w: %v", core.ErrKeyNotRecovered, err)
+	}
+	if sum := sha256.Sum256(raw); !bytes.Equal(sum[:], hash) {
+		return thread.Key{}, len(groups), fmt.Errorf("%w: key hash mismatch", core.ErrKeyNotRecovered)
+	}
+	key, err := thread.KeyFromBytes(raw)
+	if err != nil {
+		return thread.Key{}, len(groups), fmt.Errorf("%w: %v", core.ErrKeyNotRecovered, err)
+	}
+	return key, len(groups), nil
+}
+
+// KeySharePayload is signed by the recovery identity, binding a request to the thread and the
+// receiving peer, so it can't be replayed to other recovery peers.
+type KeySharePayload struct {
+	Thread    string `json:"thread"`
+	Peer      string `json:"peer"`
+	Share     []byte `json:"share,omitempty"`
+	Threshold int    `json:"threshold,omitempty"`
+	KeyHash   []byte `json:"keyHash,omitempty"`
+}
+
+// Sign returns the marshaled public key of the recovery identity along with its
+// signature over the payload.
+func (p KeySharePayload) Sign(ctx context.Context, recovery thread.Identity) (pk, sig []byte, err error) {
+	if recovery == nil {
+		return nil, nil, fmt.Errorf("a recovery identity is required")
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sig, err = recovery.Sign(ctx, data); err != nil {
+		return nil, nil, fmt.Errorf("signing key share request: %w", err)
+	}
+	if pk, err = recovery.GetPublic().MarshalBinary(); err != nil {
+		return nil, nil, err
+	}
+	return pk, sig, nil
+}
+
+// Verify returns the recovery identity which signed the payload.
+func (p KeySharePayload) Verify(pkb, sig []byte) (thread.PubKey, error) {
+	pk := &thread.Libp2pPubKey{}
+	if err := pk.UnmarshalBinary(pkb); err != nil {
+		return nil, fmt.Errorf("bad recovery key")
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := pk.Verify(data, sig); err != nil || !ok {
+		return nil, fmt.Errorf("bad recovery signature")
+	}
+	return pk, nil
+}
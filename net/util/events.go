@@ -0,0 +1,49 @@
+package util
+
+import (
+	"sync"
+
+	core "github.com/textileio/go-threads/core/net"
+)
+
+// EventRing keeps the latest events, older events are overwritten. A ring of zero size keeps nothing.
+type EventRing struct {
+	lk   sync.Mutex
+	buf  []core.LifecycleEvent
+	next int
+	full bool
+}
+
+// NewEventRing returns a ring keeping the latest size events.
+func NewEventRing(size int) *EventRing {
+	if size < 0 {
+		size = 0
+	}
+	return &EventRing{buf: make([]core.LifecycleEvent, size)}
+}
+
+// Add keeps an event, overwriting the oldest one if the ring is full.
+func (r *EventRing) Add(ev core.LifecycleEvent) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = ev
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// List returns the kept events, oldest first.
+func (r *EventRing) List() []core.LifecycleEvent {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if !r.full {
+		return append([]core.LifecycleEvent(nil), r.buf[:r.next]...)
+	}
+	evs := make([]core.LifecycleEvent, 0, len(r.buf))
+	evs = append(evs, r.buf[r.next:]...)
+	return append(evs, r.buf[:r.next]...)
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// GetMetadataJSON decodes the JSON value saved in the thread metadata under key into v.
+// v is left untouched if the key isn't set.
+func GetMetadataJSON(store lstore.ThreadMetadata, id thread.ID, key string, v interface{}) error {
+	data, err := store.GetBytes(id, key)
+	if err != nil || data == nil || len(*data) == 0 {
+		return err
+	}
+	return json.Unmarshal(*data, v)
+}
+
+// PutMetadataJSON saves v as JSON in the thread metadata under key.
+func PutMetadataJSON(store lstore.ThreadMetadata, id thread.ID, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.PutBytes(id, key, data)
+}
+
+// LogMarker returns the record ID saved in the log metadata under the given suffix.
+func LogMarker(store lstore.ThreadMetadata, tid thread.ID, lid peer.ID, suffix string) (cid.Cid, error) {
+	b, err := store.GetBytes(tid, lid.Pretty()+suffix)
+	if err != nil || b == nil {
+		return cid.Undef, err
+	}
+	_, c, err := cid.CidFromBytes(*b)
+	return c, err
+}
+
+// RetentionState is the reaper progress of a thread, saved as JSON in the thread metadata.
+type RetentionState struct {
+	Pruned     int   `json:"pruned,omitempty"`
+	LastPruned int64 `json:"lastPruned,omitempty"`
+	// Marks are the log heads seen by the reaper by log ID, oldest first, used to date records.
+	Marks map[string][]HeadMark `json:"marks,omitempty"`
+}
+
+// HeadMark is a log head seen by the reaper. The head and the records before it were noticed
+// by the host at the latest at the mark time.
+type HeadMark struct {
+	Time int64  `json:"t"`
+	Head []byte `json:"head"`
+}
+
+// MarkHead notes the log head at the given time, and returns the newest head seen more than
+// maxAge ago, or cid.Undef if none is known. The records up to that head are expired.
+func (s *RetentionState) MarkHead(lid peer.ID, head cid.Cid, now time.Time, maxAge time.Duration) cid.Cid {
+	key := lid.Pretty()
+	if maxAge <= 0 {
+		delete(s.Marks, key)
+		return cid.Undef
+	}
+	marks := s.Marks[key]
+	if len(marks) == 0 || !head.Equals(cidFromBytes(marks[len(marks)-1].Head)) {
+		marks = append(marks, HeadMark{Time: now.UnixNano(), Head: head.Bytes()})
+	}
+	expired := -1
+	for i, m := range marks {
+		if now.Sub(time.Unix(0, m.Time)) > maxAge {
+			expired = i
+		}
+	}
+	if expired < 0 {
+		s.Marks[key] = marks
+		return cid.Undef
+	}
+	// older marks are superseded by the expired one
+	s.Marks[key] = marks[expired:]
+	return cidFromBytes(marks[expired].Head)
+}
+
+func cidFromBytes(b []byte) cid.Cid {
+	_, c, err := cid.CidFromBytes(b)
+	if err != nil {
+		return cid.Undef
+	}
+	return c
+}
@@ -0,0 +1,68 @@
+package util
+
+import "sync"
+
+// NewRWLockPool returns an empty pool of read-write locks.
+// Locks are dropped from the pool once they're neither held nor awaited.
+func NewRWLockPool() *RWLockPool {
+	return &RWLockPool{ls: make(map[string]*rwLock)}
+}
+
+// RWLockPool is a set of read-write locks by key.
+type RWLockPool struct {
+	ls map[string]*rwLock
+	mu sync.Mutex
+}
+
+type rwLock struct {
+	sync.RWMutex
+	refs int
+}
+
+// RLock acquires the lock of the key shared, and returns a function releasing it.
+func (p *RWLockPool) RLock(k SemaphoreKey) (release func()) {
+	key, l := p.ref(k)
+	l.RLock()
+	return func() {
+		l.RUnlock()
+		p.unref(key, l)
+	}
+}
+
+// Lock acquires the lock of the key exclusively, and returns a function releasing it.
+func (p *RWLockPool) Lock(k SemaphoreKey) (release func()) {
+	key, l := p.ref(k)
+	l.Lock()
+	return func() {
+		l.Unlock()
+		p.unref(key, l)
+	}
+}
+
+// Size returns the number of locks in use.
+func (p *RWLockPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.ls)
+}
+
+func (p *RWLockPool) ref(k SemaphoreKey) (string, *rwLock) {
+	key := k.Key()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l, ok := p.ls[key]
+	if !ok {
+		l = &rwLock{}
+		p.ls[key] = l
+	}
+	l.refs++
+	return key, l
+}
+
+func (p *RWLockPool) unref(key string, l *rwLock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if l.refs--; l.refs == 0 && p.ls[key] == l {
+		delete(p.ls, key)
+	}
+}
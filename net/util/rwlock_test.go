@@ -0,0 +1,30 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRWLockPool(t *testing.T) {
+	p := NewRWLockPool()
+	r1, r2 := p.RLock(testKey("a")), p.RLock(testKey("a"))
+
+	locked := make(chan struct{})
+	go func() {
+		release := p.Lock(testKey("a"))
+		close(locked)
+		release()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("exclusive lock must wait for shared holders")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// other keys aren't blocked
+	p.Lock(testKey("b"))()
+
+	r1()
+	r2()
+	<-locked
+	waitFor(t, func() bool { return p.Size() == 0 })
+}
@@ -0,0 +1,280 @@
+package util
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrSemaphoreTimeout indicates that a semaphore wasn't acquired in time.
+var ErrSemaphoreTimeout = errors.New("semaphore acquisition timed out")
+
+// ErrSemaphorePoolStopped indicates that the semaphore pool was stopped.
+var ErrSemaphorePoolStopped = errors.New("semaphore pool stopped")
+
+// SemaphoreStatus describes the holders and waiters of a semaphore.
+type SemaphoreStatus struct {
+	Key      string
+	Capacity int
+	Holders  int
+	Waiters  int
+	// HeldSince is the acquisition time of the oldest holder.
+	HeldSince time.Time
+	// WaitingSince is the time the oldest waiter started waiting.
+	WaitingSince time.Time
+}
+
+func (s SemaphoreStatus) String() string {
+	if s.Holders == 0 {
+		return "free"
+	}
+	return fmt.Sprintf("held by %d/%d for %s, %d waiting",
+		s.Holders, s.Capacity, time.Since(s.HeldSince).Round(time.Millisecond), s.Waiters)
+}
+
+type semaWaiter struct {
+	ready chan struct{}
+	since time.Time
+}
+
+func NewSemaphore(capacity int) *Semaphore {
+	return newSemaphore("", capacity, nil)
+}
+
+func newSemaphore(key string, capacity int, pool *SemaphorePool) *Semaphore {
+	return &Semaphore{key: key, capacity: capacity, waiters: list.New(), pool: pool}
+}
+
+// Semaphore grants up to capacity holders at once. Waiters are served in FIFO
+// order, so callers arriving later can't starve the ones already waiting.
+type Semaphore struct {
+	key      string
+	capacity int
+	held     []time.Time // acquisition times, oldest first
+	waiters  *list.List
+	mu       sync.Mutex
+
+	pool *SemaphorePool
+	refs int // holders and waiters acquiring through the pool, guarded by the pool
+}
+
+// Blocking acquire
+func (s *Semaphore) Acquire() {
+	s.acquire(nil)
+}
+
+// AcquireTimeout blocks until the semaphore is acquired or the timeout elapses.
+// A zero timeout waits forever. The returned error wraps ErrSemaphoreTimeout
+// and describes the current holders and waiters.
+func (s *Semaphore) AcquireTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		s.Acquire()
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if s.acquire(ctx.Done()) {
+		return nil
+	}
+	return fmt.Errorf("acquiring semaphore %s: %w after %s (%s)", s.key, ErrSemaphoreTimeout, timeout, s.Status())
+}
+
+// AcquireContext blocks until the semaphore is acquired or the context is done.
+func (s *Semaphore) AcquireContext(ctx context.Context) error {
+	if s.acquire(ctx.Done()) {
+		return nil
+	}
+	return fmt.Errorf("acquiring semaphore %s: %w (%s)", s.key, ctx.Err(), s.Status())
+}
+
+// Non-blocking acquire, fails if the semaphore is full or has waiters.
+func (s *Semaphore) TryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.free() {
+		return false
+	}
+	s.held = append(s.held, time.Now())
+	return true
+}
+
+func (s *Semaphore) Release() {
+	s.release()
+	if s.pool != nil {
+		s.pool.unref(s)
+	}
+}
+
+func (s *Semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.held) == 0 {
+		panic("thread semaphore inconsistency: release before acquire!")
+	}
+	s.held = s.held[1:]
+
+	// hand over to the oldest waiter
+	if e := s.waiters.Front(); e != nil {
+		w := s.waiters.Remove(e).(*semaWaiter)
+		s.held = append(s.held, time.Now())
+		close(w.ready)
+	}
+}
+
+// Status returns the current holders and waiters of the semaphore.
+func (s *Semaphore) Status() SemaphoreStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := SemaphoreStatus{
+		Key:      s.key,
+		Capacity: s.capacity,
+		Holders:  len(s.held),
+		Waiters:  s.waiters.Len(),
+	}
+	if len(s.held) > 0 {
+		st.HeldSince = s.held[0]
+	}
+	if e := s.waiters.Front(); e != nil {
+		st.WaitingSince = e.Value.(*semaWaiter).since
+	}
+	return st
+}
+
+// acquire waits for the semaphore until done is closed, and reports whether
+// the semaphore was acquired. A nil done channel waits forever.
+func (s *Semaphore) acquire(done <-chan struct{}) bool {
+	s.mu.Lock()
+	if s.free() {
+		s.held = append(s.held, time.Now())
+		s.mu.Unlock()
+		return true
+	}
+	w := &semaWaiter{ready: make(chan struct{}), since: time.Now()}
+	e := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-done:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-w.ready:
+		// handed over while giving up
+		return true
+	default:
+		s.waiters.Remove(e)
+		return false
+	}
+}
+
+func (s *Semaphore) free() bool {
+	return len(s.held) < s.capacity && s.waiters.Len() == 0
+}
+
+type SemaphoreKey interface {
+	Key() string
+}
+
+// NewSemaphorePool returns a pool of semaphores with the given capacity.
+// Acquire gives up after the timeout, zero waits forever.
+// Semaphores are dropped from the pool once they're neither held nor awaited,
+// so the pool only keeps the semaphores of keys in use.
+func NewSemaphorePool(semaCap int, timeout time.Duration) *SemaphorePool {
+	return &SemaphorePool{ss: make(map[string]*Semaphore), semaCap: semaCap, timeout: timeout}
+}
+
+type SemaphorePool struct {
+	ss      map[string]*Semaphore
+	semaCap int
+	timeout time.Duration
+	stopped bool
+	mu      sync.Mutex
+}
+
+// Acquire acquires the semaphore of the key, waiting at most the pool timeout.
+func (p *SemaphorePool) Acquire(k SemaphoreKey) (*Semaphore, error) {
+	key := k.Key()
+	p.mu.Lock()
+	s, exist := p.ss[key]
+	if !exist {
+		if p.stopped {
+			// semaphores not in use were dropped, so they're refused rather than pinned
+			p.mu.Unlock()
+			return nil, fmt.Errorf("acquiring semaphore %s: %w", key, ErrSemaphorePoolStopped)
+		}
+		s = newSemaphore(key, p.semaCap, p)
+		p.ss[key] = s
+	}
+	s.refs++
+	p.mu.Unlock()
+
+	if err := s.AcquireTimeout(p.timeout); err != nil {
+		p.unref(s)
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *SemaphorePool) unref(s *Semaphore) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s.refs--; s.refs == 0 && p.ss[s.key] == s {
+		delete(p.ss, s.key)
+	}
+}
+
+// Size returns the number of semaphores in use.
+func (p *SemaphorePool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.ss)
+}
+
+// Status lists the semaphores which are currently held or waited for, ordered by key.
+// It's meant for debugging stuck operations, e.g., deadlocks.
+func (p *SemaphorePool) Status() []SemaphoreStatus {
+	p.mu.Lock()
+	ss := make([]*Semaphore, 0, len(p.ss))
+	for _, s := range p.ss {
+		ss = append(ss, s)
+	}
+	p.mu.Unlock()
+
+	var res []SemaphoreStatus
+	for _, s := range ss {
+		if st := s.Status(); st.Holders > 0 || st.Waiters > 0 {
+			res = append(res, st)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+// Stop blocks until all semaphores in use are released, and holds them from then on.
+// Keys whose semaphores weren't in use fail with ErrSemaphorePoolStopped from then on.
+func (p *SemaphorePool) Stop() {
+	// pin all semaphores in use, so they stay blocked after the current holders release them
+	p.mu.Lock()
+	p.stopped = true
+	ss := make([]*Semaphore, 0, len(p.ss))
+	for _, s := range p.ss {
+		s.refs++
+		ss = append(ss, s)
+	}
+	p.mu.Unlock()
+
+	// grab all semaphores and hold
+	for _, s := range ss {
+		for i := 0; i < s.capacity; i++ {
+			s.Acquire()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testKey string
+
+func (k testKey) Key() string {
+	return string(k)
+}
+
+func TestSemaphore_FIFO(t *testing.T) {
+	s := NewSemaphore(1)
+	s.Acquire()
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			s.Acquire()
+			order <- i
+			s.Release()
+		}()
+		waitFor(t, func() bool { return s.Status().Waiters == i+1 })
+	}
+	if s.TryAcquire() {
+		t.Fatal("try acquire must not overtake waiters")
+	}
+
+	s.Release()
+	for i := 0; i < 3; i++ {
+		if got := <-order; got != i {
+			t.Fatalf("expected waiter %d to acquire, got %d", i, got)
+		}
+	}
+	if !s.TryAcquire() {
+		t.Fatal("expected free semaphore")
+	}
+}
+
+func TestSemaphore_Width(t *testing.T) {
+	s := NewSemaphore(2)
+	if !s.TryAcquire() || !s.TryAcquire() {
+		t.Fatal("expected two holders")
+	}
+	if s.TryAcquire() {
+		t.Fatal("expected full semaphore")
+	}
+	s.Release()
+	if !s.TryAcquire() {
+		t.Fatal("expected released slot")
+	}
+}
+
+func TestSemaphorePool_Timeout(t *testing.T) {
+	p := NewSemaphorePool(1, 50*time.Millisecond)
+	s, err := p.Acquire(testKey("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Acquire(testKey("a")); !errors.Is(err, ErrSemaphoreTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if _, err = p.Acquire(testKey("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	status := p.Status()
+	if len(status) != 2 || status[0].Key != "a" || status[1].Key != "b" {
+		t.Fatalf("expected held semaphores to be listed, got %v", status)
+	}
+	if status[0].Holders != 1 || status[0].Waiters != 0 || status[0].HeldSince.IsZero() {
+		t.Fatalf("got bad status %v", status[0])
+	}
+
+	s.Release()
+	if status = p.Status(); len(status) != 1 || status[0].Key != "b" {
+		t.Fatalf("expected released semaphore to be omitted, got %v", status)
+	}
+}
+
+func TestSemaphorePool_Evict(t *testing.T) {
+	p := NewSemaphorePool(1, 0)
+	s, err := p.Acquire(testKey("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan *Semaphore)
+	go func() {
+		s, _ := p.Acquire(testKey("a"))
+		acquired <- s
+	}()
+	waitFor(t, func() bool { return s.Status().Waiters == 1 })
+
+	s.Release()
+	next := <-acquired
+	if next != s {
+		t.Fatal("expected waiter to acquire the same semaphore")
+	}
+	if size := p.Size(); size != 1 {
+		t.Fatalf("expected held semaphore to be kept, got %d semaphores", size)
+	}
+	next.Release()
+	if size := p.Size(); size != 0 {
+		t.Fatalf("expected released semaphore to be dropped, got %d semaphores", size)
+	}
+}
+
+func TestSemaphorePool_Stop(t *testing.T) {
+	p := NewSemaphorePool(1, 50*time.Millisecond)
+	s, err := p.Acquire(testKey("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b was used, and dropped before stopping
+	b, err := p.Acquire(testKey("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Release()
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	waitFor(t, func() bool { return s.Status().Waiters == 1 })
+	s.Release()
+	<-stopped
+
+	if _, err = p.Acquire(testKey("a")); !errors.Is(err, ErrSemaphoreTimeout) {
+		t.Fatalf("expected a to be held after stop, got %v", err)
+	}
+	for _, k := range []testKey{"b", "c"} {
+		if _, err = p.Acquire(k); !errors.Is(err, ErrSemaphorePoolStopped) {
+			t.Fatalf("expected %s to be refused after stop, got %v", k, err)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met in time")
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mbase "github.com/multiformats/go-multibase"
+	lstore "github.com/textileio/go-threads/core/logstore"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	pb "github.com/textileio/go-threads/net/pb"
+)
+
+const (
+	// invitePrefix is the metadata prefix of the pending single-use invites of a thread.
+	invitePrefix = "/invite/"
+
+	// inviteNonceBytes is the byte length of single-use invite nonces.
+	inviteNonceBytes = 16
+)
+
+// DecodeInvite returns the body of an invite with a valid inviter signature.
+func DecodeInvite(invite string) (*pb.Invite_Body, error) {
+	_, data, err := mbase.Decode(invite)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrInvalidInvite, err)
+	}
+	inv := &pb.Invite{}
+	if err = inv.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrInvalidInvite, err)
+	}
+	body := &pb.Invite_Body{}
+	if err = body.Unmarshal(inv.Body); err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrInvalidInvite, err)
+	}
+	if body.ThreadID == nil || body.Inviter == nil {
+		return nil, fmt.Errorf("%w: missing thread or inviter", core.ErrInvalidInvite)
+	}
+	pk, err := body.Inviter.ID.ExtractPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrInvalidInvite, err)
+	}
+	if ok, err := pk.Verify(inv.Body, inv.Sig); err != nil || !ok {
+		return nil, fmt.Errorf("%w: bad signature", core.ErrInvalidInvite)
+	}
+	return body, nil
+}
+
+// PutPendingInvite makes an invite single-use. It sets a random nonce on the invite body and
+// saves the bundle in the thread metadata under it, where it's only read once by
+// RedeemPendingInvite. The bundle is dropped from the invite body.
+func PutPendingInvite(store lstore.ThreadMetadata, body *pb.Invite_Body) error {
+	body.Nonce = make([]byte, inviteNonceBytes)
+	if _, err := rand.Read(body.Nonce); err != nil {
+		return err
+	}
+	pending, err := (&pb.Invite_Body{
+		Expires: body.Expires,
+		Bundle:  body.Bundle,
+	}).Marshal()
+	if err != nil {
+		return err
+	}
+	body.Bundle = nil
+	return store.PutBytes(body.ThreadID.ID, invitePrefix+hex.EncodeToString(body.Nonce), pending)
+}
+
+// RedeemPendingInvite returns the bundle of a single-use invite, which can't be redeemed again.
+// Callers must serialize redemptions of the thread invites.
+func RedeemPendingInvite(store lstore.ThreadMetadata, id thread.ID, nonce []byte, now time.Time) ([]byte, error) {
+	key := invitePrefix + hex.EncodeToString(nonce)
+	data, err := store.GetBytes(id, key)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil || len(*data) == 0 {
+		return nil, core.ErrInviteRedeemed
+	}
+	pending := &pb.Invite_Body{}
+	if err = pending.Unmarshal(*data); err != nil {
+		return nil, fmt.Errorf("decoding pending invite: %w", err)
+	}
+	if err = store.PutBytes(id, key, []byte{}); err != nil {
+		return nil, err
+	}
+	if pending.Expires > 0 && now.Unix() > pending.Expires {
+		return nil, core.ErrInviteExpired
+	}
+	return pending.Bundle, nil
+}
+
+// TokenID returns the ID of a token, which is derived from the token itself for tokens without one.
+func TokenID(token thread.Token, claims thread.TokenClaims) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithoutLink drops the links to a thread, reusing the backing array of links.
+func WithoutLink(links []core.ThreadLink, linked thread.ID) []core.ThreadLink {
+	kept := links[:0]
+	for _, l := range links {
+		if l.ID != linked {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// SameHeads returns whether the logs of two thread infos have the same heads.
+func SameHeads(a, b thread.Info) bool {
+	if len(a.Logs) != len(b.Logs) {
+		return false
+	}
+	heads := make(map[peer.ID][]cid.Cid, len(a.Logs))
+	for _, lg := range a.Logs {
+		heads[lg.ID] = lg.Heads
+	}
+	for _, lg := range b.Logs {
+		if !equalHeads(heads[lg.ID], lg.Heads) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalHeads(a, b []cid.Cid) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equals(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	format "github.com/ipfs/go-ipld-format"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+)
+
+// VerifyLog walks every branch of a log to the start or the compaction boundary. Blocks are
+// only read from the blockstore, a branch ends at a record which can't be decoded.
+// Bodies of the records withheld reports aren't expected to be stored, withheld may be nil.
+func VerifyLog(
+	ctx context.Context,
+	bstore bs.Blockstore,
+	lg thread.LogInfo,
+	sk *sym.Key,
+	boundary cid.Cid,
+	withheld func(rid cid.Cid) bool,
+) (core.LogVerification, error) {
+	heads := lg.Heads
+	if len(heads) == 0 && lg.Head.Defined() {
+		heads = []cid.Cid{lg.Head}
+	}
+	lv := core.LogVerification{ID: lg.ID, Heads: heads}
+	visited := make(map[cid.Cid]struct{})
+	for _, head := range heads {
+		for rid := head; rid.Defined(); {
+			if err := ctx.Err(); err != nil {
+				return lv, err
+			}
+			if _, ok := visited[rid]; ok {
+				break // fork point of a visited branch
+			}
+			visited[rid] = struct{}{}
+			withBody := withheld == nil || !withheld(rid)
+			rec, damage, err := verifyRecord(bstore, rid, sk, lg.PubKey, withBody)
+			if err != nil {
+				return lv, err
+			}
+			lv.Damage = append(lv.Damage, damage...)
+			if rec == nil {
+				break
+			}
+			lv.Records++
+			if rid.Equals(boundary) {
+				break
+			}
+			rid = rec.PrevID()
+		}
+	}
+	return lv, nil
+}
+
+// RepairLog verifies a log and repairs its damaged records. Repairs may uncover damage behind
+// broken links, so the log is verified again until no new damage is found. Every damaged record
+// is passed to repair once, an error returned by repair aborts the repair.
+func RepairLog(
+	verify func() (core.LogVerification, error),
+	repair func(d core.LogDamage) error,
+) (core.LogVerification, error) {
+	var (
+		repaired []core.LogDamage
+		pending  []core.LogDamage
+		tried    = make(map[cid.Cid]struct{})
+	)
+	for {
+		lv, err := verify()
+		if err != nil {
+			return lv, err
+		}
+		for _, d := range pending {
+			if !lv.HasDamage(d) {
+				d.Repaired = true
+				repaired = append(repaired, d)
+			}
+		}
+		pending = pending[:0]
+		for _, d := range lv.Damage {
+			if _, ok := tried[d.Record]; !ok {
+				pending = append(pending, d)
+			}
+		}
+		if len(pending) == 0 {
+			lv.Damage = append(repaired, lv.Damage...)
+			return lv, nil
+		}
+		for _, d := range pending {
+			tried[d.Record] = struct{}{}
+			if err = repair(d); err != nil {
+				return lv, err
+			}
+		}
+	}
+}
+
+// verifyRecord checks the signature of a record and the local availability of its blocks.
+// The record is nil if it can't be decoded, so its predecessors can't be reached.
+func verifyRecord(
+	bstore bs.Blockstore,
+	rid cid.Cid,
+	sk *sym.Key,
+	pk ic.PubKey,
+	withBody bool,
+) (core.Record, []core.LogDamage, error) {
+	node, kind, err := getLocalNode(bstore, rid)
+	if err != nil {
+		return nil, nil, err
+	} else if node == nil {
+		return nil, []core.LogDamage{{Kind: kind, Record: rid, Block: rid}}, nil
+	}
+	rec, err := cbor.RecordFromNode(node, sk)
+	if err != nil {
+		return nil, []core.LogDamage{{Kind: core.DamageBrokenLink, Record: rid, Block: rid}}, nil
+	}
+
+	var damage []core.LogDamage
+	if err = VerifyRecordSig(rec, pk); err != nil {
+		damage = append(damage, core.LogDamage{Kind: core.DamageBadSignature, Record: rid, Block: rid})
+	}
+	enode, kind, err := getLocalNode(bstore, rec.BlockID())
+	if err != nil {
+		return nil, nil, err
+	} else if enode == nil {
+		return rec, append(damage, core.LogDamage{Kind: kind, Record: rid, Block: rec.BlockID()}), nil
+	}
+	event, err := cbor.EventFromNode(enode)
+	if err != nil {
+		return rec, append(damage, core.LogDamage{Kind: core.DamageBrokenLink, Record: rid, Block: rec.BlockID()}), nil
+	}
+	ids := []cid.Cid{event.HeaderID()}
+	if withBody {
+		ids = append(ids, event.BodyID())
+	}
+	for _, id := range ids {
+		if known, err := bstore.Has(id); err != nil {
+			return nil, nil, err
+		} else if !known {
+			damage = append(damage, core.LogDamage{Kind: core.DamageMissingBlock, Record: rid, Block: id})
+		}
+	}
+	return rec, damage, nil
+}
+
+// VerifyRecordSig checks the record signature without loading the inner block.
+func VerifyRecordSig(rec core.Record, pk ic.PubKey) error {
+	r, ok := rec.(*cbor.Record)
+	if !ok {
+		return fmt.Errorf("unexpected record type %T", rec)
+	}
+	return r.VerifySig(pk)
+}
+
+// getLocalNode decodes a block of the blockstore. If the block is missing or can't be
+// decoded, the node is nil and the kind of damage is returned instead.
+func getLocalNode(bstore bs.Blockstore, id cid.Cid) (format.Node, core.DamageKind, error) {
+	if known, err := bstore.Has(id); err != nil {
+		return nil, 0, err
+	} else if !known {
+		return nil, core.DamageMissingBlock, nil
+	}
+	block, err := bstore.Get(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	node, err := cbornode.DecodeBlock(block)
+	if err != nil {
+		return nil, core.DamageBrokenLink, nil
+	}
+	return node, 0, nil
+}
@@ -0,0 +1,181 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/textileio/go-threads/net/util"
+)
+
+func (n *net) VerifyThread(
+	ctx context.Context,
+	id thread.ID,
+	opts ...core.ThreadOption,
+) (v core.ThreadVerification, err error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err = n.Validate(id, args.Token, !args.Repair); err != nil {
+		return
+	}
+	if err = n.loadThread(id); err != nil {
+		return
+	}
+	// blocks of archived threads are missing on purpose
+	if err = n.rehydrateThread(ctx, id); err != nil {
+		return
+	}
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return
+	}
+	sk := info.Key.Service()
+	if sk == nil {
+		return v, fmt.Errorf("a service-key is required to verify a thread")
+	}
+	v.ThreadID = id
+	for _, lg := range info.Logs {
+		if len(lg.Heads) == 0 {
+			continue
+		}
+		boundary, err := util.LogMarker(n.store, id, lg.ID, boundarySuffix)
+		if err != nil {
+			return v, err
+		}
+		var lv core.LogVerification
+		if args.Repair {
+			lv, err = n.repairLog(ctx, id, lg, sk, boundary)
+		} else {
+			lv, err = util.VerifyLog(ctx, n.bstore, lg, sk, boundary, nil)
+		}
+		if err != nil {
+			return v, fmt.Errorf("verifying log %s: %w", lg.ID, err)
+		}
+		v.Logs = append(v.Logs, lv)
+	}
+	sort.Slice(v.Logs, func(i, j int) bool {
+		return v.Logs[i].ID < v.Logs[j].ID
+	})
+	return v, nil
+}
+
+// repairLog verifies a log and re-fetches its damaged records from the thread peers, or
+// through the dag service if no peer returned them. Repairs may uncover damage behind broken
+// links, so the log is walked again until no new damage is found.
+func (n *net) repairLog(
+	ctx context.Context,
+	id thread.ID,
+	lg thread.LogInfo,
+	sk *sym.Key,
+	boundary cid.Cid,
+) (core.LogVerification, error) {
+	var fetched map[cid.Cid]core.Record
+	return util.RepairLog(func() (core.LogVerification, error) {
+		return util.VerifyLog(ctx, n.bstore, lg, sk, boundary, nil)
+	}, func(d core.LogDamage) (err error) {
+		if fetched == nil {
+			if fetched, err = n.fetchLogFromPeers(ctx, id, lg.ID, sk); err != nil {
+				return err
+			}
+		}
+		if err = n.repairRecord(ctx, id, d, fetched[d.Record], sk); err != nil {
+			log.Warnf("repairing record %s of log %s (thread=%s): %v", d.Record, lg.ID, id, err)
+		}
+		return nil
+	})
+}
+
+// repairRecord stores the blocks of a damaged record again. Damaged blocks are dropped first,
+// since stored blocks aren't overwritten.
+func (n *net) repairRecord(ctx context.Context, id thread.ID, d core.LogDamage, rec core.Record, sk *sym.Key) (err error) {
+	if d.Kind != core.DamageMissingBlock {
+		if err = n.bstore.DeleteBlock(d.Block); err != nil {
+			return err
+		}
+	}
+	if rec == nil {
+		if rec, err = n.fetchRecord(ctx, d.Record, sk); err != nil {
+			return err
+		}
+	}
+	return n.restoreRecord(ctx, id, rec)
+}
+
+// fetchLogFromPeers requests the latest records of a log from every thread peer, including
+// the records stored locally.
+func (n *net) fetchLogFromPeers(
+	ctx context.Context,
+	id thread.ID,
+	lid peer.ID,
+	sk *sym.Key,
+) (map[cid.Cid]core.Record, error) {
+	peers, err := n.server.threadPeers(id)
+	if err != nil {
+		return nil, err
+	}
+	req, _, err := n.server.buildGetRecordsRequest(id, map[peer.ID]cid.Cid{lid: cid.Undef}, n.syncConfig().MaxPullLimit)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.HeadersOnly = false
+
+	fetched := make(map[cid.Cid]core.Record)
+	for _, pid := range peers {
+		recs, err := n.server.getRecordsFromPeer(ctx, id, pid, req, sk)
+		if err != nil {
+			log.Warnf("getting records of log %s from %s: %v", lid, pid, err)
+			continue
+		}
+		for _, r := range recs[lid] {
+			fetched[r.Cid()] = r
+		}
+	}
+	return fetched, nil
+}
+
+// verifyRecords verifies the signatures of a chain of records received from a peer, oldest first.
+// With Config.CheckpointVerification, only records which aren't linked to by the next record are
+// checked, i.e., the newest record of every linear chain. Older records are committed to by the
+// signed prev links, down to the record the chain is connected to locally, which was verified
+// before. Extensions aren't covered by the links, so records carrying them are always checked.
+func (n *net) verifyRecords(ctx context.Context, recs []core.Record, pk ic.PubKey) error {
+	for i, rec := range recs {
+		if n.checkpointVerification && i+1 < len(recs) && recs[i+1].PrevID().Equals(rec.Cid()) {
+			linked, err := isLinkedRecord(ctx, n, rec)
+			if err != nil {
+				return err
+			} else if linked {
+				continue
+			}
+		}
+		if err := rec.Verify(pk); err != nil {
+			return fmt.Errorf("record %s: %w", rec.Cid(), err)
+		}
+	}
+	return nil
+}
+
+// isLinkedRecord returns whether a record is fully committed to by its ID, i.e., it carries
+// no extensions and its loaded event block is the one the record links to.
+func isLinkedRecord(ctx context.Context, dag format.DAGService, rec core.Record) (bool, error) {
+	if ext, ok := rec.(core.ExtendedRecord); ok && len(ext.Extensions()) > 0 {
+		return false, nil
+	}
+	block, err := rec.GetBlock(ctx, dag)
+	if err != nil {
+		return false, err
+	}
+	return block.Cid().Equals(rec.BlockID()), nil
+}
@@ -0,0 +1,504 @@
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	nnet "net"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/websocket"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	pb "github.com/textileio/go-threads/net/pb"
+	tu "github.com/textileio/go-threads/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WebSocket subprotocols of the browser adapter, see Config.WebSocketAddr. Frames of the JSON
+// variant are text messages with the service messages encoded as JSON, frames of the binary variant
+// are CBOR encoded binary messages with the service messages encoded as protobuf. JSON is used if
+// a client doesn't ask for a subprotocol.
+const (
+	WebSocketProtocolJSON   = "threads-json/1"
+	WebSocketProtocolBinary = "threads-binary/1"
+)
+
+var (
+	// WebSocketMaxMessageSize is the size limit of frames received from WebSocket peers.
+	WebSocketMaxMessageSize int64 = 32 << 20
+
+	// WebSocketAuthTimeout bounds the handshake of WebSocket peers.
+	WebSocketAuthTimeout = time.Second * 10
+
+	// WebSocketMaxInflight is the number of requests of a WebSocket peer handled at once,
+	// further frames aren't read until one of them completes.
+	WebSocketMaxInflight = 32
+)
+
+// wsAuthMethod is the method of the handshake frames. Peers prove their identity by
+// signing the nonce sent by the host, prefixed with wsAuthDomain.
+const (
+	wsAuthMethod = "auth"
+	wsAuthDomain = "threads-ws-auth:"
+)
+
+func init() {
+	cbornode.RegisterCborType(wsFrame{})
+}
+
+// wsFrame is a message exchanged with a WebSocket peer. Requests carry an ID chosen by the peer,
+// which is echoed by the reply, so the peer may have several requests in flight.
+type wsFrame struct {
+	ID     uint64 `json:"id,omitempty" refmt:"id,omitempty"`
+	Method string `json:"method,omitempty" refmt:"method,omitempty"`
+	Body   []byte `json:"-" refmt:"body,omitempty"`
+	Code   uint32 `json:"code,omitempty" refmt:"code,omitempty"`
+	Error  string `json:"error,omitempty" refmt:"error,omitempty"`
+	Nonce  []byte `json:"nonce,omitempty" refmt:"nonce,omitempty"`
+	PubKey []byte `json:"pubKey,omitempty" refmt:"pubKey,omitempty"`
+	Sig    []byte `json:"sig,omitempty" refmt:"sig,omitempty"`
+}
+
+// wsJSONFrame is a frame of the JSON variant, which embeds the message instead of its bytes.
+type wsJSONFrame struct {
+	wsFrame
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// wsCodec encodes frames and the service messages they carry.
+type wsCodec interface {
+	messageType() int
+	encodeFrame(f wsFrame) ([]byte, error)
+	decodeFrame(data []byte) (wsFrame, error)
+	encodeBody(msg proto.Message) ([]byte, error)
+	decodeBody(data []byte, msg proto.Message) error
+}
+
+var wsCodecs = map[string]wsCodec{
+	"":                      wsJSONCodec{},
+	WebSocketProtocolJSON:   wsJSONCodec{},
+	WebSocketProtocolBinary: wsBinaryCodec{},
+}
+
+type wsJSONCodec struct{}
+
+func (wsJSONCodec) messageType() int {
+	return websocket.TextMessage
+}
+
+func (wsJSONCodec) encodeFrame(f wsFrame) ([]byte, error) {
+	return json.Marshal(wsJSONFrame{wsFrame: f, Body: f.Body})
+}
+
+func (wsJSONCodec) decodeFrame(data []byte) (wsFrame, error) {
+	var f wsJSONFrame
+	if err := json.Unmarshal(data, &f); err != nil {
+		return wsFrame{}, err
+	}
+	f.wsFrame.Body = f.Body
+	return f.wsFrame, nil
+}
+
+func (wsJSONCodec) encodeBody(msg proto.Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (wsJSONCodec) decodeBody(data []byte, msg proto.Message) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, msg)
+}
+
+type wsBinaryCodec struct{}
+
+func (wsBinaryCodec) messageType() int {
+	return websocket.BinaryMessage
+}
+
+func (wsBinaryCodec) encodeFrame(f wsFrame) ([]byte, error) {
+	return cbornode.DumpObject(&f)
+}
+
+func (wsBinaryCodec) decodeFrame(data []byte) (wsFrame, error) {
+	var f wsFrame
+	err := cbornode.DecodeInto(data, &f)
+	return f, err
+}
+
+func (wsBinaryCodec) encodeBody(msg proto.Message) ([]byte, error) {
+	return proto.Marshal(msg)
+}
+
+func (wsBinaryCodec) decodeBody(data []byte, msg proto.Message) error {
+	return proto.Unmarshal(data, msg)
+}
+
+// wsMethods are the request types of the thread service methods WebSocket peers may call.
+// Only unary methods reading and pushing thread data are exposed. Streaming methods and the
+// ones handing keys, revocations or log ownership between trusted peers are left out.
+var wsMethods = map[string]reflect.Type{
+	"GetLogs":         reflect.TypeOf(pb.GetLogsRequest{}),
+	"PushLog":         reflect.TypeOf(pb.PushLogRequest{}),
+	"GetRecords":      reflect.TypeOf(pb.GetRecordsRequest{}),
+	"PushRecord":      reflect.TypeOf(pb.PushRecordRequest{}),
+	"PushRecords":     reflect.TypeOf(pb.PushRecordsRequest{}),
+	"ExchangeEdges":   reflect.TypeOf(pb.ExchangeEdgesRequest{}),
+	"GetRecordBodies": reflect.TypeOf(pb.GetRecordBodiesRequest{}),
+	"RedeemInvite":    reflect.TypeOf(pb.RedeemInviteRequest{}),
+	"GetCapabilities": reflect.TypeOf(pb.GetCapabilitiesRequest{}),
+	"Hello":           reflect.TypeOf(pb.HelloRequest{}),
+}
+
+// wsGateway exposes the thread service to peers without a libp2p host, e.g., browsers or wasm
+// builds, over WebSockets. Requests of authenticated peers are passed to the gRPC server of the
+// host over in-memory connections, so they're handled like requests of libp2p peers.
+// The host can't dial WebSocket peers, so they pull records rather than receive pushes.
+type wsGateway struct {
+	net      *net
+	server   *http.Server
+	listener nnet.Listener
+	pipes    *pipeListener
+	upgrader websocket.Upgrader
+
+	lk     sync.Mutex
+	conns  map[*websocket.Conn]struct{}
+	closed bool
+}
+
+// startWebSocket serves the thread service to WebSocket peers, see wsGateway.
+func (n *net) startWebSocket(addr ma.Multiaddr, tlsConf *tls.Config) error {
+	if n.rpc == nil {
+		return fmt.Errorf("an embedded host can't serve WebSocket peers")
+	}
+	target, err := tu.TCPAddrFromMultiAddr(addr)
+	if err != nil {
+		return err
+	}
+	listener, err := nnet.Listen("tcp", target)
+	if err != nil {
+		return err
+	}
+	if tlsConf != nil {
+		listener = tls.NewListener(listener, tlsConf)
+	}
+
+	g := &wsGateway{
+		net:      n,
+		listener: listener,
+		pipes:    newPipeListener(),
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{WebSocketProtocolJSON, WebSocketProtocolBinary},
+			// peers are authenticated with their keys rather than cookies, so pages of
+			// any origin may connect
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		conns: make(map[*websocket.Conn]struct{}),
+	}
+	g.server = &http.Server{Handler: g}
+	n.ws = g
+	go func() {
+		if err := n.rpc.Serve(g.pipes); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Errorf("WebSocket pipe serve error: %v", err)
+		}
+	}()
+	go func() {
+		if err := g.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("WebSocket serve error: %v", err)
+		}
+	}()
+	log.Infof("serving WebSocket peers on %s", listener.Addr())
+	return nil
+}
+
+// Close stops accepting WebSocket peers and disconnects the connected ones.
+func (g *wsGateway) Close() error {
+	g.lk.Lock()
+	g.closed = true
+	for c := range g.conns {
+		_ = c.Close()
+	}
+	g.lk.Unlock()
+	err := g.server.Close()
+	if perr := g.pipes.Close(); err == nil {
+		err = perr
+	}
+	return err
+}
+
+func (g *wsGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := g.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debugf("WebSocket upgrade from %s failed: %v", r.RemoteAddr, err)
+		return
+	}
+	g.lk.Lock()
+	if g.closed {
+		g.lk.Unlock()
+		_ = conn.Close()
+		return
+	}
+	g.conns[conn] = struct{}{}
+	g.lk.Unlock()
+	defer func() {
+		g.lk.Lock()
+		delete(g.conns, conn)
+		g.lk.Unlock()
+		_ = conn.Close()
+	}()
+
+	conn.SetReadLimit(WebSocketMaxMessageSize)
+	p := &wsPeer{conn: conn, codec: wsCodecs[conn.Subprotocol()]}
+	pid, err := g.authenticate(p)
+	if err != nil {
+		log.Debugf("WebSocket handshake with %s failed: %v", r.RemoteAddr, err)
+		_ = p.write(wsFrame{
+			Method: wsAuthMethod,
+			Code:   uint32(codes.Unauthenticated),
+			Error:  err.Error(),
+		})
+		return
+	}
+	log.Debugf("WebSocket peer %s connected from %s", pid, r.RemoteAddr)
+
+	ctx, cancel := context.WithCancel(g.net.ctx)
+	defer cancel()
+	cc, err := grpc.DialContext(ctx, pid.String(),
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (nnet.Conn, error) {
+			return g.pipes.dial(ctx, pid)
+		}))
+	if err != nil {
+		log.Errorf("connecting WebSocket peer %s: %v", pid, err)
+		return
+	}
+	defer cc.Close()
+	p.client = reflect.ValueOf(pb.NewServiceClient(cc))
+	p.serve(ctx)
+}
+
+// authenticate makes the peer sign a nonce with its private key, and returns its ID.
+func (g *wsGateway) authenticate(p *wsPeer) (peer.ID, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	hostKey, err := crypto.MarshalPublicKey(g.net.getPrivKey().GetPublic())
+	if err != nil {
+		return "", err
+	}
+	if err = p.write(wsFrame{Method: wsAuthMethod, Nonce: nonce, PubKey: hostKey}); err != nil {
+		return "", err
+	}
+
+	if err = p.conn.SetReadDeadline(time.Now().Add(WebSocketAuthTimeout)); err != nil {
+		return "", err
+	}
+	f, err := p.read()
+	if err != nil {
+		return "", err
+	}
+	if err = p.conn.SetReadDeadline(time.Time{}); err != nil {
+		return "", err
+	}
+	if f.Method != wsAuthMethod || len(f.PubKey) == 0 || len(f.Sig) == 0 {
+		return "", fmt.Errorf("expected signed nonce")
+	}
+	pk, err := crypto.UnmarshalPublicKey(f.PubKey)
+	if err != nil {
+		return "", err
+	}
+	if ok, err := pk.Verify(append([]byte(wsAuthDomain), nonce...), f.Sig); err != nil || !ok {
+		return "", fmt.Errorf("bad nonce signature")
+	}
+	pid, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		return "", err
+	}
+	if pid == g.net.host.ID() {
+		return "", fmt.Errorf("peer has the host identity")
+	}
+	if g.net.connGater != nil && !g.net.connGater.InterceptSecured(network.DirInbound, pid, nil) {
+		return "", fmt.Errorf("peer %s refused by the gater", pid)
+	}
+	if err = g.net.host.Peerstore().AddPubKey(pid, pk); err != nil {
+		return "", err
+	}
+	if err = p.write(wsFrame{Method: wsAuthMethod}); err != nil {
+		return "", err
+	}
+	return pid, nil
+}
+
+// wsPeer is an authenticated WebSocket connection.
+type wsPeer struct {
+	conn   *websocket.Conn
+	codec  wsCodec
+	client reflect.Value
+	lk     sync.Mutex
+}
+
+// serve handles requests of the peer until the connection or the context is closed.
+func (p *wsPeer) serve(ctx context.Context) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, WebSocketMaxInflight)
+	)
+	defer wg.Wait()
+	for {
+		f, err := p.read()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debugf("reading WebSocket frame: %v", err)
+			}
+			return
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("WebSocket request %q panicked: %v", f.Method, r)
+					_ = p.write(wsFrame{ID: f.ID, Code: uint32(codes.Internal), Error: "internal error"})
+				}
+			}()
+			reply := p.call(ctx, f)
+			reply.ID = f.ID
+			if err := p.write(reply); err != nil {
+				log.Debugf("writing WebSocket frame: %v", err)
+			}
+		}()
+	}
+}
+
+// call passes a request to the thread service.
+func (p *wsPeer) call(ctx context.Context, f wsFrame) wsFrame {
+	reqType, ok := wsMethods[f.Method]
+	if !ok {
+		return wsErrorFrame(status.Errorf(codes.Unimplemented, "unknown method %q", f.Method))
+	}
+	req := reflect.New(reqType)
+	if err := p.codec.decodeBody(f.Body, req.Interface().(proto.Message)); err != nil {
+		return wsErrorFrame(status.Error(codes.InvalidArgument, err.Error()))
+	}
+	out := p.client.MethodByName(f.Method).Call([]reflect.Value{reflect.ValueOf(ctx), req})
+	if err, _ := out[1].Interface().(error); err != nil {
+		return wsErrorFrame(err)
+	}
+	body, err := p.codec.encodeBody(out[0].Interface().(proto.Message))
+	if err != nil {
+		return wsErrorFrame(status.Error(codes.Internal, err.Error()))
+	}
+	return wsFrame{Body: body}
+}
+
+func (p *wsPeer) read() (wsFrame, error) {
+	typ, data, err := p.conn.ReadMessage()
+	if err != nil {
+		return wsFrame{}, err
+	}
+	if typ != p.codec.messageType() {
+		return wsFrame{}, fmt.Errorf("unexpected frame type %d", typ)
+	}
+	return p.codec.decodeFrame(data)
+}
+
+func (p *wsPeer) write(f wsFrame) error {
+	data, err := p.codec.encodeFrame(f)
+	if err != nil {
+		return err
+	}
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	return p.conn.WriteMessage(p.codec.messageType(), data)
+}
+
+func wsErrorFrame(err error) wsFrame {
+	st := status.Convert(err)
+	return wsFrame{Code: uint32(st.Code()), Error: st.Message()}
+}
+
+// pipeListener accepts in-memory connections, which report the dialing peer as their remote
+// address, so peerIDFromContext identifies it like the peers connected over libp2p.
+type pipeListener struct {
+	conns chan nnet.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan nnet.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) dial(ctx context.Context, pid peer.ID) (nnet.Conn, error) {
+	local, remote := nnet.Pipe()
+	select {
+	case l.conns <- &pipeConn{Conn: remote, pid: pid}:
+		return local, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, fmt.Errorf("listener closed")
+	}
+}
+
+func (l *pipeListener) Accept() (nnet.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, fmt.Errorf("listener closed")
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() nnet.Addr {
+	return pipeAddr("")
+}
+
+type pipeConn struct {
+	nnet.Conn
+	pid peer.ID
+}
+
+func (c *pipeConn) RemoteAddr() nnet.Addr {
+	return pipeAddr(c.pid)
+}
+
+type pipeAddr peer.ID
+
+func (a pipeAddr) Network() string {
+	return "pipe"
+}
+
+func (a pipeAddr) String() string {
+	return peer.ID(a).String()
+}
@@ -0,0 +1,154 @@
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	ma "github.com/multiformats/go-multiaddr"
+	tstore "github.com/textileio/go-threads/logstore/lstoremem"
+	pb "github.com/textileio/go-threads/net/pb"
+	"google.golang.org/grpc/codes"
+)
+
+func TestWebSocket(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		WebSocketAddr: ma.StringCast("/ip4/127.0.0.1/tcp/0"),
+	}).(*net)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+	url := "ws://" + n.ws.listener.Addr().String()
+
+	for _, proto := range []string{WebSocketProtocolJSON, WebSocketProtocolBinary} {
+		t.Run(proto, func(t *testing.T) {
+			conn, _, err := (&websocket.Dialer{Subprotocols: []string{proto}}).Dial(url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			p := &wsPeer{conn: conn, codec: wsCodecs[conn.Subprotocol()]}
+			if conn.Subprotocol() != proto {
+				t.Fatalf("expected subprotocol %s, got %s", proto, conn.Subprotocol())
+			}
+
+			// sign the nonce of the host
+			hello, err := p.read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hello.Method != wsAuthMethod || len(hello.Nonce) == 0 {
+				t.Fatalf("expected nonce, got %+v", hello)
+			}
+			sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := sk.Sign(append([]byte(wsAuthDomain), hello.Nonce...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rawPk, err := crypto.MarshalPublicKey(pk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = p.write(wsFrame{Method: wsAuthMethod, PubKey: rawPk, Sig: sig}); err != nil {
+				t.Fatal(err)
+			}
+			if f, err := p.read(); err != nil {
+				t.Fatal(err)
+			} else if f.Code != 0 {
+				t.Fatalf("expected handshake to succeed, got %s", f.Error)
+			}
+
+			body, err := p.codec.encodeBody(&pb.GetLogsRequest{
+				Body: &pb.GetLogsRequest_Body{
+					ThreadID:   &pb.ProtoThreadID{ID: info.ID},
+					ServiceKey: &pb.ProtoKey{Key: info.Key.Service()},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = p.write(wsFrame{ID: 1, Method: "GetLogs", Body: body}); err != nil {
+				t.Fatal(err)
+			}
+			if err = p.write(wsFrame{ID: 2, Method: "NoSuchMethod"}); err != nil {
+				t.Fatal(err)
+			}
+			// streaming and key handling methods aren't exposed to browsers
+			if err = p.write(wsFrame{ID: 3, Method: "Subscribe"}); err != nil {
+				t.Fatal(err)
+			}
+			if err = p.write(wsFrame{ID: 4, Method: "PutKeyShare"}); err != nil {
+				t.Fatal(err)
+			}
+			// requests are handled concurrently, so replies may arrive in any order
+			for i := 0; i < 4; i++ {
+				f, err := p.read()
+				if err != nil {
+					t.Fatal(err)
+				}
+				switch f.ID {
+				case 1:
+					if f.Code != 0 {
+						t.Fatalf("expected logs, got %s", f.Error)
+					}
+					reply := &pb.GetLogsReply{}
+					if err = p.codec.decodeBody(f.Body, reply); err != nil {
+						t.Fatal(err)
+					}
+					if len(reply.Logs) != 1 || reply.Logs[0].ID.ID != info.Logs[0].ID {
+						t.Fatalf("expected log %s, got %+v", info.Logs[0].ID, reply.Logs)
+					}
+				case 2, 3, 4:
+					if codes.Code(f.Code) != codes.Unimplemented {
+						t.Fatalf("expected method of request %d to be unimplemented, got %d", f.ID, f.Code)
+					}
+				default:
+					t.Fatalf("unexpected reply %d", f.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocket_BadSignature(t *testing.T) {
+	t.Parallel()
+	n := makeNetworkWithConfig(t, tstore.NewLogstore(), Config{
+		WebSocketAddr: ma.StringCast("/ip4/127.0.0.1/tcp/0"),
+	}).(*net)
+	defer n.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+n.ws.listener.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := &wsPeer{conn: conn, codec: wsCodecs[conn.Subprotocol()]}
+	if _, err = p.read(); err != nil {
+		t.Fatal(err)
+	}
+	_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPk, err := crypto.MarshalPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.write(wsFrame{Method: wsAuthMethod, PubKey: rawPk, Sig: []byte("forged")}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := p.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codes.Code(f.Code) != codes.Unauthenticated {
+		t.Fatalf("expected forged signature to be refused, got %+v", f)
+	}
+}
@@ -0,0 +1,242 @@
+package net
+
+import (
+	"context"
+	"errors"
+
+	blocks "github.com/ipfs/go-block-format"
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	bs "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	format "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/cbor"
+	core "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/net/util"
+)
+
+var withheldPrefix = ds.NewKey("/withheld")
+
+// withheld keeps the bodies of records restricted by thread ACLs apart from the blockstore,
+// which serves any block to peers asking for it over bitswap, so they're only served by
+// GetRecordBodies to the readers of the ACL.
+type withheld struct {
+	shared bs.Blockstore
+	bstore bs.Blockstore
+	dag    format.DAGService
+}
+
+func newWithheld(store ds.Datastore, shared bs.Blockstore) *withheld {
+	wbs := bs.NewBlockstore(namespace.Wrap(store, withheldPrefix))
+	return &withheld{
+		shared: shared,
+		bstore: wbs,
+		dag:    dag.NewDAGService(bserv.New(wbs, offline.Exchange(wbs))),
+	}
+}
+
+// withhold moves blocks from the shared blockstore to the withheld one.
+func (w *withheld) withhold(ids []cid.Cid) error {
+	for _, id := range ids {
+		block, err := w.shared.Get(id)
+		if errors.Is(err, bs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err = w.bstore.Put(block); err != nil {
+			return err
+		}
+		if err = w.shared.DeleteBlock(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withheldDAG reads withheld blocks along with the blocks of the wrapped dag service.
+// Blocks are added to the wrapped dag service, and withheld once their records are stored.
+type withheldDAG struct {
+	format.DAGService
+	w *withheld
+}
+
+func (d *withheldDAG) Get(ctx context.Context, id cid.Cid) (format.Node, error) {
+	if node, err := d.w.dag.Get(ctx, id); err == nil {
+		return node, nil
+	}
+	return d.DAGService.Get(ctx, id)
+}
+
+func (d *withheldDAG) GetMany(ctx context.Context, ids []cid.Cid) <-chan *format.NodeOption {
+	var missing []cid.Cid
+	out := make(chan *format.NodeOption, len(ids))
+	for _, id := range ids {
+		if node, err := d.w.dag.Get(ctx, id); err == nil {
+			out <- &format.NodeOption{Node: node}
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		close(out)
+		return out
+	}
+	go func() {
+		defer close(out)
+		for opt := range d.DAGService.GetMany(ctx, missing) {
+			select {
+			case out <- opt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (d *withheldDAG) Remove(ctx context.Context, id cid.Cid) error {
+	if has, _ := d.w.bstore.Has(id); has {
+		return d.w.dag.Remove(ctx, id)
+	}
+	return d.DAGService.Remove(ctx, id)
+}
+
+func (d *withheldDAG) RemoveMany(ctx context.Context, ids []cid.Cid) error {
+	var held, shared []cid.Cid
+	for _, id := range ids {
+		if has, _ := d.w.bstore.Has(id); has {
+			held = append(held, id)
+		} else {
+			shared = append(shared, id)
+		}
+	}
+	if len(held) > 0 {
+		if err := d.w.dag.RemoveMany(ctx, held); err != nil {
+			return err
+		}
+	}
+	if len(shared) > 0 {
+		return d.DAGService.RemoveMany(ctx, shared)
+	}
+	return nil
+}
+
+// withheldBlockstore reads withheld blocks along with the blocks of the wrapped blockstore.
+// Keys of both are listed, so withheld blocks are collected by GC as well.
+type withheldBlockstore struct {
+	bs.Blockstore
+	w *withheld
+}
+
+func (b *withheldBlockstore) Has(id cid.Cid) (bool, error) {
+	if has, err := b.w.bstore.Has(id); err != nil || has {
+		return has, err
+	}
+	return b.Blockstore.Has(id)
+}
+
+func (b *withheldBlockstore) Get(id cid.Cid) (blocks.Block, error) {
+	if block, err := b.w.bstore.Get(id); err == nil {
+		return block, nil
+	}
+	return b.Blockstore.Get(id)
+}
+
+func (b *withheldBlockstore) GetSize(id cid.Cid) (int, error) {
+	if size, err := b.w.bstore.GetSize(id); err == nil {
+		return size, nil
+	}
+	return b.Blockstore.GetSize(id)
+}
+
+func (b *withheldBlockstore) DeleteBlock(id cid.Cid) error {
+	if has, _ := b.w.bstore.Has(id); has {
+		return b.w.bstore.DeleteBlock(id)
+	}
+	return b.Blockstore.DeleteBlock(id)
+}
+
+func (b *withheldBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	shared, err := b.Blockstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	held, err := b.w.bstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cid.Cid)
+	go func() {
+		defer close(out)
+		for _, keys := range []<-chan cid.Cid{shared, held} {
+			for id := range keys {
+				select {
+				case out <- id:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// withholdBodies withholds the bodies of records added to a log, along with their chunks,
+// if the thread ACL restricts them. Bodies are stored before their records, so they are
+// withheld once the records are added.
+func (n *net) withholdBodies(ctx context.Context, tid thread.ID, lid peer.ID, recs []core.Record) error {
+	if n.isEphemeral(tid) {
+		return nil // kept in memory, which isn't served over bitswap
+	}
+	acl, err := n.threadACL(tid)
+	if err != nil || len(acl.RestrictedLogs)+len(acl.RestrictedRecords) == 0 {
+		return err
+	}
+	for _, rec := range recs {
+		if isRestrictedRecord(rec) || !acl.Restricts(lid, rec.Cid()) {
+			continue
+		}
+		ev, err := cbor.EventFromRecord(ctx, n, rec)
+		if err != nil {
+			return err
+		}
+		if err = n.withheld.withhold(append([]cid.Cid{ev.BodyID()}, util.LocalBodyChunks(n.bstore, ev.BodyID())...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withholdThread withholds the bodies of stored records of a thread its ACL restricts.
+func (n *net) withholdThread(ctx context.Context, tid thread.ID, acl core.ThreadACL) error {
+	if n.isEphemeral(tid) || len(acl.RestrictedLogs)+len(acl.RestrictedRecords) == 0 {
+		return nil
+	}
+	ts, err := n.lockThread(tid)
+	if err != nil {
+		return err
+	}
+	defer ts.Release()
+
+	var (
+		visited = make(map[cid.Cid]struct{})
+		werr    error
+	)
+	err = n.walkLogs(ctx, tid, visited, func(lid peer.ID, rid cid.Cid, ev *cbor.Event) {
+		visited[rid] = struct{}{}
+		if werr != nil || !acl.Restricts(lid, rid) {
+			return
+		}
+		werr = n.withheld.withhold(append([]cid.Cid{ev.BodyID()}, util.LocalBodyChunks(n.bstore, ev.BodyID())...))
+	})
+	if err != nil {
+		return err
+	}
+	return werr
+}
@@ -0,0 +1,55 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// writerKey is the metadata key of the only log which may contain records of a single-writer thread.
+const writerKey = "/writer"
+
+// ErrNotThreadWriter indicates a log other than the writer of a single-writer thread.
+var ErrNotThreadWriter = errors.New("log is not the thread writer")
+
+// threadWriter returns the writer log of a single-writer thread, or an empty ID for other threads.
+func (n *net) threadWriter(tid thread.ID) (peer.ID, error) {
+	data, err := n.store.GetBytes(tid, writerKey)
+	if err != nil || data == nil {
+		return "", err
+	}
+	return peer.IDFromBytes(*data)
+}
+
+// setThreadWriter declares the writer log of a thread. The writer can't be changed once set.
+func (n *net) setThreadWriter(tid thread.ID, lid peer.ID) error {
+	writer, err := n.threadWriter(tid)
+	if err != nil {
+		return err
+	}
+	if writer != "" {
+		if writer != lid {
+			return fmt.Errorf("thread %s already has writer %s", tid, writer)
+		}
+		return nil
+	}
+	data, err := lid.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return n.store.PutBytes(tid, writerKey, data)
+}
+
+// checkThreadWriter returns an error if the thread is single-writer and the log isn't its writer.
+func (n *net) checkThreadWriter(tid thread.ID, lid peer.ID) error {
+	writer, err := n.threadWriter(tid)
+	if err != nil {
+		return err
+	}
+	if writer != "" && writer != lid {
+		return fmt.Errorf("log %s: %w", lid, ErrNotThreadWriter)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"sort"
 	"testing"
 	"time"
 
@@ -387,6 +388,33 @@ func testThreadsFromAddrs(ab core.AddrBook) func(t *testing.T) {
 				t.Fatalf("expected to find %d threads without errors, got %d with err: %v", len(tids), len(threads), err)
 			}
 		})
+
+		t.Run("paged addrbook", func(t *testing.T) {
+			threads, err := ab.ThreadsFromAddrs()
+			check(t, err)
+			sort.Slice(threads, func(i, j int) bool { return core.ThreadIndexLess(threads[i], threads[j]) })
+
+			var paged thread.IDSlice
+			for cursor := thread.Undef; ; {
+				page, err := ab.ThreadsFromAddrsAfter(cursor, 2)
+				check(t, err)
+				if len(page) == 0 {
+					break
+				} else if len(page) > 2 {
+					t.Fatalf("expected page of at most 2 threads, got %d", len(page))
+				}
+				paged = append(paged, page...)
+				cursor = page[len(page)-1]
+			}
+			if len(paged) != len(threads) {
+				t.Fatalf("expected %d paged threads, got %d", len(threads), len(paged))
+			}
+			for i := range threads {
+				if paged[i] != threads[i] {
+					t.Fatalf("thread %d: expected %s, got %s", i, threads[i], paged[i])
+				}
+			}
+		})
 	}
 }
 
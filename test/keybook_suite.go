@@ -387,6 +387,28 @@ func testKeyBookThreads(kb core.KeyBook) func(t *testing.T) {
 				t.Errorf("%s not found in store list", kbid.String())
 			}
 		}
+
+		sort.Slice(kbThreads, func(i, j int) bool { return core.ThreadIndexLess(kbThreads[i], kbThreads[j]) })
+		var paged thread.IDSlice
+		for cursor := thread.Undef; ; {
+			page, err := kb.ThreadsFromKeysAfter(cursor, 2)
+			if err != nil {
+				t.Fatalf("error when paging threads from keys: %v", err)
+			}
+			if len(page) == 0 {
+				break
+			}
+			paged = append(paged, page...)
+			cursor = page[len(page)-1]
+		}
+		if len(paged) != len(kbThreads) {
+			t.Fatalf("expected %d paged threads, got %d", len(kbThreads), len(paged))
+		}
+		for i := range kbThreads {
+			if paged[i] != kbThreads[i] {
+				t.Errorf("thread %d: expected %s, got %s", i, kbThreads[i], paged[i])
+			}
+		}
 	}
 }
 
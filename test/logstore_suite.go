@@ -2,8 +2,10 @@ package test
 
 import (
 	"context"
+	crand "crypto/rand"
 	"fmt"
 	"math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -21,6 +23,7 @@ var threadstoreSuite = map[string]func(core.Logstore) func(*testing.T){
 	"GetStreamBeforeLogAdded": testGetStreamBeforeLogAdded,
 	"AddStreamDuplicates":     testAddrStreamDuplicates,
 	"BasicLogstore":           testBasicLogstore,
+	"ThreadsAfter":            testThreadsAfter,
 	"Metadata":                testMetadata,
 }
 
@@ -311,6 +314,48 @@ func testBasicLogstore(ls core.Logstore) func(t *testing.T) {
 	}
 }
 
+func testThreadsAfter(ls core.Logstore) func(t *testing.T) {
+	return func(t *testing.T) {
+		var tids thread.IDSlice
+		for i := 0; i < 25; i++ {
+			tid := thread.NewIDV1(thread.Raw, 24)
+			tids = append(tids, tid)
+			_, pub, err := crypto.GenerateEd25519Key(crand.Reader)
+			check(t, err)
+			p, err := peer.IDFromPublicKey(pub)
+			check(t, err)
+			check(t, ls.AddPubKey(tid, p, pub))
+		}
+		sort.Slice(tids, func(i, j int) bool { return core.ThreadIndexLess(tids[i], tids[j]) })
+
+		var (
+			paged  thread.IDSlice
+			cursor = thread.Undef
+		)
+		for {
+			page, err := ls.ThreadsAfter(cursor, 10)
+			check(t, err)
+			if len(page) > 10 {
+				t.Fatalf("expected page of at most 10 threads, got %d", len(page))
+			}
+			if len(page) == 0 {
+				break
+			}
+			paged = append(paged, page...)
+			cursor = page[len(page)-1]
+		}
+
+		if len(paged) != len(tids) {
+			t.Fatalf("expected %d threads, got %d", len(tids), len(paged))
+		}
+		for i := range tids {
+			if paged[i] != tids[i] {
+				t.Fatalf("thread %d: expected %s, got %s", i, tids[i], paged[i])
+			}
+		}
+	}
+}
+
 func testLogstoreManaged(ls core.Logstore) func(t *testing.T) {
 	return func(t *testing.T) {
 		tid := thread.NewIDV1(thread.Raw, 24)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/namsral/flag"
+	"github.com/textileio/go-threads/net/bench"
+)
+
+var log = logging.Logger("threadsbench")
+
+func main() {
+	fs := flag.NewFlagSetWithEnvPrefix(os.Args[0], "THRDS_BENCH", 0)
+
+	nodes := fs.Int("nodes", 2, "Number of hosts")
+	threads := fs.Int("threads", 1, "Number of threads replicated by every host")
+	writers := fs.Int("writers", 1, "Number of hosts writing to every thread")
+	records := fs.Int("records", 100, "Number of records every writer creates in every thread")
+	rate := fs.Float64("rate", 0, "Records per second every writer creates in every thread (0 is unthrottled)")
+	recordSize := fs.Int("recordSize", 1024, "Size of the record body payload in bytes")
+	enableNetPubsub := fs.Bool("enableNetPubsub", false, "Enables thread networking over libp2p pubsub")
+	timeout := fs.Duration("timeout", time.Minute, "Wait for records to sync after the last write")
+	dir := fs.String("dir", "", "Parent directory of the host repos (defaults to the system temp directory)")
+	debug := fs.Bool("debug", false, "Enables debug logging")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+	if *debug {
+		if err := logging.SetLogLevel("netbench", "debug"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt)
+		<-quit
+		fmt.Println("Stopping benchmark...")
+		cancel()
+	}()
+
+	res, err := bench.Run(ctx, bench.Config{
+		Nodes:      *nodes,
+		Threads:    *threads,
+		Writers:    *writers,
+		Records:    *records,
+		Rate:       *rate,
+		RecordSize: *recordSize,
+		PubSub:     *enableNetPubsub,
+		Timeout:    *timeout,
+		Dir:        *dir,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(res)
+	if res.Deliveries < res.Expected {
+		os.Exit(1)
+	}
+}
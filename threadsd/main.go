@@ -152,7 +152,10 @@ func main() {
 		log.Fatal(err)
 	}
 
-	server := grpc.NewServer()
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(netapi.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(netapi.StreamServerInterceptor()),
+	)
 	listener, err := net.Listen("tcp", target)
 	if err != nil {
 		log.Fatal(err)
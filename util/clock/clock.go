@@ -0,0 +1,238 @@
+// Package clock abstracts time for the timing loops of the network, so tests can
+// advance it deterministically with a Mock instead of waiting for real intervals.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer creates a timer sending the current time on its channel after the duration.
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker creates a ticker sending the current time on its channel every period.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single event, see time.Timer.
+type Timer interface {
+	// Chan returns the channel on which the time is delivered.
+	Chan() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer
+	// has already expired or been stopped.
+	Stop() bool
+
+	// Reset changes the timer to expire after the duration. It returns true
+	// if the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker delivers ticks at intervals, see time.Ticker.
+type Ticker interface {
+	// Chan returns the channel on which the ticks are delivered.
+	Chan() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// New returns a clock backed by the time package.
+func New() Clock {
+	return realClock{}
+}
+
+// OrNew returns the clock, or a new real clock if it's nil.
+func OrNew(c Clock) Clock {
+	if c == nil {
+		return New()
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) Chan() <-chan time.Time { return t.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) Chan() <-chan time.Time { return t.C }
+
+// Mock is a clock which only moves when told to. Timers and tickers fire in
+// order of their deadlines while the clock is advanced. Like with real tickers,
+// ticks are dropped if the receiver isn't keeping up.
+type Mock struct {
+	mx      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+var (
+	_ Clock  = (*Mock)(nil)
+	_ Timer  = (*waiter)(nil)
+	_ Ticker = mockTicker{}
+)
+
+// NewMock returns a mock clock set to the given time.
+func NewMock(now time.Time) *Mock {
+	m := &Mock{now: now}
+	m.cond = sync.NewCond(&m.mx)
+	return m
+}
+
+// Now returns the current time of the mock clock.
+func (m *Mock) Now() time.Time {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.now
+}
+
+// Add advances the clock by the duration, firing timers and tickers which expire meanwhile.
+func (m *Mock) Add(d time.Duration) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.advance(m.now.Add(d))
+}
+
+// Set advances the clock to the given time. The clock never goes backwards.
+func (m *Mock) Set(t time.Time) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if t.After(m.now) {
+		m.advance(t)
+	}
+}
+
+// BlockUntil waits until at least n timers and tickers are active, e.g., until
+// a loop under test is waiting for the clock before advancing it.
+func (m *Mock) BlockUntil(n int) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for len(m.waiters) < n {
+		m.cond.Wait()
+	}
+}
+
+// Waiters returns the number of active timers and tickers.
+func (m *Mock) Waiters() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.waiters)
+}
+
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	return m.NewTimer(d).Chan()
+}
+
+func (m *Mock) NewTimer(d time.Duration) Timer {
+	w := &waiter{mock: m, c: make(chan time.Time, 1)}
+	w.Reset(d)
+	return w
+}
+
+func (m *Mock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	w := &waiter{mock: m, c: make(chan time.Time, 1), period: d}
+	w.Reset(d)
+	return mockTicker{w}
+}
+
+// advance fires the expired waiters up to end. It assumes the lock is held.
+func (m *Mock) advance(end time.Time) {
+	for len(m.waiters) > 0 && !m.waiters[0].when.After(end) {
+		w := m.waiters[0]
+		m.now = w.when
+		select {
+		case w.c <- m.now:
+		default:
+		}
+		if w.period > 0 {
+			w.when = w.when.Add(w.period)
+			m.sort()
+		} else {
+			m.remove(w)
+		}
+	}
+	m.now = end
+}
+
+func (m *Mock) sort() {
+	sort.SliceStable(m.waiters, func(i, j int) bool {
+		return m.waiters[i].when.Before(m.waiters[j].when)
+	})
+}
+
+// remove deactivates a waiter, and returns whether it was active.
+func (m *Mock) remove(w *waiter) bool {
+	for i, x := range m.waiters {
+		if x == w {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			m.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// waiter implements timers and tickers of the mock clock.
+type waiter struct {
+	mock   *Mock
+	when   time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+func (w *waiter) Chan() <-chan time.Time {
+	return w.c
+}
+
+func (w *waiter) Stop() bool {
+	w.mock.mx.Lock()
+	defer w.mock.mx.Unlock()
+	return w.mock.remove(w)
+}
+
+func (w *waiter) Reset(d time.Duration) bool {
+	m := w.mock
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	active := m.remove(w)
+	w.when = m.now.Add(d)
+	if d <= 0 && w.period == 0 {
+		// fires right away, like a real timer
+		select {
+		case w.c <- m.now:
+		default:
+		}
+		return active
+	}
+	m.waiters = append(m.waiters, w)
+	m.sort()
+	m.cond.Broadcast()
+	return active
+}
+
+type mockTicker struct{ *waiter }
+
+func (t mockTicker) Stop() {
+	t.waiter.Stop()
+}
@@ -0,0 +1,93 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMock_Timer(t *testing.T) {
+	m := NewMock(time.Unix(0, 0))
+	timer := m.NewTimer(time.Second)
+
+	m.Add(time.Second - 1)
+	select {
+	case <-timer.Chan():
+		t.Fatal("timer fired too early")
+	default:
+	}
+	m.Add(1)
+	select {
+	case now := <-timer.Chan():
+		if !now.Equal(time.Unix(1, 0)) {
+			t.Fatalf("expected timer to fire at 1s, got %s", now)
+		}
+	default:
+		t.Fatal("expected timer to fire")
+	}
+	if timer.Stop() {
+		t.Fatal("expected expired timer to be inactive")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Fatal("expected expired timer to be inactive")
+	}
+	if !timer.Stop() {
+		t.Fatal("expected reset timer to be active")
+	}
+	m.Add(time.Hour)
+	select {
+	case <-timer.Chan():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
+
+func TestMock_Ticker(t *testing.T) {
+	m := NewMock(time.Unix(0, 0))
+	ticker := m.NewTicker(time.Second)
+	defer ticker.Stop()
+	timer := m.NewTimer(1500 * time.Millisecond)
+
+	var ticks []time.Time
+	for i := 0; i < 3; i++ {
+		m.Add(time.Second)
+		ticks = append(ticks, <-ticker.Chan())
+	}
+	for i, tick := range ticks {
+		if !tick.Equal(time.Unix(int64(i+1), 0)) {
+			t.Fatalf("expected tick %d at %ds, got %s", i, i+1, tick)
+		}
+	}
+	if now := <-timer.Chan(); !now.Equal(time.Unix(1, 5e8)) {
+		t.Fatalf("expected timer to fire at 1.5s, got %s", now)
+	}
+
+	// ticks are dropped if the receiver isn't keeping up
+	m.Add(10 * time.Second)
+	<-ticker.Chan()
+	select {
+	case <-ticker.Chan():
+		t.Fatal("expected a single buffered tick")
+	default:
+	}
+}
+
+func TestMock_BlockUntil(t *testing.T) {
+	m := NewMock(time.Unix(0, 0))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-m.After(time.Minute)
+	}()
+
+	m.BlockUntil(1)
+	m.Add(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected waiter to be released")
+	}
+	if m.Waiters() != 0 {
+		t.Fatalf("expected no waiters, got %d", m.Waiters())
+	}
+}
@@ -0,0 +1,249 @@
+// Package encds provides a datastore encrypting values at rest.
+package encds
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// sealedHeader marks encrypted values, so values written before encryption was enabled
+// are told apart, see WithPlaintextMigration.
+var sealedHeader = []byte{0x00, 'e', 'n', 'c', 0x01}
+
+// overhead is the number of bytes added to values by encryption, i.e., the header
+// followed by the GCM nonce and tag.
+var overhead = len(sealedHeader) + sym.NonceBytes + 16
+
+// SaltBytes is the length of salts of passphrase derived keys.
+const SaltBytes = 16
+
+// DeriveKey returns a key for encrypting values at rest derived from a local secret,
+// e.g., the host private key, so the key itself is never written to the datastore.
+func DeriveKey(secret []byte, info string) (*sym.Key, error) {
+	raw := make([]byte, sym.KeyBytes)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(info)), raw); err != nil {
+		return nil, err
+	}
+	return sym.FromBytes(raw)
+}
+
+// PassphraseKey returns a key for encrypting values at rest derived from an operator
+// passphrase with scrypt. The salt should be random and unique to the repository.
+func PassphraseKey(passphrase string, salt []byte) (*sym.Key, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	raw, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, sym.KeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return sym.FromBytes(raw)
+}
+
+// Datastore encrypts the values of keys under a prefix with a symmetric key, and
+// passes other keys through. Keys themselves are stored in the clear, and authenticated
+// along with the values, so a value moved to another key fails to decrypt.
+type Datastore struct {
+	child     ds.Batching
+	prefix    ds.Key
+	key       *sym.Key
+	plaintext bool
+}
+
+var _ ds.Batching = (*Datastore)(nil)
+
+// Option configures a Datastore.
+type Option func(*Datastore)
+
+// WithPlaintextMigration reads values written to the child in the clear, before
+// encryption was enabled, as is. They're encrypted once written again. It's meant
+// for migrating existing repositories only, since values planted in the clear are
+// trusted as well.
+func WithPlaintextMigration(enabled bool) Option {
+	return func(d *Datastore) {
+		d.plaintext = enabled
+	}
+}
+
+// Wrap returns a datastore encrypting values under prefix written to child.
+// Reading values stored in the clear fails, unless WithPlaintextMigration is set.
+func Wrap(child ds.Batching, prefix ds.Key, key *sym.Key, opts ...Option) *Datastore {
+	d := &Datastore{child: child, prefix: prefix, key: key}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *Datastore) Put(key ds.Key, value []byte) error {
+	value, err := d.seal(key, value)
+	if err != nil {
+		return err
+	}
+	return d.child.Put(key, value)
+}
+
+func (d *Datastore) Get(key ds.Key) ([]byte, error) {
+	value, err := d.child.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return d.open(key, value)
+}
+
+func (d *Datastore) Has(key ds.Key) (bool, error) {
+	return d.child.Has(key)
+}
+
+// GetSize reads the value of encrypted keys, as values stored in the clear
+// don't have the overhead of encryption.
+func (d *Datastore) GetSize(key ds.Key) (int, error) {
+	if !d.encrypts(key) {
+		return d.child.GetSize(key)
+	}
+	value, err := d.child.Get(key)
+	if err != nil {
+		return -1, err
+	}
+	return d.openedSize(key, value)
+}
+
+func (d *Datastore) Delete(key ds.Key) error {
+	return d.child.Delete(key)
+}
+
+// Query decrypts the values of matching entries. Filters and orders may look at
+// values, so they're applied after decryption. Sizes of encrypted entries are only
+// returned if requested, since they're computed from values.
+func (d *Datastore) Query(q query.Query) (query.Results, error) {
+	cq := q
+	naive := len(q.Filters) > 0 || len(q.Orders) > 0
+	if naive {
+		cq.Filters, cq.Orders, cq.Limit, cq.Offset = nil, nil, 0, 0
+	}
+	if q.KeysOnly && q.ReturnsSizes {
+		cq.KeysOnly = false
+	}
+	res, err := d.child.Query(cq)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := query.ResultsFromIterator(q, query.Iterator{
+		Next: func() (query.Result, bool) {
+			r, ok := res.NextSync()
+			if !ok || r.Error != nil {
+				return r, ok
+			}
+			k := ds.RawKey(r.Key)
+			if d.encrypts(k) {
+				if q.KeysOnly {
+					r.Size = -1
+					if q.ReturnsSizes {
+						r.Size, r.Error = d.openedSize(k, r.Value)
+						r.Value = nil
+					}
+				} else {
+					value, err := d.open(k, r.Value)
+					r.Value, r.Size, r.Error = value, len(value), err
+				}
+			}
+			return r, true
+		},
+		Close: res.Close,
+	})
+	if naive {
+		return query.NaiveQueryApply(query.Query{
+			Filters: q.Filters,
+			Orders:  q.Orders,
+			Limit:   q.Limit,
+			Offset:  q.Offset,
+		}, decrypted), nil
+	}
+	return decrypted, nil
+}
+
+func (d *Datastore) Batch() (ds.Batch, error) {
+	b, err := d.child.Batch()
+	if err != nil {
+		return nil, err
+	}
+	return &batch{Batch: b, d: d}, nil
+}
+
+func (d *Datastore) Sync(prefix ds.Key) error {
+	return d.child.Sync(prefix)
+}
+
+func (d *Datastore) Close() error {
+	return d.child.Close()
+}
+
+func (d *Datastore) encrypts(key ds.Key) bool {
+	return key.Equal(d.prefix) || d.prefix.IsAncestorOf(key)
+}
+
+func (d *Datastore) seal(key ds.Key, value []byte) ([]byte, error) {
+	if !d.encrypts(key) {
+		return value, nil
+	}
+	sealed, err := d.key.EncryptWithAD(value, key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return append(append(make([]byte, 0, len(sealedHeader)+len(sealed)), sealedHeader...), sealed...), nil
+}
+
+func (d *Datastore) open(key ds.Key, value []byte) ([]byte, error) {
+	if !d.encrypts(key) {
+		return value, nil
+	}
+	if !isSealed(value) {
+		if d.plaintext {
+			return value, nil
+		}
+		return nil, fmt.Errorf("value of %s isn't encrypted", key)
+	}
+	plain, err := d.key.DecryptWithAD(value[len(sealedHeader):], key.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("decrypting value of %s: %w", key, err)
+	}
+	return plain, nil
+}
+
+// openedSize returns the size of a stored value once decrypted.
+func (d *Datastore) openedSize(key ds.Key, value []byte) (int, error) {
+	if !isSealed(value) {
+		if d.plaintext {
+			return len(value), nil
+		}
+		return -1, fmt.Errorf("value of %s isn't encrypted", key)
+	}
+	return len(value) - overhead, nil
+}
+
+// isSealed reports whether the value was encrypted, rather than written in the clear
+// before encryption was enabled.
+func isSealed(value []byte) bool {
+	return len(value) >= overhead && bytes.HasPrefix(value, sealedHeader)
+}
+
+type batch struct {
+	ds.Batch
+	d *Datastore
+}
+
+func (b *batch) Put(key ds.Key, value []byte) error {
+	value, err := b.d.seal(key, value)
+	if err != nil {
+		return err
+	}
+	return b.Batch.Put(key, value)
+}
@@ -0,0 +1,167 @@
+package encds
+
+import (
+	"bytes"
+	"testing"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestDatastore(t *testing.T) {
+	key, err := DeriveKey([]byte("secret"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := dssync.MutexWrap(ds.NewMapDatastore())
+	d := Wrap(child, ds.NewKey("/blocks"), key)
+
+	value := []byte("decrypted block")
+	block, other := ds.NewKey("/blocks/foo"), ds.NewKey("/peers/foo")
+	if err := d.Put(block, value); err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.Batch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Put(ds.NewKey("/blocks/bar"), value); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Put(other, value); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// values under the prefix are encrypted at rest
+	for _, k := range []ds.Key{block, ds.NewKey("/blocks/bar")} {
+		stored, err := child.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(stored, value) {
+			t.Fatalf("expected value of %s to be encrypted", k)
+		}
+		got, err := d.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Fatalf("expected value %s, got %s", value, got)
+		}
+		size, err := d.GetSize(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != len(value) {
+			t.Fatalf("expected size %d, got %d", len(value), size)
+		}
+	}
+	if stored, err := child.Get(other); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(stored, value) {
+		t.Fatal("expected value outside of the prefix to be stored in the clear")
+	}
+
+	res, err := d.Query(query.Query{Prefix: "/blocks", Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "/blocks/bar" {
+		t.Fatalf("expected 2 ordered entries, got %v", entries)
+	}
+	for _, e := range entries {
+		if !bytes.Equal(e.Value, value) {
+			t.Fatalf("expected value %s, got %s", value, e.Value)
+		}
+	}
+
+	// a key derived from another secret doesn't open the values
+	wrong, err := DeriveKey([]byte("other"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Wrap(child, ds.NewKey("/blocks"), wrong).Get(block); err == nil {
+		t.Fatal("expected decryption with another key to fail")
+	}
+	// values are bound to their keys
+	stored, err := child.Get(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := ds.NewKey("/blocks/baz")
+	if err = child.Put(moved, stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.Get(moved); err == nil {
+		t.Fatal("expected decryption of a value moved to another key to fail")
+	}
+}
+
+func TestDatastore_Plaintext(t *testing.T) {
+	key, err := PassphraseKey("passphrase", []byte("salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := dssync.MutexWrap(ds.NewMapDatastore())
+	block, value := ds.NewKey("/blocks/foo"), []byte("block written before encryption")
+	if err := child.Put(block, value); err != nil {
+		t.Fatal(err)
+	}
+	// values stored in the clear aren't read by default
+	d := Wrap(child, ds.NewKey("/blocks"), key)
+	if _, err = d.Get(block); err == nil {
+		t.Fatal("expected value stored in the clear to be refused")
+	}
+	if _, err = d.GetSize(block); err == nil {
+		t.Fatal("expected size of value stored in the clear to be refused")
+	}
+
+	// but read as is while migrating
+	d = Wrap(child, ds.NewKey("/blocks"), key, WithPlaintextMigration(true))
+	got, err := d.Get(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, value) {
+		t.Fatalf("expected value %s, got %s", value, got)
+	}
+	if size, err := d.GetSize(block); err != nil {
+		t.Fatal(err)
+	} else if size != len(value) {
+		t.Fatalf("expected size %d, got %d", len(value), size)
+	}
+	res, err := d.Query(query.Query{Prefix: "/blocks", KeysOnly: true, ReturnsSizes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Size != len(value) || entries[0].Value != nil {
+		t.Fatalf("expected the key with the size of its value, got %v", entries)
+	}
+
+	// and encrypted once written again
+	if err = d.Put(block, value); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := child.Get(block); err != nil {
+		t.Fatal(err)
+	} else if bytes.Contains(stored, value) {
+		t.Fatal("expected rewritten value to be encrypted")
+	}
+	if got, err = d.Get(block); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, value) {
+		t.Fatalf("expected value %s, got %s", value, got)
+	}
+}